@@ -165,6 +165,9 @@ func main() {
 
 	// Allocate a block of memory to alter GC behaviour. See https://github.com/golang/go/issues/23044
 	ballast := make([]byte, mainFlags.ballastBytes)
+	if mainFlags.ballastBytes > 0 && cfg.GCTuning.Enabled {
+		level.Warn(util_log.Logger).Log("msg", "memory ballast is not required when GC tuning is enabled, because the GC tuning sets the Go runtime soft memory limit", "ballast_bytes", mainFlags.ballastBytes)
+	}
 
 	// In testing mode skip JAEGER setup to avoid panic due to
 	// "duplicate metrics collector registration attempted"
@@ -86,6 +86,8 @@ type Config struct {
 	Replicator        Replicator
 	Store             alertstore.AlertStore
 	PersisterConfig   PersisterConfig
+
+	NotificationHistoryConfig NotificationHistoryConfig
 }
 
 // An Alertmanager manages the alerts for one user.
@@ -95,6 +97,7 @@ type Alertmanager struct {
 	logger          log.Logger
 	state           *state
 	persister       *statePersister
+	history         *notificationHistory // Nil if the notification history is disabled.
 	nflog           *nflog.Log
 	silences        *silence.Silences
 	marker          types.Marker
@@ -186,6 +189,9 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 	am.registry = reg
 	am.state = newReplicatedStates(cfg.UserID, cfg.ReplicationFactor, cfg.Replicator, cfg.Store, am.logger, am.registry)
 	am.persister = newStatePersister(cfg.PersisterConfig, cfg.UserID, am.state, cfg.Store, am.logger, am.registry)
+	if cfg.NotificationHistoryConfig.Enabled {
+		am.history = newNotificationHistory(cfg.NotificationHistoryConfig, cfg.UserID, am.state, cfg.Store, am.logger, am.registry)
+	}
 
 	am.wg.Add(1)
 	var err error
@@ -228,6 +234,12 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		return nil, errors.Wrap(err, "failed to start state persister service")
 	}
 
+	if am.history != nil {
+		if err := am.history.StartAsync(context.Background()); err != nil {
+			return nil, errors.Wrap(err, "failed to start notification history service")
+		}
+	}
+
 	am.pipelineBuilder = notify.NewPipelineBuilder(am.registry)
 
 	am.wg.Add(1)
@@ -352,7 +364,10 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 				integration: integrationName,
 			}
 
-			return newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+			notifier = newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+		}
+		if am.history != nil {
+			notifier = newHistoryRecordingNotifier(notifier, integrationName, am.history)
 		}
 		return notifier
 	})
@@ -408,6 +423,9 @@ func (am *Alertmanager) Stop() {
 	}
 
 	am.persister.StopAsync()
+	if am.history != nil {
+		am.history.StopAsync()
+	}
 	am.state.StopAsync()
 
 	am.alerts.Close()
@@ -421,6 +439,12 @@ func (am *Alertmanager) StopAndWait() {
 		level.Warn(am.logger).Log("msg", "error while stopping state persister service", "err", err)
 	}
 
+	if am.history != nil {
+		if err := am.history.AwaitTerminated(context.Background()); err != nil {
+			level.Warn(am.logger).Log("msg", "error while stopping notification history service", "err", err)
+		}
+	}
+
 	if err := am.state.AwaitTerminated(context.Background()); err != nil {
 		level.Warn(am.logger).Log("msg", "error while stopping ring-based replication service", "err", err)
 	}
@@ -63,6 +63,9 @@ type alertmanagerMetrics struct {
 	initialSyncDuration     *prometheus.Desc
 	persistTotal            *prometheus.Desc
 	persistFailed           *prometheus.Desc
+	historyFlushTotal       *prometheus.Desc
+	historyFlushFailed      *prometheus.Desc
+	historyDroppedEntries   *prometheus.Desc
 
 	notificationRateLimited                 *prometheus.Desc
 	dispatcherAggregationGroupsLimitReached *prometheus.Desc
@@ -214,6 +217,18 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_state_persist_failed_total",
 			"Number of times we have failed to persist the running state to storage.",
 			nil, nil),
+		historyFlushTotal: prometheus.NewDesc(
+			"cortex_alertmanager_notification_history_flush_total",
+			"Number of times we have tried to flush the notification history to storage.",
+			nil, nil),
+		historyFlushFailed: prometheus.NewDesc(
+			"cortex_alertmanager_notification_history_flush_failed_total",
+			"Number of times we have failed to flush the notification history to storage.",
+			nil, nil),
+		historyDroppedEntries: prometheus.NewDesc(
+			"cortex_alertmanager_notification_history_dropped_entries_total",
+			"Number of notification history entries dropped because they couldn't be flushed to storage.",
+			[]string{"user"}, nil),
 		notificationRateLimited: prometheus.NewDesc(
 			"cortex_alertmanager_notification_rate_limited_total",
 			"Total number of rate-limited notifications per integration.",
@@ -283,6 +298,9 @@ func (m *alertmanagerMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.initialSyncDuration
 	out <- m.persistTotal
 	out <- m.persistFailed
+	out <- m.historyFlushTotal
+	out <- m.historyFlushFailed
+	out <- m.historyDroppedEntries
 	out <- m.notificationRateLimited
 	out <- m.dispatcherAggregationGroupsLimitReached
 	out <- m.insertAlertFailures
@@ -333,6 +351,9 @@ func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfHistograms(out, m.initialSyncDuration, "alertmanager_state_initial_sync_duration_seconds")
 	data.SendSumOfCounters(out, m.persistTotal, "alertmanager_state_persist_total")
 	data.SendSumOfCounters(out, m.persistFailed, "alertmanager_state_persist_failed_total")
+	data.SendSumOfCounters(out, m.historyFlushTotal, "alertmanager_notification_history_flush_total")
+	data.SendSumOfCounters(out, m.historyFlushFailed, "alertmanager_notification_history_flush_failed_total")
+	data.SendSumOfCountersPerUser(out, m.historyDroppedEntries, "alertmanager_notification_history_dropped_entries_total", util.WithSkipZeroValueMetrics)
 
 	data.SendSumOfCountersPerUser(out, m.notificationRateLimited, "alertmanager_notification_rate_limited_total", util.WithLabels("integration"), util.WithSkipZeroValueMetrics)
 	data.SendSumOfCountersPerUser(out, m.dispatcherAggregationGroupsLimitReached, "alertmanager_dispatcher_aggregation_group_limit_reached_total")
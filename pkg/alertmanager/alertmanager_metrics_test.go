@@ -98,6 +98,12 @@ func TestAlertmanagerMetricsStore(t *testing.T) {
 		# HELP cortex_alertmanager_nflog_snapshot_size_bytes Size of the last notification log snapshot in bytes.
 		# TYPE cortex_alertmanager_nflog_snapshot_size_bytes gauge
 		cortex_alertmanager_nflog_snapshot_size_bytes 111
+		# HELP cortex_alertmanager_notification_history_flush_failed_total Number of times we have failed to flush the notification history to storage.
+		# TYPE cortex_alertmanager_notification_history_flush_failed_total counter
+		cortex_alertmanager_notification_history_flush_failed_total 0
+		# HELP cortex_alertmanager_notification_history_flush_total Number of times we have tried to flush the notification history to storage.
+		# TYPE cortex_alertmanager_notification_history_flush_total counter
+		cortex_alertmanager_notification_history_flush_total 0
 		# HELP cortex_alertmanager_notification_latency_seconds The latency of notifications in seconds.
 		# TYPE cortex_alertmanager_notification_latency_seconds histogram
 		cortex_alertmanager_notification_latency_seconds_bucket{le="1"} 15
@@ -386,6 +392,12 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
         	            # TYPE cortex_alertmanager_nflog_snapshot_size_bytes gauge
         	            cortex_alertmanager_nflog_snapshot_size_bytes 111
 
+						# HELP cortex_alertmanager_notification_history_flush_failed_total Number of times we have failed to flush the notification history to storage.
+						# TYPE cortex_alertmanager_notification_history_flush_failed_total counter
+						cortex_alertmanager_notification_history_flush_failed_total 0
+						# HELP cortex_alertmanager_notification_history_flush_total Number of times we have tried to flush the notification history to storage.
+						# TYPE cortex_alertmanager_notification_history_flush_total counter
+						cortex_alertmanager_notification_history_flush_total 0
 						# HELP cortex_alertmanager_notification_latency_seconds The latency of notifications in seconds.
         	           	# TYPE cortex_alertmanager_notification_latency_seconds histogram
         	            cortex_alertmanager_notification_latency_seconds_bucket{le="1"} 15
@@ -668,6 +680,12 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
     		# TYPE cortex_alertmanager_nflog_snapshot_size_bytes gauge
     		cortex_alertmanager_nflog_snapshot_size_bytes 11
 
+    		# HELP cortex_alertmanager_notification_history_flush_failed_total Number of times we have failed to flush the notification history to storage.
+    		# TYPE cortex_alertmanager_notification_history_flush_failed_total counter
+    		cortex_alertmanager_notification_history_flush_failed_total 0
+    		# HELP cortex_alertmanager_notification_history_flush_total Number of times we have tried to flush the notification history to storage.
+    		# TYPE cortex_alertmanager_notification_history_flush_total counter
+    		cortex_alertmanager_notification_history_flush_total 0
     		# HELP cortex_alertmanager_notification_latency_seconds The latency of notifications in seconds.
     		# TYPE cortex_alertmanager_notification_latency_seconds histogram
     		cortex_alertmanager_notification_latency_seconds_bucket{le="1"} 15
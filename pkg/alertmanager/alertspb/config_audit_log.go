@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertspb
+
+import "time"
+
+const (
+	ConfigAuditLogActionSet    = "set"
+	ConfigAuditLogActionDelete = "delete"
+
+	ConfigChangeAdded    = "added"
+	ConfigChangeModified = "modified"
+	ConfigChangeDeleted  = "deleted"
+
+	ConfigChangeObjectAlertmanagerConfig = "alertmanager_config"
+	ConfigChangeObjectTemplate           = "template"
+)
+
+// ConfigAuditLogEntry is a change of the Alertmanager configuration and templates of a tenant.
+type ConfigAuditLogEntry struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Actor     string         `json:"actor"`
+	Action    string         `json:"action"`
+	Changes   []ConfigChange `json:"changes"`
+}
+
+// ConfigChange is the change of the Alertmanager configuration or of a template, along with its unified diff.
+type ConfigChange struct {
+	Object string `json:"object"`
+	Name   string `json:"name,omitempty"`
+	Type   string `json:"type"`
+	Diff   string `json:"diff"`
+}
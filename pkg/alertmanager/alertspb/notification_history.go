@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertspb
+
+import "time"
+
+const (
+	NotificationOutcomeSuccess = "success"
+	NotificationOutcomeFailure = "failure"
+)
+
+// NotificationHistoryEntry is an attempt of the Alertmanager of a tenant to send a notification
+// to an integration of a receiver.
+type NotificationHistoryEntry struct {
+	Timestamp   time.Time                  `json:"timestamp"`
+	Receiver    string                     `json:"receiver"`
+	Integration string                     `json:"integration"`
+	GroupKey    string                     `json:"group_key"`
+	Alerts      []NotificationHistoryAlert `json:"alerts"`
+	Outcome     string                     `json:"outcome"`
+	Error       string                     `json:"error,omitempty"`
+}
+
+// NotificationHistoryAlert is an alert included in a notification.
+type NotificationHistoryAlert struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      string `json:"status"`
+}
@@ -8,14 +8,21 @@ package bucketclient
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gogo/protobuf/proto"
 	"github.com/grafana/dskit/concurrency"
 	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/objstore"
 
@@ -37,6 +44,18 @@ const (
 	// The name of alertmanager full state objects (notification log + silences).
 	fullStateName = "fullstate"
 
+	// The name of the objects storing the time since which the configuration of a tenant has been exceeding the limits.
+	limitsExceededSinceName = "limits-exceeded-since"
+
+	// The prefix of the objects storing the notification history of a tenant. Each object stores the entries
+	// flushed at once by an alertmanager replica, and its name contains the time range of the entries:
+	//     notification-history/<min timestamp ms>-<max timestamp ms>-<ulid>.json
+	notificationHistoryPrefix = "notification-history/"
+
+	// The prefix of the objects storing the configuration audit log of a tenant. Each object stores an entry:
+	//     config-audit-log/<timestamp ms>-<ulid>.json
+	configAuditLogPrefix = "config-audit-log/"
+
 	// How many users to load concurrently.
 	fetchConcurrency = 16
 )
@@ -177,6 +196,232 @@ func (s *BucketAlertStore) DeleteFullState(ctx context.Context, userID string) e
 	return err
 }
 
+// GetLimitsExceededSince implements alertstore.AlertStore.
+func (s *BucketAlertStore) GetLimitsExceededSince(ctx context.Context, userID string) (time.Time, error) {
+	bkt := s.getAlertmanagerUserBucket(userID)
+
+	readCloser, err := bkt.Get(ctx, limitsExceededSinceName)
+	if s.amBucket.IsObjNotFoundErr(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	defer runutil.CloseWithLogOnErr(s.logger, readCloser, "close bucket reader")
+
+	buf, err := io.ReadAll(readCloser)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to read the time since which the limits have been exceeded for user %s", userID)
+	}
+
+	since, err := time.Parse(time.RFC3339, string(buf))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse the time since which the limits have been exceeded for user %s", userID)
+	}
+	return since, nil
+}
+
+// SetLimitsExceededSince implements alertstore.AlertStore.
+func (s *BucketAlertStore) SetLimitsExceededSince(ctx context.Context, userID string, since time.Time) error {
+	bkt := s.getAlertmanagerUserBucket(userID)
+
+	if since.IsZero() {
+		err := bkt.Delete(ctx, limitsExceededSinceName)
+		if bkt.IsObjNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+
+	return bkt.Upload(ctx, limitsExceededSinceName, strings.NewReader(since.UTC().Format(time.RFC3339)))
+}
+
+// AppendNotificationHistory implements alertstore.AlertStore.
+func (s *BucketAlertStore) AppendNotificationHistory(ctx context.Context, userID string, entries []alertspb.NotificationHistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	minT, maxT := entries[0].Timestamp, entries[0].Timestamp
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if e.Timestamp.Before(minT) {
+			minT = e.Timestamp
+		}
+		if e.Timestamp.After(maxT) {
+			maxT = e.Timestamp
+		}
+		if err := enc.Encode(e); err != nil {
+			return errors.Wrap(err, "failed to serialize notification history entry")
+		}
+	}
+
+	id := ulid.MustNew(ulid.Now(), rand.Reader)
+	name := fmt.Sprintf("%s%d-%d-%s.json", notificationHistoryPrefix, minT.UnixMilli(), maxT.UnixMilli(), id.String())
+	return s.getAlertmanagerUserBucket(userID).Upload(ctx, name, &buf)
+}
+
+// GetNotificationHistory implements alertstore.AlertStore.
+func (s *BucketAlertStore) GetNotificationHistory(ctx context.Context, userID string, start, end time.Time) ([]alertspb.NotificationHistoryEntry, error) {
+	bkt := s.getAlertmanagerUserBucket(userID)
+
+	var names []string
+	err := bkt.Iter(ctx, notificationHistoryPrefix, func(name string) error {
+		minT, maxT, ok := parseNotificationHistoryObjectName(name)
+		if ok && maxT >= start.UnixMilli() && minT <= end.UnixMilli() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list notification history for user %s", userID)
+	}
+
+	var (
+		entriesMx = sync.Mutex{}
+		entries   []alertspb.NotificationHistoryEntry
+	)
+
+	err = concurrency.ForEachJob(ctx, len(names), fetchConcurrency, func(ctx context.Context, idx int) error {
+		readCloser, err := bkt.Get(ctx, names[idx])
+		if bkt.IsObjNotFoundErr(err) {
+			// The object has been deleted by the retention in the meanwhile.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer runutil.CloseWithLogOnErr(s.logger, readCloser, "close bucket reader")
+
+		var objEntries []alertspb.NotificationHistoryEntry
+		dec := json.NewDecoder(readCloser)
+		for dec.More() {
+			e := alertspb.NotificationHistoryEntry{}
+			if err := dec.Decode(&e); err != nil {
+				return errors.Wrapf(err, "failed to deserialize notification history object %s for user %s", names[idx], userID)
+			}
+			if !e.Timestamp.Before(start) && !e.Timestamp.After(end) {
+				objEntries = append(objEntries, e)
+			}
+		}
+
+		entriesMx.Lock()
+		entries = append(entries, objEntries...)
+		entriesMx.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// DeleteNotificationHistory implements alertstore.AlertStore.
+func (s *BucketAlertStore) DeleteNotificationHistory(ctx context.Context, userID string, before time.Time) error {
+	bkt := s.getAlertmanagerUserBucket(userID)
+
+	return bkt.Iter(ctx, notificationHistoryPrefix, func(name string) error {
+		_, maxT, ok := parseNotificationHistoryObjectName(name)
+		if !ok || maxT >= before.UnixMilli() {
+			return nil
+		}
+
+		err := bkt.Delete(ctx, name)
+		if bkt.IsObjNotFoundErr(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete notification history object %s for user %s", name, userID)
+	})
+}
+
+// parseNotificationHistoryObjectName returns the time range of the entries stored in a notification history object.
+func parseNotificationHistoryObjectName(name string) (minT, maxT int64, ok bool) {
+	parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(name, notificationHistoryPrefix), ".json"), "-", 3)
+	if len(parts) != 3 {
+		return 0, 0, false
+	}
+
+	var err error
+	if minT, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, 0, false
+	}
+	if maxT, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return 0, 0, false
+	}
+	return minT, maxT, true
+}
+
+// AppendConfigAuditLog implements alertstore.AlertStore.
+func (s *BucketAlertStore) AppendConfigAuditLog(ctx context.Context, userID string, entry alertspb.ConfigAuditLogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize config audit log entry")
+	}
+
+	id := ulid.MustNew(ulid.Now(), rand.Reader)
+	name := fmt.Sprintf("%s%d-%s.json", configAuditLogPrefix, entry.Timestamp.UnixMilli(), id.String())
+	return s.getAlertmanagerUserBucket(userID).Upload(ctx, name, bytes.NewReader(data))
+}
+
+// GetConfigAuditLog implements alertstore.AlertStore.
+func (s *BucketAlertStore) GetConfigAuditLog(ctx context.Context, userID string, start, end time.Time) ([]alertspb.ConfigAuditLogEntry, error) {
+	bkt := s.getAlertmanagerUserBucket(userID)
+
+	var names []string
+	err := bkt.Iter(ctx, configAuditLogPrefix, func(name string) error {
+		ts, ok := parseConfigAuditLogObjectName(name)
+		if ok && ts >= start.UnixMilli() && ts <= end.UnixMilli() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list config audit log for user %s", userID)
+	}
+
+	entries := make([]alertspb.ConfigAuditLogEntry, len(names))
+	err = concurrency.ForEachJob(ctx, len(names), fetchConcurrency, func(ctx context.Context, idx int) error {
+		readCloser, err := bkt.Get(ctx, names[idx])
+		if err != nil {
+			return err
+		}
+		defer runutil.CloseWithLogOnErr(s.logger, readCloser, "close bucket reader")
+
+		if err := json.NewDecoder(readCloser).Decode(&entries[idx]); err != nil {
+			return errors.Wrapf(err, "failed to deserialize config audit log object %s for user %s", names[idx], userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// parseConfigAuditLogObjectName returns the timestamp of the entry stored in a configuration audit log object.
+func parseConfigAuditLogObjectName(name string) (ts int64, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(name, configAuditLogPrefix), "-", 2)
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	ts, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ts, true
+}
+
 func (s *BucketAlertStore) getAlertConfig(ctx context.Context, userID string) (alertspb.AlertConfigDesc, error) {
 	config := alertspb.AlertConfigDesc{}
 	err := s.get(ctx, s.getUserBucket(userID), userID, &config)
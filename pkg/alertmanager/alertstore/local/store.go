@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/alertmanager/config"
@@ -125,6 +126,41 @@ func (f *Store) DeleteFullState(ctx context.Context, user string) error {
 	return errState
 }
 
+// GetLimitsExceededSince implements alertstore.AlertStore. The configurations in the local store are never checked against the limits.
+func (f *Store) GetLimitsExceededSince(_ context.Context, _ string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// SetLimitsExceededSince implements alertstore.AlertStore.
+func (f *Store) SetLimitsExceededSince(_ context.Context, _ string, _ time.Time) error {
+	return errReadOnly
+}
+
+// AppendNotificationHistory implements alertstore.AlertStore.
+func (f *Store) AppendNotificationHistory(_ context.Context, _ string, _ []alertspb.NotificationHistoryEntry) error {
+	return errState
+}
+
+// GetNotificationHistory implements alertstore.AlertStore. The local store has no notification history.
+func (f *Store) GetNotificationHistory(_ context.Context, _ string, _, _ time.Time) ([]alertspb.NotificationHistoryEntry, error) {
+	return nil, nil
+}
+
+// DeleteNotificationHistory implements alertstore.AlertStore.
+func (f *Store) DeleteNotificationHistory(_ context.Context, _ string, _ time.Time) error {
+	return nil
+}
+
+// AppendConfigAuditLog implements alertstore.AlertStore.
+func (f *Store) AppendConfigAuditLog(_ context.Context, _ string, _ alertspb.ConfigAuditLogEntry) error {
+	return errReadOnly
+}
+
+// GetConfigAuditLog implements alertstore.AlertStore. The local store has no configuration audit log.
+func (f *Store) GetConfigAuditLog(_ context.Context, _ string, _, _ time.Time) ([]alertspb.ConfigAuditLogEntry, error) {
+	return nil, nil
+}
+
 func (f *Store) reloadConfigs() (map[string]alertspb.AlertConfigDesc, error) {
 	configs := map[string]alertspb.AlertConfigDesc{}
 	err := filepath.Walk(f.cfg.Path, func(path string, info os.FileInfo, err error) error {
@@ -7,6 +7,7 @@ package alertstore
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -50,6 +51,31 @@ type AlertStore interface {
 	// DeleteFullState deletes the alertmanager state for an user.
 	// If state for the user doesn't exist, no error is reported.
 	DeleteFullState(ctx context.Context, user string) error
+
+	// GetLimitsExceededSince returns the time since which the alertmanager configuration of the given user
+	// has been exceeding the limits. Returns the zero time if it's not exceeding the limits.
+	GetLimitsExceededSince(ctx context.Context, user string) (time.Time, error)
+
+	// SetLimitsExceededSince stores the time since which the alertmanager configuration of the given user
+	// has been exceeding the limits. If since is the zero time, the stored time is removed.
+	SetLimitsExceededSince(ctx context.Context, user string, since time.Time) error
+
+	// AppendNotificationHistory stores the given notification history entries of the given user.
+	AppendNotificationHistory(ctx context.Context, user string, entries []alertspb.NotificationHistoryEntry) error
+
+	// GetNotificationHistory returns the notification history entries of the given user with a timestamp
+	// between start and end (both inclusive), sorted by timestamp.
+	GetNotificationHistory(ctx context.Context, user string, start, end time.Time) ([]alertspb.NotificationHistoryEntry, error)
+
+	// DeleteNotificationHistory deletes the notification history of the given user older than the given time.
+	DeleteNotificationHistory(ctx context.Context, user string, before time.Time) error
+
+	// AppendConfigAuditLog stores the given configuration audit log entry of the given user.
+	AppendConfigAuditLog(ctx context.Context, user string, entry alertspb.ConfigAuditLogEntry) error
+
+	// GetConfigAuditLog returns the configuration audit log entries of the given user with a timestamp
+	// between start and end (both inclusive), sorted by timestamp.
+	GetConfigAuditLog(ctx context.Context, user string, start, end time.Time) ([]alertspb.ConfigAuditLogEntry, error)
 }
 
 // NewAlertStore returns a alertmanager store backend client based on the provided cfg.
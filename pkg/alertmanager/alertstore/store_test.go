@@ -8,6 +8,7 @@ package alertstore
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/alertmanager/cluster/clusterpb"
@@ -239,3 +240,134 @@ func TestBucketAlertStore_GetSetDeleteFullState(t *testing.T) {
 		require.NoError(t, store.DeleteFullState(ctx, "user-1"))
 	}
 }
+
+func TestBucketAlertStore_GetSetLimitsExceededSince(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	since := time.Unix(1700000000, 0).UTC()
+
+	// The storage is empty.
+	res, err := store.GetLimitsExceededSince(ctx, "user-1")
+	require.NoError(t, err)
+	assert.True(t, res.IsZero())
+
+	// The time is stored.
+	require.NoError(t, store.SetLimitsExceededSince(ctx, "user-1", since))
+
+	res, err = store.GetLimitsExceededSince(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, since, res)
+
+	res, err = store.GetLimitsExceededSince(ctx, "user-2")
+	require.NoError(t, err)
+	assert.True(t, res.IsZero())
+
+	exists, err := bucket.Exists(ctx, "alertmanager/user-1/limits-exceeded-since")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// The zero time removes the stored time (idempotently).
+	for i := 0; i < 2; i++ {
+		require.NoError(t, store.SetLimitsExceededSince(ctx, "user-1", time.Time{}))
+
+		res, err = store.GetLimitsExceededSince(ctx, "user-1")
+		require.NoError(t, err)
+		assert.True(t, res.IsZero())
+	}
+}
+
+func TestBucketAlertStore_NotificationHistory(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	newEntry := func(ts int64, receiver string) alertspb.NotificationHistoryEntry {
+		return alertspb.NotificationHistoryEntry{
+			Timestamp:   time.Unix(ts, 0).UTC(),
+			Receiver:    receiver,
+			Integration: "webhook",
+			Alerts:      []alertspb.NotificationHistoryAlert{{Fingerprint: "0123456789abcdef", Status: "firing"}},
+			Outcome:     alertspb.NotificationOutcomeSuccess,
+		}
+	}
+
+	// The storage is empty.
+	res, err := store.GetNotificationHistory(ctx, "user-1", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// Appending no entries is a no-op.
+	require.NoError(t, store.AppendNotificationHistory(ctx, "user-1", nil))
+
+	require.NoError(t, store.AppendNotificationHistory(ctx, "user-1", []alertspb.NotificationHistoryEntry{newEntry(100, "a"), newEntry(300, "b")}))
+	require.NoError(t, store.AppendNotificationHistory(ctx, "user-1", []alertspb.NotificationHistoryEntry{newEntry(200, "c"), newEntry(500, "d")}))
+	require.NoError(t, store.AppendNotificationHistory(ctx, "user-2", []alertspb.NotificationHistoryEntry{newEntry(200, "e")}))
+
+	// The entries are returned sorted by timestamp and filtered by the time range.
+	res, err = store.GetNotificationHistory(ctx, "user-1", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.NotificationHistoryEntry{newEntry(100, "a"), newEntry(200, "c"), newEntry(300, "b"), newEntry(500, "d")}, res)
+
+	res, err = store.GetNotificationHistory(ctx, "user-1", time.Unix(150, 0), time.Unix(300, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.NotificationHistoryEntry{newEntry(200, "c"), newEntry(300, "b")}, res)
+
+	res, err = store.GetNotificationHistory(ctx, "user-2", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.NotificationHistoryEntry{newEntry(200, "e")}, res)
+
+	// Only the objects whose entries are all older than the given time are deleted.
+	require.NoError(t, store.DeleteNotificationHistory(ctx, "user-1", time.Unix(400, 0)))
+
+	res, err = store.GetNotificationHistory(ctx, "user-1", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.NotificationHistoryEntry{newEntry(200, "c"), newEntry(500, "d")}, res)
+
+	res, err = store.GetNotificationHistory(ctx, "user-2", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+}
+
+func TestBucketAlertStore_ConfigAuditLog(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	newEntry := func(ts int64, actor string) alertspb.ConfigAuditLogEntry {
+		return alertspb.ConfigAuditLogEntry{
+			Timestamp: time.Unix(ts, 0).UTC(),
+			Actor:     actor,
+			Action:    alertspb.ConfigAuditLogActionSet,
+			Changes: []alertspb.ConfigChange{{
+				Object: alertspb.ConfigChangeObjectAlertmanagerConfig,
+				Type:   alertspb.ConfigChangeModified,
+				Diff:   "--- previous\n+++ current\n",
+			}},
+		}
+	}
+
+	// The storage is empty.
+	res, err := store.GetConfigAuditLog(ctx, "user-1", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	require.NoError(t, store.AppendConfigAuditLog(ctx, "user-1", newEntry(300, "a")))
+	require.NoError(t, store.AppendConfigAuditLog(ctx, "user-1", newEntry(100, "b")))
+	require.NoError(t, store.AppendConfigAuditLog(ctx, "user-1", newEntry(200, "c")))
+	require.NoError(t, store.AppendConfigAuditLog(ctx, "user-2", newEntry(200, "d")))
+
+	// The entries are returned sorted by timestamp and filtered by the time range.
+	res, err = store.GetConfigAuditLog(ctx, "user-1", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.ConfigAuditLogEntry{newEntry(100, "b"), newEntry(200, "c"), newEntry(300, "a")}, res)
+
+	res, err = store.GetConfigAuditLog(ctx, "user-1", time.Unix(150, 0), time.Unix(300, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.ConfigAuditLogEntry{newEntry(200, "c"), newEntry(300, "a")}, res)
+
+	res, err = store.GetConfigAuditLog(ctx, "user-2", time.Unix(0, 0), time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []alertspb.ConfigAuditLogEntry{newEntry(200, "d")}, res)
+}
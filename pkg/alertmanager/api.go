@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -28,6 +29,7 @@ import (
 	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -41,6 +43,14 @@ const (
 	errConfigurationTooBig   = "Alertmanager configuration is too big, limit: %d bytes"
 	errTooManyTemplates      = "too many templates in the configuration: %d (limit: %d)"
 	errTemplateTooBig        = "template %s is too big: %d bytes (limit: %d bytes)"
+	errTooManyReceivers      = "too many receivers in the configuration: %d (limit: %d)"
+	errTooManyRoutes         = "too many routes in the configuration: %d (limit: %d)"
+	errApplyingGracePeriod   = "unable to apply the limits grace period"
+	errReadingHistory        = "unable to read the notification history"
+	errInvalidHistoryRange   = "invalid notification history time range"
+	errReadingAuditLog       = "unable to read the Alertmanager config audit log"
+	errInvalidAuditLogRange  = "invalid Alertmanager config audit log time range"
+	errAuditLogDisabled      = "the Alertmanager config audit log is disabled"
 
 	fetchConcurrency = 16
 )
@@ -149,6 +159,28 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
+	limitsErr := validateUserConfigObjectsLimits(cfgDesc, am.limits, userID)
+	warning, limitsErr, err := validation.ApplyLimitsGracePeriod(r.Context(), am.store, userID, am.limits.AlertmanagerLimitsGracePeriod(userID), limitsErr, time.Now())
+	if err != nil {
+		level.Error(logger).Log("msg", errApplyingGracePeriod, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errApplyingGracePeriod, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if limitsErr != nil {
+		level.Warn(logger).Log("msg", errValidatingConfig, "err", limitsErr.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errValidatingConfig, limitsErr.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var prevCfgDesc alertspb.AlertConfigDesc
+	if am.configAuditLog != nil {
+		if prevCfgDesc, err = am.getUserConfigForAuditLog(r.Context(), userID); err != nil {
+			level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	err = am.store.SetAlertConfig(r.Context(), cfgDesc)
 	if err != nil {
 		level.Error(logger).Log("msg", errStoringConfiguration, "err", err.Error())
@@ -156,6 +188,14 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if am.configAuditLog != nil {
+		am.configAuditLog.record(r.Context(), userID, am.configAuditLog.actor(r), alertspb.ConfigAuditLogActionSet, prevCfgDesc, cfgDesc)
+	}
+
+	if warning != "" {
+		level.Warn(logger).Log("msg", "Alertmanager config exceeding the limits accepted within the limits grace period", "warning", warning)
+		w.Header().Set("Warning", fmt.Sprintf("299 - %q", warning))
+	}
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -170,16 +210,147 @@ func (am *MultitenantAlertmanager) DeleteUserConfig(w http.ResponseWriter, r *ht
 		return
 	}
 
+	var prevCfgDesc alertspb.AlertConfigDesc
+	if am.configAuditLog != nil {
+		if prevCfgDesc, err = am.getUserConfigForAuditLog(r.Context(), userID); err != nil {
+			level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	err = am.store.DeleteAlertConfig(r.Context(), userID)
+	if err == nil {
+		err = am.store.SetLimitsExceededSince(r.Context(), userID, time.Time{})
+	}
 	if err != nil {
 		level.Error(logger).Log("msg", errDeletingConfiguration, "err", err.Error())
 		http.Error(w, fmt.Sprintf("%s: %s", errDeletingConfiguration, err.Error()), http.StatusInternalServerError)
 		return
 	}
 
+	if am.configAuditLog != nil {
+		am.configAuditLog.record(r.Context(), userID, am.configAuditLog.actor(r), alertspb.ConfigAuditLogActionDelete, prevCfgDesc, alertspb.AlertConfigDesc{User: userID})
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
+// NotificationHistoryResponse is the response of the notification history API.
+type NotificationHistoryResponse struct {
+	Entries []alertspb.NotificationHistoryEntry `json:"entries"`
+}
+
+// GetNotificationHistory returns the notification history of the tenant between the start and end parameters
+// (defaulting to the last hour), optionally filtered by the receiver and outcome parameters.
+func (am *MultitenantAlertmanager) GetNotificationHistory(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	start, end, err := parseTimeRange(r, time.Hour)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errInvalidHistoryRange, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := am.store.GetNotificationHistory(r.Context(), userID, start, end)
+	if err != nil {
+		level.Error(logger).Log("msg", errReadingHistory, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errReadingHistory, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	receiver, outcome := r.FormValue("receiver"), r.FormValue("outcome")
+	res := NotificationHistoryResponse{Entries: make([]alertspb.NotificationHistoryEntry, 0, len(entries))}
+	for _, e := range entries {
+		if (receiver == "" || e.Receiver == receiver) && (outcome == "" || e.Outcome == outcome) {
+			res.Entries = append(res.Entries, e)
+		}
+	}
+
+	util.WriteJSONResponse(w, res)
+}
+
+// ConfigAuditLogResponse is the response of the config audit log API.
+type ConfigAuditLogResponse struct {
+	Entries []alertspb.ConfigAuditLogEntry `json:"entries"`
+}
+
+// GetConfigAuditLog returns the changes of the Alertmanager configuration of the tenant between the start
+// and end parameters (defaulting to the last 24 hours).
+func (am *MultitenantAlertmanager) GetConfigAuditLog(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	if am.configAuditLog == nil {
+		http.Error(w, errAuditLogDisabled, http.StatusNotFound)
+		return
+	}
+
+	start, end, err := parseTimeRange(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errInvalidAuditLogRange, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := am.configAuditLog.get(r.Context(), userID, start, end)
+	if err != nil {
+		level.Error(logger).Log("msg", errReadingAuditLog, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errReadingAuditLog, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	res := ConfigAuditLogResponse{Entries: entries}
+	if res.Entries == nil {
+		res.Entries = []alertspb.ConfigAuditLogEntry{}
+	}
+	util.WriteJSONResponse(w, res)
+}
+
+// getUserConfigForAuditLog returns the current Alertmanager configuration of the tenant, or an empty one if the tenant has none.
+func (am *MultitenantAlertmanager) getUserConfigForAuditLog(ctx context.Context, userID string) (alertspb.AlertConfigDesc, error) {
+	cfg, err := am.store.GetAlertConfig(ctx, userID)
+	if errors.Is(err, alertspb.ErrNotFound) {
+		return alertspb.AlertConfigDesc{User: userID}, nil
+	}
+	return cfg, err
+}
+
+// parseTimeRange returns the time range of the request from the start and end parameters. If missing,
+// the end defaults to now and the start to defaultRange before the end.
+func parseTimeRange(r *http.Request, defaultRange time.Duration) (start, end time.Time, err error) {
+	end = time.Now()
+	if v := r.FormValue("end"); v != "" {
+		ms, err := util.ParseTime(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = time.UnixMilli(ms)
+	}
+	start = end.Add(-defaultRange)
+	if v := r.FormValue("start"); v != "" {
+		ms, err := util.ParseTime(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = time.UnixMilli(ms)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("end timestamp must not be before start time")
+	}
+	return start, end, nil
+}
+
 // Partially copied from: https://github.com/prometheus/alertmanager/blob/8e861c646bf67599a1704fc843c6a94d519ce312/cli/check_config.go#L65-L96
 func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits Limits, user string) error {
 	// We don't have a valid use case for empty configurations. If a tenant does not have a
@@ -269,6 +440,36 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 	return nil
 }
 
+// validateUserConfigObjectsLimits checks the number of objects in an already validated configuration against the limits.
+// The configurations exceeding these limits may be accepted within the limits grace period.
+func validateUserConfigObjectsLimits(cfg alertspb.AlertConfigDesc, limits Limits, user string) error {
+	amCfg, err := config.Load(cfg.RawConfig)
+	if err != nil {
+		return err
+	}
+
+	if l := limits.AlertmanagerMaxReceiversCount(user); l > 0 && len(amCfg.Receivers) > l {
+		return fmt.Errorf(errTooManyReceivers, len(amCfg.Receivers), l)
+	}
+
+	if l := limits.AlertmanagerMaxRoutesCount(user); l > 0 && amCfg.Route != nil {
+		if routes := countRoutes(amCfg.Route.Routes); routes > l {
+			return fmt.Errorf(errTooManyRoutes, routes, l)
+		}
+	}
+
+	return nil
+}
+
+// countRoutes returns the number of routes, including the nested ones.
+func countRoutes(routes []*config.Route) int {
+	count := len(routes)
+	for _, r := range routes {
+		count += countRoutes(r.Routes)
+	}
+	return count
+}
+
 func (am *MultitenantAlertmanager) ListAllConfigs(w http.ResponseWriter, r *http.Request) {
 	logger := util_log.WithContext(r.Context(), am.logger)
 	userIDs, err := am.store.ListAllUsers(r.Context())
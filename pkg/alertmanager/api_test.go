@@ -8,11 +8,13 @@ package alertmanager
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gorilla/mux"
@@ -39,6 +41,8 @@ func TestAMConfigValidationAPI(t *testing.T) {
 		maxConfigSize   int
 		maxTemplates    int
 		maxTemplateSize int
+		maxReceivers    int
+		maxRoutes       int
 
 		response string
 		err      error
@@ -669,6 +673,72 @@ template_files:
 			maxTemplateSize: 20,
 			err:             nil,
 		},
+		{
+			name: "receivers limit reached",
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+  receivers:
+    - name: default-receiver
+    - name: other-receiver
+`,
+			maxReceivers: 1,
+			err:          errors.Wrap(fmt.Errorf(errTooManyReceivers, 2, 1), "error validating Alertmanager config"),
+		},
+		{
+			name: "receivers limit not reached",
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+  receivers:
+    - name: default-receiver
+    - name: other-receiver
+`,
+			maxReceivers: 2,
+			err:          nil,
+		},
+		{
+			name: "routes limit reached",
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+    routes:
+      - receiver: 'default-receiver'
+        matchers: [team="a"]
+        routes:
+          - receiver: 'default-receiver'
+            matchers: [severity="critical"]
+      - receiver: 'default-receiver'
+        matchers: [team="b"]
+  receivers:
+    - name: default-receiver
+`,
+			maxRoutes: 2,
+			err:       errors.Wrap(fmt.Errorf(errTooManyRoutes, 3, 2), "error validating Alertmanager config"),
+		},
+		{
+			name: "routes limit not reached",
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+    routes:
+      - receiver: 'default-receiver'
+        matchers: [team="a"]
+        routes:
+          - receiver: 'default-receiver'
+            matchers: [severity="critical"]
+      - receiver: 'default-receiver'
+        matchers: [team="b"]
+  receivers:
+    - name: default-receiver
+`,
+			maxRoutes: 3,
+			err:       nil,
+		},
 	}
 
 	limits := &mockAlertManagerLimits{}
@@ -682,6 +752,8 @@ template_files:
 			limits.maxConfigSize = tc.maxConfigSize
 			limits.maxTemplatesCount = tc.maxTemplates
 			limits.maxSizeOfTemplate = tc.maxTemplateSize
+			limits.maxReceiversCount = tc.maxReceivers
+			limits.maxRoutesCount = tc.maxRoutes
 
 			req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts", bytes.NewReader([]byte(tc.cfg)))
 			ctx := user.InjectOrgID(req.Context(), "testing")
@@ -703,6 +775,65 @@ template_files:
 	}
 }
 
+func TestAMConfigLimitsGracePeriod(t *testing.T) {
+	const cfg = `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+  receivers:
+    - name: default-receiver
+    - name: other-receiver
+`
+
+	store := prepareInMemoryAlertStore()
+	limits := &mockAlertManagerLimits{maxReceiversCount: 1, limitsGracePeriod: time.Hour}
+	am := &MultitenantAlertmanager{
+		store:  store,
+		logger: util_log.Logger,
+		limits: limits,
+	}
+
+	setConfig := func() *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts", bytes.NewReader([]byte(cfg)))
+		ctx := user.InjectOrgID(req.Context(), "testing")
+		w := httptest.NewRecorder()
+		am.SetUserConfig(w, req.WithContext(ctx))
+		return w.Result()
+	}
+
+	// The config exceeding the limits is accepted with a warning, and the grace period starts.
+	resp := setConfig()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Warning"), "too many receivers in the configuration: 2 (limit: 1) (accepted because the limits grace period expires at")
+
+	since, err := store.GetLimitsExceededSince(context.Background(), "testing")
+	require.NoError(t, err)
+	require.False(t, since.IsZero())
+
+	// The config is accepted again while the grace period isn't expired.
+	resp = setConfig()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Warning"))
+
+	// Once the grace period is expired, the config is rejected.
+	require.NoError(t, store.SetLimitsExceededSince(context.Background(), "testing", since.Add(-time.Hour)))
+	resp = setConfig()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "too many receivers in the configuration: 2 (limit: 1) (the limits grace period expired at")
+
+	// A config within the limits resets the grace period.
+	limits.maxReceiversCount = 2
+	resp = setConfig()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Warning"))
+
+	since, err = store.GetLimitsExceededSince(context.Background(), "testing")
+	require.NoError(t, err)
+	require.True(t, since.IsZero())
+}
+
 func TestMultitenantAlertmanager_DeleteUserConfig(t *testing.T) {
 	storage := objstore.NewInMemBucket()
 	alertStore := bucketclient.NewBucketAlertStore(storage, nil, log.NewNopLogger())
@@ -748,6 +879,173 @@ func TestMultitenantAlertmanager_DeleteUserConfig(t *testing.T) {
 	}
 }
 
+func TestMultitenantAlertmanager_GetNotificationHistory(t *testing.T) {
+	alertStore := bucketclient.NewBucketAlertStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+
+	am := &MultitenantAlertmanager{
+		store:  alertStore,
+		logger: util_log.Logger,
+	}
+
+	newEntry := func(ts int64, receiver, outcome string) alertspb.NotificationHistoryEntry {
+		return alertspb.NotificationHistoryEntry{
+			Timestamp:   time.Unix(ts, 0).UTC(),
+			Receiver:    receiver,
+			Integration: "webhook",
+			Alerts:      []alertspb.NotificationHistoryAlert{{Fingerprint: "0123456789abcdef", Status: "firing"}},
+			Outcome:     outcome,
+		}
+	}
+	require.NoError(t, alertStore.AppendNotificationHistory(context.Background(), "test_user", []alertspb.NotificationHistoryEntry{
+		newEntry(100, "a", alertspb.NotificationOutcomeSuccess),
+		newEntry(200, "b", alertspb.NotificationOutcomeFailure),
+		newEntry(300, "a", alertspb.NotificationOutcomeFailure),
+	}))
+
+	tests := map[string]struct {
+		query            string
+		expectedCode     int
+		expectedEntries  []alertspb.NotificationHistoryEntry
+		expectedResponse string
+	}{
+		"time range": {
+			query:           "start=150&end=300",
+			expectedCode:    http.StatusOK,
+			expectedEntries: []alertspb.NotificationHistoryEntry{newEntry(200, "b", alertspb.NotificationOutcomeFailure), newEntry(300, "a", alertspb.NotificationOutcomeFailure)},
+		},
+		"filtered by receiver": {
+			query:           "start=0&end=1000&receiver=a",
+			expectedCode:    http.StatusOK,
+			expectedEntries: []alertspb.NotificationHistoryEntry{newEntry(100, "a", alertspb.NotificationOutcomeSuccess), newEntry(300, "a", alertspb.NotificationOutcomeFailure)},
+		},
+		"filtered by outcome": {
+			query:           "start=1970-01-01T00:00:00Z&end=1970-01-01T00:10:00Z&outcome=failure",
+			expectedCode:    http.StatusOK,
+			expectedEntries: []alertspb.NotificationHistoryEntry{newEntry(200, "b", alertspb.NotificationOutcomeFailure), newEntry(300, "a", alertspb.NotificationOutcomeFailure)},
+		},
+		"no entries in the time range": {
+			query:            "start=400&end=1000",
+			expectedCode:     http.StatusOK,
+			expectedResponse: `{"entries":[]}`,
+		},
+		"invalid time": {
+			query:        "start=foo",
+			expectedCode: http.StatusBadRequest,
+		},
+		"end before start": {
+			query:        "start=300&end=100",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/alerts/notification-history?"+testData.query, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "test_user"))
+
+			rec := httptest.NewRecorder()
+			am.GetNotificationHistory(rec, req)
+			require.Equal(t, testData.expectedCode, rec.Code, rec.Body.String())
+			if testData.expectedCode != http.StatusOK {
+				return
+			}
+
+			if testData.expectedResponse != "" {
+				assert.JSONEq(t, testData.expectedResponse, rec.Body.String())
+				return
+			}
+
+			res := NotificationHistoryResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, testData.expectedEntries, res.Entries)
+		})
+	}
+}
+
+func TestMultitenantAlertmanager_ConfigAuditLog(t *testing.T) {
+	const (
+		cfg = `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+  receivers:
+    - name: default-receiver
+`
+		cfgWithTemplate = cfg + `
+template_files:
+  first.tpl: |
+    {{ define "t1" }}Template{{ end }}
+`
+	)
+
+	am := &MultitenantAlertmanager{
+		store:          prepareInMemoryAlertStore(),
+		logger:         util_log.Logger,
+		limits:         &mockAlertManagerLimits{},
+		configAuditLog: newConfigAuditLog(ConfigAuditLogConfig{Enabled: true, ActorHeader: "X-Grafana-User"}, prepareInMemoryAlertStore(), log.NewNopLogger(), nil),
+	}
+
+	do := func(handler http.HandlerFunc, method, body, actor string) int {
+		req := httptest.NewRequest(method, "http://alertmanager/api/v1/alerts", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Grafana-User", actor)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "testing"))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusCreated, do(am.SetUserConfig, http.MethodPost, cfg, "alice"))
+	require.Equal(t, http.StatusCreated, do(am.SetUserConfig, http.MethodPost, cfg, "alice"))
+	require.Equal(t, http.StatusCreated, do(am.SetUserConfig, http.MethodPost, cfgWithTemplate, "bob"))
+	require.Equal(t, http.StatusOK, do(am.DeleteUserConfig, http.MethodDelete, "", "carol"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/alerts/config-audit-log", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "testing"))
+	rec := httptest.NewRecorder()
+	am.GetConfigAuditLog(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	res := ConfigAuditLogResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	// The config set twice is only recorded once.
+	require.Len(t, res.Entries, 3)
+
+	assert.Equal(t, "alice", res.Entries[0].Actor)
+	assert.Equal(t, alertspb.ConfigAuditLogActionSet, res.Entries[0].Action)
+	require.Len(t, res.Entries[0].Changes, 1)
+	assert.Equal(t, alertspb.ConfigChangeObjectAlertmanagerConfig, res.Entries[0].Changes[0].Object)
+	assert.Equal(t, alertspb.ConfigChangeAdded, res.Entries[0].Changes[0].Type)
+	assert.Contains(t, res.Entries[0].Changes[0].Diff, "+  receiver: 'default-receiver'")
+
+	assert.Equal(t, "bob", res.Entries[1].Actor)
+	require.Len(t, res.Entries[1].Changes, 1)
+	assert.Equal(t, alertspb.ConfigChangeObjectTemplate, res.Entries[1].Changes[0].Object)
+	assert.Equal(t, "first.tpl", res.Entries[1].Changes[0].Name)
+	assert.Equal(t, alertspb.ConfigChangeAdded, res.Entries[1].Changes[0].Type)
+
+	assert.Equal(t, "carol", res.Entries[2].Actor)
+	assert.Equal(t, alertspb.ConfigAuditLogActionDelete, res.Entries[2].Action)
+	require.Len(t, res.Entries[2].Changes, 2)
+	assert.Equal(t, alertspb.ConfigChangeDeleted, res.Entries[2].Changes[0].Type)
+	assert.Equal(t, alertspb.ConfigChangeDeleted, res.Entries[2].Changes[1].Type)
+
+	// The time range is validated.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/alerts/config-audit-log?start=foo", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "testing"))
+	rec = httptest.NewRecorder()
+	am.GetConfigAuditLog(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// The API is not found when the audit log is disabled.
+	am.configAuditLog = nil
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/alerts/config-audit-log", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "testing"))
+	rec = httptest.NewRecorder()
+	am.GetConfigAuditLog(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
 func TestAMConfigListUserConfig(t *testing.T) {
 	testCases := map[string]*UserConfig{
 		"user1": {
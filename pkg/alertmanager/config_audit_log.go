@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+)
+
+const (
+	// The max number of configuration audit log entries of each tenant kept in memory,
+	// when they're not stored in the alertmanager storage. The oldest entries are dropped once it's reached.
+	maxInMemoryConfigAuditLogEntries = 100
+)
+
+type ConfigAuditLogConfig struct {
+	Enabled      bool   `yaml:"enabled" category:"experimental"`
+	ActorHeader  string `yaml:"actor_header" category:"experimental"`
+	StoreEnabled bool   `yaml:"store_enabled" category:"experimental"`
+}
+
+func (cfg *ConfigAuditLogConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "True to record every change of the Alertmanager configuration and templates of a tenant done through the configuration API, with its timestamp, actor and diff. The changes can be queried through the configuration audit log API.")
+	f.StringVar(&cfg.ActorHeader, prefix+".actor-header", "X-Grafana-User", "The HTTP header of the configuration API requests containing the actor changing the configuration. The actor is empty if the request has no such header.")
+	f.BoolVar(&cfg.StoreEnabled, prefix+".store-enabled", false, "True to store the configuration changes in the alertmanager storage. If disabled, the configuration audit log API only returns the latest changes received by the alertmanager serving the request since it started.")
+}
+
+// configAuditLog records the changes of the Alertmanager configuration of the tenants, done through the configuration API.
+type configAuditLog struct {
+	cfg    ConfigAuditLogConfig
+	store  alertstore.AlertStore
+	logger log.Logger
+
+	mtx     sync.Mutex
+	entries map[string][]alertspb.ConfigAuditLogEntry
+
+	storeFailed prometheus.Counter
+}
+
+func newConfigAuditLog(cfg ConfigAuditLogConfig, store alertstore.AlertStore, logger log.Logger, reg prometheus.Registerer) *configAuditLog {
+	return &configAuditLog{
+		cfg:     cfg,
+		store:   store,
+		logger:  logger,
+		entries: map[string][]alertspb.ConfigAuditLogEntry{},
+		storeFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_alertmanager_config_audit_log_store_failed_total",
+			Help: "Number of times we have failed to store a configuration audit log entry to remote storage.",
+		}),
+	}
+}
+
+// actor returns the actor of the configuration API request.
+func (l *configAuditLog) actor(r *http.Request) string {
+	if l.cfg.ActorHeader == "" {
+		return ""
+	}
+	return r.Header.Get(l.cfg.ActorHeader)
+}
+
+// record adds the change from the previous to the next configuration of the tenant to the audit log, if any.
+// The configuration has already been changed, so failing to store the entry doesn't fail the request.
+func (l *configAuditLog) record(ctx context.Context, userID, actor, action string, prev, next alertspb.AlertConfigDesc) {
+	changes := diffUserConfigs(prev, next)
+	if len(changes) == 0 {
+		return
+	}
+
+	entry := alertspb.ConfigAuditLogEntry{
+		Timestamp: time.Now().UTC(),
+		Actor:     actor,
+		Action:    action,
+		Changes:   changes,
+	}
+
+	// The diff isn't logged because the configuration can contain secrets.
+	summary := make([]string, 0, len(changes))
+	for _, c := range changes {
+		summary = append(summary, strings.TrimSpace(fmt.Sprintf("%s %s %s", c.Type, c.Object, c.Name)))
+	}
+	level.Info(l.logger).Log("msg", "Alertmanager config changed", "user", userID, "actor", actor, "action", action, "changes", strings.Join(summary, ", "))
+
+	l.mtx.Lock()
+	entries := append(l.entries[userID], entry)
+	if dropped := len(entries) - maxInMemoryConfigAuditLogEntries; dropped > 0 {
+		entries = append(entries[:0], entries[dropped:]...)
+	}
+	l.entries[userID] = entries
+	l.mtx.Unlock()
+
+	if !l.cfg.StoreEnabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultPersistTimeout)
+	defer cancel()
+
+	if err := l.store.AppendConfigAuditLog(ctx, userID, entry); err != nil {
+		l.storeFailed.Inc()
+		level.Error(l.logger).Log("msg", "failed to store config audit log entry", "user", userID, "err", err)
+	}
+}
+
+// get returns the audit log entries of the tenant between start and end (both inclusive), sorted by timestamp.
+func (l *configAuditLog) get(ctx context.Context, userID string, start, end time.Time) ([]alertspb.ConfigAuditLogEntry, error) {
+	if l.cfg.StoreEnabled {
+		return l.store.GetConfigAuditLog(ctx, userID, start, end)
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	var entries []alertspb.ConfigAuditLogEntry
+	for _, e := range l.entries[userID] {
+		if !e.Timestamp.Before(start) && !e.Timestamp.After(end) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// diffUserConfigs returns the changes of the Alertmanager configuration and templates from prev to next.
+func diffUserConfigs(prev, next alertspb.AlertConfigDesc) []alertspb.ConfigChange {
+	var changes []alertspb.ConfigChange
+
+	if c, ok := diffConfigObject(alertspb.ConfigChangeObjectAlertmanagerConfig, "", prev.RawConfig, next.RawConfig); ok {
+		changes = append(changes, c)
+	}
+
+	prevTemplates, nextTemplates := alertspb.ParseTemplates(prev), alertspb.ParseTemplates(next)
+	names := make([]string, 0, len(prevTemplates)+len(nextTemplates))
+	for name := range prevTemplates {
+		names = append(names, name)
+	}
+	for name := range nextTemplates {
+		if _, ok := prevTemplates[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if c, ok := diffConfigObject(alertspb.ConfigChangeObjectTemplate, name, prevTemplates[name], nextTemplates[name]); ok {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// diffConfigObject returns the change of the object from prev to next, where an empty content means that
+// the object doesn't exist. It returns false if the object hasn't changed.
+func diffConfigObject(object, name, prev, next string) (alertspb.ConfigChange, bool) {
+	if prev == next {
+		return alertspb.ConfigChange{}, false
+	}
+
+	changeType := alertspb.ConfigChangeModified
+	switch {
+	case prev == "":
+		changeType = alertspb.ConfigChangeAdded
+	case next == "":
+		changeType = alertspb.ConfigChangeDeleted
+	}
+
+	// The diff is written to an in-memory buffer, so it can't fail.
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(prev),
+		B:        splitLines(next),
+		FromFile: "previous",
+		ToFile:   "current",
+		Context:  3,
+	})
+
+	return alertspb.ConfigChange{
+		Object: object,
+		Name:   name,
+		Type:   changeType,
+		Diff:   diff,
+	}, true
+}
+
+// splitLines splits the input text in lines, each one ending with a newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
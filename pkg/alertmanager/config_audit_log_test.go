@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+)
+
+type failingAuditLogStore struct {
+	alertstore.AlertStore
+}
+
+func (f *failingAuditLogStore) AppendConfigAuditLog(_ context.Context, _ string, _ alertspb.ConfigAuditLogEntry) error {
+	return errors.New("storage unavailable")
+}
+
+func TestDiffUserConfigs(t *testing.T) {
+	prev := alertspb.ToProto("route:\n  receiver: a\n", map[string]string{
+		"deleted.tmpl":   "deleted\n",
+		"modified.tmpl":  "before\n",
+		"unchanged.tmpl": "unchanged\n",
+	}, "user-1")
+
+	next := alertspb.ToProto("route:\n  receiver: b\n", map[string]string{
+		"added.tmpl":     "added\n",
+		"modified.tmpl":  "after\n",
+		"unchanged.tmpl": "unchanged\n",
+	}, "user-1")
+
+	assert.Equal(t, []alertspb.ConfigChange{
+		{
+			Object: alertspb.ConfigChangeObjectAlertmanagerConfig,
+			Type:   alertspb.ConfigChangeModified,
+			Diff:   "--- previous\n+++ current\n@@ -1,2 +1,2 @@\n route:\n-  receiver: a\n+  receiver: b\n",
+		}, {
+			Object: alertspb.ConfigChangeObjectTemplate,
+			Name:   "added.tmpl",
+			Type:   alertspb.ConfigChangeAdded,
+			Diff:   "--- previous\n+++ current\n@@ -0,0 +1 @@\n+added\n",
+		}, {
+			Object: alertspb.ConfigChangeObjectTemplate,
+			Name:   "deleted.tmpl",
+			Type:   alertspb.ConfigChangeDeleted,
+			Diff:   "--- previous\n+++ current\n@@ -1 +0,0 @@\n-deleted\n",
+		}, {
+			Object: alertspb.ConfigChangeObjectTemplate,
+			Name:   "modified.tmpl",
+			Type:   alertspb.ConfigChangeModified,
+			Diff:   "--- previous\n+++ current\n@@ -1 +1 @@\n-before\n+after\n",
+		},
+	}, diffUserConfigs(prev, next))
+
+	assert.Empty(t, diffUserConfigs(next, next))
+}
+
+func TestConfigAuditLog_InMemory(t *testing.T) {
+	l := newConfigAuditLog(ConfigAuditLogConfig{Enabled: true}, &failingAuditLogStore{}, log.NewNopLogger(), nil)
+
+	empty := alertspb.AlertConfigDesc{User: "user-1"}
+	cfg := alertspb.ToProto("route:\n  receiver: a\n", nil, "user-1")
+
+	start := time.Now().Add(-time.Second)
+	l.record(context.Background(), "user-1", "alice", alertspb.ConfigAuditLogActionSet, empty, cfg)
+	l.record(context.Background(), "user-1", "alice", alertspb.ConfigAuditLogActionSet, cfg, cfg)
+	l.record(context.Background(), "user-1", "bob", alertspb.ConfigAuditLogActionDelete, cfg, empty)
+
+	// The unchanged configurations are not recorded.
+	entries, err := l.get(context.Background(), "user-1", start, time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, alertspb.ConfigAuditLogActionSet, entries[0].Action)
+	assert.Equal(t, alertspb.ConfigChangeAdded, entries[0].Changes[0].Type)
+	assert.Equal(t, "bob", entries[1].Actor)
+	assert.Equal(t, alertspb.ConfigAuditLogActionDelete, entries[1].Action)
+	assert.Equal(t, alertspb.ConfigChangeDeleted, entries[1].Changes[0].Type)
+
+	// The entries are filtered by tenant and time range.
+	entries, err = l.get(context.Background(), "user-2", start, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = l.get(context.Background(), "user-1", start.Add(-time.Hour), start)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Only the latest entries are kept in memory.
+	for i := 0; i < maxInMemoryConfigAuditLogEntries; i++ {
+		l.record(context.Background(), "user-1", "carol", alertspb.ConfigAuditLogActionSet, empty, cfg)
+	}
+	entries, err = l.get(context.Background(), "user-1", start, time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, maxInMemoryConfigAuditLogEntries)
+	assert.Equal(t, "carol", entries[0].Actor)
+}
+
+func TestConfigAuditLog_Store(t *testing.T) {
+	store := prepareInMemoryAlertStore()
+	l := newConfigAuditLog(ConfigAuditLogConfig{Enabled: true, StoreEnabled: true}, store, log.NewNopLogger(), nil)
+
+	start := time.Now().Add(-time.Second)
+	l.record(context.Background(), "user-1", "alice", alertspb.ConfigAuditLogActionSet, alertspb.AlertConfigDesc{}, alertspb.ToProto("route:\n  receiver: a\n", nil, "user-1"))
+
+	// The entries are read from the storage.
+	l.entries = map[string][]alertspb.ConfigAuditLogEntry{}
+	entries, err := l.get(context.Background(), "user-1", start, time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "alice", entries[0].Actor)
+}
+
+func TestConfigAuditLog_StoreFailure(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	l := newConfigAuditLog(ConfigAuditLogConfig{Enabled: true, StoreEnabled: true}, &failingAuditLogStore{}, log.NewNopLogger(), reg)
+
+	l.record(context.Background(), "user-1", "alice", alertspb.ConfigAuditLogActionSet, alertspb.AlertConfigDesc{}, alertspb.ToProto("route:\n  receiver: a\n", nil, "user-1"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(l.storeFailed))
+}
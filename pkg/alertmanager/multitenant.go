@@ -86,6 +86,10 @@ type MultitenantAlertmanagerConfig struct {
 
 	// For the state persister.
 	Persister PersisterConfig `yaml:",inline"`
+
+	NotificationHistory NotificationHistoryConfig `yaml:"notification_history"`
+
+	ConfigAuditLog ConfigAuditLogConfig `yaml:"config_audit_log"`
 }
 
 const (
@@ -109,6 +113,8 @@ func (cfg *MultitenantAlertmanagerConfig) RegisterFlags(f *flag.FlagSet, logger
 
 	cfg.AlertmanagerClient.RegisterFlagsWithPrefix("alertmanager.alertmanager-client", f)
 	cfg.Persister.RegisterFlagsWithPrefix("alertmanager", f)
+	cfg.NotificationHistory.RegisterFlagsWithPrefix("alertmanager.notification-history", f)
+	cfg.ConfigAuditLog.RegisterFlagsWithPrefix("alertmanager.config-audit-log", f)
 	cfg.ShardingRing.RegisterFlags(f, logger)
 
 	f.DurationVar(&cfg.PeerTimeout, "alertmanager.peer-timeout", defaultPeerTimeout, "Time to wait between peers to send notifications.")
@@ -139,6 +145,10 @@ func (cfg *MultitenantAlertmanagerConfig) Validate() error {
 		return err
 	}
 
+	if err := cfg.NotificationHistory.Validate(); err != nil {
+		return err
+	}
+
 	if cfg.ShardingRing.ZoneAwarenessEnabled && cfg.ShardingRing.InstanceZone == "" {
 		return errZoneAwarenessEnabledWithoutZoneInfo
 	}
@@ -219,6 +229,16 @@ type Limits interface {
 	// AlertmanagerMaxAlertsSizeBytes returns total max size of alerts that tenant can have active at the same time. 0 = no limit.
 	// Size of the alert is computed from alert labels, annotations and generator URL.
 	AlertmanagerMaxAlertsSizeBytes(tenant string) int
+
+	// AlertmanagerMaxReceiversCount returns max number of receivers that tenant can use in the configuration. 0 = no limit.
+	AlertmanagerMaxReceiversCount(tenant string) int
+
+	// AlertmanagerMaxRoutesCount returns max number of routes, excluding the root one, that tenant can use in the configuration. 0 = no limit.
+	AlertmanagerMaxRoutesCount(tenant string) int
+
+	// AlertmanagerLimitsGracePeriod returns the period during which the configurations exceeding the max number of
+	// receivers or routes are accepted before being rejected. 0 = no grace period.
+	AlertmanagerLimitsGracePeriod(tenant string) time.Duration
 }
 
 // A MultitenantAlertmanager manages Alertmanager instances for multiple
@@ -268,6 +288,9 @@ type MultitenantAlertmanager struct {
 
 	limits Limits
 
+	// The audit log of the configuration changes, nil if disabled.
+	configAuditLog *configAuditLog
+
 	registry          prometheus.Registerer
 	ringCheckErrors   prometheus.Counter
 	tenantsOwned      prometheus.Gauge
@@ -342,6 +365,10 @@ func createMultitenantAlertmanager(cfg *MultitenantAlertmanagerConfig, fallbackC
 		}),
 	}
 
+	if cfg.ConfigAuditLog.Enabled {
+		am.configAuditLog = newConfigAuditLog(cfg.ConfigAuditLog, store, am.logger, registerer)
+	}
+
 	// Initialize the top-level metrics.
 	for _, r := range []string{reasonInitial, reasonPeriodic, reasonRingChange} {
 		am.syncTotal.WithLabelValues(r)
@@ -750,6 +777,7 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 		ReplicationFactor:                 am.cfg.ShardingRing.ReplicationFactor,
 		Store:                             am.store,
 		PersisterConfig:                   am.cfg.Persister,
+		NotificationHistoryConfig:         am.cfg.NotificationHistory,
 		Limits:                            am.limits,
 	}, reg)
 	if err != nil {
@@ -1034,6 +1062,9 @@ func (am *MultitenantAlertmanager) deleteUnusedRemoteUserState(ctx context.Conte
 		}
 
 		err := am.store.DeleteFullState(ctx, userID)
+		if err == nil {
+			err = am.store.SetLimitsExceededSince(ctx, userID, time.Time{})
+		}
 		if err != nil {
 			level.Warn(am.logger).Log("msg", "failed to delete remote state for user", "user", userID, "err", err)
 		} else {
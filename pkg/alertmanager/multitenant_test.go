@@ -2201,6 +2201,9 @@ type mockAlertManagerLimits struct {
 	maxDispatcherAggregationGroups int
 	maxAlertsCount                 int
 	maxAlertsSizeBytes             int
+	maxReceiversCount              int
+	maxRoutesCount                 int
+	limitsGracePeriod              time.Duration
 }
 
 func (m *mockAlertManagerLimits) AlertmanagerMaxConfigSize(tenant string) int {
@@ -2242,3 +2245,15 @@ func (m *mockAlertManagerLimits) AlertmanagerMaxAlertsCount(_ string) int {
 func (m *mockAlertManagerLimits) AlertmanagerMaxAlertsSizeBytes(_ string) int {
 	return m.maxAlertsSizeBytes
 }
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxReceiversCount(_ string) int {
+	return m.maxReceiversCount
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxRoutesCount(_ string) int {
+	return m.maxRoutesCount
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerLimitsGracePeriod(_ string) time.Duration {
+	return m.limitsGracePeriod
+}
@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+)
+
+const (
+	// The max number of notification history entries kept in memory while they can't be flushed
+	// to the storage. The oldest entries are dropped once it's reached.
+	maxBufferedNotificationHistoryEntries = 10000
+)
+
+var (
+	errInvalidNotificationHistoryFlushInterval = errors.New("invalid alertmanager notification history flush interval, must be greater than zero")
+	errInvalidNotificationHistoryRetention     = errors.New("invalid alertmanager notification history retention period, must not be negative")
+)
+
+type NotificationHistoryConfig struct {
+	Enabled         bool          `yaml:"enabled" category:"experimental"`
+	FlushInterval   time.Duration `yaml:"flush_interval" category:"experimental"`
+	RetentionPeriod time.Duration `yaml:"retention_period" category:"experimental"`
+}
+
+func (cfg *NotificationHistoryConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "True to record every attempt to send a notification (receiver, integration, alert fingerprints, outcome and timestamp) and store it in the alertmanager storage, where it can be queried through the notification history API.")
+	f.DurationVar(&cfg.FlushInterval, prefix+".flush-interval", time.Minute, "The interval between flushing the recorded notification history to the alertmanager storage. The notifications recorded since the last flush are not returned by the notification history API.")
+	f.DurationVar(&cfg.RetentionPeriod, prefix+".retention-period", 30*24*time.Hour, "How long to keep the notification history in the alertmanager storage. 0 to keep it forever.")
+}
+
+func (cfg *NotificationHistoryConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FlushInterval <= 0 {
+		return errInvalidNotificationHistoryFlushInterval
+	}
+	if cfg.RetentionPeriod < 0 {
+		return errInvalidNotificationHistoryRetention
+	}
+	return nil
+}
+
+// notificationHistory records the notifications sent by the alertmanager of a tenant, and periodically
+// flushes them to the alertmanager storage.
+type notificationHistory struct {
+	services.Service
+
+	cfg    NotificationHistoryConfig
+	state  State
+	store  alertstore.AlertStore
+	userID string
+	logger log.Logger
+
+	timeout time.Duration
+
+	mtx     sync.Mutex
+	entries []alertspb.NotificationHistoryEntry
+
+	flushTotal     prometheus.Counter
+	flushFailed    prometheus.Counter
+	droppedEntries prometheus.Counter
+}
+
+func newNotificationHistory(cfg NotificationHistoryConfig, userID string, state State, store alertstore.AlertStore, l log.Logger, r prometheus.Registerer) *notificationHistory {
+	h := &notificationHistory{
+		cfg:     cfg,
+		state:   state,
+		store:   store,
+		userID:  userID,
+		logger:  l,
+		timeout: defaultPersistTimeout,
+		flushTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_notification_history_flush_total",
+			Help: "Number of times we have tried to flush the notification history to remote storage.",
+		}),
+		flushFailed: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_notification_history_flush_failed_total",
+			Help: "Number of times we have failed to flush the notification history to remote storage.",
+		}),
+		droppedEntries: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_notification_history_dropped_entries_total",
+			Help: "Number of notification history entries dropped because they couldn't be flushed to remote storage.",
+		}),
+	}
+
+	h.Service = services.NewTimerService(cfg.FlushInterval, nil, h.iteration, h.stopping)
+
+	return h
+}
+
+// record adds an attempt to send a notification to the history.
+func (h *notificationHistory) record(ctx context.Context, integration string, alerts []*types.Alert, notifyErr error) {
+	receiver, _ := notify.ReceiverName(ctx)
+	groupKey, _ := notify.GroupKey(ctx)
+
+	entry := alertspb.NotificationHistoryEntry{
+		Timestamp:   time.Now().UTC(),
+		Receiver:    receiver,
+		Integration: integration,
+		GroupKey:    groupKey,
+		Alerts:      make([]alertspb.NotificationHistoryAlert, 0, len(alerts)),
+		Outcome:     alertspb.NotificationOutcomeSuccess,
+	}
+	for _, a := range alerts {
+		entry.Alerts = append(entry.Alerts, alertspb.NotificationHistoryAlert{
+			Fingerprint: a.Fingerprint().String(),
+			Status:      string(a.Status()),
+		})
+	}
+	if notifyErr != nil {
+		entry.Outcome = alertspb.NotificationOutcomeFailure
+		entry.Error = notifyErr.Error()
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.appendEntries([]alertspb.NotificationHistoryEntry{entry})
+}
+
+// appendEntries appends the entries to the ones to flush, dropping the oldest ones above the max.
+// Must be called with the lock held.
+func (h *notificationHistory) appendEntries(entries []alertspb.NotificationHistoryEntry) {
+	h.entries = append(h.entries, entries...)
+	if dropped := len(h.entries) - maxBufferedNotificationHistoryEntries; dropped > 0 {
+		h.droppedEntries.Add(float64(dropped))
+		h.entries = append(h.entries[:0], h.entries[dropped:]...)
+	}
+}
+
+func (h *notificationHistory) iteration(ctx context.Context) error {
+	if err := h.flush(ctx); err != nil {
+		level.Error(h.logger).Log("msg", "failed to flush notification history", "user", h.userID, "err", err)
+	}
+
+	if err := h.applyRetention(ctx, time.Now()); err != nil {
+		level.Warn(h.logger).Log("msg", "failed to delete the notification history older than the retention period", "user", h.userID, "err", err)
+	}
+	return nil
+}
+
+func (h *notificationHistory) stopping(_ error) error {
+	// Flush the notifications recorded since the last flush before stopping.
+	if err := h.flush(context.Background()); err != nil {
+		level.Error(h.logger).Log("msg", "failed to flush notification history", "user", h.userID, "err", err)
+	}
+	return nil
+}
+
+func (h *notificationHistory) flush(ctx context.Context) (err error) {
+	h.mtx.Lock()
+	entries := h.entries
+	h.entries = nil
+	h.mtx.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	h.flushTotal.Inc()
+	defer func() {
+		if err != nil {
+			h.flushFailed.Inc()
+
+			// Put back the entries, so that they're flushed at the next iteration.
+			h.mtx.Lock()
+			pending := h.entries
+			h.entries = nil
+			h.appendEntries(entries)
+			h.appendEntries(pending)
+			h.mtx.Unlock()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	return h.store.AppendNotificationHistory(ctx, h.userID, entries)
+}
+
+func (h *notificationHistory) applyRetention(ctx context.Context, now time.Time) error {
+	// Only the replica at position zero deletes the old history.
+	if h.cfg.RetentionPeriod == 0 || h.state.Position() != 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	return h.store.DeleteNotificationHistory(ctx, h.userID, now.Add(-h.cfg.RetentionPeriod))
+}
+
+// historyRecordingNotifier records the notifications sent through the upstream notifier in the notification history.
+type historyRecordingNotifier struct {
+	upstream    notify.Notifier
+	integration string
+	history     *notificationHistory
+}
+
+func newHistoryRecordingNotifier(upstream notify.Notifier, integration string, history *notificationHistory) *historyRecordingNotifier {
+	return &historyRecordingNotifier{
+		upstream:    upstream,
+		integration: integration,
+		history:     history,
+	}
+}
+
+func (n *historyRecordingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	n.history.record(ctx, n.integration, alerts, err)
+	return retry, err
+}
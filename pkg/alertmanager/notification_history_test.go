@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore/bucketclient"
+)
+
+type erroringNotifier struct {
+	err error
+}
+
+func (n *erroringNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	return n.err != nil, n.err
+}
+
+type failingHistoryStore struct {
+	alertstore.AlertStore
+}
+
+func (f *failingHistoryStore) AppendNotificationHistory(_ context.Context, _ string, _ []alertspb.NotificationHistoryEntry) error {
+	return errors.New("storage unavailable")
+}
+
+func TestNotificationHistoryConfig_Validate(t *testing.T) {
+	cfg := NotificationHistoryConfig{}
+	assert.NoError(t, cfg.Validate())
+
+	cfg = NotificationHistoryConfig{Enabled: true, FlushInterval: time.Minute}
+	assert.NoError(t, cfg.Validate())
+
+	cfg = NotificationHistoryConfig{Enabled: true}
+	assert.Equal(t, errInvalidNotificationHistoryFlushInterval, cfg.Validate())
+
+	cfg = NotificationHistoryConfig{Enabled: true, FlushInterval: time.Minute, RetentionPeriod: -time.Minute}
+	assert.Equal(t, errInvalidNotificationHistoryRetention, cfg.Validate())
+}
+
+func TestNotificationHistory_RecordAndFlush(t *testing.T) {
+	const userID = "user-1"
+
+	store := bucketclient.NewBucketAlertStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	state := newFakePersistableState()
+	h := newNotificationHistory(NotificationHistoryConfig{Enabled: true, FlushInterval: time.Hour, RetentionPeriod: time.Hour}, userID, state, store, log.NewNopLogger(), nil)
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}, StartsAt: time.Now().Add(-time.Minute)}}
+	resolved := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "other"}, StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(-time.Second)}}
+
+	ctx := notify.WithReceiverName(context.Background(), "team-a")
+	ctx = notify.WithGroupKey(ctx, "{}:{alertname=\"test\"}")
+
+	start := time.Now().Add(-time.Second)
+
+	retry, err := newHistoryRecordingNotifier(&erroringNotifier{}, "webhook", h).Notify(ctx, alert, resolved)
+	require.NoError(t, err)
+	assert.False(t, retry)
+
+	retry, err = newHistoryRecordingNotifier(&erroringNotifier{err: errors.New("connection refused")}, "email", h).Notify(ctx, alert)
+	require.Error(t, err)
+	assert.True(t, retry)
+
+	// The entries are not visible until they're flushed.
+	entries, err := store.GetNotificationHistory(context.Background(), userID, start, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, h.flush(context.Background()))
+
+	entries, err = store.GetNotificationHistory(context.Background(), userID, start, time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	for _, e := range entries {
+		assert.Equal(t, "team-a", e.Receiver)
+		assert.Equal(t, "{}:{alertname=\"test\"}", e.GroupKey)
+	}
+
+	assert.Equal(t, "webhook", entries[0].Integration)
+	assert.Equal(t, alertspb.NotificationOutcomeSuccess, entries[0].Outcome)
+	assert.Empty(t, entries[0].Error)
+	assert.Equal(t, []alertspb.NotificationHistoryAlert{
+		{Fingerprint: alert.Fingerprint().String(), Status: "firing"},
+		{Fingerprint: resolved.Fingerprint().String(), Status: "resolved"},
+	}, entries[0].Alerts)
+
+	assert.Equal(t, "email", entries[1].Integration)
+	assert.Equal(t, alertspb.NotificationOutcomeFailure, entries[1].Outcome)
+	assert.Equal(t, "connection refused", entries[1].Error)
+
+	// The flushed entries are not flushed again.
+	require.NoError(t, h.flush(context.Background()))
+	entries, err = store.GetNotificationHistory(context.Background(), userID, start, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Only the replica at position zero applies the retention.
+	state.position = 1
+	require.NoError(t, h.applyRetention(context.Background(), time.Now().Add(2*time.Hour)))
+	entries, err = store.GetNotificationHistory(context.Background(), userID, start, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	state.position = 0
+	require.NoError(t, h.applyRetention(context.Background(), time.Now()))
+	entries, err = store.GetNotificationHistory(context.Background(), userID, start, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	require.NoError(t, h.applyRetention(context.Background(), time.Now().Add(2*time.Hour)))
+	entries, err = store.GetNotificationHistory(context.Background(), userID, start, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestNotificationHistory_FlushFailure(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	h := newNotificationHistory(NotificationHistoryConfig{Enabled: true, FlushInterval: time.Hour}, "user-1", newFakePersistableState(), &failingHistoryStore{}, log.NewNopLogger(), reg)
+
+	ctx := notify.WithReceiverName(context.Background(), "team-a")
+	for i := 0; i < maxBufferedNotificationHistoryEntries; i++ {
+		h.record(ctx, "webhook", nil, nil)
+	}
+
+	// The entries are kept when they can't be flushed, dropping the oldest ones above the max.
+	require.Error(t, h.flush(context.Background()))
+	h.record(ctx, "email", nil, nil)
+	assert.Len(t, h.entries, maxBufferedNotificationHistoryEntries)
+	assert.Equal(t, "email", h.entries[len(h.entries)-1].Integration)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(h.flushTotal))
+	assert.Equal(t, float64(1), testutil.ToFloat64(h.flushFailed))
+	assert.Equal(t, float64(1), testutil.ToFloat64(h.droppedEntries))
+}
@@ -42,7 +42,9 @@ import (
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/util/gziphandler"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/memberlistsnapshot"
 	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 // DistributorPushWrapper wraps around a push. It is similar to middleware.Interface.
@@ -202,6 +204,8 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, api
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.GetUserConfig), true, true, "GET")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.SetUserConfig), true, true, "POST")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.DeleteUserConfig), true, true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/notification-history", http.HandlerFunc(am.GetNotificationHistory), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/config-audit-log", http.HandlerFunc(am.GetConfigAuditLog), true, true, "GET")
 	}
 }
 
@@ -213,7 +217,12 @@ func (a *API) RegisterAPI(httpPathPrefix string, actualCfg interface{}, defaultC
 	})
 
 	a.RegisterRoute("/config", a.cfg.configHandler(actualCfg, defaultCfg), false, true, "GET")
+	a.indexPage.AddLinks(serviceStatusWeight, "Overview", []IndexPageLink{
+		{Desc: "Cluster status", Path: "/cluster-status"},
+	})
+
 	a.RegisterRoute("/", indexHandler(httpPathPrefix, a.indexPage), false, true, "GET")
+	a.RegisterRoute("/cluster-status", clusterStatusHandler(httpPathPrefix), false, true, "GET")
 	a.RegisterRoutesWithPrefix("/static/", http.StripPrefix(httpPathPrefix, http.FileServer(http.FS(staticFiles))), false, true, "GET")
 	a.RegisterRoute("/debug/fgprof", fgprof.Handler(), false, true, "GET")
 	a.RegisterRoute("/api/v1/status/buildinfo", buildInfoHandler, false, true, "GET")
@@ -232,22 +241,30 @@ func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc, userL
 }
 
 // RegisterDistributor registers the endpoints associated with the distributor.
-func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config, reg prometheus.Registerer) {
+func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config, limits *validation.Overrides, reg prometheus.Registerer) {
 	distributorpb.RegisterDistributorServer(a.server.GRPC, d)
 
 	pushFn := d.GetPushFunc(a.cfg.DistributorPushWrapper)
 	a.RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, pushFn), true, false, "POST")
-	a.RegisterRoute("/otlp/v1/metrics", push.OTLPHandler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, reg, pushFn), true, false, "POST")
+	a.RegisterRoute("/otlp/v1/metrics", push.OTLPHandler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, limits, reg, pushFn), true, false, "POST")
+	if d.PushGateway != nil {
+		a.RegisterRoutesWithPrefix("/pushgateway/metrics/", d.PushGateway, true, false, "PUT", "POST", "DELETE")
+	}
+	if d.ExpositionPush != nil {
+		a.RegisterRoute("/api/v1/push/exposition", d.ExpositionPush, true, false, "POST")
+	}
 
 	a.indexPage.AddLinks(defaultWeight, "Distributor", []IndexPageLink{
 		{Desc: "Ring status", Path: "/distributor/ring"},
 		{Desc: "Usage statistics", Path: "/distributor/all_user_stats"},
 		{Desc: "HA tracker status", Path: "/distributor/ha_tracker"},
+		{Desc: "Ejected ingesters", Path: "/distributor/ejected_ingesters"},
 	})
 
 	a.RegisterRoute("/distributor/ring", d, false, true, "GET", "POST")
 	a.RegisterRoute("/distributor/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, true, "GET")
 	a.RegisterRoute("/distributor/ha_tracker", d.HATracker, false, true, "GET")
+	a.RegisterRoute("/distributor/ejected_ingesters", d.IngesterOutliers, false, true, "GET")
 }
 
 // Ingester is defined as an interface to allow for alternative implementations
@@ -286,6 +303,11 @@ func (a *API) RegisterRuler(r *ruler.Ruler) {
 	// List all user rule groups
 	a.RegisterRoute("/ruler/rule_groups", http.HandlerFunc(r.ListAllRules), false, true, "GET")
 
+	// Canary evaluation of the modified rule groups, handled by the ruler evaluating them.
+	a.RegisterRoute("/ruler/canary_rule_groups", http.HandlerFunc(r.ListCanaryRuleGroups), true, true, "GET")
+	a.RegisterRoute("/ruler/canary_rule_groups/{namespace}/{groupName}/promote", http.HandlerFunc(r.PromoteCanaryRuleGroup), true, true, "POST")
+	a.RegisterRoute("/ruler/canary_rule_groups/{namespace}/{groupName}/rollback", http.HandlerFunc(r.RollbackCanaryRuleGroup), true, true, "POST")
+
 	ruler.RegisterRulerServer(a.server.GRPC, r)
 }
 
@@ -309,6 +331,16 @@ func (a *API) RegisterRulerAPI(r *ruler.API, configAPIEnabled bool, buildInfoHan
 	}
 }
 
+// RegisterRulerReports registers the routes of the ruler reports API.
+func (a *API) RegisterRulerReports(r *ruler.ReportsAPI) {
+	a.RegisterRoute("/api/v1/reports", http.HandlerFunc(r.ListReports), true, true, "GET")
+	a.RegisterRoute("/api/v1/reports/{name}", http.HandlerFunc(r.GetReport), true, true, "GET")
+	a.RegisterRoute("/api/v1/reports/{name}", http.HandlerFunc(r.SetReport), true, true, "PUT", "POST")
+	a.RegisterRoute("/api/v1/reports/{name}", http.HandlerFunc(r.DeleteReport), true, true, "DELETE")
+	a.RegisterRoute("/api/v1/reports/{name}/snapshots", http.HandlerFunc(r.ListSnapshots), true, true, "GET")
+	a.RegisterRoute("/api/v1/reports/{name}/snapshots/{timestamp}", http.HandlerFunc(r.GetSnapshot), true, true, "GET")
+}
+
 // RegisterRing registers the ring UI page associated with the distributor for writes.
 func (a *API) RegisterRing(r http.Handler) {
 	a.indexPage.AddLinks(defaultWeight, "Ingester", []IndexPageLink{
@@ -336,17 +368,22 @@ func (a *API) RegisterCompactor(c *compactor.MultitenantCompactor) {
 		{Desc: "Ring status", Path: "/compactor/ring"},
 	})
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/compactor/status", http.HandlerFunc(c.StatusHandler), false, true, "GET")
+	a.RegisterRoute("/compactor/tenant/{tenant}/blocks_timeline", http.HandlerFunc(c.BlocksTimelineHandler), false, true, "GET")
 	a.RegisterRoute("/api/v1/upload/block/{block}/start", http.HandlerFunc(c.StartBlockUpload), true, false, http.MethodPost)
 	a.RegisterRoute("/api/v1/upload/block/{block}/files", http.HandlerFunc(c.UploadBlockFile), true, false, http.MethodPost)
 	a.RegisterRoute("/api/v1/upload/block/{block}/finish", http.HandlerFunc(c.FinishBlockUpload), true, false, http.MethodPost)
 	a.RegisterRoute("/api/v1/upload/block/{block}/check", http.HandlerFunc(c.GetBlockUploadStateHandler), true, false, http.MethodGet)
 	a.RegisterRoute("/compactor/delete_tenant", http.HandlerFunc(c.DeleteTenant), true, true, "POST")
 	a.RegisterRoute("/compactor/delete_tenant_status", http.HandlerFunc(c.DeleteTenantStatus), true, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/admin/tsdb/delete_series"), http.HandlerFunc(c.DeleteSeries), true, true, "PUT", "POST")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/admin/tsdb/delete_series"), http.HandlerFunc(c.SeriesDeletionRequests), true, true, "GET")
 }
 
 type Distributor interface {
 	querier.Distributor
 	UserStatsHandler(w http.ResponseWriter, r *http.Request)
+	HeadStatsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 // RegisterQueryable registers the the default routes associated with the querier
@@ -357,6 +394,13 @@ func (a *API) RegisterQueryable(
 ) {
 	// these routes are always registered to the default server
 	a.RegisterRoute("/api/v1/user_stats", http.HandlerFunc(distributor.UserStatsHandler), true, true, "GET")
+	a.RegisterRoute("/api/v1/head_stats", http.HandlerFunc(distributor.HeadStatsHandler), true, true, "GET")
+}
+
+// RegisterBlocksStoreQueryable registers the routes associated with the querier blocks storage queryable.
+func (a *API) RegisterBlocksStoreQueryable(q *querier.BlocksStoreQueryable) {
+	a.RegisterRoute("/querier/blocks_selection", http.HandlerFunc(q.BlocksSelectionHandler), true, true, "GET")
+	a.RegisterRoute("/querier/ejected_store_gateways", http.HandlerFunc(q.EjectedStoreGatewaysHandler), false, true, "GET")
 }
 
 // RegisterQueryAPI registers the Prometheus API routes with the provided handler.
@@ -365,6 +409,7 @@ func (a *API) RegisterQueryAPI(handler http.Handler, buildInfoHandler http.Handl
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_range"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_exemplars"), handler, true, true, "GET", "POST")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_with_exemplars"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/labels"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/label/{name}/values"), handler, true, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/series"), handler, true, true, "GET", "POST", "DELETE")
@@ -394,6 +439,7 @@ func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
 		{Desc: "Ring status", Path: "/query-scheduler/ring"},
 	})
 	a.RegisterRoute("/query-scheduler/ring", http.HandlerFunc(f.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/query-scheduler/queues", http.HandlerFunc(f.QueuesHandler), false, true, "GET")
 
 	schedulerpb.RegisterSchedulerForFrontendServer(a.server.GRPC, f)
 	schedulerpb.RegisterSchedulerForQuerierServer(a.server.GRPC, f)
@@ -415,3 +461,8 @@ func (a *API) RegisterMemberlistKV(pathPrefix string, kvs *memberlist.KVInitServ
 	})
 	a.RegisterRoute("/memberlist", memberlistStatusHandler(pathPrefix, kvs), false, true, "GET")
 }
+
+// RegisterMemberlistSnapshot registers the endpoint serving the full state snapshot of the memberlist KV.
+func (a *API) RegisterMemberlistSnapshot(handler http.Handler) {
+	a.RegisterRoute(memberlistsnapshot.Path, handler, false, true, "GET")
+}
@@ -10,12 +10,18 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/globalerror"
 )
 
 type Type string
 
+// statusClientClosedRequest is the status code used when the client closes the connection before the response.
+const statusClientClosedRequest = 499
+
 // adapted from https://github.com/prometheus/prometheus/blob/fdbc40a9efcc8197a94f23f0e479b0b56e52d424/web/api/v1/api.go#L67-L76
 const (
 	TypeNone            Type = ""
@@ -30,6 +36,38 @@ const (
 	TypeTooLargeEntry   Type = "too_large_entry"
 )
 
+// SchemaVersionHeader is the HTTP header through which clients negotiate the version of the schema of the error
+// responses. Clients opt in the versioned schema setting the request header to the latest version they support,
+// and the response header is set to the version actually used. Without it, errors are returned in the legacy format.
+const SchemaVersionHeader = "X-Mimir-Error-Schema-Version"
+
+// Versions of the schema of the error responses.
+const (
+	// SchemaVersionLegacy is the format of the error responses of the clients not negotiating a version: a JSON
+	// object with the fields of the Prometheus API errors for the query API, and plain text for the push API.
+	SchemaVersionLegacy = 0
+
+	// SchemaVersion1 is a JSON object with the fields of the Prometheus API errors and the stable
+	// machine-readable code of the error, for both the query and push API.
+	SchemaVersion1 = 1
+
+	// LatestSchemaVersion is the latest version of the schema of the error responses.
+	LatestSchemaVersion = SchemaVersion1
+)
+
+// NegotiateSchemaVersion returns the version of the schema of the error responses to use for a request
+// with the input headers: the latest version supported by both the client and the server.
+func NegotiateSchemaVersion(h http.Header) int {
+	requested, err := strconv.Atoi(h.Get(SchemaVersionHeader))
+	if err != nil || requested <= SchemaVersionLegacy {
+		return SchemaVersionLegacy
+	}
+	if requested > LatestSchemaVersion {
+		return LatestSchemaVersion
+	}
+	return requested
+}
+
 type apiError struct {
 	Type    Type
 	Message string
@@ -91,6 +129,110 @@ func HTTPResponseFromError(err error) (*httpgrpc.HTTPResponse, bool) {
 	}, true
 }
 
+// HTTPResponseFromErrorWithSchemaVersion converts an error into a JSON HTTP response with the input version of the
+// schema of the error responses. Errors which are not an apiError keep their httpgrpc status code, and get the type
+// matching it, or the internal type and status code if they have none. It returns false if the legacy schema version is requested and the error
+// is not an apiError, in which case the error must be written as it is.
+func HTTPResponseFromErrorWithSchemaVersion(err error, version int) (*httpgrpc.HTTPResponse, bool) {
+	if version == SchemaVersionLegacy {
+		return HTTPResponseFromError(err)
+	}
+
+	var (
+		apiErr *apiError
+		code   int
+	)
+	if errors.As(err, &apiErr) {
+		code = apiErr.statusCode()
+	} else if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		code = int(resp.Code)
+		apiErr = &apiError{Type: TypeFromStatusCode(code), Message: string(resp.Body)}
+	} else {
+		code = http.StatusInternalServerError
+		apiErr = &apiError{Type: TypeInternal, Message: err.Error()}
+	}
+
+	body, err := json.Marshal(
+		struct {
+			Status    string `json:"status"`
+			ErrorType Type   `json:"errorType,omitempty"`
+			ErrorCode string `json:"errorCode"`
+			Error     string `json:"error,omitempty"`
+		}{
+			Status:    "error",
+			Error:     apiErr.Message,
+			ErrorType: apiErr.Type,
+			ErrorCode: Code(apiErr.Type, apiErr.Message),
+		},
+	)
+	if err != nil {
+		return nil, false
+	}
+
+	return &httpgrpc.HTTPResponse{
+		Code: int32(code),
+		Body: body,
+		Headers: []*httpgrpc.Header{
+			{Key: "Content-Type", Values: []string{"application/json"}},
+			{Key: SchemaVersionHeader, Values: []string{strconv.Itoa(version)}},
+		},
+	}, true
+}
+
+// Code returns the stable machine-readable code of an error with the input type and message: the code of the
+// error ID appended to the message, if any, otherwise the generic code of the error type.
+func Code(typ Type, msg string) string {
+	if id, ok := globalerror.IDFromMessage(msg); ok {
+		return id.Code()
+	}
+
+	switch typ {
+	case TypeTimeout:
+		return globalerror.Timeout.Code()
+	case TypeCanceled:
+		return globalerror.Canceled.Code()
+	case TypeExec:
+		return globalerror.Execution.Code()
+	case TypeBadData:
+		return globalerror.BadData.Code()
+	case TypeUnavailable:
+		return globalerror.Unavailable.Code()
+	case TypeNotFound:
+		return globalerror.NotFound.Code()
+	case TypeTooManyRequests:
+		return globalerror.TooManyRequests.Code()
+	case TypeTooLargeEntry:
+		return globalerror.TooLargeEntry.Code()
+	}
+	return globalerror.Internal.Code()
+}
+
+// TypeFromStatusCode returns the type of the errors with the input HTTP status code.
+func TypeFromStatusCode(code int) Type {
+	switch code {
+	case http.StatusBadRequest:
+		return TypeBadData
+	case http.StatusNotFound:
+		return TypeNotFound
+	case http.StatusRequestEntityTooLarge:
+		return TypeTooLargeEntry
+	case http.StatusUnprocessableEntity:
+		return TypeExec
+	case http.StatusTooManyRequests:
+		return TypeTooManyRequests
+	case statusClientClosedRequest:
+		return TypeCanceled
+	case http.StatusServiceUnavailable:
+		return TypeUnavailable
+	case http.StatusGatewayTimeout:
+		return TypeTimeout
+	}
+	if code >= 400 && code < 500 {
+		return TypeBadData
+	}
+	return TypeInternal
+}
+
 // New creates a new apiError with a static string message
 func New(typ Type, msg string) error {
 	return &apiError{
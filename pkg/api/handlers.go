@@ -15,6 +15,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gorilla/mux"
@@ -122,6 +123,59 @@ func indexHandler(httpPathPrefix string, content *IndexPageContent) http.Handler
 	}
 }
 
+//go:embed cluster_status.gohtml
+var clusterStatusPageHTML string
+
+// clusterStatusRefreshInterval is how frequently the cluster status page refreshes its content.
+const clusterStatusRefreshInterval = 15 * time.Second
+
+type clusterStatusRing struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type clusterStatusPageContents struct {
+	Rings             []clusterStatusRing
+	RefreshInterval   time.Duration
+	RefreshIntervalMs int64
+}
+
+// clusterStatusRings are the rings shown in the cluster status page. Their status is fetched through
+// the ring pages API, so rings of components not running in the Mimir deployment are reported as not available.
+var clusterStatusRings = []clusterStatusRing{
+	{Name: "Distributor", Path: "/distributor/ring"},
+	{Name: "Ingester", Path: "/ingester/ring"},
+	{Name: "Store-gateway", Path: "/store-gateway/ring"},
+	{Name: "Compactor", Path: "/compactor/ring"},
+	{Name: "Ruler", Path: "/ruler/ring"},
+	{Name: "Alertmanager", Path: "/multitenant_alertmanager/ring"},
+	{Name: "Query-scheduler", Path: "/query-scheduler/ring"},
+}
+
+// clusterStatusHandler serves a single page aggregating the status of the Mimir components. The page
+// fetches the status from the components APIs, which are expected to be reachable under the same
+// HTTP path prefix (e.g. through a gateway when Mimir is deployed in microservices mode).
+func clusterStatusHandler(httpPathPrefix string) http.HandlerFunc {
+	templ := template.New("cluster_status")
+	templ.Funcs(map[string]interface{}{
+		"AddPathPrefix": func(link string) string {
+			return path.Join(httpPathPrefix, link)
+		},
+	})
+	template.Must(templ.Parse(clusterStatusPageHTML))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := templ.Execute(w, clusterStatusPageContents{
+			Rings:             clusterStatusRings,
+			RefreshInterval:   clusterStatusRefreshInterval,
+			RefreshIntervalMs: clusterStatusRefreshInterval.Milliseconds(),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
 func (cfg *Config) configHandler(actualCfg interface{}, defaultCfg interface{}) http.HandlerFunc {
 	if cfg.CustomConfigHandler != nil {
 		return cfg.CustomConfigHandler(actualCfg, defaultCfg)
@@ -200,6 +254,7 @@ func NewQuerierHandler(
 	reg prometheus.Registerer,
 	logger log.Logger,
 	limits *validation.Overrides,
+	tenantConcurrencyLimiter *querier.TenantConcurrencyLimiter,
 ) http.Handler {
 	// Prometheus histograms for requests to the querier.
 	querierRequestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
@@ -269,6 +324,9 @@ func NewQuerierHandler(
 	}
 	router.Use(instrumentMiddleware.Wrap)
 
+	// Apply the query timeout set by the client, and forwarded by the query-frontend, if any.
+	router.Use(util.QueryTimeoutMiddleware)
+
 	// Define the prefixes for all routes
 	prefix := path.Join(cfg.ServerPrefix, cfg.PrometheusHTTPPrefix)
 
@@ -280,6 +338,7 @@ func NewQuerierHandler(
 	instantQueryStats := usagestats.NewRequestsMiddleware("querier_instant_query_requests")
 	rangeQueryStats := usagestats.NewRequestsMiddleware("querier_range_query_requests")
 	exemplarsQueryStats := usagestats.NewRequestsMiddleware("querier_exemplars_query_requests")
+	queryWithExemplarsStats := usagestats.NewRequestsMiddleware("querier_query_with_exemplars_requests")
 	labelsQueryStats := usagestats.NewRequestsMiddleware("querier_labels_query_requests")
 	seriesQueryStats := usagestats.NewRequestsMiddleware("querier_series_query_requests")
 	metadataQueryStats := usagestats.NewRequestsMiddleware("querier_metadata_query_requests")
@@ -288,9 +347,10 @@ func NewQuerierHandler(
 	// TODO(gotjosh): This custom handler is temporary until we're able to vendor the changes in:
 	// https://github.com/prometheus/prometheus/pull/7125/files
 	router.Path(path.Join(prefix, "/api/v1/read")).Methods("POST").Handler(remoteReadStats.Wrap(querier.RemoteReadHandler(queryable, logger)))
-	router.Path(path.Join(prefix, "/api/v1/query")).Methods("GET", "POST").Handler(instantQueryStats.Wrap(promRouter))
-	router.Path(path.Join(prefix, "/api/v1/query_range")).Methods("GET", "POST").Handler(rangeQueryStats.Wrap(promRouter))
+	router.Path(path.Join(prefix, "/api/v1/query")).Methods("GET", "POST").Handler(instantQueryStats.Wrap(tenantConcurrencyLimiter.Wrap(promRouter)))
+	router.Path(path.Join(prefix, "/api/v1/query_range")).Methods("GET", "POST").Handler(rangeQueryStats.Wrap(tenantConcurrencyLimiter.Wrap(promRouter)))
 	router.Path(path.Join(prefix, "/api/v1/query_exemplars")).Methods("GET", "POST").Handler(exemplarsQueryStats.Wrap(promRouter))
+	router.Path(path.Join(prefix, "/api/v1/query_with_exemplars")).Methods("GET", "POST").Handler(queryWithExemplarsStats.Wrap(tenantConcurrencyLimiter.Wrap(querier.NewQueryWithExemplarsHandler(engine, querier.NewErrorTranslateSampleAndChunkQueryable(queryable), exemplarQueryable))))
 	router.Path(path.Join(prefix, "/api/v1/labels")).Methods("GET", "POST").Handler(labelsQueryStats.Wrap(promRouter))
 	router.Path(path.Join(prefix, "/api/v1/label/{name}/values")).Methods("GET").Handler(labelsQueryStats.Wrap(promRouter))
 	router.Path(path.Join(prefix, "/api/v1/series")).Methods("GET", "POST", "DELETE").Handler(seriesQueryStats.Wrap(promRouter))
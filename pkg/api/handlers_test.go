@@ -66,6 +66,28 @@ func TestIndexPageContent(t *testing.T) {
 	require.False(t, strings.Contains(resp.Body.String(), "/compactor/ring"))
 }
 
+func TestClusterStatusHandler(t *testing.T) {
+	for _, tc := range []struct {
+		prefix    string
+		toBeFound string
+	}{
+		{prefix: "", toBeFound: `const pathPrefix = "/".replace(`},
+		{prefix: "/test", toBeFound: `const pathPrefix = "/test".replace(`},
+	} {
+		h := clusterStatusHandler(tc.prefix)
+
+		req := httptest.NewRequest("GET", "/cluster-status", nil)
+		resp := httptest.NewRecorder()
+
+		h.ServeHTTP(resp, req)
+
+		require.Equal(t, 200, resp.Code)
+		require.Contains(t, resp.Body.String(), tc.toBeFound)
+		require.Contains(t, resp.Body.String(), `{"name":"Ingester","path":"/ingester/ring"}`)
+		require.Contains(t, resp.Body.String(), "const refreshIntervalMs =  15000 ;")
+	}
+}
+
 type diffConfigMock struct {
 	MyInt          int          `yaml:"my_int"`
 	MyFloat        float64      `yaml:"my_float"`
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+// rewriteBlockWithDeletions downloads the block described by meta, deletes the series matching the input deletion
+// requests via tombstones, and writes a new block without them. The new block is uploaded to the bucket, and the
+// original block is marked for deletion. If all series of the block have been deleted, no new block is uploaded and
+// an empty ULID is returned.
+func rewriteBlockWithDeletions(ctx context.Context, logger log.Logger, bkt objstore.Bucket, compactor Compactor, workDir string, meta *metadata.Meta, deletions []metadata.DeletionRequest, blocksMarkedForDeletion prometheus.Counter) (_ ulid.ULID, rerr error) {
+	logger = log.With(logger, "block", meta.ULID)
+
+	tmpDir, err := os.MkdirTemp(workDir, "rewrite-"+meta.ULID.String()+"-")
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "create rewrite dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove rewrite dir", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	srcDir := filepath.Join(tmpDir, meta.ULID.String())
+	if err := block.Download(ctx, logger, bkt, meta.ULID, srcDir); err != nil {
+		return ulid.ULID{}, errors.Wrapf(err, "download block %s", meta.ULID)
+	}
+
+	src, err := tsdb.OpenBlock(logger, srcDir, nil)
+	if err != nil {
+		return ulid.ULID{}, errors.Wrapf(err, "open block %s", meta.ULID)
+	}
+	defer func() {
+		if err := src.Close(); err != nil && rerr == nil {
+			rerr = errors.Wrapf(err, "close block %s", meta.ULID)
+		}
+	}()
+
+	for _, d := range deletions {
+		intervals := d.Intervals
+		if len(intervals) == 0 {
+			intervals = tombstones.Intervals{{Mint: math.MinInt64, Maxt: math.MaxInt64}}
+		}
+
+		for _, iv := range intervals {
+			if err := src.Delete(iv.Mint, iv.Maxt, d.Matchers...); err != nil {
+				return ulid.ULID{}, errors.Wrapf(err, "delete series from block %s", meta.ULID)
+			}
+		}
+	}
+
+	begin := time.Now()
+	outDir := filepath.Join(tmpDir, "out")
+	if err := os.MkdirAll(outDir, 0750); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "create output dir")
+	}
+
+	newID, err := compactor.Write(outDir, src, meta.MinTime, meta.MaxTime, &meta.BlockMeta)
+	if err != nil {
+		return ulid.ULID{}, errors.Wrapf(err, "write rewritten block for %s", meta.ULID)
+	}
+
+	if newID != (ulid.ULID{}) {
+		newDir := filepath.Join(outDir, newID.String())
+
+		if err := writeRewrittenExemplars(srcDir, newDir, deletions); err != nil {
+			return ulid.ULID{}, errors.Wrapf(err, "rewrite exemplars of block %s", meta.ULID)
+		}
+
+		// The rewritten block keeps the same compaction metadata and external labels of the original block,
+		// so that it's compacted exactly like the original block would have been.
+		thanos := meta.Thanos
+		thanos.Source = metadata.CompactorSource
+		thanos.SegmentFiles = block.GetSegmentFiles(newDir)
+		thanos.Files = nil
+		thanos.Rewrites = append(append([]metadata.Rewrite(nil), meta.Thanos.Rewrites...), metadata.Rewrite{
+			Sources:          meta.Compaction.Sources,
+			DeletionsApplied: deletions,
+		})
+
+		newMeta, err := metadata.InjectThanos(logger, newDir, thanos, &meta.BlockMeta)
+		if err != nil {
+			return ulid.ULID{}, errors.Wrapf(err, "failed to finalize the block %s", newDir)
+		}
+
+		if err := os.Remove(filepath.Join(newDir, "tombstones")); err != nil {
+			return ulid.ULID{}, errors.Wrap(err, "remove tombstones")
+		}
+
+		if err := block.VerifyIndex(logger, filepath.Join(newDir, block.IndexFilename), newMeta.MinTime, newMeta.MaxTime); err != nil {
+			return ulid.ULID{}, errors.Wrapf(err, "invalid rewritten block %s", newDir)
+		}
+
+		if err := block.Upload(ctx, logger, bkt, newDir, nil); err != nil {
+			return ulid.ULID{}, errors.Wrapf(err, "upload of %s failed", newID)
+		}
+	}
+
+	level.Info(logger).Log("msg", "rewritten block", "new_block", newID, "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
+
+	// Spawn a new context so we always mark a block for deletion in full on shutdown.
+	delCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := block.MarkForDeletion(delCtx, logger, bkt, meta.ULID, "source of rewritten block", blocksMarkedForDeletion); err != nil {
+		return ulid.ULID{}, errors.Wrapf(err, "mark block %s for deletion from bucket", meta.ULID)
+	}
+
+	return newID, nil
+}
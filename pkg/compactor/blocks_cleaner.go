@@ -38,11 +38,13 @@ const (
 )
 
 type BlocksCleanerConfig struct {
-	DeletionDelay           time.Duration
-	CleanupInterval         time.Duration
-	CleanupConcurrency      int
-	TenantCleanupDelay      time.Duration // Delay before removing tenant deletion mark and "debug".
-	DeleteBlocksConcurrency int
+	DeletionDelay              time.Duration
+	CleanupInterval            time.Duration
+	CleanupConcurrency         int
+	TenantCleanupDelay         time.Duration // Delay before removing tenant deletion mark and "debug".
+	DeleteBlocksConcurrency    int
+	ReplacedBlocksHintsEnabled bool // Whether to track the blocks replaced by the compacted blocks in the bucket index.
+	BucketIndexMaxDeltas       int  // Max number of bucket index deltas before merging them into the full index. 0 to disable the incremental updates.
 }
 
 type BlocksCleaner struct {
@@ -343,6 +345,12 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 		level.Info(userLogger).Log("msg", "deleted files under "+block.DebugMetas+" for tenant marked for deletion", "count", deleted)
 	}
 
+	if deleted, err := bucket.DeletePrefix(ctx, userBucket, mimir_tsdb.TombstonesPath, userLogger); err != nil {
+		return errors.Wrap(err, "failed to delete tombstones")
+	} else if deleted > 0 {
+		level.Info(userLogger).Log("msg", "deleted tombstones for tenant marked for deletion", "count", deleted)
+	}
+
 	// Tenant deletion mark file is inside Markers as well.
 	if deleted, err := bucket.DeletePrefix(ctx, userBucket, bucketindex.MarkersPathname, userLogger); err != nil {
 		return errors.Wrap(err, "failed to delete marker files")
@@ -368,7 +376,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 	}()
 
 	// Read the bucket index.
-	idx, err := bucketindex.ReadIndex(ctx, c.bucketClient, userID, c.cfgProvider, c.logger)
+	oldIdx, err := bucketindex.ReadIndex(ctx, c.bucketClient, userID, c.cfgProvider, c.logger)
 	if errors.Is(err, bucketindex.ErrIndexCorrupted) {
 		level.Warn(userLogger).Log("msg", "found a corrupted bucket index, recreating it")
 	} else if err != nil && !errors.Is(err, bucketindex.ErrIndexNotFound) {
@@ -379,16 +387,16 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 	// Note doing this before UpdateIndex, so it reads in the deletion marks.
 	// The trade-off being that retention is not applied if the index has to be
 	// built, but this is rare.
-	if idx != nil {
+	if oldIdx != nil {
 		// We do not want to stop the remaining work in the cleaner if an
 		// error occurs here. Errors are logged in the function.
 		retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
-		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger)
+		c.applyUserRetentionPeriod(ctx, oldIdx, retention, userBucket, userLogger)
 	}
 
 	// Generate an updated in-memory version of the bucket index.
-	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.logger)
-	idx, partials, err := w.UpdateIndex(ctx, idx)
+	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.logger).WithReplacedBlocksHints(c.cfg.ReplacedBlocksHintsEnabled)
+	idx, partials, err := w.UpdateIndex(ctx, oldIdx)
 	if err != nil {
 		return err
 	}
@@ -410,8 +418,21 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 	}
 
 	// Upload the updated index to the storage.
-	if err := bucketindex.WriteIndex(ctx, c.bucketClient, userID, c.cfgProvider, idx); err != nil {
-		return err
+	if c.cfg.BucketIndexMaxDeltas > 0 {
+		if err := bucketindex.WriteIndexIncrementally(ctx, c.bucketClient, userID, c.cfgProvider, oldIdx, idx, c.cfg.BucketIndexMaxDeltas, userLogger); err != nil {
+			return err
+		}
+	} else {
+		if err := bucketindex.WriteIndex(ctx, c.bucketClient, userID, c.cfgProvider, idx); err != nil {
+			return err
+		}
+
+		// The index deltas are no longer read once the full index isn't incremental anymore.
+		if oldIdx != nil && oldIdx.DeltasEnabled {
+			if err := bucketindex.DeleteIndexDeltas(ctx, c.bucketClient, userID, c.cfgProvider); err != nil {
+				level.Warn(userLogger).Log("msg", "failed to delete the bucket index deltas", "err", err)
+			}
+		}
 	}
 
 	c.tenantBlocks.WithLabelValues(userID).Set(float64(len(idx.Blocks)))
@@ -35,6 +35,7 @@ import (
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/test"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 type testBlocksCleanerOptions struct {
@@ -98,6 +99,8 @@ func testBlocksCleanerWithOptions(t *testing.T, options testBlocksCleanerOptions
 	require.NoError(t, tsdb.WriteTenantDeletionMark(context.Background(), bucketClient, "user-4", nil, user4Mark))
 	user4DebugMetaFile := path.Join("user-4", block.DebugMetas, "meta.json")
 	require.NoError(t, bucketClient.Upload(context.Background(), user4DebugMetaFile, strings.NewReader("some random content here")))
+	user4Tombstone := tsdb.NewTombstone(time.Now(), 10, 20, []string{`{job="a"}`})
+	require.NoError(t, tsdb.WriteTombstone(context.Background(), bucket.NewUserBucketClient("user-4", bucketClient, nil), user4Tombstone))
 
 	cfg := BlocksCleanerConfig{
 		DeletionDelay:           deletionDelay,
@@ -146,6 +149,7 @@ func testBlocksCleanerWithOptions(t *testing.T, options testBlocksCleanerOptions
 		// User-4 is removed fully.
 		{path: path.Join("user-4", tsdb.TenantDeletionMarkPath), expectedExists: options.user4FilesExist},
 		{path: path.Join("user-4", block.DebugMetas, "meta.json"), expectedExists: options.user4FilesExist},
+		{path: path.Join("user-4", tsdb.TombstoneFilepath(user4Tombstone.RequestID)), expectedExists: options.user4FilesExist},
 	} {
 		exists, err := bucketClient.Exists(ctx, tc.path)
 		require.NoError(t, err)
@@ -335,6 +339,67 @@ func TestBlocksCleaner_ShouldRebuildBucketIndexOnCorruptedOne(t *testing.T) {
 	assert.ElementsMatch(t, []ulid.ULID{block3}, idx.BlockDeletionMarks.GetULIDs())
 }
 
+func TestBlocksCleaner_ShouldUpdateBucketIndexIncrementally(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	cfgProvider := newMockConfigProvider()
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           time.Hour,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+		BucketIndexMaxDeltas:    1,
+	}
+
+	listDeltas := func() (names []string) {
+		require.NoError(t, bucketClient.Iter(ctx, path.Join(userID, bucketindex.IndexDeltasPathname)+"/", func(name string) error {
+			names = append(names, name)
+			return nil
+		}))
+		return names
+	}
+
+	block1 := createTSDBBlock(t, bucketClient, userID, 10, 20, 2, nil)
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, logger, nil)
+
+	// The first update should write the full index, and the next one a delta.
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.Empty(t, listDeltas())
+
+	block2 := createTSDBBlock(t, bucketClient, userID, 20, 30, 2, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.Len(t, listDeltas(), 1)
+
+	idx, err := bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block1, block2}, idx.Blocks.GetULIDs())
+
+	// Once the max deltas are reached, the deltas should be merged into the full index.
+	block3 := createTSDBBlock(t, bucketClient, userID, 30, 40, 2, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.Empty(t, listDeltas())
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.Len(t, listDeltas(), 1)
+
+	// Once the incremental updates are disabled, the deltas should be deleted.
+	cfg.BucketIndexMaxDeltas = 0
+	cleaner = NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, logger, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.Empty(t, listDeltas())
+
+	idx, err = bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	assert.False(t, idx.DeltasEnabled)
+	assert.ElementsMatch(t, []ulid.ULID{block1, block2, block3}, idx.Blocks.GetULIDs())
+}
+
 func TestBlocksCleaner_ShouldRemoveMetricsForTenantsNotBelongingAnymoreToTheShard(t *testing.T) {
 	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
 	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
@@ -957,6 +1022,7 @@ type mockConfigProvider struct {
 	blockUploadEnabled           map[string]bool
 	userPartialBlockDelay        map[string]time.Duration
 	userPartialBlockDelayInvalid map[string]bool
+	seriesRetention              map[string]validation.SeriesRetentionRules
 }
 
 func newMockConfigProvider() *mockConfigProvider {
@@ -967,6 +1033,7 @@ func newMockConfigProvider() *mockConfigProvider {
 		blockUploadEnabled:           make(map[string]bool),
 		userPartialBlockDelay:        make(map[string]time.Duration),
 		userPartialBlockDelayInvalid: make(map[string]bool),
+		seriesRetention:              make(map[string]validation.SeriesRetentionRules),
 	}
 }
 
@@ -1006,6 +1073,10 @@ func (m *mockConfigProvider) CompactorPartialBlockDeletionDelay(user string) (ti
 	return m.userPartialBlockDelay[user], !m.userPartialBlockDelayInvalid[user]
 }
 
+func (m *mockConfigProvider) CompactorSeriesRetention(user string) validation.SeriesRetentionRules {
+	return m.seriesRetention[user]
+}
+
 func (m *mockConfigProvider) S3SSEType(user string) string {
 	return ""
 }
@@ -378,6 +378,10 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	elapsed = time.Since(compactionBegin)
 	level.Info(jobLogger).Log("msg", "compacted blocks", "new", fmt.Sprintf("%v", compIDs), "blocks", fmt.Sprintf("%v", blocksToCompactDirs), "duration", elapsed, "duration_ms", elapsed.Milliseconds())
 
+	if err := writeCompactedExemplars(subDir, blocksToCompactDirs, compIDs); err != nil {
+		return false, nil, errors.Wrapf(err, "compact exemplars of blocks %v", blocksToCompactDirs)
+	}
+
 	uploadBegin := time.Now()
 	uploadedBlocks := atomic.NewInt64(0)
 
@@ -689,7 +693,14 @@ func NewBucketCompactor(
 
 // Compact runs compaction over bucket.
 // If maxCompactionTime is positive then after this time no more new compactions are started.
-func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Duration) (rerr error) {
+func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Duration) error {
+	_, err := c.compact(ctx, maxCompactionTime)
+	return err
+}
+
+// compact runs compaction over bucket and returns the jobs which have been planned but not
+// started because maxCompactionTime has been reached.
+func (c *BucketCompactor) compact(ctx context.Context, maxCompactionTime time.Duration) (pendingJobs []*Job, rerr error) {
 	defer func() {
 		// Do not remove the compactDir if an error has occurred
 		// because potentially on the next run we would not have to download
@@ -790,26 +801,26 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 
 		level.Info(c.logger).Log("msg", "start sync of metas")
 		if err := c.sy.SyncMetas(ctx); err != nil {
-			return errors.Wrap(err, "sync")
+			return nil, errors.Wrap(err, "sync")
 		}
 
 		level.Info(c.logger).Log("msg", "start of GC")
 		// Blocks that were compacted are garbage collected after each Compaction.
 		// However if compactor crashes we need to resolve those on startup.
 		if err := c.sy.GarbageCollect(ctx); err != nil {
-			return errors.Wrap(err, "garbage")
+			return nil, errors.Wrap(err, "garbage")
 		}
 
 		jobs, err := c.grouper.Groups(c.sy.Metas())
 		if err != nil {
-			return errors.Wrap(err, "build compaction jobs")
+			return nil, errors.Wrap(err, "build compaction jobs")
 		}
 
 		// There is another check just before we start processing the job, but we can avoid sending it
 		// to the goroutine in the first place.
 		jobs, err = c.filterOwnJobs(jobs)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Record the difference between now and the max time for a block being compacted. This
@@ -840,7 +851,7 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		// Send all jobs found during this pass to the compaction workers.
 		var jobErrs multierror.MultiError
 	jobLoop:
-		for _, g := range jobs {
+		for idx, g := range jobs {
 			select {
 			case jobErr := <-errChan:
 				jobErrs.Add(jobErr)
@@ -848,6 +859,7 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 			case jobChan <- g:
 			case <-maxCompactionTimeChan:
 				maxCompactionTimeReached = true
+				pendingJobs = jobs[idx:]
 				level.Info(c.logger).Log("msg", "max compaction time reached, no more compactions will be started")
 				break jobLoop
 			}
@@ -864,7 +876,7 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 
 		workCtxCancel()
 		if len(jobErrs) > 0 {
-			return jobErrs.Err()
+			return nil, jobErrs.Err()
 		}
 
 		if maxCompactionTimeReached || finishedAllJobs {
@@ -872,7 +884,7 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		}
 	}
 	level.Info(c.logger).Log("msg", "compaction iterations done")
-	return nil
+	return pendingJobs, nil
 }
 
 // blockMaxTimeDeltas returns a slice of the difference between now and the MaxTime of each
@@ -34,6 +34,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -52,6 +53,8 @@ var (
 	errInvalidMaxOpeningBlocksConcurrency = fmt.Errorf("invalid max-opening-blocks-concurrency value, must be positive")
 	errInvalidMaxClosingBlocksConcurrency = fmt.Errorf("invalid max-closing-blocks-concurrency value, must be positive")
 	errInvalidSymbolFlushersConcurrency   = fmt.Errorf("invalid symbols-flushers-concurrency value, must be positive")
+	errInvalidTenantsSchedulingPolicy     = fmt.Errorf("unsupported tenants scheduling policy (supported values: %s)", strings.Join(TenantsSchedulingPolicies, ", "))
+	errInvalidTenantsSchedulingTimeSlice  = fmt.Errorf("invalid tenants-scheduling-time-slice value, must be positive when the interleaved tenants scheduling policy is used")
 	RingOp                                = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
 )
 
@@ -102,6 +105,18 @@ type Config struct {
 
 	CompactionJobsOrder string `yaml:"compaction_jobs_order" category:"advanced"`
 
+	// Tenants scheduling options.
+	TenantsSchedulingPolicy    string        `yaml:"tenants_scheduling_policy" category:"experimental"`
+	TenantsSchedulingTimeSlice time.Duration `yaml:"tenants_scheduling_time_slice" category:"experimental"`
+
+	// Series deletion options.
+	SeriesDeletionEnabled       bool          `yaml:"series_deletion_enabled" category:"experimental"`
+	SeriesDeletionPendingPeriod time.Duration `yaml:"series_deletion_pending_period" category:"experimental"`
+
+	// Store-gateways pre-warm hints.
+	ReplacedBlocksHintsEnabled bool `yaml:"replaced_blocks_hints_enabled" category:"experimental"`
+	BucketIndexMaxDeltas       int  `yaml:"bucket_index_max_deltas" category:"experimental"`
+
 	// No need to add options to customize the retry backoff,
 	// given the defaults should be fine, but allow to override
 	// it in tests.
@@ -133,6 +148,12 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.DurationVar(&cfg.CleanupInterval, "compactor.cleanup-interval", 15*time.Minute, "How frequently compactor should run blocks cleanup and maintenance, as well as update the bucket index.")
 	f.IntVar(&cfg.CleanupConcurrency, "compactor.cleanup-concurrency", 20, "Max number of tenants for which blocks cleanup and maintenance should run concurrently.")
 	f.StringVar(&cfg.CompactionJobsOrder, "compactor.compaction-jobs-order", CompactionOrderOldestFirst, fmt.Sprintf("The sorting to use when deciding which compaction jobs should run first for a given tenant. Supported values are: %s.", strings.Join(CompactionOrders, ", ")))
+	f.StringVar(&cfg.TenantsSchedulingPolicy, "compactor.tenants-scheduling-policy", TenantsSchedulingSequential, fmt.Sprintf("The policy used to schedule compaction of different tenants. With %q each tenant is compacted until there is no work left or max compaction time is reached, before moving to the next one. With %q tenants are compacted in rounds, each tenant being given up to the configured time slice per round, and tenants with the oldest uncompacted blocks are compacted first. Supported values are: %s.", TenantsSchedulingSequential, TenantsSchedulingInterleaved, strings.Join(TenantsSchedulingPolicies, ", ")))
+	f.DurationVar(&cfg.TenantsSchedulingTimeSlice, "compactor.tenants-scheduling-time-slice", 10*time.Minute, fmt.Sprintf("Max time for starting compactions for a single tenant in each round, when the %q tenants scheduling policy is used. The overall time spent compacting a tenant is still bounded by -compactor.max-compaction-time.", TenantsSchedulingInterleaved))
+	f.BoolVar(&cfg.SeriesDeletionEnabled, "compactor.series-deletion-enabled", false, "Enable the API to delete series, and the deletion of the requested series from the blocks by the compactor.")
+	f.DurationVar(&cfg.SeriesDeletionPendingPeriod, "compactor.series-deletion-pending-period", 24*time.Hour, "How long after the end of the time range of a series deletion request the compactor keeps looking for the requested series in the blocks, including the blocks uploaded by ingesters after the request. The request is processed once this period is elapsed and the requested series have been deleted from all blocks. It should be greater than the time it takes for the ingested samples to be uploaded to the storage.")
+	f.BoolVar(&cfg.ReplacedBlocksHintsEnabled, "compactor.replaced-blocks-hints-enabled", false, "Track in the bucket index the source blocks of each compacted block until they're deleted from the storage, so that the store-gateways keep the index-header of the compacted blocks loaded before the queriers switch to them.")
+	f.IntVar(&cfg.BucketIndexMaxDeltas, "compactor.bucket-index-max-deltas", 0, "Update the bucket index incrementally, uploading only the changes since the previous update as an index delta, which readers apply on top of the full index. The deltas are merged into a new full index once they are more than this number. 0 to disable and always upload the full bucket index.")
 	f.DurationVar(&cfg.DeletionDelay, "compactor.deletion-delay", 12*time.Hour, "Time before a block marked for deletion is deleted from bucket. "+
 		"If not 0, blocks will be marked for deletion and compactor component will permanently delete blocks marked for deletion from the bucket. "+
 		"If 0, blocks will be deleted straight away. Note that deleting blocks immediately can cause query failures.")
@@ -168,6 +189,13 @@ func (cfg *Config) Validate() error {
 		return errInvalidCompactionOrder
 	}
 
+	if !util.StringsContain(TenantsSchedulingPolicies, cfg.TenantsSchedulingPolicy) {
+		return errInvalidTenantsSchedulingPolicy
+	}
+	if cfg.TenantsSchedulingPolicy == TenantsSchedulingInterleaved && cfg.TenantsSchedulingTimeSlice <= 0 {
+		return errInvalidTenantsSchedulingTimeSlice
+	}
+
 	return nil
 }
 
@@ -195,6 +223,9 @@ type ConfigProvider interface {
 
 	// CompactorBlockUploadEnabled returns whether block upload is enabled for a given tenant.
 	CompactorBlockUploadEnabled(tenantID string) bool
+
+	// CompactorSeriesRetention returns the per-selector series retention periods for a given user.
+	CompactorSeriesRetention(userID string) validation.SeriesRetentionRules
 }
 
 // MultitenantCompactor is a multi-tenant TSDB blocks compactor based on Thanos.
@@ -233,6 +264,18 @@ type MultitenantCompactor struct {
 	shardingStrategy shardingStrategy
 	jobsOrder        JobsOrderFunc
 
+	// Compaction backlog of each tenant, used to prioritize tenants when the interleaved
+	// scheduling policy is used. Only accessed by the compaction loop.
+	tenantsBacklog tenantsBacklog
+
+	// Blocks checked for each tenant and found not containing series matching a series retention
+	// selector. Only accessed by the compaction loop.
+	seriesRetentionChecked map[string]map[seriesRetentionCheck]struct{}
+
+	// Blocks checked for each tenant and found not containing samples to delete for a tombstone.
+	// Only accessed by the compaction loop.
+	seriesDeletionChecked map[string]map[seriesDeletionCheck]struct{}
+
 	// Metrics.
 	compactionRunsStarted          prometheus.Counter
 	compactionRunsCompleted        prometheus.Counter
@@ -243,8 +286,18 @@ type MultitenantCompactor struct {
 	compactionRunSucceededTenants  prometheus.Gauge
 	compactionRunFailedTenants     prometheus.Gauge
 	compactionRunInterval          prometheus.Gauge
+	compactionOldestBlockAge       *prometheus.GaugeVec
 	blocksMarkedForDeletion        prometheus.Counter
 
+	seriesRetentionBlocksRewritten         prometheus.Counter
+	seriesRetentionBlocksFailed            prometheus.Counter
+	seriesRetentionBlocksMarkedForDeletion prometheus.Counter
+
+	seriesDeletionBlocksRewritten         prometheus.Counter
+	seriesDeletionBlocksFailed            prometheus.Counter
+	seriesDeletionBlocksMarkedForDeletion prometheus.Counter
+	seriesDeletionRequestsProcessed       prometheus.Counter
+
 	// Metrics shared across all BucketCompactor instances.
 	bucketCompactorMetrics *BucketCompactorMetrics
 
@@ -255,7 +308,12 @@ type MultitenantCompactor struct {
 // NewMultitenantCompactor makes a new MultitenantCompactor.
 func NewMultitenantCompactor(compactorCfg Config, storageCfg mimir_tsdb.BlocksStorageConfig, cfgProvider ConfigProvider, logger log.Logger, registerer prometheus.Registerer) (*MultitenantCompactor, error) {
 	bucketClientFactory := func(ctx context.Context) (objstore.Bucket, error) {
-		return bucket.NewClient(ctx, storageCfg.Bucket, "compactor", logger, registerer)
+		bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "compactor", logger, registerer)
+		if err != nil {
+			return nil, err
+		}
+
+		return mimir_tsdb.CreateLocalCachingBucket(storageCfg.LocalCache, bucketClient, logger, prometheus.WrapRegistererWith(prometheus.Labels{"component": "compactor"}, registerer))
 	}
 
 	// Configure the compactor and grouper factories.
@@ -297,6 +355,9 @@ func newMultitenantCompactor(
 		bucketClientFactory:    bucketClientFactory,
 		blocksGrouperFactory:   blocksGrouperFactory,
 		blocksCompactorFactory: blocksCompactorFactory,
+		tenantsBacklog:         tenantsBacklog{},
+		seriesRetentionChecked: map[string]map[seriesRetentionCheck]struct{}{},
+		seriesDeletionChecked:  map[string]map[seriesDeletionCheck]struct{}{},
 
 		compactionRunsStarted: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_compactor_runs_started_total",
@@ -334,11 +395,45 @@ func newMultitenantCompactor(
 			Name: "cortex_compactor_compaction_interval_seconds",
 			Help: "The configured interval on which compaction is run in seconds. Useful when compared to the last successful run metric to accurately detect multiple failed compaction runs.",
 		}),
+		compactionOldestBlockAge: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_compactor_tenant_oldest_uncompacted_block_age_seconds",
+			Help: "Difference between now and the max time of the oldest block waiting to be compacted, for each tenant owned by this compactor, as of the last compaction of the tenant. 0 if the tenant has no compaction backlog.",
+		}, []string{"user"}),
 		blocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name:        blocksMarkedForDeletionName,
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "compaction"},
 		}),
+		seriesRetentionBlocksRewritten: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_retention_blocks_rewritten_total",
+			Help: "Total number of blocks rewritten to delete series older than the per-tenant series retention.",
+		}),
+		seriesRetentionBlocksFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_retention_blocks_failed_total",
+			Help: "Total number of blocks which failed to be rewritten to delete series older than the per-tenant series retention.",
+		}),
+		seriesRetentionBlocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "series-retention"},
+		}),
+		seriesDeletionBlocksRewritten: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_blocks_rewritten_total",
+			Help: "Total number of blocks rewritten to delete the series requested through the series deletion API.",
+		}),
+		seriesDeletionBlocksFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_blocks_failed_total",
+			Help: "Total number of blocks which failed to be rewritten to delete the series requested through the series deletion API.",
+		}),
+		seriesDeletionBlocksMarkedForDeletion: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "series-deletion"},
+		}),
+		seriesDeletionRequestsProcessed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_deletion_requests_processed_total",
+			Help: "Total number of series deletion requests whose series have been deleted from all blocks.",
+		}),
 	}
 
 	c.bucketCompactorMetrics = NewBucketCompactorMetrics(c.blocksMarkedForDeletion, registerer)
@@ -442,11 +537,13 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 
 	// Create the blocks cleaner (service).
 	c.blocksCleaner = NewBlocksCleaner(BlocksCleanerConfig{
-		DeletionDelay:           c.compactorCfg.DeletionDelay,
-		CleanupInterval:         util.DurationWithJitter(c.compactorCfg.CleanupInterval, 0.1),
-		CleanupConcurrency:      c.compactorCfg.CleanupConcurrency,
-		TenantCleanupDelay:      c.compactorCfg.TenantCleanupDelay,
-		DeleteBlocksConcurrency: defaultDeleteBlocksConcurrency,
+		DeletionDelay:              c.compactorCfg.DeletionDelay,
+		CleanupInterval:            util.DurationWithJitter(c.compactorCfg.CleanupInterval, 0.1),
+		CleanupConcurrency:         c.compactorCfg.CleanupConcurrency,
+		TenantCleanupDelay:         c.compactorCfg.TenantCleanupDelay,
+		DeleteBlocksConcurrency:    defaultDeleteBlocksConcurrency,
+		ReplacedBlocksHintsEnabled: c.compactorCfg.ReplacedBlocksHintsEnabled,
+		BucketIndexMaxDeltas:       c.compactorCfg.BucketIndexMaxDeltas,
 	}, c.bucketClient, c.shardingStrategy.blocksCleanerOwnUser, c.cfgProvider, c.parentLogger, c.registerer)
 
 	// Start blocks cleaner asynchronously, don't wait until initial cleanup is finished.
@@ -527,6 +624,7 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 
 	// Keep track of users owned by this shard, so that we can delete the local files for all other users.
 	ownedUsers := map[string]struct{}{}
+	usersToCompact := make([]string, 0, len(users))
 	for _, userID := range users {
 		// Ensure the context has not been canceled (ie. compactor shutdown has been triggered).
 		if ctx.Err() != nil {
@@ -557,22 +655,41 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 			continue
 		}
 
-		level.Info(c.logger).Log("msg", "starting compaction of user blocks", "user", userID)
+		usersToCompact = append(usersToCompact, userID)
+	}
 
-		if err = c.compactUserWithRetries(ctx, userID); err != nil {
-			c.compactionRunFailedTenants.Inc()
-			compactionErrorCount++
-			level.Error(c.logger).Log("msg", "failed to compact user blocks", "user", userID, "err", err)
-			continue
-		}
+	if c.compactorCfg.TenantsSchedulingPolicy == TenantsSchedulingInterleaved {
+		compactionErrorCount = c.compactUsersInterleaved(ctx, usersToCompact)
+	} else {
+		compactionErrorCount = c.compactUsersSequentially(ctx, usersToCompact)
+	}
 
-		c.compactionRunSucceededTenants.Inc()
-		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
+	// Ensure the context has not been canceled (ie. compactor shutdown has been triggered).
+	if ctx.Err() != nil {
+		level.Info(c.logger).Log("msg", "interrupting compaction of user blocks", "err", ctx.Err())
+		return
 	}
 
 	// Delete local files for unowned tenants, if there are any. This cleans up
 	// leftover local files for tenants that belong to different compactors now,
 	// or have been deleted completely.
+	for userID := range c.tenantsBacklog {
+		if _, owned := ownedUsers[userID]; !owned {
+			delete(c.tenantsBacklog, userID)
+			c.compactionOldestBlockAge.DeleteLabelValues(userID)
+		}
+	}
+	for userID := range c.seriesRetentionChecked {
+		if _, owned := ownedUsers[userID]; !owned {
+			delete(c.seriesRetentionChecked, userID)
+		}
+	}
+	for userID := range c.seriesDeletionChecked {
+		if _, owned := ownedUsers[userID]; !owned {
+			delete(c.seriesDeletionChecked, userID)
+		}
+	}
+
 	for userID := range c.listTenantsWithMetaSyncDirectories() {
 		if _, owned := ownedUsers[userID]; owned {
 			continue
@@ -600,7 +717,87 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 	succeeded = true
 }
 
-func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string) error {
+// compactUsersSequentially compacts each user until there's no work left or the max compaction
+// time is reached, before moving to the next one. Returns the number of users failed to compact.
+func (c *MultitenantCompactor) compactUsersSequentially(ctx context.Context, users []string) (failed int) {
+	for _, userID := range users {
+		// Ensure the context has not been canceled (ie. compactor shutdown has been triggered).
+		if ctx.Err() != nil {
+			return failed
+		}
+
+		level.Info(c.logger).Log("msg", "starting compaction of user blocks", "user", userID)
+
+		if _, err := c.compactUserWithRetries(ctx, userID, c.compactorCfg.MaxCompactionTime); err != nil {
+			c.compactionRunFailedTenants.Inc()
+			failed++
+			level.Error(c.logger).Log("msg", "failed to compact user blocks", "user", userID, "err", err)
+			continue
+		}
+
+		c.compactionRunSucceededTenants.Inc()
+		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
+	}
+
+	return failed
+}
+
+// compactUsersInterleaved compacts users in rounds. In each round, every user with compaction work left
+// is compacted for up to the configured time slice, and users with the oldest uncompacted blocks go first.
+// Rounds are repeated until no user has work left or has exhausted its max compaction time.
+// Returns the number of users failed to compact.
+func (c *MultitenantCompactor) compactUsersInterleaved(ctx context.Context, users []string) (failed int) {
+	spent := make(map[string]time.Duration, len(users))
+	pending := append([]string(nil), users...)
+
+	for round := 1; len(pending) > 0; round++ {
+		c.tenantsBacklog.sortByPriority(pending)
+		level.Info(c.logger).Log("msg", "starting compaction round", "round", round, "users", len(pending))
+
+		next := pending[:0]
+		for _, userID := range pending {
+			// Ensure the context has not been canceled (ie. compactor shutdown has been triggered).
+			if ctx.Err() != nil {
+				return failed
+			}
+
+			timeSlice := c.compactorCfg.TenantsSchedulingTimeSlice
+			if maxTime := c.compactorCfg.MaxCompactionTime; maxTime > 0 && maxTime-spent[userID] < timeSlice {
+				timeSlice = maxTime - spent[userID]
+			}
+
+			level.Info(c.logger).Log("msg", "starting compaction of user blocks", "user", userID, "round", round, "time_slice", timeSlice)
+
+			start := time.Now()
+			moreWork, err := c.compactUserWithRetries(ctx, userID, timeSlice)
+			spent[userID] += time.Since(start)
+
+			if err != nil {
+				c.compactionRunFailedTenants.Inc()
+				failed++
+				level.Error(c.logger).Log("msg", "failed to compact user blocks", "user", userID, "err", err)
+				continue
+			}
+
+			if maxTime := c.compactorCfg.MaxCompactionTime; moreWork && (maxTime <= 0 || spent[userID] < maxTime) {
+				level.Info(c.logger).Log("msg", "compaction time slice for user reached, compaction will resume in the next round", "user", userID, "oldest_uncompacted_block_age", c.tenantsBacklog[userID])
+				next = append(next, userID)
+				continue
+			}
+
+			c.compactionRunSucceededTenants.Inc()
+			level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
+		}
+
+		pending = next
+	}
+
+	return failed
+}
+
+// compactUserWithRetries compacts the user's blocks, retrying on failure, and returns whether there are
+// compaction jobs left because maxCompactionTime has been reached.
+func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string, maxCompactionTime time.Duration) (bool, error) {
 	var lastErr error
 
 	retries := backoff.New(ctx, backoff.Config{
@@ -610,18 +807,22 @@ func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userI
 	})
 
 	for retries.Ongoing() {
-		lastErr = c.compactUser(ctx, userID)
+		var pendingJobs []*Job
+
+		pendingJobs, lastErr = c.compactUser(ctx, userID, maxCompactionTime)
 		if lastErr == nil {
-			return nil
+			age := c.tenantsBacklog.update(userID, time.Now(), pendingJobs)
+			c.compactionOldestBlockAge.WithLabelValues(userID).Set(age.Seconds())
+			return len(pendingJobs) > 0, nil
 		}
 
 		retries.Wait()
 	}
 
-	return lastErr
+	return false, lastErr
 }
 
-func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) error {
+func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string, maxCompactionTime time.Duration) ([]*Job, error) {
 	bucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
 	reg := prometheus.NewRegistry()
 	defer c.syncerMetrics.gatherThanosSyncerMetrics(reg)
@@ -661,7 +862,7 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		fetcherFilters,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	syncer, err := NewMetaSyncer(
@@ -674,7 +875,7 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.blocksMarkedForDeletion,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create syncer")
+		return nil, errors.Wrap(err, "failed to create syncer")
 	}
 
 	compactor, err := NewBucketCompactor(
@@ -693,14 +894,39 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.bucketCompactorMetrics,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create bucket compactor")
+		return nil, errors.Wrap(err, "failed to create bucket compactor")
 	}
 
-	if err := compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime); err != nil {
-		return errors.Wrap(err, "compaction")
+	pendingJobs, err := compactor.compact(ctx, maxCompactionTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "compaction")
 	}
 
-	return nil
+	// Enforce the series retention only once the tenant has no compaction backlog, to not delay compaction
+	// and to not rewrite blocks which are going to be compacted soon.
+	if len(pendingJobs) == 0 && len(c.cfgProvider.CompactorSeriesRetention(userID)) > 0 {
+		if err := syncer.SyncMetas(ctx); err != nil {
+			return nil, errors.Wrap(err, "sync blocks before enforcing series retention")
+		}
+
+		if err := c.enforceSeriesRetention(ctx, userID, ulogger, bucket, syncer.Metas()); err != nil {
+			return nil, errors.Wrap(err, "series retention")
+		}
+	}
+
+	// Like the series retention, the requested series deletions are processed only once the tenant has no
+	// compaction backlog.
+	if len(pendingJobs) == 0 && c.compactorCfg.SeriesDeletionEnabled {
+		if err := syncer.SyncMetas(ctx); err != nil {
+			return nil, errors.Wrap(err, "sync blocks before processing series deletions")
+		}
+
+		if err := c.processSeriesDeletions(ctx, userID, ulogger, bucket, syncer.Metas()); err != nil {
+			return nil, errors.Wrap(err, "series deletion")
+		}
+	}
+
+	return pendingJobs, nil
 }
 
 func (c *MultitenantCompactor) discoverUsersWithRetries(ctx context.Context) ([]string, error) {
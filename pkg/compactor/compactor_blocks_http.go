@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	_ "embed" // Used to embed html template
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+var (
+	//go:embed blocks_timeline.gohtml
+	blocksTimelinePageHTML     string
+	blocksTimelinePageTemplate = template.Must(template.New("webpage").Funcs(template.FuncMap{
+		"levelColor": levelColor,
+	}).Parse(blocksTimelinePageHTML))
+)
+
+type blocksTimelinePageContents struct {
+	Now            time.Time       `json:"now"`
+	Tenant         string          `json:"tenant"`
+	IndexUpdatedAt time.Time       `json:"bucket_index_updated_at"`
+	MinTime        time.Time       `json:"min_time"`
+	MaxTime        time.Time       `json:"max_time"`
+	Blocks         []timelineBlock `json:"blocks"`
+	ShowDeleted    bool            `json:"-"`
+}
+
+type timelineBlock struct {
+	ID               string    `json:"block_id"`
+	MinTime          time.Time `json:"min_time"`
+	MaxTime          time.Time `json:"max_time"`
+	CompactionLevel  int       `json:"compaction_level,omitempty"`
+	CompactorShardID string    `json:"compactor_shard_id,omitempty"`
+	UploadedAt       time.Time `json:"uploaded_at"`
+
+	// DeletionTime is the time the block has been marked for deletion, if it has.
+	DeletionTime *time.Time `json:"deletion_time,omitempty"`
+	// NoCompactReason is the reason why the block has been marked for no-compaction, if it has.
+	NoCompactReason string `json:"no_compact_reason,omitempty"`
+	// OverlappingBlocks are the IDs of the blocks with the same compactor shard ID whose
+	// time range overlaps with the one of this block.
+	OverlappingBlocks []string `json:"overlapping_blocks,omitempty"`
+
+	// Offset and Width are the position of the block in the timeline, in percentage of its width.
+	Offset float64 `json:"-"`
+	Width  float64 `json:"-"`
+}
+
+// BlocksTimelineHandler renders the timeline of the blocks of a tenant, as listed in its bucket index,
+// along with their compaction level, overlaps and markers.
+func (c *MultitenantCompactor) BlocksTimelineHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		// The bucket client is created when the compactor starts.
+		util.WriteTextResponse(w, "Compactor is not running yet.")
+		return
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		util.WriteTextResponse(w, "Tenant ID can't be empty")
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		util.WriteTextResponse(w, fmt.Sprintf("Can't parse form: %s", err))
+		return
+	}
+	showDeleted := req.Form.Get("show_deleted") == "on"
+
+	idx, err := bucketindex.ReadIndex(req.Context(), c.bucketClient, tenantID, c.cfgProvider, c.logger)
+	if errors.Is(err, bucketindex.ErrIndexNotFound) {
+		util.WriteTextResponse(w, "The bucket index of the tenant has not been created yet.")
+		return
+	}
+	if err != nil {
+		util.WriteTextResponse(w, fmt.Sprintf("Failed to read bucket index: %s", err))
+		return
+	}
+
+	noCompactReasons, err := c.readNoCompactMarks(req, tenantID)
+	if err != nil {
+		util.WriteTextResponse(w, fmt.Sprintf("Failed to read no-compact marks: %s", err))
+		return
+	}
+
+	util.RenderHTTPResponse(w, blocksTimeline(tenantID, idx, noCompactReasons, showDeleted, time.Now()), blocksTimelinePageTemplate, req)
+}
+
+// readNoCompactMarks returns the reason of each no-compact mark of the tenant, by block ID.
+// No-compact marks aren't tracked by the bucket index, so they're read from the storage.
+func (c *MultitenantCompactor) readNoCompactMarks(req *http.Request, tenantID string) (map[ulid.ULID]string, error) {
+	userBucket := bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider)
+
+	var ids []ulid.ULID
+	err := userBucket.Iter(req.Context(), bucketindex.MarkersPathname+"/", func(name string) error {
+		if blockID, ok := bucketindex.IsNoCompactMarkFilename(path.Base(name)); ok {
+			ids = append(ids, blockID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list no-compact marks")
+	}
+
+	reasons := make(map[ulid.ULID]string, len(ids))
+	for _, id := range ids {
+		m := metadata.NoCompactMark{}
+		if err := metadata.ReadMarker(req.Context(), c.logger, userBucket, id.String(), &m); err != nil {
+			// The block may have been deleted in the meanwhile, so we just skip the marks we can't read.
+			level.Warn(util_log.WithUserID(tenantID, c.logger)).Log("msg", "failed to read no-compact mark", "block", id.String(), "err", err)
+			continue
+		}
+		reasons[id] = string(m.Reason)
+	}
+	return reasons, nil
+}
+
+// blocksTimeline builds the timeline of the blocks in the bucket index, sorted by compaction level and time.
+func blocksTimeline(tenantID string, idx *bucketindex.Index, noCompactReasons map[ulid.ULID]string, showDeleted bool, now time.Time) blocksTimelinePageContents {
+	deletionTimes := make(map[ulid.ULID]time.Time, len(idx.BlockDeletionMarks))
+	for _, m := range idx.BlockDeletionMarks {
+		deletionTimes[m.ID] = m.GetDeletionTime()
+	}
+
+	blocks := make(bucketindex.Blocks, 0, len(idx.Blocks))
+	for _, b := range idx.Blocks {
+		if _, deleted := deletionTimes[b.ID]; deleted && !showDeleted {
+			continue
+		}
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].CompactionLevel != blocks[j].CompactionLevel {
+			return blocks[i].CompactionLevel < blocks[j].CompactionLevel
+		}
+		if blocks[i].MinTime != blocks[j].MinTime {
+			return blocks[i].MinTime < blocks[j].MinTime
+		}
+		return blocks[i].CompactorShardID < blocks[j].CompactorShardID
+	})
+
+	contents := blocksTimelinePageContents{
+		Now:            now,
+		Tenant:         tenantID,
+		IndexUpdatedAt: idx.GetUpdatedAt(),
+		Blocks:         make([]timelineBlock, 0, len(blocks)),
+		ShowDeleted:    showDeleted,
+	}
+	if len(blocks) == 0 {
+		return contents
+	}
+
+	minT, maxT := blocks[0].MinTime, blocks[0].MaxTime
+	for _, b := range blocks {
+		if b.MinTime < minT {
+			minT = b.MinTime
+		}
+		if b.MaxTime > maxT {
+			maxT = b.MaxTime
+		}
+	}
+	contents.MinTime = util.TimeFromMillis(minT).UTC()
+	contents.MaxTime = util.TimeFromMillis(maxT).UTC()
+	span := float64(maxT - minT)
+
+	for _, b := range blocks {
+		tb := timelineBlock{
+			ID:               b.ID.String(),
+			MinTime:          util.TimeFromMillis(b.MinTime).UTC(),
+			MaxTime:          util.TimeFromMillis(b.MaxTime).UTC(),
+			CompactionLevel:  b.CompactionLevel,
+			CompactorShardID: b.CompactorShardID,
+			UploadedAt:       b.GetUploadedAt().UTC(),
+			NoCompactReason:  noCompactReasons[b.ID],
+			Width:            100,
+		}
+		if span > 0 {
+			tb.Offset = float64(b.MinTime-minT) / span * 100
+			tb.Width = float64(b.MaxTime-b.MinTime) / span * 100
+		}
+		if t, ok := deletionTimes[b.ID]; ok {
+			t = t.UTC()
+			tb.DeletionTime = &t
+		}
+		for _, other := range blocks {
+			if other.ID != b.ID && other.CompactorShardID == b.CompactorShardID && other.MinTime < b.MaxTime && b.MinTime < other.MaxTime {
+				tb.OverlappingBlocks = append(tb.OverlappingBlocks, other.ID.String())
+			}
+		}
+		contents.Blocks = append(contents.Blocks, tb)
+	}
+
+	return contents
+}
+
+// levelColor returns the color of the blocks of a compaction level in the timeline.
+func levelColor(level int) string {
+	colors := []string{"#9e9e9e", "#64b5f6", "#4db6ac", "#81c784", "#ffb74d", "#e57373"}
+	if level < 0 {
+		level = 0
+	}
+	if level >= len(colors) {
+		level = len(colors) - 1
+	}
+	return colors[level]
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestBlocksTimeline(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	block4 := ulid.MustNew(4, nil)
+	now := time.Unix(1000, 0)
+
+	idx := &bucketindex.Index{
+		Version: bucketindex.IndexVersion1,
+		Blocks: bucketindex.Blocks{
+			{ID: block1, MinTime: 0, MaxTime: 200, CompactionLevel: 2, CompactorShardID: "1_of_2"},
+			{ID: block2, MinTime: 100, MaxTime: 300, CompactionLevel: 1, CompactorShardID: "1_of_2"},
+			{ID: block3, MinTime: 100, MaxTime: 300, CompactionLevel: 1, CompactorShardID: "2_of_2"},
+			{ID: block4, MinTime: 300, MaxTime: 400, CompactionLevel: 1, CompactorShardID: "1_of_2"},
+		},
+		BlockDeletionMarks: bucketindex.BlockDeletionMarks{
+			{ID: block4, DeletionTime: 500},
+		},
+		UpdatedAt: 900,
+	}
+	noCompactReasons := map[ulid.ULID]string{block3: "block-index-out-of-order-chunk"}
+
+	t.Run("should exclude blocks marked for deletion", func(t *testing.T) {
+		contents := blocksTimeline("user-1", idx, noCompactReasons, false, now)
+
+		assert.Equal(t, "user-1", contents.Tenant)
+		assert.Equal(t, time.Unix(900, 0), contents.IndexUpdatedAt)
+		assert.Equal(t, time.UnixMilli(0).UTC(), contents.MinTime)
+		assert.Equal(t, time.UnixMilli(300).UTC(), contents.MaxTime)
+
+		require.Len(t, contents.Blocks, 3)
+		assert.InDelta(t, 33.33, contents.Blocks[0].Offset, 0.01)
+		assert.InDelta(t, 66.67, contents.Blocks[0].Width, 0.01)
+		contents.Blocks[0].Offset, contents.Blocks[0].Width = 0, 0
+		assert.Equal(t, timelineBlock{
+			ID:                block2.String(),
+			MinTime:           time.UnixMilli(100).UTC(),
+			MaxTime:           time.UnixMilli(300).UTC(),
+			CompactionLevel:   1,
+			CompactorShardID:  "1_of_2",
+			UploadedAt:        time.Unix(0, 0).UTC(),
+			OverlappingBlocks: []string{block1.String()},
+		}, contents.Blocks[0])
+		assert.Equal(t, block3.String(), contents.Blocks[1].ID)
+		assert.Equal(t, "block-index-out-of-order-chunk", contents.Blocks[1].NoCompactReason)
+		assert.Empty(t, contents.Blocks[1].OverlappingBlocks)
+		assert.Equal(t, block1.String(), contents.Blocks[2].ID)
+		assert.Equal(t, []string{block2.String()}, contents.Blocks[2].OverlappingBlocks)
+		assert.Equal(t, 0.0, contents.Blocks[2].Offset)
+	})
+
+	t.Run("should include blocks marked for deletion if requested", func(t *testing.T) {
+		contents := blocksTimeline("user-1", idx, noCompactReasons, true, now)
+
+		assert.Equal(t, time.UnixMilli(400).UTC(), contents.MaxTime)
+		require.Len(t, contents.Blocks, 4)
+		assert.Equal(t, block4.String(), contents.Blocks[2].ID)
+		require.NotNil(t, contents.Blocks[2].DeletionTime)
+		assert.Equal(t, time.Unix(500, 0).UTC(), *contents.Blocks[2].DeletionTime)
+		// Blocks touching at the boundaries don't overlap.
+		assert.Empty(t, contents.Blocks[2].OverlappingBlocks)
+
+		var buf bytes.Buffer
+		require.NoError(t, blocksTimelinePageTemplate.Execute(&buf, contents))
+		assert.Contains(t, buf.String(), block4.String())
+	})
+
+	t.Run("should return no blocks for an empty index", func(t *testing.T) {
+		contents := blocksTimeline("user-1", &bucketindex.Index{}, nil, false, now)
+		assert.Empty(t, contents.Blocks)
+	})
+}
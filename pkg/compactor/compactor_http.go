@@ -9,10 +9,14 @@ import (
 	_ "embed" // Used to embed html template
 	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 
+	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
@@ -45,3 +49,53 @@ func (c *MultitenantCompactor) RingHandler(w http.ResponseWriter, req *http.Requ
 
 	c.ring.ServeHTTP(w, req)
 }
+
+type compactorStatus struct {
+	Now   time.Time `json:"now"`
+	State string    `json:"state"`
+
+	CompactionRunsStarted   int        `json:"compaction_runs_started"`
+	CompactionRunsCompleted int        `json:"compaction_runs_completed"`
+	CompactionRunsFailed    int        `json:"compaction_runs_failed"`
+	LastSuccessfulRun       *time.Time `json:"last_successful_run,omitempty"`
+
+	// Progress of the compaction run in progress, if any.
+	DiscoveredTenants int `json:"discovered_tenants"`
+	SkippedTenants    int `json:"skipped_tenants"`
+	SucceededTenants  int `json:"succeeded_tenants"`
+	FailedTenants     int `json:"failed_tenants"`
+}
+
+// StatusHandler returns the progress of the compaction runs as JSON.
+func (c *MultitenantCompactor) StatusHandler(w http.ResponseWriter, _ *http.Request) {
+	status := compactorStatus{
+		Now:                     time.Now(),
+		State:                   c.State().String(),
+		CompactionRunsStarted:   int(metricValue(c.compactionRunsStarted)),
+		CompactionRunsCompleted: int(metricValue(c.compactionRunsCompleted)),
+		CompactionRunsFailed:    int(metricValue(c.compactionRunsFailed)),
+		DiscoveredTenants:       int(metricValue(c.compactionRunDiscoveredTenants)),
+		SkippedTenants:          int(metricValue(c.compactionRunSkippedTenants)),
+		SucceededTenants:        int(metricValue(c.compactionRunSucceededTenants)),
+		FailedTenants:           int(metricValue(c.compactionRunFailedTenants)),
+	}
+
+	if lastSuccess := metricValue(c.compactionRunsLastSuccess); lastSuccess > 0 {
+		t := time.Unix(int64(lastSuccess), 0)
+		status.LastSuccessfulRun = &t
+	}
+
+	util.WriteJSONResponse(w, status)
+}
+
+// metricValue returns the current value of a counter or gauge.
+func metricValue(m prometheus.Metric) float64 {
+	out := &dto.Metric{}
+	if err := m.Write(out); err != nil {
+		return 0
+	}
+	if out.Counter != nil {
+		return out.Counter.GetValue()
+	}
+	return out.Gauge.GetValue()
+}
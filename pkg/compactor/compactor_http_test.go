@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+func TestMultitenantCompactor_StatusHandler(t *testing.T) {
+	bucketClient := &bucket.ClientMock{}
+	bucketClient.MockIter("", []string{}, nil)
+	c, _, _, _, _ := prepare(t, prepareConfig(t), bucketClient)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+	})
+
+	// Wait until a run has completed.
+	test.Poll(t, time.Second, 1.0, func() interface{} {
+		return prom_testutil.ToFloat64(c.compactionRunsCompleted)
+	})
+
+	rec := httptest.NewRecorder()
+	c.StatusHandler(rec, httptest.NewRequest(http.MethodGet, "/compactor/status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status compactorStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, "Running", status.State)
+	assert.Equal(t, 1, status.CompactionRunsCompleted)
+	assert.Equal(t, 0, status.CompactionRunsFailed)
+	require.NotNil(t, status.LastSuccessfulRun)
+	assert.WithinDuration(t, time.Now(), *status.LastSuccessfulRun, time.Minute)
+}
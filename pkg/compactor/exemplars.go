@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"path/filepath"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+// writeCompactedExemplars merges the exemplars sidecar files of the source blocks and writes them to the
+// compacted blocks in dir. When the compaction has been split, each series' exemplars are written to the
+// same output block where the compactor wrote the series. Exemplars of series belonging to an empty output
+// block are dropped.
+func writeCompactedExemplars(dir string, sourceDirs []string, compIDs []ulid.ULID) error {
+	var all []mimirpb.TimeSeries
+	for _, src := range sourceDirs {
+		series, err := block.ReadExemplarsFile(src)
+		if err != nil {
+			return errors.Wrapf(err, "read exemplars of block %s", src)
+		}
+		all = append(all, series...)
+	}
+
+	if len(all) == 0 || len(compIDs) == 0 {
+		return nil
+	}
+
+	// Group the series by output block, using the same sharding function used by the TSDB compactor.
+	sharded := make([][]mimirpb.TimeSeries, len(compIDs))
+	for _, s := range all {
+		ix := 0
+		if len(compIDs) > 1 {
+			ix = int(mimirpb.FromLabelAdaptersToLabels(s.Labels).Hash() % uint64(len(compIDs)))
+		}
+		sharded[ix] = append(sharded[ix], s)
+	}
+
+	for ix, id := range compIDs {
+		if id == (ulid.ULID{}) || len(sharded[ix]) == 0 {
+			continue
+		}
+		if err := block.WriteExemplarsFile(filepath.Join(dir, id.String()), sharded[ix]); err != nil {
+			return errors.Wrapf(err, "write exemplars of block %s", id)
+		}
+	}
+
+	return nil
+}
+
+// writeRewrittenExemplars writes the exemplars sidecar file of a block rewritten with deletions, removing the
+// exemplars of the deleted series.
+func writeRewrittenExemplars(srcDir, dstDir string, deletions []metadata.DeletionRequest) error {
+	series, err := block.ReadExemplarsFile(srcDir)
+	if err != nil {
+		return errors.Wrapf(err, "read exemplars of block %s", srcDir)
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	for ix := range series {
+		lbls := mimirpb.FromLabelAdaptersToLabels(series[ix].Labels)
+
+		for _, d := range deletions {
+			if !matchesAll(d.Matchers, lbls) {
+				continue
+			}
+
+			kept := series[ix].Exemplars[:0]
+			for _, e := range series[ix].Exemplars {
+				if !isDeleted(d, e.TimestampMs) {
+					kept = append(kept, e)
+				}
+			}
+			series[ix].Exemplars = kept
+		}
+	}
+
+	return block.WriteExemplarsFile(dstDir, series)
+}
+
+func matchesAll(matchers []*labels.Matcher, lbls labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDeleted(d metadata.DeletionRequest, ts int64) bool {
+	if len(d.Intervals) == 0 {
+		return true
+	}
+	for _, iv := range d.Intervals {
+		if iv.InBounds(ts) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+func exemplarsSeries(name string, timestamps ...int64) mimirpb.TimeSeries {
+	s := mimirpb.TimeSeries{Labels: []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: name}}}
+	for _, ts := range timestamps {
+		s.Exemplars = append(s.Exemplars, mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: name}}, Value: 1, TimestampMs: ts})
+	}
+	return s
+}
+
+func TestWriteCompactedExemplars(t *testing.T) {
+	dir := t.TempDir()
+
+	src1 := filepath.Join(dir, "src-1")
+	src2 := filepath.Join(dir, "src-2")
+	src3 := filepath.Join(dir, "src-3")
+	for _, d := range []string{src1, src2, src3} {
+		require.NoError(t, os.MkdirAll(d, 0750))
+	}
+
+	require.NoError(t, block.WriteExemplarsFile(src1, []mimirpb.TimeSeries{exemplarsSeries("series_1", 10), exemplarsSeries("series_2", 10)}))
+	require.NoError(t, block.WriteExemplarsFile(src2, []mimirpb.TimeSeries{exemplarsSeries("series_1", 20, 10)}))
+	// The third source block has no exemplars file.
+
+	t.Run("single output block", func(t *testing.T) {
+		out := ulid.MustNew(1, nil)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, out.String()), 0750))
+
+		require.NoError(t, writeCompactedExemplars(dir, []string{src1, src2, src3}, []ulid.ULID{out}))
+
+		series, err := block.ReadExemplarsFile(filepath.Join(dir, out.String()))
+		require.NoError(t, err)
+		assert.Equal(t, []mimirpb.TimeSeries{exemplarsSeries("series_1", 10, 20), exemplarsSeries("series_2", 10)}, series)
+	})
+
+	t.Run("split output blocks", func(t *testing.T) {
+		outs := []ulid.ULID{ulid.MustNew(2, nil), ulid.MustNew(3, nil), ulid.MustNew(4, nil)}
+		for _, id := range outs {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, id.String()), 0750))
+		}
+
+		require.NoError(t, writeCompactedExemplars(dir, []string{src1, src2, src3}, outs))
+
+		for _, name := range []string{"series_1", "series_2"} {
+			shard := labels.FromStrings(labels.MetricName, name).Hash() % uint64(len(outs))
+
+			series, err := block.ReadExemplarsFile(filepath.Join(dir, outs[shard].String()))
+			require.NoError(t, err)
+			assert.Contains(t, series, exemplarsSeries(name, map[string][]int64{"series_1": {10, 20}, "series_2": {10}}[name]...))
+		}
+	})
+}
+
+func TestWriteRewrittenExemplars(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	require.NoError(t, block.WriteExemplarsFile(src, []mimirpb.TimeSeries{
+		exemplarsSeries("series_1", 10, 20, 30),
+		exemplarsSeries("series_2", 10, 20, 30),
+		exemplarsSeries("series_3", 10),
+	}))
+
+	require.NoError(t, writeRewrittenExemplars(src, dst, []metadata.DeletionRequest{
+		{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "series_1")}},
+		{
+			Matchers:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "series_2")},
+			Intervals: tombstones.Intervals{{Mint: 15, Maxt: 25}},
+		},
+	}))
+
+	series, err := block.ReadExemplarsFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, []mimirpb.TimeSeries{exemplarsSeries("series_2", 10, 30), exemplarsSeries("series_3", 10)}, series)
+}
@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+// seriesDeletionRequestIDPrefix is the prefix of the request ID of the deletions applied to a block when
+// processing a tombstone. The full request ID is the prefix followed by the tombstone request ID.
+const seriesDeletionRequestIDPrefix = "series-deletion:"
+
+// seriesDeletionCheck identifies a block checked against a tombstone.
+type seriesDeletionCheck struct {
+	blockID   ulid.ULID
+	requestID string
+}
+
+// processSeriesDeletions deletes the samples requested by the pending tombstones of the tenant from the blocks.
+// Blocks containing samples to delete are rewritten without them, and the original blocks are marked for deletion.
+//
+// Each tombstone is processed by a single compactor, which rewrites all the blocks affected by the tombstone.
+// Failing to rewrite a block is not a fatal error: the block is retried at the next compaction of the tenant.
+// A tombstone is marked as processed once no block contains samples to delete and the configured pending period
+// after the end of its time range is elapsed, so that the blocks uploaded by ingesters after the request are
+// processed too.
+func (c *MultitenantCompactor) processSeriesDeletions(ctx context.Context, userID string, userLogger log.Logger, userBucket objstore.Bucket, metas map[ulid.ULID]*metadata.Meta) error {
+	all, err := mimir_tsdb.ReadTombstones(ctx, userBucket)
+	if err != nil {
+		return errors.Wrap(err, "read tombstones")
+	}
+
+	var pending []*mimir_tsdb.Tombstone
+	for _, t := range all {
+		if t.State == mimir_tsdb.TombstonePending {
+			pending = append(pending, t)
+		}
+	}
+	if len(pending) == 0 {
+		delete(c.seriesDeletionChecked, userID)
+		return nil
+	}
+
+	if err := os.MkdirAll(c.seriesDeletionDir(), 0750); err != nil {
+		return errors.Wrap(err, "create series deletion dir")
+	}
+
+	// Iterate blocks in a stable order, oldest first.
+	ids := make([]ulid.ULID, 0, len(metas))
+	for id := range metas {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Compare(ids[j]) < 0
+	})
+
+	// Keep track of blocks which have already been checked and found not containing any sample to delete for
+	// a tombstone, so that we don't download their index again. Only blocks still existing are kept.
+	prevChecked := c.seriesDeletionChecked[userID]
+	checked := map[seriesDeletionCheck]struct{}{}
+	defer func() {
+		c.seriesDeletionChecked[userID] = checked
+	}()
+
+	for _, t := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Each tombstone is processed by a single compactor.
+		job := NewJob(userID, "series-deletion-"+t.RequestID, labels.EmptyLabels(), 0, false, 0, t.RequestID)
+		if ok, err := c.shardingStrategy.ownJob(job); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to check if series deletion request is owned by this compactor", "request_id", t.RequestID, "err", err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		matchers, err := t.Matchers()
+		if err != nil {
+			level.Warn(userLogger).Log("msg", "skipping invalid series deletion request", "request_id", t.RequestID, "err", err)
+			continue
+		}
+
+		completed := true
+		for _, id := range ids {
+			meta := metas[id]
+			if !t.Overlaps(meta.MinTime, meta.MaxTime) || hasSeriesDeletionApplied(meta, t.RequestID) {
+				continue
+			}
+
+			key := seriesDeletionCheck{blockID: id, requestID: t.RequestID}
+			if _, ok := prevChecked[key]; ok {
+				checked[key] = struct{}{}
+				continue
+			}
+
+			deletions, err := c.seriesDeletionDeletions(ctx, userLogger, userBucket, id, t, matchers)
+			if err != nil {
+				level.Warn(userLogger).Log("msg", "failed to look up samples to delete in block", "block", id, "request_id", t.RequestID, "err", err)
+				c.seriesDeletionBlocksFailed.Inc()
+				completed = false
+				continue
+			}
+
+			if len(deletions) == 0 {
+				checked[key] = struct{}{}
+				continue
+			}
+
+			level.Info(userLogger).Log("msg", "rewriting block to delete series", "block", id, "request_id", t.RequestID)
+
+			newID, err := rewriteBlockWithDeletions(ctx, userLogger, userBucket, c.blocksCompactor, c.seriesDeletionDir(), meta, deletions, c.seriesDeletionBlocksMarkedForDeletion)
+			if err != nil {
+				level.Warn(userLogger).Log("msg", "failed to rewrite block to delete series", "block", id, "request_id", t.RequestID, "err", err)
+				c.seriesDeletionBlocksFailed.Inc()
+				completed = false
+				continue
+			}
+
+			level.Info(userLogger).Log("msg", "deleted series from block", "block", id, "new_block", newID, "request_id", t.RequestID)
+			c.seriesDeletionBlocksRewritten.Inc()
+		}
+
+		if !completed || time.Now().Before(time.UnixMilli(t.EndTime).Add(c.compactorCfg.SeriesDeletionPendingPeriod)) {
+			continue
+		}
+
+		t.State = mimir_tsdb.TombstoneProcessed
+		t.ProcessedAt = time.Now().Unix()
+		if err := mimir_tsdb.WriteTombstone(ctx, userBucket, t); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to mark series deletion request as processed", "request_id", t.RequestID, "err", err)
+			continue
+		}
+
+		level.Info(userLogger).Log("msg", "series deletion request processed", "request_id", t.RequestID)
+		c.seriesDeletionRequestsProcessed.Inc()
+	}
+
+	return nil
+}
+
+// seriesDeletionDeletions downloads the index of the block and returns the deletion requests for the selectors
+// of the tombstone having at least one matching series with chunks overlapping the tombstone time range.
+func (c *MultitenantCompactor) seriesDeletionDeletions(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, t *mimir_tsdb.Tombstone, matchers [][]*labels.Matcher) (_ []metadata.DeletionRequest, rerr error) {
+	tmpDir, err := os.MkdirTemp(c.seriesDeletionDir(), "index-"+id.String()+"-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create index dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove index dir", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	indexFile := filepath.Join(tmpDir, block.IndexFilename)
+	if err := objstore.DownloadFile(ctx, logger, bkt, path.Join(id.String(), block.IndexFilename), indexFile); err != nil {
+		return nil, errors.Wrapf(err, "download index of block %s", id)
+	}
+
+	ir, err := index.NewFileReader(indexFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open index of block %s", id)
+	}
+	defer func() {
+		if err := ir.Close(); err != nil && rerr == nil {
+			rerr = errors.Wrapf(err, "close index of block %s", id)
+		}
+	}()
+
+	var (
+		deletions []metadata.DeletionRequest
+		lbls      labels.Labels
+		chks      []chunks.Meta
+	)
+
+	for ix, m := range matchers {
+		p, err := tsdb.PostingsForMatchers(ir, m...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "select series matching %s", t.Selectors[ix])
+		}
+
+		found := false
+		for !found && p.Next() {
+			if err := ir.Series(p.At(), &lbls, &chks); err != nil {
+				return nil, errors.Wrapf(err, "read series of block %s", id)
+			}
+
+			// The series may have samples outside of the tombstone time range only.
+			for _, chk := range chks {
+				if chk.MinTime <= t.EndTime && chk.MaxTime >= t.StartTime {
+					found = true
+					break
+				}
+			}
+		}
+		if err := p.Err(); err != nil {
+			return nil, errors.Wrapf(err, "select series matching %s", t.Selectors[ix])
+		}
+
+		if found {
+			deletions = append(deletions, metadata.DeletionRequest{
+				Matchers:  m,
+				Intervals: tombstones.Intervals{{Mint: t.StartTime, Maxt: t.EndTime}},
+				RequestID: seriesDeletionRequestIDPrefix + t.RequestID,
+			})
+		}
+	}
+
+	return deletions, nil
+}
+
+func (c *MultitenantCompactor) seriesDeletionDir() string {
+	return filepath.Join(c.compactorCfg.DataDir, "series-deletion")
+}
+
+// hasSeriesDeletionApplied returns whether the tombstone with the input request ID has already been processed
+// on the block.
+func hasSeriesDeletionApplied(meta *metadata.Meta, requestID string) bool {
+	for _, r := range meta.Thanos.Rewrites {
+		for _, d := range r.DeletionsApplied {
+			if d.RequestID == seriesDeletionRequestIDPrefix+requestID {
+				return true
+			}
+		}
+	}
+	return false
+}
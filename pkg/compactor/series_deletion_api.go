@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const errSeriesDeletionDisabled = "series deletion is disabled"
+
+// DeleteSeries implements the Prometheus delete series API. The deletion request is stored as a tombstone
+// in the storage, and the requested series are deleted from the blocks by the compactor.
+func (c *MultitenantCompactor) DeleteSeries(w http.ResponseWriter, r *http.Request) {
+	if !c.compactorCfg.SeriesDeletionEnabled {
+		http.Error(w, errSeriesDeletionDisabled, http.StatusNotFound)
+		return
+	}
+
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		// When Mimir is running, it uses Auth Middleware for checking X-Scope-OrgID and injecting tenant into context.
+		// Auth Middleware sends http.StatusUnauthorized if X-Scope-OrgID is missing, so we do too here, for consistency.
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	selectors := r.Form["match[]"]
+	if len(selectors) == 0 {
+		http.Error(w, "no match[] parameter provided", http.StatusBadRequest)
+		return
+	}
+	for _, selector := range selectors {
+		if _, err := parser.ParseMetricSelector(selector); err != nil {
+			http.Error(w, fmt.Sprintf("invalid selector %s: %s", selector, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now()
+
+	// Like Prometheus, the whole time range is deleted by default. Samples can't be ingested in the future
+	// beyond the creation grace period, so the deletion ends at the time of the request by default.
+	startTime, endTime := int64(math.MinInt64), now.UnixMilli()
+	if v := r.FormValue("start"); v != "" {
+		if startTime, err = util.ParseTime(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid start time: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.FormValue("end"); v != "" {
+		if endTime, err = util.ParseTime(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid end time: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if endTime < startTime {
+		http.Error(w, "end time must not be before start time", http.StatusBadRequest)
+		return
+	}
+
+	userBucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
+	t := mimir_tsdb.NewTombstone(now, startTime, endTime, selectors)
+
+	// The same deletion may have already been requested.
+	existing, err := mimir_tsdb.ReadTombstone(ctx, userBucket, t.RequestID)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to read tombstone", "user", userID, "request_id", t.RequestID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if existing == nil {
+		if err := mimir_tsdb.WriteTombstone(ctx, userBucket, t); err != nil {
+			level.Error(c.logger).Log("msg", "failed to write tombstone", "user", userID, "request_id", t.RequestID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		level.Info(c.logger).Log("msg", "series deletion request created", "user", userID, "request_id", t.RequestID, "selectors", len(selectors))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SeriesDeletionRequests returns the series deletion requests of the tenant, along with their processing state.
+func (c *MultitenantCompactor) SeriesDeletionRequests(w http.ResponseWriter, r *http.Request) {
+	if !c.compactorCfg.SeriesDeletionEnabled {
+		http.Error(w, errSeriesDeletionDisabled, http.StatusNotFound)
+		return
+	}
+
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	tombstones, err := mimir_tsdb.ReadTombstones(ctx, bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider))
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to read tombstones", "user", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tombstones == nil {
+		tombstones = []*mimir_tsdb.Tombstone{}
+	}
+
+	util.WriteJSONResponse(w, tombstones)
+}
@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+func TestMultitenantCompactor_ShouldProcessSeriesDeletions(t *testing.T) {
+	const userID = "user-1"
+
+	var (
+		ctx        = context.Background()
+		blockRange = 2 * time.Hour
+		now        = time.Now()
+		firstRange = now.Add(-10 * 24 * time.Hour).Truncate(blockRange)
+		otherRange = now.Add(-12 * 24 * time.Hour).Truncate(blockRange)
+	)
+
+	storageDir := t.TempDir()
+	fetcherDir := t.TempDir()
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.Bucket.Backend = bucket.Filesystem
+	storageCfg.Bucket.Filesystem.Directory = storageDir
+
+	compactorCfg := prepareConfig(t)
+	compactorCfg.DataDir = t.TempDir()
+	compactorCfg.SeriesDeletionEnabled = true
+	compactorCfg.SeriesDeletionPendingPeriod = time.Hour
+
+	logger := log.NewLogfmtLogger(os.Stdout)
+	reg := prometheus.NewPedanticRegistry()
+
+	bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
+	require.NoError(t, err)
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+
+	// Block whose first half contains the series to delete, and other series.
+	firstBlock := createTSDBBlock(t, bucketClient, userID, firstRange.UnixMilli(), firstRange.Add(blockRange).UnixMilli(), 3, nil)
+
+	// Block outside of the time range of the deletion.
+	otherBlock := createTSDBBlock(t, bucketClient, userID, otherRange.UnixMilli(), otherRange.Add(blockRange).UnixMilli(), 3, nil)
+
+	// The first tombstone deletes the series 0 from the first block. The second tombstone doesn't match any sample,
+	// because the series 2 has no sample in the time range, while the third tombstone is still in its pending period.
+	deletion := mimir_tsdb.NewTombstone(now, firstRange.UnixMilli(), firstRange.Add(blockRange/2).UnixMilli(), []string{`{series_id="0"}`})
+	noMatch := mimir_tsdb.NewTombstone(now, firstRange.UnixMilli(), firstRange.Add(blockRange/2).UnixMilli(), []string{`{series_id="2"}`})
+	recent := mimir_tsdb.NewTombstone(now, now.Add(-blockRange).UnixMilli(), now.UnixMilli(), []string{`{series_id="0"}`})
+	for _, tombstone := range []*mimir_tsdb.Tombstone{deletion, noMatch, recent} {
+		require.NoError(t, mimir_tsdb.WriteTombstone(ctx, userBucket, tombstone))
+	}
+
+	c, err := NewMultitenantCompactor(compactorCfg, storageCfg, newMockConfigProvider(), logger, reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	// Wait until the first compaction run completed.
+	test.Poll(t, 15*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
+			# TYPE cortex_compactor_runs_completed_total counter
+			cortex_compactor_runs_completed_total 1
+		`), "cortex_compactor_runs_completed_total")
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_series_deletion_blocks_rewritten_total Total number of blocks rewritten to delete the series requested through the series deletion API.
+		# TYPE cortex_compactor_series_deletion_blocks_rewritten_total counter
+		cortex_compactor_series_deletion_blocks_rewritten_total 1
+
+		# HELP cortex_compactor_series_deletion_blocks_failed_total Total number of blocks which failed to be rewritten to delete the series requested through the series deletion API.
+		# TYPE cortex_compactor_series_deletion_blocks_failed_total counter
+		cortex_compactor_series_deletion_blocks_failed_total 0
+
+		# HELP cortex_compactor_series_deletion_requests_processed_total Total number of series deletion requests whose series have been deleted from all blocks.
+		# TYPE cortex_compactor_series_deletion_requests_processed_total counter
+		cortex_compactor_series_deletion_requests_processed_total 2
+	`), "cortex_compactor_series_deletion_blocks_rewritten_total", "cortex_compactor_series_deletion_blocks_failed_total", "cortex_compactor_series_deletion_requests_processed_total"))
+
+	// List back any (non deleted) block from the storage.
+	fetcher, err := block.NewMetaFetcher(logger, 1, userBucket, fetcherDir, reg, []block.MetadataFilter{NewExcludeMarkedForDeletionFilter(userBucket)})
+	require.NoError(t, err)
+	metas, partials, err := fetcher.Fetch(ctx)
+	require.NoError(t, err)
+	require.Empty(t, partials)
+
+	require.Len(t, metas, 2)
+	require.NotContains(t, metas, firstBlock)
+	require.Contains(t, metas, otherBlock)
+	assert.Empty(t, metas[otherBlock].Thanos.Rewrites)
+
+	var rewritten *metadata.Meta
+	for id, m := range metas {
+		if id != otherBlock {
+			rewritten = m
+		}
+	}
+
+	assert.Equal(t, []ulid.ULID{firstBlock}, rewritten.Compaction.Sources)
+	assert.Equal(t, uint64(2), rewritten.Stats.NumSeries)
+	assert.True(t, hasSeriesDeletionApplied(rewritten, deletion.RequestID))
+	assert.False(t, hasSeriesDeletionApplied(rewritten, noMatch.RequestID))
+
+	// The tombstones whose pending period elapsed are processed.
+	for tombstone, expected := range map[*mimir_tsdb.Tombstone]mimir_tsdb.TombstoneState{
+		deletion: mimir_tsdb.TombstoneProcessed,
+		noMatch:  mimir_tsdb.TombstoneProcessed,
+		recent:   mimir_tsdb.TombstonePending,
+	} {
+		read, err := mimir_tsdb.ReadTombstone(ctx, userBucket, tombstone.RequestID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, read.State)
+	}
+}
+
+func TestMultitenantCompactor_DeleteSeries(t *testing.T) {
+	const userID = "user-1"
+
+	bkt := objstore.NewInMemBucket()
+	c := &MultitenantCompactor{
+		compactorCfg: Config{SeriesDeletionEnabled: true},
+		bucketClient: bkt,
+		logger:       log.NewNopLogger(),
+	}
+
+	request := func(handler http.HandlerFunc, method string, params url.Values, tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/prometheus/api/v1/admin/tsdb/delete_series?"+params.Encode(), nil)
+		if tenantID != "" {
+			req = req.WithContext(user.InjectOrgID(req.Context(), tenantID))
+		}
+		resp := httptest.NewRecorder()
+		handler(resp, req)
+		return resp
+	}
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			params       url.Values
+			tenantID     string
+			expectedCode int
+		}{
+			"no tenant":             {params: url.Values{"match[]": {`{job="a"}`}}, expectedCode: http.StatusUnauthorized},
+			"no selector":           {params: url.Values{}, tenantID: userID, expectedCode: http.StatusBadRequest},
+			"invalid selector":      {params: url.Values{"match[]": {`{job="a"`}}, tenantID: userID, expectedCode: http.StatusBadRequest},
+			"invalid start time":    {params: url.Values{"match[]": {`{job="a"}`}, "start": {"x"}}, tenantID: userID, expectedCode: http.StatusBadRequest},
+			"end before start time": {params: url.Values{"match[]": {`{job="a"}`}, "start": {"20"}, "end": {"10"}}, tenantID: userID, expectedCode: http.StatusBadRequest},
+		} {
+			t.Run(name, func(t *testing.T) {
+				resp := request(c.DeleteSeries, http.MethodPost, tc.params, tc.tenantID)
+				assert.Equal(t, tc.expectedCode, resp.Code)
+			})
+		}
+	})
+
+	t.Run("should write the tombstone only once", func(t *testing.T) {
+		params := url.Values{"match[]": {`{job="a"}`, `{job="b"}`}, "start": {"10"}, "end": {"20"}}
+
+		resp := request(c.DeleteSeries, http.MethodPost, params, userID)
+		require.Equal(t, http.StatusNoContent, resp.Code)
+
+		tombstones, err := mimir_tsdb.ReadTombstones(context.Background(), bucket.NewUserBucketClient(userID, bkt, nil))
+		require.NoError(t, err)
+		require.Len(t, tombstones, 1)
+		assert.Equal(t, int64(10000), tombstones[0].StartTime)
+		assert.Equal(t, int64(20000), tombstones[0].EndTime)
+		assert.Equal(t, []string{`{job="a"}`, `{job="b"}`}, tombstones[0].Selectors)
+		assert.Equal(t, mimir_tsdb.TombstonePending, tombstones[0].State)
+
+		// Requesting the same deletion again doesn't replace the existing tombstone.
+		tombstones[0].State = mimir_tsdb.TombstoneProcessed
+		require.NoError(t, mimir_tsdb.WriteTombstone(context.Background(), bucket.NewUserBucketClient(userID, bkt, nil), tombstones[0]))
+
+		resp = request(c.DeleteSeries, http.MethodPut, params, userID)
+		require.Equal(t, http.StatusNoContent, resp.Code)
+
+		resp = request(c.SeriesDeletionRequests, http.MethodGet, nil, userID)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"state":"processed"`)
+
+		// Other tenants have no deletion requests.
+		resp = request(c.SeriesDeletionRequests, http.MethodGet, nil, "user-2")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `[]`, resp.Body.String())
+	})
+
+	t.Run("should fail if series deletion is disabled", func(t *testing.T) {
+		disabled := &MultitenantCompactor{bucketClient: bkt, logger: log.NewNopLogger()}
+
+		resp := request(disabled.DeleteSeries, http.MethodPost, url.Values{"match[]": {`{job="a"}`}}, userID)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		resp = request(disabled.SeriesDeletionRequests, http.MethodGet, nil, userID)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+// seriesRetentionRequestIDPrefix is the prefix of the request ID of the deletions applied to a block
+// when enforcing series retention. The full request ID is the prefix followed by the selector.
+const seriesRetentionRequestIDPrefix = "series-retention:"
+
+// seriesRetentionCheck identifies a block checked against a series retention selector.
+type seriesRetentionCheck struct {
+	blockID  ulid.ULID
+	selector string
+}
+
+// enforceSeriesRetention deletes the series matching the per-tenant series retention selectors from the blocks
+// whose samples are all older than the retention period configured for the selector. Blocks containing matching
+// series are rewritten without them, and the original blocks are marked for deletion.
+//
+// Only blocks owned by this compactor are rewritten. Failing to rewrite a block is not a fatal error: the block
+// is retried at the next compaction of the tenant.
+func (c *MultitenantCompactor) enforceSeriesRetention(ctx context.Context, userID string, userLogger log.Logger, userBucket objstore.Bucket, metas map[ulid.ULID]*metadata.Meta) error {
+	rules := c.cfgProvider.CompactorSeriesRetention(userID)
+	if len(rules) == 0 {
+		delete(c.seriesRetentionChecked, userID)
+		return nil
+	}
+
+	matchers, err := rules.Matchers()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.seriesRetentionDir(), 0750); err != nil {
+		return errors.Wrap(err, "create series retention dir")
+	}
+
+	selectors := make([]string, 0, len(rules))
+	for selector := range rules {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+
+	// Iterate blocks in a stable order, oldest first.
+	ids := make([]ulid.ULID, 0, len(metas))
+	for id := range metas {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Compare(ids[j]) < 0
+	})
+
+	// Keep track of blocks which have already been checked and found not containing any series matching a
+	// selector, so that we don't download their index again. Only blocks still existing are kept.
+	prevChecked := c.seriesRetentionChecked[userID]
+	checked := map[seriesRetentionCheck]struct{}{}
+	defer func() {
+		c.seriesRetentionChecked[userID] = checked
+	}()
+
+	now := time.Now()
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		meta := metas[id]
+
+		var pending []string
+		for _, selector := range selectors {
+			if meta.MaxTime > now.Add(-time.Duration(rules[selector])).UnixMilli() {
+				continue
+			}
+			if hasSeriesRetentionApplied(meta, selector) {
+				continue
+			}
+			key := seriesRetentionCheck{blockID: id, selector: selector}
+			if _, ok := prevChecked[key]; ok {
+				checked[key] = struct{}{}
+				continue
+			}
+			pending = append(pending, selector)
+		}
+
+		if len(pending) == 0 {
+			continue
+		}
+
+		// Each block is rewritten by a single compactor.
+		job := NewJob(userID, "series-retention-"+id.String(), labels.FromMap(meta.Thanos.Labels), meta.Thanos.Downsample.Resolution, false, 0, id.String())
+		if ok, err := c.shardingStrategy.ownJob(job); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to check if series retention of block is owned by this compactor", "block", id, "err", err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		deletions, err := c.seriesRetentionDeletions(ctx, userLogger, userBucket, id, pending, matchers)
+		if err != nil {
+			level.Warn(userLogger).Log("msg", "failed to look up series matching series retention selectors in block", "block", id, "err", err)
+			c.seriesRetentionBlocksFailed.Inc()
+			continue
+		}
+
+		if len(deletions) == 0 {
+			for _, selector := range pending {
+				checked[seriesRetentionCheck{blockID: id, selector: selector}] = struct{}{}
+			}
+			continue
+		}
+
+		level.Info(userLogger).Log("msg", "rewriting block to enforce series retention", "block", id, "selectors", len(deletions))
+
+		newID, err := rewriteBlockWithDeletions(ctx, userLogger, userBucket, c.blocksCompactor, c.seriesRetentionDir(), meta, deletions, c.seriesRetentionBlocksMarkedForDeletion)
+		if err != nil {
+			level.Warn(userLogger).Log("msg", "failed to rewrite block to enforce series retention", "block", id, "err", err)
+			c.seriesRetentionBlocksFailed.Inc()
+			continue
+		}
+
+		level.Info(userLogger).Log("msg", "enforced series retention on block", "block", id, "new_block", newID)
+		c.seriesRetentionBlocksRewritten.Inc()
+	}
+
+	return nil
+}
+
+// seriesRetentionDeletions downloads the index of the block and returns the deletion requests for the input
+// selectors having at least one matching series in the block.
+func (c *MultitenantCompactor) seriesRetentionDeletions(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, selectors []string, matchers map[string][]*labels.Matcher) (_ []metadata.DeletionRequest, rerr error) {
+	tmpDir, err := os.MkdirTemp(c.seriesRetentionDir(), "index-"+id.String()+"-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create index dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove index dir", "dir", tmpDir, "err", err)
+		}
+	}()
+
+	indexFile := filepath.Join(tmpDir, block.IndexFilename)
+	if err := objstore.DownloadFile(ctx, logger, bkt, path.Join(id.String(), block.IndexFilename), indexFile); err != nil {
+		return nil, errors.Wrapf(err, "download index of block %s", id)
+	}
+
+	ir, err := index.NewFileReader(indexFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open index of block %s", id)
+	}
+	defer func() {
+		if err := ir.Close(); err != nil && rerr == nil {
+			rerr = errors.Wrapf(err, "close index of block %s", id)
+		}
+	}()
+
+	var deletions []metadata.DeletionRequest
+	for _, selector := range selectors {
+		p, err := tsdb.PostingsForMatchers(ir, matchers[selector]...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "select series matching %s", selector)
+		}
+
+		found := p.Next()
+		if err := p.Err(); err != nil {
+			return nil, errors.Wrapf(err, "select series matching %s", selector)
+		}
+
+		if found {
+			deletions = append(deletions, metadata.DeletionRequest{
+				Matchers:  matchers[selector],
+				RequestID: seriesRetentionRequestIDPrefix + selector,
+			})
+		}
+	}
+
+	return deletions, nil
+}
+
+func (c *MultitenantCompactor) seriesRetentionDir() string {
+	return filepath.Join(c.compactorCfg.DataDir, "series-retention")
+}
+
+// hasSeriesRetentionApplied returns whether the series retention for the input selector has already been
+// enforced on the block.
+func hasSeriesRetentionApplied(meta *metadata.Meta, selector string) bool {
+	for _, r := range meta.Thanos.Rewrites {
+		for _, d := range r.DeletionsApplied {
+			if d.RequestID == seriesRetentionRequestIDPrefix+selector {
+				return true
+			}
+		}
+	}
+	return false
+}
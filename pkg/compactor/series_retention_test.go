@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestMultitenantCompactor_ShouldEnforceSeriesRetention(t *testing.T) {
+	const (
+		userID   = "user-1"
+		selector = `{series_id="0"}`
+	)
+
+	var (
+		ctx        = context.Background()
+		blockRange = 2 * time.Hour
+		now        = time.Now()
+		oldRange   = now.Add(-10 * 24 * time.Hour).Truncate(blockRange)
+		olderRange = now.Add(-12 * 24 * time.Hour).Truncate(blockRange)
+		newRange   = now.Add(-3 * blockRange).Truncate(blockRange)
+	)
+
+	storageDir := t.TempDir()
+	fetcherDir := t.TempDir()
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.Bucket.Backend = bucket.Filesystem
+	storageCfg.Bucket.Filesystem.Directory = storageDir
+
+	compactorCfg := prepareConfig(t)
+	compactorCfg.DataDir = t.TempDir()
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.seriesRetention[userID] = validation.SeriesRetentionRules{selector: model.Duration(7 * 24 * time.Hour)}
+
+	logger := log.NewLogfmtLogger(os.Stdout)
+	reg := prometheus.NewPedanticRegistry()
+
+	bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
+	require.NoError(t, err)
+
+	// Block older than the series retention, containing the series matching the selector and other series.
+	oldBlock := createTSDBBlock(t, bucketClient, userID, oldRange.UnixMilli(), oldRange.Add(blockRange).UnixMilli(), 3, nil)
+
+	// Block older than the series retention, only containing the series matching the selector.
+	olderBlock := createTSDBBlock(t, bucketClient, userID, olderRange.UnixMilli(), olderRange.Add(blockRange).UnixMilli(), 1, nil)
+
+	// Block newer than the series retention.
+	newBlock := createTSDBBlock(t, bucketClient, userID, newRange.UnixMilli(), newRange.Add(blockRange).UnixMilli(), 3, nil)
+
+	c, err := NewMultitenantCompactor(compactorCfg, storageCfg, cfgProvider, logger, reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	// Wait until the first compaction run completed.
+	test.Poll(t, 15*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
+			# TYPE cortex_compactor_runs_completed_total counter
+			cortex_compactor_runs_completed_total 1
+		`), "cortex_compactor_runs_completed_total")
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_series_retention_blocks_rewritten_total Total number of blocks rewritten to delete series older than the per-tenant series retention.
+		# TYPE cortex_compactor_series_retention_blocks_rewritten_total counter
+		cortex_compactor_series_retention_blocks_rewritten_total 2
+
+		# HELP cortex_compactor_series_retention_blocks_failed_total Total number of blocks which failed to be rewritten to delete series older than the per-tenant series retention.
+		# TYPE cortex_compactor_series_retention_blocks_failed_total counter
+		cortex_compactor_series_retention_blocks_failed_total 0
+	`), "cortex_compactor_series_retention_blocks_rewritten_total", "cortex_compactor_series_retention_blocks_failed_total"))
+
+	// List back any (non deleted) block from the storage.
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+	fetcher, err := block.NewMetaFetcher(logger, 1, userBucket, fetcherDir, reg, []block.MetadataFilter{NewExcludeMarkedForDeletionFilter(userBucket)})
+	require.NoError(t, err)
+	metas, partials, err := fetcher.Fetch(ctx)
+	require.NoError(t, err)
+	require.Empty(t, partials)
+
+	// The block only containing the matching series has been deleted, while the other old block has been replaced.
+	require.Len(t, metas, 2)
+	require.NotContains(t, metas, oldBlock)
+	require.NotContains(t, metas, olderBlock)
+	require.Contains(t, metas, newBlock)
+	assert.Equal(t, uint64(3), metas[newBlock].Stats.NumSeries)
+	assert.Empty(t, metas[newBlock].Thanos.Rewrites)
+
+	var rewritten *metadata.Meta
+	for id, m := range metas {
+		if id != newBlock {
+			rewritten = m
+		}
+	}
+
+	assert.Equal(t, oldRange.UnixMilli(), rewritten.MinTime)
+	assert.Equal(t, oldRange.Add(blockRange).UnixMilli(), rewritten.MaxTime)
+	assert.Equal(t, []ulid.ULID{oldBlock}, rewritten.Compaction.Sources)
+	assert.Equal(t, uint64(2), rewritten.Stats.NumSeries)
+	require.Len(t, rewritten.Thanos.Rewrites, 1)
+	require.Len(t, rewritten.Thanos.Rewrites[0].DeletionsApplied, 1)
+	assert.Equal(t, seriesRetentionRequestIDPrefix+selector, rewritten.Thanos.Rewrites[0].DeletionsApplied[0].RequestID)
+	assert.True(t, hasSeriesRetentionApplied(rewritten, selector))
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// TenantsSchedulingSequential compacts each tenant until there's no work left (or the
+	// max compaction time is reached) before moving to the next one.
+	TenantsSchedulingSequential = "sequential"
+
+	// TenantsSchedulingInterleaved compacts tenants in rounds, giving each tenant a bounded
+	// time slice per round, and prioritizing tenants with the oldest uncompacted blocks.
+	TenantsSchedulingInterleaved = "interleaved"
+)
+
+var TenantsSchedulingPolicies = []string{TenantsSchedulingSequential, TenantsSchedulingInterleaved}
+
+// tenantsBacklog keeps track of the compaction backlog of each tenant, expressed as the age of the
+// oldest block found in compaction jobs which have been planned but not executed yet.
+type tenantsBacklog map[string]time.Duration
+
+// update records the backlog for userID based on the input pending jobs. If there are no pending
+// jobs, the tenant backlog is reset to 0.
+func (b tenantsBacklog) update(userID string, now time.Time, pendingJobs []*Job) time.Duration {
+	age := oldestBlockAge(now, pendingJobs)
+	b[userID] = age
+	return age
+}
+
+// sortByPriority sorts the input users by the age of the oldest block waiting to be compacted,
+// oldest first. Users with no known backlog are moved to the front, because their backlog is unknown
+// and could be big. The sorting is stable, to preserve the input order for users with the same priority.
+func (b tenantsBacklog) sortByPriority(users []string) {
+	sort.SliceStable(users, func(i, j int) bool {
+		iAge, iKnown := b[users[i]]
+		jAge, jKnown := b[users[j]]
+
+		if iKnown != jKnown {
+			return !iKnown
+		}
+		return iAge > jAge
+	})
+}
+
+// oldestBlockAge returns the difference between now and the MaxTime of the oldest block in the input jobs,
+// or 0 if there are no jobs.
+func oldestBlockAge(now time.Time, jobs []*Job) time.Duration {
+	var age time.Duration
+
+	for _, j := range jobs {
+		for _, m := range j.Metas() {
+			if delta := now.Sub(time.UnixMilli(m.MaxTime)); delta > age {
+				age = delta
+			}
+		}
+	}
+
+	return age
+}
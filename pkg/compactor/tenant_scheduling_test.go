@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantsBacklog_SortByPriority(t *testing.T) {
+	tests := map[string]struct {
+		backlog  tenantsBacklog
+		input    []string
+		expected []string
+	}{
+		"should preserve the input order on empty backlog": {
+			backlog:  tenantsBacklog{},
+			input:    []string{"user-3", "user-1", "user-2"},
+			expected: []string{"user-3", "user-1", "user-2"},
+		},
+		"should sort users by oldest uncompacted block first": {
+			backlog:  tenantsBacklog{"user-1": time.Hour, "user-2": 3 * time.Hour, "user-3": 2 * time.Hour},
+			input:    []string{"user-1", "user-2", "user-3"},
+			expected: []string{"user-2", "user-3", "user-1"},
+		},
+		"should move users with unknown backlog to the front": {
+			backlog:  tenantsBacklog{"user-1": time.Hour, "user-3": 0},
+			input:    []string{"user-1", "user-2", "user-3", "user-4"},
+			expected: []string{"user-2", "user-4", "user-1", "user-3"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			testData.backlog.sortByPriority(testData.input)
+			assert.Equal(t, testData.expected, testData.input)
+		})
+	}
+}
+
+func TestTenantsBacklog_Update(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	backlog := tenantsBacklog{}
+
+	jobs := []*Job{
+		NewJob("user-1", "job", nil, 0, false, 0, ""),
+		NewJob("user-1", "job", nil, 0, false, 0, ""),
+	}
+	assert.NoError(t, jobs[0].AppendMeta(blockMeta("01DTVP434PA9VFXSW2JKB3392D", now.Add(-4*time.Hour).UnixMilli(), now.Add(-2*time.Hour).UnixMilli(), nil)))
+	assert.NoError(t, jobs[1].AppendMeta(blockMeta("01FN3VCQV5X342W2ZKMQQXAZRX", now.Add(-6*time.Hour).UnixMilli(), now.Add(-3*time.Hour).UnixMilli(), nil)))
+
+	assert.Equal(t, 3*time.Hour, backlog.update("user-1", now, jobs))
+	assert.Equal(t, tenantsBacklog{"user-1": 3 * time.Hour}, backlog)
+
+	// Backlog should be reset once there are no pending jobs.
+	assert.Equal(t, time.Duration(0), backlog.update("user-1", now, nil))
+	assert.Equal(t, tenantsBacklog{"user-1": 0}, backlog)
+}
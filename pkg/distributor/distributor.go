@@ -13,6 +13,7 @@ import (
 	"math"
 	"net/http"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -48,6 +49,7 @@ import (
 	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	util_math "github.com/grafana/mimir/pkg/util/math"
+	"github.com/grafana/mimir/pkg/util/outlierdetection"
 	"github.com/grafana/mimir/pkg/util/push"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -60,7 +62,8 @@ const (
 
 var (
 	// Validation errors.
-	errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value must be greater or equal to zero")
+	errInvalidTenantShardSize              = errors.New("invalid tenant shard size, the value must be greater or equal to zero")
+	errInvalidIngestionSeriesSamplingRatio = errors.New("invalid ingestion series sampling ratio, the value must be greater than 0 and less than or equal to 1")
 
 	// Distributor instance limits errors.
 	errMaxInflightRequestsReached      = errors.New(globalerror.DistributorMaxInflightPushRequests.MessageWithPerInstanceLimitConfig("the write request has been rejected because the distributor exceeded the allowed number of inflight push requests", maxInflightPushRequestsFlag))
@@ -102,6 +105,15 @@ type Distributor struct {
 	// For handling HA replicas.
 	HATracker *haTracker
 
+	// For holding the metrics pushed by short-lived jobs.
+	PushGateway *pushGateway
+
+	// For writing the metrics posted in the Prometheus exposition formats.
+	ExpositionPush *expositionPush
+
+	// For excluding the slow or failing ingesters from the queries.
+	IngesterOutliers *outlierdetection.Detector
+
 	// Per-user rate limiters.
 	requestRateLimiter   *limiter.RateLimiter
 	ingestionRateLimiter *limiter.RateLimiter
@@ -116,6 +128,9 @@ type Distributor struct {
 	inflightPushRequests      atomic.Int64
 	inflightPushRequestsBytes atomic.Int64
 
+	// Per-tenant inflight push requests, tracked only for the tenants with a limit.
+	tenantInflightPushRequests *tenantInflightPushRequests
+
 	// Metrics
 	queryDuration                    *instrument.HistogramCollector
 	ingesterChunksDeduplicated       prometheus.Counter
@@ -129,6 +144,7 @@ type Distributor struct {
 	incomingExemplars                *prometheus.CounterVec
 	incomingMetadata                 *prometheus.CounterVec
 	nonHASamples                     *prometheus.CounterVec
+	sampledOutSamples                *prometheus.CounterVec
 	dedupedSamples                   *prometheus.CounterVec
 	labelsHistogram                  prometheus.Histogram
 	sampleDelayHistogram             prometheus.Histogram
@@ -138,6 +154,7 @@ type Distributor struct {
 	discardedSamplesTooManyHaClusters *prometheus.CounterVec
 	discardedSamplesRateLimited       *prometheus.CounterVec
 	discardedRequestsRateLimited      *prometheus.CounterVec
+	discardedRequestsInflightLimited  *prometheus.CounterVec
 	discardedExemplarsRateLimited     *prometheus.CounterVec
 	discardedMetadataRateLimited      *prometheus.CounterVec
 
@@ -176,6 +193,9 @@ type Config struct {
 
 	// Configuration for forwarding of metrics to alternative ingestion endpoint.
 	Forwarding forwarding.Config
+
+	PushGateway    PushGatewayConfig    `yaml:"push_gateway"`
+	ExpositionPush ExpositionPushConfig `yaml:"exposition_push"`
 }
 
 type InstanceLimits struct {
@@ -190,6 +210,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.HATrackerConfig.RegisterFlags(f)
 	cfg.DistributorRing.RegisterFlags(f, logger)
 	cfg.Forwarding.RegisterFlags(f)
+	cfg.PushGateway.RegisterFlags(f)
+	cfg.ExpositionPush.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "Max message size in bytes that the distributors will accept for incoming push requests to the remote write API. If exceeded, the request will be rejected.")
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 2*time.Second, "Timeout for downstream ingesters.")
@@ -204,11 +226,23 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errInvalidTenantShardSize
 	}
 
+	if limits.IngestionSeriesSamplingRatio <= 0 || limits.IngestionSeriesSamplingRatio > 1 {
+		return errInvalidIngestionSeriesSamplingRatio
+	}
+
 	err := cfg.HATrackerConfig.Validate()
 	if err != nil {
 		return err
 	}
 
+	if err := cfg.PushGateway.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.PoolConfig.OutlierDetection.Validate(); err != nil {
+		return err
+	}
+
 	return cfg.Forwarding.Validate()
 }
 
@@ -220,6 +254,12 @@ const (
 
 // New constructs a new Distributor
 func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Overrides, ingestersRing ring.ReadRing, canJoinDistributorsRing bool, reg prometheus.Registerer, log log.Logger) (*Distributor, error) {
+	ingesterOutliers := outlierdetection.NewDetector(cfg.PoolConfig.OutlierDetection, "ingester", log, reg)
+	if cfg.PoolConfig.OutlierDetection.Enabled {
+		clientConfig.UnaryClientInterceptors = append(clientConfig.UnaryClientInterceptors, ingesterOutliers.UnaryClientInterceptor())
+		clientConfig.StreamClientInterceptors = append(clientConfig.StreamClientInterceptors, ingesterOutliers.StreamClientInterceptor())
+	}
+
 	if cfg.IngesterClientFactory == nil {
 		cfg.IngesterClientFactory = func(addr string) (ring_client.PoolClient, error) {
 			return ingester_client.MakeIngesterClient(addr, clientConfig)
@@ -237,14 +277,16 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	subservices = append(subservices, haTracker)
 
 	d := &Distributor{
-		cfg:                   cfg,
-		log:                   log,
-		ingestersRing:         ingestersRing,
-		ingesterPool:          NewPool(cfg.PoolConfig, ingestersRing, cfg.IngesterClientFactory, log),
-		healthyInstancesCount: atomic.NewUint32(0),
-		limits:                limits,
-		HATracker:             haTracker,
-		ingestionRate:         util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
+		cfg:                        cfg,
+		log:                        log,
+		ingestersRing:              ingestersRing,
+		ingesterPool:               NewPool(cfg.PoolConfig, ingestersRing, cfg.IngesterClientFactory, log),
+		healthyInstancesCount:      atomic.NewUint32(0),
+		tenantInflightPushRequests: newTenantInflightPushRequests(),
+		limits:                     limits,
+		HATracker:                  haTracker,
+		IngesterOutliers:           ingesterOutliers,
+		ingestionRate:              util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
 
 		queryDuration: instrument.NewHistogramCollector(promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "cortex",
@@ -307,6 +349,11 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_non_ha_samples_received_total",
 			Help:      "The total number of received samples for a user that has HA tracking turned on, but the sample didn't contain both HA labels.",
 		}, []string{"user"}),
+		sampledOutSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_sampled_out_samples_total",
+			Help:      "The total number of received samples discarded because their series isn't in the sample of the ingested series.",
+		}, []string{"user"}),
 		dedupedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "cortex",
 			Name:      "distributor_deduped_samples_total",
@@ -350,6 +397,7 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		discardedSamplesTooManyHaClusters: validation.DiscardedSamplesCounter(reg, validation.ReasonTooManyHAClusters),
 		discardedSamplesRateLimited:       validation.DiscardedSamplesCounter(reg, validation.ReasonRateLimited),
 		discardedRequestsRateLimited:      validation.DiscardedRequestsCounter(reg, validation.ReasonRateLimited),
+		discardedRequestsInflightLimited:  validation.DiscardedRequestsCounter(reg, validation.ReasonInflightPushRequestsLimited),
 		discardedExemplarsRateLimited:     validation.DiscardedExemplarsCounter(reg, validation.ReasonRateLimited),
 		discardedMetadataRateLimited:      validation.DiscardedMetadataCounter(reg, validation.ReasonRateLimited),
 
@@ -430,7 +478,21 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 
 	d.pushWithMiddlewares = d.GetPushFunc(nil)
 
+	// The push gateway is an optional feature, if it's disabled then d.PushGateway will be nil.
+	if cfg.PushGateway.Enabled {
+		d.PushGateway = newPushGateway(cfg.PushGateway, limits, cfg.MaxRecvMsgSize, d.Push, reg, log)
+		subservices = append(subservices, d.PushGateway)
+	}
+
+	// The exposition push is an optional feature, if it's disabled then d.ExpositionPush will be nil.
+	if cfg.ExpositionPush.Enabled {
+		d.ExpositionPush = newExpositionPush(limits, cfg.MaxRecvMsgSize, d.Push)
+	}
+
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
+	if cfg.PoolConfig.OutlierDetection.Enabled {
+		subservices = append(subservices, d.IngesterOutliers)
+	}
 	d.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
@@ -523,6 +585,7 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.incomingExemplars.DeleteLabelValues(userID)
 	d.incomingMetadata.DeleteLabelValues(userID)
 	d.nonHASamples.DeleteLabelValues(userID)
+	d.sampledOutSamples.DeleteLabelValues(userID)
 	d.latestSeenSampleTimestampPerUser.DeleteLabelValues(userID)
 
 	d.dedupedSamples.DeletePartialMatch(prometheus.Labels{"user": userID})
@@ -530,6 +593,8 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.discardedSamplesTooManyHaClusters.DeleteLabelValues(userID)
 	d.discardedSamplesRateLimited.DeleteLabelValues(userID)
 	d.discardedRequestsRateLimited.DeleteLabelValues(userID)
+	d.discardedRequestsInflightLimited.DeleteLabelValues(userID)
+	d.tenantInflightPushRequests.removeTenant(userID)
 	d.discardedExemplarsRateLimited.DeleteLabelValues(userID)
 	d.discardedMetadataRateLimited.DeleteLabelValues(userID)
 
@@ -653,7 +718,22 @@ func (d *Distributor) validateSeries(nowt time.Time, ts mimirpb.PreallocTimeseri
 		return nil
 	}
 
+	exemplarFilters := d.limits.ExemplarLabelsFilters(userID).Matchers()
+	exemplarLabelsKeep := d.limits.ExemplarLabelsKeep(userID)
+
 	for i := 0; i < len(ts.Exemplars); {
+		// The exemplars are filtered, and their labels stripped, before being validated, so that
+		// the exemplars which would be discarded anyway don't reject the whole series.
+		if !validation.FilterExemplar(d.exemplarValidationMetrics, userID, exemplarFilters, exemplarLabelsKeep, &ts.Exemplars[i]) {
+			// Delete this exemplar by moving the last one on top and shortening the slice
+			last := len(ts.Exemplars) - 1
+			if i < last {
+				ts.Exemplars[i] = ts.Exemplars[last]
+			}
+			ts.Exemplars = ts.Exemplars[:last]
+			continue
+		}
+
 		e := ts.Exemplars[i]
 		if err := validation.ValidateExemplar(d.exemplarValidationMetrics, userID, ts.Labels, e); err != nil {
 			// An exemplar validation error prevents ingesting samples
@@ -687,6 +767,7 @@ func (d *Distributor) wrapPushWithMiddlewares(externalMiddleware func(next push.
 	middlewares = append(middlewares, d.metricsMiddleware)
 	middlewares = append(middlewares, d.prePushHaDedupeMiddleware)
 	middlewares = append(middlewares, d.prePushRelabelMiddleware)
+	middlewares = append(middlewares, d.prePushSamplingMiddleware)
 	middlewares = append(middlewares, d.prePushValidationMiddleware)
 	middlewares = append(middlewares, d.prePushForwardingMiddleware)
 	if externalMiddleware != nil {
@@ -834,6 +915,112 @@ func (d *Distributor) prePushRelabelMiddleware(next push.Func) push.Func {
 	}
 }
 
+// prePushSamplingMiddleware ingests only a sample of the series of the tenant. The series are sampled by the hash of
+// their labels, so that the same series are always either ingested or discarded.
+func (d *Distributor) prePushSamplingMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		cleanupInDefer := true
+		defer func() {
+			if cleanupInDefer {
+				pushReq.CleanUp()
+			}
+		}()
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ratio := d.limits.IngestionSeriesSamplingRatio(userID)
+		rules := d.limits.IngestionSeriesSamplingRules(userID)
+		if len(req.Timeseries) == 0 || ((ratio <= 0 || ratio >= 1) && len(rules.Ratios()) == 0) {
+			cleanupInDefer = false
+			return next(ctx, pushReq)
+		}
+
+		samplingLabel := d.limits.IngestionSeriesSamplingLabel(userID)
+
+		var removeTsIndexes []int
+		sampledOutSamples := 0
+		for tsIdx := 0; tsIdx < len(req.Timeseries); tsIdx++ {
+			ts := req.Timeseries[tsIdx]
+
+			// The labels are sorted before hashing them, so that the same series is sampled regardless
+			// of the order of its labels.
+			sortLabelsIfNeeded(ts.Labels)
+			lbls := mimirpb.FromLabelAdaptersToLabels(ts.Labels)
+
+			seriesRatio := seriesSamplingRatio(lbls, ratio, rules)
+			if seriesRatio >= 1 {
+				continue
+			}
+
+			if !isSeriesSampledIn(lbls, seriesRatio) {
+				removeTsIndexes = append(removeTsIndexes, tsIdx)
+				sampledOutSamples += len(ts.Samples)
+				continue
+			}
+
+			if samplingLabel != "" {
+				removeLabel(samplingLabel, &ts.Labels)
+				ts.Labels = append(ts.Labels, mimirpb.LabelAdapter{Name: samplingLabel, Value: strconv.FormatFloat(seriesRatio, 'f', -1, 64)})
+				sortLabelsIfNeeded(ts.Labels)
+			}
+		}
+
+		if len(removeTsIndexes) > 0 {
+			for _, removeTsIndex := range removeTsIndexes {
+				mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeTsIndex])
+			}
+			req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeTsIndexes)
+			d.sampledOutSamples.WithLabelValues(userID).Add(float64(sampledOutSamples))
+		}
+
+		cleanupInDefer = false
+		return next(ctx, pushReq)
+	}
+}
+
+// seriesSamplingRatio returns the ratio the series is sampled with: the lowest ratio of the rules whose selector
+// matches the series, or the tenant ratio if none matches. A tenant ratio out of the (0, 1] range disables the sampling.
+func seriesSamplingRatio(lbls labels.Labels, ratio float64, rules validation.SeriesSamplingRules) float64 {
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	matched := false
+	ratios := rules.Ratios()
+	for selector, matchers := range rules.Matchers() {
+		if !seriesMatches(lbls, matchers) {
+			continue
+		}
+		if !matched || ratios[selector] < ratio {
+			ratio = ratios[selector]
+		}
+		matched = true
+	}
+	return ratio
+}
+
+func seriesMatches(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSeriesSampledIn returns whether the series is in the sample of the series ingested with the ratio.
+func isSeriesSampledIn(lbls labels.Labels, ratio float64) bool {
+	return float64(lbls.Hash()) < ratio*math.MaxUint64
+}
+
 func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
 		cleanupInDefer := true
@@ -1090,6 +1277,18 @@ func (d *Distributor) limitsMiddleware(next push.Func) push.Func {
 			return nil, httpgrpc.Errorf(http.StatusTooManyRequests, validation.NewRequestRateLimitedError(d.limits.RequestRate(userID), d.limits.RequestBurstSize(userID)).Error())
 		}
 
+		if maxInflight := d.limits.MaxInflightPushRequestsPerTenant(userID); maxInflight > 0 {
+			done, ok := d.tenantInflightPushRequests.tryStart(userID, maxInflight)
+			if !ok {
+				d.discardedRequestsInflightLimited.WithLabelValues(userID).Add(1)
+
+				// Return a 429 telling the client when to retry and at which rate to send the requests,
+				// so that it can slow down instead of retrying straight away.
+				return nil, newTenantBackpressureError(validation.NewMaxInflightPushRequestsPerTenantError(maxInflight).Error(), maxInflight, d.HealthyInstancesCount(), d.tenantInflightPushRequests.avgDuration(userID))
+			}
+			pushReq.AddCleanup(done)
+		}
+
 		req, err := pushReq.WriteRequest()
 		if err != nil {
 			return nil, err
@@ -1201,6 +1400,11 @@ func (d *Distributor) push(ctx context.Context, pushReq *push.Request) (*mimirpb
 	// Get a subring if tenant has shuffle shard size configured.
 	subRing := d.ingestersRing.ShuffleShard(userID, d.limits.IngestionTenantShardSize(userID))
 
+	// Write the series to less ingesters if the tenant has a lower replication factor.
+	if rf := d.tenantReplicationFactor(userID); rf < d.ingestersRing.ReplicationFactor() {
+		subRing = replicationFactorRing{ReadRing: subRing, replicationFactor: rf}
+	}
+
 	// Use a background context to make sure all ingesters get samples even if we return early
 	localCtx, cancel := context.WithTimeout(context.Background(), d.cfg.RemoteTimeout)
 	localCtx = user.InjectOrgID(localCtx, userID)
@@ -1499,7 +1703,13 @@ func (d *Distributor) LabelValuesCardinality(ctx context.Context, labelNames []m
 // labelValuesCardinality queries ingesters for label values cardinality of a set of labelNames
 // Returns a LabelValuesCardinalityResponse where each item contains an exclusive label name and associated label values
 func (d *Distributor) labelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher) (*ingester_client.LabelValuesCardinalityResponse, error) {
-	replicationSet, err := d.GetIngesters(ctx)
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The slow or failing ingesters can't be excluded, because the response is computed from all the replicas.
+	replicationSet, err := d.getAllIngesters(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1529,7 +1739,7 @@ func (d *Distributor) labelValuesCardinality(ctx context.Context, labelNames []m
 	if err != nil {
 		return nil, err
 	}
-	return cardinalityConcurrentMap.toLabelValuesCardinalityResponse(d.ingestersRing.ReplicationFactor()), nil
+	return cardinalityConcurrentMap.toLabelValuesCardinalityResponse(d.tenantReplicationFactor(userID)), nil
 }
 
 func toLabelValuesCardinalityRequest(labelNames []model.LabelName, matchers []*labels.Matcher) (*ingester_client.LabelValuesCardinalityRequest, error) {
@@ -1726,7 +1936,13 @@ func (d *Distributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetad
 
 // UserStats returns statistics about the current user.
 func (d *Distributor) UserStats(ctx context.Context) (*UserStats, error) {
-	replicationSet, err := d.GetIngesters(ctx)
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The slow or failing ingesters can't be excluded, because the response is computed from all the replicas.
+	replicationSet, err := d.getAllIngesters(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1752,12 +1968,68 @@ func (d *Distributor) UserStats(ctx context.Context) (*UserStats, error) {
 		totalStats.NumSeries += r.NumSeries
 	}
 
-	totalStats.IngestionRate /= float64(d.ingestersRing.ReplicationFactor())
-	totalStats.NumSeries /= uint64(d.ingestersRing.ReplicationFactor())
+	replicationFactor := d.tenantReplicationFactor(userID)
+	totalStats.IngestionRate /= float64(replicationFactor)
+	totalStats.NumSeries /= uint64(replicationFactor)
 
 	return totalStats, nil
 }
 
+// HeadStats returns statistics about the TSDB head of the current user, both aggregated
+// and for each ingester.
+func (d *Distributor) HeadStats(ctx context.Context) (*TenantHeadStats, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The slow or failing ingesters can't be excluded, because the response is computed from all the replicas.
+	replicationSet, err := d.getAllIngesters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure we get a successful response from all of them.
+	replicationSet.MaxErrors = 0
+	replicationSet.MaxUnavailableZones = 0
+
+	req := &ingester_client.HeadStatsRequest{}
+	resps, err := replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+		client, err := d.ingesterPool.GetClientFor(ing.Addr)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.(ingester_client.IngesterClient).HeadStats(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return IngesterHeadStats{Ingester: ing.Addr, HeadStats: headStatsFromResponse(resp)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &TenantHeadStats{Ingesters: make([]IngesterHeadStats, 0, len(resps))}
+	for _, resp := range resps {
+		r := resp.(IngesterHeadStats)
+		res.Ingesters = append(res.Ingesters, r)
+		res.HeadStats.merge(r.HeadStats)
+	}
+
+	// Series and chunks are replicated, while the WAL size is the actual disk utilization across ingesters.
+	replicationFactor := d.tenantReplicationFactor(userID)
+	res.NumSeries /= uint64(replicationFactor)
+	res.NumChunks /= uint64(replicationFactor)
+
+	sort.Slice(res.Ingesters, func(i, j int) bool {
+		return res.Ingesters[i].Ingester < res.Ingesters[j].Ingester
+	})
+
+	return res, nil
+}
+
 // UserIDStats models ingestion statistics for one user, including the user ID
 type UserIDStats struct {
 	UserID string `json:"userID"`
@@ -84,6 +84,18 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: nil,
 		},
+		"should fail if the default ingestion series sampling ratio is 0": {
+			initLimits: func(limits *validation.Limits) {
+				limits.IngestionSeriesSamplingRatio = 0
+			},
+			expected: errInvalidIngestionSeriesSamplingRatio,
+		},
+		"should fail if the default ingestion series sampling ratio is greater than 1": {
+			initLimits: func(limits *validation.Limits) {
+				limits.IngestionSeriesSamplingRatio = 1.5
+			},
+			expected: errInvalidIngestionSeriesSamplingRatio,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -1492,6 +1504,9 @@ func TestDistributor_Push_ExemplarValidation(t *testing.T) {
 }
 
 func TestDistributor_ExemplarValidation(t *testing.T) {
+	traceIDFilters, err := validation.NewExemplarLabelsFilters([]string{`{trace_id=~".+"}`})
+	require.NoError(t, err)
+
 	tests := map[string]struct {
 		prepareConfig     func(limits *validation.Limits)
 		minExemplarTS     int64
@@ -1598,6 +1613,110 @@ func TestDistributor_ExemplarValidation(t *testing.T) {
 				},
 			},
 		},
+		"exemplars not matching the labels filters": {
+			prepareConfig: func(limits *validation.Limits) {
+				limits.MaxGlobalExemplarsPerUser = 2
+				limits.ExemplarLabelsFilters = traceIDFilters
+			},
+			req: &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}}, TimestampMs: 1000},
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}, {Name: "trace_id", Value: "123"}}, TimestampMs: 1000},
+						},
+					},
+				},
+			}},
+			expectedExemplars: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}, {Name: "trace_id", Value: "123"}}, TimestampMs: 1000},
+						},
+					},
+				},
+			},
+		},
+		"exemplar labels not kept": {
+			prepareConfig: func(limits *validation.Limits) {
+				limits.MaxGlobalExemplarsPerUser = 2
+				limits.ExemplarLabelsKeep = []string{"trace_id"}
+			},
+			req: &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}}, TimestampMs: 1000},
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}, {Name: "trace_id", Value: "123"}}, TimestampMs: 1000},
+						},
+					},
+				},
+			}},
+			expectedExemplars: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "123"}}, TimestampMs: 1000},
+						},
+					},
+				},
+			},
+		},
+		"invalid exemplars not matching the labels filters": {
+			prepareConfig: func(limits *validation.Limits) {
+				limits.MaxGlobalExemplarsPerUser = 2
+				limits.ExemplarLabelsFilters = traceIDFilters
+			},
+			req: &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: strings.Repeat("0", 126)}}, TimestampMs: 1000},
+						},
+					},
+				},
+			}},
+			expectedExemplars: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels:    []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{},
+					},
+				},
+			},
+		},
+		"exemplar labels too long until stripped": {
+			prepareConfig: func(limits *validation.Limits) {
+				limits.MaxGlobalExemplarsPerUser = 2
+				limits.ExemplarLabelsKeep = []string{"trace_id"}
+			},
+			req: &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: strings.Repeat("0", 126)}, {Name: "trace_id", Value: "123"}}, TimestampMs: 1000},
+						},
+					},
+				},
+			}},
+			expectedExemplars: []mimirpb.PreallocTimeseries{
+				{
+					TimeSeries: &mimirpb.TimeSeries{
+						Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "test"}},
+						Exemplars: []mimirpb.Exemplar{
+							{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "123"}}, TimestampMs: 1000},
+						},
+					},
+				},
+			},
+		},
 	}
 	now := mtime.Now()
 	for testName, tc := range tests {
@@ -2803,6 +2922,58 @@ func TestDistributor_LabelValuesCardinality_Concurrency(t *testing.T) {
 	})
 }
 
+func TestDistributor_HeadStats(t *testing.T) {
+	const numIngesters = 3
+
+	t.Run("should aggregate the head stats from all ingesters", func(t *testing.T) {
+		ds, _, _ := prepare(t, prepConfig{
+			numIngesters:              numIngesters,
+			happyIngesters:            numIngesters,
+			numDistributors:           1,
+			ingestersSeriesCountTotal: 10,
+		})
+
+		ctx := user.InjectOrgID(context.Background(), "head-stats")
+		res, err := ds[0].HeadStats(ctx)
+		require.NoError(t, err)
+
+		expectedPerIngester := HeadStats{NumSeries: 10, NumChunks: 20, MinTime: 1000, MaxTime: 2000, WALSizeBytes: 100}
+		assert.Equal(t, &TenantHeadStats{
+			// Series and chunks are divided by the replication factor, while the WAL size is not.
+			HeadStats: HeadStats{NumSeries: 10, NumChunks: 20, MinTime: 1000, MaxTime: 2000, WALSizeBytes: 300},
+			Ingesters: []IngesterHeadStats{
+				{Ingester: "0", HeadStats: expectedPerIngester},
+				{Ingester: "1", HeadStats: expectedPerIngester},
+				{Ingester: "2", HeadStats: expectedPerIngester},
+			},
+		}, res)
+	})
+
+	t.Run("should fail with an error if at least one ingester's HeadStats operation fails", func(t *testing.T) {
+		ds, ingesters, _ := prepare(t, prepConfig{
+			numIngesters:    numIngesters,
+			happyIngesters:  numIngesters,
+			numDistributors: 1,
+		})
+
+		// Set the first ingester as unhappy
+		ingesters[0].happy = false
+
+		ctx := user.InjectOrgID(context.Background(), "head-stats")
+		_, err := ds[0].HeadStats(ctx)
+		require.Error(t, err)
+	})
+}
+
+func TestHeadStats_merge(t *testing.T) {
+	stats := HeadStats{}
+	stats.merge(HeadStats{NumSeries: 1, MinTime: 20, MaxTime: 30, OutOfOrderTimeWindowMs: 10})
+	stats.merge(HeadStats{NumSeries: 2, MinTime: 10, MaxTime: 25, OOOMinTime: 5, OOOMaxTime: 8})
+	stats.merge(HeadStats{NumSeries: 3})
+
+	assert.Equal(t, HeadStats{NumSeries: 6, MinTime: 10, MaxTime: 30, OOOMinTime: 5, OOOMaxTime: 8, OutOfOrderTimeWindowMs: 10}, stats)
+}
+
 func TestHaDedupeMiddleware(t *testing.T) {
 	ctxWithUser := user.InjectOrgID(context.Background(), "user")
 	const replica1 = "replicaA"
@@ -3122,6 +3293,111 @@ func TestRelabelMiddleware(t *testing.T) {
 	}
 }
 
+func TestSamplingMiddleware(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	const numSeries = 1000
+
+	makeRequest := func() *mimirpb.WriteRequest {
+		return makeWriteRequestForGenerators(numSeries, func(id int) []mimirpb.LabelAdapter {
+			job := "high"
+			if id%2 == 0 {
+				job = "low"
+			}
+			return []mimirpb.LabelAdapter{{Name: "__name__", Value: "metric"}, {Name: "job", Value: job}, {Name: "series", Value: strconv.Itoa(id)}}
+		}, nil, nil)
+	}
+
+	tests := map[string]struct {
+		ratio         float64
+		rules         map[string]float64
+		samplingLabel string
+		expectedRatio map[string]float64 // By job.
+	}{
+		"should ingest all the series if the sampling is disabled": {
+			ratio:         1,
+			expectedRatio: map[string]float64{"low": 1, "high": 1},
+		},
+		"should ingest a sample of all the series": {
+			ratio:         0.2,
+			expectedRatio: map[string]float64{"low": 0.2, "high": 0.2},
+		},
+		"should ingest a sample of the series matching the rules": {
+			ratio:         1,
+			rules:         map[string]float64{`{job="low"}`: 0.2},
+			expectedRatio: map[string]float64{"low": 0.2, "high": 1},
+		},
+		"should ingest a sample of the series matching the rules with the lowest ratio": {
+			ratio:         0.5,
+			rules:         map[string]float64{`{job="low"}`: 0.2, `metric`: 0.8},
+			samplingLabel: "sampling_ratio",
+			expectedRatio: map[string]float64{"low": 0.2, "high": 0.8},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var gotReqs []*mimirpb.WriteRequest
+			next := func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+				req, err := pushReq.WriteRequest()
+				require.NoError(t, err)
+				gotReqs = append(gotReqs, req)
+				pushReq.CleanUp()
+				return nil, nil
+			}
+
+			rules, err := validation.NewSeriesSamplingRules(testData.rules)
+			require.NoError(t, err)
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.IngestionSeriesSamplingRatio = testData.ratio
+			limits.IngestionSeriesSamplingRules = rules
+			limits.IngestionSeriesSamplingLabel = testData.samplingLabel
+			ds, _, _ := prepare(t, prepConfig{
+				numDistributors: 1,
+				limits:          &limits,
+			})
+			middleware := ds[0].prePushSamplingMiddleware(next)
+
+			// The same series should be sampled across requests, regardless of the order of their labels, which
+			// are sorted by the middleware when sampling.
+			sampling := testData.ratio < 1 || len(testData.rules) > 0
+			for i := 0; i < 2; i++ {
+				req := makeRequest()
+				if i == 1 && sampling {
+					for _, ts := range req.Timeseries {
+						for l, r := 0, len(ts.Labels)-1; l < r; l, r = l+1, r-1 {
+							ts.Labels[l], ts.Labels[r] = ts.Labels[r], ts.Labels[l]
+						}
+					}
+				}
+				_, err := middleware(ctx, push.NewParsedRequest(req))
+				require.NoError(t, err)
+			}
+			require.Len(t, gotReqs, 2)
+			assert.Equal(t, gotReqs[0], gotReqs[1])
+
+			ingested := map[string]int{}
+			for _, ts := range gotReqs[0].Timeseries {
+				lbls := mimirpb.FromLabelAdaptersToLabels(ts.Labels)
+				ingested[lbls.Get("job")]++
+
+				require.True(t, sort.SliceIsSorted(ts.Labels, func(i, j int) bool { return ts.Labels[i].Name < ts.Labels[j].Name }))
+				if testData.samplingLabel != "" {
+					assert.Equal(t, strconv.FormatFloat(testData.expectedRatio[lbls.Get("job")], 'f', -1, 64), lbls.Get(testData.samplingLabel))
+				}
+			}
+
+			sampledOut := 0
+			for job, ratio := range testData.expectedRatio {
+				assert.InDelta(t, ratio*numSeries/2, ingested[job], numSeries*0.05, job)
+				sampledOut += numSeries/2 - ingested[job]
+			}
+			assert.Equal(t, float64(sampledOut*2), testutil.ToFloat64(ds[0].sampledOutSamples.WithLabelValues("user")))
+		})
+	}
+}
+
 func TestHaDedupeAndRelabelBeforeForwarding(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 	const replica1 = "replicaA"
@@ -4206,6 +4482,20 @@ func (i *mockIngester) UserStats(ctx context.Context, in *client.UserStatsReques
 	}, nil
 }
 
+func (i *mockIngester) HeadStats(ctx context.Context, in *client.HeadStatsRequest, opts ...grpc.CallOption) (*client.HeadStatsResponse, error) {
+	if !i.happy {
+		return nil, errFail
+	}
+
+	return &client.HeadStatsResponse{
+		NumSeries:    i.seriesCountTotal,
+		NumChunks:    2 * i.seriesCountTotal,
+		MinTimeMs:    1000,
+		MaxTimeMs:    2000,
+		WalSizeBytes: 100,
+	}, nil
+}
+
 func match(labels []mimirpb.LabelAdapter, matchers []*labels.Matcher) bool {
 outer:
 	for _, matcher := range matchers {
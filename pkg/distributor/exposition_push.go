@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+// ExpositionPushConfig configures the exposition push endpoint, which accepts metrics in the Prometheus
+// exposition formats, like the ones scraped from a federation endpoint, and writes them like a remote write request.
+type ExpositionPushConfig struct {
+	Enabled bool `yaml:"enabled" category:"experimental"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *ExpositionPushConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.exposition-push.enabled", false, "Enable the exposition push endpoint at /api/v1/push/exposition, accepting metrics in the Prometheus text or protobuf exposition format, for example the output of a federation endpoint. The metrics are converted and written like a remote write request.")
+}
+
+type expositionPushLimits interface {
+	ExpositionPushHonorTimestamps(userID string) bool
+	ExpositionPushMaxSeriesPerRequest(userID string) int
+}
+
+// expositionPush writes the metrics posted in the Prometheus exposition formats.
+type expositionPush struct {
+	limits         expositionPushLimits
+	maxRecvMsgSize int
+	push           func(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
+}
+
+func newExpositionPush(limits expositionPushLimits, maxRecvMsgSize int, push func(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)) *expositionPush {
+	return &expositionPush{
+		limits:         limits,
+		maxRecvMsgSize: maxRecvMsgSize,
+		push:           push,
+	}
+}
+
+func (p *expositionPush) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		push.WriteErrorResponse(w, r, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	families, err := decodeExpositionMetricFamilies(r, p.maxRecvMsgSize)
+	if err != nil {
+		if util.IsRequestBodyTooLarge(err) {
+			push.WriteErrorResponse(w, r, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		push.WriteErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := expositionWriteRequest(families, p.limits.ExpositionPushHonorTimestamps(userID), time.Now())
+	if limit := p.limits.ExpositionPushMaxSeriesPerRequest(userID); limit > 0 && len(req.Timeseries) > limit {
+		mimirpb.ReuseSlice(req.Timeseries)
+		push.WriteErrorResponse(w, r, fmt.Sprintf("the push has been rejected because it contains %d series, exceeding the limit of %d series per request (limit: -distributor.exposition-push.max-series-per-request)", len(req.Timeseries), limit), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := p.push(r.Context(), req); err != nil {
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			push.WriteErrorResponse(w, r, string(resp.Body), int(resp.Code))
+			return
+		}
+		push.WriteErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// expositionWriteRequest converts the metric families to a write request, with the metadata of each family.
+// The samples without a timestamp, or all of them if honorTimestamps is false, are written with the input time.
+// The series are allocated from the pool because the push cleanup returns them to it.
+func expositionWriteRequest(families []*dto.MetricFamily, honorTimestamps bool, now time.Time) *mimirpb.WriteRequest {
+	req := &mimirpb.WriteRequest{Source: mimirpb.API}
+	nowMs := util.TimeToMillis(now)
+
+	for _, mf := range families {
+		name := mf.GetName()
+		req.Metadata = append(req.Metadata, &mimirpb.MetricMetadata{
+			Type:             expositionMetadataType(mf.GetType()),
+			MetricFamilyName: name,
+			Help:             mf.GetHelp(),
+		})
+
+		for _, m := range mf.GetMetric() {
+			ts := nowMs
+			if honorTimestamps && m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+
+			b := labels.NewBuilder(nil)
+			for _, pair := range m.GetLabel() {
+				b.Set(pair.GetName(), pair.GetValue())
+			}
+			lbls := b.Labels(nil)
+
+			expandExpositionMetric(name, mf.GetType(), m, func(series string, v float64, extra ...string) {
+				b := labels.NewBuilder(lbls)
+				b.Set(labels.MetricName, series)
+				for i := 0; i+1 < len(extra); i += 2 {
+					b.Set(extra[i], extra[i+1])
+				}
+
+				t := mimirpb.TimeseriesFromPool()
+				t.Labels = append(t.Labels, mimirpb.FromLabelsToLabelAdapters(b.Labels(nil))...)
+				t.Samples = append(t.Samples, mimirpb.Sample{TimestampMs: ts, Value: v})
+				req.Timeseries = append(req.Timeseries, mimirpb.PreallocTimeseries{TimeSeries: t})
+			})
+		}
+	}
+
+	return req
+}
+
+func expositionMetadataType(t dto.MetricType) mimirpb.MetricMetadata_MetricType {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return mimirpb.COUNTER
+	case dto.MetricType_GAUGE:
+		return mimirpb.GAUGE
+	case dto.MetricType_SUMMARY:
+		return mimirpb.SUMMARY
+	case dto.MetricType_HISTOGRAM:
+		return mimirpb.HISTOGRAM
+	default:
+		return mimirpb.UNKNOWN
+	}
+}
+
+// decodeExpositionMetricFamilies decodes the metric families in the request body, in any of the
+// Prometheus exposition formats. The families are sorted by name, given the text format decoder
+// doesn't preserve their order.
+func decodeExpositionMetricFamilies(r *http.Request, maxRecvMsgSize int) ([]*dto.MetricFamily, error) {
+	body := http.MaxBytesReader(nil, r.Body, int64(maxRecvMsgSize))
+	defer body.Close()
+
+	var families []*dto.MetricFamily
+	dec := expfmt.NewDecoder(body, expfmt.ResponseFormat(r.Header))
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+				return families, nil
+			}
+			return nil, err
+		}
+		families = append(families, mf)
+	}
+}
+
+// expandExpositionMetric calls add for each series of the metric m of the family name, with the
+// series name, value and additional label name and value pairs (the quantile or bucket label).
+func expandExpositionMetric(name string, typ dto.MetricType, m *dto.Metric, add func(series string, v float64, extra ...string)) {
+	switch typ {
+	case dto.MetricType_COUNTER:
+		add(name, m.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		add(name, m.GetGauge().GetValue())
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		for _, q := range s.GetQuantile() {
+			add(name, q.GetValue(), model.QuantileLabel, formatExpositionFloat(q.GetQuantile()))
+		}
+		add(name+"_sum", s.GetSampleSum())
+		add(name+"_count", float64(s.GetSampleCount()))
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		infSeen := false
+		for _, bucket := range h.GetBucket() {
+			if math.IsInf(bucket.GetUpperBound(), +1) {
+				infSeen = true
+			}
+			add(name+"_bucket", float64(bucket.GetCumulativeCount()), model.BucketLabel, formatExpositionFloat(bucket.GetUpperBound()))
+		}
+		if !infSeen {
+			add(name+"_bucket", float64(h.GetSampleCount()), model.BucketLabel, formatExpositionFloat(math.Inf(+1)))
+		}
+		add(name+"_sum", h.GetSampleSum())
+		add(name+"_count", float64(h.GetSampleCount()))
+	default:
+		add(name, m.GetUntyped().GetValue())
+	}
+}
+
+func formatExpositionFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
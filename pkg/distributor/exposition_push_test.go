@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestExpositionWriteRequest(t *testing.T) {
+	const body = `
+# HELP http_requests_total Total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{job="api",instance="a:80"} 10 1600000000000
+http_requests_total{job="api",instance="b:80"} 20
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{job="api",le="0.5"} 4 1600000000000
+request_duration_seconds_sum{job="api"} 1.5 1600000000000
+request_duration_seconds_count{job="api"} 4 1600000000000
+`
+	now := time.Unix(1700000000, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/push/exposition", strings.NewReader(body))
+	families, err := decodeExpositionMetricFamilies(req, 1024*1024)
+	require.NoError(t, err)
+
+	for _, honorTimestamps := range []bool{true, false} {
+		t.Run(fmt.Sprintf("honor timestamps: %t", honorTimestamps), func(t *testing.T) {
+			ts := func(ms int64) int64 {
+				if honorTimestamps {
+					return ms
+				}
+				return util.TimeToMillis(now)
+			}
+
+			wr := expositionWriteRequest(families, honorTimestamps, now)
+			assert.Equal(t, map[string]mimirpb.Sample{
+				`{__name__="http_requests_total", instance="a:80", job="api"}`:       {TimestampMs: ts(1600000000000), Value: 10},
+				`{__name__="http_requests_total", instance="b:80", job="api"}`:       {TimestampMs: util.TimeToMillis(now), Value: 20},
+				`{__name__="request_duration_seconds_bucket", job="api", le="0.5"}`:  {TimestampMs: ts(1600000000000), Value: 4},
+				`{__name__="request_duration_seconds_bucket", job="api", le="+Inf"}`: {TimestampMs: ts(1600000000000), Value: 4},
+				`{__name__="request_duration_seconds_sum", job="api"}`:               {TimestampMs: ts(1600000000000), Value: 1.5},
+				`{__name__="request_duration_seconds_count", job="api"}`:             {TimestampMs: ts(1600000000000), Value: 4},
+			}, writeRequestSamples(t, wr))
+
+			assert.Equal(t, []*mimirpb.MetricMetadata{
+				{Type: mimirpb.COUNTER, MetricFamilyName: "http_requests_total", Help: "Total number of HTTP requests."},
+				{Type: mimirpb.HISTOGRAM, MetricFamilyName: "request_duration_seconds"},
+			}, wr.Metadata)
+		})
+	}
+}
+
+func TestExpositionPush(t *testing.T) {
+	limits := &expositionPushLimitsMock{honorTimestamps: true, maxSeriesPerRequest: 2}
+	pusher := &pushGatewayPusherMock{}
+	p := newExpositionPush(limits, 1024*1024, pusher.push)
+
+	send := func(contentType string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/push/exposition", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Text format.
+	require.Equal(t, http.StatusOK, send(string(expfmt.FmtText), []byte("up{job=\"a\"} 1 1000\n")))
+	assert.Equal(t, map[string]mimirpb.Sample{`{__name__="up", job="a"}`: {TimestampMs: 1000, Value: 1}}, writeRequestSamples(t, pusher.writes["user-1"][0]))
+
+	// Protobuf delimited format.
+	buf := &bytes.Buffer{}
+	enc := expfmt.NewEncoder(buf, expfmt.FmtProtoDelim)
+	require.NoError(t, enc.Encode(&dto.MetricFamily{
+		Name:   proto.String("up"),
+		Type:   dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{Label: []*dto.LabelPair{{Name: proto.String("job"), Value: proto.String("b")}}, Gauge: &dto.Gauge{Value: proto.Float64(0)}, TimestampMs: proto.Int64(2000)}},
+	}))
+	require.Equal(t, http.StatusOK, send(string(expfmt.FmtProtoDelim), buf.Bytes()))
+	assert.Equal(t, map[string]mimirpb.Sample{`{__name__="up", job="b"}`: {TimestampMs: 2000, Value: 0}}, writeRequestSamples(t, pusher.writes["user-1"][1]))
+
+	// Requests exceeding the max series per request limit are rejected.
+	require.Equal(t, http.StatusBadRequest, send(string(expfmt.FmtText), []byte("a 1\nb 1\nc 1\n")))
+	assert.Len(t, pusher.writes["user-1"], 2)
+
+	// Malformed requests are rejected.
+	require.Equal(t, http.StatusBadRequest, send(string(expfmt.FmtText), []byte("a{ 1\n")))
+}
+
+type expositionPushLimitsMock struct {
+	honorTimestamps     bool
+	maxSeriesPerRequest int
+}
+
+func (m *expositionPushLimitsMock) ExpositionPushHonorTimestamps(string) bool {
+	return m.honorTimestamps
+}
+
+func (m *expositionPushLimitsMock) ExpositionPushMaxSeriesPerRequest(string) int {
+	return m.maxSeriesPerRequest
+}
+
+func writeRequestSamples(t *testing.T, req *mimirpb.WriteRequest) map[string]mimirpb.Sample {
+	out := map[string]mimirpb.Sample{}
+	for _, ts := range req.Timeseries {
+		require.Len(t, ts.Samples, 1)
+		out[mimirpb.FromLabelAdaptersToLabels(ts.Labels).String()] = ts.Samples[0]
+	}
+	return out
+}
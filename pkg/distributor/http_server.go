@@ -8,6 +8,7 @@ package distributor
 import (
 	"net/http"
 
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/util"
 )
 
@@ -19,6 +20,89 @@ type UserStats struct {
 	RuleIngestionRate float64 `json:"RuleIngestionRate"`
 }
 
+// HeadStats models the TSDB head statistics of one user. Timestamps are in milliseconds,
+// and are zero if the head (or the out-of-order head) is empty.
+type HeadStats struct {
+	NumSeries              uint64 `json:"numSeries"`
+	NumChunks              uint64 `json:"numChunks"`
+	MinTime                int64  `json:"minTime"`
+	MaxTime                int64  `json:"maxTime"`
+	OOOMinTime             int64  `json:"oooMinTime"`
+	OOOMaxTime             int64  `json:"oooMaxTime"`
+	OutOfOrderTimeWindowMs int64  `json:"outOfOrderTimeWindowMs"`
+	WALSizeBytes           int64  `json:"walSizeBytes"`
+	WBLSizeBytes           int64  `json:"wblSizeBytes"`
+}
+
+func headStatsFromResponse(resp *ingester_client.HeadStatsResponse) HeadStats {
+	return HeadStats{
+		NumSeries:              resp.NumSeries,
+		NumChunks:              resp.NumChunks,
+		MinTime:                resp.MinTimeMs,
+		MaxTime:                resp.MaxTimeMs,
+		OOOMinTime:             resp.OooMinTimeMs,
+		OOOMaxTime:             resp.OooMaxTimeMs,
+		OutOfOrderTimeWindowMs: resp.OutOfOrderTimeWindowMs,
+		WALSizeBytes:           resp.WalSizeBytes,
+		WBLSizeBytes:           resp.WblSizeBytes,
+	}
+}
+
+// merge adds the input stats to s, widening the time ranges to include the input ones.
+func (s *HeadStats) merge(other HeadStats) {
+	s.NumSeries += other.NumSeries
+	s.NumChunks += other.NumChunks
+	s.WALSizeBytes += other.WALSizeBytes
+	s.WBLSizeBytes += other.WBLSizeBytes
+	s.MinTime, s.MaxTime = mergeTimeRange(s.MinTime, s.MaxTime, other.MinTime, other.MaxTime)
+	s.OOOMinTime, s.OOOMaxTime = mergeTimeRange(s.OOOMinTime, s.OOOMaxTime, other.OOOMinTime, other.OOOMaxTime)
+
+	if other.OutOfOrderTimeWindowMs > s.OutOfOrderTimeWindowMs {
+		s.OutOfOrderTimeWindowMs = other.OutOfOrderTimeWindowMs
+	}
+}
+
+// mergeTimeRange merges two time ranges, where an empty range is represented by zero timestamps.
+func mergeTimeRange(minA, maxA, minB, maxB int64) (int64, int64) {
+	if minA == 0 && maxA == 0 {
+		return minB, maxB
+	}
+	if minB == 0 && maxB == 0 {
+		return minA, maxA
+	}
+	if minB < minA {
+		minA = minB
+	}
+	if maxB > maxA {
+		maxA = maxB
+	}
+	return minA, maxA
+}
+
+// IngesterHeadStats models the TSDB head statistics of one user in one ingester.
+type IngesterHeadStats struct {
+	Ingester string `json:"ingester"`
+	HeadStats
+}
+
+// TenantHeadStats models the TSDB head statistics of one user, aggregated across all ingesters
+// and for each ingester. The aggregated number of series and chunks is divided by the replication factor.
+type TenantHeadStats struct {
+	HeadStats
+	Ingesters []IngesterHeadStats `json:"ingesters"`
+}
+
+// HeadStatsHandler handles the TSDB head stats of the user.
+func (d *Distributor) HeadStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := d.HeadStats(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, stats)
+}
+
 // UserStatsHandler handles user stats to the Distributor.
 func (d *Distributor) UserStatsHandler(w http.ResponseWriter, r *http.Request) {
 	stats, err := d.UserStats(r.Context())
@@ -14,6 +14,8 @@ import (
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util/outlierdetection"
 )
 
 //lint:ignore faillint It's non-trivial to remove this global variable.
@@ -28,12 +30,15 @@ type PoolConfig struct {
 	ClientCleanupPeriod  time.Duration `yaml:"client_cleanup_period" category:"advanced"`
 	HealthCheckIngesters bool          `yaml:"health_check_ingesters" category:"advanced"`
 	RemoteTimeout        time.Duration `yaml:"-"`
+
+	OutlierDetection outlierdetection.Config `yaml:"outlier_detection"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *PoolConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.ClientCleanupPeriod, "distributor.client-cleanup-period", 15*time.Second, "How frequently to clean up clients for ingesters that have gone away.")
 	f.BoolVar(&cfg.HealthCheckIngesters, "distributor.health-check-ingesters", true, "Run a health check on each ingester client during periodic cleanup.")
+	cfg.OutlierDetection.RegisterFlagsWithPrefix("distributor.ingester-outlier-detection", "ingesters", f)
 }
 
 func NewPool(cfg PoolConfig, ring ring.ReadRing, factory ring_client.PoolFactory, logger log.Logger) *ring_client.Pool {
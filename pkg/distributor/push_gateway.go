@@ -0,0 +1,481 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+const (
+	// pushGatewayPushTimeMetric is the metric exposing, for each group, the time of the last successful push.
+	pushGatewayPushTimeMetric = "push_time_seconds"
+
+	pushGatewayBase64Suffix = "@base64"
+)
+
+// PushGatewayConfig configures the push gateway endpoint, which holds the metrics pushed by short-lived jobs
+// and periodically writes them to the ingesters with the current timestamp.
+type PushGatewayConfig struct {
+	Enabled       bool          `yaml:"enabled" category:"experimental"`
+	WriteInterval time.Duration `yaml:"write_interval" category:"experimental"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *PushGatewayConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.push-gateway.enabled", false, "Enable the push gateway endpoint at /pushgateway/metrics/job/<job>, accepting metrics pushed by short-lived jobs with the Prometheus Pushgateway API. The endpoint must be enabled for each tenant with -distributor.push-gateway.tenant-enabled. The distributor holds the last pushed metrics of each group in memory and writes them periodically with the current timestamp: the groups are not shared with the other distributors and are lost when the distributor restarts, so clients must push a given group to the same distributor.")
+	f.DurationVar(&cfg.WriteInterval, "distributor.push-gateway.write-interval", 15*time.Second, "How frequently the series held by the push gateway endpoint are written to the ingesters.")
+}
+
+// Validate config and returns error on failure.
+func (cfg *PushGatewayConfig) Validate() error {
+	if cfg.Enabled && cfg.WriteInterval <= 0 {
+		return errors.New("the push gateway write interval must be greater than 0")
+	}
+	return nil
+}
+
+type pushGatewayLimits interface {
+	PushGatewayEnabled(userID string) bool
+	PushGatewayStalenessPeriod(userID string) time.Duration
+	PushGatewayMaxSeries(userID string) int
+}
+
+// pushGatewaySeries is a series held by the push gateway, along with the name of the metric family
+// it has been pushed with.
+type pushGatewaySeries struct {
+	family string
+	labels []mimirpb.LabelAdapter
+	value  float64
+}
+
+// pushGatewayTenantLock is the lock of a tenant, along with the number of its holders and waiters,
+// so that it's removed once unused.
+type pushGatewayTenantLock struct {
+	sync.Mutex
+	refs int
+}
+
+type pushGatewayGroup struct {
+	lastPush time.Time
+	series   map[string]pushGatewaySeries
+}
+
+// pushGateway implements a Prometheus Pushgateway compatible API. The pushed series are held in memory
+// and written periodically with the current timestamp, so that they're exposed as continuous series.
+// The groups are held only by the distributor receiving the pushes, and are lost when it restarts.
+type pushGateway struct {
+	services.Service
+
+	cfg            PushGatewayConfig
+	limits         pushGatewayLimits
+	maxRecvMsgSize int
+	push           func(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
+	logger         log.Logger
+
+	mtx sync.Mutex
+	// Groups by tenant and grouping key.
+	groups map[string]map[string]*pushGatewayGroup
+	// Per-tenant locks serializing the updates and the periodic writes of the groups, from the computation
+	// of the written series through the write and the commit, so that they don't overwrite each other.
+	tenantLocks map[string]*pushGatewayTenantLock
+
+	series       *prometheus.GaugeVec
+	failedWrites *prometheus.CounterVec
+}
+
+func newPushGateway(cfg PushGatewayConfig, limits pushGatewayLimits, maxRecvMsgSize int, push func(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error), reg prometheus.Registerer, logger log.Logger) *pushGateway {
+	p := &pushGateway{
+		cfg:            cfg,
+		limits:         limits,
+		maxRecvMsgSize: maxRecvMsgSize,
+		push:           push,
+		logger:         logger,
+		groups:         map[string]map[string]*pushGatewayGroup{},
+		tenantLocks:    map[string]*pushGatewayTenantLock{},
+
+		series: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_push_gateway_series",
+			Help: "Number of series held by the push gateway endpoint.",
+		}, []string{"user"}),
+		failedWrites: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_push_gateway_failed_writes_total",
+			Help: "Total number of periodic writes of the series held by the push gateway endpoint which failed.",
+		}, []string{"user"}),
+	}
+
+	p.Service = services.NewTimerService(cfg.WriteInterval, nil, p.iteration, nil)
+	return p
+}
+
+func (p *pushGateway) iteration(ctx context.Context) error {
+	p.writeAll(ctx, time.Now())
+	return nil
+}
+
+// writeAll writes the series of all tenants with the input timestamp, marking as stale the series of the
+// groups not pushed within the staleness period.
+func (p *pushGateway) writeAll(ctx context.Context, now time.Time) {
+	p.mtx.Lock()
+	userIDs := make([]string, 0, len(p.groups))
+	for userID := range p.groups {
+		userIDs = append(userIDs, userID)
+	}
+	p.mtx.Unlock()
+
+	for _, userID := range userIDs {
+		p.write(ctx, userID, now)
+	}
+}
+
+// write writes the series of the tenant with the input timestamp, marking as stale the series of the
+// groups not pushed within the staleness period.
+func (p *pushGateway) write(ctx context.Context, userID string, now time.Time) {
+	unlock := p.lockTenant(userID)
+	defer unlock()
+
+	p.mtx.Lock()
+	staleness := p.limits.PushGatewayStalenessPeriod(userID)
+
+	var series []mimirpb.PreallocTimeseries
+	groups := p.groups[userID]
+	for key, g := range groups {
+		if staleness > 0 && now.Sub(g.lastPush) > staleness {
+			series = appendPushGatewaySeries(series, g.series, true, now)
+			delete(groups, key)
+			continue
+		}
+		series = appendPushGatewaySeries(series, g.series, false, now)
+	}
+
+	if len(groups) == 0 {
+		delete(p.groups, userID)
+	}
+	p.updateSeriesMetric(userID)
+	p.mtx.Unlock()
+
+	if len(series) == 0 {
+		return
+	}
+	if _, err := p.push(user.InjectOrgID(ctx, userID), &mimirpb.WriteRequest{Timeseries: series, Source: mimirpb.API}); err != nil {
+		p.failedWrites.WithLabelValues(userID).Inc()
+		level.Warn(p.logger).Log("msg", "failed to write the series held by the push gateway", "user", userID, "err", err)
+	}
+}
+
+// lockTenant locks the tenant and returns the function unlocking it. The lock of the tenant is removed
+// once there are no more holders or waiters.
+func (p *pushGateway) lockTenant(userID string) (unlock func()) {
+	p.mtx.Lock()
+	l, ok := p.tenantLocks[userID]
+	if !ok {
+		l = &pushGatewayTenantLock{}
+		p.tenantLocks[userID] = l
+	}
+	l.refs++
+	p.mtx.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(p.tenantLocks, userID)
+		}
+	}
+}
+
+// updateSeriesMetric must be called with the lock held.
+func (p *pushGateway) updateSeriesMetric(userID string) {
+	groups, ok := p.groups[userID]
+	if !ok {
+		p.series.DeleteLabelValues(userID)
+		return
+	}
+
+	count := 0
+	for _, g := range groups {
+		count += len(g.series)
+	}
+	p.series.WithLabelValues(userID).Set(float64(count))
+}
+
+// ServeHTTP implements the Pushgateway API: PUT replaces all the metrics of a group, POST replaces the metrics
+// with the same name of the pushed ones, and DELETE deletes the group.
+func (p *pushGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		push.WriteErrorResponse(w, r, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !p.limits.PushGatewayEnabled(userID) {
+		push.WriteErrorResponse(w, r, "the push gateway is disabled for the tenant", http.StatusBadRequest)
+		return
+	}
+
+	idx := strings.Index(r.URL.Path, "/metrics/")
+	if idx < 0 {
+		push.WriteErrorResponse(w, r, "invalid push gateway path", http.StatusNotFound)
+		return
+	}
+	grouping, err := parsePushGatewayGroupingKey(r.URL.Path[idx+len("/metrics/"):])
+	if err != nil {
+		push.WriteErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var pushed map[string]pushGatewaySeries
+	if r.Method != http.MethodDelete {
+		families, err := decodeExpositionMetricFamilies(r, p.maxRecvMsgSize)
+		if err != nil {
+			if util.IsRequestBodyTooLarge(err) {
+				push.WriteErrorResponse(w, r, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			push.WriteErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		pushed, err = pushGatewaySeriesFromFamilies(families, grouping, time.Now())
+		if err != nil {
+			push.WriteErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := p.update(r.Context(), userID, grouping, r.Method, pushed, time.Now()); err != nil {
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			push.WriteErrorResponse(w, r, string(resp.Body), int(resp.Code))
+			return
+		}
+		push.WriteErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// update applies a push or a deletion of a group, writing the pushed series and marking as stale the removed ones.
+// The group is updated only if the write succeeds.
+func (p *pushGateway) update(ctx context.Context, userID string, grouping labels.Labels, method string, pushed map[string]pushGatewaySeries, now time.Time) error {
+	key := grouping.String()
+
+	unlock := p.lockTenant(userID)
+	defer unlock()
+
+	p.mtx.Lock()
+	var current map[string]pushGatewaySeries
+	if g, ok := p.groups[userID][key]; ok {
+		current = g.series
+	}
+
+	updated := map[string]pushGatewaySeries{}
+	if method == http.MethodPost {
+		// Keep the series of the metric families which haven't been pushed.
+		pushedFamilies := map[string]struct{}{}
+		for _, s := range pushed {
+			pushedFamilies[s.family] = struct{}{}
+		}
+		for k, s := range current {
+			if _, ok := pushedFamilies[s.family]; !ok {
+				updated[k] = s
+			}
+		}
+	}
+	for k, s := range pushed {
+		updated[k] = s
+	}
+
+	removed := map[string]pushGatewaySeries{}
+	for k, s := range current {
+		if _, ok := updated[k]; !ok {
+			removed[k] = s
+		}
+	}
+
+	if limit := p.limits.PushGatewayMaxSeries(userID); limit > 0 {
+		total := len(updated)
+		for k, g := range p.groups[userID] {
+			if k != key {
+				total += len(g.series)
+			}
+		}
+		if total > limit {
+			p.mtx.Unlock()
+			return httpgrpc.Errorf(http.StatusBadRequest, "the push has been rejected because the tenant would exceed the limit of %d series held by the push gateway (limit: -distributor.push-gateway.max-series)", limit)
+		}
+	}
+	p.mtx.Unlock()
+
+	series := appendPushGatewaySeries(nil, updated, false, now)
+	series = appendPushGatewaySeries(series, removed, true, now)
+	if len(series) > 0 {
+		if _, err := p.push(user.InjectOrgID(ctx, userID), &mimirpb.WriteRequest{Timeseries: series, Source: mimirpb.API}); err != nil {
+			return err
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if len(updated) == 0 {
+		delete(p.groups[userID], key)
+		if len(p.groups[userID]) == 0 {
+			delete(p.groups, userID)
+		}
+	} else {
+		if p.groups[userID] == nil {
+			p.groups[userID] = map[string]*pushGatewayGroup{}
+		}
+		p.groups[userID][key] = &pushGatewayGroup{lastPush: now, series: updated}
+	}
+	p.updateSeriesMetric(userID)
+
+	return nil
+}
+
+// appendPushGatewaySeries appends the input series to out with the input timestamp, using stale markers
+// as values if stale is true. The series are allocated from the pool because the push cleanup returns
+// them to it.
+func appendPushGatewaySeries(out []mimirpb.PreallocTimeseries, series map[string]pushGatewaySeries, stale bool, now time.Time) []mimirpb.PreallocTimeseries {
+	ts := util.TimeToMillis(now)
+	for _, s := range series {
+		v := s.value
+		if stale {
+			v = math.Float64frombits(value.StaleNaN)
+		}
+
+		t := mimirpb.TimeseriesFromPool()
+		t.Labels = append(t.Labels, s.labels...)
+		t.Samples = append(t.Samples, mimirpb.Sample{TimestampMs: ts, Value: v})
+		out = append(out, mimirpb.PreallocTimeseries{TimeSeries: t})
+	}
+	return out
+}
+
+// parsePushGatewayGroupingKey parses the grouping key from a path in the form
+// job/<job>{/<label>/<value>}, where label names can be suffixed with @base64
+// to have base64 encoded values.
+func parsePushGatewayGroupingKey(path string) (labels.Labels, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts)%2 != 0 {
+		return nil, errors.New("the grouping key must be made of label name and value pairs")
+	}
+	if parts[0] != "job" && parts[0] != "job"+pushGatewayBase64Suffix {
+		return nil, errors.New("the grouping key must start with the job label")
+	}
+
+	b := labels.NewBuilder(nil)
+	seen := map[string]struct{}{}
+	for i := 0; i < len(parts); i += 2 {
+		name, val := parts[i], parts[i+1]
+		if strings.HasSuffix(name, pushGatewayBase64Suffix) {
+			name = strings.TrimSuffix(name, pushGatewayBase64Suffix)
+			decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(val, "="))
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 encoded value %q for the grouping label %q: %v", val, name, err)
+			}
+			val = string(decoded)
+		}
+
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid grouping label name %q", name)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicated grouping label name %q", name)
+		}
+		seen[name] = struct{}{}
+		b.Set(name, val)
+	}
+
+	grouping := b.Labels(nil)
+	if grouping.Get("job") == "" {
+		return nil, errors.New("the job label must not be empty")
+	}
+	return grouping, nil
+}
+
+// pushGatewaySeriesFromFamilies converts the pushed metric families to series with the grouping labels,
+// keyed by the series labels. A push time series is added for the group.
+func pushGatewaySeriesFromFamilies(families []*dto.MetricFamily, grouping labels.Labels, now time.Time) (map[string]pushGatewaySeries, error) {
+	out := map[string]pushGatewaySeries{}
+
+	add := func(family, name string, lbls labels.Labels, v float64, extra ...string) {
+		b := labels.NewBuilder(lbls)
+		b.Set(labels.MetricName, name)
+		for i := 0; i+1 < len(extra); i += 2 {
+			b.Set(extra[i], extra[i+1])
+		}
+		series := b.Labels(nil)
+		out[series.String()] = pushGatewaySeries{family: family, labels: mimirpb.FromLabelsToLabelAdapters(series), value: v}
+	}
+
+	for _, mf := range families {
+		name := mf.GetName()
+		if name == pushGatewayPushTimeMetric {
+			return nil, fmt.Errorf("the metric %s is reserved and can't be pushed", pushGatewayPushTimeMetric)
+		}
+
+		for _, m := range mf.GetMetric() {
+			if m.TimestampMs != nil {
+				return nil, fmt.Errorf("the pushed metric %s must not have a timestamp", name)
+			}
+
+			lbls, err := pushGatewayMetricLabels(name, m.GetLabel(), grouping)
+			if err != nil {
+				return nil, err
+			}
+
+			expandExpositionMetric(name, mf.GetType(), m, func(series string, v float64, extra ...string) {
+				add(name, series, lbls, v, extra...)
+			})
+		}
+	}
+
+	add(pushGatewayPushTimeMetric, pushGatewayPushTimeMetric, grouping, float64(now.UnixNano())/1e9)
+	return out, nil
+}
+
+// pushGatewayMetricLabels returns the labels of a pushed metric merged with the grouping labels.
+// Like the Prometheus Pushgateway, a pushed metric can't have a grouping label with a different value.
+func pushGatewayMetricLabels(name string, pairs []*dto.LabelPair, grouping labels.Labels) (labels.Labels, error) {
+	b := labels.NewBuilder(grouping)
+	for _, pair := range pairs {
+		if v := grouping.Get(pair.GetName()); v != "" && v != pair.GetValue() {
+			return nil, fmt.Errorf("the pushed metric %s has the label %s=%q which is inconsistent with the grouping key value %q", name, pair.GetName(), pair.GetValue(), v)
+		}
+		b.Set(pair.GetName(), pair.GetValue())
+	}
+	return b.Labels(nil), nil
+}
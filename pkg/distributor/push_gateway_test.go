@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestParsePushGatewayGroupingKey(t *testing.T) {
+	tests := map[string]struct {
+		path        string
+		expected    labels.Labels
+		expectedErr string
+	}{
+		"job only": {
+			path:     "job/backup",
+			expected: labels.FromStrings("job", "backup"),
+		},
+		"job and additional labels": {
+			path:     "job/backup/instance/db-1/",
+			expected: labels.FromStrings("instance", "db-1", "job", "backup"),
+		},
+		"base64 encoded values": {
+			path:     "job@base64/YmFja3Vw/path@base64/L3Zhci90bXA=",
+			expected: labels.FromStrings("job", "backup", "path", "/var/tmp"),
+		},
+		"base64 encoded empty value": {
+			path:     "job/backup/instance@base64/=",
+			expected: labels.FromStrings("job", "backup"),
+		},
+		"missing job": {
+			path:        "instance/db-1",
+			expectedErr: "the grouping key must start with the job label",
+		},
+		"empty job": {
+			path:        "job@base64/=",
+			expectedErr: "the job label must not be empty",
+		},
+		"odd number of parts": {
+			path:        "job/backup/instance",
+			expectedErr: "the grouping key must be made of label name and value pairs",
+		},
+		"invalid label name": {
+			path:        "job/backup/in-stance/db-1",
+			expectedErr: `invalid grouping label name "in-stance"`,
+		},
+		"duplicated label name": {
+			path:        "job/backup/job/restore",
+			expectedErr: `duplicated grouping label name "job"`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual, err := parsePushGatewayGroupingKey(testData.path)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestPushGatewaySeriesFromFamilies(t *testing.T) {
+	const body = `
+# TYPE job_duration_seconds histogram
+job_duration_seconds_bucket{le="1"} 2
+job_duration_seconds_bucket{le="10"} 3
+job_duration_seconds_sum 12.5
+job_duration_seconds_count 3
+# TYPE job_processed_records summary
+job_processed_records{quantile="0.5"} 100
+job_processed_records_sum 300
+job_processed_records_count 2
+# TYPE job_last_success_timestamp_seconds gauge
+job_last_success_timestamp_seconds{stage="upload"} 1.6e+09
+`
+	now := time.Unix(1700000000, 0)
+	grouping := labels.FromStrings("job", "backup")
+
+	req := httptest.NewRequest(http.MethodPut, "/pushgateway/metrics/job/backup", strings.NewReader(body))
+	families, err := decodeExpositionMetricFamilies(req, 1024*1024)
+	require.NoError(t, err)
+
+	series, err := pushGatewaySeriesFromFamilies(families, grouping, now)
+	require.NoError(t, err)
+
+	actual := map[string]float64{}
+	for _, s := range series {
+		actual[mimirpb.FromLabelAdaptersToLabels(s.labels).String()] = s.value
+	}
+	assert.Equal(t, map[string]float64{
+		`{__name__="job_duration_seconds_bucket", job="backup", le="1"}`:                2,
+		`{__name__="job_duration_seconds_bucket", job="backup", le="10"}`:               3,
+		`{__name__="job_duration_seconds_bucket", job="backup", le="+Inf"}`:             3,
+		`{__name__="job_duration_seconds_sum", job="backup"}`:                           12.5,
+		`{__name__="job_duration_seconds_count", job="backup"}`:                         3,
+		`{__name__="job_processed_records", job="backup", quantile="0.5"}`:              100,
+		`{__name__="job_processed_records_sum", job="backup"}`:                          300,
+		`{__name__="job_processed_records_count", job="backup"}`:                        2,
+		`{__name__="job_last_success_timestamp_seconds", job="backup", stage="upload"}`: 1.6e9,
+		`{__name__="push_time_seconds", job="backup"}`:                                  1.7e9,
+	}, actual)
+
+	t.Run("should reject metrics with timestamps", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/pushgateway/metrics/job/backup", strings.NewReader("metric 1 1000\n"))
+		families, err := decodeExpositionMetricFamilies(req, 1024*1024)
+		require.NoError(t, err)
+
+		_, err = pushGatewaySeriesFromFamilies(families, grouping, now)
+		require.EqualError(t, err, "the pushed metric metric must not have a timestamp")
+	})
+
+	t.Run("should reject metrics with labels inconsistent with the grouping key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/pushgateway/metrics/job/backup", strings.NewReader("metric{job=\"restore\"} 1\n"))
+		families, err := decodeExpositionMetricFamilies(req, 1024*1024)
+		require.NoError(t, err)
+
+		_, err = pushGatewaySeriesFromFamilies(families, grouping, now)
+		require.EqualError(t, err, `the pushed metric metric has the label job="restore" which is inconsistent with the grouping key value "backup"`)
+	})
+}
+
+func TestPushGateway(t *testing.T) {
+	limits := &pushGatewayLimitsMock{enabled: true, maxSeries: 4}
+	pusher := &pushGatewayPusherMock{}
+	reg := prometheus.NewPedanticRegistry()
+	p := newPushGateway(PushGatewayConfig{Enabled: true, WriteInterval: time.Minute}, limits, 1024*1024, pusher.push, reg, log.NewNopLogger())
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Push a group.
+	require.Equal(t, http.StatusOK, send(http.MethodPut, "/pushgateway/metrics/job/backup", "records 10\nbytes 100\n"))
+	assert.Equal(t, map[string]string{`{__name__="bytes", job="backup"}`: "100", `{__name__="records", job="backup"}`: "10"}, pusher.lastWrite(t, "user-1"))
+
+	// POST replaces only the pushed metric families.
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/pushgateway/metrics/job/backup", "records 20\n"))
+	assert.Equal(t, map[string]string{`{__name__="bytes", job="backup"}`: "100", `{__name__="records", job="backup"}`: "20"}, pusher.lastWrite(t, "user-1"))
+
+	// PUT replaces the whole group, marking the removed series as stale.
+	require.Equal(t, http.StatusOK, send(http.MethodPut, "/pushgateway/metrics/job/backup", "records 30\n"))
+	assert.Equal(t, map[string]string{`{__name__="bytes", job="backup"}`: "stale", `{__name__="records", job="backup"}`: "30"}, pusher.lastWrite(t, "user-1"))
+
+	// Pushes exceeding the max series limit are rejected.
+	require.Equal(t, http.StatusBadRequest, send(http.MethodPut, "/pushgateway/metrics/job/restore", "a 1\nb 1\nc 1\n"))
+
+	// The series are periodically written with the current timestamp.
+	now := time.Now().Add(time.Minute)
+	p.writeAll(context.Background(), now)
+	assert.Equal(t, map[string]string{`{__name__="records", job="backup"}`: "30"}, pusher.lastWrite(t, "user-1"))
+	assert.Equal(t, util.TimeToMillis(now), pusher.writes["user-1"][len(pusher.writes["user-1"])-1].Timeseries[0].Samples[0].TimestampMs)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_push_gateway_series Number of series held by the push gateway endpoint.
+		# TYPE cortex_distributor_push_gateway_series gauge
+		cortex_distributor_push_gateway_series{user="user-1"} 2
+	`), "cortex_distributor_push_gateway_series"))
+
+	// The series are marked as stale once the staleness period expires.
+	limits.stalenessPeriod = time.Minute
+	p.writeAll(context.Background(), now.Add(2*time.Minute))
+	assert.Equal(t, map[string]string{`{__name__="records", job="backup"}`: "stale"}, pusher.lastWrite(t, "user-1"))
+
+	writes := len(pusher.writes["user-1"])
+	p.writeAll(context.Background(), now.Add(3*time.Minute))
+	assert.Len(t, pusher.writes["user-1"], writes)
+
+	// Deleting a group marks its series as stale.
+	require.Equal(t, http.StatusOK, send(http.MethodPut, "/pushgateway/metrics/job/restore", "records 1\n"))
+	require.Equal(t, http.StatusAccepted, send(http.MethodDelete, "/pushgateway/metrics/job/restore", ""))
+	assert.Equal(t, map[string]string{`{__name__="records", job="restore"}`: "stale"}, pusher.lastWrite(t, "user-1"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(""), "cortex_distributor_push_gateway_series"))
+
+	// Pushes are rejected for the tenants for which the push gateway is disabled.
+	limits.enabled = false
+	require.Equal(t, http.StatusBadRequest, send(http.MethodPut, "/pushgateway/metrics/job/backup", "records 10\n"))
+}
+
+func TestPushGateway_ConcurrentPushes(t *testing.T) {
+	const pushes = 20
+
+	limits := &pushGatewayLimitsMock{enabled: true}
+	pusher := &pushGatewayPusherMock{delay: 10 * time.Millisecond}
+	p := newPushGateway(PushGatewayConfig{Enabled: true, WriteInterval: time.Minute}, limits, 1024*1024, pusher.push, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+	// Concurrent POSTs of different metric families to the same group must all be kept.
+	wg := sync.WaitGroup{}
+	wg.Add(pushes)
+	for i := 0; i < pushes; i++ {
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/pushgateway/metrics/job/backup", strings.NewReader(fmt.Sprintf("metric_%d 1\n", i)))
+			req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+			rec := httptest.NewRecorder()
+			p.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}(i)
+	}
+	wg.Wait()
+
+	p.writeAll(context.Background(), time.Now())
+	assert.Len(t, pusher.lastWrite(t, "user-1"), pushes)
+
+	// The tenant locks are removed once unused.
+	assert.Empty(t, p.tenantLocks)
+}
+
+type pushGatewayLimitsMock struct {
+	enabled         bool
+	stalenessPeriod time.Duration
+	maxSeries       int
+}
+
+func (m *pushGatewayLimitsMock) PushGatewayEnabled(string) bool {
+	return m.enabled
+}
+
+func (m *pushGatewayLimitsMock) PushGatewayStalenessPeriod(string) time.Duration {
+	return m.stalenessPeriod
+}
+
+func (m *pushGatewayLimitsMock) PushGatewayMaxSeries(string) int {
+	return m.maxSeries
+}
+
+type pushGatewayPusherMock struct {
+	delay time.Duration
+
+	mtx    sync.Mutex
+	writes map[string][]*mimirpb.WriteRequest
+}
+
+func (m *pushGatewayPusherMock) push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(m.delay)
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.writes == nil {
+		m.writes = map[string][]*mimirpb.WriteRequest{}
+	}
+	m.writes[userID] = append(m.writes[userID], req)
+	return &mimirpb.WriteResponse{}, nil
+}
+
+// lastWrite returns the values of the series, excluding the push time, of the last write for the tenant.
+// Stale markers are returned as "stale", given NaN values can't be compared.
+func (m *pushGatewayPusherMock) lastWrite(t *testing.T, userID string) map[string]string {
+	require.NotEmpty(t, m.writes[userID])
+	req := m.writes[userID][len(m.writes[userID])-1]
+
+	out := map[string]string{}
+	for _, ts := range req.Timeseries {
+		lbls := mimirpb.FromLabelAdaptersToLabels(ts.Labels)
+		if lbls.Get(labels.MetricName) == pushGatewayPushTimeMetric {
+			continue
+		}
+		require.Len(t, ts.Samples, 1)
+		if value.IsStaleNaN(ts.Samples[0].Value) {
+			out[lbls.String()] = "stale"
+		} else {
+			out[lbls.String()] = strconv.FormatFloat(ts.Samples[0].Value, 'g', -1, 64)
+		}
+	}
+	return out
+}
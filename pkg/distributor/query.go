@@ -80,8 +80,19 @@ func (d *Distributor) QueryStream(ctx context.Context, from, to model.Time, matc
 	return result, err
 }
 
-// GetIngesters returns a replication set including all ingesters.
+// GetIngesters returns a replication set including all ingesters, except the slow or failing ones
+// excluded by the outlier detection as long as the remaining ones satisfy the quorum.
 func (d *Distributor) GetIngesters(ctx context.Context) (ring.ReplicationSet, error) {
+	replicationSet, err := d.getAllIngesters(ctx)
+	if err != nil {
+		return ring.ReplicationSet{}, err
+	}
+
+	return d.IngesterOutliers.FilterReplicationSet(replicationSet), nil
+}
+
+// getAllIngesters returns a replication set including all ingesters.
+func (d *Distributor) getAllIngesters(ctx context.Context) (ring.ReplicationSet, error) {
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return ring.ReplicationSet{}, err
@@ -92,11 +103,18 @@ func (d *Distributor) GetIngesters(ctx context.Context) (ring.ReplicationSet, er
 	shardSize := d.limits.IngestionTenantShardSize(userID)
 	lookbackPeriod := d.cfg.ShuffleShardingLookbackPeriod
 
+	var replicationSet ring.ReplicationSet
 	if shardSize > 0 && lookbackPeriod > 0 {
-		return d.ingestersRing.ShuffleShardWithLookback(userID, shardSize, lookbackPeriod, time.Now()).GetReplicationSetForOperation(ring.Read)
+		replicationSet, err = d.ingestersRing.ShuffleShardWithLookback(userID, shardSize, lookbackPeriod, time.Now()).GetReplicationSetForOperation(ring.Read)
+	} else {
+		replicationSet, err = d.ingestersRing.GetReplicationSetForOperation(ring.Read)
+	}
+	if err != nil {
+		return ring.ReplicationSet{}, err
 	}
 
-	return d.ingestersRing.GetReplicationSetForOperation(ring.Read)
+	// If the tenant's series are replicated to less ingesters, the queries tolerate less failures.
+	return adjustReplicationSetForReplicationFactor(replicationSet, d.ingestersRing.ReplicationFactor(), d.tenantReplicationFactor(userID))
 }
 
 // mergeExemplarSets merges and dedupes two sets of already sorted exemplar pairs.
@@ -243,6 +261,11 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 		}
 	}()
 
+	// Push the query limits down to the ingesters, so that they can stop streaming series once their
+	// response alone exceeds a limit, given the query would fail anyway.
+	req.MaxSeriesHint = int64(queryLimiter.MaxSeriesPerQuery())
+	req.MaxChunksHint = int64(queryLimiter.MaxChunksPerQuery())
+
 	// Fetch samples from multiple ingesters, and send them to the results chan
 	_, err := replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"fmt"
+
+	"github.com/grafana/dskit/ring"
+)
+
+// tenantReplicationFactor returns the number of ingesters the series of the tenant are written to, which is
+// the per-tenant replication factor if larger than 0 and lower than the ingesters ring replication factor.
+func (d *Distributor) tenantReplicationFactor(userID string) int {
+	ringRF := d.ingestersRing.ReplicationFactor()
+	if rf := d.limits.IngestionReplicationFactor(userID); rf > 0 && rf < ringRF {
+		return rf
+	}
+	return ringRF
+}
+
+// replicationFactorRing is a ring.ReadRing replicating each key to the first replicationFactor instances of
+// the replication set returned by the wrapped ring, which must have a greater replication factor.
+type replicationFactorRing struct {
+	ring.ReadRing
+	replicationFactor int
+}
+
+func (r replicationFactorRing) Get(key uint32, op ring.Operation, bufDescs []ring.InstanceDesc, bufHosts, bufZones []string) (ring.ReplicationSet, error) {
+	set, err := r.ReadRing.Get(key, op, bufDescs, bufHosts, bufZones)
+	if err != nil {
+		return ring.ReplicationSet{}, err
+	}
+
+	// The wrapped ring has already filtered out the unhealthy instances, so the write goes to the
+	// first healthy ones. The quorum is computed on the tenant replication factor.
+	if len(set.Instances) > r.replicationFactor {
+		set.Instances = set.Instances[:r.replicationFactor]
+	}
+	minSuccess := (r.replicationFactor / 2) + 1
+	if len(set.Instances) < minSuccess {
+		return ring.ReplicationSet{}, fmt.Errorf("at least %d live replicas required, could only find %d", minSuccess, len(set.Instances))
+	}
+	set.MaxErrors = len(set.Instances) - minSuccess
+
+	return set, nil
+}
+
+func (r replicationFactorRing) ReplicationFactor() int {
+	return r.replicationFactor
+}
+
+// adjustReplicationSetForReplicationFactor reduces the max errors, or the max unavailable zones, tolerated when
+// querying the input replication set, computed on the ring replication factor, to the ones tolerated for the
+// series of a tenant written with a lower replication factor.
+func adjustReplicationSetForReplicationFactor(set ring.ReplicationSet, ringRF, tenantRF int) (ring.ReplicationSet, error) {
+	if tenantRF >= ringRF {
+		return set, nil
+	}
+
+	// Given the series are replicated to RF ingesters, the queries tolerate RF/2 failures.
+	delta := (ringRF / 2) - (tenantRF / 2)
+	if set.MaxUnavailableZones > 0 {
+		set.MaxUnavailableZones -= delta
+	} else {
+		set.MaxErrors -= delta
+	}
+
+	if set.MaxErrors < 0 || set.MaxUnavailableZones < 0 {
+		return ring.ReplicationSet{}, ring.ErrTooManyUnhealthyInstances
+	}
+	return set, nil
+}
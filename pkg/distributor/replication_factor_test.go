@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_TenantReplicationFactor(t *testing.T) {
+	tests := map[string]struct {
+		tenantReplicationFactor     int
+		expectedIngestersWithSeries int
+	}{
+		"tenant replication factor disabled": {
+			tenantReplicationFactor:     0,
+			expectedIngestersWithSeries: 3,
+		},
+		"tenant replication factor lower than the ring one": {
+			tenantReplicationFactor:     1,
+			expectedIngestersWithSeries: 1,
+		},
+		"tenant replication factor greater than the ring one": {
+			tenantReplicationFactor:     5,
+			expectedIngestersWithSeries: 3,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.IngestionReplicationFactor = testData.tenantReplicationFactor
+
+			ds, ingesters, _ := prepare(t, prepConfig{
+				numIngesters:    3,
+				happyIngesters:  3,
+				numDistributors: 1,
+				limits:          &limits,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "user")
+			series := labels.FromStrings(labels.MetricName, "series_1")
+			_, err := ds[0].Push(ctx, mockWriteRequest(series, 1, 1))
+			require.NoError(t, err)
+
+			// The push returns once the quorum is reached, so the series may reach the other ingesters later.
+			test.Poll(t, time.Second, testData.expectedIngestersWithSeries, func() interface{} {
+				ingestersWithSeries := 0
+				for i := range ingesters {
+					if len(ingesters[i].series()) > 0 {
+						ingestersWithSeries++
+					}
+				}
+				return ingestersWithSeries
+			})
+
+			// The series is returned by the queries regardless of the number of ingesters it's been written to.
+			res, err := ds[0].QueryStream(ctx, 0, 10, labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, "series_1"))
+			require.NoError(t, err)
+			assert.Len(t, res.Chunkseries, 1)
+		})
+	}
+}
+
+func TestReplicationFactorRing_Get(t *testing.T) {
+	instances := []ring.InstanceDesc{{Addr: "1"}, {Addr: "2"}, {Addr: "3"}}
+
+	tests := map[string]struct {
+		replicationFactor int
+		healthyInstances  []ring.InstanceDesc
+		expectedInstances []ring.InstanceDesc
+		expectedMaxErrors int
+		expectedErr       string
+	}{
+		"replication factor 1": {
+			replicationFactor: 1,
+			healthyInstances:  instances,
+			expectedInstances: instances[:1],
+			expectedMaxErrors: 0,
+		},
+		"replication factor 2": {
+			replicationFactor: 2,
+			healthyInstances:  instances,
+			expectedInstances: instances[:2],
+			expectedMaxErrors: 0,
+		},
+		"replication factor 2 with 1 healthy instance": {
+			replicationFactor: 2,
+			healthyInstances:  instances[2:],
+			expectedErr:       "at least 2 live replicas required, could only find 1",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			r := replicationFactorRing{
+				ReadRing:          &fixedReplicationSetRing{set: ring.ReplicationSet{Instances: testData.healthyInstances, MaxErrors: 1}},
+				replicationFactor: testData.replicationFactor,
+			}
+			assert.Equal(t, testData.replicationFactor, r.ReplicationFactor())
+
+			set, err := r.Get(0, ring.WriteNoExtend, nil, nil, nil)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedInstances, set.Instances)
+			assert.Equal(t, testData.expectedMaxErrors, set.MaxErrors)
+		})
+	}
+}
+
+func TestAdjustReplicationSetForReplicationFactor(t *testing.T) {
+	tests := map[string]struct {
+		set                         ring.ReplicationSet
+		ringRF, tenantRF            int
+		expectedMaxErrors           int
+		expectedMaxUnavailableZones int
+		expectedErr                 error
+	}{
+		"tenant replication factor equal to the ring one": {
+			set:               ring.ReplicationSet{MaxErrors: 1},
+			ringRF:            3,
+			tenantRF:          3,
+			expectedMaxErrors: 1,
+		},
+		"tenant replication factor 2 tolerates the same failures of 3": {
+			set:               ring.ReplicationSet{MaxErrors: 1},
+			ringRF:            3,
+			tenantRF:          2,
+			expectedMaxErrors: 1,
+		},
+		"tenant replication factor 1 tolerates no failures": {
+			set:               ring.ReplicationSet{MaxErrors: 1},
+			ringRF:            3,
+			tenantRF:          1,
+			expectedMaxErrors: 0,
+		},
+		"tenant replication factor 1 tolerates no unavailable zones": {
+			set:                         ring.ReplicationSet{MaxUnavailableZones: 1},
+			ringRF:                      3,
+			tenantRF:                    1,
+			expectedMaxUnavailableZones: 0,
+		},
+		"tenant replication factor 1 with an already failed instance": {
+			set:         ring.ReplicationSet{MaxErrors: 0},
+			ringRF:      3,
+			tenantRF:    1,
+			expectedErr: ring.ErrTooManyUnhealthyInstances,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			set, err := adjustReplicationSetForReplicationFactor(testData.set, testData.ringRF, testData.tenantRF)
+			if testData.expectedErr != nil {
+				require.Equal(t, testData.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedMaxErrors, set.MaxErrors)
+			assert.Equal(t, testData.expectedMaxUnavailableZones, set.MaxUnavailableZones)
+		})
+	}
+}
+
+// fixedReplicationSetRing is a ring.ReadRing returning the same replication set for each key.
+type fixedReplicationSetRing struct {
+	ring.ReadRing
+	set ring.ReplicationSet
+}
+
+func (r *fixedReplicationSetRing) Get(_ uint32, _ ring.Operation, _ []ring.InstanceDesc, _, _ []string) (ring.ReplicationSet, error) {
+	set := r.set
+	set.Instances = append([]ring.InstanceDesc(nil), r.set.Instances...)
+	return set, nil
+}
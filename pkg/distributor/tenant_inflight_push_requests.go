@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+const (
+	// BackpressureHeader is the header of the responses to the push requests rejected because the tenant
+	// exceeded its inflight push requests budget, holding the suggested send rate.
+	BackpressureHeader = "X-Mimir-Backpressure"
+
+	// pushLatencyEwmaWeight is the weight of the latest push request duration in the moving average of
+	// the push requests durations of a tenant.
+	pushLatencyEwmaWeight = 0.1
+)
+
+// tenantInflightPushRequests tracks the number of inflight push requests of each tenant, and the moving
+// average of the duration of their push requests, which is used to suggest a sustainable send rate to
+// the clients whose push requests are rejected.
+type tenantInflightPushRequests struct {
+	mtx     sync.Mutex
+	tenants map[string]*tenantInflight
+}
+
+type tenantInflight struct {
+	inflight int
+	// avgDuration is the moving average of the push requests duration, in seconds. It's 0 until the first request completes.
+	avgDuration float64
+}
+
+func newTenantInflightPushRequests() *tenantInflightPushRequests {
+	return &tenantInflightPushRequests{tenants: map[string]*tenantInflight{}}
+}
+
+// tryStart registers a new inflight push request of the tenant, unless the tenant already has max inflight
+// push requests. If registered, the returned done function must be called once the push request completes.
+func (t *tenantInflightPushRequests) tryStart(userID string, max int) (done func(), ok bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s, exists := t.tenants[userID]
+	if !exists {
+		s = &tenantInflight{}
+		t.tenants[userID] = s
+	}
+	if s.inflight >= max {
+		return nil, false
+	}
+	s.inflight++
+
+	start := time.Now()
+	return func() { t.done(s, time.Since(start)) }, true
+}
+
+func (t *tenantInflightPushRequests) done(s *tenantInflight, duration time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s.inflight--
+	if s.avgDuration == 0 {
+		s.avgDuration = duration.Seconds()
+	} else {
+		s.avgDuration = pushLatencyEwmaWeight*duration.Seconds() + (1-pushLatencyEwmaWeight)*s.avgDuration
+	}
+}
+
+// avgDuration returns the moving average of the push requests duration of the tenant, or 0 if unknown.
+func (t *tenantInflightPushRequests) avgDuration(userID string) time.Duration {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s, ok := t.tenants[userID]
+	if !ok {
+		return 0
+	}
+	return time.Duration(s.avgDuration * float64(time.Second))
+}
+
+// removeTenant drops the tracking of the tenant, unless it has inflight push requests.
+func (t *tenantInflightPushRequests) removeTenant(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if s, ok := t.tenants[userID]; ok && s.inflight == 0 {
+		delete(t.tenants, userID)
+	}
+}
+
+// newTenantBackpressureError returns the error of a push request rejected because the tenant has max inflight
+// push requests in a distributor. The response tells the client to retry after the average push request duration,
+// and suggests the send rate at which the tenant doesn't exceed max inflight push requests: given the push requests
+// complete in avgDuration on average, each distributor serves up to max/avgDuration requests/s of the tenant.
+func newTenantBackpressureError(message string, max, distributors int, avgDuration time.Duration) error {
+	retryAfter := int(math.Ceil(avgDuration.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	headers := []*httpgrpc.Header{
+		{Key: "Content-Type", Values: []string{"text/plain; charset=utf-8"}},
+		{Key: "Retry-After", Values: []string{strconv.Itoa(retryAfter)}},
+	}
+	if avgDuration > 0 {
+		if distributors < 1 {
+			distributors = 1
+		}
+		rate := float64(max*distributors) / avgDuration.Seconds()
+		headers = append(headers, &httpgrpc.Header{Key: BackpressureHeader, Values: []string{fmt.Sprintf("send-rate=%s", strconv.FormatFloat(rate, 'f', 2, 64))}})
+	}
+
+	return httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code:    http.StatusTooManyRequests,
+		Body:    []byte(message),
+		Headers: headers,
+	})
+}
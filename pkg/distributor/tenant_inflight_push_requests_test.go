@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestTenantInflightPushRequests(t *testing.T) {
+	r := newTenantInflightPushRequests()
+
+	done1, ok := r.tryStart("user-1", 2)
+	require.True(t, ok)
+	done2, ok := r.tryStart("user-1", 2)
+	require.True(t, ok)
+	_, ok = r.tryStart("user-1", 2)
+	require.False(t, ok)
+
+	// The limit is per tenant.
+	done3, ok := r.tryStart("user-2", 2)
+	require.True(t, ok)
+	done3()
+
+	// The average duration is unknown until the first request completes.
+	assert.Equal(t, time.Duration(0), r.avgDuration("user-1"))
+	done1()
+	assert.Greater(t, r.avgDuration("user-1"), time.Duration(0))
+
+	_, ok = r.tryStart("user-1", 2)
+	require.True(t, ok)
+
+	// The tenants with inflight requests aren't removed.
+	r.removeTenant("user-1")
+	r.removeTenant("user-2")
+	assert.Len(t, r.tenants, 1)
+	done2()
+}
+
+func TestNewTenantBackpressureError(t *testing.T) {
+	tests := map[string]struct {
+		avgDuration        time.Duration
+		expectedRetryAfter string
+		expectedSendRate   string
+	}{
+		"unknown average duration": {
+			avgDuration:        0,
+			expectedRetryAfter: "1",
+		},
+		"average duration below 1s": {
+			avgDuration:        250 * time.Millisecond,
+			expectedRetryAfter: "1",
+			expectedSendRate:   "send-rate=120.00",
+		},
+		"average duration above 1s": {
+			avgDuration:        1500 * time.Millisecond,
+			expectedRetryAfter: "2",
+			expectedSendRate:   "send-rate=20.00",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := newTenantBackpressureError("too many inflight push requests", 10, 3, testData.avgDuration)
+
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+			assert.Equal(t, "too many inflight push requests", string(resp.Body))
+
+			headers := map[string]string{}
+			for _, h := range resp.Headers {
+				headers[h.Key] = h.Values[0]
+			}
+			assert.Equal(t, testData.expectedRetryAfter, headers["Retry-After"])
+			assert.Equal(t, testData.expectedSendRate, headers[BackpressureHeader])
+		})
+	}
+}
+
+func TestDistributor_MaxInflightPushRequestsPerTenant(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxInflightPushRequestsPerTenant = 1
+
+	ds, _, regs := prepare(t, prepConfig{
+		numDistributors: 1,
+		limits:          limits,
+	})
+
+	release := make(chan struct{})
+	blockingPush := func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		defer pushReq.CleanUp()
+		<-release
+		return &mimirpb.WriteResponse{}, nil
+	}
+	wrappedPush := ds[0].wrapPushWithMiddlewares(nil, blockingPush)
+
+	ctx1 := user.InjectOrgID(context.Background(), "user-1")
+	ctx2 := user.InjectOrgID(context.Background(), "user-2")
+
+	// The first request of user-1 is inflight until released.
+	firstDone := make(chan error)
+	go func() {
+		_, err := wrappedPush(ctx1, push.NewParsedRequest(makeWriteRequest(0, 1, 0, false)))
+		firstDone <- err
+	}()
+	require.Eventually(t, func() bool {
+		ds[0].tenantInflightPushRequests.mtx.Lock()
+		defer ds[0].tenantInflightPushRequests.mtx.Unlock()
+		s, ok := ds[0].tenantInflightPushRequests.tenants["user-1"]
+		return ok && s.inflight == 1
+	}, time.Second, time.Millisecond)
+
+	// The second request of user-1 is rejected.
+	_, err := wrappedPush(ctx1, push.NewParsedRequest(makeWriteRequest(0, 1, 0, false)))
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+	assert.Equal(t, validation.NewMaxInflightPushRequestsPerTenantError(1).Error(), string(resp.Body))
+
+	// The limit is per tenant, so user-2 isn't affected.
+	secondDone := make(chan error)
+	go func() {
+		_, err := wrappedPush(ctx2, push.NewParsedRequest(makeWriteRequest(0, 1, 0, false)))
+		secondDone <- err
+	}()
+
+	close(release)
+	require.NoError(t, <-firstDone)
+	require.NoError(t, <-secondDone)
+
+	// Once the inflight request completed, user-1 can push again.
+	_, err = wrappedPush(ctx1, push.NewParsedRequest(makeWriteRequest(0, 1, 0, false)))
+	require.NoError(t, err)
+
+	metrics, err := regs[0].Gather()
+	require.NoError(t, err)
+	var discarded float64
+	for _, mf := range metrics {
+		if mf.GetName() != "cortex_discarded_requests_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			discarded += m.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, float64(1), discarded)
+}
@@ -7,9 +7,12 @@ package frontend
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -19,6 +22,7 @@ import (
 	v2 "github.com/grafana/mimir/pkg/frontend/v2"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
 // CombinedFrontendConfig combines several configuration options together to preserve backwards compatibility.
@@ -30,6 +34,8 @@ type CombinedFrontendConfig struct {
 	QueryMiddleware querymiddleware.Config `yaml:",inline"`
 
 	DownstreamURL string `yaml:"downstream_url" category:"advanced"`
+
+	QuerierResponseCompression flagext.StringSliceCSV `yaml:"querier_response_compression" category:"experimental"`
 }
 
 func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
@@ -39,6 +45,7 @@ func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet, logger log.Log
 	cfg.QueryMiddleware.RegisterFlags(f)
 
 	f.StringVar(&cfg.DownstreamURL, "query-frontend.downstream-url", "", "URL of downstream Prometheus.")
+	f.Var(&cfg.QuerierResponseCompression, "query-frontend.querier-response-compression", fmt.Sprintf("Comma-separated list of the encodings the queriers can compress the query responses with, in order of preference, to reduce the network traffic between the queriers and the query-frontends. The queriers compress the responses only with the encodings enabled with -querier.response-compression. Supported values: %s.", strings.Join(httpgrpcutil.ResponseEncodings, ", ")))
 }
 
 func (cfg *CombinedFrontendConfig) Validate(log log.Logger) error {
@@ -48,6 +55,15 @@ func (cfg *CombinedFrontendConfig) Validate(log log.Logger) error {
 	if err := cfg.QueryMiddleware.Validate(); err != nil {
 		return err
 	}
+	if err := cfg.Handler.QueryJournal.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Handler.Shadowing.Validate(); err != nil {
+		return err
+	}
+	if err := httpgrpcutil.ValidateResponseEncodings(cfg.QuerierResponseCompression); err != nil {
+		return errors.Wrap(err, "invalid -query-frontend.querier-response-compression")
+	}
 	return nil
 }
 
@@ -80,7 +96,7 @@ func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort i
 		}
 
 		fr, err := v2.NewFrontend(cfg.FrontendV2, log, reg)
-		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), nil, fr, err
+		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr, cfg.QuerierResponseCompression), nil, fr, err
 
 	default:
 		// No scheduler = use original frontend.
@@ -88,6 +104,6 @@ func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort i
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), fr, nil, nil
+		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr, cfg.QuerierResponseCompression), fr, nil, nil
 	}
 }
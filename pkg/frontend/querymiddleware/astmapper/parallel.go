@@ -171,6 +171,48 @@ func noAggregates(n parser.Node) bool {
 	return !hasAggregates
 }
 
+// hasConstantCardinality returns whether the number of series in the result of the input expr doesn't depend on
+// the number of series it queries, like an aggregation without grouping, or a scalar.
+func hasConstantCardinality(expr parser.Expr) bool {
+	if expr.Type() == parser.ValueTypeScalar || expr.Type() == parser.ValueTypeString {
+		return true
+	}
+
+	switch e := expr.(type) {
+	case *parser.AggregateExpr:
+		switch e.Op {
+		case parser.TOPK, parser.BOTTOMK, parser.COUNT_VALUES:
+			return false
+		default:
+			return !e.Without && len(e.Grouping) == 0
+		}
+
+	case *parser.BinaryExpr:
+		return hasConstantCardinality(e.LHS) && hasConstantCardinality(e.RHS)
+
+	case *parser.Call:
+		// The functions don't increase the number of series of their arguments.
+		for _, arg := range e.Args {
+			if !hasConstantCardinality(arg) {
+				return false
+			}
+		}
+		return true
+
+	case *parser.SubqueryExpr:
+		return hasConstantCardinality(e.Expr)
+
+	case *parser.ParenExpr:
+		return hasConstantCardinality(e.Expr)
+
+	case *parser.UnaryExpr:
+		return hasConstantCardinality(e.Expr)
+
+	default:
+		return false
+	}
+}
+
 func isConstantScalar(n parser.Node) bool {
 	isNot, _ := anyNode(n, isNotConstantNumber)
 	return !isNot
@@ -250,3 +250,33 @@ func TestCountVectorSelectors(t *testing.T) {
 		})
 	}
 }
+
+func TestHasConstantCardinality(t *testing.T) {
+	tests := map[string]bool{
+		`1`:                                    true,
+		`time()`:                               true,
+		`vector(1)`:                            true,
+		`scalar(metric)`:                       true,
+		`sum(metric)`:                          true,
+		`sum by() (rate(metric[1m]))`:          true,
+		`-max(metric) * 2`:                     true,
+		`abs(sum(metric))`:                     true,
+		`max_over_time(sum(metric)[5m:1m])`:    true,
+		`sum(metric) / count(metric)`:          true,
+		`metric`:                               false,
+		`rate(metric[1m])`:                     false,
+		`sum by(a) (metric)`:                   false,
+		`sum without(a) (metric)`:              false,
+		`topk(1, metric)`:                      false,
+		`count_values("value", metric)`:        false,
+		`sum(metric) / on() group_left metric`: false,
+	}
+
+	for input, expected := range tests {
+		t.Run(input, func(t *testing.T) {
+			expr, err := parser.ParseExpr(input)
+			require.NoError(t, err)
+			assert.Equal(t, expected, hasConstantCardinality(expr))
+		})
+	}
+}
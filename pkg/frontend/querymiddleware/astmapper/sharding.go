@@ -138,6 +138,13 @@ func (summer *shardSummer) MapExpr(expr parser.Expr) (mapped parser.Expr, finish
 			return summer.shardBinOp(e)
 		}
 
+		// If one of the two legs has a constant cardinality, like an aggregation without grouping, then
+		// the cardinality of the result is bounded by the cardinality of the other leg, so we can shard
+		// the other leg and broadcast the constant cardinality one to the result of all the shards.
+		if mapped, ok, err := summer.shardBinOpLeg(e); err != nil || ok {
+			return mapped, false, err
+		}
+
 		// We can't parallelize the whole binary operation but we could still parallelize
 		// at least one of the two legs. However, if we parallelize only one of the two legs
 		// then fetching results from the other (non parallelized) leg could be very expensive
@@ -145,47 +152,14 @@ func (summer *shardSummer) MapExpr(expr parser.Expr) (mapped parser.Expr, finish
 		// process in the query-frontend. Since we can't estimate the cardinality, we prefer
 		// to be pessimistic and not parallelize at all the two legs unless we're able to
 		// parallelize all vector selectors in the legs.
-		canShardAllVectorSelectors := func(expr parser.Expr) (can bool, err error) {
-			query := expr.String()
-			// We need to cache the results of this function to avoid processing it again and again
-			// in queries like `a or b or c or d`, which would lead to exponential processing time.
-			if can, ok := summer.canShardAllVectorSelectorsCache[query]; ok {
-				return can, nil
-			}
-			defer func() {
-				if err == nil {
-					summer.canShardAllVectorSelectorsCache[query] = can
-				}
-			}()
-
-			// Clone the expression cause the mapper can modify it in-place.
-			clonedExpr, err := parser.ParseExpr(query)
-			if err != nil {
-				return false, err
-			}
-
-			c := summer.Clone()
-			c.shards = 1
-			m := NewASTExprMapper(c)
-
-			mappedExpr, err := m.Map(clonedExpr)
-			if err != nil {
-				return false, err
-			}
-
-			// The mapped expression could have been rewritten and the number of vector selectors
-			// be changed compared to the original expression, so we should compare with that.
-			return c.stats.GetShardedQueries() == countVectorSelectors(mappedExpr), nil
-		}
-
-		canLHS, err := canShardAllVectorSelectors(e.LHS)
+		canLHS, err := summer.canShardAllVectorSelectors(e.LHS)
 		if err != nil {
 			return e, true, err
 		}
 		if !canLHS {
 			return e, true, nil
 		}
-		canRHS, err := canShardAllVectorSelectors(e.RHS)
+		canRHS, err := summer.canShardAllVectorSelectors(e.RHS)
 		return e, !canRHS, err
 
 	case *parser.SubqueryExpr:
@@ -205,6 +179,40 @@ func (summer *shardSummer) MapExpr(expr parser.Expr) (mapped parser.Expr, finish
 	}
 }
 
+// canShardAllVectorSelectors returns whether all vector selectors in the input expression can be sharded.
+func (summer *shardSummer) canShardAllVectorSelectors(expr parser.Expr) (can bool, err error) {
+	query := expr.String()
+	// We need to cache the results of this function to avoid processing it again and again
+	// in queries like `a or b or c or d`, which would lead to exponential processing time.
+	if can, ok := summer.canShardAllVectorSelectorsCache[query]; ok {
+		return can, nil
+	}
+	defer func() {
+		if err == nil {
+			summer.canShardAllVectorSelectorsCache[query] = can
+		}
+	}()
+
+	// Clone the expression cause the mapper can modify it in-place.
+	clonedExpr, err := parser.ParseExpr(query)
+	if err != nil {
+		return false, err
+	}
+
+	c := summer.Clone()
+	c.shards = 1
+	m := NewASTExprMapper(c)
+
+	mappedExpr, err := m.Map(clonedExpr)
+	if err != nil {
+		return false, err
+	}
+
+	// The mapped expression could have been rewritten and the number of vector selectors
+	// be changed compared to the original expression, so we should compare with that.
+	return c.stats.GetShardedQueries() == countVectorSelectors(mappedExpr), nil
+}
+
 // shardAndSquashFuncCall shards the given function call by cloning it and adding the shard label to the most outer matrix/vector selector.
 func (summer *shardSummer) shardAndSquashFuncCall(expr *parser.Call) (mapped parser.Expr, finished bool, err error) {
 	/*
@@ -449,6 +457,67 @@ func (summer *shardSummer) shardBinOp(expr *parser.BinaryExpr) (mapped parser.Ex
 	}
 }
 
+// shardBinOpLeg shards a leg of the given binary operation expression in place, if
+// the leg is parallelizable and the other leg has a constant cardinality. It returns whether a leg has been sharded.
+func (summer *shardSummer) shardBinOpLeg(expr *parser.BinaryExpr) (mapped parser.Expr, ok bool, err error) {
+	// The constant cardinality leg is mapped as usual, so we require all its vector selectors to be
+	// shardable, in order to not fold in an embedded query a subtree which is not a vector.
+	canShardLeg := func(leg, other parser.Expr) (bool, error) {
+		if leg.Type() != parser.ValueTypeVector || !CanParallelize(leg, summer.logger) || !noAggregates(leg) || isConstantScalar(leg) {
+			return false, nil
+		}
+		if !hasConstantCardinality(other) {
+			return false, nil
+		}
+		return summer.canShardAllVectorSelectors(other)
+	}
+
+	for _, leg := range []*parser.Expr{&expr.LHS, &expr.RHS} {
+		other := expr.RHS
+		if leg == &expr.RHS {
+			other = expr.LHS
+		}
+
+		can, err := canShardLeg(*leg, other)
+		if err != nil {
+			return nil, false, err
+		}
+		if !can {
+			continue
+		}
+
+		sharded, err := summer.shardAndSquashExpr(*leg)
+		if err != nil {
+			return nil, false, err
+		}
+		*leg = sharded
+		return expr, true, nil
+	}
+
+	return expr, false, nil
+}
+
+// shardAndSquashExpr returns a squashed CONCAT expression including N embedded
+// queries, where N is the number of shards and each sub-query queries a different shard
+// with the given expression.
+func (summer *shardSummer) shardAndSquashExpr(expr parser.Expr) (parser.Expr, error) {
+	children := make([]parser.Expr, 0, summer.shards)
+
+	// Create sub-query for each shard.
+	for i := 0; i < summer.shards; i++ {
+		sharded, err := cloneAndMap(NewASTExprMapper(summer.CopyWithCurShard(i)), expr)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, sharded)
+	}
+
+	// Update stats.
+	summer.stats.AddShardedQueries(summer.shards)
+
+	return summer.squash(children...)
+}
+
 // shardAndSquashBinOp returns a squashed CONCAT expression including N embedded
 // queries, where N is the number of shards and each sub-query queries a different shard
 // with the same binary operation.
@@ -63,11 +63,10 @@ func TestShardSummer(t *testing.T) {
 			3,
 		},
 		{
-			// This query is not parallelized because the leg "rate(bar2[1m])" is not aggregated and
-			// could result in high cardinality results.
 			`sum(rate(bar1[1m])) or rate(bar2[1m])`,
-			concat(`sum(rate(bar1[1m])) or rate(bar2[1m])`),
-			0,
+			`sum(` + concatShards(3, `sum(rate(bar1{__query_shard__="x_of_y"}[1m]))`) + `)` +
+				` or ` + concatShards(3, `rate(bar2{__query_shard__="x_of_y"}[1m])`),
+			6,
 		},
 		{
 			"sum(rate(bar1[1m])) or sum(rate(bar2[1m]))",
@@ -393,10 +392,10 @@ func TestShardSummer(t *testing.T) {
 			0,
 		},
 		{
-			// This query is not parallelized because the leg "year(foo)" could result in high cardinality results.
 			`sum(rate(metric_counter[1m])) / vector(3) ^ year(foo)`,
-			concat(`sum(rate(metric_counter[1m])) / vector(3) ^ year(foo)`),
-			0,
+			`sum(` + concatShards(3, `sum(rate(metric_counter{__query_shard__="x_of_y"}[1m]))`) + `)` +
+				` / vector(3) ^ ` + concatShards(3, `year(foo{__query_shard__="x_of_y"})`),
+			6,
 		},
 		{
 			// can't shard foo > bar,
@@ -442,17 +441,44 @@ func TestShardSummer(t *testing.T) {
 			3,
 		},
 		{
-			// This query is not parallelized because the leg "foo" is not aggregated and
-			// could result in high cardinality results.
 			`foo > sum(bar)`,
-			concat(`foo > sum(bar)`),
-			0,
+			concatShards(3, `foo{__query_shard__="x_of_y"}`) + ` > sum(` + concatShards(3, `sum(bar{__query_shard__="x_of_y"})`) + `)`,
+			6,
 		},
 		{
-			// This query is not parallelized because the leg "foo" is not aggregated and
-			// could result in high cardinality results.
 			`foo > scalar(sum(bar))`,
-			concat(`foo > scalar(sum(bar))`),
+			concatShards(3, `foo{__query_shard__="x_of_y"}`) + ` > scalar(sum(` + concatShards(3, `sum(bar{__query_shard__="x_of_y"})`) + `))`,
+			6,
+		},
+		{
+			`rate(foo[1m]) / on() group_left sum(rate(foo[1m]))`,
+			concatShards(3, `rate(foo{__query_shard__="x_of_y"}[1m])`) +
+				` / on() group_left sum(` + concatShards(3, `sum(rate(foo{__query_shard__="x_of_y"}[1m]))`) + `)`,
+			6,
+		},
+		{
+			`max(bar) - on() group_right label_replace(foo, "a", "b", "c", "d")`,
+			`max(` + concatShards(3, `max(bar{__query_shard__="x_of_y"})`) + `)` +
+				` - on() group_right ` + concatShards(3, `label_replace(foo{__query_shard__="x_of_y"}, "a", "b", "c", "d")`),
+			6,
+		},
+		{
+			`foo * on() group_left vector(2)`,
+			concatShards(3, `foo{__query_shard__="x_of_y"}`) + ` * on() group_left vector(2)`,
+			3,
+		},
+		{
+			// This query is not parallelized because the cardinality of the leg "sum by(a) (bar)" is not constant,
+			// so the leg "foo" could result in high cardinality results.
+			`foo * on(a) group_left sum by(a) (bar)`,
+			concat(`foo * on(a) group_left sum by(a) (bar)`),
+			0,
+		},
+		{
+			// This query is not parallelized because the leg "foo" is not aggregated and the leg
+			// "quantile(0.9, bar)" can't be sharded.
+			`foo > quantile(0.9, bar)`,
+			concat(`foo > quantile(0.9, bar)`),
 			0,
 		},
 		{
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// blockRangesSplitter chooses the interval to split range queries by for each time range, aligned to the
+// compaction block ranges: the old data, whose windows of the larger block ranges have already ended,
+// is split by the larger intervals, while the recent data is split by the smallest block range.
+type blockRangesSplitter struct {
+	// intervals are the candidate split intervals, sorted in ascending order. The largest one is the
+	// max split interval, and all the others are divisors of it, so that the split windows are nested.
+	intervals []time.Duration
+	now       func() time.Time
+}
+
+// newBlockRangesSplitter makes a new blockRangesSplitter. The block ranges must be sorted in ascending
+// order. The block ranges greater than maxInterval or not dividing it are ignored.
+func newBlockRangesSplitter(maxInterval time.Duration, blockRanges []time.Duration) *blockRangesSplitter {
+	intervals := make([]time.Duration, 0, len(blockRanges)+1)
+	for _, r := range blockRanges {
+		if r > 0 && r < maxInterval && maxInterval%r == 0 {
+			intervals = append(intervals, r)
+		}
+	}
+
+	return &blockRangesSplitter{
+		intervals: append(intervals, maxInterval),
+		now:       time.Now,
+	}
+}
+
+// intervalAt returns the interval to split the query at the given timestamp (in milliseconds) by, which is
+// the largest one whose window containing the timestamp has already ended, or the smallest one otherwise.
+func (s *blockRangesSplitter) intervalAt(t int64) time.Duration {
+	now := s.now().UnixMilli()
+
+	interval := s.intervals[0]
+	for _, candidate := range s.intervals[1:] {
+		candidateMillis := candidate.Milliseconds()
+		if windowEnd := (t/candidateMillis + 1) * candidateMillis; windowEnd > now {
+			break
+		}
+		interval = candidate
+	}
+	return interval
+}
+
+// GenerateCacheKey implements CacheSplitter. The requests split by the max interval share the same cache
+// keys of the ConstSplitter, while the other ones have a key per interval.
+func (s *blockRangesSplitter) GenerateCacheKey(ctx context.Context, userID string, r Request) string {
+	interval := s.intervalAt(r.GetStart())
+	key := ConstSplitter(interval).GenerateCacheKey(ctx, userID, r)
+	if interval == s.intervals[len(s.intervals)-1] {
+		return key
+	}
+	return fmt.Sprintf("%s:%s", key, interval)
+}
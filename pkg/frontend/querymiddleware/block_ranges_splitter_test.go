@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockRangesSplitter_IntervalAt(t *testing.T) {
+	now, err := time.Parse(time.RFC3339, "2021-10-17T13:30:00Z")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		maxInterval time.Duration
+		blockRanges []time.Duration
+		time        string
+		expected    time.Duration
+	}{
+		"should split the old data by the max interval": {
+			maxInterval: day,
+			blockRanges: []time.Duration{2 * time.Hour, 12 * time.Hour, day},
+			time:        "2021-10-15T06:00:00Z",
+			expected:    day,
+		},
+		"should split the data by the largest block range whose window has ended": {
+			maxInterval: day,
+			blockRanges: []time.Duration{2 * time.Hour, 12 * time.Hour, day},
+			time:        "2021-10-17T03:00:00Z",
+			expected:    12 * time.Hour,
+		},
+		"should split the data by the block range whose window has just ended": {
+			maxInterval: day,
+			blockRanges: []time.Duration{2 * time.Hour, 12 * time.Hour, day},
+			time:        "2021-10-17T10:00:00Z",
+			expected:    12 * time.Hour,
+		},
+		"should split the recent data by the smallest block range": {
+			maxInterval: day,
+			blockRanges: []time.Duration{2 * time.Hour, 12 * time.Hour, day},
+			time:        "2021-10-17T12:00:00Z",
+			expected:    2 * time.Hour,
+		},
+		"should ignore the block ranges greater than the max interval": {
+			maxInterval: 12 * time.Hour,
+			blockRanges: []time.Duration{2 * time.Hour, 12 * time.Hour, day},
+			time:        "2021-10-15T06:00:00Z",
+			expected:    12 * time.Hour,
+		},
+		"should ignore the block ranges not dividing the max interval": {
+			maxInterval: day,
+			blockRanges: []time.Duration{5 * time.Hour},
+			time:        "2021-10-17T12:00:00Z",
+			expected:    day,
+		},
+		"should split the old data by the max interval even if greater than the block ranges": {
+			maxInterval: 2 * day,
+			blockRanges: []time.Duration{2 * time.Hour},
+			time:        "2021-10-14T06:00:00Z",
+			expected:    2 * day,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			s := newBlockRangesSplitter(testData.maxInterval, testData.blockRanges)
+			s.now = func() time.Time { return now }
+
+			assert.Equal(t, testData.expected, s.intervalAt(timeToMillis(t, testData.time)))
+		})
+	}
+}
+
+func TestBlockRangesSplitter_SplitQuery(t *testing.T) {
+	now, err := time.Parse(time.RFC3339, "2021-10-17T13:30:00Z")
+	require.NoError(t, err)
+
+	s := newBlockRangesSplitter(day, []time.Duration{2 * time.Hour, 12 * time.Hour, day})
+	s.now = func() time.Time { return now }
+
+	step := time.Hour.Milliseconds()
+	input := &PrometheusRangeQueryRequest{Start: timeToMillis(t, "2021-10-16T18:00:00Z"), End: timeToMillis(t, "2021-10-17T13:00:00Z"), Step: step, Query: "foo"}
+
+	reqs, err := splitQueryByIntervalFunc(input, s.intervalAt)
+	require.NoError(t, err)
+	assert.Equal(t, []Request{
+		&PrometheusRangeQueryRequest{Start: timeToMillis(t, "2021-10-16T18:00:00Z"), End: timeToMillis(t, "2021-10-16T23:00:00Z"), Step: step, Query: "foo"},
+		&PrometheusRangeQueryRequest{Start: timeToMillis(t, "2021-10-17T00:00:00Z"), End: timeToMillis(t, "2021-10-17T11:00:00Z"), Step: step, Query: "foo"},
+		&PrometheusRangeQueryRequest{Start: timeToMillis(t, "2021-10-17T12:00:00Z"), End: timeToMillis(t, "2021-10-17T13:00:00Z"), Step: step, Query: "foo"},
+	}, reqs)
+}
+
+func TestBlockRangesSplitter_GenerateCacheKey(t *testing.T) {
+	now, err := time.Parse(time.RFC3339, "2021-10-17T13:30:00Z")
+	require.NoError(t, err)
+
+	s := newBlockRangesSplitter(day, []time.Duration{2 * time.Hour, 12 * time.Hour, day})
+	s.now = func() time.Time { return now }
+
+	// The requests split by the max interval should share the keys of the ConstSplitter.
+	oldReq := &PrometheusRangeQueryRequest{Start: timeToMillis(t, "2021-10-15T00:00:00Z"), End: timeToMillis(t, "2021-10-15T23:00:00Z"), Step: time.Hour.Milliseconds(), Query: "foo"}
+	assert.Equal(t, ConstSplitter(day).GenerateCacheKey(context.Background(), "user-1", oldReq), s.GenerateCacheKey(context.Background(), "user-1", oldReq))
+
+	// The requests split by a smaller interval should have a key per interval.
+	recentReq := &PrometheusRangeQueryRequest{Start: timeToMillis(t, "2021-10-17T12:00:00Z"), End: timeToMillis(t, "2021-10-17T13:00:00Z"), Step: time.Hour.Milliseconds(), Query: "foo"}
+	assert.Equal(t, ConstSplitter(2*time.Hour).GenerateCacheKey(context.Background(), "user-1", recentReq)+":2h0m0s", s.GenerateCacheKey(context.Background(), "user-1", recentReq))
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// cardinalityEstimateBucketSize is the size of the buckets the start and the length of the time range of
+	// the queries are aligned to, so that the same query over a similar time range shares the same estimate.
+	cardinalityEstimateBucketSize = 2 * time.Hour
+
+	// cardinalityEstimateTTL is the TTL of the cached cardinality estimates. Since the queries rejected
+	// because of their estimate aren't executed, their estimate isn't refreshed until it expires.
+	cardinalityEstimateTTL = 24 * time.Hour
+
+	limitMaxEstimatedFetchedSeries = "max_estimated_fetched_series_per_query"
+	limitMaxEstimatedFetchedChunks = "max_estimated_fetched_chunks_per_query"
+)
+
+type cardinalityEstimationMiddlewareMetrics struct {
+	limitExceededCount *prometheus.CounterVec
+}
+
+func newCardinalityEstimationMiddlewareMetrics(reg prometheus.Registerer) *cardinalityEstimationMiddlewareMetrics {
+	return &cardinalityEstimationMiddlewareMetrics{
+		limitExceededCount: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_estimated_cardinality_limit_exceeded_total",
+			Help: "Total number of queries estimated to fetch more series or chunks than the per-tenant limit, based on their previous execution.",
+		}, []string{"limit"}),
+	}
+}
+
+// cardinalityEstimationMiddleware is a Middleware rejecting the queries estimated to fetch more series or chunks
+// than the per-tenant limits before they're executed. The estimate of a query is the number of series and chunks
+// fetched by the previous execution of the same query over a similar time range, tracked in the results cache.
+type cardinalityEstimationMiddleware struct {
+	next     Handler
+	warnOnly bool
+	limits   Limits
+	cache    cache.Cache
+	logger   log.Logger
+	metrics  *cardinalityEstimationMiddlewareMetrics
+}
+
+// newCardinalityEstimationMiddleware makes a new cardinalityEstimationMiddleware. If warnOnly is true,
+// the queries exceeding the limits are only logged and tracked by metrics.
+func newCardinalityEstimationMiddleware(
+	warnOnly bool,
+	limits Limits,
+	cache cache.Cache,
+	logger log.Logger,
+	reg prometheus.Registerer) Middleware {
+	metrics := newCardinalityEstimationMiddlewareMetrics(reg)
+
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &cardinalityEstimationMiddleware{
+			next:     next,
+			warnOnly: warnOnly,
+			limits:   limits,
+			cache:    cache,
+			logger:   logger,
+			metrics:  metrics,
+		}
+	})
+}
+
+func (m *cardinalityEstimationMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return m.next.Do(ctx, req)
+	}
+
+	maxSeries := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, m.limits.MaxEstimatedFetchedSeriesPerQuery)
+	maxChunks := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, m.limits.MaxEstimatedFetchedChunksPerQuery)
+	if maxSeries <= 0 && maxChunks <= 0 {
+		return m.next.Do(ctx, req)
+	}
+
+	key := cardinalityEstimateCacheKey(tenant.JoinTenantIDs(tenantIDs), req)
+	if estimate := m.fetchEstimate(ctx, key); estimate != nil {
+		if err := m.checkEstimate(estimate, maxSeries, maxChunks); err != nil {
+			if !m.warnOnly {
+				return nil, apierror.New(apierror.TypeBadData, err.Error())
+			}
+			level.Warn(m.logger).Log("msg", "query estimated to exceed the cardinality limits", "query", req.GetQuery(), "err", err)
+		}
+	}
+
+	// Track the statistics of this query separately from the ones of the whole request, in order
+	// to know the number of series and chunks fetched by this query.
+	queryStats, queryCtx := stats.ContextWithEmptyStats(ctx)
+	res, err := m.next.Do(queryCtx, req)
+	stats.FromContext(ctx).Merge(queryStats)
+
+	// Nothing is fetched when the query is served from the results cache or deduplicated, in which case
+	// the previous estimate is kept.
+	if queryStats.LoadFetchedSeries() > 0 {
+		m.storeEstimate(ctx, key, queryStats)
+	}
+	return res, err
+}
+
+// checkEstimate returns the error of the first limit exceeded by the input estimate, if any.
+func (m *cardinalityEstimationMiddleware) checkEstimate(estimate *CachedCardinalityEstimate, maxSeries, maxChunks int) error {
+	var err error
+	if maxSeries > 0 && estimate.FetchedSeriesCount > uint64(maxSeries) {
+		m.metrics.limitExceededCount.WithLabelValues(limitMaxEstimatedFetchedSeries).Inc()
+		err = validation.NewMaxEstimatedSeriesPerQueryError(estimate.FetchedSeriesCount, maxSeries)
+	}
+	if maxChunks > 0 && estimate.FetchedChunksCount > uint64(maxChunks) {
+		m.metrics.limitExceededCount.WithLabelValues(limitMaxEstimatedFetchedChunks).Inc()
+		if err == nil {
+			err = validation.NewMaxEstimatedChunksPerQueryError(estimate.FetchedChunksCount, maxChunks)
+		}
+	}
+	return err
+}
+
+// fetchEstimate returns the cached cardinality estimate for the given key, or nil in case of error or cache miss.
+func (m *cardinalityEstimationMiddleware) fetchEstimate(ctx context.Context, key string) *CachedCardinalityEstimate {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, m.logger, "cardinalityEstimationMiddleware.fetchEstimate")
+	defer spanLog.Finish()
+
+	hashedKey := cacheHashKey(key)
+	spanLog.LogKV("key", key, "hashedKey", hashedKey)
+
+	founds := m.cache.Fetch(ctx, []string{hashedKey})
+	data, ok := founds[hashedKey]
+	if !ok {
+		return nil
+	}
+
+	var estimate CachedCardinalityEstimate
+	if err := proto.Unmarshal(data, &estimate); err != nil {
+		level.Error(spanLog).Log("msg", "error unmarshalling cached cardinality estimate", "err", err)
+		return nil
+	}
+
+	// Ensure there's no hashed key collision.
+	if estimate.Key != key {
+		return nil
+	}
+	return &estimate
+}
+
+// storeEstimate stores in the cache the number of series and chunks fetched by the query.
+func (m *cardinalityEstimationMiddleware) storeEstimate(ctx context.Context, key string, queryStats *stats.Stats) {
+	buf, err := proto.Marshal(&CachedCardinalityEstimate{
+		Key:                key,
+		FetchedSeriesCount: queryStats.LoadFetchedSeries(),
+		FetchedChunksCount: queryStats.LoadFetchedChunks(),
+	})
+	if err != nil {
+		level.Error(m.logger).Log("msg", "error marshalling cardinality estimate", "err", err)
+		return
+	}
+
+	m.cache.Store(ctx, map[string][]byte{cacheHashKey(key): buf}, cardinalityEstimateTTL)
+}
+
+// cardinalityEstimateCacheKey returns the cache key of the cardinality estimate of the given query. The key is
+// prefixed to never collide with the keys of the cached query results.
+func cardinalityEstimateCacheKey(userID string, req Request) string {
+	bucketSize := cardinalityEstimateBucketSize.Milliseconds()
+	return fmt.Sprintf("cardinality:%s:%s:%d:%d", userID, req.GetQuery(), req.GetStart()/bucketSize, (req.GetEnd()-req.GetStart())/bucketSize)
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
+
+func TestCardinalityEstimationMiddleware(t *testing.T) {
+	const userID = "user-1"
+
+	type fetched struct {
+		series, chunks uint64
+	}
+
+	tests := map[string]struct {
+		limits                mockLimits
+		warnOnly              bool
+		fetched               []fetched
+		expectedDownstreamReq int
+		expectedErr           globalerror.ID
+		expectedCacheItems    int
+	}{
+		"should not track the estimates if the limits are disabled": {
+			fetched:               []fetched{{series: 100, chunks: 1000}, {series: 100, chunks: 1000}},
+			expectedDownstreamReq: 2,
+		},
+		"should execute the queries estimated to be within the limits": {
+			limits:                mockLimits{maxEstimatedFetchedSeries: 100, maxEstimatedFetchedChunks: 1000},
+			fetched:               []fetched{{series: 100, chunks: 1000}, {series: 100, chunks: 1000}},
+			expectedDownstreamReq: 2,
+			expectedCacheItems:    1,
+		},
+		"should reject the queries estimated to exceed the max fetched series": {
+			limits:                mockLimits{maxEstimatedFetchedSeries: 100},
+			fetched:               []fetched{{series: 101, chunks: 1000}, {series: 101, chunks: 1000}},
+			expectedDownstreamReq: 1,
+			expectedErr:           globalerror.MaxEstimatedSeriesPerQuery,
+			expectedCacheItems:    1,
+		},
+		"should reject the queries estimated to exceed the max fetched chunks": {
+			limits:                mockLimits{maxEstimatedFetchedChunks: 1000},
+			fetched:               []fetched{{series: 100, chunks: 1001}, {series: 100, chunks: 1001}},
+			expectedDownstreamReq: 1,
+			expectedErr:           globalerror.MaxEstimatedChunksPerQuery,
+			expectedCacheItems:    1,
+		},
+		"should execute the queries exceeding the limits if warn only is enabled": {
+			limits:                mockLimits{maxEstimatedFetchedSeries: 100},
+			warnOnly:              true,
+			fetched:               []fetched{{series: 101, chunks: 1000}, {series: 101, chunks: 1000}},
+			expectedDownstreamReq: 2,
+			expectedCacheItems:    1,
+		},
+		"should not track the estimate of the queries which haven't fetched any series": {
+			limits:                mockLimits{maxEstimatedFetchedSeries: 100},
+			fetched:               []fetched{{series: 0, chunks: 0}, {series: 0, chunks: 0}},
+			expectedDownstreamReq: 2,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cacheBackend := cache.NewMockCache()
+			downstreamReqs := 0
+
+			mw := newCardinalityEstimationMiddleware(
+				testData.warnOnly,
+				testData.limits,
+				cacheBackend,
+				log.NewNopLogger(),
+				prometheus.NewPedanticRegistry(),
+			).Wrap(HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+				queryStats := stats.FromContext(ctx)
+				queryStats.AddFetchedSeries(testData.fetched[downstreamReqs].series)
+				queryStats.AddFetchedChunks(testData.fetched[downstreamReqs].chunks)
+				downstreamReqs++
+				return &PrometheusResponse{Status: statusSuccess}, nil
+			}))
+
+			ctx := user.InjectOrgID(context.Background(), userID)
+			req := &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: "up"}
+
+			var err error
+			for range testData.fetched {
+				_, err = mw.Do(ctx, req)
+				if err != nil {
+					break
+				}
+			}
+
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				assert.True(t, apierror.IsAPIError(err))
+				assert.Contains(t, err.Error(), string(testData.expectedErr))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, testData.expectedDownstreamReq, downstreamReqs)
+			assert.Len(t, cacheBackend.GetItems(), testData.expectedCacheItems)
+		})
+	}
+}
+
+func TestCardinalityEstimationMiddleware_ShouldMergeQueryStatsAndTrackMetrics(t *testing.T) {
+	const userID = "user-1"
+
+	reg := prometheus.NewPedanticRegistry()
+	limits := mockLimits{maxEstimatedFetchedSeries: 10, maxEstimatedFetchedChunks: 10}
+
+	mw := newCardinalityEstimationMiddleware(true, limits, cache.NewMockCache(), log.NewNopLogger(), reg).Wrap(
+		HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+			stats.FromContext(ctx).AddFetchedSeries(20)
+			stats.FromContext(ctx).AddFetchedChunks(20)
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		}))
+
+	requestStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), userID))
+	req := &PrometheusInstantQueryRequest{Time: 3600000, Query: "up"}
+	for i := 0; i < 2; i++ {
+		_, err := mw.Do(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// The statistics of the queries should be merged into the ones of the request.
+	assert.Equal(t, uint64(40), requestStats.LoadFetchedSeries())
+	assert.Equal(t, uint64(40), requestStats.LoadFetchedChunks())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_estimated_cardinality_limit_exceeded_total Total number of queries estimated to fetch more series or chunks than the per-tenant limit, based on their previous execution.
+		# TYPE cortex_frontend_query_estimated_cardinality_limit_exceeded_total counter
+		cortex_frontend_query_estimated_cardinality_limit_exceeded_total{limit="max_estimated_fetched_chunks_per_query"} 1
+		cortex_frontend_query_estimated_cardinality_limit_exceeded_total{limit="max_estimated_fetched_series_per_query"} 1
+	`)))
+}
+
+func TestCardinalityEstimateCacheKey(t *testing.T) {
+	const hour = int64(3600000)
+
+	// The same query over a similar time range should share the same key.
+	assert.Equal(t,
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: 0, End: 24 * hour, Query: "up"}),
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: hour, End: 25 * hour, Query: "up"}))
+
+	assert.NotEqual(t,
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: 0, End: 24 * hour, Query: "up"}),
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: 0, End: 48 * hour, Query: "up"}))
+	assert.NotEqual(t,
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: 0, End: 24 * hour, Query: "up"}),
+		cardinalityEstimateCacheKey("user-2", &PrometheusRangeQueryRequest{Start: 0, End: 24 * hour, Query: "up"}))
+	assert.NotEqual(t,
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: 0, End: 24 * hour, Query: "up"}),
+		cardinalityEstimateCacheKey("user-1", &PrometheusRangeQueryRequest{Start: 0, End: 24 * hour, Query: "sum(up)"}))
+}
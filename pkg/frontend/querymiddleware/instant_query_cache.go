@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type instantQueryCacheMiddlewareMetrics struct {
+	queryResultCacheAttemptedCount prometheus.Counter
+	queryResultCacheHitsCount      prometheus.Counter
+}
+
+func newInstantQueryCacheMiddlewareMetrics(reg prometheus.Registerer) *instantQueryCacheMiddlewareMetrics {
+	return &instantQueryCacheMiddlewareMetrics{
+		queryResultCacheAttemptedCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_instant_query_result_cache_attempted_total",
+			Help: "Total number of instant queries that were attempted to be fetched from cache.",
+		}),
+		queryResultCacheHitsCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_instant_query_result_cache_hits_total",
+			Help: "Total number of instant queries whose result was fetched from cache.",
+		}),
+	}
+}
+
+// instantQueryCacheMiddleware is a Middleware caching the results of instant queries. The time of the
+// instant queries is aligned down to the tenant time tolerance, so that the identical instant queries
+// received within the same period, like the ones issued by dashboards, are served from the cache.
+type instantQueryCacheMiddleware struct {
+	next      Handler
+	limits    Limits
+	cache     cache.Cache
+	extractor Extractor
+	logger    log.Logger
+	metrics   *instantQueryCacheMiddlewareMetrics
+}
+
+// newInstantQueryCacheMiddleware makes a new instantQueryCacheMiddleware.
+func newInstantQueryCacheMiddleware(
+	limits Limits,
+	cache cache.Cache,
+	extractor Extractor,
+	logger log.Logger,
+	reg prometheus.Registerer) Middleware {
+	metrics := newInstantQueryCacheMiddlewareMetrics(reg)
+
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &instantQueryCacheMiddleware{
+			next:      next,
+			limits:    limits,
+			cache:     cache,
+			extractor: extractor,
+			logger:    logger,
+			metrics:   metrics,
+		}
+	})
+}
+
+func (m *instantQueryCacheMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return m.next.Do(ctx, req)
+	}
+
+	// The results of instant queries are cached only if enabled for all the tenants.
+	tolerance := validation.MinDurationPerTenant(tenantIDs, m.limits.ResultsCacheInstantQueriesTimeTolerance)
+	if tolerance <= 0 || req.GetOptions().CacheDisabled {
+		return m.next.Do(ctx, req)
+	}
+
+	// Align the time of the query, so that all the identical queries within the same period share the cached result.
+	alignedTime := req.GetStart() - req.GetStart()%tolerance.Milliseconds()
+	req = req.WithStartEnd(alignedTime, alignedTime)
+	key := instantQueryCacheKey(tenant.JoinTenantIDs(tenantIDs), req)
+
+	m.metrics.queryResultCacheAttemptedCount.Inc()
+	if res := m.fetchCachedResponse(ctx, key); res != nil {
+		m.metrics.queryResultCacheHitsCount.Inc()
+		return res, nil
+	}
+
+	res, err := m.next.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if isResponseCachable(res, m.logger) {
+		m.storeCachedResponse(ctx, key, tenantIDs, req, res)
+	}
+	return res, nil
+}
+
+// fetchCachedResponse returns the cached response for the given key, or nil in case of error or cache miss.
+func (m *instantQueryCacheMiddleware) fetchCachedResponse(ctx context.Context, key string) Response {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, m.logger, "instantQueryCacheMiddleware.fetchCachedResponse")
+	defer spanLog.Finish()
+
+	hashedKey := cacheHashKey(key)
+	spanLog.LogKV("key", key, "hashedKey", hashedKey)
+
+	founds := m.cache.Fetch(ctx, []string{hashedKey})
+	data, ok := founds[hashedKey]
+	if !ok {
+		return nil
+	}
+
+	var cached CachedResponse
+	if err := proto.Unmarshal(data, &cached); err != nil {
+		level.Error(spanLog).Log("msg", "error unmarshalling cached response", "err", err)
+		return nil
+	}
+
+	// Ensure there's no hashed key collision.
+	if cached.Key != key || len(cached.Extents) != 1 {
+		return nil
+	}
+
+	res, err := cached.Extents[0].toResponse()
+	if err != nil {
+		level.Error(spanLog).Log("msg", "error decoding cached response", "err", err)
+		return nil
+	}
+	return res
+}
+
+// storeCachedResponse stores the response of the given request in the cache.
+func (m *instantQueryCacheMiddleware) storeCachedResponse(ctx context.Context, key string, tenantIDs []string, req Request, res Response) {
+	extent, err := toExtent(ctx, req, m.extractor.ResponseWithoutHeaders(res), time.Now())
+	if err != nil {
+		level.Error(m.logger).Log("msg", "error encoding instant query response to cache", "err", err)
+		return
+	}
+
+	buf, err := proto.Marshal(&CachedResponse{
+		Key:     key,
+		Extents: []Extent{extent},
+	})
+	if err != nil {
+		level.Error(m.logger).Log("msg", "error marshalling cached instant query response", "err", err)
+		return
+	}
+
+	ttl := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, m.limits.ResultsCacheTTLForInstantQueries)
+	m.cache.Store(ctx, map[string][]byte{cacheHashKey(key): buf}, ttl)
+}
+
+// instantQueryCacheKey returns the cache key of the given instant query, whose time has already been aligned.
+// The key is prefixed to never collide with the keys of the cached range query results.
+func instantQueryCacheKey(userID string, req Request) string {
+	return fmt.Sprintf("instant:%s:%s:%d", userID, req.GetQuery(), req.GetStart())
+}
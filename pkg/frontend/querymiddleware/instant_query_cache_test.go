@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestInstantQueryCacheMiddleware(t *testing.T) {
+	const userID = "user-1"
+
+	mkResponse := func(value float64, headers ...*PrometheusResponseHeader) *PrometheusResponse {
+		return &PrometheusResponse{
+			Status:  statusSuccess,
+			Headers: headers,
+			Data: &PrometheusData{
+				ResultType: model.ValVector.String(),
+				Result: []SampleStream{{
+					Labels:  []mimirpb.LabelAdapter{{Name: "job", Value: "app"}},
+					Samples: []mimirpb.Sample{{Value: value, TimestampMs: 60000}},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		limits                mockLimits
+		reqs                  []Request
+		downstreamHeaders     []*PrometheusResponseHeader
+		expectedDownstreamReq []int64
+		expectedCacheItems    int
+	}{
+		"should not cache the results if disabled for the tenant": {
+			reqs: []Request{
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up"},
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up"},
+			},
+			expectedDownstreamReq: []int64{65000, 65000},
+		},
+		"should serve the identical queries within the same period from the cache": {
+			limits: mockLimits{resultsCacheInstantTolerance: 30 * time.Second},
+			reqs: []Request{
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up"},
+				&PrometheusInstantQueryRequest{Time: 80000, Query: "up"},
+				&PrometheusInstantQueryRequest{Time: 95000, Query: "up"},
+				&PrometheusInstantQueryRequest{Time: 95000, Query: "sum(up)"},
+			},
+			expectedDownstreamReq: []int64{60000, 90000, 90000},
+			expectedCacheItems:    3,
+		},
+		"should not cache the results if caching is disabled for the request": {
+			limits: mockLimits{resultsCacheInstantTolerance: 30 * time.Second},
+			reqs: []Request{
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up", Options: Options{CacheDisabled: true}},
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up", Options: Options{CacheDisabled: true}},
+			},
+			expectedDownstreamReq: []int64{65000, 65000},
+		},
+		"should not cache the results if the response is not cachable": {
+			limits: mockLimits{resultsCacheInstantTolerance: 30 * time.Second},
+			reqs: []Request{
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up"},
+				&PrometheusInstantQueryRequest{Time: 65000, Query: "up"},
+			},
+			downstreamHeaders:     []*PrometheusResponseHeader{{Name: cacheControlHeader, Values: []string{noStoreValue}}},
+			expectedDownstreamReq: []int64{60000, 60000},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cacheBackend := cache.NewMockCache()
+			var downstreamReqs []int64
+
+			mw := newInstantQueryCacheMiddleware(
+				testData.limits,
+				cacheBackend,
+				PrometheusResponseExtractor{},
+				log.NewNopLogger(),
+				prometheus.NewPedanticRegistry(),
+			).Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				downstreamReqs = append(downstreamReqs, req.GetStart())
+				return mkResponse(float64(len(downstreamReqs)), testData.downstreamHeaders...), nil
+			}))
+
+			ctx := user.InjectOrgID(context.Background(), userID)
+			for _, req := range testData.reqs {
+				res, err := mw.Do(ctx, req)
+				require.NoError(t, err)
+				require.Equal(t, mkResponse(float64(len(downstreamReqs))).Data, res.(*PrometheusResponse).Data)
+			}
+
+			assert.Equal(t, testData.expectedDownstreamReq, downstreamReqs)
+			assert.Len(t, cacheBackend.GetItems(), testData.expectedCacheItems)
+		})
+	}
+}
+
+func TestInstantQueryCacheMiddleware_TTLAndMetrics(t *testing.T) {
+	const userID = "user-1"
+
+	cacheBackend := cache.NewMockCache()
+	reg := prometheus.NewPedanticRegistry()
+	limits := mockLimits{resultsCacheInstantTolerance: time.Minute, resultsCacheTTLForInstant: 20 * time.Second}
+
+	mw := newInstantQueryCacheMiddleware(limits, cacheBackend, PrometheusResponseExtractor{}, log.NewNopLogger(), reg).Wrap(
+		HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+			return &PrometheusResponse{Status: statusSuccess, Data: &PrometheusData{ResultType: model.ValVector.String()}}, nil
+		}))
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	req := &PrometheusInstantQueryRequest{Time: 90000, Query: "up"}
+	for i := 0; i < 3; i++ {
+		_, err := mw.Do(ctx, req)
+		require.NoError(t, err)
+	}
+
+	item, ok := cacheBackend.GetItems()[cacheHashKey(instantQueryCacheKey(userID, req.WithStartEnd(60000, 60000)))]
+	require.True(t, ok)
+	assert.InDelta(t, float64(20*time.Second), float64(time.Until(item.ExpiresAt)), float64(time.Second))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_instant_query_result_cache_attempted_total Total number of instant queries that were attempted to be fetched from cache.
+		# TYPE cortex_frontend_instant_query_result_cache_attempted_total counter
+		cortex_frontend_instant_query_result_cache_attempted_total 3
+
+		# HELP cortex_frontend_instant_query_result_cache_hits_total Total number of instant queries whose result was fetched from cache.
+		# TYPE cortex_frontend_instant_query_result_cache_hits_total counter
+		cortex_frontend_instant_query_result_cache_hits_total 2
+	`)))
+}
@@ -40,10 +40,40 @@ type Limits interface {
 	// frontend will process in parallel.
 	MaxQueryParallelism(userID string) int
 
+	// MaxEstimatedFetchedSeriesPerQuery returns the maximum number of series a query is estimated
+	// to fetch, based on its previous execution. 0 to disable.
+	MaxEstimatedFetchedSeriesPerQuery(userID string) int
+
+	// MaxEstimatedFetchedChunksPerQuery returns the maximum number of chunks a query is estimated
+	// to fetch, based on its previous execution. 0 to disable.
+	MaxEstimatedFetchedChunksPerQuery(userID string) int
+
 	// MaxCacheFreshness returns the period after which results are cacheable,
 	// to prevent caching of very recent results.
 	MaxCacheFreshness(userID string) time.Duration
 
+	// ResultsCacheTTL returns the TTL of cached results whose time range ends before the recent results window.
+	ResultsCacheTTL(userID string) time.Duration
+
+	// ResultsCacheTTLForRecentResults returns the TTL of cached results whose time range ends within
+	// the recent results window.
+	ResultsCacheTTLForRecentResults(userID string) time.Duration
+
+	// ResultsCacheRecentResultsWindow returns the period, relative to now, within which cached results
+	// are considered recent and cached with a shorter TTL.
+	ResultsCacheRecentResultsWindow(userID string) time.Duration
+
+	// ResultsCacheMaxStaleness returns the maximum period cached results are served after their TTL
+	// has expired, while they're refreshed in the background. 0 to disable.
+	ResultsCacheMaxStaleness(userID string) time.Duration
+
+	// ResultsCacheInstantQueriesTimeTolerance returns the period the time of instant queries is aligned to
+	// in order to cache their results. 0 to disable caching the results of instant queries.
+	ResultsCacheInstantQueriesTimeTolerance(userID string) time.Duration
+
+	// ResultsCacheTTLForInstantQueries returns the TTL of cached instant query results.
+	ResultsCacheTTLForInstantQueries(userID string) time.Duration
+
 	// QueryShardingTotalShards returns the number of shards to use for a given tenant.
 	QueryShardingTotalShards(userID string) int
 
@@ -284,6 +314,11 @@ func (rth roundTripperHandler) Do(ctx context.Context, r Request) (Response, err
 	if err := user.InjectOrgIDIntoHTTPRequest(ctx, request); err != nil {
 		return nil, apierror.New(apierror.TypeBadData, err.Error())
 	}
+	util.GrafanaSourceFromContext(ctx).InjectIntoHTTPHeaders(request.Header)
+	if priority := util.QueryPriorityFromContext(ctx); priority != "" {
+		request.Header.Set(util.QueryPriorityHeader, priority)
+	}
+	util.InjectQueryTimeoutIntoHTTPHeaders(ctx, request.Header)
 
 	response, err := rth.next.RoundTrip(request)
 	if err != nil {
@@ -7,6 +7,7 @@ package querymiddleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"sync"
 	"testing"
@@ -283,7 +284,15 @@ type mockLimits struct {
 	maxQueryLength                 time.Duration
 	maxTotalQueryLength            time.Duration
 	maxCacheFreshness              time.Duration
+	resultsCacheTTL                time.Duration
+	resultsCacheTTLForRecent       time.Duration
+	resultsCacheRecentWindow       time.Duration
+	resultsCacheMaxStaleness       time.Duration
+	resultsCacheInstantTolerance   time.Duration
+	resultsCacheTTLForInstant      time.Duration
 	maxQueryParallelism            int
+	maxEstimatedFetchedSeries      int
+	maxEstimatedFetchedChunks      int
 	maxShardedQueries              int
 	splitInstantQueriesInterval    time.Duration
 	totalShards                    int
@@ -315,10 +324,51 @@ func (m mockLimits) MaxQueryParallelism(string) int {
 	return m.maxQueryParallelism
 }
 
+func (m mockLimits) MaxEstimatedFetchedSeriesPerQuery(string) int {
+	return m.maxEstimatedFetchedSeries
+}
+
+func (m mockLimits) MaxEstimatedFetchedChunksPerQuery(string) int {
+	return m.maxEstimatedFetchedChunks
+}
+
 func (m mockLimits) MaxCacheFreshness(string) time.Duration {
 	return m.maxCacheFreshness
 }
 
+func (m mockLimits) ResultsCacheTTL(string) time.Duration {
+	if m.resultsCacheTTL == 0 {
+		return 7 * 24 * time.Hour // Flag default.
+	}
+	return m.resultsCacheTTL
+}
+
+func (m mockLimits) ResultsCacheTTLForRecentResults(string) time.Duration {
+	if m.resultsCacheTTLForRecent == 0 {
+		return 10 * time.Minute // Flag default.
+	}
+	return m.resultsCacheTTLForRecent
+}
+
+func (m mockLimits) ResultsCacheRecentResultsWindow(string) time.Duration {
+	return m.resultsCacheRecentWindow
+}
+
+func (m mockLimits) ResultsCacheMaxStaleness(string) time.Duration {
+	return m.resultsCacheMaxStaleness
+}
+
+func (m mockLimits) ResultsCacheInstantQueriesTimeTolerance(string) time.Duration {
+	return m.resultsCacheInstantTolerance
+}
+
+func (m mockLimits) ResultsCacheTTLForInstantQueries(string) time.Duration {
+	if m.resultsCacheTTLForInstant == 0 {
+		return time.Minute // Flag default.
+	}
+	return m.resultsCacheTTLForInstant
+}
+
 func (m mockLimits) QueryShardingTotalShards(string) int {
 	return m.totalShards
 }
@@ -501,3 +551,37 @@ func TestLimitedRoundTripper_OriginalRequestContextCancellation(t *testing.T) {
 	).RoundTrip(r)
 	require.NoError(t, err)
 }
+
+func TestRoundTripperHandler_ShouldPropagateGrafanaSource(t *testing.T) {
+	source := util.GrafanaSource{DashboardUID: "dashboard", PanelID: "2"}
+
+	var actual util.GrafanaSource
+	handler := roundTripperHandler{
+		logger: log.NewNopLogger(),
+		codec:  PrometheusCodec,
+		next: RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			actual = util.GrafanaSourceFromHTTPHeaders(r.Header)
+			return nil, errors.New("stop")
+		}),
+	}
+
+	ctx := util.ContextWithGrafanaSource(user.InjectOrgID(context.Background(), "user"), source)
+	_, _ = handler.Do(ctx, &PrometheusInstantQueryRequest{Path: "/api/v1/query", Query: "up"})
+	assert.Equal(t, source, actual)
+}
+
+func TestRoundTripperHandler_ShouldPropagateQueryPriority(t *testing.T) {
+	var actual string
+	handler := roundTripperHandler{
+		logger: log.NewNopLogger(),
+		codec:  PrometheusCodec,
+		next: RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			actual = r.Header.Get(util.QueryPriorityHeader)
+			return nil, errors.New("stop")
+		}),
+	}
+
+	ctx := util.ContextWithQueryPriority(user.InjectOrgID(context.Background(), "user"), "high")
+	_, _ = handler.Do(ctx, &PrometheusInstantQueryRequest{Path: "/api/v1/query", Query: "up"})
+	assert.Equal(t, "high", actual)
+}
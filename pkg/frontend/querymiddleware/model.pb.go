@@ -287,6 +287,7 @@ type PrometheusResponse struct {
 	ErrorType string                      `protobuf:"bytes,3,opt,name=ErrorType,proto3" json:"errorType,omitempty"`
 	Error     string                      `protobuf:"bytes,4,opt,name=Error,proto3" json:"error,omitempty"`
 	Headers   []*PrometheusResponseHeader `protobuf:"bytes,5,rep,name=Headers,proto3" json:"-"`
+	Warnings  []string                    `protobuf:"bytes,6,rep,name=Warnings,proto3" json:"warnings,omitempty"`
 }
 
 func (m *PrometheusResponse) Reset()      { *m = PrometheusResponse{} }
@@ -356,6 +357,13 @@ func (m *PrometheusResponse) GetHeaders() []*PrometheusResponseHeader {
 	return nil
 }
 
+func (m *PrometheusResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type PrometheusData struct {
 	ResultType string         `protobuf:"bytes,1,opt,name=ResultType,proto3" json:"resultType"`
 	Result     []SampleStream `protobuf:"bytes,2,rep,name=Result,proto3" json:"result"`
@@ -504,10 +512,11 @@ func (m *CachedResponse) GetExtents() []Extent {
 }
 
 type Extent struct {
-	Start    int64      `protobuf:"varint,1,opt,name=start,proto3" json:"start"`
-	End      int64      `protobuf:"varint,2,opt,name=end,proto3" json:"end"`
-	TraceId  string     `protobuf:"bytes,4,opt,name=trace_id,json=traceId,proto3" json:"-"`
-	Response *types.Any `protobuf:"bytes,5,opt,name=response,proto3" json:"response"`
+	Start            int64      `protobuf:"varint,1,opt,name=start,proto3" json:"start"`
+	End              int64      `protobuf:"varint,2,opt,name=end,proto3" json:"end"`
+	TraceId          string     `protobuf:"bytes,4,opt,name=trace_id,json=traceId,proto3" json:"-"`
+	Response         *types.Any `protobuf:"bytes,5,opt,name=response,proto3" json:"response"`
+	QueryTimestampMs int64      `protobuf:"varint,6,opt,name=query_timestamp_ms,json=queryTimestampMs,proto3" json:"query_timestamp_ms"`
 }
 
 func (m *Extent) Reset()      { *m = Extent{} }
@@ -570,6 +579,13 @@ func (m *Extent) GetResponse() *types.Any {
 	return nil
 }
 
+func (m *Extent) GetQueryTimestampMs() int64 {
+	if m != nil {
+		return m.QueryTimestampMs
+	}
+	return 0
+}
+
 type Options struct {
 	CacheDisabled        bool  `protobuf:"varint,1,opt,name=CacheDisabled,proto3" json:"CacheDisabled,omitempty"`
 	ShardingDisabled     bool  `protobuf:"varint,2,opt,name=ShardingDisabled,proto3" json:"ShardingDisabled,omitempty"`
@@ -690,6 +706,67 @@ func (m *Hints) GetTotalQueries() int32 {
 	return 0
 }
 
+// CachedCardinalityEstimate holds the number of series and chunks fetched by the last execution of a query,
+// used to estimate the cardinality of the next executions of the same query.
+type CachedCardinalityEstimate struct {
+	Key                string `protobuf:"bytes,1,opt,name=key,proto3" json:"key"`
+	FetchedSeriesCount uint64 `protobuf:"varint,2,opt,name=fetched_series_count,json=fetchedSeriesCount,proto3" json:"fetched_series_count,omitempty"`
+	FetchedChunksCount uint64 `protobuf:"varint,3,opt,name=fetched_chunks_count,json=fetchedChunksCount,proto3" json:"fetched_chunks_count,omitempty"`
+}
+
+func (m *CachedCardinalityEstimate) Reset()      { *m = CachedCardinalityEstimate{} }
+func (*CachedCardinalityEstimate) ProtoMessage() {}
+func (*CachedCardinalityEstimate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4c16552f9fdb66d8, []int{10}
+}
+func (m *CachedCardinalityEstimate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CachedCardinalityEstimate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CachedCardinalityEstimate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CachedCardinalityEstimate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CachedCardinalityEstimate.Merge(m, src)
+}
+func (m *CachedCardinalityEstimate) XXX_Size() int {
+	return m.Size()
+}
+func (m *CachedCardinalityEstimate) XXX_DiscardUnknown() {
+	xxx_messageInfo_CachedCardinalityEstimate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CachedCardinalityEstimate proto.InternalMessageInfo
+
+func (m *CachedCardinalityEstimate) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *CachedCardinalityEstimate) GetFetchedSeriesCount() uint64 {
+	if m != nil {
+		return m.FetchedSeriesCount
+	}
+	return 0
+}
+
+func (m *CachedCardinalityEstimate) GetFetchedChunksCount() uint64 {
+	if m != nil {
+		return m.FetchedChunksCount
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*PrometheusRangeQueryRequest)(nil), "queryrange.PrometheusRangeQueryRequest")
 	proto.RegisterType((*PrometheusInstantQueryRequest)(nil), "queryrange.PrometheusInstantQueryRequest")
@@ -701,75 +778,82 @@ func init() {
 	proto.RegisterType((*Extent)(nil), "queryrange.Extent")
 	proto.RegisterType((*Options)(nil), "queryrange.Options")
 	proto.RegisterType((*Hints)(nil), "queryrange.Hints")
+	proto.RegisterType((*CachedCardinalityEstimate)(nil), "queryrange.CachedCardinalityEstimate")
 }
 
 func init() { proto.RegisterFile("model.proto", fileDescriptor_4c16552f9fdb66d8) }
 
 var fileDescriptor_4c16552f9fdb66d8 = []byte{
-	// 993 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xcd, 0x6e, 0x1c, 0x45,
-	0x10, 0xde, 0xd9, 0x7f, 0xd7, 0x86, 0xb5, 0x69, 0x5b, 0x62, 0x6c, 0x94, 0x99, 0xd5, 0x28, 0x07,
-	0xf3, 0xe3, 0x35, 0x38, 0xe2, 0x82, 0x04, 0x22, 0x13, 0x5b, 0x8a, 0x11, 0x82, 0xd0, 0xb6, 0x38,
-	0x70, 0x41, 0xbd, 0x3b, 0x9d, 0xdd, 0x21, 0xf3, 0x97, 0x9e, 0x9e, 0x90, 0xbd, 0x21, 0x9e, 0x80,
-	0x23, 0x2f, 0x80, 0xc4, 0x81, 0x33, 0x27, 0x1e, 0x20, 0x47, 0x73, 0x8b, 0x38, 0x0c, 0x78, 0x2d,
-	0x24, 0xb4, 0xa7, 0x3c, 0x02, 0xea, 0xea, 0x99, 0xdd, 0x71, 0x6c, 0x44, 0xb8, 0xec, 0x56, 0x57,
-	0x7d, 0x55, 0xfd, 0xd5, 0x37, 0xd5, 0x05, 0xbd, 0x30, 0xf6, 0x78, 0x30, 0x4c, 0x44, 0x2c, 0x63,
-	0x02, 0x8f, 0x32, 0x2e, 0x66, 0x82, 0x45, 0x13, 0xbe, 0xb3, 0x37, 0xf1, 0xe5, 0x34, 0x1b, 0x0d,
-	0xc7, 0x71, 0xb8, 0x3f, 0x89, 0x27, 0xf1, 0x3e, 0x42, 0x46, 0xd9, 0x03, 0x3c, 0xe1, 0x01, 0x2d,
-	0x9d, 0xba, 0x63, 0x4d, 0xe2, 0x78, 0x12, 0xf0, 0x15, 0xca, 0xcb, 0x04, 0x93, 0x7e, 0x1c, 0x15,
-	0xf1, 0x77, 0xaa, 0xe5, 0x04, 0x7b, 0xc0, 0x22, 0xb6, 0x1f, 0xfa, 0xa1, 0x2f, 0xf6, 0x93, 0x87,
-	0x13, 0x6d, 0x25, 0x23, 0xfd, 0x5f, 0x64, 0x6c, 0xbf, 0x58, 0x91, 0x45, 0x33, 0x1d, 0x72, 0x7e,
-	0xa9, 0xc3, 0xeb, 0xf7, 0x45, 0x1c, 0x72, 0x39, 0xe5, 0x59, 0x4a, 0x15, 0xdf, 0xcf, 0x15, 0x73,
-	0xca, 0x1f, 0x65, 0x3c, 0x95, 0x84, 0x40, 0x33, 0x61, 0x72, 0x6a, 0x1a, 0x03, 0x63, 0x77, 0x8d,
-	0xa2, 0x4d, 0xb6, 0xa0, 0x95, 0x4a, 0x26, 0xa4, 0x59, 0x1f, 0x18, 0xbb, 0x0d, 0xaa, 0x0f, 0x64,
-	0x03, 0x1a, 0x3c, 0xf2, 0xcc, 0x06, 0xfa, 0x94, 0xa9, 0x72, 0x53, 0xc9, 0x13, 0xb3, 0x89, 0x2e,
-	0xb4, 0xc9, 0x07, 0xd0, 0x91, 0x7e, 0xc8, 0xe3, 0x4c, 0x9a, 0xad, 0x81, 0xb1, 0xdb, 0x3b, 0xd8,
-	0x1e, 0x6a, 0x72, 0xc3, 0x92, 0xdc, 0xf0, 0xb0, 0x68, 0xd7, 0xed, 0x3e, 0xcd, 0xed, 0xda, 0x0f,
-	0x7f, 0xd8, 0x06, 0x2d, 0x73, 0xd4, 0xd5, 0x28, 0xac, 0xd9, 0x46, 0x3e, 0xfa, 0x40, 0x6e, 0x43,
-	0x27, 0x4e, 0x54, 0x4a, 0x6a, 0x76, 0xb0, 0xe8, 0xe6, 0x70, 0x25, 0xff, 0xf0, 0x33, 0x1d, 0x72,
-	0x9b, 0xaa, 0x1c, 0x2d, 0x91, 0xa4, 0x0f, 0x75, 0xdf, 0x33, 0xbb, 0xc8, 0xad, 0xee, 0x7b, 0x64,
-	0x0f, 0x5a, 0x53, 0x3f, 0x92, 0xa9, 0xb9, 0x86, 0x25, 0x5e, 0xad, 0x96, 0xb8, 0xa7, 0x02, 0x58,
-	0xc0, 0xa0, 0x1a, 0xe5, 0xfc, 0x66, 0xc0, 0xcd, 0x95, 0x70, 0xc7, 0x51, 0x2a, 0x59, 0x24, 0xff,
-	0x53, 0x3a, 0x02, 0x4d, 0xd5, 0x4a, 0xa1, 0x1c, 0xda, 0xab, 0x9e, 0x1a, 0xff, 0xd2, 0x53, 0xf3,
-	0x7f, 0xf6, 0xd4, 0xba, 0xda, 0x53, 0xfb, 0xa5, 0x7a, 0x3a, 0x05, 0xb3, 0x32, 0x0b, 0x3c, 0x4d,
-	0xe2, 0x28, 0xe5, 0xf7, 0x38, 0xf3, 0xb8, 0x20, 0xdb, 0xd0, 0xfc, 0x94, 0x85, 0x5c, 0x77, 0xe3,
-	0xb6, 0x16, 0xb9, 0x6d, 0xec, 0x51, 0x74, 0x91, 0x9b, 0xd0, 0xfe, 0x82, 0x05, 0x19, 0x4f, 0xcd,
-	0xfa, 0xa0, 0xb1, 0x0a, 0x16, 0x4e, 0xe7, 0xc7, 0x3a, 0x90, 0xab, 0x65, 0x89, 0x03, 0xed, 0x13,
-	0xc9, 0x64, 0x96, 0x16, 0x25, 0x61, 0x91, 0xdb, 0xed, 0x14, 0x3d, 0xb4, 0x88, 0x10, 0x17, 0x9a,
-	0x87, 0x4c, 0x32, 0x94, 0xab, 0x77, 0xb0, 0x53, 0xa5, 0xbf, 0xaa, 0xa8, 0x10, 0x2e, 0x59, 0xe4,
-	0x76, 0xdf, 0x63, 0x92, 0xbd, 0x1d, 0x87, 0xbe, 0xe4, 0x61, 0x22, 0x67, 0x14, 0x73, 0xc9, 0x7b,
-	0xb0, 0x76, 0x24, 0x44, 0x2c, 0x4e, 0x67, 0x09, 0xd7, 0x12, 0xbb, 0xaf, 0x2d, 0x72, 0x7b, 0x93,
-	0x97, 0xce, 0x4a, 0xc6, 0x0a, 0x49, 0xde, 0x80, 0x16, 0x1e, 0x50, 0xfd, 0x35, 0x77, 0x73, 0x91,
-	0xdb, 0xeb, 0x98, 0x52, 0x81, 0x6b, 0x04, 0x39, 0x82, 0x8e, 0x16, 0x29, 0x35, 0x5b, 0x83, 0xc6,
-	0x6e, 0xef, 0xe0, 0xd6, 0xf5, 0x44, 0x2f, 0x2b, 0x5a, 0xca, 0x54, 0xe6, 0x3a, 0xdf, 0x19, 0xd0,
-	0xbf, 0xdc, 0x15, 0x19, 0x02, 0x50, 0x9e, 0x66, 0x81, 0x44, 0xf2, 0x5a, 0xa7, 0xfe, 0x22, 0xb7,
-	0x41, 0x2c, 0xbd, 0xb4, 0x82, 0x20, 0x1f, 0x41, 0x5b, 0x9f, 0xf0, 0x4b, 0xf4, 0x0e, 0xcc, 0x2a,
-	0x91, 0x13, 0x16, 0x26, 0x01, 0x3f, 0x91, 0x82, 0xb3, 0xd0, 0xed, 0xab, 0xc1, 0x51, 0x8a, 0xeb,
-	0x4a, 0xb4, 0xc8, 0x73, 0x7e, 0x35, 0xe0, 0x46, 0x15, 0x48, 0x12, 0x68, 0x07, 0x6c, 0xc4, 0x03,
-	0xf5, 0x99, 0x1a, 0x38, 0x86, 0xe3, 0x58, 0x48, 0xfe, 0x24, 0x19, 0x0d, 0x3f, 0x51, 0xfe, 0xfb,
-	0xcc, 0x17, 0xee, 0x5d, 0x55, 0xed, 0xf7, 0xdc, 0x7e, 0xf7, 0x65, 0x56, 0x93, 0xce, 0xbb, 0xe3,
-	0xb1, 0x44, 0x72, 0xa1, 0x28, 0x84, 0x5c, 0x0a, 0x7f, 0x4c, 0x8b, 0x7b, 0xc8, 0xfb, 0xd0, 0x49,
-	0x91, 0x41, 0x5a, 0x74, 0xb1, 0xb1, 0xba, 0x52, 0x53, 0x5b, 0xb1, 0x7f, 0x8c, 0x23, 0x46, 0xcb,
-	0x04, 0xe7, 0x6b, 0xe8, 0xdf, 0x65, 0xe3, 0x29, 0xf7, 0x96, 0x63, 0xb6, 0x0d, 0x8d, 0x87, 0x7c,
-	0x56, 0x68, 0xd7, 0x59, 0xe4, 0xb6, 0x3a, 0x52, 0xf5, 0xa3, 0x76, 0x11, 0x7f, 0x22, 0xb9, 0x7a,
-	0x1f, 0xfa, 0x22, 0x52, 0x95, 0xeb, 0x08, 0x43, 0xee, 0x7a, 0x71, 0x55, 0x09, 0xa5, 0xa5, 0xe1,
-	0xfc, 0x6c, 0x40, 0x5b, 0x83, 0x88, 0x5d, 0x6e, 0x44, 0x75, 0x4d, 0xc3, 0x5d, 0x5b, 0xe4, 0xb6,
-	0x76, 0x94, 0xcb, 0x71, 0x5b, 0x2f, 0x47, 0x7c, 0xf6, 0x9a, 0x05, 0x8f, 0x3c, 0xbd, 0x25, 0x07,
-	0xd0, 0x95, 0x82, 0x8d, 0xf9, 0x57, 0xbe, 0x57, 0xcc, 0x5a, 0x39, 0x18, 0xe8, 0x3e, 0xf6, 0xc8,
-	0x87, 0xd0, 0x15, 0x45, 0x3b, 0xc5, 0xd2, 0xdc, 0xba, 0xb2, 0x34, 0xef, 0x44, 0x33, 0xf7, 0xc6,
-	0x22, 0xb7, 0x97, 0x48, 0xba, 0xb4, 0x3e, 0x6e, 0x76, 0x1b, 0x1b, 0x4d, 0xe7, 0x2f, 0x03, 0x3a,
-	0xc5, 0xda, 0x20, 0xb7, 0xe0, 0x15, 0x94, 0xe9, 0xd0, 0x4f, 0xd9, 0x28, 0xe0, 0x1e, 0xf2, 0xee,
-	0xd2, 0xcb, 0x4e, 0xf2, 0x26, 0x6c, 0x9c, 0x4c, 0x99, 0xf0, 0xfc, 0x68, 0xb2, 0x04, 0xd6, 0x11,
-	0x78, 0xc5, 0x4f, 0x06, 0xd0, 0x3b, 0x8d, 0x25, 0x0b, 0x30, 0x90, 0xe2, 0x3b, 0x6b, 0xd1, 0xaa,
-	0x8b, 0x1c, 0xc0, 0x56, 0xb1, 0x25, 0x4f, 0x92, 0xc0, 0x97, 0xcb, 0x8a, 0x4d, 0xac, 0x78, 0x6d,
-	0xec, 0xc5, 0x9c, 0xe3, 0x48, 0x72, 0xf1, 0x98, 0x05, 0xc5, 0x86, 0xbb, 0x36, 0xe6, 0xbc, 0x05,
-	0x2d, 0x5c, 0x6d, 0xc4, 0x81, 0x1b, 0x78, 0xbf, 0x5a, 0xca, 0x3e, 0xd7, 0x6b, 0xa6, 0x45, 0x2f,
-	0xf9, 0xdc, 0xa3, 0xb3, 0x73, 0xab, 0xf6, 0xec, 0xdc, 0xaa, 0x3d, 0x3f, 0xb7, 0x8c, 0x6f, 0xe7,
-	0x96, 0xf1, 0xd3, 0xdc, 0x32, 0x9e, 0xce, 0x2d, 0xe3, 0x6c, 0x6e, 0x19, 0x7f, 0xce, 0x2d, 0xe3,
-	0xef, 0xb9, 0x55, 0x7b, 0x3e, 0xb7, 0x8c, 0xef, 0x2f, 0xac, 0xda, 0xd9, 0x85, 0x55, 0x7b, 0x76,
-	0x61, 0xd5, 0xbe, 0x5c, 0xc7, 0x31, 0x09, 0x7d, 0xcf, 0x0b, 0xf8, 0x37, 0x4c, 0xf0, 0x51, 0x1b,
-	0xbf, 0xc3, 0xed, 0x7f, 0x02, 0x00, 0x00, 0xff, 0xff, 0x35, 0xce, 0x26, 0x85, 0x03, 0x08, 0x00,
-	0x00,
+	// 1104 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0x4d, 0x6f, 0x1b, 0x45,
+	0x18, 0xf6, 0x7a, 0xfd, 0x95, 0x71, 0x71, 0xc3, 0x34, 0x82, 0x75, 0x50, 0x77, 0xad, 0x55, 0x0f,
+	0xe1, 0x23, 0x4e, 0x71, 0xc5, 0x05, 0x09, 0x44, 0x37, 0x89, 0xd4, 0x20, 0x3e, 0xca, 0x38, 0x02,
+	0x89, 0x4b, 0x34, 0xf6, 0x4e, 0xec, 0x25, 0xfb, 0xd5, 0xd9, 0xd9, 0xb6, 0xbe, 0x21, 0x7e, 0x01,
+	0x47, 0xc4, 0x2f, 0xe0, 0x17, 0x70, 0xe2, 0x07, 0xf4, 0x18, 0x6e, 0x85, 0xc3, 0x42, 0x1c, 0x21,
+	0x21, 0x9f, 0x2a, 0x7e, 0x01, 0x9a, 0x77, 0x76, 0xed, 0x4d, 0x13, 0xa0, 0x5c, 0x92, 0x99, 0xe7,
+	0x7d, 0x9f, 0xf7, 0xe3, 0x99, 0xd7, 0xef, 0xa2, 0x76, 0x10, 0xb9, 0xcc, 0xef, 0xc7, 0x3c, 0x12,
+	0x11, 0x46, 0x0f, 0x52, 0xc6, 0x67, 0x9c, 0x86, 0x13, 0xb6, 0xb9, 0x3d, 0xf1, 0xc4, 0x34, 0x1d,
+	0xf5, 0xc7, 0x51, 0xb0, 0x33, 0x89, 0x26, 0xd1, 0x0e, 0xb8, 0x8c, 0xd2, 0x63, 0xb8, 0xc1, 0x05,
+	0x4e, 0x8a, 0xba, 0x69, 0x4e, 0xa2, 0x68, 0xe2, 0xb3, 0x95, 0x97, 0x9b, 0x72, 0x2a, 0xbc, 0x28,
+	0xcc, 0xed, 0xb7, 0xcb, 0xe1, 0x38, 0x3d, 0xa6, 0x21, 0xdd, 0x09, 0xbc, 0xc0, 0xe3, 0x3b, 0xf1,
+	0xc9, 0x44, 0x9d, 0xe2, 0x91, 0xfa, 0x9f, 0x33, 0xba, 0xcf, 0x47, 0xa4, 0xe1, 0x4c, 0x99, 0xec,
+	0x1f, 0xab, 0xe8, 0xb5, 0xfb, 0x3c, 0x0a, 0x98, 0x98, 0xb2, 0x34, 0x21, 0xb2, 0xde, 0xcf, 0x64,
+	0xe5, 0x84, 0x3d, 0x48, 0x59, 0x22, 0x30, 0x46, 0xb5, 0x98, 0x8a, 0xa9, 0xa1, 0xf5, 0xb4, 0xad,
+	0x35, 0x02, 0x67, 0xbc, 0x81, 0xea, 0x89, 0xa0, 0x5c, 0x18, 0xd5, 0x9e, 0xb6, 0xa5, 0x13, 0x75,
+	0xc1, 0xeb, 0x48, 0x67, 0xa1, 0x6b, 0xe8, 0x80, 0xc9, 0xa3, 0xe4, 0x26, 0x82, 0xc5, 0x46, 0x0d,
+	0x20, 0x38, 0xe3, 0xf7, 0x50, 0x53, 0x78, 0x01, 0x8b, 0x52, 0x61, 0xd4, 0x7b, 0xda, 0x56, 0x7b,
+	0xd0, 0xed, 0xab, 0xe2, 0xfa, 0x45, 0x71, 0xfd, 0xbd, 0xbc, 0x5d, 0xa7, 0xf5, 0x24, 0xb3, 0x2a,
+	0xdf, 0xfd, 0x66, 0x69, 0xa4, 0xe0, 0xc8, 0xd4, 0x20, 0xac, 0xd1, 0x80, 0x7a, 0xd4, 0x05, 0xdf,
+	0x41, 0xcd, 0x28, 0x96, 0x94, 0xc4, 0x68, 0x42, 0xd0, 0x1b, 0xfd, 0x95, 0xfc, 0xfd, 0x4f, 0x95,
+	0xc9, 0xa9, 0xc9, 0x70, 0xa4, 0xf0, 0xc4, 0x1d, 0x54, 0xf5, 0x5c, 0xa3, 0x05, 0xb5, 0x55, 0x3d,
+	0x17, 0x6f, 0xa3, 0xfa, 0xd4, 0x0b, 0x45, 0x62, 0xac, 0x41, 0x88, 0x97, 0xcb, 0x21, 0xee, 0x49,
+	0x03, 0x04, 0xd0, 0x88, 0xf2, 0xb2, 0x7f, 0xd6, 0xd0, 0xcd, 0x95, 0x70, 0x07, 0x61, 0x22, 0x68,
+	0x28, 0xfe, 0x53, 0x3a, 0x8c, 0x6a, 0xb2, 0x95, 0x5c, 0x39, 0x38, 0xaf, 0x7a, 0xd2, 0xff, 0xa1,
+	0xa7, 0xda, 0xff, 0xec, 0xa9, 0x7e, 0xb9, 0xa7, 0xc6, 0x0b, 0xf5, 0x74, 0x88, 0x8c, 0xd2, 0x2c,
+	0xb0, 0x24, 0x8e, 0xc2, 0x84, 0xdd, 0x63, 0xd4, 0x65, 0x1c, 0x77, 0x51, 0xed, 0x13, 0x1a, 0x30,
+	0xd5, 0x8d, 0x53, 0x5f, 0x64, 0x96, 0xb6, 0x4d, 0x00, 0xc2, 0x37, 0x51, 0xe3, 0x73, 0xea, 0xa7,
+	0x2c, 0x31, 0xaa, 0x3d, 0x7d, 0x65, 0xcc, 0x41, 0xfb, 0x97, 0x2a, 0xc2, 0x97, 0xc3, 0x62, 0x1b,
+	0x35, 0x86, 0x82, 0x8a, 0x34, 0xc9, 0x43, 0xa2, 0x45, 0x66, 0x35, 0x12, 0x40, 0x48, 0x6e, 0xc1,
+	0x0e, 0xaa, 0xed, 0x51, 0x41, 0x41, 0xae, 0xf6, 0x60, 0xb3, 0x5c, 0xfe, 0x2a, 0xa2, 0xf4, 0x70,
+	0xf0, 0x22, 0xb3, 0x3a, 0x2e, 0x15, 0xf4, 0xad, 0x28, 0xf0, 0x04, 0x0b, 0x62, 0x31, 0x23, 0xc0,
+	0xc5, 0xef, 0xa0, 0xb5, 0x7d, 0xce, 0x23, 0x7e, 0x38, 0x8b, 0x99, 0x92, 0xd8, 0x79, 0x75, 0x91,
+	0x59, 0x37, 0x58, 0x01, 0x96, 0x18, 0x2b, 0x4f, 0xfc, 0x3a, 0xaa, 0xc3, 0x05, 0xd4, 0x5f, 0x73,
+	0x6e, 0x2c, 0x32, 0xeb, 0x3a, 0x50, 0x4a, 0xee, 0xca, 0x03, 0xef, 0xa3, 0xa6, 0x12, 0x29, 0x31,
+	0xea, 0x3d, 0x7d, 0xab, 0x3d, 0xb8, 0x75, 0x75, 0xa1, 0x17, 0x15, 0x2d, 0x64, 0x2a, 0xb8, 0x78,
+	0x80, 0x5a, 0x5f, 0x50, 0x1e, 0x7a, 0xe1, 0x44, 0xbe, 0x97, 0x14, 0xf2, 0x95, 0x45, 0x66, 0xe1,
+	0x47, 0x39, 0x56, 0xca, 0xbb, 0xf4, 0xb3, 0xbf, 0xd1, 0x50, 0xe7, 0xa2, 0x12, 0xb8, 0x8f, 0x10,
+	0x61, 0x49, 0xea, 0x0b, 0x68, 0x58, 0x69, 0xdb, 0x59, 0x64, 0x16, 0xe2, 0x4b, 0x94, 0x94, 0x3c,
+	0xf0, 0x07, 0xa8, 0xa1, 0x6e, 0xf0, 0x7a, 0xed, 0x81, 0x51, 0x2e, 0x7e, 0x48, 0x83, 0xd8, 0x67,
+	0x43, 0xc1, 0x19, 0x0d, 0x9c, 0x8e, 0x1c, 0x36, 0xf9, 0x4a, 0x2a, 0x12, 0xc9, 0x79, 0xf6, 0x4f,
+	0x1a, 0xba, 0x56, 0x76, 0xc4, 0x31, 0x6a, 0xf8, 0x74, 0xc4, 0x7c, 0xf9, 0xb4, 0x3a, 0x8c, 0xee,
+	0x38, 0xe2, 0x82, 0x3d, 0x8e, 0x47, 0xfd, 0x8f, 0x24, 0x7e, 0x9f, 0x7a, 0xdc, 0xd9, 0x95, 0xd1,
+	0x7e, 0xcd, 0xac, 0xb7, 0x5f, 0x64, 0x9d, 0x29, 0xde, 0x5d, 0x97, 0xc6, 0x82, 0x71, 0x59, 0x42,
+	0xc0, 0x04, 0xf7, 0xc6, 0x24, 0xcf, 0x83, 0xdf, 0x45, 0xcd, 0x04, 0x2a, 0x48, 0xf2, 0x2e, 0xd6,
+	0x57, 0x29, 0x55, 0x69, 0xab, 0xea, 0x1f, 0xc2, 0x58, 0x92, 0x82, 0x60, 0x7f, 0x85, 0x3a, 0xbb,
+	0x74, 0x3c, 0x65, 0xee, 0x72, 0x34, 0xbb, 0x48, 0x3f, 0x61, 0xb3, 0x5c, 0xbb, 0xe6, 0x22, 0xb3,
+	0xe4, 0x95, 0xc8, 0x3f, 0x72, 0x7f, 0xb1, 0xc7, 0x82, 0x85, 0xa2, 0x48, 0x84, 0xcb, 0x72, 0xed,
+	0x83, 0xc9, 0xb9, 0x9e, 0xa7, 0x2a, 0x5c, 0x49, 0x71, 0xb0, 0xff, 0xd2, 0x50, 0x43, 0x39, 0x61,
+	0xab, 0xd8, 0xa2, 0x32, 0x8d, 0xee, 0xac, 0x2d, 0x32, 0x4b, 0x01, 0xc5, 0x42, 0xed, 0xaa, 0x85,
+	0x0a, 0xab, 0x42, 0x55, 0xc1, 0x42, 0x57, 0x6d, 0xd6, 0x1e, 0x6a, 0x09, 0x4e, 0xc7, 0xec, 0xc8,
+	0x73, 0xf3, 0xf9, 0x2c, 0x86, 0x09, 0xe0, 0x03, 0x17, 0xbf, 0x8f, 0x5a, 0x3c, 0x6f, 0x27, 0x5f,
+	0xb4, 0x1b, 0x97, 0x16, 0xed, 0xdd, 0x70, 0xe6, 0x5c, 0x5b, 0x64, 0xd6, 0xd2, 0x93, 0x2c, 0x4f,
+	0x78, 0x0f, 0x61, 0xe8, 0xeb, 0x48, 0xae, 0xa8, 0x44, 0xd0, 0x20, 0x3e, 0x0a, 0xd4, 0x1a, 0xd1,
+	0xd5, 0x58, 0x5e, 0xb6, 0x92, 0x75, 0xc0, 0x0e, 0x0b, 0xe8, 0xe3, 0xe4, 0xc3, 0x5a, 0x4b, 0x5f,
+	0xaf, 0xd9, 0x7f, 0x68, 0xa8, 0x99, 0x2f, 0x2c, 0x7c, 0x0b, 0xbd, 0x04, 0x62, 0xef, 0x79, 0x09,
+	0x1d, 0xf9, 0xcc, 0x85, 0xee, 0x5b, 0xe4, 0x22, 0x88, 0xdf, 0x40, 0xeb, 0xc3, 0x29, 0xe5, 0xae,
+	0x17, 0x4e, 0x96, 0x8e, 0x55, 0x70, 0xbc, 0x84, 0xe3, 0x1e, 0x6a, 0x1f, 0x46, 0x82, 0xfa, 0x60,
+	0x48, 0xe0, 0x17, 0x5e, 0x27, 0x65, 0x08, 0x0f, 0xd0, 0x46, 0xbe, 0x9f, 0x87, 0xb1, 0xef, 0x89,
+	0x65, 0xc4, 0x1a, 0x44, 0xbc, 0xd2, 0xf6, 0x3c, 0xe7, 0x20, 0x14, 0x8c, 0x3f, 0xa4, 0x7e, 0xbe,
+	0x5b, 0xaf, 0xb4, 0xd9, 0x6f, 0xa2, 0x3a, 0x2c, 0x55, 0x6c, 0xa3, 0x6b, 0x90, 0x5f, 0x7e, 0x0e,
+	0x3c, 0xa6, 0x16, 0x5c, 0x9d, 0x5c, 0xc0, 0xec, 0xef, 0x35, 0xd4, 0x55, 0x63, 0xb7, 0x0b, 0x0d,
+	0x51, 0xdf, 0x13, 0xb3, 0xfd, 0x44, 0x78, 0x01, 0x15, 0xff, 0x3a, 0x81, 0xb7, 0xd1, 0xc6, 0x31,
+	0x13, 0x92, 0x78, 0x94, 0x40, 0xa8, 0xa3, 0x71, 0x94, 0x86, 0xea, 0x63, 0x5c, 0x23, 0x38, 0xb7,
+	0x0d, 0xc1, 0xb4, 0x2b, 0x2d, 0x65, 0xc6, 0x78, 0x9a, 0x86, 0x27, 0x05, 0x43, 0xbf, 0xc0, 0xd8,
+	0x05, 0x13, 0x30, 0x9c, 0xfd, 0xd3, 0x33, 0xb3, 0xf2, 0xf4, 0xcc, 0xac, 0x3c, 0x3b, 0x33, 0xb5,
+	0xaf, 0xe7, 0xa6, 0xf6, 0xc3, 0xdc, 0xd4, 0x9e, 0xcc, 0x4d, 0xed, 0x74, 0x6e, 0x6a, 0xbf, 0xcf,
+	0x4d, 0xed, 0xcf, 0xb9, 0x59, 0x79, 0x36, 0x37, 0xb5, 0x6f, 0xcf, 0xcd, 0xca, 0xe9, 0xb9, 0x59,
+	0x79, 0x7a, 0x6e, 0x56, 0xbe, 0xbc, 0x0e, 0xef, 0x1f, 0x78, 0xae, 0xeb, 0xb3, 0x47, 0x94, 0xb3,
+	0x51, 0x03, 0x46, 0xed, 0xce, 0xdf, 0x03, 0x00, 0xfa, 0x3f, 0x19, 0xc7, 0x1a, 0x09, 0x00, 0x00,
 }
 
 func (this *PrometheusRangeQueryRequest) Equal(that interface{}) bool {
@@ -930,6 +1014,14 @@ func (this *PrometheusResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *PrometheusData) Equal(that interface{}) bool {
@@ -1064,6 +1156,9 @@ func (this *Extent) Equal(that interface{}) bool {
 	if !this.Response.Equal(that1.Response) {
 		return false
 	}
+	if this.QueryTimestampMs != that1.QueryTimestampMs {
+		return false
+	}
 	return true
 }
 func (this *Options) Equal(that interface{}) bool {
@@ -1126,6 +1221,36 @@ func (this *Hints) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *CachedCardinalityEstimate) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CachedCardinalityEstimate)
+	if !ok {
+		that2, ok := that.(CachedCardinalityEstimate)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Key != that1.Key {
+		return false
+	}
+	if this.FetchedSeriesCount != that1.FetchedSeriesCount {
+		return false
+	}
+	if this.FetchedChunksCount != that1.FetchedChunksCount {
+		return false
+	}
+	return true
+}
 func (this *PrometheusRangeQueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -1178,7 +1303,7 @@ func (this *PrometheusResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&querymiddleware.PrometheusResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	if this.Data != nil {
@@ -1189,6 +1314,7 @@ func (this *PrometheusResponse) GoString() string {
 	if this.Headers != nil {
 		s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
 	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1247,7 +1373,7 @@ func (this *Extent) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&querymiddleware.Extent{")
 	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
 	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
@@ -1255,6 +1381,7 @@ func (this *Extent) GoString() string {
 	if this.Response != nil {
 		s = append(s, "Response: "+fmt.Sprintf("%#v", this.Response)+",\n")
 	}
+	s = append(s, "QueryTimestampMs: "+fmt.Sprintf("%#v", this.QueryTimestampMs)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1282,6 +1409,18 @@ func (this *Hints) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *CachedCardinalityEstimate) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&querymiddleware.CachedCardinalityEstimate{")
+	s = append(s, "Key: "+fmt.Sprintf("%#v", this.Key)+",\n")
+	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
+	s = append(s, "FetchedChunksCount: "+fmt.Sprintf("%#v", this.FetchedChunksCount)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringModel(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1505,6 +1644,15 @@ func (m *PrometheusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintModel(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
 	if len(m.Headers) > 0 {
 		for iNdEx := len(m.Headers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1714,6 +1862,11 @@ func (m *Extent) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueryTimestampMs != 0 {
+		i = encodeVarintModel(dAtA, i, uint64(m.QueryTimestampMs))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.Response != nil {
 		{
 			size, err := m.Response.MarshalToSizedBuffer(dAtA[:i])
@@ -1837,6 +1990,46 @@ func (m *Hints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *CachedCardinalityEstimate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CachedCardinalityEstimate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CachedCardinalityEstimate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.FetchedChunksCount != 0 {
+		i = encodeVarintModel(dAtA, i, uint64(m.FetchedChunksCount))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.FetchedSeriesCount != 0 {
+		i = encodeVarintModel(dAtA, i, uint64(m.FetchedSeriesCount))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintModel(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintModel(dAtA []byte, offset int, v uint64) int {
 	offset -= sovModel(v)
 	base := offset
@@ -1961,6 +2154,12 @@ func (m *PrometheusResponse) Size() (n int) {
 			n += 1 + l + sovModel(uint64(l))
 		}
 	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovModel(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -2043,6 +2242,9 @@ func (m *Extent) Size() (n int) {
 		l = m.Response.Size()
 		n += 1 + l + sovModel(uint64(l))
 	}
+	if m.QueryTimestampMs != 0 {
+		n += 1 + sovModel(uint64(m.QueryTimestampMs))
+	}
 	return n
 }
 
@@ -2082,6 +2284,25 @@ func (m *Hints) Size() (n int) {
 	return n
 }
 
+func (m *CachedCardinalityEstimate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovModel(uint64(l))
+	}
+	if m.FetchedSeriesCount != 0 {
+		n += 1 + sovModel(uint64(m.FetchedSeriesCount))
+	}
+	if m.FetchedChunksCount != 0 {
+		n += 1 + sovModel(uint64(m.FetchedChunksCount))
+	}
+	return n
+}
+
 func sovModel(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -2147,6 +2368,7 @@ func (this *PrometheusResponse) String() string {
 		`ErrorType:` + fmt.Sprintf("%v", this.ErrorType) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
 		`Headers:` + repeatedStringForHeaders + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2208,6 +2430,7 @@ func (this *Extent) String() string {
 		`End:` + fmt.Sprintf("%v", this.End) + `,`,
 		`TraceId:` + fmt.Sprintf("%v", this.TraceId) + `,`,
 		`Response:` + strings.Replace(fmt.Sprintf("%v", this.Response), "Any", "types.Any", 1) + `,`,
+		`QueryTimestampMs:` + fmt.Sprintf("%v", this.QueryTimestampMs) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2236,6 +2459,18 @@ func (this *Hints) String() string {
 	}, "")
 	return s
 }
+func (this *CachedCardinalityEstimate) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&CachedCardinalityEstimate{`,
+		`Key:` + fmt.Sprintf("%v", this.Key) + `,`,
+		`FetchedSeriesCount:` + fmt.Sprintf("%v", this.FetchedSeriesCount) + `,`,
+		`FetchedChunksCount:` + fmt.Sprintf("%v", this.FetchedChunksCount) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringModel(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -3075,6 +3310,38 @@ func (m *PrometheusResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthModel
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthModel
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -3593,6 +3860,25 @@ func (m *Extent) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryTimestampMs", wireType)
+			}
+			m.QueryTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -3840,6 +4126,129 @@ func (m *Hints) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *CachedCardinalityEstimate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowModel
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CachedCardinalityEstimate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CachedCardinalityEstimate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthModel
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthModel
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedSeriesCount", wireType)
+			}
+			m.FetchedSeriesCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedSeriesCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedChunksCount", wireType)
+			}
+			m.FetchedChunksCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedChunksCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipModel(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthModel
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthModel
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipModel(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
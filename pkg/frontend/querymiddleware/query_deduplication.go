@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+var errQueryExecutionAborted = errors.New("the shared execution of the query has been aborted")
+
+// inflightQuery is a query being executed, whose result is shared with the identical queries received meanwhile.
+type inflightQuery struct {
+	done chan struct{}
+
+	// resp and err can be read only once done is closed.
+	resp Response
+	err  error
+}
+
+// queryDeduplication holds the queries being executed, shared by all the deduplication middleware instances.
+type queryDeduplication struct {
+	mtx      sync.Mutex
+	inflight map[string]*inflightQuery
+
+	executedQueries     prometheus.Counter
+	deduplicatedQueries prometheus.Counter
+}
+
+type queryDeduplicationMiddleware struct {
+	next   Handler
+	logger log.Logger
+	dedup  *queryDeduplication
+}
+
+// newQueryDeduplicationMiddleware returns a middleware collapsing identical queries (same tenant, query,
+// start, end, step and options) received concurrently into a single downstream execution, whose result
+// is shared with all the requests waiting for it. The shared response must not be modified.
+func newQueryDeduplicationMiddleware(logger log.Logger, registerer prometheus.Registerer) Middleware {
+	dedup := &queryDeduplication{
+		inflight: map[string]*inflightQuery{},
+		executedQueries: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_deduplication_executed_queries_total",
+			Help: "Total number of queries executed downstream by the query deduplication middleware.",
+		}),
+		deduplicatedQueries: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_deduplication_deduplicated_queries_total",
+			Help: "Total number of queries which haven't been executed downstream because they received the result of an identical concurrent query.",
+		}),
+	}
+
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &queryDeduplicationMiddleware{
+			next:   next,
+			logger: logger,
+			dedup:  dedup,
+		}
+	})
+}
+
+func (m *queryDeduplicationMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return m.next.Do(ctx, req)
+	}
+
+	// The queries with a timeout or a soft timeout set by the client aren't deduplicated, because their
+	// execution depends on it: a query with a soft timeout can get a partial result, which must not be
+	// shared with the identical queries without it.
+	if _, ok := util.QueryTimeoutFromContext(ctx); ok {
+		return m.next.Do(ctx, req)
+	}
+	if _, ok := util.QuerySoftDeadlineFromContext(ctx); ok {
+		return m.next.Do(ctx, req)
+	}
+
+	key := queryDeduplicationKey(tenant.JoinTenantIDs(tenantIDs), req)
+
+	for {
+		m.dedup.mtx.Lock()
+		query, found := m.dedup.inflight[key]
+		if !found {
+			query = &inflightQuery{done: make(chan struct{})}
+			m.dedup.inflight[key] = query
+		}
+		m.dedup.mtx.Unlock()
+
+		if !found {
+			return m.execute(ctx, key, query, req)
+		}
+
+		select {
+		case <-query.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The execution may have been canceled because the request which triggered it has been canceled.
+		// In this case, as long as this request hasn't been canceled too, it's executed again.
+		if isContextCanceledOrDeadlineExceeded(query.err) && ctx.Err() == nil {
+			level.Debug(util_log.WithContext(ctx, m.logger)).Log("msg", "the shared execution of an identical query has been canceled, executing the query again", "query", req.GetQuery())
+			continue
+		}
+
+		m.dedup.deduplicatedQueries.Inc()
+		return query.resp, query.err
+	}
+}
+
+func (m *queryDeduplicationMiddleware) execute(ctx context.Context, key string, query *inflightQuery, req Request) (Response, error) {
+	defer func() {
+		m.dedup.mtx.Lock()
+		delete(m.dedup.inflight, key)
+		m.dedup.mtx.Unlock()
+
+		close(query.done)
+	}()
+
+	// The error is overwritten once the execution completes, so that the waiting requests don't
+	// get an empty response if the execution doesn't complete.
+	query.err = errQueryExecutionAborted
+
+	m.dedup.executedQueries.Inc()
+	query.resp, query.err = m.next.Do(ctx, req)
+	return query.resp, query.err
+}
+
+// queryDeduplicationKey returns the key identifying the identical queries of the input tenant.
+func queryDeduplicationKey(tenantID string, req Request) string {
+	// The options are part of the key because they affect how the query is executed.
+	opts := req.GetOptions()
+	return fmt.Sprintf("%s:%T:%d:%d:%d:%s:%s", tenantID, req, req.GetStart(), req.GetEnd(), req.GetStep(), opts.String(), req.GetQuery())
+}
+
+func isContextCanceledOrDeadlineExceeded(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestQueryDeduplicationMiddleware(t *testing.T) {
+	const numRequests = 5
+
+	rangeReq := &PrometheusRangeQueryRequest{Path: "/api/v1/query_range", Start: 0, End: 3600000, Step: 60000, Query: "sum(up)"}
+
+	t.Run("should execute identical concurrent queries only once", func(t *testing.T) {
+		var (
+			calls   atomic.Int32
+			release = make(chan struct{})
+			reg     = prometheus.NewPedanticRegistry()
+		)
+
+		handler := newQueryDeduplicationMiddleware(log.NewNopLogger(), reg).Wrap(HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			calls.Inc()
+			<-release
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		}))
+
+		ctx := user.InjectOrgID(context.Background(), "user-1")
+		responses := make([]Response, numRequests)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < numRequests; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				resp, err := handler.Do(ctx, rangeReq)
+				require.NoError(t, err)
+				responses[i] = resp
+			}()
+		}
+
+		// Wait until the query is executed, and give the other requests the time to wait for it.
+		require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		for _, resp := range responses {
+			assert.Same(t, responses[0], resp)
+		}
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_frontend_query_deduplication_executed_queries_total Total number of queries executed downstream by the query deduplication middleware.
+			# TYPE cortex_frontend_query_deduplication_executed_queries_total counter
+			cortex_frontend_query_deduplication_executed_queries_total 1
+
+			# HELP cortex_frontend_query_deduplication_deduplicated_queries_total Total number of queries which haven't been executed downstream because they received the result of an identical concurrent query.
+			# TYPE cortex_frontend_query_deduplication_deduplicated_queries_total counter
+			cortex_frontend_query_deduplication_deduplicated_queries_total 4
+		`)))
+	})
+
+	t.Run("should not deduplicate queries of different tenants or with different parameters", func(t *testing.T) {
+		var (
+			calls   atomic.Int32
+			release = make(chan struct{})
+		)
+
+		handler := newQueryDeduplicationMiddleware(log.NewNopLogger(), nil).Wrap(HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			calls.Inc()
+			<-release
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		}))
+
+		requests := []struct {
+			tenantID string
+			req      Request
+		}{
+			{tenantID: "user-1", req: rangeReq},
+			{tenantID: "user-2", req: rangeReq},
+			{tenantID: "user-1", req: rangeReq.WithQuery("sum(rate(up[1m]))")},
+			{tenantID: "user-1", req: rangeReq.WithStartEnd(60000, 3600000)},
+			{tenantID: "user-1", req: &PrometheusRangeQueryRequest{Path: rangeReq.Path, Start: rangeReq.Start, End: rangeReq.End, Step: 30000, Query: rangeReq.Query}},
+			{tenantID: "user-1", req: &PrometheusRangeQueryRequest{Path: rangeReq.Path, Start: rangeReq.Start, End: rangeReq.End, Step: rangeReq.Step, Query: rangeReq.Query, Options: Options{CacheDisabled: true}}},
+			{tenantID: "user-1", req: &PrometheusInstantQueryRequest{Path: "/api/v1/query", Time: 3600000, Query: rangeReq.Query}},
+		}
+
+		wg := sync.WaitGroup{}
+		for _, r := range requests {
+			r := r
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := handler.Do(user.InjectOrgID(context.Background(), r.tenantID), r.req)
+				require.NoError(t, err)
+			}()
+		}
+
+		require.Eventually(t, func() bool { return calls.Load() == int32(len(requests)) }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("should not deduplicate queries with a timeout or a soft timeout set by the client", func(t *testing.T) {
+		var (
+			calls   atomic.Int32
+			release = make(chan struct{})
+		)
+
+		handler := newQueryDeduplicationMiddleware(log.NewNopLogger(), nil).Wrap(HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			calls.Inc()
+			<-release
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		}))
+
+		timeoutCtx, cancel, err := util.ContextWithQueryTimeoutFromHTTPHeaders(user.InjectOrgID(context.Background(), "user-1"), http.Header{util.QueryTimeoutHeader: []string{"1m"}})
+		require.NoError(t, err)
+		defer cancel()
+
+		contexts := []context.Context{
+			user.InjectOrgID(context.Background(), "user-1"),
+			util.ContextWithQuerySoftDeadline(user.InjectOrgID(context.Background(), "user-1"), time.Now().Add(time.Minute)),
+			timeoutCtx,
+		}
+
+		wg := sync.WaitGroup{}
+		for _, ctx := range contexts {
+			ctx := ctx
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := handler.Do(ctx, rangeReq)
+				require.NoError(t, err)
+			}()
+		}
+
+		require.Eventually(t, func() bool { return calls.Load() == int32(len(contexts)) }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("should execute the query again if the shared execution has been canceled", func(t *testing.T) {
+		var (
+			calls         atomic.Int32
+			firstStarted  = make(chan struct{})
+			firstCanceled = make(chan struct{})
+		)
+
+		handler := newQueryDeduplicationMiddleware(log.NewNopLogger(), nil).Wrap(HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			if calls.Inc() == 1 {
+				close(firstStarted)
+				<-ctx.Done()
+				close(firstCanceled)
+				return nil, ctx.Err()
+			}
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		}))
+
+		firstCtx, cancelFirst := context.WithCancel(user.InjectOrgID(context.Background(), "user-1"))
+		defer cancelFirst()
+
+		go func() {
+			_, err := handler.Do(firstCtx, rangeReq)
+			assert.ErrorIs(t, err, context.Canceled)
+		}()
+		<-firstStarted
+
+		secondDone := make(chan struct{})
+		go func() {
+			defer close(secondDone)
+
+			resp, err := handler.Do(user.InjectOrgID(context.Background(), "user-1"), rangeReq)
+			require.NoError(t, err)
+			assert.Equal(t, &PrometheusResponse{Status: statusSuccess}, resp)
+		}()
+
+		// Give the second request the time to wait for the first execution.
+		time.Sleep(100 * time.Millisecond)
+		cancelFirst()
+		<-firstCanceled
+		<-secondDone
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("should stop waiting for the shared execution once the request is canceled", func(t *testing.T) {
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+
+		handler := newQueryDeduplicationMiddleware(log.NewNopLogger(), nil).Wrap(HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			close(started)
+			<-release
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		}))
+
+		firstDone := make(chan struct{})
+		go func() {
+			defer close(firstDone)
+
+			_, err := handler.Do(user.InjectOrgID(context.Background(), "user-1"), rangeReq)
+			require.NoError(t, err)
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), "user-1"), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := handler.Do(ctx, rangeReq)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		close(release)
+		<-firstDone
+	})
+}
@@ -395,6 +395,18 @@ func TestQueryShardingCorrectness(t *testing.T) {
 			query:                  `max by(unique) (max_over_time(metric_counter[5m])) > scalar(min(metric_counter))`,
 			expectedShardedQueries: 2,
 		},
+		`binary operation with a non aggregated hand and a constant cardinality hand`: {
+			query:                  `rate(metric_counter[1m]) / on() group_left sum(rate(metric_counter[1m]))`,
+			expectedShardedQueries: 2,
+		},
+		`binary operation with a non aggregated hand and a scalar hand`: {
+			query:                  `max_over_time(metric_counter[5m]) > scalar(avg(metric_counter))`,
+			expectedShardedQueries: 3, // avg() is parallelized as sum()/count().
+		},
+		`set operation with a non aggregated hand and a constant cardinality hand`: {
+			query:                  `sum(rate(metric_counter{group_1="0"}[1m])) or rate(metric_counter{group_1="1"}[1m])`,
+			expectedShardedQueries: 2,
+		},
 		//
 		// The following queries are not expected to be shardable.
 		//
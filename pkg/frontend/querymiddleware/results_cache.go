@@ -29,6 +29,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/cachedebug"
 )
 
 const (
@@ -40,6 +41,9 @@ const (
 
 	// noStoreValue is the value that cacheControlHeader has if the response indicates that the results should not be cached.
 	noStoreValue = "no-store"
+
+	// resultsCacheKeysHeader is the name of the response header listing the results cache keys fetched by a traced query.
+	resultsCacheKeysHeader = "X-Mimir-Results-Cache-Keys"
 )
 
 var (
@@ -50,6 +54,9 @@ var (
 type ResultsCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
 	Compression         cache.CompressionConfig `yaml:",inline"`
+
+	DebugKeysEnabled               bool `yaml:"debug_keys_enabled" category:"experimental"`
+	DebugKeysResponseHeaderEnabled bool `yaml:"debug_keys_response_header_enabled" category:"experimental"`
 }
 
 // RegisterFlags registers flags.
@@ -57,6 +64,8 @@ func (cfg *ResultsCacheConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.Backend, "query-frontend.results-cache.backend", "", fmt.Sprintf("Backend for query-frontend results cache, if not empty. Supported values: %s.", supportedResultsCacheBackends))
 	cfg.Memcached.RegisterFlagsWithPrefix(f, "query-frontend.results-cache.memcached.")
 	cfg.Compression.RegisterFlagsWithPrefix(f, "query-frontend.results-cache.")
+	f.BoolVar(&cfg.DebugKeysEnabled, "query-frontend.results-cache.debug-keys-enabled", false, "Record the results cache keys fetched by sampled traced queries, along with whether each key was a hit or a miss, into the query span.")
+	f.BoolVar(&cfg.DebugKeysResponseHeaderEnabled, "query-frontend.results-cache.debug-keys-response-header-enabled", false, fmt.Sprintf("Return the results cache keys fetched by sampled traced queries in the %s response header. Requires -query-frontend.results-cache.debug-keys-enabled.", resultsCacheKeysHeader))
 }
 
 func (cfg *ResultsCacheConfig) Validate() error {
@@ -94,6 +103,10 @@ func newResultsCache(cfg ResultsCacheConfig, logger log.Logger, reg prometheus.R
 		return nil, errUnsupportedResultsCacheBackend(cfg.Backend)
 	}
 
+	if cfg.DebugKeysEnabled {
+		client = cachedebug.NewKeysRecordingCache(client)
+	}
+
 	return cache.NewVersioned(
 		cache.NewSpanlessTracingCache(client, logger, tenant.NewMultiResolver()),
 		resultsCacheVersion,
@@ -317,6 +330,7 @@ func mergeCacheExtentsForRequest(ctx context.Context, r Request, merger Merger,
 		}
 		accumulator.TraceId = jaegerTraceID(ctx)
 		accumulator.End = extents[i].End
+		accumulator.QueryTimestampMs = oldestQueryTimestamp(accumulator.QueryTimestampMs, extents[i].QueryTimestampMs)
 		currentRes, err := extents[i].toResponse()
 		if err != nil {
 			return nil, err
@@ -342,10 +356,11 @@ func mergeCacheExtentsWithAccumulator(extents []Extent, acc *accumulator) ([]Ext
 		return nil, err
 	}
 	return append(extents, Extent{
-		Start:    acc.Extent.Start,
-		End:      acc.Extent.End,
-		Response: any,
-		TraceId:  acc.Extent.TraceId,
+		Start:            acc.Extent.Start,
+		End:              acc.Extent.End,
+		Response:         any,
+		TraceId:          acc.Extent.TraceId,
+		QueryTimestampMs: acc.Extent.QueryTimestampMs,
 	}), nil
 }
 
@@ -360,19 +375,28 @@ func newAccumulator(base Extent) (*accumulator, error) {
 	}, nil
 }
 
-func toExtent(ctx context.Context, req Request, res Response) (Extent, error) {
+func toExtent(ctx context.Context, req Request, res Response, queryTime time.Time) (Extent, error) {
 	any, err := types.MarshalAny(res)
 	if err != nil {
 		return Extent{}, err
 	}
 	return Extent{
-		Start:    req.GetStart(),
-		End:      req.GetEnd(),
-		Response: any,
-		TraceId:  jaegerTraceID(ctx),
+		Start:            req.GetStart(),
+		End:              req.GetEnd(),
+		Response:         any,
+		TraceId:          jaegerTraceID(ctx),
+		QueryTimestampMs: queryTime.UnixMilli(),
 	}, nil
 }
 
+// oldestQueryTimestamp returns the oldest of the input query timestamps, ignoring the unknown (zero) ones.
+func oldestQueryTimestamp(a, b int64) int64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
 // partitionCacheExtents calculates the required requests to satisfy req given the cached data.
 // extents must be in order by start time.
 func partitionCacheExtents(req Request, extents []Extent, minCacheExtent int64, extractor Extractor) ([]Request, []Response, error) {
@@ -22,6 +22,7 @@ import (
 	"github.com/prometheus/prometheus/promql"
 
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/cachedebug"
 )
 
 const (
@@ -40,6 +41,16 @@ type Config struct {
 	ShardedQueries         bool `yaml:"parallelize_shardable_queries"`
 	CacheUnalignedRequests bool `yaml:"cache_unaligned_requests" category:"advanced"`
 
+	DeduplicateConcurrentQueries  bool `yaml:"deduplicate_concurrent_queries" category:"experimental"`
+	CardinalityEstimationWarnOnly bool `yaml:"cardinality_estimation_warn_only" category:"experimental"`
+	SplitQueriesByBlockRanges     bool `yaml:"split_queries_by_block_ranges" category:"experimental"`
+
+	ResultsCacheMaxConcurrentRevalidations int `yaml:"results_cache_max_concurrent_revalidations" category:"experimental"`
+
+	// BlockRanges allows to inject the compaction block ranges, used to choose the split interval
+	// when SplitQueriesByBlockRanges is enabled.
+	BlockRanges []time.Duration `yaml:"-"`
+
 	// CacheSplitter allows to inject a CacheSplitter to use for generating cache keys.
 	// If nil, the querymiddleware package uses a ConstSplitter with SplitQueriesByInterval.
 	CacheSplitter CacheSplitter `yaml:"-"`
@@ -55,11 +66,18 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.CacheResults, "query-frontend.cache-results", false, "Cache query results.")
 	f.BoolVar(&cfg.ShardedQueries, "query-frontend.parallelize-shardable-queries", false, "True to enable query sharding.")
 	f.BoolVar(&cfg.CacheUnalignedRequests, "query-frontend.cache-unaligned-requests", false, "Cache requests that are not step-aligned.")
+	f.BoolVar(&cfg.DeduplicateConcurrentQueries, "query-frontend.deduplicate-concurrent-queries", false, "Collapse identical queries (same tenant, query, start, end and step) received concurrently, like the ones issued by dashboards with repeated panels, into a single execution whose result is shared with all the waiting requests.")
+	f.BoolVar(&cfg.CardinalityEstimationWarnOnly, "query-frontend.cardinality-estimation-warn-only", false, "If true, the queries estimated to exceed the per-tenant -query-frontend.max-estimated-fetched-series-per-query or -query-frontend.max-estimated-fetched-chunks-per-query limits are logged and tracked by the cortex_frontend_query_estimated_cardinality_limit_exceeded_total metric, but not rejected.")
+	f.BoolVar(&cfg.SplitQueriesByBlockRanges, "query-frontend.split-queries-by-block-ranges", false, "True to split range queries by the compaction block ranges (-compactor.block-ranges) not greater than -query-frontend.split-queries-by-interval, instead of a fixed interval: the old data is split by the largest block range whose time window has already ended, and the recent data by the smallest one.")
+	f.IntVar(&cfg.ResultsCacheMaxConcurrentRevalidations, "query-frontend.results-cache-max-concurrent-revalidations", 10, "Max number of stale cached query results refreshed in the background at the same time, across all tenants, when -query-frontend.results-cache-max-staleness is enabled. The stale cached results served while the limit is reached aren't refreshed. Each refresh is canceled after -querier.timeout. 0 to disable the limit.")
 	cfg.ResultsCacheConfig.RegisterFlags(f)
 }
 
 // Validate validates the config.
 func (cfg *Config) Validate() error {
+	if cfg.SplitQueriesByBlockRanges && cfg.SplitQueriesByInterval <= 0 {
+		return errors.New("-query-frontend.split-queries-by-block-ranges may only be enabled in conjunction with -query-frontend.split-queries-by-interval. Please set the latter")
+	}
 	if cfg.CacheResults {
 		if cfg.SplitQueriesByInterval <= 0 {
 			return errors.New("-query-frontend.cache-results may only be enabled in conjunction with -query-frontend.split-queries-by-interval. Please set the latter")
@@ -166,44 +184,72 @@ func newQueryTripperware(
 	// Metric used to keep track of each middleware execution duration.
 	metrics := newInstrumentMiddlewareMetrics(registerer)
 
+	// Init the cache client, shared by the range and instant queries results cache and the cardinality estimates.
+	var c cache.Cache
+	if cfg.CacheResults {
+		var err error
+
+		c, err = newResultsCache(cfg.ResultsCacheConfig, log, registerer)
+		if err != nil {
+			return nil, err
+		}
+		c = cache.NewCompression(cfg.ResultsCacheConfig.Compression, c, log)
+	}
+
 	queryRangeMiddleware := []Middleware{
 		// Track query range statistics. Added first before any subsequent middleware modifies the request.
 		newQueryStatsMiddleware(registerer),
 		newLimitsMiddleware(limits, log),
 	}
+
+	// The cardinality estimation middleware is shared between range and instant queries, and placed before the
+	// middlewares splitting, sharding or caching the query, so that it tracks what's fetched by the whole query.
+	var cardinalityEstimationMiddleware Middleware
+	if cfg.CacheResults {
+		cardinalityEstimationMiddleware = newCardinalityEstimationMiddleware(cfg.CardinalityEstimationWarnOnly, limits, c, log, registerer)
+		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("cardinality_estimation", metrics, log), cardinalityEstimationMiddleware)
+	}
+
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("step_align", metrics, log), newStepAlignMiddleware())
 	}
 
+	// The deduplication middleware is shared between range and instant queries, and placed before the
+	// middlewares executing the query, so that identical queries are executed only once.
+	var queryDeduplicationMiddleware Middleware
+	if cfg.DeduplicateConcurrentQueries {
+		queryDeduplicationMiddleware = newQueryDeduplicationMiddleware(log, registerer)
+		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("query_deduplication", metrics, log), queryDeduplicationMiddleware)
+	}
+
 	// Inject the middleware to split requests by interval + results cache (if at least one of the two is enabled).
 	if cfg.SplitQueriesByInterval > 0 || cfg.CacheResults {
-		var c cache.Cache
-
-		// Init the cache client.
-		if cfg.CacheResults {
-			var err error
-
-			c, err = newResultsCache(cfg.ResultsCacheConfig, log, registerer)
-			if err != nil {
-				return nil, err
-			}
-			c = cache.NewCompression(cfg.ResultsCacheConfig.Compression, c, log)
-		}
-
 		shouldCache := func(r Request) bool {
 			return !r.GetOptions().CacheDisabled
 		}
 
+		var splitBlockRanges []time.Duration
+		if cfg.SplitQueriesByBlockRanges {
+			splitBlockRanges = cfg.BlockRanges
+		}
+
 		splitter := cfg.CacheSplitter
 		if splitter == nil {
-			splitter = ConstSplitter(cfg.SplitQueriesByInterval)
+			if len(splitBlockRanges) > 0 {
+				splitter = newBlockRangesSplitter(cfg.SplitQueriesByInterval, splitBlockRanges)
+			} else {
+				splitter = ConstSplitter(cfg.SplitQueriesByInterval)
+			}
 		}
 
 		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("split_by_interval_and_results_cache", metrics, log), newSplitAndCacheMiddleware(
 			cfg.SplitQueriesByInterval > 0,
 			cfg.CacheResults,
 			cfg.SplitQueriesByInterval,
+			splitBlockRanges,
 			cfg.CacheUnalignedRequests,
+			cfg.ResultsCacheMaxConcurrentRevalidations,
+			engineOpts.Timeout,
 			limits,
 			codec,
 			c,
@@ -217,6 +263,20 @@ func newQueryTripperware(
 
 	queryInstantMiddleware := []Middleware{newLimitsMiddleware(limits, log)}
 
+	if cardinalityEstimationMiddleware != nil {
+		queryInstantMiddleware = append(queryInstantMiddleware, newInstrumentMiddleware("cardinality_estimation", metrics, log), cardinalityEstimationMiddleware)
+	}
+
+	// The instant queries results cache is placed before the deduplication middleware, so that the
+	// identical instant queries whose time has been aligned are deduplicated as well.
+	if cfg.CacheResults {
+		queryInstantMiddleware = append(queryInstantMiddleware, newInstrumentMiddleware("instant_query_results_cache", metrics, log), newInstantQueryCacheMiddleware(limits, c, cacheExtractor, log, registerer))
+	}
+
+	if queryDeduplicationMiddleware != nil {
+		queryInstantMiddleware = append(queryInstantMiddleware, newInstrumentMiddleware("query_deduplication", metrics, log), queryDeduplicationMiddleware)
+	}
+
 	queryInstantMiddleware = append(
 		queryInstantMiddleware,
 		newSplitInstantQueryByIntervalMiddleware(limits, log, engine, registerer),
@@ -252,6 +312,10 @@ func newQueryTripperware(
 		instant := defaultInstantQueryParamsRoundTripper(
 			newLimitedParallelismRoundTripper(next, codec, limits, queryInstantMiddleware...),
 		)
+		if cfg.CacheResults && cfg.ResultsCacheConfig.DebugKeysEnabled && cfg.ResultsCacheConfig.DebugKeysResponseHeaderEnabled {
+			queryrange = newResultsCacheKeysHeaderRoundTripper(queryrange)
+		}
+
 		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 			switch {
 			case isRangeQuery(r.URL.Path):
@@ -265,6 +329,27 @@ func newQueryTripperware(
 	}, nil
 }
 
+// newResultsCacheKeysHeaderRoundTripper returns a http.RoundTripper adding to the responses of sampled traced
+// queries a header listing the results cache keys fetched while serving the query.
+func newResultsCacheKeysHeaderRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !cachedebug.IsSampled(r.Context()) {
+			return next.RoundTrip(r)
+		}
+
+		collector := &cachedebug.Collector{}
+		resp, err := next.RoundTrip(r.WithContext(cachedebug.ContextWithCollector(r.Context(), collector)))
+		if err != nil {
+			return resp, err
+		}
+
+		for _, key := range collector.Keys() {
+			resp.Header.Add(resultsCacheKeysHeader, key.String())
+		}
+		return resp, nil
+	})
+}
+
 func newActiveUsersTripperware(registerer prometheus.Registerer) Tripperware {
 	// Per tenant query metrics.
 	queriesPerTenant := promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
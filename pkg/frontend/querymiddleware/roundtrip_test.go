@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
@@ -26,10 +28,12 @@ import (
 	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
 	"github.com/weaveworks/common/middleware"
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/cachedebug"
 )
 
 func TestRangeTripperware(t *testing.T) {
@@ -336,3 +340,36 @@ func (s singleHostRoundTripper) RoundTrip(r *http.Request) (*http.Response, erro
 	r.URL.Host = s.host
 	return s.next.RoundTrip(r)
 }
+
+func TestResultsCacheKeysHeaderRoundTripper(t *testing.T) {
+	backend := cache.NewMockCache()
+	backend.Store(context.Background(), map[string][]byte{"key-1": []byte("value-1")}, time.Minute)
+	c := cachedebug.NewKeysRecordingCache(backend)
+
+	rt := newResultsCacheKeysHeaderRoundTripper(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		c.Fetch(r.Context(), []string{"key-1", "key-2"})
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+
+	for _, sampled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("sampled=%t", sampled), func(t *testing.T) {
+			tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(sampled), jaeger.NewNullReporter())
+			defer func() { _ = closer.Close() }()
+
+			span := tracer.StartSpan("query")
+			defer span.Finish()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/query_range", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), span))
+
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+
+			if sampled {
+				assert.Equal(t, []string{backend.Name() + ":key-1:hit", backend.Name() + ":key-2:miss"}, resp.Header.Values(resultsCacheKeysHeader))
+			} else {
+				assert.Empty(t, resp.Header.Values(resultsCacheKeysHeader))
+			}
+		})
+	}
+}
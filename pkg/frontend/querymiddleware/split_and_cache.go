@@ -22,6 +22,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/user"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/dskit/cache"
@@ -29,15 +30,17 @@ import (
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
-	// Cache entries for 7 days. We're not disabling TTL because the backend client currently doesn't support it.
-	resultsCacheTTL = 7 * 24 * time.Hour
-	// resultsCacheLowerTTL is the smaller TTL used in specific cases. For example OOO queries.
-	resultsCacheLowerTTL                  = 10 * time.Minute
+	// defaultResultsCacheTTL and defaultResultsCacheTTLForRecentResults are the TTLs used when the configured
+	// ones are not positive, given a TTL of 0 would make the cached results never expire.
+	defaultResultsCacheTTL                 = 7 * 24 * time.Hour
+	defaultResultsCacheTTLForRecentResults = 10 * time.Minute
+
 	notCachableReasonUnalignedTimeRange   = "unaligned-time-range"
 	notCachableReasonTooNew               = "too-new"
 	notCachableReasonModifiersNotCachable = "has-modifiers"
@@ -49,10 +52,16 @@ var (
 	defaultMinCacheExtent = (5 * time.Minute).Milliseconds()
 )
 
+// partialResponseWarning is the warning added to the query results which are partial, because the soft
+// timeout set by the client expired before all the split queries completed.
+const partialResponseWarning = "the query results are partial: the query soft timeout expired before all the split queries completed"
+
 type splitAndCacheMiddlewareMetrics struct {
 	splitQueriesCount              prometheus.Counter
 	queryResultCacheAttemptedCount prometheus.Counter
 	queryResultCacheSkippedCount   *prometheus.CounterVec
+	queryResultCacheStaleCount     prometheus.Counter
+	partialResponsesCount          prometheus.Counter
 }
 
 func newSplitAndCacheMiddlewareMetrics(reg prometheus.Registerer) *splitAndCacheMiddlewareMetrics {
@@ -69,6 +78,14 @@ func newSplitAndCacheMiddlewareMetrics(reg prometheus.Registerer) *splitAndCache
 			Name: "cortex_frontend_query_result_cache_skipped_total",
 			Help: "Total number of times a query was not cacheable because of a reason. This metric is tracked for each partial query when time-splitting is enabled.",
 		}, []string{"reason"}),
+		queryResultCacheStaleCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_result_cache_stale_total",
+			Help: "Total number of queries whose results were served from stale cached results, while being refreshed in the background. This metric is tracked for each partial query when time-splitting is enabled.",
+		}),
+		partialResponsesCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_partial_responses_total",
+			Help: "Total number of queries whose partial results have been returned, because the soft timeout set by the client expired before all the split queries completed.",
+		}),
 	}
 
 	// Initialize known label values.
@@ -90,8 +107,10 @@ type splitAndCacheMiddleware struct {
 	metrics *splitAndCacheMiddlewareMetrics
 
 	// Split by interval.
-	splitEnabled  bool
-	splitInterval time.Duration
+	splitEnabled bool
+
+	// splitIntervalAt returns the interval to split the query at the given timestamp by.
+	splitIntervalAt func(t int64) time.Duration
 
 	// Results caching.
 	cacheEnabled           bool
@@ -100,6 +119,15 @@ type splitAndCacheMiddleware struct {
 	splitter               CacheSplitter
 	extractor              Extractor
 	shouldCacheReq         shouldCacheFn
+
+	// Cache keys of the stale cached results being refreshed in the background.
+	revalidatingMtx sync.Mutex
+	revalidating    map[string]struct{}
+
+	// maxConcurrentRevalidations, if greater than 0, is the max number of stale cached results refreshed in the
+	// background at the same time, and revalidationTimeout, if greater than 0, is the timeout of each refresh.
+	maxConcurrentRevalidations int
+	revalidationTimeout        time.Duration
 }
 
 // newSplitAndCacheMiddleware makes a new splitAndCacheMiddleware.
@@ -107,7 +135,10 @@ func newSplitAndCacheMiddleware(
 	splitEnabled bool,
 	cacheEnabled bool,
 	splitInterval time.Duration,
+	splitBlockRanges []time.Duration,
 	cacheUnalignedRequests bool,
+	maxConcurrentRevalidations int,
+	revalidationTimeout time.Duration,
 	limits Limits,
 	merger Merger,
 	cache cache.Cache,
@@ -118,6 +149,11 @@ func newSplitAndCacheMiddleware(
 	reg prometheus.Registerer) Middleware {
 	metrics := newSplitAndCacheMiddlewareMetrics(reg)
 
+	splitIntervalAt := func(int64) time.Duration { return splitInterval }
+	if len(splitBlockRanges) > 0 {
+		splitIntervalAt = newBlockRangesSplitter(splitInterval, splitBlockRanges).intervalAt
+	}
+
 	return MiddlewareFunc(func(next Handler) Handler {
 		return &splitAndCacheMiddleware{
 			splitEnabled:           splitEnabled,
@@ -126,13 +162,17 @@ func newSplitAndCacheMiddleware(
 			next:                   next,
 			limits:                 limits,
 			merger:                 merger,
-			splitInterval:          splitInterval,
+			splitIntervalAt:        splitIntervalAt,
 			metrics:                metrics,
 			cache:                  cache,
 			splitter:               splitter,
 			extractor:              extractor,
 			shouldCacheReq:         shouldCacheReq,
 			logger:                 logger,
+			revalidating:           map[string]struct{}{},
+
+			maxConcurrentRevalidations: maxConcurrentRevalidations,
+			revalidationTimeout:        revalidationTimeout,
 		}
 	})
 }
@@ -150,9 +190,11 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		return nil, err
 	}
 
+	now := time.Now()
 	isCacheEnabled := s.cacheEnabled && (s.shouldCacheReq == nil || s.shouldCacheReq(req))
 	maxCacheFreshness := validation.MaxDurationPerTenant(tenantIDs, s.limits.MaxCacheFreshness)
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
+	maxCacheStaleness := s.resultsCacheMaxStaleness(tenantIDs)
 
 	// Lookup the results cache.
 	if isCacheEnabled {
@@ -179,6 +221,17 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		fetchedExtents := s.fetchCacheExtents(ctx, lookupKeys)
 
 		for lookupIdx, extents := range fetchedExtents {
+			// Stale cached results are served while they're refreshed in the background, unless they
+			// have been stale for longer than allowed, in which case they're ignored.
+			if len(extents) > 0 && maxCacheStaleness > 0 {
+				if staleness := s.cacheExtentsStaleness(tenantIDs, extents, now); staleness > maxCacheStaleness {
+					extents = nil
+				} else if staleness > 0 {
+					lookupReqs[lookupIdx].revalidate = true
+					s.metrics.queryResultCacheStaleCount.Inc()
+				}
+			}
+
 			if len(extents) == 0 {
 				// We just need to run the request as is because no part of it has been cached yet.
 				lookupReqs[lookupIdx].downstreamRequests = []Request{lookupReqs[lookupIdx].orig}
@@ -222,14 +275,16 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 	queryStats := stats.FromContext(ctx)
 	queryStats.AddSplitQueries(uint32(len(execReqs)))
 
+	partial := false
 	if len(execReqs) > 0 {
-		execResps, err := doRequests(ctx, s.next, execReqs, true)
+		var execResps []requestResponse
+		execResps, partial, err = doRequestsUntilSoftDeadline(ctx, s.next, execReqs, true)
 		if err != nil {
 			return nil, err
 		}
 
 		// Store the downstream responses in our internal data structure.
-		if err := splitReqs.storeDownstreamResponses(execResps); err != nil {
+		if err := splitReqs.storeDownstreamResponses(execResps, partial); err != nil {
 			return nil, err
 		}
 	}
@@ -253,11 +308,11 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 
 			for downstreamIdx, downstreamReq := range splitReq.downstreamRequests {
 				downstreamRes := splitReq.downstreamResponses[downstreamIdx]
-				if !isResponseCachable(downstreamRes, s.logger) {
+				if downstreamRes == nil || !isResponseCachable(downstreamRes, s.logger) {
 					continue
 				}
 
-				extent, err := toExtent(ctx, downstreamReq, s.extractor.ResponseWithoutHeaders(downstreamRes))
+				extent, err := toExtent(ctx, downstreamReq, s.extractor.ResponseWithoutHeaders(downstreamRes), now)
 				if err != nil {
 					return nil, err
 				}
@@ -287,15 +342,38 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		}
 	}
 
+	// Refresh the stale cached results in the background.
+	for _, splitReq := range splitReqs {
+		if splitReq.revalidate {
+			s.revalidateCacheExtents(ctx, tenantIDs, splitReq, maxCacheFreshness)
+		}
+	}
+
 	// We can finally build the response, which is the merge of all downstream responses and the responses
 	// we've got from the cache (if any).
 	responses := make([]Response, 0, splitReqs.countDownstreamRequests()+splitReqs.countCachedResponses())
 	for _, splitReq := range splitReqs {
 		responses = append(responses, splitReq.cachedResponses...)
-		responses = append(responses, splitReq.downstreamResponses...)
+		for _, downstreamRes := range splitReq.downstreamResponses {
+			// The response is missing if the downstream request didn't complete before the soft deadline.
+			if downstreamRes != nil {
+				responses = append(responses, downstreamRes)
+			}
+		}
+	}
+
+	merged, err := s.merger.MergeResponse(responses...)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.merger.MergeResponse(responses...)
+	if partial {
+		s.metrics.partialResponsesCount.Inc()
+		if promRes, ok := merged.(*PrometheusResponse); ok {
+			promRes.Warnings = append(promRes.Warnings, partialResponseWarning)
+		}
+	}
+	return merged, nil
 }
 
 // splitRequestByInterval splits the given Request by configured interval. Returns the input request if splitting is disabled.
@@ -304,7 +382,7 @@ func (s *splitAndCacheMiddleware) splitRequestByInterval(req Request) (splitRequ
 		return splitRequests{{orig: req}}, nil
 	}
 
-	splitReqs, err := splitQueryByInterval(req, s.splitInterval)
+	splitReqs, err := splitQueryByIntervalFunc(req, s.splitIntervalAt)
 	if err != nil {
 		return nil, err
 	}
@@ -382,16 +460,10 @@ func (s *splitAndCacheMiddleware) fetchCacheExtents(ctx context.Context, keys []
 	return extents
 }
 
-// storeCacheExtents stores the extents for given key in the cache.
+// storeCacheExtents stores the extents for given key in the cache. The extents are kept in the cache
+// for the max staleness period after their TTL, so that they can be served while being refreshed.
 func (s *splitAndCacheMiddleware) storeCacheExtents(ctx context.Context, key string, tenantIDs []string, extents []Extent) {
-	ttl := resultsCacheTTL
-	lowerTTLWithinTimePeriod := validation.MaxDurationPerTenant(tenantIDs, func(tenantID string) time.Duration {
-		return time.Duration(s.limits.OutOfOrderTimeWindow(tenantID))
-	})
-	if lowerTTLWithinTimePeriod > 0 && len(extents) > 0 &&
-		extents[len(extents)-1].End >= time.Now().Add(-lowerTTLWithinTimePeriod).UnixMilli() {
-		ttl = resultsCacheLowerTTL
-	}
+	ttl := s.cacheExtentsTTL(tenantIDs, extents, time.Now()) + s.resultsCacheMaxStaleness(tenantIDs)
 
 	buf, err := proto.Marshal(&CachedResponse{
 		Key:     key,
@@ -405,6 +477,124 @@ func (s *splitAndCacheMiddleware) storeCacheExtents(ctx context.Context, key str
 	s.cache.Store(ctx, map[string][]byte{cacheHashKey(key): buf}, ttl)
 }
 
+// cacheExtentsTTL returns the TTL to use when storing the given extents in the cache. Extents ending
+// within the recent results window (or the out-of-order time window) may still change, so they're
+// cached with a short TTL, while extents ending before it are cached with the longer TTL.
+func (s *splitAndCacheMiddleware) cacheExtentsTTL(tenantIDs []string, extents []Extent, now time.Time) time.Duration {
+	// We're not disabling TTL because the backend client currently doesn't support it.
+	ttl := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTL)
+	if ttl <= 0 {
+		ttl = defaultResultsCacheTTL
+	}
+	if len(extents) == 0 {
+		return ttl
+	}
+
+	recentWindow := validation.MaxDurationPerTenant(tenantIDs, func(tenantID string) time.Duration {
+		window := s.limits.ResultsCacheRecentResultsWindow(tenantID)
+		if oooWindow := time.Duration(s.limits.OutOfOrderTimeWindow(tenantID)); oooWindow > window {
+			window = oooWindow
+		}
+		return window
+	})
+	if recentWindow > 0 && extents[len(extents)-1].End >= now.Add(-recentWindow).UnixMilli() {
+		ttl = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTLForRecentResults)
+		if ttl <= 0 {
+			ttl = defaultResultsCacheTTLForRecentResults
+		}
+	}
+
+	return ttl
+}
+
+// resultsCacheMaxStaleness returns the max staleness of cached results allowed by all the given tenants.
+func (s *splitAndCacheMiddleware) resultsCacheMaxStaleness(tenantIDs []string) time.Duration {
+	return validation.MinDurationPerTenant(tenantIDs, s.limits.ResultsCacheMaxStaleness)
+}
+
+// cacheExtentsStaleness returns for how long the given extents are stale, which is the time elapsed since
+// the TTL they had when the oldest query whose results they contain was run has expired. Returns 0 if the
+// extents are not stale, or the time of the query is unknown.
+func (s *splitAndCacheMiddleware) cacheExtentsStaleness(tenantIDs []string, extents []Extent, now time.Time) time.Duration {
+	var queryTimestamp int64
+	for _, extent := range extents {
+		queryTimestamp = oldestQueryTimestamp(queryTimestamp, extent.QueryTimestampMs)
+	}
+	if queryTimestamp == 0 {
+		return 0
+	}
+
+	queryTime := time.UnixMilli(queryTimestamp)
+	expiration := queryTime.Add(s.cacheExtentsTTL(tenantIDs, extents, queryTime))
+	if !now.After(expiration) {
+		return 0
+	}
+	return now.Sub(expiration)
+}
+
+// revalidateCacheExtents runs the given split request in the background and replaces its stale extents
+// in the cache with the fresh results. Only one revalidation per cache key runs at a time, and the
+// revalidation is skipped if the max number of concurrent revalidations has been reached.
+func (s *splitAndCacheMiddleware) revalidateCacheExtents(ctx context.Context, tenantIDs []string, splitReq *splitRequest, maxCacheFreshness time.Duration) {
+	key := splitReq.cacheKey
+
+	s.revalidatingMtx.Lock()
+	if _, ok := s.revalidating[key]; ok {
+		s.revalidatingMtx.Unlock()
+		return
+	}
+	if s.maxConcurrentRevalidations > 0 && len(s.revalidating) >= s.maxConcurrentRevalidations {
+		s.revalidatingMtx.Unlock()
+		level.Debug(s.logger).Log("msg", "skipped the refresh of stale cached query results because the max number of concurrent refreshes has been reached", "key", key)
+		return
+	}
+	s.revalidating[key] = struct{}{}
+	s.revalidatingMtx.Unlock()
+
+	// The revalidation must not be canceled once the query which triggered it has completed.
+	revalidateCtx := user.InjectOrgID(context.Background(), tenant.JoinTenantIDs(tenantIDs))
+	revalidateCtx = util.ContextWithQueryPriority(revalidateCtx, util.QueryPriorityFromContext(ctx))
+	cancel := context.CancelFunc(func() {})
+	if s.revalidationTimeout > 0 {
+		revalidateCtx, cancel = context.WithTimeout(revalidateCtx, s.revalidationTimeout)
+	}
+	req := splitReq.orig.WithID(1).WithHints(&Hints{TotalQueries: 1})
+
+	go func() {
+		defer func() {
+			cancel()
+
+			s.revalidatingMtx.Lock()
+			delete(s.revalidating, key)
+			s.revalidatingMtx.Unlock()
+		}()
+
+		queryTime := time.Now()
+		res, err := s.next.Do(revalidateCtx, req)
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "failed to refresh stale cached query results", "key", key, "err", err)
+			return
+		}
+		if !isResponseCachable(res, s.logger) {
+			return
+		}
+
+		extent, err := toExtent(revalidateCtx, req, s.extractor.ResponseWithoutHeaders(res), queryTime)
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "failed to refresh stale cached query results", "key", key, "err", err)
+			return
+		}
+
+		extents, err := filterRecentCacheExtents(req, maxCacheFreshness, s.extractor, []Extent{extent})
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "failed to refresh stale cached query results", "key", key, "err", err)
+			return
+		}
+
+		s.storeCacheExtents(revalidateCtx, key, tenantIDs, extents)
+	}()
+}
+
 // splitRequest holds information about a split request.
 type splitRequest struct {
 	// The original split query.
@@ -416,6 +606,9 @@ type splitRequest struct {
 	// The extents picked up from the cache.
 	cachedExtents []Extent
 
+	// Whether the extents picked up from the cache are stale and should be refreshed in the background.
+	revalidate bool
+
 	// The responses picked up from the cache.
 	cachedResponses []Response
 
@@ -480,7 +673,7 @@ func (s *splitRequests) prepareDownstreamRequests() []Request {
 // storeDownstreamResponses associates the given executed requestResponse with the downstream requests
 // and stores the associated downstream responses for each request. If returns no error, then it's guaranteed
 // that any downstream request got its response associated.
-func (s *splitRequests) storeDownstreamResponses(responses []requestResponse) error {
+func (s *splitRequests) storeDownstreamResponses(responses []requestResponse, partial bool) error {
 	execRespsByID := make(map[int64]Response, len(responses))
 
 	// Map responses by (unique) request IDs.
@@ -499,6 +692,10 @@ func (s *splitRequests) storeDownstreamResponses(responses []requestResponse) er
 	for _, splitReq := range *s {
 		for downstreamIdx, downstreamReq := range splitReq.downstreamRequests {
 			downstreamRes, ok := execRespsByID[downstreamReq.GetId()]
+			if !ok && partial {
+				// The downstream request didn't complete before the soft deadline.
+				continue
+			}
 			if !ok {
 				// Should never happen unless a bug.
 				return errors.New("consistency check failed: missing downstream response")
@@ -554,7 +751,62 @@ func doRequests(ctx context.Context, downstream Handler, reqs []Request, recordS
 	return resps, g.Wait()
 }
 
+// doRequestsUntilSoftDeadline executes the requests like doRequests, but if the context carries a soft deadline
+// and the requests don't complete before it, the requests still running are canceled and the responses received
+// so far are returned, with partial set to true.
+func doRequestsUntilSoftDeadline(ctx context.Context, downstream Handler, reqs []Request, recordSpan bool) (_ []requestResponse, partial bool, _ error) {
+	softDeadline, ok := util.QuerySoftDeadlineFromContext(ctx)
+	if !ok {
+		resps, err := doRequests(ctx, downstream, reqs, recordSpan)
+		return resps, false, err
+	}
+
+	reqsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Collect the responses as they're received, since doRequests returns them only once all requests complete.
+	mtx := sync.Mutex{}
+	resps := make([]requestResponse, 0, len(reqs))
+	collector := HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+		resp, err := downstream.Do(ctx, req)
+		if err == nil {
+			mtx.Lock()
+			resps = append(resps, requestResponse{req, resp})
+			mtx.Unlock()
+		}
+		return resp, err
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := doRequests(reqsCtx, collector, reqs, recordSpan)
+		done <- err
+	}()
+
+	timer := time.NewTimer(time.Until(softDeadline))
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, false, err
+		}
+		return resps, false, nil
+	case <-timer.C:
+		cancel()
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]requestResponse(nil), resps...), len(resps) < len(reqs), nil
+	}
+}
+
 func splitQueryByInterval(r Request, interval time.Duration) ([]Request, error) {
+	return splitQueryByIntervalFunc(r, func(int64) time.Duration { return interval })
+}
+
+// splitQueryByIntervalFunc splits the query by the interval returned by intervalAt for the start of each split query.
+func splitQueryByIntervalFunc(r Request, intervalAt func(t int64) time.Duration) ([]Request, error) {
 	// Replace @ modifier function to their respective constant values in the query.
 	// This way subqueries will be evaluated at the same time as the parent query.
 	query, err := evaluateAtModifierFunction(r.GetQuery(), r.GetStart(), r.GetEnd())
@@ -563,7 +815,7 @@ func splitQueryByInterval(r Request, interval time.Duration) ([]Request, error)
 	}
 	var reqs []Request
 	for start := r.GetStart(); start <= r.GetEnd(); {
-		end := nextIntervalBoundary(start, r.GetStep(), interval)
+		end := nextIntervalBoundary(start, r.GetStep(), intervalAt(start))
 		if end > r.GetEnd() {
 			end = r.GetEnd()
 		}
@@ -4,6 +4,7 @@ package querymiddleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -20,6 +21,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/test"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -87,7 +89,10 @@ func TestSplitAndCacheMiddleware_SplitByInterval(t *testing.T) {
 		true,
 		false, // Cache disabled.
 		24*time.Hour,
+		nil,
 		false,
+		0,
+		0,
 		mockLimits{},
 		PrometheusCodec,
 		nil,
@@ -127,6 +132,9 @@ func TestSplitAndCacheMiddleware_SplitByInterval(t *testing.T) {
 
 	// Assert metrics
 	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_partial_responses_total Total number of queries whose partial results have been returned, because the soft timeout set by the client expired before all the split queries completed.
+		# TYPE cortex_frontend_partial_responses_total counter
+		cortex_frontend_partial_responses_total 0
 		# HELP cortex_frontend_query_result_cache_attempted_total Total number of queries that were attempted to be fetched from cache.
 		# TYPE cortex_frontend_query_result_cache_attempted_total counter
 		cortex_frontend_query_result_cache_attempted_total 0
@@ -135,6 +143,9 @@ func TestSplitAndCacheMiddleware_SplitByInterval(t *testing.T) {
 		cortex_frontend_query_result_cache_skipped_total{reason="has-modifiers"} 0
 		cortex_frontend_query_result_cache_skipped_total{reason="too-new"} 0
 		cortex_frontend_query_result_cache_skipped_total{reason="unaligned-time-range"} 0
+		# HELP cortex_frontend_query_result_cache_stale_total Total number of queries whose results were served from stale cached results, while being refreshed in the background. This metric is tracked for each partial query when time-splitting is enabled.
+		# TYPE cortex_frontend_query_result_cache_stale_total counter
+		cortex_frontend_query_result_cache_stale_total 0
 		# HELP cortex_frontend_split_queries_total Total number of underlying query requests after the split by interval is applied.
 		# TYPE cortex_frontend_split_queries_total counter
 		cortex_frontend_split_queries_total 2
@@ -152,7 +163,10 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 		true,
 		true,
 		24*time.Hour,
+		nil,
 		false,
+		0,
+		0,
 		mockLimits{maxCacheFreshness: 10 * time.Minute},
 		PrometheusCodec,
 		cacheBackend,
@@ -237,7 +251,10 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAli
 		true,
 		true,
 		24*time.Hour,
+		nil,
 		false,
+		0,
+		0,
 		mockLimits{maxCacheFreshness: 10 * time.Minute},
 		PrometheusCodec,
 		cacheBackend,
@@ -296,6 +313,9 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAli
 	assert.Equal(t, uint32(1), queryStats.LoadSplitQueries())
 	// Assert metrics
 	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_partial_responses_total Total number of queries whose partial results have been returned, because the soft timeout set by the client expired before all the split queries completed.
+		# TYPE cortex_frontend_partial_responses_total counter
+		cortex_frontend_partial_responses_total 0
 		# HELP cortex_frontend_query_result_cache_attempted_total Total number of queries that were attempted to be fetched from cache.
 		# TYPE cortex_frontend_query_result_cache_attempted_total counter
 		cortex_frontend_query_result_cache_attempted_total 1
@@ -304,6 +324,9 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAli
 		cortex_frontend_query_result_cache_skipped_total{reason="has-modifiers"} 0
 		cortex_frontend_query_result_cache_skipped_total{reason="too-new"} 0
 		cortex_frontend_query_result_cache_skipped_total{reason="unaligned-time-range"} 1
+		# HELP cortex_frontend_query_result_cache_stale_total Total number of queries whose results were served from stale cached results, while being refreshed in the background. This metric is tracked for each partial query when time-splitting is enabled.
+		# TYPE cortex_frontend_query_result_cache_stale_total counter
+		cortex_frontend_query_result_cache_stale_total 0
 		# HELP cortex_frontend_split_queries_total Total number of underlying query requests after the split by interval is applied.
 		# TYPE cortex_frontend_split_queries_total counter
 		cortex_frontend_split_queries_total 1
@@ -317,7 +340,10 @@ func TestSplitAndCacheMiddleware_ResultsCache_EnabledCachingOfStepUnalignedReque
 		true,
 		true,
 		24*time.Hour,
+		nil,
 		true, // caching of step-unaligned requests is enabled in this test.
+		0,
+		0,
 		mockLimits{maxCacheFreshness: 10 * time.Minute},
 		PrometheusCodec,
 		cacheBackend,
@@ -432,6 +458,9 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotCacheRequestEarlierThanMa
 			expectedDownstreamEndTime:   now,
 			expectedCachedResponses:     nil,
 			expectedMetrics: `
+				# HELP cortex_frontend_partial_responses_total Total number of queries whose partial results have been returned, because the soft timeout set by the client expired before all the split queries completed.
+				# TYPE cortex_frontend_partial_responses_total counter
+				cortex_frontend_partial_responses_total 0
 				# HELP cortex_frontend_query_result_cache_attempted_total Total number of queries that were attempted to be fetched from cache.
 				# TYPE cortex_frontend_query_result_cache_attempted_total counter
 				cortex_frontend_query_result_cache_attempted_total 2
@@ -440,6 +469,9 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotCacheRequestEarlierThanMa
 				cortex_frontend_query_result_cache_skipped_total{reason="has-modifiers"} 0
 				cortex_frontend_query_result_cache_skipped_total{reason="too-new"} 2
 				cortex_frontend_query_result_cache_skipped_total{reason="unaligned-time-range"} 0
+				# HELP cortex_frontend_query_result_cache_stale_total Total number of queries whose results were served from stale cached results, while being refreshed in the background. This metric is tracked for each partial query when time-splitting is enabled.
+				# TYPE cortex_frontend_query_result_cache_stale_total counter
+				cortex_frontend_query_result_cache_stale_total 0
 				# HELP cortex_frontend_split_queries_total Total number of underlying query requests after the split by interval is applied.
 				# TYPE cortex_frontend_split_queries_total counter
 				cortex_frontend_split_queries_total 0
@@ -473,7 +505,10 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotCacheRequestEarlierThanMa
 				false, // No interval splitting.
 				true,
 				24*time.Hour,
+				nil,
 				false,
+				0,
+				0,
 				mockLimits{maxCacheFreshness: maxCacheFreshness},
 				PrometheusCodec,
 				cacheBackend,
@@ -682,7 +717,10 @@ func TestSplitAndCacheMiddleware_ResultsCacheFuzzy(t *testing.T) {
 					testData.splitEnabled,
 					testData.cacheEnabled,
 					24*time.Hour,
+					nil,
 					testData.cacheUnaligned,
+					0,
+					0,
 					mockLimits{
 						maxCacheFreshness:   testData.maxCacheFreshness,
 						maxQueryParallelism: testData.maxQueryParallelism,
@@ -921,7 +959,10 @@ func TestSplitAndCacheMiddleware_ResultsCache_ExtentsEdgeCases(t *testing.T) {
 				false, // No splitting.
 				true,
 				24*time.Hour,
+				nil,
 				false,
+				0,
+				0,
 				mockLimits{},
 				PrometheusCodec,
 				cacheBackend,
@@ -948,6 +989,10 @@ func TestSplitAndCacheMiddleware_ResultsCache_ExtentsEdgeCases(t *testing.T) {
 			// Check the updated cached extents.
 			actualExtents := mw.fetchCacheExtents(ctx, []string{cacheKey})
 			require.Len(t, actualExtents, 1)
+			for i := range actualExtents[0] {
+				// The query timestamp depends on the current time.
+				actualExtents[0][i].QueryTimestampMs = 0
+			}
 			assert.Equal(t, testData.expectedCachedExtents, actualExtents[0])
 
 			// We always call Store() once to set the fixtures.
@@ -960,13 +1005,343 @@ func TestSplitAndCacheMiddleware_ResultsCache_ExtentsEdgeCases(t *testing.T) {
 	}
 }
 
+func TestSplitAndCacheMiddleware_ResultsCache_StaleWhileRevalidate(t *testing.T) {
+	const userID = "user-1"
+
+	req := &PrometheusRangeQueryRequest{
+		Start: 100,
+		End:   200,
+		Step:  10,
+	}
+
+	tests := map[string]struct {
+		cachedQueryTime          time.Duration
+		expectedDownstreamReqs   int
+		expectedStaleCount       int
+		expectedRevalidatedCache bool
+	}{
+		"should serve fresh cached results": {
+			cachedQueryTime:        -30 * time.Minute,
+			expectedDownstreamReqs: 0,
+		},
+		"should serve stale cached results and refresh them in the background": {
+			cachedQueryTime:          -90 * time.Minute,
+			expectedDownstreamReqs:   1,
+			expectedStaleCount:       1,
+			expectedRevalidatedCache: true,
+		},
+		"should not serve cached results stale for longer than the max staleness": {
+			cachedQueryTime:        -3 * time.Hour,
+			expectedDownstreamReqs: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), userID)
+			cacheSplitter := ConstSplitter(day)
+			reg := prometheus.NewPedanticRegistry()
+			downstreamReqs := atomic.NewInt64(0)
+
+			mw := newSplitAndCacheMiddleware(
+				false, // No splitting.
+				true,
+				24*time.Hour,
+				nil,
+				false,
+				0,
+				0,
+				mockLimits{resultsCacheTTL: time.Hour, resultsCacheMaxStaleness: time.Hour},
+				PrometheusCodec,
+				cache.NewMockCache(),
+				cacheSplitter,
+				PrometheusResponseExtractor{},
+				resultsCacheAlwaysEnabled,
+				log.NewNopLogger(),
+				reg,
+			).Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				downstreamReqs.Inc()
+				return mkAPIResponse(req.GetStart(), req.GetEnd(), req.GetStep()), nil
+			})).(*splitAndCacheMiddleware)
+
+			// Store the cached results of a query run in the past.
+			cachedQueryTime := time.Now().Add(testData.cachedQueryTime)
+			extent := mkExtentWithStep(100, 200, 10)
+			extent.QueryTimestampMs = cachedQueryTime.UnixMilli()
+
+			cacheKey := cacheSplitter.GenerateCacheKey(ctx, userID, req)
+			mw.storeCacheExtents(ctx, cacheKey, []string{userID}, []Extent{extent})
+
+			actualRes, err := mw.Do(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, mkAPIResponse(100, 200, 10), actualRes)
+
+			test.Poll(t, time.Second, int64(testData.expectedDownstreamReqs), func() interface{} {
+				return downstreamReqs.Load()
+			})
+			if testData.expectedRevalidatedCache {
+				test.Poll(t, time.Second, true, func() interface{} {
+					actualExtents := mw.fetchCacheExtents(ctx, []string{cacheKey})
+					return len(actualExtents[0]) == 1 && actualExtents[0][0].QueryTimestampMs > cachedQueryTime.UnixMilli()
+				})
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_frontend_query_result_cache_stale_total Total number of queries whose results were served from stale cached results, while being refreshed in the background. This metric is tracked for each partial query when time-splitting is enabled.
+				# TYPE cortex_frontend_query_result_cache_stale_total counter
+				cortex_frontend_query_result_cache_stale_total %d
+			`, testData.expectedStaleCount)), "cortex_frontend_query_result_cache_stale_total"))
+		})
+	}
+}
+
+func TestSplitAndCacheMiddleware_ShouldReturnPartialResultsOnSoftDeadline(t *testing.T) {
+	dayMs := (24 * time.Hour).Milliseconds()
+	hourMs := time.Hour.Milliseconds()
+
+	req := &PrometheusRangeQueryRequest{
+		Start: 0,
+		End:   3*dayMs - hourMs,
+		Step:  hourMs,
+		Query: "up",
+	}
+
+	tests := map[string]struct {
+		softTimeout      time.Duration
+		failFastSplit    bool
+		expectedResponse *PrometheusResponse
+		expectedErr      bool
+		expectedPartial  int
+	}{
+		"should return the results of the completed split queries once the soft deadline expires": {
+			softTimeout: 100 * time.Millisecond,
+			expectedResponse: func() *PrometheusResponse {
+				res := mkAPIResponse(0, 2*dayMs-hourMs, hourMs)
+				res.Warnings = []string{partialResponseWarning}
+				return res
+			}(),
+			expectedPartial: 1,
+		},
+		"should fail if a split query fails before the soft deadline": {
+			softTimeout:   time.Minute,
+			failFastSplit: true,
+			expectedErr:   true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			mw := newSplitAndCacheMiddleware(
+				true,
+				false, // No caching.
+				24*time.Hour,
+				nil,
+				false,
+				0,
+				0,
+				mockLimits{},
+				PrometheusCodec,
+				nil,
+				nil,
+				nil,
+				resultsCacheAlwaysEnabled,
+				log.NewNopLogger(),
+				reg,
+			).Wrap(HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+				switch {
+				case req.GetStart() >= 2*dayMs:
+					// The last split query doesn't complete until it's canceled.
+					<-ctx.Done()
+					return nil, ctx.Err()
+				case testData.failFastSplit && req.GetStart() == 0:
+					return nil, errors.New("split query failed")
+				default:
+					return mkAPIResponse(req.GetStart(), req.GetEnd(), req.GetStep()), nil
+				}
+			}))
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			ctx = util.ContextWithQuerySoftDeadline(ctx, time.Now().Add(testData.softTimeout))
+
+			actualRes, err := mw.Do(ctx, req)
+			if testData.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedResponse, actualRes)
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_frontend_partial_responses_total Total number of queries whose partial results have been returned, because the soft timeout set by the client expired before all the split queries completed.
+				# TYPE cortex_frontend_partial_responses_total counter
+				cortex_frontend_partial_responses_total %d
+			`, testData.expectedPartial)), "cortex_frontend_partial_responses_total"))
+		})
+	}
+}
+
+func TestSplitAndCacheMiddleware_RevalidateCacheExtents_ShouldRunOncePerKey(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	cacheSplitter := ConstSplitter(day)
+	req := &PrometheusRangeQueryRequest{Start: 100, End: 200, Step: 10}
+	downstreamReqs := atomic.NewInt64(0)
+	release := make(chan struct{})
+
+	mw := newSplitAndCacheMiddleware(
+		false, // No splitting.
+		true,
+		24*time.Hour,
+		nil,
+		false,
+		0,
+		0,
+		mockLimits{resultsCacheMaxStaleness: time.Hour},
+		PrometheusCodec,
+		cache.NewMockCache(),
+		cacheSplitter,
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		prometheus.NewPedanticRegistry(),
+	).Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		downstreamReqs.Inc()
+		<-release
+		return mkAPIResponse(req.GetStart(), req.GetEnd(), req.GetStep()), nil
+	})).(*splitAndCacheMiddleware)
+
+	splitReq := &splitRequest{orig: req, cacheKey: cacheSplitter.GenerateCacheKey(ctx, userID, req)}
+	mw.revalidateCacheExtents(ctx, []string{userID}, splitReq, 0)
+	mw.revalidateCacheExtents(ctx, []string{userID}, splitReq, 0)
+
+	test.Poll(t, time.Second, int64(1), func() interface{} {
+		return downstreamReqs.Load()
+	})
+	close(release)
+
+	// Once the revalidation has completed, the key can be revalidated again.
+	test.Poll(t, time.Second, 0, func() interface{} {
+		mw.revalidatingMtx.Lock()
+		defer mw.revalidatingMtx.Unlock()
+		return len(mw.revalidating)
+	})
+	assert.Equal(t, int64(1), downstreamReqs.Load())
+
+	extents := mw.fetchCacheExtents(ctx, []string{splitReq.cacheKey})
+	require.Len(t, extents[0], 1)
+	assert.Equal(t, int64(100), extents[0][0].Start)
+	assert.Equal(t, int64(200), extents[0][0].End)
+}
+
+func TestSplitAndCacheMiddleware_RevalidateCacheExtents_ShouldSkipOnceMaxConcurrentRevalidationsReached(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	cacheSplitter := ConstSplitter(day)
+	downstreamReqs := atomic.NewInt64(0)
+	release := make(chan struct{})
+
+	mw := newSplitAndCacheMiddleware(
+		false, // No splitting.
+		true,
+		24*time.Hour,
+		nil,
+		false,
+		2,
+		0,
+		mockLimits{resultsCacheMaxStaleness: time.Hour},
+		PrometheusCodec,
+		cache.NewMockCache(),
+		cacheSplitter,
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		prometheus.NewPedanticRegistry(),
+	).Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		downstreamReqs.Inc()
+		<-release
+		return mkAPIResponse(req.GetStart(), req.GetEnd(), req.GetStep()), nil
+	})).(*splitAndCacheMiddleware)
+
+	// The requests are in different days, so that they have different cache keys.
+	for _, start := range []int64{0, day.Milliseconds(), 2 * day.Milliseconds()} {
+		req := &PrometheusRangeQueryRequest{Start: start, End: start + 100, Step: 10}
+		mw.revalidateCacheExtents(ctx, []string{userID}, &splitRequest{orig: req, cacheKey: cacheSplitter.GenerateCacheKey(ctx, userID, req)}, 0)
+	}
+
+	test.Poll(t, time.Second, int64(2), func() interface{} {
+		return downstreamReqs.Load()
+	})
+	close(release)
+
+	test.Poll(t, time.Second, 0, func() interface{} {
+		mw.revalidatingMtx.Lock()
+		defer mw.revalidatingMtx.Unlock()
+		return len(mw.revalidating)
+	})
+	assert.Equal(t, int64(2), downstreamReqs.Load())
+}
+
+func TestSplitAndCacheMiddleware_RevalidateCacheExtents_ShouldCancelOnceTimeoutExpired(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	cacheSplitter := ConstSplitter(day)
+	req := &PrometheusRangeQueryRequest{Start: 100, End: 200, Step: 10}
+	downstreamErr := make(chan error, 1)
+
+	mw := newSplitAndCacheMiddleware(
+		false, // No splitting.
+		true,
+		24*time.Hour,
+		nil,
+		false,
+		0,
+		100*time.Millisecond,
+		mockLimits{resultsCacheMaxStaleness: time.Hour},
+		PrometheusCodec,
+		cache.NewMockCache(),
+		cacheSplitter,
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		prometheus.NewPedanticRegistry(),
+	).Wrap(HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+		<-ctx.Done()
+		downstreamErr <- ctx.Err()
+		return nil, ctx.Err()
+	})).(*splitAndCacheMiddleware)
+
+	splitReq := &splitRequest{orig: req, cacheKey: cacheSplitter.GenerateCacheKey(ctx, userID, req)}
+	mw.revalidateCacheExtents(ctx, []string{userID}, splitReq, 0)
+
+	select {
+	case err := <-downstreamErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		require.FailNow(t, "the revalidation hasn't been canceled once the timeout expired")
+	}
+
+	test.Poll(t, time.Second, 0, func() interface{} {
+		mw.revalidatingMtx.Lock()
+		defer mw.revalidatingMtx.Unlock()
+		return len(mw.revalidating)
+	})
+	assert.Empty(t, mw.fetchCacheExtents(ctx, []string{splitReq.cacheKey})[0])
+}
+
 func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 	cacheBackend := cache.NewMockCache()
 	mw := newSplitAndCacheMiddleware(
 		false,
 		true,
 		24*time.Hour,
+		nil,
 		false,
+		0,
+		0,
 		mockLimits{},
 		PrometheusCodec,
 		cacheBackend,
@@ -986,8 +1361,8 @@ func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 	})
 
 	t.Run("fetchCacheExtents() should return a slice with the same number of input keys and some extends filled up on partial cache hit", func(t *testing.T) {
-		mw.storeCacheExtents(ctx, "key-1", nil, []Extent{mkExtent(10, 20)})
-		mw.storeCacheExtents(ctx, "key-3", nil, []Extent{mkExtent(20, 30), mkExtent(40, 50)})
+		mw.storeCacheExtents(ctx, "key-1", []string{"user-1"}, []Extent{mkExtent(10, 20)})
+		mw.storeCacheExtents(ctx, "key-3", []string{"user-1"}, []Extent{mkExtent(20, 30), mkExtent(40, 50)})
 
 		actual := mw.fetchCacheExtents(ctx, []string{"key-1", "key-2", "key-3"})
 		expected := [][]Extent{{mkExtent(10, 20)}, nil, {mkExtent(20, 30), mkExtent(40, 50)}}
@@ -1000,7 +1375,7 @@ func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 		require.NoError(t, err)
 		cacheBackend.Store(ctx, map[string][]byte{cacheHashKey("key-1"): buf}, 0)
 
-		mw.storeCacheExtents(ctx, "key-3", nil, []Extent{mkExtent(20, 30), mkExtent(40, 50)})
+		mw.storeCacheExtents(ctx, "key-3", []string{"user-1"}, []Extent{mkExtent(20, 30), mkExtent(40, 50)})
 
 		actual := mw.fetchCacheExtents(ctx, []string{"key-1", "key-2", "key-3"})
 		expected := [][]Extent{nil, nil, {mkExtent(20, 30), mkExtent(40, 50)}}
@@ -1013,7 +1388,10 @@ func TestSplitAndCacheMiddleware_WrapMultipleTimes(t *testing.T) {
 		false,
 		true,
 		24*time.Hour,
+		nil,
 		false,
+		0,
+		0,
 		mockLimits{},
 		PrometheusCodec,
 		cache.NewMockCache(),
@@ -1186,7 +1564,7 @@ func TestSplitRequests_storeDownstreamResponses(t *testing.T) {
 				require.Len(t, req.downstreamResponses, len(req.downstreamRequests))
 			}
 
-			err := testData.requests.storeDownstreamResponses(testData.responses)
+			err := testData.requests.storeDownstreamResponses(testData.responses, false)
 
 			if testData.expectedErr != "" {
 				assert.EqualError(t, err, testData.expectedErr)
@@ -1560,27 +1938,27 @@ func TestSplitAndCacheMiddlewareLowerTTL(t *testing.T) {
 	}{
 		{
 			endTime: time.Now(),
-			expTTL:  resultsCacheLowerTTL,
+			expTTL:  10 * time.Minute,
 		},
 		{
 			endTime: time.Now().Add(-30 * time.Minute),
-			expTTL:  resultsCacheLowerTTL,
+			expTTL:  10 * time.Minute,
 		},
 		{
 			endTime: time.Now().Add(-59 * time.Minute),
-			expTTL:  resultsCacheLowerTTL,
+			expTTL:  10 * time.Minute,
 		},
 		{
 			endTime: time.Now().Add(-61 * time.Minute),
-			expTTL:  resultsCacheTTL,
+			expTTL:  7 * 24 * time.Hour,
 		},
 		{
 			endTime: time.Now().Add(-2 * time.Hour),
-			expTTL:  resultsCacheTTL,
+			expTTL:  7 * 24 * time.Hour,
 		},
 		{
 			endTime: time.Now().Add(-12 * time.Hour),
-			expTTL:  resultsCacheTTL,
+			expTTL:  7 * 24 * time.Hour,
 		},
 	}
 
@@ -1601,3 +1979,70 @@ func TestSplitAndCacheMiddlewareLowerTTL(t *testing.T) {
 		require.Less(t, actualTTL, c.expTTL+(50*time.Millisecond))
 	}
 }
+
+func TestSplitAndCacheMiddleware_CacheExtentsTTL(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		limits      Limits
+		extentsEnd  time.Time
+		expectedTTL time.Duration
+	}{
+		"should use the default TTL if the recent results window is disabled": {
+			extentsEnd:  now,
+			expectedTTL: 7 * 24 * time.Hour,
+		},
+		"should use the TTL for recent results if extents end within the recent results window": {
+			limits:      mockLimits{resultsCacheRecentWindow: 13 * time.Hour, resultsCacheTTLForRecent: time.Minute},
+			extentsEnd:  now.Add(-12 * time.Hour),
+			expectedTTL: time.Minute,
+		},
+		"should use the TTL if extents end before the recent results window": {
+			limits:      mockLimits{resultsCacheRecentWindow: 13 * time.Hour, resultsCacheTTL: 30 * 24 * time.Hour},
+			extentsEnd:  now.Add(-14 * time.Hour),
+			expectedTTL: 30 * 24 * time.Hour,
+		},
+		"should use the TTL for recent results if extents end within the out-of-order time window": {
+			limits:      mockLimits{resultsCacheRecentWindow: time.Hour, outOfOrderTimeWindow: model.Duration(3 * time.Hour)},
+			extentsEnd:  now.Add(-2 * time.Hour),
+			expectedTTL: 10 * time.Minute,
+		},
+		"should use the default TTL if the TTL is 0": {
+			limits:      zeroResultsCacheTTLLimits{mockLimits{resultsCacheRecentWindow: 13 * time.Hour}},
+			extentsEnd:  now.Add(-14 * time.Hour),
+			expectedTTL: 7 * 24 * time.Hour,
+		},
+		"should use the default TTL for recent results if the TTL for recent results is 0": {
+			limits:      zeroResultsCacheTTLLimits{mockLimits{resultsCacheRecentWindow: 13 * time.Hour}},
+			extentsEnd:  now.Add(-12 * time.Hour),
+			expectedTTL: 10 * time.Minute,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := testData.limits
+			if limits == nil {
+				limits = mockLimits{}
+			}
+			m := splitAndCacheMiddleware{limits: limits}
+			extents := []Extent{{Start: 0, End: testData.extentsEnd.UnixMilli()}}
+
+			assert.Equal(t, testData.expectedTTL, m.cacheExtentsTTL([]string{"user-1"}, extents, now))
+		})
+	}
+}
+
+// zeroResultsCacheTTLLimits are mockLimits whose results cache TTLs are overridden to 0, which the mockLimits
+// would otherwise replace with the flag defaults.
+type zeroResultsCacheTTLLimits struct {
+	mockLimits
+}
+
+func (zeroResultsCacheTTLLimits) ResultsCacheTTL(string) time.Duration {
+	return 0
+}
+
+func (zeroResultsCacheTTLLimits) ResultsCacheTTLForRecentResults(string) time.Duration {
+	return 0
+}
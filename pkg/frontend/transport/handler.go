@@ -20,6 +20,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/weaveworks/common/httpgrpc"
@@ -32,6 +33,7 @@ import (
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/queryjournal"
 )
 
 const (
@@ -51,12 +53,17 @@ type HandlerConfig struct {
 	LogQueriesLongerThan time.Duration `yaml:"log_queries_longer_than"`
 	MaxBodySize          int64         `yaml:"max_body_size" category:"advanced"`
 	QueryStatsEnabled    bool          `yaml:"query_stats_enabled" category:"advanced"`
+
+	QueryJournal queryjournal.Config `yaml:"query_journal"`
+	Shadowing    ShadowConfig        `yaml:"query_shadowing"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LogQueriesLongerThan, "query-frontend.log-queries-longer-than", 0, "Log queries that are slower than the specified duration. Set to 0 to disable. Set to < 0 to enable on all queries.")
 	f.Int64Var(&cfg.MaxBodySize, "query-frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "query-frontend.query-stats-enabled", true, "False to disable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
+	cfg.QueryJournal.RegisterFlagsWithPrefix("query-frontend.query-journal.", f)
+	cfg.Shadowing.RegisterFlagsWithPrefix("query-frontend.query-shadowing.", f)
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can log slow queries,
@@ -66,6 +73,8 @@ type Handler struct {
 	log          log.Logger
 	roundTripper http.RoundTripper
 	at           *activitytracker.ActivityTracker
+	journal      *queryjournal.Journal
+	shadower     *shadower
 
 	// Metrics.
 	querySeconds *prometheus.CounterVec
@@ -76,12 +85,14 @@ type Handler struct {
 }
 
 // NewHandler creates a new frontend handler.
-func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker) http.Handler {
+func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker, journal *queryjournal.Journal) http.Handler {
 	h := &Handler{
 		cfg:          cfg,
 		log:          log,
 		roundTripper: roundTripper,
 		at:           at,
+		journal:      journal,
+		shadower:     newShadower(cfg.Shadowing, log, reg),
 	}
 
 	if cfg.QueryStatsEnabled {
@@ -132,17 +143,54 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	defer func() { _ = r.Body.Close() }()
 
+	// Keep track of the Grafana dashboard panel issuing the query, if any, so that it's
+	// propagated to the requests sent downstream and attributed in the logs and traces.
+	source := util.GrafanaSourceFromHTTPHeaders(r.Header)
+	if !source.IsEmpty() {
+		r = r.WithContext(util.ContextWithGrafanaSource(r.Context(), source))
+
+		if span := opentracing.SpanFromContext(r.Context()); span != nil {
+			span.SetTag("dashboard_uid", source.DashboardUID)
+			span.SetTag("panel_id", source.PanelID)
+		}
+	}
+
+	// Keep track of the query priority, if any, so that it's propagated to the requests sent to the query-scheduler.
+	if priority := r.Header.Get(util.QueryPriorityHeader); priority != "" {
+		r = r.WithContext(util.ContextWithQueryPriority(r.Context(), priority))
+	}
+
+	// Apply the query timeout set by the client, if any, so that the query is canceled in the
+	// query-scheduler and the queriers once it expires.
+	ctx, cancel, err := util.ContextWithQueryTimeoutFromHTTPHeaders(r.Context(), r.Header)
+	if err != nil {
+		writeError(w, r, apierror.New(apierror.TypeBadData, err.Error()))
+		return
+	}
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	// Keep track of the soft timeout set by the client, if any, after which the results received so far are returned.
+	softTimeout, err := util.QuerySoftTimeoutFromHTTPHeaders(r.Header)
+	if err != nil {
+		writeError(w, r, apierror.New(apierror.TypeBadData, err.Error()))
+		return
+	}
+	if softTimeout > 0 {
+		r = r.WithContext(util.ContextWithQuerySoftDeadline(r.Context(), time.Now().Add(softTimeout)))
+	}
+
 	// Store the body contents, so we can read it multiple times.
 	bodyBytes, err := io.ReadAll(http.MaxBytesReader(w, r.Body, f.cfg.MaxBodySize))
 	if err != nil {
-		writeError(w, err)
+		writeError(w, r, err)
 		return
 	}
 	r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 
 	// Parse the form, as it's needed to build the activity for the activity-tracker.
 	if err := r.ParseForm(); err != nil {
-		writeError(w, apierror.New(apierror.TypeBadData, err.Error()))
+		writeError(w, r, apierror.New(apierror.TypeBadData, err.Error()))
 		return
 	}
 
@@ -161,8 +209,9 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	queryResponseTime := time.Since(startTime)
 
 	if err != nil {
-		writeError(w, err)
+		writeError(w, r, err)
 		f.reportQueryStats(r, params, queryResponseTime, stats, err)
+		f.recordQueryJournal(r, params, queryResponseTime, stats, err)
 		return
 	}
 
@@ -175,9 +224,21 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		writeServiceTimingHeader(queryResponseTime, hs, stats)
 	}
 
+	// Keep a copy of the response to compare it with the one of the secondary downstream, if the query is shadowed.
+	var body io.Reader = resp.Body
+	var primaryBody bytes.Buffer
+	shadow := f.shadower.shouldShadow() && resp.Header.Get("Content-Encoding") == ""
+	if shadow {
+		body = io.TeeReader(resp.Body, &primaryBody)
+	}
+
 	w.WriteHeader(resp.StatusCode)
 	// we don't check for copy error as there is no much we can do at this point
-	_, _ = io.Copy(w, resp.Body)
+	_, _ = io.Copy(w, body)
+
+	if shadow {
+		f.shadower.shadow(r, bodyBytes, params, resp.StatusCode, primaryBody.Bytes())
+	}
 
 	if f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan {
 		f.reportSlowQuery(r, params, queryResponseTime)
@@ -185,6 +246,7 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if f.cfg.QueryStatsEnabled {
 		f.reportQueryStats(r, params, queryResponseTime, stats, nil)
 	}
+	f.recordQueryJournal(r, params, queryResponseTime, stats, nil)
 }
 
 // reportSlowQuery reports slow queries.
@@ -195,7 +257,8 @@ func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, query
 		"host", r.Host,
 		"path", r.URL.Path,
 		"time_taken", queryResponseTime.String(),
-	}, formatQueryString(queryString)...)
+	}, util.GrafanaSourceFromContext(r.Context()).LogFields()...)
+	logMessage = append(logMessage, formatQueryString(queryString)...)
 
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
@@ -236,7 +299,8 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 		"fetched_index_bytes", numIndexBytes,
 		"sharded_queries", stats.LoadShardedQueries(),
 		"split_queries", stats.LoadSplitQueries(),
-	}, formatQueryString(queryString)...)
+	}, util.GrafanaSourceFromContext(r.Context()).LogFields()...)
+	logMessage = append(logMessage, formatQueryString(queryString)...)
 
 	if queryErr != nil {
 		logMessage = append(logMessage,
@@ -250,6 +314,45 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
 
+// recordQueryJournal records the query in the query journal, if enabled.
+func (f *Handler) recordQueryJournal(r *http.Request, queryString url.Values, queryResponseTime time.Duration, stats *querier_stats.Stats, queryErr error) {
+	if f.journal == nil {
+		return
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return
+	}
+
+	entry := queryjournal.Entry{
+		Timestamp:          time.Now(),
+		Tenant:             tenant.JoinTenantIDs(tenantIDs),
+		Method:             r.Method,
+		Path:               r.URL.Path,
+		Query:              queryString.Get("query"),
+		Start:              queryString.Get("start"),
+		End:                queryString.Get("end"),
+		Step:               queryString.Get("step"),
+		Time:               queryString.Get("time"),
+		ResponseTime:       queryResponseTime,
+		WallTime:           stats.LoadWallTime(),
+		FetchedSeriesCount: stats.LoadFetchedSeries(),
+		FetchedChunksCount: stats.LoadFetchedChunks(),
+		FetchedChunkBytes:  stats.LoadFetchedChunkBytes(),
+		FetchedIndexBytes:  stats.LoadFetchedIndexBytes(),
+		ShardedQueries:     stats.LoadShardedQueries(),
+		SplitQueries:       stats.LoadSplitQueries(),
+		Status:             queryjournal.StatusSuccess,
+	}
+	if queryErr != nil {
+		entry.Status = queryjournal.StatusFailed
+		entry.Error = queryErr.Error()
+	}
+
+	f.journal.Record(entry)
+}
+
 func formatQueryString(queryString url.Values) (fields []interface{}) {
 	for k, v := range queryString {
 		fields = append(fields, fmt.Sprintf("param_%s", k), strings.Join(v, ","))
@@ -257,7 +360,8 @@ func formatQueryString(queryString url.Values) (fields []interface{}) {
 	return fields
 }
 
-func writeError(w http.ResponseWriter, err error) {
+// writeError writes the error response, in the version of the schema of the error responses negotiated by the request.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
 	switch {
 	case errors.Is(err, context.Canceled):
 		err = errCanceled
@@ -269,8 +373,8 @@ func writeError(w http.ResponseWriter, err error) {
 		}
 	}
 
-	// if the error error is an APIError, ensure it gets written as a JSON response
-	if resp, ok := apierror.HTTPResponseFromError(err); ok {
+	// if the error error is an APIError, or a versioned error response is requested, ensure it gets written as a JSON response
+	if resp, ok := apierror.HTTPResponseFromErrorWithSchemaVersion(err, apierror.NegotiateSchemaVersion(r.Header)); ok {
 		_ = server.WriteResponse(w, resp)
 		return
 	}
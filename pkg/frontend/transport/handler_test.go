@@ -7,13 +7,16 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/concurrency"
@@ -25,7 +28,12 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
 
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/queryjournal"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -46,12 +54,52 @@ func TestWriteError(t *testing.T) {
 	} {
 		t.Run(test.err.Error(), func(t *testing.T) {
 			w := httptest.NewRecorder()
-			writeError(w, test.err)
+			writeError(w, httptest.NewRequest("GET", "/", nil), test.err)
 			require.Equal(t, test.status, w.Result().StatusCode)
 		})
 	}
 }
 
+func TestWriteError_VersionedSchema(t *testing.T) {
+	for _, test := range []struct {
+		err          error
+		status       int
+		expectedBody string
+	}{
+		{
+			err:          errors.New("unknown"),
+			status:       http.StatusInternalServerError,
+			expectedBody: `{"status":"error","errorType":"internal","errorCode":"err-mimir-internal","error":"unknown"}`,
+		},
+		{
+			err:          context.Canceled,
+			status:       StatusClientClosedRequest,
+			expectedBody: `{"status":"error","errorType":"canceled","errorCode":"err-mimir-canceled","error":"context canceled"}`,
+		},
+		{
+			err:          apierror.New(apierror.TypeBadData, globalerror.MaxTotalQueryLength.Message("the query time range exceeds the limit")),
+			status:       http.StatusBadRequest,
+			expectedBody: `{"status":"error","errorType":"bad_data","errorCode":"err-mimir-max-total-query-length","error":"the query time range exceeds the limit (err-mimir-max-total-query-length)"}`,
+		},
+		{
+			err:          httpgrpc.Errorf(http.StatusTooManyRequests, "too many outstanding requests"),
+			status:       http.StatusTooManyRequests,
+			expectedBody: `{"status":"error","errorType":"too_many_requests","errorCode":"err-mimir-too-many-requests","error":"too many outstanding requests"}`,
+		},
+	} {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set(apierror.SchemaVersionHeader, "1")
+
+			w := httptest.NewRecorder()
+			writeError(w, r, test.err)
+			require.Equal(t, test.status, w.Result().StatusCode)
+			assert.Equal(t, "1", w.Result().Header.Get(apierror.SchemaVersionHeader))
+			assert.JSONEq(t, test.expectedBody, w.Body.String())
+		})
+	}
+}
+
 func TestHandler_ServeHTTP(t *testing.T) {
 	for _, tt := range []struct {
 		name             string
@@ -141,7 +189,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			require.NoError(t, err)
 			t.Cleanup(func() { require.NoError(t, at.Close()) })
 
-			handler := NewHandler(tt.cfg, roundTripper, log.NewNopLogger(), reg, at)
+			handler := NewHandler(tt.cfg, roundTripper, log.NewNopLogger(), reg, at, nil)
 
 			req := tt.request().WithContext(user.InjectOrgID(context.Background(), "12345"))
 			resp := httptest.NewRecorder()
@@ -202,7 +250,7 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
 			logs := &concurrency.SyncBuffer{}
 			logger := log.NewLogfmtLogger(logs)
-			handler := NewHandler(test.cfg, roundTripper, logger, reg, nil)
+			handler := NewHandler(test.cfg, roundTripper, logger, reg, nil, nil)
 
 			ctx := user.InjectOrgID(context.Background(), "12345")
 			req := httptest.NewRequest("GET", test.path, nil)
@@ -231,3 +279,133 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GrafanaSource(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, util.GrafanaSource{DashboardUID: "dashboard", PanelID: "2"}, util.GrafanaSourceFromContext(req.Context()))
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	logs := &concurrency.SyncBuffer{}
+	cfg := HandlerConfig{QueryStatsEnabled: true, LogQueriesLongerThan: -1}
+	handler := NewHandler(cfg, roundTripper, log.NewLogfmtLogger(logs), prometheus.NewPedanticRegistry(), nil, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+	req.Header.Set(util.GrafanaDashboardUIDHeader, "dashboard")
+	req.Header.Set(util.GrafanaPanelIDHeader, "2")
+	req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		assert.Contains(t, line, "dashboard_uid=dashboard panel_id=2")
+	}
+}
+
+func TestHandler_QueryJournal(t *testing.T) {
+	journalPath := filepath.Join(t.TempDir(), "queries.log")
+	journal, err := queryjournal.NewJournal(queryjournal.Config{Filepath: journalPath, MaxFileSizeBytes: 1024 * 1024, MaxFiles: 1, SampleRate: 1}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, journal.Close()) })
+
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		querier_stats.FromContext(req.Context()).AddFetchedSeries(10)
+
+		if req.URL.Query().Get("query") == "fail" {
+			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "query failed")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	cfg := HandlerConfig{QueryStatsEnabled: true, MaxBodySize: 1024}
+	handler := NewHandler(cfg, roundTripper, log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil, journal)
+
+	for _, query := range []string{"up", "fail"} {
+		req := httptest.NewRequest("GET", "/api/v1/query_range?query="+query+"&start=0&end=3600&step=60", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	data, err := os.ReadFile(journalPath)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var entries []queryjournal.Entry
+	for _, line := range lines {
+		var entry queryjournal.Entry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	assert.Equal(t, "12345", entries[0].Tenant)
+	assert.Equal(t, "/api/v1/query_range", entries[0].Path)
+	assert.Equal(t, "up", entries[0].Query)
+	assert.Equal(t, "0", entries[0].Start)
+	assert.Equal(t, "3600", entries[0].End)
+	assert.Equal(t, "60", entries[0].Step)
+	assert.Equal(t, uint64(10), entries[0].FetchedSeriesCount)
+	assert.Equal(t, queryjournal.StatusSuccess, entries[0].Status)
+	assert.Empty(t, entries[0].Error)
+
+	assert.Equal(t, "fail", entries[1].Query)
+	assert.Equal(t, queryjournal.StatusFailed, entries[1].Status)
+	assert.Contains(t, entries[1].Error, "query failed")
+}
+
+func TestHandler_QueryTimeout(t *testing.T) {
+	var deadline, softDeadline time.Time
+	var hasDeadline, hasSoftDeadline bool
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		deadline, hasDeadline = req.Context().Deadline()
+		softDeadline, hasSoftDeadline = util.QuerySoftDeadlineFromContext(req.Context())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	handler := NewHandler(HandlerConfig{MaxBodySize: 1024}, roundTripper, log.NewNopLogger(), nil, nil, nil)
+
+	// The query should have the timeout set by the client.
+	req := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+	req.Header.Set(util.QueryTimeoutHeader, "10s")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, time.Second)
+	assert.False(t, hasSoftDeadline)
+
+	// The query should have the soft timeout set by the client.
+	req = httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+	req.Header.Set(util.QuerySoftTimeoutHeader, "5")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.True(t, hasSoftDeadline)
+	assert.WithinDuration(t, time.Now().Add(5*time.Second), softDeadline, time.Second)
+
+	// The query should be rejected if the timeout is invalid.
+	req = httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+	req.Header.Set(util.QueryTimeoutHeader, "-1")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+	req.Header.Set(util.QuerySoftTimeoutHeader, "soon")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -13,6 +13,8 @@ import (
 
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/httpgrpc/server"
+
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
 // GrpcRoundTripper is similar to http.RoundTripper, but works with HTTP requests converted to protobuf messages.
@@ -20,13 +22,16 @@ type GrpcRoundTripper interface {
 	RoundTripGRPC(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
 }
 
-func AdaptGrpcRoundTripperToHTTPRoundTripper(r GrpcRoundTripper) http.RoundTripper {
-	return &grpcRoundTripperAdapter{roundTripper: r}
+// AdaptGrpcRoundTripperToHTTPRoundTripper adapts the GrpcRoundTripper into a http.RoundTripper. The responses
+// can be compressed by the queriers with the responseEncodings, in order of preference.
+func AdaptGrpcRoundTripperToHTTPRoundTripper(r GrpcRoundTripper, responseEncodings []string) http.RoundTripper {
+	return &grpcRoundTripperAdapter{roundTripper: r, responseEncodings: responseEncodings}
 }
 
 // This adapter wraps GrpcRoundTripper and converted it into http.RoundTripper
 type grpcRoundTripperAdapter struct {
-	roundTripper GrpcRoundTripper
+	roundTripper      GrpcRoundTripper
+	responseEncodings []string
 }
 
 type buffer struct {
@@ -43,6 +48,7 @@ func (a *grpcRoundTripperAdapter) RoundTrip(r *http.Request) (*http.Response, er
 	if err != nil {
 		return nil, err
 	}
+	httpgrpcutil.SetAcceptedResponseEncodings(req, a.responseEncodings)
 
 	resp, err := a.roundTripper.RoundTripGRPC(r.Context(), req)
 	if err != nil {
@@ -52,6 +58,10 @@ func (a *grpcRoundTripperAdapter) RoundTrip(r *http.Request) (*http.Response, er
 		}
 	}
 
+	if err := httpgrpcutil.DecompressResponse(resp); err != nil {
+		return nil, err
+	}
+
 	httpResp := &http.Response{
 		StatusCode:    int(resp.Code),
 		Body:          &buffer{buff: resp.Body, ReadCloser: io.NopCloser(bytes.NewReader(resp.Body))},
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	shadowResultMatch    = "match"
+	shadowResultMismatch = "mismatch"
+	shadowResultFailed   = "failed"
+	shadowResultDropped  = "dropped"
+)
+
+var (
+	errInvalidShadowURL        = errors.New("the query shadowing URL must be an absolute HTTP or HTTPS URL")
+	errInvalidShadowPercentage = errors.New("the query shadowing percentage must be between 0 and 100")
+	errInvalidShadowInflight   = errors.New("the query shadowing max in-flight requests must be greater than 0")
+)
+
+type ShadowConfig struct {
+	URL                 string        `yaml:"url" category:"experimental"`
+	Percentage          float64       `yaml:"percentage" category:"experimental"`
+	Timeout             time.Duration `yaml:"timeout" category:"experimental"`
+	MaxInflightRequests int           `yaml:"max_inflight_requests" category:"experimental"`
+}
+
+func (cfg *ShadowConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.URL, prefix+"url", "", "Base URL of a secondary Mimir cluster, or query-frontend, where a copy of the queries is sent to compare its responses with the ones of this cluster, e.g. to validate an upgrade. If empty, the query shadowing is disabled.")
+	f.Float64Var(&cfg.Percentage, prefix+"percentage", 0, "Percentage of the queries sent to the secondary downstream, between 0 and 100.")
+	f.DurationVar(&cfg.Timeout, prefix+"timeout", 2*time.Minute, "Timeout of the queries sent to the secondary downstream.")
+	f.IntVar(&cfg.MaxInflightRequests, prefix+"max-inflight-requests", 10, "Max number of queries concurrently sent to the secondary downstream. The queries exceeding the limit are not shadowed.")
+}
+
+func (cfg *ShadowConfig) Validate() error {
+	if cfg.URL == "" {
+		return nil
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidShadowURL
+	}
+	if cfg.Percentage < 0 || cfg.Percentage > 100 {
+		return errInvalidShadowPercentage
+	}
+	if cfg.MaxInflightRequests <= 0 {
+		return errInvalidShadowInflight
+	}
+	return nil
+}
+
+// shadower mirrors a percentage of the queries to a secondary downstream, and compares its responses
+// with the ones of the primary downstream asynchronously. Nil shadower doesn't mirror any query.
+type shadower struct {
+	cfg     ShadowConfig
+	baseURL *url.URL
+	client  *http.Client
+	logger  log.Logger
+
+	// inflight limits the number of concurrent queries to the secondary downstream.
+	inflight chan struct{}
+
+	// sample returns a random number in [0, 100) to decide whether a query is shadowed.
+	sample func() float64
+
+	queries *prometheus.CounterVec
+}
+
+// newShadower makes a new shadower. It returns nil if the query shadowing is disabled.
+func newShadower(cfg ShadowConfig, logger log.Logger, reg prometheus.Registerer) *shadower {
+	if cfg.URL == "" || cfg.Percentage <= 0 {
+		return nil
+	}
+
+	// The URL has already been validated.
+	baseURL, _ := url.Parse(cfg.URL)
+
+	return &shadower{
+		cfg:      cfg,
+		baseURL:  baseURL,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		logger:   log.With(logger, "component", "query-shadowing"),
+		inflight: make(chan struct{}, cfg.MaxInflightRequests),
+		sample:   func() float64 { return rand.Float64() * 100 },
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_shadow_queries_total",
+			Help: "Total number of queries mirrored to the secondary downstream, by result of the comparison of the responses.",
+		}, []string{"result"}),
+	}
+}
+
+// shouldShadow returns whether the query should be mirrored to the secondary downstream.
+func (s *shadower) shouldShadow() bool {
+	return s != nil && s.sample() < s.cfg.Percentage
+}
+
+// shadow sends a copy of the request to the secondary downstream, and compares its response with
+// the primary response in the background. The query is dropped if there are too many in-flight queries.
+func (s *shadower) shadow(r *http.Request, body []byte, queryString url.Values, primaryStatus int, primaryBody []byte) {
+	req, err := s.newRequest(r, body)
+	if err != nil {
+		s.queries.WithLabelValues(shadowResultFailed).Inc()
+		level.Warn(util_log.WithContext(r.Context(), s.logger)).Log("msg", "failed to build shadow query request", "err", err)
+		return
+	}
+
+	select {
+	case s.inflight <- struct{}{}:
+	default:
+		s.queries.WithLabelValues(shadowResultDropped).Inc()
+		return
+	}
+
+	logger := util_log.WithContext(r.Context(), s.logger)
+	go func() {
+		defer func() { <-s.inflight }()
+		s.compare(req, queryString, primaryStatus, primaryBody, logger)
+	}()
+}
+
+// newRequest builds the request to the secondary downstream. It isn't bound to the context of the
+// original request, which is canceled once the primary response has been sent to the client.
+func (s *shadower) newRequest(r *http.Request, body []byte) (*http.Request, error) {
+	u := *s.baseURL
+	u.Path = s.baseURL.Path + r.URL.Path
+	u.RawQuery = r.URL.RawQuery
+
+	req, err := http.NewRequestWithContext(context.Background(), r.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+
+	// The responses are compared uncompressed.
+	req.Header.Del("Accept-Encoding")
+
+	if orgID, err := user.ExtractOrgID(r.Context()); err == nil {
+		req.Header.Set(user.OrgIDHeaderName, orgID)
+	}
+	return req, nil
+}
+
+func (s *shadower) compare(req *http.Request, queryString url.Values, primaryStatus int, primaryBody []byte, logger log.Logger) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.queries.WithLabelValues(shadowResultFailed).Inc()
+		level.Warn(logger).Log("msg", "failed to send shadow query", "err", err)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	shadowBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.queries.WithLabelValues(shadowResultFailed).Inc()
+		level.Warn(logger).Log("msg", "failed to read shadow query response", "err", err)
+		return
+	}
+
+	if reason := compareShadowResponses(primaryStatus, primaryBody, resp.StatusCode, shadowBody); reason != "" {
+		s.queries.WithLabelValues(shadowResultMismatch).Inc()
+
+		logMessage := append([]interface{}{
+			"msg", "shadow query response mismatch",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"reason", reason,
+			"primary_status_code", primaryStatus,
+			"shadow_status_code", resp.StatusCode,
+		}, formatQueryString(queryString)...)
+		level.Warn(logger).Log(logMessage...)
+		return
+	}
+
+	s.queries.WithLabelValues(shadowResultMatch).Inc()
+}
+
+// compareShadowResponses compares the primary and shadow responses, and returns the reason why they
+// differ, or an empty string if they match. JSON responses are compared semantically, regardless of
+// the order of the series in the query results.
+func compareShadowResponses(primaryStatus int, primaryBody []byte, shadowStatus int, shadowBody []byte) string {
+	if primaryStatus != shadowStatus {
+		return "status code"
+	}
+
+	var primary, shadow interface{}
+	if json.Unmarshal(primaryBody, &primary) != nil || json.Unmarshal(shadowBody, &shadow) != nil {
+		if !bytes.Equal(primaryBody, shadowBody) {
+			return "body"
+		}
+		return ""
+	}
+
+	sortQueryResult(primary)
+	sortQueryResult(shadow)
+	if !reflect.DeepEqual(primary, shadow) {
+		return "body"
+	}
+	return ""
+}
+
+// sortQueryResult sorts the series of a Prometheus API query result by their labels, since the
+// order of the series of an instant query result is not deterministic.
+func sortQueryResult(resp interface{}) {
+	obj, ok := resp.(map[string]interface{})
+	if !ok {
+		return
+	}
+	data, ok := obj["data"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	result, ok := data["result"].([]interface{})
+	if !ok {
+		return
+	}
+
+	type keyedSeries struct {
+		key    string
+		series interface{}
+	}
+	sorted := make([]keyedSeries, 0, len(result))
+	for _, series := range result {
+		var key []byte
+		if s, ok := series.(map[string]interface{}); ok {
+			key, _ = json.Marshal(s["metric"])
+		}
+		sorted = append(sorted, keyedSeries{key: string(key), series: series})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+
+	for i := range sorted {
+		result[i] = sorted[i].series
+	}
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestShadowConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *ShadowConfig)
+		expected error
+	}{
+		"should pass with the default config": {
+			setup: func(cfg *ShadowConfig) {},
+		},
+		"should pass with a valid config": {
+			setup: func(cfg *ShadowConfig) {
+				cfg.URL = "http://mimir-canary/prometheus"
+				cfg.Percentage = 10
+			},
+		},
+		"should fail on relative URL": {
+			setup: func(cfg *ShadowConfig) {
+				cfg.URL = "mimir-canary/prometheus"
+			},
+			expected: errInvalidShadowURL,
+		},
+		"should fail on invalid percentage": {
+			setup: func(cfg *ShadowConfig) {
+				cfg.URL = "http://mimir-canary/prometheus"
+				cfg.Percentage = 101
+			},
+			expected: errInvalidShadowPercentage,
+		},
+		"should fail on invalid max in-flight requests": {
+			setup: func(cfg *ShadowConfig) {
+				cfg.URL = "http://mimir-canary/prometheus"
+				cfg.MaxInflightRequests = 0
+			},
+			expected: errInvalidShadowInflight,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := ShadowConfig{Timeout: time.Minute, MaxInflightRequests: 10}
+			testData.setup(&cfg)
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestCompareShadowResponses(t *testing.T) {
+	tests := map[string]struct {
+		primaryStatus, shadowStatus int
+		primaryBody, shadowBody     string
+		expected                    string
+	}{
+		"should match equal responses": {
+			primaryStatus: 200, shadowStatus: 200,
+			primaryBody: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			shadowBody:  `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		},
+		"should match responses with different formatting and series order": {
+			primaryStatus: 200, shadowStatus: 200,
+			primaryBody: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1,"1"]},{"metric":{"job":"b"},"value":[1,"2"]}]}}`,
+			shadowBody:  `{"data": {"result": [{"value": [1, "2"], "metric": {"job": "b"}}, {"metric": {"job": "a"}, "value": [1, "1"]}], "resultType": "vector"}, "status": "success"}`,
+		},
+		"should not match responses with different values": {
+			primaryStatus: 200, shadowStatus: 200,
+			primaryBody: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1,"1"]}]}}`,
+			shadowBody:  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1,"2"]}]}}`,
+			expected:    "body",
+		},
+		"should not match responses with different status code": {
+			primaryStatus: 200, shadowStatus: 500,
+			primaryBody: `{}`,
+			shadowBody:  `{}`,
+			expected:    "status code",
+		},
+		"should compare non-JSON responses byte by byte": {
+			primaryStatus: 200, shadowStatus: 200,
+			primaryBody: `not json`,
+			shadowBody:  `not JSON`,
+			expected:    "body",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, compareShadowResponses(testData.primaryStatus, []byte(testData.primaryBody), testData.shadowStatus, []byte(testData.shadowBody)))
+		})
+	}
+}
+
+func TestHandler_QueryShadowing(t *testing.T) {
+	received := make(chan *http.Request, 2)
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		if r.URL.Query().Get("query") == "mismatch" {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"2"]}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"1"]}]}}`))
+	}))
+	t.Cleanup(secondary.Close)
+
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"1"]}]}}`)),
+		}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	cfg := HandlerConfig{
+		MaxBodySize: 1024,
+		Shadowing:   ShadowConfig{URL: secondary.URL + "/prometheus", Percentage: 100, Timeout: time.Minute, MaxInflightRequests: 1},
+	}
+	handler := NewHandler(cfg, roundTripper, log.NewNopLogger(), reg, nil, nil)
+
+	for _, query := range []string{"match", "mismatch"} {
+		req := httptest.NewRequest("GET", "/api/v1/query?query="+query, nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		// The client should receive the primary response.
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"value":[1,"1"]`)
+
+		// The shadow query should be sent to the secondary downstream, with the same tenant.
+		select {
+		case shadowReq := <-received:
+			assert.Equal(t, "/prometheus/api/v1/query", shadowReq.URL.Path)
+			assert.Equal(t, query, shadowReq.URL.Query().Get("query"))
+			assert.Equal(t, "12345", shadowReq.Header.Get(user.OrgIDHeaderName))
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "shadow query not received")
+		}
+
+		// Wait until the comparison has completed before sending the next query, so that it's not dropped.
+		require.Eventually(t, func() bool {
+			return len(handler.(*Handler).shadower.inflight) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_shadow_queries_total Total number of queries mirrored to the secondary downstream, by result of the comparison of the responses.
+		# TYPE cortex_frontend_shadow_queries_total counter
+		cortex_frontend_shadow_queries_total{result="match"} 1
+		cortex_frontend_shadow_queries_total{result="mismatch"} 1
+	`), "cortex_frontend_shadow_queries_total"))
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
@@ -102,6 +103,7 @@ type frontendRequest struct {
 
 	enqueue  chan enqueueResult
 	response chan *frontendv2pb.QueryResultRequest
+	requeue  chan struct{}
 }
 
 type enqueueStatus int
@@ -215,6 +217,7 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 		// even if this goroutine goes away due to client context cancellation.
 		enqueue:  make(chan enqueueResult, 1),
 		response: make(chan *frontendv2pb.QueryResultRequest, 1),
+		requeue:  make(chan struct{}, 1),
 	}
 
 	f.requests.put(freq)
@@ -257,6 +260,12 @@ enqueueAgain:
 		}
 		return nil, ctx.Err()
 
+	case <-freq.requeue:
+		// The query-scheduler which had the request in its queue has been restarted
+		// and asked us to enqueue the request again.
+		level.Debug(f.log).Log("msg", "enqueuing again request after query-scheduler restart", "queryID", freq.queryID, "user", userID)
+		goto enqueueAgain
+
 	case resp := <-freq.response:
 		if stats.ShouldTrackHTTPGRPCResponse(resp.HttpResponse) {
 			stats := stats.FromContext(ctx)
@@ -290,6 +299,29 @@ func (f *Frontend) QueryResult(ctx context.Context, qrReq *frontendv2pb.QueryRes
 	return &frontendv2pb.QueryResultResponse{}, nil
 }
 
+// RequeueQuery is called by the query-scheduler after a restart, for each request which was in its queue
+// before the restart, to ask the frontend to enqueue the request again.
+func (f *Frontend) RequeueQuery(ctx context.Context, rqReq *frontendv2pb.RequeueQueryRequest) (*frontendv2pb.RequeueQueryResponse, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := tenant.JoinTenantIDs(tenantIDs)
+
+	req := f.requests.get(rqReq.QueryID)
+	// The request may have been completed in the meanwhile, or the queryID may belong to a different
+	// request if this frontend has restarted too, so we verify both the user and the request fingerprint.
+	if req != nil && req.userID == userID && schedulerpb.RequestFingerprint(req.request) == rqReq.RequestFingerprint {
+		select {
+		case req.requeue <- struct{}{}:
+		default:
+			// A requeue is already pending for this request.
+		}
+	}
+
+	return &frontendv2pb.RequeueQueryResponse{}, nil
+}
+
 // CheckReady determines if the query frontend is ready.  Function parameters/return
 // chosen to match the same method in the ingester
 func (f *Frontend) CheckReady(_ context.Context) error {
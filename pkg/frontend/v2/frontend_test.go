@@ -203,6 +203,46 @@ func TestFrontendRetryEnqueue(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFrontendRequeueAfterSchedulerRestart(t *testing.T) {
+	const (
+		body   = "all fine here"
+		userID = "test"
+	)
+
+	enqueues := atomic.NewInt64(0)
+	req := &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"}
+
+	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		if enqueues.Inc() == 1 {
+			// Simulate a query-scheduler restart: the request is lost from the queue, and the restarted
+			// query-scheduler asks the frontend to enqueue it again.
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+
+				// Requests with a different tenant or fingerprint are ignored.
+				_, _ = f.RequeueQuery(user.InjectOrgID(context.Background(), "another"), &frontendv2pb.RequeueQueryRequest{QueryID: msg.QueryID, RequestFingerprint: schedulerpb.RequestFingerprint(req)})
+				_, _ = f.RequeueQuery(user.InjectOrgID(context.Background(), userID), &frontendv2pb.RequeueQueryRequest{QueryID: msg.QueryID, RequestFingerprint: 0})
+				_, _ = f.RequeueQuery(user.InjectOrgID(context.Background(), userID), &frontendv2pb.RequeueQueryRequest{QueryID: msg.QueryID, RequestFingerprint: schedulerpb.RequestFingerprint(req)})
+			}()
+
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		}
+
+		go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{
+			Code: 200,
+			Body: []byte(body),
+		})
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+
+	resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), req)
+	require.NoError(t, err)
+	require.Equal(t, int32(200), resp.Code)
+	require.Equal(t, []byte(body), resp.Body)
+	require.Equal(t, int64(2), enqueues.Load())
+}
+
 func TestFrontendTooManyRequests(t *testing.T) {
 	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
 		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT}
@@ -125,37 +125,128 @@ func (m *QueryResultResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_QueryResultResponse proto.InternalMessageInfo
 
+type RequeueQueryRequest struct {
+	QueryID            uint64 `protobuf:"varint,1,opt,name=queryID,proto3" json:"queryID,omitempty"`
+	RequestFingerprint uint64 `protobuf:"varint,2,opt,name=requestFingerprint,proto3" json:"requestFingerprint,omitempty"`
+}
+
+func (m *RequeueQueryRequest) Reset()      { *m = RequeueQueryRequest{} }
+func (*RequeueQueryRequest) ProtoMessage() {}
+func (*RequeueQueryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{2}
+}
+func (m *RequeueQueryRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RequeueQueryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RequeueQueryRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RequeueQueryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequeueQueryRequest.Merge(m, src)
+}
+func (m *RequeueQueryRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *RequeueQueryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequeueQueryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequeueQueryRequest proto.InternalMessageInfo
+
+func (m *RequeueQueryRequest) GetQueryID() uint64 {
+	if m != nil {
+		return m.QueryID
+	}
+	return 0
+}
+
+func (m *RequeueQueryRequest) GetRequestFingerprint() uint64 {
+	if m != nil {
+		return m.RequestFingerprint
+	}
+	return 0
+}
+
+type RequeueQueryResponse struct {
+}
+
+func (m *RequeueQueryResponse) Reset()      { *m = RequeueQueryResponse{} }
+func (*RequeueQueryResponse) ProtoMessage() {}
+func (*RequeueQueryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{3}
+}
+func (m *RequeueQueryResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RequeueQueryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RequeueQueryResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RequeueQueryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequeueQueryResponse.Merge(m, src)
+}
+func (m *RequeueQueryResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *RequeueQueryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequeueQueryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequeueQueryResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*QueryResultRequest)(nil), "frontendv2pb.QueryResultRequest")
 	proto.RegisterType((*QueryResultResponse)(nil), "frontendv2pb.QueryResultResponse")
+	proto.RegisterType((*RequeueQueryRequest)(nil), "frontendv2pb.RequeueQueryRequest")
+	proto.RegisterType((*RequeueQueryResponse)(nil), "frontendv2pb.RequeueQueryResponse")
 }
 
 func init() { proto.RegisterFile("frontend.proto", fileDescriptor_eca3873955a29cfe) }
 
 var fileDescriptor_eca3873955a29cfe = []byte{
-	// 343 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x91, 0xbd, 0x4e, 0x3a, 0x41,
-	0x14, 0xc5, 0x67, 0xfe, 0x7f, 0x3f, 0x92, 0x81, 0x58, 0x8c, 0x1f, 0xd9, 0x50, 0xdc, 0xe0, 0x56,
-	0x54, 0x3b, 0x09, 0x1a, 0x0b, 0x4b, 0x62, 0x88, 0x76, 0xb2, 0x52, 0xd9, 0x2d, 0x38, 0x2c, 0x2b,
-	0xee, 0xce, 0x32, 0x33, 0x0b, 0xa1, 0xf3, 0x09, 0x8c, 0x8f, 0xe1, 0xa3, 0x58, 0x52, 0x52, 0xca,
-	0xd0, 0x58, 0xf2, 0x08, 0x66, 0x77, 0x80, 0x40, 0x4c, 0x6c, 0x26, 0xf7, 0xe6, 0x9e, 0x5f, 0xee,
-	0xb9, 0x67, 0xc8, 0x51, 0x4f, 0x8a, 0x44, 0xf3, 0xe4, 0xc9, 0x4b, 0xa5, 0xd0, 0x82, 0x96, 0xd7,
-	0xfd, 0xa8, 0x9e, 0x76, 0x2a, 0x27, 0xa1, 0x08, 0x45, 0x31, 0x60, 0x79, 0x65, 0x35, 0x95, 0xcb,
-	0x30, 0xd2, 0xfd, 0xac, 0xe3, 0x75, 0x45, 0xcc, 0xc6, 0x3c, 0x18, 0xf1, 0xb1, 0x90, 0x03, 0xc5,
-	0xba, 0x22, 0x8e, 0x45, 0xc2, 0xfa, 0x5a, 0xa7, 0xa1, 0x4c, 0xbb, 0x9b, 0x62, 0x45, 0x5d, 0x6d,
-	0x51, 0xa1, 0x0c, 0x7a, 0x41, 0x12, 0xb0, 0x38, 0x8a, 0x23, 0xc9, 0xd2, 0x41, 0xc8, 0x86, 0x19,
-	0x97, 0x11, 0x97, 0x4c, 0xe9, 0x40, 0x2b, 0xfb, 0x5a, 0xce, 0x7d, 0xc3, 0x84, 0xb6, 0x32, 0x2e,
-	0x27, 0x3e, 0x57, 0xd9, 0x8b, 0xf6, 0xf9, 0x30, 0xe3, 0x4a, 0x53, 0x87, 0x1c, 0xe6, 0xcc, 0xe4,
-	0xee, 0xc6, 0xc1, 0x55, 0x5c, 0xdb, 0xf3, 0xd7, 0x2d, 0xbd, 0x26, 0xe5, 0x7c, 0xb5, 0xcf, 0x55,
-	0x2a, 0x12, 0xc5, 0x9d, 0x7f, 0x55, 0x5c, 0x2b, 0xd5, 0xcf, 0xbc, 0x8d, 0x9f, 0xdb, 0x76, 0xfb,
-	0x7e, 0x3d, 0xf5, 0x77, 0xb4, 0xd4, 0x25, 0xfb, 0xc5, 0x6e, 0xe7, 0x7f, 0x01, 0x95, 0x3d, 0xeb,
-	0xe4, 0x21, 0x7f, 0x7d, 0x3b, 0x72, 0x4f, 0xc9, 0xf1, 0x8e, 0x1f, 0x8b, 0xd6, 0x9f, 0x09, 0x6d,
-	0xae, 0xb2, 0x6b, 0x0a, 0xd9, 0xb2, 0xf7, 0xd0, 0x36, 0x29, 0x6d, 0x89, 0x69, 0xd5, 0xdb, 0xce,
-	0xd7, 0xfb, 0x7d, 0x57, 0xe5, 0xfc, 0x0f, 0x85, 0xdd, 0xe4, 0xa2, 0x46, 0x63, 0x3a, 0x07, 0x34,
-	0x9b, 0x03, 0x5a, 0xce, 0x01, 0xbf, 0x1a, 0xc0, 0x1f, 0x06, 0xf0, 0xa7, 0x01, 0x3c, 0x35, 0x80,
-	0xbf, 0x0c, 0xe0, 0x6f, 0x03, 0x68, 0x69, 0x00, 0xbf, 0x2f, 0x00, 0x4d, 0x17, 0x80, 0x66, 0x0b,
-	0x40, 0x8f, 0x3b, 0x7f, 0xdb, 0x39, 0x28, 0xe2, 0xbd, 0xf8, 0x09, 0x00, 0x00, 0xff, 0xff, 0xfc,
-	0x56, 0xe4, 0xc6, 0x02, 0x02, 0x00, 0x00,
+	// 398 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0xb1, 0x6e, 0xe2, 0x30,
+	0x1c, 0xc6, 0xe3, 0x3b, 0xee, 0x4e, 0x32, 0xd1, 0x0d, 0x86, 0x43, 0x51, 0x06, 0x8b, 0xcb, 0xc4,
+	0x14, 0x4b, 0xb4, 0xea, 0xd0, 0x11, 0x55, 0xa8, 0xdd, 0x4a, 0x8a, 0x54, 0xa9, 0x4b, 0x15, 0xa8,
+	0x09, 0x11, 0x4d, 0x1c, 0x1c, 0x07, 0xc4, 0xd6, 0x27, 0xa8, 0xfa, 0x18, 0x7d, 0x8f, 0x2e, 0x1d,
+	0x19, 0x19, 0x4b, 0x58, 0x3a, 0xf2, 0x08, 0x55, 0xe2, 0x04, 0x25, 0x2d, 0xa2, 0x8b, 0x65, 0xeb,
+	0xfb, 0xbe, 0x7c, 0xbf, 0xfc, 0x6d, 0xf8, 0x77, 0xc4, 0x99, 0x2f, 0xa8, 0x7f, 0x67, 0x06, 0x9c,
+	0x09, 0x86, 0xd4, 0xfc, 0x3c, 0x6b, 0x07, 0x03, 0xbd, 0xee, 0x30, 0x87, 0xa5, 0x02, 0x49, 0x76,
+	0xd2, 0xa3, 0x1f, 0x3b, 0xae, 0x18, 0x47, 0x03, 0x73, 0xc8, 0x3c, 0x32, 0xa7, 0xf6, 0x8c, 0xce,
+	0x19, 0x9f, 0x84, 0x64, 0xc8, 0x3c, 0x8f, 0xf9, 0x64, 0x2c, 0x44, 0xe0, 0xf0, 0x60, 0xb8, 0xdb,
+	0x64, 0xa9, 0x93, 0x42, 0xca, 0xe1, 0xf6, 0xc8, 0xf6, 0x6d, 0xe2, 0xb9, 0x9e, 0xcb, 0x49, 0x30,
+	0x71, 0xc8, 0x34, 0xa2, 0xdc, 0xa5, 0x9c, 0x84, 0xc2, 0x16, 0xa1, 0x5c, 0x65, 0xce, 0x78, 0x04,
+	0x10, 0xf5, 0x22, 0xca, 0x17, 0x16, 0x0d, 0xa3, 0x7b, 0x61, 0xd1, 0x69, 0x44, 0x43, 0x81, 0x34,
+	0xf8, 0x27, 0xc9, 0x2c, 0x2e, 0xce, 0x34, 0xd0, 0x04, 0xad, 0x8a, 0x95, 0x1f, 0xd1, 0x29, 0x54,
+	0x93, 0x6a, 0x8b, 0x86, 0x01, 0xf3, 0x43, 0xaa, 0xfd, 0x68, 0x82, 0x56, 0xb5, 0xdd, 0x30, 0x77,
+	0x3c, 0xe7, 0xfd, 0xfe, 0x65, 0xae, 0x5a, 0x25, 0x2f, 0x32, 0xe0, 0xaf, 0xb4, 0x5b, 0xfb, 0x99,
+	0x86, 0x54, 0x53, 0x92, 0x5c, 0x25, 0xab, 0x25, 0x25, 0xe3, 0x1f, 0xac, 0x95, 0x78, 0x64, 0xd4,
+	0xb8, 0x85, 0xb5, 0x94, 0x2d, 0xa2, 0x99, 0xfa, 0x1d, 0xa7, 0x09, 0x11, 0x97, 0xa6, 0xae, 0xeb,
+	0x3b, 0x94, 0x07, 0xdc, 0xf5, 0x45, 0x4a, 0x5b, 0xb1, 0xf6, 0x28, 0x46, 0x03, 0xd6, 0xcb, 0x05,
+	0xb2, 0xb8, 0xfd, 0x02, 0x20, 0xea, 0x66, 0xb7, 0xd6, 0x65, 0xbc, 0x27, 0x27, 0x89, 0xfa, 0xb0,
+	0x5a, 0xc0, 0x44, 0x4d, 0xb3, 0x78, 0xb3, 0xe6, 0xd7, 0x89, 0xea, 0xff, 0x0f, 0x38, 0xb2, 0x7f,
+	0x54, 0xd0, 0x35, 0x54, 0x8b, 0x10, 0xe8, 0x53, 0x68, 0xcf, 0x04, 0x74, 0xe3, 0x90, 0x25, 0xff,
+	0x70, 0xa7, 0xb3, 0x5c, 0x63, 0x65, 0xb5, 0xc6, 0xca, 0x76, 0x8d, 0xc1, 0x43, 0x8c, 0xc1, 0x73,
+	0x8c, 0xc1, 0x6b, 0x8c, 0xc1, 0x32, 0xc6, 0xe0, 0x2d, 0xc6, 0xe0, 0x3d, 0xc6, 0xca, 0x36, 0xc6,
+	0xe0, 0x69, 0x83, 0x95, 0xe5, 0x06, 0x2b, 0xab, 0x0d, 0x56, 0x6e, 0x4a, 0xcf, 0x75, 0xf0, 0x3b,
+	0x7d, 0x31, 0x47, 0x1f, 0x03, 0x00, 0x3d, 0x64, 0xa7, 0x31, 0xd5, 0x02, 0x00, 0x00,
 }
 
 func (this *QueryResultRequest) Equal(that interface{}) bool {
@@ -209,6 +300,54 @@ func (this *QueryResultResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *RequeueQueryRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RequeueQueryRequest)
+	if !ok {
+		that2, ok := that.(RequeueQueryRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.QueryID != that1.QueryID {
+		return false
+	}
+	if this.RequestFingerprint != that1.RequestFingerprint {
+		return false
+	}
+	return true
+}
+func (this *RequeueQueryResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RequeueQueryResponse)
+	if !ok {
+		that2, ok := that.(RequeueQueryResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
 func (this *QueryResultRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -234,6 +373,26 @@ func (this *QueryResultResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *RequeueQueryRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&frontendv2pb.RequeueQueryRequest{")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	s = append(s, "RequestFingerprint: "+fmt.Sprintf("%#v", this.RequestFingerprint)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *RequeueQueryResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&frontendv2pb.RequeueQueryResponse{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringFrontend(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -256,6 +415,7 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type FrontendForQuerierClient interface {
 	QueryResult(ctx context.Context, in *QueryResultRequest, opts ...grpc.CallOption) (*QueryResultResponse, error)
+	RequeueQuery(ctx context.Context, in *RequeueQueryRequest, opts ...grpc.CallOption) (*RequeueQueryResponse, error)
 }
 
 type frontendForQuerierClient struct {
@@ -275,9 +435,19 @@ func (c *frontendForQuerierClient) QueryResult(ctx context.Context, in *QueryRes
 	return out, nil
 }
 
+func (c *frontendForQuerierClient) RequeueQuery(ctx context.Context, in *RequeueQueryRequest, opts ...grpc.CallOption) (*RequeueQueryResponse, error) {
+	out := new(RequeueQueryResponse)
+	err := c.cc.Invoke(ctx, "/frontendv2pb.FrontendForQuerier/RequeueQuery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FrontendForQuerierServer is the server API for FrontendForQuerier service.
 type FrontendForQuerierServer interface {
 	QueryResult(context.Context, *QueryResultRequest) (*QueryResultResponse, error)
+	RequeueQuery(context.Context, *RequeueQueryRequest) (*RequeueQueryResponse, error)
 }
 
 // UnimplementedFrontendForQuerierServer can be embedded to have forward compatible implementations.
@@ -287,6 +457,9 @@ type UnimplementedFrontendForQuerierServer struct {
 func (*UnimplementedFrontendForQuerierServer) QueryResult(ctx context.Context, req *QueryResultRequest) (*QueryResultResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryResult not implemented")
 }
+func (*UnimplementedFrontendForQuerierServer) RequeueQuery(ctx context.Context, req *RequeueQueryRequest) (*RequeueQueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequeueQuery not implemented")
+}
 
 func RegisterFrontendForQuerierServer(s *grpc.Server, srv FrontendForQuerierServer) {
 	s.RegisterService(&_FrontendForQuerier_serviceDesc, srv)
@@ -310,6 +483,24 @@ func _FrontendForQuerier_QueryResult_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FrontendForQuerier_RequeueQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequeueQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendForQuerierServer).RequeueQuery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/frontendv2pb.FrontendForQuerier/RequeueQuery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendForQuerierServer).RequeueQuery(ctx, req.(*RequeueQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _FrontendForQuerier_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "frontendv2pb.FrontendForQuerier",
 	HandlerType: (*FrontendForQuerierServer)(nil),
@@ -318,6 +509,10 @@ var _FrontendForQuerier_serviceDesc = grpc.ServiceDesc{
 			MethodName: "QueryResult",
 			Handler:    _FrontendForQuerier_QueryResult_Handler,
 		},
+		{
+			MethodName: "RequeueQuery",
+			Handler:    _FrontendForQuerier_RequeueQuery_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "frontend.proto",
@@ -398,6 +593,62 @@ func (m *QueryResultResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *RequeueQueryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RequeueQueryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RequeueQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.RequestFingerprint != 0 {
+		i = encodeVarintFrontend(dAtA, i, uint64(m.RequestFingerprint))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.QueryID != 0 {
+		i = encodeVarintFrontend(dAtA, i, uint64(m.QueryID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RequeueQueryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RequeueQueryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RequeueQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintFrontend(dAtA []byte, offset int, v uint64) int {
 	offset -= sovFrontend(v)
 	base := offset
@@ -438,6 +689,30 @@ func (m *QueryResultResponse) Size() (n int) {
 	return n
 }
 
+func (m *RequeueQueryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.QueryID != 0 {
+		n += 1 + sovFrontend(uint64(m.QueryID))
+	}
+	if m.RequestFingerprint != 0 {
+		n += 1 + sovFrontend(uint64(m.RequestFingerprint))
+	}
+	return n
+}
+
+func (m *RequeueQueryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
 func sovFrontend(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -465,6 +740,26 @@ func (this *QueryResultResponse) String() string {
 	}, "")
 	return s
 }
+func (this *RequeueQueryRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RequeueQueryRequest{`,
+		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
+		`RequestFingerprint:` + fmt.Sprintf("%v", this.RequestFingerprint) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RequeueQueryResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RequeueQueryResponse{`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringFrontend(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -670,6 +965,150 @@ func (m *QueryResultResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *RequeueQueryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFrontend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RequeueQueryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RequeueQueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RequestFingerprint", wireType)
+			}
+			m.RequestFingerprint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RequestFingerprint |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFrontend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RequeueQueryResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFrontend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RequeueQueryResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RequeueQueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFrontend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipFrontend(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
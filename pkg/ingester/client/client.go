@@ -38,7 +38,8 @@ type closableHealthAndIngesterClient struct {
 
 // MakeIngesterClient makes a new IngesterClient
 func MakeIngesterClient(addr string, cfg Config) (HealthAndIngesterClient, error) {
-	dialOpts, err := cfg.GRPCClientConfig.DialOption(grpcclient.Instrument(ingesterClientRequestDuration))
+	unary, stream := grpcclient.Instrument(ingesterClientRequestDuration)
+	dialOpts, err := cfg.GRPCClientConfig.DialOption(append(unary, cfg.UnaryClientInterceptors...), append(stream, cfg.StreamClientInterceptors...))
 	if err != nil {
 		return nil, err
 	}
@@ -60,6 +61,10 @@ func (c *closableHealthAndIngesterClient) Close() error {
 // Config is the configuration struct for the ingester client
 type Config struct {
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between distributors and ingesters."`
+
+	// This configuration is injected internally.
+	UnaryClientInterceptors  []grpc.UnaryClientInterceptor  `yaml:"-"`
+	StreamClientInterceptors []grpc.StreamClientInterceptor `yaml:"-"`
 }
 
 // RegisterFlags registers configuration settings used by the ingester client config.
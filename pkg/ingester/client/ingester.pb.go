@@ -648,6 +648,8 @@ type QueryRequest struct {
 	StartTimestampMs int64           `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64           `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         []*LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers,omitempty"`
+	MaxSeriesHint    int64           `protobuf:"varint,4,opt,name=max_series_hint,json=maxSeriesHint,proto3" json:"max_series_hint,omitempty"`
+	MaxChunksHint    int64           `protobuf:"varint,5,opt,name=max_chunks_hint,json=maxChunksHint,proto3" json:"max_chunks_hint,omitempty"`
 }
 
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
@@ -703,6 +705,20 @@ func (m *QueryRequest) GetMatchers() []*LabelMatcher {
 	return nil
 }
 
+func (m *QueryRequest) GetMaxSeriesHint() int64 {
+	if m != nil {
+		return m.MaxSeriesHint
+	}
+	return 0
+}
+
+func (m *QueryRequest) GetMaxChunksHint() int64 {
+	if m != nil {
+		return m.MaxChunksHint
+	}
+	return 0
+}
+
 type ExemplarQueryRequest struct {
 	StartTimestampMs int64            `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64            `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
@@ -1308,6 +1324,148 @@ func (m *UsersStatsResponse) GetStats() []*UserIDStatsResponse {
 	return nil
 }
 
+type HeadStatsRequest struct {
+}
+
+func (m *HeadStatsRequest) Reset()      { *m = HeadStatsRequest{} }
+func (*HeadStatsRequest) ProtoMessage() {}
+func (*HeadStatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{24}
+}
+func (m *HeadStatsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HeadStatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HeadStatsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HeadStatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeadStatsRequest.Merge(m, src)
+}
+func (m *HeadStatsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *HeadStatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeadStatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeadStatsRequest proto.InternalMessageInfo
+
+type HeadStatsResponse struct {
+	NumSeries              uint64 `protobuf:"varint,1,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
+	NumChunks              uint64 `protobuf:"varint,2,opt,name=num_chunks,json=numChunks,proto3" json:"num_chunks,omitempty"`
+	MinTimeMs              int64  `protobuf:"varint,3,opt,name=min_time_ms,json=minTimeMs,proto3" json:"min_time_ms,omitempty"`
+	MaxTimeMs              int64  `protobuf:"varint,4,opt,name=max_time_ms,json=maxTimeMs,proto3" json:"max_time_ms,omitempty"`
+	OooMinTimeMs           int64  `protobuf:"varint,5,opt,name=ooo_min_time_ms,json=oooMinTimeMs,proto3" json:"ooo_min_time_ms,omitempty"`
+	OooMaxTimeMs           int64  `protobuf:"varint,6,opt,name=ooo_max_time_ms,json=oooMaxTimeMs,proto3" json:"ooo_max_time_ms,omitempty"`
+	OutOfOrderTimeWindowMs int64  `protobuf:"varint,7,opt,name=out_of_order_time_window_ms,json=outOfOrderTimeWindowMs,proto3" json:"out_of_order_time_window_ms,omitempty"`
+	WalSizeBytes           int64  `protobuf:"varint,8,opt,name=wal_size_bytes,json=walSizeBytes,proto3" json:"wal_size_bytes,omitempty"`
+	WblSizeBytes           int64  `protobuf:"varint,9,opt,name=wbl_size_bytes,json=wblSizeBytes,proto3" json:"wbl_size_bytes,omitempty"`
+}
+
+func (m *HeadStatsResponse) Reset()      { *m = HeadStatsResponse{} }
+func (*HeadStatsResponse) ProtoMessage() {}
+func (*HeadStatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{25}
+}
+func (m *HeadStatsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HeadStatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HeadStatsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HeadStatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeadStatsResponse.Merge(m, src)
+}
+func (m *HeadStatsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *HeadStatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeadStatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeadStatsResponse proto.InternalMessageInfo
+
+func (m *HeadStatsResponse) GetNumSeries() uint64 {
+	if m != nil {
+		return m.NumSeries
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetNumChunks() uint64 {
+	if m != nil {
+		return m.NumChunks
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetMinTimeMs() int64 {
+	if m != nil {
+		return m.MinTimeMs
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetMaxTimeMs() int64 {
+	if m != nil {
+		return m.MaxTimeMs
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetOooMinTimeMs() int64 {
+	if m != nil {
+		return m.OooMinTimeMs
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetOooMaxTimeMs() int64 {
+	if m != nil {
+		return m.OooMaxTimeMs
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetOutOfOrderTimeWindowMs() int64 {
+	if m != nil {
+		return m.OutOfOrderTimeWindowMs
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetWalSizeBytes() int64 {
+	if m != nil {
+		return m.WalSizeBytes
+	}
+	return 0
+}
+
+func (m *HeadStatsResponse) GetWblSizeBytes() int64 {
+	if m != nil {
+		return m.WblSizeBytes
+	}
+	return 0
+}
+
 type MetricsForLabelMatchersRequest struct {
 	StartTimestampMs int64            `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64            `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
@@ -1317,7 +1475,7 @@ type MetricsForLabelMatchersRequest struct {
 func (m *MetricsForLabelMatchersRequest) Reset()      { *m = MetricsForLabelMatchersRequest{} }
 func (*MetricsForLabelMatchersRequest) ProtoMessage() {}
 func (*MetricsForLabelMatchersRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{24}
+	return fileDescriptor_60f6df4f3586b478, []int{26}
 }
 func (m *MetricsForLabelMatchersRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1374,7 +1532,7 @@ type MetricsForLabelMatchersResponse struct {
 func (m *MetricsForLabelMatchersResponse) Reset()      { *m = MetricsForLabelMatchersResponse{} }
 func (*MetricsForLabelMatchersResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{25}
+	return fileDescriptor_60f6df4f3586b478, []int{27}
 }
 func (m *MetricsForLabelMatchersResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1416,7 +1574,7 @@ type MetricsMetadataRequest struct {
 func (m *MetricsMetadataRequest) Reset()      { *m = MetricsMetadataRequest{} }
 func (*MetricsMetadataRequest) ProtoMessage() {}
 func (*MetricsMetadataRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+	return fileDescriptor_60f6df4f3586b478, []int{28}
 }
 func (m *MetricsMetadataRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1452,7 +1610,7 @@ type MetricsMetadataResponse struct {
 func (m *MetricsMetadataResponse) Reset()      { *m = MetricsMetadataResponse{} }
 func (*MetricsMetadataResponse) ProtoMessage() {}
 func (*MetricsMetadataResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{27}
+	return fileDescriptor_60f6df4f3586b478, []int{29}
 }
 func (m *MetricsMetadataResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1498,7 +1656,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{28}
+	return fileDescriptor_60f6df4f3586b478, []int{30}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1558,7 +1716,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{29}
+	return fileDescriptor_60f6df4f3586b478, []int{31}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1622,7 +1780,7 @@ type LabelMatchers struct {
 func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
 func (*LabelMatchers) ProtoMessage() {}
 func (*LabelMatchers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{30}
+	return fileDescriptor_60f6df4f3586b478, []int{32}
 }
 func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1667,7 +1825,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{31}
+	return fileDescriptor_60f6df4f3586b478, []int{33}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1727,7 +1885,7 @@ type TimeSeriesFile struct {
 func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
 func (*TimeSeriesFile) ProtoMessage() {}
 func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{32}
+	return fileDescriptor_60f6df4f3586b478, []int{34}
 }
 func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1813,6 +1971,8 @@ func init() {
 	proto.RegisterType((*UserStatsResponse)(nil), "cortex.UserStatsResponse")
 	proto.RegisterType((*UserIDStatsResponse)(nil), "cortex.UserIDStatsResponse")
 	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
+	proto.RegisterType((*HeadStatsRequest)(nil), "cortex.HeadStatsRequest")
+	proto.RegisterType((*HeadStatsResponse)(nil), "cortex.HeadStatsResponse")
 	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
 	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
 	proto.RegisterType((*MetricsMetadataRequest)(nil), "cortex.MetricsMetadataRequest")
@@ -1827,110 +1987,122 @@ func init() {
 func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
 
 var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1640 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x6f, 0xdb, 0x46,
-	0x16, 0xd7, 0x48, 0xb2, 0x6c, 0x3d, 0xd9, 0x8a, 0x3c, 0x8a, 0x6d, 0x85, 0x59, 0xd3, 0x5a, 0x2e,
-	0x92, 0xd5, 0xee, 0x26, 0xf2, 0x47, 0xb2, 0x40, 0x12, 0x14, 0x08, 0x64, 0x5b, 0x89, 0x5d, 0x47,
-	0x72, 0x42, 0xd9, 0x8d, 0x51, 0xa0, 0x20, 0x28, 0x69, 0x6c, 0x13, 0x16, 0x29, 0x85, 0xa4, 0x0a,
-	0xfb, 0x56, 0xa0, 0xf7, 0xb6, 0xe8, 0xa9, 0xa7, 0x02, 0xbd, 0xf5, 0x58, 0x14, 0x28, 0x7a, 0xeb,
-	0x39, 0x97, 0x02, 0x39, 0x06, 0x3d, 0x04, 0x8d, 0x73, 0x69, 0x6f, 0xf9, 0x13, 0x0a, 0xce, 0x0c,
-	0x29, 0x92, 0xa2, 0x3f, 0x52, 0x24, 0x39, 0x49, 0xf3, 0xde, 0x6f, 0x7e, 0xf3, 0xbe, 0x38, 0xef,
-	0x91, 0x90, 0xd5, 0x8c, 0x3d, 0x62, 0xd9, 0xc4, 0x2c, 0xf7, 0xcc, 0xae, 0xdd, 0xc5, 0xa9, 0x56,
-	0xd7, 0xb4, 0xc9, 0xa1, 0x70, 0x7d, 0x4f, 0xb3, 0xf7, 0xfb, 0xcd, 0x72, 0xab, 0xab, 0xcf, 0xef,
-	0x75, 0xf7, 0xba, 0xf3, 0x54, 0xdd, 0xec, 0xef, 0xd2, 0x15, 0x5d, 0xd0, 0x7f, 0x6c, 0x9b, 0xb0,
-	0xe0, 0x87, 0x9b, 0xea, 0xae, 0x6a, 0xa8, 0xf3, 0xba, 0xa6, 0x6b, 0xe6, 0x7c, 0xef, 0x60, 0x8f,
-	0xfd, 0xeb, 0x35, 0xd9, 0x2f, 0xdb, 0x21, 0xd5, 0x41, 0x78, 0xa0, 0x36, 0x49, 0xa7, 0xae, 0xea,
-	0xc4, 0xaa, 0x18, 0xed, 0x8f, 0xd4, 0x4e, 0x9f, 0x58, 0x32, 0x79, 0xd2, 0x27, 0x96, 0x8d, 0x17,
-	0x60, 0x4c, 0x57, 0xed, 0xd6, 0x3e, 0x31, 0xad, 0x02, 0x2a, 0x26, 0x4a, 0x99, 0xa5, 0x8b, 0x65,
-	0x66, 0x59, 0x99, 0xee, 0xaa, 0x31, 0xa5, 0xec, 0xa1, 0xa4, 0x35, 0xb8, 0x1c, 0xc9, 0x67, 0xf5,
-	0xba, 0x86, 0x45, 0xf0, 0x7f, 0x60, 0x44, 0xb3, 0x89, 0xee, 0xb2, 0xe5, 0x03, 0x6c, 0x1c, 0xcb,
-	0x10, 0xd2, 0x2a, 0x64, 0x7c, 0x52, 0x3c, 0x0b, 0xd0, 0x71, 0x96, 0x8a, 0xa1, 0xea, 0xa4, 0x80,
-	0x8a, 0xa8, 0x94, 0x96, 0xd3, 0x1d, 0xf7, 0x28, 0x3c, 0x0d, 0xa9, 0x4f, 0x29, 0xb0, 0x10, 0x2f,
-	0x26, 0x4a, 0x69, 0x99, 0xaf, 0x24, 0x13, 0x66, 0x7d, 0x2c, 0x2b, 0xaa, 0xd9, 0xd6, 0x0c, 0xb5,
-	0xa3, 0xd9, 0x47, 0xae, 0x8b, 0x73, 0x90, 0x19, 0xf0, 0x32, 0xbb, 0xd2, 0x32, 0x78, 0xc4, 0x56,
-	0x20, 0x06, 0xf1, 0x73, 0xc5, 0x60, 0x1b, 0xc4, 0x93, 0xce, 0xe4, 0x61, 0xb8, 0x11, 0x0c, 0xc3,
-	0xec, 0x70, 0x18, 0x1a, 0xc4, 0xd4, 0x88, 0xb5, 0xd2, 0xed, 0x1b, 0xb6, 0x1b, 0x90, 0x17, 0x08,
-	0xa6, 0x22, 0x01, 0x67, 0xc5, 0x46, 0x05, 0xcc, 0xd4, 0x34, 0x26, 0x8a, 0x45, 0x77, 0x72, 0x5f,
-	0x6e, 0x9c, 0x7a, 0xf4, 0x90, 0xb4, 0x6a, 0xd8, 0xe6, 0x91, 0x9c, 0xeb, 0x84, 0xc4, 0xc2, 0xca,
-	0xb0, 0x69, 0x14, 0x8a, 0x73, 0x90, 0x38, 0x20, 0x47, 0xdc, 0x26, 0xe7, 0x2f, 0xbe, 0x08, 0x23,
-	0xd4, 0x8e, 0x42, 0xbc, 0x88, 0x4a, 0x49, 0x99, 0x2d, 0xee, 0xc4, 0x6f, 0x21, 0xe9, 0x57, 0x04,
-	0x19, 0x99, 0xa8, 0x6d, 0x37, 0x35, 0x65, 0x18, 0x7d, 0xd2, 0x67, 0xc6, 0x86, 0x8a, 0xef, 0x51,
-	0x9f, 0x98, 0x6e, 0x06, 0x65, 0x17, 0x84, 0x77, 0x60, 0x46, 0x6d, 0xb5, 0x48, 0xcf, 0x26, 0x6d,
-	0xc5, 0xe4, 0xa1, 0x56, 0xec, 0xa3, 0x1e, 0x77, 0x36, 0xbb, 0x54, 0x74, 0xf7, 0xfb, 0x4e, 0x29,
-	0xbb, 0x49, 0xd9, 0x3a, 0xea, 0x11, 0x79, 0xca, 0x25, 0xf0, 0x4b, 0x2d, 0xe9, 0x26, 0x8c, 0xfb,
-	0x05, 0x38, 0x03, 0xa3, 0x8d, 0x4a, 0xed, 0xe1, 0x83, 0x6a, 0x23, 0x17, 0xc3, 0x33, 0x90, 0x6f,
-	0x6c, 0xc9, 0xd5, 0x4a, 0xad, 0xba, 0xaa, 0xec, 0x6c, 0xca, 0xca, 0xca, 0xda, 0x76, 0x7d, 0xa3,
-	0x91, 0x43, 0xd2, 0x5d, 0x67, 0x97, 0xea, 0x51, 0xe1, 0x79, 0x18, 0x35, 0x89, 0xd5, 0xef, 0xd8,
-	0xae, 0x3f, 0x53, 0x21, 0x7f, 0x18, 0x4e, 0x76, 0x51, 0xd2, 0x11, 0xe0, 0x86, 0x6d, 0x12, 0x55,
-	0x0f, 0xd0, 0x2c, 0x43, 0xb6, 0xb5, 0xdf, 0x37, 0x0e, 0x48, 0xdb, 0x4d, 0x25, 0x63, 0xbb, 0xec,
-	0xb2, 0xb1, 0x3d, 0x2b, 0x0c, 0xc3, 0x92, 0x21, 0x4f, 0xb4, 0xfc, 0x4b, 0xa7, 0xea, 0x9d, 0xa8,
-	0x1d, 0x29, 0x9a, 0xd1, 0x26, 0x87, 0x34, 0x15, 0x09, 0x19, 0xa8, 0x68, 0xdd, 0x91, 0x48, 0x3f,
-	0x20, 0xc8, 0x47, 0xf0, 0xe0, 0x5d, 0x48, 0xd1, 0xe4, 0x87, 0x9f, 0xe0, 0x5e, 0x93, 0xd5, 0xca,
-	0x43, 0x55, 0x33, 0x97, 0x6f, 0x3f, 0x7d, 0x31, 0x17, 0xfb, 0xed, 0xc5, 0xdc, 0xe2, 0x79, 0xae,
-	0x23, 0xb6, 0xaf, 0xd2, 0x56, 0x7b, 0x36, 0x31, 0x65, 0xce, 0x8e, 0x17, 0x21, 0x45, 0x2d, 0x76,
-	0xeb, 0x34, 0x1f, 0xe1, 0xdc, 0x72, 0xd2, 0x39, 0x47, 0xe6, 0x40, 0xe9, 0x27, 0x04, 0x19, 0x9f,
-	0x16, 0x8b, 0x90, 0xd1, 0x35, 0x43, 0xb1, 0x35, 0x9d, 0x28, 0xf4, 0x51, 0x73, 0x7c, 0x4c, 0xeb,
-	0x9a, 0xb1, 0xa5, 0xe9, 0xa4, 0x66, 0x51, 0xbd, 0x7a, 0xe8, 0xe9, 0xe3, 0x5c, 0xaf, 0x1e, 0x72,
-	0xfd, 0x02, 0x24, 0x9d, 0xe2, 0x29, 0x24, 0x8a, 0xa8, 0x94, 0x5d, 0xfa, 0x47, 0x84, 0x01, 0xe5,
-	0xaa, 0xd1, 0xea, 0xb6, 0x35, 0x63, 0x4f, 0xa6, 0x48, 0x8c, 0x21, 0xd9, 0x56, 0x6d, 0xb5, 0x90,
-	0x2c, 0xa2, 0xd2, 0xb8, 0x4c, 0xff, 0x4b, 0x45, 0x18, 0x73, 0x51, 0x4e, 0xd9, 0x6c, 0xd7, 0x37,
-	0xea, 0x9b, 0x8f, 0xeb, 0xb9, 0x18, 0x1e, 0x85, 0xc4, 0xce, 0xa6, 0x9c, 0x43, 0xd2, 0x37, 0x08,
-	0xc6, 0xfd, 0x05, 0x8d, 0xaf, 0x01, 0xb6, 0x6c, 0xd5, 0xb4, 0xa9, 0x69, 0x96, 0xad, 0xea, 0xbd,
-	0x81, 0xfd, 0x39, 0xaa, 0xd9, 0x72, 0x15, 0x35, 0x0b, 0x97, 0x20, 0x47, 0x8c, 0x76, 0x10, 0xcb,
-	0x7c, 0xc9, 0x12, 0xa3, 0xed, 0x47, 0xfa, 0x6f, 0xb2, 0xc4, 0xb9, 0x6e, 0xb2, 0xef, 0x10, 0x5c,
-	0xac, 0x1e, 0x12, 0xbd, 0xd7, 0x51, 0xcd, 0xf7, 0x62, 0xe2, 0xe2, 0x90, 0x89, 0x53, 0x51, 0x26,
-	0x5a, 0x3e, 0x1b, 0x37, 0x60, 0x22, 0xf0, 0xf8, 0xe0, 0x3b, 0x00, 0xf4, 0xa4, 0xa8, 0x9b, 0xa3,
-	0xd7, 0x2c, 0x3b, 0xc7, 0xb1, 0x62, 0xe6, 0xf5, 0xe3, 0x43, 0x4b, 0x5f, 0x23, 0xc8, 0x53, 0x36,
-	0xf7, 0xb9, 0xe3, 0x9c, 0x77, 0x21, 0xc3, 0xaa, 0xcc, 0x4f, 0x3a, 0xe3, 0x9a, 0x36, 0xa0, 0xf4,
-	0xd7, 0xa5, 0x7f, 0x47, 0xc8, 0xa8, 0xf8, 0x1b, 0x19, 0xd5, 0x80, 0xa9, 0x50, 0x12, 0xde, 0x82,
-	0xa7, 0xbf, 0x20, 0xc0, 0xfe, 0xae, 0xcb, 0x13, 0x7b, 0x46, 0x2b, 0x89, 0xce, 0x7b, 0xfc, 0x0d,
-	0xf2, 0x9e, 0x38, 0x33, 0xef, 0xce, 0xd3, 0x73, 0x8e, 0xbc, 0xdf, 0x82, 0x7c, 0xc0, 0x7e, 0x1e,
-	0x93, 0x7f, 0xc2, 0xb8, 0xaf, 0xd9, 0xb9, 0x0d, 0x3d, 0x33, 0xe8, 0x58, 0x96, 0xf4, 0x2d, 0x82,
-	0xc9, 0xc1, 0x90, 0xf2, 0x7e, 0x4b, 0xfa, 0x5c, 0xae, 0xfd, 0x9f, 0xa7, 0x86, 0xdb, 0xc7, 0x3d,
-	0x3b, 0x6b, 0x52, 0x91, 0x30, 0xe4, 0xb6, 0x2d, 0x62, 0x36, 0x6c, 0xd5, 0x76, 0xbd, 0x92, 0x7e,
-	0x46, 0x30, 0xe9, 0x13, 0x72, 0xaa, 0x2b, 0xee, 0xc0, 0xa9, 0x75, 0x0d, 0xc5, 0x54, 0x6d, 0x96,
-	0x69, 0x24, 0x4f, 0x78, 0x52, 0x59, 0xb5, 0x89, 0x53, 0x0c, 0x46, 0x5f, 0x1f, 0x0c, 0x0c, 0x4e,
-	0xbf, 0x4e, 0x1b, 0x7d, 0x9d, 0xf7, 0x82, 0x6b, 0x80, 0xd5, 0x9e, 0xa6, 0x84, 0x98, 0x12, 0x94,
-	0x29, 0xa7, 0xf6, 0xb4, 0xf5, 0x00, 0x59, 0x19, 0xf2, 0x66, 0xbf, 0x43, 0xc2, 0xf0, 0x24, 0x85,
-	0x4f, 0x3a, 0xaa, 0x00, 0x5e, 0xfa, 0x04, 0xf2, 0x8e, 0xe1, 0xeb, 0xab, 0x41, 0xd3, 0x67, 0x60,
-	0xb4, 0x6f, 0x11, 0x53, 0xd1, 0xda, 0xbc, 0x3a, 0x53, 0xce, 0x72, 0xbd, 0x8d, 0xaf, 0xf3, 0xcb,
-	0x37, 0x4e, 0x63, 0x7c, 0xc9, 0x8d, 0xf1, 0x90, 0xf3, 0xfc, 0x5e, 0xbe, 0x0f, 0xd8, 0x51, 0x59,
-	0x41, 0xf6, 0x45, 0x18, 0xb1, 0x1c, 0x41, 0xb8, 0xa5, 0x46, 0x58, 0x22, 0x33, 0xa4, 0xf4, 0x23,
-	0x02, 0xb1, 0x46, 0x6c, 0x53, 0x6b, 0x59, 0xf7, 0xba, 0x66, 0x30, 0xa5, 0xef, 0xb8, 0xb4, 0x6e,
-	0xc1, 0xb8, 0x5b, 0x33, 0x8a, 0x45, 0xec, 0xd3, 0x6f, 0xcc, 0x8c, 0x0b, 0x6d, 0x10, 0x5b, 0xda,
-	0x80, 0xb9, 0x13, 0x6d, 0xe6, 0xa1, 0x28, 0x41, 0x4a, 0xa7, 0x10, 0x1e, 0x8b, 0xdc, 0xe0, 0x62,
-	0x61, 0x5b, 0x65, 0xae, 0x97, 0x0a, 0x30, 0xcd, 0xc9, 0x6a, 0xc4, 0x56, 0x9d, 0xe8, 0xba, 0xd5,
-	0xb7, 0x09, 0x33, 0x43, 0x1a, 0x4e, 0x7f, 0x13, 0xc6, 0x74, 0x2e, 0xe3, 0x07, 0x14, 0xc2, 0x07,
-	0x78, 0x7b, 0x3c, 0xa4, 0xf4, 0x27, 0x82, 0x0b, 0xa1, 0xdb, 0xd6, 0x89, 0xd7, 0xae, 0xd9, 0xd5,
-	0x15, 0xf7, 0x15, 0x6a, 0x50, 0x1a, 0x59, 0x47, 0xbe, 0xce, 0xc5, 0xeb, 0x6d, 0x7f, 0xed, 0xc4,
-	0x03, 0xb5, 0x33, 0x98, 0x6a, 0x12, 0xef, 0x74, 0xaa, 0xf9, 0x9f, 0x37, 0xd5, 0x24, 0xe9, 0x39,
-	0x13, 0x6e, 0xaa, 0xa2, 0xe6, 0x99, 0x2f, 0x11, 0x8c, 0x30, 0x0f, 0xdf, 0x55, 0xfd, 0x08, 0x30,
-	0x46, 0xf8, 0x6c, 0x42, 0x1f, 0xdb, 0x11, 0xd9, 0x5b, 0x47, 0xce, 0x32, 0x15, 0x98, 0x08, 0xd4,
-	0xca, 0xdf, 0x78, 0x3f, 0x54, 0x60, 0xdc, 0xaf, 0xc1, 0x57, 0xf8, 0x90, 0x85, 0xe8, 0x90, 0x35,
-	0xe9, 0xee, 0xa6, 0x6a, 0x3a, 0x91, 0x7b, 0x93, 0x15, 0x6d, 0x48, 0x2c, 0x6d, 0xf4, 0xff, 0xe0,
-	0x45, 0x22, 0x41, 0x85, 0x6c, 0x21, 0x7d, 0x8e, 0x20, 0x3b, 0xa8, 0x90, 0x7b, 0x5a, 0x87, 0xbc,
-	0x8d, 0x02, 0x11, 0x60, 0x6c, 0x57, 0xeb, 0x10, 0x6a, 0x03, 0x3b, 0xce, 0x5b, 0x47, 0x45, 0xea,
-	0xbf, 0x1f, 0x42, 0xda, 0x73, 0x01, 0xa7, 0x61, 0xa4, 0xfa, 0x68, 0xbb, 0xf2, 0x20, 0x17, 0xc3,
-	0x13, 0x90, 0xae, 0x6f, 0x6e, 0x29, 0x6c, 0x89, 0xf0, 0x05, 0xc8, 0xc8, 0xd5, 0xfb, 0xd5, 0x1d,
-	0xa5, 0x56, 0xd9, 0x5a, 0x59, 0xcb, 0xc5, 0x31, 0x86, 0x2c, 0x13, 0xd4, 0x37, 0xb9, 0x2c, 0xb1,
-	0xf4, 0xc5, 0x28, 0x8c, 0xb9, 0x36, 0xe2, 0xdb, 0x90, 0x7c, 0xd8, 0xb7, 0xf6, 0xf1, 0xf4, 0xa0,
-	0x42, 0x1f, 0x9b, 0x9a, 0x4d, 0xf8, 0x13, 0x27, 0xcc, 0x0c, 0xc9, 0xd9, 0xf3, 0x26, 0xc5, 0xf0,
-	0x2a, 0x64, 0x7c, 0xa3, 0x0d, 0x8e, 0x7c, 0x99, 0x12, 0x2e, 0x07, 0xa4, 0xc1, 0x29, 0x48, 0x8a,
-	0x2d, 0x20, 0xbc, 0x09, 0x59, 0xaa, 0x72, 0x27, 0x12, 0x0b, 0x7b, 0x93, 0x71, 0xd4, 0xa4, 0x28,
-	0xcc, 0x9e, 0xa0, 0xf5, 0xcc, 0x5a, 0x0b, 0xbe, 0xe7, 0x0b, 0x51, 0x9f, 0x04, 0xc2, 0xc6, 0x45,
-	0x34, 0x7e, 0x29, 0x86, 0xab, 0x00, 0x83, 0xb6, 0x89, 0x2f, 0x05, 0xc0, 0xfe, 0x56, 0x2f, 0x08,
-	0x51, 0x2a, 0x8f, 0x66, 0x19, 0xd2, 0x5e, 0xd3, 0xc0, 0x85, 0x88, 0x3e, 0xc2, 0x48, 0x4e, 0xee,
-	0x30, 0x52, 0x0c, 0xdf, 0x83, 0xf1, 0x4a, 0xa7, 0x73, 0x1e, 0x1a, 0xc1, 0xaf, 0xb1, 0xc2, 0x3c,
-	0x1d, 0xef, 0x02, 0x0d, 0xdf, 0xd3, 0xf8, 0xaa, 0xf7, 0xac, 0x9c, 0xda, 0x7c, 0x84, 0x7f, 0x9f,
-	0x89, 0xf3, 0x4e, 0xdb, 0x82, 0x0b, 0xa1, 0xeb, 0x1a, 0x8b, 0xa1, 0xdd, 0xa1, 0x1b, 0x5e, 0x98,
-	0x3b, 0x51, 0xef, 0xb1, 0x36, 0xf9, 0xa0, 0x16, 0xfc, 0x24, 0x84, 0xa5, 0xe1, 0x24, 0x84, 0xbf,
-	0x3f, 0x09, 0xff, 0x3a, 0x15, 0xe3, 0xab, 0xca, 0x03, 0x98, 0x8e, 0xfe, 0xe4, 0x82, 0xaf, 0x44,
-	0xd4, 0xcc, 0xf0, 0x67, 0x20, 0xe1, 0xea, 0x59, 0xb0, 0xc1, 0x61, 0xcb, 0x1f, 0x3c, 0x7b, 0x29,
-	0xc6, 0x9e, 0xbf, 0x14, 0x63, 0xaf, 0x5f, 0x8a, 0xe8, 0xb3, 0x63, 0x11, 0x7d, 0x7f, 0x2c, 0xa2,
-	0xa7, 0xc7, 0x22, 0x7a, 0x76, 0x2c, 0xa2, 0xdf, 0x8f, 0x45, 0xf4, 0xc7, 0xb1, 0x18, 0x7b, 0x7d,
-	0x2c, 0xa2, 0xaf, 0x5e, 0x89, 0xb1, 0x67, 0xaf, 0xc4, 0xd8, 0xf3, 0x57, 0x62, 0xec, 0xe3, 0x54,
-	0xab, 0xa3, 0x11, 0xc3, 0x6e, 0xa6, 0xe8, 0x87, 0xb7, 0x1b, 0x7f, 0x05, 0x00, 0x00, 0xff, 0xff,
-	0x2f, 0xe4, 0x86, 0xaf, 0xf3, 0x13, 0x00, 0x00,
+	// 1828 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcb, 0x6f, 0x1b, 0xc7,
+	0x19, 0xe7, 0x90, 0x12, 0x25, 0x7e, 0xa4, 0x28, 0x6a, 0x68, 0x49, 0xf4, 0xba, 0xa6, 0xd4, 0x6d,
+	0xed, 0xaa, 0x6d, 0x42, 0xf9, 0x91, 0x02, 0x4e, 0x5a, 0x20, 0x90, 0x64, 0x3a, 0x52, 0x6d, 0x8a,
+	0xce, 0x52, 0xaa, 0x8d, 0x02, 0xc5, 0x62, 0xc9, 0x1d, 0x49, 0x0b, 0xef, 0x83, 0xd9, 0x1d, 0xd6,
+	0x52, 0x4e, 0x05, 0xfa, 0x07, 0xb4, 0xe8, 0x1f, 0x50, 0xa0, 0xb7, 0x1e, 0x8b, 0x02, 0x45, 0x6f,
+	0x3d, 0xe7, 0x52, 0xc0, 0xe8, 0x29, 0xe8, 0xc1, 0xa8, 0xe5, 0x8b, 0x7b, 0xcb, 0x9f, 0x50, 0xec,
+	0x3c, 0xf6, 0xa5, 0x95, 0xa5, 0x14, 0x71, 0x4e, 0xe4, 0x7c, 0xdf, 0x6f, 0x7e, 0xf3, 0xbd, 0x66,
+	0xe6, 0xdb, 0x81, 0xba, 0xe5, 0x1e, 0x92, 0x80, 0x12, 0xbf, 0x33, 0xf6, 0x3d, 0xea, 0xe1, 0xf2,
+	0xc8, 0xf3, 0x29, 0x39, 0x56, 0xde, 0x3f, 0xb4, 0xe8, 0xd1, 0x64, 0xd8, 0x19, 0x79, 0xce, 0xfa,
+	0xa1, 0x77, 0xe8, 0xad, 0x33, 0xf5, 0x70, 0x72, 0xc0, 0x46, 0x6c, 0xc0, 0xfe, 0xf1, 0x69, 0xca,
+	0xad, 0x24, 0xdc, 0x37, 0x0e, 0x0c, 0xd7, 0x58, 0x77, 0x2c, 0xc7, 0xf2, 0xd7, 0xc7, 0xcf, 0x0e,
+	0xf9, 0xbf, 0xf1, 0x90, 0xff, 0xf2, 0x19, 0xea, 0x2e, 0x28, 0x8f, 0x8c, 0x21, 0xb1, 0x77, 0x0d,
+	0x87, 0x04, 0x1b, 0xae, 0xf9, 0x0b, 0xc3, 0x9e, 0x90, 0x40, 0x23, 0x9f, 0x4d, 0x48, 0x40, 0xf1,
+	0x2d, 0x98, 0x75, 0x0c, 0x3a, 0x3a, 0x22, 0x7e, 0xd0, 0x42, 0xab, 0xa5, 0xb5, 0xea, 0x9d, 0x2b,
+	0x1d, 0x6e, 0x59, 0x87, 0xcd, 0xea, 0x71, 0xa5, 0x16, 0xa1, 0xd4, 0x6d, 0xb8, 0x96, 0xcb, 0x17,
+	0x8c, 0x3d, 0x37, 0x20, 0xf8, 0x87, 0x30, 0x6d, 0x51, 0xe2, 0x48, 0xb6, 0x66, 0x8a, 0x4d, 0x60,
+	0x39, 0x42, 0xbd, 0x0f, 0xd5, 0x84, 0x14, 0x5f, 0x07, 0xb0, 0xc3, 0xa1, 0xee, 0x1a, 0x0e, 0x69,
+	0xa1, 0x55, 0xb4, 0x56, 0xd1, 0x2a, 0xb6, 0x5c, 0x0a, 0x2f, 0x41, 0xf9, 0xd7, 0x0c, 0xd8, 0x2a,
+	0xae, 0x96, 0xd6, 0x2a, 0x9a, 0x18, 0xa9, 0x3e, 0x5c, 0x4f, 0xb0, 0x6c, 0x19, 0xbe, 0x69, 0xb9,
+	0x86, 0x6d, 0xd1, 0x13, 0xe9, 0xe2, 0x0a, 0x54, 0x63, 0x5e, 0x6e, 0x57, 0x45, 0x83, 0x88, 0x38,
+	0x48, 0xc5, 0xa0, 0x78, 0xa9, 0x18, 0xec, 0x43, 0xfb, 0xbc, 0x35, 0x45, 0x18, 0xee, 0xa6, 0xc3,
+	0x70, 0xfd, 0x6c, 0x18, 0x06, 0xc4, 0xb7, 0x48, 0xb0, 0xe5, 0x4d, 0x5c, 0x2a, 0x03, 0xf2, 0x12,
+	0xc1, 0x62, 0x2e, 0xe0, 0xa2, 0xd8, 0x18, 0x80, 0xb9, 0x9a, 0xc5, 0x44, 0x0f, 0xd8, 0x4c, 0xe1,
+	0xcb, 0xdd, 0xb7, 0x2e, 0x7d, 0x46, 0xda, 0x75, 0xa9, 0x7f, 0xa2, 0x35, 0xec, 0x8c, 0x58, 0xd9,
+	0x82, 0xc5, 0x5c, 0x28, 0x6e, 0x40, 0xe9, 0x19, 0x39, 0x11, 0x36, 0x85, 0x7f, 0xf1, 0x15, 0x98,
+	0x66, 0x76, 0xb4, 0x8a, 0xab, 0x68, 0x6d, 0x4a, 0xe3, 0x83, 0x8f, 0x8a, 0xf7, 0x90, 0xfa, 0x4f,
+	0x04, 0x55, 0x8d, 0x18, 0xa6, 0x4c, 0x4d, 0x07, 0x66, 0x3e, 0x9b, 0x70, 0x63, 0x33, 0xc5, 0xf7,
+	0xe9, 0x84, 0xf8, 0x32, 0x83, 0x9a, 0x04, 0xe1, 0xa7, 0xb0, 0x6c, 0x8c, 0x46, 0x64, 0x4c, 0x89,
+	0xa9, 0xfb, 0x22, 0xd4, 0x3a, 0x3d, 0x19, 0x0b, 0x67, 0xeb, 0x77, 0x56, 0xe5, 0xfc, 0xc4, 0x2a,
+	0x1d, 0x99, 0x94, 0xbd, 0x93, 0x31, 0xd1, 0x16, 0x25, 0x41, 0x52, 0x1a, 0xa8, 0x1f, 0x40, 0x2d,
+	0x29, 0xc0, 0x55, 0x98, 0x19, 0x6c, 0xf4, 0x1e, 0x3f, 0xea, 0x0e, 0x1a, 0x05, 0xbc, 0x0c, 0xcd,
+	0xc1, 0x9e, 0xd6, 0xdd, 0xe8, 0x75, 0xef, 0xeb, 0x4f, 0xfb, 0x9a, 0xbe, 0xb5, 0xbd, 0xbf, 0xfb,
+	0x70, 0xd0, 0x40, 0xea, 0xc7, 0x50, 0xe3, 0x0b, 0x89, 0xac, 0xaf, 0xc3, 0x8c, 0x4f, 0x82, 0x89,
+	0x4d, 0xa5, 0x3f, 0x8b, 0x19, 0x7f, 0x38, 0x4e, 0x93, 0x28, 0xf5, 0x04, 0xf0, 0x80, 0xfa, 0xc4,
+	0x70, 0x52, 0x34, 0x9b, 0x50, 0x1f, 0x1d, 0x4d, 0xdc, 0x67, 0xc4, 0x94, 0xa9, 0xe4, 0x6c, 0xd7,
+	0x24, 0x1b, 0x9f, 0xb3, 0xc5, 0x31, 0x3c, 0x19, 0xda, 0xdc, 0x28, 0x39, 0x0c, 0xab, 0x3e, 0x8c,
+	0xda, 0x89, 0x6e, 0xb9, 0x26, 0x39, 0x66, 0xa9, 0x28, 0x69, 0xc0, 0x44, 0x3b, 0xa1, 0x44, 0xfd,
+	0x0b, 0x82, 0x66, 0x0e, 0x0f, 0x3e, 0x80, 0x32, 0x4b, 0x7e, 0x76, 0x07, 0x8f, 0x87, 0xbc, 0x56,
+	0x1e, 0x1b, 0x96, 0xbf, 0xf9, 0xe1, 0x17, 0x2f, 0x57, 0x0a, 0xff, 0x7e, 0xb9, 0x72, 0xfb, 0x32,
+	0xc7, 0x11, 0x9f, 0xb7, 0x61, 0x1a, 0x63, 0x4a, 0x7c, 0x4d, 0xb0, 0xe3, 0xdb, 0x50, 0x66, 0x16,
+	0xcb, 0x3a, 0x6d, 0xe6, 0x38, 0xb7, 0x39, 0x15, 0xae, 0xa3, 0x09, 0xa0, 0xfa, 0x37, 0x04, 0xd5,
+	0x84, 0x16, 0xb7, 0xa1, 0xea, 0x58, 0xae, 0x4e, 0x2d, 0x87, 0xe8, 0x6c, 0xab, 0x85, 0x3e, 0x56,
+	0x1c, 0xcb, 0xdd, 0xb3, 0x1c, 0xd2, 0x0b, 0x98, 0xde, 0x38, 0x8e, 0xf4, 0x45, 0xa1, 0x37, 0x8e,
+	0x85, 0xfe, 0x16, 0x4c, 0x85, 0xc5, 0xd3, 0x2a, 0xad, 0xa2, 0xb5, 0xfa, 0x9d, 0xef, 0xe4, 0x18,
+	0xd0, 0xe9, 0xba, 0x23, 0xcf, 0xb4, 0xdc, 0x43, 0x8d, 0x21, 0x31, 0x86, 0x29, 0xd3, 0xa0, 0x46,
+	0x6b, 0x6a, 0x15, 0xad, 0xd5, 0x34, 0xf6, 0x5f, 0x5d, 0x85, 0x59, 0x89, 0x0a, 0xcb, 0x66, 0x7f,
+	0xf7, 0xe1, 0x6e, 0xff, 0xc9, 0x6e, 0xa3, 0x80, 0x67, 0xa0, 0xf4, 0xb4, 0xaf, 0x35, 0x90, 0xfa,
+	0x06, 0x41, 0x2d, 0x59, 0xd0, 0xf8, 0x3d, 0xc0, 0x01, 0x35, 0x7c, 0xca, 0x4c, 0x0b, 0xa8, 0xe1,
+	0x8c, 0x63, 0xfb, 0x1b, 0x4c, 0xb3, 0x27, 0x15, 0xbd, 0x00, 0xaf, 0x41, 0x83, 0xb8, 0x66, 0x1a,
+	0xcb, 0x7d, 0xa9, 0x13, 0xd7, 0x4c, 0x22, 0x93, 0x27, 0x59, 0xe9, 0x32, 0x27, 0x19, 0xbe, 0x09,
+	0xf3, 0x61, 0x88, 0x78, 0x99, 0xe9, 0x47, 0x96, 0x4b, 0x99, 0x6f, 0x25, 0x6d, 0xce, 0x31, 0x8e,
+	0x79, 0x45, 0x6c, 0x5b, 0x2e, 0x95, 0x38, 0x9e, 0x08, 0x8e, 0x9b, 0x8e, 0x70, 0x2c, 0x54, 0x0c,
+	0xa7, 0xfe, 0x09, 0xc1, 0x95, 0xee, 0x31, 0x71, 0xc6, 0xb6, 0xe1, 0x7f, 0x2b, 0x2e, 0xdf, 0x3e,
+	0xe3, 0xf2, 0x62, 0x9e, 0xcb, 0x41, 0xe2, 0xf4, 0x7e, 0x08, 0x73, 0xa9, 0xed, 0x88, 0x3f, 0x02,
+	0x60, 0x2b, 0xe5, 0x9d, 0x44, 0xe3, 0x61, 0x27, 0x5c, 0x8e, 0x87, 0x42, 0xd4, 0x63, 0x02, 0xad,
+	0xfe, 0x01, 0x41, 0x93, 0xb1, 0xc9, 0x7d, 0x2c, 0x38, 0x3f, 0x86, 0x2a, 0x0f, 0x56, 0x92, 0x74,
+	0x59, 0x9a, 0x16, 0x53, 0x26, 0xeb, 0x3c, 0x39, 0x23, 0x63, 0x54, 0xf1, 0x6b, 0x19, 0x35, 0x80,
+	0xc5, 0x4c, 0x12, 0xbe, 0x01, 0x4f, 0xff, 0x81, 0x00, 0x27, 0x6f, 0x71, 0x91, 0xd8, 0x0b, 0xae,
+	0xa6, 0xfc, 0xbc, 0x17, 0xbf, 0x46, 0xde, 0x4b, 0x17, 0xe6, 0x3d, 0xac, 0xd8, 0x4b, 0xe4, 0xfd,
+	0x1e, 0x34, 0x53, 0xf6, 0x8b, 0x98, 0x7c, 0x17, 0x6a, 0x89, 0xcb, 0x53, 0x36, 0x08, 0xd5, 0xf8,
+	0x06, 0x0c, 0xd4, 0x3f, 0x22, 0x58, 0x88, 0x9b, 0x9e, 0x6f, 0xb7, 0xa4, 0x2f, 0xe5, 0xda, 0x4f,
+	0x00, 0x27, 0xed, 0x13, 0x9e, 0x5d, 0xd4, 0xf9, 0xa8, 0x18, 0x1a, 0xfb, 0x01, 0xf1, 0x07, 0xd4,
+	0xa0, 0xd2, 0x2b, 0xf5, 0xef, 0x08, 0x16, 0x12, 0x42, 0x41, 0x75, 0x43, 0x36, 0xb0, 0x96, 0xe7,
+	0xea, 0xbe, 0x41, 0x79, 0xa6, 0x91, 0x36, 0x17, 0x49, 0x35, 0x83, 0x92, 0xb0, 0x18, 0xdc, 0x89,
+	0x13, 0x37, 0x20, 0xe1, 0xfd, 0x5f, 0x71, 0x27, 0x8e, 0xb8, 0x5b, 0xde, 0x03, 0x6c, 0x8c, 0x2d,
+	0x3d, 0xc3, 0x54, 0x62, 0x4c, 0x0d, 0x63, 0x6c, 0xed, 0xa4, 0xc8, 0x3a, 0xd0, 0xf4, 0x27, 0x36,
+	0xc9, 0xc2, 0xa7, 0x18, 0x7c, 0x21, 0x54, 0xa5, 0xf0, 0xea, 0xaf, 0xa0, 0x19, 0x1a, 0xbe, 0x73,
+	0x3f, 0x6d, 0xfa, 0x32, 0xcc, 0x4c, 0x02, 0xe2, 0xeb, 0x96, 0x29, 0xaa, 0xb3, 0x1c, 0x0e, 0x77,
+	0x4c, 0xfc, 0xbe, 0x38, 0xcc, 0x8b, 0x2c, 0xc6, 0x57, 0x65, 0x8c, 0xcf, 0x38, 0x2f, 0xce, 0xf9,
+	0x4f, 0x00, 0x87, 0xaa, 0x20, 0xcd, 0x7e, 0x1b, 0xa6, 0x83, 0x50, 0x90, 0xbd, 0xa2, 0x73, 0x2c,
+	0xd1, 0x38, 0x32, 0x8c, 0xfa, 0x36, 0x31, 0xcc, 0x54, 0xd4, 0xdf, 0x14, 0x61, 0x21, 0x21, 0x14,
+	0xe4, 0xe9, 0x70, 0xa2, 0x6c, 0x38, 0x85, 0x3a, 0xba, 0x46, 0xa5, 0x9a, 0x9f, 0xc7, 0xd9, 0xeb,
+	0xb1, 0x74, 0xc1, 0xf5, 0x38, 0x95, 0xbd, 0x1e, 0x6f, 0xc0, 0xbc, 0xe7, 0x79, 0x7a, 0x92, 0x83,
+	0x9f, 0xf9, 0x35, 0xcf, 0xf3, 0x7a, 0x96, 0x9b, 0x81, 0x25, 0xa8, 0xca, 0x31, 0x2c, 0x62, 0xfb,
+	0x29, 0x5c, 0xf3, 0x26, 0x54, 0xf7, 0x0e, 0x74, 0xcf, 0x37, 0x89, 0xcf, 0xb1, 0xcf, 0x2d, 0xd7,
+	0xf4, 0x9e, 0x87, 0x53, 0x66, 0xd8, 0x94, 0x25, 0x6f, 0x42, 0xfb, 0x07, 0xfd, 0x10, 0x10, 0x4e,
+	0x7b, 0xc2, 0xd4, 0xbd, 0x00, 0x7f, 0x1f, 0xea, 0xcf, 0x0d, 0x5b, 0x0f, 0xac, 0xcf, 0x89, 0x3e,
+	0x3c, 0xa1, 0x24, 0x68, 0xcd, 0xf2, 0x25, 0x9e, 0x1b, 0xf6, 0xc0, 0xfa, 0x9c, 0x6c, 0x86, 0x32,
+	0x86, 0x1a, 0xa6, 0x50, 0x15, 0x81, 0x1a, 0xc6, 0x28, 0xf5, 0xaf, 0x08, 0xda, 0x3d, 0x42, 0x7d,
+	0x6b, 0x14, 0x3c, 0xf0, 0xfc, 0xf4, 0x8e, 0x7a, 0xc7, 0x3b, 0xfb, 0x1e, 0xd4, 0xe4, 0x96, 0xd5,
+	0x03, 0x42, 0xdf, 0x7e, 0x61, 0x55, 0x25, 0x74, 0x40, 0xa8, 0xfa, 0x10, 0x56, 0xce, 0xb5, 0x59,
+	0x14, 0xcb, 0x1a, 0x94, 0x1d, 0x06, 0x11, 0xa5, 0xd8, 0x88, 0xcf, 0x75, 0x3e, 0x55, 0x13, 0x7a,
+	0xb5, 0x05, 0x4b, 0x82, 0xac, 0x47, 0xa8, 0x11, 0x16, 0xb7, 0x2c, 0xc3, 0x3e, 0x2c, 0x9f, 0xd1,
+	0x08, 0xfa, 0x0f, 0x60, 0xd6, 0x11, 0x32, 0xb1, 0x40, 0x2b, 0xbb, 0x40, 0x34, 0x27, 0x42, 0xaa,
+	0xff, 0x45, 0x30, 0x9f, 0xb9, 0xec, 0xc2, 0x78, 0x1d, 0xf8, 0x9e, 0xa3, 0xcb, 0x2f, 0xe2, 0x78,
+	0x67, 0xd6, 0x43, 0xf9, 0x8e, 0x10, 0xef, 0x98, 0xc9, 0xad, 0x5b, 0x4c, 0x6d, 0xdd, 0xb8, 0x49,
+	0x2d, 0xbd, 0xd3, 0x26, 0xf5, 0xc7, 0x51, 0x93, 0x3a, 0xc5, 0xd6, 0x99, 0x93, 0xa9, 0xca, 0x6b,
+	0x4f, 0x7f, 0x87, 0x60, 0x9a, 0x7b, 0xf8, 0xae, 0xea, 0x47, 0x81, 0x59, 0x22, 0x5a, 0x4d, 0xb6,
+	0x9d, 0xa7, 0xb5, 0x68, 0x9c, 0xdb, 0x9a, 0x6e, 0xc0, 0x5c, 0xaa, 0x56, 0xfe, 0x8f, 0xcf, 0x7d,
+	0x1d, 0x6a, 0x49, 0x0d, 0xbe, 0x21, 0x7a, 0x66, 0xc4, 0x7a, 0xe6, 0x05, 0x39, 0x9b, 0xa9, 0xd9,
+	0x07, 0x56, 0xd4, 0x28, 0xb3, 0x7e, 0x80, 0xa7, 0x8d, 0xfd, 0x8f, 0xbf, 0x0b, 0x4b, 0x4c, 0xc8,
+	0x07, 0xea, 0x6f, 0x11, 0xd4, 0xe3, 0x0a, 0x79, 0x60, 0xd9, 0xe4, 0x9b, 0x28, 0x10, 0x05, 0x66,
+	0x0f, 0x2c, 0x9b, 0x30, 0x1b, 0xf8, 0x72, 0xd1, 0x38, 0x2f, 0x52, 0x3f, 0xfa, 0x39, 0x54, 0x22,
+	0x17, 0x70, 0x05, 0xa6, 0xbb, 0x9f, 0xee, 0x6f, 0x3c, 0x6a, 0x14, 0xf0, 0x1c, 0x54, 0x76, 0xfb,
+	0x7b, 0x3a, 0x1f, 0x22, 0x3c, 0x0f, 0x55, 0xad, 0xfb, 0x49, 0xf7, 0xa9, 0xde, 0xdb, 0xd8, 0xdb,
+	0xda, 0x6e, 0x14, 0x31, 0x86, 0x3a, 0x17, 0xec, 0xf6, 0x85, 0xac, 0x74, 0xe7, 0x5f, 0x33, 0x30,
+	0x2b, 0x6d, 0xc4, 0x1f, 0xc2, 0xd4, 0xe3, 0x49, 0x70, 0x84, 0x97, 0xe2, 0x0a, 0x7d, 0xe2, 0x5b,
+	0x94, 0x88, 0x1d, 0xa7, 0x2c, 0x9f, 0x91, 0xf3, 0xfd, 0xa6, 0x16, 0xf0, 0x7d, 0xa8, 0x26, 0x3a,
+	0x4b, 0x9c, 0xfb, 0x6d, 0xac, 0x5c, 0x4b, 0x49, 0xd3, 0x4d, 0xa8, 0x5a, 0xb8, 0x85, 0x70, 0x1f,
+	0xea, 0x4c, 0x25, 0x1b, 0xc2, 0x00, 0x47, 0x1f, 0x3a, 0x79, 0x8d, 0xba, 0x72, 0xfd, 0x1c, 0x6d,
+	0x64, 0xd6, 0x76, 0xfa, 0xd9, 0x46, 0xc9, 0x7b, 0xe1, 0xc9, 0x1a, 0x97, 0xd3, 0x77, 0xa9, 0x05,
+	0xdc, 0x05, 0x88, 0xbb, 0x16, 0x7c, 0x35, 0x05, 0x4e, 0x76, 0x5a, 0x8a, 0x92, 0xa7, 0x8a, 0x68,
+	0x36, 0xa1, 0x12, 0xdd, 0xd9, 0xb8, 0x95, 0x73, 0x8d, 0x73, 0x92, 0xf3, 0x2f, 0x78, 0xb5, 0x80,
+	0x1f, 0x40, 0x6d, 0xc3, 0xb6, 0x2f, 0x43, 0xa3, 0x24, 0x35, 0x41, 0x96, 0xc7, 0x86, 0xe5, 0x73,
+	0xce, 0x69, 0x7c, 0x33, 0xda, 0x2b, 0x6f, 0xbd, 0x7c, 0x94, 0x1f, 0x5c, 0x88, 0x8b, 0x56, 0xdb,
+	0x83, 0xf9, 0xcc, 0x71, 0x8d, 0xdb, 0x99, 0xd9, 0x99, 0x13, 0x5e, 0x59, 0x39, 0x57, 0x1f, 0xb1,
+	0x0e, 0xa1, 0x19, 0xc7, 0x39, 0x7a, 0xe1, 0xc3, 0xea, 0xd9, 0x24, 0x64, 0x9f, 0x13, 0x95, 0xef,
+	0xbd, 0x15, 0x93, 0xa8, 0xca, 0x67, 0xb0, 0x94, 0xff, 0x82, 0x86, 0x6f, 0xe4, 0xd4, 0xcc, 0xd9,
+	0x57, 0x3d, 0xe5, 0xe6, 0x45, 0xb0, 0xc4, 0x62, 0x9b, 0x50, 0x89, 0x7a, 0xab, 0x38, 0xb3, 0xd9,
+	0x1e, 0x4c, 0xb9, 0x9a, 0xa3, 0x91, 0x2c, 0x9b, 0x3f, 0x7b, 0xf1, 0xaa, 0x5d, 0xf8, 0xf2, 0x55,
+	0xbb, 0xf0, 0xd5, 0xab, 0x36, 0xfa, 0xcd, 0x69, 0x1b, 0xfd, 0xf9, 0xb4, 0x8d, 0xbe, 0x38, 0x6d,
+	0xa3, 0x17, 0xa7, 0x6d, 0xf4, 0x9f, 0xd3, 0x36, 0x7a, 0x73, 0xda, 0x2e, 0x7c, 0x75, 0xda, 0x46,
+	0xbf, 0x7f, 0xdd, 0x2e, 0xbc, 0x78, 0xdd, 0x2e, 0x7c, 0xf9, 0xba, 0x5d, 0xf8, 0x65, 0x79, 0x64,
+	0x5b, 0xc4, 0xa5, 0xc3, 0x32, 0x7b, 0x8b, 0xbd, 0xfb, 0xbf, 0x01, 0x00, 0xdc, 0x2d, 0xad, 0x79,
+	0x06, 0x16, 0x00, 0x00,
 }
 
 func (x MatchType) String() string {
@@ -2343,6 +2515,12 @@ func (this *QueryRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.MaxSeriesHint != that1.MaxSeriesHint {
+		return false
+	}
+	if this.MaxChunksHint != that1.MaxChunksHint {
+		return false
+	}
 	return true
 }
 func (this *ExemplarQueryRequest) Equal(that interface{}) bool {
@@ -2706,6 +2884,75 @@ func (this *UsersStatsResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *HeadStatsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*HeadStatsRequest)
+	if !ok {
+		that2, ok := that.(HeadStatsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *HeadStatsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*HeadStatsResponse)
+	if !ok {
+		that2, ok := that.(HeadStatsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.NumSeries != that1.NumSeries {
+		return false
+	}
+	if this.NumChunks != that1.NumChunks {
+		return false
+	}
+	if this.MinTimeMs != that1.MinTimeMs {
+		return false
+	}
+	if this.MaxTimeMs != that1.MaxTimeMs {
+		return false
+	}
+	if this.OooMinTimeMs != that1.OooMinTimeMs {
+		return false
+	}
+	if this.OooMaxTimeMs != that1.OooMaxTimeMs {
+		return false
+	}
+	if this.OutOfOrderTimeWindowMs != that1.OutOfOrderTimeWindowMs {
+		return false
+	}
+	if this.WalSizeBytes != that1.WalSizeBytes {
+		return false
+	}
+	if this.WblSizeBytes != that1.WblSizeBytes {
+		return false
+	}
+	return true
+}
 func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -3143,13 +3390,15 @@ func (this *QueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 9)
 	s = append(s, "&client.QueryRequest{")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
 	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "MaxSeriesHint: "+fmt.Sprintf("%#v", this.MaxSeriesHint)+",\n")
+	s = append(s, "MaxChunksHint: "+fmt.Sprintf("%#v", this.MaxChunksHint)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3318,6 +3567,33 @@ func (this *UsersStatsResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *HeadStatsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&client.HeadStatsRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *HeadStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 13)
+	s = append(s, "&client.HeadStatsResponse{")
+	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
+	s = append(s, "NumChunks: "+fmt.Sprintf("%#v", this.NumChunks)+",\n")
+	s = append(s, "MinTimeMs: "+fmt.Sprintf("%#v", this.MinTimeMs)+",\n")
+	s = append(s, "MaxTimeMs: "+fmt.Sprintf("%#v", this.MaxTimeMs)+",\n")
+	s = append(s, "OooMinTimeMs: "+fmt.Sprintf("%#v", this.OooMinTimeMs)+",\n")
+	s = append(s, "OooMaxTimeMs: "+fmt.Sprintf("%#v", this.OooMaxTimeMs)+",\n")
+	s = append(s, "OutOfOrderTimeWindowMs: "+fmt.Sprintf("%#v", this.OutOfOrderTimeWindowMs)+",\n")
+	s = append(s, "WalSizeBytes: "+fmt.Sprintf("%#v", this.WalSizeBytes)+",\n")
+	s = append(s, "WblSizeBytes: "+fmt.Sprintf("%#v", this.WblSizeBytes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *MetricsForLabelMatchersRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3471,6 +3747,8 @@ type IngesterClient interface {
 	// that match the matchers.
 	// The listing order of the labels is not guaranteed.
 	LabelValuesCardinality(ctx context.Context, in *LabelValuesCardinalityRequest, opts ...grpc.CallOption) (Ingester_LabelValuesCardinalityClient, error)
+	// HeadStats returns statistics about the TSDB head of the tenant.
+	HeadStats(ctx context.Context, in *HeadStatsRequest, opts ...grpc.CallOption) (*HeadStatsResponse, error)
 }
 
 type ingesterClient struct {
@@ -3649,6 +3927,15 @@ func (x *ingesterLabelValuesCardinalityClient) Recv() (*LabelValuesCardinalityRe
 	return m, nil
 }
 
+func (c *ingesterClient) HeadStats(ctx context.Context, in *HeadStatsRequest, opts ...grpc.CallOption) (*HeadStatsResponse, error) {
+	out := new(HeadStatsResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/HeadStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // IngesterServer is the server API for Ingester service.
 type IngesterServer interface {
 	Push(context.Context, *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
@@ -3667,6 +3954,8 @@ type IngesterServer interface {
 	// that match the matchers.
 	// The listing order of the labels is not guaranteed.
 	LabelValuesCardinality(*LabelValuesCardinalityRequest, Ingester_LabelValuesCardinalityServer) error
+	// HeadStats returns statistics about the TSDB head of the tenant.
+	HeadStats(context.Context, *HeadStatsRequest) (*HeadStatsResponse, error)
 }
 
 // UnimplementedIngesterServer can be embedded to have forward compatible implementations.
@@ -3706,6 +3995,9 @@ func (*UnimplementedIngesterServer) LabelNamesAndValues(req *LabelNamesAndValues
 func (*UnimplementedIngesterServer) LabelValuesCardinality(req *LabelValuesCardinalityRequest, srv Ingester_LabelValuesCardinalityServer) error {
 	return status.Errorf(codes.Unimplemented, "method LabelValuesCardinality not implemented")
 }
+func (*UnimplementedIngesterServer) HeadStats(ctx context.Context, req *HeadStatsRequest) (*HeadStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HeadStats not implemented")
+}
 
 func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
 	s.RegisterService(&_Ingester_serviceDesc, srv)
@@ -3918,6 +4210,24 @@ func (x *ingesterLabelValuesCardinalityServer) Send(m *LabelValuesCardinalityRes
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Ingester_HeadStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeadStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngesterServer).HeadStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/HeadStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).HeadStats(ctx, req.(*HeadStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Ingester_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "cortex.Ingester",
 	HandlerType: (*IngesterServer)(nil),
@@ -3954,6 +4264,10 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			MethodName: "MetricsMetadata",
 			Handler:    _Ingester_MetricsMetadata_Handler,
 		},
+		{
+			MethodName: "HeadStats",
+			Handler:    _Ingester_HeadStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -4468,6 +4782,16 @@ func (m *QueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.MaxChunksHint != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxChunksHint))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.MaxSeriesHint != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxSeriesHint))
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.Matchers) > 0 {
 		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -4976,7 +5300,7 @@ func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
+func (m *HeadStatsRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -4986,44 +5310,20 @@ func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MetricsForLabelMatchersRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *HeadStatsRequest) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *HeadStatsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.MatchersSet) > 0 {
-		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
-	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
-	}
 	return len(dAtA) - i, nil
 }
 
-func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
+func (m *HeadStatsResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -5033,20 +5333,135 @@ func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MetricsForLabelMatchersResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *HeadStatsResponse) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *MetricsForLabelMatchersResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *HeadStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Metric) > 0 {
-		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+	if m.WblSizeBytes != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.WblSizeBytes))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.WalSizeBytes != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.WalSizeBytes))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.OutOfOrderTimeWindowMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.OutOfOrderTimeWindowMs))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.OooMaxTimeMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.OooMaxTimeMs))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.OooMinTimeMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.OooMinTimeMs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.MaxTimeMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxTimeMs))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MinTimeMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MinTimeMs))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.NumChunks != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumChunks))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.NumSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumSeries))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.MatchersSet) > 0 {
+		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -5593,6 +6008,12 @@ func (m *QueryRequest) Size() (n int) {
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
+	if m.MaxSeriesHint != 0 {
+		n += 1 + sovIngester(uint64(m.MaxSeriesHint))
+	}
+	if m.MaxChunksHint != 0 {
+		n += 1 + sovIngester(uint64(m.MaxChunksHint))
+	}
 	return n
 }
 
@@ -5802,6 +6223,51 @@ func (m *UsersStatsResponse) Size() (n int) {
 	return n
 }
 
+func (m *HeadStatsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *HeadStatsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.NumSeries != 0 {
+		n += 1 + sovIngester(uint64(m.NumSeries))
+	}
+	if m.NumChunks != 0 {
+		n += 1 + sovIngester(uint64(m.NumChunks))
+	}
+	if m.MinTimeMs != 0 {
+		n += 1 + sovIngester(uint64(m.MinTimeMs))
+	}
+	if m.MaxTimeMs != 0 {
+		n += 1 + sovIngester(uint64(m.MaxTimeMs))
+	}
+	if m.OooMinTimeMs != 0 {
+		n += 1 + sovIngester(uint64(m.OooMinTimeMs))
+	}
+	if m.OooMaxTimeMs != 0 {
+		n += 1 + sovIngester(uint64(m.OooMaxTimeMs))
+	}
+	if m.OutOfOrderTimeWindowMs != 0 {
+		n += 1 + sovIngester(uint64(m.OutOfOrderTimeWindowMs))
+	}
+	if m.WalSizeBytes != 0 {
+		n += 1 + sovIngester(uint64(m.WalSizeBytes))
+	}
+	if m.WblSizeBytes != 0 {
+		n += 1 + sovIngester(uint64(m.WblSizeBytes))
+	}
+	return n
+}
+
 func (m *MetricsForLabelMatchersRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -6161,6 +6627,8 @@ func (this *QueryRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + repeatedStringForMatchers + `,`,
+		`MaxSeriesHint:` + fmt.Sprintf("%v", this.MaxSeriesHint) + `,`,
+		`MaxChunksHint:` + fmt.Sprintf("%v", this.MaxChunksHint) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6326,6 +6794,33 @@ func (this *UsersStatsResponse) String() string {
 	}, "")
 	return s
 }
+func (this *HeadStatsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&HeadStatsRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *HeadStatsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&HeadStatsResponse{`,
+		`NumSeries:` + fmt.Sprintf("%v", this.NumSeries) + `,`,
+		`NumChunks:` + fmt.Sprintf("%v", this.NumChunks) + `,`,
+		`MinTimeMs:` + fmt.Sprintf("%v", this.MinTimeMs) + `,`,
+		`MaxTimeMs:` + fmt.Sprintf("%v", this.MaxTimeMs) + `,`,
+		`OooMinTimeMs:` + fmt.Sprintf("%v", this.OooMinTimeMs) + `,`,
+		`OooMaxTimeMs:` + fmt.Sprintf("%v", this.OooMaxTimeMs) + `,`,
+		`OutOfOrderTimeWindowMs:` + fmt.Sprintf("%v", this.OutOfOrderTimeWindowMs) + `,`,
+		`WalSizeBytes:` + fmt.Sprintf("%v", this.WalSizeBytes) + `,`,
+		`WblSizeBytes:` + fmt.Sprintf("%v", this.WblSizeBytes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *MetricsForLabelMatchersRequest) String() string {
 	if this == nil {
 		return "nil"
@@ -7871,6 +8366,44 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSeriesHint", wireType)
+			}
+			m.MaxSeriesHint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSeriesHint |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxChunksHint", wireType)
+			}
+			m.MaxChunksHint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxChunksHint |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -9137,6 +9670,283 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *HeadStatsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HeadStatsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HeadStatsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *HeadStatsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HeadStatsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HeadStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
+			}
+			m.NumSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumChunks", wireType)
+			}
+			m.NumChunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumChunks |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTimeMs", wireType)
+			}
+			m.MinTimeMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTimeMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTimeMs", wireType)
+			}
+			m.MaxTimeMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTimeMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OooMinTimeMs", wireType)
+			}
+			m.OooMinTimeMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OooMinTimeMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OooMaxTimeMs", wireType)
+			}
+			m.OooMaxTimeMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OooMaxTimeMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutOfOrderTimeWindowMs", wireType)
+			}
+			m.OutOfOrderTimeWindowMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OutOfOrderTimeWindowMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WalSizeBytes", wireType)
+			}
+			m.WalSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WalSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WblSizeBytes", wireType)
+			}
+			m.WblSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WblSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"sync"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// deltaSeries keeps track of the cumulative value of the in-memory series of a tenant whose samples are
+// pushed as the increments since the previous sample (delta temporality), in order to store them as
+// cumulative samples. The value of a series is forgotten once the series is removed from the TSDB head,
+// so the next increment is stored as if the counter has been reset.
+type deltaSeries struct {
+	mtx    sync.Mutex
+	values deltaSeriesValues
+}
+
+func newDeltaSeries() *deltaSeries {
+	return &deltaSeries{values: deltaSeriesValues{}}
+}
+
+// begin returns an appender of the cumulative values of the delta series. The appender holds the lock of the
+// delta series until it's closed, because a push request must read and update the values atomically.
+func (s *deltaSeries) begin() *deltaSeriesAppender {
+	s.mtx.Lock()
+	return &deltaSeriesAppender{series: s, pending: deltaSeriesValues{}}
+}
+
+func (s *deltaSeries) remove(lbls labels.Labels) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.values.delete(lbls, lbls.Hash())
+}
+
+// deltaSeriesAppender reads and updates the cumulative values of the delta series while a push
+// request is appended. The updated values are applied only once the samples have been committed.
+type deltaSeriesAppender struct {
+	series  *deltaSeries
+	pending deltaSeriesValues
+}
+
+// get returns the cumulative value of the series, which is 0 for the series not seen before.
+func (a *deltaSeriesAppender) get(lbls labels.Labels) float64 {
+	hash := lbls.Hash()
+	if v, ok := a.pending.get(lbls, hash); ok {
+		return v
+	}
+	v, _ := a.series.values.get(lbls, hash)
+	return v
+}
+
+// set updates the cumulative value of the series. The labels are retained, so they must not be modified later.
+func (a *deltaSeriesAppender) set(lbls labels.Labels, value float64) {
+	a.pending.set(lbls, lbls.Hash(), value)
+}
+
+// commit applies the updated values. It's a no-op on a nil appender.
+func (a *deltaSeriesAppender) commit() {
+	if a == nil {
+		return
+	}
+	for hash, entries := range a.pending {
+		for _, e := range entries {
+			a.series.values.set(e.labels, hash, e.value)
+		}
+	}
+	a.pending = deltaSeriesValues{}
+}
+
+// close releases the lock of the delta series, discarding the values not committed.
+func (a *deltaSeriesAppender) close() {
+	a.pending = nil
+	a.series.mtx.Unlock()
+}
+
+type deltaSeriesEntry struct {
+	labels labels.Labels
+	value  float64
+}
+
+// deltaSeriesValues are the cumulative values of the delta series, by labels hash.
+type deltaSeriesValues map[uint64][]deltaSeriesEntry
+
+func (m deltaSeriesValues) get(lbls labels.Labels, hash uint64) (float64, bool) {
+	for _, e := range m[hash] {
+		if labels.Equal(e.labels, lbls) {
+			return e.value, true
+		}
+	}
+	return 0, false
+}
+
+func (m deltaSeriesValues) set(lbls labels.Labels, hash uint64, value float64) {
+	entries := m[hash]
+	for i, e := range entries {
+		if labels.Equal(e.labels, lbls) {
+			entries[i].value = value
+			return
+		}
+	}
+	m[hash] = append(entries, deltaSeriesEntry{labels: lbls, value: value})
+}
+
+func (m deltaSeriesValues) delete(lbls labels.Labels, hash uint64) {
+	entries := m[hash]
+	for i, e := range entries {
+		if labels.Equal(e.labels, lbls) {
+			entries = slices.Delete(entries, i, i+1)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(m, hash)
+		return
+	}
+	m[hash] = entries
+}
+
+// hasDeltaSeries returns whether any of the input series is pushed with delta temporality.
+func hasDeltaSeries(series []mimirpb.PreallocTimeseries) bool {
+	for _, ts := range series {
+		if ts.Delta {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeltaSeries(t *testing.T) {
+	series1 := labels.FromStrings(labels.MetricName, "calls_total", "job", "app")
+	series2 := labels.FromStrings(labels.MetricName, "calls_total", "job", "other")
+
+	s := newDeltaSeries()
+
+	app := s.begin()
+	assert.Equal(t, 0.0, app.get(series1))
+	app.set(series1, 3)
+	app.set(series2, 5)
+	assert.Equal(t, 3.0, app.get(series1))
+	app.commit()
+	app.close()
+
+	// The values not committed are discarded.
+	app = s.begin()
+	assert.Equal(t, 3.0, app.get(series1))
+	app.set(series1, 10)
+	app.close()
+
+	app = s.begin()
+	assert.Equal(t, 3.0, app.get(series1))
+	app.close()
+
+	// The value of the series removed from the TSDB head is forgotten.
+	s.remove(series1)
+
+	app = s.begin()
+	assert.Equal(t, 0.0, app.get(series1))
+	assert.Equal(t, 5.0, app.get(series2))
+	app.close()
+}
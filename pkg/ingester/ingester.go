@@ -17,6 +17,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	runtimemetrics "runtime/metrics"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +35,7 @@ import (
 	promcfg "github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
@@ -229,6 +231,9 @@ type Ingester struct {
 	// Timeout chosen for idle compactions.
 	compactionIdleTimeout time.Duration
 
+	// Returns the memory used by the Go heap, checked by the early head compaction. Tests can override it.
+	heapInUse func() uint64
+
 	// Number of series in memory, across all tenants.
 	seriesCount atomic.Int64
 
@@ -276,6 +281,7 @@ func newIngester(cfg Config, limits *validation.Overrides, registerer prometheus
 		forceCompactTrigger: make(chan requestWithUsersAndCallback),
 		shipTrigger:         make(chan requestWithUsersAndCallback),
 		seriesHashCache:     hashcache.NewSeriesHashCache(cfg.BlocksStorageConfig.TSDB.SeriesHashCacheMaxBytes),
+		heapInUse:           readHeapInUse,
 
 		memorySeriesStats:                  usagestats.GetAndResetInt(memorySeriesStatsName),
 		memoryTenantsStats:                 usagestats.GetAndResetInt(memoryTenantsStatsName),
@@ -703,6 +709,11 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		newValueForTimestampCount = 0
 		perUserSeriesLimitCount   = 0
 		perMetricSeriesLimitCount = 0
+		discardedStaleMarkers     = 0
+
+		discardOOOStaleMarkers     = i.limits.DiscardOutOfOrderStaleMarkers(userID)
+		targetDeletionStaleMarkers = i.limits.TargetDeletionStaleMarkers(userID)
+		deletedTargets             []deletedTarget
 
 		minAppendTime, minAppendTimeAvailable = db.Head().AppendableMinValidTime()
 
@@ -717,6 +728,14 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 	app := db.Appender(ctx).(extendedAppender)
 	level.Debug(spanlog).Log("event", "got appender", "numSeries", len(req.Timeseries))
 
+	// The samples of the series pushed with delta temporality are the increments since the previous sample,
+	// which are added to the cumulative value of the series to store the samples as cumulative ones.
+	var deltaApp *deltaSeriesAppender
+	if hasDeltaSeries(req.Timeseries) {
+		deltaApp = db.deltaSeries.begin()
+		defer deltaApp.close()
+	}
+
 	oooTW := i.limits.OutOfOrderTimeWindow(userID)
 	for _, ts := range req.Timeseries {
 		// The labels must be sorted (in our case, it's guaranteed a write request
@@ -728,8 +747,18 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		// extend the fast path to fail early.
 		if oooTW <= 0 && minAppendTimeAvailable &&
 			len(ts.Samples) > 0 && len(ts.Exemplars) == 0 && allOutOfBounds(ts.Samples, minAppendTime) {
-			failedSamplesCount += len(ts.Samples)
-			sampleOutOfBoundsCount += len(ts.Samples)
+			outOfBoundsCount := len(ts.Samples)
+			if discardOOOStaleMarkers {
+				staleMarkers := countStaleMarkers(ts.Samples)
+				discardedStaleMarkers += staleMarkers
+				outOfBoundsCount -= staleMarkers
+			}
+			if outOfBoundsCount == 0 {
+				continue
+			}
+
+			failedSamplesCount += outOfBoundsCount
+			sampleOutOfBoundsCount += outOfBoundsCount
 
 			updateFirstPartial(func() error {
 				return newIngestErrSampleTimestampTooOld(model.Time(ts.Samples[0].TimestampMs), ts.Labels)
@@ -743,24 +772,41 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		// To find out if any sample was added to this series, we keep old value.
 		oldSucceededSamplesCount := succeededSamplesCount
 
+		var deltaValue float64
+		if ts.Delta {
+			deltaValue = deltaApp.get(mimirpb.FromLabelAdaptersToLabels(ts.Labels))
+		}
+
 		for _, s := range ts.Samples {
 			var err error
 
+			sampleValue := s.Value
+			if ts.Delta && !value.IsStaleNaN(s.Value) {
+				sampleValue += deltaValue
+			}
+
 			// If the cached reference exists, we try to use it.
 			if ref != 0 {
-				if _, err = app.Append(ref, copiedLabels, s.TimestampMs, s.Value); err == nil {
-					succeededSamplesCount++
-					continue
-				}
+				_, err = app.Append(ref, copiedLabels, s.TimestampMs, sampleValue)
 			} else {
 				// Copy the label set because both TSDB and the active series tracker may retain it.
 				copiedLabels = mimirpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
 
 				// Retain the reference in case there are multiple samples for the series.
-				if ref, err = app.Append(0, copiedLabels, s.TimestampMs, s.Value); err == nil {
-					succeededSamplesCount++
-					continue
+				ref, err = app.Append(0, copiedLabels, s.TimestampMs, sampleValue)
+			}
+			if err == nil {
+				succeededSamplesCount++
+				if ts.Delta && !value.IsStaleNaN(s.Value) {
+					deltaValue = sampleValue
 				}
+				continue
+			}
+
+			// A staleness marker older than the last sample of the series is meaningless, so it's safe to discard it.
+			if discardOOOStaleMarkers && value.IsStaleNaN(s.Value) && isOutOfOrderSampleErr(err) {
+				discardedStaleMarkers++
+				continue
 			}
 
 			failedSamplesCount++
@@ -816,6 +862,17 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 			return nil, wrapWithUser(err, userID)
 		}
 
+		if ts.Delta && succeededSamplesCount > oldSucceededSamplesCount {
+			// We must already have copied the labels if succeededSamplesCount has been incremented.
+			deltaApp.set(copiedLabels, deltaValue)
+		}
+
+		if targetDeletionStaleMarkers && succeededSamplesCount > oldSucceededSamplesCount {
+			if target, ok := deletedTargetFromSeries(ts); ok {
+				deletedTargets = append(deletedTargets, target)
+			}
+		}
+
 		if i.cfg.ActiveSeriesMetricsEnabled && succeededSamplesCount > oldSucceededSamplesCount {
 			db.activeSeries.UpdateSeries(mimirpb.FromLabelAdaptersToLabels(ts.Labels), startAppend, func(l labels.Labels) labels.Labels {
 				// we must already have copied the labels if succeededSamplesCount has been incremented.
@@ -870,11 +927,22 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 	if err := app.Commit(); err != nil {
 		return nil, wrapWithUser(err, userID)
 	}
+	deltaApp.commit()
 
 	commitDuration := time.Since(startCommit)
 	i.metrics.appenderCommitDuration.Observe(commitDuration.Seconds())
 	level.Debug(spanlog).Log("event", "complete commit", "commitDuration", commitDuration.String())
 
+	// The staleness markers for the series of the deleted targets are appended once the request samples
+	// have been committed, so that the series already marked as stale by the request are skipped.
+	if len(deletedTargets) > 0 {
+		appended, err := appendTargetDeletionStaleMarkers(ctx, db, deletedTargets)
+		if err != nil {
+			level.Warn(spanlog).Log("msg", "failed to append staleness markers to the series of deleted targets", "user", userID, "err", err)
+		}
+		i.metrics.injectedStaleMarkers.Add(float64(appended))
+	}
+
 	// If only invalid samples are pushed, don't change "last update", as TSDB was not modified.
 	if succeededSamplesCount > 0 {
 		db.setLastUpdate(time.Now())
@@ -908,6 +976,9 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 	if perMetricSeriesLimitCount > 0 {
 		i.metrics.discardedSamplesPerMetricSeriesLimit.WithLabelValues(userID).Add(float64(perMetricSeriesLimitCount))
 	}
+	if discardedStaleMarkers > 0 {
+		i.metrics.discardedStaleMarkers.Add(float64(discardedStaleMarkers))
+	}
 	if succeededSamplesCount > 0 {
 		i.ingestionRate.Add(int64(succeededSamplesCount))
 
@@ -987,6 +1058,15 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 	return result, nil
 }
 
+// checkReadEndpointEnabled returns an error if the given read endpoint has been disabled in the ingesters
+// for the user, in which case queriers are expected to query the store-gateways only.
+func (i *Ingester) checkReadEndpointEnabled(userID, endpoint string) error {
+	if i.limits.IngesterReadEndpointDisabled(userID, endpoint) {
+		return status.Errorf(codes.FailedPrecondition, "the %s read endpoint is disabled in the ingesters for this tenant", endpoint)
+	}
+	return nil
+}
+
 func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesRequest) (*client.LabelValuesResponse, error) {
 	if err := i.checkRunning(); err != nil {
 		return nil, err
@@ -1002,6 +1082,10 @@ func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesReque
 		return nil, err
 	}
 
+	if err := i.checkReadEndpointEnabled(userID, validation.IngesterReadEndpointLabelValues); err != nil {
+		return nil, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.LabelValuesResponse{}, nil
@@ -1033,6 +1117,10 @@ func (i *Ingester) LabelNames(ctx context.Context, req *client.LabelNamesRequest
 		return nil, err
 	}
 
+	if err := i.checkReadEndpointEnabled(userID, validation.IngesterReadEndpointLabelNames); err != nil {
+		return nil, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.LabelNamesResponse{}, nil
@@ -1070,6 +1158,10 @@ func (i *Ingester) MetricsForLabelMatchers(ctx context.Context, req *client.Metr
 		return nil, err
 	}
 
+	if err := i.checkReadEndpointEnabled(userID, validation.IngesterReadEndpointSeries); err != nil {
+		return nil, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.MetricsForLabelMatchersResponse{}, nil
@@ -1167,6 +1259,33 @@ func (i *Ingester) AllUserStats(ctx context.Context, req *client.UserStatsReques
 	return response, nil
 }
 
+// HeadStats returns statistics about the TSDB head of the tenant.
+func (i *Ingester) HeadStats(ctx context.Context, req *client.HeadStatsRequest) (*client.HeadStatsResponse, error) {
+	if err := i.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	oooWindow := time.Duration(i.limits.OutOfOrderTimeWindow(userID)).Milliseconds()
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		return &client.HeadStatsResponse{OutOfOrderTimeWindowMs: oooWindow}, nil
+	}
+
+	stats, err := db.headStats()
+	if err != nil {
+		return nil, err
+	}
+	stats.OutOfOrderTimeWindowMs = oooWindow
+
+	return stats, nil
+}
+
 // we defined to use the limit of 1 MB because we have default limit for the GRPC message that is 4 MB.
 // So, 1 MB limit will prevent reaching the limit and won't affect performance significantly.
 const labelNamesAndValuesTargetSizeBytes = 1 * 1024 * 1024
@@ -1280,6 +1399,7 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 
 	numSamples := 0
 	numSeries := 0
+	limits := queryStreamLimits{maxSeries: int(req.MaxSeriesHint), maxChunks: int(req.MaxChunksHint)}
 
 	streamType := QueryStreamSamples
 	if i.cfg.StreamChunksWhenUsingBlocks {
@@ -1300,10 +1420,10 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 
 	if streamType == QueryStreamChunks {
 		level.Debug(spanlog).Log("msg", "using queryStreamChunks")
-		numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), matchers, shard, stream)
+		numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), matchers, shard, limits, stream)
 	} else {
 		level.Debug(spanlog).Log("msg", "using queryStreamSamples")
-		numSeries, numSamples, err = i.queryStreamSamples(ctx, db, int64(from), int64(through), matchers, shard, stream)
+		numSeries, numSamples, err = i.queryStreamSamples(ctx, db, int64(from), int64(through), matchers, shard, limits, stream)
 	}
 	if err != nil {
 		return err
@@ -1315,7 +1435,19 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 	return nil
 }
 
-func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
+// queryStreamLimits are the query limits pushed down by the querier. The querier fails the query once
+// a limit is exceeded, so there's no need to stream further series after that.
+type queryStreamLimits struct {
+	maxSeries int
+	maxChunks int
+}
+
+// exceeded returns true if the given number of series or chunks exceeds the limits.
+func (l queryStreamLimits) exceeded(numSeries, numChunks int) bool {
+	return (l.maxSeries > 0 && numSeries > l.maxSeries) || (l.maxChunks > 0 && numChunks > l.maxChunks)
+}
+
+func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, limits queryStreamLimits, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
 	q, err := db.Querier(ctx, from, through)
 	if err != nil {
 		return 0, 0, err
@@ -1368,6 +1500,11 @@ func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, t
 
 		timeseries = append(timeseries, ts)
 		batchSizeBytes += tsSize
+
+		// Stop once the limits are exceeded. The series streamed so far are enough for the querier to fail the query.
+		if limits.exceeded(numSeries, 0) {
+			break
+		}
 	}
 
 	// Ensure no error occurred while iterating the series set.
@@ -1389,7 +1526,7 @@ func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, t
 }
 
 // queryStreamChunks streams metrics from a TSDB. This implements the client.IngesterServer interface
-func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
+func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, limits queryStreamLimits, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
 	var q storage.ChunkQuerier
 	var err error
 	if i.limits.OutOfOrderTimeWindow(db.userID) > 0 {
@@ -1416,6 +1553,7 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 
 	chunkSeries := make([]client.TimeSeriesChunk, 0, queryStreamBatchSize)
 	batchSizeBytes := 0
+	numChunks := 0
 	for ss.Next() {
 		series := ss.At()
 
@@ -1452,6 +1590,7 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 			numSamples += meta.Chunk.NumSamples()
 		}
 		numSeries++
+		numChunks += len(ts.Chunks)
 		tsSize := ts.Size()
 
 		if (batchSizeBytes > 0 && batchSizeBytes+tsSize > queryStreamBatchMessageSize) || len(chunkSeries) >= queryStreamBatchSize {
@@ -1470,6 +1609,11 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 
 		chunkSeries = append(chunkSeries, ts)
 		batchSizeBytes += tsSize
+
+		// Stop once the limits are exceeded. The series streamed so far are enough for the querier to fail the query.
+		if limits.exceeded(numSeries, numChunks) {
+			break
+		}
 	}
 
 	// Ensure no error occurred while iterating the series set.
@@ -1575,6 +1719,9 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 		instanceLimitsFn:    i.getInstanceLimits,
 		instanceSeriesCount: &i.seriesCount,
+		graceSeries:         newGraceSeries(),
+		deltaSeries:         newDeltaSeries(),
+		tsdbMetrics:         tsdbPromReg,
 	}
 
 	maxExemplars := i.limiter.convertGlobalToLocalLimit(userID, i.limits.MaxGlobalExemplarsPerUser(userID))
@@ -1632,12 +1779,18 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 	// Create a new shipper for this database
 	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		var shipperExemplars storage.ExemplarQueryable
+		if i.cfg.BlocksStorageConfig.TSDB.ShipExemplars {
+			shipperExemplars = userDB
+		}
+
 		userDB.shipper = NewShipper(
 			userLogger,
 			tsdbPromReg,
 			udir,
 			bucket.NewUserBucketClient(userID, i.bucket, i.limits),
 			metadata.ReceiveSource,
+			shipperExemplars,
 		)
 
 		// Initialise the shipper blocks cache.
@@ -1930,6 +2083,12 @@ func (i *Ingester) compactionLoop(ctx context.Context) error {
 		case <-ticker.C:
 			i.compactBlocks(ctx, false, nil)
 
+			if threshold := i.cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMemoryThresholdBytes; threshold > 0 {
+				if heapInUse := i.heapInUse(); heapInUse >= threshold {
+					i.compactHeadsEarly(ctx, heapInUse)
+				}
+			}
+
 		case req := <-i.forceCompactTrigger:
 			i.compactBlocks(ctx, true, req.users)
 			close(req.callback) // Notify back.
@@ -1999,6 +2158,65 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 	})
 }
 
+// compactHeadsEarly compacts the samples of the TSDB heads older than the early head compaction min sample age,
+// without waiting for the head to cover the smallest block range, and triggers the shipping of the compacted blocks.
+// It's used to release the memory held by the heads when the memory in use is above the configured threshold.
+func (i *Ingester) compactHeadsEarly(ctx context.Context, heapInUse uint64) {
+	if i.lifecycler != nil {
+		if ingesterState := i.lifecycler.GetState(); ingesterState == ring.JOINING {
+			level.Info(i.logger).Log("msg", "TSDB early head compaction has been skipped because of the current ingester state", "state", ingesterState)
+			return
+		}
+	}
+
+	level.Warn(i.logger).Log("msg", "memory in use is above the threshold, compacting TSDB heads early", "heap_in_use_bytes", heapInUse, "threshold_bytes", i.cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMemoryThresholdBytes)
+	i.metrics.earlyCompactionsTriggered.Inc()
+
+	maxT := time.Now().Add(-i.cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMinSampleAge).UnixMilli()
+
+	_ = concurrency.ForEachUser(ctx, i.getTSDBUsers(), i.cfg.BlocksStorageConfig.TSDB.HeadCompactionConcurrency, func(ctx context.Context, userID string) error {
+		userDB := i.getTSDB(userID)
+		if userDB == nil {
+			return nil
+		}
+
+		// Don't do anything, if there are no samples old enough to be compacted.
+		h := userDB.Head()
+		if h.NumSeries() == 0 || h.MinTime() > maxT {
+			return nil
+		}
+
+		i.metrics.compactionsTriggered.Inc()
+
+		if err := userDB.compactHeadUpTo(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), maxT); err != nil {
+			i.metrics.compactionsFailed.Inc()
+			level.Warn(i.logger).Log("msg", "TSDB blocks compaction for user has failed", "user", userID, "err", err, "compactReason", "early")
+		} else {
+			level.Debug(i.logger).Log("msg", "TSDB blocks compaction completed successfully", "user", userID, "compactReason", "early")
+		}
+
+		return nil
+	})
+
+	// Ship the compacted blocks without waiting for the next shipping interval, unless the shipping is already in progress.
+	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		select {
+		case i.shipTrigger <- requestWithUsersAndCallback{callback: make(chan struct{})}:
+		default:
+		}
+	}
+}
+
+// readHeapInUse returns the memory occupied by the live and not yet freed objects of the Go heap.
+func readHeapInUse() uint64 {
+	sample := []runtimemetrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	runtimemetrics.Read(sample)
+	if sample[0].Value.Kind() != runtimemetrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
 func (i *Ingester) closeAndDeleteIdleUserTSDBs(ctx context.Context) error {
 	for _, userID := range i.getTSDBUsers() {
 		if ctx.Err() != nil {
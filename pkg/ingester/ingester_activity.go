@@ -95,6 +95,15 @@ func (i *ActivityTrackerWrapper) AllUserStats(ctx context.Context, request *clie
 	return i.ing.AllUserStats(ctx, request)
 }
 
+func (i *ActivityTrackerWrapper) HeadStats(ctx context.Context, request *client.HeadStatsRequest) (*client.HeadStatsResponse, error) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(ctx, "Ingester/HeadStats", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.HeadStats(ctx, request)
+}
+
 func (i *ActivityTrackerWrapper) MetricsForLabelMatchers(ctx context.Context, request *client.MetricsForLabelMatchersRequest) (*client.MetricsForLabelMatchersResponse, error) {
 	ix := i.tracker.Insert(func() string {
 		return requestActivity(ctx, "Ingester/MetricsForLabelMatchers", request)
@@ -207,7 +216,17 @@ func queryRequestToString(sb *bytes.Buffer, req *client.QueryRequest) {
 		labelMatcherToString(sb, m)
 		sb.WriteString(",")
 	}
-	sb.WriteString("},}")
+	sb.WriteString("},")
+
+	b = b[:0]
+	sb.WriteString("MaxSeriesHint:")
+	sb.Write(strconv.AppendInt(b, req.MaxSeriesHint, 10))
+	sb.WriteString(",")
+
+	b = b[:0]
+	sb.WriteString("MaxChunksHint:")
+	sb.Write(strconv.AppendInt(b, req.MaxChunksHint, 10))
+	sb.WriteString(",}")
 }
 
 func labelMatcherToString(sb *bytes.Buffer, m *client.LabelMatcher) {
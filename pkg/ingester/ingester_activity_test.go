@@ -72,6 +72,14 @@ func TestQueryRequest_CustomStringer(t *testing.T) {
 				},
 			},
 		},
+		"limits hints": {
+			request: &client.QueryRequest{
+				StartTimestampMs: rand.Int63(),
+				EndTimestampMs:   rand.Int63(),
+				MaxSeriesHint:    rand.Int63(),
+				MaxChunksHint:    rand.Int63(),
+			},
+		},
 	}
 	for tn, tc := range tcs {
 		t.Run(tn, func(t *testing.T) {
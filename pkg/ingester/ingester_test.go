@@ -37,6 +37,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
@@ -47,9 +48,12 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	"github.com/grafana/mimir/pkg/ingester/client"
@@ -1902,6 +1906,28 @@ func TestIngester_LabelNames_ShouldNotCreateTSDBIfDoesNotExists(t *testing.T) {
 	assert.False(t, tsdbCreated)
 }
 
+func TestIngester_DisabledReadEndpoints(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.IngesterDisabledReadEndpoints = []string{validation.IngesterReadEndpointLabelValues, validation.IngesterReadEndpointSeries}
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	_, err = i.LabelValues(ctx, &client.LabelValuesRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = i.MetricsForLabelMatchers(ctx, &client.MetricsForLabelMatchersRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The label names endpoint has not been disabled.
+	_, err = i.LabelNames(ctx, &client.LabelNamesRequest{EndTimestampMs: math.MaxInt64})
+	require.NoError(t, err)
+}
+
 func TestIngester_Push_ShouldNotCreateTSDBIfNotInActiveState(t *testing.T) {
 	// Configure the lifecycler to not immediately join the ring, to make sure
 	// the ingester will NOT be in the ACTIVE state when we'll push samples.
@@ -2649,6 +2675,114 @@ func TestIngester_QueryStreamManySamplesChunks(t *testing.T) {
 	require.Equal(t, 100000+500000+samplesCount, totalSamples)
 }
 
+func TestIngester_QueryStream_ShouldStopStreamingOnceLimitsHintsAreExceeded(t *testing.T) {
+	const numSeries = 10
+
+	tests := map[string]struct {
+		streamChunks   bool
+		maxSeriesHint  int64
+		maxChunksHint  int64
+		expectedSeries int
+	}{
+		"streaming samples, no limits hints": {
+			expectedSeries: numSeries,
+		},
+		"streaming samples, series limit hint exceeded": {
+			maxSeriesHint:  3,
+			expectedSeries: 4,
+		},
+		"streaming samples, series limit hint not exceeded": {
+			maxSeriesHint:  numSeries,
+			expectedSeries: numSeries,
+		},
+		"streaming samples, chunks limit hint is ignored": {
+			maxChunksHint:  3,
+			expectedSeries: numSeries,
+		},
+		"streaming chunks, no limits hints": {
+			streamChunks:   true,
+			expectedSeries: numSeries,
+		},
+		"streaming chunks, series limit hint exceeded": {
+			streamChunks:   true,
+			maxSeriesHint:  3,
+			expectedSeries: 4,
+		},
+		"streaming chunks, chunks limit hint exceeded": {
+			streamChunks:   true,
+			maxChunksHint:  5,
+			expectedSeries: 6,
+		},
+		"streaming chunks, chunks limit hint not exceeded": {
+			streamChunks:   true,
+			maxChunksHint:  numSeries,
+			expectedSeries: numSeries,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.StreamChunksWhenUsingBlocks = testData.streamChunks
+
+			i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			// Wait until it's healthy.
+			test.Poll(t, 1*time.Second, 1, func() interface{} {
+				return i.lifecycler.HealthyInstancesCount()
+			})
+
+			// Push series, each one with a single chunk.
+			ctx := user.InjectOrgID(context.Background(), userID)
+			for n := 0; n < numSeries; n++ {
+				_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "foo", "l", strconv.Itoa(n)), []mimirpb.Sample{{Value: 1, TimestampMs: 10}}))
+				require.NoError(t, err)
+			}
+
+			// Create a GRPC server used to query back the data.
+			serv := grpc.NewServer(grpc.StreamInterceptor(middleware.StreamServerUserHeaderInterceptor))
+			defer serv.GracefulStop()
+			client.RegisterIngesterServer(serv, i)
+
+			listener, err := net.Listen("tcp", "localhost:0")
+			require.NoError(t, err)
+
+			go func() {
+				require.NoError(t, serv.Serve(listener))
+			}()
+
+			// Query back the series using GRPC streaming.
+			c, err := client.MakeIngesterClient(listener.Addr().String(), defaultClientTestConfig())
+			require.NoError(t, err)
+			defer c.Close()
+
+			s, err := c.QueryStream(ctx, &client.QueryRequest{
+				StartTimestampMs: 0,
+				EndTimestampMs:   100,
+				Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+				MaxSeriesHint:    testData.maxSeriesHint,
+				MaxChunksHint:    testData.maxChunksHint,
+			})
+			require.NoError(t, err)
+
+			series := 0
+			for {
+				resp, err := s.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				series += len(resp.Timeseries) + len(resp.Chunkseries)
+			}
+
+			assert.Equal(t, testData.expectedSeries, series)
+		})
+	}
+}
+
 func writeRequestSingleSeries(lbls labels.Labels, samples []mimirpb.Sample) *mimirpb.WriteRequest {
 	req := &mimirpb.WriteRequest{
 		Source: mimirpb.API,
@@ -3813,6 +3947,225 @@ func Test_Ingester_AllUserStats(t *testing.T) {
 	assert.ElementsMatch(t, expect, res.Stats)
 }
 
+func Test_Ingester_HeadStats(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.OutOfOrderTimeWindow = model.Duration(30 * time.Minute)
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	// A tenant without any TSDB should only get the out-of-order time window.
+	res, err := i.HeadStats(user.InjectOrgID(context.Background(), "test"), &client.HeadStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &client.HeadStatsResponse{OutOfOrderTimeWindowMs: (30 * time.Minute).Milliseconds()}, res)
+
+	// Push series, the last one being out-of-order.
+	ctx := user.InjectOrgID(context.Background(), "test")
+	now := time.Now().UnixMilli()
+	for _, s := range []struct {
+		lbls      labels.Labels
+		timestamp int64
+	}{
+		{labels.FromStrings(labels.MetricName, "test_1", "status", "200"), now - 60000},
+		{labels.FromStrings(labels.MetricName, "test_1", "status", "500"), now},
+		{labels.FromStrings(labels.MetricName, "test_1", "status", "200"), now - 120000},
+	} {
+		req, _, _, _ := mockWriteRequest(t, s.lbls, 1, s.timestamp)
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	res, err = i.HeadStats(ctx, &client.HeadStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), res.NumSeries)
+	assert.Equal(t, uint64(3), res.NumChunks) // The out-of-order sample is stored in a separate chunk.
+	assert.Equal(t, now-60000, res.MinTimeMs)
+	assert.Equal(t, now, res.MaxTimeMs)
+	assert.Equal(t, now-120000, res.OooMinTimeMs)
+	assert.Equal(t, now-120000, res.OooMaxTimeMs)
+	assert.Equal(t, (30 * time.Minute).Milliseconds(), res.OutOfOrderTimeWindowMs)
+	assert.Greater(t, res.WalSizeBytes, int64(0))
+	assert.Greater(t, res.WblSizeBytes, int64(0))
+}
+
+func TestIngester_StaleMarkers(t *testing.T) {
+	var (
+		stale = math.Float64frombits(value.StaleNaN)
+		now   = time.Now().UnixMilli()
+
+		upSeries          = labels.FromStrings(labels.MetricName, "up", "job", "app", "instance", "pod-1")
+		activeSeries      = labels.FromStrings(labels.MetricName, "series_1", "job", "app", "instance", "pod-1")
+		staleSeries       = labels.FromStrings(labels.MetricName, "series_2", "job", "app", "instance", "pod-1")
+		inactiveSeries    = labels.FromStrings(labels.MetricName, "series_3", "job", "app", "instance", "pod-1")
+		otherTargetSeries = labels.FromStrings(labels.MetricName, "series_1", "job", "app", "instance", "pod-2")
+		noInstanceUp      = labels.FromStrings(labels.MetricName, "up", "job", "app")
+	)
+
+	tests := map[string]struct {
+		discardOOOStaleMarkers     bool
+		targetDeletionStaleMarkers bool
+		expectedPushErr            bool
+		expectedStale              []labels.Labels
+	}{
+		"should fail on out-of-order staleness markers and not append staleness markers to the series of deleted targets by default": {
+			expectedPushErr: true,
+			expectedStale:   []labels.Labels{upSeries, staleSeries, noInstanceUp},
+		},
+		"should discard out-of-order staleness markers if enabled": {
+			discardOOOStaleMarkers: true,
+			expectedStale:          []labels.Labels{upSeries, staleSeries, noInstanceUp},
+		},
+		"should append staleness markers to the active series of deleted targets if enabled": {
+			discardOOOStaleMarkers:     true,
+			targetDeletionStaleMarkers: true,
+			// The series of the target with no instance label are not marked as stale, since they can't be
+			// told apart from the series of the other targets of the job.
+			expectedStale: []labels.Labels{upSeries, activeSeries, staleSeries, noInstanceUp},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := defaultLimitsTestConfig()
+			limits.DiscardOutOfOrderStaleMarkers = testData.discardOOOStaleMarkers
+			limits.TargetDeletionStaleMarkers = testData.targetDeletionStaleMarkers
+
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, "", nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			// Wait until it's healthy
+			test.Poll(t, 1*time.Second, 1, func() interface{} {
+				return i.lifecycler.HealthyInstancesCount()
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			push := func(series []labels.Labels, samples []mimirpb.Sample) error {
+				_, err := i.Push(ctx, mimirpb.ToWriteRequest(series, samples, nil, nil, mimirpb.API))
+				return err
+			}
+
+			require.NoError(t, push([]labels.Labels{inactiveSeries}, []mimirpb.Sample{{TimestampMs: now - 10*time.Minute.Milliseconds(), Value: 1}}))
+			require.NoError(t, push(
+				[]labels.Labels{upSeries, activeSeries, staleSeries, otherTargetSeries, noInstanceUp},
+				[]mimirpb.Sample{{TimestampMs: now - 15000, Value: 1}, {TimestampMs: now - 15000, Value: 1}, {TimestampMs: now - 15000, Value: 1}, {TimestampMs: now - 15000, Value: 1}, {TimestampMs: now - 15000, Value: 1}},
+			))
+
+			// The targets disappear: staleness markers are sent only for some of their series.
+			require.NoError(t, push(
+				[]labels.Labels{upSeries, staleSeries, noInstanceUp},
+				[]mimirpb.Sample{{TimestampMs: now, Value: stale}, {TimestampMs: now, Value: stale}, {TimestampMs: now, Value: stale}},
+			))
+
+			// An out-of-order staleness marker.
+			err = push([]labels.Labels{otherTargetSeries}, []mimirpb.Sample{{TimestampMs: now - 30000, Value: stale}})
+			if testData.expectedPushErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Check which series are stale.
+			db := i.getTSDB("test")
+			require.NotNil(t, db)
+			q, err := db.Querier(ctx, math.MinInt64, math.MaxInt64)
+			require.NoError(t, err)
+			defer q.Close()
+
+			var actualStale []labels.Labels
+			set := q.Select(true, nil, labels.MustNewMatcher(labels.MatchEqual, "job", "app"))
+			for set.Next() {
+				lastT, lastV, ok := lastSample(set.At().Iterator())
+				require.True(t, ok)
+				if value.IsStaleNaN(lastV) {
+					assert.Equal(t, now, lastT)
+					actualStale = append(actualStale, set.At().Labels())
+				}
+			}
+			require.NoError(t, set.Err())
+
+			sort.Slice(testData.expectedStale, func(i, j int) bool {
+				return labels.Compare(testData.expectedStale[i], testData.expectedStale[j]) < 0
+			})
+			assert.Equal(t, testData.expectedStale, actualStale)
+		})
+	}
+}
+
+func TestIngester_DeltaSeries(t *testing.T) {
+	var (
+		stale = math.Float64frombits(value.StaleNaN)
+		now   = time.Now().UnixMilli()
+
+		deltaSeries      = labels.FromStrings(labels.MetricName, "calls_total", "job", "app")
+		cumulativeSeries = labels.FromStrings(labels.MetricName, "requests_total", "job", "app")
+	)
+
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	push := func(series labels.Labels, delta bool, samples ...mimirpb.Sample) error {
+		req := mimirpb.ToWriteRequest([]labels.Labels{series}, samples[:1], nil, nil, mimirpb.API)
+		req.Timeseries[0].Samples = append(req.Timeseries[0].Samples, samples[1:]...)
+		req.Timeseries[0].Delta = delta
+		_, err := i.Push(ctx, req)
+		return err
+	}
+
+	require.NoError(t, push(deltaSeries, true, mimirpb.Sample{TimestampMs: now - 5000, Value: 1}, mimirpb.Sample{TimestampMs: now - 4000, Value: 2}))
+	require.NoError(t, push(cumulativeSeries, false, mimirpb.Sample{TimestampMs: now - 5000, Value: 10}, mimirpb.Sample{TimestampMs: now - 4000, Value: 12}))
+
+	// The increments of the samples failing to be appended are not added to the cumulative value.
+	require.Error(t, push(deltaSeries, true, mimirpb.Sample{TimestampMs: now - 4500, Value: 100}))
+
+	// The staleness markers are stored as is, and don't change the cumulative value.
+	require.NoError(t, push(deltaSeries, true, mimirpb.Sample{TimestampMs: now - 3000, Value: 5}, mimirpb.Sample{TimestampMs: now - 2000, Value: stale}))
+	require.NoError(t, push(deltaSeries, true, mimirpb.Sample{TimestampMs: now - 1000, Value: 1}))
+
+	db := i.getTSDB("test")
+	require.NotNil(t, db)
+	q, err := db.Querier(ctx, math.MinInt64, math.MaxInt64)
+	require.NoError(t, err)
+	defer q.Close()
+
+	actual := map[string][]string{}
+	set := q.Select(true, nil, labels.MustNewMatcher(labels.MatchEqual, "job", "app"))
+	for set.Next() {
+		it := set.At().Iterator()
+		for it.Next() {
+			_, v := it.At()
+			if value.IsStaleNaN(v) {
+				actual[set.At().Labels().String()] = append(actual[set.At().Labels().String()], "stale")
+				continue
+			}
+			actual[set.At().Labels().String()] = append(actual[set.At().Labels().String()], strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		require.NoError(t, it.Err())
+	}
+	require.NoError(t, set.Err())
+
+	assert.Equal(t, map[string][]string{
+		deltaSeries.String():      {"1", "3", "8", "stale", "9"},
+		cumulativeSeries.String(): {"10", "12"},
+	}, actual)
+}
+
 func TestIngesterCompactIdleBlock(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.BlocksStorageConfig.TSDB.ShipConcurrency = 1
@@ -3891,6 +4244,61 @@ func TestIngesterCompactIdleBlock(t *testing.T) {
     `), "cortex_ingester_memory_series_created_total", "cortex_ingester_memory_series_removed_total", "cortex_ingester_memory_users"))
 }
 
+func TestIngesterCompactHeadsEarly(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval = 100 * time.Millisecond
+	cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMemoryThresholdBytes = 1000
+	cfg.BlocksStorageConfig.TSDB.EarlyHeadCompactionMinSampleAge = time.Hour
+
+	r := prometheus.NewRegistry()
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, r)
+	require.NoError(t, err)
+
+	heapInUse := atomic.NewUint64(0)
+	i.heapInUse = heapInUse.Load
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	// Push samples spanning less than the block range, so that the regular head compaction doesn't compact them.
+	now := time.Now()
+	pushSingleSampleAtTime(t, i, now.Add(-90*time.Minute).UnixMilli())
+	pushSingleSampleAtTime(t, i, now.UnixMilli())
+
+	db := i.getTSDB(userID)
+	require.NotNil(t, db)
+
+	// The head is not compacted while the memory in use is below the threshold.
+	time.Sleep(3 * cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval)
+	require.Len(t, db.Blocks(), 0)
+	require.Equal(t, now.Add(-90*time.Minute).UnixMilli(), db.Head().MinTime())
+
+	// The samples older than the min sample age are compacted once the memory in use is above the threshold.
+	heapInUse.Store(1000)
+	test.Poll(t, 5*time.Second, 1, func() interface{} {
+		return len(db.Blocks())
+	})
+	require.GreaterOrEqual(t, db.Head().MinTime(), now.Add(-time.Hour).UnixMilli())
+	require.Less(t, db.Head().MinTime(), now.UnixMilli())
+	require.Equal(t, uint64(1), db.Head().NumSeries())
+
+	require.NoError(t, testutil.GatherAndCompare(r, strings.NewReader(`
+		# HELP cortex_ingester_tsdb_compactions_failed_total Total number of compactions that failed.
+		# TYPE cortex_ingester_tsdb_compactions_failed_total counter
+		cortex_ingester_tsdb_compactions_failed_total 0
+	`), "cortex_ingester_tsdb_compactions_failed_total"))
+	require.NotZero(t, testutil.ToFloat64(i.metrics.earlyCompactionsTriggered))
+}
+
 func TestIngesterCompactAndCloseIdleTSDB(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.BlocksStorageConfig.TSDB.ShipInterval = 1 * time.Second // Required to enable shipping.
@@ -204,7 +204,15 @@ func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int
 		numIngesters = util_math.Min(numIngesters, util.ShuffleShardExpectedInstances(shardSize, l.getNumZones()))
 	}
 
-	return int((float64(globalLimit) / float64(numIngesters)) * float64(l.replicationFactor))
+	return int((float64(globalLimit) / float64(numIngesters)) * float64(l.getReplicationFactor(userID)))
+}
+
+// getReplicationFactor returns the number of ingesters the series of the tenant are written to.
+func (l *Limiter) getReplicationFactor(userID string) int {
+	if rf := l.limits.IngestionReplicationFactor(userID); rf > 0 && rf < l.replicationFactor {
+		return rf
+	}
+	return l.replicationFactor
 }
 
 func (l *Limiter) getShardSize(userID string) int {
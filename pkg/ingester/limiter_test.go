@@ -77,6 +77,7 @@ func runLimiterMaxFunctionTest(
 		ringIngesterCount        int
 		ringZonesCount           int
 		shardSize                int
+		tenantReplicationFactor  int
 		expectedValue            int
 	}{
 		"limit is disabled": {
@@ -118,6 +119,22 @@ func runLimiterMaxFunctionTest(
 			shardSize:             5,
 			expectedValue:         600,
 		},
+		"limit is enabled with replication-factor=3, tenant replication-factor=1": {
+			globalLimit:             1000,
+			ringReplicationFactor:   3,
+			ringIngesterCount:       10,
+			ringZonesCount:          1,
+			tenantReplicationFactor: 1,
+			expectedValue:           100,
+		},
+		"limit is enabled with replication-factor=1, tenant replication-factor=3": {
+			globalLimit:             1000,
+			ringReplicationFactor:   1,
+			ringIngesterCount:       10,
+			ringZonesCount:          1,
+			tenantReplicationFactor: 3, // Greater than the ring replication factor.
+			expectedValue:           100,
+		},
 		"zone-awareness enabled, limit enabled and the shard size is 0": {
 			globalLimit:              900,
 			ringReplicationFactor:    3,
@@ -166,7 +183,7 @@ func runLimiterMaxFunctionTest(
 			ring.On("ZonesCount").Return(testData.ringZonesCount)
 
 			// Mock limits
-			limits := validation.Limits{IngestionTenantShardSize: testData.shardSize}
+			limits := validation.Limits{IngestionTenantShardSize: testData.shardSize, IngestionReplicationFactor: testData.tenantReplicationFactor}
 			applyLimits(&limits, testData.globalLimit)
 
 			overrides, err := validation.NewOverrides(limits, nil)
@@ -22,6 +22,8 @@ type ingesterMetrics struct {
 	ingestedSamplesFail     *prometheus.CounterVec
 	ingestedExemplarsFail   prometheus.Counter
 	ingestedMetadataFail    prometheus.Counter
+	discardedStaleMarkers   prometheus.Counter
+	injectedStaleMarkers    prometheus.Counter
 	queries                 prometheus.Counter
 	queriedSamples          prometheus.Histogram
 	queriedExemplars        prometheus.Histogram
@@ -44,12 +46,13 @@ type ingesterMetrics struct {
 	inflightRequests        prometheus.GaugeFunc
 
 	// Head compactions metrics.
-	compactionsTriggered   prometheus.Counter
-	compactionsFailed      prometheus.Counter
-	walReplayTime          prometheus.Histogram
-	appenderAddDuration    prometheus.Histogram
-	appenderCommitDuration prometheus.Histogram
-	idleTsdbChecks         *prometheus.CounterVec
+	compactionsTriggered      prometheus.Counter
+	compactionsFailed         prometheus.Counter
+	earlyCompactionsTriggered prometheus.Counter
+	walReplayTime             prometheus.Histogram
+	appenderAddDuration       prometheus.Histogram
+	appenderCommitDuration    prometheus.Histogram
+	idleTsdbChecks            *prometheus.CounterVec
 
 	// Discarded samples
 	discardedSamplesSampleOutOfBounds    *prometheus.CounterVec
@@ -124,6 +127,14 @@ func newIngesterMetrics(
 			Name: "cortex_ingester_ingested_metadata_failures_total",
 			Help: "The total number of metadata that errored on ingestion.",
 		}),
+		discardedStaleMarkers: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_discarded_out_of_order_stale_markers_total",
+			Help: "The total number of staleness markers discarded because out-of-order, without failing the request.",
+		}),
+		injectedStaleMarkers: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_target_deletion_stale_markers_total",
+			Help: "The total number of staleness markers appended by the ingester to the series of targets hinted as deleted.",
+		}),
 		queries: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_ingester_queries_total",
 			Help: "The total number of queries the ingester has handled.",
@@ -254,6 +265,10 @@ func newIngesterMetrics(
 			Name: "cortex_ingester_tsdb_compactions_failed_total",
 			Help: "Total number of compactions that failed.",
 		}),
+		earlyCompactionsTriggered: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_early_compactions_triggered_total",
+			Help: "Total number of times the early head compaction has been triggered because the memory in use was above the threshold.",
+		}),
 		walReplayTime: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
 			Name:    "cortex_ingester_tsdb_wal_replay_duration_seconds",
 			Help:    "The total time it takes to open and replay a TSDB WAL.",
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"sync"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// SeriesPolicyLabel is a pseudo label which can be used in the label matchers of the queries to select the
+	// in-memory series by the policy they've been created under. It's not a label of the series: the ingesters
+	// match it against the policy of each series, which is GraceSeriesPolicy for the series created while the
+	// tenant was exceeding the series limits during the grace period, and the empty string otherwise.
+	SeriesPolicyLabel = "__mimir_series_policy__"
+
+	// GraceSeriesPolicy is the policy of the series created during the series limits grace period.
+	GraceSeriesPolicy = "grace"
+)
+
+// graceSeries keeps track of the in-memory series of a tenant created during the series limits grace period.
+type graceSeries struct {
+	mtx    sync.RWMutex
+	series map[uint64][]labels.Labels // Series labels, by hash.
+}
+
+func newGraceSeries() *graceSeries {
+	return &graceSeries{series: map[uint64][]labels.Labels{}}
+}
+
+func (s *graceSeries) add(lbls labels.Labels) {
+	hash := lbls.Hash()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, l := range s.series[hash] {
+		if labels.Equal(l, lbls) {
+			return
+		}
+	}
+	s.series[hash] = append(s.series[hash], lbls)
+}
+
+func (s *graceSeries) remove(lbls labels.Labels) {
+	hash := lbls.Hash()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	entries := s.series[hash]
+	for i, l := range entries {
+		if labels.Equal(l, lbls) {
+			entries = slices.Delete(entries, i, i+1)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(s.series, hash)
+		return
+	}
+	s.series[hash] = entries
+}
+
+// policyOf returns the policy the series has been created under.
+func (s *graceSeries) policyOf(lbls labels.Labels) string {
+	hash := lbls.Hash()
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for _, l := range s.series[hash] {
+		if labels.Equal(l, lbls) {
+			return GraceSeriesPolicy
+		}
+	}
+	return ""
+}
+
+// splitSeriesPolicyMatchers splits the matchers on the SeriesPolicyLabel from the other ones. If all the matchers
+// are on the SeriesPolicyLabel, a matcher selecting all the series is returned, so that TSDB can run the query.
+func splitSeriesPolicyMatchers(matchers []*labels.Matcher) (other, policy []*labels.Matcher) {
+	for _, m := range matchers {
+		if m.Name == SeriesPolicyLabel {
+			policy = append(policy, m)
+		} else {
+			other = append(other, m)
+		}
+	}
+	if len(policy) > 0 && len(other) == 0 {
+		other = append(other, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+"))
+	}
+	return other, policy
+}
+
+func (s *graceSeries) matches(lbls labels.Labels, policyMatchers []*labels.Matcher) bool {
+	policy := s.policyOf(lbls)
+	for _, m := range policyMatchers {
+		if !m.Matches(policy) {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesPolicyQuerier is a storage.Querier which supports the matchers on the SeriesPolicyLabel.
+type seriesPolicyQuerier struct {
+	storage.Querier
+	graceSeries *graceSeries
+	mint, maxt  int64
+}
+
+func (q *seriesPolicyQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	other, policy := splitSeriesPolicyMatchers(matchers)
+	if len(policy) == 0 {
+		return q.Querier.Select(sortSeries, hints, matchers...)
+	}
+	return &seriesPolicySeriesSet{
+		SeriesSet:      q.Querier.Select(sortSeries, hints, other...),
+		graceSeries:    q.graceSeries,
+		policyMatchers: policy,
+	}
+}
+
+// LabelValues implements storage.Querier. If there are matchers on the SeriesPolicyLabel, the label values
+// are read from the matching series.
+func (q *seriesPolicyQuerier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	if _, policy := splitSeriesPolicyMatchers(matchers); len(policy) == 0 {
+		return q.Querier.LabelValues(name, matchers...)
+	}
+
+	values := map[string]struct{}{}
+	set := q.Select(false, &storage.SelectHints{Start: q.mint, End: q.maxt, Func: "series"}, matchers...)
+	for set.Next() {
+		if v := set.At().Labels().Get(name); v != "" {
+			values[v] = struct{}{}
+		}
+	}
+	return sortedKeys(values), set.Warnings(), set.Err()
+}
+
+// LabelNames implements storage.Querier. If there are matchers on the SeriesPolicyLabel, the label names
+// are read from the matching series.
+func (q *seriesPolicyQuerier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	if _, policy := splitSeriesPolicyMatchers(matchers); len(policy) == 0 {
+		return q.Querier.LabelNames(matchers...)
+	}
+
+	names := map[string]struct{}{}
+	set := q.Select(false, &storage.SelectHints{Start: q.mint, End: q.maxt, Func: "series"}, matchers...)
+	for set.Next() {
+		for _, l := range set.At().Labels() {
+			names[l.Name] = struct{}{}
+		}
+	}
+	return sortedKeys(names), set.Warnings(), set.Err()
+}
+
+// seriesPolicyChunkQuerier is a storage.ChunkQuerier which supports the matchers on the SeriesPolicyLabel
+// when selecting the series.
+type seriesPolicyChunkQuerier struct {
+	storage.ChunkQuerier
+	graceSeries *graceSeries
+}
+
+func (q *seriesPolicyChunkQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.ChunkSeriesSet {
+	other, policy := splitSeriesPolicyMatchers(matchers)
+	if len(policy) == 0 {
+		return q.ChunkQuerier.Select(sortSeries, hints, matchers...)
+	}
+	return &seriesPolicyChunkSeriesSet{
+		ChunkSeriesSet: q.ChunkQuerier.Select(sortSeries, hints, other...),
+		graceSeries:    q.graceSeries,
+		policyMatchers: policy,
+	}
+}
+
+// seriesPolicySeriesSet filters the series of the set by their policy.
+type seriesPolicySeriesSet struct {
+	storage.SeriesSet
+	graceSeries    *graceSeries
+	policyMatchers []*labels.Matcher
+}
+
+func (s *seriesPolicySeriesSet) Next() bool {
+	for s.SeriesSet.Next() {
+		if s.graceSeries.matches(s.At().Labels(), s.policyMatchers) {
+			return true
+		}
+	}
+	return false
+}
+
+// seriesPolicyChunkSeriesSet filters the series of the set by their policy.
+type seriesPolicyChunkSeriesSet struct {
+	storage.ChunkSeriesSet
+	graceSeries    *graceSeries
+	policyMatchers []*labels.Matcher
+}
+
+func (s *seriesPolicyChunkSeriesSet) Next() bool {
+	for s.ChunkSeriesSet.Next() {
+		if s.graceSeries.matches(s.At().Labels(), s.policyMatchers) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/chunkcompat"
+)
+
+func TestGraceSeries(t *testing.T) {
+	series1 := labels.FromStrings(labels.MetricName, "test", "foo", "1")
+	series2 := labels.FromStrings(labels.MetricName, "test", "foo", "2")
+
+	s := newGraceSeries()
+	s.add(series1)
+	s.add(series1)
+	assert.Equal(t, GraceSeriesPolicy, s.policyOf(series1))
+	assert.Equal(t, "", s.policyOf(series2))
+
+	policyMatcher := labels.MustNewMatcher(labels.MatchEqual, SeriesPolicyLabel, GraceSeriesPolicy)
+	assert.True(t, s.matches(series1, []*labels.Matcher{policyMatcher}))
+	assert.False(t, s.matches(series2, []*labels.Matcher{policyMatcher}))
+
+	s.remove(series1)
+	assert.Equal(t, "", s.policyOf(series1))
+	assert.Empty(t, s.series)
+}
+
+func TestSplitSeriesPolicyMatchers(t *testing.T) {
+	nameMatcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "test")
+	policyMatcher := labels.MustNewMatcher(labels.MatchNotEqual, SeriesPolicyLabel, GraceSeriesPolicy)
+
+	other, policy := splitSeriesPolicyMatchers([]*labels.Matcher{nameMatcher})
+	assert.Equal(t, []*labels.Matcher{nameMatcher}, other)
+	assert.Empty(t, policy)
+
+	other, policy = splitSeriesPolicyMatchers([]*labels.Matcher{policyMatcher, nameMatcher})
+	assert.Equal(t, []*labels.Matcher{nameMatcher}, other)
+	assert.Equal(t, []*labels.Matcher{policyMatcher}, policy)
+
+	// A matcher selecting all the series is added if there are only matchers on the policy.
+	other, policy = splitSeriesPolicyMatchers([]*labels.Matcher{policyMatcher})
+	assert.Equal(t, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")}, other)
+	assert.Equal(t, []*labels.Matcher{policyMatcher}, policy)
+}
+
+func TestIngester_SeriesLimitsGracePeriod(t *testing.T) {
+	const userID = "1"
+
+	limits := defaultLimitsTestConfig()
+	limits.MaxGlobalSeriesPerUser = 1
+	limits.SeriesLimitsGracePeriod = model.Duration(time.Hour)
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.ReplicationFactor = 1
+	ing, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, t.TempDir(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), ing))
+	})
+
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return ing.lifecycler.HealthyInstancesCount()
+	})
+
+	series1 := labels.FromStrings(labels.MetricName, "test", "foo", "1")
+	series2 := labels.FromStrings(labels.MetricName, "test", "foo", "2")
+	series3 := labels.FromStrings(labels.MetricName, "test", "foo", "3")
+
+	// The series exceeding the limit are accepted during the grace period.
+	ctx := user.InjectOrgID(context.Background(), userID)
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([]labels.Labels{series1, series2}, []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 1, Value: 2}}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+
+	query := func(matchers ...*labels.Matcher) []string {
+		req, err := client.ToQueryRequest(model.Earliest, model.Latest, matchers)
+		require.NoError(t, err)
+		s := stream{ctx: ctx}
+		require.NoError(t, ing.QueryStream(req, &s))
+
+		res, err := chunkcompat.StreamsToMatrix(model.Earliest, model.Latest, s.responses)
+		require.NoError(t, err)
+
+		var values []string
+		for _, series := range res {
+			values = append(values, string(series.Metric["foo"]))
+		}
+		sort.Strings(values)
+		return values
+	}
+
+	nameMatcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "test")
+	assert.Equal(t, []string{"1", "2"}, query(nameMatcher))
+	assert.Equal(t, []string{"2"}, query(nameMatcher, labels.MustNewMatcher(labels.MatchEqual, SeriesPolicyLabel, GraceSeriesPolicy)))
+	assert.Equal(t, []string{"1"}, query(nameMatcher, labels.MustNewMatcher(labels.MatchNotEqual, SeriesPolicyLabel, GraceSeriesPolicy)))
+	assert.Equal(t, []string{"2"}, query(labels.MustNewMatcher(labels.MatchEqual, SeriesPolicyLabel, GraceSeriesPolicy)))
+
+	labelValuesReq, err := client.ToLabelValuesRequest("foo", model.Earliest, model.Latest, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, SeriesPolicyLabel, GraceSeriesPolicy)})
+	require.NoError(t, err)
+	labelValuesRes, err := ing.LabelValues(ctx, labelValuesReq)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, labelValuesRes.LabelValues)
+
+	// Once the grace period expires, the series exceeding the limit are rejected.
+	ing.getTSDB(userID).seriesLimitsExceededSince.Store(time.Now().Add(-2 * time.Hour).UnixMilli())
+	_, err = ing.Push(ctx, mimirpb.ToWriteRequest([]labels.Labels{series3}, []mimirpb.Sample{{TimestampMs: 1, Value: 3}}, nil, nil, mimirpb.API))
+	require.Error(t, err)
+	assert.Equal(t, []string{"1", "2"}, query(nameMatcher))
+}
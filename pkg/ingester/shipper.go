@@ -20,9 +20,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 )
@@ -71,28 +74,33 @@ type Shipper struct {
 	metrics *metrics
 	bucket  objstore.Bucket
 	source  metadata.SourceType
+
+	// exemplars is the optional source of the exemplars persisted in a sidecar file of each shipped block.
+	exemplars storage.ExemplarQueryable
 }
 
 // NewShipper creates a new uploader that detects new TSDB blocks in dir and uploads them to
 // remote if necessary. It attaches the Thanos metadata section in each meta JSON file.
-// If uploadCompacted is enabled, it also uploads compacted blocks which are already in filesystem.
+// If exemplars is not nil, the exemplars of each block are queried from it and shipped along with the block.
 func NewShipper(
 	logger log.Logger,
 	r prometheus.Registerer,
 	dir string,
 	bucket objstore.Bucket,
 	source metadata.SourceType,
+	exemplars storage.ExemplarQueryable,
 ) *Shipper {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
 
 	return &Shipper{
-		logger:  logger,
-		dir:     dir,
-		bucket:  bucket,
-		metrics: newMetrics(r),
-		source:  source,
+		logger:    logger,
+		dir:       dir,
+		bucket:    bucket,
+		metrics:   newMetrics(r),
+		source:    source,
+		exemplars: exemplars,
 	}
 }
 
@@ -193,10 +201,50 @@ func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
 	meta.Thanos.Source = s.source
 	meta.Thanos.SegmentFiles = block.GetSegmentFiles(blockDir)
 
+	if s.exemplars != nil {
+		if err := s.writeExemplars(ctx, blockDir, meta); err != nil {
+			return errors.Wrap(err, "write exemplars")
+		}
+	}
+
 	// Upload block with custom metadata.
 	return block.Upload(ctx, s.logger, s.bucket, blockDir, meta)
 }
 
+// writeExemplars writes the exemplars sidecar file of the block, containing the exemplars within the block time
+// range. The file is written only once, so that a retried upload ships the same exemplars.
+func (s *Shipper) writeExemplars(ctx context.Context, blockDir string, meta *metadata.Meta) error {
+	if _, err := os.Stat(filepath.Join(blockDir, block.ExemplarsFilename)); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	q, err := s.exemplars.ExemplarQuerier(ctx)
+	if err != nil {
+		return err
+	}
+	if q == nil {
+		return nil
+	}
+
+	// The block max time is exclusive, while the exemplars query end is inclusive.
+	results, err := q.Select(meta.MinTime, meta.MaxTime-1, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")})
+	if err != nil {
+		return err
+	}
+
+	series := make([]mimirpb.TimeSeries, 0, len(results))
+	for _, r := range results {
+		series = append(series, mimirpb.TimeSeries{
+			Labels:    mimirpb.FromLabelsToLabelAdapters(r.SeriesLabels),
+			Exemplars: mimirpb.FromExemplarsToExemplarProtos(r.Exemplars),
+		})
+	}
+
+	return block.WriteExemplarsFile(blockDir, series)
+}
+
 // blockMetasFromOldest returns the block meta of each block found in dir
 // sorted by minTime asc.
 func (s *Shipper) blockMetasFromOldest() (metas []*metadata.Meta, _ error) {
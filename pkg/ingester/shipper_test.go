@@ -20,10 +20,14 @@ import (
 	"github.com/grafana/dskit/concurrency"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
@@ -49,7 +53,7 @@ func TestShipper(t *testing.T) {
 	logs := &concurrency.SyncBuffer{}
 	logger := log.NewLogfmtLogger(logs)
 
-	s := NewShipper(logger, nil, blocksDir, bkt, metadata.TestSource)
+	s := NewShipper(logger, nil, blocksDir, bkt, metadata.TestSource, nil)
 
 	t.Run("no shipper file yet", func(t *testing.T) {
 		// No shipper file = nothing is reported as shipped.
@@ -189,7 +193,7 @@ func TestShipper_DeceivingUploadErrors(t *testing.T) {
 	bkt = deceivingUploadBucket{Bucket: bkt, objectBaseName: block.MetaFilename}
 
 	logger := log.NewLogfmtLogger(os.Stderr)
-	s := NewShipper(logger, nil, blocksDir, bkt, metadata.TestSource)
+	s := NewShipper(logger, nil, blocksDir, bkt, metadata.TestSource, nil)
 
 	// Create and upload a block
 	id1 := ulid.MustNew(1, nil)
@@ -254,7 +258,7 @@ func TestIterBlockMetas(t *testing.T) {
 		},
 	}.WriteToDir(log.NewNopLogger(), path.Join(dir, id3.String())))
 
-	shipper := NewShipper(nil, nil, dir, nil, metadata.TestSource)
+	shipper := NewShipper(nil, nil, dir, nil, metadata.TestSource, nil)
 	metas, err := shipper.blockMetasFromOldest()
 	require.NoError(t, err)
 	require.Equal(t, sort.SliceIsSorted(metas, func(i, j int) bool {
@@ -267,7 +271,7 @@ func TestShipperAddsSegmentFiles(t *testing.T) {
 
 	inmemory := objstore.NewInMemBucket()
 
-	s := NewShipper(nil, nil, dir, inmemory, metadata.TestSource)
+	s := NewShipper(nil, nil, dir, inmemory, metadata.TestSource, nil)
 
 	id := ulid.MustNew(1, nil)
 	blockDir := path.Join(dir, id.String())
@@ -300,6 +304,68 @@ func TestShipperAddsSegmentFiles(t *testing.T) {
 	require.Equal(t, []string{segmentFile}, meta.Thanos.SegmentFiles)
 }
 
+func TestShipperShipsExemplars(t *testing.T) {
+	dir := t.TempDir()
+
+	inmemory := objstore.NewInMemBucket()
+	exemplars := &mockExemplarQueryable{results: []exemplar.QueryResult{{
+		SeriesLabels: labels.FromStrings(labels.MetricName, "series_1"),
+		Exemplars:    []exemplar.Exemplar{{Labels: labels.FromStrings("trace_id", "123"), Value: 1, Ts: 1500, HasTs: true}},
+	}}}
+
+	s := NewShipper(nil, nil, dir, inmemory, metadata.TestSource, exemplars)
+
+	id := ulid.MustNew(1, nil)
+	createBlock(t, dir, id, metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:    id,
+			MaxTime: 2000,
+			MinTime: 1000,
+			Version: 1,
+			Stats: tsdb.BlockStats{
+				NumSamples: 100,
+			},
+		},
+	})
+
+	uploaded, err := s.Sync(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, uploaded)
+
+	// The exemplars have been queried within the block time range.
+	require.Equal(t, int64(1000), exemplars.start)
+	require.Equal(t, int64(1999), exemplars.end)
+
+	meta, err := block.DownloadMeta(context.Background(), log.NewNopLogger(), inmemory, id)
+	require.NoError(t, err)
+	require.True(t, block.HasExemplars(&meta))
+
+	r, err := inmemory.Get(context.Background(), path.Join(id.String(), block.ExemplarsFilename))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, r.Close()) })
+
+	series, err := block.ReadExemplars(r)
+	require.NoError(t, err)
+	require.Equal(t, []mimirpb.TimeSeries{{
+		Labels:    []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "series_1"}},
+		Exemplars: []mimirpb.Exemplar{{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "123"}}, Value: 1, TimestampMs: 1500}},
+	}}, series)
+}
+
+type mockExemplarQueryable struct {
+	results    []exemplar.QueryResult
+	start, end int64
+}
+
+func (m *mockExemplarQueryable) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *mockExemplarQueryable) Select(start, end int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	m.start, m.end = start, end
+	return m.results, nil
+}
+
 func TestReadMetaFile(t *testing.T) {
 	t.Run("Missing meta file", func(t *testing.T) {
 		// Create TSDB directory without meta file
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// targetDeletionLookback is how long, before the deletion of a target, a series of the target must have received
+// a sample to get a staleness marker. It matches the default PromQL lookback delta, because older series are not
+// returned by queries anyway.
+const targetDeletionLookback = 5 * time.Minute
+
+// deletedTarget is a scrape target hinted as deleted by a staleness marker for its up series.
+type deletedTarget struct {
+	job       string
+	instance  string
+	timestamp int64
+}
+
+// deletedTargetFromSeries returns the target hinted as deleted by the input series, if it's the up series of
+// a target and its last sample is a staleness marker.
+func deletedTargetFromSeries(ts mimirpb.PreallocTimeseries) (deletedTarget, bool) {
+	if len(ts.Samples) == 0 {
+		return deletedTarget{}, false
+	}
+
+	last := ts.Samples[len(ts.Samples)-1]
+	if !value.IsStaleNaN(last.Value) {
+		return deletedTarget{}, false
+	}
+
+	target := deletedTarget{timestamp: last.TimestampMs}
+	isUp := false
+	for _, l := range ts.Labels {
+		switch l.Name {
+		case labels.MetricName:
+			isUp = l.Value == "up"
+		case "job":
+			// The labels are unmarshalled without copying the request buffer, so they must be copied to be retained.
+			target.job = strings.Clone(l.Value)
+		case "instance":
+			target.instance = strings.Clone(l.Value)
+		}
+	}
+
+	// The targets with no instance label are skipped, because the series of the target couldn't be told apart
+	// from the series of the other targets of the job.
+	return target, isUp && target.job != "" && target.instance != ""
+}
+
+// countStaleMarkers returns the number of staleness markers in the input samples.
+func countStaleMarkers(samples []mimirpb.Sample) int {
+	count := 0
+	for _, s := range samples {
+		if value.IsStaleNaN(s.Value) {
+			count++
+		}
+	}
+	return count
+}
+
+// isOutOfOrderSampleErr returns whether the input append error means that a newer sample
+// or a sample with the same timestamp has already been ingested.
+func isOutOfOrderSampleErr(err error) bool {
+	//nolint:errorlint // We don't expect the cause error to be wrapped.
+	switch errors.Cause(err) {
+	case storage.ErrOutOfBounds, storage.ErrOutOfOrderSample, storage.ErrTooOldSample, storage.ErrDuplicateSampleForTimestamp:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendTargetDeletionStaleMarkers appends a staleness marker, with the timestamp of the deletion hint, to all
+// the series of the deleted targets which received a sample in the targetDeletionLookback period before the
+// deletion and have not been marked as stale yet. Returns the number of appended staleness markers.
+func appendTargetDeletionStaleMarkers(ctx context.Context, db *userTSDB, targets []deletedTarget) (int, error) {
+	app := db.Appender(ctx)
+
+	appended := 0
+	for _, target := range targets {
+		n, err := appendStaleMarkersForTarget(db, app, target)
+		if err != nil {
+			_ = app.Rollback()
+			return 0, err
+		}
+		appended += n
+	}
+
+	if err := app.Commit(); err != nil {
+		return 0, err
+	}
+	return appended, nil
+}
+
+// appendStaleMarkersForTarget looks up the series of the target in the head, and reads only their last chunk
+// to find their last sample, instead of querying all their samples.
+func appendStaleMarkersForTarget(db *userTSDB, app storage.Appender, target deletedTarget) (int, error) {
+	// The chunks with no sample in the lookback period are not returned by the range head.
+	head := tsdb.NewRangeHead(db.Head(), target.timestamp-targetDeletionLookback.Milliseconds(), math.MaxInt64)
+
+	idx, err := head.Index()
+	if err != nil {
+		return 0, err
+	}
+	defer idx.Close()
+
+	chunkr, err := head.Chunks()
+	if err != nil {
+		return 0, err
+	}
+	defer chunkr.Close()
+
+	postings, err := tsdb.PostingsForMatchers(idx,
+		labels.MustNewMatcher(labels.MatchEqual, "job", target.job),
+		labels.MustNewMatcher(labels.MatchEqual, "instance", target.instance))
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		appended = 0
+		lbls     labels.Labels
+		chks     []chunks.Meta
+	)
+	for postings.Next() {
+		ref := postings.At()
+		if err := idx.Series(ref, &lbls, &chks); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				// The series has been garbage collected in the meanwhile.
+				continue
+			}
+			return 0, err
+		}
+		if len(chks) == 0 {
+			continue
+		}
+
+		chk, err := chunkr.Chunk(chks[len(chks)-1])
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return 0, err
+		}
+
+		lastT, lastV, ok := lastSample(chk.Iterator(nil))
+		if !ok || lastT >= target.timestamp || value.IsStaleNaN(lastV) {
+			continue
+		}
+
+		if _, err := app.Append(ref, lbls, target.timestamp, math.Float64frombits(value.StaleNaN)); err != nil {
+			if isOutOfOrderSampleErr(err) {
+				continue
+			}
+			return 0, err
+		}
+		appended++
+	}
+
+	return appended, postings.Err()
+}
+
+// lastSample returns the timestamp and value of the last sample of the input iterator, and whether there's any sample.
+func lastSample(it chunkenc.Iterator) (int64, float64, bool) {
+	var (
+		t  int64
+		v  float64
+		ok bool
+	)
+	for it.Next() {
+		t, v = it.At()
+		ok = true
+	}
+	return t, v, ok && it.Err() == nil
+}
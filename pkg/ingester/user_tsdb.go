@@ -7,17 +7,25 @@ package ingester
 
 import (
 	"context"
+	"math"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/wal"
 	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/extract"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 )
@@ -63,6 +71,14 @@ type userTSDB struct {
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
 	instanceLimitsFn    func() *InstanceLimits
 
+	// The in-memory series created during the series limits grace period, and the Unix timestamp (in milliseconds)
+	// since which the tenant has been exceeding the series limits (0 if it's not exceeding them).
+	graceSeries               *graceSeries
+	seriesLimitsExceededSince atomic.Int64
+
+	// The cumulative value of the in-memory series pushed with delta temporality.
+	deltaSeries *deltaSeries
+
 	stateMtx       sync.RWMutex
 	state          tsdbState
 	pushesInFlight sync.WaitGroup // Increased with stateMtx read lock held, only if state == active or activeShipping.
@@ -87,6 +103,9 @@ type userTSDB struct {
 	// Cached shipped blocks.
 	shippedBlocksMtx sync.Mutex
 	shippedBlocks    map[ulid.ULID]struct{}
+
+	// Gatherer of the TSDB metrics, used to compute the head stats.
+	tsdbMetrics prometheus.Gatherer
 }
 
 // Explicitly wrapping the tsdb.DB functions that we use.
@@ -96,16 +115,29 @@ func (u *userTSDB) Appender(ctx context.Context) storage.Appender {
 }
 
 // Querier returns a new querier over the data partition for the given time range.
+// The returned querier supports the matchers on the SeriesPolicyLabel.
 func (u *userTSDB) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return u.db.Querier(ctx, mint, maxt)
+	q, err := u.db.Querier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &seriesPolicyQuerier{Querier: q, graceSeries: u.graceSeries, mint: mint, maxt: maxt}, nil
 }
 
 func (u *userTSDB) ChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.ChunkQuerier(ctx, mint, maxt)
+	q, err := u.db.ChunkQuerier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &seriesPolicyChunkQuerier{ChunkQuerier: q, graceSeries: u.graceSeries}, nil
 }
 
 func (u *userTSDB) UnorderedChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.UnorderedChunkQuerier(ctx, mint, maxt)
+	q, err := u.db.UnorderedChunkQuerier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &seriesPolicyChunkQuerier{ChunkQuerier: q, graceSeries: u.graceSeries}, nil
 }
 
 func (u *userTSDB) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
@@ -132,6 +164,53 @@ func (u *userTSDB) StartTime() (int64, error) {
 	return u.db.StartTime()
 }
 
+// headStats returns statistics about the TSDB head. The out-of-order time window is not set.
+func (u *userTSDB) headStats() (*client.HeadStatsResponse, error) {
+	h := u.Head()
+	stats := &client.HeadStatsResponse{
+		NumSeries: h.NumSeries(),
+	}
+
+	// Time ranges are left to zero when the head (or the out-of-order head) is empty.
+	if minT, maxT := h.MinTime(), h.MaxTime(); minT <= maxT {
+		stats.MinTimeMs, stats.MaxTimeMs = minT, maxT
+	}
+	if minT, maxT := h.MinOOOTime(), h.MaxOOOTime(); minT <= maxT {
+		stats.OooMinTimeMs, stats.OooMaxTimeMs = minT, maxT
+	}
+
+	if u.tsdbMetrics != nil {
+		mfs, err := u.tsdbMetrics.Gather()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to gather TSDB metrics")
+		}
+		mfm, err := util.NewMetricFamilyMap(mfs)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to gather TSDB metrics")
+		}
+		stats.NumChunks = uint64(mfm.SumGauges("prometheus_tsdb_head_chunks"))
+	}
+
+	var err error
+	if stats.WalSizeBytes, err = walDirSize(filepath.Join(u.db.Dir(), "wal")); err != nil {
+		return nil, errors.Wrap(err, "failed to compute WAL size")
+	}
+	if stats.WblSizeBytes, err = walDirSize(filepath.Join(u.db.Dir(), wal.WblDirName)); err != nil {
+		return nil, errors.Wrap(err, "failed to compute out-of-order WAL size")
+	}
+
+	return stats, nil
+}
+
+// walDirSize returns the size of the WAL at dir, or 0 if it doesn't exist.
+func walDirSize(dir string) (int64, error) {
+	size, err := fileutil.DirSize(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	return size, err
+}
+
 func (u *userTSDB) casState(from, to tsdbState) bool {
 	u.stateMtx.Lock()
 	defer u.stateMtx.Unlock()
@@ -145,6 +224,12 @@ func (u *userTSDB) casState(from, to tsdbState) bool {
 
 // compactHead compacts the Head block at specified block durations avoiding a single huge block.
 func (u *userTSDB) compactHead(blockDuration int64) error {
+	return u.compactHeadUpTo(blockDuration, math.MaxInt64)
+}
+
+// compactHeadUpTo compacts the head samples with timestamp lower than or equal to maxT, breaking them
+// into blocks aligned to the block duration. The samples newer than maxT are kept in the head.
+func (u *userTSDB) compactHeadUpTo(blockDuration, maxT int64) error {
 	if !u.casState(active, forceCompacting) {
 		return errors.New("TSDB head cannot be compacted because it is not in active state (possibly being closed or blocks shipping in progress)")
 	}
@@ -158,9 +243,9 @@ func (u *userTSDB) compactHead(blockDuration int64) error {
 
 	h := u.Head()
 
-	minTime, maxTime := h.MinTime(), h.MaxTime()
+	minTime, maxTime := h.MinTime(), util_math.Min64(h.MaxTime(), maxT)
 
-	for (minTime/blockDuration)*blockDuration != (maxTime/blockDuration)*blockDuration {
+	for minTime <= maxTime && (minTime/blockDuration)*blockDuration != (maxTime/blockDuration)*blockDuration {
 		// Data in Head spans across multiple block ranges, so we break it into blocks here.
 		// Block max time is exclusive, so we do a -1 here.
 		blockMaxTime := ((minTime/blockDuration)+1)*blockDuration - 1
@@ -169,7 +254,11 @@ func (u *userTSDB) compactHead(blockDuration int64) error {
 		}
 
 		// Get current min/max times after compaction.
-		minTime, maxTime = h.MinTime(), h.MaxTime()
+		minTime, maxTime = h.MinTime(), util_math.Min64(h.MaxTime(), maxT)
+	}
+
+	if minTime > maxTime {
+		return nil
 	}
 
 	return u.db.CompactHead(tsdb.NewRangeHead(h, minTime, maxTime))
@@ -189,23 +278,40 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 		}
 	}
 
-	// Total series limit.
-	if err := u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries())); err != nil {
-		return err
-	}
-
-	// Series per metric name limit.
 	metricName, err := extract.MetricNameFromLabels(metric)
 	if err != nil {
 		return err
 	}
-	if err := u.seriesInMetric.canAddSeriesFor(u.userID, metricName); err != nil {
-		return err
+
+	// Total series limit and series per metric name limit.
+	err = u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries()))
+	if err == nil {
+		err = u.seriesInMetric.canAddSeriesFor(u.userID, metricName)
+	}
+	if err != nil {
+		if !u.inSeriesLimitsGracePeriod(time.Now()) {
+			return err
+		}
+		u.graceSeries.add(metric)
+		return nil
 	}
 
+	u.seriesLimitsExceededSince.Store(0)
 	return nil
 }
 
+// inSeriesLimitsGracePeriod returns whether the series exceeding the series limits can be created, because the
+// tenant started exceeding them within the grace period. It must be called when the limits are exceeded.
+func (u *userTSDB) inSeriesLimitsGracePeriod(now time.Time) bool {
+	gracePeriod := u.limiter.limits.SeriesLimitsGracePeriod(u.userID)
+	if gracePeriod <= 0 {
+		return false
+	}
+
+	u.seriesLimitsExceededSince.CompareAndSwap(0, now.UnixMilli())
+	return now.Sub(time.UnixMilli(u.seriesLimitsExceededSince.Load())) < gracePeriod
+}
+
 // PostCreation implements SeriesLifecycleCallback interface.
 func (u *userTSDB) PostCreation(metric labels.Labels) {
 	u.instanceSeriesCount.Inc()
@@ -223,6 +329,9 @@ func (u *userTSDB) PostDeletion(metrics ...labels.Labels) {
 	u.instanceSeriesCount.Sub(int64(len(metrics)))
 
 	for _, metric := range metrics {
+		u.graceSeries.remove(metric)
+		u.deltaSeries.remove(metric)
+
 		metricName, err := extract.MetricNameFromLabels(metric)
 		if err != nil {
 			// This should never happen because it has already been checked in PreCreation().
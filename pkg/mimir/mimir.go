@@ -67,9 +67,12 @@ import (
 	"github.com/grafana/mimir/pkg/usagestats"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/gctuning"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/memberlistsnapshot"
 	"github.com/grafana/mimir/pkg/util/noauth"
 	"github.com/grafana/mimir/pkg/util/process"
+	"github.com/grafana/mimir/pkg/util/queryjournal"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -122,9 +125,10 @@ type Config struct {
 	Alertmanager        alertmanager.MultitenantAlertmanagerConfig `yaml:"alertmanager"`
 	AlertmanagerStorage alertstore.Config                          `yaml:"alertmanager_storage"`
 	RuntimeConfig       runtimeconfig.Config                       `yaml:"runtime_config"`
-	MemberlistKV        memberlist.KVConfig                        `yaml:"memberlist"`
+	MemberlistKV        MemberlistKVConfig                         `yaml:"memberlist"`
 	QueryScheduler      scheduler.Config                           `yaml:"query_scheduler"`
 	UsageStats          usagestats.Config                          `yaml:"usage_stats"`
+	GCTuning            gctuning.Config                            `yaml:"gc_tuning"`
 
 	Common CommonConfig `yaml:"common"`
 }
@@ -172,6 +176,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	c.ActivityTracker.RegisterFlags(f)
 	c.QueryScheduler.RegisterFlags(f, logger)
 	c.UsageStats.RegisterFlags(f)
+	c.GCTuning.RegisterFlags(f)
 
 	c.Common.RegisterFlags(f, logger)
 }
@@ -213,6 +218,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.RulerStorage.Validate(); err != nil {
 		return errors.Wrap(err, "invalid rulestore config")
 	}
+	if err := c.LimitsConfig.Validate(); err != nil {
+		return errors.Wrap(err, "invalid limits config")
+	}
 	if err := c.Ruler.Validate(c.LimitsConfig, log); err != nil {
 		return errors.Wrap(err, "invalid ruler config")
 	}
@@ -225,6 +233,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Querier.Validate(); err != nil {
 		return errors.Wrap(err, "invalid querier config")
 	}
+	if err := c.TenantFederation.Validate(); err != nil {
+		return errors.Wrap(err, "invalid tenant_federation config")
+	}
 	if c.Querier.EngineConfig.Timeout > c.Server.HTTPServerWriteTimeout {
 		return fmt.Errorf("querier timeout (%s) must be lower than or equal to HTTP server write timeout (%s)",
 			c.Querier.EngineConfig.Timeout, c.Server.HTTPServerWriteTimeout)
@@ -253,6 +264,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.UsageStats.Validate(); err != nil {
 		return errors.Wrap(err, "invalid usage stats config")
 	}
+	if err := c.GCTuning.Validate(); err != nil {
+		return errors.Wrap(err, "invalid GC tuning config")
+	}
 	if c.isAnyModuleEnabled(AlertManager, Backend) {
 		if err := c.Alertmanager.Validate(); err != nil {
 			return errors.Wrap(err, "invalid alertmanager config")
@@ -596,6 +610,19 @@ func (c *CommonConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	c.Storage.RegisterFlagsWithPrefix("common.storage.", f, logger)
 }
 
+// MemberlistKVConfig extends the memberlist KV config with the config of the full state snapshots.
+type MemberlistKVConfig struct {
+	memberlist.KVConfig `yaml:",inline"`
+
+	Snapshot memberlistsnapshot.Config `yaml:",inline"`
+}
+
+// RegisterFlags registers flag.
+func (c *MemberlistKVConfig) RegisterFlags(f *flag.FlagSet) {
+	c.KVConfig.RegisterFlags(f)
+	c.Snapshot.RegisterFlags(f)
+}
+
 // configWithCustomCommonUnmarshaler unmarshals config with custom unmarshaler for the `common` field.
 type configWithCustomCommonUnmarshaler struct {
 	// Common will unmarshal `common` yaml key using a custom unmarshaler.
@@ -656,11 +683,15 @@ type Mimir struct {
 	StoreGateway             *storegateway.StoreGateway
 	MemberlistKV             *memberlist.KVInitService
 	ActivityTracker          *activitytracker.ActivityTracker
+	QueryJournal             *queryjournal.Journal
 	UsageStatsReporter       *usagestats.Reporter
 	BuildInfoHandler         http.Handler
 
 	// Queryables that the querier should use to query the long term storage.
 	StoreQueryables []querier.QueryableWithFilter
+
+	// Exemplar queryable that the querier should use to query the exemplars persisted in the long term storage.
+	StoreExemplarQueryable prom_storage.ExemplarQueryable
 }
 
 // New makes a new Mimir.
@@ -54,7 +54,10 @@ import (
 	"github.com/grafana/mimir/pkg/usagestats"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/gctuning"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/memberlistsnapshot"
+	"github.com/grafana/mimir/pkg/util/queryjournal"
 	"github.com/grafana/mimir/pkg/util/validation"
 	"github.com/grafana/mimir/pkg/util/version"
 )
@@ -62,6 +65,7 @@ import (
 // The various modules that make up Mimir.
 const (
 	ActivityTracker          string = "activity-tracker"
+	GCTuning                 string = "gc-tuning"
 	API                      string = "api"
 	SanityCheck              string = "sanity-check"
 	Ring                     string = "ring"
@@ -79,6 +83,7 @@ const (
 	StoreQueryable           string = "store-queryable"
 	QueryFrontend            string = "query-frontend"
 	QueryFrontendTripperware string = "query-frontend-tripperware"
+	QueryJournal             string = "query-journal"
 	RulerStorage             string = "ruler-storage"
 	Ruler                    string = "ruler"
 	AlertManager             string = "alertmanager"
@@ -166,6 +171,14 @@ func (t *Mimir) initActivityTracker() (services.Service, error) {
 	}), nil
 }
 
+func (t *Mimir) initGCTuning() (services.Service, error) {
+	if !t.Cfg.GCTuning.Enabled {
+		return nil, nil
+	}
+
+	return gctuning.NewManager(t.Cfg.GCTuning, util_log.Logger, t.Registerer), nil
+}
+
 func (t *Mimir) initSanityCheck() (services.Service, error) {
 	return services.NewIdleService(func(ctx context.Context) error {
 		return runSanityCheck(ctx, t.Cfg, util_log.Logger)
@@ -295,7 +308,7 @@ func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 }
 
 func (t *Mimir) initDistributor() (serv services.Service, err error) {
-	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor, t.Registerer)
+	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor, t.Overrides, t.Registerer)
 
 	return nil, nil
 }
@@ -306,7 +319,15 @@ func (t *Mimir) initQueryable() (serv services.Service, err error) {
 	querierRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "querier"}, t.Registerer)
 
 	// Create a querier queryable and PromQL engine
-	t.QuerierQueryable, t.ExemplarQueryable, t.QuerierEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, util_log.Logger, t.ActivityTracker)
+	t.QuerierQueryable, t.ExemplarQueryable, t.QuerierEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, t.StoreExemplarQueryable, querierRegisterer, util_log.Logger, t.ActivityTracker)
+
+	if t.Cfg.Querier.LookupTablesEnabled {
+		bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "querier-lookup-tables", util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the bucket client for the lookup tables")
+		}
+		t.QuerierQueryable = querier.NewLookupTablesQueryable(t.QuerierQueryable, bucketClient, t.Cfg.Querier.LookupTablesCacheTTL, util_log.Logger, t.Registerer)
+	}
 
 	// Use the distributor to return metric metadata by default
 	t.MetadataSupplier = t.Distributor
@@ -321,11 +342,13 @@ func (t *Mimir) initQueryable() (serv services.Service, err error) {
 func (t *Mimir) initTenantFederation() (serv services.Service, err error) {
 	if t.Cfg.TenantFederation.Enabled {
 		// Make sure the mergeQuerier is only used for request with more than a
-		// single tenant. This allows for a less impactful enabling of tenant
+		// single tenant, unless the tenant label is injected for single tenant
+		// queries too. This allows for a less impactful enabling of tenant
 		// federation.
-		const bypassForSingleQuerier = true
-		t.QuerierQueryable = querier.NewSampleAndChunkQueryable(tenantfederation.NewQueryable(t.QuerierQueryable, bypassForSingleQuerier, util_log.Logger))
-		t.ExemplarQueryable = tenantfederation.NewExemplarQueryable(t.ExemplarQueryable, bypassForSingleQuerier, util_log.Logger)
+		bypassForSingleQuerier := !t.Cfg.TenantFederation.SingleTenantLabelEnabled
+		tenantLabelName := t.Cfg.TenantFederation.TenantLabelName
+		t.QuerierQueryable = querier.NewSampleAndChunkQueryable(tenantfederation.NewMergeQueryable(tenantLabelName, tenantfederation.TenantQuerierCallback(t.QuerierQueryable), bypassForSingleQuerier, util_log.Logger))
+		t.ExemplarQueryable = tenantfederation.NewMergeExemplarQueryable(tenantLabelName, t.ExemplarQueryable, bypassForSingleQuerier, util_log.Logger)
 		t.MetadataSupplier = tenantfederation.NewMetadataSupplier(t.MetadataSupplier, util_log.Logger)
 	}
 	return nil, nil
@@ -396,6 +419,7 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 		t.Registerer,
 		util_log.Logger,
 		t.Overrides,
+		querier.NewTenantConcurrencyLimiter(t.Overrides, t.Cfg.Querier.TenantQueueTimeout, util_log.Logger, t.Registerer),
 	)
 
 	// If the querier is running standalone without the query-frontend or query-scheduler, we must register it's internal
@@ -445,6 +469,8 @@ func (t *Mimir) initStoreQueryables() (services.Service, error) {
 		return nil, fmt.Errorf("failed to initialize querier: %v", err)
 	} else {
 		t.StoreQueryables = append(t.StoreQueryables, querier.UseAlwaysQueryable(q))
+		t.StoreExemplarQueryable = q
+		t.API.RegisterBlocksStoreQueryable(q)
 		servs = append(servs, q)
 	}
 
@@ -511,6 +537,8 @@ func (t *Mimir) initFlusher() (serv services.Service, err error) {
 // initQueryFrontendTripperware instantiates the tripperware used by the query frontend
 // to optimize Prometheus query requests.
 func (t *Mimir) initQueryFrontendTripperware() (serv services.Service, err error) {
+	t.Cfg.Frontend.QueryMiddleware.BlockRanges = t.Cfg.Compactor.BlockRanges
+
 	promqlEngineRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "query-frontend"}, t.Registerer)
 
 	tripperware, err := querymiddleware.NewTripperware(
@@ -530,6 +558,19 @@ func (t *Mimir) initQueryFrontendTripperware() (serv services.Service, err error
 	return nil, nil
 }
 
+func (t *Mimir) initQueryJournal() (services.Service, error) {
+	journal, err := queryjournal.NewJournal(t.Cfg.Frontend.Handler.QueryJournal, util_log.Logger, t.Registerer)
+	if err != nil || journal == nil {
+		return nil, err
+	}
+
+	t.QueryJournal = journal
+
+	return services.NewIdleService(nil, func(_ error) error {
+		return t.QueryJournal.Close()
+	}), nil
+}
+
 func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	t.Cfg.Frontend.FrontendV2.QuerySchedulerDiscovery = t.Cfg.QueryScheduler.ServiceDiscovery
 
@@ -541,7 +582,7 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
-	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, t.Registerer, t.ActivityTracker)
+	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, t.Registerer, t.ActivityTracker, t.QueryJournal)
 	t.API.RegisterQueryFrontendHandler(handler, t.BuildInfoHandler)
 
 	if frontendV1 != nil {
@@ -602,19 +643,19 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 		// TODO: Consider wrapping logger to differentiate from querier module logger
 		rulerRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "ruler"}, t.Registerer)
 
-		queryable, _, eng := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, rulerRegisterer, util_log.Logger, t.ActivityTracker)
+		queryable, _, eng := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, nil, rulerRegisterer, util_log.Logger, t.ActivityTracker)
 		queryable = querier.NewErrorTranslateQueryableWithFn(queryable, ruler.WrapQueryableErrors)
 
 		if t.Cfg.Ruler.TenantFederation.Enabled {
 			if !t.Cfg.TenantFederation.Enabled {
 				return nil, errors.New("-ruler.tenant-federation.enabled=true requires -tenant-federation.enabled=true")
 			}
-			// Setting bypassForSingleQuerier=false forces `tenantfederation.NewQueryable` to add
-			// the `__tenant_id__` label on all metrics regardless if they're for a single tenant or multiple tenants.
+			// Setting bypassForSingleQuerier=false forces `tenantfederation.NewMergeQueryable` to add
+			// the tenant label on all metrics regardless if they're for a single tenant or multiple tenants.
 			// This makes this label more consistent and hopefully less confusing to users.
 			const bypassForSingleQuerier = false
 
-			federatedQueryable = tenantfederation.NewQueryable(queryable, bypassForSingleQuerier, util_log.Logger)
+			federatedQueryable = tenantfederation.NewMergeQueryable(t.Cfg.TenantFederation.TenantLabelName, tenantfederation.TenantQuerierCallback(queryable), bypassForSingleQuerier, util_log.Logger)
 
 			regularQueryFunc := rules.EngineQueryFunc(eng, queryable)
 			federatedQueryFunc := rules.EngineQueryFunc(eng, federatedQueryable)
@@ -647,7 +688,7 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 	)
 
 	dnsResolver := dns.NewProvider(util_log.Logger, dnsProviderReg, dns.GolangResolverType)
-	manager, err := ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, t.Registerer, util_log.Logger, dnsResolver)
+	manager, err := ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, t.Overrides, t.Registerer, util_log.Logger, dnsResolver)
 	if err != nil {
 		return nil, err
 	}
@@ -664,6 +705,16 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 		return
 	}
 
+	if t.Cfg.Ruler.Reports.Enabled {
+		reportStore, err := ruler.NewReportStoreFromConfig(context.Background(), t.Cfg.RulerStorage, t.Overrides, util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Ruler.EnableReports(ruler.NewReportsScheduler(t.Cfg.Ruler.Reports, reportStore, queryFunc, util_log.Logger, t.Registerer))
+		t.API.RegisterRulerReports(ruler.NewReportsAPI(reportStore, t.Overrides, util_log.Logger))
+	}
+
 	// Expose HTTP/GRPC admin endpoints for the Ruler service
 	t.API.RegisterRuler(t.Ruler)
 
@@ -732,17 +783,26 @@ func (t *Mimir) initMemberlistKV() (services.Service, error) {
 		),
 	)
 	dnsProvider := dns.NewProvider(util_log.Logger, dnsProviderReg, dns.GolangResolverType)
-	t.MemberlistKV = memberlist.NewKVInitService(&t.Cfg.MemberlistKV, util_log.Logger, dnsProvider, reg)
+	t.MemberlistKV = memberlist.NewKVInitService(&t.Cfg.MemberlistKV.KVConfig, util_log.Logger, dnsProvider, reg)
 	t.API.RegisterMemberlistKV(t.Cfg.Server.PathPrefix, t.MemberlistKV)
+	if t.Cfg.MemberlistKV.Snapshot.ServeEnabled {
+		t.API.RegisterMemberlistSnapshot(memberlistsnapshot.Handler(t.MemberlistKV, util_log.Logger))
+	}
+
+	// Load the full state snapshot from the seeds as soon as the memberlist KV is started by its first user.
+	getMemberlistKV := t.MemberlistKV.GetMemberlistKV
+	if len(t.Cfg.MemberlistKV.Snapshot.SeedAddresses) > 0 {
+		getMemberlistKV = memberlistsnapshot.NewSeeder(t.Cfg.MemberlistKV.Snapshot, util_log.Logger, reg).WrapGetMemberlistKV(getMemberlistKV)
+	}
 
 	// Update the config.
-	t.Cfg.Distributor.DistributorRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
-	t.Cfg.Ingester.IngesterRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
-	t.Cfg.StoreGateway.ShardingRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
-	t.Cfg.Compactor.ShardingRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
-	t.Cfg.Ruler.Ring.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
-	t.Cfg.Alertmanager.ShardingRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
-	t.Cfg.QueryScheduler.ServiceDiscovery.SchedulerRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
+	t.Cfg.Distributor.DistributorRing.KVStore.MemberlistKV = getMemberlistKV
+	t.Cfg.Ingester.IngesterRing.KVStore.MemberlistKV = getMemberlistKV
+	t.Cfg.StoreGateway.ShardingRing.KVStore.MemberlistKV = getMemberlistKV
+	t.Cfg.Compactor.ShardingRing.KVStore.MemberlistKV = getMemberlistKV
+	t.Cfg.Ruler.Ring.KVStore.MemberlistKV = getMemberlistKV
+	t.Cfg.Alertmanager.ShardingRing.KVStore.MemberlistKV = getMemberlistKV
+	t.Cfg.QueryScheduler.ServiceDiscovery.SchedulerRing.KVStore.MemberlistKV = getMemberlistKV
 
 	return t.MemberlistKV, nil
 }
@@ -791,6 +851,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(Server, t.initServer, modules.UserInvisibleModule)
 	mm.RegisterModule(ActivityTracker, t.initActivityTracker, modules.UserInvisibleModule)
 	mm.RegisterModule(SanityCheck, t.initSanityCheck, modules.UserInvisibleModule)
+	mm.RegisterModule(GCTuning, t.initGCTuning, modules.UserInvisibleModule)
 	mm.RegisterModule(API, t.initAPI, modules.UserInvisibleModule)
 	mm.RegisterModule(RuntimeConfig, t.initRuntimeConfig, modules.UserInvisibleModule)
 	mm.RegisterModule(MemberlistKV, t.initMemberlistKV, modules.UserInvisibleModule)
@@ -806,6 +867,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(Querier, t.initQuerier)
 	mm.RegisterModule(StoreQueryable, t.initStoreQueryables, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryFrontendTripperware, t.initQueryFrontendTripperware, modules.UserInvisibleModule)
+	mm.RegisterModule(QueryJournal, t.initQueryJournal, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryFrontend, t.initQueryFrontend)
 	mm.RegisterModule(RulerStorage, t.initRulerStorage, modules.UserInvisibleModule)
 	mm.RegisterModule(Ruler, t.initRuler)
@@ -822,7 +884,7 @@ func (t *Mimir) setupModuleManager() error {
 
 	// Add dependencies
 	deps := map[string][]string{
-		Server:                   {ActivityTracker, SanityCheck, UsageStats},
+		Server:                   {ActivityTracker, SanityCheck, UsageStats, GCTuning},
 		API:                      {Server},
 		MemberlistKV:             {API},
 		RuntimeConfig:            {API},
@@ -838,7 +900,7 @@ func (t *Mimir) setupModuleManager() error {
 		Querier:                  {TenantFederation},
 		StoreQueryable:           {Overrides, MemberlistKV},
 		QueryFrontendTripperware: {API, Overrides},
-		QueryFrontend:            {QueryFrontendTripperware, MemberlistKV},
+		QueryFrontend:            {QueryFrontendTripperware, QueryJournal, MemberlistKV},
 		QueryScheduler:           {API, Overrides, MemberlistKV},
 		Ruler:                    {DistributorService, StoreQueryable, RulerStorage},
 		RulerStorage:             {Overrides},
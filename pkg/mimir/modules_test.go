@@ -227,6 +227,7 @@ func TestMultiKVSetup(t *testing.T) {
 
 			// Must be set, otherwise MultiKV config provider will not be set.
 			cfg.RuntimeConfig.LoadPath = []string{filepath.Join(dir, "config.yaml")}
+			cfg.ActivityTracker.Filepath = filepath.Join(t.TempDir(), "metrics-activity.log")
 
 			c, err := New(cfg, prometheus.NewPedanticRegistry())
 			require.NoError(t, err)
@@ -7,8 +7,11 @@ package mimir
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/runtimeconfig"
@@ -29,6 +32,14 @@ var (
 type runtimeConfigValues struct {
 	TenantLimits map[string]*validation.Limits `yaml:"overrides"`
 
+	// TenantGroups are groups of tenants sharing the same limits overrides. The limits of a tenant
+	// belonging to a group are resolved from its group limits, overridden by its own overrides.
+	TenantGroups map[string]*validation.TenantGroup `yaml:"tenant_groups"`
+
+	// ScheduledOverrides are limits overrides applied to a tenant only during recurring time windows,
+	// on top of its limits. If multiple time windows overlap, the first one in the list is applied.
+	ScheduledOverrides map[string][]*validation.ScheduledLimits `yaml:"scheduled_overrides"`
+
 	Multi kv.MultiRuntimeConfig `yaml:"multi_kv_config"`
 
 	IngesterChunkStreaming *bool `yaml:"ingester_stream_chunks_when_using_blocks"`
@@ -51,26 +62,85 @@ func newTenantLimits(manager *runtimeconfig.Manager) validation.TenantLimits {
 }
 
 func (l *runtimeConfigTenantLimits) ByUserID(userID string) *validation.Limits {
-	return l.AllByUserID()[userID]
+	cfg, ok := l.manager.GetConfig().(*runtimeConfigValues)
+	if cfg == nil || !ok {
+		return nil
+	}
+
+	// Look up the current time only for the tenants having scheduled limits, since it's called for each limit.
+	if len(cfg.ScheduledOverrides[userID]) > 0 {
+		if scheduled := cfg.activeScheduledLimits(userID, time.Now()); scheduled != nil {
+			return scheduled.Limits
+		}
+	}
+	return cfg.TenantLimits[userID]
 }
 
 func (l *runtimeConfigTenantLimits) AllByUserID() map[string]*validation.Limits {
 	cfg, ok := l.manager.GetConfig().(*runtimeConfigValues)
 	if cfg != nil && ok {
-		return cfg.TenantLimits
+		return cfg.allLimitsAt(time.Now())
 	}
 
 	return nil
 }
 
+// ActiveScheduledLimitsByUserID implements validation.ScheduledTenantLimits.
+func (l *runtimeConfigTenantLimits) ActiveScheduledLimitsByUserID() map[string]string {
+	cfg, ok := l.manager.GetConfig().(*runtimeConfigValues)
+	if cfg == nil || !ok {
+		return nil
+	}
+
+	now := time.Now()
+	active := map[string]string{}
+	for userID := range cfg.ScheduledOverrides {
+		if scheduled := cfg.activeScheduledLimits(userID, now); scheduled != nil {
+			active[userID] = scheduled.Name
+		}
+	}
+	return active
+}
+
+// activeScheduledLimits returns the scheduled limits of the tenant applied at the input time, if any.
+func (cfg *runtimeConfigValues) activeScheduledLimits(userID string, now time.Time) *validation.ScheduledLimits {
+	for _, scheduled := range cfg.ScheduledOverrides[userID] {
+		if scheduled.Schedule.Contains(now) {
+			return scheduled
+		}
+	}
+	return nil
+}
+
+// allLimitsAt returns the limits of all the tenants at the input time, taking into account the scheduled limits.
+func (cfg *runtimeConfigValues) allLimitsAt(now time.Time) map[string]*validation.Limits {
+	if len(cfg.ScheduledOverrides) == 0 {
+		return cfg.TenantLimits
+	}
+
+	all := make(map[string]*validation.Limits, len(cfg.TenantLimits)+len(cfg.ScheduledOverrides))
+	for userID, limits := range cfg.TenantLimits {
+		all[userID] = limits
+	}
+	for userID := range cfg.ScheduledOverrides {
+		if scheduled := cfg.activeScheduledLimits(userID, now); scheduled != nil {
+			all[userID] = scheduled.Limits
+		}
+	}
+	return all
+}
+
 func loadRuntimeConfig(r io.Reader) (interface{}, error) {
 	var overrides = &runtimeConfigValues{}
 
 	decoder := yaml.NewDecoder(r)
 	decoder.KnownFields(true)
 
-	// Decode the first document. An empty document (EOF) is OK.
-	if err := decoder.Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+	// Decode the first document. An empty document (EOF) is OK. The document is decoded
+	// to a node first, because the tenant groups resolution needs the tenants overrides
+	// as they've been set in the document.
+	var root yaml.Node
+	if err := decoder.Decode(&root); err != nil && !errors.Is(err, io.EOF) {
 		return nil, err
 	}
 
@@ -79,9 +149,161 @@ func loadRuntimeConfig(r io.Reader) (interface{}, error) {
 		return nil, errMultipleDocuments
 	}
 
+	if root.Kind == 0 {
+		return overrides, nil
+	}
+	if err := root.DecodeWithOptions(overrides, yaml.DecodeOptions{KnownFields: true}); err != nil {
+		return nil, err
+	}
+
+	if err := resolveTenantGroups(overrides, &root); err != nil {
+		return nil, err
+	}
+
+	if err := resolveScheduledOverrides(overrides, &root); err != nil {
+		return nil, err
+	}
+
 	return overrides, nil
 }
 
+// resolveTenantGroups sets the limits of the tenants belonging to a tenant group: the overrides set for
+// a tenant are applied on top of its group limits, which are applied on top of the default limits.
+func resolveTenantGroups(cfg *runtimeConfigValues, root *yaml.Node) error {
+	if len(cfg.TenantGroups) == 0 {
+		return nil
+	}
+
+	// Iterate the groups in a deterministic order, to consistently report tenants belonging to multiple groups.
+	groupNames := make([]string, 0, len(cfg.TenantGroups))
+	for name := range cfg.TenantGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	var (
+		overridesNodes = tenantOverridesNodes(root)
+		tenantGroup    = map[string]string{}
+	)
+
+	for _, name := range groupNames {
+		group := cfg.TenantGroups[name]
+		if group == nil {
+			continue
+		}
+
+		for _, tenantID := range group.Tenants {
+			if other, ok := tenantGroup[tenantID]; ok {
+				return fmt.Errorf("the tenant %s belongs to multiple tenant groups: %s and %s", tenantID, other, name)
+			}
+			tenantGroup[tenantID] = name
+
+			// Without group limits, the tenant limits are the ones already decoded on top of the default limits.
+			if group.Limits == nil {
+				continue
+			}
+
+			if cfg.TenantLimits == nil {
+				cfg.TenantLimits = map[string]*validation.Limits{}
+			}
+
+			node, ok := overridesNodes[tenantID]
+			if !ok || node.Tag == "!!null" {
+				cfg.TenantLimits[tenantID] = group.Limits
+				continue
+			}
+
+			limits := &validation.Limits{}
+			if err := limits.UnmarshalYAMLWithBase(node, group.Limits); err != nil {
+				return fmt.Errorf("failed to apply the overrides of the tenant %s to the tenant group %s limits: %w", tenantID, name, err)
+			}
+			cfg.TenantLimits[tenantID] = limits
+		}
+	}
+
+	return nil
+}
+
+// resolveScheduledOverrides sets the limits of the tenants scheduled limits: the scheduled limits
+// overrides are applied on top of the tenant limits, or the default limits if the tenant has none.
+func resolveScheduledOverrides(cfg *runtimeConfigValues, root *yaml.Node) error {
+	if len(cfg.ScheduledOverrides) == 0 {
+		return nil
+	}
+
+	var limitsNodes map[string]*yaml.Node
+	if scheduledNodes := mappingNodes(documentMappingValue(root, "scheduled_overrides")); len(scheduledNodes) > 0 {
+		limitsNodes = map[string]*yaml.Node{}
+		for tenantID, node := range scheduledNodes {
+			for i, item := range resolveAlias(node).Content {
+				limitsNodes[scheduledLimitsKey(tenantID, i)] = mappingNodes(resolveAlias(item))["limits"]
+			}
+		}
+	}
+
+	for tenantID, scheduled := range cfg.ScheduledOverrides {
+		for i, s := range scheduled {
+			if s == nil || s.Limits == nil {
+				return fmt.Errorf("the scheduled limits #%d of the tenant %s have no limits", i, tenantID)
+			}
+
+			// Without tenant limits, the scheduled limits are the ones already decoded on top of the default limits.
+			base := cfg.TenantLimits[tenantID]
+			node := limitsNodes[scheduledLimitsKey(tenantID, i)]
+			if base == nil || node == nil {
+				continue
+			}
+
+			limits := &validation.Limits{}
+			if err := limits.UnmarshalYAMLWithBase(node, base); err != nil {
+				return fmt.Errorf("failed to apply the scheduled limits %s to the tenant %s limits: %w", s.Name, tenantID, err)
+			}
+			s.Limits = limits
+		}
+	}
+
+	return nil
+}
+
+func scheduledLimitsKey(tenantID string, i int) string {
+	return fmt.Sprintf("%s/%d", tenantID, i)
+}
+
+// tenantOverridesNodes returns the overrides nodes of the YAML document, by tenant ID.
+func tenantOverridesNodes(root *yaml.Node) map[string]*yaml.Node {
+	return mappingNodes(documentMappingValue(root, "overrides"))
+}
+
+// documentMappingValue returns the value node of the input key at the top level of the YAML document.
+func documentMappingValue(root *yaml.Node, key string) *yaml.Node {
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	return mappingNodes(doc)[key]
+}
+
+// mappingNodes returns the value nodes of the input mapping node, by key, or nil if it's not a mapping node.
+func mappingNodes(node *yaml.Node) map[string]*yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	nodes := make(map[string]*yaml.Node, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		nodes[node.Content[i].Value] = node.Content[i+1]
+	}
+	return nodes
+}
+
+// resolveAlias returns the node referenced by the input node, if it's an alias.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
 func multiClientRuntimeConfigChannel(manager *runtimeconfig.Manager) func() <-chan kv.MultiRuntimeConfig {
 	if manager == nil {
 		return nil
@@ -8,6 +8,7 @@ package mimir
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,6 +54,213 @@ overrides:
 	require.Equal(t, limits, *loadedLimits["1236"])
 }
 
+func TestLoadRuntimeConfig_ShouldResolveTenantGroups(t *testing.T) {
+	validation.SetDefaultLimitsForYAMLUnmarshalling(validation.Limits{IngestionRate: 100, IngestionBurstSize: 1000})
+
+	yamlFile := strings.NewReader(`
+tenant_groups:
+  business-unit-a:
+    tenants: [tenant-1, tenant-2, tenant-3]
+    limits:
+      ingestion_rate: 1500
+      max_global_series_per_user: 15000
+overrides:
+  tenant-1:
+    max_global_series_per_user: 30000
+  tenant-3:
+  tenant-4:
+    ingestion_rate: 200
+`)
+	runtimeCfg, err := loadRuntimeConfig(yamlFile)
+	require.NoError(t, err)
+
+	groupLimits := validation.Limits{
+		IngestionRate:                       1500,
+		IngestionBurstSize:                  1000,
+		MaxGlobalSeriesPerUser:              15000,
+		NotificationRateLimitPerIntegration: validation.NotificationRateLimitMap{},
+	}
+
+	loadedLimits := runtimeCfg.(*runtimeConfigValues).TenantLimits
+	require.Len(t, loadedLimits, 4)
+
+	// The tenant overrides are applied on top of the group limits.
+	tenant1Limits := groupLimits
+	tenant1Limits.MaxGlobalSeriesPerUser = 30000
+	assert.Equal(t, tenant1Limits, *loadedLimits["tenant-1"])
+
+	// The tenants without overrides inherit the group limits.
+	assert.Equal(t, groupLimits, *loadedLimits["tenant-2"])
+	assert.Equal(t, groupLimits, *loadedLimits["tenant-3"])
+
+	// The overrides of tenants not belonging to any group are applied on top of the default limits.
+	assert.Equal(t, validation.Limits{
+		IngestionRate:                       200,
+		IngestionBurstSize:                  1000,
+		NotificationRateLimitPerIntegration: validation.NotificationRateLimitMap{},
+	}, *loadedLimits["tenant-4"])
+}
+
+func TestLoadRuntimeConfig_ShouldReturnErrorOnTenantBelongingToMultipleGroups(t *testing.T) {
+	validation.SetDefaultLimitsForYAMLUnmarshalling(validation.Limits{})
+
+	yamlFile := strings.NewReader(`
+tenant_groups:
+  group-a:
+    tenants: [tenant-1]
+  group-b:
+    tenants: [tenant-2, tenant-1]
+`)
+	_, err := loadRuntimeConfig(yamlFile)
+	require.EqualError(t, err, "the tenant tenant-1 belongs to multiple tenant groups: group-a and group-b")
+}
+
+func TestLoadRuntimeConfig_ShouldResolveScheduledOverrides(t *testing.T) {
+	validation.SetDefaultLimitsForYAMLUnmarshalling(validation.Limits{IngestionRate: 100, MaxFetchedSeriesPerQuery: 1000})
+
+	yamlFile := strings.NewReader(`
+overrides:
+  tenant-1:
+    ingestion_rate: 200
+scheduled_overrides:
+  tenant-1:
+    - name: business-hours
+      schedule:
+        days_of_week: [monday, tuesday, wednesday, thursday, friday]
+        start_time: "08:00"
+        end_time: "18:00"
+      limits:
+        max_fetched_series_per_query: 5000
+    - name: nights
+      schedule:
+        start_time: "22:00"
+        end_time: "06:00"
+      limits:
+        max_fetched_series_per_query: 500
+  tenant-2:
+    - name: business-hours
+      schedule:
+        start_time: "08:00"
+        end_time: "18:00"
+      limits:
+        max_fetched_series_per_query: 2000
+`)
+	runtimeCfg, err := loadRuntimeConfig(yamlFile)
+	require.NoError(t, err)
+	cfg := runtimeCfg.(*runtimeConfigValues)
+
+	tenant1Limits := validation.Limits{
+		IngestionRate:                       200,
+		MaxFetchedSeriesPerQuery:            1000,
+		NotificationRateLimitPerIntegration: validation.NotificationRateLimitMap{},
+	}
+
+	// The scheduled limits are applied on top of the tenant limits, or the default limits if the tenant has none.
+	tenant1BusinessHoursLimits := tenant1Limits
+	tenant1BusinessHoursLimits.MaxFetchedSeriesPerQuery = 5000
+	assert.Equal(t, tenant1BusinessHoursLimits, *cfg.ScheduledOverrides["tenant-1"][0].Limits)
+
+	tenant1NightsLimits := tenant1Limits
+	tenant1NightsLimits.MaxFetchedSeriesPerQuery = 500
+	assert.Equal(t, tenant1NightsLimits, *cfg.ScheduledOverrides["tenant-1"][1].Limits)
+
+	assert.Equal(t, validation.Limits{
+		IngestionRate:                       100,
+		MaxFetchedSeriesPerQuery:            2000,
+		NotificationRateLimitPerIntegration: validation.NotificationRateLimitMap{},
+	}, *cfg.ScheduledOverrides["tenant-2"][0].Limits)
+
+	// 2022-11-07 is a Monday.
+	tests := map[string]struct {
+		time              time.Time
+		expectedTenant1   *validation.Limits
+		expectedTenant2   *validation.Limits
+		expectedScheduled string
+	}{
+		"business hours on a weekday": {
+			time:              time.Date(2022, 11, 7, 12, 0, 0, 0, time.UTC),
+			expectedTenant1:   &tenant1BusinessHoursLimits,
+			expectedTenant2:   cfg.ScheduledOverrides["tenant-2"][0].Limits,
+			expectedScheduled: "business-hours",
+		},
+		"business hours on the weekend": {
+			time:            time.Date(2022, 11, 6, 12, 0, 0, 0, time.UTC),
+			expectedTenant1: &tenant1Limits,
+			expectedTenant2: cfg.ScheduledOverrides["tenant-2"][0].Limits,
+		},
+		"night": {
+			time:              time.Date(2022, 11, 7, 23, 0, 0, 0, time.UTC),
+			expectedTenant1:   &tenant1NightsLimits,
+			expectedScheduled: "nights",
+		},
+		"out of any time window": {
+			time:            time.Date(2022, 11, 7, 20, 0, 0, 0, time.UTC),
+			expectedTenant1: &tenant1Limits,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			all := cfg.allLimitsAt(testData.time)
+			assert.Equal(t, testData.expectedTenant1, all["tenant-1"])
+			assert.Equal(t, testData.expectedTenant2, all["tenant-2"])
+
+			scheduled := cfg.activeScheduledLimits("tenant-1", testData.time)
+			if testData.expectedScheduled == "" {
+				assert.Nil(t, scheduled)
+			} else {
+				require.NotNil(t, scheduled)
+				assert.Equal(t, testData.expectedScheduled, scheduled.Name)
+			}
+		})
+	}
+}
+
+func TestLoadRuntimeConfig_ShouldReturnErrorOnInvalidScheduledOverrides(t *testing.T) {
+	validation.SetDefaultLimitsForYAMLUnmarshalling(validation.Limits{})
+
+	tests := map[string]struct {
+		config      string
+		expectedErr string
+	}{
+		"missing name": {
+			config: `
+scheduled_overrides:
+  tenant-1:
+    - schedule: {start_time: "08:00", end_time: "18:00"}
+      limits: {ingestion_rate: 10}
+`,
+			expectedErr: "the name of the scheduled limits is required",
+		},
+		"missing limits": {
+			config: `
+scheduled_overrides:
+  tenant-1:
+    - name: business-hours
+      schedule: {start_time: "08:00", end_time: "18:00"}
+`,
+			expectedErr: "the scheduled limits #0 of the tenant tenant-1 have no limits",
+		},
+		"invalid schedule": {
+			config: `
+scheduled_overrides:
+  tenant-1:
+    - name: business-hours
+      schedule: {start_time: "08:00", end_time: "08:00"}
+      limits: {ingestion_rate: 10}
+`,
+			expectedErr: "the start time and end time of the time window must be different",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := loadRuntimeConfig(strings.NewReader(testData.config))
+			require.EqualError(t, err, testData.expectedErr)
+		})
+	}
+}
+
 func TestLoadRuntimeConfig_ShouldLoadEmptyFile(t *testing.T) {
 	yamlFile := strings.NewReader(`
 # This is an empty YAML.
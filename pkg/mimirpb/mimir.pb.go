@@ -188,6 +188,9 @@ type TimeSeries struct {
 	// Sorted by time, oldest sample first.
 	Samples   []Sample   `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples"`
 	Exemplars []Exemplar `protobuf:"bytes,3,rep,name=exemplars,proto3" json:"exemplars"`
+	// Set if the samples are the increments of a counter since the previous sample, which are
+	// converted to the cumulative value of the counter by the ingesters.
+	Delta bool `protobuf:"varint,4,opt,name=delta,proto3" json:"delta,omitempty"`
 }
 
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
@@ -236,6 +239,13 @@ func (m *TimeSeries) GetExemplars() []Exemplar {
 	return nil
 }
 
+func (m *TimeSeries) GetDelta() bool {
+	if m != nil {
+		return m.Delta
+	}
+	return false
+}
+
 type LabelPair struct {
 	Name  []byte `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -511,51 +521,52 @@ func init() {
 func init() { proto.RegisterFile("mimir.proto", fileDescriptor_86d4d7485f544059) }
 
 var fileDescriptor_86d4d7485f544059 = []byte{
-	// 701 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xbd, 0x6e, 0xd3, 0x5e,
-	0x14, 0xf7, 0xcd, 0x77, 0x4e, 0xd2, 0xfc, 0xad, 0xfb, 0xaf, 0x84, 0xd5, 0xc1, 0x49, 0xcd, 0x92,
-	0x01, 0x52, 0x54, 0x04, 0x08, 0x04, 0x83, 0x83, 0xd2, 0x52, 0xb5, 0xf9, 0xd0, 0x8d, 0x43, 0x05,
-	0x4b, 0x74, 0x93, 0xde, 0xb6, 0x16, 0xbe, 0xb1, 0xb1, 0x9d, 0xaa, 0xd9, 0x98, 0x98, 0x99, 0x79,
-	0x02, 0x9e, 0x00, 0x89, 0x37, 0xe8, 0xd8, 0xb1, 0x62, 0xa8, 0xa8, 0xbb, 0x74, 0xec, 0x23, 0x20,
-	0x5f, 0x3b, 0x71, 0xab, 0x8a, 0xad, 0xdb, 0x3d, 0xe7, 0xf7, 0x71, 0x8e, 0xcf, 0x39, 0x32, 0x94,
-	0xb8, 0xc9, 0x4d, 0xb7, 0xe1, 0xb8, 0xb6, 0x6f, 0xe3, 0xc2, 0xd8, 0x76, 0x7d, 0x76, 0xec, 0x8c,
-	0x56, 0x1e, 0x1f, 0x98, 0xfe, 0xe1, 0x74, 0xd4, 0x18, 0xdb, 0x7c, 0xed, 0xc0, 0x3e, 0xb0, 0xd7,
-	0x04, 0x61, 0x34, 0xdd, 0x17, 0x91, 0x08, 0xc4, 0x2b, 0x12, 0x6a, 0x3f, 0x53, 0x50, 0xde, 0x75,
-	0x4d, 0x9f, 0x11, 0xf6, 0x79, 0xca, 0x3c, 0x1f, 0xf7, 0x00, 0x7c, 0x93, 0x33, 0x8f, 0xb9, 0x26,
-	0xf3, 0x14, 0x54, 0x4b, 0xd7, 0x4b, 0xeb, 0xcb, 0x8d, 0xb9, 0x7d, 0xc3, 0x30, 0x39, 0xeb, 0x0b,
-	0xac, 0xb9, 0x72, 0x72, 0x5e, 0x95, 0x7e, 0x9f, 0x57, 0x71, 0xcf, 0x65, 0xd4, 0xb2, 0xec, 0xb1,
-	0xb1, 0xd0, 0x91, 0x1b, 0x1e, 0xf8, 0x25, 0xe4, 0xfa, 0xf6, 0xd4, 0x1d, 0x33, 0x25, 0x55, 0x43,
-	0xf5, 0xca, 0xfa, 0x6a, 0xe2, 0x76, 0xb3, 0x72, 0x23, 0x22, 0xb5, 0x26, 0x53, 0x4e, 0x62, 0x01,
-	0x7e, 0x05, 0x05, 0xce, 0x7c, 0xba, 0x47, 0x7d, 0xaa, 0xa4, 0x45, 0x2b, 0x4a, 0x22, 0x6e, 0x33,
-	0xdf, 0x35, 0xc7, 0xed, 0x18, 0x6f, 0x66, 0x4e, 0xce, 0xab, 0x88, 0x2c, 0xf8, 0xf8, 0x35, 0xac,
-	0x78, 0x9f, 0x4c, 0x67, 0x68, 0xd1, 0x11, 0xb3, 0x86, 0x13, 0xca, 0xd9, 0xf0, 0x88, 0x5a, 0xe6,
-	0x1e, 0xf5, 0x4d, 0x7b, 0xa2, 0x5c, 0xe5, 0x6b, 0xa8, 0x5e, 0x20, 0x0f, 0x42, 0xca, 0x4e, 0xc8,
-	0xe8, 0x50, 0xce, 0xde, 0x2f, 0x70, 0xad, 0x0a, 0x90, 0xf4, 0x83, 0xf3, 0x90, 0xd6, 0x7b, 0x5b,
-	0xb2, 0x84, 0x0b, 0x90, 0x21, 0x83, 0x9d, 0x96, 0x8c, 0xb4, 0xff, 0x60, 0x29, 0xee, 0xde, 0x73,
-	0xec, 0x89, 0xc7, 0xb4, 0x5f, 0x08, 0x20, 0x99, 0x0e, 0xd6, 0x21, 0x27, 0x2a, 0xcf, 0x67, 0xf8,
-	0x7f, 0xd2, 0xb8, 0xa8, 0xd7, 0xa3, 0xa6, 0xdb, 0x5c, 0x8e, 0x47, 0x58, 0x16, 0x29, 0x7d, 0x8f,
-	0x3a, 0x3e, 0x73, 0x49, 0x2c, 0xc4, 0x4f, 0x20, 0xef, 0x51, 0xee, 0x58, 0xcc, 0x53, 0x52, 0xc2,
-	0x43, 0x4e, 0x3c, 0xfa, 0x02, 0x10, 0x1f, 0x2d, 0x91, 0x39, 0x0d, 0x3f, 0x87, 0x22, 0x3b, 0x66,
-	0xdc, 0xb1, 0xa8, 0xeb, 0xc5, 0x03, 0xc3, 0x89, 0xa6, 0x15, 0x43, 0xb1, 0x2a, 0xa1, 0x6a, 0xcf,
-	0xa0, 0xb8, 0x68, 0x0a, 0x63, 0xc8, 0x84, 0xd3, 0x52, 0x50, 0x0d, 0xd5, 0xcb, 0x44, 0xbc, 0xf1,
-	0x32, 0x64, 0x8f, 0xa8, 0x35, 0x8d, 0x56, 0x58, 0x26, 0x51, 0xa0, 0xe9, 0x90, 0x8b, 0xfa, 0xc0,
-	0xab, 0x50, 0x16, 0x1b, 0xf7, 0x29, 0x77, 0x86, 0xdc, 0x13, 0xb4, 0x34, 0x29, 0x2d, 0x72, 0x6d,
-	0x2f, 0xb1, 0x08, 0x7d, 0xd1, 0xdc, 0xe2, 0x7b, 0x0a, 0x2a, 0xb7, 0x17, 0x89, 0x5f, 0x40, 0xc6,
-	0x9f, 0x39, 0x11, 0xaf, 0xb2, 0xfe, 0xf0, 0x5f, 0x0b, 0x8f, 0x43, 0x63, 0xe6, 0x30, 0x22, 0x04,
-	0xf8, 0x11, 0x60, 0x2e, 0x72, 0xc3, 0x7d, 0xca, 0x4d, 0x6b, 0x26, 0x96, 0x2e, 0x5a, 0x29, 0x12,
-	0x39, 0x42, 0x36, 0x04, 0x10, 0xee, 0x3a, 0xfc, 0xcc, 0x43, 0x66, 0x39, 0x4a, 0x46, 0xe0, 0xe2,
-	0x1d, 0xe6, 0xa6, 0x13, 0xd3, 0x57, 0xb2, 0x51, 0x2e, 0x7c, 0x6b, 0x33, 0x80, 0xa4, 0x12, 0x2e,
-	0x41, 0x7e, 0xd0, 0xd9, 0xee, 0x74, 0x77, 0x3b, 0xb2, 0x14, 0x06, 0x6f, 0xbb, 0x83, 0x8e, 0xd1,
-	0x22, 0x32, 0xc2, 0x45, 0xc8, 0x6e, 0xea, 0x83, 0xcd, 0x96, 0x9c, 0xc2, 0x4b, 0x50, 0x7c, 0xb7,
-	0xd5, 0x37, 0xba, 0x9b, 0x44, 0x6f, 0xcb, 0x69, 0x8c, 0xa1, 0x22, 0x90, 0x24, 0x97, 0x09, 0xa5,
-	0xfd, 0x41, 0xbb, 0xad, 0x93, 0x0f, 0x72, 0x36, 0xbc, 0xaa, 0xad, 0xce, 0x46, 0x57, 0xce, 0xe1,
-	0x32, 0x14, 0xfa, 0x86, 0x6e, 0xb4, 0xfa, 0x2d, 0x43, 0xce, 0x6b, 0xdb, 0x90, 0x8b, 0x4a, 0xdf,
-	0xc3, 0x35, 0x69, 0x5f, 0x11, 0x14, 0xe6, 0x17, 0x70, 0x1f, 0xd7, 0x79, 0xeb, 0x24, 0xe6, 0xfb,
-	0xbc, 0x73, 0x08, 0xe9, 0x3b, 0x87, 0xd0, 0x7c, 0x73, 0x7a, 0xa1, 0x4a, 0x67, 0x17, 0xaa, 0x74,
-	0x7d, 0xa1, 0xa2, 0x2f, 0x81, 0x8a, 0x7e, 0x04, 0x2a, 0x3a, 0x09, 0x54, 0x74, 0x1a, 0xa8, 0xe8,
-	0x4f, 0xa0, 0xa2, 0xab, 0x40, 0x95, 0xae, 0x03, 0x15, 0x7d, 0xbb, 0x54, 0xa5, 0xd3, 0x4b, 0x55,
-	0x3a, 0xbb, 0x54, 0xa5, 0x8f, 0x79, 0xf1, 0xbb, 0x73, 0x46, 0xa3, 0x9c, 0xf8, 0x71, 0x3d, 0xfd,
-	0x1b, 0x00, 0x00, 0xff, 0xff, 0x40, 0x35, 0x74, 0x55, 0x00, 0x05, 0x00, 0x00,
+	// 712 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xbb, 0x6e, 0xdb, 0x48,
+	0x14, 0xe5, 0xe8, 0xad, 0x2b, 0x59, 0x4b, 0xcc, 0x1a, 0x58, 0xc2, 0x05, 0x25, 0x73, 0x1b, 0x15,
+	0xbb, 0xf2, 0xc2, 0x8b, 0xdd, 0xc5, 0x2e, 0x36, 0x05, 0x15, 0xc8, 0x8e, 0x61, 0xeb, 0x81, 0x11,
+	0x15, 0x23, 0x69, 0x84, 0x91, 0x34, 0xb6, 0x89, 0x70, 0x44, 0x86, 0x1c, 0x19, 0x56, 0x97, 0x2a,
+	0x75, 0xea, 0x7c, 0x41, 0xbe, 0x20, 0xdf, 0xe0, 0x2e, 0x2e, 0x8d, 0x14, 0x46, 0x2c, 0x37, 0x2e,
+	0xfd, 0x09, 0x01, 0x87, 0x94, 0x68, 0xc3, 0x48, 0xe7, 0xee, 0x3e, 0xce, 0x39, 0xf7, 0xf2, 0xce,
+	0x01, 0xa1, 0xc4, 0x6d, 0x6e, 0xfb, 0x0d, 0xcf, 0x77, 0x85, 0x8b, 0x0b, 0x63, 0xd7, 0x17, 0xec,
+	0xcc, 0x1b, 0x6d, 0xfc, 0x7e, 0x6c, 0x8b, 0x93, 0xd9, 0xa8, 0x31, 0x76, 0xf9, 0xd6, 0xb1, 0x7b,
+	0xec, 0x6e, 0x49, 0xc0, 0x68, 0x76, 0x24, 0x33, 0x99, 0xc8, 0x28, 0x22, 0x1a, 0x9f, 0x53, 0x50,
+	0x3e, 0xf4, 0x6d, 0xc1, 0x08, 0x7b, 0x3b, 0x63, 0x81, 0xc0, 0x3d, 0x00, 0x61, 0x73, 0x16, 0x30,
+	0xdf, 0x66, 0x81, 0x86, 0x6a, 0xe9, 0x7a, 0x69, 0x7b, 0xbd, 0xb1, 0x94, 0x6f, 0x58, 0x36, 0x67,
+	0x7d, 0xd9, 0x6b, 0x6e, 0x9c, 0x5f, 0x55, 0x95, 0xaf, 0x57, 0x55, 0xdc, 0xf3, 0x19, 0x75, 0x1c,
+	0x77, 0x6c, 0xad, 0x78, 0xe4, 0x9e, 0x06, 0xfe, 0x17, 0x72, 0x7d, 0x77, 0xe6, 0x8f, 0x99, 0x96,
+	0xaa, 0xa1, 0x7a, 0x65, 0x7b, 0x33, 0x51, 0xbb, 0x3f, 0xb9, 0x11, 0x81, 0x5a, 0xd3, 0x19, 0x27,
+	0x31, 0x01, 0xff, 0x07, 0x05, 0xce, 0x04, 0x9d, 0x50, 0x41, 0xb5, 0xb4, 0x5c, 0x45, 0x4b, 0xc8,
+	0x6d, 0x26, 0x7c, 0x7b, 0xdc, 0x8e, 0xfb, 0xcd, 0xcc, 0xf9, 0x55, 0x15, 0x91, 0x15, 0x1e, 0xff,
+	0x0f, 0x1b, 0xc1, 0x1b, 0xdb, 0x1b, 0x3a, 0x74, 0xc4, 0x9c, 0xe1, 0x94, 0x72, 0x36, 0x3c, 0xa5,
+	0x8e, 0x3d, 0xa1, 0xc2, 0x76, 0xa7, 0xda, 0x6d, 0xbe, 0x86, 0xea, 0x05, 0xf2, 0x4b, 0x08, 0x39,
+	0x08, 0x11, 0x1d, 0xca, 0xd9, 0xcb, 0x55, 0xdf, 0xa8, 0x02, 0x24, 0xfb, 0xe0, 0x3c, 0xa4, 0xcd,
+	0xde, 0x9e, 0xaa, 0xe0, 0x02, 0x64, 0xc8, 0xe0, 0xa0, 0xa5, 0x22, 0xe3, 0x27, 0x58, 0x8b, 0xb7,
+	0x0f, 0x3c, 0x77, 0x1a, 0x30, 0xe3, 0x0b, 0x02, 0x48, 0xae, 0x83, 0x4d, 0xc8, 0xc9, 0xc9, 0xcb,
+	0x1b, 0xfe, 0x9c, 0x2c, 0x2e, 0xe7, 0xf5, 0xa8, 0xed, 0x37, 0xd7, 0xe3, 0x13, 0x96, 0x65, 0xc9,
+	0x9c, 0x50, 0x4f, 0x30, 0x9f, 0xc4, 0x44, 0xfc, 0x07, 0xe4, 0x03, 0xca, 0x3d, 0x87, 0x05, 0x5a,
+	0x4a, 0x6a, 0xa8, 0x89, 0x46, 0x5f, 0x36, 0xe4, 0x47, 0x2b, 0x64, 0x09, 0xc3, 0x7f, 0x43, 0x91,
+	0x9d, 0x31, 0xee, 0x39, 0xd4, 0x0f, 0xe2, 0x83, 0xe1, 0x84, 0xd3, 0x8a, 0x5b, 0x31, 0x2b, 0x81,
+	0xe2, 0x75, 0xc8, 0x4e, 0x98, 0x23, 0xa8, 0x96, 0x91, 0x57, 0x89, 0x12, 0xe3, 0x2f, 0x28, 0xae,
+	0x56, 0xc5, 0x18, 0x32, 0xe1, 0x0d, 0x35, 0x54, 0x43, 0xf5, 0x32, 0x91, 0x71, 0x48, 0x3b, 0xa5,
+	0xce, 0x2c, 0x7a, 0xd8, 0x32, 0x89, 0x12, 0xc3, 0x84, 0x5c, 0xb4, 0x1d, 0xde, 0x84, 0xb2, 0xf4,
+	0x81, 0xa0, 0xdc, 0x1b, 0xf2, 0x40, 0xc2, 0xd2, 0xa4, 0xb4, 0xaa, 0xb5, 0x83, 0x44, 0x22, 0xd4,
+	0x45, 0x4b, 0x89, 0x8f, 0x29, 0xa8, 0x3c, 0x7c, 0x5e, 0xfc, 0x0f, 0x64, 0xc4, 0xdc, 0x8b, 0x70,
+	0x95, 0xed, 0x5f, 0x7f, 0x64, 0x83, 0x38, 0xb5, 0xe6, 0x1e, 0x23, 0x92, 0x80, 0x7f, 0x03, 0xcc,
+	0x65, 0x6d, 0x78, 0x44, 0xb9, 0xed, 0xcc, 0xa5, 0x15, 0xe4, 0x2a, 0x45, 0xa2, 0x46, 0x9d, 0x1d,
+	0xd9, 0x08, 0x1d, 0x10, 0x7e, 0xe6, 0x09, 0x73, 0x3c, 0x79, 0x88, 0x22, 0x91, 0x71, 0x58, 0x9b,
+	0x4d, 0x6d, 0xa1, 0x65, 0xa3, 0x5a, 0x18, 0x1b, 0x73, 0x80, 0x64, 0x12, 0x2e, 0x41, 0x7e, 0xd0,
+	0xd9, 0xef, 0x74, 0x0f, 0x3b, 0xaa, 0x12, 0x26, 0xcf, 0xbb, 0x83, 0x8e, 0xd5, 0x22, 0x2a, 0xc2,
+	0x45, 0xc8, 0xee, 0x9a, 0x83, 0xdd, 0x96, 0x9a, 0xc2, 0x6b, 0x50, 0x7c, 0xb1, 0xd7, 0xb7, 0xba,
+	0xbb, 0xc4, 0x6c, 0xab, 0x69, 0x8c, 0xa1, 0x22, 0x3b, 0x49, 0x2d, 0x13, 0x52, 0xfb, 0x83, 0x76,
+	0xdb, 0x24, 0xaf, 0xd4, 0x6c, 0xe8, 0xb5, 0xbd, 0xce, 0x4e, 0x57, 0xcd, 0xe1, 0x32, 0x14, 0xfa,
+	0x96, 0x69, 0xb5, 0xfa, 0x2d, 0x4b, 0xcd, 0x1b, 0xfb, 0x90, 0x8b, 0x46, 0x3f, 0x81, 0xc7, 0x8c,
+	0xf7, 0x08, 0x0a, 0x4b, 0x5f, 0x3c, 0x85, 0x67, 0x1f, 0x58, 0x62, 0xf9, 0x9e, 0x8f, 0x8c, 0x90,
+	0x7e, 0x64, 0x84, 0xe6, 0xb3, 0x8b, 0x6b, 0x5d, 0xb9, 0xbc, 0xd6, 0x95, 0xbb, 0x6b, 0x1d, 0xbd,
+	0x5b, 0xe8, 0xe8, 0xd3, 0x42, 0x47, 0xe7, 0x0b, 0x1d, 0x5d, 0x2c, 0x74, 0xf4, 0x6d, 0xa1, 0xa3,
+	0xdb, 0x85, 0xae, 0xdc, 0x2d, 0x74, 0xf4, 0xe1, 0x46, 0x57, 0x2e, 0x6e, 0x74, 0xe5, 0xf2, 0x46,
+	0x57, 0x5e, 0xe7, 0xe5, 0x4f, 0xd0, 0x1b, 0x8d, 0x72, 0xf2, 0x77, 0xf6, 0xe7, 0xf7, 0x01, 0x00,
+	0x76, 0x8d, 0xe0, 0x06, 0x16, 0x05, 0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -679,6 +690,9 @@ func (this *TimeSeries) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.Delta != that1.Delta {
+		return false
+	}
 	return true
 }
 func (this *LabelPair) Equal(that interface{}) bool {
@@ -860,7 +874,7 @@ func (this *TimeSeries) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&mimirpb.TimeSeries{")
 	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
 	if this.Samples != nil {
@@ -877,6 +891,7 @@ func (this *TimeSeries) GoString() string {
 		}
 		s = append(s, "Exemplars: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "Delta: "+fmt.Sprintf("%#v", this.Delta)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1056,6 +1071,16 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Delta {
+		i--
+		if m.Delta {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.Exemplars) > 0 {
 		for iNdEx := len(m.Exemplars) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1377,6 +1402,9 @@ func (m *TimeSeries) Size() (n int) {
 			n += 1 + l + sovMimir(uint64(l))
 		}
 	}
+	if m.Delta {
+		n += 2
+	}
 	return n
 }
 
@@ -1523,6 +1551,7 @@ func (this *TimeSeries) String() string {
 		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
 		`Samples:` + repeatedStringForSamples + `,`,
 		`Exemplars:` + repeatedStringForExemplars + `,`,
+		`Delta:` + fmt.Sprintf("%v", this.Delta) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1936,6 +1965,26 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Delta", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Delta = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
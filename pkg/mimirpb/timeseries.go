@@ -318,6 +318,7 @@ func ReuseTimeseries(ts *TimeSeries) {
 		}
 	}
 	ts.Exemplars = ts.Exemplars[:0]
+	ts.Delta = false
 	timeSeriesPool.Put(ts)
 }
 
@@ -369,6 +370,7 @@ func DeepCopyTimeseries(dst, src PreallocTimeseries, keepExemplars bool) Preallo
 		dstTs.Samples = dstTs.Samples[:len(src.Samples)]
 	}
 	copy(dstTs.Samples, srcTs.Samples)
+	dstTs.Delta = srcTs.Delta
 
 	// Prepare the slice of exemplars.
 	if keepExemplars {
@@ -120,6 +120,7 @@ func TestDeepCopyTimeseries(t *testing.T) {
 					{Name: "exemplarLabel2", Value: "exemplarValue2"},
 				},
 			}},
+			Delta: true,
 		},
 	}
 	dst := PreallocTimeseries{}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 )
 
@@ -48,6 +49,9 @@ type blockQuerierSeriesSet struct {
 	series   []*storepb.Series
 	warnings storage.Warnings
 
+	// aggrs are the aggregates read from the chunks of downsampled blocks.
+	aggrs []downsample.AggrType
+
 	// next response to process
 	next int
 
@@ -74,7 +78,7 @@ func (bqss *blockQuerierSeriesSet) Next() bool {
 		bqss.next++
 	}
 
-	bqss.currSeries = newBlockQuerierSeries(currLabels, currChunks)
+	bqss.currSeries = newBlockQuerierSeries(currLabels, currChunks, bqss.aggrs)
 	return true
 }
 
@@ -91,17 +95,19 @@ func (bqss *blockQuerierSeriesSet) Warnings() storage.Warnings {
 }
 
 // newBlockQuerierSeries makes a new blockQuerierSeries. Input labels must be already sorted by name.
-func newBlockQuerierSeries(lbls []labels.Label, chunks []storepb.AggrChunk) *blockQuerierSeries {
+// The input aggregates are read from the chunks of downsampled blocks, which have no raw data.
+func newBlockQuerierSeries(lbls []labels.Label, chunks []storepb.AggrChunk, aggrs []downsample.AggrType) *blockQuerierSeries {
 	sort.Slice(chunks, func(i, j int) bool {
 		return chunks[i].MinTime < chunks[j].MinTime
 	})
 
-	return &blockQuerierSeries{labels: lbls, chunks: chunks}
+	return &blockQuerierSeries{labels: lbls, chunks: chunks, aggrs: aggrs}
 }
 
 type blockQuerierSeries struct {
 	labels labels.Labels
 	chunks []storepb.AggrChunk
+	aggrs  []downsample.AggrType
 }
 
 func (bqs *blockQuerierSeries) Labels() labels.Labels {
@@ -117,6 +123,16 @@ func (bqs *blockQuerierSeries) Iterator() chunkenc.Iterator {
 	its := make([]iteratorWithMaxTime, 0, len(bqs.chunks))
 
 	for _, c := range bqs.chunks {
+		if c.Raw == nil {
+			it, err := newDownsampledChunkIterator(c, bqs.aggrs)
+			if err != nil {
+				return series.NewErrIterator(errors.Wrapf(err, "failed to initialize downsampled chunk iterator (series: %v min time: %d max time: %d)", bqs.Labels(), c.MinTime, c.MaxTime))
+			}
+
+			its = append(its, iteratorWithMaxTime{it, c.MaxTime})
+			continue
+		}
+
 		ch, err := chunkenc.FromData(chunkenc.EncXOR, c.Raw.Data)
 		if err != nil {
 			return series.NewErrIterator(errors.Wrapf(err, "failed to initialize chunk from XOR encoded raw data (series: %v min time: %d max time: %d)", bqs.Labels(), c.MinTime, c.MaxTime))
@@ -75,7 +75,7 @@ func TestBlockQuerierSeries(t *testing.T) {
 		testData := testData
 
 		t.Run(testName, func(t *testing.T) {
-			series := newBlockQuerierSeries(mimirpb.FromLabelAdaptersToLabels(testData.series.Labels), testData.series.Chunks)
+			series := newBlockQuerierSeries(mimirpb.FromLabelAdaptersToLabels(testData.series.Labels), testData.series.Chunks, nil)
 
 			assert.Equal(t, testData.expectedMetric, series.Labels())
 
@@ -448,7 +448,7 @@ func Benchmark_newBlockQuerierSeries(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		newBlockQuerierSeries(lbls, chunks)
+		newBlockQuerierSeries(lbls, chunks, nil)
 	}
 }
 
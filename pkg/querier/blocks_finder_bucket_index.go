@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
@@ -30,6 +31,10 @@ type BucketIndexBlocksFinderConfig struct {
 	IndexLoader              bucketindex.LoaderConfig
 	MaxStalePeriod           time.Duration
 	IgnoreDeletionMarksDelay time.Duration
+
+	// FallbackScanEnabled enables scanning the bucket when the bucket index is missing, corrupted or too old.
+	FallbackScanEnabled bool
+	FallbackScan        bucketindex.ScannerConfig
 }
 
 // BucketIndexBlocksFinder implements BlocksFinder interface and find blocks in the bucket
@@ -37,22 +42,37 @@ type BucketIndexBlocksFinderConfig struct {
 type BucketIndexBlocksFinder struct {
 	services.Service
 
-	cfg    BucketIndexBlocksFinderConfig
-	loader *bucketindex.Loader
+	cfg     BucketIndexBlocksFinderConfig
+	loader  *bucketindex.Loader
+	scanner *bucketindex.Scanner
+	logger  log.Logger
 }
 
 func NewBucketIndexBlocksFinder(cfg BucketIndexBlocksFinderConfig, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *BucketIndexBlocksFinder {
 	loader := bucketindex.NewLoader(cfg.IndexLoader, bkt, cfgProvider, logger, reg)
 
+	var scanner *bucketindex.Scanner
+	if cfg.FallbackScanEnabled {
+		scanner = bucketindex.NewScanner(cfg.FallbackScan, bkt, cfgProvider, logger, reg)
+	}
+
 	return &BucketIndexBlocksFinder{
 		cfg:     cfg,
 		loader:  loader,
+		scanner: scanner,
+		logger:  logger,
 		Service: loader,
 	}
 }
 
 // GetBlocks implements BlocksFinder.
 func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string, minT, maxT int64) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error) {
+	return f.SelectBlocks(ctx, userID, minT, maxT, nil)
+}
+
+// SelectBlocks is like GetBlocks, but it also calls onBlock, if not nil, with each block of the bucket index
+// and the reason why the block has been selected or pruned. It implements blocksSelector.
+func (f *BucketIndexBlocksFinder) SelectBlocks(ctx context.Context, userID string, minT, maxT int64, onBlock func(*bucketindex.Block, BlockSelectionReason)) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error) {
 	if f.State() != services.Running {
 		return nil, nil, errBucketIndexBlocksFinderNotRunning
 	}
@@ -60,58 +80,90 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 		return nil, nil, errInvalidBlocksRange
 	}
 
-	// Get the bucket index for this user.
-	idx, err := f.loader.GetIndex(ctx, userID)
-	if errors.Is(err, bucketindex.ErrIndexNotFound) {
-		// This is a legit edge case, happening when a new tenant has not shipped blocks to the storage yet
-		// so the bucket index hasn't been created yet.
-		return nil, nil, nil
-	}
-	if err != nil {
+	idx, err := f.getIndex(ctx, userID, minT)
+	if err != nil || idx == nil {
 		return nil, nil, err
 	}
 
-	// Ensure the bucket index is not too old.
-	if time.Since(idx.GetUpdatedAt()) > f.cfg.MaxStalePeriod {
-		return nil, nil, newBucketIndexTooOldError(idx.GetUpdatedAt(), f.cfg.MaxStalePeriod)
+	deletionMarks := make(map[ulid.ULID]*bucketindex.BlockDeletionMark, len(idx.BlockDeletionMarks))
+	for _, mark := range idx.BlockDeletionMarks {
+		deletionMarks[mark.ID] = mark
 	}
 
 	var (
-		matchingBlocks        = map[ulid.ULID]*bucketindex.Block{}
+		matchingBlocks        = bucketindex.Blocks{}
 		matchingDeletionMarks = map[ulid.ULID]*bucketindex.BlockDeletionMark{}
 	)
 
-	// Filter blocks containing samples within the range.
 	for _, block := range idx.Blocks {
+		reason := BlockSelected
+
 		if !block.Within(minT, maxT) {
-			continue
+			// Filter blocks containing samples within the range.
+			reason = BlockPrunedByTimeRange
+		} else if mark, ok := deletionMarks[block.ID]; ok {
+			// Exclude blocks marked for deletion. This is the same logic as Thanos IgnoreDeletionMarkFilter.
+			if time.Since(time.Unix(mark.DeletionTime, 0)).Seconds() > f.cfg.IgnoreDeletionMarksDelay.Seconds() {
+				reason = BlockPrunedByDeletionMark
+			} else {
+				matchingDeletionMarks[mark.ID] = mark
+			}
 		}
 
-		matchingBlocks[block.ID] = block
+		if onBlock != nil {
+			onBlock(block, reason)
+		}
+		if reason == BlockSelected {
+			matchingBlocks = append(matchingBlocks, block)
+		}
 	}
 
-	for _, mark := range idx.BlockDeletionMarks {
-		// Filter deletion marks by matching blocks only.
-		if _, ok := matchingBlocks[mark.ID]; !ok {
-			continue
-		}
+	return matchingBlocks, matchingDeletionMarks, nil
+}
 
-		// Exclude blocks marked for deletion. This is the same logic as Thanos IgnoreDeletionMarkFilter.
-		if time.Since(time.Unix(mark.DeletionTime, 0)).Seconds() > f.cfg.IgnoreDeletionMarksDelay.Seconds() {
-			delete(matchingBlocks, mark.ID)
-			continue
+// getIndex returns the bucket index of the tenant, scanning the bucket if the bucket index is missing or
+// too old and the fallback scan is enabled. It returns a nil index if the tenant has no bucket index.
+func (f *BucketIndexBlocksFinder) getIndex(ctx context.Context, userID string, minT int64) (*bucketindex.Index, error) {
+	idx, err := f.loader.GetIndex(ctx, userID)
+	if errors.Is(err, bucketindex.ErrIndexNotFound) || errors.Is(err, bucketindex.ErrIndexCorrupted) {
+		if scanned, ok := f.scanIndex(ctx, userID, nil, minT); ok {
+			idx, err = scanned, nil
 		}
+	}
+	if errors.Is(err, bucketindex.ErrIndexNotFound) {
+		// This is a legit edge case, happening when a new tenant has not shipped blocks to the storage yet
+		// so the bucket index hasn't been created yet.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		matchingDeletionMarks[mark.ID] = mark
+	// Ensure the bucket index is not too old.
+	if time.Since(idx.GetUpdatedAt()) > f.cfg.MaxStalePeriod {
+		scanned, ok := f.scanIndex(ctx, userID, idx, minT)
+		if !ok {
+			return nil, newBucketIndexTooOldError(idx.GetUpdatedAt(), f.cfg.MaxStalePeriod)
+		}
+		idx = scanned
 	}
 
-	// Convert matching blocks into a list.
-	blocks := make(bucketindex.Blocks, 0, len(matchingBlocks))
-	for _, b := range matchingBlocks {
-		blocks = append(blocks, b)
+	return idx, nil
+}
+
+// scanIndex scans the bucket to find the blocks with samples at or after minT which are not in the old bucket index,
+// if the fallback scan is enabled. It returns false if the bucket index couldn't be scanned.
+func (f *BucketIndexBlocksFinder) scanIndex(ctx context.Context, userID string, old *bucketindex.Index, minT int64) (*bucketindex.Index, bool) {
+	if f.scanner == nil {
+		return nil, false
 	}
 
-	return blocks, matchingDeletionMarks, nil
+	idx, err := f.scanner.ScanIndex(ctx, userID, old, minT)
+	if err != nil {
+		level.Warn(f.logger).Log("msg", "failed to scan the bucket because the bucket index is missing or too old", "user", userID, "err", err)
+		return nil, false
+	}
+	return idx, true
 }
 
 func newBucketIndexTooOldError(updatedAt time.Time, maxStalePeriod time.Duration) error {
@@ -204,7 +204,77 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsTooOld(t *testing.T) {
 	require.EqualError(t, err, newBucketIndexTooOldError(idx.GetUpdatedAt(), finder.cfg.MaxStalePeriod).Error())
 }
 
-func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket) *BucketIndexBlocksFinder {
+func TestBucketIndexBlocksFinder_GetBlocks_FallbackScan(t *testing.T) {
+	const userID = "user-1"
+
+	withFallbackScan := func(maxBlocks int) func(*BucketIndexBlocksFinderConfig) {
+		return func(cfg *BucketIndexBlocksFinderConfig) {
+			cfg.FallbackScanEnabled = true
+			cfg.FallbackScan = bucketindex.ScannerConfig{MaxBlocks: maxBlocks, FailuresThreshold: 1, CooldownPeriod: time.Hour}
+		}
+	}
+
+	t.Run("should scan the bucket if the bucket index does not exist", func(t *testing.T) {
+		ctx := context.Background()
+		bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+		block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+		block2 := mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+		mimir_testutil.MockStorageBlock(t, bkt, userID, 30, 40)
+		finder := prepareBucketIndexBlocksFinder(t, bkt, withFallbackScan(10))
+
+		blocks, deletionMarks, err := finder.GetBlocks(ctx, userID, 15, 25)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []ulid.ULID{block1.ULID, block2.ULID}, blocksIDs(blocks))
+		assert.Empty(t, deletionMarks)
+	})
+
+	t.Run("should scan the bucket for the blocks not in the bucket index if it's too old", func(t *testing.T) {
+		ctx := context.Background()
+		bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+		block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+		idx := &bucketindex.Index{
+			Version:            bucketindex.IndexVersion2,
+			Blocks:             bucketindex.Blocks{{ID: block1.ULID, MinTime: block1.MinTime, MaxTime: block1.MaxTime}},
+			BlockDeletionMarks: bucketindex.BlockDeletionMarks{},
+			UpdatedAt:          time.Now().Add(-2 * time.Hour).Unix(),
+		}
+		require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+		block2 := mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+		finder := prepareBucketIndexBlocksFinder(t, bkt, withFallbackScan(10))
+
+		blocks, _, err := finder.GetBlocks(ctx, userID, 10, 30)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []ulid.ULID{block1.ULID, block2.ULID}, blocksIDs(blocks))
+	})
+
+	t.Run("should fail as if the fallback scan is disabled if the bucket scan fails", func(t *testing.T) {
+		ctx := context.Background()
+		bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+		idx := &bucketindex.Index{
+			Version:            bucketindex.IndexVersion2,
+			Blocks:             bucketindex.Blocks{},
+			BlockDeletionMarks: bucketindex.BlockDeletionMarks{},
+			UpdatedAt:          time.Now().Add(-2 * time.Hour).Unix(),
+		}
+		require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+		mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+		mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+		finder := prepareBucketIndexBlocksFinder(t, bkt, withFallbackScan(1))
+
+		_, _, err := finder.GetBlocks(ctx, userID, 10, 30)
+		require.EqualError(t, err, newBucketIndexTooOldError(idx.GetUpdatedAt(), finder.cfg.MaxStalePeriod).Error())
+	})
+}
+
+func blocksIDs(blocks bucketindex.Blocks) []ulid.ULID {
+	ids := make([]ulid.ULID, 0, len(blocks))
+	for _, b := range blocks {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket, opts ...func(*BucketIndexBlocksFinderConfig)) *BucketIndexBlocksFinder {
 	ctx := context.Background()
 	cfg := BucketIndexBlocksFinderConfig{
 		IndexLoader: bucketindex.LoaderConfig{
@@ -216,6 +286,9 @@ func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket) *BucketIn
 		MaxStalePeriod:           time.Hour,
 		IgnoreDeletionMarksDelay: time.Hour,
 	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
 	finder := NewBucketIndexBlocksFinder(cfg, bkt, nil, log.NewNopLogger(), nil)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, finder))
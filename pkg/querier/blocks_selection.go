@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/math"
+)
+
+var errBlocksSelectionNotSupported = errors.New("the blocks selection can be explained only when the bucket index is enabled")
+
+// BlockSelectionReason is the reason why a block of the bucket index is queried or pruned by a query.
+type BlockSelectionReason string
+
+const (
+	BlockSelected             BlockSelectionReason = "selected"
+	BlockPrunedByTimeRange    BlockSelectionReason = "time_range"
+	BlockPrunedByDeletionMark BlockSelectionReason = "deletion_mark"
+	BlockPrunedByResolution   BlockSelectionReason = "resolution"
+	BlockPrunedByShard        BlockSelectionReason = "shard"
+)
+
+// blocksPruningReasons are the reasons why a block is pruned, in the order they're evaluated.
+var blocksPruningReasons = []BlockSelectionReason{BlockPrunedByTimeRange, BlockPrunedByDeletionMark, BlockPrunedByResolution, BlockPrunedByShard}
+
+// blocksSelector is implemented by the BlocksFinder which can tell why each block is selected or pruned.
+type blocksSelector interface {
+	SelectBlocks(ctx context.Context, userID string, minT, maxT int64, onBlock func(*bucketindex.Block, BlockSelectionReason)) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error)
+}
+
+// blocksSelection is the number of blocks consulted by a query, by selection reason.
+type blocksSelection map[BlockSelectionReason]int
+
+// prune moves count selected blocks to the input pruning reason.
+func (s blocksSelection) prune(reason BlockSelectionReason, count int) {
+	if s == nil || count == 0 {
+		return
+	}
+	s[BlockSelected] -= count
+	s[reason] += count
+}
+
+// consulted returns the number of blocks consulted.
+func (s blocksSelection) consulted() int {
+	count := 0
+	for _, c := range s {
+		count += c
+	}
+	return count
+}
+
+// BlockSelection is a block of the bucket index along with the reason why it's queried or pruned.
+type BlockSelection struct {
+	*bucketindex.Block
+	Reason BlockSelectionReason `json:"reason"`
+}
+
+// BlocksSelectionExplanation explains which blocks are queried for a query time range.
+type BlocksSelectionExplanation struct {
+	// MinTime and MaxTime are the time range queried from the blocks, which may be shorter
+	// than the query time range because of -querier.query-store-after.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+
+	Blocks  []BlockSelection `json:"blocks"`
+	Summary blocksSelection  `json:"summary"`
+}
+
+// ExplainBlocksSelection returns each block of the tenant bucket index, along with the reason why it's queried
+// or pruned by a query in the time range between minT and maxT (both inclusive), for the input max resolution
+// of the downsampled blocks and query shard, if any.
+func (q *BlocksStoreQueryable) ExplainBlocksSelection(ctx context.Context, userID string, minT, maxT, maxResolution int64, shard *sharding.ShardSelector) (*BlocksSelectionExplanation, error) {
+	selector, ok := q.finder.(blocksSelector)
+	if !ok {
+		return nil, errBlocksSelectionNotSupported
+	}
+
+	if q.queryStoreAfter > 0 {
+		maxT = math.Min64(maxT, util.TimeToMillis(time.Now().Add(-q.queryStoreAfter)))
+	}
+
+	res := &BlocksSelectionExplanation{MinTime: minT, MaxTime: maxT, Blocks: []BlockSelection{}, Summary: blocksSelection{}}
+	if maxT < minT {
+		return res, nil
+	}
+
+	blocks, _, err := selector.SelectBlocks(ctx, userID, minT, maxT, func(b *bucketindex.Block, reason BlockSelectionReason) {
+		res.Blocks = append(res.Blocks, BlockSelection{Block: b, Reason: reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if q.downsampledBlocksEnabled {
+		blocks = filterBlocksByResolution(blocks, minT, maxT, maxResolution)
+		markPrunedBlocks(res.Blocks, blocks, BlockPrunedByResolution)
+	}
+	if shard != nil && shard.ShardCount > 0 {
+		blocks, _ = filterBlocksByShard(blocks, shard.ShardIndex, shard.ShardCount)
+		markPrunedBlocks(res.Blocks, blocks, BlockPrunedByShard)
+	}
+
+	for _, b := range res.Blocks {
+		res.Summary[b.Reason]++
+	}
+	return res, nil
+}
+
+// markPrunedBlocks sets the input reason to the selected blocks which are not in the remaining blocks.
+func markPrunedBlocks(selection []BlockSelection, remaining bucketindex.Blocks, reason BlockSelectionReason) {
+	ids := make(map[ulid.ULID]struct{}, len(remaining))
+	for _, b := range remaining {
+		ids[b.ID] = struct{}{}
+	}
+
+	for i, b := range selection {
+		if _, ok := ids[b.ID]; !ok && b.Reason == BlockSelected {
+			selection[i].Reason = reason
+		}
+	}
+}
+
+// BlocksSelectionHandler explains which blocks of the tenant are queried for the query time range
+// between the start and end parameters, optionally for a range query step and a query shard.
+func (q *BlocksStoreQueryable) BlocksSelectionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, err := util.ParseTime(r.FormValue("start"))
+	if err != nil {
+		http.Error(w, `invalid parameter "start": `+err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := util.ParseTime(r.FormValue("end"))
+	if err != nil {
+		http.Error(w, `invalid parameter "end": `+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if end < start {
+		http.Error(w, `invalid parameter "end": end timestamp must not be before start time`, http.StatusBadRequest)
+		return
+	}
+
+	var maxResolution int64
+	if v := r.FormValue("step"); v != "" {
+		step, err := parseStepMs(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		maxResolution, _ = downsampledMaxResolution(&storage.SelectHints{Start: start, End: end, Step: step}, q.lookbackDelta)
+	}
+
+	var shard *sharding.ShardSelector
+	if v := r.FormValue("shard"); v != "" {
+		index, count, err := sharding.ParseShardIDLabelValue(v)
+		if err != nil {
+			http.Error(w, `invalid parameter "shard": `+err.Error(), http.StatusBadRequest)
+			return
+		}
+		shard = &sharding.ShardSelector{ShardIndex: index, ShardCount: count}
+	}
+
+	res, err := q.ExplainBlocksSelection(r.Context(), userID, start, end, maxResolution, shard)
+	if errors.Is(err, errBlocksSelectionNotSupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, res)
+}
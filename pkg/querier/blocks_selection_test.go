@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestBlocksStoreQueryable_ExplainBlocksSelection(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	raw := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 100}
+	rawShard1 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 100, MaxTime: 200, CompactorShardID: "1_of_2"}
+	rawShard2 := &bucketindex.Block{ID: ulid.MustNew(3, nil), MinTime: 100, MaxTime: 200, CompactorShardID: "2_of_2"}
+	downsampled := &bucketindex.Block{ID: ulid.MustNew(4, nil), MinTime: 0, MaxTime: 200, Resolution: downsample.ResLevel1}
+	outOfRange := &bucketindex.Block{ID: ulid.MustNew(5, nil), MinTime: 1000, MaxTime: 2000}
+	deleted := &bucketindex.Block{ID: ulid.MustNew(6, nil), MinTime: 0, MaxTime: 100}
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:            bucketindex.IndexVersion1,
+		Blocks:             bucketindex.Blocks{raw, rawShard1, rawShard2, downsampled, outOfRange, deleted},
+		BlockDeletionMarks: bucketindex.BlockDeletionMarks{{ID: deleted.ID, DeletionTime: time.Now().Add(-2 * time.Hour).Unix()}},
+		UpdatedAt:          time.Now().Unix(),
+	}))
+
+	q := &BlocksStoreQueryable{
+		finder:                   prepareBucketIndexBlocksFinder(t, bkt),
+		downsampledBlocksEnabled: true,
+		lookbackDelta:            5 * time.Minute,
+	}
+
+	t.Run("without query shard", func(t *testing.T) {
+		res, err := q.ExplainBlocksSelection(ctx, userID, 0, 500, 0, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []BlockSelection{
+			{Block: raw, Reason: BlockSelected},
+			{Block: rawShard1, Reason: BlockSelected},
+			{Block: rawShard2, Reason: BlockSelected},
+			{Block: downsampled, Reason: BlockPrunedByResolution},
+			{Block: outOfRange, Reason: BlockPrunedByTimeRange},
+			{Block: deleted, Reason: BlockPrunedByDeletionMark},
+		}, res.Blocks)
+		assert.Equal(t, blocksSelection{
+			BlockSelected:             3,
+			BlockPrunedByResolution:   1,
+			BlockPrunedByTimeRange:    1,
+			BlockPrunedByDeletionMark: 1,
+		}, res.Summary)
+	})
+
+	t.Run("with query shard", func(t *testing.T) {
+		res, err := q.ExplainBlocksSelection(ctx, userID, 0, 500, 0, &sharding.ShardSelector{ShardIndex: 1, ShardCount: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, BlockSelected, res.Blocks[0].Reason)
+		assert.Equal(t, BlockPrunedByShard, res.Blocks[1].Reason)
+		assert.Equal(t, BlockSelected, res.Blocks[2].Reason)
+	})
+
+	t.Run("HTTP handler", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/querier/blocks_selection?start=0&end=0.5&shard=2_of_2", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		rec := httptest.NewRecorder()
+		q.BlocksSelectionHandler(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var res struct {
+			MinTime int64            `json:"min_time"`
+			MaxTime int64            `json:"max_time"`
+			Summary map[string]int   `json:"summary"`
+			Blocks  []map[string]any `json:"blocks"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, int64(0), res.MinTime)
+		assert.Equal(t, int64(500), res.MaxTime)
+		assert.Equal(t, map[string]int{"selected": 2, "shard": 1, "resolution": 1, "time_range": 1, "deletion_mark": 1}, res.Summary)
+		assert.Equal(t, rawShard1.ID.String(), res.Blocks[1]["block_id"])
+		assert.Equal(t, "shard", res.Blocks[1]["reason"])
+	})
+
+	t.Run("HTTP handler with invalid parameters", func(t *testing.T) {
+		for _, params := range []string{"start=0", "start=10&end=0", "start=0&end=10&step=x", "start=0&end=10&shard=3_of_2"} {
+			req := httptest.NewRequest(http.MethodGet, "/querier/blocks_selection?"+params, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+			rec := httptest.NewRecorder()
+			q.BlocksSelectionHandler(rec, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, params)
+		}
+	})
+}
+
+func TestBlocksStoreQueryableMetrics_ObserveBlocksSelection(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	metrics := newBlocksStoreQueryableMetrics(reg)
+
+	selection := blocksSelection{BlockSelected: 10, BlockPrunedByTimeRange: 5}
+	selection.prune(BlockPrunedByShard, 6)
+	metrics.observeBlocksSelection(selection)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_blocks_consulted_per_query Number of blocks of the bucket index consulted to find the blocks to query, for a single query.
+		# TYPE cortex_querier_blocks_consulted_per_query histogram
+		cortex_querier_blocks_consulted_per_query_bucket{le="1"} 0
+		cortex_querier_blocks_consulted_per_query_bucket{le="4"} 0
+		cortex_querier_blocks_consulted_per_query_bucket{le="16"} 1
+		cortex_querier_blocks_consulted_per_query_bucket{le="64"} 1
+		cortex_querier_blocks_consulted_per_query_bucket{le="256"} 1
+		cortex_querier_blocks_consulted_per_query_bucket{le="1024"} 1
+		cortex_querier_blocks_consulted_per_query_bucket{le="4096"} 1
+		cortex_querier_blocks_consulted_per_query_bucket{le="16384"} 1
+		cortex_querier_blocks_consulted_per_query_bucket{le="+Inf"} 1
+		cortex_querier_blocks_consulted_per_query_sum 15
+		cortex_querier_blocks_consulted_per_query_count 1
+	`), "cortex_querier_blocks_consulted_per_query"))
+
+	assert.Equal(t, 4, selection[BlockSelected])
+	for reason, expected := range map[BlockSelectionReason]float64{BlockPrunedByTimeRange: 5, BlockPrunedByDeletionMark: 0, BlockPrunedByResolution: 0, BlockPrunedByShard: 6} {
+		m := &dto.Metric{}
+		require.NoError(t, metrics.blocksPruned.WithLabelValues(string(reason)).(prometheus.Histogram).Write(m))
+		assert.Equal(t, expected, m.GetHistogram().GetSampleSum(), reason)
+		assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount(), reason)
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+// coverageRange is a time range, with both the min and max time inclusive (millis precision).
+type coverageRange struct {
+	minT, maxT int64
+}
+
+// uncoveredTimeRanges returns the time ranges between minT and maxT (both inclusive) which are not covered
+// by any of the input blocks, sorted by time.
+func uncoveredTimeRanges(blocks bucketindex.Blocks, minT, maxT int64) []coverageRange {
+	covered := make([]coverageRange, 0, len(blocks))
+	for _, b := range blocks {
+		// The block max time is exclusive.
+		covered = append(covered, coverageRange{minT: b.MinTime, maxT: b.MaxTime - 1})
+	}
+	return timeRangesGaps(covered, minT, maxT)
+}
+
+// timeRangesGaps returns the time ranges between minT and maxT (both inclusive) which are not covered
+// by any of the input ranges, sorted by time. The input slice is sorted in place.
+func timeRangesGaps(ranges []coverageRange, minT, maxT int64) []coverageRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].minT < ranges[j].minT
+	})
+
+	var gaps []coverageRange
+	next := minT
+	for _, r := range ranges {
+		if r.maxT < next {
+			continue
+		}
+		if r.minT > maxT {
+			break
+		}
+		if r.minT > next {
+			gaps = append(gaps, coverageRange{minT: next, maxT: r.minT - 1})
+		}
+		next = r.maxT + 1
+		if next > maxT {
+			return gaps
+		}
+	}
+	return append(gaps, coverageRange{minT: next, maxT: maxT})
+}
+
+// intersectTimeRanges returns the intersection between the input sorted and non-overlapping time ranges.
+func intersectTimeRanges(a, b []coverageRange) []coverageRange {
+	var res []coverageRange
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		minT := a[i].minT
+		if b[j].minT > minT {
+			minT = b[j].minT
+		}
+		maxT := a[i].maxT
+		if b[j].maxT < maxT {
+			maxT = b[j].maxT
+		}
+		if minT <= maxT {
+			res = append(res, coverageRange{minT: minT, maxT: maxT})
+		}
+		if a[i].maxT < b[j].maxT {
+			i++
+		} else {
+			j++
+		}
+	}
+	return res
+}
+
+// verifyChunksCoverage compares, for each series returned by the store-gateways, the union of the time ranges
+// of its chunks against the query time range, and returns a warning for each detected gap falling in a time
+// range not covered by any block. Such gaps can't be filled by any store-gateway, so they're likely caused by
+// blocks missing from the storage. The input series sets must be *blockQuerierSeriesSet.
+func verifyChunksCoverage(seriesSets []storage.SeriesSet, uncovered []coverageRange, minT, maxT int64) storage.Warnings {
+	if len(uncovered) == 0 {
+		return nil
+	}
+
+	// The chunks of the same series may be returned by different store-gateways.
+	chunksRanges := map[string][]coverageRange{}
+	for _, set := range seriesSets {
+		blockSet, ok := set.(*blockQuerierSeriesSet)
+		if !ok {
+			continue
+		}
+		for _, s := range blockSet.series {
+			key := mimirpb.FromLabelAdaptersToLabels(s.Labels).String()
+			chunksRanges[key] = appendChunksTimeRanges(chunksRanges[key], s.Chunks)
+		}
+	}
+
+	// Count the series affected by each gap.
+	affected := map[coverageRange]int{}
+	for _, ranges := range chunksRanges {
+		for _, gap := range intersectTimeRanges(timeRangesGaps(ranges, minT, maxT), uncovered) {
+			affected[gap]++
+		}
+	}
+
+	gaps := make([]coverageRange, 0, len(affected))
+	for gap := range affected {
+		gaps = append(gaps, gap)
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		return gaps[i].minT < gaps[j].minT
+	})
+
+	warnings := make(storage.Warnings, 0, len(gaps))
+	for _, gap := range gaps {
+		warnings = append(warnings, fmt.Errorf("no data returned for %d series between %s and %s because no block covers the time range, possibly because some blocks are missing from the storage",
+			affected[gap], formatTimestamp(gap.minT), formatTimestamp(gap.maxT)))
+	}
+	return warnings
+}
+
+func appendChunksTimeRanges(ranges []coverageRange, chks []storepb.AggrChunk) []coverageRange {
+	for _, c := range chks {
+		ranges = append(ranges, coverageRange{minT: c.MinTime, maxT: c.MaxTime})
+	}
+	return ranges
+}
+
+func formatTimestamp(ts int64) string {
+	return time.UnixMilli(ts).UTC().Format(time.RFC3339Nano)
+}
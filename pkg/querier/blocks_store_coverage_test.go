@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestUncoveredTimeRanges(t *testing.T) {
+	tests := map[string]struct {
+		blocks     bucketindex.Blocks
+		minT, maxT int64
+		expected   []coverageRange
+	}{
+		"no blocks": {
+			minT:     10,
+			maxT:     20,
+			expected: []coverageRange{{minT: 10, maxT: 20}},
+		},
+		"blocks fully covering the time range": {
+			blocks:   bucketindex.Blocks{{MinTime: 0, MaxTime: 15}, {MinTime: 15, MaxTime: 30}},
+			minT:     10,
+			maxT:     20,
+			expected: nil,
+		},
+		"overlapping blocks fully covering the time range": {
+			blocks:   bucketindex.Blocks{{MinTime: 12, MaxTime: 30}, {MinTime: 0, MaxTime: 20}, {MinTime: 5, MaxTime: 13}},
+			minT:     10,
+			maxT:     20,
+			expected: nil,
+		},
+		"gap between blocks": {
+			blocks:   bucketindex.Blocks{{MinTime: 0, MaxTime: 12}, {MinTime: 15, MaxTime: 30}},
+			minT:     10,
+			maxT:     20,
+			expected: []coverageRange{{minT: 12, maxT: 14}},
+		},
+		"gaps at the beginning and end of the time range": {
+			blocks:   bucketindex.Blocks{{MinTime: 12, MaxTime: 15}},
+			minT:     10,
+			maxT:     20,
+			expected: []coverageRange{{minT: 10, maxT: 11}, {minT: 15, maxT: 20}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, uncoveredTimeRanges(testData.blocks, testData.minT, testData.maxT))
+		})
+	}
+}
+
+func TestIntersectTimeRanges(t *testing.T) {
+	assert.Equal(t,
+		[]coverageRange{{minT: 5, maxT: 10}, {minT: 20, maxT: 22}, {minT: 25, maxT: 30}},
+		intersectTimeRanges(
+			[]coverageRange{{minT: 0, maxT: 10}, {minT: 20, maxT: 30}},
+			[]coverageRange{{minT: 5, maxT: 12}, {minT: 15, maxT: 22}, {minT: 25, maxT: 40}},
+		))
+
+	assert.Empty(t, intersectTimeRanges([]coverageRange{{minT: 0, maxT: 10}}, []coverageRange{{minT: 11, maxT: 20}}))
+}
+
+func TestVerifyChunksCoverage(t *testing.T) {
+	series1 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_1"))
+	series2 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_2"))
+
+	// The chunks of series_1 are returned by two store-gateways.
+	seriesSets := []storage.SeriesSet{
+		&blockQuerierSeriesSet{series: []*storepb.Series{
+			{Labels: series1, Chunks: []storepb.AggrChunk{{MinTime: 0, MaxTime: 9}}},
+			{Labels: series2, Chunks: []storepb.AggrChunk{{MinTime: 0, MaxTime: 9}}},
+		}},
+		&blockQuerierSeriesSet{series: []*storepb.Series{
+			{Labels: series1, Chunks: []storepb.AggrChunk{{MinTime: 20, MaxTime: 30}}},
+		}},
+	}
+
+	t.Run("should not return warnings if the whole time range is covered by blocks", func(t *testing.T) {
+		assert.Empty(t, verifyChunksCoverage(seriesSets, nil, 0, 30))
+	})
+
+	t.Run("should return a warning for each gap attributable to missing blocks", func(t *testing.T) {
+		// series_2 has no chunks after 9, but only the gaps in the time ranges not covered by any block are reported.
+		uncovered := []coverageRange{{minT: 10, maxT: 19}}
+
+		assert.Equal(t, storage.Warnings{
+			errors.New("no data returned for 2 series between 1970-01-01T00:00:00.01Z and 1970-01-01T00:00:00.019Z because no block covers the time range, possibly because some blocks are missing from the storage"),
+		}, verifyChunksCoverage(seriesSets, uncovered, 0, 30))
+	})
+}
@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+// downsampledResolutions are the resolutions of the downsampled blocks which can be queried, from the biggest
+// to the smallest one.
+var downsampledResolutions = []int64{downsample.ResLevel2, downsample.ResLevel1, downsample.ResLevel0}
+
+// downsampledAggrsFor returns the aggregates of the chunks of the downsampled blocks to read for the input
+// function or aggregation, or nil if the function needs the raw samples. Both the count and sum aggregates
+// are returned when the average of the samples is read.
+func downsampledAggrsFor(fn string) []downsample.AggrType {
+	switch fn {
+	case "min", "min_over_time":
+		return []downsample.AggrType{downsample.AggrMin}
+	case "max", "max_over_time":
+		return []downsample.AggrType{downsample.AggrMax}
+	case "sum_over_time":
+		return []downsample.AggrType{downsample.AggrSum}
+	case "", "avg", "avg_over_time", "sum", "count", "group":
+		return []downsample.AggrType{downsample.AggrCount, downsample.AggrSum}
+	}
+	return nil
+}
+
+// downsampledMaxResolution returns the max resolution of the downsampled blocks to query for the input hints,
+// along with the aggregates to read from their chunks. The resolution is at most a fifth of the query step,
+// and it's never bigger than the range of range vector selectors or the lookback delta of instant vector
+// selectors, so that each selector selects at least one downsampled sample. It returns 0 if only the raw
+// blocks should be queried.
+func downsampledMaxResolution(sp *storage.SelectHints, lookbackDelta time.Duration) (int64, []downsample.AggrType) {
+	if sp == nil {
+		return 0, nil
+	}
+
+	aggrs := downsampledAggrsFor(sp.Func)
+	if aggrs == nil {
+		return 0, nil
+	}
+
+	maxResolution := sp.Step / 5
+	if sp.Range > 0 && sp.Range < maxResolution {
+		maxResolution = sp.Range
+	}
+	if sp.Range == 0 && lookbackDelta.Milliseconds() < maxResolution {
+		maxResolution = lookbackDelta.Milliseconds()
+	}
+	if maxResolution < downsample.ResLevel1 {
+		return 0, nil
+	}
+	return maxResolution, aggrs
+}
+
+// filterBlocksByResolution returns the blocks covering the time range between minT and maxT (both inclusive)
+// with the biggest resolution not bigger than maxResolution, filling the time range not covered by them with
+// the blocks of smaller resolutions. The overlapping blocks of the same resolution are all returned.
+func filterBlocksByResolution(blocks bucketindex.Blocks, minT, maxT, maxResolution int64) bucketindex.Blocks {
+	byResolution := make([]bucketindex.Blocks, len(downsampledResolutions))
+	for _, b := range blocks {
+		for i, res := range downsampledResolutions {
+			if b.Resolution == res {
+				byResolution[i] = append(byResolution[i], b)
+				break
+			}
+		}
+	}
+	for _, bs := range byResolution {
+		sort.Slice(bs, func(i, j int) bool {
+			return bs[i].MinTime < bs[j].MinTime
+		})
+	}
+
+	i := 0
+	for ; i < len(downsampledResolutions)-1 && downsampledResolutions[i] > maxResolution; i++ {
+	}
+	return blocksForResolution(byResolution, i, minT, maxT)
+}
+
+// blocksForResolution returns the blocks of the i-th resolution covering the time range between minT and maxT,
+// recursively filling the gaps with the blocks of the next resolutions. Input blocks must be sorted by min time.
+func blocksForResolution(byResolution []bucketindex.Blocks, i int, minT, maxT int64) (res bucketindex.Blocks) {
+	if minT > maxT {
+		return nil
+	}
+
+	start := minT
+	for _, b := range byResolution[i] {
+		// NOTE: Block intervals are half-open: [MinTime, MaxTime).
+		if b.MaxTime <= minT {
+			continue
+		}
+		if b.MinTime > maxT {
+			break
+		}
+
+		if i+1 < len(byResolution) {
+			res = append(res, blocksForResolution(byResolution, i+1, start, b.MinTime-1)...)
+		}
+		res = append(res, b)
+		start = b.MaxTime
+	}
+
+	if i+1 < len(byResolution) {
+		res = append(res, blocksForResolution(byResolution, i+1, start, maxT)...)
+	}
+	return res
+}
+
+// newDownsampledChunkIterator returns an iterator over the input aggregates of a chunk of a downsampled block.
+// If both the count and sum aggregates are requested, it iterates over the average of the samples.
+func newDownsampledChunkIterator(c storepb.AggrChunk, aggrs []downsample.AggrType) (chunkenc.Iterator, error) {
+	if len(aggrs) == 0 {
+		return nil, errors.New("the chunk has no raw data")
+	}
+
+	its := make([]chunkenc.Iterator, 0, len(aggrs))
+	for _, aggr := range aggrs {
+		var chk *storepb.Chunk
+		switch aggr {
+		case downsample.AggrCount:
+			chk = c.Count
+		case downsample.AggrSum:
+			chk = c.Sum
+		case downsample.AggrMin:
+			chk = c.Min
+		case downsample.AggrMax:
+			chk = c.Max
+		case downsample.AggrCounter:
+			chk = c.Counter
+		}
+		if chk == nil {
+			return nil, errors.Errorf("the chunk has no %s aggregate", aggr)
+		}
+
+		ch, err := chunkenc.FromData(chunkenc.EncXOR, chk.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize %s aggregate chunk from XOR encoded data", aggr)
+		}
+		its = append(its, ch.Iterator(nil))
+	}
+
+	if len(its) == 2 {
+		return newAverageChunkIterator(its[0], its[1])
+	}
+	return its[0], nil
+}
+
+// newAverageChunkIterator returns an iterator over the average of the samples of a downsampled chunk, computed
+// from its count and sum aggregates, which have samples at the same timestamps. The averages are encoded in a
+// new chunk, whose iterator supports seeking.
+func newAverageChunkIterator(count, sum chunkenc.Iterator) (chunkenc.Iterator, error) {
+	avg := chunkenc.NewXORChunk()
+	app, err := avg.Appender()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		countOk, sumOk := count.Next(), sum.Next()
+		if countOk != sumOk {
+			return nil, errors.New("the count and sum aggregates have a different number of samples")
+		}
+		if !countOk {
+			break
+		}
+
+		countT, countV := count.At()
+		sumT, sumV := sum.At()
+		if countT != sumT {
+			return nil, errors.Errorf("the count and sum aggregates have samples at different timestamps: %d and %d", countT, sumT)
+		}
+		app.Append(countT, sumV/countV)
+	}
+	if err := count.Err(); err != nil {
+		return nil, err
+	}
+	if err := sum.Err(); err != nil {
+		return nil, err
+	}
+
+	return avg.Iterator(nil), nil
+}
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestDownsampledMaxResolution(t *testing.T) {
+	const (
+		minute = int64(time.Minute / time.Millisecond)
+		hour   = int64(time.Hour / time.Millisecond)
+	)
+
+	tests := map[string]struct {
+		hints                 *storage.SelectHints
+		expectedMaxResolution int64
+		expectedAggrs         []downsample.AggrType
+	}{
+		"no hints": {
+			hints:                 nil,
+			expectedMaxResolution: 0,
+		},
+		"step too small for the downsampled blocks": {
+			hints:                 &storage.SelectHints{Step: 20 * minute, Func: "max_over_time", Range: 10 * hour},
+			expectedMaxResolution: 0,
+		},
+		"range vector selector": {
+			hints:                 &storage.SelectHints{Step: 10 * hour, Func: "max_over_time", Range: 10 * hour},
+			expectedMaxResolution: 2 * hour,
+			expectedAggrs:         []downsample.AggrType{downsample.AggrMax},
+		},
+		"range vector selector with a range smaller than the step": {
+			hints:                 &storage.SelectHints{Step: 10 * hour, Func: "sum_over_time", Range: 30 * minute},
+			expectedMaxResolution: 30 * minute,
+			expectedAggrs:         []downsample.AggrType{downsample.AggrSum},
+		},
+		"instant vector selector": {
+			hints:                 &storage.SelectHints{Step: 10 * hour, Func: "min"},
+			expectedMaxResolution: 5 * minute,
+			expectedAggrs:         []downsample.AggrType{downsample.AggrMin},
+		},
+		"average of the samples": {
+			hints:                 &storage.SelectHints{Step: 10 * hour, Func: "avg_over_time", Range: 10 * hour},
+			expectedMaxResolution: 2 * hour,
+			expectedAggrs:         []downsample.AggrType{downsample.AggrCount, downsample.AggrSum},
+		},
+		"function needing the raw samples": {
+			hints:                 &storage.SelectHints{Step: 10 * hour, Func: "rate", Range: 10 * hour},
+			expectedMaxResolution: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			maxResolution, aggrs := downsampledMaxResolution(testData.hints, 5*time.Minute)
+			assert.Equal(t, testData.expectedMaxResolution, maxResolution)
+			assert.Equal(t, testData.expectedAggrs, aggrs)
+		})
+	}
+}
+
+func TestFilterBlocksByResolution(t *testing.T) {
+	var (
+		raw1 = &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 100}
+		raw2 = &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 100, MaxTime: 200}
+		raw3 = &bucketindex.Block{ID: ulid.MustNew(3, nil), MinTime: 200, MaxTime: 300}
+		res1 = &bucketindex.Block{ID: ulid.MustNew(4, nil), MinTime: 0, MaxTime: 200, Resolution: downsample.ResLevel1}
+		res2 = &bucketindex.Block{ID: ulid.MustNew(5, nil), MinTime: 0, MaxTime: 100, Resolution: downsample.ResLevel2}
+	)
+	blocks := bucketindex.Blocks{raw3, res2, raw1, res1, raw2}
+
+	tests := map[string]struct {
+		minT, maxT    int64
+		maxResolution int64
+		expected      bucketindex.Blocks
+	}{
+		"raw resolution": {
+			minT:          0,
+			maxT:          299,
+			maxResolution: 0,
+			expected:      bucketindex.Blocks{raw1, raw2, raw3},
+		},
+		"5m resolution": {
+			minT:          0,
+			maxT:          299,
+			maxResolution: downsample.ResLevel1,
+			expected:      bucketindex.Blocks{res1, raw3},
+		},
+		"1h resolution": {
+			minT:          0,
+			maxT:          299,
+			maxResolution: downsample.ResLevel2,
+			expected:      bucketindex.Blocks{res2, res1, raw3},
+		},
+		"time range covered by the raw blocks only": {
+			minT:          200,
+			maxT:          299,
+			maxResolution: downsample.ResLevel2,
+			expected:      bucketindex.Blocks{raw3},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, filterBlocksByResolution(blocks, testData.minT, testData.maxT, testData.maxResolution))
+		})
+	}
+}
+
+func TestBlockQuerierSeries_DownsampledChunks(t *testing.T) {
+	newChunk := func(minT int64, values ...float64) *storepb.Chunk {
+		c := chunkenc.NewXORChunk()
+		app, err := c.Appender()
+		require.NoError(t, err)
+		for i, v := range values {
+			app.Append(minT+int64(i)*10, v)
+		}
+		return &storepb.Chunk{Type: storepb.Chunk_XOR, Data: c.Bytes()}
+	}
+
+	chunks := []storepb.AggrChunk{
+		{MinTime: 0, MaxTime: 10, Count: newChunk(0, 2, 4), Sum: newChunk(0, 10, 12), Max: newChunk(0, 7, 5)},
+		{MinTime: 20, MaxTime: 30, Count: newChunk(20, 1, 5), Sum: newChunk(20, 3, 10), Max: newChunk(20, 3, 9)},
+	}
+
+	tests := map[string]struct {
+		aggrs         []downsample.AggrType
+		expected      []float64
+		expectedError string
+	}{
+		"max aggregate": {
+			aggrs:    []downsample.AggrType{downsample.AggrMax},
+			expected: []float64{7, 5, 3, 9},
+		},
+		"average of the samples": {
+			aggrs:    []downsample.AggrType{downsample.AggrCount, downsample.AggrSum},
+			expected: []float64{5, 3, 3, 2},
+		},
+		"missing aggregate": {
+			aggrs:         []downsample.AggrType{downsample.AggrMin},
+			expectedError: "the chunk has no min aggregate",
+		},
+		"no aggregates": {
+			expectedError: "the chunk has no raw data",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			it := newBlockQuerierSeries(labels.FromStrings("__name__", "test"), chunks, testData.aggrs).Iterator()
+
+			var (
+				actualT []int64
+				actualV []float64
+			)
+			for it.Next() {
+				ts, v := it.At()
+				actualT = append(actualT, ts)
+				actualV = append(actualV, v)
+			}
+
+			if testData.expectedError != "" {
+				require.Error(t, it.Err())
+				assert.Contains(t, it.Err().Error(), testData.expectedError)
+				return
+			}
+			require.NoError(t, it.Err())
+			assert.Equal(t, []int64{0, 10, 20, 30}, actualT)
+			assert.Equal(t, testData.expected, actualV)
+
+			// Seeking skips to the first sample with a timestamp not lower than the seeked one.
+			it = newBlockQuerierSeries(labels.FromStrings("__name__", "test"), chunks, testData.aggrs).Iterator()
+			require.True(t, it.Seek(15))
+			ts, v := it.At()
+			assert.Equal(t, int64(20), ts)
+			assert.Equal(t, testData.expected[2], v)
+		})
+	}
+}
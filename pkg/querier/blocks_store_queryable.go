@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -25,14 +26,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/thanos-io/objstore"
 	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
 	grpc_metadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/series"
@@ -40,6 +45,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
@@ -112,6 +118,9 @@ type blocksStoreQueryableMetrics struct {
 	blocksFound                                       prometheus.Counter
 	blocksQueried                                     prometheus.Counter
 	blocksWithCompactorShardButIncompatibleQueryShard prometheus.Counter
+
+	blocksConsulted prometheus.Histogram
+	blocksPruned    *prometheus.HistogramVec
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -141,6 +150,25 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Name: "cortex_querier_blocks_with_compactor_shard_but_incompatible_query_shard_total",
 			Help: "Blocks that couldn't be checked for query and compactor sharding optimization due to incompatible shard counts.",
 		}),
+
+		blocksConsulted: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_querier_blocks_consulted_per_query",
+			Help:    "Number of blocks of the bucket index consulted to find the blocks to query, for a single query.",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+		}),
+		blocksPruned: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_querier_blocks_pruned_per_query",
+			Help:    "Number of blocks of the bucket index not queried, by reason, for a single query.",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+		}, []string{"reason"}),
+	}
+}
+
+// observeBlocksSelection tracks the number of blocks consulted and pruned by a query.
+func (m *blocksStoreQueryableMetrics) observeBlocksSelection(selection blocksSelection) {
+	m.blocksConsulted.Observe(float64(selection.consulted()))
+	for _, reason := range blocksPruningReasons {
+		m.blocksPruned.WithLabelValues(string(reason)).Observe(float64(selection[reason]))
 	}
 }
 
@@ -157,6 +185,14 @@ type BlocksStoreQueryable struct {
 	metrics         *blocksStoreQueryableMetrics
 	limits          BlocksStoreLimits
 
+	// If set, the querier verifies the time range coverage of the chunks returned by the store-gateways.
+	chunksCoverageVerificationEnabled bool
+
+	// If set, the querier queries the downsampled blocks for the queries with a large enough step. The lookback
+	// delta limits the resolution of the downsampled blocks queried by instant vector selectors.
+	downsampledBlocksEnabled bool
+	lookbackDelta            time.Duration
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -168,6 +204,9 @@ func NewBlocksStoreQueryable(
 	consistency *BlocksConsistencyChecker,
 	limits BlocksStoreLimits,
 	queryStoreAfter time.Duration,
+	chunksCoverageVerificationEnabled bool,
+	downsampledBlocksEnabled bool,
+	lookbackDelta time.Duration,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*BlocksStoreQueryable, error) {
@@ -186,6 +225,10 @@ func NewBlocksStoreQueryable(
 		subservicesWatcher: services.NewFailureWatcher(),
 		metrics:            newBlocksStoreQueryableMetrics(reg),
 		limits:             limits,
+
+		chunksCoverageVerificationEnabled: chunksCoverageVerificationEnabled,
+		downsampledBlocksEnabled:          downsampledBlocksEnabled,
+		lookbackDelta:                     lookbackDelta,
 	}
 
 	q.Service = services.NewBasicService(q.starting, q.running, q.stopping)
@@ -205,7 +248,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 	}
 
 	// Blocks finder doesn't use chunks, but we pass config for consistency.
-	cachingBucket, err := mimir_tsdb.CreateCachingBucket(storageCfg.BucketStore.ChunksCache, storageCfg.BucketStore.MetadataCache, bucketClient, logger, prometheus.WrapRegistererWith(prometheus.Labels{"component": "querier"}, reg))
+	cachingBucket, err := mimir_tsdb.CreateCachingBucket(storageCfg.BucketStore.ChunksCache, storageCfg.BucketStore.MetadataCache, storageCfg.BucketStore.DebugCacheKeysEnabled, bucketClient, logger, prometheus.WrapRegistererWith(prometheus.Labels{"component": "querier"}, reg))
 	if err != nil {
 		return nil, errors.Wrap(err, "create caching bucket")
 	}
@@ -223,6 +266,12 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 			},
 			MaxStalePeriod:           storageCfg.BucketStore.BucketIndex.MaxStalePeriod,
 			IgnoreDeletionMarksDelay: storageCfg.BucketStore.IgnoreDeletionMarksDelay,
+			FallbackScanEnabled:      storageCfg.BucketStore.BucketIndex.FallbackScanEnabled,
+			FallbackScan: bucketindex.ScannerConfig{
+				MaxBlocks:         storageCfg.BucketStore.BucketIndex.FallbackScanMaxBlocks,
+				FailuresThreshold: storageCfg.BucketStore.BucketIndex.FallbackScanFailuresThreshold,
+				CooldownPeriod:    storageCfg.BucketStore.BucketIndex.FallbackScanCooldownPeriod,
+			},
 		}, bucketClient, limits, logger, reg)
 	} else {
 		finder = NewBucketScanBlocksFinder(BucketScanBlocksFinderConfig{
@@ -250,7 +299,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		return nil, errors.Wrap(err, "failed to create store-gateway ring client")
 	}
 
-	stores, err = newBlocksStoreReplicationSet(storesRing, randomLoadBalancing, limits, querierCfg.StoreGatewayClient, logger, reg)
+	stores, err = newBlocksStoreReplicationSet(storesRing, randomLoadBalancing, querierCfg.PreferAvailabilityZone, limits, querierCfg.StoreGatewayClient, logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create store set")
 	}
@@ -267,7 +316,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		reg,
 	)
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, logger, reg)
+	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, querierCfg.ChunksCoverageVerificationEnabled, storageCfg.BucketStore.DownsampledBlocksEnabled, querierCfg.EngineConfig.LookbackDelta, logger, reg)
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -297,6 +346,20 @@ func (q *BlocksStoreQueryable) stopping(_ error) error {
 
 // Querier returns a new Querier on the storage.
 func (q *BlocksStoreQueryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+	return q.newQuerier(ctx, mint, maxt)
+}
+
+// ExemplarQuerier returns a new ExemplarQuerier on the exemplars persisted in the storage blocks.
+func (q *BlocksStoreQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	querier, err := q.newQuerier(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blocksStoreExemplarQuerier{querier}, nil
+}
+
+func (q *BlocksStoreQueryable) newQuerier(ctx context.Context, mint, maxt int64) (*blocksStoreQuerier, error) {
 	if s := q.State(); s != services.Running {
 		return nil, errors.Errorf("BlocksStoreQueryable is not running: %v", s)
 	}
@@ -318,6 +381,10 @@ func (q *BlocksStoreQueryable) Querier(ctx context.Context, mint, maxt int64) (s
 		consistency:     q.consistency,
 		logger:          q.logger,
 		queryStoreAfter: q.queryStoreAfter,
+
+		chunksCoverageVerificationEnabled: q.chunksCoverageVerificationEnabled,
+		downsampledBlocksEnabled:          q.downsampledBlocksEnabled,
+		lookbackDelta:                     q.lookbackDelta,
 	}, nil
 }
 
@@ -335,6 +402,13 @@ type blocksStoreQuerier struct {
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
 	queryStoreAfter time.Duration
+
+	// If set, the querier verifies the time range coverage of the chunks returned by the store-gateways.
+	chunksCoverageVerificationEnabled bool
+
+	// If set, the querier queries the downsampled blocks for the queries with a large enough step.
+	downsampledBlocksEnabled bool
+	lookbackDelta            time.Duration
 }
 
 // Select implements storage.Querier interface.
@@ -377,7 +451,7 @@ func (q *blocksStoreQuerier) LabelNames(matchers ...*labels.Matcher) ([]string,
 		return queriedBlocks, nil
 	}
 
-	err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, nil, queryFunc)
+	err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, 0, nil, queryFunc)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -418,7 +492,7 @@ func (q *blocksStoreQuerier) LabelValues(name string, matchers ...*labels.Matche
 		return queriedBlocks, nil
 	}
 
-	err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, nil, queryFunc)
+	err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, 0, nil, queryFunc)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -450,12 +524,26 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 		return storage.ErrSeriesSet(err)
 	}
 
+	var (
+		maxResolution int64
+		aggrs         []downsample.AggrType
+	)
+	if q.downsampledBlocksEnabled {
+		maxResolution, aggrs = downsampledMaxResolution(sp, q.lookbackDelta)
+	}
+
+	// The time range actually queried from the store-gateways, which may differ from the
+	// requested one because of the query-store-after period.
+	queriedMinT, queriedMaxT := minT, maxT
+
 	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
-		seriesSets, queriedBlocks, warnings, numChunks, err := q.fetchSeriesFromStores(spanCtx, sp, clients, minT, maxT, matchers, convertedMatchers, maxChunksLimit, leftChunksLimit)
+		seriesSets, queriedBlocks, warnings, numChunks, err := q.fetchSeriesFromStores(spanCtx, sp, clients, minT, maxT, maxResolution, aggrs, matchers, convertedMatchers, maxChunksLimit, leftChunksLimit)
 		if err != nil {
 			return nil, err
 		}
 
+		queriedMinT, queriedMaxT = minT, maxT
+
 		resSeriesSets = append(resSeriesSets, seriesSets...)
 		resWarnings = append(resWarnings, warnings...)
 
@@ -468,7 +556,7 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 		return queriedBlocks, nil
 	}
 
-	err = q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, shard, queryFunc)
+	err = q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, maxResolution, shard, queryFunc)
 	if err != nil {
 		return storage.ErrSeriesSet(err)
 	}
@@ -477,12 +565,80 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 		storage.EmptySeriesSet()
 	}
 
+	if q.chunksCoverageVerificationEnabled && !(sp != nil && sp.Func == "series") && len(resSeriesSets) > 0 {
+		resWarnings = append(resWarnings, q.verifyChunksCoverage(spanCtx, spanLog, resSeriesSets, queriedMinT, queriedMaxT)...)
+	}
+
 	return series.NewSeriesSetWithWarnings(
 		storage.NewMergeSeriesSet(resSeriesSets, storage.ChainedSeriesMerge),
 		resWarnings)
 }
 
-func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, shard *sharding.ShardSelector,
+// blocksStoreExemplarQuerier queries the exemplars persisted in the storage blocks via store-gateways.
+type blocksStoreExemplarQuerier struct {
+	q *blocksStoreQuerier
+}
+
+// Select implements storage.ExemplarQuerier interface.
+func (e *blocksStoreExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	q := e.q
+	spanLog, spanCtx := spanlogger.NewWithLogger(q.ctx, q.logger, "blocksStoreExemplarQuerier.Select")
+	defer spanLog.Span.Finish()
+
+	level.Debug(spanLog).Log("start", util.TimeFromMillis(start).UTC().String(), "end",
+		util.TimeFromMillis(end).UTC().String(), "matchers", util.MultiMatchersStringer(matchers))
+
+	var resSeries []mimirpb.TimeSeries
+
+	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
+		series, queriedBlocks, err := q.fetchExemplarsFromStores(spanCtx, clients, minT, maxT, matchers)
+		if err != nil {
+			return nil, err
+		}
+
+		resSeries = append(resSeries, series...)
+
+		return queriedBlocks, nil
+	}
+
+	err := q.queryWithConsistencyCheck(spanCtx, spanLog, start, end, 0, nil, queryFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same exemplars may have been returned by different store-gateways for overlapping blocks.
+	resSeries = block.SortExemplarsSeries(resSeries)
+
+	res := make([]exemplar.QueryResult, 0, len(resSeries))
+	for _, s := range resSeries {
+		res = append(res, exemplar.QueryResult{
+			SeriesLabels: mimirpb.FromLabelAdaptersToLabels(s.Labels),
+			Exemplars:    mimirpb.FromExemplarProtosToExemplars(s.Exemplars),
+		})
+	}
+
+	return res, nil
+}
+
+// verifyChunksCoverage returns a warning for each gap in the time range coverage of the chunks of the
+// input series which is attributable to missing blocks. See verifyChunksCoverage().
+func (q *blocksStoreQuerier) verifyChunksCoverage(ctx context.Context, logger log.Logger, seriesSets []storage.SeriesSet, minT, maxT int64) storage.Warnings {
+	// The blocks are looked up again, instead of reusing the ones found by the consistency check,
+	// because the latter may have been filtered by query sharding.
+	knownBlocks, _, err := q.finder.GetBlocks(ctx, q.userID, minT, maxT)
+	if err != nil {
+		level.Warn(logger).Log("msg", "unable to get blocks to verify chunks coverage", "err", err)
+		return nil
+	}
+
+	warnings := verifyChunksCoverage(seriesSets, uncoveredTimeRanges(knownBlocks, minT, maxT), minT, maxT)
+	if len(warnings) > 0 {
+		level.Debug(logger).Log("msg", "detected gaps in chunks coverage attributable to missing blocks", "gaps", len(warnings))
+	}
+	return warnings
+}
+
+func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT, maxResolution int64, shard *sharding.ShardSelector,
 	queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error)) error {
 	// If queryStoreAfter is enabled, we do manipulate the query maxt to query samples up until
 	// now - queryStoreAfter, because the most recent time range is covered by ingesters. This
@@ -505,10 +661,13 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 	}
 
 	// Find the list of blocks we need to query given the time range.
-	knownBlocks, knownDeletionMarks, err := q.finder.GetBlocks(ctx, q.userID, minT, maxT)
+	knownBlocks, knownDeletionMarks, selection, err := q.findBlocks(ctx, minT, maxT)
 	if err != nil {
 		return err
 	}
+	if selection != nil {
+		defer q.metrics.observeBlocksSelection(selection)
+	}
 
 	if len(knownBlocks) == 0 {
 		q.metrics.storesHit.Observe(0)
@@ -518,6 +677,14 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 
 	q.metrics.blocksFound.Add(float64(len(knownBlocks)))
 
+	// Only the blocks of the resolution matching the query are queried, so that each time range is queried
+	// from a single resolution.
+	if q.downsampledBlocksEnabled {
+		filtered := filterBlocksByResolution(knownBlocks, minT, maxT, maxResolution)
+		selection.prune(BlockPrunedByResolution, len(knownBlocks)-len(filtered))
+		knownBlocks = filtered
+	}
+
 	if shard != nil && shard.ShardCount > 0 {
 		level.Debug(logger).Log("msg", "filtering blocks due to sharding", "blocksBeforeFiltering", knownBlocks.String(), "shardID", shard.LabelValue())
 
@@ -525,6 +692,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 
 		level.Debug(logger).Log("msg", "result of filtering blocks", "before", len(knownBlocks), "after", len(result), "filtered", len(knownBlocks)-len(result), "incompatible", incompatibleBlocks)
 		q.metrics.blocksWithCompactorShardButIncompatibleQueryShard.Add(float64(incompatibleBlocks))
+		selection.prune(BlockPrunedByShard, len(knownBlocks)-len(result))
 
 		knownBlocks = result
 	}
@@ -597,6 +765,25 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 	return newStoreConsistencyCheckFailedError(remainingBlocks)
 }
 
+// findBlocks returns the blocks to query in the time range between minT and maxT (both inclusive). If the
+// finder supports it, it also returns the number of blocks consulted by selection reason, otherwise nil.
+func (q *blocksStoreQuerier) findBlocks(ctx context.Context, minT, maxT int64) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, blocksSelection, error) {
+	selector, ok := q.finder.(blocksSelector)
+	if !ok {
+		blocks, deletionMarks, err := q.finder.GetBlocks(ctx, q.userID, minT, maxT)
+		return blocks, deletionMarks, nil, err
+	}
+
+	selection := blocksSelection{}
+	blocks, deletionMarks, err := selector.SelectBlocks(ctx, q.userID, minT, maxT, func(_ *bucketindex.Block, reason BlockSelectionReason) {
+		selection[reason]++
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return blocks, deletionMarks, selection, nil
+}
+
 func newStoreConsistencyCheckFailedError(remainingBlocks []ulid.ULID) error {
 	return fmt.Errorf("%v. The non-queried blocks are: %s", globalerror.StoreConsistencyCheckFailed.Message("the consistency check failed because some blocks were not queried"), strings.Join(convertULIDsToString(remainingBlocks), " "))
 }
@@ -680,6 +867,8 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 	clients map[BlocksStoreClient][]ulid.ULID,
 	minT int64,
 	maxT int64,
+	maxResolution int64,
+	aggrs []downsample.AggrType,
 	matchers []*labels.Matcher,
 	convertedMatchers []storepb.LabelMatcher,
 	maxChunksLimit int,
@@ -696,6 +885,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 		spanLog       = spanlogger.FromContext(ctx, q.logger)
 		queryLimiter  = limiter.QueryLimiterFromContextWithFallback(ctx)
 		reqStats      = stats.FromContext(ctx)
+		storeStats    = &hintspb.QueryStats{}
 	)
 
 	// Concurrently fetch series from all clients.
@@ -711,7 +901,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			// But this is an acceptable workaround for now.
 			skipChunks := sp != nil && sp.Func == "series"
 
-			req, err := createSeriesRequest(minT, maxT, convertedMatchers, skipChunks, blockIDs)
+			req, err := createSeriesRequest(minT, maxT, maxResolution, convertedMatchers, skipChunks, blockIDs)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create series request")
 			}
@@ -725,6 +915,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			mySeries := []*storepb.Series(nil)
 			myWarnings := storage.Warnings(nil)
 			myQueriedBlocks := []ulid.ULID(nil)
+			myStoreStats := &hintspb.QueryStats{}
 			indexBytesFetched := uint64(0)
 
 			for {
@@ -786,6 +977,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 					}
 
 					myQueriedBlocks = append(myQueriedBlocks, ids...)
+					myStoreStats.Merge(hints.QueryStats)
 				}
 
 				if s := resp.GetStats(); s != nil {
@@ -808,13 +1000,16 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 				"fetched chunks", chunksFetched,
 				"fetched index bytes", indexBytesFetched,
 				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
-				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
+				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "),
+				"chunks fetched from bucket", myStoreStats.ChunksFetched,
+				"chunks cache hit ratio", myStoreStats.ChunksCacheHitRatio())
 
 			// Store the result.
 			mtx.Lock()
-			seriesSets = append(seriesSets, &blockQuerierSeriesSet{series: mySeries})
+			seriesSets = append(seriesSets, &blockQuerierSeriesSet{series: mySeries, aggrs: aggrs})
 			warnings = append(warnings, myWarnings...)
 			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
+			storeStats.Merge(myStoreStats)
 			mtx.Unlock()
 
 			return nil
@@ -826,9 +1021,34 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 		return nil, nil, nil, 0, err
 	}
 
+	level.Debug(spanLog).Log(append([]interface{}{"msg", "store-gateways chunks loading stats"}, storeQueryStatsLogFields(storeStats)...)...)
+
 	return seriesSets, queriedBlocks, warnings, int(numChunks.Load()), nil
 }
 
+// storeQueryStatsLogFields returns the key-value pairs to log the chunks loading statistics returned
+// by the store-gateways in the Series() response hints.
+func storeQueryStatsLogFields(s *hintspb.QueryStats) []interface{} {
+	fields := []interface{}{
+		"chunks touched", s.ChunksTouched,
+		"chunks touched size bytes", s.ChunksTouchedSizeBytes,
+		"chunks fetched from bucket", s.ChunksFetched,
+		"chunks fetched from bucket size bytes", s.ChunksFetchedSizeBytes,
+		"chunks cache requests", s.ChunksCacheRequests,
+		"chunks cache hits", s.ChunksCacheHits,
+		"chunks cache hit ratio", s.ChunksCacheHitRatio(),
+		"series load duration", s.SeriesLoadDuration,
+		"chunks load duration", s.ChunksLoadDuration,
+		"chunks preloaded duration", s.ChunksPreloadedDuration,
+	}
+
+	blocks := make([]string, 0, len(s.Blocks))
+	for _, b := range s.Blocks {
+		blocks = append(blocks, fmt.Sprintf("%s:%d", b.Id, b.ChunksFetchedSizeBytes))
+	}
+	return append(fields, "chunks fetched size bytes per block", strings.Join(blocks, " "))
+}
+
 func (q *blocksStoreQuerier) fetchLabelNamesFromStore(
 	ctx context.Context,
 	clients map[BlocksStoreClient][]ulid.ULID,
@@ -987,7 +1207,86 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 	return valueSets, warnings, queriedBlocks, nil
 }
 
-func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skipChunks bool, blockIDs []ulid.ULID) (*storepb.SeriesRequest, error) {
+func (q *blocksStoreQuerier) fetchExemplarsFromStores(
+	ctx context.Context,
+	clients map[BlocksStoreClient][]ulid.ULID,
+	minT int64,
+	maxT int64,
+	matchers [][]*labels.Matcher,
+) ([]mimirpb.TimeSeries, []ulid.ULID, error) {
+	var (
+		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, q.userID)
+		g, gCtx       = errgroup.WithContext(reqCtx)
+		mtx           = sync.Mutex{}
+		series        = []mimirpb.TimeSeries(nil)
+		queriedBlocks = []ulid.ULID(nil)
+		spanLog       = spanlogger.FromContext(ctx, q.logger)
+	)
+
+	// Concurrently fetch exemplars from all clients.
+	for c, blockIDs := range clients {
+		// Change variables scope since it will be used in a goroutine.
+		c := c
+		blockIDs := blockIDs
+
+		g.Go(func() error {
+			req, err := createExemplarsRequest(minT, maxT, blockIDs, matchers)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create exemplars request")
+			}
+
+			exemplarsResp, err := c.Exemplars(gCtx, req)
+			if err != nil {
+				// The query exceeded the max exemplars bytes per query of the store-gateway, so it would fail on
+				// any other store-gateway too.
+				if s, ok := status.FromError(err); ok && s.Code() == codes.ResourceExhausted {
+					return validation.LimitError(s.Message())
+				}
+
+				level.Warn(spanLog).Log("msg", "failed to fetch exemplars", "remote", c.RemoteAddress(), "err", err)
+				return nil
+			}
+
+			myQueriedBlocks := []ulid.ULID(nil)
+			if exemplarsResp.Hints != nil {
+				hints := hintspb.ExemplarsResponseHints{}
+				if err := types.UnmarshalAny(exemplarsResp.Hints, &hints); err != nil {
+					return errors.Wrapf(err, "failed to unmarshal exemplars hints from %s", c.RemoteAddress())
+				}
+
+				ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
+				if err != nil {
+					return errors.Wrapf(err, "failed to parse queried block IDs from received hints")
+				}
+
+				myQueriedBlocks = ids
+			}
+
+			level.Debug(spanLog).Log("msg", "received exemplars from store-gateway",
+				"instance", c.RemoteAddress(),
+				"num series", len(exemplarsResp.Timeseries),
+				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
+				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
+
+			// Store the result.
+			mtx.Lock()
+			series = append(series, exemplarsResp.Timeseries...)
+			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	// Wait until all client requests complete.
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return series, queriedBlocks, nil
+}
+
+func createSeriesRequest(minT, maxT, maxResolution int64, matchers []storepb.LabelMatcher, skipChunks bool, blockIDs []ulid.ULID) (*storepb.SeriesRequest, error) {
 	// Selectively query only specific blocks.
 	hints := &hintspb.SeriesRequestHints{
 		BlockMatchers: []storepb.LabelMatcher{
@@ -1005,11 +1304,12 @@ func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skip
 	}
 
 	return &storepb.SeriesRequest{
-		MinTime:    minT,
-		MaxTime:    maxT,
-		Matchers:   matchers,
-		Hints:      anyHints,
-		SkipChunks: skipChunks,
+		MinTime:             minT,
+		MaxTime:             maxT,
+		MaxResolutionWindow: maxResolution,
+		Matchers:            matchers,
+		Hints:               anyHints,
+		SkipChunks:          skipChunks,
 	}, nil
 }
 
@@ -1070,6 +1370,38 @@ func createLabelValuesRequest(minT, maxT int64, label string, blockIDs []ulid.UL
 	return req, nil
 }
 
+func createExemplarsRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers [][]*labels.Matcher) (*storepb.ExemplarsRequest, error) {
+	req := &storepb.ExemplarsRequest{
+		Start:    minT,
+		End:      maxT,
+		Matchers: make([]storepb.ExemplarMatchers, 0, len(matchers)),
+	}
+
+	for _, m := range matchers {
+		req.Matchers = append(req.Matchers, storepb.ExemplarMatchers{Matchers: convertMatchersToLabelMatcher(m)})
+	}
+
+	// Selectively query only specific blocks.
+	hints := &hintspb.ExemplarsRequestHints{
+		BlockMatchers: []storepb.LabelMatcher{
+			{
+				Type:  storepb.LabelMatcher_RE,
+				Name:  block.BlockIDLabel,
+				Value: strings.Join(convertULIDsToString(blockIDs), "|"),
+			},
+		},
+	}
+
+	anyHints, err := types.MarshalAny(hints)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal exemplars request hints")
+	}
+
+	req.Hints = anyHints
+
+	return req, nil
+}
+
 func convertULIDsToString(ids []ulid.ULID) []string {
 	res := make([]string, len(ids))
 	for idx, id := range ids {
@@ -1104,3 +1436,16 @@ func countChunksAndBytes(series ...*storepb.Series) (chunks, bytes int) {
 
 	return chunks, bytes
 }
+
+// EjectedStoreGatewaysHandler serves the status page of the store-gateways temporarily excluded from the
+// queries because of their elevated latency or error rate.
+func (q *BlocksStoreQueryable) EjectedStoreGatewaysHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := q.stores.(interface {
+		EjectedStoreGatewaysHandler(http.ResponseWriter, *http.Request)
+	})
+	if !ok {
+		util.WriteTextResponse(w, "The store-gateways outlier detection is not supported by the configured blocks store set.")
+		return
+	}
+	s.EjectedStoreGatewaysHandler(w, r)
+}
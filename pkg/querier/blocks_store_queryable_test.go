@@ -22,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
@@ -31,6 +32,8 @@ import (
 	"github.com/weaveworks/common/user"
 	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/sharding"
@@ -1419,6 +1422,132 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 	}
 }
 
+func TestBlocksStoreExemplarQuerier_Select(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	var (
+		block1      = ulid.MustNew(1, nil)
+		block2      = ulid.MustNew(2, nil)
+		series1     = []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "series_1"}}
+		series2     = []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "series_2"}}
+		newExemplar = func(traceID string, ts int64) mimirpb.Exemplar {
+			return mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: traceID}}, Value: 1, TimestampMs: ts}
+		}
+	)
+
+	tests := map[string]struct {
+		finderResult      bucketindex.Blocks
+		storeSetResponses []interface{}
+		expected          []exemplar.QueryResult
+		expectedErr       string
+	}{
+		"no block in the storage matching the query time range": {
+			finderResult: nil,
+			expected:     []exemplar.QueryResult{},
+		},
+		"a single store-gateway instance holds the required blocks": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedExemplarsResponse: &storepb.ExemplarsResponse{
+						Timeseries: []mimirpb.TimeSeries{
+							{Labels: series1, Exemplars: []mimirpb.Exemplar{newExemplar("a", 10)}},
+							{Labels: series2, Exemplars: []mimirpb.Exemplar{newExemplar("b", 15)}},
+						},
+						Hints: mockExemplarsHints(block1, block2),
+					}}: {block1, block2},
+				},
+			},
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: mimirpb.FromLabelAdaptersToLabels(series1), Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{newExemplar("a", 10)})},
+				{SeriesLabels: mimirpb.FromLabelAdaptersToLabels(series2), Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{newExemplar("b", 15)})},
+			},
+		},
+		"multiple store-gateway instances returning the same exemplars for overlapping blocks": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedExemplarsResponse: &storepb.ExemplarsResponse{
+						Timeseries: []mimirpb.TimeSeries{{Labels: series1, Exemplars: []mimirpb.Exemplar{newExemplar("a", 10), newExemplar("b", 20)}}},
+						Hints:      mockExemplarsHints(block1),
+					}}: {block1},
+					&storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedExemplarsResponse: &storepb.ExemplarsResponse{
+						Timeseries: []mimirpb.TimeSeries{{Labels: series1, Exemplars: []mimirpb.Exemplar{newExemplar("a", 10), newExemplar("c", 15)}}},
+						Hints:      mockExemplarsHints(block2),
+					}}: {block2},
+				},
+			},
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: mimirpb.FromLabelAdaptersToLabels(series1), Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{newExemplar("a", 10), newExemplar("c", 15), newExemplar("b", 20)})},
+			},
+		},
+		"a store-gateway instance doesn't return the queried blocks": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedExemplarsResponse: &storepb.ExemplarsResponse{
+						Hints: mockExemplarsHints(),
+					}}: {block1},
+				},
+				// Second attempt returns an error because there are no other store-gateways left.
+				errors.New("no store-gateway remaining after exclude"),
+			},
+			expectedErr: newStoreConsistencyCheckFailedError([]ulid.ULID{block1}).Error(),
+		},
+		"a store-gateway instance fails the query because of the max exemplars bytes per query": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedExemplarsErr: status.Error(codes.ResourceExhausted, "the query exceeded the max size of the exemplars read from the blocks")}: {block1},
+				},
+			},
+			expectedErr: "the query exceeded the max size of the exemplars read from the blocks",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			stores := &blocksStoreSetMock{mockedResponses: testData.storeSetResponses}
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(testData.finderResult, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+			q := &blocksStoreExemplarQuerier{&blocksStoreQuerier{
+				ctx:         ctx,
+				userID:      "user-1",
+				finder:      finder,
+				stores:      stores,
+				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(nil),
+				limits:      &blocksStoreLimitsMock{},
+			}}
+
+			res, err := q.Select(minT, maxT, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "series_.*")})
+			if testData.expectedErr != "" {
+				require.ErrorContains(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, res)
+		})
+	}
+}
+
 func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T) {
 	now := time.Now()
 
@@ -1669,7 +1798,7 @@ func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 
 			// Instantiate the querier that will be executed to run the query.
 			logger := log.NewNopLogger()
-			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, logger, nil)
+			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, false, false, 0, logger, nil)
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(context.Background(), queryable))
 			defer services.StopAndAwaitTerminated(context.Background(), queryable) // nolint:errcheck
@@ -1839,6 +1968,8 @@ type storeGatewayClientMock struct {
 	mockedLabelNamesErr       error
 	mockedLabelValuesResponse *storepb.LabelValuesResponse
 	mockedLabelValuesErr      error
+	mockedExemplarsResponse   *storepb.ExemplarsResponse
+	mockedExemplarsErr        error
 }
 
 func (m *storeGatewayClientMock) Series(ctx context.Context, in *storepb.SeriesRequest, opts ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
@@ -1857,6 +1988,10 @@ func (m *storeGatewayClientMock) LabelValues(context.Context, *storepb.LabelValu
 	return m.mockedLabelValuesResponse, m.mockedLabelValuesErr
 }
 
+func (m *storeGatewayClientMock) Exemplars(context.Context, *storepb.ExemplarsRequest, ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	return m.mockedExemplarsResponse, m.mockedExemplarsErr
+}
+
 func (m *storeGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
@@ -1983,6 +2118,20 @@ func mockValuesHints(ids ...ulid.ULID) *types.Any {
 	return any
 }
 
+func mockExemplarsHints(ids ...ulid.ULID) *types.Any {
+	hints := &hintspb.ExemplarsResponseHints{}
+	for _, id := range ids {
+		hints.AddQueriedBlock(id)
+	}
+
+	any, err := types.MarshalAny(hints)
+	if err != nil {
+		panic(err)
+	}
+
+	return any
+}
+
 func namesFromSeries(series ...labels.Labels) []string {
 	namesMap := map[string]struct{}{}
 	for _, s := range series {
@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/ring"
@@ -21,6 +22,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/outlierdetection"
 )
 
 type loadBalancingStrategy int
@@ -38,8 +40,12 @@ type blocksStoreReplicationSet struct {
 	storesRing        *ring.Ring
 	clientsPool       *client.Pool
 	balancingStrategy loadBalancingStrategy
+	preferredZone     string
 	limits            BlocksStoreLimits
 
+	// The store-gateways with elevated latency or error rate, excluded from the queries if possible.
+	outliers *outlierdetection.Detector
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -48,21 +54,36 @@ type blocksStoreReplicationSet struct {
 func newBlocksStoreReplicationSet(
 	storesRing *ring.Ring,
 	balancingStrategy loadBalancingStrategy,
+	preferredZone string,
 	limits BlocksStoreLimits,
 	clientConfig ClientConfig,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*blocksStoreReplicationSet, error) {
+	outliers := outlierdetection.NewDetector(clientConfig.OutlierDetection, "store-gateway", logger, reg)
+
+	var poolOutliers *outlierdetection.Detector
+	if clientConfig.OutlierDetection.Enabled {
+		poolOutliers = outliers
+	}
+
 	s := &blocksStoreReplicationSet{
 		storesRing:         storesRing,
-		clientsPool:        newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, logger, reg),
+		clientsPool:        newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, poolOutliers, logger, reg),
 		balancingStrategy:  balancingStrategy,
+		preferredZone:      preferredZone,
 		limits:             limits,
+		outliers:           outliers,
 		subservicesWatcher: services.NewFailureWatcher(),
 	}
 
+	subservices := []services.Service{s.storesRing, s.clientsPool}
+	if clientConfig.OutlierDetection.Enabled {
+		subservices = append(subservices, s.outliers)
+	}
+
 	var err error
-	s.subservices, err = services.NewManager(s.storesRing, s.clientsPool)
+	s.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
 	}
@@ -113,8 +134,15 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 			return nil, errors.Wrapf(err, "failed to get store-gateway replication set owning the block %s", blockID.String())
 		}
 
-		// Pick a non excluded store-gateway instance.
-		addr := getNonExcludedInstanceAddr(set, exclude[blockID], s.balancingStrategy)
+		// Pick a non excluded store-gateway instance, preferring the ones not ejected because of their
+		// elevated latency or error rate.
+		addr := ""
+		if ejected := s.ejectedInstancesAddrs(set); len(ejected) > 0 {
+			addr = getNonExcludedInstanceAddr(set, append(ejected, exclude[blockID]...), s.balancingStrategy, s.preferredZone)
+		}
+		if addr == "" {
+			addr = getNonExcludedInstanceAddr(set, exclude[blockID], s.balancingStrategy, s.preferredZone)
+		}
 		if addr == "" {
 			return nil, fmt.Errorf("no store-gateway instance left after checking exclude for block %s", blockID.String())
 		}
@@ -137,7 +165,26 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 	return clients, nil
 }
 
-func getNonExcludedInstanceAddr(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy) string {
+// EjectedStoreGatewaysHandler serves the status page of the store-gateways ejected by the outlier detection.
+func (s *blocksStoreReplicationSet) EjectedStoreGatewaysHandler(w http.ResponseWriter, req *http.Request) {
+	s.outliers.ServeHTTP(w, req)
+}
+
+// ejectedInstancesAddrs returns the addresses of the instances of the replication set ejected by the outlier detection.
+func (s *blocksStoreReplicationSet) ejectedInstancesAddrs(set ring.ReplicationSet) []string {
+	var ejected []string
+	for _, instance := range set.Instances {
+		if s.outliers.IsEjected(instance.Addr) {
+			ejected = append(ejected, instance.Addr)
+		}
+	}
+	return ejected
+}
+
+// getNonExcludedInstanceAddr returns the address of a non excluded instance of the replication set. If a preferred
+// zone is configured, the instances in that zone are picked first, and the instances in the other zones are only
+// picked once all the instances in the preferred zone have been excluded (e.g. because the requests to them failed).
+func getNonExcludedInstanceAddr(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy, preferredZone string) string {
 	if balancingStrategy == randomLoadBalancing {
 		// Randomize the list of instances to not always query the same one.
 		rand.Shuffle(len(set.Instances), func(i, j int) {
@@ -145,6 +192,14 @@ func getNonExcludedInstanceAddr(set ring.ReplicationSet, exclude []string, balan
 		})
 	}
 
+	if preferredZone != "" {
+		for _, instance := range set.Instances {
+			if instance.Zone == preferredZone && !util.StringsContain(exclude, instance.Addr) {
+				return instance.Addr
+			}
+		}
+	}
+
 	for _, instance := range set.Instances {
 		if !util.StringsContain(exclude, instance.Addr) {
 			return instance.Addr
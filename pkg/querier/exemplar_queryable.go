@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// mergeExemplarQueryable is a storage.ExemplarQueryable merging the exemplars held in memory by the ingesters
+// with the exemplars persisted in the storage blocks.
+type mergeExemplarQueryable struct {
+	ingesters       storage.ExemplarQueryable
+	store           storage.ExemplarQueryable
+	queryStoreAfter time.Duration
+}
+
+func newMergeExemplarQueryable(ingesters, store storage.ExemplarQueryable, queryStoreAfter time.Duration) storage.ExemplarQueryable {
+	return &mergeExemplarQueryable{
+		ingesters:       ingesters,
+		store:           store,
+		queryStoreAfter: queryStoreAfter,
+	}
+}
+
+func (m *mergeExemplarQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	ingesters, err := m.ingesters.ExemplarQuerier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := m.store.ExemplarQuerier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergeExemplarQuerier{
+		ingesters:       ingesters,
+		store:           store,
+		queryStoreAfter: m.queryStoreAfter,
+	}, nil
+}
+
+type mergeExemplarQuerier struct {
+	ingesters       storage.ExemplarQuerier
+	store           storage.ExemplarQuerier
+	queryStoreAfter time.Duration
+}
+
+// Select implements storage.ExemplarQuerier. Ingesters are always queried, while the store is queried
+// only if the query time range starts before "now - query store after".
+func (m *mergeExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	queryStore := m.queryStoreAfter == 0 || start < util.TimeToMillis(time.Now().Add(-m.queryStoreAfter))
+	if !queryStore {
+		return m.ingesters.Select(start, end, matchers...)
+	}
+
+	var ingestersRes, storeRes []exemplar.QueryResult
+
+	g := errgroup.Group{}
+	g.Go(func() (err error) {
+		ingestersRes, err = m.ingesters.Select(start, end, matchers...)
+		return
+	})
+	g.Go(func() (err error) {
+		storeRes, err = m.store.Select(start, end, matchers...)
+		return
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if len(storeRes) == 0 {
+		return ingestersRes, nil
+	}
+	if len(ingestersRes) == 0 {
+		return storeRes, nil
+	}
+
+	// Exemplars are held in memory by ingesters after the blocks containing them have been shipped,
+	// so the same exemplars can be returned by both ingesters and store.
+	series := make([]mimirpb.TimeSeries, 0, len(ingestersRes)+len(storeRes))
+	for _, res := range [][]exemplar.QueryResult{ingestersRes, storeRes} {
+		for _, r := range res {
+			series = append(series, mimirpb.TimeSeries{
+				Labels:    mimirpb.FromLabelsToLabelAdapters(r.SeriesLabels),
+				Exemplars: mimirpb.FromExemplarsToExemplarProtos(r.Exemplars),
+			})
+		}
+	}
+
+	series = block.SortExemplarsSeries(series)
+
+	merged := make([]exemplar.QueryResult, 0, len(series))
+	for _, s := range series {
+		merged = append(merged, exemplar.QueryResult{
+			SeriesLabels: mimirpb.FromLabelAdaptersToLabels(s.Labels),
+			Exemplars:    mimirpb.FromExemplarProtosToExemplars(s.Exemplars),
+		})
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestMergeExemplarQueryable(t *testing.T) {
+	var (
+		now     = time.Now()
+		series1 = labels.FromStrings(labels.MetricName, "series_1")
+		series2 = labels.FromStrings(labels.MetricName, "series_2")
+		traceA  = exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "a"), Value: 1, Ts: 10}
+		traceB  = exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "b"), Value: 1, Ts: 20}
+		traceC  = exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "c"), Value: 1, Ts: 30}
+	)
+
+	ingesters := &exemplarQueryableMock{results: []exemplar.QueryResult{
+		{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{traceB, traceC}},
+	}}
+	store := &exemplarQueryableMock{results: []exemplar.QueryResult{
+		{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{traceA, traceB}},
+		{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{traceA}},
+	}}
+
+	tests := map[string]struct {
+		start, end    int64
+		expectedStore bool
+		expected      []exemplar.QueryResult
+	}{
+		"should only query ingesters if the query starts after the query store after period": {
+			start:    util.TimeToMillis(now.Add(-time.Hour)),
+			end:      util.TimeToMillis(now),
+			expected: ingesters.results,
+		},
+		"should query and merge ingesters and store if the query starts before the query store after period": {
+			start:         util.TimeToMillis(now.Add(-24 * time.Hour)),
+			end:           util.TimeToMillis(now),
+			expectedStore: true,
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{traceA, traceB, traceC}},
+				{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{traceA}},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			store.called = false
+
+			q, err := newMergeExemplarQueryable(ingesters, store, 12*time.Hour).ExemplarQuerier(context.Background())
+			require.NoError(t, err)
+
+			res, err := q.Select(testData.start, testData.end, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "series_.*")})
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, res)
+			assert.Equal(t, testData.expectedStore, store.called)
+		})
+	}
+}
+
+type exemplarQueryableMock struct {
+	results []exemplar.QueryResult
+	called  bool
+}
+
+func (m *exemplarQueryableMock) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *exemplarQueryableMock) Select(int64, int64, ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	m.called = true
+	return m.results, nil
+}
@@ -0,0 +1,332 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/objstore"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+const (
+	// LookupTableLabel is the name of the label matcher selecting the lookup table used to add labels
+	// to the series returned by a selector, e.g. {__mimir_lookup_table__="teams", job="node"}.
+	LookupTableLabel = "__mimir_lookup_table__"
+
+	// LookupTablesPathname is the path, in the tenant bucket, where the lookup tables are stored.
+	LookupTablesPathname = "lookup-tables"
+)
+
+// lookupTableNameRegexp matches the valid lookup table names, which can't reference other paths of the bucket.
+var lookupTableNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// lookupTable maps the values of a label of the series to the labels added to them.
+type lookupTable struct {
+	// KeyLabel is the name of the label whose value is looked up in the table.
+	KeyLabel string `yaml:"key_label"`
+
+	// Entries are the labels added to the series, by value of the key label.
+	Entries map[string]map[string]string `yaml:"entries"`
+
+	// labelNames are the names of all the labels added by the table.
+	labelNames map[string]struct{}
+}
+
+func parseLookupTable(r io.Reader) (*lookupTable, error) {
+	table := &lookupTable{}
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(table); err != nil {
+		return nil, err
+	}
+
+	if table.KeyLabel == "" {
+		return nil, errors.New("the key label is required")
+	}
+
+	table.labelNames = map[string]struct{}{}
+	for _, entry := range table.Entries {
+		for name := range entry {
+			if !model.LabelName(name).IsValid() || name == labels.MetricName {
+				return nil, fmt.Errorf("invalid label name: %s", name)
+			}
+			table.labelNames[name] = struct{}{}
+		}
+	}
+
+	return table, nil
+}
+
+// addLabels returns the input labels with the labels of the table entry matching the value of the key label.
+// The labels already set in the input labels are not overridden.
+func (t *lookupTable) addLabels(lbls labels.Labels, builder *labels.Builder) labels.Labels {
+	entry, ok := t.Entries[lbls.Get(t.KeyLabel)]
+	if !ok {
+		return lbls
+	}
+
+	builder.Reset(lbls)
+	for name, value := range entry {
+		if lbls.Get(name) == "" {
+			builder.Set(name, value)
+		}
+	}
+	return builder.Labels(nil)
+}
+
+type cachedLookupTable struct {
+	table    *lookupTable
+	loadedAt time.Time
+}
+
+// lookupTables loads the lookup tables of the tenants from the bucket, caching them for a TTL.
+type lookupTables struct {
+	bkt    objstore.Bucket
+	ttl    time.Duration
+	logger log.Logger
+
+	mtx   sync.Mutex
+	cache map[string]cachedLookupTable
+
+	loads        prometheus.Counter
+	loadFailures prometheus.Counter
+}
+
+func newLookupTables(bkt objstore.Bucket, ttl time.Duration, logger log.Logger, reg prometheus.Registerer) *lookupTables {
+	return &lookupTables{
+		bkt:    bkt,
+		ttl:    ttl,
+		logger: logger,
+		cache:  map[string]cachedLookupTable{},
+		loads: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_lookup_table_loads_total",
+			Help: "Total number of lookup tables loaded from the storage.",
+		}),
+		loadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_lookup_table_load_failures_total",
+			Help: "Total number of lookup tables failed to be loaded from the storage.",
+		}),
+	}
+}
+
+// get returns the lookup table of the tenant, loading it from the bucket if it's not cached or expired.
+func (l *lookupTables) get(ctx context.Context, userID, name string) (*lookupTable, error) {
+	key := userID + "/" + name
+
+	l.mtx.Lock()
+	cached, ok := l.cache[key]
+	l.mtx.Unlock()
+
+	if ok && time.Since(cached.loadedAt) < l.ttl {
+		return cached.table, nil
+	}
+
+	table, err := l.load(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mtx.Lock()
+	l.cache[key] = cachedLookupTable{table: table, loadedAt: time.Now()}
+	l.mtx.Unlock()
+
+	return table, nil
+}
+
+func (l *lookupTables) load(ctx context.Context, userID, name string) (*lookupTable, error) {
+	l.loads.Inc()
+
+	userBkt := bucket.NewUserBucketClient(userID, l.bkt, nil)
+	r, err := userBkt.Get(ctx, LookupTablesPathname+"/"+name+".yaml")
+	if userBkt.IsObjNotFoundErr(err) {
+		return nil, fmt.Errorf("the lookup table %s does not exist", name)
+	}
+	if err != nil {
+		l.loadFailures.Inc()
+		level.Warn(l.logger).Log("msg", "failed to load lookup table", "user", userID, "table", name, "err", err)
+		return nil, errors.Wrapf(err, "failed to load the lookup table %s", name)
+	}
+	defer func() { _ = r.Close() }()
+
+	table, err := parseLookupTable(r)
+	if err != nil {
+		l.loadFailures.Inc()
+		return nil, errors.Wrapf(err, "invalid lookup table %s", name)
+	}
+	return table, nil
+}
+
+// NewLookupTablesQueryable returns a queryable adding to the series selected with the LookupTableLabel
+// matcher the labels of the tenant lookup table, stored in the bucket.
+func NewLookupTablesQueryable(queryable storage.Queryable, bkt objstore.Bucket, ttl time.Duration, logger log.Logger, reg prometheus.Registerer) storage.SampleAndChunkQueryable {
+	tables := newLookupTables(bkt, ttl, logger, reg)
+
+	return NewSampleAndChunkQueryable(storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		q, err := queryable.Querier(ctx, mint, maxt)
+		if err != nil {
+			return nil, err
+		}
+		return &lookupTablesQuerier{Querier: q, ctx: ctx, tables: tables}, nil
+	}))
+}
+
+type lookupTablesQuerier struct {
+	storage.Querier
+
+	ctx    context.Context
+	tables *lookupTables
+}
+
+// Select implements storage.Querier. The matchers on the labels added by the lookup table
+// are applied to the series after adding the labels.
+func (q *lookupTablesQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var (
+		tableMatcher *labels.Matcher
+		others       = make([]*labels.Matcher, 0, len(matchers))
+	)
+	for _, m := range matchers {
+		if m.Name == LookupTableLabel {
+			tableMatcher = m
+		} else {
+			others = append(others, m)
+		}
+	}
+
+	if tableMatcher == nil {
+		return q.Querier.Select(sortSeries, hints, matchers...)
+	}
+	if tableMatcher.Type != labels.MatchEqual {
+		return storage.ErrSeriesSet(fmt.Errorf("the %s label matcher must be an equality matcher", LookupTableLabel))
+	}
+	if !lookupTableNameRegexp.MatchString(tableMatcher.Value) {
+		return storage.ErrSeriesSet(fmt.Errorf("invalid lookup table name: %s", tableMatcher.Value))
+	}
+
+	userID, err := tenant.TenantID(q.ctx)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	table, err := q.tables.get(q.ctx, userID, tableMatcher.Value)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	var storageMatchers, tableMatchers []*labels.Matcher
+	for _, m := range others {
+		if _, ok := table.labelNames[m.Name]; ok {
+			tableMatchers = append(tableMatchers, m)
+		} else {
+			storageMatchers = append(storageMatchers, m)
+		}
+	}
+	if len(storageMatchers) == 0 {
+		return storage.ErrSeriesSet(fmt.Errorf("the selector must have at least one label matcher not on the labels added by the lookup table %s", tableMatcher.Value))
+	}
+
+	set := &lookupTableSeriesSet{
+		SeriesSet: q.Querier.Select(sortSeries, hints, storageMatchers...),
+		table:     table,
+		matchers:  tableMatchers,
+		builder:   labels.NewBuilder(nil),
+	}
+
+	// Adding labels may change the order of the series, so they're sorted again if required.
+	if sortSeries {
+		return set.sorted()
+	}
+	return set
+}
+
+// lookupTableSeriesSet adds the labels of the lookup table to the series of the wrapped set,
+// filtering them out if they don't match the matchers on the added labels.
+type lookupTableSeriesSet struct {
+	storage.SeriesSet
+
+	table    *lookupTable
+	matchers []*labels.Matcher
+	builder  *labels.Builder
+	curr     storage.Series
+}
+
+func (s *lookupTableSeriesSet) Next() bool {
+	for s.SeriesSet.Next() {
+		series := s.SeriesSet.At()
+		lbls := s.table.addLabels(series.Labels(), s.builder)
+
+		if !matchesAll(s.matchers, lbls) {
+			continue
+		}
+
+		s.curr = &labelledSeries{Series: series, labels: lbls}
+		return true
+	}
+	return false
+}
+
+func (s *lookupTableSeriesSet) At() storage.Series {
+	return s.curr
+}
+
+// sorted returns a set with the series of s sorted by labels.
+func (s *lookupTableSeriesSet) sorted() storage.SeriesSet {
+	var series []storage.Series
+	for s.Next() {
+		series = append(series, s.At())
+	}
+	if err := s.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return labels.Compare(series[i].Labels(), series[j].Labels()) < 0
+	})
+	return &sortedLookupTableSeriesSet{sliceSeriesSet: sliceSeriesSet{series: series, ix: -1}, warnings: s.Warnings()}
+}
+
+type sortedLookupTableSeriesSet struct {
+	sliceSeriesSet
+	warnings storage.Warnings
+}
+
+func (s *sortedLookupTableSeriesSet) Warnings() storage.Warnings {
+	return s.warnings
+}
+
+func matchesAll(matchers []*labels.Matcher, lbls labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// labelledSeries is a storage.Series whose labels are replaced.
+type labelledSeries struct {
+	storage.Series
+	labels labels.Labels
+}
+
+func (s *labelledSeries) Labels() labels.Labels {
+	return s.labels
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+func TestLookupTablesQueryable(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, bkt.Upload(context.Background(), "user-1/lookup-tables/teams.yaml", strings.NewReader(`
+key_label: instance
+entries:
+  host-1:
+    team: a
+  host-2:
+    team: b
+    env: prod
+  host-3:
+    team: c
+    env: dev
+`)))
+
+	stored := []labels.Labels{
+		labels.FromStrings(model.MetricNameLabel, "up", "env", "staging", "instance", "host-3", "job", "node"),
+		labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-1", "job", "node"),
+		labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-2", "job", "node"),
+		labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-4", "job", "node"),
+	}
+
+	tests := map[string]struct {
+		matchers    []*labels.Matcher
+		expected    []labels.Labels
+		expectedErr string
+	}{
+		"no lookup table": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "node")},
+			expected: stored,
+		},
+		"labels added by the lookup table, without overriding the existing ones": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, LookupTableLabel, "teams"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "node"),
+			},
+			expected: []labels.Labels{
+				labels.FromStrings(model.MetricNameLabel, "up", "env", "prod", "instance", "host-2", "job", "node", "team", "b"),
+				labels.FromStrings(model.MetricNameLabel, "up", "env", "staging", "instance", "host-3", "job", "node", "team", "c"),
+				labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-1", "job", "node", "team", "a"),
+				labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-4", "job", "node"),
+			},
+		},
+		"matchers on the labels added by the lookup table": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, LookupTableLabel, "teams"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "node"),
+				labels.MustNewMatcher(labels.MatchRegexp, "team", "a|b"),
+			},
+			expected: []labels.Labels{
+				labels.FromStrings(model.MetricNameLabel, "up", "env", "prod", "instance", "host-2", "job", "node", "team", "b"),
+				labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-1", "job", "node", "team", "a"),
+			},
+		},
+		"only matchers on the labels added by the lookup table": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, LookupTableLabel, "teams"),
+				labels.MustNewMatcher(labels.MatchEqual, "team", "a"),
+			},
+			expectedErr: "the selector must have at least one label matcher not on the labels added by the lookup table teams",
+		},
+		"not existing lookup table": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, LookupTableLabel, "unknown"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "node"),
+			},
+			expectedErr: "the lookup table unknown does not exist",
+		},
+		"invalid lookup table name": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, LookupTableLabel, "../user-2/teams"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "node"),
+			},
+			expectedErr: "invalid lookup table name: ../user-2/teams",
+		},
+		"not equality lookup table matcher": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, LookupTableLabel, "teams"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "node"),
+			},
+			expectedErr: "the __mimir_lookup_table__ label matcher must be an equality matcher",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			queryable := NewLookupTablesQueryable(storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+				return &lookupTablesTestQuerier{series: stored}, nil
+			}), bkt, time.Minute, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			q, err := queryable.Querier(ctx, 0, 10)
+			require.NoError(t, err)
+
+			set := q.Select(true, nil, testData.matchers...)
+
+			var actual []labels.Labels
+			for set.Next() {
+				actual = append(actual, set.At().Labels())
+			}
+
+			if testData.expectedErr != "" {
+				require.EqualError(t, set.Err(), testData.expectedErr)
+				return
+			}
+			require.NoError(t, set.Err())
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestLookupTablesQueryable_ShouldSortSeriesAfterAddingLabels(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, bkt.Upload(context.Background(), "user-1/lookup-tables/teams.yaml", strings.NewReader(`
+key_label: instance
+entries:
+  host-1: {a_team: z}
+  host-2: {a_team: y}
+`)))
+
+	stored := []labels.Labels{
+		labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-1"),
+		labels.FromStrings(model.MetricNameLabel, "up", "instance", "host-2"),
+	}
+
+	queryable := NewLookupTablesQueryable(storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		return &lookupTablesTestQuerier{series: stored}, nil
+	}), bkt, time.Minute, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+	q, err := queryable.Querier(user.InjectOrgID(context.Background(), "user-1"), 0, 10)
+	require.NoError(t, err)
+
+	matchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, LookupTableLabel, "teams"),
+		labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, "up"),
+	}
+
+	for _, sortSeries := range []bool{true, false} {
+		var actual []labels.Labels
+		set := q.Select(sortSeries, nil, matchers...)
+		for set.Next() {
+			actual = append(actual, set.At().Labels())
+		}
+		require.NoError(t, set.Err())
+
+		if sortSeries {
+			assert.Equal(t, []labels.Labels{
+				labels.FromStrings(model.MetricNameLabel, "up", "a_team", "y", "instance", "host-2"),
+				labels.FromStrings(model.MetricNameLabel, "up", "a_team", "z", "instance", "host-1"),
+			}, actual)
+		} else {
+			assert.Len(t, actual, 2)
+		}
+	}
+}
+
+func TestParseLookupTable_ShouldReturnErrorOnInvalidTable(t *testing.T) {
+	tests := map[string]struct {
+		table       string
+		expectedErr string
+	}{
+		"missing key label": {
+			table:       `entries: {host-1: {team: a}}`,
+			expectedErr: "the key label is required",
+		},
+		"invalid label name": {
+			table:       `{key_label: instance, entries: {host-1: {"team-name": a}}}`,
+			expectedErr: "invalid label name: team-name",
+		},
+		"metric name label": {
+			table:       `{key_label: instance, entries: {host-1: {__name__: a}}}`,
+			expectedErr: "invalid label name: __name__",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := parseLookupTable(strings.NewReader(testData.table))
+			assert.EqualError(t, err, testData.expectedErr)
+		})
+	}
+}
+
+// lookupTablesTestQuerier is a storage.Querier returning the series matching the matchers, in the input order.
+type lookupTablesTestQuerier struct {
+	storage.Querier
+	series []labels.Labels
+}
+
+func (q *lookupTablesTestQuerier) Select(_ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var result []storage.Series
+	for _, lbls := range q.series {
+		if matchesAll(matchers, lbls) {
+			result = append(result, series.NewConcreteSeries(lbls, nil))
+		}
+	}
+	return series.NewConcreteSeriesSet(result)
+}
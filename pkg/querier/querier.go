@@ -46,10 +46,31 @@ type Config struct {
 	QueryStoreAfter    time.Duration `yaml:"query_store_after" category:"advanced"`
 	MaxQueryIntoFuture time.Duration `yaml:"max_query_into_future" category:"advanced"`
 
+	// QueryStoreForExemplars enables querying the exemplars persisted in the storage blocks.
+	QueryStoreForExemplars bool `yaml:"query_store_for_exemplars" category:"experimental"`
+
 	StoreGatewayClient ClientConfig `yaml:"store_gateway_client"`
 
+	// PreferAvailabilityZone is the availability zone of the store-gateways to query first.
+	PreferAvailabilityZone string `yaml:"prefer_availability_zone" category:"experimental"`
+
+	// ChunksCoverageVerificationEnabled enables the verification of the time range coverage of the chunks returned by the store-gateways.
+	ChunksCoverageVerificationEnabled bool `yaml:"chunks_coverage_verification_enabled" category:"experimental"`
+
 	ShuffleShardingIngestersEnabled bool `yaml:"shuffle_sharding_ingesters_enabled" category:"advanced"`
 
+	// StreamingSeriesMergeBatchSize is the max number of series read at a time from the results of each
+	// of the ingesters and store-gateways when merging them. 0 to disable the streaming merge.
+	StreamingSeriesMergeBatchSize int `yaml:"streaming_series_merge_batch_size" category:"experimental"`
+
+	// LookupTablesEnabled enables adding the labels of the tenant lookup tables to the series at query time.
+	LookupTablesEnabled  bool          `yaml:"lookup_tables_enabled" category:"experimental"`
+	LookupTablesCacheTTL time.Duration `yaml:"lookup_tables_cache_ttl" category:"experimental"`
+
+	// TenantQueueTimeout is the max time a query waits for the running queries of its tenant to complete,
+	// when the tenant reached the max number of concurrent queries per querier.
+	TenantQueueTimeout time.Duration `yaml:"tenant_queue_timeout" category:"experimental"`
+
 	// PromQL engine config.
 	EngineConfig engine.Config `yaml:",inline"`
 }
@@ -72,7 +93,15 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.QueryIngestersWithin, queryIngestersWithinFlag, 13*time.Hour, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
 	f.DurationVar(&cfg.MaxQueryIntoFuture, "querier.max-query-into-future", 10*time.Minute, "Maximum duration into the future you can query. 0 to disable.")
 	f.DurationVar(&cfg.QueryStoreAfter, queryStoreAfterFlag, 12*time.Hour, "The time after which a metric should be queried from storage and not just ingesters. 0 means all queries are sent to store. If this option is enabled, the time range of the query sent to the store-gateway will be manipulated to ensure the query end is not more recent than 'now - query-store-after'.")
+	f.BoolVar(&cfg.QueryStoreForExemplars, "querier.query-store-for-exemplars", false, fmt.Sprintf("True to also query the exemplars persisted in the storage blocks through the store-gateways, when the query time range starts before the -%s period. Exemplars are persisted in the blocks only when -blocks-storage.tsdb.ship-exemplars is enabled in the ingesters.", queryStoreAfterFlag))
+	f.BoolVar(&cfg.ChunksCoverageVerificationEnabled, "querier.chunks-coverage-verification-enabled", false, "True to compare, for each series returned by the store-gateways, the time range covered by its chunks against the query time range, and return a warning for each gap in a time range not covered by any block, which is likely caused by blocks missing from the storage.")
+	f.StringVar(&cfg.PreferAvailabilityZone, "querier.prefer-availability-zone", "", "The availability zone where this querier is running. When set, the querier queries the store-gateways in the same availability zone first, and only falls back to the store-gateways in the other zones when the requests to the ones in the same zone fail, reducing the cross-zone data transfer. Requires -store-gateway.sharding-ring.zone-awareness-enabled.")
 	f.BoolVar(&cfg.ShuffleShardingIngestersEnabled, "querier.shuffle-sharding-ingesters-enabled", true, fmt.Sprintf("Fetch in-memory series from the minimum set of required ingesters, selecting only ingesters which may have received series since -%s. If this setting is false or -%s is '0', queriers always query all ingesters (ingesters shuffle sharding on read path is disabled).", queryIngestersWithinFlag, queryIngestersWithinFlag))
+	f.IntVar(&cfg.StreamingSeriesMergeBatchSize, "querier.streaming-series-merge-batch-size", 0, "If greater than 0, the series returned by the ingesters and store-gateways are merged while being read, reading up to this number of series at a time from the results of each of them, instead of collecting the chunks of all the series before merging them. 0 to disable.")
+
+	f.BoolVar(&cfg.LookupTablesEnabled, "querier.lookup-tables-enabled", false, fmt.Sprintf("True to add to the series selected with the %s label matcher the labels of the tenant lookup table with the matcher value as name, stored in the blocks storage bucket at <tenant>/%s/<name>.yaml. The lookup table maps the values of a label of the series to the labels added to them.", LookupTableLabel, LookupTablesPathname))
+	f.DurationVar(&cfg.LookupTablesCacheTTL, "querier.lookup-tables-cache-ttl", 5*time.Minute, "How long the lookup tables loaded from the storage are cached in memory before being loaded again.")
+	f.DurationVar(&cfg.TenantQueueTimeout, "querier.tenant-queue-timeout", 10*time.Second, "Maximum time a query waits inside the querier for the running queries of its tenant to complete, when the tenant reached -querier.max-concurrent-queries-per-tenant. The query is then failed with a retryable error, so that the query-frontend can retry it, possibly on another querier. 0 to wait until the query is canceled.")
 
 	cfg.EngineConfig.RegisterFlags(f)
 }
@@ -86,6 +115,10 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if err := cfg.StoreGatewayClient.OutlierDetection.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -98,8 +131,9 @@ func getChunksIteratorFunction(cfg Config) chunkIteratorFunc {
 	return mergeChunks
 }
 
-// New builds a queryable and promql engine.
-func New(cfg Config, limits *validation.Overrides, distributor Distributor, stores []QueryableWithFilter, reg prometheus.Registerer, logger log.Logger, tracker *activitytracker.ActivityTracker) (storage.SampleAndChunkQueryable, storage.ExemplarQueryable, *promql.Engine) {
+// New builds a queryable and promql engine. The optional storeExemplars is used to query the exemplars
+// persisted in the storage, if enabled.
+func New(cfg Config, limits *validation.Overrides, distributor Distributor, stores []QueryableWithFilter, storeExemplars storage.ExemplarQueryable, reg prometheus.Registerer, logger log.Logger, tracker *activitytracker.ActivityTracker) (storage.SampleAndChunkQueryable, storage.ExemplarQueryable, *promql.Engine) {
 	iteratorFunc := getChunksIteratorFunction(cfg)
 
 	distributorQueryable := newDistributorQueryable(distributor, iteratorFunc, cfg.QueryIngestersWithin, logger)
@@ -113,6 +147,9 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 	}
 	queryable := NewQueryable(distributorQueryable, ns, iteratorFunc, cfg, limits, logger)
 	exemplarQueryable := newDistributorExemplarQueryable(distributor, logger)
+	if cfg.QueryStoreForExemplars && storeExemplars != nil {
+		exemplarQueryable = newMergeExemplarQueryable(exemplarQueryable, storeExemplars, cfg.QueryStoreAfter)
+	}
 
 	lazyQueryable := storage.QueryableFunc(func(ctx context.Context, mint int64, maxt int64) (storage.Querier, error) {
 		querier, err := queryable.Querier(ctx, mint, maxt)
@@ -183,13 +220,17 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			ctx:                ctx,
 			mint:               mint,
 			maxt:               maxt,
+			userID:             userID,
 			chunkIterFn:        chunkIterFn,
 			limits:             limits,
 			maxQueryIntoFuture: cfg.MaxQueryIntoFuture,
 			logger:             logger,
+
+			streamingSeriesMergeBatchSize: cfg.StreamingSeriesMergeBatchSize,
 		}
 
-		if distributor.UseQueryable(now, mint, maxt) {
+		useDistributor := distributor.UseQueryable(now, mint, maxt)
+		if useDistributor {
 			dqr, err := distributor.Querier(ctx, mint, maxt)
 			if err != nil {
 				return nil, err
@@ -197,8 +238,13 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			q.queriers = append(q.queriers, dqr)
 		}
 
+		// The read endpoints disabled in the ingesters are served by the stores only,
+		// regardless of the query time range.
+		useStoresOnly := useDistributor && len(limits.IngesterDisabledReadEndpoints(userID)) > 0
+
 		for _, s := range stores {
-			if !s.UseQueryable(now, mint, maxt) {
+			useStore := s.UseQueryable(now, mint, maxt)
+			if !useStore && !useStoresOnly {
 				continue
 			}
 
@@ -207,7 +253,16 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 				return nil, err
 			}
 
-			q.queriers = append(q.queriers, cqr)
+			if useStore {
+				q.queriers = append(q.queriers, cqr)
+			}
+			if useStoresOnly {
+				q.storeQueriers = append(q.storeQueriers, cqr)
+			}
+		}
+
+		if !useStoresOnly {
+			q.storeQueriers = q.queriers
 		}
 
 		return q, nil
@@ -218,13 +273,20 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 type querier struct {
 	queriers []storage.Querier
 
+	// storeQueriers are the queriers used for the read endpoints disabled in the ingesters for the tenant.
+	storeQueriers []storage.Querier
+
 	chunkIterFn chunkIteratorFunc
 	ctx         context.Context
 	mint, maxt  int64
+	userID      string
 
 	limits             *validation.Overrides
 	maxQueryIntoFuture time.Duration
 	logger             log.Logger
+
+	// streamingSeriesMergeBatchSize is the batch size of the streaming merge of the series sets, 0 if disabled.
+	streamingSeriesMergeBatchSize int
 }
 
 // Select implements storage.Querier interface.
@@ -275,19 +337,24 @@ func (q querier) Select(_ bool, sp *storage.SelectHints, matchers ...*labels.Mat
 		return storage.ErrSeriesSet(validation.NewMaxQueryLengthError(endTime.Sub(startTime), maxQueryLength))
 	}
 
-	if len(q.queriers) == 1 {
-		return q.queriers[0].Select(true, sp, matchers...)
+	queriers := q.queriers
+	if sp.Func == "series" {
+		queriers = q.queriersFor(validation.IngesterReadEndpointSeries)
+	}
+
+	if len(queriers) == 1 {
+		return queriers[0].Select(true, sp, matchers...)
 	}
 
-	sets := make(chan storage.SeriesSet, len(q.queriers))
-	for _, querier := range q.queriers {
+	sets := make(chan storage.SeriesSet, len(queriers))
+	for _, querier := range queriers {
 		go func(querier storage.Querier) {
 			sets <- querier.Select(true, sp, matchers...)
 		}(querier)
 	}
 
 	var result []storage.SeriesSet
-	for range q.queriers {
+	for range queriers {
 		select {
 		case set := <-sets:
 			result = append(result, set)
@@ -304,8 +371,9 @@ func (q querier) Select(_ bool, sp *storage.SelectHints, matchers ...*labels.Mat
 
 // LabelValues implements storage.Querier.
 func (q querier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
-	if len(q.queriers) == 1 {
-		return q.queriers[0].LabelValues(name, matchers...)
+	queriers := q.queriersFor(validation.IngesterReadEndpointLabelValues)
+	if len(queriers) == 1 {
+		return queriers[0].LabelValues(name, matchers...)
 	}
 
 	var (
@@ -316,7 +384,7 @@ func (q querier) LabelValues(name string, matchers ...*labels.Matcher) ([]string
 		resMtx sync.Mutex
 	)
 
-	for _, querier := range q.queriers {
+	for _, querier := range queriers {
 		// Need to reassign as the original variable will change and can't be relied on in a goroutine.
 		querier := querier
 		g.Go(func() error {
@@ -344,8 +412,9 @@ func (q querier) LabelValues(name string, matchers ...*labels.Matcher) ([]string
 }
 
 func (q querier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
-	if len(q.queriers) == 1 {
-		return q.queriers[0].LabelNames(matchers...)
+	queriers := q.queriersFor(validation.IngesterReadEndpointLabelNames)
+	if len(queriers) == 1 {
+		return queriers[0].LabelNames(matchers...)
 	}
 
 	var (
@@ -356,7 +425,7 @@ func (q querier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warn
 		resMtx sync.Mutex
 	)
 
-	for _, querier := range q.queriers {
+	for _, querier := range queriers {
 		// Need to reassign as the original variable will change and can't be relied on in a goroutine.
 		querier := querier
 		g.Go(func() error {
@@ -383,11 +452,23 @@ func (q querier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warn
 	return util.MergeSlices(sets...), warnings, nil
 }
 
+// queriersFor returns the queriers to use for the given read endpoint.
+func (q querier) queriersFor(endpoint string) []storage.Querier {
+	if q.limits.IngesterReadEndpointDisabled(q.userID, endpoint) {
+		return q.storeQueriers
+	}
+	return q.queriers
+}
+
 func (querier) Close() error {
 	return nil
 }
 
 func (q querier) mergeSeriesSets(sets []storage.SeriesSet) storage.SeriesSet {
+	if q.streamingSeriesMergeBatchSize > 0 {
+		return newStreamingMergeSeriesSet(sets, q.streamingSeriesMergeBatchSize, q.mint, q.maxt, q.chunkIterFn)
+	}
+
 	// Here we deal with sets that are based on chunks and build single set from them.
 	// Remaining sets are merged with chunks-based one using storage.NewMergeSeriesSet
 
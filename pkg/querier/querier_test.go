@@ -128,7 +128,7 @@ func TestQuerier(t *testing.T) {
 				require.NoError(t, err)
 
 				queryables := []QueryableWithFilter{UseAlwaysQueryable(db)}
-				queryable, _, _ := New(cfg, overrides, distributor, queryables, nil, log.NewNopLogger(), nil)
+				queryable, _, _ := New(cfg, overrides, distributor, queryables, nil, nil, log.NewNopLogger(), nil)
 				testRangeQuery(t, queryable, through, query)
 			})
 		}
@@ -204,7 +204,7 @@ func TestQuerier_QueryableReturnsChunksOutsideQueriedRange(t *testing.T) {
 		Timeout:    1 * time.Minute,
 	})
 
-	queryable, _, _ := New(cfg, overrides, distributor, nil, nil, logger, nil)
+	queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, logger, nil)
 	query, err := engine.NewRangeQuery(queryable, nil, `sum({__name__=~".+"})`, queryStart, queryEnd, queryStep)
 	require.NoError(t, err)
 
@@ -331,7 +331,7 @@ func TestQuerier_QueryIngestersWithinConfig(t *testing.T) {
 			// with no store queryable.
 			var storeQueryables []QueryableWithFilter
 
-			queryable, _, _ := New(cfg, overrides, distributor, storeQueryables, nil, log.NewNopLogger(), nil)
+			queryable, _, _ := New(cfg, overrides, distributor, storeQueryables, nil, nil, log.NewNopLogger(), nil)
 			query, err := engine.NewRangeQuery(queryable, nil, "dummy", c.mint, c.maxt, 1*time.Minute)
 			require.NoError(t, err)
 
@@ -415,7 +415,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryIntoFuture(t *testing.T) {
 			overrides, err := validation.NewOverrides(defaultLimitsConfig(), nil)
 			require.NoError(t, err)
 
-			queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger(), nil)
+			queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, log.NewNopLogger(), nil)
 			query, err := engine.NewRangeQuery(queryable, nil, "dummy", c.queryStartTime, c.queryEndTime, time.Minute)
 			require.NoError(t, err)
 
@@ -487,7 +487,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLength(t *testing.T) {
 
 			// We don't need to query any data for this test, so an empty distributor is fine.
 			distributor := &emptyDistributor{}
-			queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger(), nil)
+			queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, log.NewNopLogger(), nil)
 
 			// Create the PromQL engine to execute the query.
 			engine := promql.NewEngine(promql.EngineOpts{
@@ -608,7 +608,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLookback(t *testing.T) {
 				distributor.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Matrix{}, nil)
 				distributor.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&client.QueryStreamResponse{}, nil)
 
-				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger(), nil)
+				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, log.NewNopLogger(), nil)
 				require.NoError(t, err)
 
 				query, err := engine.NewRangeQuery(queryable, nil, testData.query, testData.queryStartTime, testData.queryEndTime, time.Minute)
@@ -636,7 +636,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLookback(t *testing.T) {
 				distributor := &mockDistributor{}
 				distributor.On("MetricsForLabelMatchers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]labels.Labels{}, nil)
 
-				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger(), nil)
+				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, log.NewNopLogger(), nil)
 				q, err := queryable.Querier(ctx, util.TimeToMillis(testData.queryStartTime), util.TimeToMillis(testData.queryEndTime))
 				require.NoError(t, err)
 
@@ -671,7 +671,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLookback(t *testing.T) {
 				distributor := &mockDistributor{}
 				distributor.On("LabelNames", mock.Anything, mock.Anything, mock.Anything, matchers).Return([]string{}, nil)
 
-				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger(), nil)
+				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, log.NewNopLogger(), nil)
 				q, err := queryable.Querier(ctx, util.TimeToMillis(testData.queryStartTime), util.TimeToMillis(testData.queryEndTime))
 				require.NoError(t, err)
 
@@ -697,7 +697,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLookback(t *testing.T) {
 				distributor := &mockDistributor{}
 				distributor.On("LabelValuesForLabelName", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
 
-				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger(), nil)
+				queryable, _, _ := New(cfg, overrides, distributor, nil, nil, nil, log.NewNopLogger(), nil)
 				q, err := queryable.Querier(ctx, util.TimeToMillis(testData.queryStartTime), util.TimeToMillis(testData.queryEndTime))
 				require.NoError(t, err)
 
@@ -767,7 +767,7 @@ func TestQuerier_MaxLabelsQueryRange(t *testing.T) {
 				distributor := &mockDistributor{}
 				distributor.On("MetricsForLabelMatchers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]labels.Labels{}, nil)
 
-				queryable, _, _ := New(cfg, overrides, distributor, storeQueryable, nil, log.NewNopLogger(), nil)
+				queryable, _, _ := New(cfg, overrides, distributor, storeQueryable, nil, nil, log.NewNopLogger(), nil)
 				q, err := queryable.Querier(ctx, util.TimeToMillis(testData.queryStartTime), util.TimeToMillis(testData.queryEndTime))
 				require.NoError(t, err)
 
@@ -966,7 +966,7 @@ func TestQuerier_QueryStoreAfterConfig(t *testing.T) {
 			querier := &mockBlocksStorageQuerier{}
 			querier.On("Select", true, mock.Anything, expectedMatchers).Return(storage.EmptySeriesSet())
 
-			queryable, _, _ := New(cfg, overrides, distributor, []QueryableWithFilter{UseAlwaysQueryable(newMockBlocksStorageQueryable(querier))}, nil, log.NewNopLogger(), nil)
+			queryable, _, _ := New(cfg, overrides, distributor, []QueryableWithFilter{UseAlwaysQueryable(newMockBlocksStorageQueryable(querier))}, nil, nil, log.NewNopLogger(), nil)
 			query, err := engine.NewRangeQuery(queryable, nil, "metric", c.mint, c.maxt, 1*time.Minute)
 			require.NoError(t, err)
 
@@ -996,6 +996,66 @@ func TestQuerier_QueryStoreAfterConfig(t *testing.T) {
 	}
 }
 
+func TestQuerier_IngesterDisabledReadEndpoints(t *testing.T) {
+	var (
+		now      = time.Now()
+		mint     = util.TimeToMillis(now.Add(-5 * time.Minute))
+		maxt     = util.TimeToMillis(now)
+		matchers = []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric")}
+	)
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+
+	for _, disabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("endpoints disabled: %t", disabled), func(t *testing.T) {
+			limits := defaultLimitsConfig()
+			if disabled {
+				limits.IngesterDisabledReadEndpoints = []string{validation.IngesterReadEndpointLabelNames, validation.IngesterReadEndpointLabelValues, validation.IngesterReadEndpointSeries}
+			}
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			storeQuerier := &mockBlocksStorageQuerier{}
+			storeQuerier.On("Select", true, mock.Anything, matchers).Return(storage.EmptySeriesSet())
+			storeQuerier.On("LabelValues", labels.MetricName, matchers).Return([]string{"metric"}, storage.Warnings(nil), nil)
+			storeQuerier.On("LabelNames", matchers).Return([]string{labels.MetricName}, storage.Warnings(nil), nil)
+
+			// The store is not queried for the most recent time range, so it's used only for the disabled endpoints.
+			stores := []QueryableWithFilter{storeQueryable{QueryableWithFilter: UseAlwaysQueryable(newMockBlocksStorageQueryable(storeQuerier)), QueryStoreAfter: time.Hour}}
+			queryable := NewQueryable(newDistributorQueryable(&errDistributor{}, nil, 0, log.NewNopLogger()), stores, nil, cfg, overrides, log.NewNopLogger())
+
+			ctx := user.InjectOrgID(context.Background(), "0")
+			q, err := queryable.Querier(ctx, mint, maxt)
+			require.NoError(t, err)
+
+			values, _, err := q.LabelValues(labels.MetricName, matchers...)
+			names, _, namesErr := q.LabelNames(matchers...)
+			set := q.Select(true, &storage.SelectHints{Start: mint, End: maxt, Func: "series"}, matchers...)
+			require.False(t, set.Next())
+
+			if disabled {
+				require.NoError(t, err)
+				require.Equal(t, []string{"metric"}, values)
+				require.NoError(t, namesErr)
+				require.Equal(t, []string{labels.MetricName}, names)
+				require.NoError(t, set.Err())
+				storeQuerier.AssertNumberOfCalls(t, "Select", 1)
+			} else {
+				require.ErrorIs(t, err, errDistributorError)
+				require.ErrorIs(t, namesErr, errDistributorError)
+				require.ErrorIs(t, set.Err(), errDistributorError)
+				storeQuerier.AssertNotCalled(t, "Select", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			// Queries not related to the disabled endpoints are always served by ingesters.
+			set = q.Select(true, &storage.SelectHints{Start: mint, End: maxt}, matchers...)
+			require.False(t, set.Next())
+			require.ErrorIs(t, set.Err(), errDistributorError)
+		})
+	}
+}
+
 func TestUseAlwaysQueryable(t *testing.T) {
 	m := &mockQueryableWithFilter{}
 	qwf := UseAlwaysQueryable(m)
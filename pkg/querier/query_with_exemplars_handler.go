@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// maxQueryWithExemplarsPoints is the max number of points per series a query with exemplars can return,
+// which is the same limit enforced by the Prometheus range query API.
+const maxQueryWithExemplarsPoints = 11000
+
+type queryWithExemplarsResult struct {
+	Status    string                  `json:"status"`
+	Data      *queryWithExemplarsData `json:"data,omitempty"`
+	ErrorType apierror.Type           `json:"errorType,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+}
+
+type queryWithExemplarsData struct {
+	ResultType parser.ValueType           `json:"resultType"`
+	Result     []queryWithExemplarsSeries `json:"result"`
+}
+
+type queryWithExemplarsSeries struct {
+	Metric    labels.Labels     `json:"metric"`
+	Points    []promql.Point    `json:"values"`
+	Exemplars []alignedExemplar `json:"exemplars"`
+}
+
+// alignedExemplar is an exemplar of a series of the query result, along with the index of the
+// sample of the series it's aligned to.
+type alignedExemplar struct {
+	Labels      labels.Labels `json:"labels"`
+	Value       string        `json:"value"`
+	Timestamp   model.Time    `json:"timestamp"`
+	SampleIndex int           `json:"sampleIndex"`
+}
+
+// NewQueryWithExemplarsHandler creates a http.Handler evaluating a range query, and returning each series
+// of the result along with the exemplars of the series selected by the query, aligned to the series samples.
+// This saves clients from running a range query and an exemplars query and correlating their results.
+func NewQueryWithExemplarsHandler(engine *promql.Engine, queryable storage.Queryable, exemplarQueryable storage.ExemplarQueryable) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := queryWithExemplars(r, engine, queryable, exemplarQueryable)
+		if err != nil {
+			respondQueryWithExemplarsError(w, err)
+			return
+		}
+
+		util.WriteJSONResponse(w, queryWithExemplarsResult{Status: statusSuccess, Data: data})
+	})
+}
+
+func queryWithExemplars(r *http.Request, engine *promql.Engine, queryable storage.Queryable, exemplarQueryable storage.ExemplarQueryable) (*queryWithExemplarsData, error) {
+	qs, start, end, step, err := parseQueryWithExemplarsParams(r)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := parser.ParseExpr(qs)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	ctx := r.Context()
+	q, err := engine.NewRangeQuery(queryable, nil, qs, util.TimeFromMillis(start), util.TimeFromMillis(end), time.Duration(step)*time.Millisecond)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	matrix, ok := res.Value.(promql.Matrix)
+	if !ok {
+		return nil, apierror.Newf(apierror.TypeInternal, "unexpected query result type %s", res.Value.Type())
+	}
+
+	// Exemplars in the step preceding the start are aligned to the first step.
+	exemplars, err := selectExemplars(ctx, exemplarQueryable, start-step+1, end, parser.ExtractSelectors(expr))
+	if err != nil {
+		return nil, err
+	}
+
+	return &queryWithExemplarsData{
+		ResultType: parser.ValueTypeMatrix,
+		Result:     alignExemplars(matrix, exemplars, start, step),
+	}, nil
+}
+
+func parseQueryWithExemplarsParams(r *http.Request) (qs string, start, end, step int64, err error) {
+	if err := r.ParseForm(); err != nil {
+		return "", 0, 0, 0, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	qs = r.FormValue("query")
+	if qs == "" {
+		return "", 0, 0, 0, apierror.New(apierror.TypeBadData, `missing parameter "query"`)
+	}
+	if start, err = util.ParseTime(r.FormValue("start")); err != nil {
+		return "", 0, 0, 0, apierror.Newf(apierror.TypeBadData, `invalid parameter "start": cannot parse %q to a valid timestamp`, r.FormValue("start"))
+	}
+	if end, err = util.ParseTime(r.FormValue("end")); err != nil {
+		return "", 0, 0, 0, apierror.Newf(apierror.TypeBadData, `invalid parameter "end": cannot parse %q to a valid timestamp`, r.FormValue("end"))
+	}
+	if end < start {
+		return "", 0, 0, 0, apierror.New(apierror.TypeBadData, `invalid parameter "end": end timestamp must not be before start time`)
+	}
+	if step, err = parseStepMs(r.FormValue("step")); err != nil {
+		return "", 0, 0, 0, err
+	}
+	if step <= 0 {
+		return "", 0, 0, 0, apierror.New(apierror.TypeBadData, `invalid parameter "step": zero or negative query resolution step widths are not accepted. Try a positive integer`)
+	}
+	// For safety, limit the number of returned points per series.
+	if (end-start)/step > maxQueryWithExemplarsPoints {
+		return "", 0, 0, 0, apierror.Newf(apierror.TypeBadData, "exceeded maximum resolution of %d points per timeseries. Try decreasing the query resolution (?step=XX)", maxQueryWithExemplarsPoints)
+	}
+
+	return qs, start, end, step, nil
+}
+
+func parseStepMs(s string) (int64, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		ts := d * float64(time.Second/time.Millisecond)
+		if ts > float64(math.MaxInt64) || ts < float64(math.MinInt64) {
+			return 0, apierror.Newf(apierror.TypeBadData, `invalid parameter "step": cannot parse %q to a valid duration. It overflows int64`, s)
+		}
+		return int64(ts), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return int64(time.Duration(d) / time.Millisecond), nil
+	}
+	return 0, apierror.Newf(apierror.TypeBadData, `invalid parameter "step": cannot parse %q to a valid duration`, s)
+}
+
+func selectExemplars(ctx context.Context, exemplarQueryable storage.ExemplarQueryable, start, end int64, selectors [][]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	if len(selectors) == 0 {
+		return nil, nil
+	}
+
+	q, err := exemplarQueryable.ExemplarQuerier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exemplars, err := q.Select(start, end, selectors...)
+	if err != nil {
+		return nil, promql.ErrStorage{Err: err}
+	}
+	return exemplars, nil
+}
+
+// alignExemplars returns the series of the matrix along with their exemplars. Each exemplars series is
+// assigned to the series of the matrix whose labels, except the metric name, are the most specific subset
+// of the exemplars series labels. Each exemplar is aligned to the sample of the first step at or after
+// the exemplar, and is dropped if the series has no such sample.
+func alignExemplars(matrix promql.Matrix, exemplars []exemplar.QueryResult, start, step int64) []queryWithExemplarsSeries {
+	result := make([]queryWithExemplarsSeries, 0, len(matrix))
+	for _, s := range matrix {
+		result = append(result, queryWithExemplarsSeries{Metric: s.Metric, Points: s.Points, Exemplars: []alignedExemplar{}})
+	}
+
+	for _, es := range exemplars {
+		idx := matchingSeries(matrix, es.SeriesLabels)
+		if idx < 0 {
+			continue
+		}
+
+		points := matrix[idx].Points
+		for _, e := range es.Exemplars {
+			ts := e.Ts
+			if ts > start {
+				ts = start + ((ts-start+step-1)/step)*step
+			} else {
+				ts = start
+			}
+
+			i := sort.Search(len(points), func(i int) bool { return points[i].T >= ts })
+			if i == len(points) || points[i].T != ts {
+				continue
+			}
+
+			result[idx].Exemplars = append(result[idx].Exemplars, alignedExemplar{
+				Labels:      e.Labels,
+				Value:       strconv.FormatFloat(e.Value, 'f', -1, 64),
+				Timestamp:   model.Time(e.Ts),
+				SampleIndex: i,
+			})
+		}
+	}
+
+	for _, s := range result {
+		sort.SliceStable(s.Exemplars, func(i, j int) bool { return s.Exemplars[i].Timestamp < s.Exemplars[j].Timestamp })
+	}
+	return result
+}
+
+// matchingSeries returns the index of the series of the matrix matching the exemplars series labels, or -1 if none.
+func matchingSeries(matrix promql.Matrix, seriesLabels labels.Labels) int {
+	match, matchLen := -1, -1
+	for i, s := range matrix {
+		n := 0
+		subset := true
+		for _, l := range s.Metric {
+			if l.Name == labels.MetricName {
+				continue
+			}
+			if seriesLabels.Get(l.Name) != l.Value {
+				subset = false
+				break
+			}
+			n++
+		}
+		if subset && n > matchLen {
+			match, matchLen = i, n
+		}
+	}
+	return match
+}
+
+// respondQueryWithExemplarsError writes the error response like the Prometheus API does.
+func respondQueryWithExemplarsError(w http.ResponseWriter, err error) {
+	if resp, ok := apierror.HTTPResponseFromError(err); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(int(resp.Code))
+		w.Write(resp.Body) //nolint
+		return
+	}
+
+	var (
+		canceledErr promql.ErrQueryCanceled
+		timeoutErr  promql.ErrQueryTimeout
+		storageErr  promql.ErrStorage
+	)
+	typ, code := apierror.TypeExec, http.StatusUnprocessableEntity
+	switch {
+	case errors.As(err, &canceledErr), errors.Is(err, context.Canceled):
+		typ, code = apierror.TypeCanceled, http.StatusServiceUnavailable
+	case errors.As(err, &timeoutErr):
+		typ, code = apierror.TypeTimeout, http.StatusServiceUnavailable
+	case errors.As(err, &storageErr):
+		typ, code = apierror.TypeInternal, http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	util.WriteJSONResponse(w, queryWithExemplarsResult{Status: statusError, ErrorType: typ, Error: err.Error()})
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestQueryWithExemplarsHandler(t *testing.T) {
+	series := mimirpb.TimeSeries{
+		Labels: []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "metric"}, {Name: "job", Value: "a"}, {Name: "instance", Value: "1"}},
+	}
+	for ts := int64(0); ts <= 60000; ts += 15000 {
+		series.Samples = append(series.Samples, mimirpb.Sample{TimestampMs: ts, Value: float64(ts / 1000)})
+	}
+	queryable := &testQueryable{ts: newTimeSeriesSeriesSet([]mimirpb.TimeSeries{series})}
+
+	exemplarQueryable := &mockExemplarQueryable{results: []exemplar.QueryResult{
+		{
+			SeriesLabels: labels.FromStrings(labels.MetricName, "metric", "job", "a", "instance", "1"),
+			Exemplars: []exemplar.Exemplar{
+				{Labels: labels.FromStrings("trace_id", "1"), Value: 1, Ts: 0},
+				{Labels: labels.FromStrings("trace_id", "2"), Value: 2, Ts: 20000},
+				// No sample at the step this exemplar is aligned to.
+				{Labels: labels.FromStrings("trace_id", "3"), Value: 3, Ts: 61000},
+			},
+		},
+		{
+			// Doesn't match any series of the result.
+			SeriesLabels: labels.FromStrings(labels.MetricName, "metric", "job", "b", "instance", "1"),
+			Exemplars: []exemplar.Exemplar{
+				{Labels: labels.FromStrings("trace_id", "4"), Value: 4, Ts: 30000},
+			},
+		},
+	}}
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		Logger:     log.NewNopLogger(),
+		Timeout:    10 * time.Second,
+		MaxSamples: 1e6,
+	})
+	handler := NewQueryWithExemplarsHandler(engine, queryable, exemplarQueryable)
+
+	tests := map[string]struct {
+		params           url.Values
+		expectedCode     int
+		expectedResponse string
+	}{
+		"should return the exemplars aligned to the samples of the series": {
+			params:       url.Values{"query": []string{`sum by (job) (metric)`}, "start": []string{"0"}, "end": []string{"60"}, "step": []string{"15s"}},
+			expectedCode: http.StatusOK,
+			expectedResponse: `{
+				"status": "success",
+				"data": {
+					"resultType": "matrix",
+					"result": [{
+						"metric": {"job": "a"},
+						"values": [[0, "0"], [15, "15"], [30, "30"], [45, "45"], [60, "60"]],
+						"exemplars": [
+							{"labels": {"trace_id": "1"}, "value": "1", "timestamp": 0, "sampleIndex": 0},
+							{"labels": {"trace_id": "2"}, "value": "2", "timestamp": 20, "sampleIndex": 2}
+						]
+					}]
+				}
+			}`,
+		},
+		"should fail on missing query": {
+			params:           url.Values{"start": []string{"0"}, "end": []string{"60"}, "step": []string{"15"}},
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: `{"status": "error", "errorType": "bad_data", "error": "missing parameter \"query\""}`,
+		},
+		"should fail on invalid step": {
+			params:           url.Values{"query": []string{"metric"}, "start": []string{"0"}, "end": []string{"60"}, "step": []string{"0"}},
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: `{"status": "error", "errorType": "bad_data", "error": "invalid parameter \"step\": zero or negative query resolution step widths are not accepted. Try a positive integer"}`,
+		},
+		"should fail on end before start": {
+			params:           url.Values{"query": []string{"metric"}, "start": []string{"60"}, "end": []string{"0"}, "step": []string{"15"}},
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: `{"status": "error", "errorType": "bad_data", "error": "invalid parameter \"end\": end timestamp must not be before start time"}`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "/api/v1/query_with_exemplars?"+testData.params.Encode(), nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testData.expectedCode, recorder.Result().StatusCode)
+			responseBody, err := io.ReadAll(recorder.Result().Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, testData.expectedResponse, string(responseBody))
+		})
+	}
+}
+
+type mockExemplarQueryable struct {
+	results []exemplar.QueryResult
+}
+
+func (m *mockExemplarQueryable) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *mockExemplarQueryable) Select(_, _ int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	return m.results, nil
+}
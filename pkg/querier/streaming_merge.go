@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"container/heap"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storage/chunk"
+)
+
+// streamingMergeSeriesSet is a storage.SeriesSet merging the sorted series sets returned by the
+// ingesters and the store-gateways while reading them, instead of collecting the series of all the
+// sets before merging them. Up to batchSize series are read at a time from each set, so the memory
+// required to merge the sets scales with the batch size rather than the number of series.
+type streamingMergeSeriesSet struct {
+	sets        []*seriesSetBatches
+	mint, maxt  int64
+	chunkIterFn chunkIteratorFunc
+
+	initialized bool
+	heap        seriesSetBatchesHeap
+
+	// popped are the sets whose current series is the one returned by At.
+	popped []*seriesSetBatches
+	curr   storage.Series
+	err    error
+}
+
+func newStreamingMergeSeriesSet(sets []storage.SeriesSet, batchSize int, mint, maxt int64, chunkIterFn chunkIteratorFunc) storage.SeriesSet {
+	batches := make([]*seriesSetBatches, 0, len(sets))
+	for _, set := range sets {
+		batches = append(batches, newSeriesSetBatches(set, batchSize))
+	}
+
+	return &streamingMergeSeriesSet{
+		sets:        batches,
+		mint:        mint,
+		maxt:        maxt,
+		chunkIterFn: chunkIterFn,
+		heap:        make(seriesSetBatchesHeap, 0, len(batches)),
+		popped:      make([]*seriesSetBatches, 0, len(batches)),
+	}
+}
+
+func (s *streamingMergeSeriesSet) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	// Move forward the sets whose series have been returned by the previous call,
+	// or all the sets if it's the first call.
+	toAdvance := s.popped
+	if !s.initialized {
+		s.initialized = true
+		toAdvance = s.sets
+	}
+
+	for _, set := range toAdvance {
+		if set.next() {
+			heap.Push(&s.heap, set)
+		} else if set.err != nil {
+			s.err = set.err
+			return false
+		}
+	}
+
+	s.curr = nil
+	s.popped = s.popped[:0]
+	if len(s.heap) == 0 {
+		return false
+	}
+
+	// Pick the series with the lowest labels from all the sets having it.
+	s.popped = append(s.popped, heap.Pop(&s.heap).(*seriesSetBatches))
+	lbls := s.popped[0].at().Labels()
+	for len(s.heap) > 0 && labels.Equal(s.heap[0].at().Labels(), lbls) {
+		s.popped = append(s.popped, heap.Pop(&s.heap).(*seriesSetBatches))
+	}
+
+	s.curr = s.mergeSeries(s.popped)
+	return true
+}
+
+// mergeSeries merges the current series of the input sets, which all have the same labels.
+// The chunks of the series backed by Mimir chunks are merged into a single series.
+func (s *streamingMergeSeriesSet) mergeSeries(sets []*seriesSetBatches) storage.Series {
+	if len(sets) == 1 {
+		return sets[0].at()
+	}
+
+	var (
+		series = make([]storage.Series, 0, len(sets))
+		chunks []chunk.Chunk
+	)
+
+	for _, set := range sets {
+		if sc, ok := set.at().(SeriesWithChunks); ok {
+			chunks = append(chunks, sc.Chunks()...)
+		} else {
+			series = append(series, set.at())
+		}
+	}
+
+	if len(chunks) > 0 {
+		series = append(series, &chunkSeries{
+			labels:            sets[0].at().Labels(),
+			chunks:            chunks,
+			chunkIteratorFunc: s.chunkIterFn,
+			mint:              s.mint,
+			maxt:              s.maxt,
+		})
+	}
+
+	if len(series) == 1 {
+		return series[0]
+	}
+	return storage.ChainedSeriesMerge(series...)
+}
+
+func (s *streamingMergeSeriesSet) At() storage.Series {
+	return s.curr
+}
+
+func (s *streamingMergeSeriesSet) Err() error {
+	return s.err
+}
+
+func (s *streamingMergeSeriesSet) Warnings() storage.Warnings {
+	var warnings storage.Warnings
+	for _, set := range s.sets {
+		warnings = append(warnings, set.set.Warnings()...)
+	}
+	return warnings
+}
+
+// seriesSetBatches reads a storage.SeriesSet in batches of up to batchSize series.
+type seriesSetBatches struct {
+	set   storage.SeriesSet
+	batch []storage.Series
+	ix    int
+	done  bool
+	err   error
+}
+
+func newSeriesSetBatches(set storage.SeriesSet, batchSize int) *seriesSetBatches {
+	return &seriesSetBatches{
+		set:   set,
+		batch: make([]storage.Series, 0, batchSize),
+		ix:    -1,
+	}
+}
+
+// next moves to the next series, reading the next batch from the set if the current one has been consumed.
+func (b *seriesSetBatches) next() bool {
+	b.ix++
+	if b.ix < len(b.batch) {
+		return true
+	}
+	if b.done {
+		return false
+	}
+
+	// Release the series of the previous batch before reading the next one.
+	for i := range b.batch {
+		b.batch[i] = nil
+	}
+	b.batch = b.batch[:0]
+	b.ix = 0
+
+	for len(b.batch) < cap(b.batch) && b.set.Next() {
+		b.batch = append(b.batch, b.set.At())
+	}
+	if len(b.batch) < cap(b.batch) {
+		b.done = true
+		b.err = b.set.Err()
+	}
+
+	return b.err == nil && len(b.batch) > 0
+}
+
+func (b *seriesSetBatches) at() storage.Series {
+	return b.batch[b.ix]
+}
+
+// seriesSetBatchesHeap is a heap of seriesSetBatches ordered by the labels of their current series.
+type seriesSetBatchesHeap []*seriesSetBatches
+
+func (h seriesSetBatchesHeap) Len() int      { return len(h) }
+func (h seriesSetBatchesHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h seriesSetBatchesHeap) Less(i, j int) bool {
+	return labels.Compare(h[i].at().Labels(), h[j].at().Labels()) < 0
+}
+
+func (h *seriesSetBatchesHeap) Push(x interface{}) {
+	*h = append(*h, x.(*seriesSetBatches))
+}
+
+func (h *seriesSetBatchesHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[0 : n-1]
+	return x
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/chunk"
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+func TestStreamingMergeSeriesSet(t *testing.T) {
+	// The ingesters return chunks for the series in [0, 20), while two store-gateways
+	// return samples for respectively the even and odd series in [10, 30).
+	warning := errors.New("warning")
+	newSets := func() []storage.SeriesSet {
+		return []storage.SeriesSet{
+			newStreamingMergeTestChunksSet(t, 0, 20, 100, 200),
+			newStreamingMergeTestSamplesSet(10, 30, 0, 0, 150),
+			newStreamingMergeTestSamplesSet(10, 30, 1, 50, 150),
+			series.NewSeriesSetWithWarnings(storage.EmptySeriesSet(), storage.Warnings{warning}),
+		}
+	}
+
+	q := querier{mint: 0, maxt: 200, chunkIterFn: mergeChunks}
+	expected, _ := readStreamingMergeTestSet(t, q.mergeSeriesSets(newSets()))
+	require.Len(t, expected, 30)
+
+	for _, batchSize := range []int{1, 2, 7, 100} {
+		t.Run(fmt.Sprintf("batch size: %d", batchSize), func(t *testing.T) {
+			q.streamingSeriesMergeBatchSize = batchSize
+
+			set := q.mergeSeriesSets(newSets())
+			require.IsType(t, &streamingMergeSeriesSet{}, set)
+
+			actual, actualWarnings := readStreamingMergeTestSet(t, set)
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, storage.Warnings{warning}, actualWarnings)
+		})
+	}
+}
+
+func TestStreamingMergeSeriesSet_ShouldReturnErrors(t *testing.T) {
+	expectedErr := errors.New("failed")
+
+	for _, batchSize := range []int{1, 5, 100} {
+		t.Run(fmt.Sprintf("batch size: %d", batchSize), func(t *testing.T) {
+			set := newStreamingMergeSeriesSet([]storage.SeriesSet{
+				newStreamingMergeTestSamplesSet(0, 10, 0, 0, 10),
+				&failingSeriesSet{SeriesSet: newStreamingMergeTestSamplesSet(0, 10, 1, 0, 10), err: expectedErr},
+			}, batchSize, 0, 10, mergeChunks)
+
+			for set.Next() {
+				require.NotNil(t, set.At())
+			}
+			assert.Equal(t, expectedErr, set.Err())
+		})
+	}
+}
+
+// newStreamingMergeTestChunksSet returns a set of series backed by chunks in the [from, to) range of series.
+func newStreamingMergeTestChunksSet(t *testing.T, from, to int, mint, maxt model.Time) storage.SeriesSet {
+	var chunks []chunk.Chunk
+	for i := from; i < to; i++ {
+		c := mkChunk(t, mint, maxt, 10*time.Millisecond, chunk.PrometheusXorChunk)
+		c.Metric = streamingMergeTestLabels(i)
+		chunks = append(chunks, c)
+	}
+
+	return partitionChunks(chunks, int64(mint), int64(maxt), mergeChunks)
+}
+
+// newStreamingMergeTestSamplesSet returns a set of series with samples in the [from, to) range of series
+// having the given remainder of the division by 2.
+func newStreamingMergeTestSamplesSet(from, to, remainder int, mint, maxt model.Time) storage.SeriesSet {
+	var serieses []storage.Series
+	for i := from; i < to; i++ {
+		if i%2 != remainder {
+			continue
+		}
+
+		var samples []model.SamplePair
+		for ts := mint; ts < maxt; ts += 10 {
+			samples = append(samples, model.SamplePair{Timestamp: ts, Value: model.SampleValue(ts)})
+		}
+		serieses = append(serieses, series.NewConcreteSeries(streamingMergeTestLabels(i), samples))
+	}
+
+	return series.NewConcreteSeriesSet(serieses)
+}
+
+func streamingMergeTestLabels(i int) labels.Labels {
+	return labels.FromStrings(model.MetricNameLabel, "series", "i", fmt.Sprintf("%03d", i))
+}
+
+type streamingMergeTestSeries struct {
+	labels  labels.Labels
+	samples []model.SamplePair
+}
+
+func readStreamingMergeTestSet(t *testing.T, set storage.SeriesSet) ([]streamingMergeTestSeries, storage.Warnings) {
+	var result []streamingMergeTestSeries
+	for set.Next() {
+		s := streamingMergeTestSeries{labels: set.At().Labels()}
+
+		it := set.At().Iterator()
+		for it.Next() {
+			ts, v := it.At()
+			s.samples = append(s.samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+		}
+		require.NoError(t, it.Err())
+
+		result = append(result, s)
+	}
+	require.NoError(t, set.Err())
+
+	return result, set.Warnings()
+}
+
+// failingSeriesSet is a storage.SeriesSet failing once the wrapped set has been consumed.
+type failingSeriesSet struct {
+	storage.SeriesSet
+	err error
+}
+
+func (s *failingSeriesSet) Err() error {
+	return s.err
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// TenantConcurrencyLimits are the per-tenant limits enforced by the TenantConcurrencyLimiter.
+type TenantConcurrencyLimits interface {
+	MaxConcurrentQueriesPerTenant(userID string) int
+}
+
+// TenantConcurrencyLimiter is a HTTP middleware limiting the number of queries of each tenant concurrently
+// executed by the querier, so that the queries of a single tenant, like the ones resulting from sharding
+// a query, can't occupy all the query workers of the querier and starve the queries of the other tenants.
+// The queries exceeding the limit wait, in the order they're received, for the running queries of their
+// tenant to complete.
+type TenantConcurrencyLimiter struct {
+	limits       TenantConcurrencyLimits
+	queueTimeout time.Duration
+	logger       log.Logger
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantQueries
+
+	queuedQueries prometheus.Gauge
+	queueTimeouts prometheus.Counter
+}
+
+// tenantQueries holds the running and queued queries of a tenant.
+type tenantQueries struct {
+	running int
+
+	// queued holds a channel for each waiting query, closed when the query can run.
+	queued []chan struct{}
+}
+
+// NewTenantConcurrencyLimiter makes a new TenantConcurrencyLimiter. The queries waiting for longer than
+// queueTimeout are failed with a retryable error. If queueTimeout is 0, they wait until they're canceled.
+func NewTenantConcurrencyLimiter(limits TenantConcurrencyLimits, queueTimeout time.Duration, logger log.Logger, reg prometheus.Registerer) *TenantConcurrencyLimiter {
+	return &TenantConcurrencyLimiter{
+		limits:       limits,
+		queueTimeout: queueTimeout,
+		logger:       logger,
+		tenants:      map[string]*tenantQueries{},
+		queuedQueries: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_querier_tenant_queued_queries",
+			Help: "Number of queries waiting for the running queries of their tenant to complete, because the tenant reached the max number of concurrent queries.",
+		}),
+		queueTimeouts: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_tenant_queue_timeouts_total",
+			Help: "Total number of queries failed because they waited for the running queries of their tenant to complete for longer than the queue timeout.",
+		}),
+	}
+}
+
+// Wrap implements middleware.Interface.
+func (l *TenantConcurrencyLimiter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantIDs, err := tenant.TenantIDs(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID := tenant.JoinTenantIDs(tenantIDs)
+		limit := func() int {
+			return validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, l.limits.MaxConcurrentQueriesPerTenant)
+		}
+
+		if err := l.acquire(r.Context(), userID, limit); err != nil {
+			level.Warn(util_log.WithContext(r.Context(), l.logger)).Log("msg", "query failed while waiting for the running queries of the tenant to complete", "err", err)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer l.release(userID, limit)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire waits until the query of the tenant can run. The limit function returns the current max number
+// of concurrent queries of the tenant, or 0 if unlimited.
+func (l *TenantConcurrencyLimiter) acquire(ctx context.Context, userID string, limit func() int) error {
+	l.mtx.Lock()
+	t := l.tenants[userID]
+	if t == nil {
+		t = &tenantQueries{}
+		l.tenants[userID] = t
+	}
+
+	if maxQueries := limit(); maxQueries <= 0 || t.running < maxQueries {
+		t.running++
+		l.mtx.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	t.queued = append(t.queued, ready)
+	l.mtx.Unlock()
+
+	l.queuedQueries.Inc()
+	defer l.queuedQueries.Dec()
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		l.queueTimeouts.Inc()
+		err = fmt.Errorf("the query waited for more than %s for the running queries of the tenant to complete, because the tenant reached the max number of concurrent queries per querier", l.queueTimeout)
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	for i, q := range t.queued {
+		if q == ready {
+			t.queued = append(t.queued[:i], t.queued[i+1:]...)
+			l.cleanupTenant(userID, t)
+			return err
+		}
+	}
+
+	// The query has been dequeued in the meanwhile, so it has to give back its slot.
+	l.releaseTenant(userID, t, limit)
+	return err
+}
+
+// release releases the slot of a query of the tenant, and lets the queued queries run if possible.
+func (l *TenantConcurrencyLimiter) release(userID string, limit func() int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if t := l.tenants[userID]; t != nil {
+		l.releaseTenant(userID, t, limit)
+	}
+}
+
+// releaseTenant must be called with the lock held.
+func (l *TenantConcurrencyLimiter) releaseTenant(userID string, t *tenantQueries, limit func() int) {
+	t.running--
+
+	maxQueries := limit()
+	for len(t.queued) > 0 && (maxQueries <= 0 || t.running < maxQueries) {
+		close(t.queued[0])
+		t.queued = t.queued[1:]
+		t.running++
+	}
+
+	l.cleanupTenant(userID, t)
+}
+
+// cleanupTenant removes the tenant without running or queued queries. It must be called with the lock held.
+func (l *TenantConcurrencyLimiter) cleanupTenant(userID string, t *tenantQueries) {
+	if t.running <= 0 && len(t.queued) == 0 {
+		delete(l.tenants, userID)
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+)
+
+type mockTenantConcurrencyLimits map[string]int
+
+func (m mockTenantConcurrencyLimits) MaxConcurrentQueriesPerTenant(userID string) int {
+	return m[userID]
+}
+
+func TestTenantConcurrencyLimiter(t *testing.T) {
+	limits := mockTenantConcurrencyLimits{"user-1": 1, "user-2": 2}
+	limiter := NewTenantConcurrencyLimiter(limits, 0, log.NewNopLogger(), nil)
+
+	running := map[string]*atomic.Int32{"user-1": atomic.NewInt32(0), "user-2": atomic.NewInt32(0), "user-3": atomic.NewInt32(0)}
+	unblock := make(chan struct{})
+
+	handler := limiter.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := user.ExtractOrgID(r.Context())
+		running[userID].Inc()
+		<-unblock
+		running[userID].Dec()
+	}))
+
+	done := make(chan int, 15)
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		for i := 0; i < 5; i++ {
+			go func(userID string) {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(user.InjectOrgID(context.Background(), userID)))
+				done <- rec.Code
+			}(userID)
+		}
+	}
+
+	// The queries of the tenants with a limit should be queued.
+	test.Poll(t, time.Second, true, func() interface{} {
+		return running["user-1"].Load() == 1 && running["user-2"].Load() == 2 && running["user-3"].Load() == 5
+	})
+	assert.Equal(t, 4+3, int(testutil.ToFloat64(limiter.queuedQueries)))
+
+	// The queued queries should run once the running ones complete, never exceeding the limit.
+	for i := 0; i < 15; i++ {
+		unblock <- struct{}{}
+		assert.LessOrEqual(t, running["user-1"].Load(), int32(1))
+		assert.LessOrEqual(t, running["user-2"].Load(), int32(2))
+	}
+	for i := 0; i < 15; i++ {
+		assert.Equal(t, http.StatusOK, <-done)
+	}
+
+	assert.Equal(t, 0, int(testutil.ToFloat64(limiter.queuedQueries)))
+	assert.Empty(t, limiter.tenants)
+}
+
+func TestTenantConcurrencyLimiter_QueuedQueryTimeoutAndCancellation(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	limiter := NewTenantConcurrencyLimiter(mockTenantConcurrencyLimits{"user-1": 1}, 100*time.Millisecond, log.NewNopLogger(), reg)
+
+	unblock := make(chan struct{})
+	handler := limiter.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	done := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+		done <- rec.Code
+	}()
+
+	test.Poll(t, time.Second, 1, func() interface{} {
+		limiter.mtx.Lock()
+		defer limiter.mtx.Unlock()
+		if limiter.tenants["user-1"] == nil {
+			return 0
+		}
+		return limiter.tenants["user-1"].running
+	})
+
+	// The queued query should fail with a retryable error once the queue timeout expires.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	// The queued query should fail once canceled.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(canceledCtx))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	close(unblock)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Empty(t, limiter.tenants)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_tenant_queue_timeouts_total Total number of queries failed because they waited for the running queries of their tenant to complete for longer than the queue timeout.
+		# TYPE cortex_querier_tenant_queue_timeouts_total counter
+		cortex_querier_tenant_queue_timeouts_total 1
+
+		# HELP cortex_querier_tenant_queued_queries Number of queries waiting for the running queries of their tenant to complete, because the tenant reached the max number of concurrent queries.
+		# TYPE cortex_querier_tenant_queued_queries gauge
+		cortex_querier_tenant_queued_queries 0
+	`)))
+}
+
+func TestTenantConcurrencyLimiter_ShouldNotLimitRequestsWithoutTenant(t *testing.T) {
+	limiter := NewTenantConcurrencyLimiter(mockTenantConcurrencyLimits{}, 0, log.NewNopLogger(), nil)
+	handler := limiter.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, limiter.tenants)
+}
@@ -38,10 +38,12 @@ import (
 // by the tenant ID and the previous value is exposed through a new label
 // prefixed with "original_". This behaviour is not implemented recursively.
 func NewQueryable(upstream storage.Queryable, byPassWithSingleQuerier bool, logger log.Logger) storage.Queryable {
-	return NewMergeQueryable(defaultTenantLabel, tenantQuerierCallback(upstream), byPassWithSingleQuerier, logger)
+	return NewMergeQueryable(defaultTenantLabel, TenantQuerierCallback(upstream), byPassWithSingleQuerier, logger)
 }
 
-func tenantQuerierCallback(queryable storage.Queryable) MergeQuerierCallback {
+// TenantQuerierCallback returns a MergeQuerierCallback returning a querier of the upstream queryable for
+// each tenant ID of the request, and the tenant IDs as ids.
+func TenantQuerierCallback(queryable storage.Queryable) MergeQuerierCallback {
 	return func(ctx context.Context, mint int64, maxt int64) ([]string, []storage.Querier, error) {
 		tenantIDs, err := tenant.TenantIDs(ctx)
 		if err != nil {
@@ -355,6 +355,39 @@ func TestMergeQueryable_Querier(t *testing.T) {
 	})
 }
 
+func TestMergeQueryable_CustomTenantLabelName(t *testing.T) {
+	const tenantLabelName = "tenant"
+
+	// Set a multi tenant resolver.
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+
+	queryable := &mockTenantQueryableWithFilter{logger: log.NewNopLogger(), extraLabels: []string{tenantLabelName, "original-value"}}
+	ctx := user.InjectOrgID(context.Background(), "team-a|team-b")
+
+	for _, bypassWithSingleQuerier := range []bool{false, true} {
+		q, err := NewMergeQueryable(tenantLabelName, TenantQuerierCallback(queryable), bypassWithSingleQuerier, log.NewNopLogger()).Querier(ctx, mint, maxt)
+		require.NoError(t, err)
+
+		set := q.Select(true, nil, labels.MustNewMatcher(labels.MatchEqual, tenantLabelName, "team-b"), labels.MustNewMatcher(labels.MatchEqual, "instance", "host1"))
+		var actual []labels.Labels
+		for set.Next() {
+			actual = append(actual, set.At().Labels())
+		}
+		require.NoError(t, set.Err())
+
+		assert.Equal(t, []labels.Labels{
+			labels.FromStrings("instance", "host1", "original_"+tenantLabelName, "original-value", tenantLabelName, "team-b", "tenant-team-b", "static"),
+		}, actual)
+	}
+
+	// The tenant label is injected for a single tenant too, when the merge querier is not bypassed.
+	q, err := NewMergeQueryable(tenantLabelName, TenantQuerierCallback(queryable), false, log.NewNopLogger()).Querier(user.InjectOrgID(context.Background(), "team-a"), mint, maxt)
+	require.NoError(t, err)
+	values, _, err := q.LabelValues(tenantLabelName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a"}, values)
+}
+
 var (
 	singleTenantScenario = mergeQueryableScenario{
 		name:    "single tenant",
@@ -532,7 +565,7 @@ func TestMergeQueryable_Select(t *testing.T) {
 }
 
 func TestMergeQueryable_LabelNames(t *testing.T) {
-	// set a multi tenant resolver
+	// Set a multi tenant resolver.
 	tenant.WithDefaultResolver(tenant.NewMultiResolver())
 
 	for _, scenario := range []labelNamesScenario{
@@ -666,7 +699,7 @@ func TestMergeQueryable_LabelNames(t *testing.T) {
 }
 
 func TestMergeQueryable_LabelValues(t *testing.T) {
-	// set a multi tenant resolver
+	// Set a multi tenant resolver.
 	tenant.WithDefaultResolver(tenant.NewMultiResolver())
 
 	for _, scenario := range []labelValuesScenario{
@@ -897,7 +930,7 @@ func TestTracingMergeQueryable(t *testing.T) {
 	opentracing.SetGlobalTracer(mockTracer)
 	ctx := user.InjectOrgID(context.Background(), "team-a|team-b")
 
-	// set a multi tenant resolver
+	// Set a multi tenant resolver.
 	tenant.WithDefaultResolver(tenant.NewMultiResolver())
 	filter := mockTenantQueryableWithFilter{}
 	q := NewQueryable(&filter, false, log.NewNopLogger())
@@ -8,6 +8,8 @@ package tenantfederation
 import (
 	"flag"
 
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 )
 
@@ -17,13 +19,30 @@ const (
 	maxConcurrency       = 16
 )
 
+var errInvalidTenantLabelName = errors.New("invalid tenant federation tenant label name")
+
 type Config struct {
 	// Enabled switches on support for multi tenant query federation
 	Enabled bool `yaml:"enabled"`
+
+	// TenantLabelName is the name of the label injected in the series returned by federated queries.
+	TenantLabelName string `yaml:"tenant_label_name" category:"experimental"`
+
+	// SingleTenantLabelEnabled injects the tenant label in the series returned by the queries of a single tenant too.
+	SingleTenantLabelEnabled bool `yaml:"single_tenant_label_enabled" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.Enabled, "tenant-federation.enabled", false, "If enabled on all services, queries can be federated across multiple tenants. The tenant IDs involved need to be specified separated by a '|' character in the 'X-Scope-OrgID' header.")
+	f.StringVar(&cfg.TenantLabelName, "tenant-federation.tenant-label-name", defaultTenantLabel, "Name of the label injected in the series returned by the queries federated across multiple tenants, whose value is the tenant ID the series belongs to. If a series already has the label, its value is exposed through the label prefixed with 'original_'.")
+	f.BoolVar(&cfg.SingleTenantLabelEnabled, "tenant-federation.single-tenant-label-enabled", false, "If enabled, the tenant label is injected in the series returned by the queries of a single tenant too, so that dashboards can group or filter the series by tenant regardless of the number of tenants queried.")
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Enabled && !model.LabelName(cfg.TenantLabelName).IsValid() {
+		return errors.Wrapf(errInvalidTenantLabelName, "%q", cfg.TenantLabelName)
+	}
+	return nil
 }
 
 // filterValuesByMatchers applies matchers to inputed `idLabelName` and
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenantfederation
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup       func(cfg *Config)
+		expectedErr error
+	}{
+		"default config": {
+			setup: func(*Config) {},
+		},
+		"custom tenant label name": {
+			setup: func(cfg *Config) {
+				cfg.Enabled = true
+				cfg.TenantLabelName = "tenant"
+			},
+		},
+		"invalid tenant label name": {
+			setup: func(cfg *Config) {
+				cfg.Enabled = true
+				cfg.TenantLabelName = "tenant-id"
+			},
+			expectedErr: errInvalidTenantLabelName,
+		},
+		"invalid tenant label name with tenant federation disabled": {
+			setup: func(cfg *Config) {
+				cfg.TenantLabelName = ""
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{}
+			cfg.RegisterFlags(flag.NewFlagSet("", flag.PanicOnError))
+			testData.setup(&cfg)
+
+			assert.ErrorIs(t, cfg.Validate(), testData.expectedErr)
+		})
+	}
+}
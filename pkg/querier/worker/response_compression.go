@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package worker
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// responseCompressionHandler is a RequestHandler compressing the responses sent to the query-frontend
+// with the first of the encodings accepted by the query-frontend which is also enabled in the querier.
+type responseCompressionHandler struct {
+	next      RequestHandler
+	encodings []string
+	logger    log.Logger
+}
+
+func newResponseCompressionHandler(next RequestHandler, encodings []string, logger log.Logger) RequestHandler {
+	return &responseCompressionHandler{
+		next:      next,
+		encodings: encodings,
+		logger:    logger,
+	}
+}
+
+func (h *responseCompressionHandler) Handle(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	resp, err := h.next.Handle(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	encoding := httpgrpcutil.NegotiateResponseEncoding(req, h.encodings)
+	if encoding == "" {
+		return resp, nil
+	}
+
+	// The response is sent uncompressed if the compression fails.
+	if err := httpgrpcutil.CompressResponse(resp, encoding); err != nil {
+		level.Warn(util_log.WithContext(ctx, h.logger)).Log("msg", "failed to compress the query response", "encoding", encoding, "err", err)
+	}
+	return resp, nil
+}
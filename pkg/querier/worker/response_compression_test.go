@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
+)
+
+type requestHandlerFunc func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+
+func (f requestHandlerFunc) Handle(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	return f(ctx, req)
+}
+
+func TestResponseCompressionHandler(t *testing.T) {
+	body := []byte(strings.Repeat("response", 1000))
+	next := requestHandlerFunc(func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+		return &httpgrpc.HTTPResponse{Code: 200, Body: append([]byte{}, body...)}, nil
+	})
+
+	tests := map[string]struct {
+		accepted         []string
+		supported        []string
+		expectedEncoding string
+	}{
+		"should not compress the response if the query-frontend accepts no encoding": {
+			supported: []string{httpgrpcutil.ResponseEncodingZstd},
+		},
+		"should not compress the response if the querier supports none of the accepted encodings": {
+			accepted:  []string{httpgrpcutil.ResponseEncodingSnappy},
+			supported: []string{httpgrpcutil.ResponseEncodingZstd},
+		},
+		"should compress the response with the preferred encoding of the query-frontend": {
+			accepted:         []string{httpgrpcutil.ResponseEncodingZstd, httpgrpcutil.ResponseEncodingSnappy},
+			supported:        []string{httpgrpcutil.ResponseEncodingSnappy, httpgrpcutil.ResponseEncodingZstd},
+			expectedEncoding: httpgrpcutil.ResponseEncodingZstd,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := &httpgrpc.HTTPRequest{Method: "GET", Url: "/api/v1/query_range"}
+			httpgrpcutil.SetAcceptedResponseEncodings(req, testData.accepted)
+
+			resp, err := newResponseCompressionHandler(next, testData.supported, log.NewNopLogger()).Handle(context.Background(), req)
+			require.NoError(t, err)
+
+			if testData.expectedEncoding == "" {
+				assert.Equal(t, body, resp.Body)
+				assert.Empty(t, resp.Headers)
+				return
+			}
+
+			assert.Equal(t, []*httpgrpc.Header{{Key: httpgrpcutil.ResponseEncodingHeader, Values: []string{testData.expectedEncoding}}}, resp.Headers)
+			require.NoError(t, httpgrpcutil.DecompressResponse(resp))
+			assert.Equal(t, body, resp.Body)
+		})
+	}
+}
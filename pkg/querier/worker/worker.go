@@ -10,11 +10,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/grpcclient"
 	"github.com/grafana/dskit/services"
 	"github.com/pkg/errors"
@@ -23,6 +25,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	"github.com/grafana/mimir/pkg/util/servicediscovery"
 )
 
@@ -33,6 +36,8 @@ type Config struct {
 	QuerierID        string            `yaml:"id" category:"advanced"`
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the queriers and the query-frontends / query-schedulers."`
 
+	ResponseCompression flagext.StringSliceCSV `yaml:"response_compression" category:"experimental"`
+
 	// This configuration is injected internally.
 	MaxConcurrentRequests   int                       `yaml:"-"` // Must be same as passed to PromQL Engine.
 	QuerySchedulerDiscovery schedulerdiscovery.Config `yaml:"-"`
@@ -44,6 +49,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.DNSLookupPeriod, "querier.dns-lookup-period", 10*time.Second, "How often to query DNS for query-frontend or query-scheduler address.")
 	f.StringVar(&cfg.QuerierID, "querier.id", "", "Querier ID, sent to the query-frontend to identify requests from the same querier. Defaults to hostname.")
 
+	f.Var(&cfg.ResponseCompression, "querier.response-compression", fmt.Sprintf("Comma-separated list of the encodings the querier can compress the query responses sent to the query-frontend with. The querier uses the first of the encodings accepted by the query-frontend, configured with -query-frontend.querier-response-compression, which is also in this list. Supported values: %s.", strings.Join(httpgrpcutil.ResponseEncodings, ", ")))
+
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("querier.frontend-client", f)
 }
 
@@ -55,6 +62,10 @@ func (cfg *Config) Validate(log log.Logger) error {
 		return fmt.Errorf("frontend address and scheduler address cannot be specified when query-scheduler service discovery mode is set to '%s'", cfg.QuerySchedulerDiscovery.Mode)
 	}
 
+	if err := httpgrpcutil.ValidateResponseEncodings(cfg.ResponseCompression); err != nil {
+		return errors.Wrap(err, "invalid -querier.response-compression")
+	}
+
 	return cfg.GRPCClientConfig.Validate(log)
 }
 
@@ -112,6 +123,10 @@ func NewQuerierWorker(cfg Config, handler RequestHandler, log log.Logger, reg pr
 		cfg.QuerierID = hostname
 	}
 
+	if len(cfg.ResponseCompression) > 0 {
+		handler = newResponseCompressionHandler(handler, cfg.ResponseCompression, log)
+	}
+
 	var processor processor
 	var servs []services.Service
 	var factory serviceDiscoveryFactory
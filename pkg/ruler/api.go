@@ -6,7 +6,9 @@
 package ruler
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -31,6 +33,7 @@ import (
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
 	"github.com/grafana/mimir/pkg/ruler/rulestore"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 // In order to reimplement the prometheus rules API, a large amount of code was copied over
@@ -42,6 +45,7 @@ type response struct {
 	Data      interface{}  `json:"data"`
 	ErrorType v1.ErrorType `json:"errorType"`
 	Error     string       `json:"error"`
+	Warnings  []string     `json:"warnings,omitempty"`
 }
 
 // AlertDiscovery has info for all active alerts.
@@ -313,9 +317,10 @@ func marshalAndSend(output interface{}, w http.ResponseWriter, logger log.Logger
 	}
 }
 
-func respondAccepted(w http.ResponseWriter, logger log.Logger) {
+func respondAccepted(w http.ResponseWriter, logger log.Logger, warnings ...string) {
 	b, err := json.Marshal(&response{
-		Status: "success",
+		Status:   "success",
+		Warnings: warnings,
 	})
 	if err != nil {
 		level.Error(logger).Log("msg", "error marshaling json response", "err", err)
@@ -485,12 +490,6 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err := a.ruler.AssertMaxRulesPerRuleGroup(userID, len(rg.Rules)); err != nil {
-		level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
 	rgs, err := a.store.ListRuleGroupsForUserAndNamespace(req.Context(), userID, "")
 	if err != nil {
 		level.Error(logger).Log("msg", "unable to fetch current rule groups for validation", "err", err.Error(), "user", userID)
@@ -498,11 +497,43 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err := a.ruler.AssertMaxRuleGroups(userID, len(rgs)+1); err != nil {
-		level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	limitsErr := a.ruler.AssertMaxRulesPerRuleGroup(userID, len(rg.Rules))
+	if limitsErr == nil {
+		limitsErr = a.ruler.AssertMaxRuleGroups(userID, len(rgs)+1)
+	}
+
+	var warning string
+	gracePeriod := a.ruler.limits.RulerLimitsGracePeriod(userID)
+	if limitsErr == nil && gracePeriod > 0 {
+		// The rule group is within the limits, but the limits exceeded since marker can be reset only once
+		// the whole rule set of the tenant is within the limits.
+		ruleSetErr, err := a.assertRuleSetLimits(req.Context(), userID, rgs, namespace, rg)
+		if err != nil {
+			level.Error(logger).Log("msg", "unable to load current rule groups for validation", "err", err.Error(), "user", userID)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if ruleSetErr != nil {
+			warning = fmt.Sprintf("the rule group is within the limits, but the rule set is not: %s", ruleSetErr)
+		}
+	}
+
+	if warning == "" {
+		warning, limitsErr, err = validation.ApplyLimitsGracePeriod(req.Context(), a.store, userID, gracePeriod, limitsErr, time.Now())
+		if err != nil {
+			level.Error(logger).Log("msg", "unable to apply the limits grace period", "err", err.Error(), "user", userID)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if limitsErr != nil {
+		level.Error(logger).Log("msg", "limit validation failure", "err", limitsErr.Error(), "user", userID)
+		http.Error(w, limitsErr.Error(), http.StatusBadRequest)
 		return
 	}
+	if warning != "" {
+		level.Warn(logger).Log("msg", "rule group exceeding the limits accepted within the limits grace period", "warning", warning, "user", userID)
+	}
 
 	rgProto := rulespb.ToProto(userID, namespace, rg)
 
@@ -514,9 +545,39 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if warning != "" {
+		respondAccepted(w, logger, warning)
+		return
+	}
 	respondAccepted(w, logger)
 }
 
+// assertRuleSetLimits checks whether the rule set of the tenant is within the limits once the input rule group
+// is stored, replacing the existing rule group with the same namespace and name if any.
+func (a *API) assertRuleSetLimits(ctx context.Context, userID string, rgs rulespb.RuleGroupList, namespace string, rg rulefmt.RuleGroup) (limitsErr, err error) {
+	others := make(rulespb.RuleGroupList, 0, len(rgs))
+	for _, g := range rgs {
+		if g.GetNamespace() == namespace && g.GetName() == rg.Name {
+			continue
+		}
+		others = append(others, g)
+	}
+
+	if err := a.ruler.AssertMaxRuleGroups(userID, len(others)+1); err != nil {
+		return err, nil
+	}
+
+	if err := a.store.LoadRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: others}); err != nil {
+		return nil, err
+	}
+	for _, g := range others {
+		if err := a.ruler.AssertMaxRulesPerRuleGroup(userID, len(g.GetRules())); err != nil {
+			return err, nil
+		}
+	}
+	return nil, nil
+}
+
 func (a *API) DeleteNamespace(w http.ResponseWriter, req *http.Request) {
 	logger := util_log.WithContext(req.Context(), a.logger)
 
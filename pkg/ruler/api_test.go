@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -20,6 +21,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/test"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
@@ -595,6 +597,108 @@ rules:
 	}
 }
 
+func TestRuler_LimitsGracePeriod(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList))
+
+	r := prepareRuler(t, cfg, store, withStart(), withLimits(validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+		defaults.RulerMaxRuleGroupsPerTenant = 1
+		defaults.RulerMaxRulesPerRuleGroup = 1
+		defaults.RulerLimitsGracePeriod = model.Duration(time.Hour)
+	})))
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+
+	push := func(group string, rules int) *httptest.ResponseRecorder {
+		input := fmt.Sprintf("name: %s\ninterval: 15s\nrules:\n", group)
+		for i := 0; i < rules; i++ {
+			input += fmt.Sprintf("- record: up_rule_%d\n  expr: up{}\n", i)
+		}
+
+		req := requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/rules/namespace", strings.NewReader(input), "user1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A rule group within the limits is accepted without warnings.
+	w := push("first", 1)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NotContains(t, w.Body.String(), "warnings")
+
+	// A rule group exceeding the limits is accepted with a warning, and the grace period starts.
+	w = push("second", 2)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Contains(t, w.Body.String(), "per-user rules per rule group limit (limit: 1 actual: 2) exceeded (accepted because the limits grace period expires at")
+
+	since, err := store.GetLimitsExceededSince(context.Background(), "user1")
+	require.NoError(t, err)
+	require.False(t, since.IsZero())
+
+	// Once the grace period is expired, the rule groups exceeding the limits are rejected.
+	require.NoError(t, store.SetLimitsExceededSince(context.Background(), "user1", since.Add(-time.Hour)))
+	w = push("third", 2)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "per-user rules per rule group limit (limit: 1 actual: 2) exceeded (the limits grace period expired at")
+}
+
+func TestRuler_LimitsGracePeriod_ShouldBeResetOnlyOnceTheRuleSetIsWithinTheLimits(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList))
+
+	r := prepareRuler(t, cfg, store, withStart(), withLimits(validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+		defaults.RulerMaxRuleGroupsPerTenant = 3
+		defaults.RulerMaxRulesPerRuleGroup = 1
+		defaults.RulerLimitsGracePeriod = model.Duration(time.Hour)
+	})))
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+
+	push := func(group string, rules int) *httptest.ResponseRecorder {
+		input := fmt.Sprintf("name: %s\ninterval: 15s\nrules:\n", group)
+		for i := 0; i < rules; i++ {
+			input += fmt.Sprintf("- record: up_rule_%d\n  expr: up{}\n", i)
+		}
+
+		req := requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/rules/namespace", strings.NewReader(input), "user1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	exceededSince := func() time.Time {
+		since, err := store.GetLimitsExceededSince(context.Background(), "user1")
+		require.NoError(t, err)
+		return since
+	}
+
+	w := push("first", 1)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NotContains(t, w.Body.String(), "warnings")
+
+	w = push("second", 2)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Contains(t, w.Body.String(), "per-user rules per rule group limit (limit: 1 actual: 2) exceeded (accepted because the limits grace period expires at")
+	since := exceededSince()
+	require.False(t, since.IsZero())
+
+	// A rule group within the limits is accepted, but the grace period isn't reset while another rule group
+	// of the tenant is still exceeding the limits.
+	w = push("first", 1)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Contains(t, w.Body.String(), "the rule group is within the limits, but the rule set is not: per-user rules per rule group limit (limit: 1 actual: 2) exceeded")
+	require.Equal(t, since, exceededSince())
+
+	// The grace period is reset once the rule group exceeding the limits is replaced by one within the limits.
+	w = push("second", 1)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NotContains(t, w.Body.String(), "warnings")
+	require.True(t, exceededSince().IsZero())
+}
+
 func requestFor(t *testing.T, method string, url string, body io.Reader, userID string) *http.Request {
 	t.Helper()
 
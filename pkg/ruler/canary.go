@@ -0,0 +1,433 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/notifier"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const shadowEvaluation contextKey = 2
+
+// ErrCanaryRuleGroupNotFound is returned when promoting or rolling back a rule group which is not evaluated in shadow.
+var ErrCanaryRuleGroupNotFound = errors.New("rule group is not evaluated in shadow")
+
+// withShadowEvaluation marks the context of the rules manager evaluating the rule groups in shadow.
+func withShadowEvaluation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shadowEvaluation, true)
+}
+
+// isShadowEvaluation returns whether the rule groups are evaluated in shadow, in which case the results are discarded.
+func isShadowEvaluation(ctx context.Context) bool {
+	shadow, _ := ctx.Value(shadowEvaluation).(bool)
+	return shadow
+}
+
+// discardAppender is a storage.Appender discarding the results of the rule groups evaluated in shadow.
+type discardAppender struct{}
+
+func (discardAppender) Append(_ storage.SeriesRef, _ labels.Labels, _ int64, _ float64) (storage.SeriesRef, error) {
+	return 0, nil
+}
+
+func (discardAppender) AppendExemplar(_ storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar) (storage.SeriesRef, error) {
+	return 0, nil
+}
+
+func (discardAppender) UpdateMetadata(_ storage.SeriesRef, _ labels.Labels, _ metadata.Metadata) (storage.SeriesRef, error) {
+	return 0, nil
+}
+
+func (discardAppender) Commit() error   { return nil }
+func (discardAppender) Rollback() error { return nil }
+
+// discardSender is a rules.Sender discarding the alerts of the rule groups evaluated in shadow.
+type discardSender struct{}
+
+func (discardSender) Send(...*notifier.Alert) {}
+
+// CanaryRuleGroup is the status of a modified rule group evaluated in shadow.
+type CanaryRuleGroup struct {
+	Namespace      string            `yaml:"namespace"`
+	Name           string            `yaml:"name"`
+	Since          time.Time         `yaml:"since"`
+	LastEvaluation time.Time         `yaml:"last_evaluation"`
+	EvaluationTime float64           `yaml:"evaluation_time_seconds"`
+	Errors         []CanaryRuleError `yaml:"errors,omitempty"`
+}
+
+// CanaryRuleError is the error of the last evaluation of a rule evaluated in shadow.
+type CanaryRuleError struct {
+	Rule  string `yaml:"rule"`
+	Error string `yaml:"error"`
+}
+
+type canaryKey struct {
+	namespace, name string
+}
+
+type canaryRuleGroup struct {
+	desc  *rulespb.RuleGroupDesc
+	since time.Time
+}
+
+// userCanaries holds the state of the canary evaluation of the modified rule groups of a tenant.
+type userCanaries struct {
+	// active holds the version of each rule group evaluated by the rules manager of the tenant.
+	active map[canaryKey]*rulespb.RuleGroupDesc
+
+	// canaries holds the modified rule groups evaluated in shadow.
+	canaries map[canaryKey]*canaryRuleGroup
+
+	// rolledBack holds the versions of the rule groups which have been rolled back. They're
+	// not evaluated until the rule group is modified again.
+	rolledBack map[canaryKey]*rulespb.RuleGroupDesc
+
+	// manager evaluates the canaries in shadow. Nil if there are no canaries.
+	manager RulesManager
+}
+
+func newUserCanaries() *userCanaries {
+	return &userCanaries{
+		active:     map[canaryKey]*rulespb.RuleGroupDesc{},
+		canaries:   map[canaryKey]*canaryRuleGroup{},
+		rolledBack: map[canaryKey]*rulespb.RuleGroupDesc{},
+	}
+}
+
+// update computes the rule groups to evaluate given the ones stored for the tenant. A modified rule group is evaluated
+// in shadow for the canary period, while the previous version keeps being evaluated, and then promoted if healthy.
+// It returns the rule groups to evaluate, the ones to evaluate in shadow and the promoted ones.
+func (c *userCanaries) update(stored rulespb.RuleGroupList, period time.Duration, now time.Time, healthy func(canaryKey) bool) (active, shadow rulespb.RuleGroupList, promoted []canaryKey) {
+	seen := make(map[canaryKey]struct{}, len(stored))
+
+	for _, g := range stored {
+		key := canaryKey{namespace: g.Namespace, name: g.Name}
+		seen[key] = struct{}{}
+
+		prev, exists := c.active[key]
+		switch {
+		case !exists || prev.Equal(g):
+			// New rule groups have no previous version to protect, so they're evaluated straight away.
+			c.active[key] = g
+			delete(c.canaries, key)
+			delete(c.rolledBack, key)
+		case c.rolledBack[key] != nil && c.rolledBack[key].Equal(g):
+			// Keep evaluating the previous version until the rule group is modified again.
+		default:
+			delete(c.rolledBack, key)
+
+			canary, exists := c.canaries[key]
+			if !exists || !canary.desc.Equal(g) {
+				c.canaries[key] = &canaryRuleGroup{desc: g, since: now}
+			} else if now.Sub(canary.since) >= period && healthy(key) {
+				c.active[key] = g
+				delete(c.canaries, key)
+				promoted = append(promoted, key)
+			}
+		}
+
+		active = append(active, c.active[key])
+		if canary, exists := c.canaries[key]; exists {
+			shadow = append(shadow, canary.desc)
+		}
+	}
+
+	// The deleted rule groups are not evaluated in shadow.
+	for key := range c.active {
+		if _, exists := seen[key]; !exists {
+			delete(c.active, key)
+			delete(c.canaries, key)
+			delete(c.rolledBack, key)
+		}
+	}
+
+	return active, shadow, promoted
+}
+
+// promote replaces the active version of the rule group with the one evaluated in shadow.
+func (c *userCanaries) promote(key canaryKey) error {
+	canary, exists := c.canaries[key]
+	if !exists {
+		return ErrCanaryRuleGroupNotFound
+	}
+
+	c.active[key] = canary.desc
+	delete(c.canaries, key)
+	return nil
+}
+
+// rollback discards the version of the rule group evaluated in shadow.
+func (c *userCanaries) rollback(key canaryKey) error {
+	canary, exists := c.canaries[key]
+	if !exists {
+		return ErrCanaryRuleGroupNotFound
+	}
+
+	c.rolledBack[key] = canary.desc
+	delete(c.canaries, key)
+	return nil
+}
+
+// shadowGroups returns the rule groups evaluated in shadow by the manager, by namespace and name.
+func (c *userCanaries) shadowGroups() map[canaryKey]*promRules.Group {
+	if c.manager == nil {
+		return nil
+	}
+
+	groups := map[canaryKey]*promRules.Group{}
+	for _, g := range c.manager.RuleGroups() {
+		// The rule files are named after the url path encoded namespace.
+		namespace, err := url.PathUnescape(filepath.Base(g.File()))
+		if err != nil {
+			continue
+		}
+		groups[canaryKey{namespace: namespace, name: g.Name()}] = g
+	}
+	return groups
+}
+
+// isHealthyShadowGroup returns whether the rule group evaluated in shadow has been evaluated and the last
+// evaluation of all its rules succeeded.
+func isHealthyShadowGroup(g *promRules.Group) bool {
+	if g.GetLastEvaluation().IsZero() {
+		return false
+	}
+	for _, r := range g.Rules() {
+		if r.Health() == promRules.HealthBad {
+			return false
+		}
+	}
+	return true
+}
+
+// syncCanaryRuleGroups updates the canary evaluation of the modified rule groups of the user, if enabled,
+// and returns the rule groups to be evaluated by the rules manager of the user.
+func (r *DefaultMultiTenantManager) syncCanaryRuleGroups(ctx context.Context, user string, groups rulespb.RuleGroupList) rulespb.RuleGroupList {
+	r.canariesMtx.Lock()
+	defer r.canariesMtx.Unlock()
+
+	period := r.limits.RulerCanaryPeriod(user)
+	c, exists := r.canaries[user]
+	if period <= 0 {
+		if exists {
+			r.removeCanariesLocked(user)
+		}
+		return groups
+	}
+	if !exists {
+		c = newUserCanaries()
+		r.canaries[user] = c
+	}
+
+	shadowGroups := c.shadowGroups()
+	active, shadow, promoted := c.update(groups, period, time.Now(), func(key canaryKey) bool {
+		g, exists := shadowGroups[key]
+		return exists && isHealthyShadowGroup(g)
+	})
+
+	for _, key := range promoted {
+		level.Info(r.logger).Log("msg", "promoted rule group evaluated in shadow", "user", user, "namespace", key.namespace, "group", key.name)
+		r.canaryDecisionsTotal.WithLabelValues(user, "promoted").Inc()
+	}
+	r.canaryRuleGroups.WithLabelValues(user).Set(float64(len(shadow)))
+
+	if err := r.syncShadowManager(ctx, user, c, shadow); err != nil {
+		level.Error(r.logger).Log("msg", "unable to update the rule groups evaluated in shadow", "user", user, "err", err)
+	}
+
+	return active
+}
+
+// syncShadowManager updates the rules manager evaluating the rule groups of the user in shadow, creating it if
+// it doesn't exist, or stopping it if there are no rule groups to evaluate. Must be called with canariesMtx held.
+func (r *DefaultMultiTenantManager) syncShadowManager(ctx context.Context, user string, c *userCanaries, shadow rulespb.RuleGroupList) error {
+	if len(shadow) == 0 {
+		if c.manager != nil {
+			go c.manager.Stop()
+			c.manager = nil
+			r.canaryMapper.cleanupUser(user)
+		}
+		return nil
+	}
+
+	update, files, err := r.canaryMapper.MapRules(user, shadow.Formatted())
+	if err != nil {
+		return err
+	}
+
+	created := false
+	if c.manager == nil {
+		// The results and alerts of the rule groups evaluated in shadow are discarded, and the metrics of
+		// the manager not exported, so that they don't clash with the ones of the active rule groups.
+		c.manager = r.managerFactory(withShadowEvaluation(ctx), user, discardSender{}, log.With(r.logger, "shadow", "true"), nil)
+		go c.manager.Run()
+		created = true
+	}
+
+	if !(created || update) {
+		return nil
+	}
+	return c.manager.Update(r.cfg.EvaluationInterval, files, nil, r.cfg.ExternalURL.String(), nil)
+}
+
+// removeCanaries stops the canary evaluation of the rule groups of the user.
+func (r *DefaultMultiTenantManager) removeCanaries(user string) {
+	r.canariesMtx.Lock()
+	defer r.canariesMtx.Unlock()
+
+	if _, exists := r.canaries[user]; exists {
+		r.removeCanariesLocked(user)
+	}
+}
+
+// removeCanariesLocked is like removeCanaries, but must be called with canariesMtx held.
+func (r *DefaultMultiTenantManager) removeCanariesLocked(user string) {
+	if c := r.canaries[user]; c.manager != nil {
+		go c.manager.Stop()
+		r.canaryMapper.cleanupUser(user)
+	}
+	delete(r.canaries, user)
+	r.canaryRuleGroups.DeleteLabelValues(user)
+	r.canaryDecisionsTotal.DeletePartialMatch(map[string]string{"user": user})
+}
+
+// GetCanaryRuleGroups returns the status of the rule groups of the user evaluated in shadow.
+func (r *DefaultMultiTenantManager) GetCanaryRuleGroups(user string) []CanaryRuleGroup {
+	r.canariesMtx.Lock()
+	defer r.canariesMtx.Unlock()
+
+	c, exists := r.canaries[user]
+	if !exists {
+		return nil
+	}
+
+	shadowGroups := c.shadowGroups()
+	result := make([]CanaryRuleGroup, 0, len(c.canaries))
+	for key, canary := range c.canaries {
+		status := CanaryRuleGroup{
+			Namespace: key.namespace,
+			Name:      key.name,
+			Since:     canary.since,
+		}
+
+		// The rule group may have not been loaded by the manager yet.
+		if g, exists := shadowGroups[key]; exists {
+			status.LastEvaluation = g.GetLastEvaluation()
+			status.EvaluationTime = g.GetEvaluationTime().Seconds()
+			for _, rule := range g.Rules() {
+				if err := rule.LastError(); err != nil {
+					status.Errors = append(status.Errors, CanaryRuleError{Rule: rule.Name(), Error: err.Error()})
+				}
+			}
+		}
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// PromoteCanaryRuleGroup replaces the active version of the rule group of the user with the one evaluated
+// in shadow. The change is applied at the next sync of the rule groups.
+func (r *DefaultMultiTenantManager) PromoteCanaryRuleGroup(user, namespace, group string) error {
+	r.canariesMtx.Lock()
+	defer r.canariesMtx.Unlock()
+
+	c, exists := r.canaries[user]
+	if !exists {
+		return ErrCanaryRuleGroupNotFound
+	}
+	if err := c.promote(canaryKey{namespace: namespace, name: group}); err != nil {
+		return err
+	}
+
+	level.Info(r.logger).Log("msg", "promoted rule group evaluated in shadow", "user", user, "namespace", namespace, "group", group)
+	r.canaryDecisionsTotal.WithLabelValues(user, "promoted").Inc()
+	return nil
+}
+
+// RollbackCanaryRuleGroup discards the version of the rule group of the user evaluated in shadow, which is not
+// evaluated until the rule group is modified again. The change is applied at the next sync of the rule groups.
+func (r *DefaultMultiTenantManager) RollbackCanaryRuleGroup(user, namespace, group string) error {
+	r.canariesMtx.Lock()
+	defer r.canariesMtx.Unlock()
+
+	c, exists := r.canaries[user]
+	if !exists {
+		return ErrCanaryRuleGroupNotFound
+	}
+	if err := c.rollback(canaryKey{namespace: namespace, name: group}); err != nil {
+		return err
+	}
+
+	level.Info(r.logger).Log("msg", "rolled back rule group evaluated in shadow", "user", user, "namespace", namespace, "group", group)
+	r.canaryDecisionsTotal.WithLabelValues(user, "rolled_back").Inc()
+	return nil
+}
+
+// ListCanaryRuleGroups returns the rule groups of the tenant evaluated in shadow by this ruler, in YAML.
+func (r *Ruler) ListCanaryRuleGroups(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), r.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	marshalAndSend(map[string][]CanaryRuleGroup{"groups": r.manager.GetCanaryRuleGroups(userID)}, w, logger)
+}
+
+// PromoteCanaryRuleGroup promotes the rule group of the tenant evaluated in shadow by this ruler.
+func (r *Ruler) PromoteCanaryRuleGroup(w http.ResponseWriter, req *http.Request) {
+	r.handleCanaryRuleGroup(w, req, r.manager.PromoteCanaryRuleGroup)
+}
+
+// RollbackCanaryRuleGroup rolls back the rule group of the tenant evaluated in shadow by this ruler.
+func (r *Ruler) RollbackCanaryRuleGroup(w http.ResponseWriter, req *http.Request) {
+	r.handleCanaryRuleGroup(w, req, r.manager.RollbackCanaryRuleGroup)
+}
+
+func (r *Ruler) handleCanaryRuleGroup(w http.ResponseWriter, req *http.Request, action func(user, namespace, group string) error) {
+	logger := util_log.WithContext(req.Context(), r.logger)
+	userID, namespace, group, err := parseRequest(req, true, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := action(userID, namespace, group); err != nil {
+		if errors.Is(err, ErrCanaryRuleGroupNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	// The change is applied at the next sync of the rule groups.
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestUserCanaries_Update(t *testing.T) {
+	const period = time.Minute
+
+	var (
+		now     = time.Now()
+		c       = newUserCanaries()
+		v1      = newCanaryTestRuleGroup("group", "v1")
+		v2      = newCanaryTestRuleGroup("group", "v2")
+		v3      = newCanaryTestRuleGroup("group", "v3")
+		other   = newCanaryTestRuleGroup("other", "v1")
+		healthy = false
+	)
+
+	update := func(now time.Time, stored ...*rulespb.RuleGroupDesc) (rulespb.RuleGroupList, rulespb.RuleGroupList, []canaryKey) {
+		return c.update(stored, period, now, func(canaryKey) bool { return healthy })
+	}
+
+	// New rule groups are evaluated straight away.
+	active, shadow, promoted := update(now, v1, other)
+	assert.Equal(t, rulespb.RuleGroupList{v1, other}, active)
+	assert.Empty(t, shadow)
+	assert.Empty(t, promoted)
+
+	// A modified rule group is evaluated in shadow, while the previous version is still evaluated.
+	active, shadow, promoted = update(now, v2, other)
+	assert.Equal(t, rulespb.RuleGroupList{v1, other}, active)
+	assert.Equal(t, rulespb.RuleGroupList{v2}, shadow)
+	assert.Empty(t, promoted)
+
+	// The rule group is not promoted once the period has elapsed if it's not healthy.
+	active, shadow, promoted = update(now.Add(period), v2, other)
+	assert.Equal(t, rulespb.RuleGroupList{v1, other}, active)
+	assert.Equal(t, rulespb.RuleGroupList{v2}, shadow)
+	assert.Empty(t, promoted)
+
+	// The rule group is not promoted before the period has elapsed, even if healthy.
+	healthy = true
+	active, shadow, promoted = update(now.Add(period/2), v2, other)
+	assert.Equal(t, rulespb.RuleGroupList{v1, other}, active)
+	assert.Equal(t, rulespb.RuleGroupList{v2}, shadow)
+	assert.Empty(t, promoted)
+
+	// The healthy rule group is promoted once the period has elapsed.
+	active, shadow, promoted = update(now.Add(period), v2, other)
+	assert.Equal(t, rulespb.RuleGroupList{v2, other}, active)
+	assert.Empty(t, shadow)
+	assert.Equal(t, []canaryKey{{namespace: "ns", name: "group"}}, promoted)
+
+	// Modifying a rule group evaluated in shadow restarts the period.
+	update(now.Add(period), v3, other)
+	update(now.Add(2*period), v1, other)
+	active, shadow, promoted = update(now.Add(5*period/2), v1, other)
+	assert.Equal(t, rulespb.RuleGroupList{v2, other}, active)
+	assert.Equal(t, rulespb.RuleGroupList{v1}, shadow)
+	assert.Empty(t, promoted)
+
+	// A rolled back rule group is not evaluated until it's modified again.
+	require.NoError(t, c.rollback(canaryKey{namespace: "ns", name: "group"}))
+	active, shadow, _ = update(now.Add(10*period), v1, other)
+	assert.Equal(t, rulespb.RuleGroupList{v2, other}, active)
+	assert.Empty(t, shadow)
+
+	active, shadow, _ = update(now.Add(10*period), v3, other)
+	assert.Equal(t, rulespb.RuleGroupList{v2, other}, active)
+	assert.Equal(t, rulespb.RuleGroupList{v3}, shadow)
+
+	// A promoted rule group is evaluated straight away.
+	require.NoError(t, c.promote(canaryKey{namespace: "ns", name: "group"}))
+	active, shadow, _ = update(now.Add(10*period), v3, other)
+	assert.Equal(t, rulespb.RuleGroupList{v3, other}, active)
+	assert.Empty(t, shadow)
+
+	assert.Equal(t, ErrCanaryRuleGroupNotFound, c.promote(canaryKey{namespace: "ns", name: "group"}))
+	assert.Equal(t, ErrCanaryRuleGroupNotFound, c.rollback(canaryKey{namespace: "ns", name: "group"}))
+
+	// The deleted rule groups are forgotten.
+	update(now.Add(10*period), newCanaryTestRuleGroup("other", "v2"))
+	active, shadow, _ = update(now.Add(10*period), v1)
+	assert.Equal(t, rulespb.RuleGroupList{v1}, active)
+	assert.Empty(t, shadow)
+	assert.Len(t, c.active, 1)
+	assert.Empty(t, c.canaries)
+	assert.Empty(t, c.rolledBack)
+}
+
+func TestDefaultMultiTenantManager_CanaryRuleGroups(t *testing.T) {
+	const userID = "user"
+
+	var (
+		dir      = t.TempDir()
+		mtx      sync.Mutex
+		managers []*canaryTestRulesManager
+	)
+
+	factory := func(ctx context.Context, _ string, _ promRules.Sender, _ log.Logger, _ prometheus.Registerer) RulesManager {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		m := &canaryTestRulesManager{mockRulesManager: mockRulesManager{done: make(chan struct{})}, shadow: isShadowEvaluation(ctx)}
+		managers = append(managers, m)
+		return m
+	}
+	limits := validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+		defaults.RulerCanaryPeriod = model.Duration(time.Hour)
+	})
+
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, limits, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+
+	ctx := context.Background()
+	m.SyncRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: {newCanaryTestRuleGroup("group", "v1")}})
+	require.Len(t, managers, 1)
+	assert.False(t, managers[0].shadow)
+	assert.Contains(t, managers[0].loadedRules(t), "v1")
+	assert.Empty(t, m.GetCanaryRuleGroups(userID))
+
+	// The modified rule group is evaluated in shadow.
+	m.SyncRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: {newCanaryTestRuleGroup("group", "v2")}})
+	require.Len(t, managers, 2)
+	assert.True(t, managers[1].shadow)
+	assert.Contains(t, managers[0].loadedRules(t), "v1")
+	assert.Contains(t, managers[1].loadedRules(t), "v2")
+	test.Poll(t, time.Second, true, func() interface{} {
+		return managers[1].running.Load()
+	})
+
+	canaries := m.GetCanaryRuleGroups(userID)
+	require.Len(t, canaries, 1)
+	assert.Equal(t, "ns", canaries[0].Namespace)
+	assert.Equal(t, "group", canaries[0].Name)
+
+	// Promoting the rule group replaces the active version at the next sync.
+	require.NoError(t, m.PromoteCanaryRuleGroup(userID, "ns", "group"))
+	assert.Equal(t, ErrCanaryRuleGroupNotFound, m.PromoteCanaryRuleGroup(userID, "ns", "group"))
+
+	m.SyncRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: {newCanaryTestRuleGroup("group", "v2")}})
+	assert.Contains(t, managers[0].loadedRules(t), "v2")
+	assert.Empty(t, m.GetCanaryRuleGroups(userID))
+
+	// The manager evaluating the rule groups in shadow is stopped once there are no more.
+	test.Poll(t, time.Second, false, func() interface{} {
+		return managers[1].running.Load()
+	})
+
+	// Rolling back the rule group keeps the active version.
+	m.SyncRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: {newCanaryTestRuleGroup("group", "v3")}})
+	require.Len(t, managers, 3)
+	require.NoError(t, m.RollbackCanaryRuleGroup(userID, "ns", "group"))
+
+	m.SyncRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: {newCanaryTestRuleGroup("group", "v3")}})
+	assert.Contains(t, managers[0].loadedRules(t), "v2")
+	assert.Empty(t, m.GetCanaryRuleGroups(userID))
+}
+
+func TestRuler_CanaryRuleGroupsAPI(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+	r := prepareRuler(t, cfg, newMockRuleStore(nil))
+
+	router := mux.NewRouter()
+	router.Path("/ruler/canary_rule_groups").Methods("GET").HandlerFunc(r.ListCanaryRuleGroups)
+	router.Path("/ruler/canary_rule_groups/{namespace}/{groupName}/promote").Methods("POST").HandlerFunc(r.PromoteCanaryRuleGroup)
+	router.Path("/ruler/canary_rule_groups/{namespace}/{groupName}/rollback").Methods("POST").HandlerFunc(r.RollbackCanaryRuleGroup)
+
+	for path, expectedStatus := range map[string]int{
+		"/ruler/canary_rule_groups":               http.StatusOK,
+		"/ruler/canary_rule_groups/ns/g/promote":  http.StatusNotFound,
+		"/ruler/canary_rule_groups/ns/g/rollback": http.StatusNotFound,
+	} {
+		method := http.MethodPost
+		if path == "/ruler/canary_rule_groups" {
+			method = http.MethodGet
+		}
+
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, expectedStatus, w.Code, path)
+	}
+}
+
+func TestPusherAppendable_ShadowEvaluation(t *testing.T) {
+	pusher := newPusherMock()
+	pusher.MockPush(&mimirpb.WriteResponse{}, nil)
+
+	totalWrites := prometheus.NewCounter(prometheus.CounterOpts{})
+	failedWrites := prometheus.NewCounter(prometheus.CounterOpts{})
+	pa := NewPusherAppendable(pusher, "user", nil, totalWrites, failedWrites)
+
+	app := pa.Appender(withShadowEvaluation(user.InjectOrgID(context.Background(), "user")))
+	_, err := app.Append(0, labels.FromStrings(labels.MetricName, "series"), 0, 1)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	pusher.AssertNotCalled(t, "Push")
+}
+
+func newCanaryTestRuleGroup(name, version string) *rulespb.RuleGroupDesc {
+	return &rulespb.RuleGroupDesc{
+		Name:      name,
+		Namespace: "ns",
+		User:      "user",
+		Interval:  time.Minute,
+		Rules:     []*rulespb.RuleDesc{{Record: "version", Expr: `up{version="` + version + `"}`}},
+	}
+}
+
+// canaryTestRulesManager is a mockRulesManager recording the rule files it's been updated with.
+type canaryTestRulesManager struct {
+	mockRulesManager
+
+	shadow bool
+
+	mtx   sync.Mutex
+	files []string
+}
+
+func (m *canaryTestRulesManager) Update(_ time.Duration, files []string, _ labels.Labels, _ string, _ promRules.RuleGroupPostProcessFunc) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.files = files
+	return nil
+}
+
+// loadedRules returns the content of the rule files the manager has been last updated with.
+func (m *canaryTestRulesManager) loadedRules(t *testing.T) string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	content := ""
+	for _, file := range m.files {
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		content += string(b)
+	}
+	return content
+}
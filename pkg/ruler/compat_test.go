@@ -10,10 +10,12 @@ import (
 	"errors"
 	"math"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -25,8 +27,10 @@ import (
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
+	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
@@ -346,6 +350,71 @@ func TestManagerFactory_CorrectQueryableUsed(t *testing.T) {
 	}
 }
 
+func TestDefaultManagerFactory_ShouldApplyTheEvaluationDelayToAllRuleExpressions(t *testing.T) {
+	const (
+		userID        = "tenant-1"
+		tenantDelay   = time.Minute
+		overrideDelay = 2 * time.Minute
+	)
+
+	cfg := defaultRulerConfig(t)
+	options := applyPrepareOptions()
+	notifierManager := notifier.NewManager(&notifier.Options{Do: func(_ context.Context, _ *http.Client, _ *http.Request) (*http.Response, error) { return nil, nil }}, options.logger)
+	limits := validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+		defaults.RulerEvaluationDelay = model.Duration(tenantDelay)
+	})
+
+	groupDelay := model.Duration(overrideDelay)
+	_, ruleFiles, err := newMapper(cfg.RulePath, options.logger).MapRules(userID, map[string][]rulefmt.RuleGroup{
+		"namespace": {
+			{Name: "default", Rules: []rulefmt.RuleNode{{Record: yaml.Node{Kind: yaml.ScalarNode, Value: "first"}, Expr: yaml.Node{Kind: yaml.ScalarNode, Value: "vector(1)"}}}},
+			{Name: "override", EvaluationDelay: &groupDelay, Rules: []rulefmt.RuleNode{{Record: yaml.Node{Kind: yaml.ScalarNode, Value: "second"}, Expr: yaml.Node{Kind: yaml.ScalarNode, Value: "vector(2)"}}}},
+		},
+	})
+	require.NoError(t, err)
+
+	// Track the time the rule expressions are queried at, along with the time they're evaluated at.
+	var (
+		mtx       sync.Mutex
+		queriedAt = map[string]time.Duration{}
+	)
+	queryFunc := func(_ context.Context, q string, ts time.Time) (promql.Vector, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if _, ok := queriedAt[q]; !ok {
+			queriedAt[q] = time.Since(ts)
+		}
+		return promql.Vector{}, nil
+	}
+
+	pusher := newPusherMock()
+	pusher.MockPush(&mimirpb.WriteResponse{}, nil)
+	manager := DefaultTenantManagerFactory(cfg, pusher, newMockQueryable(), queryFunc, limits, nil)(context.Background(), userID, notifierManager, options.logger, nil)
+
+	require.NoError(t, manager.Update(10*time.Millisecond, ruleFiles, nil, "", nil))
+	go manager.Run()
+	defer manager.Stop()
+
+	test.Poll(t, time.Second, 2, func() interface{} {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(queriedAt)
+	})
+
+	// The tenant evaluation delay applies to all the rule groups, unless overridden by the group.
+	delays := map[string]time.Duration{}
+	for _, g := range manager.RuleGroups() {
+		delays[g.Name()] = g.EvaluationDelay()
+	}
+	assert.Equal(t, map[string]time.Duration{"default": tenantDelay, "override": overrideDelay}, delays)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.GreaterOrEqual(t, queriedAt["vector(1)"], tenantDelay)
+	assert.Less(t, queriedAt["vector(1)"], overrideDelay)
+	assert.GreaterOrEqual(t, queriedAt["vector(2)"], overrideDelay)
+}
+
 func writeRuleGroupToFiles(t *testing.T, path string, logger log.Logger, userID string, ruleGroup rulespb.RuleGroupDesc) []string {
 	_, files, err := newMapper(path, logger).MapRules(userID, map[string][]rulefmt.RuleGroup{
 		"namespace": {rulespb.FromProto(&ruleGroup)},
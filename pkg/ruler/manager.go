@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"sync"
 
 	"github.com/go-kit/log"
@@ -32,6 +33,8 @@ type DefaultMultiTenantManager struct {
 	cfg            Config
 	notifierCfg    *config.Config
 	managerFactory ManagerFactory
+	limits         RulesLimits
+	dnsResolver    cache.AddressProvider
 
 	mapper *mapper
 
@@ -44,17 +47,24 @@ type DefaultMultiTenantManager struct {
 
 	// Per-user notifiers with separate queues.
 	notifiersMtx sync.Mutex
-	notifiers    map[string]*rulerNotifier
+	notifiers    map[string]*userNotifier
+
+	// Per-user canary evaluation of the modified rule groups.
+	canariesMtx  sync.Mutex
+	canaries     map[string]*userCanaries
+	canaryMapper *mapper
 
 	managersTotal                 prometheus.Gauge
 	lastReloadSuccessful          *prometheus.GaugeVec
 	lastReloadSuccessfulTimestamp *prometheus.GaugeVec
 	configUpdatesTotal            *prometheus.CounterVec
+	canaryRuleGroups              *prometheus.GaugeVec
+	canaryDecisionsTotal          *prometheus.CounterVec
 	registry                      prometheus.Registerer
 	logger                        log.Logger
 }
 
-func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg prometheus.Registerer, logger log.Logger, dnsResolver cache.AddressProvider) (*DefaultMultiTenantManager, error) {
+func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, limits RulesLimits, reg prometheus.Registerer, logger log.Logger, dnsResolver cache.AddressProvider) (*DefaultMultiTenantManager, error) {
 	ncfg, err := buildNotifierConfig(&cfg, dnsResolver)
 	if err != nil {
 		return nil, err
@@ -69,8 +79,12 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 		cfg:                cfg,
 		notifierCfg:        ncfg,
 		managerFactory:     managerFactory,
-		notifiers:          map[string]*rulerNotifier{},
+		limits:             limits,
+		dnsResolver:        dnsResolver,
+		notifiers:          map[string]*userNotifier{},
 		mapper:             newMapper(cfg.RulePath, logger),
+		canaries:           map[string]*userCanaries{},
+		canaryMapper:       newMapper(filepath.Clean(cfg.RulePath)+"-canary", logger),
 		userManagers:       map[string]RulesManager{},
 		userManagerMetrics: userManagerMetrics,
 		managersTotal: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
@@ -93,6 +107,14 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 			Name:      "ruler_config_updates_total",
 			Help:      "Total number of config updates triggered by a user",
 		}, []string{"user"}),
+		canaryRuleGroups: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ruler_canary_rule_groups",
+			Help: "Number of modified rule groups evaluated in shadow before replacing the active version.",
+		}, []string{"user"}),
+		canaryDecisionsTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_canary_rule_group_decisions_total",
+			Help: "Total number of rule groups evaluated in shadow which have been promoted or rolled back.",
+		}, []string{"user", "decision"}),
 		registry: reg,
 		logger:   logger,
 	}, nil
@@ -119,6 +141,7 @@ func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGrou
 			delete(r.userManagers, userID)
 
 			r.mapper.cleanupUser(userID)
+			r.removeCanaries(userID)
 			r.lastReloadSuccessful.DeleteLabelValues(userID)
 			r.lastReloadSuccessfulTimestamp.DeleteLabelValues(userID)
 			r.configUpdatesTotal.DeleteLabelValues(userID)
@@ -134,6 +157,9 @@ func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGrou
 // the user's Prometheus Rules Manager. Since this method writes to disk it is not safe to call
 // concurrently for the same user.
 func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user string, groups rulespb.RuleGroupList) {
+	// The modified rule groups may be evaluated in shadow before replacing the active version.
+	groups = r.syncCanaryRuleGroups(ctx, user, groups)
+
 	// Map the files to disk and return the file names to be passed to the users manager if they
 	// have been updated
 	update, files, err := r.mapper.MapRules(user, groups.Formatted())
@@ -150,6 +176,11 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 		return
 	}
 
+	// The tenant-specific Alertmanager URL may have changed, regardless of the rules.
+	if err := r.syncTenantNotifier(user); err != nil {
+		level.Error(r.logger).Log("msg", "unable to update the tenant-specific alertmanager configuration", "user", user, "err", err)
+	}
+
 	// We need to update the manager only if it was just created or rules on disk have changed.
 	if !(created || update) {
 		level.Debug(r.logger).Log("msg", "rules have not changed, skipping rule manager update", "user", user)
@@ -221,18 +252,77 @@ func (r *DefaultMultiTenantManager) newManager(ctx context.Context, userID strin
 	return r.managerFactory(ctx, userID, notifier, r.logger, reg), nil
 }
 
-func (r *DefaultMultiTenantManager) getOrCreateNotifier(userID string) (*notifier.Manager, error) {
+func (r *DefaultMultiTenantManager) getOrCreateNotifier(userID string) (*userNotifier, error) {
 	r.notifiersMtx.Lock()
 	defer r.notifiersMtx.Unlock()
 
 	n, ok := r.notifiers[userID]
 	if ok {
-		return n.notifier, nil
+		return n, nil
+	}
+
+	defaultNotifier := r.newRulerNotifier(userID, "cortex_")
+
+	// This should never fail, unless there's a programming mistake.
+	if err := defaultNotifier.applyConfig(r.notifierCfg); err != nil {
+		return nil, err
+	}
+
+	n = newUserNotifier(defaultNotifier)
+	r.notifiers[userID] = n
+
+	if err := r.syncTenantNotifierLocked(userID, n); err != nil {
+		level.Error(r.logger).Log("msg", "unable to apply the tenant-specific alertmanager configuration", "user", userID, "err", err)
+	}
+
+	return n, nil
+}
+
+// syncTenantNotifier configures the notifier of the tenant to send alerts to the tenant-specific Alertmanagers,
+// if any, instead of or in addition to the ones configured with -ruler.alertmanager-url.
+func (r *DefaultMultiTenantManager) syncTenantNotifier(userID string) error {
+	r.notifiersMtx.Lock()
+	defer r.notifiersMtx.Unlock()
+
+	n, ok := r.notifiers[userID]
+	if !ok {
+		return nil
+	}
+	return r.syncTenantNotifierLocked(userID, n)
+}
+
+// syncTenantNotifierLocked is like syncTenantNotifier, but must be called with notifiersMtx held.
+func (r *DefaultMultiTenantManager) syncTenantNotifierLocked(userID string, n *userNotifier) error {
+	tenantURL := r.limits.RulerTenantAlertmanagerURL(userID)
+	sendToDefault := tenantURL == "" || r.limits.RulerSendToDefaultAlertmanager(userID)
+	if !n.tenantConfigChanged(tenantURL, sendToDefault) {
+		return nil
+	}
+
+	tenantNotifier := n.getTenantNotifier()
+	if tenantNotifier == nil && tenantURL != "" {
+		tenantNotifier = r.newRulerNotifier(userID, "cortex_ruler_tenant_")
 	}
 
+	if tenantNotifier != nil {
+		cfg, err := buildNotifierConfigForURL(tenantURL, &r.cfg, r.dnsResolver)
+		if err != nil {
+			return err
+		}
+		if err := tenantNotifier.applyConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	n.setTenantConfig(tenantNotifier, tenantURL, sendToDefault)
+	return nil
+}
+
+// newRulerNotifier creates and runs a new notifier for the user, registering its metrics with the input prefix.
+func (r *DefaultMultiTenantManager) newRulerNotifier(userID, metricsPrefix string) *rulerNotifier {
 	reg := prometheus.WrapRegistererWith(prometheus.Labels{"user": userID}, r.registry)
-	reg = prometheus.WrapRegistererWithPrefix("cortex_", reg)
-	n = newRulerNotifier(&notifier.Options{
+	reg = prometheus.WrapRegistererWithPrefix(metricsPrefix, reg)
+	n := newRulerNotifier(&notifier.Options{
 		QueueCapacity: r.cfg.NotificationQueueCapacity,
 		Registerer:    reg,
 		Do: func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
@@ -253,14 +343,7 @@ func (r *DefaultMultiTenantManager) getOrCreateNotifier(userID string) (*notifie
 	}, log.With(r.logger, "user", userID))
 
 	n.run()
-
-	// This should never fail, unless there's a programming mistake.
-	if err := n.applyConfig(r.notifierCfg); err != nil {
-		return nil, err
-	}
-
-	r.notifiers[userID] = n
-	return n.notifier, nil
+	return n
 }
 
 func (r *DefaultMultiTenantManager) GetRules(userID string) []*promRules.Group {
@@ -297,8 +380,15 @@ func (r *DefaultMultiTenantManager) Stop() {
 	r.userManagerMtx.Unlock()
 	level.Info(r.logger).Log("msg", "all user managers stopped")
 
+	r.canariesMtx.Lock()
+	for user := range r.canaries {
+		r.removeCanariesLocked(user)
+	}
+	r.canariesMtx.Unlock()
+
 	// cleanup user rules directories
 	r.mapper.cleanup()
+	r.canaryMapper.cleanup()
 }
 
 func (*DefaultMultiTenantManager) ValidateRuleGroup(g rulefmt.RuleGroup) []error {
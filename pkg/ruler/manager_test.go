@@ -14,19 +14,19 @@ import (
 	"github.com/grafana/dskit/test"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
-	"github.com/prometheus/prometheus/notifier"
 	"github.com/prometheus/prometheus/rules"
 	promRules "github.com/prometheus/prometheus/rules"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 func TestSyncRuleGroups(t *testing.T) {
 	dir := t.TempDir()
 
-	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger(), nil)
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, validation.MockDefaultOverrides(), nil, log.NewNopLogger(), nil)
 	require.NoError(t, err)
 
 	const user = "testUser"
@@ -105,7 +105,7 @@ func getManager(m *DefaultMultiTenantManager, user string) RulesManager {
 	return m.userManagers[user]
 }
 
-func factory(_ context.Context, _ string, _ *notifier.Manager, _ log.Logger, _ prometheus.Registerer) RulesManager {
+func factory(_ context.Context, _ string, _ promRules.Sender, _ log.Logger, _ prometheus.Registerer) RulesManager {
 	return &mockRulesManager{done: make(chan struct{})}
 }
 
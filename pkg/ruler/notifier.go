@@ -91,15 +91,115 @@ func (rn *rulerNotifier) stop() {
 	rn.wg.Wait()
 }
 
+// userNotifier sends the alerts of a tenant to the Alertmanagers configured with -ruler.alertmanager-url
+// and/or to the tenant-specific Alertmanagers. Each destination has its own notifier, and so its own
+// queue and metrics.
+type userNotifier struct {
+	defaultNotifier *rulerNotifier
+
+	mtx sync.RWMutex
+	// tenantNotifier sends the alerts to the tenant-specific Alertmanagers. It's lazily created the first
+	// time a tenant-specific Alertmanager URL is configured and never removed, given its metrics can't be
+	// registered again, but it's reconfigured without Alertmanagers if the URL gets unset.
+	tenantNotifier *rulerNotifier
+	tenantURL      string
+	sendToDefault  bool
+}
+
+func newUserNotifier(defaultNotifier *rulerNotifier) *userNotifier {
+	return &userNotifier{
+		defaultNotifier: defaultNotifier,
+		sendToDefault:   true,
+	}
+}
+
+// Send implements rules.Sender.
+func (n *userNotifier) Send(alerts ...*notifier.Alert) {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	if n.sendToDefault {
+		n.defaultNotifier.notifier.Send(alerts...)
+	}
+
+	if n.tenantNotifier != nil && n.tenantURL != "" {
+		if n.sendToDefault {
+			// The notifier rewrites the labels of the alerts it gets in place and keeps them in
+			// its queue, so each destination needs its own copy.
+			alerts = copyAlerts(alerts)
+		}
+		n.tenantNotifier.notifier.Send(alerts...)
+	}
+}
+
+// Alertmanagers returns the discovered Alertmanagers the alerts are sent to.
+func (n *userNotifier) Alertmanagers() []*url.URL {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	var ams []*url.URL
+	if n.sendToDefault {
+		ams = append(ams, n.defaultNotifier.notifier.Alertmanagers()...)
+	}
+	if n.tenantNotifier != nil && n.tenantURL != "" {
+		ams = append(ams, n.tenantNotifier.notifier.Alertmanagers()...)
+	}
+	return ams
+}
+
+// tenantConfigChanged returns whether the input tenant-specific configuration differs from the applied one.
+func (n *userNotifier) tenantConfigChanged(tenantURL string, sendToDefault bool) bool {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+	return n.tenantURL != tenantURL || n.sendToDefault != sendToDefault
+}
+
+func (n *userNotifier) getTenantNotifier() *rulerNotifier {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+	return n.tenantNotifier
+}
+
+func (n *userNotifier) setTenantConfig(tenantNotifier *rulerNotifier, tenantURL string, sendToDefault bool) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.tenantNotifier = tenantNotifier
+	n.tenantURL = tenantURL
+	n.sendToDefault = sendToDefault
+}
+
+func (n *userNotifier) stop() {
+	n.defaultNotifier.stop()
+	if tenantNotifier := n.getTenantNotifier(); tenantNotifier != nil {
+		tenantNotifier.stop()
+	}
+}
+
+func copyAlerts(alerts []*notifier.Alert) []*notifier.Alert {
+	out := make([]*notifier.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		c := *a
+		c.Labels = a.Labels.Copy()
+		out = append(out, &c)
+	}
+	return out
+}
+
 // Builds a Prometheus config.Config from a ruler.Config with just the required
 // options to configure notifications to Alertmanager.
 func buildNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider) (*config.Config, error) {
-	if rulerConfig.AlertmanagerURL == "" {
+	return buildNotifierConfigForURL(rulerConfig.AlertmanagerURL, rulerConfig, resolver)
+}
+
+// buildNotifierConfigForURL is like buildNotifierConfig, but configures notifications to the input
+// comma-separated list of Alertmanager URLs instead of the ones in the ruler.Config.
+func buildNotifierConfigForURL(alertmanagerURL string, rulerConfig *Config, resolver cache.AddressProvider) (*config.Config, error) {
+	if alertmanagerURL == "" {
 		// no AM URLs were provided, so we can just return a default config without errors
 		return &config.Config{}, nil
 	}
 
-	amURLs := strings.Split(rulerConfig.AlertmanagerURL, ",")
+	amURLs := strings.Split(alertmanagerURL, ",")
 	amConfigs := make([]*config.AlertmanagerConfig, 0, len(amURLs))
 
 	for _, rawURL := range amURLs {
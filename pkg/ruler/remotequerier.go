@@ -33,6 +33,7 @@ import (
 	"github.com/weaveworks/common/user"
 	"google.golang.org/grpc"
 
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/version"
 )
@@ -45,6 +46,8 @@ const (
 
 	mimeTypeFormPost = "application/x-www-form-urlencoded"
 
+	queryPriorityHigh = "high"
+
 	statusError = "error"
 
 	maxRequestRetries = 3
@@ -211,6 +214,8 @@ func (q *RemoteQuerier) query(ctx context.Context, query string, ts time.Time, l
 			{Key: textproto.CanonicalMIMEHeaderKey("User-Agent"), Values: []string{userAgent}},
 			{Key: textproto.CanonicalMIMEHeaderKey("Content-Type"), Values: []string{mimeTypeFormPost}},
 			{Key: textproto.CanonicalMIMEHeaderKey("Content-Length"), Values: []string{strconv.Itoa(len(body))}},
+			// The rule evaluations are dequeued by the query-scheduler before the other queries of the tenant.
+			{Key: util.QueryPriorityHeader, Values: []string{queryPriorityHigh}},
 		},
 	}
 
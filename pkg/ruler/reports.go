@@ -0,0 +1,383 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+const (
+	// ReportsPrefix is the bucket prefix under which the reports of all tenants are stored.
+	// Note that objects stored under this prefix follow the pattern:
+	//     reports/<user-id>/<report-name>/report.yaml
+	//     reports/<user-id>/<report-name>/snapshots/<timestamp-ms>.json
+	ReportsPrefix = "reports"
+
+	reportObjectName  = "report.yaml"
+	snapshotsPrefix   = "snapshots"
+	snapshotObjectExt = ".json"
+	minReportInterval = time.Minute
+
+	// reportsRingNamespace is the namespace used to shard the reports among the rulers like the rule groups.
+	reportsRingNamespace = "__reports__"
+)
+
+var (
+	ErrReportNotFound   = errors.New("report not found")
+	ErrSnapshotNotFound = errors.New("report snapshot not found")
+
+	reportNameRegexp = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+)
+
+// ReportsConfig configures the reports, which are queries executed by the rulers on a schedule, whose result
+// snapshots are stored in the ruler storage.
+type ReportsConfig struct {
+	Enabled      bool          `yaml:"enabled" category:"experimental"`
+	PollInterval time.Duration `yaml:"poll_interval" category:"experimental"`
+}
+
+func (cfg *ReportsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ruler.reports.enabled", false, "Enable the reports API at /api/v1/reports, to define queries executed by the rulers on a schedule, whose result snapshots are stored in the ruler storage. Requires an object storage backend for the ruler storage.")
+	f.DurationVar(&cfg.PollInterval, "ruler.reports.poll-interval", time.Minute, "How frequently the rulers check for the reports to execute and for the report snapshots exceeding the retention.")
+}
+
+// Report is a query executed every interval, whose result snapshots are kept for the retention period.
+type Report struct {
+	Name      string         `yaml:"name" json:"name"`
+	Query     string         `yaml:"query" json:"query"`
+	Interval  model.Duration `yaml:"interval" json:"interval"`
+	Retention model.Duration `yaml:"retention" json:"retention"`
+}
+
+// Validate returns an error if the report is invalid.
+func (r *Report) Validate() error {
+	if !reportNameRegexp.MatchString(r.Name) {
+		return fmt.Errorf("invalid report name %q: it must only contain letters, digits, underscores and dashes", r.Name)
+	}
+	if _, err := parser.ParseExpr(r.Query); err != nil {
+		return errors.Wrapf(err, "invalid query of report %q", r.Name)
+	}
+	if time.Duration(r.Interval) < minReportInterval {
+		return fmt.Errorf("the interval of report %q must be at least %s", r.Name, minReportInterval)
+	}
+	if r.Retention < r.Interval {
+		return fmt.Errorf("the retention of report %q must be greater than or equal to its interval", r.Name)
+	}
+	return nil
+}
+
+// ReportSnapshot is the result of a report execution.
+type ReportSnapshot struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Query     string        `json:"query"`
+	Result    promql.Vector `json:"result"`
+}
+
+// ReportStore stores the reports and their snapshots in the object storage.
+type ReportStore struct {
+	bucket      objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	logger      log.Logger
+}
+
+func NewReportStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *ReportStore {
+	return &ReportStore{
+		bucket:      bucket.NewPrefixedBucketClient(bkt, ReportsPrefix),
+		cfgProvider: cfgProvider,
+		logger:      logger,
+	}
+}
+
+// ListAllUsers returns the tenants having reports.
+func (s *ReportStore) ListAllUsers(ctx context.Context) ([]string, error) {
+	var users []string
+	err := s.bucket.Iter(ctx, "", func(name string) error {
+		users = append(users, strings.TrimSuffix(name, objstore.DirDelim))
+		return nil
+	})
+	return users, errors.Wrap(err, "unable to list users in reports store")
+}
+
+// ListReports returns the reports of the tenant, sorted by name.
+func (s *ReportStore) ListReports(ctx context.Context, userID string) ([]*Report, error) {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+
+	var names []string
+	if err := userBucket.Iter(ctx, "", func(name string) error {
+		names = append(names, strings.TrimSuffix(name, objstore.DirDelim))
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "unable to list reports of user %s", userID)
+	}
+	sort.Strings(names)
+
+	reports := make([]*Report, 0, len(names))
+	for _, name := range names {
+		r, err := s.GetReport(ctx, userID, name)
+		if errors.Is(err, ErrReportNotFound) {
+			// The report may have been deleted in the meanwhile, or only its snapshots are left.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// GetReport returns the report of the tenant with the input name, or ErrReportNotFound.
+func (s *ReportStore) GetReport(ctx context.Context, userID, name string) (*Report, error) {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+
+	reader, err := userBucket.Get(ctx, path.Join(name, reportObjectName))
+	if userBucket.IsObjNotFoundErr(err) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get report %s", name)
+	}
+	defer func() { _ = reader.Close() }()
+
+	r := &Report{}
+	if err := yaml.NewDecoder(reader).Decode(r); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode report %s", name)
+	}
+	return r, nil
+}
+
+// SetReport creates or replaces the report of the tenant.
+func (s *ReportStore) SetReport(ctx context.Context, userID string, r *Report) error {
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+	return userBucket.Upload(ctx, path.Join(r.Name, reportObjectName), bytes.NewReader(data))
+}
+
+// DeleteReport deletes the report of the tenant along with its snapshots.
+func (s *ReportStore) DeleteReport(ctx context.Context, userID, name string) error {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+
+	// Delete the report first, so that no new snapshot is taken while the snapshots are deleted.
+	err := userBucket.Delete(ctx, path.Join(name, reportObjectName))
+	if userBucket.IsObjNotFoundErr(err) {
+		return ErrReportNotFound
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete report %s", name)
+	}
+
+	_, err = bucket.DeletePrefix(ctx, userBucket, snapshotsDir(name), s.logger)
+	return errors.Wrapf(err, "failed to delete the snapshots of report %s", name)
+}
+
+// ListSnapshots returns the timestamps of the snapshots of the report, sorted from the oldest.
+func (s *ReportStore) ListSnapshots(ctx context.Context, userID, name string) ([]time.Time, error) {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+
+	var timestamps []time.Time
+	err := userBucket.Iter(ctx, snapshotsDir(name), func(key string) error {
+		ms, err := strconv.ParseInt(strings.TrimSuffix(path.Base(key), snapshotObjectExt), 10, 64)
+		if err != nil || !strings.HasSuffix(key, snapshotObjectExt) {
+			level.Warn(s.logger).Log("msg", "invalid report snapshot object key found while listing snapshots", "user", userID, "report", name, "key", key)
+			return nil
+		}
+		timestamps = append(timestamps, time.UnixMilli(ms).UTC())
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list the snapshots of report %s", name)
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+	return timestamps, nil
+}
+
+// GetSnapshot returns a reader of the JSON encoded snapshot of the report taken at ts, or ErrSnapshotNotFound.
+func (s *ReportStore) GetSnapshot(ctx context.Context, userID, name string, ts time.Time) (io.ReadCloser, error) {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+
+	reader, err := userBucket.Get(ctx, snapshotObjectKey(name, ts))
+	if userBucket.IsObjNotFoundErr(err) {
+		return nil, ErrSnapshotNotFound
+	}
+	return reader, errors.Wrapf(err, "failed to get snapshot of report %s", name)
+}
+
+// WriteSnapshot stores the snapshot of the report.
+func (s *ReportStore) WriteSnapshot(ctx context.Context, userID, name string, snapshot *ReportSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+	return userBucket.Upload(ctx, snapshotObjectKey(name, snapshot.Timestamp), bytes.NewReader(data))
+}
+
+// DeleteSnapshot deletes the snapshot of the report taken at ts.
+func (s *ReportStore) DeleteSnapshot(ctx context.Context, userID, name string, ts time.Time) error {
+	userBucket := bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+
+	err := userBucket.Delete(ctx, snapshotObjectKey(name, ts))
+	if userBucket.IsObjNotFoundErr(err) {
+		return nil
+	}
+	return err
+}
+
+func snapshotsDir(name string) string {
+	return path.Join(name, snapshotsPrefix) + objstore.DirDelim
+}
+
+func snapshotObjectKey(name string, ts time.Time) string {
+	return path.Join(name, snapshotsPrefix, strconv.FormatInt(ts.UnixMilli(), 10)+snapshotObjectExt)
+}
+
+// ReportsScheduler periodically executes the reports owned by the ruler, and deletes their snapshots
+// exceeding the retention.
+type ReportsScheduler struct {
+	services.Service
+
+	cfg       ReportsConfig
+	store     *ReportStore
+	queryFunc rules.QueryFunc
+	logger    log.Logger
+
+	// owns returns whether the report of the tenant is executed by this ruler. It's set by the ruler.
+	owns func(userID, name string) (bool, error)
+	now  func() time.Time
+
+	executions       *prometheus.CounterVec
+	deletedSnapshots prometheus.Counter
+}
+
+func NewReportsScheduler(cfg ReportsConfig, store *ReportStore, queryFunc rules.QueryFunc, logger log.Logger, reg prometheus.Registerer) *ReportsScheduler {
+	s := &ReportsScheduler{
+		cfg:       cfg,
+		store:     store,
+		queryFunc: queryFunc,
+		logger:    logger,
+		owns:      func(string, string) (bool, error) { return true, nil },
+		now:       time.Now,
+
+		executions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_report_executions_total",
+			Help: "Total number of report executions.",
+		}, []string{"outcome"}),
+		deletedSnapshots: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ruler_report_snapshots_deleted_total",
+			Help: "Total number of report snapshots deleted because exceeding the report retention.",
+		}),
+	}
+
+	s.Service = services.NewTimerService(cfg.PollInterval, nil, s.iteration, nil)
+	return s
+}
+
+func (s *ReportsScheduler) iteration(ctx context.Context) error {
+	users, err := s.store.ListAllUsers(ctx)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to list the users with reports", "err", err)
+		return nil
+	}
+
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		reports, err := s.store.ListReports(ctx, userID)
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "failed to list the reports", "user", userID, "err", err)
+			continue
+		}
+
+		for _, r := range reports {
+			owned, err := s.owns(userID, r.Name)
+			if err != nil {
+				level.Warn(s.logger).Log("msg", "failed to check if the ruler owns the report", "user", userID, "report", r.Name, "err", err)
+				continue
+			}
+			if !owned {
+				continue
+			}
+
+			if err := s.runReport(ctx, userID, r); err != nil {
+				level.Warn(s.logger).Log("msg", "failed to run report", "user", userID, "report", r.Name, "err", err)
+			}
+		}
+	}
+	return nil
+}
+
+// runReport executes the report if its last snapshot is older than the report interval, and deletes the snapshots
+// exceeding the report retention.
+func (s *ReportsScheduler) runReport(ctx context.Context, userID string, r *Report) error {
+	// The snapshots are identified by their timestamp in milliseconds.
+	now := time.UnixMilli(s.now().UnixMilli()).UTC()
+
+	snapshots, err := s.store.ListSnapshots(ctx, userID, r.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, ts := range snapshots {
+		if now.Sub(ts) <= time.Duration(r.Retention) {
+			break
+		}
+		if err := s.store.DeleteSnapshot(ctx, userID, r.Name, ts); err != nil {
+			return errors.Wrap(err, "delete snapshot exceeding the retention")
+		}
+		s.deletedSnapshots.Inc()
+	}
+
+	if len(snapshots) > 0 && now.Sub(snapshots[len(snapshots)-1]) < time.Duration(r.Interval) {
+		return nil
+	}
+
+	result, err := s.queryFunc(user.InjectOrgID(ctx, userID), r.Query, now)
+	if err != nil {
+		s.executions.WithLabelValues("failure").Inc()
+		return errors.Wrap(err, "execute query")
+	}
+
+	if err := s.store.WriteSnapshot(ctx, userID, r.Name, &ReportSnapshot{Timestamp: now, Query: r.Query, Result: result}); err != nil {
+		s.executions.WithLabelValues("failure").Inc()
+		return errors.Wrap(err, "write snapshot")
+	}
+
+	s.executions.WithLabelValues("success").Inc()
+	level.Debug(s.logger).Log("msg", "report executed", "user", userID, "report", r.Name, "series", len(result))
+	return nil
+}
@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// latestSnapshot can be used in place of the snapshot timestamp to get the most recent snapshot of a report.
+const latestSnapshot = "latest"
+
+// ReportsLimits defines the limits applied to the reports API.
+type ReportsLimits interface {
+	RulerMaxReportsPerTenant(userID string) int
+}
+
+// ReportsAPI handles the HTTP requests to configure the reports and read their snapshots.
+type ReportsAPI struct {
+	store  *ReportStore
+	limits ReportsLimits
+	logger log.Logger
+}
+
+func NewReportsAPI(store *ReportStore, limits ReportsLimits, logger log.Logger) *ReportsAPI {
+	return &ReportsAPI{
+		store:  store,
+		limits: limits,
+		logger: logger,
+	}
+}
+
+// ListReports returns the reports of the tenant, in YAML.
+func (a *ReportsAPI) ListReports(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	reports, err := a.store.ListReports(req.Context(), userID)
+	if err != nil {
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	marshalAndSend(map[string][]*Report{"reports": reports}, w, logger)
+}
+
+// GetReport returns the report in YAML.
+func (a *ReportsAPI) GetReport(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	r, err := a.store.GetReport(req.Context(), userID, mux.Vars(req)["name"])
+	if err != nil {
+		if errors.Is(err, ErrReportNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	marshalAndSend(r, w, logger)
+}
+
+// SetReport creates or replaces the report with the YAML definition in the request body.
+func (a *ReportsAPI) SetReport(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(req)["name"]
+	r := &Report{}
+	if err := yaml.Unmarshal(payload, r); err != nil {
+		http.Error(w, errors.Wrap(err, "unable to decode report").Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Name == "" {
+		r.Name = name
+	}
+	if r.Name != name {
+		http.Error(w, fmt.Sprintf("the report name %q doesn't match the name %q in the request path", r.Name, name), http.StatusBadRequest)
+		return
+	}
+	if err := r.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The limit only applies when a new report is created.
+	if limit := a.limits.RulerMaxReportsPerTenant(userID); limit > 0 {
+		reports, err := a.store.ListReports(req.Context(), userID)
+		if err != nil {
+			level.Error(logger).Log("msg", "unable to fetch current reports for validation", "err", err.Error(), "user", userID)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		exists := false
+		for _, existing := range reports {
+			exists = exists || existing.Name == name
+		}
+		if !exists && len(reports) >= limit {
+			http.Error(w, fmt.Sprintf("per-user reports limit (limit: %d actual: %d) exceeded", limit, len(reports)+1), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := a.store.SetReport(req.Context(), userID, r); err != nil {
+		level.Error(logger).Log("msg", "unable to store report", "err", err.Error(), "user", userID, "report", name)
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	respondAccepted(w, logger)
+}
+
+// DeleteReport deletes the report and its snapshots.
+func (a *ReportsAPI) DeleteReport(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := a.store.DeleteReport(req.Context(), userID, mux.Vars(req)["name"]); err != nil {
+		if errors.Is(err, ErrReportNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	respondAccepted(w, logger)
+}
+
+// ListSnapshots returns the timestamps of the report snapshots, sorted from the oldest, in JSON.
+func (a *ReportsAPI) ListSnapshots(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	name := mux.Vars(req)["name"]
+	if _, err := a.store.GetReport(req.Context(), userID, name); err != nil {
+		if errors.Is(err, ErrReportNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	timestamps, err := a.store.ListSnapshots(req.Context(), userID, name)
+	if err != nil {
+		respondError(logger, w, err.Error())
+		return
+	}
+	if timestamps == nil {
+		timestamps = []time.Time{}
+	}
+
+	util.WriteJSONResponse(w, map[string][]time.Time{"snapshots": timestamps})
+}
+
+// GetSnapshot returns the report snapshot taken at the timestamp in the request path, in JSON.
+// The timestamp can be a Unix timestamp in seconds, a RFC3339 timestamp or "latest".
+func (a *ReportsAPI) GetSnapshot(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	name := mux.Vars(req)["name"]
+	param := mux.Vars(req)["timestamp"]
+
+	var ts time.Time
+	if param == latestSnapshot {
+		timestamps, err := a.store.ListSnapshots(req.Context(), userID, name)
+		if err != nil {
+			respondError(logger, w, err.Error())
+			return
+		}
+		if len(timestamps) == 0 {
+			http.Error(w, ErrSnapshotNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		ts = timestamps[len(timestamps)-1]
+	} else {
+		ms, err := util.ParseTime(param)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ts = util.TimeFromMillis(ms)
+	}
+
+	reader, err := a.store.GetSnapshot(req.Context(), userID, name, ts)
+	if err != nil {
+		if errors.Is(err, ErrSnapshotNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondError(logger, w, err.Error())
+		return
+	}
+	defer func() { _ = reader.Close() }()
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := io.Copy(w, reader); err != nil {
+		level.Error(logger).Log("msg", "error writing report snapshot response", "err", err)
+	}
+}
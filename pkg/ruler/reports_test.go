@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+)
+
+func TestReport_Validate(t *testing.T) {
+	valid := Report{Name: "daily-usage", Query: "sum(up)", Interval: model.Duration(time.Hour), Retention: model.Duration(24 * time.Hour)}
+	require.NoError(t, valid.Validate())
+
+	for name, mutate := range map[string]func(r *Report){
+		"invalid name":               func(r *Report) { r.Name = "daily/usage" },
+		"invalid query":              func(r *Report) { r.Query = "sum(" },
+		"interval too short":         func(r *Report) { r.Interval = model.Duration(time.Second) },
+		"retention shorter than int": func(r *Report) { r.Retention = model.Duration(time.Minute) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := valid
+			mutate(&r)
+			assert.Error(t, r.Validate())
+		})
+	}
+}
+
+func TestReportStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewReportStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+
+	r := &Report{Name: "usage", Query: "sum(up)", Interval: model.Duration(time.Hour), Retention: model.Duration(24 * time.Hour)}
+	require.NoError(t, store.SetReport(ctx, "user-1", r))
+	require.NoError(t, store.SetReport(ctx, "user-1", &Report{Name: "alerts", Query: "count(ALERTS)", Interval: model.Duration(time.Hour), Retention: model.Duration(time.Hour)}))
+
+	users, err := store.ListAllUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, users)
+
+	reports, err := store.ListReports(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "alerts", reports[0].Name)
+	assert.Equal(t, r, reports[1])
+
+	_, err = store.GetReport(ctx, "user-2", "usage")
+	assert.ErrorIs(t, err, ErrReportNotFound)
+
+	t1, t2 := time.UnixMilli(2000).UTC(), time.UnixMilli(1000).UTC()
+	require.NoError(t, store.WriteSnapshot(ctx, "user-1", "usage", &ReportSnapshot{Timestamp: t1, Query: r.Query}))
+	require.NoError(t, store.WriteSnapshot(ctx, "user-1", "usage", &ReportSnapshot{Timestamp: t2, Query: r.Query}))
+
+	snapshots, err := store.ListSnapshots(ctx, "user-1", "usage")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{t2, t1}, snapshots)
+
+	_, err = store.GetSnapshot(ctx, "user-1", "usage", time.UnixMilli(3000))
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	require.NoError(t, store.DeleteReport(ctx, "user-1", "usage"))
+	assert.ErrorIs(t, store.DeleteReport(ctx, "user-1", "usage"), ErrReportNotFound)
+
+	snapshots, err = store.ListSnapshots(ctx, "user-1", "usage")
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestReportsScheduler(t *testing.T) {
+	ctx := context.Background()
+	store := NewReportStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	require.NoError(t, store.SetReport(ctx, "user-1", &Report{Name: "usage", Query: "sum(up)", Interval: model.Duration(time.Hour), Retention: model.Duration(2 * time.Hour)}))
+	require.NoError(t, store.SetReport(ctx, "user-1", &Report{Name: "not-owned", Query: "sum(up)", Interval: model.Duration(time.Hour), Retention: model.Duration(time.Hour)}))
+
+	var queries []string
+	queryFunc := func(ctx context.Context, q string, ts time.Time) (promql.Vector, error) {
+		userID, err := user.ExtractOrgID(ctx)
+		require.NoError(t, err)
+		queries = append(queries, userID+":"+q)
+		return promql.Vector{{Metric: labels.FromStrings("job", "a"), Point: promql.Point{T: ts.UnixMilli(), V: 1}}}, nil
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	s := NewReportsScheduler(ReportsConfig{PollInterval: time.Minute}, store, queryFunc, log.NewNopLogger(), reg)
+	s.owns = func(_, name string) (bool, error) { return name == "usage", nil }
+
+	now := time.Unix(10000, 0).UTC()
+	s.now = func() time.Time { return now }
+
+	expectSnapshots := func(expected ...time.Time) {
+		t.Helper()
+		snapshots, err := store.ListSnapshots(ctx, "user-1", "usage")
+		require.NoError(t, err)
+		assert.Equal(t, expected, snapshots)
+	}
+
+	// The first iteration executes the owned report.
+	require.NoError(t, s.iteration(ctx))
+	assert.Equal(t, []string{"user-1:sum(up)"}, queries)
+	expectSnapshots(now)
+
+	reader, err := store.GetSnapshot(ctx, "user-1", "usage", now)
+	require.NoError(t, err)
+	snapshot := ReportSnapshot{}
+	require.NoError(t, json.NewDecoder(reader).Decode(&snapshot))
+	require.NoError(t, reader.Close())
+	assert.Equal(t, now, snapshot.Timestamp)
+	assert.Len(t, snapshot.Result, 1)
+
+	// The report isn't executed again before its interval elapsed.
+	first := now
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, s.iteration(ctx))
+	assert.Len(t, queries, 1)
+
+	second := first.Add(time.Hour)
+	now = second
+	require.NoError(t, s.iteration(ctx))
+	assert.Len(t, queries, 2)
+	expectSnapshots(first, second)
+
+	// The snapshots exceeding the retention are deleted.
+	now = first.Add(3 * time.Hour)
+	require.NoError(t, s.iteration(ctx))
+	expectSnapshots(second, now)
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(s.executions.WithLabelValues("success")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(s.deletedSnapshots))
+}
+
+func TestReportsAPI(t *testing.T) {
+	store := NewReportStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	a := NewReportsAPI(store, reportsLimitsMock{maxReports: 1}, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/reports").Methods(http.MethodGet).HandlerFunc(a.ListReports)
+	router.Path("/api/v1/reports/{name}").Methods(http.MethodGet).HandlerFunc(a.GetReport)
+	router.Path("/api/v1/reports/{name}").Methods(http.MethodPut).HandlerFunc(a.SetReport)
+	router.Path("/api/v1/reports/{name}").Methods(http.MethodDelete).HandlerFunc(a.DeleteReport)
+	router.Path("/api/v1/reports/{name}/snapshots").Methods(http.MethodGet).HandlerFunc(a.ListSnapshots)
+	router.Path("/api/v1/reports/{name}/snapshots/{timestamp}").Methods(http.MethodGet).HandlerFunc(a.GetSnapshot)
+
+	send := func(method, path, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		out, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		return rec.Code, string(out)
+	}
+
+	code, _ := send(http.MethodPut, "/api/v1/reports/usage", "query: sum(up)\ninterval: 1h\nretention: 1d\n")
+	require.Equal(t, http.StatusAccepted, code)
+
+	code, body := send(http.MethodGet, "/api/v1/reports/usage", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.YAMLEq(t, "name: usage\nquery: sum(up)\ninterval: 1h\nretention: 1d\n", body)
+
+	// Replacing an existing report doesn't count against the limit.
+	code, _ = send(http.MethodPut, "/api/v1/reports/usage", "query: sum(up)\ninterval: 2h\nretention: 1d\n")
+	require.Equal(t, http.StatusAccepted, code)
+
+	code, body = send(http.MethodPut, "/api/v1/reports/other", "query: sum(up)\ninterval: 1h\nretention: 1d\n")
+	require.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "per-user reports limit")
+
+	// Invalid reports are rejected.
+	code, _ = send(http.MethodPut, "/api/v1/reports/usage", "name: other\nquery: sum(up)\ninterval: 1h\nretention: 1d\n")
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = send(http.MethodPut, "/api/v1/reports/usage", "query: sum(\ninterval: 1h\nretention: 1d\n")
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, body = send(http.MethodGet, "/api/v1/reports", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.YAMLEq(t, "reports:\n- name: usage\n  query: sum(up)\n  interval: 2h\n  retention: 1d\n", body)
+
+	// Snapshots.
+	code, _ = send(http.MethodGet, "/api/v1/reports/usage/snapshots/latest", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	ts := time.Unix(1600000000, 0).UTC()
+	require.NoError(t, store.WriteSnapshot(context.Background(), "user-1", "usage", &ReportSnapshot{Timestamp: ts, Query: "sum(up)"}))
+
+	code, body = send(http.MethodGet, "/api/v1/reports/usage/snapshots", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"snapshots":["2020-09-13T12:26:40Z"]}`, body)
+
+	for _, param := range []string{"latest", "1600000000", "2020-09-13T12:26:40Z"} {
+		code, body = send(http.MethodGet, "/api/v1/reports/usage/snapshots/"+param, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.JSONEq(t, `{"timestamp":"2020-09-13T12:26:40Z","query":"sum(up)","result":null}`, body)
+	}
+
+	code, _ = send(http.MethodGet, "/api/v1/reports/usage/snapshots/1600000001", "")
+	require.Equal(t, http.StatusNotFound, code)
+	code, _ = send(http.MethodGet, "/api/v1/reports/unknown/snapshots", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	// Deletion.
+	code, _ = send(http.MethodDelete, "/api/v1/reports/usage", "")
+	require.Equal(t, http.StatusAccepted, code)
+	code, _ = send(http.MethodDelete, "/api/v1/reports/usage", "")
+	require.Equal(t, http.StatusNotFound, code)
+	code, _ = send(http.MethodGet, "/api/v1/reports/usage", "")
+	require.Equal(t, http.StatusNotFound, code)
+}
+
+type reportsLimitsMock struct {
+	maxReports int
+}
+
+func (m reportsLimitsMock) RulerMaxReportsPerTenant(string) int {
+	return m.maxReports
+}
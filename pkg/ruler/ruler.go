@@ -114,6 +114,8 @@ type Config struct {
 	QueryFrontend QueryFrontendConfig `yaml:"query_frontend"`
 
 	TenantFederation TenantFederationConfig `yaml:"tenant_federation"`
+
+	Reports ReportsConfig `yaml:"reports"`
 }
 
 // Validate config and returns error on failure
@@ -135,6 +137,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.Notifier.RegisterFlags(f)
 	cfg.TenantFederation.RegisterFlags(f)
 	cfg.QueryFrontend.RegisterFlags(f)
+	cfg.Reports.RegisterFlags(f)
 
 	cfg.ExternalURL.URL, _ = url.Parse("") // Must be non-nil
 	f.Var(&cfg.ExternalURL, "ruler.external.url", "URL of alerts return path.")
@@ -201,6 +204,12 @@ type MultiTenantManager interface {
 	Stop()
 	// ValidateRuleGroup validates a rulegroup
 	ValidateRuleGroup(rulefmt.RuleGroup) []error
+	// GetCanaryRuleGroups returns the rule groups of a tenant evaluated in shadow.
+	GetCanaryRuleGroups(userID string) []CanaryRuleGroup
+	// PromoteCanaryRuleGroup replaces the active version of a rule group with the one evaluated in shadow.
+	PromoteCanaryRuleGroup(userID, namespace, group string) error
+	// RollbackCanaryRuleGroup discards the version of a rule group evaluated in shadow.
+	RollbackCanaryRuleGroup(userID, namespace, group string) error
 }
 
 // Ruler evaluates rules.
@@ -248,6 +257,9 @@ type Ruler struct {
 	// Pool of clients used to connect to other ruler replicas.
 	clientsPool ClientsPool
 
+	// The reports scheduler is an optional feature, if it's disabled then reports will be nil.
+	reports *ReportsScheduler
+
 	allowedTenants *util.AllowedTenants
 
 	registry prometheus.Registerer
@@ -326,7 +338,12 @@ func enableSharding(r *Ruler, ringStore kv.Client) error {
 func (r *Ruler) starting(ctx context.Context) error {
 	var err error
 
-	if r.subservices, err = services.NewManager(r.lifecycler, r.ring, r.clientsPool); err != nil {
+	subservices := []services.Service{r.lifecycler, r.ring, r.clientsPool}
+	if r.reports != nil {
+		subservices = append(subservices, r.reports)
+	}
+
+	if r.subservices, err = services.NewManager(subservices...); err != nil {
 		return errors.Wrap(err, "unable to start ruler subservices")
 	}
 
@@ -387,6 +404,28 @@ func instanceOwnsRuleGroup(r ring.ReadRing, g *rulespb.RuleGroupDesc, instanceAd
 	return rlrs.Instances[0].Addr == instanceAddr, nil
 }
 
+// EnableReports makes the ruler run the reports scheduler, executing only the reports owned by the ruler.
+// It must be called before the ruler is started.
+func (r *Ruler) EnableReports(s *ReportsScheduler) {
+	s.owns = r.ownsReport
+	r.reports = s
+}
+
+// ownsReport returns whether the report of the tenant is executed by this ruler. The reports are sharded
+// among the rulers like the rule groups.
+func (r *Ruler) ownsReport(userID, name string) (bool, error) {
+	if !r.allowedTenants.IsAllowed(userID) {
+		return false, nil
+	}
+
+	var userRing ring.ReadRing = r.ring
+	if shardSize := r.limits.RulerTenantShardSize(userID); shardSize > 0 {
+		userRing = r.ring.ShuffleShard(userID, shardSize)
+	}
+
+	return instanceOwnsRuleGroup(userRing, &rulespb.RuleGroupDesc{User: userID, Namespace: reportsRingNamespace, Name: name}, r.lifecycler.GetInstanceAddr())
+}
+
 func (r *Ruler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.ring.ServeHTTP(w, req)
 }
@@ -894,6 +933,11 @@ func (r *Ruler) DeleteTenantConfiguration(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	if err := r.store.SetLimitsExceededSince(req.Context(), userID, time.Time{}); err != nil {
+		respondError(logger, w, err.Error())
+		return
+	}
+
 	level.Info(logger).Log("msg", "deleted all tenant rule groups", "user", userID)
 	w.WriteHeader(http.StatusOK)
 }
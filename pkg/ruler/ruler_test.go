@@ -204,7 +204,7 @@ func prepareRulerManager(t *testing.T, cfg Config, opts ...prepareOption) *Defau
 	pusher.MockPush(&mimirpb.WriteResponse{}, nil)
 
 	managerFactory := DefaultTenantManagerFactory(cfg, pusher, noopQueryable, noopQueryFunc, options.limits, options.registerer)
-	manager, err := NewDefaultMultiTenantManager(cfg, managerFactory, prometheus.NewRegistry(), options.logger, nil)
+	manager, err := NewDefaultMultiTenantManager(cfg, managerFactory, options.limits, prometheus.NewRegistry(), options.logger, nil)
 	require.NoError(t, err)
 
 	return manager
@@ -253,6 +253,79 @@ func TestNotifierSendsUserIDHeader(t *testing.T) {
 	`), "cortex_prometheus_notifications_dropped_total"))
 }
 
+func TestNotifierSendsToTenantAlertmanager(t *testing.T) {
+	newAlertmanager := func(received *atomic.Int64) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Inc()
+		}))
+	}
+
+	var defaultReceived, tenantReceived atomic.Int64
+	defaultAM := newAlertmanager(&defaultReceived)
+	defer defaultAM.Close()
+	tenantAM := newAlertmanager(&tenantReceived)
+	defer tenantAM.Close()
+
+	limits := &tenantAlertmanagerLimitsMock{RulesLimits: validation.MockDefaultOverrides()}
+	limits.tenantURL.Store(tenantAM.URL)
+
+	cfg := defaultRulerConfig(t)
+	cfg.AlertmanagerURL = defaultAM.URL
+
+	manager := prepareRulerManager(t, cfg, withLimits(limits))
+	defer manager.Stop()
+
+	n, err := manager.getOrCreateNotifier("1")
+	require.NoError(t, err)
+
+	sendAndWait := func(expectedAlertmanagers int, expectedDefault, expectedTenant int64) {
+		// Loop until notifier discovery syncs up.
+		for len(n.Alertmanagers()) != expectedAlertmanagers {
+			time.Sleep(10 * time.Millisecond)
+		}
+		n.Send(&notifier.Alert{Labels: labels.FromStrings("alertname", "testalert")})
+
+		test.Poll(t, 5*time.Second, true, func() interface{} {
+			return defaultReceived.Load() == expectedDefault && tenantReceived.Load() == expectedTenant
+		})
+	}
+
+	// The alerts are sent only to the tenant Alertmanager.
+	sendAndWait(1, 0, 1)
+
+	// The alerts are sent to both the tenant and default Alertmanagers.
+	limits.sendToDefault.Store(true)
+	require.NoError(t, manager.syncTenantNotifier("1"))
+	sendAndWait(2, 1, 2)
+
+	// The alerts are sent only to the default Alertmanager once the tenant URL is unset.
+	limits.tenantURL.Store("")
+	require.NoError(t, manager.syncTenantNotifier("1"))
+	sendAndWait(1, 2, 2)
+
+	// Ensure the tenant notifier has its own metrics.
+	assert.NoError(t, prom_testutil.GatherAndCompare(manager.registry.(*prometheus.Registry), strings.NewReader(`
+		# HELP cortex_ruler_tenant_prometheus_notifications_dropped_total Total number of alerts dropped due to errors when sending to Alertmanager.
+		# TYPE cortex_ruler_tenant_prometheus_notifications_dropped_total counter
+		cortex_ruler_tenant_prometheus_notifications_dropped_total{user="1"} 0
+	`), "cortex_ruler_tenant_prometheus_notifications_dropped_total"))
+}
+
+type tenantAlertmanagerLimitsMock struct {
+	RulesLimits
+
+	tenantURL     atomic.String
+	sendToDefault atomic.Bool
+}
+
+func (m *tenantAlertmanagerLimitsMock) RulerTenantAlertmanagerURL(string) string {
+	return m.tenantURL.Load()
+}
+
+func (m *tenantAlertmanagerLimitsMock) RulerSendToDefaultAlertmanager(string) bool {
+	return m.sendToDefault.Load()
+}
+
 func TestRuler_Rules(t *testing.T) {
 	testCases := map[string]struct {
 		mockRules map[string]rulespb.RuleGroupList
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -29,6 +30,14 @@ const (
 	// RulesPrefix is the bucket prefix under which all tenants rule groups are stored.
 	RulesPrefix = "rules"
 
+	// RulerPrefix is the bucket prefix under which other ruler state is stored.
+	// Note that objects stored under this prefix follow the pattern:
+	//     ruler/<user-id>/<object>
+	RulerPrefix = "ruler"
+
+	// The name of the object storing the time since which the rule groups of a tenant have been exceeding the limits.
+	limitsExceededSinceName = "limits-exceeded-since"
+
 	loadConcurrency = 10
 )
 
@@ -43,6 +52,7 @@ var (
 // using the Thanos objstore.Bucket interface
 type BucketRuleStore struct {
 	bucket      objstore.Bucket
+	rulerBucket objstore.Bucket
 	cfgProvider bucket.TenantConfigProvider
 	logger      log.Logger
 }
@@ -50,6 +60,7 @@ type BucketRuleStore struct {
 func NewBucketRuleStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *BucketRuleStore {
 	return &BucketRuleStore{
 		bucket:      bucket.NewPrefixedBucketClient(bkt, RulesPrefix),
+		rulerBucket: bucket.NewPrefixedBucketClient(bkt, RulerPrefix),
 		cfgProvider: cfgProvider,
 		logger:      logger,
 	}
@@ -243,6 +254,44 @@ func (b *BucketRuleStore) DeleteNamespace(ctx context.Context, userID string, na
 	return nil
 }
 
+// GetLimitsExceededSince implements rules.RuleStore.
+func (b *BucketRuleStore) GetLimitsExceededSince(ctx context.Context, userID string) (time.Time, error) {
+	userBucket := bucket.NewUserBucketClient(userID, b.rulerBucket, b.cfgProvider)
+	reader, err := userBucket.Get(ctx, limitsExceededSinceName)
+	if b.rulerBucket.IsObjNotFoundErr(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to get the time since which the limits have been exceeded for user %s", userID)
+	}
+	defer func() { _ = reader.Close() }()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to read the time since which the limits have been exceeded for user %s", userID)
+	}
+
+	since, err := time.Parse(time.RFC3339, string(data))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse the time since which the limits have been exceeded for user %s", userID)
+	}
+	return since, nil
+}
+
+// SetLimitsExceededSince implements rules.RuleStore.
+func (b *BucketRuleStore) SetLimitsExceededSince(ctx context.Context, userID string, since time.Time) error {
+	userBucket := bucket.NewUserBucketClient(userID, b.rulerBucket, b.cfgProvider)
+	if since.IsZero() {
+		err := userBucket.Delete(ctx, limitsExceededSinceName)
+		if b.rulerBucket.IsObjNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+
+	return userBucket.Upload(ctx, limitsExceededSinceName, strings.NewReader(since.UTC().Format(time.RFC3339)))
+}
+
 func getNamespacePrefix(namespace string) string {
 	return base64.URLEncoding.EncodeToString([]byte(namespace)) + objstore.DirDelim
 }
@@ -406,3 +406,40 @@ func (mb mockBucket) Iter(_ context.Context, dir string, f func(string) error, o
 	}
 	return nil
 }
+
+func TestGetSetLimitsExceededSince(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	rs := NewBucketRuleStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	since := time.Unix(1700000000, 0).UTC()
+
+	// The storage is empty.
+	res, err := rs.GetLimitsExceededSince(ctx, "user1")
+	require.NoError(t, err)
+	assert.True(t, res.IsZero())
+
+	// The time is stored outside of the rule groups of the user.
+	require.NoError(t, rs.SetLimitsExceededSince(ctx, "user1", since))
+
+	res, err = rs.GetLimitsExceededSince(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, since, res)
+
+	exists, err := bucket.Exists(ctx, "ruler/user1/limits-exceeded-since")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	users, err := rs.ListAllUsers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	// The zero time removes the stored time (idempotently).
+	for i := 0; i < 2; i++ {
+		require.NoError(t, rs.SetLimitsExceededSince(ctx, "user1", time.Time{}))
+
+		res, err = rs.GetLimitsExceededSince(ctx, "user1")
+		require.NoError(t, err)
+		assert.True(t, res.IsZero())
+	}
+}
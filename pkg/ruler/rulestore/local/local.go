@@ -10,6 +10,7 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	promRules "github.com/prometheus/prometheus/rules"
@@ -114,6 +115,16 @@ func (l *Client) DeleteNamespace(ctx context.Context, userID, namespace string)
 	return errors.New("DeleteNamespace unsupported in rule local store")
 }
 
+// GetLimitsExceededSince implements rules.RuleStore. The rule groups in the local store are never checked against the limits.
+func (l *Client) GetLimitsExceededSince(_ context.Context, _ string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// SetLimitsExceededSince implements rules.RuleStore. This method is not available in this storage.
+func (l *Client) SetLimitsExceededSince(_ context.Context, _ string, _ time.Time) error {
+	return errors.New("SetLimitsExceededSince unsupported in rule local store")
+}
+
 func (l *Client) loadAllRulesGroupsForUser(ctx context.Context, userID string) (rulespb.RuleGroupList, error) {
 	var allLists rulespb.RuleGroupList
 
@@ -8,6 +8,7 @@ package rulestore
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
 )
@@ -50,4 +51,12 @@ type RuleStore interface {
 	// DeleteNamespace lists rule groups for given user and namespace, and deletes all rule groups.
 	// If namespace is empty, deletes all rule groups for user.
 	DeleteNamespace(ctx context.Context, userID, namespace string) error
+
+	// GetLimitsExceededSince returns the time since which the rule groups of the user have been exceeding
+	// the limits. Returns the zero time if they're not exceeding the limits.
+	GetLimitsExceededSince(ctx context.Context, userID string) (time.Time, error)
+
+	// SetLimitsExceededSince stores the time since which the rule groups of the user have been exceeding
+	// the limits. If since is the zero time, the stored time is removed.
+	SetLimitsExceededSince(ctx context.Context, userID string, since time.Time) error
 }
@@ -7,6 +7,7 @@ package ruler
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -41,3 +42,17 @@ func NewRuleStore(ctx context.Context, cfg rulestore.Config, cfgProvider bucket.
 
 	return store, nil
 }
+
+// NewReportStoreFromConfig returns a report store backed by the object storage configured for the rules.
+func NewReportStoreFromConfig(ctx context.Context, cfg rulestore.Config, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) (*ReportStore, error) {
+	if cfg.Backend == local.Name {
+		return nil, fmt.Errorf("the reports are not supported by the %s ruler storage backend", local.Name)
+	}
+
+	bucketClient, err := bucket.NewClient(ctx, cfg.Config, "ruler-reports", logger, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReportStore(bucketClient, cfgProvider, logger), nil
+}
@@ -17,8 +17,9 @@ import (
 )
 
 type mockRuleStore struct {
-	rules map[string]rulespb.RuleGroupList
-	mtx   sync.Mutex
+	rules               map[string]rulespb.RuleGroupList
+	limitsExceededSince map[string]time.Time
+	mtx                 sync.Mutex
 }
 
 var (
@@ -48,7 +49,8 @@ var (
 
 func newMockRuleStore(rules map[string]rulespb.RuleGroupList) *mockRuleStore {
 	return &mockRuleStore{
-		rules: rules,
+		rules:               rules,
+		limitsExceededSince: map[string]time.Time{},
 	}
 }
 
@@ -210,3 +212,22 @@ func (m *mockRuleStore) DeleteNamespace(ctx context.Context, userID, namespace s
 
 	return nil
 }
+
+func (m *mockRuleStore) GetLimitsExceededSince(_ context.Context, userID string) (time.Time, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.limitsExceededSince[userID], nil
+}
+
+func (m *mockRuleStore) SetLimitsExceededSince(_ context.Context, userID string, since time.Time) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if since.IsZero() {
+		delete(m.limitsExceededSince, userID)
+	} else {
+		m.limitsExceededSince[userID] = since
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority is the priority class of a request. The requests of a tenant with a higher priority
+// are dequeued before the requests of the same tenant with a lower priority.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+
+	numPriorities = int(PriorityHigh) + 1
+)
+
+var priorityNames = [numPriorities]string{"low", "normal", "high"}
+
+// PriorityNames returns the names of the supported priority classes, from the lowest to the highest.
+func PriorityNames() []string {
+	return priorityNames[:]
+}
+
+// ParsePriority parses the name of a priority class.
+func ParsePriority(name string) (Priority, error) {
+	for p, n := range priorityNames {
+		if strings.EqualFold(name, n) {
+			return Priority(p), nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("invalid query priority %q, supported values are: %s", name, strings.Join(priorityNames[:], ", "))
+}
+
+func (p Priority) String() string {
+	if p < 0 || int(p) >= numPriorities {
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+	return priorityNames[p]
+}
@@ -7,6 +7,7 @@ package queue
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -65,9 +66,11 @@ type RequestQueue struct {
 	discardedRequests *prometheus.CounterVec // Per user.
 }
 
-func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, queueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec) *RequestQueue {
+// NewRequestQueue returns a new RequestQueue. The requests of a tenant with a lower priority can be skipped in favor
+// of the ones with a higher priority at most maxSkippedLowerPriorityRequests times in a row (0 = no limit).
+func NewRequestQueue(maxOutstandingPerTenant, maxSkippedLowerPriorityRequests int, forgetDelay time.Duration, queueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec) *RequestQueue {
 	q := &RequestQueue{
-		queues:                  newUserQueues(maxOutstandingPerTenant, forgetDelay),
+		queues:                  newUserQueues(maxOutstandingPerTenant, maxSkippedLowerPriorityRequests, forgetDelay),
 		connectedQuerierWorkers: atomic.NewInt32(0),
 		queueLength:             queueLength,
 		discardedRequests:       discardedRequests,
@@ -79,12 +82,12 @@ func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, que
 	return q
 }
 
-// EnqueueRequest puts the request into the queue. MaxQueries is user-specific value that specifies how many queriers can
+// EnqueueRequest puts the request into the queue, with the given priority. MaxQueries is user-specific value that specifies how many queriers can
 // this user use (zero or negative = all queriers). It is passed to each EnqueueRequest, because it can change
 // between calls.
 //
 // If request is successfully enqueued, successFn is called with the lock held, before any querier can receive the request.
-func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers int, successFn func()) error {
+func (q *RequestQueue) EnqueueRequest(userID string, req Request, priority Priority, maxQueriers int, successFn func()) error {
 	q.mtx.Lock()
 	defer q.mtx.Unlock()
 
@@ -92,25 +95,30 @@ func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers in
 		return ErrStopped
 	}
 
+	if priority < 0 || int(priority) >= numPriorities {
+		return errors.Errorf("invalid priority %d", priority)
+	}
+
 	queue := q.queues.getOrAddQueue(userID, maxQueriers)
 	if queue == nil {
 		// This can only happen if userID is "".
 		return errors.New("no queue found")
 	}
 
-	select {
-	case queue <- req:
-		q.queueLength.WithLabelValues(userID).Inc()
-		q.cond.Broadcast()
-		// Call this function while holding a lock. This guarantees that no querier can fetch the request before function returns.
-		if successFn != nil {
-			successFn()
-		}
-		return nil
-	default:
+	// The limit applies to the requests of the user of all priority classes.
+	if queue.len() >= q.queues.maxUserQueueSize {
 		q.discardedRequests.WithLabelValues(userID).Inc()
 		return ErrTooManyRequests
 	}
+
+	queue.chs[priority] <- req
+	q.queueLength.WithLabelValues(userID).Inc()
+	q.cond.Broadcast()
+	// Call this function while holding a lock. This guarantees that no querier can fetch the request before function returns.
+	if successFn != nil {
+		successFn()
+	}
+	return nil
 }
 
 // GetNextRequestForQuerier find next user queue and takes the next request off of it. Will block if there are no requests.
@@ -146,8 +154,8 @@ FindQueue:
 
 		// Pick next request from the queue.
 		for {
-			request := <-queue
-			if len(queue) == 0 {
+			request := queue.dequeue(q.queues.maxSkippedRequests)
+			if queue.len() == 0 {
 				q.queues.deleteQueue(userID)
 			}
 
@@ -222,6 +230,51 @@ func (q *RequestQueue) GetConnectedQuerierWorkersMetric() float64 {
 	return float64(q.connectedQuerierWorkers.Load())
 }
 
+// UserQueueStatus holds the status of the queue of a single user.
+type UserQueueStatus struct {
+	UserID string `json:"tenant"`
+
+	// Number of requests waiting in the queue.
+	Length int `json:"length"`
+
+	// Number of requests waiting in the queue, by priority class.
+	LengthByPriority map[string]int `json:"length_by_priority"`
+
+	// Max number of queriers the user can use (zero = all queriers).
+	MaxQueriers int `json:"max_queriers"`
+
+	// Number of queriers currently selected to handle the user requests (zero = all queriers).
+	Queriers int `json:"queriers"`
+}
+
+// GetUserQueuesStatus returns the status of the queues of all users, sorted by user ID.
+func (q *RequestQueue) GetUserQueuesStatus() []UserQueueStatus {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	status := make([]UserQueueStatus, 0, len(q.queues.userQueues))
+	for userID, uq := range q.queues.userQueues {
+		lengthByPriority := make(map[string]int, numPriorities)
+		for p, ch := range uq.chs {
+			lengthByPriority[Priority(p).String()] = len(ch)
+		}
+
+		status = append(status, UserQueueStatus{
+			UserID:           userID,
+			Length:           uq.len(),
+			LengthByPriority: lengthByPriority,
+			MaxQueriers:      uq.maxQueriers,
+			Queriers:         len(uq.queriers),
+		})
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].UserID < status[j].UserID
+	})
+
+	return status
+}
+
 // contextCond is a *sync.Cond with Wait() method overridden to support context-based waiting.
 type contextCond struct {
 	*sync.Cond
@@ -28,7 +28,7 @@ func BenchmarkGetNextRequest(b *testing.B) {
 	queues := make([]*RequestQueue, 0, b.N)
 
 	for n := 0; n < b.N; n++ {
-		queue := NewRequestQueue(maxOutstandingPerTenant, 0,
+		queue := NewRequestQueue(maxOutstandingPerTenant, 0, 0,
 			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
 			promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
 		)
@@ -42,7 +42,7 @@ func BenchmarkGetNextRequest(b *testing.B) {
 			for j := 0; j < numTenants; j++ {
 				userID := strconv.Itoa(j)
 
-				err := queue.EnqueueRequest(userID, "request", 0, nil)
+				err := queue.EnqueueRequest(userID, "request", PriorityNormal, 0, nil)
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -85,7 +85,7 @@ func BenchmarkQueueRequest(b *testing.B) {
 	requests := make([]string, 0, numTenants)
 
 	for n := 0; n < b.N; n++ {
-		q := NewRequestQueue(maxOutstandingPerTenant, 0,
+		q := NewRequestQueue(maxOutstandingPerTenant, 0, 0,
 			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
 			promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
 		)
@@ -106,7 +106,7 @@ func BenchmarkQueueRequest(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		for i := 0; i < maxOutstandingPerTenant; i++ {
 			for j := 0; j < numTenants; j++ {
-				err := queues[n].EnqueueRequest(users[j], requests[j], 0, nil)
+				err := queues[n].EnqueueRequest(users[j], requests[j], PriorityNormal, 0, nil)
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -118,7 +118,7 @@ func BenchmarkQueueRequest(b *testing.B) {
 func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBecauseQuerierHasBeenForgotten(t *testing.T) {
 	const forgetDelay = 3 * time.Second
 
-	queue := NewRequestQueue(1, forgetDelay,
+	queue := NewRequestQueue(1, 0, forgetDelay,
 		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
 		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}))
 
@@ -147,7 +147,7 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 
 	// Enqueue a request from an user which would be assigned to querier-1.
 	// NOTE: "user-1" hash falls in the querier-1 shard.
-	require.NoError(t, queue.EnqueueRequest("user-1", "request", 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request", PriorityNormal, 1, nil))
 
 	startTime := time.Now()
 	querier2wg.Wait()
@@ -157,6 +157,92 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 	assert.GreaterOrEqual(t, waitTime.Milliseconds(), forgetDelay.Milliseconds())
 }
 
+func TestRequestQueue_GetNextRequestForQuerier_ShouldDequeueHigherPriorityRequestsFirst(t *testing.T) {
+	tests := map[string]struct {
+		maxSkipped int
+		expected   []string
+	}{
+		"without starvation protection": {
+			expected: []string{"high-1", "high-2", "high-3", "high-4", "normal-1", "normal-2", "low-1", "low-2"},
+		},
+		"with starvation protection": {
+			maxSkipped: 2,
+			// The normal and low requests are skipped twice before one of them is dequeued, and the low
+			// requests are skipped in favor of the normal ones too.
+			expected: []string{"high-1", "high-2", "normal-1", "low-1", "high-3", "high-4", "low-2", "normal-2"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			queue := NewRequestQueue(10, testData.maxSkipped, 0,
+				promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+				promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+			)
+			queue.RegisterQuerierConnection("querier-1")
+
+			for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+				for i := 1; i <= 2; i++ {
+					require.NoError(t, queue.EnqueueRequest("user-1", fmt.Sprintf("%s-%d", p, i), p, 0, nil))
+				}
+			}
+			for i := 3; i <= 4; i++ {
+				require.NoError(t, queue.EnqueueRequest("user-1", fmt.Sprintf("high-%d", i), PriorityHigh, 0, nil))
+			}
+
+			// The limit applies to all the requests of the user.
+			queue.queues.maxUserQueueSize = 8
+			require.Equal(t, ErrTooManyRequests, queue.EnqueueRequest("user-1", "high-5", PriorityHigh, 0, nil))
+
+			var actual []string
+			last := FirstUser()
+			for range testData.expected {
+				req, idx, err := queue.GetNextRequestForQuerier(context.Background(), last, "querier-1")
+				require.NoError(t, err)
+				actual = append(actual, req.(string))
+				last = idx
+			}
+			assert.Equal(t, testData.expected, actual)
+			assert.Equal(t, 0, queue.queues.len())
+		})
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		actual, err := ParsePriority(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, actual)
+	}
+
+	actual, err := ParsePriority("HIGH")
+	require.NoError(t, err)
+	assert.Equal(t, PriorityHigh, actual)
+
+	_, err = ParsePriority("urgent")
+	assert.EqualError(t, err, `invalid query priority "urgent", supported values are: low, normal, high`)
+}
+
+func TestRequestQueue_GetUserQueuesStatus(t *testing.T) {
+	queue := NewRequestQueue(10, 0, 0,
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+	)
+
+	for i := 0; i < 3; i++ {
+		queue.RegisterQuerierConnection(fmt.Sprintf("querier-%d", i))
+	}
+
+	require.NoError(t, queue.EnqueueRequest("user-2", "request", PriorityNormal, 0, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request", PriorityNormal, 2, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request", PriorityHigh, 2, nil))
+
+	assert.Equal(t, []UserQueueStatus{
+		{UserID: "user-1", Length: 2, LengthByPriority: map[string]int{"low": 0, "normal": 1, "high": 1}, MaxQueriers: 2, Queriers: 2},
+		{UserID: "user-2", Length: 1, LengthByPriority: map[string]int{"low": 0, "normal": 1, "high": 0}, MaxQueriers: 0, Queriers: 0},
+	}, queue.GetUserQueuesStatus())
+}
+
 func TestContextCond(t *testing.T) {
 	t.Run("wait until broadcast", func(t *testing.T) {
 		t.Parallel()
@@ -39,6 +39,10 @@ type queues struct {
 
 	maxUserQueueSize int
 
+	// Max number of times the requests of a priority class can be skipped in favor of the requests
+	// of a higher priority class of the same user, before one of them is dequeued (0 = no limit).
+	maxSkippedRequests int
+
 	// How long to wait before removing a querier which has got disconnected
 	// but hasn't notified about a graceful shutdown.
 	forgetDelay time.Duration
@@ -51,7 +55,12 @@ type queues struct {
 }
 
 type userQueue struct {
-	ch chan Request
+	// Pending requests, by priority class.
+	chs [numPriorities]chan Request
+
+	// Number of times the requests of each priority class have been skipped in favor of
+	// higher priority requests since a request of the class has been dequeued.
+	skipped [numPriorities]int
 
 	// If not nil, only these queriers can handle user requests. If nil, all queriers can.
 	// We set this to nil if number of available queriers <= maxQueriers.
@@ -66,14 +75,15 @@ type userQueue struct {
 	index int
 }
 
-func newUserQueues(maxUserQueueSize int, forgetDelay time.Duration) *queues {
+func newUserQueues(maxUserQueueSize, maxSkippedRequests int, forgetDelay time.Duration) *queues {
 	return &queues{
-		userQueues:       map[string]*userQueue{},
-		users:            nil,
-		maxUserQueueSize: maxUserQueueSize,
-		forgetDelay:      forgetDelay,
-		queriers:         map[string]*querier{},
-		sortedQueriers:   nil,
+		userQueues:         map[string]*userQueue{},
+		users:              nil,
+		maxUserQueueSize:   maxUserQueueSize,
+		maxSkippedRequests: maxSkippedRequests,
+		forgetDelay:        forgetDelay,
+		queriers:           map[string]*querier{},
+		sortedQueriers:     nil,
 	}
 }
 
@@ -100,7 +110,7 @@ func (q *queues) deleteQueue(userID string) {
 // MaxQueriers is used to compute which queriers should handle requests for this user.
 // If maxQueriers is <= 0, all queriers can handle this user's requests.
 // If maxQueriers has changed since the last call, queriers for this are recomputed.
-func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
+func (q *queues) getOrAddQueue(userID string, maxQueriers int) *userQueue {
 	// Empty user is not allowed, as that would break our users list ("" is used for free spot).
 	if userID == "" {
 		return nil
@@ -114,10 +124,12 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 
 	if uq == nil {
 		uq = &userQueue{
-			ch:    make(chan Request, q.maxUserQueueSize),
 			seed:  util.ShuffleShardSeed(userID, ""),
 			index: -1,
 		}
+		for p := range uq.chs {
+			uq.chs[p] = make(chan Request, q.maxUserQueueSize)
+		}
 		q.userQueues[userID] = uq
 
 		// Add user to the list of users... find first free spot, and put it there.
@@ -141,13 +153,13 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 		uq.queriers = shuffleQueriersForUser(uq.seed, maxQueriers, q.sortedQueriers, nil)
 	}
 
-	return uq.ch
+	return uq
 }
 
 // Finds next queue for the querier. To support fair scheduling between users, client is expected
 // to pass last user index returned by this function as argument. Is there was no previous
 // last user index, use -1.
-func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (chan Request, string, int) {
+func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (*userQueue, string, int) {
 	uid := lastUserIndex
 
 	// Ensure the querier is not shutting down. If the querier is shutting down, we shouldn't forward
@@ -179,11 +191,50 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (ch
 			}
 		}
 
-		return q.ch, u, uid
+		return q, u, uid
 	}
 	return nil, "", uid
 }
 
+// len returns the number of pending requests of the user.
+func (uq *userQueue) len() int {
+	n := 0
+	for _, ch := range uq.chs {
+		n += len(ch)
+	}
+	return n
+}
+
+// dequeue takes the next request off the user queue, which is the oldest request with the highest
+// priority, unless the requests with a lower priority have been skipped more than maxSkipped times.
+// The user queue must not be empty.
+func (uq *userQueue) dequeue(maxSkipped int) Request {
+	selected := -1
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(uq.chs[p]) == 0 {
+			continue
+		}
+		if selected < 0 {
+			selected = p
+			continue
+		}
+		if maxSkipped > 0 && uq.skipped[p] >= maxSkipped {
+			selected = p
+			break
+		}
+	}
+
+	// Only the priority classes lower than the selected one have been skipped.
+	for p := 0; p < selected; p++ {
+		if len(uq.chs[p]) > 0 {
+			uq.skipped[p]++
+		}
+	}
+	uq.skipped[selected] = 0
+
+	return <-uq.chs[selected]
+}
+
 func (q *queues) addQuerierConnection(querierID string) {
 	info := q.queriers[querierID]
 	if info != nil {
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	otgrpc "github.com/opentracing-contrib/go-grpc"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+const (
+	// queueStateVersion1 represents the version 1 of the queue state file.
+	queueStateVersion1 = 1
+
+	// How frequently the queue state is persisted to the file while the query-scheduler is running.
+	queueStateSyncPeriod = time.Second
+
+	// Max number of query-frontends concurrently notified about the queries to enqueue again.
+	queueStateRecoveryConcurrency = 16
+)
+
+// queueState defines the format of the file where the query-scheduler persists the metadata
+// of the requests enqueued but not dispatched to queriers yet.
+type queueState struct {
+	Version  int                  `json:"version"`
+	Requests []queuedRequestState `json:"requests"`
+}
+
+type queuedRequestState struct {
+	FrontendAddress    string `json:"frontend_address"`
+	UserID             string `json:"user_id"`
+	QueryID            uint64 `json:"query_id"`
+	RequestFingerprint uint64 `json:"request_fingerprint"`
+}
+
+// getQueueState returns the state of the requests enqueued but not dispatched to queriers yet.
+func (s *Scheduler) getQueueState() *queueState {
+	state := &queueState{Version: queueStateVersion1}
+
+	s.pendingRequestsMu.Lock()
+	for _, req := range s.pendingRequests {
+		if req.dispatched {
+			continue
+		}
+
+		state.Requests = append(state.Requests, queuedRequestState{
+			FrontendAddress:    req.frontendAddress,
+			UserID:             req.userID,
+			QueryID:            req.queryID,
+			RequestFingerprint: schedulerpb.RequestFingerprint(req.request),
+		})
+	}
+	s.pendingRequestsMu.Unlock()
+
+	sort.Slice(state.Requests, func(i, j int) bool {
+		if state.Requests[i].FrontendAddress != state.Requests[j].FrontendAddress {
+			return state.Requests[i].FrontendAddress < state.Requests[j].FrontendAddress
+		}
+		return state.Requests[i].QueryID < state.Requests[j].QueryID
+	})
+
+	return state
+}
+
+// writeQueueStateFile writes the given state to the file at path.
+func writeQueueStateFile(path string, state *queueState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// Make any changes to the file appear atomic.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readQueueStateFile reads the state from the file at path. Returns nil and no error if the file doesn't exist.
+func readQueueStateFile(path string) (*queueState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	state := &queueState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s as JSON", path)
+	}
+	if state.Version != queueStateVersion1 {
+		return nil, errors.Errorf("unexpected queue state file version %d", state.Version)
+	}
+
+	return state, nil
+}
+
+// syncQueueState persists the current queue state to the configured file, if any.
+func (s *Scheduler) syncQueueState() {
+	if s.cfg.QueueStateFile == "" {
+		return
+	}
+
+	if err := writeQueueStateFile(s.cfg.QueueStateFile, s.getQueueState()); err != nil {
+		level.Warn(s.log).Log("msg", "failed to persist query-scheduler queue state", "file", s.cfg.QueueStateFile, "err", err)
+	}
+}
+
+// loadQueueState reads and removes the queue state file persisted before the restart, if any.
+// The file is removed so that the same requests are never recovered twice.
+func (s *Scheduler) loadQueueState() *queueState {
+	if s.cfg.QueueStateFile == "" {
+		return nil
+	}
+
+	state, err := readQueueStateFile(s.cfg.QueueStateFile)
+	if err != nil {
+		level.Warn(s.log).Log("msg", "failed to read query-scheduler queue state", "file", s.cfg.QueueStateFile, "err", err)
+	}
+
+	if err := os.Remove(s.cfg.QueueStateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		level.Warn(s.log).Log("msg", "failed to remove query-scheduler queue state", "file", s.cfg.QueueStateFile, "err", err)
+	}
+
+	return state
+}
+
+// requeueRecoveredRequests asks the query-frontends to enqueue again the requests which were
+// in the queue before the query-scheduler restart.
+func (s *Scheduler) requeueRecoveredRequests(ctx context.Context, state *queueState) {
+	if state == nil || len(state.Requests) == 0 {
+		return
+	}
+
+	byFrontend := map[string][]queuedRequestState{}
+	for _, req := range state.Requests {
+		byFrontend[req.FrontendAddress] = append(byFrontend[req.FrontendAddress], req)
+	}
+
+	addrs := make([]string, 0, len(byFrontend))
+	for addr := range byFrontend {
+		addrs = append(addrs, addr)
+	}
+
+	level.Info(s.log).Log("msg", "asking query-frontends to enqueue again the requests queued before the restart", "requests", len(state.Requests), "frontends", len(addrs))
+
+	_ = concurrency.ForEachJob(ctx, len(addrs), queueStateRecoveryConcurrency, func(ctx context.Context, idx int) error {
+		s.requeueRequestsToFrontend(ctx, addrs[idx], byFrontend[addrs[idx]])
+		return nil
+	})
+}
+
+func (s *Scheduler) requeueRequestsToFrontend(ctx context.Context, frontendAddress string, reqs []queuedRequestState) {
+	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
+		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
+		middleware.ClientUserHeaderInterceptor},
+		nil)
+	if err != nil {
+		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to requeue requests", "frontend", frontendAddress, "err", err)
+		return
+	}
+
+	conn, err := grpc.DialContext(ctx, frontendAddress, opts...)
+	if err != nil {
+		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to requeue requests", "frontend", frontendAddress, "err", err)
+		return
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	client := frontendv2pb.NewFrontendForQuerierClient(conn)
+
+	for _, req := range reqs {
+		userCtx := user.InjectOrgID(ctx, req.UserID)
+		_, err = client.RequeueQuery(userCtx, &frontendv2pb.RequeueQueryRequest{
+			QueryID:            req.QueryID,
+			RequestFingerprint: req.RequestFingerprint,
+		})
+
+		if err != nil {
+			// The frontend is likely unreachable, so there's no point in trying with the other requests.
+			level.Warn(s.log).Log("msg", "failed to ask frontend to requeue request", "frontend", frontendAddress, "queryID", req.QueryID, "err", err)
+			return
+		}
+	}
+}
@@ -61,6 +61,9 @@ type Scheduler struct {
 	// The ring is optional.
 	schedulerLifecycler *ring.BasicLifecycler
 
+	// Queue state persisted before the last restart, loaded at startup.
+	recoveredQueueState *queueState
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -94,6 +97,9 @@ type Config struct {
 	QuerierForgetDelay      time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
 	GRPCClientConfig        grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
 	ServiceDiscovery        schedulerdiscovery.Config `yaml:",inline"`
+	QueueStateFile          string                    `yaml:"queue_state_file" category:"experimental"`
+
+	MaxSkippedLowerPriorityRequests int `yaml:"max_skipped_lower_priority_requests" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
@@ -101,6 +107,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 	cfg.ServiceDiscovery.RegisterFlags(f, logger)
+	f.StringVar(&cfg.QueueStateFile, "query-scheduler.queue-state-file", "", "Path to the file where the query-scheduler periodically persists the metadata of the queued requests. After a restart, the query-scheduler reads the file and asks the query-frontends to enqueue those requests again, instead of letting them fail. The file should be stored on a persistent volume. If empty, the queue state is not persisted.")
+	f.IntVar(&cfg.MaxSkippedLowerPriorityRequests, "query-scheduler.max-skipped-lower-priority-requests", 10, "Maximum number of consecutive times the queued requests of a tenant with a lower priority can be skipped in favor of the requests of the tenant with a higher priority, before one of them is dequeued. This prevents the starvation of the lower priority requests. 0 to always dequeue the higher priority requests first.")
 }
 
 func (cfg *Config) Validate() error {
@@ -134,7 +142,7 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "cortex_query_scheduler_discarded_requests_total",
 		Help: "Total number of query requests discarded.",
 	}, []string{"user"})
-	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests)
+	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.MaxSkippedLowerPriorityRequests, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests)
 
 	s.queueDuration = promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_query_scheduler_queue_duration_seconds",
@@ -184,6 +192,9 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 type Limits interface {
 	// MaxQueriersPerUser returns max queriers to use per tenant, or 0 if shuffle sharding is disabled.
 	MaxQueriersPerUser(user string) int
+
+	// DefaultQueryPriority returns the priority of the queries of the tenant which don't set it explicitly.
+	DefaultQueryPriority(user string) string
 }
 
 type schedulerRequest struct {
@@ -195,6 +206,9 @@ type schedulerRequest struct {
 
 	enqueueTime time.Time
 
+	// Whether the request has been dispatched to a querier. Guarded by Scheduler.pendingRequestsMu.
+	dispatched bool
+
 	ctx       context.Context
 	ctxCancel context.CancelFunc
 	queueSpan opentracing.Span
@@ -337,6 +351,10 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 
 	req.parentSpanContext = parentSpanContext
 	req.queueSpan, req.ctx = opentracing.StartSpanFromContextWithTracer(ctx, tracer, "queued", opentracing.ChildOf(parentSpanContext))
+	if source := util.GrafanaSourceFromHTTPGRPCRequest(msg.HttpRequest); !source.IsEmpty() {
+		req.queueSpan.SetTag("dashboard_uid", source.DashboardUID)
+		req.queueSpan.SetTag("panel_id", source.PanelID)
+	}
 	req.enqueueTime = now
 	req.ctxCancel = cancel
 
@@ -347,8 +365,11 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 	}
 	maxQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxQueriersPerUser)
 
+	priority := s.queryPriority(tenantIDs, msg.HttpRequest)
+	req.queueSpan.SetTag("priority", priority.String())
+
 	s.activeUsers.UpdateUserTimestamp(userID, now)
-	return s.requestQueue.EnqueueRequest(userID, req, maxQueriers, func() {
+	return s.requestQueue.EnqueueRequest(userID, req, priority, maxQueriers, func() {
 		shouldCancel = false
 
 		s.pendingRequestsMu.Lock()
@@ -357,6 +378,26 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 	})
 }
 
+// queryPriority returns the priority of the request, which is set by its header or, if missing or invalid,
+// by the default priority of the tenants. In the case of a multi tenant query, the lowest default priority applies.
+func (s *Scheduler) queryPriority(tenantIDs []string, req *httpgrpc.HTTPRequest) queue.Priority {
+	if priority, err := queue.ParsePriority(util.QueryPriorityFromHTTPGRPCRequest(req)); err == nil {
+		return priority
+	}
+
+	result := queue.PriorityHigh
+	for _, tenantID := range tenantIDs {
+		priority, err := queue.ParsePriority(s.limits.DefaultQueryPriority(tenantID))
+		if err != nil {
+			priority = queue.PriorityNormal
+		}
+		if priority < result {
+			result = priority
+		}
+	}
+	return result
+}
+
 // This method doesn't do removal from the queue.
 func (s *Scheduler) cancelRequestAndRemoveFromPending(frontendAddr string, queryID uint64) {
 	s.pendingRequestsMu.Lock()
@@ -442,6 +483,10 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 	// Make sure to cancel request at the end to cleanup resources.
 	defer s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
 
+	s.pendingRequestsMu.Lock()
+	req.dispatched = true
+	s.pendingRequestsMu.Unlock()
+
 	// Handle the stream sending & receiving on a goroutine so we can
 	// monitoring the contexts in a select and cancel things appropriately.
 	errCh := make(chan error, 1)
@@ -535,6 +580,8 @@ func (s *Scheduler) starting(ctx context.Context) error {
 		return errors.Wrap(err, "unable to start scheduler subservices")
 	}
 
+	s.recoveredQueueState = s.loadQueueState()
+
 	return nil
 }
 
@@ -546,6 +593,17 @@ func (s *Scheduler) running(ctx context.Context) error {
 	inflightRequestsTicker := time.NewTicker(250 * time.Millisecond)
 	defer inflightRequestsTicker.Stop()
 
+	// The ticker channel is nil (never fires) if the queue state persistence is disabled.
+	var queueStateSyncTickerChan <-chan time.Time
+	if s.cfg.QueueStateFile != "" {
+		queueStateSyncTicker := time.NewTicker(queueStateSyncPeriod)
+		defer queueStateSyncTicker.Stop()
+		queueStateSyncTickerChan = queueStateSyncTicker.C
+	}
+
+	// Ask the query-frontends to enqueue again the requests which were queued before the restart.
+	go s.requeueRecoveredRequests(ctx, s.recoveredQueueState)
+
 	for {
 		select {
 		case <-inflightRequestsTicker.C:
@@ -554,6 +612,8 @@ func (s *Scheduler) running(ctx context.Context) error {
 			s.pendingRequestsMu.Unlock()
 
 			s.inflightRequests.Observe(float64(inflight))
+		case <-queueStateSyncTickerChan:
+			s.syncQueueState()
 		case <-ctx.Done():
 			return nil
 		case err := <-s.subservicesWatcher.Chan():
@@ -565,7 +625,13 @@ func (s *Scheduler) running(ctx context.Context) error {
 // Close the Scheduler.
 func (s *Scheduler) stopping(_ error) error {
 	// This will also stop the requests queue, which stop accepting new requests and errors out any pending requests.
-	return services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
+	err := services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
+
+	// Persist the requests which have not been dispatched to queriers before shutting down,
+	// so that they can be enqueued again once the query-scheduler is restarted.
+	s.syncQueueState()
+
+	return err
 }
 
 func (s *Scheduler) cleanupMetricsForInactiveUser(user string) {
@@ -606,3 +672,20 @@ func (s *Scheduler) RingHandler(w http.ResponseWriter, req *http.Request) {
 		</html>`
 	util.WriteHTMLResponse(w, ringDisabledPage)
 }
+
+type queuesStatus struct {
+	Now                      time.Time               `json:"now"`
+	ConnectedFrontendClients int                     `json:"connected_frontend_clients"`
+	ConnectedQuerierWorkers  int                     `json:"connected_querier_workers"`
+	Tenants                  []queue.UserQueueStatus `json:"tenants"`
+}
+
+// QueuesHandler returns the status of the per-tenant queues as JSON.
+func (s *Scheduler) QueuesHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, queuesStatus{
+		Now:                      time.Now(),
+		ConnectedFrontendClients: int(s.getConnectedFrontendClientsMetric()),
+		ConnectedQuerierWorkers:  int(s.requestQueue.GetConnectedQuerierWorkersMetric()),
+		Tenants:                  s.requestQueue.GetUserQueuesStatus(),
+	})
+}
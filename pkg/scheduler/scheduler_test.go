@@ -7,9 +7,13 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -29,16 +33,23 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
+	"github.com/grafana/mimir/pkg/scheduler/queue"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
 const testMaxOutstandingPerTenant = 5
 
 func setupScheduler(t *testing.T, reg prometheus.Registerer) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
+	return setupSchedulerWithConfig(t, reg, func(*Config) {})
+}
+
+func setupSchedulerWithConfig(t *testing.T, reg prometheus.Registerer, cfgFn func(*Config)) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
 	cfg := Config{}
 	flagext.DefaultValues(&cfg)
 	cfg.MaxOutstandingPerTenant = testMaxOutstandingPerTenant
+	cfgFn(&cfg)
 
 	s, err := NewScheduler(cfg, &limits{queriers: 2}, log.NewNopLogger(), reg)
 	require.NoError(t, err)
@@ -354,27 +365,7 @@ func TestSchedulerMaxOutstandingRequests(t *testing.T) {
 func TestSchedulerForwardsErrorToFrontend(t *testing.T) {
 	_, frontendClient, querierClient := setupScheduler(t, nil)
 
-	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
-	frontendAddress := ""
-
-	// Setup frontend grpc server
-	{
-		frontendGrpcServer := grpc.NewServer()
-		frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
-
-		l, err := net.Listen("tcp", "")
-		require.NoError(t, err)
-
-		frontendAddress = l.Addr().String()
-
-		go func() {
-			_ = frontendGrpcServer.Serve(l)
-		}()
-
-		t.Cleanup(func() {
-			_ = l.Close()
-		})
-	}
+	fm, frontendAddress := setupFrontendMock(t)
 
 	// After preparations, start frontend and querier.
 	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
@@ -411,6 +402,93 @@ func TestSchedulerForwardsErrorToFrontend(t *testing.T) {
 	})
 }
 
+func TestSchedulerRequeuesQueuedRequestsAfterRestart(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "queue-state.json")
+	cfgFn := func(cfg *Config) {
+		cfg.QueueStateFile = stateFile
+	}
+
+	fm, frontendAddress := setupFrontendMock(t)
+
+	// Enqueue some requests, without any querier connected.
+	scheduler, frontendClient, _ := setupSchedulerWithConfig(t, nil, cfgFn)
+	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
+
+	reqs := map[uint64]*httpgrpc.HTTPRequest{
+		1: {Method: "GET", Url: "/hello"},
+		2: {Method: "POST", Url: "/world", Body: []byte("query=up")},
+	}
+	for queryID, req := range reqs {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     queryID,
+			UserID:      "test",
+			HttpRequest: req,
+		})
+	}
+
+	// Stopping the query-scheduler should persist the queue state.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), scheduler))
+
+	state, err := readQueueStateFile(stateFile)
+	require.NoError(t, err)
+	require.Equal(t, &queueState{
+		Version: queueStateVersion1,
+		Requests: []queuedRequestState{
+			{FrontendAddress: frontendAddress, UserID: "test", QueryID: 1, RequestFingerprint: schedulerpb.RequestFingerprint(reqs[1])},
+			{FrontendAddress: frontendAddress, UserID: "test", QueryID: 2, RequestFingerprint: schedulerpb.RequestFingerprint(reqs[2])},
+		},
+	}, state)
+
+	// The restarted query-scheduler should ask the frontend to requeue the requests, and remove the state file.
+	setupSchedulerWithConfig(t, nil, cfgFn)
+
+	test.Poll(t, 2*time.Second, 2, func() interface{} {
+		return len(fm.getRequeued())
+	})
+	require.Equal(t, map[uint64]uint64{
+		1: schedulerpb.RequestFingerprint(reqs[1]),
+		2: schedulerpb.RequestFingerprint(reqs[2]),
+	}, fm.getRequeued())
+
+	_, err = os.Stat(stateFile)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestScheduler_getQueueState(t *testing.T) {
+	scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-1")
+	for queryID := uint64(1); queryID <= 2; queryID++ {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     queryID,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: fmt.Sprintf("/%d", queryID)},
+		})
+	}
+
+	require.Len(t, scheduler.getQueueState().Requests, 2)
+
+	// Requests dispatched to a querier should not be part of the queue state.
+	querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+	msg, err := querierLoop.Recv()
+	require.NoError(t, err)
+
+	state := scheduler.getQueueState()
+	require.Len(t, state.Requests, 1)
+	require.NotEqual(t, msg.QueryID, state.Requests[0].QueryID)
+
+	// Complete all requests.
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+	_, err = querierLoop.Recv()
+	require.NoError(t, err)
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+	verifyNoPendingRequestsLeft(t, scheduler)
+	require.Empty(t, scheduler.getQueueState().Requests)
+}
+
 func TestSchedulerMetrics(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 
@@ -446,6 +524,38 @@ func TestSchedulerMetrics(t *testing.T) {
 	`), "cortex_query_scheduler_queue_length"))
 }
 
+func TestSchedulerQueuesHandler(t *testing.T) {
+	scheduler, frontendClient, _ := setupScheduler(t, nil)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     0,
+		UserID:      "test",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+	})
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "test",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello", Headers: []*httpgrpc.Header{{Key: util.QueryPriorityHeader, Values: []string{"high"}}}},
+	})
+
+	rec := httptest.NewRecorder()
+	scheduler.QueuesHandler(rec, httptest.NewRequest(http.MethodGet, "/query-scheduler/queues", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status queuesStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Equal(t, 1, status.ConnectedFrontendClients)
+	require.Equal(t, []queue.UserQueueStatus{{
+		UserID:           "test",
+		Length:           2,
+		LengthByPriority: map[string]int{"low": 0, "normal": 1, "high": 1},
+		MaxQueriers:      2,
+	}}, status.Tenants)
+}
+
 func initFrontendLoop(t *testing.T, client schedulerpb.SchedulerForFrontendClient, frontendAddr string) schedulerpb.SchedulerForFrontend_FrontendLoopClient {
 	loop, err := client.FrontendLoop(context.Background())
 	require.NoError(t, err)
@@ -507,9 +617,34 @@ func (l limits) MaxQueriersPerUser(_ string) int {
 	return l.queriers
 }
 
+func (l limits) DefaultQueryPriority(_ string) string {
+	return "normal"
+}
+
+func setupFrontendMock(t *testing.T) (*frontendMock, string) {
+	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}, requeued: map[uint64]uint64{}}
+
+	frontendGrpcServer := grpc.NewServer()
+	frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
+
+	l, err := net.Listen("tcp", "")
+	require.NoError(t, err)
+
+	go func() {
+		_ = frontendGrpcServer.Serve(l)
+	}()
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	return fm, l.Addr().String()
+}
+
 type frontendMock struct {
-	mu   sync.Mutex
-	resp map[uint64]*httpgrpc.HTTPResponse
+	mu       sync.Mutex
+	resp     map[uint64]*httpgrpc.HTTPResponse
+	requeued map[uint64]uint64
 }
 
 func (f *frontendMock) QueryResult(_ context.Context, request *frontendv2pb.QueryResultRequest) (*frontendv2pb.QueryResultResponse, error) {
@@ -526,3 +661,22 @@ func (f *frontendMock) getRequest(queryID uint64) *httpgrpc.HTTPResponse {
 
 	return f.resp[queryID]
 }
+
+func (f *frontendMock) RequeueQuery(_ context.Context, request *frontendv2pb.RequeueQueryRequest) (*frontendv2pb.RequeueQueryResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requeued[request.QueryID] = request.RequestFingerprint
+	return &frontendv2pb.RequeueQueryResponse{}, nil
+}
+
+func (f *frontendMock) getRequeued() map[uint64]uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make(map[uint64]uint64, len(f.requeued))
+	for queryID, fingerprint := range f.requeued {
+		res[queryID] = fingerprint
+	}
+	return res
+}
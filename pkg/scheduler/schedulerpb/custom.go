@@ -2,8 +2,29 @@
 
 package schedulerpb
 
-import "github.com/pkg/errors"
+import (
+	"hash/fnv"
+
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/httpgrpc"
+)
 
 var (
 	ErrSchedulerIsNotRunning = errors.New("scheduler is not running")
 )
+
+// RequestFingerprint returns a fingerprint of the input HTTP request. It's used by the query-scheduler
+// and query-frontend to check whether a queryID still refers to the same request.
+func RequestFingerprint(req *httpgrpc.HTTPRequest) uint64 {
+	sep := []byte{0}
+
+	// Hasher never returns err.
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(req.GetMethod()))
+	_, _ = hasher.Write(sep)
+	_, _ = hasher.Write([]byte(req.GetUrl()))
+	_, _ = hasher.Write(sep)
+	_, _ = hasher.Write(req.GetBody())
+
+	return hasher.Sum64()
+}
@@ -30,5 +30,17 @@ func NewBucketClient(cfg Config, name string, logger log.Logger) (objstore.Bucke
 		return nil, err
 	}
 
-	return azure.NewBucket(logger, serialized, name)
+	bkt, err := azure.NewBucket(logger, serialized, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Thanos client doesn't expose its container client, so we create our own one
+	// for the operations which need to be aware of soft-deleted blobs and blob versions.
+	containerClient, err := newContainerClient(bucketConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSoftDeleteAwareBucket(bkt, containerClient, cfg.PurgeVersionsOnDelete, logger), nil
 }
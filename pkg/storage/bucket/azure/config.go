@@ -21,6 +21,8 @@ type Config struct {
 	MaxRetries         int            `yaml:"max_retries" category:"advanced"`
 	MSIResource        string         `yaml:"msi_resource" category:"advanced" doc:"hidden"` // TODO Remove in Mimir 2.7.
 	UserAssignedID     string         `yaml:"user_assigned_id" category:"advanced"`
+
+	PurgeVersionsOnDelete bool `yaml:"purge_versions_on_delete" category:"experimental"`
 }
 
 // RegisterFlags registers the flags for Azure storage
@@ -37,4 +39,5 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet, logge
 	f.IntVar(&cfg.MaxRetries, prefix+"azure.max-retries", 20, "Number of retries for recoverable errors")
 	flagext.DeprecatedFlag(f, prefix+"azure.msi-resource", "Deprecated: this setting was used for obtaining ServicePrincipalToken from MSI. The Azure SDK now chooses the address.", logger)
 	f.StringVar(&cfg.UserAssignedID, prefix+"azure.user-assigned-id", "", "User assigned identity. If empty, then System assigned identity is used.")
+	f.BoolVar(&cfg.PurgeVersionsOnDelete, prefix+"azure.purge-versions-on-delete", false, "If enabled, deleting an object also deletes its previous versions, when blob versioning is enabled on the storage account. The versions are not deleted if the identity is not allowed to delete blob versions. Deleted versions are still retained for the soft-delete retention period, if soft-delete is enabled.")
 }
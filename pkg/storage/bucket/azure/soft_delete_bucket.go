@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/objstore/blob/main/providers/azure/azure.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/objstore/exthttp"
+	"github.com/thanos-io/objstore/providers/azure"
+)
+
+const (
+	// dirDelim is the delimiter used to model a directory structure in an object store bucket.
+	dirDelim = "/"
+
+	defaultEndpoint = "blob.core.windows.net"
+)
+
+// softDeleteAwareBucket wraps the Thanos Azure client to work with containers having soft-delete
+// or blob versioning enabled: the deleted blobs and the previous versions of the blobs are never
+// listed, and operations on deleted blobs consistently fail with a not-found error. Optionally,
+// the previous versions of a blob are deleted too when the blob is deleted.
+type softDeleteAwareBucket struct {
+	objstore.Bucket
+
+	logger                log.Logger
+	containerClient       *azblob.ContainerClient
+	purgeVersionsOnDelete bool
+}
+
+func newSoftDeleteAwareBucket(bkt objstore.Bucket, containerClient *azblob.ContainerClient, purgeVersionsOnDelete bool, logger log.Logger) *softDeleteAwareBucket {
+	return &softDeleteAwareBucket{
+		Bucket:                bkt,
+		logger:                logger,
+		containerClient:       containerClient,
+		purgeVersionsOnDelete: purgeVersionsOnDelete,
+	}
+}
+
+// Iter calls f for each entry in the given directory, skipping deleted blobs and previous versions
+// of blobs. The argument to f is the full object name including the prefix of the inspected directory.
+func (b *softDeleteAwareBucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	prefix := dir
+	if prefix != "" && !strings.HasSuffix(prefix, dirDelim) {
+		prefix += dirDelim
+	}
+	params := objstore.ApplyIterOptions(options...)
+
+	if params.Recursive {
+		pager := b.containerClient.ListBlobsFlat(&azblob.ContainerListBlobsFlatOptions{Prefix: &prefix})
+		for pager.NextPage(ctx) {
+			for _, blob := range pager.PageResponse().Segment.BlobItems {
+				if !isLiveBlob(blob) {
+					continue
+				}
+				if err := f(*blob.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return pager.Err()
+	}
+
+	pager := b.containerClient.ListBlobsHierarchy(dirDelim, &azblob.ContainerListBlobsHierarchyOptions{Prefix: &prefix})
+	for pager.NextPage(ctx) {
+		resp := pager.PageResponse()
+		for _, blob := range resp.Segment.BlobItems {
+			if !isLiveBlob(blob) {
+				continue
+			}
+			if err := f(*blob.Name); err != nil {
+				return err
+			}
+		}
+		for _, blobPrefix := range resp.Segment.BlobPrefixes {
+			if err := f(*blobPrefix.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return pager.Err()
+}
+
+// isLiveBlob returns whether the listed blob is the current version of a blob which hasn't been deleted.
+func isLiveBlob(blob *azblob.BlobItemInternal) bool {
+	if blob.Name == nil {
+		return false
+	}
+	if blob.Deleted != nil && *blob.Deleted {
+		return false
+	}
+	if blob.IsCurrentVersion != nil && !*blob.IsCurrentVersion {
+		return false
+	}
+	if blob.HasVersionsOnly != nil && *blob.HasVersionsOnly {
+		return false
+	}
+	return true
+}
+
+// Exists checks if the given object exists. Soft-deleted blobs and blobs whose current version
+// has been deleted don't exist.
+func (b *softDeleteAwareBucket) Exists(ctx context.Context, name string) (bool, error) {
+	level.Debug(b.logger).Log("msg", "checking if blob exists", "blob", name)
+	blobClient, err := b.containerClient.NewBlobClient(name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := blobClient.GetProperties(ctx, nil); err != nil {
+		if b.IsObjNotFoundErr(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "cannot get properties for Azure blob, address: %s", name)
+	}
+	return true, nil
+}
+
+// Delete removes the object with the given name. If the blob doesn't exist, because it has never
+// been created or it has already been deleted, the returned error is a not-found error. If versions
+// purging is enabled, the previous versions of the blob are deleted too, even if the blob doesn't exist.
+func (b *softDeleteAwareBucket) Delete(ctx context.Context, name string) error {
+	level.Debug(b.logger).Log("msg", "deleting blob", "blob", name)
+	blobClient, err := b.containerClient.NewBlobClient(name)
+	if err != nil {
+		return err
+	}
+
+	_, deleteErr := blobClient.Delete(ctx, &azblob.BlobDeleteOptions{
+		DeleteSnapshots: azblob.DeleteSnapshotsOptionTypeInclude.ToPtr(),
+	})
+	if deleteErr != nil && !b.IsObjNotFoundErr(deleteErr) {
+		return errors.Wrapf(deleteErr, "error deleting blob, address: %s", name)
+	}
+
+	// The previous versions are deleted after the current one, because deleting
+	// the current version turns it into a previous version.
+	if b.purgeVersionsOnDelete {
+		if err := b.deletePreviousVersions(ctx, blobClient, name); err != nil {
+			return err
+		}
+	}
+
+	if deleteErr != nil {
+		return errors.Wrapf(deleteErr, "error deleting blob, address: %s", name)
+	}
+	return nil
+}
+
+func (b *softDeleteAwareBucket) deletePreviousVersions(ctx context.Context, blobClient *azblob.BlobClient, name string) error {
+	pager := b.containerClient.ListBlobsFlat(&azblob.ContainerListBlobsFlatOptions{
+		Prefix:  &name,
+		Include: []azblob.ListBlobsIncludeItem{azblob.ListBlobsIncludeItemVersions},
+	})
+
+	for pager.NextPage(ctx) {
+		for _, blob := range pager.PageResponse().Segment.BlobItems {
+			// The prefix may match other blobs too.
+			if blob.Name == nil || *blob.Name != name || blob.VersionID == nil {
+				continue
+			}
+			if blob.IsCurrentVersion != nil && *blob.IsCurrentVersion {
+				continue
+			}
+
+			versionClient, err := blobClient.WithVersionID(*blob.VersionID)
+			if err != nil {
+				return err
+			}
+
+			_, err = versionClient.Delete(ctx, nil)
+			switch {
+			case err == nil || b.IsObjNotFoundErr(err):
+			case isPermissionDeniedErr(err):
+				level.Warn(b.logger).Log("msg", "not allowed to delete the previous versions of the blob, skipping", "blob", name, "err", err)
+				return nil
+			default:
+				return errors.Wrapf(err, "error deleting blob version %s, address: %s", *blob.VersionID, name)
+			}
+		}
+	}
+
+	return errors.Wrapf(pager.Err(), "error listing blob versions, address: %s", name)
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *softDeleteAwareBucket) IsObjNotFoundErr(err error) bool {
+	storageErr := &azblob.StorageError{}
+	if err == nil || !errors.As(err, &storageErr) {
+		return false
+	}
+
+	switch storageErr.ErrorCode {
+	case azblob.StorageErrorCodeBlobNotFound, azblob.StorageErrorCodeInvalidURI, azblob.StorageErrorCodeResourceNotFound:
+		return true
+	case "":
+		// The responses to HEAD requests have no body, so the error code may be missing.
+		return storageErr.Response() != nil && storageErr.Response().StatusCode == http.StatusNotFound
+	default:
+		return false
+	}
+}
+
+func isPermissionDeniedErr(err error) bool {
+	storageErr := &azblob.StorageError{}
+	if !errors.As(err, &storageErr) {
+		return false
+	}
+	return storageErr.Response() != nil && storageErr.Response().StatusCode == http.StatusForbidden
+}
+
+// newContainerClient creates a client for the container configured in conf, applying the same defaults
+// and authentication logic of the Thanos client.
+func newContainerClient(conf azure.Config) (*azblob.ContainerClient, error) {
+	if conf.Endpoint == "" {
+		conf.Endpoint = defaultEndpoint
+	}
+	if conf.MaxRetries > 0 && conf.PipelineConfig.MaxTries == 0 {
+		conf.PipelineConfig.MaxTries = int32(conf.MaxRetries)
+	}
+
+	dt, err := exthttp.DefaultTransport(conf.HTTPConfig)
+	if err != nil {
+		return nil, err
+	}
+	opt := &azblob.ClientOptions{
+		Retry: policy.RetryOptions{
+			MaxRetries:    conf.PipelineConfig.MaxTries,
+			TryTimeout:    time.Duration(conf.PipelineConfig.TryTimeout),
+			RetryDelay:    time.Duration(conf.PipelineConfig.RetryDelay),
+			MaxRetryDelay: time.Duration(conf.PipelineConfig.MaxRetryDelay),
+		},
+		Telemetry: policy.TelemetryOptions{
+			ApplicationID: "Thanos",
+		},
+		Transport: &http.Client{Transport: dt},
+	}
+	containerURL := fmt.Sprintf("https://%s.%s/%s", conf.StorageAccountName, conf.Endpoint, conf.ContainerName)
+
+	// Use shared keys if set.
+	if conf.StorageAccountKey != "" {
+		cred, err := azblob.NewSharedKeyCredential(conf.StorageAccountName, conf.StorageAccountKey)
+		if err != nil {
+			return nil, err
+		}
+		return azblob.NewContainerClientWithSharedKey(containerURL, cred, opt)
+	}
+
+	// Use MSI for authentication.
+	msiOpt := &azidentity.ManagedIdentityCredentialOptions{}
+	if conf.UserAssignedID != "" {
+		msiOpt.ID = azidentity.ClientID(conf.UserAssignedID)
+	}
+	cred, err := azidentity.NewManagedIdentityCredential(msiOpt)
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewContainerClient(containerURL, cred, opt)
+}
@@ -0,0 +1,289 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package azure
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestSoftDeleteAwareBucket_Iter(t *testing.T) {
+	server := newVersionedContainerServer()
+	server.addBlob("dir/live", "v1", true, false)
+	server.addBlob("dir/live", "v0", false, false)
+	server.addBlob("dir/soft-deleted", "v1", true, true)
+	server.addBlob("dir/versions-only", "v1", false, false)
+	server.addBlob("dir/sub/live", "v1", true, false)
+
+	bkt := newTestSoftDeleteAwareBucket(t, server, false)
+
+	t.Run("recursive", func(t *testing.T) {
+		var names []string
+		require.NoError(t, bkt.Iter(context.Background(), "dir", func(name string) error {
+			names = append(names, name)
+			return nil
+		}, objstore.WithRecursiveIter))
+
+		assert.Equal(t, []string{"dir/live", "dir/sub/live"}, names)
+	})
+
+	t.Run("not recursive", func(t *testing.T) {
+		var names []string
+		require.NoError(t, bkt.Iter(context.Background(), "dir", func(name string) error {
+			names = append(names, name)
+			return nil
+		}))
+
+		assert.Equal(t, []string{"dir/live", "dir/sub/"}, names)
+	})
+}
+
+func TestSoftDeleteAwareBucket_Exists(t *testing.T) {
+	server := newVersionedContainerServer()
+	server.addBlob("live", "v1", true, false)
+	server.addBlob("soft-deleted", "v1", true, true)
+	server.addBlob("versions-only", "v1", false, false)
+
+	bkt := newTestSoftDeleteAwareBucket(t, server, false)
+
+	for name, expected := range map[string]bool{"live": true, "soft-deleted": false, "versions-only": false, "missing": false} {
+		exists, err := bkt.Exists(context.Background(), name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exists, name)
+	}
+}
+
+func TestSoftDeleteAwareBucket_Delete(t *testing.T) {
+	t.Run("should return a not-found error when deleting a blob which doesn't exist", func(t *testing.T) {
+		server := newVersionedContainerServer()
+		server.addBlob("versions-only", "v1", false, false)
+
+		bkt := newTestSoftDeleteAwareBucket(t, server, false)
+
+		for _, name := range []string{"missing", "versions-only"} {
+			err := bkt.Delete(context.Background(), name)
+			require.Error(t, err)
+			assert.True(t, bkt.IsObjNotFoundErr(err), name)
+		}
+	})
+
+	t.Run("should keep the previous versions if versions purging is disabled", func(t *testing.T) {
+		server := newVersionedContainerServer()
+		server.addBlob("blob", "v1", true, false)
+		server.addBlob("blob", "v0", false, false)
+
+		bkt := newTestSoftDeleteAwareBucket(t, server, false)
+		require.NoError(t, bkt.Delete(context.Background(), "blob"))
+
+		exists, err := bkt.Exists(context.Background(), "blob")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.Equal(t, []string{"v0", "v1"}, server.versions("blob"))
+	})
+
+	t.Run("should delete the previous versions if versions purging is enabled", func(t *testing.T) {
+		server := newVersionedContainerServer()
+		server.addBlob("blob", "v1", true, false)
+		server.addBlob("blob", "v0", false, false)
+		server.addBlob("blob-other", "v1", true, false)
+
+		bkt := newTestSoftDeleteAwareBucket(t, server, true)
+		require.NoError(t, bkt.Delete(context.Background(), "blob"))
+
+		assert.Empty(t, server.versions("blob"))
+		assert.Equal(t, []string{"v1"}, server.versions("blob-other"))
+	})
+
+	t.Run("should delete the previous versions of a blob which doesn't exist anymore if versions purging is enabled", func(t *testing.T) {
+		server := newVersionedContainerServer()
+		server.addBlob("blob", "v0", false, false)
+
+		bkt := newTestSoftDeleteAwareBucket(t, server, true)
+		err := bkt.Delete(context.Background(), "blob")
+		require.Error(t, err)
+		assert.True(t, bkt.IsObjNotFoundErr(err))
+		assert.Empty(t, server.versions("blob"))
+	})
+
+	t.Run("should skip versions purging if not allowed to delete versions", func(t *testing.T) {
+		server := newVersionedContainerServer()
+		server.addBlob("blob", "v1", true, false)
+		server.addBlob("blob", "v0", false, false)
+		server.denyVersionsDeletion = true
+
+		bkt := newTestSoftDeleteAwareBucket(t, server, true)
+		require.NoError(t, bkt.Delete(context.Background(), "blob"))
+		assert.Equal(t, []string{"v0", "v1"}, server.versions("blob"))
+	})
+}
+
+func newTestSoftDeleteAwareBucket(t *testing.T, server *versionedContainerServer, purgeVersionsOnDelete bool) *softDeleteAwareBucket {
+	srv := httptest.NewTLSServer(server)
+	t.Cleanup(srv.Close)
+
+	containerClient, err := azblob.NewContainerClientWithNoCredential(srv.URL+"/container", &azblob.ClientOptions{
+		Transport: srv.Client(),
+		Retry:     policy.RetryOptions{MaxRetries: -1},
+	})
+	require.NoError(t, err)
+
+	return newSoftDeleteAwareBucket(objstore.NewInMemBucket(), containerClient, purgeVersionsOnDelete, log.NewNopLogger())
+}
+
+type blobVersion struct {
+	name      string
+	versionID string
+	current   bool
+	deleted   bool
+}
+
+// versionedContainerServer is a minimal fake of the Azure Blob Storage API for a container with blob
+// versioning and soft-delete enabled. Listings always include the deleted blobs and the previous versions.
+type versionedContainerServer struct {
+	mtx                  sync.Mutex
+	blobs                []*blobVersion
+	nextVersion          int
+	denyVersionsDeletion bool
+}
+
+func newVersionedContainerServer() *versionedContainerServer {
+	return &versionedContainerServer{}
+}
+
+func (s *versionedContainerServer) addBlob(name, versionID string, current, deleted bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.blobs = append(s.blobs, &blobVersion{name: name, versionID: versionID, current: current, deleted: deleted})
+}
+
+// versions returns the sorted IDs of the versions of the blob.
+func (s *versionedContainerServer) versions(name string) []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var ids []string
+	for _, b := range s.blobs {
+		if b.name == name {
+			ids = append(ids, b.versionID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *versionedContainerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/container"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+		s.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case r.Method == http.MethodHead:
+		if s.current(name) == nil {
+			// Responses to HEAD requests have no body.
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && r.URL.Query().Get("versionid") != "":
+		if s.denyVersionsDeletion {
+			writeError(w, http.StatusForbidden, azblob.StorageErrorCodeAuthorizationPermissionMismatch)
+			return
+		}
+		versionID := r.URL.Query().Get("versionid")
+		for i, b := range s.blobs {
+			if b.name == name && b.versionID == versionID {
+				s.blobs = append(s.blobs[:i], s.blobs[i+1:]...)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, azblob.StorageErrorCodeBlobNotFound)
+	case r.Method == http.MethodDelete:
+		b := s.current(name)
+		if b == nil {
+			writeError(w, http.StatusNotFound, azblob.StorageErrorCodeBlobNotFound)
+			return
+		}
+		// With versioning enabled, the deleted current version becomes a previous version.
+		b.current = false
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (s *versionedContainerServer) current(name string) *blobVersion {
+	for _, b := range s.blobs {
+		if b.name == name && b.current && !b.deleted {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *versionedContainerServer) list(w http.ResponseWriter, prefix, delimiter string) {
+	type blobPrefix struct {
+		Name string `xml:"Name"`
+	}
+	type blob struct {
+		Name             string `xml:"Name"`
+		VersionID        string `xml:"VersionId"`
+		IsCurrentVersion bool   `xml:"IsCurrentVersion"`
+		Deleted          bool   `xml:"Deleted"`
+	}
+	type response struct {
+		XMLName       xml.Name     `xml:"EnumerationResults"`
+		ContainerName string       `xml:"ContainerName,attr"`
+		Blobs         []blob       `xml:"Blobs>Blob"`
+		BlobPrefixes  []blobPrefix `xml:"Blobs>BlobPrefix"`
+		NextMarker    string       `xml:"NextMarker"`
+	}
+
+	resp := response{ContainerName: "container"}
+	seenPrefixes := map[string]bool{}
+
+	for _, b := range s.blobs {
+		if !strings.HasPrefix(b.name, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if idx := strings.Index(b.name[len(prefix):], delimiter); idx >= 0 {
+				p := b.name[:len(prefix)+idx+1]
+				if !seenPrefixes[p] {
+					seenPrefixes[p] = true
+					resp.BlobPrefixes = append(resp.BlobPrefixes, blobPrefix{Name: p})
+				}
+				continue
+			}
+		}
+		resp.Blobs = append(resp.Blobs, blob{Name: b.name, VersionID: b.versionID, IsCurrentVersion: b.current, Deleted: b.deleted})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_ = xml.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, status int, code azblob.StorageErrorCode) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("x-ms-error-code", string(code))
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><Error><Code>%s</Code><Message>error</Message></Error>`, code)
+}
@@ -112,6 +112,12 @@ func (cfg *StorageBackendConfig) Validate() error {
 		}
 	}
 
+	if cfg.Backend == GCS {
+		if err := cfg.GCS.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -7,15 +7,29 @@ package gcs
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/go-kit/log"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/objstore/providers/gcs"
+	"github.com/thanos-io/objstore/providers/s3"
 	yaml "gopkg.in/yaml.v3"
 )
 
+// defaultXMLAPIEndpoint is the endpoint of the GCS XML API, used for the HMAC interoperability mode.
+const defaultXMLAPIEndpoint = "storage.googleapis.com"
+
 // NewBucketClient creates a new GCS bucket client
 func NewBucketClient(ctx context.Context, cfg Config, name string, logger log.Logger) (objstore.Bucket, error) {
+	if cfg.hmacEnabled() {
+		return newHMACBucketClient(cfg, name, logger)
+	}
+
+	// The Thanos client doesn't support custom endpoints, so we use our own client in such case.
+	if cfg.Endpoint != "" || cfg.SkipAuthentication {
+		return newCustomEndpointBucket(ctx, cfg, name, logger)
+	}
+
 	bucketConfig := gcs.Config{
 		Bucket:         cfg.BucketName,
 		ServiceAccount: cfg.ServiceAccount.String(),
@@ -30,3 +44,39 @@ func NewBucketClient(ctx context.Context, cfg Config, name string, logger log.Lo
 
 	return gcs.NewBucket(ctx, logger, serialized, name)
 }
+
+// newHMACBucketClient creates a client accessing GCS through its interoperability XML API,
+// which is compatible with the S3 API and supports authentication with HMAC keys.
+func newHMACBucketClient(cfg Config, name string, logger log.Logger) (objstore.Bucket, error) {
+	s3Cfg, err := newHMACS3Config(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.NewBucketWithConfig(logger, s3Cfg, name)
+}
+
+func newHMACS3Config(cfg Config) (s3.Config, error) {
+	s3Cfg := s3.DefaultConfig
+	s3Cfg.Bucket = cfg.BucketName
+	s3Cfg.Endpoint = defaultXMLAPIEndpoint
+	s3Cfg.AccessKey = cfg.HMACAccessKeyID
+	s3Cfg.SecretKey = cfg.HMACSecretAccessKey.String()
+	// GCS expects "auto" as region when signing requests with HMAC keys.
+	s3Cfg.Region = "auto"
+	// The XML API doesn't support virtual-hosted-style requests on custom domains.
+	s3Cfg.BucketLookupType = s3.PathLookup
+	s3Cfg.ListObjectsVersion = "v1"
+
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil {
+			return s3.Config{}, err
+		}
+
+		s3Cfg.Endpoint = u.Host
+		s3Cfg.Insecure = u.Scheme == "http"
+	}
+
+	return s3Cfg, nil
+}
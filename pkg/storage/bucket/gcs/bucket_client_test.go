@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gcs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *Config)
+		expected error
+	}{
+		"should pass with default config": {
+			setup: func(cfg *Config) {},
+		},
+		"should pass with a valid custom endpoint": {
+			setup: func(cfg *Config) {
+				cfg.Endpoint = "https://storage.example.com/storage/v1/"
+			},
+		},
+		"should fail with a custom endpoint without scheme": {
+			setup: func(cfg *Config) {
+				cfg.Endpoint = "storage.example.com"
+			},
+			expected: errInvalidEndpoint,
+		},
+		"should fail with a custom endpoint with unsupported scheme": {
+			setup: func(cfg *Config) {
+				cfg.Endpoint = "ftp://storage.example.com"
+			},
+			expected: errInvalidEndpoint,
+		},
+		"should pass with HMAC keys": {
+			setup: func(cfg *Config) {
+				cfg.HMACAccessKeyID = "GOOG1EXAMPLE"
+				cfg.HMACSecretAccessKey = flagext.SecretWithValue("secret")
+			},
+		},
+		"should fail with HMAC access key ID but no secret": {
+			setup: func(cfg *Config) {
+				cfg.HMACAccessKeyID = "GOOG1EXAMPLE"
+			},
+			expected: errMissingHMACSecretAccessKey,
+		},
+		"should fail with HMAC secret but no access key ID": {
+			setup: func(cfg *Config) {
+				cfg.HMACSecretAccessKey = flagext.SecretWithValue("secret")
+			},
+			expected: errMissingHMACAccessKeyID,
+		},
+		"should fail with both HMAC keys and service account": {
+			setup: func(cfg *Config) {
+				cfg.HMACAccessKeyID = "GOOG1EXAMPLE"
+				cfg.HMACSecretAccessKey = flagext.SecretWithValue("secret")
+				cfg.ServiceAccount = flagext.SecretWithValue("{}")
+			},
+			expected: errHMACAndServiceAccountEnabled,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestNewBucketClient_ShouldSendRequestsToCustomEndpoint(t *testing.T) {
+	srv, requests := newRecordingServer(t)
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.BucketName = "test-bucket"
+	cfg.Endpoint = srv.URL + "/storage/v1/"
+	cfg.SkipAuthentication = true
+
+	client, err := NewBucketClient(context.Background(), cfg, "test", log.NewNopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, client.Close()) })
+
+	exists, err := client.Exists(context.Background(), "object")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	assert.True(t, strings.HasPrefix(reqs[0].URL.Path, "/storage/v1/b/test-bucket/o/object"), reqs[0].URL.Path)
+	assert.Empty(t, reqs[0].Header.Get("Authorization"))
+}
+
+func TestNewBucketClient_ShouldAuthenticateWithHMACKeys(t *testing.T) {
+	srv, requests := newRecordingServer(t)
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.BucketName = "test-bucket"
+	cfg.Endpoint = srv.URL
+	cfg.HMACAccessKeyID = "GOOG1EXAMPLE"
+	cfg.HMACSecretAccessKey = flagext.SecretWithValue("secret")
+
+	client, err := NewBucketClient(context.Background(), cfg, "test", log.NewNopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, client.Close()) })
+
+	exists, err := client.Exists(context.Background(), "object")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "/test-bucket/object", reqs[0].URL.Path)
+	assert.Contains(t, reqs[0].Header.Get("Authorization"), "Credential=GOOG1EXAMPLE/")
+}
+
+func TestNewHMACS3Config(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.BucketName = "test-bucket"
+	cfg.HMACAccessKeyID = "GOOG1EXAMPLE"
+	cfg.HMACSecretAccessKey = flagext.SecretWithValue("secret")
+
+	s3Cfg, err := newHMACS3Config(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, defaultXMLAPIEndpoint, s3Cfg.Endpoint)
+	assert.False(t, s3Cfg.Insecure)
+	assert.Equal(t, "test-bucket", s3Cfg.Bucket)
+	assert.Equal(t, "GOOG1EXAMPLE", s3Cfg.AccessKey)
+	assert.Equal(t, "secret", s3Cfg.SecretKey)
+
+	cfg.Endpoint = "http://localhost:9000"
+	s3Cfg, err = newHMACS3Config(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:9000", s3Cfg.Endpoint)
+	assert.True(t, s3Cfg.Insecure)
+}
+
+// newRecordingServer starts an HTTP server replying 404 to every request, and returns a function
+// to get the requests received so far.
+func newRecordingServer(t *testing.T) (*httptest.Server, func() []*http.Request) {
+	var (
+		mtx      sync.Mutex
+		requests []*http.Request
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		mtx.Lock()
+		requests = append(requests, r)
+		mtx.Unlock()
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []*http.Request {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]*http.Request(nil), requests...)
+	}
+}
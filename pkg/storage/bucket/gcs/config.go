@@ -7,14 +7,28 @@ package gcs
 
 import (
 	"flag"
+	"net/url"
 
 	"github.com/grafana/dskit/flagext"
+	"github.com/pkg/errors"
+)
+
+var (
+	errInvalidEndpoint              = errors.New("invalid GCS endpoint, it must be an absolute http:// or https:// URL")
+	errMissingHMACSecretAccessKey   = errors.New("GCS HMAC secret access key is required when the HMAC access key ID is set")
+	errMissingHMACAccessKeyID       = errors.New("GCS HMAC access key ID is required when the HMAC secret access key is set")
+	errHMACAndServiceAccountEnabled = errors.New("GCS HMAC keys and service account are mutually exclusive")
 )
 
 // Config holds the config options for GCS backend
 type Config struct {
 	BucketName     string         `yaml:"bucket_name"`
 	ServiceAccount flagext.Secret `yaml:"service_account" doc:"description_method=GCSServiceAccountLongDescription"`
+
+	Endpoint            string         `yaml:"endpoint" category:"experimental"`
+	SkipAuthentication  bool           `yaml:"skip_authentication" category:"experimental"`
+	HMACAccessKeyID     string         `yaml:"hmac_access_key_id" category:"experimental"`
+	HMACSecretAccessKey flagext.Secret `yaml:"hmac_secret_access_key" category:"experimental"`
 }
 
 // RegisterFlags registers the flags for GCS storage
@@ -26,6 +40,10 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.StringVar(&cfg.BucketName, prefix+"gcs.bucket-name", "", "GCS bucket name")
 	f.Var(&cfg.ServiceAccount, prefix+"gcs.service-account", cfg.GCSServiceAccountShortDescription())
+	f.StringVar(&cfg.Endpoint, prefix+"gcs.endpoint", "", "Custom GCS endpoint URL, for example to connect to a GCS emulator, a proxy or a GCS-compatible storage appliance. The URL must include the scheme. If empty, the default GCS endpoint is used.")
+	f.BoolVar(&cfg.SkipAuthentication, prefix+"gcs.skip-authentication", false, "If enabled, requests to GCS are not authenticated. This is only meant to be used with GCS emulators and test benches.")
+	f.StringVar(&cfg.HMACAccessKeyID, prefix+"gcs.hmac-access-key-id", "", "GCS HMAC access key ID. If set, GCS is accessed through the interoperability (S3-compatible) XML API, authenticating with HMAC keys instead of a service account.")
+	f.Var(&cfg.HMACSecretAccessKey, prefix+"gcs.hmac-secret-access-key", "GCS HMAC secret access key, used together with the HMAC access key ID.")
 }
 
 func (cfg *Config) GCSServiceAccountShortDescription() string {
@@ -39,3 +57,29 @@ func (cfg *Config) GCSServiceAccountLongDescription() string {
 		"\n2. A JSON file in a location known to the gcloud command-line tool: $HOME/.config/gcloud/application_default_credentials.json." +
 		"\n3. On Google Compute Engine it fetches credentials from the metadata server."
 }
+
+// Validate config and returns error on failure
+func (cfg *Config) Validate() error {
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return errInvalidEndpoint
+		}
+	}
+
+	if cfg.HMACAccessKeyID != "" && cfg.HMACSecretAccessKey.String() == "" {
+		return errMissingHMACSecretAccessKey
+	}
+	if cfg.HMACAccessKeyID == "" && cfg.HMACSecretAccessKey.String() != "" {
+		return errMissingHMACAccessKeyID
+	}
+	if cfg.hmacEnabled() && cfg.ServiceAccount.String() != "" {
+		return errHMACAndServiceAccountEnabled
+	}
+
+	return nil
+}
+
+func (cfg *Config) hmacEnabled() bool {
+	return cfg.HMACAccessKeyID != ""
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/objstore/blob/main/providers/gcs/gcs.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/version"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// dirDelim is the delimiter used to model a directory structure in an object store bucket.
+const dirDelim = "/"
+
+// customEndpointBucket implements objstore.Bucket against GCS, or any GCS-compatible storage,
+// exposed at a custom endpoint.
+type customEndpointBucket struct {
+	logger log.Logger
+	bkt    *storage.BucketHandle
+	name   string
+
+	closer io.Closer
+}
+
+func newCustomEndpointBucket(ctx context.Context, cfg Config, component string, logger log.Logger) (*customEndpointBucket, error) {
+	if cfg.BucketName == "" {
+		return nil, errors.New("missing Google Cloud Storage bucket name for stored blocks")
+	}
+
+	var opts []option.ClientOption
+
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+
+	// If ServiceAccount is provided, use them in GCS client, otherwise fallback to Google default logic.
+	if cfg.SkipAuthentication {
+		opts = append(opts, option.WithoutAuthentication())
+	} else if cfg.ServiceAccount.String() != "" {
+		credentials, err := google.CredentialsFromJSON(ctx, []byte(cfg.ServiceAccount.String()), storage.ScopeFullControl)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create credentials from JSON")
+		}
+		opts = append(opts, option.WithCredentials(credentials))
+	}
+
+	opts = append(opts,
+		option.WithUserAgent(fmt.Sprintf("thanos-%s/%s (%s)", component, version.Version, runtime.Version())),
+	)
+
+	gcsClient, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &customEndpointBucket{
+		logger: logger,
+		bkt:    gcsClient.Bucket(cfg.BucketName),
+		closer: gcsClient,
+		name:   cfg.BucketName,
+	}, nil
+}
+
+// Name returns the bucket name.
+func (b *customEndpointBucket) Name() string {
+	return b.name
+}
+
+// Iter calls f for each entry in the given directory. The argument to f is the full
+// object name including the prefix of the inspected directory.
+func (b *customEndpointBucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	// Ensure the object name actually ends with a dir suffix. Otherwise we'll just iterate the
+	// object itself as one prefix item.
+	if dir != "" {
+		dir = strings.TrimSuffix(dir, dirDelim) + dirDelim
+	}
+
+	// If recursive iteration is enabled we should pass an empty delimiter.
+	delimiter := dirDelim
+	if objstore.ApplyIterOptions(options...).Recursive {
+		delimiter = ""
+	}
+
+	it := b.bkt.Objects(ctx, &storage.Query{
+		Prefix:    dir,
+		Delimiter: delimiter,
+	})
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f(attrs.Prefix + attrs.Name); err != nil {
+			return err
+		}
+	}
+}
+
+// Get returns a reader for the given object name.
+func (b *customEndpointBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.bkt.Object(name).NewReader(ctx)
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *customEndpointBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.bkt.Object(name).NewRangeReader(ctx, off, length)
+}
+
+// Attributes returns information about the specified object.
+func (b *customEndpointBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	attrs, err := b.bkt.Object(name).Attrs(ctx)
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         attrs.Size,
+		LastModified: attrs.Updated,
+	}, nil
+}
+
+// Exists checks if the given object exists.
+func (b *customEndpointBucket) Exists(ctx context.Context, name string) (bool, error) {
+	if _, err := b.bkt.Object(name).Attrs(ctx); err == nil {
+		return true, nil
+	} else if err != storage.ErrObjectNotExist {
+		return false, err
+	}
+	return false, nil
+}
+
+// Upload writes the file specified in src to remote GCS location specified as target.
+func (b *customEndpointBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	w := b.bkt.Object(name).NewWriter(ctx)
+
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Delete removes the object with the given name.
+func (b *customEndpointBucket) Delete(ctx context.Context, name string) error {
+	return b.bkt.Object(name).Delete(ctx)
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *customEndpointBucket) IsObjNotFoundErr(err error) bool {
+	return errors.Is(err, storage.ErrObjectNotExist)
+}
+
+func (b *customEndpointBucket) Close() error {
+	return b.closer.Close()
+}
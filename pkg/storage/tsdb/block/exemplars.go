@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+const (
+	// ExemplarsFilename is the known file name of the optional block sidecar file storing the exemplars
+	// of the series in the block.
+	ExemplarsFilename = "exemplars"
+
+	exemplarsMagic         = uint32(0x45584D50)
+	exemplarsFormatV1      = byte(1)
+	exemplarsMaxRecordSize = 16 * 1024 * 1024
+)
+
+// HasExemplars returns whether the block described by meta has been uploaded with an exemplars
+// sidecar file.
+func HasExemplars(meta *metadata.Meta) bool {
+	for _, f := range meta.Thanos.Files {
+		if f.RelPath == ExemplarsFilename {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteExemplarsFile writes the exemplars of the input series to the exemplars sidecar file in
+// blockDir. Series without exemplars are skipped, and no file is written if there are no exemplars
+// at all. Samples of the input series are ignored.
+func WriteExemplarsFile(blockDir string, series []mimirpb.TimeSeries) (err error) {
+	series = SortExemplarsSeries(series)
+	if len(series) == 0 {
+		return nil
+	}
+
+	tmp := filepath.Join(blockDir, ExemplarsFilename+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrap(err, "create exemplars file")
+	}
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header, exemplarsMagic)
+	header[4] = exemplarsFormatV1
+	if _, err := f.Write(header); err != nil {
+		return errors.Wrap(err, "write exemplars file header")
+	}
+
+	w := snappy.NewBufferedWriter(f)
+	var (
+		lenBuf [binary.MaxVarintLen64]byte
+		buf    []byte
+	)
+	for _, s := range series {
+		s.Samples = nil
+
+		size := s.Size()
+		if cap(buf) < size {
+			buf = make([]byte, size)
+		}
+		n, err := s.MarshalToSizedBuffer(buf[:size])
+		if err != nil {
+			return errors.Wrap(err, "encode exemplars")
+		}
+
+		if _, err := w.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(n))]); err != nil {
+			return errors.Wrap(err, "write exemplars")
+		}
+		if _, err := w.Write(buf[:n]); err != nil {
+			return errors.Wrap(err, "write exemplars")
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "write exemplars")
+	}
+	if err := f.Sync(); err != nil {
+		return errors.Wrap(err, "sync exemplars file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "close exemplars file")
+	}
+	f = nil
+
+	return errors.Wrap(os.Rename(tmp, filepath.Join(blockDir, ExemplarsFilename)), "rename exemplars file")
+}
+
+// ReadExemplarsFile reads the exemplars sidecar file from blockDir. If the block has no exemplars file,
+// no series and no error are returned.
+func ReadExemplarsFile(blockDir string) ([]mimirpb.TimeSeries, error) {
+	f, err := os.Open(filepath.Join(blockDir, ExemplarsFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open exemplars file")
+	}
+	defer f.Close()
+
+	return ReadExemplars(f)
+}
+
+// ReadExemplars decodes the content of an exemplars sidecar file. The returned series are sorted by labels.
+func ReadExemplars(r io.Reader) ([]mimirpb.TimeSeries, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.Wrap(err, "read exemplars file header")
+	}
+	if binary.BigEndian.Uint32(header) != exemplarsMagic {
+		return nil, errors.New("invalid exemplars file magic number")
+	}
+	if header[4] != exemplarsFormatV1 {
+		return nil, errors.Errorf("unsupported exemplars file format version %d", header[4])
+	}
+
+	var (
+		br  = bufio.NewReader(snappy.NewReader(r))
+		out []mimirpb.TimeSeries
+	)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "read exemplars")
+		}
+		if size > exemplarsMaxRecordSize {
+			return nil, errors.Errorf("exemplars record size %d exceeds the max allowed size", size)
+		}
+
+		// The labels of the unmarshalled series reference the buffer, so a new one is allocated for each record.
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, errors.Wrap(err, "read exemplars")
+		}
+
+		s := mimirpb.TimeSeries{}
+		if err := s.Unmarshal(buf); err != nil {
+			return nil, errors.Wrap(err, "decode exemplars")
+		}
+		out = append(out, s)
+	}
+}
+
+// SortExemplarsSeries merges the input series with the same labels, sorts their exemplars by timestamp
+// and removes duplicated exemplars. Series without exemplars are removed. The returned series are sorted
+// by labels.
+func SortExemplarsSeries(series []mimirpb.TimeSeries) []mimirpb.TimeSeries {
+	type entry struct {
+		lbls labels.Labels
+		ts   mimirpb.TimeSeries
+	}
+
+	byLabels := map[string]*entry{}
+	for _, s := range series {
+		if len(s.Exemplars) == 0 {
+			continue
+		}
+
+		lbls := mimirpb.FromLabelAdaptersToLabels(s.Labels)
+		key := lbls.String()
+		e, ok := byLabels[key]
+		if !ok {
+			e = &entry{lbls: lbls, ts: mimirpb.TimeSeries{Labels: s.Labels}}
+			byLabels[key] = e
+		}
+		e.ts.Exemplars = append(e.ts.Exemplars, s.Exemplars...)
+	}
+
+	entries := make([]*entry, 0, len(byLabels))
+	for _, e := range byLabels {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return labels.Compare(entries[i].lbls, entries[j].lbls) < 0
+	})
+
+	out := make([]mimirpb.TimeSeries, 0, len(entries))
+	for _, e := range entries {
+		e.ts.Exemplars = dedupeExemplars(e.ts.Exemplars)
+		out = append(out, e.ts)
+	}
+	return out
+}
+
+// dedupeExemplars sorts the input exemplars by timestamp and removes the duplicated ones.
+func dedupeExemplars(exemplars []mimirpb.Exemplar) []mimirpb.Exemplar {
+	sort.SliceStable(exemplars, func(i, j int) bool {
+		return exemplars[i].TimestampMs < exemplars[j].TimestampMs
+	})
+
+	out := exemplars[:0]
+	for _, e := range exemplars {
+		if !containsExemplar(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// containsExemplar returns whether the input exemplar is in the list of exemplars sorted by timestamp,
+// looking only at the tail of the list with the same timestamp.
+func containsExemplar(sorted []mimirpb.Exemplar, e mimirpb.Exemplar) bool {
+	for i := len(sorted) - 1; i >= 0 && sorted[i].TimestampMs == e.TimestampMs; i-- {
+		if isSameExemplar(sorted[i], e) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSameExemplar(a, b mimirpb.Exemplar) bool {
+	if a.TimestampMs != b.TimestampMs || a.Value != b.Value {
+		return false
+	}
+	return labels.Equal(mimirpb.FromLabelAdaptersToLabels(a.Labels), mimirpb.FromLabelAdaptersToLabels(b.Labels))
+}
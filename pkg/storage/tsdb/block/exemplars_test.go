@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestWriteAndReadExemplarsFile(t *testing.T) {
+	var (
+		series1  = []mimirpb.LabelAdapter{{Name: "__name__", Value: "series_1"}}
+		series2  = []mimirpb.LabelAdapter{{Name: "__name__", Value: "series_2"}}
+		series3  = []mimirpb.LabelAdapter{{Name: "__name__", Value: "series_3"}}
+		exemplar = func(traceID string, ts int64) mimirpb.Exemplar {
+			return mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: traceID}}, Value: 1, TimestampMs: ts}
+		}
+	)
+
+	t.Run("should not write the file if there are no exemplars", func(t *testing.T) {
+		dir := t.TempDir()
+
+		require.NoError(t, WriteExemplarsFile(dir, []mimirpb.TimeSeries{{Labels: series1}}))
+
+		_, err := os.Stat(filepath.Join(dir, ExemplarsFilename))
+		require.True(t, os.IsNotExist(err))
+
+		series, err := ReadExemplarsFile(dir)
+		require.NoError(t, err)
+		require.Empty(t, series)
+	})
+
+	t.Run("should merge, sort and dedupe exemplars", func(t *testing.T) {
+		dir := t.TempDir()
+
+		require.NoError(t, WriteExemplarsFile(dir, []mimirpb.TimeSeries{
+			{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("b", 20), exemplar("a", 10)}},
+			{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("c", 30)}},
+			{Labels: series3},
+			{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("a", 10), exemplar("d", 10)}},
+		}))
+
+		series, err := ReadExemplarsFile(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []mimirpb.TimeSeries{
+			{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("c", 30)}},
+			{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("a", 10), exemplar("d", 10), exemplar("b", 20)}},
+		}, series)
+
+		// No temporary file should be left behind.
+		_, err = os.Stat(filepath.Join(dir, ExemplarsFilename+".tmp"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should fail on a corrupted file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ExemplarsFilename), []byte("corrupted"), 0666))
+
+		_, err := ReadExemplarsFile(dir)
+		require.ErrorContains(t, err, "invalid exemplars file magic number")
+	})
+}
@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+)
+
+const (
+	// localCacheAttributesSuffix is the suffix of the file storing the attributes of a locally cached object,
+	// which are used to validate the local copy against the object in the bucket.
+	localCacheAttributesSuffix = ".attributes"
+
+	// localCacheCleanupInterval is how frequently the objects which haven't been validated for
+	// localCacheIdleTimeout are removed from the local cache.
+	localCacheCleanupInterval = time.Hour
+	localCacheIdleTimeout     = 24 * time.Hour
+)
+
+// LocalCachingBucket is an objstore.Bucket keeping a copy of the small objects matching the input matcher on the
+// local disk. A local copy is validated against the attributes (size and last modified time) of the object in the
+// bucket once it has been read without validation for the validation interval, and fetched again if they differ.
+// The uploads of matching objects write through to the local cache, and the deletions remove the local copy.
+type LocalCachingBucket struct {
+	objstore.Bucket
+
+	dir                string
+	matcher            func(name string) bool
+	validationInterval time.Duration
+	maxObjectSize      int64
+	logger             log.Logger
+
+	lastCleanup *atomic.Int64 // Unix timestamp in nanoseconds.
+
+	requests    prometheus.Counter
+	hits        prometheus.Counter
+	validations *prometheus.CounterVec
+}
+
+// NewLocalCachingBucket creates a new LocalCachingBucket, storing the local copies of the objects in dir.
+func NewLocalCachingBucket(b objstore.Bucket, dir string, matcher func(name string) bool, validationInterval time.Duration, maxObjectSize int64, logger log.Logger, reg prometheus.Registerer) (*LocalCachingBucket, error) {
+	if b == nil {
+		return nil, errors.New("bucket is nil")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, errors.Wrap(err, "create local cache directory")
+	}
+
+	cb := &LocalCachingBucket{
+		Bucket:             b,
+		dir:                dir,
+		matcher:            matcher,
+		validationInterval: validationInterval,
+		maxObjectSize:      maxObjectSize,
+		logger:             logger,
+		lastCleanup:        atomic.NewInt64(time.Now().UnixNano()),
+
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_local_cache_requests_total",
+			Help: "Total number of requests for objects which can be cached on the local disk.",
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_local_cache_hits_total",
+			Help: "Total number of requests served from the local disk cache.",
+		}),
+		validations: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_local_cache_validations_total",
+			Help: "Total number of validations of the locally cached objects against the bucket, by result.",
+		}, []string{"result"}),
+	}
+
+	cb.validations.WithLabelValues("valid")
+	cb.validations.WithLabelValues("invalid")
+	cb.validations.WithLabelValues("failed")
+
+	return cb, nil
+}
+
+func (cb *LocalCachingBucket) Name() string {
+	return "local-caching: " + cb.Bucket.Name()
+}
+
+func (cb *LocalCachingBucket) WithExpectedErrs(expectedFunc objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := cb.Bucket.(objstore.InstrumentedBucket); ok {
+		// Make a copy, but replace bucket with instrumented one.
+		res := &LocalCachingBucket{}
+		*res = *cb
+		res.Bucket = ib.WithExpectedErrs(expectedFunc)
+		return res
+	}
+
+	return cb
+}
+
+func (cb *LocalCachingBucket) ReaderWithExpectedErrs(expectedFunc objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return cb.WithExpectedErrs(expectedFunc)
+}
+
+func (cb *LocalCachingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, ok := cb.localPath(name)
+	if !ok {
+		return cb.Bucket.Get(ctx, name)
+	}
+
+	cb.requests.Inc()
+	cb.maybeCleanup()
+
+	// Serve the local copy, if any, as long as it's still valid.
+	if attrs, validatedAt, err := readLocalAttributes(path); err == nil {
+		valid := time.Since(validatedAt) < cb.validationInterval
+		if !valid {
+			valid, err = cb.validate(ctx, name, path, attrs)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if valid {
+			if f, err := os.Open(path); err == nil {
+				cb.hits.Inc()
+				return f, nil
+			}
+		}
+	}
+
+	attrs, err := cb.Bucket.Attributes(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if attrs.Size > cb.maxObjectSize {
+		return cb.Bucket.Get(ctx, name)
+	}
+
+	content, err := cb.getContent(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cb.store(path, content, attrs); err != nil {
+		level.Warn(cb.logger).Log("msg", "failed to store object in the local cache", "name", name, "err", err)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (cb *LocalCachingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	path, ok := cb.localPath(name)
+	if !ok {
+		return cb.Bucket.Upload(ctx, name, r)
+	}
+
+	// Remove the local copy first, so that a stale one is never served if the upload fails.
+	cb.remove(path)
+
+	// Only the objects not bigger than the max size are written to the local cache.
+	content, err := io.ReadAll(io.LimitReader(r, cb.maxObjectSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(content)) > cb.maxObjectSize {
+		return cb.Bucket.Upload(ctx, name, io.MultiReader(bytes.NewReader(content), r))
+	}
+
+	if err := cb.Bucket.Upload(ctx, name, bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	// The attributes of the uploaded object are required to validate the local copy later on.
+	attrs, err := cb.Bucket.Attributes(ctx, name)
+	if err != nil {
+		level.Warn(cb.logger).Log("msg", "failed to get attributes of the uploaded object, not storing it in the local cache", "name", name, "err", err)
+		return nil
+	}
+	if err := cb.store(path, content, attrs); err != nil {
+		level.Warn(cb.logger).Log("msg", "failed to store object in the local cache", "name", name, "err", err)
+	}
+	return nil
+}
+
+func (cb *LocalCachingBucket) Delete(ctx context.Context, name string) error {
+	if path, ok := cb.localPath(name); ok {
+		cb.remove(path)
+	}
+	return cb.Bucket.Delete(ctx, name)
+}
+
+// validate returns whether the local copy at path of the object is still valid, removing it if it's not.
+// It returns the error of the bucket if the object doesn't exist anymore.
+func (cb *LocalCachingBucket) validate(ctx context.Context, name, path string, local objstore.ObjectAttributes) (bool, error) {
+	attrs, err := cb.Bucket.Attributes(ctx, name)
+	switch {
+	case err != nil && cb.Bucket.IsObjNotFoundErr(err):
+		cb.validations.WithLabelValues("invalid").Inc()
+		cb.remove(path)
+		return false, err
+	case err != nil:
+		// The object is fetched from the bucket, which is likely to fail too.
+		cb.validations.WithLabelValues("failed").Inc()
+		return false, nil
+	case attrs.Size != local.Size || !attrs.LastModified.Equal(local.LastModified):
+		cb.validations.WithLabelValues("invalid").Inc()
+		cb.remove(path)
+		return false, nil
+	}
+
+	cb.validations.WithLabelValues("valid").Inc()
+
+	// The modification time of the attributes file is the time of the last validation.
+	now := time.Now()
+	if err := os.Chtimes(path+localCacheAttributesSuffix, now, now); err != nil {
+		level.Warn(cb.logger).Log("msg", "failed to update the validation time of the locally cached object", "name", name, "err", err)
+	}
+	return true, nil
+}
+
+func (cb *LocalCachingBucket) getContent(ctx context.Context, name string) ([]byte, error) {
+	r, err := cb.Bucket.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	return io.ReadAll(r)
+}
+
+// localPath returns the path of the local copy of the object, and whether the object can be cached locally.
+func (cb *LocalCachingBucket) localPath(name string) (string, bool) {
+	if !cb.matcher(name) || strings.HasSuffix(name, localCacheAttributesSuffix) {
+		return "", false
+	}
+
+	// Never write outside of the local cache directory.
+	path := filepath.Join(cb.dir, filepath.FromSlash(name))
+	if !strings.HasPrefix(path, filepath.Clean(cb.dir)+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
+
+// store writes the content of the object, along with its attributes, to the local cache.
+func (cb *LocalCachingBucket) store(path string, content []byte, attrs objstore.ObjectAttributes) error {
+	encodedAttrs, err := json.Marshal(attrs)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// The content is written before the attributes, so that the local copy is never served before being complete.
+	if err := writeFileAtomically(path, content); err != nil {
+		return err
+	}
+	return writeFileAtomically(path+localCacheAttributesSuffix, encodedAttrs)
+}
+
+func (cb *LocalCachingBucket) remove(path string) {
+	for _, p := range []string{path + localCacheAttributesSuffix, path} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			level.Warn(cb.logger).Log("msg", "failed to remove object from the local cache", "path", p, "err", err)
+		}
+	}
+}
+
+// maybeCleanup removes, in the background, the local copies which haven't been validated for localCacheIdleTimeout,
+// if localCacheCleanupInterval has elapsed since the last cleanup. They're likely copies of deleted objects.
+func (cb *LocalCachingBucket) maybeCleanup() {
+	last := cb.lastCleanup.Load()
+	if time.Since(time.Unix(0, last)) < localCacheCleanupInterval || !cb.lastCleanup.CAS(last, time.Now().UnixNano()) {
+		return
+	}
+
+	go cb.cleanup(time.Now().Add(-localCacheIdleTimeout))
+}
+
+// cleanup removes the local copies which haven't been validated since the input time.
+func (cb *LocalCachingBucket) cleanup(validatedBefore time.Time) {
+	err := filepath.WalkDir(cb.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, localCacheAttributesSuffix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err == nil && info.ModTime().Before(validatedBefore) {
+			cb.remove(strings.TrimSuffix(path, localCacheAttributesSuffix))
+		}
+		return nil
+	})
+	if err != nil {
+		level.Warn(cb.logger).Log("msg", "failed to clean up the local cache", "dir", cb.dir, "err", err)
+	}
+}
+
+// readLocalAttributes returns the attributes of the locally cached object at path, and the time of their last validation.
+func readLocalAttributes(path string) (objstore.ObjectAttributes, time.Time, error) {
+	attrsPath := path + localCacheAttributesSuffix
+
+	info, err := os.Stat(attrsPath)
+	if err != nil {
+		return objstore.ObjectAttributes{}, time.Time{}, err
+	}
+
+	content, err := os.ReadFile(attrsPath)
+	if err != nil {
+		return objstore.ObjectAttributes{}, time.Time{}, err
+	}
+
+	var attrs objstore.ObjectAttributes
+	if err := json.Unmarshal(content, &attrs); err != nil {
+		return objstore.ObjectAttributes{}, time.Time{}, err
+	}
+	return attrs, info.ModTime(), nil
+}
+
+// writeFileAtomically writes the file with a rename, so that a partially written file is never read.
+func writeFileAtomically(path string, content []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
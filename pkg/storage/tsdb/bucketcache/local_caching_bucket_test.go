@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketcache
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestLocalCachingBucket_Get(t *testing.T) {
+	const name = "user/block/meta.json"
+
+	tests := map[string]struct {
+		validationInterval time.Duration
+		expectedAfterEdit  string
+		expectedGets       int
+	}{
+		"local copy used without validation within the validation interval": {
+			validationInterval: time.Hour,
+			expectedAfterEdit:  "v1",
+			expectedGets:       1,
+		},
+		"local copy validated every time it's read": {
+			validationInterval: 0,
+			expectedAfterEdit:  "v2",
+			expectedGets:       2,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			bkt := &getsCountingBucket{InMemBucket: objstore.NewInMemBucket()}
+			require.NoError(t, bkt.Upload(ctx, name, strings.NewReader("v1")))
+
+			cb := newTestLocalCachingBucket(t, bkt, testData.validationInterval)
+
+			// The object is fetched from the bucket the first time.
+			assert.Equal(t, "v1", readObject(t, cb, name))
+			assert.Equal(t, 1, bkt.gets)
+
+			// The local copy is used as long as it's valid.
+			assert.Equal(t, "v1", readObject(t, cb, name))
+			assert.Equal(t, 1, bkt.gets)
+
+			// The object is modified in the bucket.
+			require.NoError(t, bkt.Upload(ctx, name, strings.NewReader("v2")))
+			assert.Equal(t, testData.expectedAfterEdit, readObject(t, cb, name))
+			assert.Equal(t, testData.expectedGets, bkt.gets)
+
+			// The object is deleted from the bucket.
+			require.NoError(t, bkt.Delete(ctx, name))
+			if testData.validationInterval == 0 {
+				_, err := cb.Get(ctx, name)
+				require.Error(t, err)
+				assert.True(t, cb.IsObjNotFoundErr(err))
+
+				_, err = os.Stat(filepath.Join(cb.dir, name))
+				assert.True(t, os.IsNotExist(err))
+			}
+		})
+	}
+}
+
+func TestLocalCachingBucket_ShouldNotCacheNotMatchingOrBigObjects(t *testing.T) {
+	ctx := context.Background()
+	bkt := &getsCountingBucket{InMemBucket: objstore.NewInMemBucket()}
+	require.NoError(t, bkt.Upload(ctx, "user/block/index", strings.NewReader("index")))
+	require.NoError(t, bkt.Upload(ctx, "user/big/meta.json", strings.NewReader(strings.Repeat("x", 100))))
+
+	cb := newTestLocalCachingBucket(t, bkt, time.Hour)
+
+	for i := 1; i <= 2; i++ {
+		assert.Equal(t, "index", readObject(t, cb, "user/block/index"))
+		assert.Equal(t, strings.Repeat("x", 100), readObject(t, cb, "user/big/meta.json"))
+		assert.Equal(t, 2*i, bkt.gets)
+	}
+}
+
+func TestLocalCachingBucket_UploadAndDelete(t *testing.T) {
+	const name = "user/block/meta.json"
+
+	ctx := context.Background()
+	bkt := &getsCountingBucket{InMemBucket: objstore.NewInMemBucket()}
+	cb := newTestLocalCachingBucket(t, bkt, 0)
+
+	// The uploaded object is written through to the local cache.
+	require.NoError(t, cb.Upload(ctx, name, strings.NewReader("v1")))
+	assert.Equal(t, "v1", readObject(t, cb, name))
+	assert.Equal(t, 0, bkt.gets)
+
+	// Uploading a new version replaces the local copy.
+	require.NoError(t, cb.Upload(ctx, name, strings.NewReader("v2")))
+	assert.Equal(t, "v2", readObject(t, cb, name))
+	assert.Equal(t, 0, bkt.gets)
+
+	// The objects bigger than the max size are uploaded, but not cached.
+	require.NoError(t, cb.Upload(ctx, "user/big/meta.json", strings.NewReader(strings.Repeat("x", 100))))
+	assert.Equal(t, strings.Repeat("x", 100), readObject(t, cb, "user/big/meta.json"))
+	assert.Equal(t, 1, bkt.gets)
+
+	// Deleting the object removes the local copy.
+	require.NoError(t, cb.Delete(ctx, name))
+	_, err := os.Stat(filepath.Join(cb.dir, name))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = cb.Get(ctx, name)
+	assert.True(t, cb.IsObjNotFoundErr(err))
+}
+
+func TestLocalCachingBucket_Cleanup(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	cb := newTestLocalCachingBucket(t, bkt, time.Hour)
+
+	require.NoError(t, cb.Upload(ctx, "user/old/meta.json", strings.NewReader("old")))
+	require.NoError(t, cb.Upload(ctx, "user/new/meta.json", strings.NewReader("new")))
+
+	oldPath := filepath.Join(cb.dir, "user/old/meta.json")
+	validatedAt := time.Now().Add(-2 * localCacheIdleTimeout)
+	require.NoError(t, os.Chtimes(oldPath+localCacheAttributesSuffix, validatedAt, validatedAt))
+
+	cb.cleanup(time.Now().Add(-localCacheIdleTimeout))
+
+	_, err := os.Stat(oldPath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(cb.dir, "user/new/meta.json"))
+	assert.NoError(t, err)
+}
+
+func newTestLocalCachingBucket(t *testing.T, bkt objstore.Bucket, validationInterval time.Duration) *LocalCachingBucket {
+	matcher := func(name string) bool { return strings.HasSuffix(name, "/meta.json") }
+
+	cb, err := NewLocalCachingBucket(bkt, t.TempDir(), matcher, validationInterval, 10, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	return cb
+}
+
+func readObject(t *testing.T, bkt objstore.BucketReader, name string) string {
+	r, err := bkt.Get(context.Background(), name)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// getsCountingBucket is an objstore.Bucket counting the Get requests.
+type getsCountingBucket struct {
+	*objstore.InMemBucket
+	gets int
+}
+
+func (b *getsCountingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	b.gets++
+	return b.InMemBucket.Get(ctx, name)
+}
@@ -0,0 +1,274 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketindex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+const (
+	// IndexDeltasPathname is the directory of the index deltas, relative to the tenant.
+	IndexDeltasPathname = "bucket-index-deltas"
+
+	IndexDeltaVersion1 = 1
+
+	indexDeltaFilenameSuffix = ".json.gz"
+)
+
+var errIndexDeltaNotFound = errors.New("bucket index delta not found")
+
+// IndexDelta holds the changes between two consecutive versions of an incremental bucket index. The
+// deltas are written in place of the whole index, and applied on top of it when reading it.
+type IndexDelta struct {
+	// Version of the index delta format.
+	Version int `json:"version"`
+
+	// Sequence number of the delta. The deltas of an index have consecutive sequence numbers.
+	Sequence int64 `json:"sequence"`
+
+	// Blocks added to the index, or updated in the index, and IDs of the blocks removed from it.
+	AddedBlocks   Blocks      `json:"added_blocks,omitempty"`
+	RemovedBlocks []ulid.ULID `json:"removed_blocks,omitempty"`
+
+	// Block deletion marks added to the index, and IDs of the blocks whose deletion mark has been removed from it.
+	AddedBlockDeletionMarks   BlockDeletionMarks `json:"added_block_deletion_marks,omitempty"`
+	RemovedBlockDeletionMarks []ulid.ULID        `json:"removed_block_deletion_marks,omitempty"`
+
+	// UpdatedAt is a unix timestamp (seconds precision) of when the index has been updated with this delta.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// WriteIndexIncrementally uploads the changes from the old to the new index as a delta, instead of uploading
+// the whole index. The whole index is uploaded, merging the previous deltas, if the old index is nil or not
+// incremental, or if it already has maxDeltas deltas.
+func WriteIndexIncrementally(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, old, idx *Index, maxDeltas int, logger log.Logger) error {
+	if old == nil || !old.DeltasEnabled || old.deltas >= maxDeltas {
+		return writeIncrementalFullIndex(ctx, bkt, userID, cfgProvider, old, idx, logger)
+	}
+
+	delta := newIndexDelta(old, idx)
+	delta.Sequence = old.DeltaSequence + 1
+
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+	name := indexDeltaFilename(delta.Sequence)
+	if err := writeGzipJSON(ctx, userBkt, name, path.Base(strings.TrimSuffix(name, ".gz")), delta); err != nil {
+		return errors.Wrap(err, "upload bucket index delta")
+	}
+
+	idx.DeltasEnabled = true
+	idx.DeltaSequence = delta.Sequence
+	idx.deltas = old.deltas + 1
+	return nil
+}
+
+// DeleteIndexDeltas deletes the index deltas from the storage, if any. It's used to cleanup the deltas once
+// the index isn't incremental anymore.
+func DeleteIndexDeltas(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider) error {
+	return deleteIndexDeltas(ctx, bucket.NewUserBucketClient(userID, bkt, cfgProvider), math.MaxInt64)
+}
+
+// writeIncrementalFullIndex uploads the whole index, merging the deltas written so far, and then deletes them.
+func writeIncrementalFullIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, old, idx *Index, logger log.Logger) error {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	// The index includes the changes of all the deltas in the storage, even the ones not applied to the old
+	// index, e.g. because the old index was corrupted, so that they're not applied on top of it.
+	sequences, err := listIndexDeltas(ctx, userBkt)
+	if err != nil {
+		return err
+	}
+	idx.DeltaSequence = 0
+	if old != nil {
+		idx.DeltaSequence = old.DeltaSequence
+	}
+	if len(sequences) > 0 && sequences[len(sequences)-1] > idx.DeltaSequence {
+		idx.DeltaSequence = sequences[len(sequences)-1]
+	}
+	idx.DeltasEnabled = true
+	idx.deltas = 0
+
+	if err := WriteIndex(ctx, bkt, userID, cfgProvider, idx); err != nil {
+		return err
+	}
+
+	// The merged deltas are no longer read, so failing to delete them isn't critical.
+	if err := deleteIndexDeltas(ctx, userBkt, idx.DeltaSequence); err != nil {
+		level.Warn(logger).Log("msg", "failed to delete the bucket index deltas merged into the bucket index", "user", userID, "err", err)
+	}
+	return nil
+}
+
+// newIndexDelta returns the changes from the old to the new index. The blocks and deletion marks not in
+// the old index, or different from the ones in the old index, are added.
+func newIndexDelta(old, idx *Index) *IndexDelta {
+	delta := &IndexDelta{
+		Version:   IndexDeltaVersion1,
+		UpdatedAt: idx.UpdatedAt,
+	}
+
+	oldBlocks := make(map[ulid.ULID]*Block, len(old.Blocks))
+	for _, b := range old.Blocks {
+		oldBlocks[b.ID] = b
+	}
+	for _, b := range idx.Blocks {
+		if o, ok := oldBlocks[b.ID]; !ok || (o != b && !reflect.DeepEqual(o, b)) {
+			delta.AddedBlocks = append(delta.AddedBlocks, b)
+		}
+		delete(oldBlocks, b.ID)
+	}
+	for id := range oldBlocks {
+		delta.RemovedBlocks = append(delta.RemovedBlocks, id)
+	}
+
+	oldMarks := make(map[ulid.ULID]*BlockDeletionMark, len(old.BlockDeletionMarks))
+	for _, m := range old.BlockDeletionMarks {
+		oldMarks[m.ID] = m
+	}
+	for _, m := range idx.BlockDeletionMarks {
+		if o, ok := oldMarks[m.ID]; !ok || *o != *m {
+			delta.AddedBlockDeletionMarks = append(delta.AddedBlockDeletionMarks, m)
+		}
+		delete(oldMarks, m.ID)
+	}
+	for id := range oldMarks {
+		delta.RemovedBlockDeletionMarks = append(delta.RemovedBlockDeletionMarks, id)
+	}
+
+	return delta
+}
+
+// apply applies the delta on top of the index.
+func (d *IndexDelta) apply(idx *Index) {
+	replaced := make(map[ulid.ULID]struct{}, len(d.RemovedBlocks)+len(d.AddedBlocks))
+	for _, id := range d.RemovedBlocks {
+		replaced[id] = struct{}{}
+	}
+	for _, b := range d.AddedBlocks {
+		replaced[b.ID] = struct{}{}
+	}
+	blocks := make(Blocks, 0, len(idx.Blocks)+len(d.AddedBlocks))
+	for _, b := range idx.Blocks {
+		if _, ok := replaced[b.ID]; !ok {
+			blocks = append(blocks, b)
+		}
+	}
+	idx.Blocks = append(blocks, d.AddedBlocks...)
+
+	replaced = make(map[ulid.ULID]struct{}, len(d.RemovedBlockDeletionMarks)+len(d.AddedBlockDeletionMarks))
+	for _, id := range d.RemovedBlockDeletionMarks {
+		replaced[id] = struct{}{}
+	}
+	for _, m := range d.AddedBlockDeletionMarks {
+		replaced[m.ID] = struct{}{}
+	}
+	marks := make(BlockDeletionMarks, 0, len(idx.BlockDeletionMarks)+len(d.AddedBlockDeletionMarks))
+	for _, m := range idx.BlockDeletionMarks {
+		if _, ok := replaced[m.ID]; !ok {
+			marks = append(marks, m)
+		}
+	}
+	idx.BlockDeletionMarks = append(marks, d.AddedBlockDeletionMarks...)
+
+	idx.DeltaSequence = d.Sequence
+	idx.UpdatedAt = d.UpdatedAt
+	idx.deltas++
+}
+
+// applyIndexDeltas applies the deltas written after the full index on top of it. It returns errIndexDeltaNotFound
+// if a delta has been deleted in the meanwhile, because merged into a new full index.
+func applyIndexDeltas(ctx context.Context, bkt objstore.InstrumentedBucket, idx *Index, logger log.Logger) error {
+	sequences, err := listIndexDeltas(ctx, bkt)
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range sequences {
+		if seq <= idx.DeltaSequence {
+			continue
+		}
+		if seq != idx.DeltaSequence+1 {
+			return errIndexDeltaNotFound
+		}
+
+		delta := &IndexDelta{}
+		if err := readGzipJSON(ctx, bkt, indexDeltaFilename(seq), delta, logger); err != nil {
+			if errors.Is(err, errObjectNotFound) {
+				return errIndexDeltaNotFound
+			}
+			return err
+		}
+		if delta.Version != IndexDeltaVersion1 || delta.Sequence != seq {
+			return ErrIndexCorrupted
+		}
+
+		delta.apply(idx)
+	}
+
+	return nil
+}
+
+// listIndexDeltas returns the sequence numbers of the index deltas in the storage, sorted in ascending order.
+func listIndexDeltas(ctx context.Context, bkt objstore.Bucket) ([]int64, error) {
+	var sequences []int64
+	err := bkt.Iter(ctx, IndexDeltasPathname+"/", func(name string) error {
+		if seq, ok := parseIndexDeltaFilename(name); ok {
+			sequences = append(sequences, seq)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list bucket index deltas")
+	}
+
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+	return sequences, nil
+}
+
+// deleteIndexDeltas deletes the index deltas with sequence number lower than or equal to maxSequence.
+func deleteIndexDeltas(ctx context.Context, bkt objstore.Bucket, maxSequence int64) error {
+	sequences, err := listIndexDeltas(ctx, bkt)
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range sequences {
+		if seq > maxSequence {
+			break
+		}
+		if err := bkt.Delete(ctx, indexDeltaFilename(seq)); err != nil && !bkt.IsObjNotFoundErr(err) {
+			return errors.Wrap(err, "delete bucket index delta")
+		}
+	}
+	return nil
+}
+
+func indexDeltaFilename(seq int64) string {
+	return fmt.Sprintf("%s/%020d%s", IndexDeltasPathname, seq, indexDeltaFilenameSuffix)
+}
+
+func parseIndexDeltaFilename(name string) (int64, bool) {
+	base := path.Base(name)
+	if !strings.HasSuffix(base, indexDeltaFilenameSuffix) {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(strings.TrimSuffix(base, indexDeltaFilenameSuffix), 10, 64)
+	if err != nil || seq <= 0 {
+		return 0, false
+	}
+	return seq, true
+}
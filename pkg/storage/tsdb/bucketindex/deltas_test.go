@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketindex
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestWriteIndexIncrementally(t *testing.T) {
+	const (
+		userID    = "user-1"
+		maxDeltas = 2
+	)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	bkt = BucketWithGlobalMarkers(bkt)
+	u := NewUpdater(bkt, userID, nil, logger)
+
+	// updateAndWrite updates the index, writes it incrementally and checks it's read back unchanged.
+	updateAndWrite := func(old *Index) *Index {
+		idx, _, err := u.UpdateIndex(ctx, old)
+		require.NoError(t, err)
+		require.NoError(t, WriteIndexIncrementally(ctx, bkt, userID, nil, old, idx, maxDeltas, logger))
+
+		actual, err := ReadIndex(ctx, bkt, userID, nil, logger)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, idx.Blocks, actual.Blocks)
+		assert.ElementsMatch(t, idx.BlockDeletionMarks, actual.BlockDeletionMarks)
+		assert.Equal(t, idx.UpdatedAt, actual.UpdatedAt)
+		assert.Equal(t, idx.DeltaSequence, actual.DeltaSequence)
+		assert.Equal(t, idx.deltas, actual.deltas)
+		return actual
+	}
+
+	block1 := testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	block2 := testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+
+	// The first write should upload the full index.
+	idx := updateAndWrite(nil)
+	assert.True(t, idx.DeltasEnabled)
+	assert.Equal(t, int64(0), idx.DeltaSequence)
+	assert.Equal(t, []int64(nil), listTestIndexDeltas(t, bkt, userID))
+
+	// The next writes should upload the deltas.
+	testutil.MockStorageDeletionMark(t, bkt, userID, block1)
+	block3 := testutil.MockStorageBlock(t, bkt, userID, 30, 40)
+	idx = updateAndWrite(idx)
+	assert.Equal(t, int64(1), idx.DeltaSequence)
+	assert.Len(t, idx.Blocks, 3)
+	assert.Len(t, idx.BlockDeletionMarks, 1)
+
+	require.NoError(t, bkt.Delete(ctx, path.Join(userID, block2.ULID.String(), "meta.json")))
+	idx = updateAndWrite(idx)
+	assert.Equal(t, int64(2), idx.DeltaSequence)
+	assert.Equal(t, []int64{1, 2}, listTestIndexDeltas(t, bkt, userID))
+	assert.Equal(t, 2, idx.deltas)
+
+	// Once the max deltas are reached, the deltas should be merged into the full index.
+	testutil.MockStorageDeletionMark(t, bkt, userID, block3)
+	idx = updateAndWrite(idx)
+	assert.Equal(t, int64(2), idx.DeltaSequence)
+	assert.Equal(t, 0, idx.deltas)
+	assert.Len(t, idx.BlockDeletionMarks, 2)
+	assert.Equal(t, []int64(nil), listTestIndexDeltas(t, bkt, userID))
+
+	// The sequence numbers should continue after the merged deltas.
+	idx = updateAndWrite(idx)
+	assert.Equal(t, int64(3), idx.DeltaSequence)
+	assert.Equal(t, []int64{3}, listTestIndexDeltas(t, bkt, userID))
+
+	// Deleting the index should delete the deltas too.
+	require.NoError(t, DeleteIndex(ctx, bkt, userID, nil))
+	assert.Equal(t, []int64(nil), listTestIndexDeltas(t, bkt, userID))
+
+	_, err := ReadIndex(ctx, bkt, userID, nil, logger)
+	assert.Equal(t, ErrIndexNotFound, err)
+}
+
+func TestWriteIndexIncrementally_ShouldWriteTheFullIndexIfTheOldIndexIsNotIncremental(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	bkt = BucketWithGlobalMarkers(bkt)
+	testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+
+	u := NewUpdater(bkt, userID, nil, logger)
+	old, _, err := u.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, WriteIndex(ctx, bkt, userID, nil, old))
+
+	idx, _, err := u.UpdateIndex(ctx, old)
+	require.NoError(t, err)
+	require.NoError(t, WriteIndexIncrementally(ctx, bkt, userID, nil, old, idx, 10, logger))
+	assert.Equal(t, []int64(nil), listTestIndexDeltas(t, bkt, userID))
+
+	actual, err := ReadIndex(ctx, bkt, userID, nil, logger)
+	require.NoError(t, err)
+	assert.Equal(t, idx, actual)
+	assert.True(t, actual.DeltasEnabled)
+}
+
+func TestReadIndex_ShouldFailOnGapsInTheIndexDeltas(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	bkt = BucketWithGlobalMarkers(bkt)
+	testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+
+	u := NewUpdater(bkt, userID, nil, logger)
+	old, _, err := u.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, WriteIndexIncrementally(ctx, bkt, userID, nil, nil, old, 10, logger))
+
+	for i := 0; i < 2; i++ {
+		idx, _, err := u.UpdateIndex(ctx, old)
+		require.NoError(t, err)
+		require.NoError(t, WriteIndexIncrementally(ctx, bkt, userID, nil, old, idx, 10, logger))
+		old = idx
+	}
+
+	// A missing delta means that the deltas have been merged while reading them, so
+	// the full index should be read again, up to the max attempts.
+	require.NoError(t, bkt.Delete(ctx, path.Join(userID, indexDeltaFilename(1))))
+
+	_, err = ReadIndex(ctx, bkt, userID, nil, logger)
+	assert.ErrorIs(t, err, errIndexDeltaNotFound)
+}
+
+func TestReadIndex_ShouldReturnErrorIfIndexDeltaIsCorrupted(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	require.NoError(t, WriteIndexIncrementally(ctx, bkt, userID, nil, nil, &Index{Version: IndexVersion2}, 10, logger))
+
+	// Write a corrupted delta.
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, indexDeltaFilename(1)), strings.NewReader("invalid!}")))
+
+	idx, err := ReadIndex(ctx, bkt, userID, nil, logger)
+	require.Equal(t, ErrIndexCorrupted, err)
+	require.Nil(t, idx)
+}
+
+func TestParseIndexDeltaFilename(t *testing.T) {
+	seq, ok := parseIndexDeltaFilename(indexDeltaFilename(123))
+	assert.True(t, ok)
+	assert.Equal(t, int64(123), seq)
+
+	for _, name := range []string{"bucket-index-deltas/", "bucket-index-deltas/abc.json.gz", "bucket-index-deltas/0.json.gz", "bucket-index-deltas/1.json"} {
+		_, ok := parseIndexDeltaFilename(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func listTestIndexDeltas(t *testing.T, bkt objstore.Bucket, userID string) []int64 {
+	sequences, err := listIndexDeltas(context.Background(), bucket.NewUserBucketClient(userID, bkt, nil))
+	require.NoError(t, err)
+	return sequences
+}
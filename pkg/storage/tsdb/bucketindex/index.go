@@ -46,6 +46,16 @@ type Index struct {
 	// UpdatedAt is a unix timestamp (seconds precision) of when the index has been updated
 	// (written in the storage) the last time.
 	UpdatedAt int64 `json:"updated_at"`
+
+	// DeltasEnabled is true if the index is updated incrementally, writing the changes to index deltas
+	// which are applied on top of it when reading it.
+	DeltasEnabled bool `json:"deltas_enabled,omitempty"`
+
+	// DeltaSequence is the sequence number of the last index delta included in the index.
+	DeltaSequence int64 `json:"delta_sequence,omitempty"`
+
+	// Number of index deltas applied on top of the full index when reading it.
+	deltas int
 }
 
 func (idx *Index) GetUpdatedAt() time.Time {
@@ -91,6 +101,17 @@ type Block struct {
 
 	// Block's compactor shard ID, copied from tsdb.CompactorShardIDExternalLabel label.
 	CompactorShardID string `json:"compactor_shard_id,omitempty"`
+
+	// Block's compaction level, copied from meta.json. It's 0 for blocks added to the index
+	// before the compaction level was tracked.
+	CompactionLevel int `json:"compaction_level,omitempty"`
+
+	// Block's downsampling resolution (millis precision), copied from meta.json. It's 0 for raw blocks.
+	Resolution int64 `json:"resolution,omitempty"`
+
+	// ReplacedBlocks are the IDs of the blocks compacted into this block which are still in the storage.
+	// It's a hint for the store-gateways to keep this block warm until the replaced blocks are deleted.
+	ReplacedBlocks []ulid.ULID `json:"replaced_blocks,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -114,14 +135,27 @@ func (m *Block) ThanosMeta() *metadata.Meta {
 			MinTime: m.MinTime,
 			MaxTime: m.MaxTime,
 			Version: metadata.TSDBVersion1,
+			Compaction: tsdb.BlockMetaCompaction{
+				Parents: m.thanosMetaParents(),
+			},
 		},
 		Thanos: metadata.Thanos{
 			Version:      metadata.ThanosVersion1,
 			SegmentFiles: m.thanosMetaSegmentFiles(),
+			Downsample:   metadata.ThanosDownsample{Resolution: m.Resolution},
 		},
 	}
 }
 
+// thanosMetaParents returns the replaced blocks as the parents of the block. Only their IDs are known.
+func (m *Block) thanosMetaParents() (parents []tsdb.BlockDesc) {
+	for _, id := range m.ReplacedBlocks {
+		parents = append(parents, tsdb.BlockDesc{ULID: id})
+	}
+
+	return parents
+}
+
 func (m *Block) thanosMetaSegmentFiles() (files []string) {
 	if m.SegmentsFormat == SegmentsFormat1Based6Digits {
 		for i := 1; i <= m.SegmentsNum; i++ {
@@ -154,6 +188,8 @@ func BlockFromThanosMeta(meta metadata.Meta) *Block {
 		SegmentsFormat:   segmentsFormat,
 		SegmentsNum:      segmentsNum,
 		CompactorShardID: meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel],
+		CompactionLevel:  meta.Compaction.Level,
+		Resolution:       meta.Thanos.Downsample.Resolution,
 	}
 }
 
@@ -272,6 +272,40 @@ func TestBlockFromThanosMeta(t *testing.T) {
 				CompactorShardID: "some weird value",
 			},
 		},
+		"meta.json with compaction level": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:       blockID,
+					MinTime:    10,
+					MaxTime:    20,
+					Compaction: tsdb.BlockMetaCompaction{Level: 3},
+				},
+			},
+			expected: Block{
+				ID:              blockID,
+				MinTime:         10,
+				MaxTime:         20,
+				CompactionLevel: 3,
+			},
+		},
+		"meta.json with downsampling resolution": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+				},
+				Thanos: metadata.Thanos{
+					Downsample: metadata.ThanosDownsample{Resolution: 300000},
+				},
+			},
+			expected: Block{
+				ID:         blockID,
+				MinTime:    10,
+				MaxTime:    20,
+				Resolution: 300000,
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -378,6 +412,28 @@ func TestBlock_ThanosMeta(t *testing.T) {
 				},
 			},
 		},
+		"block with replaced blocks": {
+			block: Block{
+				ID:             blockID,
+				MinTime:        10,
+				MaxTime:        20,
+				ReplacedBlocks: []ulid.ULID{ulid.MustNew(2, nil), ulid.MustNew(3, nil)},
+			},
+			expected: &metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Version: metadata.TSDBVersion1,
+					Compaction: tsdb.BlockMetaCompaction{
+						Parents: []tsdb.BlockDesc{{ULID: ulid.MustNew(2, nil)}, {ULID: ulid.MustNew(3, nil)}},
+					},
+				},
+				Thanos: metadata.Thanos{
+					Version: metadata.ThanosVersion1,
+				},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
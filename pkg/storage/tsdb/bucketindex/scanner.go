@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketindex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+var ErrScannerCircuitBreakerOpen = errors.New("the circuit breaker of the bucket scan is open because of too many failed scans")
+
+type ScannerConfig struct {
+	// MaxBlocks is the max number of blocks whose meta.json is fetched by a single scan.
+	MaxBlocks int
+
+	// FailuresThreshold is the number of consecutive failed scans of a tenant after which
+	// its scans are skipped until the CooldownPeriod expires.
+	FailuresThreshold int
+	CooldownPeriod    time.Duration
+}
+
+// Scanner generates the bucket index of a tenant scanning the bucket, when the bucket index stored by the
+// compactor is missing or too old. The scans are bounded by the time range of the blocks to find and the
+// max number of blocks to fetch, and are skipped for a while once they keep failing.
+type Scanner struct {
+	bkt         objstore.Bucket
+	logger      log.Logger
+	cfg         ScannerConfig
+	cfgProvider bucket.TenantConfigProvider
+
+	mx      sync.Mutex
+	tenants map[string]*scannerTenantState
+
+	// Metrics.
+	scans *prometheus.CounterVec
+}
+
+type scannerTenantState struct {
+	// index is the index generated by the last successful scan, used as the base of the next scan
+	// so that the meta.json of the blocks already found is not fetched again.
+	index *Index
+
+	failures  int
+	openUntil time.Time
+}
+
+// NewScanner makes a new Scanner.
+func NewScanner(cfg ScannerConfig, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *Scanner {
+	return &Scanner{
+		bkt:         bkt,
+		logger:      logger,
+		cfg:         cfg,
+		cfgProvider: cfgProvider,
+		tenants:     map[string]*scannerTenantState{},
+
+		scans: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_index_fallback_scans_total",
+			Help: "Total number of bucket scans done because the bucket index is missing or too old, by outcome.",
+		}, []string{"outcome"}),
+	}
+}
+
+// ScanIndex returns the bucket index of the tenant with all the blocks containing samples at or after minT.
+// The old index, if any, is the last bucket index stored by the compactor: its blocks are not fetched again.
+// It returns ErrScannerCircuitBreakerOpen without scanning the bucket if the last scans of the tenant failed.
+func (s *Scanner) ScanIndex(ctx context.Context, userID string, old *Index, minT int64) (*Index, error) {
+	s.mx.Lock()
+	state := s.tenants[userID]
+	if state == nil {
+		state = &scannerTenantState{}
+		s.tenants[userID] = state
+	}
+	if time.Now().Before(state.openUntil) {
+		s.mx.Unlock()
+		s.scans.WithLabelValues("skipped").Inc()
+		return nil, ErrScannerCircuitBreakerOpen
+	}
+	if state.index != nil && (old == nil || state.index.UpdatedAt > old.UpdatedAt) {
+		old = state.index
+	}
+	s.mx.Unlock()
+
+	idx, _, err := NewUpdater(s.bkt, userID, s.cfgProvider, s.logger).ScanIndex(ctx, old, minT, s.cfg.MaxBlocks)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err != nil {
+		// Canceled scans are not a failure of the bucket.
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+
+		s.scans.WithLabelValues("failed").Inc()
+		state.failures++
+		if state.failures >= s.cfg.FailuresThreshold {
+			state.openUntil = time.Now().Add(s.cfg.CooldownPeriod)
+		}
+		return nil, errors.Wrap(err, "scan bucket")
+	}
+
+	s.scans.WithLabelValues("success").Inc()
+	state.failures = 0
+	state.index = idx
+	return idx, nil
+}
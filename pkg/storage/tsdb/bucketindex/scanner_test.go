@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketindex
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestScanner_ScanIndex(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bkt = BucketWithGlobalMarkers(bkt)
+	block1 := mimir_testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block2 := mimir_testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+
+	reg := prometheus.NewPedanticRegistry()
+	s := NewScanner(ScannerConfig{MaxBlocks: 10, FailuresThreshold: 1, CooldownPeriod: time.Hour}, bkt, nil, log.NewNopLogger(), reg)
+
+	idx, err := s.ScanIndex(context.Background(), userID, nil, 0)
+	require.NoError(t, err)
+	assertBucketIndexEqual(t, idx, bkt, userID, []metadata.Meta{block1, block2}, nil)
+
+	// The blocks found by the previous scan are kept, even if created before the min time.
+	block3 := mimir_testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 30, 40, nil)
+	idx, err = s.ScanIndex(context.Background(), userID, nil, 40)
+	require.NoError(t, err)
+	assertBucketIndexEqual(t, idx, bkt, userID, []metadata.Meta{block1, block2, block3}, nil)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_index_fallback_scans_total Total number of bucket scans done because the bucket index is missing or too old, by outcome.
+		# TYPE cortex_bucket_index_fallback_scans_total counter
+		cortex_bucket_index_fallback_scans_total{outcome="success"} 2
+	`), "cortex_bucket_index_fallback_scans_total"))
+}
+
+func TestScanner_ScanIndex_ShouldSkipScansAfterTooManyFailures(t *testing.T) {
+	const userID = "user-1"
+
+	bkt := &bucket.ClientMock{}
+	bkt.MockIter(userID+"/", nil, errors.New("failed to list"))
+
+	reg := prometheus.NewPedanticRegistry()
+	s := NewScanner(ScannerConfig{MaxBlocks: 10, FailuresThreshold: 2, CooldownPeriod: time.Hour}, bkt, nil, log.NewNopLogger(), reg)
+
+	for i := 0; i < 2; i++ {
+		_, err := s.ScanIndex(context.Background(), userID, nil, 0)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrScannerCircuitBreakerOpen)
+	}
+
+	// The circuit breaker is open, so the bucket is not scanned anymore.
+	_, err := s.ScanIndex(context.Background(), userID, nil, 0)
+	require.ErrorIs(t, err, ErrScannerCircuitBreakerOpen)
+	bkt.AssertNumberOfCalls(t, "Iter", 2)
+
+	// Other tenants are not affected.
+	bkt.MockIter("user-2/", nil, nil)
+	bkt.MockIter("user-2/markers/", nil, nil)
+	_, err = s.ScanIndex(context.Background(), "user-2", nil, 0)
+	require.NoError(t, err)
+
+	// Scans are retried once the cooldown period expires.
+	s.tenants[userID].openUntil = time.Now().Add(-time.Second)
+	_, err = s.ScanIndex(context.Background(), userID, nil, 0)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrScannerCircuitBreakerOpen)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_index_fallback_scans_total Total number of bucket scans done because the bucket index is missing or too old, by outcome.
+		# TYPE cortex_bucket_index_fallback_scans_total counter
+		cortex_bucket_index_fallback_scans_total{outcome="failed"} 3
+		cortex_bucket_index_fallback_scans_total{outcome="skipped"} 1
+		cortex_bucket_index_fallback_scans_total{outcome="success"} 1
+	`), "cortex_bucket_index_fallback_scans_total"))
+}
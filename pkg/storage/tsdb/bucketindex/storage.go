@@ -10,6 +10,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"math"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/runutil"
@@ -19,70 +20,109 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket"
 )
 
+const (
+	// The max number of times the full index is read when the index deltas are merged while reading them.
+	maxReadIndexAttempts = 3
+)
+
 var (
 	ErrIndexNotFound  = errors.New("bucket index not found")
 	ErrIndexCorrupted = errors.New("bucket index corrupted")
+
+	errObjectNotFound = errors.New("object not found")
 )
 
-// ReadIndex reads, parses and returns a bucket index from the bucket.
+// ReadIndex reads, parses and returns a bucket index from the bucket. If the index is incremental, the index
+// deltas written after the full index are applied on top of it.
 func ReadIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) (*Index, error) {
 	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
 
-	// Get the bucket index.
-	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, IndexCompressedFilename)
+	for attempt := 1; ; attempt++ {
+		index := &Index{}
+		if err := readGzipJSON(ctx, userBkt, IndexCompressedFilename, index, logger); err != nil {
+			if errors.Is(err, errObjectNotFound) {
+				return nil, ErrIndexNotFound
+			}
+			return nil, err
+		}
+
+		if !index.DeltasEnabled {
+			return index, nil
+		}
+
+		// The deltas may be merged into a new full index, and deleted, while applying them. In such case,
+		// the full index is read again.
+		err := applyIndexDeltas(ctx, userBkt, index, logger)
+		if errors.Is(err, errIndexDeltaNotFound) && attempt < maxReadIndexAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return index, nil
+	}
+}
+
+// WriteIndex uploads the provided index to the storage.
+func WriteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, idx *Index) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	if err := writeGzipJSON(ctx, bkt, IndexCompressedFilename, IndexFilename, idx); err != nil {
+		return errors.Wrap(err, "upload bucket index")
+	}
+	return nil
+}
+
+// readGzipJSON reads and deserializes the gzipped JSON object from the bucket. It returns errObjectNotFound
+// if the object doesn't exist, or ErrIndexCorrupted if it can't be deserialized.
+func readGzipJSON(ctx context.Context, bkt objstore.InstrumentedBucket, name string, v interface{}, logger log.Logger) error {
+	reader, err := bkt.WithExpectedErrs(bkt.IsObjNotFoundErr).Get(ctx, name)
 	if err != nil {
-		if userBkt.IsObjNotFoundErr(err) {
-			return nil, ErrIndexNotFound
+		if bkt.IsObjNotFoundErr(err) {
+			return errObjectNotFound
 		}
-		return nil, errors.Wrap(err, "read bucket index")
+		return errors.Wrap(err, "read bucket index")
 	}
 	defer runutil.CloseWithLogOnErr(logger, reader, "close bucket index reader")
 
 	// Read all the content.
 	gzipReader, err := gzip.NewReader(reader)
 	if err != nil {
-		return nil, ErrIndexCorrupted
+		return ErrIndexCorrupted
 	}
 	defer runutil.CloseWithLogOnErr(logger, gzipReader, "close bucket index gzip reader")
 
 	// Deserialize it.
-	index := &Index{}
 	d := json.NewDecoder(gzipReader)
-	if err := d.Decode(index); err != nil {
-		return nil, ErrIndexCorrupted
+	if err := d.Decode(v); err != nil {
+		return ErrIndexCorrupted
 	}
 
-	return index, nil
+	return nil
 }
 
-// WriteIndex uploads the provided index to the storage.
-func WriteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, idx *Index) error {
-	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
-
+// writeGzipJSON serializes, gzips and uploads the object to the bucket.
+func writeGzipJSON(ctx context.Context, bkt objstore.Bucket, name, gzipName string, v interface{}) error {
 	// Marshal the index.
-	content, err := json.Marshal(idx)
+	content, err := json.Marshal(v)
 	if err != nil {
-		return errors.Wrap(err, "marshal bucket index")
+		return errors.Wrap(err, "marshal")
 	}
 
 	// Compress it.
 	var gzipContent bytes.Buffer
 	gzip := gzip.NewWriter(&gzipContent)
-	gzip.Name = IndexFilename
+	gzip.Name = gzipName
 
 	if _, err := gzip.Write(content); err != nil {
-		return errors.Wrap(err, "gzip bucket index")
+		return errors.Wrap(err, "gzip")
 	}
 	if err := gzip.Close(); err != nil {
-		return errors.Wrap(err, "close gzip bucket index")
+		return errors.Wrap(err, "close gzip")
 	}
 
 	// Upload the index to the storage.
-	if err := bkt.Upload(ctx, IndexCompressedFilename, &gzipContent); err != nil {
-		return errors.Wrap(err, "upload bucket index")
-	}
-
-	return nil
+	return bkt.Upload(ctx, name, &gzipContent)
 }
 
 // DeleteIndex deletes the bucket index from the storage. No error is returned if the index
@@ -94,5 +134,6 @@ func DeleteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgPro
 	if err != nil && !bkt.IsObjNotFoundErr(err) {
 		return errors.Wrap(err, "delete bucket index")
 	}
-	return nil
+
+	return deleteIndexDeltas(ctx, bkt, math.MaxInt64)
 }
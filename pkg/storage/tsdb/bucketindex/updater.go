@@ -30,12 +30,16 @@ var (
 	ErrBlockMetaCorrupted         = block.ErrorSyncMetaCorrupted
 	ErrBlockDeletionMarkNotFound  = errors.New("block deletion mark not found")
 	ErrBlockDeletionMarkCorrupted = errors.New("block deletion mark corrupted")
+	ErrTooManyBlocksToScan        = errors.New("too many blocks to scan")
 )
 
 // Updater is responsible to generate an update in-memory bucket index.
 type Updater struct {
 	bkt    objstore.InstrumentedBucket
 	logger log.Logger
+
+	// replacedBlocksHints enables tracking the blocks replaced by the compacted blocks.
+	replacedBlocksHints bool
 }
 
 func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *Updater {
@@ -45,6 +49,13 @@ func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantCon
 	}
 }
 
+// WithReplacedBlocksHints enables tracking, in the bucket index, the blocks compacted into each
+// block while they're still in the storage.
+func (w *Updater) WithReplacedBlocksHints(enabled bool) *Updater {
+	w.replacedBlocksHints = enabled
+	return w
+}
+
 // UpdateIndex generates the bucket index and returns it, without storing it to the storage.
 // If the old index is not passed in input, then the bucket index will be generated from scratch.
 func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid.ULID]error, error) {
@@ -57,12 +68,49 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 		oldBlockDeletionMarks = old.BlockDeletionMarks
 	}
 
-	blocks, partials, err := w.updateBlocks(ctx, oldBlocks)
+	blocks, partials, err := w.updateBlocks(ctx, oldBlocks, blocksFilter{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blocks = w.updateReplacedBlocks(blocks)
+
+	blockDeletionMarks, err := w.updateBlockDeletionMarks(ctx, oldBlockDeletionMarks, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Index{
+		Version:            IndexVersion2,
+		Blocks:             blocks,
+		BlockDeletionMarks: blockDeletionMarks,
+		UpdatedAt:          time.Now().Unix(),
+	}, partials, nil
+}
+
+// ScanIndex generates the bucket index like UpdateIndex, but only fetching the meta.json of the blocks not in
+// the old index which have been created at or after minT, and failing with ErrTooManyBlocksToScan if they're
+// more than maxBlocks. Since a block can't contain samples more recent than its creation, the returned
+// index has all the blocks with samples at or after minT. Only the deletion marks of the returned blocks
+// are fetched. The old index is used regardless of its version, and can be nil.
+func (w *Updater) ScanIndex(ctx context.Context, old *Index, minT int64, maxBlocks int) (*Index, map[ulid.ULID]error, error) {
+	var oldBlocks []*Block
+	var oldBlockDeletionMarks []*BlockDeletionMark
+	if old != nil {
+		oldBlocks = old.Blocks
+		oldBlockDeletionMarks = old.BlockDeletionMarks
+	}
+
+	blocks, partials, err := w.updateBlocks(ctx, oldBlocks, blocksFilter{minCreatedAt: minT, maxBlocks: maxBlocks})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	blockDeletionMarks, err := w.updateBlockDeletionMarks(ctx, oldBlockDeletionMarks)
+	blockIDs := make(map[ulid.ULID]struct{}, len(blocks))
+	for _, b := range blocks {
+		blockIDs[b.ID] = struct{}{}
+	}
+	blockDeletionMarks, err := w.updateBlockDeletionMarks(ctx, oldBlockDeletionMarks, blockIDs)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -75,7 +123,17 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 	}, partials, nil
 }
 
-func (w *Updater) updateBlocks(ctx context.Context, old []*Block) (blocks []*Block, partials map[ulid.ULID]error, _ error) {
+// blocksFilter limits the new blocks whose meta.json is fetched when updating the bucket index.
+// The zero value doesn't limit them.
+type blocksFilter struct {
+	// minCreatedAt is the min creation time of the blocks to fetch, in milliseconds.
+	minCreatedAt int64
+
+	// maxBlocks is the max number of blocks to fetch, or 0 for no limit.
+	maxBlocks int
+}
+
+func (w *Updater) updateBlocks(ctx context.Context, old []*Block, filter blocksFilter) (blocks []*Block, partials map[ulid.ULID]error, _ error) {
 	discovered := map[ulid.ULID]struct{}{}
 	partials = map[ulid.ULID]error{}
 
@@ -98,6 +156,17 @@ func (w *Updater) updateBlocks(ctx context.Context, old []*Block) (blocks []*Blo
 		}
 	}
 
+	if filter.minCreatedAt > 0 {
+		for id := range discovered {
+			if int64(id.Time()) < filter.minCreatedAt {
+				delete(discovered, id)
+			}
+		}
+	}
+	if filter.maxBlocks > 0 && len(discovered) > filter.maxBlocks {
+		return nil, nil, errors.Wrapf(ErrTooManyBlocksToScan, "found %d blocks to fetch, exceeding the limit of %d blocks", len(discovered), filter.maxBlocks)
+	}
+
 	// Remaining blocks are new ones and we have to fetch the meta.json for each of them, in order
 	// to find out if their upload has been completed (meta.json is uploaded last) and get the block
 	// information to store in the bucket index.
@@ -153,6 +222,11 @@ func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Blo
 	}
 
 	block := BlockFromThanosMeta(m)
+	if w.replacedBlocksHints {
+		for _, parent := range m.Compaction.Parents {
+			block.ReplacedBlocks = append(block.ReplacedBlocks, parent.ULID)
+		}
+	}
 
 	// Get the meta.json attributes.
 	attrs, err := w.bkt.Attributes(ctx, metaFile)
@@ -168,14 +242,53 @@ func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Blo
 	return block, nil
 }
 
-func (w *Updater) updateBlockDeletionMarks(ctx context.Context, old []*BlockDeletionMark) ([]*BlockDeletionMark, error) {
+// updateReplacedBlocks removes the replaced blocks which are no longer in the storage from the input
+// blocks, or all of them if the replaced blocks hints are disabled. Since the input blocks may be shared
+// with the old index, the blocks to update are copied.
+func (w *Updater) updateReplacedBlocks(blocks []*Block) []*Block {
+	blockIDs := make(map[ulid.ULID]struct{}, len(blocks))
+	for _, b := range blocks {
+		blockIDs[b.ID] = struct{}{}
+	}
+
+	for i, b := range blocks {
+		if len(b.ReplacedBlocks) == 0 {
+			continue
+		}
+
+		var replaced []ulid.ULID
+		if w.replacedBlocksHints {
+			for _, id := range b.ReplacedBlocks {
+				if _, ok := blockIDs[id]; ok {
+					replaced = append(replaced, id)
+				}
+			}
+		}
+
+		if len(replaced) == len(b.ReplacedBlocks) {
+			continue
+		}
+
+		updated := *b
+		updated.ReplacedBlocks = replaced
+		blocks[i] = &updated
+	}
+
+	return blocks
+}
+
+// updateBlockDeletionMarks returns the deletion marks in the storage. If blockIDs is not nil, only the deletion
+// marks of the input blocks are returned.
+func (w *Updater) updateBlockDeletionMarks(ctx context.Context, old []*BlockDeletionMark, blockIDs map[ulid.ULID]struct{}) ([]*BlockDeletionMark, error) {
 	out := make([]*BlockDeletionMark, 0, len(old))
 	discovered := map[ulid.ULID]struct{}{}
 
 	// Find all markers in the storage.
 	err := w.bkt.Iter(ctx, MarkersPathname+"/", func(name string) error {
 		if blockID, ok := IsBlockDeletionMarkFilename(path.Base(name)); ok {
-			discovered[blockID] = struct{}{}
+			if _, ok := blockIDs[blockID]; ok || blockIDs == nil {
+				discovered[blockID] = struct{}{}
+			}
 		}
 		return nil
 	})
@@ -8,6 +8,7 @@ package bucketindex
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"path"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
@@ -69,6 +71,111 @@ func TestUpdater_UpdateIndex(t *testing.T) {
 		[]*metadata.DeletionMark{block4Mark})
 }
 
+func TestUpdater_UpdateIndex_ReplacedBlocksHints(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt = BucketWithGlobalMarkers(bkt)
+	block1 := testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block2 := testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+
+	// Mock a block compacted from block1 and block2.
+	block3 := testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 30, nil)
+	block3.Compaction.Level = 2
+	block3.Compaction.Parents = []tsdb.BlockDesc{
+		{ULID: block1.ULID, MinTime: block1.MinTime, MaxTime: block1.MaxTime},
+		{ULID: block2.ULID, MinTime: block2.MinTime, MaxTime: block2.MaxTime},
+	}
+	metaContent, err := json.Marshal(block3)
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, block3.ULID.String(), block.MetaFilename), bytes.NewReader(metaContent)))
+
+	getReplacedBlocks := func(idx *Index, id ulid.ULID) []ulid.ULID {
+		for _, b := range idx.Blocks {
+			if b.ID == id {
+				return b.ReplacedBlocks
+			}
+		}
+		return nil
+	}
+
+	t.Run("should not track the replaced blocks if disabled", func(t *testing.T) {
+		w := NewUpdater(bkt, userID, nil, logger)
+		returnedIdx, _, err := w.UpdateIndex(ctx, nil)
+		require.NoError(t, err)
+		assertBucketIndexEqual(t, returnedIdx, bkt, userID, []metadata.Meta{block1, block2, block3}, nil)
+	})
+
+	t.Run("should track the replaced blocks until they're deleted from the storage", func(t *testing.T) {
+		w := NewUpdater(bkt, userID, nil, logger).WithReplacedBlocksHints(true)
+		returnedIdx, _, err := w.UpdateIndex(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []ulid.ULID{block1.ULID, block2.ULID}, getReplacedBlocks(returnedIdx, block3.ULID))
+
+		// Hard delete a replaced block and update the index.
+		require.NoError(t, block.Delete(ctx, log.NewNopLogger(), bucket.NewUserBucketClient(userID, bkt, nil), block1.ULID))
+
+		updatedIdx, _, err := w.UpdateIndex(ctx, returnedIdx)
+		require.NoError(t, err)
+		assert.Equal(t, []ulid.ULID{block2.ULID}, getReplacedBlocks(updatedIdx, block3.ULID))
+
+		// The old index should not be modified.
+		assert.Equal(t, []ulid.ULID{block1.ULID, block2.ULID}, getReplacedBlocks(returnedIdx, block3.ULID))
+
+		// Disabling the hints should clear the replaced blocks already tracked.
+		updatedIdx, _, err = w.WithReplacedBlocksHints(false).UpdateIndex(ctx, updatedIdx)
+		require.NoError(t, err)
+		assert.Empty(t, getReplacedBlocks(updatedIdx, block3.ULID))
+	})
+}
+
+func TestUpdater_ScanIndex(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt = BucketWithGlobalMarkers(bkt)
+	block1 := testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 10, 20, nil)
+	block1Mark := testutil.MockStorageDeletionMark(t, bkt, userID, block1.BlockMeta)
+	block2 := testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 20, 30, nil)
+	block2Mark := testutil.MockStorageDeletionMark(t, bkt, userID, block2.BlockMeta)
+	block3 := testutil.MockStorageBlockWithExtLabels(t, bkt, userID, 30, 40, nil)
+
+	w := NewUpdater(bkt, userID, nil, logger)
+
+	t.Run("should only fetch the blocks created at or after min time, and their deletion marks", func(t *testing.T) {
+		returnedIdx, _, err := w.ScanIndex(ctx, nil, 30, 0)
+		require.NoError(t, err)
+		assertBucketIndexEqual(t, returnedIdx, bkt, userID,
+			[]metadata.Meta{block2, block3},
+			[]*metadata.DeletionMark{block2Mark})
+	})
+
+	t.Run("should keep the blocks of the old index", func(t *testing.T) {
+		oldIdx, _, err := w.UpdateIndex(ctx, nil)
+		require.NoError(t, err)
+		oldIdx.RemoveBlock(block3.ULID)
+
+		returnedIdx, _, err := w.ScanIndex(ctx, oldIdx, 40, 1)
+		require.NoError(t, err)
+		assertBucketIndexEqual(t, returnedIdx, bkt, userID,
+			[]metadata.Meta{block1, block2, block3},
+			[]*metadata.DeletionMark{block1Mark, block2Mark})
+	})
+
+	t.Run("should fail if there are more blocks to fetch than the limit", func(t *testing.T) {
+		_, _, err := w.ScanIndex(ctx, nil, 0, 2)
+		require.ErrorIs(t, err, ErrTooManyBlocksToScan)
+	})
+}
+
 func TestUpdater_UpdateIndex_ShouldSkipPartialBlocks(t *testing.T) {
 	const userID = "user-1"
 
@@ -251,6 +358,8 @@ func assertBucketIndexEqual(t testing.TB, idx *Index, bkt objstore.Bucket, userI
 			MaxTime:          b.MaxTime,
 			UploadedAt:       getBlockUploadedAt(t, bkt, userID, b.ULID),
 			CompactorShardID: b.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel],
+			CompactionLevel:  b.Compaction.Level,
+			Resolution:       b.Thanos.Downsample.Resolution,
 		})
 	}
 
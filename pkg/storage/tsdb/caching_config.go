@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/log"
 	"github.com/golang/snappy"
 	"github.com/grafana/dskit/cache"
@@ -25,6 +26,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketcache"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/util/cachedebug"
 )
 
 type ChunksCacheConfig struct {
@@ -35,6 +37,11 @@ type ChunksCacheConfig struct {
 	AttributesTTL              time.Duration `yaml:"attributes_ttl" category:"advanced"`
 	AttributesInMemoryMaxItems int           `yaml:"attributes_in_memory_max_items" category:"advanced"`
 	SubrangeTTL                time.Duration `yaml:"subrange_ttl" category:"advanced"`
+
+	FineGrainedChunksCachingEnabled bool `yaml:"fine_grained_chunks_caching_enabled" category:"experimental"`
+
+	RecentBlocksMaxAge               time.Duration `yaml:"recent_blocks_max_age" category:"experimental"`
+	RecentBlocksInMemoryMaxSizeBytes uint64        `yaml:"recent_blocks_inmemory_max_size_bytes" category:"experimental"`
 }
 
 func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
@@ -46,13 +53,38 @@ func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 	f.IntVar(&cfg.MaxGetRangeRequests, prefix+"max-get-range-requests", 3, "Maximum number of sub-GetRange requests that a single GetRange request can be split into when fetching chunks. Zero or negative value = unlimited number of sub-requests.")
 	f.DurationVar(&cfg.AttributesTTL, prefix+"attributes-ttl", 168*time.Hour, "TTL for caching object attributes for chunks. If the metadata cache is configured, attributes will be stored under this cache backend, otherwise attributes are stored in the chunks cache backend.")
 	f.IntVar(&cfg.AttributesInMemoryMaxItems, prefix+"attributes-in-memory-max-items", 50000, "Maximum number of object attribute items to keep in a first level in-memory LRU cache. Metadata will be stored and fetched in-memory before hitting the cache backend. 0 to disable the in-memory cache.")
-	f.DurationVar(&cfg.SubrangeTTL, prefix+"subrange-ttl", 24*time.Hour, "TTL for caching individual chunks subranges.")
+	f.DurationVar(&cfg.SubrangeTTL, prefix+"subrange-ttl", 24*time.Hour, "TTL for caching individual chunks subranges, or individual chunks when fine-grained chunks caching is enabled.")
+	f.BoolVar(&cfg.FineGrainedChunksCachingEnabled, prefix+"fine-grained-chunks-caching-enabled", false, "If enabled, the store-gateway caches individual chunks, keyed by block ID and chunk reference, instead of subranges of the chunks files. Chunks are looked up in the cache before being fetched from the bucket.")
+	f.DurationVar(&cfg.RecentBlocksMaxAge, prefix+"recent-blocks-max-age", 0, "If greater than 0, the chunks of the blocks created more recently than this age are cached in memory instead of in the configured backend. The age of a block is computed from the creation time encoded in its ID. 0 to cache the chunks of all the blocks in the configured backend.")
+	f.Uint64Var(&cfg.RecentBlocksInMemoryMaxSizeBytes, prefix+"recent-blocks-inmemory-max-size-bytes", uint64(1*units.Gibibyte), "Maximum size in bytes of the in-memory cache for the chunks of the recent blocks. This option is used only when -"+prefix+"recent-blocks-max-age is greater than 0.")
 }
 
 func (cfg *ChunksCacheConfig) Validate() error {
 	return cfg.BackendConfig.Validate()
 }
 
+// PostingsCacheConfig configures the in-memory cache of the expanded postings of the blocks.
+type PostingsCacheConfig struct {
+	Enabled          bool          `yaml:"enabled" category:"experimental"`
+	MaxSizeBytes     uint64        `yaml:"max_size_bytes" category:"experimental"`
+	MaxItemSizeBytes uint64        `yaml:"max_item_size_bytes" category:"experimental"`
+	TTL              time.Duration `yaml:"ttl" category:"experimental"`
+}
+
+func (cfg *PostingsCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "If enabled, the store-gateway caches in memory the expanded postings of each block for each set of label matchers, in front of the index cache. The cached postings of a block are removed once the block is marked for deletion.")
+	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", uint64(256*units.Mebibyte), "Maximum size in bytes of the postings cache.")
+	f.Uint64Var(&cfg.MaxItemSizeBytes, prefix+"max-item-size-bytes", uint64(16*units.Mebibyte), "Maximum size in bytes of the expanded postings of a block for a set of label matchers to be cached.")
+	f.DurationVar(&cfg.TTL, prefix+"ttl", time.Hour, "TTL of the expanded postings in the postings cache.")
+}
+
+func (cfg *PostingsCacheConfig) Validate() error {
+	if cfg.Enabled && cfg.MaxItemSizeBytes > cfg.MaxSizeBytes {
+		return errInvalidPostingsCacheMaxItemSize
+	}
+	return nil
+}
+
 type MetadataCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
 
@@ -91,15 +123,74 @@ func (cfg *MetadataCacheConfig) Validate() error {
 	return cfg.BackendConfig.Validate()
 }
 
-func CreateCachingBucket(chunksConfig ChunksCacheConfig, metadataConfig MetadataCacheConfig, bkt objstore.Bucket, logger log.Logger, reg prometheus.Registerer) (objstore.Bucket, error) {
-	cfg := bucketcache.NewCachingBucketConfig()
-	cachingConfigured := false
+// LocalCacheConfig configures the cache, on the local disk, of the small objects frequently read from the bucket.
+type LocalCacheConfig struct {
+	Enabled            bool          `yaml:"enabled" category:"experimental"`
+	Dir                string        `yaml:"dir" category:"experimental"`
+	ValidationInterval time.Duration `yaml:"validation_interval" category:"experimental"`
+	MaxObjectSizeBytes int64         `yaml:"max_object_size_bytes" category:"experimental"`
+}
+
+func (cfg *LocalCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "If enabled, the compactor and store-gateway keep a copy on the local disk of the block meta files, deletion marks and bucket indexes read from or written to the bucket. A local copy is validated against the size and last modified time of the object in the bucket before being used, and fetched again if they differ.")
+	f.StringVar(&cfg.Dir, prefix+"dir", "./bucket-local-cache/", "Directory to store the local copies of the objects in.")
+	f.DurationVar(&cfg.ValidationInterval, prefix+"validation-interval", time.Minute, "How long a local copy is used without validating it against the object in the bucket after its last validation. 0 to validate it every time it's read.")
+	f.Int64Var(&cfg.MaxObjectSizeBytes, prefix+"max-object-size-bytes", int64(10*units.Mebibyte), "Maximum size in bytes of an object to be stored in the local cache.")
+}
+
+func (cfg *LocalCacheConfig) Validate() error {
+	if cfg.Enabled && cfg.Dir == "" {
+		return errInvalidLocalCacheDir
+	}
+	if cfg.Enabled && cfg.MaxObjectSizeBytes <= 0 {
+		return errInvalidLocalCacheMaxObjectSize
+	}
+	return nil
+}
 
-	chunksCache, err := cache.CreateClient("chunks-cache", chunksConfig.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+// CreateLocalCachingBucket wraps the input bucket with the local disk cache of the block meta files, deletion
+// marks and bucket indexes, if enabled.
+func CreateLocalCachingBucket(cfg LocalCacheConfig, bkt objstore.Bucket, logger log.Logger, reg prometheus.Registerer) (objstore.Bucket, error) {
+	if !cfg.Enabled {
+		return bkt, nil
+	}
+
+	return bucketcache.NewLocalCachingBucket(bkt, cfg.Dir, isLocallyCachedFile, cfg.ValidationInterval, cfg.MaxObjectSizeBytes, logger, reg)
+}
+
+// CreateChunksCacheClient creates the client of the configured chunks cache. Returns nil if the chunks
+// cache is not configured. If caching the chunks of the recent blocks in memory is enabled, the returned
+// client routes the chunks of the recent blocks to the in-memory cache.
+func CreateChunksCacheClient(chunksConfig ChunksCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+	client, err := cache.CreateClient("chunks-cache", chunksConfig.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	if err != nil {
 		return nil, errors.Wrapf(err, "chunks-cache")
 	}
 
+	if client != nil && chunksConfig.RecentBlocksMaxAge > 0 {
+		recent := newInMemoryCache("chunks-cache-recent-blocks", chunksConfig.RecentBlocksInMemoryMaxSizeBytes, reg)
+		client = newBlockAgeTieredCache(recent, client, chunksConfig.RecentBlocksMaxAge)
+	}
+	return client, nil
+}
+
+// CreateCachingBucket wraps the input bucket with the configured chunks and metadata caches. If debugKeys is enabled,
+// the keys fetched from the caches by sampled traced requests are recorded in their span.
+func CreateCachingBucket(chunksConfig ChunksCacheConfig, metadataConfig MetadataCacheConfig, debugKeys bool, bkt objstore.Bucket, logger log.Logger, reg prometheus.Registerer) (objstore.Bucket, error) {
+	cfg := bucketcache.NewCachingBucketConfig()
+	cachingConfigured := false
+
+	var chunksCache cache.Cache
+	if !chunksConfig.FineGrainedChunksCachingEnabled {
+		// When fine-grained chunks caching is enabled, the chunks cache is used by the store-gateway to cache
+		// individual chunks, instead of by the caching bucket.
+		var err error
+		chunksCache, err = CreateChunksCacheClient(chunksConfig, logger, reg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	metadataCache, err := cache.CreateClient("metadata-cache", metadataConfig.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	if err != nil {
 		return nil, errors.Wrapf(err, "metadata-cache")
@@ -107,6 +198,9 @@ func CreateCachingBucket(chunksConfig ChunksCacheConfig, metadataConfig Metadata
 	if metadataCache != nil {
 		cachingConfigured = true
 		metadataCache = cache.NewSpanlessTracingCache(metadataCache, logger, tenant.NewMultiResolver())
+		if debugKeys {
+			metadataCache = cachedebug.NewKeysRecordingCache(metadataCache)
+		}
 
 		cfg.CacheExists("metafile", metadataCache, isMetaFile, metadataConfig.MetafileExistsTTL, metadataConfig.MetafileDoesntExistTTL)
 		cfg.CacheGet("metafile", metadataCache, isMetaFile, metadataConfig.MetafileMaxSize, metadataConfig.MetafileContentTTL, metadataConfig.MetafileExistsTTL, metadataConfig.MetafileDoesntExistTTL)
@@ -123,6 +217,9 @@ func CreateCachingBucket(chunksConfig ChunksCacheConfig, metadataConfig Metadata
 	if chunksCache != nil {
 		cachingConfigured = true
 		chunksCache = cache.NewSpanlessTracingCache(chunksCache, logger, tenant.NewMultiResolver())
+		if debugKeys {
+			chunksCache = cachedebug.NewKeysRecordingCache(chunksCache)
+		}
 
 		// Use the metadata cache for attributes if configured, otherwise fallback to chunks cache.
 		// If in-memory cache is enabled, wrap the attributes cache with the in-memory LRU cache.
@@ -157,6 +254,10 @@ func isMetaFile(name string) bool {
 	return strings.HasSuffix(name, "/"+metadata.MetaFilename) || strings.HasSuffix(name, "/"+metadata.DeletionMarkFilename) || strings.HasSuffix(name, "/"+TenantDeletionMarkPath)
 }
 
+func isLocallyCachedFile(name string) bool {
+	return isMetaFile(name) || isBucketIndexFile(name)
+}
+
 func isBlockIndexFile(name string) bool {
 	// Ensure the path ends with "<block id>/<index filename>".
 	if !strings.HasSuffix(name, "/"+block.IndexFilename) {
@@ -13,6 +13,7 @@ import (
 
 	"github.com/alecthomas/units"
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/wal"
@@ -65,17 +66,41 @@ const (
 	// DefaultPartitionerMaxGapSize is the default max size - in bytes - of a gap for which the store-gateway
 	// partitioner aggregates together two bucket GET object requests.
 	DefaultPartitionerMaxGapSize = uint64(512 * 1024)
+
+	// DefaultStreamingChunksSlabSize is the default number of chunks per slab of the memory pool used by the store-gateway
+	// to allocate the chunks of the series of a batch. Mimir compacts blocks up to 24h. Assuming a 5s scrape interval as
+	// worst case scenario, and 120 samples per chunk, there could be 86400 * (1 / 5) * (1 / 120) = 144 chunks for a series
+	// in the biggest block. Using a slab size of 1000 looks a good trade-off to support high frequency scraping without
+	// wasting too much memory in case of queries hitting a low number of chunks (across series).
+	DefaultStreamingChunksSlabSize = 1000
 )
 
 // Validation errors
 var (
-	errInvalidShipConcurrency       = errors.New("invalid TSDB ship concurrency")
-	errInvalidOpeningConcurrency    = errors.New("invalid TSDB opening concurrency")
-	errInvalidCompactionInterval    = errors.New("invalid TSDB compaction interval")
-	errInvalidCompactionConcurrency = errors.New("invalid TSDB compaction concurrency")
-	errInvalidWALSegmentSizeBytes   = errors.New("invalid TSDB WAL segment size bytes")
-	errInvalidStripeSize            = errors.New("invalid TSDB stripe size")
-	errEmptyBlockranges             = errors.New("empty block ranges for TSDB")
+	errInvalidShipConcurrency                 = errors.New("invalid TSDB ship concurrency")
+	errInvalidOpeningConcurrency              = errors.New("invalid TSDB opening concurrency")
+	errInvalidCompactionInterval              = errors.New("invalid TSDB compaction interval")
+	errInvalidCompactionConcurrency           = errors.New("invalid TSDB compaction concurrency")
+	errInvalidWALSegmentSizeBytes             = errors.New("invalid TSDB WAL segment size bytes")
+	errInvalidStripeSize                      = errors.New("invalid TSDB stripe size")
+	errInvalidEarlyHeadCompactionMinSampleAge = errors.New("invalid TSDB early head compaction min sample age, must be greater than 0")
+	errEmptyBlockranges                       = errors.New("empty block ranges for TSDB")
+
+	errInvalidStreamingAdaptivePreloadingMaxBytes    = errors.New("invalid bucket store series streaming adaptive preloading max bytes")
+	errStreamingEagerSendingWithAdaptivePreloading   = errors.New("bucket store series streaming eager sending and adaptive preloading can't be both enabled")
+	errInvalidStreamingChunksSlabSize                = errors.New("invalid bucket store series streaming chunks slab size")
+	errInvalidChunksFetchConcurrency                 = errors.New("invalid bucket store chunks fetch concurrency")
+	errInvalidChunksFetchAdaptiveMaxConcurrency      = errors.New("invalid bucket store chunks fetch adaptive max concurrency")
+	errInvalidChunksFetchAdaptiveMaxInflightRequests = errors.New("invalid bucket store chunks fetch adaptive max inflight requests")
+	errInvalidChunksFetchHedgingQuantile             = errors.New("invalid bucket store chunks fetch hedging quantile, must be greater than 0 and lower than 1")
+	errInvalidChunksFetchHedgingMinDelay             = errors.New("invalid bucket store chunks fetch hedging min delay, must be greater than 0")
+	errInvalidIndexHeaderFormatVersion               = errors.New("invalid bucket store index-header format version")
+	errIndexHeaderFormatV3RequiresStreamReader       = errors.New("bucket store index-header format version 3 requires the index-header streaming reader")
+	errInvalidPostingsCacheMaxItemSize               = errors.New("the postings cache max item size cannot be bigger than the max size")
+	errInvalidBucketIndexFallbackScanMaxBlocks       = errors.New("invalid bucket index fallback scan max blocks")
+	errInvalidBucketIndexFallbackScanFailures        = errors.New("invalid bucket index fallback scan failures threshold")
+	errInvalidLocalCacheDir                          = errors.New("the bucket local cache directory must be set when the local cache is enabled")
+	errInvalidLocalCacheMaxObjectSize                = errors.New("invalid bucket local cache max object size, must be greater than 0")
 )
 
 // BlocksStorageConfig holds the config information for the blocks storage.
@@ -83,6 +108,7 @@ type BlocksStorageConfig struct {
 	Bucket      bucket.Config     `yaml:",inline"`
 	BucketStore BucketStoreConfig `yaml:"bucket_store" doc:"description=This configures how the querier and store-gateway discover and synchronize blocks stored in the bucket."`
 	TSDB        TSDBConfig        `yaml:"tsdb"`
+	LocalCache  LocalCacheConfig  `yaml:"local_cache" doc:"description=This configures the cache, on the local disk, of the small objects frequently read from the bucket by the compactor and store-gateway."`
 }
 
 // DurationList is the block ranges for a tsdb
@@ -127,6 +153,7 @@ func (cfg *BlocksStorageConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger
 	cfg.Bucket.RegisterFlagsWithPrefixAndDefaultDirectory("blocks-storage.", "blocks", f, logger)
 	cfg.BucketStore.RegisterFlags(f)
 	cfg.TSDB.RegisterFlags(f)
+	cfg.LocalCache.RegisterFlagsWithPrefix(f, "blocks-storage.local-cache.")
 }
 
 // Validate the config.
@@ -139,6 +166,10 @@ func (cfg *BlocksStorageConfig) Validate() error {
 		return err
 	}
 
+	if err := cfg.LocalCache.Validate(); err != nil {
+		return err
+	}
+
 	return cfg.BucketStore.Validate()
 }
 
@@ -146,23 +177,26 @@ func (cfg *BlocksStorageConfig) Validate() error {
 //
 //nolint:revive
 type TSDBConfig struct {
-	Dir                       string        `yaml:"dir"`
-	BlockRanges               DurationList  `yaml:"block_ranges_period" category:"experimental" doc:"hidden"`
-	Retention                 time.Duration `yaml:"retention_period"`
-	ShipInterval              time.Duration `yaml:"ship_interval" category:"advanced"`
-	ShipConcurrency           int           `yaml:"ship_concurrency" category:"advanced"`
-	HeadCompactionInterval    time.Duration `yaml:"head_compaction_interval" category:"advanced"`
-	HeadCompactionConcurrency int           `yaml:"head_compaction_concurrency" category:"advanced"`
-	HeadCompactionIdleTimeout time.Duration `yaml:"head_compaction_idle_timeout" category:"advanced"`
-	HeadChunksWriteBufferSize int           `yaml:"head_chunks_write_buffer_size_bytes" category:"advanced"`
-	HeadChunksEndTimeVariance float64       `yaml:"head_chunks_end_time_variance" category:"experimental"`
-	StripeSize                int           `yaml:"stripe_size" category:"advanced"`
-	WALCompressionEnabled     bool          `yaml:"wal_compression_enabled" category:"advanced"`
-	WALSegmentSizeBytes       int           `yaml:"wal_segment_size_bytes" category:"advanced"`
-	FlushBlocksOnShutdown     bool          `yaml:"flush_blocks_on_shutdown" category:"advanced"`
-	CloseIdleTSDBTimeout      time.Duration `yaml:"close_idle_tsdb_timeout" category:"advanced"`
-	MemorySnapshotOnShutdown  bool          `yaml:"memory_snapshot_on_shutdown" category:"experimental"`
-	HeadChunksWriteQueueSize  int           `yaml:"head_chunks_write_queue_size" category:"advanced"`
+	Dir                                     string        `yaml:"dir"`
+	BlockRanges                             DurationList  `yaml:"block_ranges_period" category:"experimental" doc:"hidden"`
+	Retention                               time.Duration `yaml:"retention_period"`
+	ShipInterval                            time.Duration `yaml:"ship_interval" category:"advanced"`
+	ShipConcurrency                         int           `yaml:"ship_concurrency" category:"advanced"`
+	ShipExemplars                           bool          `yaml:"ship_exemplars" category:"experimental"`
+	HeadCompactionInterval                  time.Duration `yaml:"head_compaction_interval" category:"advanced"`
+	HeadCompactionConcurrency               int           `yaml:"head_compaction_concurrency" category:"advanced"`
+	HeadCompactionIdleTimeout               time.Duration `yaml:"head_compaction_idle_timeout" category:"advanced"`
+	EarlyHeadCompactionMemoryThresholdBytes uint64        `yaml:"early_head_compaction_memory_threshold_bytes" category:"experimental"`
+	EarlyHeadCompactionMinSampleAge         time.Duration `yaml:"early_head_compaction_min_sample_age" category:"experimental"`
+	HeadChunksWriteBufferSize               int           `yaml:"head_chunks_write_buffer_size_bytes" category:"advanced"`
+	HeadChunksEndTimeVariance               float64       `yaml:"head_chunks_end_time_variance" category:"experimental"`
+	StripeSize                              int           `yaml:"stripe_size" category:"advanced"`
+	WALCompressionEnabled                   bool          `yaml:"wal_compression_enabled" category:"advanced"`
+	WALSegmentSizeBytes                     int           `yaml:"wal_segment_size_bytes" category:"advanced"`
+	FlushBlocksOnShutdown                   bool          `yaml:"flush_blocks_on_shutdown" category:"advanced"`
+	CloseIdleTSDBTimeout                    time.Duration `yaml:"close_idle_tsdb_timeout" category:"advanced"`
+	MemorySnapshotOnShutdown                bool          `yaml:"memory_snapshot_on_shutdown" category:"experimental"`
+	HeadChunksWriteQueueSize                int           `yaml:"head_chunks_write_queue_size" category:"advanced"`
 
 	// Series hash cache.
 	SeriesHashCacheMaxBytes uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
@@ -192,11 +226,14 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.Retention, "blocks-storage.tsdb.retention-period", 24*time.Hour, "TSDB blocks retention in the ingester before a block is removed, relative to the newest block written for the tenant. This should be larger than the -blocks-storage.tsdb.block-ranges-period, -querier.query-store-after and large enough to give store-gateways and queriers enough time to discover newly uploaded blocks.")
 	f.DurationVar(&cfg.ShipInterval, "blocks-storage.tsdb.ship-interval", 1*time.Minute, "How frequently the TSDB blocks are scanned and new ones are shipped to the storage. 0 means shipping is disabled.")
 	f.IntVar(&cfg.ShipConcurrency, "blocks-storage.tsdb.ship-concurrency", 10, "Maximum number of tenants concurrently shipping blocks to the storage.")
+	f.BoolVar(&cfg.ShipExemplars, "blocks-storage.tsdb.ship-exemplars", false, "True to persist the in-memory exemplars of each TSDB block in a sidecar file shipped along with the block, so that exemplars can be queried from the store-gateways after the ingesters retention. Only the exemplars still held in memory when the block is shipped are persisted.")
 	f.Uint64Var(&cfg.SeriesHashCacheMaxBytes, "blocks-storage.tsdb.series-hash-cache-max-size-bytes", uint64(1*units.Gibibyte), "Max size - in bytes - of the in-memory series hash cache. The cache is shared across all tenants and it's used only when query sharding is enabled.")
 	f.IntVar(&cfg.MaxTSDBOpeningConcurrencyOnStartup, "blocks-storage.tsdb.max-tsdb-opening-concurrency-on-startup", 10, "limit the number of concurrently opening TSDB's on startup")
 	f.DurationVar(&cfg.HeadCompactionInterval, "blocks-storage.tsdb.head-compaction-interval", 1*time.Minute, "How frequently ingesters try to compact TSDB head. Block is only created if data covers smallest block range. Must be greater than 0 and max 5 minutes.")
 	f.IntVar(&cfg.HeadCompactionConcurrency, "blocks-storage.tsdb.head-compaction-concurrency", 1, "Maximum number of tenants concurrently compacting TSDB head into a new block")
 	f.DurationVar(&cfg.HeadCompactionIdleTimeout, "blocks-storage.tsdb.head-compaction-idle-timeout", 1*time.Hour, "If TSDB head is idle for this duration, it is compacted. Note that up to 25% jitter is added to the value to avoid ingesters compacting concurrently. 0 means disabled.")
+	f.Uint64Var(&cfg.EarlyHeadCompactionMemoryThresholdBytes, "blocks-storage.tsdb.early-head-compaction-memory-threshold-bytes", 0, "When the memory - in bytes - used by the Go heap of the ingester is above this threshold, the samples in the TSDB heads older than -blocks-storage.tsdb.early-head-compaction-min-sample-age are compacted into blocks and shipped to the storage, without waiting for the regular head compaction, to release memory instead of hitting the memory limit. Samples older than the compacted range can't be ingested anymore. 0 means disabled.")
+	f.DurationVar(&cfg.EarlyHeadCompactionMinSampleAge, "blocks-storage.tsdb.early-head-compaction-min-sample-age", 15*time.Minute, "Minimum age of the samples compacted by the early head compaction. Must be greater than 0.")
 	f.IntVar(&cfg.HeadChunksWriteBufferSize, "blocks-storage.tsdb.head-chunks-write-buffer-size-bytes", chunks.DefaultWriteBufferSize, "The write buffer size used by the head chunks mapper. Lower values reduce memory utilisation on clusters with a large number of tenants at the cost of increased disk I/O operations.")
 	f.Float64Var(&cfg.HeadChunksEndTimeVariance, "blocks-storage.tsdb.head-chunks-end-time-variance", 0, "How much variance (as percentage between 0 and 1) should be applied to the chunk end time, to spread chunks writing across time. Doesn't apply to the last chunk of the chunk range. 0 means no variance.")
 	f.IntVar(&cfg.StripeSize, "blocks-storage.tsdb.stripe-size", 16384, "The number of shards of series to use in TSDB (must be a power of 2). Reducing this will decrease memory footprint, but can negatively impact performance.")
@@ -227,6 +264,10 @@ func (cfg *TSDBConfig) Validate() error {
 		return errInvalidCompactionConcurrency
 	}
 
+	if cfg.EarlyHeadCompactionMemoryThresholdBytes > 0 && cfg.EarlyHeadCompactionMinSampleAge <= 0 {
+		return errInvalidEarlyHeadCompactionMinSampleAge
+	}
+
 	if cfg.HeadChunksWriteBufferSize < chunks.MinWriteBufferSize || cfg.HeadChunksWriteBufferSize > chunks.MaxWriteBufferSize || cfg.HeadChunksWriteBufferSize%1024 != 0 {
 		return errors.Errorf("head chunks write buffer size must be a multiple of 1024 between %d and %d", chunks.MinWriteBufferSize, chunks.MaxWriteBufferSize)
 	}
@@ -262,6 +303,7 @@ type BucketStoreConfig struct {
 	SyncDir                  string              `yaml:"sync_dir"`
 	SyncInterval             time.Duration       `yaml:"sync_interval" category:"advanced"`
 	MaxConcurrent            int                 `yaml:"max_concurrent" category:"advanced"`
+	TenantFairScheduling     bool                `yaml:"tenant_fair_scheduling_enabled" category:"experimental"`
 	TenantSyncConcurrency    int                 `yaml:"tenant_sync_concurrency" category:"advanced"`
 	BlockSyncConcurrency     int                 `yaml:"block_sync_concurrency" category:"advanced"`
 	MetaSyncConcurrency      int                 `yaml:"meta_sync_concurrency" category:"advanced"`
@@ -269,6 +311,7 @@ type BucketStoreConfig struct {
 	IndexCache               IndexCacheConfig    `yaml:"index_cache"`
 	ChunksCache              ChunksCacheConfig   `yaml:"chunks_cache"`
 	MetadataCache            MetadataCacheConfig `yaml:"metadata_cache"`
+	PostingsCache            PostingsCacheConfig `yaml:"postings_cache"`
 	IgnoreDeletionMarksDelay time.Duration       `yaml:"ignore_deletion_mark_delay" category:"advanced"`
 	BucketIndex              BucketIndexConfig   `yaml:"bucket_index"`
 	IgnoreBlocksWithin       time.Duration       `yaml:"ignore_blocks_within" category:"advanced"`
@@ -277,13 +320,24 @@ type BucketStoreConfig struct {
 	MaxChunkPoolBytes           uint64 `yaml:"max_chunk_pool_bytes" category:"advanced"`
 	ChunkPoolMinBucketSizeBytes int    `yaml:"chunk_pool_min_bucket_size_bytes" category:"advanced"`
 	ChunkPoolMaxBucketSizeBytes int    `yaml:"chunk_pool_max_bucket_size_bytes" category:"advanced"`
+	MaxInflightChunksBytes      uint64 `yaml:"max_inflight_chunks_bytes" category:"experimental"`
 
 	// Series hash cache.
-	SeriesHashCacheMaxBytes uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
+	SeriesHashCacheMaxBytes           uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
+	SeriesHashCachePersistenceEnabled bool   `yaml:"series_hash_cache_persistence_enabled" category:"experimental"`
+
+	// Series requests deduplication.
+	SeriesRequestsDeduplicationEnabled          bool   `yaml:"series_requests_deduplication_enabled" category:"experimental"`
+	SeriesRequestsDeduplicationMaxResponseBytes uint64 `yaml:"series_requests_deduplication_max_response_bytes" category:"experimental"`
+
+	// Exemplars cache.
+	ExemplarsCacheMaxSizeBytes uint64 `yaml:"exemplars_cache_max_size_bytes" category:"experimental"`
 
 	// Controls whether index-header lazy loading is enabled.
-	IndexHeaderLazyLoadingEnabled     bool          `yaml:"index_header_lazy_loading_enabled" category:"advanced"`
-	IndexHeaderLazyLoadingIdleTimeout time.Duration `yaml:"index_header_lazy_loading_idle_timeout" category:"advanced"`
+	IndexHeaderLazyLoadingEnabled             bool          `yaml:"index_header_lazy_loading_enabled" category:"advanced"`
+	IndexHeaderLazyLoadingIdleTimeout         time.Duration `yaml:"index_header_lazy_loading_idle_timeout" category:"advanced"`
+	IndexHeaderLazyLoadingMaxLoaded           int           `yaml:"index_header_lazy_loading_max_loaded" category:"experimental"`
+	IndexHeaderLazyLoadingPrefetchConcurrency int           `yaml:"index_header_lazy_loading_prefetch_concurrency" category:"experimental"`
 
 	// Controls the partitioner, used to aggregate multiple GET object API requests.
 	PartitionerMaxGapBytes uint64 `yaml:"partitioner_max_gap_bytes" category:"advanced"`
@@ -299,6 +353,35 @@ type BucketStoreConfig struct {
 	IndexHeader indexheader.Config `yaml:"index_header" category:"experimental"`
 
 	StreamingBatchSize int `yaml:"streaming_series_batch_size" category:"experimental"`
+
+	StreamingAdaptivePreloadingEnabled  bool `yaml:"streaming_series_adaptive_preloading_enabled" category:"experimental"`
+	StreamingAdaptivePreloadingMaxBytes int  `yaml:"streaming_series_adaptive_preloading_max_bytes" category:"experimental"`
+
+	StreamingEagerSendingEnabled bool `yaml:"streaming_series_eager_sending_enabled" category:"experimental"`
+
+	StreamingChunksSlabSize                int  `yaml:"streaming_series_chunks_slab_size" category:"experimental"`
+	StreamingAdaptiveChunksSlabSizeEnabled bool `yaml:"streaming_series_adaptive_chunks_slab_size_enabled" category:"experimental"`
+
+	StreamingChunksDeduplicationEnabled bool `yaml:"streaming_series_chunks_deduplication_enabled" category:"experimental"`
+
+	StreamingSlowBatchLogThreshold time.Duration `yaml:"streaming_series_slow_batch_log_threshold" category:"experimental"`
+
+	ChunksFetchConcurrency   int    `yaml:"chunks_fetch_concurrency" category:"experimental"`
+	ChunksFetchMaxRangeBytes uint64 `yaml:"chunks_fetch_max_range_bytes" category:"experimental"`
+
+	ChunksFetchAdaptiveConcurrencyEnabled  bool `yaml:"chunks_fetch_adaptive_concurrency_enabled" category:"experimental"`
+	ChunksFetchAdaptiveMaxConcurrency      int  `yaml:"chunks_fetch_adaptive_max_concurrency" category:"experimental"`
+	ChunksFetchAdaptiveMaxInflightRequests int  `yaml:"chunks_fetch_adaptive_max_inflight_requests" category:"experimental"`
+
+	ChunksFetchHedgingEnabled  bool          `yaml:"chunks_fetch_hedging_enabled" category:"experimental"`
+	ChunksFetchHedgingQuantile float64       `yaml:"chunks_fetch_hedging_quantile" category:"experimental"`
+	ChunksFetchHedgingMinDelay time.Duration `yaml:"chunks_fetch_hedging_min_delay" category:"experimental"`
+
+	DownsampledBlocksEnabled bool `yaml:"downsampled_blocks_enabled" category:"experimental"`
+
+	DebugCacheKeysEnabled bool `yaml:"debug_cache_keys_enabled" category:"experimental"`
+
+	ExternalLabelMatchers flagext.StringSliceCSV `yaml:"external_label_matchers" category:"experimental"`
 }
 
 // RegisterFlags registers the BucketStore flags
@@ -306,6 +389,7 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.IndexCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.index-cache.")
 	cfg.ChunksCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.chunks-cache.")
 	cfg.MetadataCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.metadata-cache.")
+	cfg.PostingsCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.postings-cache.")
 	cfg.BucketIndex.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.bucket-index.")
 	cfg.IndexHeader.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.index-header.")
 
@@ -314,8 +398,14 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Uint64Var(&cfg.MaxChunkPoolBytes, "blocks-storage.bucket-store.max-chunk-pool-bytes", uint64(2*units.Gibibyte), "Max size - in bytes - of a chunks pool, used to reduce memory allocations. The pool is shared across all tenants. 0 to disable the limit.")
 	f.IntVar(&cfg.ChunkPoolMinBucketSizeBytes, "blocks-storage.bucket-store.chunk-pool-min-bucket-size-bytes", ChunkPoolDefaultMinBucketSize, "Size - in bytes - of the smallest chunks pool bucket.")
 	f.IntVar(&cfg.ChunkPoolMaxBucketSizeBytes, "blocks-storage.bucket-store.chunk-pool-max-bucket-size-bytes", ChunkPoolDefaultMaxBucketSize, "Size - in bytes - of the largest chunks pool bucket.")
+	f.Uint64Var(&cfg.MaxInflightChunksBytes, "blocks-storage.bucket-store.max-inflight-chunks-bytes", 0, "Max size - in bytes - of the chunks held by the in-flight Series() requests, across all tenants, after which new Series() requests are rejected with a resource exhausted error until some memory is released. The chunks memory is tracked through the chunks pool. 0 to disable the limit.")
 	f.Uint64Var(&cfg.SeriesHashCacheMaxBytes, "blocks-storage.bucket-store.series-hash-cache-max-size-bytes", uint64(1*units.Gibibyte), "Max size - in bytes - of the in-memory series hash cache. The cache is shared across all tenants and it's used only when query sharding is enabled.")
+	f.BoolVar(&cfg.SeriesHashCachePersistenceEnabled, "blocks-storage.bucket-store.series-hash-cache-persistence-enabled", false, "If enabled, the series hashes computed by the sharded queries are kept for each block, in addition to the series hash cache, and periodically persisted in the local directory of the block along with the index-header, so that the sharded queries skip the series not belonging to the requested shard without hashing their labels, even after a restart. The hashes kept for each block are not bounded by -blocks-storage.bucket-store.series-hash-cache-max-size-bytes, and are released only when the block is unloaded.")
+	f.BoolVar(&cfg.SeriesRequestsDeduplicationEnabled, "blocks-storage.bucket-store.series-requests-deduplication-enabled", false, "If enabled, identical Series() requests (same tenant, matchers, time range, shard and hints) received concurrently are collapsed into a single execution, whose responses are sent to all the requests.")
+	f.Uint64Var(&cfg.SeriesRequestsDeduplicationMaxResponseBytes, "blocks-storage.bucket-store.series-requests-deduplication-max-response-bytes", uint64(64*units.Mebibyte), "Max size - in bytes - of the responses of a Series() request kept in memory to be sent to the identical requests received concurrently. The identical requests of a Series() request whose responses exceed this size are executed on their own.")
+	f.Uint64Var(&cfg.ExemplarsCacheMaxSizeBytes, "blocks-storage.bucket-store.exemplars-cache-max-size-bytes", uint64(256*units.Mebibyte), "Max size - in bytes - of the in-memory cache of the exemplars read from the exemplars files of the blocks. The cache is shared across all tenants. The exemplars of the blocks which don't fit in the cache are read from the object storage on each exemplars query.")
 	f.IntVar(&cfg.MaxConcurrent, "blocks-storage.bucket-store.max-concurrent", 100, "Max number of concurrent queries to execute against the long-term storage. The limit is shared across all tenants.")
+	f.BoolVar(&cfg.TenantFairScheduling, "blocks-storage.bucket-store.tenant-fair-scheduling-enabled", false, "If enabled, when the queries have to wait because the max number of concurrent queries is reached, the free slots are allocated to the tenants in proportion to their -store-gateway.query-concurrency-weight, instead of in arrival order.")
 	f.IntVar(&cfg.TenantSyncConcurrency, "blocks-storage.bucket-store.tenant-sync-concurrency", 10, "Maximum number of concurrent tenants synching blocks.")
 	f.IntVar(&cfg.BlockSyncConcurrency, "blocks-storage.bucket-store.block-sync-concurrency", 20, "Maximum number of concurrent blocks synching per tenant.")
 	f.IntVar(&cfg.MetaSyncConcurrency, "blocks-storage.bucket-store.meta-sync-concurrency", 20, "Number of Go routines to use when syncing block meta files from object storage per tenant.")
@@ -326,8 +416,28 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.PostingOffsetsInMemSampling, "blocks-storage.bucket-store.posting-offsets-in-mem-sampling", DefaultPostingOffsetInMemorySampling, "Controls what is the ratio of postings offsets that the store will hold in memory.")
 	f.BoolVar(&cfg.IndexHeaderLazyLoadingEnabled, "blocks-storage.bucket-store.index-header-lazy-loading-enabled", true, "If enabled, store-gateway will lazy load an index-header only once required by a query.")
 	f.DurationVar(&cfg.IndexHeaderLazyLoadingIdleTimeout, "blocks-storage.bucket-store.index-header-lazy-loading-idle-timeout", 60*time.Minute, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway will offload unused index-headers after 'idle timeout' inactivity.")
+	f.IntVar(&cfg.IndexHeaderLazyLoadingMaxLoaded, "blocks-storage.bucket-store.index-header-lazy-loading-max-loaded", 0, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway keeps at most this number of index-headers loaded per tenant at the same time, offloading the least recently used ones when the limit is exceeded. 0 means no limit.")
+	f.IntVar(&cfg.IndexHeaderLazyLoadingPrefetchConcurrency, "blocks-storage.bucket-store.index-header-lazy-loading-prefetch-concurrency", 0, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway prefetches in background the index-headers of the blocks discovered by each blocks sync, loading at most this number of index-headers concurrently across all tenants. 0 disables the prefetching.")
 	f.Uint64Var(&cfg.PartitionerMaxGapBytes, "blocks-storage.bucket-store.partitioner-max-gap-bytes", DefaultPartitionerMaxGapSize, "Max size - in bytes - of a gap for which the partitioner aggregates together two bucket GET object requests.")
 	f.IntVar(&cfg.StreamingBatchSize, "blocks-storage.bucket-store.batch-series-size", 0, "If larger than 0, this option enables store-gateway series streaming. The store-gateway will load series from the bucket in batches instead of buffering them all in memory before returning to the querier. This option controls how many series to fetch per batch.")
+	f.BoolVar(&cfg.StreamingAdaptivePreloadingEnabled, "blocks-storage.bucket-store.batch-series-adaptive-preloading-enabled", false, "If enabled and series streaming is enabled, the number of series batches preloaded ahead adapts to the time the store-gateway waits for preloaded batches compared to the time it takes to load them, instead of always preloading one batch.")
+	f.IntVar(&cfg.StreamingAdaptivePreloadingMaxBytes, "blocks-storage.bucket-store.batch-series-adaptive-preloading-max-bytes", int(64*units.Mebibyte), "Max size - in bytes - of the chunks of the series batches preloaded ahead for each request when adaptive preloading is enabled. At least one batch is always preloaded.")
+	f.BoolVar(&cfg.StreamingEagerSendingEnabled, "blocks-storage.bucket-store.batch-series-eager-sending-enabled", false, "If enabled and series streaming is enabled, each series of a batch is sent to the querier as soon as its chunks have been loaded, instead of waiting for the chunks of the whole batch to be loaded. It can't be enabled together with adaptive preloading.")
+	f.IntVar(&cfg.StreamingChunksSlabSize, "blocks-storage.bucket-store.batch-series-chunks-slab-size", DefaultStreamingChunksSlabSize, "Number of chunks per slab of the memory pool used to allocate the chunks of the series of a batch when series streaming is enabled. The chunks of a series with more chunks than the slab size are allocated outside the pool.")
+	f.BoolVar(&cfg.StreamingAdaptiveChunksSlabSizeEnabled, "blocks-storage.bucket-store.batch-series-adaptive-chunks-slab-size-enabled", false, "If enabled and series streaming is enabled, the chunks slab size of each batch is estimated from the average number of chunks per series of the previous batch, up to the configured chunks slab size. This reduces the memory wasted by partially filled slabs when series have few chunks, for example because of a low scrape frequency.")
+	f.BoolVar(&cfg.StreamingChunksDeduplicationEnabled, "blocks-storage.bucket-store.batch-series-chunks-deduplication-enabled", false, "If enabled and series streaming is enabled, identical chunks of the same series, with the same min time, max time and data, are sent to the querier only once. Identical chunks are typically loaded from overlapping blocks which haven't been compacted yet.")
+	f.DurationVar(&cfg.StreamingSlowBatchLogThreshold, "blocks-storage.bucket-store.batch-series-slow-batch-log-threshold", 0, "If larger than 0 and series streaming is enabled, a batch of series whose chunks take longer than this threshold to load is logged, along with the tenant, the IDs of the blocks the chunks are loaded from, the number of series and the size of the chunks.")
+	f.IntVar(&cfg.ChunksFetchConcurrency, "blocks-storage.bucket-store.chunks-fetch-concurrency", 0, "Max number of concurrent requests to the bucket issued for each block to fetch the chunks of a series batch, or of the whole request if series streaming is disabled. 0 to disable the limit.")
+	f.Uint64Var(&cfg.ChunksFetchMaxRangeBytes, "blocks-storage.bucket-store.chunks-fetch-max-range-bytes", 0, "Max size - in bytes - of a range of chunks fetched from the bucket with a single request. Adjacent chunks are merged into a single range by the partitioner. Larger ranges are split, at chunk boundaries, into multiple ranges fetched concurrently. 0 to disable the splitting.")
+	f.BoolVar(&cfg.ChunksFetchAdaptiveConcurrencyEnabled, "blocks-storage.bucket-store.chunks-fetch-adaptive-concurrency-enabled", false, "If enabled, the max number of concurrent requests issued for each block to fetch the chunks is computed for each request, instead of using -blocks-storage.bucket-store.chunks-fetch-concurrency. The concurrency of a request is shared between its blocks, reduced when the store-gateway is loaded, and never larger than the number of ranges of chunks to fetch for a block.")
+	f.IntVar(&cfg.ChunksFetchAdaptiveMaxConcurrency, "blocks-storage.bucket-store.chunks-fetch-adaptive-max-concurrency", 32, "Max number of concurrent requests issued to fetch the chunks of all the blocks queried by a request, when the adaptive chunks fetch concurrency is enabled. Each block is always allowed at least one request.")
+	f.IntVar(&cfg.ChunksFetchAdaptiveMaxInflightRequests, "blocks-storage.bucket-store.chunks-fetch-adaptive-max-inflight-requests", 1000, "Number of in-flight requests issued to fetch chunks by the store-gateway, across all the requests, over which the concurrency of the requests is reduced, when the adaptive chunks fetch concurrency is enabled. 0 to not take into account the store-gateway load.")
+	f.BoolVar(&cfg.ChunksFetchHedgingEnabled, "blocks-storage.bucket-store.chunks-fetch-hedging-enabled", false, "If enabled, a request issued to the bucket to fetch a range of chunks which hasn't returned within the hedging delay is hedged: a second identical request is issued, and the response of the first one returning is used while the other one is cancelled.")
+	f.Float64Var(&cfg.ChunksFetchHedgingQuantile, "blocks-storage.bucket-store.chunks-fetch-hedging-quantile", 0.9, "The quantile of the latency of the last requests issued to fetch chunks used as hedging delay, when the chunks fetch hedging is enabled. Must be greater than 0 and lower than 1.")
+	f.DurationVar(&cfg.ChunksFetchHedgingMinDelay, "blocks-storage.bucket-store.chunks-fetch-hedging-min-delay", 100*time.Millisecond, "The min hedging delay, when the chunks fetch hedging is enabled. It's also the hedging delay used until enough requests have been issued to compute the latency quantile.")
+	f.BoolVar(&cfg.DownsampledBlocksEnabled, "blocks-storage.bucket-store.downsampled-blocks-enabled", false, "If enabled, the store-gateway loads the blocks downsampled at 5m and 1h resolution, and the querier queries them for the queries whose step is at least 5 times their resolution, reading the min, max, sum or average aggregates of the samples instead of the raw samples. Queries of functions which need the raw samples, like rate(), always query the raw blocks.")
+	f.BoolVar(&cfg.DebugCacheKeysEnabled, "blocks-storage.bucket-store.debug-cache-keys-enabled", false, "If enabled, the index cache (when backed by Memcached), chunks cache and metadata cache keys fetched while serving sampled traced requests are logged, together with whether they were a hit or a miss, in the request span.")
+	f.Var(&cfg.ExternalLabelMatchers, "blocks-storage.bucket-store.external-label-matchers", "Comma-separated list of block external label names, for example __block_shard__, which queries can match to select the blocks to query. The label matchers of a query on these labels are matched against the external labels of each block, instead of the series labels, and the blocks not matching them are skipped without looking up their index.")
 }
 
 // Validate the config.
@@ -344,6 +454,46 @@ func (cfg *BucketStoreConfig) Validate() error {
 	if err != nil {
 		return errors.Wrap(err, "metadata-cache configuration")
 	}
+	err = cfg.PostingsCache.Validate()
+	if err != nil {
+		return errors.Wrap(err, "postings-cache configuration")
+	}
+	if cfg.StreamingAdaptivePreloadingEnabled && cfg.StreamingAdaptivePreloadingMaxBytes <= 0 {
+		return errInvalidStreamingAdaptivePreloadingMaxBytes
+	}
+	if cfg.StreamingEagerSendingEnabled && cfg.StreamingAdaptivePreloadingEnabled {
+		return errStreamingEagerSendingWithAdaptivePreloading
+	}
+	if cfg.StreamingChunksSlabSize <= 0 {
+		return errInvalidStreamingChunksSlabSize
+	}
+	if cfg.ChunksFetchConcurrency < 0 {
+		return errInvalidChunksFetchConcurrency
+	}
+	if cfg.ChunksFetchAdaptiveConcurrencyEnabled && cfg.ChunksFetchAdaptiveMaxConcurrency <= 0 {
+		return errInvalidChunksFetchAdaptiveMaxConcurrency
+	}
+	if cfg.ChunksFetchAdaptiveMaxInflightRequests < 0 {
+		return errInvalidChunksFetchAdaptiveMaxInflightRequests
+	}
+	if cfg.ChunksFetchHedgingEnabled && (cfg.ChunksFetchHedgingQuantile <= 0 || cfg.ChunksFetchHedgingQuantile >= 1) {
+		return errInvalidChunksFetchHedgingQuantile
+	}
+	if cfg.ChunksFetchHedgingEnabled && cfg.ChunksFetchHedgingMinDelay <= 0 {
+		return errInvalidChunksFetchHedgingMinDelay
+	}
+	if v := cfg.IndexHeader.FormatVersion; v != indexheader.BinaryFormatV1 && v != indexheader.BinaryFormatV2 && v != indexheader.BinaryFormatV3 {
+		return errInvalidIndexHeaderFormatVersion
+	}
+	if cfg.IndexHeader.FormatVersion == indexheader.BinaryFormatV3 && !cfg.IndexHeader.StreamReaderEnabled {
+		return errIndexHeaderFormatV3RequiresStreamReader
+	}
+	if cfg.BucketIndex.FallbackScanEnabled && cfg.BucketIndex.FallbackScanMaxBlocks <= 0 {
+		return errInvalidBucketIndexFallbackScanMaxBlocks
+	}
+	if cfg.BucketIndex.FallbackScanEnabled && cfg.BucketIndex.FallbackScanFailuresThreshold <= 0 {
+		return errInvalidBucketIndexFallbackScanFailures
+	}
 	return nil
 }
 
@@ -352,6 +502,11 @@ type BucketIndexConfig struct {
 	UpdateOnErrorInterval time.Duration `yaml:"update_on_error_interval" category:"advanced"`
 	IdleTimeout           time.Duration `yaml:"idle_timeout" category:"advanced"`
 	MaxStalePeriod        time.Duration `yaml:"max_stale_period" category:"advanced"`
+
+	FallbackScanEnabled           bool          `yaml:"fallback_scan_enabled" category:"experimental"`
+	FallbackScanMaxBlocks         int           `yaml:"fallback_scan_max_blocks" category:"experimental"`
+	FallbackScanFailuresThreshold int           `yaml:"fallback_scan_failures_threshold" category:"experimental"`
+	FallbackScanCooldownPeriod    time.Duration `yaml:"fallback_scan_cooldown_period" category:"experimental"`
 }
 
 func (cfg *BucketIndexConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
@@ -359,4 +514,8 @@ func (cfg *BucketIndexConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 	f.DurationVar(&cfg.UpdateOnErrorInterval, prefix+"update-on-error-interval", time.Minute, "How frequently a bucket index, which previously failed to load, should be tried to load again. This option is used only by querier.")
 	f.DurationVar(&cfg.IdleTimeout, prefix+"idle-timeout", time.Hour, "How long a unused bucket index should be cached. Once this timeout expires, the unused bucket index is removed from the in-memory cache. This option is used only by querier.")
 	f.DurationVar(&cfg.MaxStalePeriod, prefix+"max-stale-period", time.Hour, "The maximum allowed age of a bucket index (last updated) before queries start failing because the bucket index is too old. The bucket index is periodically updated by the compactor, and this check is enforced in the querier (at query time).")
+	f.BoolVar(&cfg.FallbackScanEnabled, prefix+"fallback-scan-enabled", false, "If enabled, queriers and store-gateways scan the bucket to find the blocks not in the bucket index when the bucket index is missing, corrupted or older than the max stale period, instead of failing the queries. Queriers only scan the blocks created within the queried time range.")
+	f.IntVar(&cfg.FallbackScanMaxBlocks, prefix+"fallback-scan-max-blocks", 1000, "The maximum number of blocks not in the bucket index whose metadata is fetched by a single bucket scan. A scan finding more blocks fails.")
+	f.IntVar(&cfg.FallbackScanFailuresThreshold, prefix+"fallback-scan-failures-threshold", 3, "The number of consecutive failed bucket scans of a tenant after which its bucket scans are skipped until the cooldown period expires.")
+	f.DurationVar(&cfg.FallbackScanCooldownPeriod, prefix+"fallback-scan-cooldown-period", 5*time.Minute, "How long the bucket scans of a tenant are skipped after too many consecutive failed scans.")
 }
@@ -85,6 +85,19 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		"should fail on invalid early head compaction min sample age": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.TSDB.EarlyHeadCompactionMemoryThresholdBytes = 1024
+				cfg.TSDB.EarlyHeadCompactionMinSampleAge = 0
+			},
+			expectedErr: errInvalidEarlyHeadCompactionMinSampleAge,
+		},
+		"should pass on invalid early head compaction min sample age if early head compaction is disabled": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.TSDB.EarlyHeadCompactionMinSampleAge = 0
+			},
+			expectedErr: nil,
+		},
 		"should fail on negative stripe size": {
 			setup: func(cfg *BlocksStorageConfig) {
 				cfg.TSDB.StripeSize = -2
@@ -121,6 +134,112 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: errInvalidWALSegmentSizeBytes,
 		},
+		"should fail on invalid series streaming adaptive preloading max bytes": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.StreamingAdaptivePreloadingEnabled = true
+				cfg.BucketStore.StreamingAdaptivePreloadingMaxBytes = 0
+			},
+			expectedErr: errInvalidStreamingAdaptivePreloadingMaxBytes,
+		},
+		"should fail if both series streaming eager sending and adaptive preloading are enabled": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.StreamingEagerSendingEnabled = true
+				cfg.BucketStore.StreamingAdaptivePreloadingEnabled = true
+			},
+			expectedErr: errStreamingEagerSendingWithAdaptivePreloading,
+		},
+		"should fail on invalid series streaming chunks slab size": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.StreamingChunksSlabSize = 0
+			},
+			expectedErr: errInvalidStreamingChunksSlabSize,
+		},
+		"should fail on negative chunks fetch concurrency": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.ChunksFetchConcurrency = -1
+			},
+			expectedErr: errInvalidChunksFetchConcurrency,
+		},
+		"should fail on invalid chunks fetch adaptive max concurrency": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.ChunksFetchAdaptiveConcurrencyEnabled = true
+				cfg.BucketStore.ChunksFetchAdaptiveMaxConcurrency = 0
+			},
+			expectedErr: errInvalidChunksFetchAdaptiveMaxConcurrency,
+		},
+		"should fail on negative chunks fetch adaptive max inflight requests": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.ChunksFetchAdaptiveMaxInflightRequests = -1
+			},
+			expectedErr: errInvalidChunksFetchAdaptiveMaxInflightRequests,
+		},
+		"should fail on invalid chunks fetch hedging quantile": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.ChunksFetchHedgingEnabled = true
+				cfg.BucketStore.ChunksFetchHedgingQuantile = 1
+			},
+			expectedErr: errInvalidChunksFetchHedgingQuantile,
+		},
+		"should fail on invalid chunks fetch hedging min delay": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.ChunksFetchHedgingEnabled = true
+				cfg.BucketStore.ChunksFetchHedgingMinDelay = 0
+			},
+			expectedErr: errInvalidChunksFetchHedgingMinDelay,
+		},
+		"should fail on invalid bucket index fallback scan max blocks": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.BucketIndex.FallbackScanEnabled = true
+				cfg.BucketStore.BucketIndex.FallbackScanMaxBlocks = 0
+			},
+			expectedErr: errInvalidBucketIndexFallbackScanMaxBlocks,
+		},
+		"should fail on invalid bucket index fallback scan failures threshold": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.BucketIndex.FallbackScanEnabled = true
+				cfg.BucketStore.BucketIndex.FallbackScanFailuresThreshold = 0
+			},
+			expectedErr: errInvalidBucketIndexFallbackScanFailures,
+		},
+		"should fail on empty local cache directory": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.LocalCache.Enabled = true
+				cfg.LocalCache.Dir = ""
+			},
+			expectedErr: errInvalidLocalCacheDir,
+		},
+		"should fail on invalid local cache max object size": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.LocalCache.Enabled = true
+				cfg.LocalCache.MaxObjectSizeBytes = 0
+			},
+			expectedErr: errInvalidLocalCacheMaxObjectSize,
+		},
+		"should fail on unsupported index-header format version": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeader.FormatVersion = 4
+			},
+			expectedErr: errInvalidIndexHeaderFormatVersion,
+		},
+		"should pass on index-header format version 2": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeader.FormatVersion = 2
+			},
+			expectedErr: nil,
+		},
+		"should fail on index-header format version 3 without the streaming reader": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeader.FormatVersion = 3
+			},
+			expectedErr: errIndexHeaderFormatV3RequiresStreamReader,
+		},
+		"should pass on index-header format version 3 with the streaming reader": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeader.FormatVersion = 3
+				cfg.BucketStore.IndexHeader.StreamReaderEnabled = true
+			},
+			expectedErr: nil,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/thanos/blob/main/pkg/compact/downsample/downsample.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package downsample
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// Standard downsampling resolution levels of the downsampled blocks, in milliseconds.
+const (
+	ResLevel0 = int64(0)              // Raw data.
+	ResLevel1 = int64(5 * 60 * 1000)  // 5 minutes.
+	ResLevel2 = int64(60 * 60 * 1000) // 1 hour.
+)
+
+// ChunkEncAggr is the encoding of the chunks of the downsampled blocks, which hold a chunk for each
+// aggregate of the raw samples.
+const ChunkEncAggr = chunkenc.Encoding(0xff)
+
+// AggrType represents an aggregation type of the samples of a downsampled chunk.
+type AggrType uint8
+
+// Valid aggregation types, in the order they're encoded in an AggrChunk.
+const (
+	AggrCount AggrType = iota
+	AggrSum
+	AggrMin
+	AggrMax
+	AggrCounter
+)
+
+// AggrTypes are all the aggregation types.
+var AggrTypes = []AggrType{AggrCount, AggrSum, AggrMin, AggrMax, AggrCounter}
+
+func (t AggrType) String() string {
+	switch t {
+	case AggrCount:
+		return "count"
+	case AggrSum:
+		return "sum"
+	case AggrMin:
+		return "min"
+	case AggrMax:
+		return "max"
+	case AggrCounter:
+		return "counter"
+	}
+	return "<unknown>"
+}
+
+// ErrAggrNotExist is returned if a requested aggregation is not present in an AggrChunk.
+var ErrAggrNotExist = errors.New("aggregate does not exist")
+
+// AggrChunk is a chunk that is composed of a chunk for each aggregate of the raw samples. Each aggregate
+// is encoded as its length, followed by its encoding and data. Unset aggregates are encoded as a zero length.
+type AggrChunk []byte
+
+// EncodeAggrChunk encodes the chunks of the aggregates, indexed by AggrType, into an AggrChunk.
+// Nil chunks are encoded as unset aggregates.
+func EncodeAggrChunk(chks [5]chunkenc.Chunk) AggrChunk {
+	var (
+		b   []byte
+		buf [binary.MaxVarintLen64]byte
+	)
+	for _, c := range chks {
+		if c == nil {
+			n := binary.PutUvarint(buf[:], 0)
+			b = append(b, buf[:n]...)
+			continue
+		}
+		n := binary.PutUvarint(buf[:], uint64(len(c.Bytes())))
+		b = append(b, buf[:n]...)
+		b = append(b, byte(c.Encoding()))
+		b = append(b, c.Bytes()...)
+	}
+	return b
+}
+
+// Get returns the chunk of the input aggregate. The returned chunk references the AggrChunk memory.
+func (c AggrChunk) Get(t AggrType) (chunkenc.Chunk, error) {
+	if t > AggrCounter {
+		return nil, errors.Errorf("unknown aggregate %d", t)
+	}
+
+	b := c[:]
+	for i := AggrType(0); ; i++ {
+		l, n := binary.Uvarint(b)
+		if n < 1 {
+			return nil, errors.Errorf("invalid size of aggregate %s", i)
+		}
+		b = b[n:]
+
+		// If length is set to zero explicitly, that means the aggregate is unset.
+		if l == 0 {
+			if i == t {
+				return nil, ErrAggrNotExist
+			}
+			continue
+		}
+		if uint64(len(b)) < l+1 {
+			return nil, errors.Errorf("invalid size of aggregate %s: %d bytes left, expected %d", i, len(b), l+1)
+		}
+		if i == t {
+			return chunkenc.FromData(chunkenc.Encoding(b[0]), b[1:l+1])
+		}
+		b = b[l+1:]
+	}
+}
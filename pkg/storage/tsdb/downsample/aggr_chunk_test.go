@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package downsample
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggrChunk(t *testing.T) {
+	newChunk := func(values ...float64) chunkenc.Chunk {
+		c := chunkenc.NewXORChunk()
+		app, err := c.Appender()
+		require.NoError(t, err)
+		for i, v := range values {
+			app.Append(int64(i), v)
+		}
+		return c
+	}
+
+	var chks [5]chunkenc.Chunk
+	chks[AggrCount] = newChunk(10, 20)
+	chks[AggrSum] = newChunk(100, 200)
+	chks[AggrMax] = newChunk(15, 25)
+	chunk := EncodeAggrChunk(chks)
+
+	for _, aggr := range AggrTypes {
+		t.Run(aggr.String(), func(t *testing.T) {
+			c, err := chunk.Get(aggr)
+			if chks[aggr] == nil {
+				assert.ErrorIs(t, err, ErrAggrNotExist)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, chunkenc.EncXOR, c.Encoding())
+			assert.Equal(t, chks[aggr].Bytes(), c.Bytes())
+		})
+	}
+
+	t.Run("unknown aggregate", func(t *testing.T) {
+		_, err := chunk.Get(AggrCounter + 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("truncated chunk", func(t *testing.T) {
+		_, err := chunk[:len(chunk)-len(chks[AggrMax].Bytes())].Get(AggrMax)
+		assert.Error(t, err)
+	})
+}
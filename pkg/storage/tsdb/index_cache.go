@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/units"
 	"github.com/go-kit/log"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/cachedebug"
 )
 
 const (
@@ -43,6 +45,8 @@ var (
 type IndexCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
 	InMemory            InMemoryIndexCacheConfig `yaml:"inmemory"`
+
+	RecentBlocksMaxAge time.Duration `yaml:"recent_blocks_max_age" category:"experimental"`
 }
 
 func (cfg *IndexCacheConfig) RegisterFlags(f *flag.FlagSet) {
@@ -54,6 +58,8 @@ func (cfg *IndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix str
 
 	cfg.InMemory.RegisterFlagsWithPrefix(f, prefix+"inmemory.")
 	cfg.Memcached.RegisterFlagsWithPrefix(f, prefix+"memcached.")
+
+	f.DurationVar(&cfg.RecentBlocksMaxAge, prefix+"recent-blocks-max-age", 0, fmt.Sprintf("If greater than 0 and the backend is %s, the index cache entries of the blocks created more recently than this age are stored in an in-memory cache, whose size is configured by -%sinmemory.max-size-bytes, instead of %s. The age of a block is computed from the creation time encoded in its ID.", IndexCacheBackendMemcached, prefix, IndexCacheBackendMemcached))
 }
 
 // Validate the config.
@@ -79,13 +85,17 @@ func (cfg *InMemoryIndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, pr
 	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", uint64(1*units.Gibibyte), "Maximum size in bytes of in-memory index cache used to speed up blocks index lookups (shared between all tenants).")
 }
 
-// NewIndexCache creates a new index cache based on the input configuration.
-func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+// NewIndexCache creates a new index cache based on the input configuration. If debugKeys is enabled, the keys
+// fetched from the Memcached-based index cache by sampled traced requests are recorded in their span.
+func NewIndexCache(cfg IndexCacheConfig, debugKeys bool, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
 	switch cfg.Backend {
 	case IndexCacheBackendInMemory:
 		return newInMemoryIndexCache(cfg.InMemory, logger, registerer)
 	case IndexCacheBackendMemcached:
-		return newMemcachedIndexCache(cfg.Memcached, logger, registerer)
+		if cfg.RecentBlocksMaxAge > 0 {
+			return newBlockAgeTieredIndexCache(cfg, debugKeys, logger, registerer)
+		}
+		return newMemcachedIndexCache(cfg.Memcached, debugKeys, logger, registerer)
 	default:
 		return nil, errUnsupportedIndexCacheBackend
 	}
@@ -106,11 +116,15 @@ func newInMemoryIndexCache(cfg InMemoryIndexCacheConfig, logger log.Logger, regi
 	})
 }
 
-func newMemcachedIndexCache(cfg cache.MemcachedConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+func newMemcachedIndexCache(cfg cache.MemcachedConfig, debugKeys bool, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	var client cache.RemoteCacheClient
 	client, err := cache.NewMemcachedClientWithConfig(logger, "index-cache", cfg.ToMemcachedClientConfig(), prometheus.WrapRegistererWithPrefix("thanos_", registerer))
 	if err != nil {
 		return nil, errors.Wrap(err, "create index cache memcached client")
 	}
+	if debugKeys {
+		client = cachedebug.NewKeysRecordingRemoteCacheClient("index-cache", client)
+	}
 
 	cache, err := indexcache.NewMemcachedIndexCache(logger, client, registerer)
 	if err != nil {
@@ -119,3 +133,19 @@ func newMemcachedIndexCache(cfg cache.MemcachedConfig, logger log.Logger, regist
 
 	return indexcache.NewTracingIndexCache(cache, logger), nil
 }
+
+// newBlockAgeTieredIndexCache creates an index cache storing the entries of the recent blocks in memory,
+// and the entries of the other blocks in Memcached. The metrics of each cache are labelled with its tier.
+func newBlockAgeTieredIndexCache(cfg IndexCacheConfig, debugKeys bool, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	recent, err := newInMemoryIndexCache(cfg.InMemory, logger, prometheus.WrapRegistererWith(prometheus.Labels{"tier": "recent-blocks"}, registerer))
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := newMemcachedIndexCache(cfg.Memcached, debugKeys, logger, prometheus.WrapRegistererWith(prometheus.Labels{"tier": "old-blocks"}, registerer))
+	if err != nil {
+		return nil, err
+	}
+
+	return indexcache.NewBlockAgeTieredIndexCache(recent, old, cfg.RecentBlocksMaxAge), nil
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// blockAgeTieredCache is a cache.Cache storing the entries of the blocks created more recently than maxAge
+// in the recent cache, and the entries of the older blocks in the old cache. The block of each entry is
+// looked up in its key, and the age of the block is computed from the creation time encoded in its ID.
+// The entries whose key doesn't reference any block are stored in the old cache.
+type blockAgeTieredCache struct {
+	recent cache.Cache
+	old    cache.Cache
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+func newBlockAgeTieredCache(recent, old cache.Cache, maxAge time.Duration) *blockAgeTieredCache {
+	return &blockAgeTieredCache{
+		recent: recent,
+		old:    old,
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+func (c *blockAgeTieredCache) isRecent(key string) bool {
+	blockID, ok := blockIDFromCacheKey(key)
+	return ok && c.now().Sub(ulid.Time(blockID.Time())) < c.maxAge
+}
+
+func (c *blockAgeTieredCache) Store(ctx context.Context, data map[string][]byte, ttl time.Duration) {
+	recent := map[string][]byte{}
+	old := map[string][]byte{}
+	for k, v := range data {
+		if c.isRecent(k) {
+			recent[k] = v
+		} else {
+			old[k] = v
+		}
+	}
+
+	if len(recent) > 0 {
+		c.recent.Store(ctx, recent, ttl)
+	}
+	if len(old) > 0 {
+		c.old.Store(ctx, old, ttl)
+	}
+}
+
+func (c *blockAgeTieredCache) Fetch(ctx context.Context, keys []string) map[string][]byte {
+	var recent, old []string
+	for _, k := range keys {
+		if c.isRecent(k) {
+			recent = append(recent, k)
+		} else {
+			old = append(old, k)
+		}
+	}
+
+	var recentHits, oldHits map[string][]byte
+	if len(recent) > 0 {
+		recentHits = c.recent.Fetch(ctx, recent)
+	}
+	if len(old) > 0 {
+		oldHits = c.old.Fetch(ctx, old)
+	}
+
+	if len(recentHits) == 0 {
+		return oldHits
+	}
+	for k, v := range oldHits {
+		recentHits[k] = v
+	}
+	return recentHits
+}
+
+func (c *blockAgeTieredCache) Name() string {
+	return c.old.Name()
+}
+
+// blockIDFromCacheKey returns the ID of the block referenced by the cache key, looking for it among the
+// parts of the key separated by ":" or "/". The last part which is a valid block ID is returned, because
+// the tenant ID, which could look like a block ID too, comes before the block ID in the cache keys.
+func blockIDFromCacheKey(key string) (blockID ulid.ULID, found bool) {
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool { return r == ':' || r == '/' }) {
+		if len(part) != ulid.EncodedSize {
+			continue
+		}
+		if id, err := ulid.Parse(part); err == nil {
+			blockID, found = id, true
+		}
+	}
+	return blockID, found
+}
+
+// inMemoryCache is a cache.Cache keeping the entries in memory, evicting the least recently used
+// ones once the total size of the keys and values of the entries exceeds the max size.
+type inMemoryCache struct {
+	name         string
+	maxSizeBytes uint64
+
+	mtx     sync.Mutex
+	lru     *lru.LRU
+	curSize uint64
+
+	// Metrics.
+	items   prometheus.Gauge
+	size    prometheus.Gauge
+	evicted prometheus.Counter
+}
+
+type inMemoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func newInMemoryCache(name string, maxSizeBytes uint64, reg prometheus.Registerer) *inMemoryCache {
+	c := &inMemoryCache{
+		name:         name,
+		maxSizeBytes: maxSizeBytes,
+		items: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name:        "cortex_cache_inmemory_items",
+			Help:        "Current number of items in the in-memory cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		size: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name:        "cortex_cache_inmemory_size_bytes",
+			Help:        "Current size in bytes of the keys and values of the items in the in-memory cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		evicted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_cache_inmemory_items_evicted_total",
+			Help:        "Total number of items evicted from the in-memory cache to make room for new items.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+	}
+
+	// The size of the cache is bounded by the size of the items, not their number.
+	c.lru, _ = lru.NewLRU(math.MaxInt, c.onRemoved)
+
+	return c
+}
+
+func (c *inMemoryCache) onRemoved(key, value interface{}) {
+	c.curSize -= inMemoryCacheEntrySize(key.(string), value.(inMemoryCacheEntry).value)
+}
+
+func inMemoryCacheEntrySize(key string, value []byte) uint64 {
+	return uint64(len(key) + len(value))
+}
+
+func (c *inMemoryCache) Store(_ context.Context, data map[string][]byte, ttl time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	for k, v := range data {
+		size := inMemoryCacheEntrySize(k, v)
+		if size > c.maxSizeBytes {
+			continue
+		}
+
+		c.lru.Remove(k)
+		for c.curSize+size > c.maxSizeBytes {
+			if _, _, ok := c.lru.RemoveOldest(); !ok {
+				break
+			}
+			c.evicted.Inc()
+		}
+
+		c.lru.Add(k, inMemoryCacheEntry{value: v, expiresAt: expiresAt})
+		c.curSize += size
+	}
+
+	c.items.Set(float64(c.lru.Len()))
+	c.size.Set(float64(c.curSize))
+}
+
+func (c *inMemoryCache) Fetch(_ context.Context, keys []string) map[string][]byte {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := time.Now()
+	hits := map[string][]byte{}
+	for _, k := range keys {
+		v, ok := c.lru.Get(k)
+		if !ok {
+			continue
+		}
+
+		entry := v.(inMemoryCacheEntry)
+		if now.After(entry.expiresAt) {
+			c.lru.Remove(k)
+			continue
+		}
+		hits[k] = entry.value
+	}
+
+	c.items.Set(float64(c.lru.Len()))
+	c.size.Set(float64(c.curSize))
+	return hits
+}
+
+func (c *inMemoryCache) Name() string {
+	return c.name
+}
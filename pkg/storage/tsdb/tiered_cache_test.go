@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockIDFromCacheKey(t *testing.T) {
+	blockID := ulid.MustNew(1, nil)
+	tenantLikeBlockID := ulid.MustNew(2, nil)
+
+	for key, expected := range map[string]bool{
+		"C:user-1:" + blockID.String() + ":4294967306":                                  true,
+		"subrange:user-1/" + blockID.String() + "/chunks/000001:0:16000":                true,
+		"attrs:user-1/" + blockID.String() + "/chunks/000001":                           true,
+		"subrange:" + tenantLikeBlockID.String() + "/" + blockID.String() + "/chunks/1": true,
+		"iter:user-1/":                     false,
+		"content:user-1/bucket-index.json": false,
+	} {
+		t.Run(key, func(t *testing.T) {
+			actual, ok := blockIDFromCacheKey(key)
+			require.Equal(t, expected, ok)
+			if expected {
+				assert.Equal(t, blockID, actual)
+			}
+		})
+	}
+}
+
+func TestBlockAgeTieredCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	recentKey := "C:user-1:" + ulid.MustNew(ulid.Timestamp(now.Add(-time.Hour)), nil).String() + ":1"
+	oldKey := "C:user-1:" + ulid.MustNew(ulid.Timestamp(now.Add(-48*time.Hour)), nil).String() + ":1"
+	noBlockKey := "iter:user-1/"
+
+	recent := cache.NewMockCache()
+	old := cache.NewMockCache()
+	c := newBlockAgeTieredCache(recent, old, 24*time.Hour)
+	c.now = func() time.Time { return now }
+
+	c.Store(ctx, map[string][]byte{
+		recentKey:  []byte("recent"),
+		oldKey:     []byte("old"),
+		noBlockKey: []byte("no-block"),
+	}, time.Hour)
+
+	assert.Equal(t, map[string][]byte{recentKey: []byte("recent")}, recent.Fetch(ctx, []string{recentKey, oldKey, noBlockKey}))
+	assert.Equal(t, map[string][]byte{oldKey: []byte("old"), noBlockKey: []byte("no-block")}, old.Fetch(ctx, []string{recentKey, oldKey, noBlockKey}))
+
+	// The entries should be read back through the tiered cache.
+	assert.Equal(t, map[string][]byte{
+		recentKey:  []byte("recent"),
+		oldKey:     []byte("old"),
+		noBlockKey: []byte("no-block"),
+	}, c.Fetch(ctx, []string{recentKey, oldKey, noBlockKey}))
+
+	// Once the recent block gets older than the max age, its entries are looked up in the old cache.
+	c.now = func() time.Time { return now.Add(24 * time.Hour) }
+	assert.Equal(t, map[string][]byte{oldKey: []byte("old")}, c.Fetch(ctx, []string{recentKey, oldKey}))
+}
+
+func TestInMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+
+	// Each entry is 10 bytes: 2 for the key and 8 for the value.
+	c := newInMemoryCache("test", 30, reg)
+
+	c.Store(ctx, map[string][]byte{"k1": []byte("value-01"), "k2": []byte("value-02")}, time.Hour)
+	assert.Equal(t, map[string][]byte{"k1": []byte("value-01"), "k2": []byte("value-02")}, c.Fetch(ctx, []string{"k1", "k2", "k3"}))
+
+	// Storing a third entry should fit in the cache.
+	c.Store(ctx, map[string][]byte{"k3": []byte("value-03")}, time.Hour)
+	assert.Len(t, c.Fetch(ctx, []string{"k1", "k2", "k3"}), 3)
+
+	// Storing a fourth entry should evict the least recently used one.
+	c.Fetch(ctx, []string{"k1"})
+	c.Store(ctx, map[string][]byte{"k4": []byte("value-04")}, time.Hour)
+	assert.Equal(t, map[string][]byte{"k1": []byte("value-01"), "k3": []byte("value-03"), "k4": []byte("value-04")}, c.Fetch(ctx, []string{"k1", "k2", "k3", "k4"}))
+
+	// Overwriting an entry should account only for its new size.
+	c.Store(ctx, map[string][]byte{"k4": []byte("value-05")}, time.Hour)
+	assert.Equal(t, map[string][]byte{"k1": []byte("value-01"), "k3": []byte("value-03"), "k4": []byte("value-05")}, c.Fetch(ctx, []string{"k1", "k3", "k4"}))
+
+	// Entries larger than the cache shouldn't be stored.
+	c.Store(ctx, map[string][]byte{"k5": make([]byte, 100)}, time.Hour)
+	assert.Empty(t, c.Fetch(ctx, []string{"k5"}))
+
+	// Expired entries shouldn't be returned.
+	c.Store(ctx, map[string][]byte{"k1": []byte("value-01")}, -time.Second)
+	assert.Empty(t, c.Fetch(ctx, []string{"k1"}))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(c.items))
+	assert.Equal(t, float64(20), testutil.ToFloat64(c.size))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.evicted))
+}
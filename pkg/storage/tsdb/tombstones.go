@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/thanos-io/objstore"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// Relative to user-specific prefix.
+const TombstonesPath = "tombstones"
+
+type TombstoneState string
+
+const (
+	// TombstonePending is the state of the series deletion requests whose series may still be found in the blocks.
+	TombstonePending TombstoneState = "pending"
+
+	// TombstoneProcessed is the state of the series deletion requests whose series have been deleted from all blocks.
+	TombstoneProcessed TombstoneState = "processed"
+)
+
+// Tombstone is a request to delete the samples of the series matching any of the selectors within a time range.
+type Tombstone struct {
+	RequestID string `json:"request_id"`
+
+	// Unix timestamp when the deletion has been requested.
+	RequestCreatedAt int64 `json:"request_created_at"`
+
+	// Time range of the samples to delete, in milliseconds since epoch (both included).
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+
+	Selectors []string       `json:"selectors"`
+	State     TombstoneState `json:"state"`
+
+	// Unix timestamp when the series have been deleted from all blocks.
+	ProcessedAt int64 `json:"processed_at,omitempty"`
+}
+
+// NewTombstone returns a pending tombstone. The request ID is derived from the time range and the selectors,
+// so that requesting the same deletion again results in the same tombstone.
+func NewTombstone(createdAt time.Time, startTime, endTime int64, selectors []string) *Tombstone {
+	selectors = append([]string(nil), selectors...)
+	sort.Strings(selectors)
+
+	h := sha256.New()
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(startTime))
+	binary.BigEndian.PutUint64(buf[8:], uint64(endTime))
+	_, _ = h.Write(buf[:])
+	_, _ = h.Write([]byte(strings.Join(selectors, "\x00")))
+
+	return &Tombstone{
+		RequestID:        hex.EncodeToString(h.Sum(nil)[:16]),
+		RequestCreatedAt: createdAt.Unix(),
+		StartTime:        startTime,
+		EndTime:          endTime,
+		Selectors:        selectors,
+		State:            TombstonePending,
+	}
+}
+
+// Matchers returns the parsed selectors of the tombstone.
+func (t *Tombstone) Matchers() ([][]*labels.Matcher, error) {
+	matchers := make([][]*labels.Matcher, 0, len(t.Selectors))
+	for _, selector := range t.Selectors {
+		m, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid selector %s of tombstone %s", selector, t.RequestID)
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// Overlaps returns whether the tombstone time range overlaps the input block time range (max time excluded).
+func (t *Tombstone) Overlaps(minTime, maxTime int64) bool {
+	return t.StartTime < maxTime && t.EndTime >= minTime
+}
+
+// TombstoneFilepath returns the path of the tombstone, relative to user-specific prefix.
+func TombstoneFilepath(requestID string) string {
+	return path.Join(TombstonesPath, requestID+".json")
+}
+
+// WriteTombstone uploads the tombstone to the user-specific bucket, replacing the existing one if any.
+func WriteTombstone(ctx context.Context, userBkt objstore.Bucket, t *Tombstone) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return errors.Wrap(err, "serialize tombstone")
+	}
+
+	return errors.Wrap(userBkt.Upload(ctx, TombstoneFilepath(t.RequestID), bytes.NewReader(data)), "upload tombstone")
+}
+
+// ReadTombstone returns the tombstone with the input request ID from the user-specific bucket. If it doesn't exist,
+// returns nil tombstone, and no error.
+func ReadTombstone(ctx context.Context, userBkt objstore.BucketReader, requestID string) (*Tombstone, error) {
+	tombstonePath := TombstoneFilepath(requestID)
+
+	r, err := userBkt.Get(ctx, tombstonePath)
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to read tombstone object: %s", tombstonePath)
+	}
+
+	t := &Tombstone{}
+	err = json.NewDecoder(r).Decode(t)
+
+	// Close reader before dealing with decode error.
+	if closeErr := r.Close(); closeErr != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to close bucket reader", "err", closeErr)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode tombstone object: %s", tombstonePath)
+	}
+
+	return t, nil
+}
+
+// ReadTombstones returns all tombstones of the user-specific bucket, sorted by creation time.
+func ReadTombstones(ctx context.Context, userBkt objstore.BucketReader) ([]*Tombstone, error) {
+	var tombstones []*Tombstone
+
+	err := userBkt.Iter(ctx, TombstonesPath, func(name string) error {
+		requestID := strings.TrimSuffix(path.Base(name), ".json")
+		if requestID == path.Base(name) {
+			return nil
+		}
+
+		t, err := ReadTombstone(ctx, userBkt, requestID)
+		if err != nil {
+			return err
+		}
+		// The tombstone may have been deleted in the meanwhile.
+		if t != nil {
+			tombstones = append(tombstones, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tombstones, func(i, j int) bool {
+		if tombstones[i].RequestCreatedAt != tombstones[j].RequestCreatedAt {
+			return tombstones[i].RequestCreatedAt < tombstones[j].RequestCreatedAt
+		}
+		return tombstones[i].RequestID < tombstones[j].RequestID
+	})
+	return tombstones, nil
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestNewTombstone(t *testing.T) {
+	now := time.Now()
+
+	t1 := NewTombstone(now, 10, 20, []string{`{job="b"}`, `{job="a"}`})
+	assert.Equal(t, []string{`{job="a"}`, `{job="b"}`}, t1.Selectors)
+	assert.Equal(t, TombstonePending, t1.State)
+	assert.Equal(t, now.Unix(), t1.RequestCreatedAt)
+
+	// The same deletion requested again has the same ID, regardless of the selectors order.
+	t2 := NewTombstone(now.Add(time.Hour), 10, 20, []string{`{job="a"}`, `{job="b"}`})
+	assert.Equal(t, t1.RequestID, t2.RequestID)
+
+	// A different time range or different selectors have a different ID.
+	assert.NotEqual(t, t1.RequestID, NewTombstone(now, 10, 21, t1.Selectors).RequestID)
+	assert.NotEqual(t, t1.RequestID, NewTombstone(now, 10, 20, []string{`{job="a"}`}).RequestID)
+}
+
+func TestTombstone_Overlaps(t *testing.T) {
+	tombstone := NewTombstone(time.Now(), 10, 20, []string{`{job="a"}`})
+
+	assert.False(t, tombstone.Overlaps(0, 10))
+	assert.True(t, tombstone.Overlaps(0, 11))
+	assert.True(t, tombstone.Overlaps(15, 16))
+	assert.True(t, tombstone.Overlaps(20, 30))
+	assert.False(t, tombstone.Overlaps(21, 30))
+}
+
+func TestTombstone_Matchers(t *testing.T) {
+	matchers, err := NewTombstone(time.Now(), 10, 20, []string{`{job="a"}`, `up{job=~"b.*"}`}).Matchers()
+	require.NoError(t, err)
+	require.Len(t, matchers, 2)
+
+	// Selectors are sorted.
+	assert.Len(t, matchers[0], 2)
+	assert.Len(t, matchers[1], 1)
+
+	_, err = NewTombstone(time.Now(), 10, 20, []string{`{job="a"`}).Matchers()
+	assert.Error(t, err)
+}
+
+func TestWriteAndReadTombstones(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	tombstones, err := ReadTombstones(ctx, bkt)
+	require.NoError(t, err)
+	assert.Empty(t, tombstones)
+
+	t1 := NewTombstone(time.Unix(200, 0), 10, 20, []string{`{job="a"}`})
+	t2 := NewTombstone(time.Unix(100, 0), 10, 20, []string{`{job="b"}`})
+	require.NoError(t, WriteTombstone(ctx, bkt, t1))
+	require.NoError(t, WriteTombstone(ctx, bkt, t2))
+
+	read, err := ReadTombstone(ctx, bkt, t1.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, t1, read)
+
+	read, err = ReadTombstone(ctx, bkt, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, read)
+
+	// Tombstones are sorted by creation time.
+	tombstones, err = ReadTombstones(ctx, bkt)
+	require.NoError(t, err)
+	assert.Equal(t, []*Tombstone{t2, t1}, tombstones)
+
+	// Writing a tombstone again replaces it.
+	t1.State = TombstoneProcessed
+	t1.ProcessedAt = 300
+	require.NoError(t, WriteTombstone(ctx, bkt, t1))
+
+	read, err = ReadTombstone(ctx, bkt, t1.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, TombstoneProcessed, read.State)
+	assert.Equal(t, int64(300), read.ProcessedAt)
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/objstore/tracing"
+	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
@@ -44,10 +45,13 @@ import (
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+	"github.com/grafana/mimir/pkg/storegateway/postingscache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/pool"
@@ -91,7 +95,20 @@ type BucketStore struct {
 	fetcher         block.MetadataFetcher
 	dir             string
 	indexCache      indexcache.IndexCache
+	chunksCache     chunkscache.Cache
 	indexReaderPool *indexheader.ReaderPool
+
+	// postingsCache, if not nil, caches the expanded postings of the blocks, which are invalidated once
+	// the block is in the deletionMarkedBlocks.
+	postingsCache        *postingscache.Cache
+	deletionMarkedBlocks func() map[ulid.ULID]*metadata.DeletionMark
+
+	// chunksFetchHedging, if not nil, hedges the requests issued to fetch the chunks of the blocks.
+	chunksFetchHedging *chunksFetchHedging
+
+	// seriesHashesPersistence enables persisting the series hashes of each block along with its index-header.
+	seriesHashesPersistence bool
+
 	chunkPool       pool.Bytes
 	seriesHashCache *hashcache.SeriesHashCache
 
@@ -109,10 +126,57 @@ type BucketStore struct {
 	// or to load and unload them in batches and stream them to the querier. The bucketStore uses streaming when
 	// maxSeriesPerBatch is larger than zero.
 	maxSeriesPerBatch int
+	// maxSeriesPerBatchOverride, if set and returning a value larger than zero, overrides maxSeriesPerBatch.
+	maxSeriesPerBatchOverride func() int
+	// adaptivePreloadingMaxBytes, if larger than zero, enables the adaptive preloading of series chunks batches,
+	// bounded by the max size of the preloaded chunks for each Series() call.
+	adaptivePreloadingMaxBytes int
+	// eagerSending, if true, enables sending each series of a series chunks batch as soon as its chunks
+	// have been loaded, instead of waiting for the whole batch to be loaded.
+	eagerSending bool
+	// chunksSlabSize is the number of chunks per slab used to allocate the chunks of a series chunks batch,
+	// or the max one if adaptiveChunksSlabSize is true.
+	chunksSlabSize         int
+	adaptiveChunksSlabSize bool
+	// chunksDeduplication, if true, enables the deduplication of the identical chunks of each series, loaded
+	// from overlapping blocks, before sending the series when streaming series.
+	chunksDeduplication bool
+	// slowBatchLogThreshold, if larger than zero, is the load duration of a series chunks batch above which
+	// the batch is logged.
+	slowBatchLogThreshold time.Duration
+	// chunksFetchOpts controls how the chunks are fetched from the bucket.
+	chunksFetchOpts chunksFetchOptions
+	// chunksFetchMaxConcurrency, if set, returns the max number of concurrent requests issued to fetch the
+	// chunks of each request when the adaptive chunks fetch concurrency is enabled.
+	chunksFetchMaxConcurrency func() int
+	// externalLabelMatchers are the names of the block external labels which can be matched by the request
+	// label matchers to select the blocks to query.
+	externalLabelMatchers map[string]struct{}
+	// partialResults, if set and returning true, enables returning the series of the healthy blocks, along with
+	// a warning for each failed block, when fetching the series of some blocks fails.
+	partialResults func() bool
+	// lazyPostings, if set and returning true, enables streaming the postings matching the request label
+	// matchers in batches when streaming series, instead of expanding them before fetching the series.
+	lazyPostings func() bool
+	// maxExemplarsBytesPerQuery, if set and returning a value greater than 0, is the max size of the exemplars
+	// returned by an Exemplars() call.
+	maxExemplarsBytesPerQuery func() int
+	// exemplarsCache, if not nil, caches the exemplars decoded from the exemplars files of the blocks.
+	exemplarsCache *exemplarsCache
 
 	// Query gate which limits the maximum amount of concurrent queries.
 	queryGate gate.Gate
 
+	// indexHeaderPrefetchGate, if not nil, enables the background prefetching of the index-headers of the
+	// blocks discovered by SyncBlocks, and limits the maximum amount of concurrent prefetches.
+	indexHeaderPrefetchGate   gate.Gate
+	indexHeaderPrefetchCtx    context.Context
+	indexHeaderPrefetchCancel context.CancelFunc
+	indexHeaderPrefetchWg     sync.WaitGroup
+
+	// memoryLimiter, if not nil, rejects new Series() calls once the memory held by the in-flight ones is over the limit.
+	memoryLimiter *MemoryLimiter
+
 	// chunksLimiterFactory creates a new limiter used to limit the number of chunks fetched by each Series() call.
 	chunksLimiterFactory ChunksLimiterFactory
 	// seriesLimiterFactory creates a new limiter used to limit the number of touched series by each Series() call,
@@ -187,6 +251,22 @@ func WithIndexCache(cache indexcache.IndexCache) BucketStoreOption {
 	}
 }
 
+// WithChunksCache sets a chunksCache to use instead of a chunkscache.NoopCache.
+func WithChunksCache(cache chunkscache.Cache) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksCache = cache
+	}
+}
+
+// WithPostingsCache sets the cache of the expanded postings of the blocks. The cached postings of a block are
+// invalidated once the block is returned by deletionMarkedBlocks, or it's dropped.
+func WithPostingsCache(cache *postingscache.Cache, deletionMarkedBlocks func() map[ulid.ULID]*metadata.DeletionMark) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.postingsCache = cache
+		s.deletionMarkedBlocks = deletionMarkedBlocks
+	}
+}
+
 // WithQueryGate sets a queryGate to use instead of a noopGate.
 func WithQueryGate(queryGate gate.Gate) BucketStoreOption {
 	return func(s *BucketStore) {
@@ -194,6 +274,22 @@ func WithQueryGate(queryGate gate.Gate) BucketStoreOption {
 	}
 }
 
+// WithIndexHeaderPrefetching enables the background prefetching of the index-headers of the blocks
+// discovered by SyncBlocks, running at most as many concurrent prefetches as allowed by prefetchGate.
+func WithIndexHeaderPrefetching(prefetchGate gate.Gate) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.indexHeaderPrefetchGate = prefetchGate
+	}
+}
+
+// WithMemoryLimiter sets a MemoryLimiter used to reject new Series() calls once the memory held by the
+// in-flight ones is over the limit.
+func WithMemoryLimiter(memoryLimiter *MemoryLimiter) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.memoryLimiter = memoryLimiter
+	}
+}
+
 // WithChunkPool sets a pool.Bytes to use for chunks.
 func WithChunkPool(chunkPool pool.Bytes) BucketStoreOption {
 	return func(s *BucketStore) {
@@ -214,6 +310,151 @@ func WithStreamingSeriesPerBatch(seriesPerBatch int) BucketStoreOption {
 	}
 }
 
+// WithStreamingSeriesPerBatchOverride sets a function returning the number of series per batch overriding
+// the one set with WithStreamingSeriesPerBatch, if larger than zero. The function is called on each Series()
+// call, so that the override can be live reloaded.
+func WithStreamingSeriesPerBatchOverride(seriesPerBatch func() int) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.maxSeriesPerBatchOverride = seriesPerBatch
+	}
+}
+
+// WithStreamingSeriesAdaptivePreloading enables the adaptive preloading of series chunks batches when streaming
+// series, bounded by maxBytes of preloaded chunks for each Series() call.
+func WithStreamingSeriesAdaptivePreloading(maxBytes int) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.adaptivePreloadingMaxBytes = maxBytes
+	}
+}
+
+// WithStreamingSeriesEagerSending enables sending each series of a series chunks batch as soon as its
+// chunks have been loaded when streaming series, instead of waiting for the whole batch to be loaded.
+func WithStreamingSeriesEagerSending(enabled bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.eagerSending = enabled
+	}
+}
+
+// WithStreamingSeriesChunksSlabSize sets the number of chunks per slab used to allocate the chunks of a series
+// chunks batch when streaming series. If adaptive is true, the slab size of each batch is estimated from the
+// previous batch, up to slabSize.
+func WithStreamingSeriesChunksSlabSize(slabSize int, adaptive bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksSlabSize = slabSize
+		s.adaptiveChunksSlabSize = adaptive
+	}
+}
+
+// WithStreamingSeriesChunksDeduplication enables or disables the deduplication of the identical chunks of each series,
+// loaded from overlapping blocks, before sending the series to the querier when streaming series.
+func WithStreamingSeriesChunksDeduplication(enabled bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksDeduplication = enabled
+	}
+}
+
+// WithStreamingSeriesSlowBatchLogging enables logging the series chunks batches whose chunks took longer than
+// threshold to load when streaming series, along with the blocks they've been loaded from.
+func WithStreamingSeriesSlowBatchLogging(threshold time.Duration) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.slowBatchLogThreshold = threshold
+	}
+}
+
+// WithChunksFetching sets the max number of concurrent requests issued for each block to fetch the chunks
+// of a series batch, and the max size of a range of chunks fetched with a single request. A value of 0
+// disables the respective limit.
+func WithChunksFetching(concurrency int, maxRangeBytes uint64) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksFetchOpts.concurrency = concurrency
+		s.chunksFetchOpts.maxRangeBytes = maxRangeBytes
+	}
+}
+
+// WithChunksFetchAdaptiveConcurrency enables the adaptive concurrency of the requests issued to fetch the chunks,
+// replacing the concurrency set with WithChunksFetching. The concurrency of each block is computed from the number
+// of ranges to fetch, the number of blocks queried by the request and the load tracked by load, shared by all the
+// BucketStores, up to the value returned by maxConcurrency for the whole request. maxConcurrency is called on each
+// Series() call, so that it can be live reloaded.
+func WithChunksFetchAdaptiveConcurrency(load *chunksFetchLoad, maxConcurrency func() int) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksFetchOpts.adaptive = load
+		s.chunksFetchMaxConcurrency = maxConcurrency
+	}
+}
+
+// WithChunksFetchHedging enables the hedging of the requests issued to fetch the chunks. The hedging delay is
+// computed from the latency of the requests tracked by hedging, which can be shared by all the BucketStores.
+func WithChunksFetchHedging(hedging *chunksFetchHedging) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksFetchHedging = hedging
+	}
+}
+
+// WithSeriesHashesPersistence enables keeping the series hashes computed by the sharded queries for each block
+// and persisting them in the local directory of the block, along with the index-header.
+func WithSeriesHashesPersistence() BucketStoreOption {
+	return func(s *BucketStore) {
+		s.seriesHashesPersistence = true
+	}
+}
+
+// WithExternalLabelMatchers sets the names of the block external labels which can be matched by the request
+// label matchers. The matchers on these labels are removed from the series matchers and matched against the
+// external labels of each block instead, so that the blocks not matching them are skipped.
+func WithExternalLabelMatchers(names []string) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.externalLabelMatchers = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			s.externalLabelMatchers[name] = struct{}{}
+		}
+	}
+}
+
+// WithPartialResults sets a function returning whether a Series() call returns the series of the healthy blocks,
+// along with a warning for each failed block, when fetching the series of some blocks fails. The function is
+// called on each Series() call, so that the setting can be live reloaded.
+func WithPartialResults(enabled func() bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.partialResults = enabled
+	}
+}
+
+// WithMaxExemplarsBytesPerQuery sets a function returning the max size of the exemplars returned by an
+// Exemplars() call, or 0 if unlimited. The function is called on each Exemplars() call, so that the setting
+// can be live reloaded.
+func WithMaxExemplarsBytesPerQuery(limit func() int) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.maxExemplarsBytesPerQuery = limit
+	}
+}
+
+// withExemplarsCache sets the cache of the exemplars decoded from the exemplars files of the blocks. The cached
+// exemplars of a block are removed once it's dropped.
+func withExemplarsCache(cache *exemplarsCache) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.exemplarsCache = cache
+	}
+}
+
+// WithLazyPostings sets a function returning whether a Series() call streams the postings matching the label
+// matchers in batches, instead of expanding them before fetching the series, when streaming series. The function
+// is called on each Series() call, so that the setting can be live reloaded.
+func WithLazyPostings(enabled func() bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.lazyPostings = enabled
+	}
+}
+
+// WithDownsampledBlocks enables loading the blocks downsampled at 5m and 1h resolution, along with the raw blocks.
+// The blocks of the biggest resolution not bigger than the request max resolution window are queried, filling the
+// time range not covered by them with blocks of smaller resolutions.
+func WithDownsampledBlocks() BucketStoreOption {
+	return func(s *BucketStore) {
+		s.blockSet = newBucketBlockSet(downsampledResolutions)
+	}
+}
+
 // NewBucketStore creates a new bucket backed store that implements the store API against
 // an object store bucket. It is optimized to work against high latency backends.
 func NewBucketStore(
@@ -229,6 +470,7 @@ func NewBucketStore(
 	indexHeaderCfg indexheader.Config,
 	lazyIndexReaderEnabled bool,
 	lazyIndexReaderIdleTimeout time.Duration,
+	lazyIndexReaderMaxLoaded int,
 	seriesHashCache *hashcache.SeriesHashCache,
 	metrics *BucketStoreMetrics,
 	options ...BucketStoreOption,
@@ -239,11 +481,13 @@ func NewBucketStore(
 		fetcher:                     fetcher,
 		dir:                         dir,
 		indexCache:                  noopCache{},
+		chunksCache:                 chunkscache.NoopCache{},
 		chunkPool:                   pool.NoopBytes{},
 		blocks:                      map[ulid.ULID]*bucketBlock{},
-		blockSet:                    newBucketBlockSet(),
+		blockSet:                    newBucketBlockSet(rawResolutions),
 		blockSyncConcurrency:        blockSyncConcurrency,
 		queryGate:                   gate.NewNoop(),
+		chunksSlabSize:              seriesChunksSlabSize,
 		chunksLimiterFactory:        chunksLimiterFactory,
 		seriesLimiterFactory:        seriesLimiterFactory,
 		partitioner:                 partitioner,
@@ -253,13 +497,14 @@ func NewBucketStore(
 		metrics:                     metrics,
 		userID:                      userID,
 	}
+	s.indexHeaderPrefetchCtx, s.indexHeaderPrefetchCancel = context.WithCancel(context.Background())
 
 	for _, option := range options {
 		option(s)
 	}
 
 	// Depend on the options
-	s.indexReaderPool = indexheader.NewReaderPool(s.logger, lazyIndexReaderEnabled, lazyIndexReaderIdleTimeout, metrics.indexHeaderReaderMetrics)
+	s.indexReaderPool = indexheader.NewReaderPool(s.logger, lazyIndexReaderEnabled, lazyIndexReaderIdleTimeout, lazyIndexReaderMaxLoaded, metrics.indexHeaderReaderMetrics)
 
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, errors.Wrap(err, "create dir")
@@ -270,6 +515,12 @@ func NewBucketStore(
 
 // RemoveBlocksAndClose remove all blocks from local disk and releases all resources associated with the BucketStore.
 func (s *BucketStore) RemoveBlocksAndClose() error {
+	// Stop the in-flight index-header prefetches before closing the blocks.
+	if s.indexHeaderPrefetchCancel != nil {
+		s.indexHeaderPrefetchCancel()
+	}
+	s.indexHeaderPrefetchWg.Wait()
+
 	err := s.removeAllBlocks()
 
 	// Release other resources even if it failed to close some blocks.
@@ -308,6 +559,9 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 				if err := s.addBlock(ctx, meta); err != nil {
 					continue
 				}
+				if s.indexHeaderPrefetchGate != nil {
+					s.prefetchIndexHeader(meta.ULID)
+				}
 			}
 			wg.Done()
 		}()
@@ -315,6 +569,11 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 
 	for id, meta := range metas {
 		if b := s.getBlock(id); b != nil {
+			// Keep the index-header of the compacted blocks loaded while their source blocks are still in the storage,
+			// so that it isn't unloaded because idle before the queriers switch from the source blocks to them.
+			if s.indexHeaderPrefetchGate != nil && len(meta.Compaction.Parents) > 0 {
+				s.prefetchIndexHeader(id)
+			}
 			continue
 		}
 		select {
@@ -341,9 +600,52 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		level.Info(s.logger).Log("msg", "dropped outdated block", "block", id)
 	}
 
+	s.invalidateDeletionMarkedBlocksPostings()
+	s.persistSeriesHashes()
+
 	return nil
 }
 
+// persistSeriesHashes persists the series hashes of the blocks computed since the last sync.
+func (s *BucketStore) persistSeriesHashes() {
+	if !s.seriesHashesPersistence {
+		return
+	}
+
+	// Don't hold the lock while writing the files.
+	s.blocksMx.RLock()
+	blocks := make([]*bucketBlock, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		blocks = append(blocks, b)
+	}
+	s.blocksMx.RUnlock()
+
+	for _, b := range blocks {
+		if err := b.seriesHashes.persist(); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to persist the series hashes", "block", b.meta.ULID, "err", err)
+		}
+	}
+}
+
+// invalidateDeletionMarkedBlocksPostings removes from the postings cache the expanded postings of the blocks marked
+// for deletion, which are still queried until the deletion marks delay expires, and stops caching them.
+func (s *BucketStore) invalidateDeletionMarkedBlocksPostings() {
+	if s.postingsCache == nil || s.deletionMarkedBlocks == nil {
+		return
+	}
+
+	s.blocksMx.RLock()
+	defer s.blocksMx.RUnlock()
+
+	for id := range s.deletionMarkedBlocks() {
+		b, ok := s.blocks[id]
+		if !ok || b.postingsCacheInvalidated.Swap(true) {
+			continue
+		}
+		s.postingsCache.InvalidateBlock(s.userID, id)
+	}
+}
+
 // InitialSync perform blocking sync with extra step at the end to delete locally saved blocks that are no longer
 // present in the bucket. The mismatch of these can only happen between restarts, so we can do that only once per startup.
 func (s *BucketStore) InitialSync(ctx context.Context) error {
@@ -429,6 +731,7 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 		s.bkt,
 		dir,
 		s.indexCache,
+		s.chunksCache,
 		s.chunkPool,
 		indexHeaderReader,
 		s.partitioner,
@@ -436,6 +739,12 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	if err != nil {
 		return errors.Wrap(err, "new bucket block")
 	}
+	b.postingsCache = s.postingsCache
+	b.exemplarsCache = s.exemplarsCache
+	b.chunksFetchHedging = s.chunksFetchHedging
+	if s.seriesHashesPersistence {
+		b.seriesHashes = loadBlockSeriesHashes(dir, b.logger)
+	}
 	defer func() {
 		if err != nil {
 			runutil.CloseWithErrCapture(&err, b, "index-header")
@@ -453,6 +762,43 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	return nil
 }
 
+// prefetchIndexHeader loads in background the index-header of the block with the given ID, so that
+// the first query hitting the block doesn't have to wait for the index-header to be lazy loaded.
+func (s *BucketStore) prefetchIndexHeader(id ulid.ULID) {
+	s.metrics.indexHeaderPrefetchQueueLength.Inc()
+	s.indexHeaderPrefetchWg.Add(1)
+
+	go func() {
+		defer s.indexHeaderPrefetchWg.Done()
+
+		err := s.indexHeaderPrefetchGate.Start(s.indexHeaderPrefetchCtx)
+		s.metrics.indexHeaderPrefetchQueueLength.Dec()
+		if err != nil {
+			// The store is closing.
+			return
+		}
+		defer s.indexHeaderPrefetchGate.Done()
+
+		// The block may have been removed in the meanwhile. Tracking the prefetch as a pending
+		// reader guarantees the block isn't closed while its index-header is being loaded.
+		s.blocksMx.RLock()
+		b, ok := s.blocks[id]
+		if ok {
+			b.pendingReaders.Add(1)
+		}
+		s.blocksMx.RUnlock()
+
+		if !ok {
+			return
+		}
+		defer b.pendingReaders.Done()
+
+		if _, err := b.indexHeaderReader.IndexVersion(); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to prefetch index-header", "block", id, "err", err)
+		}
+	}()
+}
+
 func (s *BucketStore) removeBlock(id ulid.ULID) (returnErr error) {
 	defer func() {
 		if returnErr != nil {
@@ -476,6 +822,13 @@ func (s *BucketStore) removeBlock(id ulid.ULID) (returnErr error) {
 	// even if releasing its resources could fail below.
 	s.metrics.blockDrops.Inc()
 
+	if s.postingsCache != nil && !b.postingsCacheInvalidated.Swap(true) {
+		s.postingsCache.InvalidateBlock(s.userID, id)
+	}
+	if s.exemplarsCache != nil {
+		s.exemplarsCache.invalidateBlock(s.userID, id)
+	}
+
 	if err := b.Close(); err != nil {
 		return errors.Wrap(err, "close block")
 	}
@@ -714,7 +1067,7 @@ func blockSeries(
 		return newBucketSeriesSet(res), reqStats, nil
 	}
 
-	if err := chunkr.load(res, chunksPool, reqStats); err != nil {
+	if err := chunkr.load(ctx, res, chunksPool, reqStats, nil); err != nil {
 		return nil, nil, errors.Wrap(err, "load chunks")
 	}
 
@@ -831,6 +1184,13 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		defer s.queryGate.Done()
 	}
 
+	// The memory in use is checked once the request got its turn, given the memory held by the
+	// in-flight requests may have been released while waiting for the query gate.
+	if err := s.memoryLimiter.Admit(); err != nil {
+		s.metrics.queriesDropped.WithLabelValues("memory").Inc()
+		return status.Error(codes.ResourceExhausted, errors.Wrap(err, "rejected series request").Error())
+	}
+
 	matchers, err := storepb.MatchersToPromMatchers(req.Matchers...)
 	if err != nil {
 		return status.Error(codes.InvalidArgument, err.Error())
@@ -842,6 +1202,13 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		return status.Error(codes.InvalidArgument, errors.Wrap(err, "parse query sharding label").Error())
 	}
 
+	// Check if matchers include matchers on the block external labels.
+	externalLabelMatchers, matchers := s.removeExternalLabelMatchers(matchers)
+	if len(externalLabelMatchers) > 0 && len(matchers) == 0 {
+		// The request selects all the series of the matching blocks.
+		matchers = []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".*")}
+	}
+
 	spanLogger := spanlogger.FromContext(srv.Context(), s.logger)
 	level.Debug(spanLogger).Log(
 		"msg", "BucketStore.Series",
@@ -849,6 +1216,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		"request max time", time.UnixMilli(req.MaxTime).UTC().Format(time.RFC3339Nano),
 		"request matchers", storepb.PromMatchersToString(matchers...),
 		"request shard selector", maybeNilShard(shardSelector).LabelValue(),
+		"request external label matchers", storepb.PromMatchersToString(externalLabelMatchers...),
 	)
 
 	var (
@@ -859,6 +1227,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		seriesLimiter    = s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
 	)
 	defer s.recordSeriesCallResult(stats)
+	defer logSeriesPoolsBorrowedSize(spanLogger, stats)
 
 	if req.Hints != nil {
 		reqHints := &hintspb.SeriesRequestHints{}
@@ -874,7 +1243,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 
 	span, ctx := tracing.StartSpan(ctx, "bucket_store_preload_all")
 
-	blocks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, req.SkipChunks, req.MinTime, req.MaxTime, req.MaxResolutionWindow, reqBlockMatchers)
+	blocks, skippedBlocks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, req.SkipChunks, req.MinTime, req.MaxTime, req.MaxResolutionWindow, reqBlockMatchers, externalLabelMatchers)
 	// We must keep the readers open until all their data has been sent.
 	for _, r := range indexReaders {
 		defer runutil.CloseWithLogOnErr(s.logger, r, "close block index reader")
@@ -888,32 +1257,49 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	var (
 		seriesSet storepb.SeriesSet
 		resHints  = &hintspb.SeriesResponseHints{}
+		failures  *blockFailures
 	)
 
-	if s.maxSeriesPerBatch <= 0 {
+	if s.partialResults != nil && s.partialResults() {
+		failures = newBlockFailures(spanLogger, s.metrics.seriesBlocksFailed)
+	}
+
+	if maxSeriesPerBatch := s.seriesPerBatch(); maxSeriesPerBatch <= 0 {
 		var chunksPool *pool.BatchBytes
 
 		// All the memory allocated from the pool for the chunks will be released at the end.
 		// Required only if we'll fetch the chunks.
 		if !req.SkipChunks {
 			chunksPool = &pool.BatchBytes{Delegate: s.chunkPool}
-			defer chunksPool.Release()
+			defer func() {
+				borrowed := chunksPool.Borrowed()
+				stats.update(func(stats *queryStats) {
+					stats.chunksPoolBorrowedSizeSum += borrowed
+				})
+				chunksPool.Release()
+			}()
 		}
 
-		seriesSet, err = s.synchronousSeriesSet(ctx, req, stats, blocks, indexReaders, chunkReaders, chunksPool, resHints, shardSelector, matchers, chunksLimiter, seriesLimiter)
+		seriesSet, err = s.synchronousSeriesSet(ctx, req, stats, blocks, indexReaders, chunkReaders, chunksPool, resHints, shardSelector, matchers, chunksLimiter, seriesLimiter, failures)
 	} else {
 		var readers *bucketChunkReaders
 		if !req.SkipChunks {
 			readers = newChunkReaders(chunkReaders)
 		}
 
-		seriesSet, resHints, err = s.streamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, readers, s.chunkPool, shardSelector, matchers, chunksLimiter, seriesLimiter, stats)
+		seriesSet, resHints, err = s.streamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, readers, s.chunkPool, shardSelector, matchers, chunksLimiter, seriesLimiter, maxSeriesPerBatch, stats, failures)
 	}
 
 	if err != nil {
 		return err
 	}
 
+	// The blocks skipped because of their external labels can't contain any of the requested series,
+	// so they're reported as queried.
+	for _, b := range skippedBlocks {
+		resHints.AddQueriedBlock(b.meta.ULID)
+	}
+
 	// Merge the sub-results from each selected block.
 	mergeStats := &queryStats{}
 	tracing.DoWithSpan(ctx, "bucket_store_merge_all", func(ctx context.Context, _ tracing.Span) {
@@ -959,6 +1345,20 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		return
 	}
 
+	// The failed blocks are still reported as queried, so that the querier doesn't query them again from other
+	// store-gateways, and the failures are reported as warnings instead.
+	for _, warning := range failures.warnings() {
+		if err = srv.Send(storepb.NewWarnSeriesResponse(warning)); err != nil {
+			err = status.Error(codes.Unknown, errors.Wrap(err, "send series response warning").Error())
+			return
+		}
+	}
+
+	unsafeStats := stats.export()
+	if !req.SkipChunks {
+		resHints.QueryStats = unsafeStats.toHints()
+	}
+
 	var anyHints *types.Any
 	if anyHints, err = types.MarshalAny(resHints); err != nil {
 		err = status.Error(codes.Unknown, errors.Wrap(err, "marshal series response hints").Error())
@@ -970,7 +1370,6 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		return
 	}
 
-	unsafeStats := stats.export()
 	if err = srv.Send(storepb.NewStatsResponse(unsafeStats.postingsFetchedSizeSum + unsafeStats.seriesFetchedSizeSum)); err != nil {
 		err = status.Error(codes.Unknown, errors.Wrap(err, "sends series response stats").Error())
 		return
@@ -1001,6 +1400,7 @@ func (s *BucketStore) synchronousSeriesSet(
 	matchers []*labels.Matcher,
 	chunksLimiter ChunksLimiter,
 	seriesLimiter SeriesLimiter,
+	failures *blockFailures,
 ) (storepb.SeriesSet, error) {
 	var (
 		resMtx sync.Mutex
@@ -1032,7 +1432,7 @@ func (s *BucketStore) synchronousSeriesSet(
 				chunksPool,
 				matchers,
 				shardSelector,
-				cachedSeriesHasher{blockSeriesHashCache},
+				newSeriesHasher(b, blockSeriesHashCache),
 				chunksLimiter,
 				seriesLimiter,
 				req.SkipChunks,
@@ -1040,6 +1440,9 @@ func (s *BucketStore) synchronousSeriesSet(
 				s.logger,
 			)
 			if err != nil {
+				if failures.tolerate(ctx, b.meta.ULID, err) {
+					return nil
+				}
 				return errors.Wrapf(err, "fetch series for block %s", b.meta.ULID)
 			}
 
@@ -1081,13 +1484,17 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 	matchers []*labels.Matcher,
 	chunksLimiter ChunksLimiter,
 	seriesLimiter SeriesLimiter,
+	maxSeriesPerBatch int,
 	stats *safeQueryStats,
+	failures *blockFailures,
 ) (storepb.SeriesSet, *hintspb.SeriesResponseHints, error) {
 	var (
 		resHints = &hintspb.SeriesResponseHints{}
 		mtx      = sync.Mutex{}
 		batches  = make([]seriesChunkRefsSetIterator, 0, len(blocks))
 		g, _     = errgroup.WithContext(ctx)
+
+		lazyPostings = s.lazyPostings != nil && s.lazyPostings()
 	)
 
 	for _, b := range blocks {
@@ -1111,14 +1518,15 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 
 			part, err = openBlockSeriesChunkRefsSetsIterator(
 				ctx,
-				s.maxSeriesPerBatch,
+				maxSeriesPerBatch,
+				lazyPostings,
 				s.userID,
 				indexr,
 				s.indexCache,
 				b.meta,
 				matchers,
 				shardSelector,
-				cachedSeriesHasher{blockSeriesHashCache},
+				newSeriesHasher(b, blockSeriesHashCache),
 				chunksLimiter,
 				seriesLimiter,
 				req.SkipChunks,
@@ -1128,8 +1536,14 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 				s.logger,
 			)
 			if err != nil {
+				if failures.tolerate(ctx, b.meta.ULID, err) {
+					return nil
+				}
 				return errors.Wrapf(err, "fetch series for block %s", b.meta.ULID)
 			}
+			if failures != nil {
+				part = newPartialResultsSeriesChunkRefsSetIterator(ctx, part, b.meta.ULID, failures)
+			}
 
 			mtx.Lock()
 			batches = append(batches, part)
@@ -1153,16 +1567,32 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 	s.metrics.seriesGetAllDuration.Observe(getAllDuration.Seconds())
 	s.metrics.seriesBlocksQueried.Observe(float64(len(batches)))
 
-	mergedBatches := mergedSeriesChunkRefsSetIterators(s.maxSeriesPerBatch, batches...)
+	mergedBatches := mergedSeriesChunkRefsSetIterators(maxSeriesPerBatch, batches...)
 	var set storepb.SeriesSet
 	if chunkReaders != nil {
-		set = newSeriesSetWithChunks(ctx, *chunkReaders, chunksPool, mergedBatches, s.maxSeriesPerBatch, stats, s.metrics.iteratorLoadDurations)
+		batchObserver := &seriesChunksBatchObserver{
+			chunksBytes:   s.metrics.seriesBatchChunksBytes,
+			slowThreshold: s.slowBatchLogThreshold,
+			logger:        log.With(spanlogger.FromContext(ctx, s.logger), "user", s.userID),
+		}
+		set = newSeriesSetWithChunks(ctx, *chunkReaders, chunksPool, mergedBatches, maxSeriesPerBatch, s.adaptivePreloadingMaxBytes, s.eagerSending, s.chunksSlabSize, s.adaptiveChunksSlabSize, s.chunksDeduplication, batchObserver, stats, s.metrics.iteratorLoadDurations)
 	} else {
 		set = newSeriesSetWithoutChunks(ctx, mergedBatches)
 	}
 	return set, resHints, nil
 }
 
+// seriesPerBatch returns the number of series per batch to use for a Series() call. The bucketStore uses
+// streaming when it's larger than zero.
+func (s *BucketStore) seriesPerBatch() int {
+	if s.maxSeriesPerBatchOverride != nil {
+		if override := s.maxSeriesPerBatchOverride(); override > 0 {
+			return override
+		}
+	}
+	return s.maxSeriesPerBatch
+}
+
 func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
 	stats := safeStats.export()
 	s.metrics.seriesDataTouched.WithLabelValues("postings").Observe(float64(stats.postingsTouched))
@@ -1178,6 +1608,7 @@ func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
 	s.metrics.seriesDataSizeTouched.WithLabelValues("chunks").Observe(float64(stats.chunksTouchedSizeSum))
 	s.metrics.seriesDataSizeFetched.WithLabelValues("chunks").Observe(float64(stats.chunksFetchedSizeSum))
 	s.metrics.resultSeriesCount.Observe(float64(stats.mergedSeriesCount))
+	s.metrics.chunksDeduplicated.Add(float64(stats.chunksDeduplicated))
 	s.metrics.cachedPostingsCompressions.WithLabelValues(labelEncode).Add(float64(stats.cachedPostingsCompressions))
 	s.metrics.cachedPostingsCompressions.WithLabelValues(labelDecode).Add(float64(stats.cachedPostingsDecompressions))
 	s.metrics.cachedPostingsCompressionErrors.WithLabelValues(labelEncode).Add(float64(stats.cachedPostingsCompressionErrors))
@@ -1191,7 +1622,20 @@ func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
 	s.metrics.expandPostingsDuration.Observe(stats.expandedPostingsDuration.Seconds())
 }
 
-func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool, minT, maxT, maxResolutionMillis int64, blockMatchers []*labels.Matcher) ([]*bucketBlock, map[ulid.ULID]*bucketIndexReader, map[ulid.ULID]chunkReader) {
+// logSeriesPoolsBorrowedSize logs the memory borrowed from the pools by a Series() call, so that it's recorded in its span.
+func logSeriesPoolsBorrowedSize(logger log.Logger, safeStats *safeQueryStats) {
+	stats := safeStats.export()
+	level.Debug(logger).Log(
+		"msg", "BucketStore.Series pools usage",
+		"series pool borrowed bytes", stats.seriesPoolBorrowedSizeSum,
+		"series chunks pool borrowed bytes", stats.seriesChunksPoolBorrowedSizeSum,
+		"chunks pool borrowed bytes", stats.chunksPoolBorrowedSizeSum,
+	)
+}
+
+// openBlocksForReading opens the readers of the blocks matching the request. The blocks whose external labels
+// don't match externalLabelMatchers are returned as skipped, without opening their readers.
+func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool, minT, maxT, maxResolutionMillis int64, blockMatchers, externalLabelMatchers []*labels.Matcher) ([]*bucketBlock, []*bucketBlock, map[ulid.ULID]*bucketIndexReader, map[ulid.ULID]chunkReader) {
 	s.blocksMx.RLock()
 	defer s.blocksMx.RUnlock()
 
@@ -1201,20 +1645,57 @@ func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool,
 		debugFoundBlockSetOverview(s.logger, minT, maxT, maxResolutionMillis, blocks)
 	}
 
+	var skipped []*bucketBlock
+	if len(externalLabelMatchers) > 0 {
+		matching := blocks[:0]
+		for _, b := range blocks {
+			if b.matchLabels(externalLabelMatchers) {
+				matching = append(matching, b)
+			} else {
+				skipped = append(skipped, b)
+			}
+		}
+		blocks = matching
+	}
+
 	indexReaders := make(map[ulid.ULID]*bucketIndexReader, len(blocks))
 	for _, b := range blocks {
 		indexReaders[b.meta.ULID] = b.indexReader()
 	}
 	if skipChunks {
-		return blocks, indexReaders, nil
+		return blocks, skipped, indexReaders, nil
+	}
+
+	fetchOpts := s.chunksFetchOpts
+	if fetchOpts.adaptive != nil {
+		fetchOpts.blocks = len(blocks)
+		fetchOpts.maxConcurrency = s.chunksFetchMaxConcurrency()
 	}
 
 	chunkReaders := make(map[ulid.ULID]chunkReader, len(blocks))
 	for _, b := range blocks {
-		chunkReaders[b.meta.ULID] = b.chunkReader(ctx)
+		chunkReaders[b.meta.ULID] = b.chunkReader(fetchOpts)
 	}
 
-	return blocks, indexReaders, chunkReaders
+	return blocks, skipped, indexReaders, chunkReaders
+}
+
+// removeExternalLabelMatchers returns the matchers on the block external labels configured with
+// WithExternalLabelMatchers, and the input matchers without them.
+func (s *BucketStore) removeExternalLabelMatchers(matchers []*labels.Matcher) (external, filtered []*labels.Matcher) {
+	if len(s.externalLabelMatchers) == 0 {
+		return nil, matchers
+	}
+
+	filtered = make([]*labels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		if _, ok := s.externalLabelMatchers[m.Name]; ok {
+			external = append(external, m)
+		} else {
+			filtered = append(filtered, m)
+		}
+	}
+	return external, filtered
 }
 
 // LabelNames implements the storepb.StoreServer interface.
@@ -1223,6 +1704,7 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
 	}
+	externalLabelMatchers, reqSeriesMatchers := s.removeExternalLabelMatchers(reqSeriesMatchers)
 
 	resHints := &hintspb.LabelNamesResponseHints{}
 
@@ -1259,6 +1741,11 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		// The blocks whose external labels don't match are reported as queried, without looking up their index.
+		if len(externalLabelMatchers) > 0 && !b.matchLabels(externalLabelMatchers) {
+			continue
+		}
+
 		indexr := b.indexReader()
 
 		g.Go(func() error {
@@ -1313,32 +1800,71 @@ func blockLabelNames(ctx context.Context, indexr *bucketIndexReader, matchers []
 		return names, nil
 	}
 
-	// We ignore request's min/max time and query the entire block to make the result cacheable.
-	minTime, maxTime := indexr.block.meta.MinTime, indexr.block.meta.MaxTime
-	seriesSet, _, err := blockSeries(ctx, indexr, nil, nil, matchers, nil, nil, nil, seriesLimiter, true, minTime, maxTime, logger)
+	names, err := labelNamesOfMatchingSeries(ctx, indexr, matchers, seriesLimiter)
 	if err != nil {
-		return nil, errors.Wrap(err, "fetch series")
+		return nil, err
 	}
 
-	// Extract label names from all series. Many label names will be the same, so we need to deduplicate them.
-	labelNames := map[string]struct{}{}
-	for seriesSet.Next() {
-		ls, _ := seriesSet.At()
-		for _, l := range ls {
-			labelNames[l.Name] = struct{}{}
-		}
+	storeCachedLabelNames(ctx, indexr.block.indexCache, indexr.block.userID, indexr.block.meta.ULID, matchers, names, logger)
+	return names, nil
+}
+
+// labelNamesOfMatchingSeries returns the sorted names of the labels of the series matching the matchers.
+// Only the index is read: the series label names symbols are collected without looking up the series
+// label values, and the chunks are never loaded.
+func labelNamesOfMatchingSeries(ctx context.Context, indexr *bucketIndexReader, matchers []*labels.Matcher, seriesLimiter SeriesLimiter) ([]string, error) {
+	stats := newSafeQueryStats()
+
+	ps, err := indexr.ExpandedPostings(ctx, matchers, stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanded matching posting")
+	}
+	if len(ps) == 0 {
+		return nil, nil
+	}
+
+	loadedSeries, err := indexr.preloadSeries(ctx, ps, stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "preload series")
 	}
-	if seriesSet.Err() != nil {
-		return nil, errors.Wrap(seriesSet.Err(), "iterate series")
+
+	var (
+		symbolizedLset []symbolizedLabel
+		chks           []chunks.Meta
+		postingsStats  = &queryStats{}
+		nameSymbols    = map[uint32]struct{}{}
+	)
+
+	// We ignore request's min/max time and query the entire block to make the result cacheable.
+	minTime, maxTime := indexr.block.meta.MinTime, indexr.block.meta.MaxTime
+	for _, id := range ps {
+		ok, err := loadedSeries.unsafeLoadSeriesForTime(id, &symbolizedLset, &chks, true, minTime, maxTime, postingsStats)
+		if err != nil {
+			return nil, errors.Wrap(err, "read series")
+		}
+		if !ok {
+			continue
+		}
+
+		if err := seriesLimiter.Reserve(1); err != nil {
+			return nil, errors.Wrap(err, "exceeded series limit")
+		}
+
+		for _, l := range symbolizedLset {
+			nameSymbols[l.name] = struct{}{}
+		}
 	}
 
-	names = make([]string, 0, len(labelNames))
-	for n := range labelNames {
-		names = append(names, n)
+	names := make([]string, 0, len(nameSymbols))
+	for ref := range nameSymbols {
+		name, err := indexr.dec.LookupSymbol(ref)
+		if err != nil {
+			return nil, errors.Wrap(err, "lookup label name")
+		}
+		names = append(names, name)
 	}
 	slices.Sort(names)
 
-	storeCachedLabelNames(ctx, indexr.block.indexCache, indexr.block.userID, indexr.block.meta.ULID, matchers, names, logger)
 	return names, nil
 }
 
@@ -1385,6 +1911,7 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
 	}
+	externalLabelMatchers, reqSeriesMatchers := s.removeExternalLabelMatchers(reqSeriesMatchers)
 
 	resHints := &hintspb.LabelValuesResponseHints{}
 
@@ -1420,6 +1947,11 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		// The blocks whose external labels don't match are reported as queried, without looking up their index.
+		if len(externalLabelMatchers) > 0 && !b.matchLabels(externalLabelMatchers) {
+			continue
+		}
+
 		indexr := b.indexReader()
 
 		g.Go(func() error {
@@ -1461,6 +1993,7 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 // optionally restricting the search to the series that match the matchers provided.
 // - First we fetch all possible values for this label from the index.
 //   - If no matchers were provided, we just return those values.
+//   - If all the matchers are on the requested label, we just return the values matching them.
 //
 // - Next we load the postings (references to series) for supplied matchers.
 // - Then we load the postings for each label-value fetched in the first step.
@@ -1486,6 +2019,20 @@ func blockLabelValues(ctx context.Context, indexr *bucketIndexReader, labelName
 		return allValues, nil
 	}
 
+	// When all the matchers are on the requested label, a series has one of the values if and only if the
+	// value matches all the matchers, so the values can be filtered without looking up the postings.
+	if matchersOnlyOnLabel(matchers, labelName) {
+		matched := make([]string, 0, len(allValues))
+		for _, value := range allValues {
+			if matchesAll(matchers, value) {
+				matched = append(matched, value)
+			}
+		}
+
+		storeCachedLabelValues(ctx, indexr.block.indexCache, indexr.block.userID, indexr.block.meta.ULID, labelName, matchers, matched, logger)
+		return matched, nil
+	}
+
 	p, err := indexr.ExpandedPostings(ctx, matchers, stats)
 	if err != nil {
 		return nil, errors.Wrap(err, "expanded postings")
@@ -1516,6 +2063,24 @@ func blockLabelValues(ctx context.Context, indexr *bucketIndexReader, labelName
 	return matched, nil
 }
 
+func matchersOnlyOnLabel(matchers []*labels.Matcher, labelName string) bool {
+	for _, m := range matchers {
+		if m.Name != labelName {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAll(matchers []*labels.Matcher, value string) bool {
+	for _, m := range matchers {
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
 type labelValuesCacheEntry struct {
 	Values      []string
 	LabelName   string
@@ -1567,13 +2132,21 @@ type bucketBlockSet struct {
 	blocks      [][]*bucketBlock // Ordered buckets for the existing resolutions.
 }
 
-// newBucketBlockSet initializes a new set with the known downsampling windows hard-configured.
-// (Mimir only supports no-downsampling)
-// The set currently does not support arbitrary ranges.
-func newBucketBlockSet() *bucketBlockSet {
+var (
+	// rawResolutions are the resolutions of the blocks loaded by default: only the raw blocks.
+	rawResolutions = []int64{downsample.ResLevel0}
+
+	// downsampledResolutions are the resolutions of the blocks loaded when the downsampled blocks are enabled,
+	// from the biggest to the smallest one.
+	downsampledResolutions = []int64{downsample.ResLevel2, downsample.ResLevel1, downsample.ResLevel0}
+)
+
+// newBucketBlockSet initializes a new set with the input downsampling windows, sorted from the biggest to the
+// smallest one. The set currently does not support arbitrary ranges.
+func newBucketBlockSet(resolutions []int64) *bucketBlockSet {
 	return &bucketBlockSet{
-		resolutions: []int64{0},
-		blocks:      make([][]*bucketBlock, 3),
+		resolutions: resolutions,
+		blocks:      make([][]*bucketBlock, len(resolutions)),
 	}
 }
 
@@ -1624,7 +2197,8 @@ func int64index(s []int64, x int64) int {
 
 // getFor returns a time-ordered list of blocks that cover date between mint and maxt.
 // Blocks with the biggest resolution possible but not bigger than the given max resolution are returned.
-// It supports overlapping blocks.
+// The blocks not matching the block-level matchers don't cover any time range, so that it's filled with
+// the matching blocks of smaller resolutions. It supports overlapping blocks.
 //
 // NOTE: s.blocks are expected to be sorted in minTime order.
 func (s *bucketBlockSet) getFor(mint, maxt, maxResolutionMillis int64, blockMatchers []*labels.Matcher) (bs []*bucketBlock) {
@@ -1653,15 +2227,16 @@ func (s *bucketBlockSet) getFor(mint, maxt, maxResolutionMillis int64, blockMatc
 			break
 		}
 
-		if i+1 < len(s.resolutions) {
-			bs = append(bs, s.getFor(start, b.meta.MinTime-1, s.resolutions[i+1], blockMatchers)...)
-		}
-
 		// Include the block in the list of matching ones only if there are no block-level matchers
 		// or they actually match.
-		if len(blockMatchers) == 0 || b.matchLabels(blockMatchers) {
-			bs = append(bs, b)
+		if len(blockMatchers) > 0 && !b.matchLabels(blockMatchers) {
+			continue
+		}
+
+		if i+1 < len(s.resolutions) {
+			bs = append(bs, s.getFor(start, b.meta.MinTime-1, s.resolutions[i+1], blockMatchers)...)
 		}
+		bs = append(bs, b)
 
 		start = b.meta.MaxTime
 	}
@@ -1675,14 +2250,15 @@ func (s *bucketBlockSet) getFor(mint, maxt, maxResolutionMillis int64, blockMatc
 // bucketBlock represents a block that is located in a bucket. It holds intermediate
 // state for the block on local disk.
 type bucketBlock struct {
-	userID     string
-	logger     log.Logger
-	metrics    *BucketStoreMetrics
-	bkt        objstore.BucketReader
-	meta       *metadata.Meta
-	dir        string
-	indexCache indexcache.IndexCache
-	chunkPool  pool.Bytes
+	userID      string
+	logger      log.Logger
+	metrics     *BucketStoreMetrics
+	bkt         objstore.BucketReader
+	meta        *metadata.Meta
+	dir         string
+	indexCache  indexcache.IndexCache
+	chunksCache chunkscache.Cache
+	chunkPool   pool.Bytes
 
 	indexHeaderReader indexheader.Reader
 
@@ -1697,6 +2273,19 @@ type bucketBlock struct {
 	blockLabels labels.Labels
 
 	expandedPostingsPromises sync.Map
+
+	// exemplarsCache, if not nil, caches the exemplars of the block, decoded from the exemplars file.
+	exemplarsCache *exemplarsCache
+
+	// postingsCache, if not nil, caches the expanded postings of the block until postingsCacheInvalidated is set.
+	postingsCache            *postingscache.Cache
+	postingsCacheInvalidated atomic.Bool
+
+	// chunksFetchHedging, if not nil, hedges the requests issued to fetch the chunks.
+	chunksFetchHedging *chunksFetchHedging
+
+	// seriesHashes, if not nil, holds the series hashes persisted in the local directory of the block.
+	seriesHashes *blockSeriesHashes
 }
 
 func newBucketBlock(
@@ -1708,6 +2297,7 @@ func newBucketBlock(
 	bkt objstore.BucketReader,
 	dir string,
 	indexCache indexcache.IndexCache,
+	chunksCache chunkscache.Cache,
 	chunkPool pool.Bytes,
 	indexHeadReader indexheader.Reader,
 	p Partitioner,
@@ -1718,13 +2308,14 @@ func newBucketBlock(
 		metrics:           metrics,
 		bkt:               bkt,
 		indexCache:        indexCache,
+		chunksCache:       chunksCache,
 		chunkPool:         chunkPool,
 		dir:               dir,
 		partitioner:       p,
 		meta:              meta,
 		indexHeaderReader: indexHeadReader,
-		// Inject the block ID as a label to allow to match blocks by ID.
-		blockLabels: labels.FromStrings(block.BlockIDLabel, meta.ULID.String()),
+		// Inject the block ID as a label to allow to match blocks by ID, along with the block external labels.
+		blockLabels: blockLabelsFromMeta(meta),
 	}
 
 	// Get object handles for all chunk files (segment files) from meta.json, if available.
@@ -1775,7 +2366,7 @@ func (b *bucketBlock) readChunkRange(ctx context.Context, seq int, off, length i
 	}
 
 	// Get a reader for the required range.
-	reader, err := b.bkt.GetRange(ctx, b.chunkObjs[seq], off, length)
+	reader, err := b.getChunkRange(ctx, seq, off, length)
 	if err != nil {
 		return nil, errors.Wrap(err, "get range reader")
 	}
@@ -1800,6 +2391,13 @@ func (b *bucketBlock) chunkRangeReader(ctx context.Context, seq int, off, length
 		return nil, errors.Errorf("unknown segment file for index %d", seq)
 	}
 
+	return b.getChunkRange(ctx, seq, off, length)
+}
+
+func (b *bucketBlock) getChunkRange(ctx context.Context, seq int, off, length int64) (io.ReadCloser, error) {
+	if b.chunksFetchHedging != nil {
+		return b.chunksFetchHedging.getRange(ctx, b.bkt, b.chunkObjs[seq], off, length, b.logger)
+	}
 	return b.bkt.GetRange(ctx, b.chunkObjs[seq], off, length)
 }
 
@@ -1808,9 +2406,32 @@ func (b *bucketBlock) indexReader() *bucketIndexReader {
 	return newBucketIndexReader(b)
 }
 
-func (b *bucketBlock) chunkReader(ctx context.Context) *bucketChunkReader {
+func (b *bucketBlock) chunkReader(fetchOpts chunksFetchOptions) *bucketChunkReader {
 	b.pendingReaders.Add(1)
-	return newBucketChunkReader(ctx, b)
+	return newBucketChunkReader(b, fetchOpts)
+}
+
+// fetchCachedPostings returns the expanded postings of the block for the label matchers from the postings cache.
+func (b *bucketBlock) fetchCachedPostings(key indexcache.LabelMatchersKey) ([]storage.SeriesRef, bool) {
+	if b.postingsCache == nil || b.postingsCacheInvalidated.Load() {
+		return nil, false
+	}
+	return b.postingsCache.Fetch(b.userID, b.meta.ULID, key)
+}
+
+// storeCachedPostings stores the expanded postings of the block for the label matchers in the postings cache,
+// unless the block has been invalidated.
+func (b *bucketBlock) storeCachedPostings(key indexcache.LabelMatchersKey, refs []storage.SeriesRef) {
+	if b.postingsCache == nil || b.postingsCacheInvalidated.Load() {
+		return
+	}
+	b.postingsCache.Store(b.userID, b.meta.ULID, key, refs)
+}
+
+func blockLabelsFromMeta(meta *metadata.Meta) labels.Labels {
+	b := labels.NewBuilder(labels.FromMap(meta.Thanos.Labels))
+	b.Set(block.BlockIDLabel, meta.ULID.String())
+	return b.Labels(nil)
 }
 
 // matchLabels verifies whether the block matches the given matchers.
@@ -1838,6 +2459,7 @@ func (b *bucketBlock) Close() error {
 
 type labelValuesReader interface {
 	LabelValues(name string, filter func(string) bool) ([]string, error)
+	LabelValuesWithPrefix(name, prefix string, filter func(string) bool) ([]string, error)
 }
 
 type Part struct {
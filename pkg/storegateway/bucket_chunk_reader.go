@@ -21,22 +21,42 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util/pool"
 )
 
+// chunksFetchOptions controls how the chunks are fetched from the bucket.
+type chunksFetchOptions struct {
+	// concurrency is the max number of concurrent range requests issued by a bucketChunkReader
+	// on each call to load(). 0 means no limit.
+	concurrency int
+
+	// maxRangeBytes is the max size of a range of chunks fetched with a single request. The ranges
+	// merged together by the partitioner are split at chunks boundaries when larger. 0 means no limit.
+	maxRangeBytes uint64
+
+	// adaptive, if not nil, enables the adaptive concurrency, replacing concurrency: the concurrency of each
+	// call to load() is computed from the number of ranges to fetch, the number of blocks queried by the
+	// request and the store-gateway load, up to maxConcurrency for the whole request.
+	adaptive       *chunksFetchLoad
+	maxConcurrency int
+	blocks         int
+}
+
 type bucketChunkReader struct {
-	ctx   context.Context
-	block *bucketBlock
+	block     *bucketBlock
+	fetchOpts chunksFetchOptions
 
 	toLoad [][]loadIdx
 }
 
-func newBucketChunkReader(ctx context.Context, block *bucketBlock) *bucketChunkReader {
+func newBucketChunkReader(block *bucketBlock, fetchOpts chunksFetchOptions) *bucketChunkReader {
 	return &bucketChunkReader{
-		ctx:    ctx,
-		block:  block,
-		toLoad: make([][]loadIdx, len(block.chunkObjs)),
+		block:     block,
+		fetchOpts: fetchOpts,
+		toLoad:    make([][]loadIdx, len(block.chunkObjs)),
 	}
 }
 
@@ -54,7 +74,7 @@ func (r *bucketChunkReader) reset() {
 }
 
 // addLoad adds the chunk with id to the data set to be fetched.
-// Chunk will be fetched and saved to res[seriesEntry][chunk] upon r.load(res, <...>) call.
+// Chunk will be fetched and saved to res[seriesEntry][chunk] upon r.load(ctx, res, <...>) call.
 func (r *bucketChunkReader) addLoad(id chunks.ChunkRef, seriesEntry, chunk int) error {
 	var (
 		seq = int(id >> 32)
@@ -67,28 +87,179 @@ func (r *bucketChunkReader) addLoad(id chunks.ChunkRef, seriesEntry, chunk int)
 	return nil
 }
 
-// load all added chunks and saves resulting chunks to res.
-func (r *bucketChunkReader) load(res []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats) error {
-	g, ctx := errgroup.WithContext(r.ctx)
+// load all added chunks and saves resulting chunks to res. If loaded is not nil, it's notified
+// about each chunk as soon as it's been saved to res. The in-flight requests to the bucket are
+// aborted as soon as ctx is canceled.
+func (r *bucketChunkReader) load(ctx context.Context, res []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats, loaded *seriesChunksLoadTracker) error {
+	if err := r.loadFromCache(ctx, res, chunksPool, stats, loaded); err != nil {
+		return err
+	}
 
+	// The ranges of all the segment files are computed upfront, so that the adaptive concurrency
+	// can take into account the number of ranges to fetch.
+	parts := make([][]Part, len(r.toLoad))
+	numParts := 0
 	for seq, pIdxs := range r.toLoad {
 		sort.Slice(pIdxs, func(i, j int) bool {
 			return pIdxs[i].offset < pIdxs[j].offset
 		})
-		parts := r.block.partitioner.Partition(len(pIdxs), func(i int) (start, end uint64) {
+		parts[seq] = r.block.partitioner.Partition(len(pIdxs), func(i int) (start, end uint64) {
 			return uint64(pIdxs[i].offset), uint64(pIdxs[i].offset) + mimir_tsdb.EstimatedMaxChunkSize
 		})
+		parts[seq] = splitParts(parts[seq], pIdxs, r.fetchOpts.maxRangeBytes)
+		numParts += len(parts[seq])
+	}
 
-		for _, p := range parts {
+	g, gCtx := errgroup.WithContext(ctx)
+	if adaptive := r.fetchOpts.adaptive; adaptive != nil {
+		if numParts > 0 {
+			g.SetLimit(adaptive.concurrencyFor(numParts, r.fetchOpts.blocks, r.fetchOpts.maxConcurrency))
+		}
+	} else if r.fetchOpts.concurrency > 0 {
+		g.SetLimit(r.fetchOpts.concurrency)
+	}
+
+	for seq, pIdxs := range r.toLoad {
+		for _, p := range parts[seq] {
 			seq := seq
 			p := p
 			indices := pIdxs[p.ElemRng[0]:p.ElemRng[1]]
 			g.Go(func() error {
-				return r.loadChunks(ctx, res, seq, p, indices, chunksPool, stats)
+				if adaptive := r.fetchOpts.adaptive; adaptive != nil {
+					adaptive.start()
+					defer adaptive.done()
+				}
+				return r.loadChunks(gCtx, res, seq, p, indices, chunksPool, stats, loaded)
 			})
 		}
 	}
-	return g.Wait()
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	r.storeToCache(ctx, res)
+	return nil
+}
+
+// splitParts splits the parts larger than maxBytes into smaller ones, so that they can be fetched
+// concurrently. Parts are split only at chunks boundaries, so each resulting part covers at least one
+// chunk and may be larger than maxBytes. pIdxs are the sorted chunks the parts have been computed from.
+func splitParts(parts []Part, pIdxs []loadIdx, maxBytes uint64) []Part {
+	if maxBytes == 0 {
+		return parts
+	}
+
+	var res []Part
+	for _, p := range parts {
+		if p.End-p.Start <= maxBytes {
+			res = append(res, p)
+			continue
+		}
+
+		curr := Part{Start: p.Start, ElemRng: [2]int{p.ElemRng[0], p.ElemRng[0]}}
+		for i := p.ElemRng[0] + 1; i < p.ElemRng[1]; i++ {
+			start := uint64(pIdxs[i].offset)
+			if start-curr.Start < maxBytes {
+				continue
+			}
+
+			// The previous chunk ends at most at the start of this chunk, so there's no need to fetch further.
+			curr.End = uint64(pIdxs[i-1].offset) + mimir_tsdb.EstimatedMaxChunkSize
+			if curr.End > start {
+				curr.End = start
+			}
+			curr.ElemRng[1] = i
+			res = append(res, curr)
+
+			curr = Part{Start: start, ElemRng: [2]int{i, i}}
+		}
+		curr.End = p.End
+		curr.ElemRng[1] = p.ElemRng[1]
+		res = append(res, curr)
+	}
+	return res
+}
+
+// loadFromCache loads the added chunks found in the chunks cache, saves them to res and removes
+// them from the chunks to load from the bucket. The cached chunks are copied to chunksPool, so
+// cache hits don't allocate.
+func (r *bucketChunkReader) loadFromCache(ctx context.Context, res []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats, loaded *seriesChunksLoadTracker) error {
+	if _, ok := r.block.chunksCache.(chunkscache.NoopCache); ok || r.block.chunksCache == nil {
+		return nil
+	}
+
+	var keys []chunkscache.Key
+	for seq, pIdxs := range r.toLoad {
+		for _, pIdx := range pIdxs {
+			keys = append(keys, chunkscache.Key{BlockID: r.block.meta.ULID, Ref: chunkRef(seq, pIdx.offset)})
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	hits := r.block.chunksCache.FetchMultiChunks(ctx, r.block.userID, keys)
+
+	localStats := queryStats{chunksCacheRequests: len(keys)}
+	defer stats.merge(&localStats)
+
+	if len(hits) == 0 {
+		return nil
+	}
+
+	for seq, pIdxs := range r.toLoad {
+		misses := pIdxs[:0]
+		for _, pIdx := range pIdxs {
+			cached, ok := hits[chunkscache.Key{BlockID: r.block.meta.ULID, Ref: chunkRef(seq, pIdx.offset)}]
+			// A valid cached chunk has at least the encoding. If it's not valid, we fall back to the bucket.
+			if !ok || len(cached) < 1 {
+				misses = append(misses, pIdx)
+				continue
+			}
+
+			if err := populateChunk(&(res[pIdx.seriesEntry].chks[pIdx.chunk]), rawChunk(cached), chunksPool); err != nil {
+				return errors.Wrap(err, "populate chunk from cache")
+			}
+			loaded.chunkLoaded(pIdx.seriesEntry)
+			localStats.chunksCacheHits++
+			localStats.chunksTouched++
+			localStats.chunksTouchedSizeSum += len(cached) - 1
+		}
+		r.toLoad[seq] = misses
+	}
+	return nil
+}
+
+// storeToCache stores to the chunks cache the chunks loaded from the bucket.
+func (r *bucketChunkReader) storeToCache(ctx context.Context, res []seriesEntry) {
+	if _, ok := r.block.chunksCache.(chunkscache.NoopCache); ok || r.block.chunksCache == nil {
+		return
+	}
+
+	toStore := map[chunkscache.Key][]byte{}
+	for seq, pIdxs := range r.toLoad {
+		for _, pIdx := range pIdxs {
+			chk := res[pIdx.seriesEntry].chks[pIdx.chunk].Raw
+			if chk == nil {
+				continue
+			}
+
+			// The chunk data is held by the chunks pool, which is released once the chunks are sent,
+			// while the chunks are asynchronously stored to the cache, so they must be copied.
+			cached := make([]byte, 1+len(chk.Data))
+			cached[0] = byte(chunkenc.EncXOR)
+			copy(cached[1:], chk.Data)
+			toStore[chunkscache.Key{BlockID: r.block.meta.ULID, Ref: chunkRef(seq, pIdx.offset)}] = cached
+		}
+	}
+	if len(toStore) > 0 {
+		r.block.chunksCache.StoreChunks(ctx, r.block.userID, toStore)
+	}
+}
+
+// chunkRef returns the ref of the chunk at the input offset of the segment file with the input sequence number.
+func chunkRef(seq int, offset uint32) chunks.ChunkRef {
+	return chunks.ChunkRef(uint64(seq)<<32 | uint64(offset))
 }
 
 // loadChunks will read range [start, end] from the segment file with sequence number seq.
@@ -98,9 +269,20 @@ func (r *bucketChunkReader) load(res []seriesEntry, chunksPool *pool.BatchBytes,
 // passed to multiple concurrent invocations. However, this shouldn't require a mutex
 // because part and pIdxs is only read, and different calls are expected to write to
 // different chunks in the res.
-func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, seq int, part Part, pIdxs []loadIdx, chunksPool *pool.BatchBytes, stats *safeQueryStats) error {
+//
+// The fetching is aborted as soon as ctx is canceled, and the bytes of the range not read
+// yet are tracked as abandoned.
+func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, seq int, part Part, pIdxs []loadIdx, chunksPool *pool.BatchBytes, stats *safeQueryStats, loaded *seriesChunksLoadTracker) (err error) {
 	fetchBegin := time.Now()
 
+	// The offset up to which the range has been read.
+	readOffset := int(part.Start)
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			r.block.metrics.chunksAbandonedBytes.Add(float64(int(part.End) - readOffset))
+		}
+	}()
+
 	// Get a reader for the required range.
 	reader, err := r.block.chunkRangeReader(ctx, seq, int64(part.Start), int64(part.End-part.Start))
 	if err != nil {
@@ -118,10 +300,10 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 	localStats.chunksFetched += len(pIdxs)
 	localStats.chunksFetchDurationSum += time.Since(fetchBegin)
 	localStats.chunksFetchedSizeSum += int(part.End - part.Start)
+	localStats.chunksFetchedSizeSumByBlock = map[ulid.ULID]int{r.block.meta.ULID: int(part.End - part.Start)}
 
 	var (
-		buf        = make([]byte, mimir_tsdb.EstimatedMaxChunkSize)
-		readOffset = int(pIdxs[0].offset)
+		buf = make([]byte, mimir_tsdb.EstimatedMaxChunkSize)
 
 		// Save a few allocations.
 		written  int64
@@ -131,6 +313,11 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 	)
 
 	for i, pIdx := range pIdxs {
+		// Stop reading as soon as the request is canceled, in case the range reader doesn't honor the context.
+		if err = ctx.Err(); err != nil {
+			return errors.Wrap(err, "read chunks range")
+		}
+
 		// Fast forward range reader to the next chunk start in case of sparse (for our purposes) byte range.
 		for readOffset < int(pIdx.offset) {
 			written, err = io.CopyN(io.Discard, bufReader, int64(pIdx.offset)-int64(readOffset))
@@ -169,6 +356,7 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 			if err != nil {
 				return errors.Wrap(err, "populate chunk")
 			}
+			loaded.chunkLoaded(pIdx.seriesEntry)
 			localStats.chunksTouched++
 			localStats.chunksTouchedSizeSum += int(chunkDataLen)
 			continue
@@ -194,6 +382,7 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 			r.block.chunkPool.Put(nb)
 			return errors.Wrap(err, "populate chunk")
 		}
+		loaded.chunkLoaded(pIdx.seriesEntry)
 		localStats.chunksTouched++
 		localStats.chunksTouchedSizeSum += int(chunkDataLen)
 
@@ -203,17 +392,57 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 }
 
 func populateChunk(out *storepb.AggrChunk, in chunkenc.Chunk, chunksPool *pool.BatchBytes) error {
-	if in.Encoding() == chunkenc.EncXOR {
+	switch in.Encoding() {
+	case chunkenc.EncXOR:
 		b, err := saveChunk(in.Bytes(), chunksPool)
 		if err != nil {
 			return err
 		}
 		out.Raw = &storepb.Chunk{Type: storepb.Chunk_XOR, Data: b}
 		return nil
+	case downsample.ChunkEncAggr:
+		return populateAggrChunk(out, downsample.AggrChunk(in.Bytes()), chunksPool)
 	}
 	return errors.Errorf("unsupported chunk encoding %d", in.Encoding())
 }
 
+// populateAggrChunk sets the aggregates of the chunk of a downsampled block. The aggregates which are
+// not present in the chunk are left unset.
+func populateAggrChunk(out *storepb.AggrChunk, in downsample.AggrChunk, chunksPool *pool.BatchBytes) error {
+	for _, aggr := range downsample.AggrTypes {
+		c, err := in.Get(aggr)
+		if errors.Is(err, downsample.ErrAggrNotExist) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "get %s aggregate", aggr)
+		}
+		if c.Encoding() != chunkenc.EncXOR {
+			return errors.Errorf("unsupported encoding %d of %s aggregate", c.Encoding(), aggr)
+		}
+
+		b, err := saveChunk(c.Bytes(), chunksPool)
+		if err != nil {
+			return err
+		}
+		chk := &storepb.Chunk{Type: storepb.Chunk_XOR, Data: b}
+
+		switch aggr {
+		case downsample.AggrCount:
+			out.Count = chk
+		case downsample.AggrSum:
+			out.Sum = chk
+		case downsample.AggrMin:
+			out.Min = chk
+		case downsample.AggrMax:
+			out.Max = chk
+		case downsample.AggrCounter:
+			out.Counter = chk
+		}
+	}
+	return nil
+}
+
 // saveChunk saves a copy of b's payload to a buffer pulled from chunksPool.
 // The buffer containing the chunk data is returned.
 // The returned slice becomes invalid once chunksPool is released.
@@ -269,7 +498,7 @@ type chunkReader interface {
 	io.Closer
 
 	addLoad(id chunks.ChunkRef, seriesEntry, chunk int) error
-	load(result []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats) error
+	load(ctx context.Context, result []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats, loaded *seriesChunksLoadTracker) error
 	reset()
 }
 
@@ -283,7 +512,9 @@ func (r bucketChunkReaders) addLoad(blockID ulid.ULID, id chunks.ChunkRef, serie
 	return r.readers[blockID].addLoad(id, seriesEntry, chunk)
 }
 
-func (r bucketChunkReaders) load(entries []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats) error {
+// load the chunks added to all readers and saves them to entries. The in-flight requests to the
+// bucket are aborted as soon as ctx is canceled.
+func (r bucketChunkReaders) load(ctx context.Context, entries []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats, loaded *seriesChunksLoadTracker) error {
 	g := &errgroup.Group{}
 	for _, reader := range r.readers {
 		reader := reader
@@ -291,7 +522,7 @@ func (r bucketChunkReaders) load(entries []seriesEntry, chunksPool *pool.BatchBy
 			// We don't need synchronisation on the access to entries because each chunk in
 			// every series will be loaded by exactly one reader. Since the chunks slices are already
 			// initialized to the correct length, they don't need to be resized and can just be accessed.
-			return reader.load(entries, chunksPool, stats)
+			return reader.load(ctx, entries, chunksPool, stats, loaded)
 		})
 	}
 
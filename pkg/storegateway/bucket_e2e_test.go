@@ -11,12 +11,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gogo/status"
 	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/timestamp"
@@ -32,6 +34,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/indexheader"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
@@ -182,7 +185,7 @@ func withManyParts() prepareStoreConfigOption {
 
 func withBucketStoreOptions(opts ...BucketStoreOption) prepareStoreConfigOption {
 	return func(config *prepareStoreConfig) {
-		config.bucketStoreOpts = opts
+		config.bucketStoreOpts = append(config.bucketStoreOpts, opts...)
 	}
 }
 
@@ -218,6 +221,7 @@ func prepareStoreWithTestBlocks(t testing.TB, bkt objstore.Bucket, cfg *prepareS
 		indexheader.Config{},
 		true,
 		time.Minute,
+		0,
 		hashcache.NewSeriesHashCache(1024*1024),
 		NewBucketStoreMetrics(s.metricsRegistry),
 		storeOpts...,
@@ -578,6 +582,83 @@ func TestBucketStore_e2e(t *testing.T) {
 	})
 }
 
+func TestBucketStore_e2e_ChunksCache(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		chunksCache := newInMemoryChunksCache()
+		s := newSuite(withBucketStoreOptions(WithChunksCache(chunksCache)))
+		s.cache.SwapWith(noopCache{})
+
+		if ok := t.Run("cold chunks cache", func(t *testing.T) {
+			testBucketStore_e2e(t, ctx, s)
+		}); !ok {
+			return
+		}
+		require.NotZero(t, chunksCache.stored(), "the chunks loaded from the bucket should be stored to the cache")
+
+		t.Run("warm chunks cache", func(t *testing.T) {
+			testBucketStore_e2e(t, ctx, s)
+			assert.NotZero(t, chunksCache.hits(), "the chunks should be loaded from the cache")
+		})
+	})
+}
+
+// inMemoryChunksCache is a chunkscache.Cache keeping the chunks in memory.
+type inMemoryChunksCache struct {
+	mtx       sync.Mutex
+	chunks    map[string]map[chunkscache.Key][]byte
+	hitsCount int
+}
+
+func newInMemoryChunksCache() *inMemoryChunksCache {
+	return &inMemoryChunksCache{chunks: map[string]map[chunkscache.Key][]byte{}}
+}
+
+func (c *inMemoryChunksCache) FetchMultiChunks(_ context.Context, userID string, keys []chunkscache.Key) map[chunkscache.Key][]byte {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	hits := map[chunkscache.Key][]byte{}
+	for _, k := range keys {
+		if chk, ok := c.chunks[userID][k]; ok {
+			hits[k] = chk
+		}
+	}
+	c.hitsCount += len(hits)
+	return hits
+}
+
+func (c *inMemoryChunksCache) StoreChunks(_ context.Context, userID string, chunks map[chunkscache.Key][]byte) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.chunks[userID] == nil {
+		c.chunks[userID] = map[chunkscache.Key][]byte{}
+	}
+	for k, chk := range chunks {
+		c.chunks[userID][k] = chk
+	}
+}
+
+func (c *inMemoryChunksCache) hits() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.hitsCount
+}
+
+func (c *inMemoryChunksCache) stored() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	count := 0
+	for _, userChunks := range c.chunks {
+		count += len(userChunks)
+	}
+	return count
+}
+
 type naivePartitioner struct{}
 
 func (g naivePartitioner) Partition(length int, rng func(int) (uint64, uint64)) (parts []Part) {
@@ -612,6 +693,57 @@ func TestBucketStore_ManyParts_e2e(t *testing.T) {
 	})
 }
 
+func TestBucketStore_Series_MemoryLimiter_e2e(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chunksPool, err := newChunkBytesPool(mimir_tsdb.ChunkPoolDefaultMinBucketSize, mimir_tsdb.ChunkPoolDefaultMaxBucketSize, 0, nil)
+	require.NoError(t, err)
+
+	prepConfig := defaultPrepareStoreConfig(t)
+	prepConfig.bucketStoreOpts = []BucketStoreOption{
+		WithChunkPool(chunksPool),
+		WithMemoryLimiter(NewMemoryLimiter(1, chunksPool.usedBytes)),
+	}
+	s := prepareStoreWithTestBlocks(t, objstore.NewInMemBucket(), prepConfig)
+	assert.NoError(t, s.store.SyncBlocks(ctx))
+
+	req := &storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"},
+		},
+		MinTime: timestamp.FromTime(minTime),
+		MaxTime: timestamp.FromTime(maxTime),
+	}
+
+	// The request is admitted while no memory is held by in-flight requests.
+	require.NoError(t, s.store.Series(req, newBucketStoreSeriesServer(ctx)))
+	require.Zero(t, chunksPool.usedBytes())
+
+	// The request is rejected while the memory held by in-flight requests is over the limit.
+	held, err := chunksPool.Get(1024)
+	require.NoError(t, err)
+
+	err = s.store.Series(req, newBucketStoreSeriesServer(ctx))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "memory limit of 1 bytes reached")
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+
+	assert.NoError(t, prom_testutil.GatherAndCompare(s.metricsRegistry, strings.NewReader(`
+		# HELP cortex_bucket_store_queries_dropped_total Number of queries that were dropped due to the max chunks per query limit.
+		# TYPE cortex_bucket_store_queries_dropped_total counter
+		cortex_bucket_store_queries_dropped_total{reason="chunks"} 0
+		cortex_bucket_store_queries_dropped_total{reason="memory"} 1
+		cortex_bucket_store_queries_dropped_total{reason="series"} 0
+	`), "cortex_bucket_store_queries_dropped_total"))
+
+	// The request is admitted again once the memory has been released.
+	chunksPool.Put(held)
+	require.NoError(t, s.store.Series(req, newBucketStoreSeriesServer(ctx)))
+}
+
 func TestBucketStore_Series_ChunksLimiter_e2e(t *testing.T) {
 	// The query will fetch 2 series from 6 blocks, so we do expect to hit a total of 12 chunks.
 	expectedChunks := uint64(2 * 6)
@@ -910,4 +1042,103 @@ func foreachStore(t *testing.T, runTest func(t *testing.T, newSuite suiteFactory
 		}
 		runTest(t, factory)
 	})
+
+	t.Run("streaming with eager sending", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithStreamingSeriesEagerSending(true)))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("streaming with adaptive chunks slab size", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithStreamingSeriesChunksSlabSize(100, true)))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("streaming with concurrent chunks fetching", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithChunksFetching(2, 100)))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("streaming with adaptive chunks fetch concurrency", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			load := newChunksFetchLoad(2, nil)
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithChunksFetching(0, 100), WithChunksFetchAdaptiveConcurrency(load, func() int { return 4 })))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("streaming with chunks fetch hedging", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			// Hedge all the requests.
+			hedging := newChunksFetchHedging(0.5, time.Nanosecond, nil)
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithChunksFetchHedging(hedging)))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("streaming with chunks deduplication", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithStreamingSeriesChunksDeduplication(true)))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("streaming with lazy postings", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			opts = append(opts, withBucketStoreOptions(WithStreamingSeriesPerBatch(10), WithLazyPostings(func() bool { return true })))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
+
+	t.Run("with series hashes persistence", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := filesystem.NewBucket(t.TempDir())
+		assert.NoError(t, err)
+		factory := func(opts ...prepareStoreConfigOption) *storeSuite {
+			opts = append(opts, withBucketStoreOptions(WithSeriesHashesPersistence()))
+			return prepareStoreWithTestBlocks(t, b, defaultPrepareStoreConfig(t).apply(opts...))
+		}
+		runTest(t, factory)
+	})
 }
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"go.uber.org/atomic"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+var errMaxExemplarsBytesPerQueryExceeded = errors.New("the query exceeded the max size of the exemplars read from the blocks (-store-gateway.max-exemplars-bytes-per-query)")
+
+// Exemplars implements the storegatewaypb.StoreGatewayServer interface.
+func (s *BucketStore) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	matcherSets := make([][]*labels.Matcher, 0, len(req.Matchers))
+	for _, m := range req.Matchers {
+		matchers, err := storepb.MatchersToPromMatchers(m.Matchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	var reqBlockMatchers []*labels.Matcher
+	if req.Hints != nil {
+		reqHints := &hintspb.ExemplarsRequestHints{}
+		err := types.UnmarshalAny(req.Hints, reqHints)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "unmarshal exemplars request hints").Error())
+		}
+
+		reqBlockMatchers, err = storepb.MatchersToPromMatchers(reqHints.BlockMatchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request hints labels matchers").Error())
+		}
+	}
+
+	resHints := &hintspb.ExemplarsResponseHints{}
+
+	maxBytes := 0
+	if s.maxExemplarsBytesPerQuery != nil {
+		maxBytes = s.maxExemplarsBytesPerQuery()
+	}
+	fetchedBytes := atomic.NewInt64(0)
+	reserve := func(bytes int) error {
+		if total := fetchedBytes.Add(int64(bytes)); maxBytes > 0 && total > int64(maxBytes) {
+			return fmt.Errorf("%w (limit: %d bytes)", errMaxExemplarsBytesPerQueryExceeded, maxBytes)
+		}
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	s.blocksMx.RLock()
+
+	var mtx sync.Mutex
+	var series []mimirpb.TimeSeries
+	for _, b := range s.blocks {
+		b := b
+
+		if !b.overlapsClosedInterval(req.Start, req.End) {
+			continue
+		}
+		if len(reqBlockMatchers) > 0 && !b.matchLabels(reqBlockMatchers) {
+			continue
+		}
+
+		resHints.AddQueriedBlock(b.meta.ULID)
+
+		b.pendingReaders.Add(1)
+
+		g.Go(func() error {
+			defer b.pendingReaders.Done()
+
+			result, err := b.exemplars(gctx, req.Start, req.End, matcherSets, reserve)
+			if errors.Is(err, errMaxExemplarsBytesPerQueryExceeded) {
+				return err
+			}
+			if err != nil {
+				return errors.Wrapf(err, "block %s", b.meta.ULID)
+			}
+
+			if len(result) > 0 {
+				mtx.Lock()
+				series = append(series, result...)
+				mtx.Unlock()
+			}
+
+			return nil
+		})
+	}
+
+	s.blocksMx.RUnlock()
+
+	if err := g.Wait(); err != nil {
+		if errors.Is(err, errMaxExemplarsBytesPerQueryExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	anyHints, err := types.MarshalAny(resHints)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, errors.Wrap(err, "marshal exemplars response hints").Error())
+	}
+
+	return &storepb.ExemplarsResponse{
+		// Blocks may overlap (e.g. blocks shipped by different ingesters), so exemplars are deduplicated.
+		Timeseries: block.SortExemplarsSeries(series),
+		Hints:      anyHints,
+	}, nil
+}
+
+// exemplars returns the exemplars of the block within [mint, maxt] whose series match any of the input
+// matcher sets. The size of each returned series is reserved with the input function, which fails once
+// the query exceeds its limit.
+func (b *bucketBlock) exemplars(ctx context.Context, mint, maxt int64, matcherSets [][]*labels.Matcher, reserve func(bytes int) error) ([]mimirpb.TimeSeries, error) {
+	all, err := b.loadExemplars(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []mimirpb.TimeSeries
+	for _, s := range all {
+		if !matchesAnySet(matcherSets, mimirpb.FromLabelAdaptersToLabels(s.Labels)) {
+			continue
+		}
+
+		var exemplars []mimirpb.Exemplar
+		for _, e := range s.Exemplars {
+			if e.TimestampMs >= mint && e.TimestampMs <= maxt {
+				exemplars = append(exemplars, e)
+			}
+		}
+		if len(exemplars) == 0 {
+			continue
+		}
+
+		series := mimirpb.TimeSeries{Labels: s.Labels, Exemplars: exemplars}
+		if err := reserve(series.Size()); err != nil {
+			return nil, err
+		}
+		out = append(out, series)
+	}
+
+	return out, nil
+}
+
+// loadExemplars returns the exemplars of the block. Blocks are immutable and the exemplars file is uploaded
+// before the block is complete, so the exemplars, or the lack of an exemplars file, are kept in the exemplars
+// cache, if any. The returned series must not be modified.
+func (b *bucketBlock) loadExemplars(ctx context.Context) ([]mimirpb.TimeSeries, error) {
+	if b.exemplarsCache == nil {
+		return b.readExemplars(ctx)
+	}
+	return b.exemplarsCache.get(ctx, b.userID, b.meta.ULID, b.readExemplars)
+}
+
+// readExemplars reads the exemplars of the block from its exemplars file, if any.
+func (b *bucketBlock) readExemplars(ctx context.Context) ([]mimirpb.TimeSeries, error) {
+	r, err := b.bkt.Get(ctx, path.Join(b.meta.ULID.String(), block.ExemplarsFilename))
+	if b.bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get exemplars file")
+	}
+	defer runutil.CloseWithLogOnErr(b.logger, r, "close exemplars file reader")
+
+	return block.ReadExemplars(r)
+}
+
+func matchesAnySet(matcherSets [][]*labels.Matcher, lbls labels.Labels) bool {
+	for _, matchers := range matcherSets {
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(lbls.Get(m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestBucketStore_Exemplars(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	cache, err := newExemplarsCache(1024*1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	s := prepareStoreWithTestBlocks(t, bkt, defaultPrepareStoreConfig(t).apply(withBucketStoreOptions(withExemplarsCache(cache))))
+
+	var (
+		seriesA1 = []mimirpb.LabelAdapter{{Name: "a", Value: "1"}, {Name: "b", Value: "1"}}
+		seriesA2 = []mimirpb.LabelAdapter{{Name: "a", Value: "2"}, {Name: "b", Value: "1"}}
+		exemplar = func(traceID string, ts int64) mimirpb.Exemplar {
+			return mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: traceID}}, Value: 1, TimestampMs: ts}
+		}
+	)
+
+	// Upload the same exemplars file for all blocks: exemplars are expected to be deduplicated.
+	dir := t.TempDir()
+	require.NoError(t, block.WriteExemplarsFile(dir, []mimirpb.TimeSeries{
+		{Labels: seriesA1, Exemplars: []mimirpb.Exemplar{exemplar("x", s.minTime), exemplar("y", s.minTime+10)}},
+		{Labels: seriesA2, Exemplars: []mimirpb.Exemplar{exemplar("z", s.minTime+20)}},
+	}))
+	content, err := os.ReadFile(filepath.Join(dir, block.ExemplarsFilename))
+	require.NoError(t, err)
+
+	var blockIDs []string
+	require.NoError(t, bkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if ok {
+			blockIDs = append(blockIDs, id.String())
+			return bkt.Upload(ctx, path.Join(id.String(), block.ExemplarsFilename), bytes.NewReader(content))
+		}
+		return nil
+	}))
+	require.NotEmpty(t, blockIDs)
+
+	for name, tc := range map[string]struct {
+		req      *storepb.ExemplarsRequest
+		expected []mimirpb.TimeSeries
+	}{
+		"all exemplars": {
+			req: &storepb.ExemplarsRequest{
+				Start:    s.minTime,
+				End:      s.maxTime,
+				Matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}}}},
+			},
+			expected: []mimirpb.TimeSeries{
+				{Labels: seriesA1, Exemplars: []mimirpb.Exemplar{exemplar("x", s.minTime), exemplar("y", s.minTime+10)}},
+				{Labels: seriesA2, Exemplars: []mimirpb.Exemplar{exemplar("z", s.minTime+20)}},
+			},
+		},
+		"filtered by matchers and time range": {
+			req: &storepb.ExemplarsRequest{
+				Start:    s.minTime + 5,
+				End:      s.maxTime,
+				Matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}}}},
+			},
+			expected: []mimirpb.TimeSeries{
+				{Labels: seriesA1, Exemplars: []mimirpb.Exemplar{exemplar("y", s.minTime+10)}},
+			},
+		},
+		"no matching series": {
+			req: &storepb.ExemplarsRequest{
+				Start:    s.minTime,
+				End:      s.maxTime,
+				Matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "3"}}}},
+			},
+			expected: []mimirpb.TimeSeries{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, err := s.store.Exemplars(ctx, tc.req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, res.Timeseries)
+
+			hints := hintspb.ExemplarsResponseHints{}
+			require.NoError(t, types.UnmarshalAny(res.Hints, &hints))
+			assert.Len(t, hints.QueriedBlocks, len(blockIDs))
+		})
+	}
+
+	allExemplarsReq := &storepb.ExemplarsRequest{
+		Start:    s.minTime,
+		End:      s.maxTime,
+		Matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}}}},
+	}
+
+	t.Run("exemplars are kept in the exemplars cache once read", func(t *testing.T) {
+		for _, id := range blockIDs {
+			require.NoError(t, bkt.Delete(ctx, path.Join(id, block.ExemplarsFilename)))
+		}
+
+		res, err := s.store.Exemplars(ctx, allExemplarsReq)
+		require.NoError(t, err)
+		assert.Len(t, res.Timeseries, 2)
+	})
+
+	t.Run("queries exceeding the max exemplars bytes per query", func(t *testing.T) {
+		s.store.maxExemplarsBytesPerQuery = func() int { return 1 }
+		defer func() { s.store.maxExemplarsBytesPerQuery = nil }()
+
+		_, err := s.store.Exemplars(ctx, allExemplarsReq)
+		require.Error(t, err)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.ErrorContains(t, err, "the query exceeded the max size of the exemplars read from the blocks (-store-gateway.max-exemplars-bytes-per-query) (limit: 1 bytes)")
+	})
+}
+
+func TestBucketStore_Exemplars_BlocksWithoutExemplars(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	s := prepareStoreWithTestBlocks(t, bkt, defaultPrepareStoreConfig(t))
+
+	res, err := s.store.Exemplars(ctx, &storepb.ExemplarsRequest{
+		Start:    s.minTime,
+		End:      s.maxTime,
+		Matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}}}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.Timeseries)
+}
@@ -35,6 +35,11 @@ type BucketIndexMetadataFetcher struct {
 	logger      log.Logger
 	filters     []block.MetadataFilter
 	metrics     *block.FetcherMetrics
+
+	// scanner, if not nil, is used to scan the bucket when the bucket index is missing, corrupted
+	// or older than maxStalePeriod.
+	scanner        *bucketindex.Scanner
+	maxStalePeriod time.Duration
 }
 
 func NewBucketIndexMetadataFetcher(
@@ -44,14 +49,18 @@ func NewBucketIndexMetadataFetcher(
 	logger log.Logger,
 	reg prometheus.Registerer,
 	filters []block.MetadataFilter,
+	scanner *bucketindex.Scanner,
+	maxStalePeriod time.Duration,
 ) *BucketIndexMetadataFetcher {
 	return &BucketIndexMetadataFetcher{
-		userID:      userID,
-		bkt:         bkt,
-		cfgProvider: cfgProvider,
-		logger:      logger,
-		filters:     filters,
-		metrics:     block.NewFetcherMetrics(reg, [][]string{{corruptedBucketIndex}, {noBucketIndex}, {minTimeExcludedMeta}}, nil),
+		userID:         userID,
+		bkt:            bkt,
+		cfgProvider:    cfgProvider,
+		logger:         logger,
+		filters:        filters,
+		metrics:        block.NewFetcherMetrics(reg, [][]string{{corruptedBucketIndex}, {noBucketIndex}, {minTimeExcludedMeta}}, nil),
+		scanner:        scanner,
+		maxStalePeriod: maxStalePeriod,
 	}
 }
 
@@ -70,6 +79,9 @@ func (f *BucketIndexMetadataFetcher) Fetch(ctx context.Context) (metas map[ulid.
 
 	// Fetch the bucket index.
 	idx, err := bucketindex.ReadIndex(ctx, f.bkt, f.userID, f.cfgProvider, f.logger)
+	if f.scanner != nil {
+		idx, err = f.scanIndexIfNeeded(ctx, idx, err)
+	}
 	if errors.Is(err, bucketindex.ErrIndexNotFound) {
 		// This is a legit case happening when the first blocks of a tenant have recently been uploaded by ingesters
 		// and their bucket index has not been created yet.
@@ -122,6 +134,28 @@ func (f *BucketIndexMetadataFetcher) Fetch(ctx context.Context) (metas map[ulid.
 	return metas, nil, nil
 }
 
+// scanIndexIfNeeded scans the bucket to find the blocks not in the bucket index if the bucket index is missing,
+// corrupted or too old. Since the store-gateway loads the blocks regardless of the queried time range, the scan
+// is only bounded by the max number of blocks to fetch. If the scan fails, the input index and error are returned.
+func (f *BucketIndexMetadataFetcher) scanIndexIfNeeded(ctx context.Context, idx *bucketindex.Index, err error) (*bucketindex.Index, error) {
+	missing := errors.Is(err, bucketindex.ErrIndexNotFound) || errors.Is(err, bucketindex.ErrIndexCorrupted)
+	stale := err == nil && f.maxStalePeriod > 0 && time.Since(idx.GetUpdatedAt()) > f.maxStalePeriod
+	if !missing && !stale {
+		return idx, err
+	}
+
+	var old *bucketindex.Index
+	if stale {
+		old = idx
+	}
+	scanned, scanErr := f.scanner.ScanIndex(ctx, f.userID, old, 0)
+	if scanErr != nil {
+		level.Warn(f.logger).Log("msg", "failed to scan the bucket because the bucket index is missing or too old", "user", f.userID, "err", scanErr)
+		return idx, err
+	}
+	return scanned, nil
+}
+
 func (f *BucketIndexMetadataFetcher) UpdateOnChange(callback func([]metadata.Meta, error)) {
 	// Unused by the store-gateway.
 	callback(nil, errors.New("UpdateOnChange is unsupported"))
@@ -61,7 +61,7 @@ func TestBucketIndexMetadataFetcher_Fetch(t *testing.T) {
 		newMinTimeMetaFilter(1 * time.Hour),
 	}
 
-	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, filters)
+	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, filters, nil, 0)
 	metas, partials, err := fetcher.Fetch(ctx)
 	require.NoError(t, err)
 	assert.Equal(t, map[ulid.ULID]*metadata.Meta{
@@ -116,7 +116,7 @@ func TestBucketIndexMetadataFetcher_Fetch_NoBucketIndex(t *testing.T) {
 	logs := &concurrency.SyncBuffer{}
 	logger := log.NewLogfmtLogger(logs)
 
-	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil)
+	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil, nil, 0)
 	metas, partials, err := fetcher.Fetch(ctx)
 	require.NoError(t, err)
 	assert.Empty(t, metas)
@@ -171,7 +171,7 @@ func TestBucketIndexMetadataFetcher_Fetch_CorruptedBucketIndex(t *testing.T) {
 	// Upload a corrupted bucket index.
 	require.NoError(t, bkt.Upload(ctx, path.Join(userID, bucketindex.IndexCompressedFilename), strings.NewReader("invalid}!")))
 
-	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil)
+	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil, nil, 0)
 	metas, partials, err := fetcher.Fetch(ctx)
 	require.NoError(t, err)
 	assert.Empty(t, metas)
@@ -214,6 +214,78 @@ func TestBucketIndexMetadataFetcher_Fetch_CorruptedBucketIndex(t *testing.T) {
 	))
 }
 
+func TestBucketIndexMetadataFetcher_Fetch_FallbackScan(t *testing.T) {
+	const userID = "user-1"
+
+	scannerCfg := bucketindex.ScannerConfig{MaxBlocks: 10, FailuresThreshold: 1, CooldownPeriod: time.Hour}
+
+	t.Run("should scan the bucket if the bucket index does not exist", func(t *testing.T) {
+		bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+		block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+		block2 := mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+
+		scanner := bucketindex.NewScanner(scannerCfg, bkt, nil, log.NewNopLogger(), nil)
+		fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, log.NewNopLogger(), nil, nil, scanner, time.Hour)
+		metas, _, err := fetcher.Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, metas, 2)
+		assert.Contains(t, metas, block1.ULID)
+		assert.Contains(t, metas, block2.ULID)
+	})
+
+	t.Run("should scan the bucket for the blocks not in the bucket index if it's too old", func(t *testing.T) {
+		ctx := context.Background()
+		bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+		block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+		idx := &bucketindex.Index{
+			Version:            bucketindex.IndexVersion2,
+			Blocks:             bucketindex.Blocks{{ID: block1.ULID, MinTime: block1.MinTime, MaxTime: block1.MaxTime}},
+			BlockDeletionMarks: bucketindex.BlockDeletionMarks{},
+			UpdatedAt:          time.Now().Add(-2 * time.Hour).Unix(),
+		}
+		require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+		block2 := mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+
+		scanner := bucketindex.NewScanner(scannerCfg, bkt, nil, log.NewNopLogger(), nil)
+
+		// The bucket index is not scanned if it's not too old.
+		fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, log.NewNopLogger(), nil, nil, scanner, 3*time.Hour)
+		metas, _, err := fetcher.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Len(t, metas, 1)
+		assert.Contains(t, metas, block1.ULID)
+
+		fetcher = NewBucketIndexMetadataFetcher(userID, bkt, nil, log.NewNopLogger(), nil, nil, scanner, time.Hour)
+		metas, _, err = fetcher.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Len(t, metas, 2)
+		assert.Contains(t, metas, block1.ULID)
+		assert.Contains(t, metas, block2.ULID)
+	})
+
+	t.Run("should use the bucket index if the bucket scan fails", func(t *testing.T) {
+		ctx := context.Background()
+		bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+		block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+		idx := &bucketindex.Index{
+			Version:            bucketindex.IndexVersion2,
+			Blocks:             bucketindex.Blocks{{ID: block1.ULID, MinTime: block1.MinTime, MaxTime: block1.MaxTime}},
+			BlockDeletionMarks: bucketindex.BlockDeletionMarks{},
+			UpdatedAt:          time.Now().Add(-2 * time.Hour).Unix(),
+		}
+		require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+		mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+		mimir_testutil.MockStorageBlock(t, bkt, userID, 30, 40)
+
+		scanner := bucketindex.NewScanner(bucketindex.ScannerConfig{MaxBlocks: 1, FailuresThreshold: 1, CooldownPeriod: time.Hour}, bkt, nil, log.NewNopLogger(), nil)
+		fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, log.NewNopLogger(), nil, nil, scanner, time.Hour)
+		metas, _, err := fetcher.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Len(t, metas, 1)
+		assert.Contains(t, metas, block1.ULID)
+	})
+}
+
 // noShardingStrategy is a no-op strategy. When this strategy is used, no tenant/block is filtered out.
 type noShardingStrategy struct{}
 
@@ -7,6 +7,7 @@ package storegateway
 
 import (
 	"encoding/binary"
+	"regexp"
 	"sort"
 
 	"github.com/pkg/errors"
@@ -82,7 +83,7 @@ func toPostingGroup(lvr labelValuesReader, m *labels.Matcher) (*postingGroup, er
 
 	// Our matcher does not match the empty value, so we just need the postings that correspond
 	// to label values matched by the matcher.
-	vals, err := lvr.LabelValues(m.Name, m.Matches)
+	vals, err := lvr.LabelValuesWithPrefix(m.Name, matcherPrefix(m), m.Matches)
 	toAdd := make([]labels.Label, len(vals))
 	for i := range vals {
 		toAdd[i] = labels.Label{Name: m.Name, Value: vals[i]}
@@ -90,6 +91,21 @@ func toPostingGroup(lvr labelValuesReader, m *labels.Matcher) (*postingGroup, er
 	return newPostingGroup(false, toAdd, nil), err
 }
 
+// matcherPrefix returns the literal prefix all the values matched by a regexp matcher start with,
+// or an empty string if there's no such prefix.
+func matcherPrefix(m *labels.Matcher) string {
+	if m.Type != labels.MatchRegexp {
+		return ""
+	}
+	// Matchers are anchored, see labels.NewFastRegexMatcher().
+	re, err := regexp.Compile("^(?:" + m.Value + ")$")
+	if err != nil {
+		return ""
+	}
+	prefix, _ := re.LiteralPrefix()
+	return prefix
+}
+
 func not(filter func(string) bool) func(string) bool {
 	return func(s string) bool { return !filter(s) }
 }
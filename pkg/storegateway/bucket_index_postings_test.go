@@ -10,6 +10,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,3 +31,23 @@ func TestBigEndianPostingsCount(t *testing.T) {
 	}
 	assert.Equal(t, count, c)
 }
+
+func TestMatcherPrefix(t *testing.T) {
+	tests := []struct {
+		matcher  *labels.Matcher
+		expected string
+	}{
+		{matcher: labels.MustNewMatcher(labels.MatchEqual, "n", "foo"), expected: ""},
+		{matcher: labels.MustNewMatcher(labels.MatchNotRegexp, "n", "foo.*"), expected: ""},
+		{matcher: labels.MustNewMatcher(labels.MatchRegexp, "n", "foo.*"), expected: "foo"},
+		{matcher: labels.MustNewMatcher(labels.MatchRegexp, "n", "foo(bar|baz)"), expected: "foo"},
+		{matcher: labels.MustNewMatcher(labels.MatchRegexp, "n", `foo\.bar.+`), expected: "foo.bar"},
+		{matcher: labels.MustNewMatcher(labels.MatchRegexp, "n", "foo|bar"), expected: ""},
+		{matcher: labels.MustNewMatcher(labels.MatchRegexp, "n", ".*foo"), expected: ""},
+		{matcher: labels.MustNewMatcher(labels.MatchRegexp, "n", "(?i)foo.*"), expected: ""},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, matcherPrefix(tc.matcher), tc.matcher.String())
+	}
+}
@@ -126,8 +126,13 @@ func (r *bucketIndexReader) expandedPostingsPromise(ctx context.Context, ms []*l
 	defer close(done)
 	defer r.block.expandedPostingsPromises.Delete(key)
 
+	refs, cached = r.block.fetchCachedPostings(key)
+	if cached {
+		return promise, false
+	}
 	refs, cached = r.fetchCachedExpandedPostings(ctx, r.block.userID, key, stats)
 	if cached {
+		r.block.storeCachedPostings(key, refs)
 		return promise, false
 	}
 	refs, err = r.expandedPostings(ctx, ms, stats)
@@ -135,6 +140,7 @@ func (r *bucketIndexReader) expandedPostingsPromise(ctx context.Context, ms []*l
 		return promise, false
 	}
 	r.cacheExpandedPostings(ctx, r.block.userID, key, refs)
+	r.block.storeCachedPostings(key, refs)
 	return promise, false
 }
 
@@ -167,8 +173,40 @@ func (r *bucketIndexReader) fetchCachedExpandedPostings(ctx context.Context, use
 	return refs, true
 }
 
+// LazyPostings returns the postings matching the input label matchers, like ExpandedPostings, without expanding
+// them: the postings are intersected while they're iterated. The expanded postings caches are bypassed.
+func (r *bucketIndexReader) LazyPostings(ctx context.Context, ms []*labels.Matcher, stats *safeQueryStats) (_ index.Postings, returnErr error) {
+	start := time.Now()
+	defer stats.update(func(stats *queryStats) {
+		stats.expandedPostingsDuration += time.Since(start)
+	})
+	span, ctx := tracing.StartSpan(ctx, "LazyPostings()")
+	defer func() {
+		if returnErr != nil {
+			span.LogFields(otlog.Error(returnErr))
+		}
+		span.Finish()
+	}()
+	return r.matchingPostings(ctx, ms, stats)
+}
+
 // expandedPostings is the main logic of ExpandedPostings, without the promise wrapper.
-func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.Matcher, stats *safeQueryStats) (returnRefs []storage.SeriesRef, returnErr error) {
+func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.Matcher, stats *safeQueryStats) ([]storage.SeriesRef, error) {
+	p, err := r.matchingPostings(ctx, ms, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	ps, err := index.ExpandPostings(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "expand")
+	}
+	return ps, nil
+}
+
+// matchingPostings returns the not expanded postings matching the input label matchers, with the index
+// version padding applied.
+func (r *bucketIndexReader) matchingPostings(ctx context.Context, ms []*labels.Matcher, stats *safeQueryStats) (index.Postings, error) {
 	var (
 		postingGroups []*postingGroup
 		allRequested  = false
@@ -188,7 +226,7 @@ func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.M
 		// postings would return no postings anyway.
 		// E.g. label="non-existing-value" returns empty group.
 		if !pg.addAll && len(pg.addKeys) == 0 {
-			return nil, nil
+			return index.EmptyPostings(), nil
 		}
 
 		postingGroups = append(postingGroups, pg)
@@ -203,7 +241,7 @@ func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.M
 	}
 
 	if len(postingGroups) == 0 {
-		return nil, nil
+		return index.EmptyPostings(), nil
 	}
 
 	// We only need special All postings if there are no other adds. If there are, we can skip fetching
@@ -248,11 +286,6 @@ func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.M
 
 	result := index.Without(index.Intersect(groupAdds...), index.Merge(groupRemovals...))
 
-	ps, err := index.ExpandPostings(result)
-	if err != nil {
-		return nil, errors.Wrap(err, "expand")
-	}
-
 	// As of version two all series entries are 16 byte padded. All references
 	// we get have to account for that to get the correct offset.
 	version, err := r.block.indexHeaderReader.IndexVersion()
@@ -260,12 +293,10 @@ func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.M
 		return nil, errors.Wrap(err, "get index version")
 	}
 	if version >= 2 {
-		for i, id := range ps {
-			ps[i] = id * 16
-		}
+		result = paddedPostings{result}
 	}
 
-	return ps, nil
+	return result, nil
 }
 
 // FetchPostings fills postings requested by posting groups.
@@ -29,12 +29,15 @@ type BucketStoreMetrics struct {
 	seriesDataSizeTouched *prometheus.SummaryVec
 	seriesDataSizeFetched *prometheus.SummaryVec
 	seriesBlocksQueried   prometheus.Summary
+	seriesBlocksFailed    prometheus.Counter
 	seriesGetAllDuration  prometheus.Histogram
 	seriesMergeDuration   prometheus.Histogram
 	resultSeriesCount     prometheus.Summary
 	chunkSizeBytes        prometheus.Histogram
 	queriesDropped        *prometheus.CounterVec
 	seriesRefetches       prometheus.Counter
+	chunksAbandonedBytes  prometheus.Counter
+	chunksDeduplicated    prometheus.Counter
 
 	cachedPostingsCompressions           *prometheus.CounterVec
 	cachedPostingsCompressionErrors      *prometheus.CounterVec
@@ -48,9 +51,11 @@ type BucketStoreMetrics struct {
 	seriesFetchDuration   prometheus.Histogram
 	postingsFetchDuration prometheus.Histogram
 
-	indexHeaderReaderMetrics *indexheader.ReaderPoolMetrics
+	indexHeaderReaderMetrics       *indexheader.ReaderPoolMetrics
+	indexHeaderPrefetchQueueLength prometheus.Gauge
 
 	iteratorLoadDurations  *prometheus.HistogramVec
+	seriesBatchChunksBytes prometheus.Histogram
 	expandPostingsDuration prometheus.Histogram
 }
 
@@ -96,6 +101,10 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Name: "cortex_bucket_store_series_blocks_queried",
 		Help: "Number of blocks in a bucket store that were touched to satisfy a query.",
 	})
+	m.seriesBlocksFailed = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_series_blocks_failed_total",
+		Help: "Total number of blocks whose series couldn't be fetched by Series() calls returning partial results.",
+	})
 	m.seriesGetAllDuration = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_bucket_store_series_get_all_duration_seconds",
 		Help:    "Time it takes until all per-block prepares and loads for a query are finished.",
@@ -110,6 +119,14 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Name: "cortex_bucket_store_series_refetches_total",
 		Help: "Total number of cases where the built-in max series size was not enough to fetch series from index, resulting in refetch.",
 	})
+	m.chunksAbandonedBytes = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_fetch_abandoned_bytes_total",
+		Help: "Total size of the chunks byte ranges which were not fetched from the bucket, or whose fetching was interrupted, because the request was canceled.",
+	})
+	m.chunksDeduplicated = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_series_chunks_deduplicated_total",
+		Help: "Total number of identical chunks of the same series, loaded from overlapping blocks, which have been deduplicated before returning the series.",
+	})
 	m.resultSeriesCount = promauto.With(reg).NewSummary(prometheus.SummaryOpts{
 		Name: "cortex_bucket_store_series_result_series",
 		Help: "Number of series observed in the final result of a query.",
@@ -178,6 +195,10 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 	})
 
 	m.indexHeaderReaderMetrics = indexheader.NewReaderPoolMetrics(prometheus.WrapRegistererWithPrefix("cortex_bucket_store_", reg))
+	m.indexHeaderPrefetchQueueLength = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_index_header_prefetch_queue_length",
+		Help: "Number of index-headers of newly discovered blocks waiting to be prefetched.",
+	})
 
 	m.iteratorLoadDurations = promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "cortex_bucket_store_iterator_load_duration",
@@ -185,6 +206,12 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Buckets: []float64{0.001, 0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120},
 	}, []string{"iterator"})
 
+	m.seriesBatchChunksBytes = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+		Name:    "cortex_bucket_store_series_batch_chunks_size_bytes",
+		Help:    "Size in bytes of the chunks loaded for each batch of series when series streaming is enabled.",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 10), // 1KiB -> 256MiB
+	})
+
 	m.expandPostingsDuration = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_bucket_store_expanded_postings_duration",
 		Help:    "The time it takes to get a list of all series that match the request matcher.",
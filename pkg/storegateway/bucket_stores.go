@@ -31,8 +31,12 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	"github.com/grafana/mimir/pkg/storegateway/postingscache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/cachedebug"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
@@ -58,18 +62,50 @@ type BucketStores struct {
 	// Index cache shared across all tenants.
 	indexCache indexcache.IndexCache
 
+	// Chunks cache shared across all tenants.
+	chunksCache chunkscache.Cache
+
 	// Series hash cache shared across all tenants.
 	seriesHashCache *hashcache.SeriesHashCache
 
+	// Expanded postings cache shared across all tenants. Nil if disabled.
+	postingsCache *postingscache.Cache
+
+	// Exemplars cache shared across all tenants.
+	exemplarsCache *exemplarsCache
+
 	// Chunks bytes pool shared across all tenants.
 	chunksPool pool.Bytes
 
+	// Memory limiter based on the chunks bytes pool usage, shared across all tenants.
+	memoryLimiter *MemoryLimiter
+
 	// Partitioner shared across all tenants.
 	partitioner Partitioner
 
-	// Gate used to limit query concurrency across all tenants.
+	// Gate used to limit query concurrency across all tenants. Nil if the tenant fair scheduling is enabled.
 	queryGate gate.Gate
 
+	// Scheduler used to limit query concurrency across all tenants, allocating the slots to the tenants
+	// based on their weight. Nil if the tenant fair scheduling is disabled.
+	queryScheduler *tenantScheduler
+
+	// Collapses the identical Series() requests received concurrently. Nil if disabled.
+	seriesDeduplication *seriesDeduplication
+
+	// Tracks the requests issued to fetch chunks across all tenants, to compute the adaptive chunks
+	// fetch concurrency. Nil if disabled.
+	chunksFetchLoad *chunksFetchLoad
+
+	// Hedges the requests issued to fetch chunks across all tenants. Nil if disabled.
+	chunksFetchHedging *chunksFetchHedging
+
+	// Gate used to limit the index-header prefetches concurrency across all tenants. Nil if prefetching is disabled.
+	indexHeaderPrefetchGate gate.Gate
+
+	// Scans the bucket when the bucket index of a tenant is missing or too old. Nil if disabled.
+	bucketIndexScanner *bucketindex.Scanner
+
 	// Keeps a bucket store for each tenant.
 	storesMu sync.RWMutex
 	stores   map[string]*BucketStore
@@ -84,29 +120,45 @@ type BucketStores struct {
 
 // NewBucketStores makes a new BucketStores.
 func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStrategy, bucketClient objstore.Bucket, limits *validation.Overrides, logLevel logging.Level, logger log.Logger, reg prometheus.Registerer) (*BucketStores, error) {
-	cachingBucket, err := tsdb.CreateCachingBucket(cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, bucketClient, logger, reg)
+	cachingBucket, err := tsdb.CreateCachingBucket(cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, cfg.BucketStore.DebugCacheKeysEnabled, bucketClient, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "create caching bucket")
 	}
 
 	// The number of concurrent queries against the tenants BucketStores are limited.
 	queryGateReg := prometheus.WrapRegistererWithPrefix("cortex_bucket_stores_", reg)
-	queryGate := gate.NewBlocking(cfg.BucketStore.MaxConcurrent)
-	queryGate = gate.NewInstrumented(queryGateReg, cfg.BucketStore.MaxConcurrent, queryGate)
+	var (
+		queryGate      gate.Gate
+		queryScheduler *tenantScheduler
+	)
+	if cfg.BucketStore.TenantFairScheduling {
+		queryScheduler = newTenantScheduler(cfg.BucketStore.MaxConcurrent, limits.StoreGatewayQueryConcurrencyWeight, queryGateReg)
+	} else {
+		queryGate = gate.NewBlocking(cfg.BucketStore.MaxConcurrent)
+		queryGate = gate.NewInstrumented(queryGateReg, cfg.BucketStore.MaxConcurrent, queryGate)
+	}
+
+	// The number of concurrent index-header prefetches is limited across all tenants.
+	var indexHeaderPrefetchGate gate.Gate
+	if cfg.BucketStore.IndexHeaderLazyLoadingEnabled && cfg.BucketStore.IndexHeaderLazyLoadingPrefetchConcurrency > 0 {
+		indexHeaderPrefetchGate = gate.NewBlocking(cfg.BucketStore.IndexHeaderLazyLoadingPrefetchConcurrency)
+	}
 
 	u := &BucketStores{
-		logger:             logger,
-		cfg:                cfg,
-		limits:             limits,
-		bucket:             cachingBucket,
-		shardingStrategy:   shardingStrategy,
-		stores:             map[string]*BucketStore{},
-		logLevel:           logLevel,
-		bucketStoreMetrics: NewBucketStoreMetrics(reg),
-		metaFetcherMetrics: NewMetadataFetcherMetrics(),
-		queryGate:          queryGate,
-		partitioner:        newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, reg),
-		seriesHashCache:    hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
+		logger:                  logger,
+		cfg:                     cfg,
+		limits:                  limits,
+		bucket:                  cachingBucket,
+		shardingStrategy:        shardingStrategy,
+		stores:                  map[string]*BucketStore{},
+		logLevel:                logLevel,
+		bucketStoreMetrics:      NewBucketStoreMetrics(reg),
+		metaFetcherMetrics:      NewMetadataFetcherMetrics(),
+		queryGate:               queryGate,
+		queryScheduler:          queryScheduler,
+		indexHeaderPrefetchGate: indexHeaderPrefetchGate,
+		partitioner:             newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, reg),
+		seriesHashCache:         hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
 		syncBackoffConfig: backoff.Config{
 			MinBackoff: 1 * time.Second,
 			MaxBackoff: 10 * time.Second,
@@ -114,6 +166,26 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		},
 	}
 
+	if cfg.BucketStore.SeriesRequestsDeduplicationEnabled {
+		u.seriesDeduplication = newSeriesDeduplication(int(cfg.BucketStore.SeriesRequestsDeduplicationMaxResponseBytes), logger, reg)
+	}
+
+	if cfg.BucketStore.ChunksFetchAdaptiveConcurrencyEnabled {
+		u.chunksFetchLoad = newChunksFetchLoad(cfg.BucketStore.ChunksFetchAdaptiveMaxInflightRequests, reg)
+	}
+
+	if cfg.BucketStore.ChunksFetchHedgingEnabled {
+		u.chunksFetchHedging = newChunksFetchHedging(cfg.BucketStore.ChunksFetchHedgingQuantile, cfg.BucketStore.ChunksFetchHedgingMinDelay, reg)
+	}
+
+	if cfg.BucketStore.BucketIndex.Enabled && cfg.BucketStore.BucketIndex.FallbackScanEnabled {
+		u.bucketIndexScanner = bucketindex.NewScanner(bucketindex.ScannerConfig{
+			MaxBlocks:         cfg.BucketStore.BucketIndex.FallbackScanMaxBlocks,
+			FailuresThreshold: cfg.BucketStore.BucketIndex.FallbackScanFailuresThreshold,
+			CooldownPeriod:    cfg.BucketStore.BucketIndex.FallbackScanCooldownPeriod,
+		}, cachingBucket, limits, logger, reg)
+	}
+
 	// Register metrics.
 	u.syncTimes = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_bucket_stores_blocks_sync_seconds",
@@ -138,14 +210,35 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	}, u.getBlocksLoadedMetric)
 
 	// Init the index cache.
-	if u.indexCache, err = tsdb.NewIndexCache(cfg.BucketStore.IndexCache, logger, reg); err != nil {
+	if u.indexCache, err = tsdb.NewIndexCache(cfg.BucketStore.IndexCache, cfg.BucketStore.DebugCacheKeysEnabled, logger, reg); err != nil {
 		return nil, errors.Wrap(err, "create index cache")
 	}
 
+	// Init the chunks cache, used only when fine-grained chunks caching is enabled.
+	if u.chunksCache, err = newChunksCache(cfg.BucketStore, logger, reg); err != nil {
+		return nil, errors.Wrap(err, "create chunks cache")
+	}
+
+	// Init the expanded postings cache.
+	if cfg.BucketStore.PostingsCache.Enabled {
+		postingsCfg := cfg.BucketStore.PostingsCache
+		if u.postingsCache, err = postingscache.NewCache(postingsCfg.MaxSizeBytes, postingsCfg.MaxItemSizeBytes, postingsCfg.TTL, logger, reg); err != nil {
+			return nil, errors.Wrap(err, "create postings cache")
+		}
+	}
+
+	// Init the exemplars cache.
+	if u.exemplarsCache, err = newExemplarsCache(cfg.BucketStore.ExemplarsCacheMaxSizeBytes, logger, reg); err != nil {
+		return nil, errors.Wrap(err, "create exemplars cache")
+	}
+
 	// Init the chunks bytes pool.
-	if u.chunksPool, err = newChunkBytesPool(cfg.BucketStore.ChunkPoolMinBucketSizeBytes, cfg.BucketStore.ChunkPoolMaxBucketSizeBytes, cfg.BucketStore.MaxChunkPoolBytes, reg); err != nil {
+	chunksPool, err := newChunkBytesPool(cfg.BucketStore.ChunkPoolMinBucketSizeBytes, cfg.BucketStore.ChunkPoolMaxBucketSizeBytes, cfg.BucketStore.MaxChunkPoolBytes, reg)
+	if err != nil {
 		return nil, errors.Wrap(err, "create chunks bytes pool")
 	}
+	u.chunksPool = chunksPool
+	u.memoryLimiter = NewMemoryLimiter(cfg.BucketStore.MaxInflightChunksBytes, chunksPool.usedBytes)
 
 	if reg != nil {
 		reg.MustRegister(u.metaFetcherMetrics)
@@ -154,6 +247,23 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	return u, nil
 }
 
+// newChunksCache creates the cache of individual chunks if fine-grained chunks caching is enabled,
+// otherwise returns a chunkscache.NoopCache.
+func newChunksCache(cfg tsdb.BucketStoreConfig, logger log.Logger, reg prometheus.Registerer) (chunkscache.Cache, error) {
+	if !cfg.ChunksCache.FineGrainedChunksCachingEnabled {
+		return chunkscache.NoopCache{}, nil
+	}
+
+	client, err := tsdb.CreateChunksCacheClient(cfg.ChunksCache, logger, reg)
+	if err != nil || client == nil {
+		return chunkscache.NoopCache{}, err
+	}
+	if cfg.DebugCacheKeysEnabled {
+		client = cachedebug.NewKeysRecordingCache(client)
+	}
+	return chunkscache.NewChunksCache(logger, client, cfg.ChunksCache.SubrangeTTL, reg), nil
+}
+
 // InitialSync does an initial synchronization of blocks for all users.
 func (u *BucketStores) InitialSync(ctx context.Context) error {
 	level.Info(u.logger).Log("msg", "synchronizing TSDB blocks for all users")
@@ -303,10 +413,18 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_Seri
 		return nil
 	}
 
-	return store.Series(req, spanSeriesServer{
+	spanSrv := spanSeriesServer{
 		Store_SeriesServer: srv,
 		ctx:                spanCtx,
-	})
+	}
+
+	if u.seriesDeduplication != nil {
+		return u.seriesDeduplication.series(userID, req, spanSrv, func(srv storepb.Store_SeriesServer) error {
+			return store.Series(req, srv)
+		})
+	}
+
+	return store.Series(req, spanSrv)
 }
 
 // LabelNames implements the storepb.StoreServer interface.
@@ -345,6 +463,24 @@ func (u *BucketStores) LabelValues(ctx context.Context, req *storepb.LabelValues
 	return store.LabelValues(ctx, req)
 }
 
+// Exemplars implements the storegatewaypb.StoreGatewayServer interface.
+func (u *BucketStores) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	spanLog, spanCtx := spanlogger.NewWithLogger(ctx, u.logger, "BucketStores.Exemplars")
+	defer spanLog.Span.Finish()
+
+	userID := getUserIDFromGRPCContext(spanCtx)
+	if userID == "" {
+		return nil, fmt.Errorf("no userID")
+	}
+
+	store := u.getStore(userID)
+	if store == nil {
+		return &storepb.ExemplarsResponse{}, nil
+	}
+
+	return store.Exemplars(ctx, req)
+}
+
 // scanUsers in the bucket and return the list of found users. If an error occurs while
 // iterating the bucket, it may return both an error and a subset of the users in the bucket.
 func (u *BucketStores) scanUsers(ctx context.Context) ([]string, error) {
@@ -384,6 +520,9 @@ func (u *BucketStores) closeBucketStore(userID string) error {
 	u.storesMu.Unlock()
 
 	u.metaFetcherMetrics.RemoveUserRegistry(userID)
+	if u.queryScheduler != nil {
+		u.queryScheduler.removeTenant(userID)
+	}
 	return bs.RemoveBlocksAndClose()
 }
 
@@ -414,13 +553,15 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 	userBkt := bucket.NewUserBucketClient(userID, u.bucket, u.limits)
 	fetcherReg := prometheus.NewRegistry()
 
+	// Use our own custom implementation.
+	deletionMarkFilter := NewIgnoreDeletionMarkFilter(userLogger, userBkt, u.cfg.BucketStore.IgnoreDeletionMarksDelay, u.cfg.BucketStore.MetaSyncConcurrency)
+
 	// The sharding strategy filter MUST be before the ones we create here (order matters).
 	filters := []block.MetadataFilter{
 		NewShardingMetadataFilterAdapter(userID, u.shardingStrategy),
 		block.NewConsistencyDelayMetaFilter(userLogger, u.cfg.BucketStore.ConsistencyDelay, fetcherReg),
 		newMinTimeMetaFilter(u.cfg.BucketStore.IgnoreBlocksWithin),
-		// Use our own custom implementation.
-		NewIgnoreDeletionMarkFilter(userLogger, userBkt, u.cfg.BucketStore.IgnoreDeletionMarksDelay, u.cfg.BucketStore.MetaSyncConcurrency),
+		deletionMarkFilter,
 		// The duplicate filter has been intentionally omitted because it could cause troubles with
 		// the consistency check done on the querier. The duplicate filter removes redundant blocks
 		// but if the store-gateway removes redundant blocks before the querier discovers them, the
@@ -437,6 +578,8 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 			u.logger,
 			fetcherReg,
 			filters,
+			u.bucketIndexScanner,
+			u.cfg.BucketStore.BucketIndex.MaxStalePeriod,
 		)
 	} else {
 		var err error
@@ -456,9 +599,54 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 	bucketStoreOpts := []BucketStoreOption{
 		WithLogger(userLogger),
 		WithIndexCache(u.indexCache),
-		WithQueryGate(u.queryGate),
+		WithChunksCache(u.chunksCache),
 		WithChunkPool(u.chunksPool),
+		WithMemoryLimiter(u.memoryLimiter),
 		WithStreamingSeriesPerBatch(u.cfg.BucketStore.StreamingBatchSize),
+		WithStreamingSeriesPerBatchOverride(func() int { return u.limits.StoreGatewayStreamingSeriesBatchSize(userID) }),
+		WithStreamingSeriesChunksSlabSize(u.cfg.BucketStore.StreamingChunksSlabSize, u.cfg.BucketStore.StreamingAdaptiveChunksSlabSizeEnabled),
+		WithStreamingSeriesChunksDeduplication(u.cfg.BucketStore.StreamingChunksDeduplicationEnabled),
+		WithStreamingSeriesSlowBatchLogging(u.cfg.BucketStore.StreamingSlowBatchLogThreshold),
+		WithChunksFetching(u.cfg.BucketStore.ChunksFetchConcurrency, u.cfg.BucketStore.ChunksFetchMaxRangeBytes),
+		WithExternalLabelMatchers(u.cfg.BucketStore.ExternalLabelMatchers),
+		WithPartialResults(func() bool { return u.limits.StoreGatewayPartialResultsEnabled(userID) }),
+		WithLazyPostings(func() bool { return u.limits.StoreGatewayLazyPostingsEnabled(userID) }),
+		WithMaxExemplarsBytesPerQuery(func() int { return u.limits.StoreGatewayMaxExemplarsBytesPerQuery(userID) }),
+		withExemplarsCache(u.exemplarsCache),
+	}
+	if u.cfg.BucketStore.StreamingAdaptivePreloadingEnabled {
+		bucketStoreOpts = append(bucketStoreOpts, WithStreamingSeriesAdaptivePreloading(u.cfg.BucketStore.StreamingAdaptivePreloadingMaxBytes))
+	}
+	if u.cfg.BucketStore.StreamingEagerSendingEnabled {
+		bucketStoreOpts = append(bucketStoreOpts, WithStreamingSeriesEagerSending(true))
+	}
+	if u.cfg.BucketStore.DownsampledBlocksEnabled {
+		bucketStoreOpts = append(bucketStoreOpts, WithDownsampledBlocks())
+	}
+	if u.cfg.BucketStore.SeriesHashCachePersistenceEnabled {
+		bucketStoreOpts = append(bucketStoreOpts, WithSeriesHashesPersistence())
+	}
+	if u.chunksFetchLoad != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithChunksFetchAdaptiveConcurrency(u.chunksFetchLoad, func() int {
+			if override := u.limits.StoreGatewayChunksFetchMaxConcurrency(userID); override > 0 {
+				return override
+			}
+			return u.cfg.BucketStore.ChunksFetchAdaptiveMaxConcurrency
+		}))
+	}
+	if u.chunksFetchHedging != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithChunksFetchHedging(u.chunksFetchHedging))
+	}
+	if u.queryScheduler != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithQueryGate(u.queryScheduler.tenantGate(userID)))
+	} else {
+		bucketStoreOpts = append(bucketStoreOpts, WithQueryGate(u.queryGate))
+	}
+	if u.indexHeaderPrefetchGate != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithIndexHeaderPrefetching(u.indexHeaderPrefetchGate))
+	}
+	if u.postingsCache != nil {
+		bucketStoreOpts = append(bucketStoreOpts, WithPostingsCache(u.postingsCache, deletionMarkFilter.DeletionMarkBlocks))
 	}
 	if u.logLevel.String() == "debug" {
 		bucketStoreOpts = append(bucketStoreOpts, WithDebugLogging())
@@ -477,6 +665,7 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 		u.cfg.BucketStore.IndexHeader,
 		u.cfg.BucketStore.IndexHeaderLazyLoadingEnabled,
 		u.cfg.BucketStore.IndexHeaderLazyLoadingIdleTimeout,
+		u.cfg.BucketStore.IndexHeaderLazyLoadingMaxLoaded,
 		u.seriesHashCache,
 		u.bucketStoreMetrics,
 		bucketStoreOpts...,
@@ -15,11 +15,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
+	dstest "github.com/grafana/dskit/test"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -44,6 +46,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
@@ -265,6 +268,123 @@ func TestBucketStores_SyncBlocks(t *testing.T) {
 	assert.Greater(t, testutil.ToFloat64(stores.syncLastSuccess), float64(0))
 }
 
+func TestBucketStores_SyncBlocks_ShouldPrefetchIndexHeadersOfNewBlocks(t *testing.T) {
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.IndexHeaderLazyLoadingEnabled = true
+	cfg.BucketStore.IndexHeaderLazyLoadingPrefetchConcurrency = 1
+
+	storageDir := t.TempDir()
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, stores.closeBucketStore(userID))
+	})
+
+	// Run an initial sync to discover 2 blocks.
+	generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	generateStorageBlock(t, storageDir, userID, metricName, 100, 200, 15)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// The index-headers of the discovered blocks should be loaded in background, without querying the blocks.
+	dstest.Poll(t, 5*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_indexheader_lazy_load_total Total number of index-header lazy load operations.
+			# TYPE cortex_bucket_store_indexheader_lazy_load_total counter
+			cortex_bucket_store_indexheader_lazy_load_total 2
+
+			# HELP cortex_bucket_store_index_header_prefetch_queue_length Number of index-headers of newly discovered blocks waiting to be prefetched.
+			# TYPE cortex_bucket_store_index_header_prefetch_queue_length gauge
+			cortex_bucket_store_index_header_prefetch_queue_length 0
+		`),
+			"cortex_bucket_store_indexheader_lazy_load_total",
+			"cortex_bucket_store_index_header_prefetch_queue_length",
+		)
+	})
+
+	// A sync which doesn't discover new blocks shouldn't prefetch anything.
+	require.NoError(t, stores.SyncBlocks(ctx))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_indexheader_lazy_load_total Total number of index-header lazy load operations.
+			# TYPE cortex_bucket_store_indexheader_lazy_load_total counter
+			cortex_bucket_store_indexheader_lazy_load_total 2
+	`), "cortex_bucket_store_indexheader_lazy_load_total"))
+}
+
+func TestBucketStores_SyncBlocks_ShouldKeepIndexHeadersOfCompactedBlocksLoaded(t *testing.T) {
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.BucketIndex.Enabled = true
+	cfg.BucketStore.IndexHeaderLazyLoadingEnabled = true
+	cfg.BucketStore.IndexHeaderLazyLoadingIdleTimeout = time.Second
+	cfg.BucketStore.IndexHeaderLazyLoadingPrefetchConcurrency = 1
+
+	storageDir := t.TempDir()
+
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	// Generate 2 blocks and a bucket index where the second block has replaced the first one.
+	generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	generateStorageBlock(t, storageDir, userID, metricName, 100, 200, 15)
+
+	idx, _, err := bucketindex.NewUpdater(bkt, userID, nil, log.NewNopLogger()).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 2)
+	idx.Blocks[1].ReplacedBlocks = []ulid.ULID{idx.Blocks[0].ID}
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bkt, defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, stores.closeBucketStore(userID))
+	})
+
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// Wait until the prefetched index-headers have been unloaded because idle.
+	dstest.Poll(t, 5*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_indexheader_lazy_load_total Total number of index-header lazy load operations.
+			# TYPE cortex_bucket_store_indexheader_lazy_load_total counter
+			cortex_bucket_store_indexheader_lazy_load_total 2
+
+			# HELP cortex_bucket_store_indexheader_lazy_unload_total Total number of index-header lazy unload operations.
+			# TYPE cortex_bucket_store_indexheader_lazy_unload_total counter
+			cortex_bucket_store_indexheader_lazy_unload_total 2
+		`),
+			"cortex_bucket_store_indexheader_lazy_load_total",
+			"cortex_bucket_store_indexheader_lazy_unload_total",
+		)
+	})
+
+	// A sync should load again the index-header of the compacted block only.
+	require.NoError(t, stores.SyncBlocks(ctx))
+	dstest.Poll(t, 5*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_indexheader_lazy_load_total Total number of index-header lazy load operations.
+			# TYPE cortex_bucket_store_indexheader_lazy_load_total counter
+			cortex_bucket_store_indexheader_lazy_load_total 3
+		`), "cortex_bucket_store_indexheader_lazy_load_total")
+	})
+}
+
 func TestBucketStores_syncUsersBlocks(t *testing.T) {
 	test.VerifyNoLeak(t)
 
@@ -499,6 +619,52 @@ func TestBucketStore_Series_ShouldQueryBlockWithOutOfOrderChunks(t *testing.T) {
 	}
 }
 
+func TestBucketStores_Series_ShouldReturnTheSameSeriesWhenDeduplicatingConcurrentRequests(t *testing.T) {
+	const (
+		userID      = "user-1"
+		metricName  = "series_1"
+		numRequests = 10
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.SeriesRequestsDeduplicationEnabled = true
+
+	storageDir := t.TempDir()
+	generateStorageBlock(t, storageDir, userID, metricName, 0, 10000, 1)
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	wg := sync.WaitGroup{}
+	wg.Add(numRequests)
+	for i := 0; i < numRequests; i++ {
+		go func() {
+			defer wg.Done()
+
+			seriesSet, warnings, err := querySeries(stores, userID, metricName, 0, 10000)
+			require.NoError(t, err)
+			assert.Empty(t, warnings)
+			require.Len(t, seriesSet, 1)
+
+			samples, err := readSamplesFromChunks(seriesSet[0].Chunks)
+			require.NoError(t, err)
+			assert.Equal(t, 10000, len(samples))
+		}()
+	}
+	wg.Wait()
+
+	// Each request has either been executed or received the responses of an identical request.
+	d := stores.seriesDeduplication
+	assert.Equal(t, float64(numRequests), testutil.ToFloat64(d.executedRequests)+testutil.ToFloat64(d.deduplicatedRequests))
+	assert.Empty(t, d.inflight)
+}
+
 func prepareStorageConfig(t *testing.T) mimir_tsdb.BlocksStorageConfig {
 	tmpDir := t.TempDir()
 
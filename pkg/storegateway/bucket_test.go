@@ -53,10 +53,13 @@ import (
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+	"github.com/grafana/mimir/pkg/storegateway/postingscache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/test"
@@ -82,7 +85,7 @@ func TestBucketBlock_matchLabels(t *testing.T) {
 		},
 	}
 
-	b, err := newBucketBlock(context.Background(), "test", log.NewNopLogger(), NewBucketStoreMetrics(nil), meta, bkt, path.Join(dir, blockID.String()), nil, nil, nil, nil)
+	b, err := newBucketBlock(context.Background(), "test", log.NewNopLogger(), NewBucketStoreMetrics(nil), meta, bkt, path.Join(dir, blockID.String()), nil, nil, nil, nil, nil)
 	assert.NoError(t, err)
 
 	cases := []struct {
@@ -156,7 +159,7 @@ func TestBucketBlock_matchLabels(t *testing.T) {
 }
 
 func TestBucketBlockSet_remove(t *testing.T) {
-	set := newBucketBlockSet()
+	set := newBucketBlockSet(rawResolutions)
 
 	type resBlock struct {
 		id         ulid.ULID
@@ -183,6 +186,91 @@ func TestBucketBlockSet_remove(t *testing.T) {
 	assert.Equal(t, input[2].id, res[1].meta.ULID)
 }
 
+func TestBucketBlockSet_getForDownsampledBlocks(t *testing.T) {
+	set := newBucketBlockSet(downsampledResolutions)
+
+	type resBlock struct {
+		id         ulid.ULID
+		mint, maxt int64
+		resolution int64
+	}
+	input := []resBlock{
+		{id: ulid.MustNew(1, nil), mint: 0, maxt: 100, resolution: downsample.ResLevel0},
+		{id: ulid.MustNew(2, nil), mint: 100, maxt: 200, resolution: downsample.ResLevel0},
+		{id: ulid.MustNew(3, nil), mint: 200, maxt: 300, resolution: downsample.ResLevel0},
+		{id: ulid.MustNew(4, nil), mint: 0, maxt: 200, resolution: downsample.ResLevel1},
+		{id: ulid.MustNew(5, nil), mint: 0, maxt: 100, resolution: downsample.ResLevel2},
+	}
+	for _, in := range input {
+		var m metadata.Meta
+		m.ULID = in.id
+		m.MinTime = in.mint
+		m.MaxTime = in.maxt
+		m.Thanos.Downsample.Resolution = in.resolution
+		require.NoError(t, set.add(&bucketBlock{meta: &m, blockLabels: blockLabelsFromMeta(&m)}))
+	}
+
+	var unsupported metadata.Meta
+	unsupported.ULID = ulid.MustNew(6, nil)
+	unsupported.Thanos.Downsample.Resolution = 1000
+	require.Error(t, set.add(&bucketBlock{meta: &unsupported}))
+
+	blockMatcher := func(ids ...int) []*labels.Matcher {
+		var values []string
+		for _, id := range ids {
+			values = append(values, input[id-1].id.String())
+		}
+		return []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, block.BlockIDLabel, strings.Join(values, "|"))}
+	}
+
+	tests := map[string]struct {
+		maxResolution int64
+		blockMatchers []*labels.Matcher
+		expected      []int
+	}{
+		"raw resolution": {
+			maxResolution: 0,
+			expected:      []int{1, 2, 3},
+		},
+		"5m resolution": {
+			maxResolution: downsample.ResLevel1,
+			expected:      []int{4, 3},
+		},
+		"1h resolution": {
+			maxResolution: downsample.ResLevel2,
+			expected:      []int{5, 4, 3},
+		},
+		"resolution between the downsampling resolutions": {
+			maxResolution: downsample.ResLevel2 - 1,
+			expected:      []int{4, 3},
+		},
+		"blocks not matching the block matchers don't shadow the matching blocks of smaller resolutions": {
+			maxResolution: downsample.ResLevel2,
+			blockMatchers: blockMatcher(1, 2, 3),
+			expected:      []int{1, 2, 3},
+		},
+		"blocks of mixed resolutions matching the block matchers": {
+			maxResolution: downsample.ResLevel2,
+			blockMatchers: blockMatcher(2, 3, 5),
+			expected:      []int{5, 2, 3},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actual []int
+			for _, b := range set.getFor(0, 300, testData.maxResolution, testData.blockMatchers) {
+				for i, in := range input {
+					if in.id == b.meta.ULID {
+						actual = append(actual, i+1)
+					}
+				}
+			}
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
 // Regression tests against: https://github.com/thanos-io/thanos/issues/1983.
 func TestReadIndexCache_LoadSeries(t *testing.T) {
 	bkt := objstore.NewInMemBucket()
@@ -359,6 +447,32 @@ func TestBlockLabelNames(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, jNotFooLabelNames, names)
 	})
+
+	t.Run("happy case with matchers should only read the index", func(t *testing.T) {
+		b := newTestBucketBlock()
+		// The matching series are looked up without building the series set cached for the Series() calls.
+		b.indexCache = cacheNotExpectingToStoreSeries{t: t}
+
+		jFooMatchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "j", "foo")}
+		names, err := blockLabelNames(context.Background(), b.indexReader(), jFooMatchers, sl, log.NewNopLogger())
+		require.NoError(t, err)
+		require.Equal(t, jFooLabelNames, names)
+
+		noMatchMatchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "j", "baz")}
+		names, err = blockLabelNames(context.Background(), b.indexReader(), noMatchMatchers, sl, log.NewNopLogger())
+		require.NoError(t, err)
+		require.Empty(t, names)
+	})
+
+	t.Run("series limit exceeded with matchers", func(t *testing.T) {
+		b := newTestBucketBlock()
+		b.indexCache = cacheNotExpectingToStoreLabelNames{t: t}
+
+		limiter := NewLimiter(10, promauto.With(nil).NewCounter(prometheus.CounterOpts{Name: "test"}))
+		jFooMatchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "j", "foo")}
+		_, err := blockLabelNames(context.Background(), b.indexReader(), jFooMatchers, limiter, log.NewNopLogger())
+		require.ErrorContains(t, err, "exceeded series limit")
+	})
 }
 
 type cacheNotExpectingToStoreLabelNames struct {
@@ -460,6 +574,27 @@ func TestBlockLabelValues(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, []string{"bar"}, values)
 	})
+
+	t.Run("happy case with matchers only on the requested label", func(t *testing.T) {
+		expectedCalls := 1
+		b := newTestBucketBlock()
+		b.indexHeaderReader = &interceptedIndexReader{
+			Reader: b.indexHeaderReader,
+			onLabelValuesCalled: func(name string) error {
+				// Looking up the postings of the regexp matcher would call LabelValues(j) again.
+				expectedCalls--
+				if expectedCalls < 0 {
+					return fmt.Errorf("didn't expect another index.Reader.LabelValues() call")
+				}
+				return nil
+			},
+		}
+
+		matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "j", "f.*"), labels.MustNewMatcher(labels.MatchNotEqual, "j", "")}
+		values, err := blockLabelValues(context.Background(), b.indexReader(), "j", matchers, log.NewNopLogger(), newSafeQueryStats())
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo"}, values)
+	})
 }
 
 type cacheNotExpectingToStoreLabelValues struct {
@@ -724,6 +859,15 @@ func (iir *interceptedIndexReader) LabelValues(name string, filter func(string)
 	return iir.Reader.LabelValues(name, filter)
 }
 
+func (iir *interceptedIndexReader) LabelValuesWithPrefix(name, prefix string, filter func(string) bool) ([]string, error) {
+	if iir.onLabelValuesCalled != nil {
+		if err := iir.onLabelValuesCalled(name); err != nil {
+			return nil, err
+		}
+	}
+	return iir.Reader.LabelValuesWithPrefix(name, prefix, filter)
+}
+
 type contextNotifyingOnDoneWaiting struct {
 	context.Context
 	once        sync.Once
@@ -1047,7 +1191,7 @@ func benchBucketSeries(t test.TB, skipChunk bool, samplesPerSeries, totalSeries
 			st.chunkPool = &trackedBytesPool{parent: st.chunkPool}
 
 			// Reset the memory pool tracker (only if streaming store-gateway is enabled).
-			if st.maxSeriesPerBatch > 0 {
+			if st.seriesPerBatch() > 0 {
 				seriesEntrySlicePool.(*pool.TrackedPool).Reset()
 				seriesChunksSlicePool.(*pool.TrackedPool).Reset()
 			}
@@ -1093,7 +1237,7 @@ func benchBucketSeries(t test.TB, skipChunk bool, samplesPerSeries, totalSeries
 				st.chunkPool.(*trackedBytesPool).gets.Store(0)
 
 				// Only if streaming store-gateway is enabled.
-				if st.maxSeriesPerBatch > 0 {
+				if st.seriesPerBatch() > 0 {
 					assert.Zero(t, seriesEntrySlicePool.(*pool.TrackedPool).Balance.Load())
 					assert.Zero(t, seriesChunksSlicePool.(*pool.TrackedPool).Balance.Load())
 
@@ -1128,6 +1272,7 @@ func benchBucketSeries(t test.TB, skipChunk bool, samplesPerSeries, totalSeries
 			indexheader.Config{},
 			false,
 			0,
+			0,
 			hashcache.NewSeriesHashCache(1024*1024),
 			NewBucketStoreMetrics(nil),
 			bucketStoreOpts...,
@@ -1251,6 +1396,7 @@ func TestBucket_Series_Concurrency(t *testing.T) {
 				indexheader.Config{},
 				false, // Lazy index-header loading disabled.
 				0,
+				0,
 				hashcache.NewSeriesHashCache(1024*1024),
 				NewBucketStoreMetrics(nil),
 				WithLogger(logger),
@@ -1427,7 +1573,7 @@ func TestBucketSeries_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 		bkt:             objstore.WithNoopInstr(bkt),
 		logger:          logger,
 		indexCache:      indexCache,
-		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), false, 0, indexheader.NewReaderPoolMetrics(nil)),
+		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), false, 0, 0, indexheader.NewReaderPoolMetrics(nil)),
 		metrics:         NewBucketStoreMetrics(nil),
 		blockSet:        &bucketBlockSet{blocks: [][]*bucketBlock{{b1, b2}}},
 		blocks: map[ulid.ULID]*bucketBlock{
@@ -1594,6 +1740,7 @@ func TestSeries_ErrorUnmarshallingRequestHints(t *testing.T) {
 		indexheader.Config{},
 		false,
 		0,
+		0,
 		hashcache.NewSeriesHashCache(1024*1024),
 		NewBucketStoreMetrics(nil),
 		WithLogger(logger),
@@ -1620,6 +1767,301 @@ func TestSeries_ErrorUnmarshallingRequestHints(t *testing.T) {
 	assert.Equal(t, true, regexp.MustCompile(".*unmarshal series request hints.*").MatchString(err.Error()))
 }
 
+func TestBucketStore_ExternalLabelMatchers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bktDir := filepath.Join(tmpDir, "bkt")
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, bkt.Close()) })
+
+	var (
+		logger   = log.NewNopLogger()
+		instrBkt = objstore.WithNoopInstr(bkt)
+		random   = rand.New(rand.NewSource(120))
+	)
+
+	// Create two blocks with a different value of the __block_shard__ external label.
+	var blockIDs []ulid.ULID
+	var seriesSets [][]*storepb.Series
+	for i, shard := range []string{"1", "2"} {
+		head, seriesSet := createHeadWithSeries(t, i, headGenOptions{
+			TSDBDir:          filepath.Join(tmpDir, shard),
+			SamplesPerSeries: 1,
+			Series:           2,
+			Random:           random,
+		})
+		blockID := createBlockFromHead(t, bktDir, head)
+		require.NoError(t, head.Close())
+
+		_, err := metadata.InjectThanos(logger, filepath.Join(bktDir, blockID.String()), metadata.Thanos{
+			Labels: map[string]string{"__block_shard__": shard},
+			Source: metadata.TestSource,
+		}, nil)
+		require.NoError(t, err)
+
+		blockIDs = append(blockIDs, blockID)
+		seriesSets = append(seriesSets, seriesSet)
+	}
+
+	fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, tmpDir, nil, nil)
+	require.NoError(t, err)
+
+	store, err := NewBucketStore(
+		"tenant",
+		instrBkt,
+		fetcher,
+		tmpDir,
+		NewChunksLimiterFactory(0),
+		NewSeriesLimiterFactory(0),
+		newGapBasedPartitioner(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+		10,
+		mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+		indexheader.Config{},
+		false,
+		0,
+		0,
+		hashcache.NewSeriesHashCache(1024*1024),
+		NewBucketStoreMetrics(nil),
+		WithLogger(logger),
+		WithExternalLabelMatchers([]string{"__block_shard__"}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, store.RemoveBlocksAndClose()) })
+	require.NoError(t, store.SyncBlocks(context.Background()))
+
+	allBlocksHints := []hintspb.Block{{Id: blockIDs[0].String()}, {Id: blockIDs[1].String()}}
+
+	for name, matchers := range map[string][]storepb.LabelMatcher{
+		"with series matchers": {
+			{Type: storepb.LabelMatcher_EQ, Name: "foo", Value: "bar"},
+			{Type: storepb.LabelMatcher_EQ, Name: "__block_shard__", Value: "2"},
+		},
+		"without series matchers": {
+			{Type: storepb.LabelMatcher_EQ, Name: "__block_shard__", Value: "2"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := newBucketStoreSeriesServer(context.Background())
+			require.NoError(t, store.Series(&storepb.SeriesRequest{MinTime: 0, MaxTime: 3, Matchers: matchers}, srv))
+			assert.Equal(t, seriesSets[1], srv.SeriesSet)
+
+			// The skipped block is reported as queried.
+			assert.ElementsMatch(t, allBlocksHints, srv.Hints.QueriedBlocks)
+		})
+	}
+
+	t.Run("label values", func(t *testing.T) {
+		resp, err := store.LabelValues(context.Background(), &storepb.LabelValuesRequest{
+			Label:    "i",
+			Start:    0,
+			End:      3,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "__block_shard__", Value: "1"}},
+		})
+		require.NoError(t, err)
+
+		var expected []string
+		for _, series := range seriesSets[0] {
+			expected = append(expected, mimirpb.FromLabelAdaptersToLabels(series.Labels).Get("i"))
+		}
+		assert.Equal(t, expected, resp.Values)
+
+		hints := hintspb.LabelValuesResponseHints{}
+		require.NoError(t, types.UnmarshalAny(resp.Hints, &hints))
+		assert.ElementsMatch(t, allBlocksHints, hints.QueriedBlocks)
+	})
+}
+
+func TestBucketStore_PartialResults(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bktDir := filepath.Join(tmpDir, "bkt")
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, bkt.Close()) })
+
+	var (
+		logger   = log.NewNopLogger()
+		instrBkt = objstore.WithNoopInstr(bkt)
+		random   = rand.New(rand.NewSource(120))
+	)
+
+	// Create two blocks, and remove the index of the second one once the blocks have been synced,
+	// so that fetching its series fails.
+	var blockIDs []ulid.ULID
+	var seriesSets [][]*storepb.Series
+	for i := 0; i < 2; i++ {
+		head, seriesSet := createHeadWithSeries(t, i, headGenOptions{
+			TSDBDir:          filepath.Join(tmpDir, strconv.Itoa(i)),
+			SamplesPerSeries: 1,
+			Series:           2,
+			Random:           random,
+		})
+		blockIDs = append(blockIDs, createBlockFromHead(t, bktDir, head))
+		seriesSets = append(seriesSets, seriesSet)
+		require.NoError(t, head.Close())
+	}
+
+	for _, batchSize := range []int{0, 1} {
+		t.Run(fmt.Sprintf("batch size: %d", batchSize), func(t *testing.T) {
+			for _, enabled := range []bool{false, true} {
+				t.Run(fmt.Sprintf("partial results enabled: %t", enabled), func(t *testing.T) {
+					fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, t.TempDir(), nil, nil)
+					require.NoError(t, err)
+
+					reg := prometheus.NewPedanticRegistry()
+					store, err := NewBucketStore(
+						"tenant",
+						instrBkt,
+						fetcher,
+						t.TempDir(),
+						NewChunksLimiterFactory(0),
+						NewSeriesLimiterFactory(0),
+						newGapBasedPartitioner(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+						10,
+						mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+						indexheader.Config{},
+						false,
+						0,
+						0,
+						hashcache.NewSeriesHashCache(1024*1024),
+						NewBucketStoreMetrics(reg),
+						WithLogger(logger),
+						WithStreamingSeriesPerBatch(batchSize),
+						WithPartialResults(func() bool { return enabled }),
+					)
+					require.NoError(t, err)
+					t.Cleanup(func() { assert.NoError(t, store.RemoveBlocksAndClose()) })
+					require.NoError(t, store.SyncBlocks(context.Background()))
+
+					indexPath := filepath.Join(bktDir, blockIDs[1].String(), block.IndexFilename)
+					index, err := os.ReadFile(indexPath)
+					require.NoError(t, err)
+					require.NoError(t, os.Remove(indexPath))
+					t.Cleanup(func() { require.NoError(t, os.WriteFile(indexPath, index, 0666)) })
+
+					srv := newBucketStoreSeriesServer(context.Background())
+					err = store.Series(&storepb.SeriesRequest{
+						MinTime:  0,
+						MaxTime:  3,
+						Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "foo", Value: "bar"}},
+					}, srv)
+
+					if !enabled {
+						require.Error(t, err)
+						assert.Contains(t, err.Error(), blockIDs[1].String())
+						return
+					}
+
+					require.NoError(t, err)
+					assert.Equal(t, seriesSets[0], srv.SeriesSet)
+					require.Len(t, srv.Warnings, 1)
+					assert.Contains(t, srv.Warnings[0].Error(), "failed to fetch series for block "+blockIDs[1].String())
+
+					// The failed block is reported as queried, so that the querier doesn't retry it.
+					assert.ElementsMatch(t, []hintspb.Block{{Id: blockIDs[0].String()}, {Id: blockIDs[1].String()}}, srv.Hints.QueriedBlocks)
+
+					assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+						# HELP cortex_bucket_store_series_blocks_failed_total Total number of blocks whose series couldn't be fetched by Series() calls returning partial results.
+						# TYPE cortex_bucket_store_series_blocks_failed_total counter
+						cortex_bucket_store_series_blocks_failed_total 1
+					`), "cortex_bucket_store_series_blocks_failed_total"))
+				})
+			}
+		})
+	}
+}
+
+func TestBucketStore_PostingsCache(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bktDir := filepath.Join(tmpDir, "bkt")
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, bkt.Close()) })
+
+	var (
+		logger   = log.NewNopLogger()
+		instrBkt = objstore.WithNoopInstr(bkt)
+	)
+
+	head, seriesSet := createHeadWithSeries(t, 0, headGenOptions{
+		TSDBDir:          filepath.Join(tmpDir, "head"),
+		SamplesPerSeries: 1,
+		Series:           2,
+		Random:           rand.New(rand.NewSource(120)),
+	})
+	blockID := createBlockFromHead(t, bktDir, head)
+	require.NoError(t, head.Close())
+
+	fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, tmpDir, nil, nil)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	cache, err := postingscache.NewCache(1024*1024, 1024, time.Hour, logger, reg)
+	require.NoError(t, err)
+
+	deletionMarks := map[ulid.ULID]*metadata.DeletionMark{}
+	store, err := NewBucketStore(
+		"tenant",
+		instrBkt,
+		fetcher,
+		tmpDir,
+		NewChunksLimiterFactory(0),
+		NewSeriesLimiterFactory(0),
+		newGapBasedPartitioner(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+		10,
+		mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+		indexheader.Config{},
+		false,
+		0,
+		0,
+		hashcache.NewSeriesHashCache(1024*1024),
+		NewBucketStoreMetrics(nil),
+		WithLogger(logger),
+		WithPostingsCache(cache, func() map[ulid.ULID]*metadata.DeletionMark { return deletionMarks }),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, store.RemoveBlocksAndClose()) })
+	require.NoError(t, store.SyncBlocks(context.Background()))
+
+	querySeries := func() {
+		t.Helper()
+		srv := newBucketStoreSeriesServer(context.Background())
+		require.NoError(t, store.Series(&storepb.SeriesRequest{
+			MinTime:  0,
+			MaxTime:  3,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "foo", Value: "bar"}},
+		}, srv))
+		assert.Equal(t, seriesSet, srv.SeriesSet)
+	}
+
+	key := indexcache.CanonicalLabelMatchersKey([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")})
+
+	// The first request fills the cache.
+	querySeries()
+	refs, ok := cache.Fetch("tenant", blockID, key)
+	require.True(t, ok)
+	assert.Len(t, refs, len(seriesSet))
+
+	// Once the block is marked for deletion, its postings are removed from the cache and not cached anymore.
+	deletionMarks[blockID] = &metadata.DeletionMark{ID: blockID}
+	require.NoError(t, store.SyncBlocks(context.Background()))
+	_, ok = cache.Fetch("tenant", blockID, key)
+	require.False(t, ok)
+
+	querySeries()
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_postings_cache_items Current number of items in the postings cache.
+		# TYPE cortex_bucket_store_postings_cache_items gauge
+		cortex_bucket_store_postings_cache_items 0
+
+		# HELP cortex_bucket_store_postings_cache_blocks_invalidated_total Total number of blocks whose entries have been removed from the postings cache because the block has been marked for deletion or dropped.
+		# TYPE cortex_bucket_store_postings_cache_blocks_invalidated_total counter
+		cortex_bucket_store_postings_cache_blocks_invalidated_total 1
+	`), "cortex_bucket_store_postings_cache_items", "cortex_bucket_store_postings_cache_blocks_invalidated_total"))
+}
+
 func TestSeries_BlockWithMultipleChunks(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -1684,6 +2126,7 @@ func TestSeries_BlockWithMultipleChunks(t *testing.T) {
 		indexheader.Config{},
 		false,
 		0,
+		0,
 		hashcache.NewSeriesHashCache(1024*1024),
 		NewBucketStoreMetrics(nil),
 		WithLogger(logger),
@@ -1850,6 +2293,7 @@ func setupStoreForHintsTest(t *testing.T, opts ...BucketStoreOption) (test.TB, *
 		indexheader.Config{},
 		false,
 		0,
+		0,
 		hashcache.NewSeriesHashCache(1024*1024),
 		NewBucketStoreMetrics(nil),
 		opts...,
@@ -2061,7 +2505,7 @@ func BenchmarkBucketBlock_readChunkRange(b *testing.B) {
 	assert.NoError(b, err)
 
 	// Create a bucket block with only the dependencies we need for the benchmark.
-	blk, err := newBucketBlock(context.Background(), "tenant", logger, NewBucketStoreMetrics(nil), blockMeta, bkt, tmpDir, nil, chunkPool, nil, nil)
+	blk, err := newBucketBlock(context.Background(), "tenant", logger, NewBucketStoreMetrics(nil), blockMeta, bkt, tmpDir, nil, nil, chunkPool, nil, nil)
 	assert.NoError(b, err)
 
 	b.ResetTimer()
@@ -2141,7 +2585,7 @@ func prepareBucket(b *testing.B) (*bucketBlock, *metadata.Meta) {
 	assert.NoError(b, err)
 
 	// Create a bucket block with only the dependencies we need for the benchmark.
-	blk, err := newBucketBlock(context.Background(), "tenant", logger, NewBucketStoreMetrics(nil), blockMeta, bkt, tmpDir, indexCache, chunkPool, indexHeaderReader, partitioner)
+	blk, err := newBucketBlock(context.Background(), "tenant", logger, NewBucketStoreMetrics(nil), blockMeta, bkt, tmpDir, indexCache, chunkscache.NoopCache{}, chunkPool, indexHeaderReader, partitioner)
 	assert.NoError(b, err)
 	return blk, blockMeta
 }
@@ -2203,10 +2647,10 @@ func benchmarkBlockSeriesWithConcurrency(b *testing.B, concurrency int, blockMet
 				require.NoError(b, err)
 
 				indexReader := blk.indexReader()
-				chunkReader := blk.chunkReader(ctx)
+				chunkReader := blk.chunkReader(chunksFetchOptions{})
 				chunksPool := &pool.BatchBytes{Delegate: pool.NoopBytes{}}
 
-				seriesSet, _, err := blockSeries(context.Background(), indexReader, chunkReader, chunksPool, matchers, shardSelector, cachedSeriesHasher{seriesHashCache}, chunksLimiter, seriesLimiter, req.SkipChunks, req.MinTime, req.MaxTime, log.NewNopLogger())
+				seriesSet, _, err := blockSeries(ctx, indexReader, chunkReader, chunksPool, matchers, shardSelector, cachedSeriesHasher{seriesHashCache}, chunksLimiter, seriesLimiter, req.SkipChunks, req.MinTime, req.MaxTime, log.NewNopLogger())
 				require.NoError(b, err)
 
 				// Ensure at least 1 series has been returned (as expected).
@@ -2535,7 +2979,23 @@ func runTestServerSeries(t test.TB, store *BucketStore, cases ...*seriesCase) {
 						assert.Equal(t, c.ExpectedSeries, srv.SeriesSet)
 					}
 
-					assert.Equal(t, c.ExpectedHints, srv.Hints)
+					// The query stats depend on the timing and the chunks encoding, so we just check
+					// they're consistent with the returned series.
+					actualHints := srv.Hints
+					actualStats := actualHints.QueryStats
+					actualHints.QueryStats = nil
+					assert.Equal(t, c.ExpectedHints, actualHints)
+
+					if c.Req.SkipChunks {
+						assert.Nil(t, actualStats)
+					} else if assert.NotNil(t, actualStats) {
+						expectedChunks := 0
+						for _, s := range srv.SeriesSet {
+							expectedChunks += len(s.Chunks)
+						}
+						assert.Equal(t, uint64(expectedChunks), actualStats.ChunksTouched)
+						assert.Equal(t, actualStats.ChunksTouched, actualStats.ChunksFetched+actualStats.ChunksCacheHits)
+					}
 				}
 			}
 		})
@@ -2655,3 +3115,86 @@ func BenchmarkFilterPostingsByCachedShardHash_NoPostingsShifted(b *testing.B) {
 		filterPostingsByCachedShardHash(ps, shard, cachedSeriesHasher{cache}, nil)
 	}
 }
+
+func TestBucketStore_SeriesPerBatch(t *testing.T) {
+	tests := map[string]struct {
+		opts     []BucketStoreOption
+		expected int
+	}{
+		"streaming disabled": {
+			expected: 0,
+		},
+		"streaming enabled": {
+			opts:     []BucketStoreOption{WithStreamingSeriesPerBatch(1000)},
+			expected: 1000,
+		},
+		"streaming enabled with override": {
+			opts:     []BucketStoreOption{WithStreamingSeriesPerBatch(1000), WithStreamingSeriesPerBatchOverride(func() int { return 100 })},
+			expected: 100,
+		},
+		"streaming enabled with zero override": {
+			opts:     []BucketStoreOption{WithStreamingSeriesPerBatch(1000), WithStreamingSeriesPerBatchOverride(func() int { return 0 })},
+			expected: 1000,
+		},
+		"streaming disabled with override": {
+			opts:     []BucketStoreOption{WithStreamingSeriesPerBatchOverride(func() int { return 100 })},
+			expected: 100,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			s := &BucketStore{}
+			for _, opt := range testData.opts {
+				opt(s)
+			}
+			assert.Equal(t, testData.expected, s.seriesPerBatch())
+		})
+	}
+}
+
+func TestSplitParts(t *testing.T) {
+	// Chunks are 1000 bytes each, except the last one which is followed by a gap.
+	pIdxs := []loadIdx{{offset: 0}, {offset: 1000}, {offset: 2000}, {offset: 3000}, {offset: 30000}}
+	parts := []Part{
+		{Start: 0, End: 30000 + mimir_tsdb.EstimatedMaxChunkSize, ElemRng: [2]int{0, 5}},
+	}
+
+	tests := map[string]struct {
+		maxBytes uint64
+		expected []Part
+	}{
+		"should not split if disabled": {
+			maxBytes: 0,
+			expected: parts,
+		},
+		"should not split parts smaller than the max size": {
+			maxBytes: 100000,
+			expected: parts,
+		},
+		"should split parts at chunks boundaries": {
+			maxBytes: 2000,
+			expected: []Part{
+				{Start: 0, End: 2000, ElemRng: [2]int{0, 2}},
+				{Start: 2000, End: 3000 + mimir_tsdb.EstimatedMaxChunkSize, ElemRng: [2]int{2, 4}},
+				{Start: 30000, End: 30000 + mimir_tsdb.EstimatedMaxChunkSize, ElemRng: [2]int{4, 5}},
+			},
+		},
+		"should split parts in single chunks if the max size is smaller than chunks": {
+			maxBytes: 10,
+			expected: []Part{
+				{Start: 0, End: 1000, ElemRng: [2]int{0, 1}},
+				{Start: 1000, End: 2000, ElemRng: [2]int{1, 2}},
+				{Start: 2000, End: 3000, ElemRng: [2]int{2, 3}},
+				{Start: 3000, End: 3000 + mimir_tsdb.EstimatedMaxChunkSize, ElemRng: [2]int{3, 4}},
+				{Start: 30000, End: 30000 + mimir_tsdb.EstimatedMaxChunkSize, ElemRng: [2]int{4, 5}},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, splitParts(parts, pIdxs, testData.maxBytes))
+		})
+	}
+}
@@ -26,7 +26,7 @@ func newChunkBytesPool(minBucketSize, maxBucketSize int, maxChunkPoolBytes uint6
 		return nil, err
 	}
 
-	return &chunkBytesPool{
+	p := &chunkBytesPool{
 		pool: upstream,
 		requestedBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_bucket_store_chunk_pool_requested_bytes_total",
@@ -36,7 +36,16 @@ func newChunkBytesPool(minBucketSize, maxBucketSize int, maxChunkPoolBytes uint6
 			Name: "cortex_bucket_store_chunk_pool_returned_bytes_total",
 			Help: "Total bytes returned by the chunk bytes pool.",
 		}),
-	}, nil
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_chunk_pool_used_bytes",
+		Help: "Bytes obtained from the chunk bytes pool and not returned yet.",
+	}, func() float64 {
+		return float64(p.usedBytes())
+	})
+
+	return p, nil
 }
 
 func (p *chunkBytesPool) Get(sz int) (*[]byte, error) {
@@ -54,3 +63,8 @@ func (p *chunkBytesPool) Get(sz int) (*[]byte, error) {
 func (p *chunkBytesPool) Put(b *[]byte) {
 	p.pool.Put(b)
 }
+
+// usedBytes returns the bytes held by the in-flight requests, across all tenants.
+func (p *chunkBytesPool) usedBytes() uint64 {
+	return p.pool.UsedBytes()
+}
@@ -23,12 +23,14 @@ func TestChunkBytesPool_Get(t *testing.T) {
 	p, err := newChunkBytesPool(mimir_tsdb.ChunkPoolDefaultMinBucketSize, mimir_tsdb.ChunkPoolDefaultMaxBucketSize, 0, reg)
 	require.NoError(t, err)
 
-	_, err = p.Get(mimir_tsdb.EstimatedMaxChunkSize - 1)
+	b1, err := p.Get(mimir_tsdb.EstimatedMaxChunkSize - 1)
 	require.NoError(t, err)
 
 	_, err = p.Get(mimir_tsdb.EstimatedMaxChunkSize + 1)
 	require.NoError(t, err)
 
+	p.Put(b1)
+
 	assert.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(fmt.Sprintf(`
 		# HELP cortex_bucket_store_chunk_pool_requested_bytes_total Total bytes requested to chunk bytes pool.
 		# TYPE cortex_bucket_store_chunk_pool_requested_bytes_total counter
@@ -37,5 +39,9 @@ func TestChunkBytesPool_Get(t *testing.T) {
 		# HELP cortex_bucket_store_chunk_pool_returned_bytes_total Total bytes returned by the chunk bytes pool.
 		# TYPE cortex_bucket_store_chunk_pool_returned_bytes_total counter
 		cortex_bucket_store_chunk_pool_returned_bytes_total %d
-	`, mimir_tsdb.EstimatedMaxChunkSize*2, mimir_tsdb.EstimatedMaxChunkSize*3))))
+
+		# HELP cortex_bucket_store_chunk_pool_used_bytes Bytes obtained from the chunk bytes pool and not returned yet.
+		# TYPE cortex_bucket_store_chunk_pool_used_bytes gauge
+		cortex_bucket_store_chunk_pool_used_bytes %d
+	`, mimir_tsdb.EstimatedMaxChunkSize*2, mimir_tsdb.EstimatedMaxChunkSize*3, mimir_tsdb.EstimatedMaxChunkSize*2))))
 }
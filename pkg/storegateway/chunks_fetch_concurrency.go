@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/atomic"
+)
+
+// chunksFetchLoad keeps track of the requests issued to the bucket to fetch chunks by all the requests
+// served by the store-gateway, and computes the concurrency of each call to bucketChunkReader.load()
+// when the adaptive chunks fetch concurrency is enabled.
+type chunksFetchLoad struct {
+	// maxInflight is the number of in-flight requests over which the concurrency of each call is reduced,
+	// so that the requests share the remaining ones. 0 means the load is not taken into account.
+	maxInflight int
+	inflight    atomic.Int64
+
+	concurrency prometheus.Histogram
+}
+
+func newChunksFetchLoad(maxInflight int, reg prometheus.Registerer) *chunksFetchLoad {
+	l := &chunksFetchLoad{maxInflight: maxInflight}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_bucket_stores_chunks_fetch_inflight_requests",
+		Help: "Number of in-flight requests issued to the bucket to fetch chunks.",
+	}, func() float64 {
+		return float64(l.inflight.Load())
+	})
+	l.concurrency = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+		Name:    "cortex_bucket_stores_chunks_fetch_concurrency",
+		Help:    "Max number of concurrent requests issued to the bucket for each block to fetch the chunks of a series batch, or of the whole request if series streaming is disabled, computed by the adaptive chunks fetch concurrency.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 8),
+	})
+	return l
+}
+
+// concurrencyFor returns the max number of concurrent requests to fetch the ranges of chunks of a block.
+// The maxConcurrency of the whole request is shared between its blocks, and reduced to the requests still
+// available once the store-gateway is loaded. Each block is allowed at least one request, and never more
+// requests than ranges to fetch, so that small requests fetch all their ranges concurrently.
+func (l *chunksFetchLoad) concurrencyFor(ranges, blocks, maxConcurrency int) int {
+	budget := maxConcurrency
+	if l.maxInflight > 0 {
+		if available := l.maxInflight - int(l.inflight.Load()); available < budget {
+			budget = available
+		}
+	}
+
+	concurrency := budget
+	if blocks > 1 {
+		concurrency = budget / blocks
+	}
+	if concurrency > ranges {
+		concurrency = ranges
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	l.concurrency.Observe(float64(concurrency))
+	return concurrency
+}
+
+func (l *chunksFetchLoad) start() {
+	l.inflight.Inc()
+}
+
+func (l *chunksFetchLoad) done() {
+	l.inflight.Dec()
+}
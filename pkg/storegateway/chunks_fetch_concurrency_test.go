@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunksFetchLoad_ConcurrencyFor(t *testing.T) {
+	for name, tc := range map[string]struct {
+		maxInflight    int
+		inflight       int
+		ranges         int
+		blocks         int
+		maxConcurrency int
+		expected       int
+	}{
+		"small request fetches all its ranges concurrently": {ranges: 3, blocks: 1, maxConcurrency: 32, expected: 3},
+		"large request is limited to the max concurrency":   {ranges: 100, blocks: 1, maxConcurrency: 32, expected: 32},
+		"max concurrency is shared between the blocks":      {ranges: 100, blocks: 4, maxConcurrency: 32, expected: 8},
+		"each block is allowed at least one request":        {ranges: 100, blocks: 64, maxConcurrency: 32, expected: 1},
+		"store-gateway not loaded":                          {maxInflight: 100, inflight: 10, ranges: 100, blocks: 1, maxConcurrency: 32, expected: 32},
+		"store-gateway loaded":                              {maxInflight: 100, inflight: 84, ranges: 100, blocks: 2, maxConcurrency: 32, expected: 8},
+		"store-gateway overloaded":                          {maxInflight: 100, inflight: 120, ranges: 100, blocks: 1, maxConcurrency: 32, expected: 1},
+		"store-gateway load not taken into account":         {inflight: 120, ranges: 100, blocks: 1, maxConcurrency: 32, expected: 32},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l := newChunksFetchLoad(tc.maxInflight, nil)
+			l.inflight.Store(int64(tc.inflight))
+
+			assert.Equal(t, tc.expected, l.concurrencyFor(tc.ranges, tc.blocks, tc.maxConcurrency))
+		})
+	}
+}
+
+func TestChunksFetchLoad_Inflight(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	l := newChunksFetchLoad(10, reg)
+
+	l.start()
+	l.start()
+	l.done()
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_stores_chunks_fetch_inflight_requests Number of in-flight requests issued to the bucket to fetch chunks.
+		# TYPE cortex_bucket_stores_chunks_fetch_inflight_requests gauge
+		cortex_bucket_stores_chunks_fetch_inflight_requests 1
+	`), "cortex_bucket_stores_chunks_fetch_inflight_requests"))
+	assert.Equal(t, 9, l.concurrencyFor(100, 1, 32))
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/runutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+)
+
+const (
+	// The number of latencies of the last requests used to compute the hedging delay.
+	chunksFetchHedgingWindowSize = 1000
+
+	// The hedging delay is recomputed every chunksFetchHedgingRecomputeInterval requests. The min delay is used
+	// until the first recomputation.
+	chunksFetchHedgingRecomputeInterval = 100
+)
+
+// chunksFetchHedging hedges the requests issued to the bucket to fetch the chunks by all the requests served by the
+// store-gateway: when a request hasn't returned within the hedging delay, a second identical request is issued, and
+// the response of the first one returning successfully is used. The hedging delay is the configured quantile of the
+// latency of the last requests, and never lower than the configured min delay.
+type chunksFetchHedging struct {
+	quantile float64
+	minDelay time.Duration
+
+	latenciesMx sync.Mutex
+	latencies   []time.Duration // Circular buffer of the last latencies.
+	observed    int
+
+	// quantileDelay is the quantile of the latencies, as of the last recomputation.
+	quantileDelay atomic.Duration
+
+	hedged    prometheus.Counter
+	cancelled prometheus.Counter
+}
+
+func newChunksFetchHedging(quantile float64, minDelay time.Duration, reg prometheus.Registerer) *chunksFetchHedging {
+	return &chunksFetchHedging{
+		quantile:  quantile,
+		minDelay:  minDelay,
+		latencies: make([]time.Duration, 0, chunksFetchHedgingWindowSize),
+
+		hedged: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_chunks_fetch_hedged_requests_total",
+			Help: "Total number of hedged requests issued to the bucket to fetch chunks because the original request didn't return within the hedging delay.",
+		}),
+		cancelled: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_chunks_fetch_hedged_requests_cancelled_total",
+			Help: "Total number of requests issued to the bucket to fetch chunks which have been cancelled because another request for the same range returned first.",
+		}),
+	}
+}
+
+// delay returns the time after which a request is hedged.
+func (h *chunksFetchHedging) delay() time.Duration {
+	if d := h.quantileDelay.Load(); d > h.minDelay {
+		return d
+	}
+	return h.minDelay
+}
+
+// observe records the latency of a request and periodically recomputes the hedging delay.
+func (h *chunksFetchHedging) observe(latency time.Duration) {
+	h.latenciesMx.Lock()
+	defer h.latenciesMx.Unlock()
+
+	if len(h.latencies) < chunksFetchHedgingWindowSize {
+		h.latencies = append(h.latencies, latency)
+	} else {
+		h.latencies[h.observed%chunksFetchHedgingWindowSize] = latency
+	}
+	h.observed++
+
+	if h.observed%chunksFetchHedgingRecomputeInterval != 0 {
+		return
+	}
+
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	h.quantileDelay.Store(sorted[int(h.quantile*float64(len(sorted)-1))])
+}
+
+// getRange issues a GetRange request to the bucket, hedging it if it doesn't return within the hedging delay.
+// The context of the request used is canceled when the returned reader is closed.
+func (h *chunksFetchHedging) getRange(ctx context.Context, bkt objstore.BucketReader, name string, off, length int64, logger log.Logger) (io.ReadCloser, error) {
+	type response struct {
+		idx     int
+		reader  io.ReadCloser
+		err     error
+		latency time.Duration
+	}
+
+	var (
+		responses = make(chan response, 2)
+		cancels   []context.CancelFunc
+		inflight  int
+	)
+
+	issue := func() {
+		reqCtx, cancel := context.WithCancel(ctx)
+		idx := len(cancels)
+		cancels = append(cancels, cancel)
+		inflight++
+
+		go func() {
+			start := time.Now()
+			reader, err := bkt.GetRange(reqCtx, name, off, length)
+			responses <- response{idx: idx, reader: reader, err: err, latency: time.Since(start)}
+		}()
+	}
+
+	issue()
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	var res response
+	for {
+		select {
+		case <-timer.C:
+			h.hedged.Inc()
+			issue()
+			continue
+		case res = <-responses:
+			inflight--
+		}
+
+		// If a request fails, wait for the other one, if any, unless the context has been canceled.
+		if res.err != nil && inflight > 0 && ctx.Err() == nil {
+			continue
+		}
+		break
+	}
+
+	// Cancel the other requests, closing their readers once they return.
+	for idx, cancel := range cancels {
+		if idx != res.idx {
+			cancel()
+		}
+	}
+	if inflight > 0 {
+		h.cancelled.Add(float64(inflight))
+		go func(inflight int) {
+			for ; inflight > 0; inflight-- {
+				if other := <-responses; other.err == nil {
+					runutil.CloseWithLogOnErr(logger, other.reader, "close cancelled hedged range reader")
+				}
+			}
+		}(inflight)
+	}
+
+	if res.err != nil {
+		cancels[res.idx]()
+		return nil, res.err
+	}
+
+	h.observe(res.latency)
+	return &cancelOnCloseReader{ReadCloser: res.reader, cancel: cancels[res.idx]}, nil
+}
+
+// cancelOnCloseReader cancels the context of the request it reads from once it's closed.
+type cancelOnCloseReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnCloseReader) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
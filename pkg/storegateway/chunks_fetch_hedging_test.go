@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+)
+
+// slowGetRangeBucket delays the GetRange calls, in order, by the configured delays, failing those with an error.
+type slowGetRangeBucket struct {
+	objstore.Bucket
+
+	calls     atomic.Int64
+	cancelled atomic.Int64
+	delays    []time.Duration
+	errs      []error
+}
+
+func (b *slowGetRangeBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	call := int(b.calls.Inc()) - 1
+
+	select {
+	case <-time.After(b.delays[call]):
+	case <-ctx.Done():
+		b.cancelled.Inc()
+		return nil, ctx.Err()
+	}
+
+	if b.errs != nil && b.errs[call] != nil {
+		return nil, b.errs[call]
+	}
+	return b.Bucket.GetRange(ctx, name, off, length)
+}
+
+func TestChunksFetchHedging_GetRange(t *testing.T) {
+	const minDelay = 50 * time.Millisecond
+
+	tests := map[string]struct {
+		delays            []time.Duration
+		errs              []error
+		expectedErr       bool
+		expectedCalls     int
+		expectedHedged    int
+		expectedCancelled int
+	}{
+		"request returning within the hedging delay": {
+			delays:        []time.Duration{0},
+			expectedCalls: 1,
+		},
+		"request failing within the hedging delay": {
+			delays:        []time.Duration{0},
+			errs:          []error{errors.New("failed")},
+			expectedErr:   true,
+			expectedCalls: 1,
+		},
+		"request not returning within the hedging delay": {
+			delays:            []time.Duration{time.Minute, 0},
+			expectedCalls:     2,
+			expectedHedged:    1,
+			expectedCancelled: 1,
+		},
+		"request returning before the hedged request": {
+			delays:            []time.Duration{2 * minDelay, time.Minute},
+			expectedCalls:     2,
+			expectedHedged:    1,
+			expectedCancelled: 1,
+		},
+		"request failing after the hedged request has been issued": {
+			delays:         []time.Duration{2 * minDelay, 4 * minDelay},
+			errs:           []error{errors.New("failed"), nil},
+			expectedCalls:  2,
+			expectedHedged: 1,
+		},
+		"both requests failing": {
+			delays:         []time.Duration{2 * minDelay, 4 * minDelay},
+			errs:           []error{errors.New("failed"), errors.New("failed")},
+			expectedErr:    true,
+			expectedCalls:  2,
+			expectedHedged: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			inmem := objstore.NewInMemBucket()
+			require.NoError(t, inmem.Upload(context.Background(), "chunks", bytes.NewReader([]byte("0123456789"))))
+			bkt := &slowGetRangeBucket{Bucket: inmem, delays: testData.delays, errs: testData.errs}
+
+			h := newChunksFetchHedging(0.9, minDelay, prometheus.NewPedanticRegistry())
+			reader, err := h.getRange(context.Background(), bkt, "chunks", 2, 5, log.NewNopLogger())
+			if testData.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				data, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				assert.Equal(t, "23456", string(data))
+				require.NoError(t, reader.Close())
+			}
+
+			assert.Equal(t, int64(testData.expectedCalls), bkt.calls.Load())
+			assert.Equal(t, float64(testData.expectedHedged), testutil.ToFloat64(h.hedged))
+			assert.Equal(t, float64(testData.expectedCancelled), testutil.ToFloat64(h.cancelled))
+
+			// The cancelled requests are actually cancelled.
+			test.Poll(t, time.Second, int64(testData.expectedCancelled), func() interface{} {
+				return bkt.cancelled.Load()
+			})
+		})
+	}
+}
+
+func TestChunksFetchHedging_Delay(t *testing.T) {
+	h := newChunksFetchHedging(0.9, 10*time.Millisecond, nil)
+
+	// The min delay is used until enough latencies have been observed.
+	for i := 1; i < chunksFetchHedgingRecomputeInterval; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 10*time.Millisecond, h.delay())
+
+	h.observe(100 * time.Millisecond)
+	assert.Equal(t, 90*time.Millisecond, h.delay())
+
+	// Only the last latencies are taken into account.
+	for i := 0; i < chunksFetchHedgingWindowSize; i++ {
+		h.observe(20 * time.Millisecond)
+	}
+	assert.Equal(t, 20*time.Millisecond, h.delay())
+
+	// The delay is never lower than the min delay.
+	for i := 0; i < chunksFetchHedgingWindowSize; i++ {
+		h.observe(time.Millisecond)
+	}
+	assert.Equal(t, 10*time.Millisecond, h.delay())
+}
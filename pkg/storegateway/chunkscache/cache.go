@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package chunkscache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+)
+
+// Key identifies a chunk in a block. The chunk ref encodes both the segment file and the
+// offset of the chunk in the segment file, so the key identifies the byte range of the chunk.
+type Key struct {
+	BlockID ulid.ULID
+	Ref     chunks.ChunkRef
+}
+
+// Cache is the interface exported by chunks cache backends. The cached values are the raw
+// chunks, made of the chunk encoding followed by the chunk data.
+type Cache interface {
+	// FetchMultiChunks fetches multiple chunks and returns a map containing the cache hits.
+	FetchMultiChunks(ctx context.Context, userID string, keys []Key) map[Key][]byte
+
+	// StoreChunks stores the input chunks. The function enqueues the request and returns
+	// immediately: the chunks will be asynchronously stored in the cache, so the input
+	// byte slices must not be modified after this call.
+	StoreChunks(ctx context.Context, userID string, chunks map[Key][]byte)
+}
+
+// NoopCache is a Cache which never returns any chunk and doesn't store them.
+type NoopCache struct{}
+
+func (NoopCache) FetchMultiChunks(context.Context, string, []Key) map[Key][]byte {
+	return nil
+}
+
+func (NoopCache) StoreChunks(context.Context, string, map[Key][]byte) {}
+
+// ChunksCache is a Cache based on a remote cache backend.
+type ChunksCache struct {
+	logger log.Logger
+	cache  cache.Cache
+	ttl    time.Duration
+
+	// Metrics.
+	requests prometheus.Counter
+	hits     prometheus.Counter
+}
+
+// NewChunksCache makes a new ChunksCache storing the chunks in the input cache for the input TTL.
+func NewChunksCache(logger log.Logger, client cache.Cache, ttl time.Duration, reg prometheus.Registerer) *ChunksCache {
+	c := &ChunksCache{
+		logger: logger,
+		cache:  client,
+		ttl:    ttl,
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_chunks_cache_requests_total",
+			Help: "Total number of chunks requested to the chunks cache.",
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_chunks_cache_hits_total",
+			Help: "Total number of chunks requested to the chunks cache that were a hit.",
+		}),
+	}
+
+	level.Info(logger).Log("msg", "created chunks cache")
+
+	return c
+}
+
+// FetchMultiChunks implements Cache.
+func (c *ChunksCache) FetchMultiChunks(ctx context.Context, userID string, keys []Key) map[Key][]byte {
+	cacheKeys := make([]string, 0, len(keys))
+	for _, k := range keys {
+		cacheKeys = append(cacheKeys, chunksCacheKey(userID, k))
+	}
+
+	c.requests.Add(float64(len(keys)))
+	results := c.cache.Fetch(ctx, cacheKeys)
+	if len(results) == 0 {
+		return nil
+	}
+
+	hits := make(map[Key][]byte, len(results))
+	for i, k := range keys {
+		if value, ok := results[cacheKeys[i]]; ok {
+			hits[k] = value
+		}
+	}
+
+	c.hits.Add(float64(len(hits)))
+	return hits
+}
+
+// StoreChunks implements Cache.
+func (c *ChunksCache) StoreChunks(ctx context.Context, userID string, chunks map[Key][]byte) {
+	data := make(map[string][]byte, len(chunks))
+	for k, v := range chunks {
+		data[chunksCacheKey(userID, k)] = v
+	}
+	c.cache.Store(ctx, data, c.ttl)
+}
+
+func chunksCacheKey(userID string, k Key) string {
+	return "C:" + userID + ":" + k.BlockID.String() + ":" + strconv.FormatUint(uint64(k.Ref), 10)
+}
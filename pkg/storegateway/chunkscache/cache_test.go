@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package chunkscache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunksCache(t *testing.T) {
+	const userID = "user-1"
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	key1 := Key{BlockID: block1, Ref: 10}
+	key2 := Key{BlockID: block1, Ref: 20}
+	key3 := Key{BlockID: block2, Ref: 10}
+
+	backend := cache.NewMockCache()
+	reg := prometheus.NewPedanticRegistry()
+	c := NewChunksCache(log.NewNopLogger(), backend, time.Hour, reg)
+
+	ctx := context.Background()
+	assert.Empty(t, c.FetchMultiChunks(ctx, userID, []Key{key1, key2, key3}))
+
+	c.StoreChunks(ctx, userID, map[Key][]byte{key1: []byte("chunk-1"), key3: []byte("chunk-3")})
+	assert.Equal(t, map[Key][]byte{key1: []byte("chunk-1"), key3: []byte("chunk-3")}, c.FetchMultiChunks(ctx, userID, []Key{key1, key2, key3}))
+
+	// The chunks are cached per tenant.
+	assert.Empty(t, c.FetchMultiChunks(ctx, "user-2", []Key{key1, key2, key3}))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_chunks_cache_hits_total Total number of chunks requested to the chunks cache that were a hit.
+		# TYPE cortex_bucket_store_chunks_cache_hits_total counter
+		cortex_bucket_store_chunks_cache_hits_total 2
+		# HELP cortex_bucket_store_chunks_cache_requests_total Total number of chunks requested to the chunks cache.
+		# TYPE cortex_bucket_store_chunks_cache_requests_total counter
+		cortex_bucket_store_chunks_cache_requests_total 9
+	`)))
+}
+
+func TestChunksCacheKey(t *testing.T) {
+	block := ulid.MustNew(1, nil)
+	assert.Equal(t, "C:user-1:"+block.String()+":4294967306", chunksCacheKey("user-1", Key{BlockID: block, Ref: 1<<32 | 10}))
+}
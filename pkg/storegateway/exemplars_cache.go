@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+type exemplarsCacheKey struct {
+	userID  string
+	blockID ulid.ULID
+}
+
+type exemplarsCacheEntry struct {
+	series []mimirpb.TimeSeries
+	size   uint64
+}
+
+// inflightExemplarsLoad is the loading of the exemplars of a block, shared by the concurrent requests.
+type inflightExemplarsLoad struct {
+	done chan struct{}
+
+	// series and err can be read only once done is closed.
+	series []mimirpb.TimeSeries
+	err    error
+}
+
+// exemplarsCache is an in-memory LRU cache of the exemplars decoded from the exemplars files of the blocks,
+// holding up to maxSizeBytes of exemplars. The exemplars of a block are loaded once even if requested
+// concurrently, and are removed from the cache once the block is dropped.
+type exemplarsCache struct {
+	logger       log.Logger
+	maxSizeBytes uint64
+
+	mtx      sync.Mutex
+	lru      *lru.LRU
+	curSize  uint64
+	inflight map[exemplarsCacheKey]*inflightExemplarsLoad
+
+	items     prometheus.Gauge
+	sizeBytes prometheus.Gauge
+}
+
+func newExemplarsCache(maxSizeBytes uint64, logger log.Logger, reg prometheus.Registerer) (*exemplarsCache, error) {
+	c := &exemplarsCache{
+		logger:       logger,
+		maxSizeBytes: maxSizeBytes,
+		inflight:     map[exemplarsCacheKey]*inflightExemplarsLoad{},
+		items: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_exemplars_cache_items",
+			Help: "Current number of blocks whose exemplars are in the exemplars cache.",
+		}),
+		sizeBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_exemplars_cache_size_bytes",
+			Help: "Current byte size of the exemplars in the exemplars cache.",
+		}),
+	}
+
+	// The evictions are managed by the cache based on the stored size.
+	l, err := lru.NewLRU(math.MaxInt, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+
+	return c, nil
+}
+
+func (c *exemplarsCache) onEvict(_, val interface{}) {
+	size := val.(exemplarsCacheEntry).size
+
+	c.curSize -= size
+	c.items.Dec()
+	c.sizeBytes.Sub(float64(size))
+}
+
+// get returns the exemplars of the block, loading them with the input function if they're not cached. The load
+// is shared by the concurrent calls for the same block, and is run without holding the lock of the cache.
+// The returned series must not be modified.
+func (c *exemplarsCache) get(ctx context.Context, userID string, blockID ulid.ULID, load func(context.Context) ([]mimirpb.TimeSeries, error)) ([]mimirpb.TimeSeries, error) {
+	key := exemplarsCacheKey{userID: userID, blockID: blockID}
+
+	for {
+		c.mtx.Lock()
+		if v, ok := c.lru.Get(key); ok {
+			c.mtx.Unlock()
+			return v.(exemplarsCacheEntry).series, nil
+		}
+		inflight, found := c.inflight[key]
+		if !found {
+			inflight = &inflightExemplarsLoad{done: make(chan struct{})}
+			c.inflight[key] = inflight
+		}
+		c.mtx.Unlock()
+
+		if !found {
+			return c.load(ctx, key, inflight, load)
+		}
+
+		select {
+		case <-inflight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The load may have failed because the request which triggered it has been canceled, in which
+		// case the exemplars are loaded again.
+		if errors.Is(inflight.err, context.Canceled) || errors.Is(inflight.err, context.DeadlineExceeded) {
+			continue
+		}
+		return inflight.series, inflight.err
+	}
+}
+
+func (c *exemplarsCache) load(ctx context.Context, key exemplarsCacheKey, inflight *inflightExemplarsLoad, load func(context.Context) ([]mimirpb.TimeSeries, error)) ([]mimirpb.TimeSeries, error) {
+	defer close(inflight.done)
+
+	inflight.series, inflight.err = load(ctx)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.inflight, key)
+	if inflight.err == nil {
+		c.store(key, inflight.series)
+	}
+	return inflight.series, inflight.err
+}
+
+// store caches the exemplars of the block. The size of the exemplars is estimated as their protobuf size.
+// It must be called with the lock held.
+func (c *exemplarsCache) store(key exemplarsCacheKey, series []mimirpb.TimeSeries) {
+	var size uint64
+	for _, s := range series {
+		size += uint64(s.Size())
+	}
+	if size > c.maxSizeBytes {
+		return
+	}
+	for c.curSize+size > c.maxSizeBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			level.Error(c.logger).Log("msg", "LRU has nothing more to evict, but we still cannot allocate the item", "maxSizeBytes", c.maxSizeBytes, "curSize", c.curSize, "itemSize", size)
+			return
+		}
+	}
+
+	c.lru.Add(key, exemplarsCacheEntry{series: series, size: size})
+	c.curSize += size
+	c.items.Inc()
+	c.sizeBytes.Add(float64(size))
+}
+
+// invalidateBlock removes the cached exemplars of the block.
+func (c *exemplarsCache) invalidateBlock(userID string, blockID ulid.ULID) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.lru.Remove(exemplarsCacheKey{userID: userID, blockID: blockID})
+}
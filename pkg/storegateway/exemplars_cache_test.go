@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestExemplarsCache(t *testing.T) {
+	ctx := context.Background()
+	series := []mimirpb.TimeSeries{{
+		Labels:    []mimirpb.LabelAdapter{{Name: "a", Value: "1"}},
+		Exemplars: []mimirpb.Exemplar{{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "x"}}, Value: 1, TimestampMs: 10}},
+	}}
+	seriesSize := uint64(series[0].Size())
+
+	block1, block2, block3 := ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil)
+
+	// countingLoad returns a load function returning the input series, and the number of times it's called.
+	countingLoad := func(series []mimirpb.TimeSeries, err error) (func(context.Context) ([]mimirpb.TimeSeries, error), *atomic.Int32) {
+		calls := atomic.NewInt32(0)
+		return func(context.Context) ([]mimirpb.TimeSeries, error) {
+			calls.Inc()
+			return series, err
+		}, calls
+	}
+
+	t.Run("should load the exemplars of a block once, and evict the least recently used blocks once the max size is exceeded", func(t *testing.T) {
+		reg := prometheus.NewPedanticRegistry()
+		c, err := newExemplarsCache(2*seriesSize, log.NewNopLogger(), reg)
+		require.NoError(t, err)
+
+		load, calls := countingLoad(series, nil)
+		for _, id := range []ulid.ULID{block1, block2, block1} {
+			actual, err := c.get(ctx, "user-1", id, load)
+			require.NoError(t, err)
+			assert.Equal(t, series, actual)
+		}
+		assert.Equal(t, int32(2), calls.Load())
+
+		// The exemplars of block2 are evicted, being the least recently used.
+		_, err = c.get(ctx, "user-1", block3, load)
+		require.NoError(t, err)
+		_, err = c.get(ctx, "user-1", block1, load)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+		_, err = c.get(ctx, "user-1", block2, load)
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), calls.Load())
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_exemplars_cache_items Current number of blocks whose exemplars are in the exemplars cache.
+			# TYPE cortex_bucket_store_exemplars_cache_items gauge
+			cortex_bucket_store_exemplars_cache_items 2
+
+			# HELP cortex_bucket_store_exemplars_cache_size_bytes Current byte size of the exemplars in the exemplars cache.
+			# TYPE cortex_bucket_store_exemplars_cache_size_bytes gauge
+			cortex_bucket_store_exemplars_cache_size_bytes `+strconv.FormatUint(2*seriesSize, 10)+`
+		`)))
+	})
+
+	t.Run("should not cache the exemplars bigger than the max size", func(t *testing.T) {
+		c, err := newExemplarsCache(seriesSize-1, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+
+		load, calls := countingLoad(series, nil)
+		for i := 0; i < 2; i++ {
+			actual, err := c.get(ctx, "user-1", block1, load)
+			require.NoError(t, err)
+			assert.Equal(t, series, actual)
+		}
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, float64(0), testutil.ToFloat64(c.sizeBytes))
+	})
+
+	t.Run("should cache the lack of exemplars of a block", func(t *testing.T) {
+		c, err := newExemplarsCache(0, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+
+		load, calls := countingLoad(nil, nil)
+		for i := 0; i < 2; i++ {
+			actual, err := c.get(ctx, "user-1", block1, load)
+			require.NoError(t, err)
+			assert.Empty(t, actual)
+		}
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("should not cache the errors", func(t *testing.T) {
+		c, err := newExemplarsCache(1024, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+
+		load, calls := countingLoad(nil, errors.New("failed"))
+		for i := 0; i < 2; i++ {
+			_, err := c.get(ctx, "user-1", block1, load)
+			assert.EqualError(t, err, "failed")
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("should remove the exemplars of an invalidated block", func(t *testing.T) {
+		c, err := newExemplarsCache(1024, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+
+		load, calls := countingLoad(series, nil)
+		for _, userID := range []string{"user-1", "user-2"} {
+			_, err := c.get(ctx, userID, block1, load)
+			require.NoError(t, err)
+		}
+
+		c.invalidateBlock("user-1", block1)
+		assert.Equal(t, float64(1), testutil.ToFloat64(c.items))
+		assert.Equal(t, float64(seriesSize), testutil.ToFloat64(c.sizeBytes))
+
+		for _, userID := range []string{"user-1", "user-2"} {
+			_, err := c.get(ctx, userID, block1, load)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("should share the load of the exemplars of a block among the concurrent calls", func(t *testing.T) {
+		c, err := newExemplarsCache(1024, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+
+		var (
+			calls   = atomic.NewInt32(0)
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+		load := func(context.Context) ([]mimirpb.TimeSeries, error) {
+			if calls.Inc() == 1 {
+				close(started)
+			}
+			<-release
+			return series, nil
+		}
+
+		results := make(chan []mimirpb.TimeSeries, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				actual, err := c.get(ctx, "user-1", block1, load)
+				require.NoError(t, err)
+				results <- actual
+			}()
+		}
+		<-started
+		time.Sleep(50 * time.Millisecond)
+
+		close(release)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, series, <-results)
+		}
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("should load the exemplars again if the shared load has been canceled", func(t *testing.T) {
+		c, err := newExemplarsCache(1024, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+
+		var (
+			calls   = atomic.NewInt32(0)
+			started = make(chan struct{})
+		)
+		load := func(ctx context.Context) ([]mimirpb.TimeSeries, error) {
+			if calls.Inc() == 1 {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return series, nil
+		}
+
+		firstCtx, cancelFirst := context.WithCancel(ctx)
+		firstErr := make(chan error)
+		go func() {
+			_, err := c.get(firstCtx, "user-1", block1, load)
+			firstErr <- err
+		}()
+		<-started
+
+		secondResult := make(chan []mimirpb.TimeSeries)
+		go func() {
+			actual, err := c.get(ctx, "user-1", block1, load)
+			require.NoError(t, err)
+			secondResult <- actual
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		cancelFirst()
+		assert.ErrorIs(t, <-firstErr, context.Canceled)
+		assert.Equal(t, series, <-secondResult)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
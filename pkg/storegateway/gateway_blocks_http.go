@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/ring"
+	"github.com/oklog/ulid"
 	"github.com/prometheus/prometheus/model/labels"
 	prom_tsdb "github.com/prometheus/prometheus/tsdb"
 
@@ -46,6 +49,7 @@ type formattedBlockData struct {
 	CompactionLevel int
 	BlockSize       string
 	Labels          string
+	Owners          []string
 	Sources         []string
 	Parents         []string
 	Stats           prom_tsdb.BlockStats
@@ -53,8 +57,9 @@ type formattedBlockData struct {
 
 type richMeta struct {
 	*metadata.Meta
-	DeletedTime *int64  `json:"deletedTime,omitempty"`
-	SplitID     *uint32 `json:"splitId,omitempty"`
+	DeletedTime *int64   `json:"deletedTime,omitempty"`
+	SplitID     *uint32  `json:"splitId,omitempty"`
+	Owners      []string `json:"owners,omitempty"`
 }
 
 func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
@@ -90,6 +95,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 
 	formattedBlocks := make([]formattedBlockData, 0, len(metas))
 	richMetas := make([]richMeta, 0, len(metas))
+	blockOwners := s.blockOwnersFunc(tenantID)
 
 	for _, m := range metas {
 		if !showDeleted && !deletedTimes[m.ULID].IsZero() {
@@ -109,6 +115,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 			blockSplitID = &bsc
 		}
 		lbls := labels.FromMap(m.Thanos.Labels)
+		owners := blockOwners(m.ULID)
 		formattedBlocks = append(formattedBlocks, formattedBlockData{
 			ULID:            m.ULID.String(),
 			ULIDTime:        util.TimeFromMillis(int64(m.ULID.Time())).UTC().Format(time.RFC3339),
@@ -120,6 +127,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 			CompactionLevel: m.Compaction.Level,
 			BlockSize:       listblocks.GetFormattedBlockSize(m),
 			Labels:          lbls.String(),
+			Owners:          owners,
 			Sources:         sources,
 			Parents:         parents,
 			Stats:           m.Stats,
@@ -133,6 +141,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 			Meta:        m,
 			DeletedTime: deletedAt,
 			SplitID:     blockSplitID,
+			Owners:      owners,
 		})
 	}
 
@@ -149,6 +158,31 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 	}, blocksPageTemplate, req)
 }
 
+// blockOwnersFunc returns a function returning the addresses of the store-gateways owning a block of the tenant,
+// according to the ring. The returned function returns no owners if they can't be looked up.
+func (s *StoreGateway) blockOwnersFunc(tenantID string) func(ulid.ULID) []string {
+	if s.ring == nil {
+		return func(ulid.ULID) []string { return nil }
+	}
+
+	r := GetShuffleShardingSubring(s.ring, tenantID, s.stores.limits)
+	bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+
+	return func(blockID ulid.ULID) []string {
+		set, err := r.Get(tsdb.HashBlockID(blockID), BlocksOwnerSync, bufDescs, bufHosts, bufZones)
+		if err != nil {
+			return nil
+		}
+
+		owners := make([]string, 0, len(set.Instances))
+		for _, instance := range set.Instances {
+			owners = append(owners, instance.Addr)
+		}
+		sort.Strings(owners)
+		return owners
+	}
+}
+
 func formatTimeIfNotZero(t time.Time, format string) string {
 	if t.IsZero() {
 		return ""
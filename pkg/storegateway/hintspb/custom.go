@@ -13,6 +13,32 @@ func (m *SeriesResponseHints) AddQueriedBlock(id ulid.ULID) {
 	})
 }
 
+// Merge adds the statistics in o to m.
+func (m *QueryStats) Merge(o *QueryStats) {
+	if o == nil {
+		return
+	}
+	m.ChunksTouched += o.ChunksTouched
+	m.ChunksTouchedSizeBytes += o.ChunksTouchedSizeBytes
+	m.ChunksFetched += o.ChunksFetched
+	m.ChunksFetchedSizeBytes += o.ChunksFetchedSizeBytes
+	m.ChunksCacheRequests += o.ChunksCacheRequests
+	m.ChunksCacheHits += o.ChunksCacheHits
+	m.Blocks = append(m.Blocks, o.Blocks...)
+	m.SeriesLoadDuration += o.SeriesLoadDuration
+	m.ChunksLoadDuration += o.ChunksLoadDuration
+	m.ChunksPreloadedDuration += o.ChunksPreloadedDuration
+}
+
+// ChunksCacheHitRatio returns the ratio of the chunks looked up in the chunks cache which have been found,
+// or 0 if no chunk has been looked up.
+func (m *QueryStats) ChunksCacheHitRatio() float64 {
+	if m.ChunksCacheRequests == 0 {
+		return 0
+	}
+	return float64(m.ChunksCacheHits) / float64(m.ChunksCacheRequests)
+}
+
 func (m *LabelNamesResponseHints) AddQueriedBlock(id ulid.ULID) {
 	m.QueriedBlocks = append(m.QueriedBlocks, Block{
 		Id: id.String(),
@@ -24,3 +50,9 @@ func (m *LabelValuesResponseHints) AddQueriedBlock(id ulid.ULID) {
 		Id: id.String(),
 	})
 }
+
+func (m *ExemplarsResponseHints) AddQueriedBlock(id ulid.ULID) {
+	m.QueriedBlocks = append(m.QueriedBlocks, Block{
+		Id: id.String(),
+	})
+}
@@ -7,18 +7,22 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
+	_ "github.com/golang/protobuf/ptypes/duration"
 	storepb "github.com/grafana/mimir/pkg/storegateway/storepb"
 	io "io"
 	math "math"
 	math_bits "math/bits"
 	reflect "reflect"
 	strings "strings"
+	time "time"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -68,6 +72,8 @@ var xxx_messageInfo_SeriesRequestHints proto.InternalMessageInfo
 type SeriesResponseHints struct {
 	/// queried_blocks is the list of blocks that have been queried.
 	QueriedBlocks []Block `protobuf:"bytes,1,rep,name=queried_blocks,json=queriedBlocks,proto3" json:"queried_blocks"`
+	/// query_stats holds the statistics about the chunks loaded by the store-gateway to serve the request.
+	QueryStats *QueryStats `protobuf:"bytes,2,opt,name=query_stats,json=queryStats,proto3" json:"query_stats,omitempty"`
 }
 
 func (m *SeriesResponseHints) Reset()      { *m = SeriesResponseHints{} }
@@ -102,6 +108,96 @@ func (m *SeriesResponseHints) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_SeriesResponseHints proto.InternalMessageInfo
 
+type QueryStats struct {
+	/// chunks_touched is the number of chunks returned, either fetched from the bucket or from the chunks cache.
+	ChunksTouched          uint64 `protobuf:"varint,1,opt,name=chunks_touched,json=chunksTouched,proto3" json:"chunks_touched,omitempty"`
+	ChunksTouchedSizeBytes uint64 `protobuf:"varint,2,opt,name=chunks_touched_size_bytes,json=chunksTouchedSizeBytes,proto3" json:"chunks_touched_size_bytes,omitempty"`
+	/// chunks_fetched is the number of chunks fetched from the bucket. chunks_fetched_size_bytes is the
+	/// number of bytes downloaded, including the bytes in between the chunks fetched in the same range.
+	ChunksFetched          uint64 `protobuf:"varint,3,opt,name=chunks_fetched,json=chunksFetched,proto3" json:"chunks_fetched,omitempty"`
+	ChunksFetchedSizeBytes uint64 `protobuf:"varint,4,opt,name=chunks_fetched_size_bytes,json=chunksFetchedSizeBytes,proto3" json:"chunks_fetched_size_bytes,omitempty"`
+	/// chunks_cache_requests is the number of chunks looked up in the chunks cache and chunks_cache_hits
+	/// is the number of them found in the cache.
+	ChunksCacheRequests uint64 `protobuf:"varint,5,opt,name=chunks_cache_requests,json=chunksCacheRequests,proto3" json:"chunks_cache_requests,omitempty"`
+	ChunksCacheHits     uint64 `protobuf:"varint,6,opt,name=chunks_cache_hits,json=chunksCacheHits,proto3" json:"chunks_cache_hits,omitempty"`
+	/// blocks holds the statistics of each block chunks have been fetched from the bucket for.
+	Blocks []BlockQueryStats `protobuf:"bytes,7,rep,name=blocks,proto3" json:"blocks"`
+	/// The time spent in each stage of the streaming series iterators. They're zero if the series
+	/// haven't been streamed.
+	SeriesLoadDuration      time.Duration `protobuf:"bytes,8,opt,name=series_load_duration,json=seriesLoadDuration,proto3,stdduration" json:"series_load_duration"`
+	ChunksLoadDuration      time.Duration `protobuf:"bytes,9,opt,name=chunks_load_duration,json=chunksLoadDuration,proto3,stdduration" json:"chunks_load_duration"`
+	ChunksPreloadedDuration time.Duration `protobuf:"bytes,10,opt,name=chunks_preloaded_duration,json=chunksPreloadedDuration,proto3,stdduration" json:"chunks_preloaded_duration"`
+}
+
+func (m *QueryStats) Reset()      { *m = QueryStats{} }
+func (*QueryStats) ProtoMessage() {}
+func (*QueryStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_522be8e0d2634375, []int{2}
+}
+func (m *QueryStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryStats.Merge(m, src)
+}
+func (m *QueryStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryStats proto.InternalMessageInfo
+
+type BlockQueryStats struct {
+	Id                     string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ChunksFetchedSizeBytes uint64 `protobuf:"varint,2,opt,name=chunks_fetched_size_bytes,json=chunksFetchedSizeBytes,proto3" json:"chunks_fetched_size_bytes,omitempty"`
+}
+
+func (m *BlockQueryStats) Reset()      { *m = BlockQueryStats{} }
+func (*BlockQueryStats) ProtoMessage() {}
+func (*BlockQueryStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_522be8e0d2634375, []int{3}
+}
+func (m *BlockQueryStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BlockQueryStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BlockQueryStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BlockQueryStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockQueryStats.Merge(m, src)
+}
+func (m *BlockQueryStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *BlockQueryStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockQueryStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockQueryStats proto.InternalMessageInfo
+
 type Block struct {
 	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 }
@@ -109,7 +205,7 @@ type Block struct {
 func (m *Block) Reset()      { *m = Block{} }
 func (*Block) ProtoMessage() {}
 func (*Block) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{2}
+	return fileDescriptor_522be8e0d2634375, []int{4}
 }
 func (m *Block) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -148,7 +244,7 @@ type LabelNamesRequestHints struct {
 func (m *LabelNamesRequestHints) Reset()      { *m = LabelNamesRequestHints{} }
 func (*LabelNamesRequestHints) ProtoMessage() {}
 func (*LabelNamesRequestHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{3}
+	return fileDescriptor_522be8e0d2634375, []int{5}
 }
 func (m *LabelNamesRequestHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -185,7 +281,7 @@ type LabelNamesResponseHints struct {
 func (m *LabelNamesResponseHints) Reset()      { *m = LabelNamesResponseHints{} }
 func (*LabelNamesResponseHints) ProtoMessage() {}
 func (*LabelNamesResponseHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{4}
+	return fileDescriptor_522be8e0d2634375, []int{6}
 }
 func (m *LabelNamesResponseHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -224,7 +320,7 @@ type LabelValuesRequestHints struct {
 func (m *LabelValuesRequestHints) Reset()      { *m = LabelValuesRequestHints{} }
 func (*LabelValuesRequestHints) ProtoMessage() {}
 func (*LabelValuesRequestHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{5}
+	return fileDescriptor_522be8e0d2634375, []int{7}
 }
 func (m *LabelValuesRequestHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -261,7 +357,7 @@ type LabelValuesResponseHints struct {
 func (m *LabelValuesResponseHints) Reset()      { *m = LabelValuesResponseHints{} }
 func (*LabelValuesResponseHints) ProtoMessage() {}
 func (*LabelValuesResponseHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{6}
+	return fileDescriptor_522be8e0d2634375, []int{8}
 }
 func (m *LabelValuesResponseHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -290,43 +386,142 @@ func (m *LabelValuesResponseHints) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_LabelValuesResponseHints proto.InternalMessageInfo
 
+type ExemplarsRequestHints struct {
+	/// block_matchers is a list of label matchers that are evaluated against each single block's
+	/// labels to filter which blocks get queried. If the list is empty, no per-block filtering
+	/// is applied.
+	BlockMatchers []storepb.LabelMatcher `protobuf:"bytes,1,rep,name=block_matchers,json=blockMatchers,proto3" json:"block_matchers"`
+}
+
+func (m *ExemplarsRequestHints) Reset()      { *m = ExemplarsRequestHints{} }
+func (*ExemplarsRequestHints) ProtoMessage() {}
+func (*ExemplarsRequestHints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_522be8e0d2634375, []int{9}
+}
+func (m *ExemplarsRequestHints) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsRequestHints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsRequestHints.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsRequestHints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsRequestHints.Merge(m, src)
+}
+func (m *ExemplarsRequestHints) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsRequestHints) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsRequestHints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsRequestHints proto.InternalMessageInfo
+
+type ExemplarsResponseHints struct {
+	/// queried_blocks is the list of blocks that have been queried.
+	QueriedBlocks []Block `protobuf:"bytes,1,rep,name=queried_blocks,json=queriedBlocks,proto3" json:"queried_blocks"`
+}
+
+func (m *ExemplarsResponseHints) Reset()      { *m = ExemplarsResponseHints{} }
+func (*ExemplarsResponseHints) ProtoMessage() {}
+func (*ExemplarsResponseHints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_522be8e0d2634375, []int{10}
+}
+func (m *ExemplarsResponseHints) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsResponseHints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsResponseHints.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsResponseHints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsResponseHints.Merge(m, src)
+}
+func (m *ExemplarsResponseHints) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsResponseHints) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsResponseHints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsResponseHints proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*SeriesRequestHints)(nil), "hintspb.SeriesRequestHints")
 	proto.RegisterType((*SeriesResponseHints)(nil), "hintspb.SeriesResponseHints")
+	proto.RegisterType((*QueryStats)(nil), "hintspb.QueryStats")
+	proto.RegisterType((*BlockQueryStats)(nil), "hintspb.BlockQueryStats")
 	proto.RegisterType((*Block)(nil), "hintspb.Block")
 	proto.RegisterType((*LabelNamesRequestHints)(nil), "hintspb.LabelNamesRequestHints")
 	proto.RegisterType((*LabelNamesResponseHints)(nil), "hintspb.LabelNamesResponseHints")
 	proto.RegisterType((*LabelValuesRequestHints)(nil), "hintspb.LabelValuesRequestHints")
 	proto.RegisterType((*LabelValuesResponseHints)(nil), "hintspb.LabelValuesResponseHints")
+	proto.RegisterType((*ExemplarsRequestHints)(nil), "hintspb.ExemplarsRequestHints")
+	proto.RegisterType((*ExemplarsResponseHints)(nil), "hintspb.ExemplarsResponseHints")
 }
 
 func init() { proto.RegisterFile("hints.proto", fileDescriptor_522be8e0d2634375) }
 
 var fileDescriptor_522be8e0d2634375 = []byte{
-	// 358 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0xb1, 0x4e, 0xfb, 0x30,
-	0x10, 0xc6, 0xed, 0xfe, 0xff, 0x80, 0x70, 0x45, 0x86, 0x80, 0x68, 0xd5, 0xe1, 0xa8, 0x32, 0x75,
-	0x21, 0x91, 0x60, 0x44, 0x0c, 0xed, 0xc4, 0x00, 0x0c, 0x41, 0x6a, 0x25, 0x40, 0xaa, 0x9c, 0xd6,
-	0x4d, 0xa2, 0x36, 0x71, 0x1a, 0x3b, 0x42, 0xdd, 0x78, 0x04, 0x1e, 0x83, 0x47, 0xe9, 0xd8, 0xb1,
-	0x13, 0x22, 0xe9, 0xc2, 0xd8, 0x47, 0x40, 0x75, 0x12, 0xa9, 0xec, 0xd9, 0xfc, 0x7d, 0x77, 0xf7,
-	0xf3, 0x77, 0xd2, 0x91, 0xba, 0xe7, 0x87, 0x52, 0x98, 0x51, 0xcc, 0x25, 0xd7, 0x8f, 0x94, 0x88,
-	0x9c, 0xd6, 0xa5, 0xeb, 0x4b, 0x2f, 0x71, 0xcc, 0x11, 0x0f, 0x2c, 0x97, 0xbb, 0xdc, 0x52, 0x75,
-	0x27, 0x99, 0x28, 0xa5, 0x84, 0x7a, 0xe5, 0x73, 0xad, 0xdb, 0xfd, 0xf6, 0x98, 0x4e, 0x68, 0x48,
-	0xad, 0xc0, 0x0f, 0xfc, 0xd8, 0x8a, 0xa6, 0xae, 0x25, 0x24, 0x8f, 0x99, 0x4b, 0x25, 0x7b, 0xa3,
-	0x8b, 0x5c, 0x44, 0x8e, 0x25, 0x17, 0x11, 0x2b, 0xbe, 0x35, 0x06, 0x44, 0x7f, 0x62, 0xb1, 0xcf,
-	0x84, 0xcd, 0xe6, 0x09, 0x13, 0xf2, 0x6e, 0x97, 0x42, 0xef, 0x12, 0xcd, 0x99, 0xf1, 0xd1, 0x74,
-	0x18, 0x50, 0x39, 0xf2, 0x58, 0x2c, 0x9a, 0xb8, 0xfd, 0xaf, 0x53, 0xbf, 0x3a, 0x33, 0xa5, 0x47,
-	0x43, 0x2e, 0xcc, 0x7b, 0xea, 0xb0, 0xd9, 0x43, 0x5e, 0xec, 0xfd, 0x5f, 0x7e, 0x5d, 0x20, 0xfb,
-	0x44, 0x4d, 0x14, 0x9e, 0x30, 0x6c, 0x72, 0x5a, 0x82, 0x45, 0xc4, 0x43, 0xc1, 0x72, 0xf2, 0x0d,
-	0xd1, 0xe6, 0xc9, 0xce, 0x1f, 0x0f, 0x55, 0x7f, 0x49, 0xd6, 0xcc, 0x62, 0x7f, 0xb3, 0xb7, 0xb3,
-	0x4b, 0x66, 0xd1, 0xab, 0x3c, 0x61, 0x34, 0xc8, 0x81, 0x7a, 0xe9, 0x1a, 0xa9, 0xf9, 0xe3, 0x26,
-	0x6e, 0xe3, 0xce, 0xb1, 0x5d, 0xf3, 0xc7, 0xc6, 0x0b, 0x39, 0x57, 0x89, 0x1e, 0x69, 0x50, 0xfd,
-	0x26, 0x7d, 0xd2, 0xd8, 0x87, 0x57, 0xb6, 0xcd, 0x6b, 0xc1, 0xed, 0xd3, 0x59, 0x52, 0x7d, 0xea,
-	0x01, 0x69, 0xfe, 0xa1, 0x57, 0x15, 0xbb, 0xd7, 0x5d, 0xa6, 0x80, 0x56, 0x29, 0xa0, 0x75, 0x0a,
-	0x68, 0x9b, 0x02, 0x7e, 0xcf, 0x00, 0x7f, 0x66, 0x80, 0x97, 0x19, 0xe0, 0x55, 0x06, 0xf8, 0x3b,
-	0x03, 0xfc, 0x93, 0x01, 0xda, 0x66, 0x80, 0x3f, 0x36, 0x80, 0x56, 0x1b, 0x40, 0xeb, 0x0d, 0xa0,
-	0xe7, 0xf2, 0xc4, 0x9d, 0x43, 0x75, 0x7b, 0xd7, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xe1, 0x13,
-	0x56, 0x30, 0x01, 0x03, 0x00, 0x00,
+	// 663 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x95, 0x3b, 0x6b, 0xdc, 0x4c,
+	0x14, 0x86, 0x35, 0xeb, 0xf5, 0x6d, 0x16, 0xaf, 0xf9, 0xe4, 0x9b, 0xec, 0x62, 0x6c, 0x04, 0x1f,
+	0x98, 0x40, 0xb4, 0xe0, 0x84, 0x40, 0x08, 0x29, 0xbc, 0xb9, 0xe0, 0xc2, 0x09, 0x89, 0x1c, 0xdb,
+	0xe0, 0x18, 0xc4, 0x48, 0x3b, 0x96, 0x84, 0x77, 0x77, 0x64, 0xcd, 0x88, 0x64, 0x5d, 0xa5, 0x4c,
+	0x99, 0x32, 0x3f, 0x21, 0x3f, 0xc5, 0xa5, 0x4b, 0x57, 0x49, 0x56, 0x6e, 0x52, 0xba, 0x4b, 0x1b,
+	0x34, 0x33, 0xf2, 0x4a, 0x5b, 0xe4, 0x02, 0xea, 0x34, 0xe7, 0x3d, 0xe7, 0x79, 0x0f, 0x73, 0xce,
+	0x20, 0xd8, 0x08, 0xc2, 0x3e, 0x67, 0x56, 0x14, 0x53, 0x4e, 0xf5, 0x69, 0x71, 0x88, 0xdc, 0xb5,
+	0xbb, 0x7e, 0xc8, 0x83, 0xc4, 0xb5, 0x3c, 0xda, 0x6b, 0xf9, 0xd4, 0xa7, 0x2d, 0xa1, 0xbb, 0xc9,
+	0x89, 0x38, 0x89, 0x83, 0xf8, 0x92, 0x75, 0x6b, 0x8f, 0x8b, 0xe9, 0x31, 0x3e, 0xc1, 0x7d, 0xdc,
+	0xea, 0x85, 0xbd, 0x30, 0x6e, 0x45, 0xa7, 0x7e, 0x8b, 0x71, 0x1a, 0x13, 0x1f, 0x73, 0xf2, 0x0e,
+	0x0f, 0xe4, 0x21, 0x72, 0x5b, 0x7c, 0x10, 0x11, 0x65, 0xbb, 0x86, 0x7c, 0x4a, 0xfd, 0x2e, 0x19,
+	0x99, 0x74, 0x92, 0x18, 0xf3, 0x90, 0xf6, 0xa5, 0x6e, 0x1e, 0x42, 0x7d, 0x8f, 0xc4, 0x21, 0x61,
+	0x36, 0x39, 0x4b, 0x08, 0xe3, 0x3b, 0x59, 0x97, 0xfa, 0x36, 0x6c, 0xba, 0x5d, 0xea, 0x9d, 0x3a,
+	0x3d, 0xcc, 0xbd, 0x80, 0xc4, 0xcc, 0x00, 0x1b, 0x13, 0x9b, 0x8d, 0xad, 0x45, 0x8b, 0x07, 0xb8,
+	0x4f, 0x99, 0xb5, 0x8b, 0x5d, 0xd2, 0x7d, 0x21, 0xc5, 0x76, 0xfd, 0xe2, 0xeb, 0xba, 0x66, 0xcf,
+	0x89, 0x0a, 0x15, 0x63, 0xe6, 0x47, 0x00, 0x17, 0x72, 0x32, 0x8b, 0x68, 0x9f, 0x11, 0x89, 0x7e,
+	0x04, 0x9b, 0x67, 0x49, 0x16, 0xef, 0x38, 0xa2, 0x20, 0x47, 0x37, 0x2d, 0x75, 0x41, 0x56, 0x3b,
+	0x0b, 0xe7, 0x50, 0x95, 0x2b, 0x62, 0x4c, 0xbf, 0x0f, 0x1b, 0x59, 0x60, 0xe0, 0x30, 0x8e, 0x39,
+	0x33, 0x6a, 0x1b, 0x60, 0xb3, 0xb1, 0xb5, 0x70, 0x5b, 0xf9, 0x3a, 0xd3, 0xf6, 0x32, 0xc9, 0x86,
+	0x67, 0xb7, 0xdf, 0xe6, 0xcf, 0x3a, 0x84, 0x23, 0x49, 0xff, 0x1f, 0x36, 0xbd, 0x20, 0xe9, 0x9f,
+	0x32, 0x87, 0xd3, 0xc4, 0x0b, 0x48, 0xc7, 0x00, 0x1b, 0x60, 0xb3, 0x6e, 0xcf, 0xc9, 0xe8, 0x1b,
+	0x19, 0xd4, 0x1f, 0xc2, 0xd5, 0x72, 0x9a, 0xc3, 0xc2, 0x73, 0xe2, 0xb8, 0x03, 0x4e, 0xa4, 0x73,
+	0xdd, 0x5e, 0x2e, 0x55, 0xec, 0x85, 0xe7, 0xa4, 0x9d, 0xa9, 0x05, 0x87, 0x13, 0xc2, 0x85, 0xc3,
+	0x44, 0xd1, 0xe1, 0x39, 0xe1, 0x63, 0x0e, 0x2a, 0xad, 0xe8, 0x50, 0x2f, 0x3a, 0xa8, 0x8a, 0x91,
+	0xc3, 0x16, 0x5c, 0x52, 0xa5, 0x1e, 0xf6, 0x02, 0xe2, 0xc4, 0x72, 0x7a, 0xcc, 0x98, 0x14, 0x65,
+	0x0b, 0x52, 0x7c, 0x92, 0x69, 0x6a, 0xb0, 0x4c, 0xbf, 0x03, 0xff, 0x2b, 0xd5, 0x04, 0x21, 0x67,
+	0xc6, 0x94, 0xc8, 0x9f, 0x2f, 0xe4, 0xef, 0x84, 0x9c, 0xe9, 0x0f, 0xe0, 0x94, 0x9a, 0xce, 0xb4,
+	0x98, 0x8e, 0x51, 0x9e, 0xce, 0xe8, 0x36, 0xd5, 0x9c, 0x54, 0xb6, 0xbe, 0x0f, 0x17, 0x99, 0x18,
+	0xba, 0xd3, 0xa5, 0xb8, 0xe3, 0xe4, 0xcb, 0x66, 0xcc, 0x88, 0x49, 0xad, 0x5a, 0x72, 0x1b, 0xad,
+	0x7c, 0x1b, 0xad, 0xa7, 0x2a, 0xa1, 0x3d, 0x93, 0x61, 0x3e, 0x7f, 0x5b, 0x07, 0xb6, 0x2e, 0x01,
+	0xbb, 0x14, 0x77, 0x72, 0x35, 0xc3, 0xaa, 0xd6, 0xcb, 0xd8, 0xd9, 0x7f, 0xc0, 0x4a, 0x40, 0x09,
+	0xeb, 0xdc, 0x0e, 0x20, 0x8a, 0x49, 0x46, 0x26, 0x05, 0x36, 0xfc, 0x7b, 0xf6, 0x8a, 0xa4, 0xbc,
+	0xca, 0x21, 0x79, 0x8a, 0x79, 0x0c, 0xe7, 0xc7, 0xee, 0x4b, 0x6f, 0xc2, 0x5a, 0x28, 0x37, 0x6e,
+	0xd6, 0xae, 0x85, 0x7f, 0x58, 0x82, 0xda, 0xef, 0x96, 0xc0, 0x5c, 0x81, 0x93, 0x82, 0x3e, 0xce,
+	0x34, 0xdf, 0xc2, 0x65, 0xf1, 0x40, 0x5f, 0xe2, 0x5e, 0xf5, 0x0f, 0xfb, 0x00, 0xae, 0x14, 0xe1,
+	0x55, 0xbd, 0x6d, 0xf3, 0x58, 0x71, 0x0f, 0x70, 0x37, 0xa9, 0xbe, 0xeb, 0x43, 0x68, 0x94, 0xe8,
+	0x95, 0xb5, 0x7d, 0x04, 0x97, 0x9e, 0xbd, 0x27, 0xbd, 0xa8, 0x8b, 0xe3, 0xca, 0x9b, 0xde, 0x87,
+	0xcb, 0x05, 0x76, 0x55, 0x2d, 0xb7, 0xb7, 0x2f, 0x86, 0x48, 0xbb, 0x1c, 0x22, 0xed, 0x6a, 0x88,
+	0xb4, 0x9b, 0x21, 0x02, 0x1f, 0x52, 0x04, 0xbe, 0xa4, 0x08, 0x5c, 0xa4, 0x08, 0x5c, 0xa6, 0x08,
+	0x7c, 0x4f, 0x11, 0xf8, 0x91, 0x22, 0xed, 0x26, 0x45, 0xe0, 0xd3, 0x35, 0xd2, 0x2e, 0xaf, 0x91,
+	0x76, 0x75, 0x8d, 0xb4, 0xa3, 0xfc, 0x27, 0xe6, 0x4e, 0x89, 0xe7, 0x70, 0xef, 0xd7, 0x00, 0xde,
+	0x4b, 0xec, 0x33, 0xe3, 0x06, 0x00, 0x00,
 }
 
 func (this *SeriesRequestHints) Equal(that interface{}) bool {
@@ -385,6 +580,92 @@ func (this *SeriesResponseHints) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if !this.QueryStats.Equal(that1.QueryStats) {
+		return false
+	}
+	return true
+}
+func (this *QueryStats) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryStats)
+	if !ok {
+		that2, ok := that.(QueryStats)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.ChunksTouched != that1.ChunksTouched {
+		return false
+	}
+	if this.ChunksTouchedSizeBytes != that1.ChunksTouchedSizeBytes {
+		return false
+	}
+	if this.ChunksFetched != that1.ChunksFetched {
+		return false
+	}
+	if this.ChunksFetchedSizeBytes != that1.ChunksFetchedSizeBytes {
+		return false
+	}
+	if this.ChunksCacheRequests != that1.ChunksCacheRequests {
+		return false
+	}
+	if this.ChunksCacheHits != that1.ChunksCacheHits {
+		return false
+	}
+	if len(this.Blocks) != len(that1.Blocks) {
+		return false
+	}
+	for i := range this.Blocks {
+		if !this.Blocks[i].Equal(&that1.Blocks[i]) {
+			return false
+		}
+	}
+	if this.SeriesLoadDuration != that1.SeriesLoadDuration {
+		return false
+	}
+	if this.ChunksLoadDuration != that1.ChunksLoadDuration {
+		return false
+	}
+	if this.ChunksPreloadedDuration != that1.ChunksPreloadedDuration {
+		return false
+	}
+	return true
+}
+func (this *BlockQueryStats) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*BlockQueryStats)
+	if !ok {
+		that2, ok := that.(BlockQueryStats)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Id != that1.Id {
+		return false
+	}
+	if this.ChunksFetchedSizeBytes != that1.ChunksFetchedSizeBytes {
+		return false
+	}
 	return true
 }
 func (this *Block) Equal(that interface{}) bool {
@@ -527,6 +808,64 @@ func (this *LabelValuesResponseHints) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *ExemplarsRequestHints) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarsRequestHints)
+	if !ok {
+		that2, ok := that.(ExemplarsRequestHints)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.BlockMatchers) != len(that1.BlockMatchers) {
+		return false
+	}
+	for i := range this.BlockMatchers {
+		if !this.BlockMatchers[i].Equal(&that1.BlockMatchers[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *ExemplarsResponseHints) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarsResponseHints)
+	if !ok {
+		that2, ok := that.(ExemplarsResponseHints)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.QueriedBlocks) != len(that1.QueriedBlocks) {
+		return false
+	}
+	for i := range this.QueriedBlocks {
+		if !this.QueriedBlocks[i].Equal(&that1.QueriedBlocks[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *SeriesRequestHints) GoString() string {
 	if this == nil {
 		return "nil"
@@ -547,7 +886,7 @@ func (this *SeriesResponseHints) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 6)
 	s = append(s, "&hintspb.SeriesResponseHints{")
 	if this.QueriedBlocks != nil {
 		vs := make([]*Block, len(this.QueriedBlocks))
@@ -556,6 +895,45 @@ func (this *SeriesResponseHints) GoString() string {
 		}
 		s = append(s, "QueriedBlocks: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	if this.QueryStats != nil {
+		s = append(s, "QueryStats: "+fmt.Sprintf("%#v", this.QueryStats)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QueryStats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 14)
+	s = append(s, "&hintspb.QueryStats{")
+	s = append(s, "ChunksTouched: "+fmt.Sprintf("%#v", this.ChunksTouched)+",\n")
+	s = append(s, "ChunksTouchedSizeBytes: "+fmt.Sprintf("%#v", this.ChunksTouchedSizeBytes)+",\n")
+	s = append(s, "ChunksFetched: "+fmt.Sprintf("%#v", this.ChunksFetched)+",\n")
+	s = append(s, "ChunksFetchedSizeBytes: "+fmt.Sprintf("%#v", this.ChunksFetchedSizeBytes)+",\n")
+	s = append(s, "ChunksCacheRequests: "+fmt.Sprintf("%#v", this.ChunksCacheRequests)+",\n")
+	s = append(s, "ChunksCacheHits: "+fmt.Sprintf("%#v", this.ChunksCacheHits)+",\n")
+	if this.Blocks != nil {
+		vs := make([]*BlockQueryStats, len(this.Blocks))
+		for i := range vs {
+			vs[i] = &this.Blocks[i]
+		}
+		s = append(s, "Blocks: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "SeriesLoadDuration: "+fmt.Sprintf("%#v", this.SeriesLoadDuration)+",\n")
+	s = append(s, "ChunksLoadDuration: "+fmt.Sprintf("%#v", this.ChunksLoadDuration)+",\n")
+	s = append(s, "ChunksPreloadedDuration: "+fmt.Sprintf("%#v", this.ChunksPreloadedDuration)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *BlockQueryStats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&hintspb.BlockQueryStats{")
+	s = append(s, "Id: "+fmt.Sprintf("%#v", this.Id)+",\n")
+	s = append(s, "ChunksFetchedSizeBytes: "+fmt.Sprintf("%#v", this.ChunksFetchedSizeBytes)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -633,23 +1011,55 @@ func (this *LabelValuesResponseHints) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func valueToGoStringHints(v interface{}, typ string) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *ExemplarsRequestHints) GoString() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
-}
-func (m *SeriesRequestHints) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	s := make([]string, 0, 5)
+	s = append(s, "&hintspb.ExemplarsRequestHints{")
+	if this.BlockMatchers != nil {
+		vs := make([]*storepb.LabelMatcher, len(this.BlockMatchers))
+		for i := range vs {
+			vs[i] = &this.BlockMatchers[i]
+		}
+		s = append(s, "BlockMatchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return dAtA[:n], nil
-}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarsResponseHints) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&hintspb.ExemplarsResponseHints{")
+	if this.QueriedBlocks != nil {
+		vs := make([]*Block, len(this.QueriedBlocks))
+		for i := range vs {
+			vs[i] = &this.QueriedBlocks[i]
+		}
+		s = append(s, "QueriedBlocks: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringHints(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+func (m *SeriesRequestHints) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
 
 func (m *SeriesRequestHints) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
@@ -698,6 +1108,18 @@ func (m *SeriesResponseHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueryStats != nil {
+		{
+			size, err := m.QueryStats.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHints(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.QueriedBlocks) > 0 {
 		for iNdEx := len(m.QueriedBlocks) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -715,6 +1137,132 @@ func (m *SeriesResponseHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QueryStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryStats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ChunksPreloadedDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.ChunksPreloadedDuration):])
+	if err2 != nil {
+		return 0, err2
+	}
+	i -= n2
+	i = encodeVarintHints(dAtA, i, uint64(n2))
+	i--
+	dAtA[i] = 0x52
+	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ChunksLoadDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.ChunksLoadDuration):])
+	if err3 != nil {
+		return 0, err3
+	}
+	i -= n3
+	i = encodeVarintHints(dAtA, i, uint64(n3))
+	i--
+	dAtA[i] = 0x4a
+	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.SeriesLoadDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.SeriesLoadDuration):])
+	if err4 != nil {
+		return 0, err4
+	}
+	i -= n4
+	i = encodeVarintHints(dAtA, i, uint64(n4))
+	i--
+	dAtA[i] = 0x42
+	if len(m.Blocks) > 0 {
+		for iNdEx := len(m.Blocks) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Blocks[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintHints(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.ChunksCacheHits != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksCacheHits))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.ChunksCacheRequests != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksCacheRequests))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.ChunksFetchedSizeBytes != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksFetchedSizeBytes))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.ChunksFetched != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksFetched))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.ChunksTouchedSizeBytes != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksTouchedSizeBytes))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ChunksTouched != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksTouched))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BlockQueryStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlockQueryStats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BlockQueryStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.ChunksFetchedSizeBytes != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.ChunksFetchedSizeBytes))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Id) > 0 {
+		i -= len(m.Id)
+		copy(dAtA[i:], m.Id)
+		i = encodeVarintHints(dAtA, i, uint64(len(m.Id)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Block) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -893,6 +1441,80 @@ func (m *LabelValuesResponseHints) MarshalToSizedBuffer(dAtA []byte) (int, error
 	return len(dAtA) - i, nil
 }
 
+func (m *ExemplarsRequestHints) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsRequestHints) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsRequestHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.BlockMatchers) > 0 {
+		for iNdEx := len(m.BlockMatchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.BlockMatchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintHints(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarsResponseHints) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsResponseHints) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsResponseHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.QueriedBlocks) > 0 {
+		for iNdEx := len(m.QueriedBlocks) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.QueriedBlocks[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintHints(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintHints(dAtA []byte, offset int, v uint64) int {
 	offset -= sovHints(v)
 	base := offset
@@ -931,6 +1553,65 @@ func (m *SeriesResponseHints) Size() (n int) {
 			n += 1 + l + sovHints(uint64(l))
 		}
 	}
+	if m.QueryStats != nil {
+		l = m.QueryStats.Size()
+		n += 1 + l + sovHints(uint64(l))
+	}
+	return n
+}
+
+func (m *QueryStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ChunksTouched != 0 {
+		n += 1 + sovHints(uint64(m.ChunksTouched))
+	}
+	if m.ChunksTouchedSizeBytes != 0 {
+		n += 1 + sovHints(uint64(m.ChunksTouchedSizeBytes))
+	}
+	if m.ChunksFetched != 0 {
+		n += 1 + sovHints(uint64(m.ChunksFetched))
+	}
+	if m.ChunksFetchedSizeBytes != 0 {
+		n += 1 + sovHints(uint64(m.ChunksFetchedSizeBytes))
+	}
+	if m.ChunksCacheRequests != 0 {
+		n += 1 + sovHints(uint64(m.ChunksCacheRequests))
+	}
+	if m.ChunksCacheHits != 0 {
+		n += 1 + sovHints(uint64(m.ChunksCacheHits))
+	}
+	if len(m.Blocks) > 0 {
+		for _, e := range m.Blocks {
+			l = e.Size()
+			n += 1 + l + sovHints(uint64(l))
+		}
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.SeriesLoadDuration)
+	n += 1 + l + sovHints(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.ChunksLoadDuration)
+	n += 1 + l + sovHints(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.ChunksPreloadedDuration)
+	n += 1 + l + sovHints(uint64(l))
+	return n
+}
+
+func (m *BlockQueryStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Id)
+	if l > 0 {
+		n += 1 + l + sovHints(uint64(l))
+	}
+	if m.ChunksFetchedSizeBytes != 0 {
+		n += 1 + sovHints(uint64(m.ChunksFetchedSizeBytes))
+	}
 	return n
 }
 
@@ -1007,6 +1688,36 @@ func (m *LabelValuesResponseHints) Size() (n int) {
 	return n
 }
 
+func (m *ExemplarsRequestHints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.BlockMatchers) > 0 {
+		for _, e := range m.BlockMatchers {
+			l = e.Size()
+			n += 1 + l + sovHints(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExemplarsResponseHints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.QueriedBlocks) > 0 {
+		for _, e := range m.QueriedBlocks {
+			l = e.Size()
+			n += 1 + l + sovHints(uint64(l))
+		}
+	}
+	return n
+}
+
 func sovHints(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1039,6 +1750,42 @@ func (this *SeriesResponseHints) String() string {
 	repeatedStringForQueriedBlocks += "}"
 	s := strings.Join([]string{`&SeriesResponseHints{`,
 		`QueriedBlocks:` + repeatedStringForQueriedBlocks + `,`,
+		`QueryStats:` + strings.Replace(this.QueryStats.String(), "QueryStats", "QueryStats", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryStats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForBlocks := "[]BlockQueryStats{"
+	for _, f := range this.Blocks {
+		repeatedStringForBlocks += strings.Replace(strings.Replace(f.String(), "BlockQueryStats", "BlockQueryStats", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForBlocks += "}"
+	s := strings.Join([]string{`&QueryStats{`,
+		`ChunksTouched:` + fmt.Sprintf("%v", this.ChunksTouched) + `,`,
+		`ChunksTouchedSizeBytes:` + fmt.Sprintf("%v", this.ChunksTouchedSizeBytes) + `,`,
+		`ChunksFetched:` + fmt.Sprintf("%v", this.ChunksFetched) + `,`,
+		`ChunksFetchedSizeBytes:` + fmt.Sprintf("%v", this.ChunksFetchedSizeBytes) + `,`,
+		`ChunksCacheRequests:` + fmt.Sprintf("%v", this.ChunksCacheRequests) + `,`,
+		`ChunksCacheHits:` + fmt.Sprintf("%v", this.ChunksCacheHits) + `,`,
+		`Blocks:` + repeatedStringForBlocks + `,`,
+		`SeriesLoadDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.SeriesLoadDuration), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`ChunksLoadDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.ChunksLoadDuration), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`ChunksPreloadedDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.ChunksPreloadedDuration), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *BlockQueryStats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&BlockQueryStats{`,
+		`Id:` + fmt.Sprintf("%v", this.Id) + `,`,
+		`ChunksFetchedSizeBytes:` + fmt.Sprintf("%v", this.ChunksFetchedSizeBytes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1087,41 +1834,685 @@ func (this *LabelValuesRequestHints) String() string {
 	if this == nil {
 		return "nil"
 	}
-	repeatedStringForBlockMatchers := "[]LabelMatcher{"
-	for _, f := range this.BlockMatchers {
-		repeatedStringForBlockMatchers += fmt.Sprintf("%v", f) + ","
+	repeatedStringForBlockMatchers := "[]LabelMatcher{"
+	for _, f := range this.BlockMatchers {
+		repeatedStringForBlockMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForBlockMatchers += "}"
+	s := strings.Join([]string{`&LabelValuesRequestHints{`,
+		`BlockMatchers:` + repeatedStringForBlockMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesResponseHints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForQueriedBlocks := "[]Block{"
+	for _, f := range this.QueriedBlocks {
+		repeatedStringForQueriedBlocks += strings.Replace(strings.Replace(f.String(), "Block", "Block", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForQueriedBlocks += "}"
+	s := strings.Join([]string{`&LabelValuesResponseHints{`,
+		`QueriedBlocks:` + repeatedStringForQueriedBlocks + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarsRequestHints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForBlockMatchers := "[]LabelMatcher{"
+	for _, f := range this.BlockMatchers {
+		repeatedStringForBlockMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForBlockMatchers += "}"
+	s := strings.Join([]string{`&ExemplarsRequestHints{`,
+		`BlockMatchers:` + repeatedStringForBlockMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarsResponseHints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForQueriedBlocks := "[]Block{"
+	for _, f := range this.QueriedBlocks {
+		repeatedStringForQueriedBlocks += strings.Replace(strings.Replace(f.String(), "Block", "Block", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForQueriedBlocks += "}"
+	s := strings.Join([]string{`&ExemplarsResponseHints{`,
+		`QueriedBlocks:` + repeatedStringForQueriedBlocks + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringHints(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *SeriesRequestHints) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHints
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesRequestHints: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockMatchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BlockMatchers = append(m.BlockMatchers, storepb.LabelMatcher{})
+			if err := m.BlockMatchers[len(m.BlockMatchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHints(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SeriesResponseHints) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHints
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesResponseHints: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueriedBlocks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QueriedBlocks = append(m.QueriedBlocks, Block{})
+			if err := m.QueriedBlocks[len(m.QueriedBlocks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryStats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.QueryStats == nil {
+				m.QueryStats = &QueryStats{}
+			}
+			if err := m.QueryStats.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHints(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHints
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksTouched", wireType)
+			}
+			m.ChunksTouched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksTouched |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksTouchedSizeBytes", wireType)
+			}
+			m.ChunksTouchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksTouchedSizeBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetched", wireType)
+			}
+			m.ChunksFetched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetched |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetchedSizeBytes", wireType)
+			}
+			m.ChunksFetchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetchedSizeBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksCacheRequests", wireType)
+			}
+			m.ChunksCacheRequests = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksCacheRequests |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksCacheHits", wireType)
+			}
+			m.ChunksCacheHits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksCacheHits |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Blocks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Blocks = append(m.Blocks, BlockQueryStats{})
+			if err := m.Blocks[len(m.Blocks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesLoadDuration", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.SeriesLoadDuration, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksLoadDuration", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.ChunksLoadDuration, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksPreloadedDuration", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.ChunksPreloadedDuration, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHints(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	repeatedStringForBlockMatchers += "}"
-	s := strings.Join([]string{`&LabelValuesRequestHints{`,
-		`BlockMatchers:` + repeatedStringForBlockMatchers + `,`,
-		`}`,
-	}, "")
-	return s
+	return nil
 }
-func (this *LabelValuesResponseHints) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForQueriedBlocks := "[]Block{"
-	for _, f := range this.QueriedBlocks {
-		repeatedStringForQueriedBlocks += strings.Replace(strings.Replace(f.String(), "Block", "Block", 1), `&`, ``, 1) + ","
+func (m *BlockQueryStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHints
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlockQueryStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlockQueryStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Id = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetchedSizeBytes", wireType)
+			}
+			m.ChunksFetchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetchedSizeBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHints(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	repeatedStringForQueriedBlocks += "}"
-	s := strings.Join([]string{`&LabelValuesResponseHints{`,
-		`QueriedBlocks:` + repeatedStringForQueriedBlocks + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func valueToStringHints(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	return nil
 }
-func (m *SeriesRequestHints) Unmarshal(dAtA []byte) error {
+func (m *Block) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1144,17 +2535,17 @@ func (m *SeriesRequestHints) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SeriesRequestHints: wiretype end group for non-group")
+			return fmt.Errorf("proto: Block: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SeriesRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Block: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BlockMatchers", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowHints
@@ -1164,25 +2555,23 @@ func (m *SeriesRequestHints) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthHints
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthHints
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.BlockMatchers = append(m.BlockMatchers, storepb.LabelMatcher{})
-			if err := m.BlockMatchers[len(m.BlockMatchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Id = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1208,7 +2597,7 @@ func (m *SeriesRequestHints) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SeriesResponseHints) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesRequestHints) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1231,15 +2620,15 @@ func (m *SeriesResponseHints) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SeriesResponseHints: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesRequestHints: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SeriesResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field QueriedBlocks", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockMatchers", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1266,8 +2655,8 @@ func (m *SeriesResponseHints) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.QueriedBlocks = append(m.QueriedBlocks, Block{})
-			if err := m.QueriedBlocks[len(m.QueriedBlocks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.BlockMatchers = append(m.BlockMatchers, storepb.LabelMatcher{})
+			if err := m.BlockMatchers[len(m.BlockMatchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -1295,7 +2684,7 @@ func (m *SeriesResponseHints) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Block) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesResponseHints) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1318,17 +2707,17 @@ func (m *Block) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Block: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesResponseHints: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Block: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field QueriedBlocks", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowHints
@@ -1338,23 +2727,25 @@ func (m *Block) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthHints
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthHints
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Id = string(dAtA[iNdEx:postIndex])
+			m.QueriedBlocks = append(m.QueriedBlocks, Block{})
+			if err := m.QueriedBlocks[len(m.QueriedBlocks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1380,7 +2771,7 @@ func (m *Block) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesRequestHints) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesRequestHints) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1403,10 +2794,10 @@ func (m *LabelNamesRequestHints) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesRequestHints: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesRequestHints: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1467,7 +2858,7 @@ func (m *LabelNamesRequestHints) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesResponseHints) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesResponseHints) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1490,10 +2881,10 @@ func (m *LabelNamesResponseHints) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesResponseHints: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesResponseHints: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1554,7 +2945,7 @@ func (m *LabelNamesResponseHints) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesRequestHints) Unmarshal(dAtA []byte) error {
+func (m *ExemplarsRequestHints) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1577,10 +2968,10 @@ func (m *LabelValuesRequestHints) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesRequestHints: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExemplarsRequestHints: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExemplarsRequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1641,7 +3032,7 @@ func (m *LabelValuesRequestHints) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesResponseHints) Unmarshal(dAtA []byte) error {
+func (m *ExemplarsResponseHints) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1664,10 +3055,10 @@ func (m *LabelValuesResponseHints) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesResponseHints: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExemplarsResponseHints: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExemplarsResponseHints: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storage/sharding"
+)
+
+// BlockAgeTieredIndexCache is an IndexCache storing the entries of the blocks created more recently
+// than a max age in a cache for recent blocks, and the entries of the older blocks in a cache for old
+// blocks. The age of a block is computed from the creation time encoded in its ID.
+type BlockAgeTieredIndexCache struct {
+	recent IndexCache
+	old    IndexCache
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewBlockAgeTieredIndexCache makes a new BlockAgeTieredIndexCache storing the entries of the blocks
+// created in the last maxAge in the recent cache, and the entries of the other blocks in the old cache.
+func NewBlockAgeTieredIndexCache(recent, old IndexCache, maxAge time.Duration) *BlockAgeTieredIndexCache {
+	return &BlockAgeTieredIndexCache{
+		recent: recent,
+		old:    old,
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+// cacheFor returns the cache for the entries of the block blockID.
+func (c *BlockAgeTieredIndexCache) cacheFor(blockID ulid.ULID) IndexCache {
+	if c.now().Sub(ulid.Time(blockID.Time())) < c.maxAge {
+		return c.recent
+	}
+	return c.old
+}
+
+func (c *BlockAgeTieredIndexCache) StorePostings(ctx context.Context, userID string, blockID ulid.ULID, l labels.Label, v []byte) {
+	c.cacheFor(blockID).StorePostings(ctx, userID, blockID, l, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchMultiPostings(ctx context.Context, userID string, blockID ulid.ULID, keys []labels.Label) (hits map[labels.Label][]byte, misses []labels.Label) {
+	return c.cacheFor(blockID).FetchMultiPostings(ctx, userID, blockID, keys)
+}
+
+func (c *BlockAgeTieredIndexCache) StoreSeriesForRef(ctx context.Context, userID string, blockID ulid.ULID, id storage.SeriesRef, v []byte) {
+	c.cacheFor(blockID).StoreSeriesForRef(ctx, userID, blockID, id, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchMultiSeriesForRefs(ctx context.Context, userID string, blockID ulid.ULID, ids []storage.SeriesRef) (hits map[storage.SeriesRef][]byte, misses []storage.SeriesRef) {
+	return c.cacheFor(blockID).FetchMultiSeriesForRefs(ctx, userID, blockID, ids)
+}
+
+func (c *BlockAgeTieredIndexCache) StoreExpandedPostings(ctx context.Context, userID string, blockID ulid.ULID, key LabelMatchersKey, v []byte) {
+	c.cacheFor(blockID).StoreExpandedPostings(ctx, userID, blockID, key, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchExpandedPostings(ctx context.Context, userID string, blockID ulid.ULID, key LabelMatchersKey) ([]byte, bool) {
+	return c.cacheFor(blockID).FetchExpandedPostings(ctx, userID, blockID, key)
+}
+
+func (c *BlockAgeTieredIndexCache) StoreSeries(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey, shard *sharding.ShardSelector, v []byte) {
+	c.cacheFor(blockID).StoreSeries(ctx, userID, blockID, matchersKey, shard, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchSeries(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey, shard *sharding.ShardSelector) ([]byte, bool) {
+	return c.cacheFor(blockID).FetchSeries(ctx, userID, blockID, matchersKey, shard)
+}
+
+func (c *BlockAgeTieredIndexCache) StoreSeriesForPostings(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey, shard *sharding.ShardSelector, postingsKey PostingsKey, v []byte) {
+	c.cacheFor(blockID).StoreSeriesForPostings(ctx, userID, blockID, matchersKey, shard, postingsKey, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchSeriesForPostings(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey, shard *sharding.ShardSelector, postingsKey PostingsKey) ([]byte, bool) {
+	return c.cacheFor(blockID).FetchSeriesForPostings(ctx, userID, blockID, matchersKey, shard, postingsKey)
+}
+
+func (c *BlockAgeTieredIndexCache) StoreLabelNames(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey, v []byte) {
+	c.cacheFor(blockID).StoreLabelNames(ctx, userID, blockID, matchersKey, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchLabelNames(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey) ([]byte, bool) {
+	return c.cacheFor(blockID).FetchLabelNames(ctx, userID, blockID, matchersKey)
+}
+
+func (c *BlockAgeTieredIndexCache) StoreLabelValues(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte) {
+	c.cacheFor(blockID).StoreLabelValues(ctx, userID, blockID, labelName, matchersKey, v)
+}
+
+func (c *BlockAgeTieredIndexCache) FetchLabelValues(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool) {
+	return c.cacheFor(blockID).FetchLabelValues(ctx, userID, blockID, labelName, matchersKey)
+}
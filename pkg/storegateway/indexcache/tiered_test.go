@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockAgeTieredIndexCache(t *testing.T) {
+	const user = "tenant"
+
+	ctx := context.Background()
+	now := time.Now()
+	recentBlock := ulid.MustNew(ulid.Timestamp(now.Add(-time.Hour)), nil)
+	oldBlock := ulid.MustNew(ulid.Timestamp(now.Add(-48*time.Hour)), nil)
+	lbl := labels.Label{Name: "foo", Value: "bar"}
+	matchersKey := CanonicalLabelMatchersKey([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")})
+
+	recent, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), nil, DefaultInMemoryIndexCacheConfig)
+	require.NoError(t, err)
+	old, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), nil, DefaultInMemoryIndexCacheConfig)
+	require.NoError(t, err)
+
+	c := NewBlockAgeTieredIndexCache(recent, old, 24*time.Hour)
+	c.now = func() time.Time { return now }
+
+	c.StorePostings(ctx, user, recentBlock, lbl, []byte("recent"))
+	c.StorePostings(ctx, user, oldBlock, lbl, []byte("old"))
+	c.StoreLabelNames(ctx, user, recentBlock, matchersKey, []byte("recent"))
+	c.StoreLabelNames(ctx, user, oldBlock, matchersKey, []byte("old"))
+
+	// The entries should be read back through the tiered cache.
+	hits, misses := c.FetchMultiPostings(ctx, user, recentBlock, []labels.Label{lbl})
+	assert.Equal(t, map[labels.Label][]byte{lbl: []byte("recent")}, hits)
+	assert.Empty(t, misses)
+	hits, misses = c.FetchMultiPostings(ctx, user, oldBlock, []labels.Label{lbl})
+	assert.Equal(t, map[labels.Label][]byte{lbl: []byte("old")}, hits)
+	assert.Empty(t, misses)
+
+	// The entries of each block should be stored only in the cache of its tier.
+	_, misses = recent.FetchMultiPostings(ctx, user, oldBlock, []labels.Label{lbl})
+	assert.Equal(t, []labels.Label{lbl}, misses)
+	_, misses = old.FetchMultiPostings(ctx, user, recentBlock, []labels.Label{lbl})
+	assert.Equal(t, []labels.Label{lbl}, misses)
+
+	v, ok := recent.FetchLabelNames(ctx, user, recentBlock, matchersKey)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("recent"), v)
+	_, ok = recent.FetchLabelNames(ctx, user, oldBlock, matchersKey)
+	assert.False(t, ok)
+
+	v, ok = old.FetchLabelNames(ctx, user, oldBlock, matchersKey)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("old"), v)
+	_, ok = old.FetchLabelNames(ctx, user, recentBlock, matchersKey)
+	assert.False(t, ok)
+
+	// Once the recent block gets older than the max age, its entries are looked up in the old cache.
+	c.now = func() time.Time { return now.Add(24 * time.Hour) }
+	_, misses = c.FetchMultiPostings(ctx, user, recentBlock, []labels.Label{lbl})
+	assert.Equal(t, []labels.Label{lbl}, misses)
+}
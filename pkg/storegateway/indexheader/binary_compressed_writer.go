@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+
+	streamindex "github.com/grafana/mimir/pkg/storegateway/indexheader/index"
+)
+
+// compressedPostingOffsetsBlockEntries is the max number of entries of the postings offset table compressed
+// together in the index-header format v2. Each lookup decompresses a whole block, while the first value of
+// each block is kept in memory.
+const compressedPostingOffsetsBlockEntries = 64
+
+// WriteCompressedPostingOffsets writes the postings offset table of the index read by ir, compressed like described
+// by streamindex.CompressedPostingOffsetTableWriter.
+func (w *binaryWriter) WriteCompressedPostingOffsets(ir *chunkedIndexReader) error {
+	start := w.f.Pos()
+	w.toc.PostingsOffsetTable = start
+
+	// The length of the table is only known once it's written, so it's updated afterwards.
+	if err := w.f.Write(make([]byte, 4)); err != nil {
+		return errors.Wrap(err, "write compressed posting offsets length")
+	}
+
+	crc := newCRC32()
+	tw := streamindex.NewCompressedPostingOffsetTableWriter(io.MultiWriter(w, crc), compressedPostingOffsetsBlockEntries)
+	if err := ir.ForEachPostingsOffset(tw.Add); err != nil {
+		return err
+	}
+	if err := tw.Finish(ir.toc.PostingsTable); err != nil {
+		return err
+	}
+
+	length := w.f.Pos() - start - 4
+	if length > math.MaxUint32 {
+		return errors.Errorf("compressed posting offsets length %d exceeds 4 bytes", length)
+	}
+
+	if err := w.f.Write(crc.Sum(nil)); err != nil {
+		return errors.Wrap(err, "write compressed posting offsets CRC32")
+	}
+
+	lengthBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBytes, uint32(length))
+	return errors.Wrap(w.f.WriteAt(lengthBytes, start), "write compressed posting offsets length")
+}
+
+// ForEachPostingsOffset streams the postings offset table of the index from the object storage, calling f for each
+// of its entries in order. The name and value are only valid until f returns. An error is returned if the
+// table doesn't match its CRC32.
+func (r *chunkedIndexReader) ForEachPostingsOffset(f func(name, value []byte, off uint64) error) (err error) {
+	rc, err := r.bkt.GetRange(r.ctx, r.path, int64(r.toc.PostingsTable), int64(r.size-r.toc.PostingsTable))
+	if err != nil {
+		return errors.Wrapf(err, "get posting offset table from object storage of %s", r.path)
+	}
+	defer runutil.CloseWithErrCapture(&err, rc, "close posting offsets reader")
+
+	br := bufio.NewReaderSize(rc, 32*1024)
+	lengthBytes := make([]byte, 4)
+	if _, err := io.ReadFull(br, lengthBytes); err != nil {
+		return errors.Wrap(err, "read posting offset table length")
+	}
+
+	crc := newCRC32()
+	tr := bufio.NewReaderSize(io.TeeReader(io.LimitReader(br, int64(binary.BigEndian.Uint32(lengthBytes))), crc), 32*1024)
+
+	countBytes := make([]byte, 4)
+	if _, err := io.ReadFull(tr, countBytes); err != nil {
+		return errors.Wrap(err, "read posting offset table entries count")
+	}
+
+	var name, value []byte
+	for count := binary.BigEndian.Uint32(countBytes); count > 0; count-- {
+		keyCount, err := binary.ReadUvarint(tr)
+		if err != nil {
+			return errors.Wrap(err, "read posting offset table entry")
+		}
+		// The Postings offset table takes only 2 keys per entry (name and value of label).
+		if keyCount != 2 {
+			return errors.Errorf("unexpected key length for posting table %d", keyCount)
+		}
+
+		if name, err = readUvarintBytes(tr, name); err != nil {
+			return errors.Wrap(err, "read posting offset table entry")
+		}
+		if value, err = readUvarintBytes(tr, value); err != nil {
+			return errors.Wrap(err, "read posting offset table entry")
+		}
+		off, err := binary.ReadUvarint(tr)
+		if err != nil {
+			return errors.Wrap(err, "read posting offset table entry")
+		}
+
+		if err := f(name, value, off); err != nil {
+			return err
+		}
+	}
+
+	// Make sure the whole table has been hashed before checking its CRC32.
+	if _, err := io.Copy(io.Discard, tr); err != nil {
+		return errors.Wrap(err, "read posting offset table")
+	}
+	crcBytes := make([]byte, 4)
+	if _, err := io.ReadFull(br, crcBytes); err != nil {
+		return errors.Wrap(err, "read posting offset table CRC32")
+	}
+	if binary.BigEndian.Uint32(crcBytes) != crc.Sum32() {
+		return errors.Errorf("posting offset table of %s doesn't match its CRC32", r.path)
+	}
+
+	return nil
+}
+
+// readUvarintBytes reads varint prefixed bytes from r into buf, which is grown if needed.
+func readUvarintBytes(r *bufio.Reader, buf []byte) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(cap(buf)) < l {
+		buf = make([]byte, l)
+	}
+	buf = buf[:l]
+	_, err = io.ReadFull(r, buf)
+	return buf, err
+}
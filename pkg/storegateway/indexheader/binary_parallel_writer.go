@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+// parallelBuildPartSize is the max size of each range of the block index downloaded by WriteBinaryParallel.
+const parallelBuildPartSize = 32 * 1024 * 1024
+
+// writeBinary builds the index-header file at filename, either sequentially or in parallel, and with
+// the format version based on the cfg.
+func writeBinary(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, filename string, cfg Config) (err error) {
+	if cfg.FormatVersion != BinaryFormatV3 {
+		return writeUncompressedBinary(ctx, bkt, id, filename, cfg.FormatVersion, cfg.BuildConcurrency)
+	}
+
+	// The index-header of version 3 holds a compressed index-header of version 1, which is built first.
+	uncompressedFilename := filename + ".uncompressed"
+	if err := writeUncompressedBinary(ctx, bkt, id, uncompressedFilename, BinaryFormatV1, cfg.BuildConcurrency); err != nil {
+		return err
+	}
+	defer func() {
+		if removeErr := os.Remove(uncompressedFilename); removeErr != nil && err == nil {
+			err = errors.Wrap(removeErr, "remove uncompressed index header")
+		}
+	}()
+
+	return compressBinary(uncompressedFilename, filename)
+}
+
+func writeUncompressedBinary(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, filename string, formatVersion, concurrency int) error {
+	if concurrency > 1 {
+		return writeBinaryParallel(ctx, bkt, id, filename, formatVersion, concurrency, parallelBuildPartSize)
+	}
+	return writeBinaryWithFormat(ctx, bkt, id, filename, formatVersion)
+}
+
+// WriteBinaryParallel builds the same index-header file as WriteBinary, but reads the pieces of index in
+// object storage concurrently. The positions of the symbols and postings offset table in the index-header
+// are known upfront from the index TOC, so both sections are downloaded through up to concurrency range
+// requests at the same time, and each range is streamed directly to its position in the index-header file.
+func WriteBinaryParallel(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, filename string, concurrency int) error {
+	return writeBinaryParallel(ctx, bkt, id, filename, BinaryFormatV1, concurrency, parallelBuildPartSize)
+}
+
+// writeBinaryParallel is like WriteBinaryParallel, but builds an index-header file with formatVersion if supported
+// by the index. The compressed postings offset table of the index-header format v2 is built sequentially, after
+// the symbols have been downloaded concurrently.
+func writeBinaryParallel(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, filename string, formatVersion, concurrencyLimit int, partSize uint64) (err error) {
+	ir, indexVersion, err := newChunkedIndexReaderParallel(ctx, bkt, id)
+	if err != nil {
+		return errors.Wrap(err, "new index reader")
+	}
+	tmpFilename := filename + ".tmp"
+	version := binaryFormatVersion(formatVersion, indexVersion)
+
+	bw, err := newBinaryWriter(tmpFilename, version, make([]byte, 32*1024))
+	if err != nil {
+		return errors.Wrap(err, "new binary index header writer")
+	}
+	defer runutil.CloseWithErrCapture(&err, bw, "close binary writer for %s", tmpFilename)
+
+	if err := bw.AddIndexMeta(indexVersion, ir.toc.PostingsTable); err != nil {
+		return errors.Wrap(err, "add index meta")
+	}
+
+	if err := bw.f.Flush(); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+
+	// The symbols are followed by the postings offset table, which is copied along with the index TOC
+	// up to the end of the index, exactly like WriteBinary does.
+	symbolsLen := ir.toc.Series - ir.toc.Symbols
+	postingsLen := ir.size - ir.toc.PostingsTable
+	bw.toc.Symbols = bw.f.Pos()
+	bw.toc.PostingsOffsetTable = bw.toc.Symbols + symbolsLen
+	end := bw.toc.PostingsOffsetTable + postingsLen
+
+	parts := splitIndexRange(nil, ir.toc.Symbols, bw.toc.Symbols, symbolsLen, partSize)
+	if version == BinaryFormatV2 {
+		end = bw.toc.PostingsOffsetTable
+	} else {
+		parts = splitIndexRange(parts, ir.toc.PostingsTable, bw.toc.PostingsOffsetTable, postingsLen, partSize)
+	}
+
+	err = concurrency.ForEachJob(ctx, len(parts), concurrencyLimit, func(ctx context.Context, idx int) error {
+		return ir.copyRangeAt(ctx, parts[idx], bw.f.f)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Move the file writer after the sections written concurrently, to append the index-header TOC.
+	if _, err := bw.f.f.Seek(int64(end), io.SeekStart); err != nil {
+		return errors.Wrap(err, "seek")
+	}
+	bw.f.pos = end
+
+	if version == BinaryFormatV2 {
+		if err := bw.WriteCompressedPostingOffsets(ir); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.WriteTOC(); err != nil {
+		return errors.Wrap(err, "write index header TOC")
+	}
+
+	if err := bw.f.Flush(); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+
+	if err := bw.f.f.Sync(); err != nil {
+		return errors.Wrap(err, "sync")
+	}
+
+	// Create index-header in atomic way, to avoid partial writes (e.g during restart or crash of store GW).
+	return os.Rename(tmpFilename, filename)
+}
+
+// newChunkedIndexReaderParallel is like newChunkedIndexReader, but reads the index header and TOC concurrently.
+func newChunkedIndexReaderParallel(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (*chunkedIndexReader, int, error) {
+	ir := &chunkedIndexReader{
+		ctx:  ctx,
+		path: filepath.Join(id.String(), block.IndexFilename),
+		bkt:  bkt,
+	}
+
+	var version int
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		version, err = readIndexVersion(gctx, bkt, ir.path)
+		return err
+	})
+	g.Go(func() error {
+		attrs, err := bkt.Attributes(gctx, ir.path)
+		if err != nil {
+			return errors.Wrapf(err, "get object attributes of %s", ir.path)
+		}
+		ir.size = uint64(attrs.Size)
+
+		toc, err := ir.readTOC()
+		if err != nil {
+			return err
+		}
+		ir.toc = toc
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, 0, err
+	}
+
+	return ir, version, nil
+}
+
+// indexRange is a range of the block index, and the position it's copied to in the index-header file.
+type indexRange struct {
+	indexOffset  uint64
+	headerOffset uint64
+	length       uint64
+}
+
+// splitIndexRange appends to parts the ranges of at most partSize bytes the input range is made of.
+func splitIndexRange(parts []indexRange, indexOffset, headerOffset, length, partSize uint64) []indexRange {
+	for done := uint64(0); done < length; done += partSize {
+		partLen := partSize
+		if length-done < partSize {
+			partLen = length - done
+		}
+		parts = append(parts, indexRange{indexOffset: indexOffset + done, headerOffset: headerOffset + done, length: partLen})
+	}
+	return parts
+}
+
+// copyRangeAt downloads the range r of the index, and writes it to f at its position in the index-header.
+func (r *chunkedIndexReader) copyRangeAt(ctx context.Context, rng indexRange, f io.WriterAt) (err error) {
+	rc, err := r.bkt.GetRange(ctx, r.path, int64(rng.indexOffset), int64(rng.length))
+	if err != nil {
+		return errors.Wrapf(err, "get range %d-%d from object storage of %s", rng.indexOffset, rng.indexOffset+rng.length, r.path)
+	}
+	defer runutil.CloseWithErrCapture(&err, rc, "close range reader")
+
+	w := &offsetWriter{w: f, off: int64(rng.headerOffset)}
+	n, err := io.CopyBuffer(w, rc, make([]byte, 32*1024))
+	if err != nil {
+		return errors.Wrapf(err, "copy range %d-%d", rng.indexOffset, rng.indexOffset+rng.length)
+	}
+	if uint64(n) != rng.length {
+		return errors.Errorf("copied %d bytes instead of %d for range %d-%d of %s", n, rng.length, rng.indexOffset, rng.indexOffset+rng.length, r.path)
+	}
+
+	return nil
+}
+
+// offsetWriter is an io.Writer writing sequentially to an io.WriterAt, starting from off.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.w.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
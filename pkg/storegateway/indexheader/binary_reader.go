@@ -16,15 +16,15 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
-	"time"
 	"unsafe"
 
 	"github.com/go-kit/log"
-	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/runutil"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb/encoding"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
@@ -34,11 +34,18 @@ import (
 
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	mmap "github.com/grafana/mimir/pkg/storegateway/indexheader/fileutil"
+	streamindex "github.com/grafana/mimir/pkg/storegateway/indexheader/index"
 )
 
 const (
 	// BinaryFormatV1 represents first version of index-header file.
 	BinaryFormatV1 = 1
+	// BinaryFormatV2 represents the version of index-header file storing the postings offset table compressed.
+	// It's only used for the index v2, since the postings offset table of the index v1 isn't sorted.
+	BinaryFormatV2 = 2
+	// BinaryFormatV3 represents the version of index-header file holding an index-header file of version 1
+	// compressed with zstd. It's only read by the StreamBinaryReader.
+	BinaryFormatV3 = 3
 
 	indexTOCLen  = 6*8 + crc32.Size
 	binaryTOCLen = 2*8 + crc32.Size
@@ -76,16 +83,31 @@ type BinaryTOC struct {
 
 // WriteBinary build index-header file from the pieces of index in object storage.
 func WriteBinary(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, filename string) (err error) {
+	return writeBinaryWithFormat(ctx, bkt, id, filename, BinaryFormatV1)
+}
+
+// binaryFormatVersion returns the version of the index-header file built for an index with indexVersion,
+// when formatVersion is configured.
+func binaryFormatVersion(formatVersion, indexVersion int) int {
+	if formatVersion == BinaryFormatV2 && indexVersion == index.FormatV2 {
+		return BinaryFormatV2
+	}
+	return BinaryFormatV1
+}
+
+// writeBinaryWithFormat is like WriteBinary, but builds an index-header file with formatVersion if supported by the index.
+func writeBinaryWithFormat(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, filename string, formatVersion int) (err error) {
 	ir, indexVersion, err := newChunkedIndexReader(ctx, bkt, id)
 	if err != nil {
 		return errors.Wrap(err, "new index reader")
 	}
 	tmpFilename := filename + ".tmp"
+	version := binaryFormatVersion(formatVersion, indexVersion)
 
 	// Buffer for copying and encbuffers.
 	// This also will control the size of file writer buffer.
 	buf := make([]byte, 32*1024)
-	bw, err := newBinaryWriter(tmpFilename, buf)
+	bw, err := newBinaryWriter(tmpFilename, version, buf)
 	if err != nil {
 		return errors.Wrap(err, "new binary index header writer")
 	}
@@ -103,7 +125,12 @@ func WriteBinary(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, f
 		return errors.Wrap(err, "flush")
 	}
 
-	if err := ir.CopyPostingsOffsets(bw.PostingOffsetsWriter(), buf); err != nil {
+	if version == BinaryFormatV2 {
+		err = bw.WriteCompressedPostingOffsets(ir)
+	} else {
+		err = ir.CopyPostingsOffsets(bw.PostingOffsetsWriter(), buf)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -142,45 +169,55 @@ func newChunkedIndexReader(ctx context.Context, bkt objstore.BucketReader, id ul
 		return nil, 0, errors.Wrapf(err, "get object attributes of %s", indexFilepath)
 	}
 
+	version, err := readIndexVersion(ctx, bkt, indexFilepath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ir := &chunkedIndexReader{
+		ctx:  ctx,
+		path: indexFilepath,
+		size: uint64(attrs.Size),
+		bkt:  bkt,
+	}
+
+	toc, err := ir.readTOC()
+	if err != nil {
+		return nil, 0, err
+	}
+	ir.toc = toc
+
+	return ir, version, nil
+}
+
+// readIndexVersion reads the header of the index at indexFilepath, and returns the index format version.
+func readIndexVersion(ctx context.Context, bkt objstore.BucketReader, indexFilepath string) (version int, err error) {
 	rc, err := bkt.GetRange(ctx, indexFilepath, 0, index.HeaderLen)
 	if err != nil {
-		return nil, 0, errors.Wrapf(err, "get TOC from object storage of %s", indexFilepath)
+		return 0, errors.Wrapf(err, "get TOC from object storage of %s", indexFilepath)
 	}
 
 	b, err := io.ReadAll(rc)
 	if err != nil {
 		runutil.CloseWithErrCapture(&err, rc, "close reader")
-		return nil, 0, errors.Wrapf(err, "get header from object storage of %s", indexFilepath)
+		return 0, errors.Wrapf(err, "get header from object storage of %s", indexFilepath)
 	}
 
 	if err := rc.Close(); err != nil {
-		return nil, 0, errors.Wrap(err, "close reader")
+		return 0, errors.Wrap(err, "close reader")
 	}
 
 	if m := binary.BigEndian.Uint32(b[0:4]); m != index.MagicIndex {
-		return nil, 0, errors.Errorf("invalid magic number %x for %s", m, indexFilepath)
+		return 0, errors.Errorf("invalid magic number %x for %s", m, indexFilepath)
 	}
 
-	version := int(b[4:5][0])
+	version = int(b[4:5][0])
 
 	if version != index.FormatV1 && version != index.FormatV2 {
-		return nil, 0, errors.Errorf("not supported index file version %d of %s", version, indexFilepath)
+		return 0, errors.Errorf("not supported index file version %d of %s", version, indexFilepath)
 	}
 
-	ir := &chunkedIndexReader{
-		ctx:  ctx,
-		path: indexFilepath,
-		size: uint64(attrs.Size),
-		bkt:  bkt,
-	}
-
-	toc, err := ir.readTOC()
-	if err != nil {
-		return nil, 0, err
-	}
-	ir.toc = toc
-
-	return ir, version, nil
+	return version, nil
 }
 
 func (r *chunkedIndexReader) readTOC() (*index.TOC, error) {
@@ -246,7 +283,7 @@ type binaryWriter struct {
 	crc32 hash.Hash
 }
 
-func newBinaryWriter(fn string, buf []byte) (w *binaryWriter, err error) {
+func newBinaryWriter(fn string, version int, buf []byte) (w *binaryWriter, err error) {
 	dir := filepath.Dir(fn)
 
 	df, err := fileutil.OpenDir(dir)
@@ -286,7 +323,7 @@ func newBinaryWriter(fn string, buf []byte) (w *binaryWriter, err error) {
 
 	w.buf.Reset()
 	w.buf.PutBE32(MagicIndex)
-	w.buf.PutByte(BinaryFormatV1)
+	w.buf.PutByte(byte(version))
 
 	return w, w.f.Write(w.buf.Get())
 }
@@ -438,6 +475,8 @@ type BinaryReader struct {
 	postings map[string]*postingValueOffsets
 	// For the v1 format, labelname -> labelvalue -> offset.
 	postingsV1 map[string]map[string]index.Range
+	// For the index-header format v2, the compressed postings offset table.
+	compressedPostings *streamindex.CompressedPostingOffsetTable
 
 	// Symbols struct that keeps only 1/postingOffsetsInMemSampling in the memory, then looks up the rest via mmap.
 	symbols *index.Symbols
@@ -461,21 +500,16 @@ type BinaryReader struct {
 
 // NewBinaryReader loads or builds new index-header if not present on disk.
 func NewBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID, postingOffsetsInMemSampling int, cfg Config) (*BinaryReader, error) {
-	binfn := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
-	br, err := newFileBinaryReader(binfn, postingOffsetsInMemSampling, cfg)
-	if err == nil {
-		return br, nil
-	}
-
-	level.Debug(logger).Log("msg", "failed to read index-header from disk; recreating", "path", binfn, "err", err)
-
-	start := time.Now()
-	if err := WriteBinary(ctx, bkt, id, binfn); err != nil {
-		return nil, errors.Wrap(err, "write index header")
-	}
+	return newBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, cfg, nil)
+}
 
-	level.Debug(logger).Log("msg", "built index-header file", "path", binfn, "elapsed", time.Since(start))
-	return newFileBinaryReader(binfn, postingOffsetsInMemSampling, cfg)
+// newBinaryReader is like NewBinaryReader, but increments healed each time a local
+// index-header which can't be read is rebuilt.
+func newBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID, postingOffsetsInMemSampling int, cfg Config, healed prometheus.Counter) (*BinaryReader, error) {
+	binfn := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+	return openOrBuild(ctx, logger, bkt, id, binfn, cfg, healed, func() (*BinaryReader, error) {
+		return newFileBinaryReader(binfn, postingOffsetsInMemSampling, cfg)
+	})
 }
 
 func newFileBinaryReader(path string, postingOffsetsInMemSampling int, cfg Config) (bw *BinaryReader, err error) {
@@ -508,7 +542,10 @@ func newFileBinaryReader(path string, postingOffsetsInMemSampling int, cfg Confi
 
 	r.indexLastPostingEnd = int64(binary.BigEndian.Uint64(r.b.Range(6, headerLen)))
 
-	if r.version != BinaryFormatV1 {
+	if r.version == BinaryFormatV3 {
+		return nil, errors.Errorf("index header file version %d is only supported by the streaming reader", r.version)
+	}
+	if r.version != BinaryFormatV1 && r.version != BinaryFormatV2 {
 		return nil, errors.Errorf("unknown index header file version %d", r.version)
 	}
 
@@ -523,7 +560,21 @@ func newFileBinaryReader(path string, postingOffsetsInMemSampling int, cfg Confi
 		return nil, errors.Wrap(err, "read symbols")
 	}
 
-	if r.indexVersion == index.FormatV1 {
+	if r.version == BinaryFormatV2 {
+		r.compressedPostings, err = streamindex.NewCompressedPostingOffsetTableFromByteSlice(r.b, int(r.toc.PostingsOffsetTable))
+		if err != nil {
+			return nil, errors.Wrap(err, "read postings table")
+		}
+
+		// Label names are used to get a list of labelnames in places.
+		labelNames, err := r.compressedPostings.LabelNames()
+		if err != nil {
+			return nil, errors.Wrap(err, "read postings table")
+		}
+		for _, name := range labelNames {
+			r.postings[name] = nil
+		}
+	} else if r.indexVersion == index.FormatV1 {
 		var lastLbl labels.Label
 		lastSet := false
 		// Earlier V1 formats don't have a sorted postings offset table, so
@@ -675,6 +726,10 @@ func skipNAndName(d *encoding.Decbuf, buf *int) {
 	d.Skip(*buf)
 }
 func (r *BinaryReader) postingsOffset(name string, values ...string) ([]index.Range, error) {
+	if r.compressedPostings != nil {
+		return r.compressedPostings.PostingsOffset(name, values...)
+	}
+
 	rngs := make([]index.Range, 0, len(values))
 	if r.indexVersion == index.FormatV1 {
 		e, ok := r.postingsV1[name]
@@ -844,6 +899,13 @@ func (r *BinaryReader) LookupSymbol(o uint32) (string, error) {
 }
 
 func (r *BinaryReader) LabelValues(name string, filter func(string) bool) ([]string, error) {
+	return r.LabelValuesWithPrefix(name, "", filter)
+}
+
+func (r *BinaryReader) LabelValuesWithPrefix(name, prefix string, filter func(string) bool) ([]string, error) {
+	if r.compressedPostings != nil {
+		return r.compressedPostings.LabelValues(name, prefix, filter)
+	}
 	if r.indexVersion == index.FormatV1 {
 		e, ok := r.postingsV1[name]
 		if !ok {
@@ -851,7 +913,7 @@ func (r *BinaryReader) LabelValues(name string, filter func(string) bool) ([]str
 		}
 		values := make([]string, 0, len(e))
 		for k := range e {
-			if filter == nil || filter(k) {
+			if strings.HasPrefix(k, prefix) && (filter == nil || filter(k)) {
 				values = append(values, k)
 			}
 		}
@@ -866,10 +928,24 @@ func (r *BinaryReader) LabelValues(name string, filter func(string) bool) ([]str
 	if len(e.offsets) == 0 {
 		return nil, nil
 	}
-	values := make([]string, 0, len(e.offsets)*r.postingOffsetsInMemSampling)
+
+	// The values are sorted, so the ones starting with prefix can only be found after the last
+	// sampled value smaller than prefix.
+	start := 0
+	if prefix != "" {
+		start = sort.Search(len(e.offsets), func(i int) bool { return e.offsets[i].value >= prefix })
+		if start == len(e.offsets) {
+			// All values are smaller than prefix.
+			return nil, nil
+		}
+		if start > 0 {
+			start--
+		}
+	}
+	values := make([]string, 0, (len(e.offsets)-start)*r.postingOffsetsInMemSampling)
 
 	d := encoding.NewDecbufAt(r.b, int(r.toc.PostingsOffsetTable), nil)
-	d.Skip(e.offsets[0].tableOff)
+	d.Skip(e.offsets[start].tableOff)
 	lastVal := e.offsets[len(e.offsets)-1].value
 
 	skip := 0
@@ -885,7 +961,11 @@ func (r *BinaryReader) LabelValues(name string, filter func(string) bool) ([]str
 			d.Skip(skip)
 		}
 		s := yoloString(d.UvarintBytes()) // Label value.
-		if filter == nil || filter(s) {
+		if prefix != "" && !strings.HasPrefix(s, prefix) && s > prefix {
+			// The values are sorted, so no further value can start with prefix.
+			break
+		}
+		if strings.HasPrefix(s, prefix) && (filter == nil || filter(s)) {
 			values = append(values, s)
 		}
 		if s == lastVal {
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+
+	streamencoding "github.com/grafana/mimir/pkg/storegateway/indexheader/encoding"
+)
+
+// compressBinary writes at filename the index-header file of version 3 holding the index-header file
+// at uncompressedFilename, compressed like described by streamencoding.ZstdWriter. The header of the
+// index-header of version 3 is the same as the uncompressed one, except for the version.
+func compressBinary(uncompressedFilename, filename string) (err error) {
+	src, err := os.Open(filepath.Clean(uncompressedFilename))
+	if err != nil {
+		return errors.Wrap(err, "open uncompressed index header")
+	}
+	defer runutil.CloseWithErrCapture(&err, src, "close uncompressed index header %s", uncompressedFilename)
+
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return errors.Wrap(err, "read uncompressed index header's header")
+	}
+	if m := binary.BigEndian.Uint32(header[0:4]); m != MagicIndex {
+		return errors.Errorf("invalid magic number %x", m)
+	}
+	header[4] = BinaryFormatV3
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "seek uncompressed index header")
+	}
+
+	tmpFilename := filename + ".tmp"
+	f, err := os.OpenFile(filepath.Clean(tmpFilename), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "create compressed index header")
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close compressed index header %s", tmpFilename)
+
+	bw := bufio.NewWriterSize(f, 32*1024)
+	zw, err := streamencoding.NewZstdWriter(bw, header)
+	if err != nil {
+		return errors.Wrap(err, "new compressed index header writer")
+	}
+	if _, err := io.CopyBuffer(zw, src, make([]byte, 32*1024)); err != nil {
+		return errors.Wrap(err, "compress index header")
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrap(err, "compress index header")
+	}
+
+	if err := bw.Flush(); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+	if err := f.Sync(); err != nil {
+		return errors.Wrap(err, "sync")
+	}
+
+	// Create index-header in atomic way, to avoid partial writes (e.g during restart or crash of store GW).
+	return os.Rename(tmpFilename, filename)
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package encoding
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/grafana/dskit/runutil"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+)
+
+// bucketPageSize is the size, in bytes, of the pages in which an object is read from the bucket.
+const bucketPageSize = 64 * 1024
+
+// bucketReader reads the contents of an object in the bucket through range requests, fetching
+// them in pages of bucketPageSize bytes and keeping the most recently used pages in memory.
+//
+// bucketReader is safe for concurrent use, so the same reader is returned to all the decoding
+// buffers of a DecbufFactory: it implements readerPool too.
+type bucketReader struct {
+	bkt  objstore.BucketReader
+	name string
+	len  int64
+
+	// pages maps the index of a page to its contents. It's nil if pages caching is disabled.
+	pages *lru.Cache
+
+	mtx     sync.RWMutex
+	stopped bool
+
+	pageFetches   prometheus.Counter
+	pageCacheHits prometheus.Counter
+}
+
+// newBucketReader creates a new bucketReader for the object name in bkt, whose size is len bytes.
+// If maxCachedPages is 0, pages are not cached and every read fetches them from the bucket.
+func newBucketReader(bkt objstore.BucketReader, name string, len int64, maxCachedPages int, pageFetches prometheus.Counter, pageCacheHits prometheus.Counter) (*bucketReader, error) {
+	r := &bucketReader{
+		bkt:           bkt,
+		name:          name,
+		len:           len,
+		pageFetches:   pageFetches,
+		pageCacheHits: pageCacheHits,
+	}
+
+	if maxCachedPages > 0 {
+		pages, err := lru.New(maxCachedPages)
+		if err != nil {
+			return nil, err
+		}
+		r.pages = pages
+	}
+
+	return r, nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *bucketReader) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.Errorf("invalid negative offset %d reading %s", off, r.name)
+	}
+
+	n := 0
+	for n < len(b) {
+		pos := off + int64(n)
+		if pos >= r.len {
+			return n, io.EOF
+		}
+
+		page, err := r.page(pos / bucketPageSize)
+		if err != nil {
+			return n, err
+		}
+
+		n += copy(b[n:], page[pos%bucketPageSize:])
+	}
+
+	return n, nil
+}
+
+// page returns the contents of the page at index idx, from the cache if available.
+func (r *bucketReader) page(idx int64) ([]byte, error) {
+	if r.pages != nil {
+		if page, ok := r.pages.Get(idx); ok {
+			r.pageCacheHits.Inc()
+			return page.([]byte), nil
+		}
+	}
+
+	start := idx * bucketPageSize
+	length := r.len - start
+	if length > bucketPageSize {
+		length = bucketPageSize
+	}
+
+	r.pageFetches.Inc()
+
+	// The reader outlives the request for which it has been created, so the range
+	// requests are not bound to the context of any request.
+	rc, err := r.bkt.GetRange(context.Background(), r.name, start, length)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get range [%d, %d) of %s", start, start+length, r.name)
+	}
+
+	page := make([]byte, length)
+	if _, err = io.ReadFull(rc, page); err != nil {
+		runutil.CloseWithErrCapture(&err, rc, "close range reader")
+		return nil, errors.Wrapf(err, "read range [%d, %d) of %s", start, start+length, r.name)
+	}
+
+	if err := rc.Close(); err != nil {
+		return nil, errors.Wrap(err, "close range reader")
+	}
+
+	if r.pages != nil {
+		r.pages.Add(idx, page)
+	}
+
+	return page, nil
+}
+
+// get returns the reader itself, unless it has been stopped.
+func (r *bucketReader) get() (io.ReaderAt, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if r.stopped {
+		return nil, ErrPoolStopped
+	}
+
+	return r, nil
+}
+
+// put is a no-op, because the reader is shared and has no handle to close.
+func (r *bucketReader) put(io.ReaderAt) error {
+	return nil
+}
+
+// size returns the size of the object in the bucket.
+func (r *bucketReader) size(io.ReaderAt) (int64, error) {
+	return r.len, nil
+}
+
+// stop drops the cached pages. After this method is called, subsequent get calls will return an error.
+func (r *bucketReader) stop() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.stopped = true
+
+	if r.pages != nil {
+		r.pages.Purge()
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package encoding
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestBucketReader_ReadAt(t *testing.T) {
+	// Make the object span 3 pages, the last one partially.
+	contents := make([]byte, 2*bucketPageSize+100)
+	for i := range contents {
+		contents[i] = byte(i % 251)
+	}
+
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, bkt.Upload(context.Background(), "index", bytes.NewReader(contents)))
+
+	tests := map[string]struct {
+		off         int64
+		len         int
+		expectedLen int
+		expectedErr error
+	}{
+		"read within a page": {
+			off:         10,
+			len:         100,
+			expectedLen: 100,
+		},
+		"read across pages": {
+			off:         bucketPageSize - 10,
+			len:         bucketPageSize + 20,
+			expectedLen: bucketPageSize + 20,
+		},
+		"read up to the end of the object": {
+			off:         int64(len(contents)) - 50,
+			len:         50,
+			expectedLen: 50,
+		},
+		"read beyond the end of the object": {
+			off:         int64(len(contents)) - 50,
+			len:         100,
+			expectedLen: 50,
+			expectedErr: io.EOF,
+		},
+		"read at the end of the object": {
+			off:         int64(len(contents)),
+			len:         10,
+			expectedLen: 0,
+			expectedErr: io.EOF,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			r, err := newBucketReader(bkt, "index", int64(len(contents)), 2, prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}))
+			require.NoError(t, err)
+
+			b := make([]byte, testData.len)
+			n, err := r.ReadAt(b, testData.off)
+			assert.Equal(t, testData.expectedErr, err)
+			require.Equal(t, testData.expectedLen, n)
+			assert.Equal(t, contents[testData.off:testData.off+int64(n)], b[:n])
+		})
+	}
+}
+
+func TestBucketReader_ShouldCachePages(t *testing.T) {
+	contents := make([]byte, 3*bucketPageSize)
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, bkt.Upload(context.Background(), "index", bytes.NewReader(contents)))
+
+	fetches := prometheus.NewCounter(prometheus.CounterOpts{})
+	hits := prometheus.NewCounter(prometheus.CounterOpts{})
+	r, err := newBucketReader(bkt, "index", int64(len(contents)), 2, fetches, hits)
+	require.NoError(t, err)
+
+	b := make([]byte, 10)
+	for _, off := range []int64{0, 10, bucketPageSize, 2 * bucketPageSize, 0} {
+		_, err := r.ReadAt(b, off)
+		require.NoError(t, err)
+	}
+
+	// The first page has been evicted by the third one, so it has been fetched twice.
+	assert.Equal(t, float64(4), promtestutil.ToFloat64(fetches))
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(hits))
+
+	// Once stopped, the reader can't be used anymore.
+	r.stop()
+	_, err = r.get()
+	assert.ErrorIs(t, err, ErrPoolStopped)
+}
@@ -115,6 +115,23 @@ func (d *Decbuf) UvarintStr() string {
 	return string(d.UnsafeUvarintBytes())
 }
 
+// Bytes reads the given number of bytes into a byte slice and consumes them. The byte slice
+// returned allocates its own memory and may be used after subsequent reads from the Decbuf.
+// If E is non-nil, this method returns a nil byte slice.
+func (d *Decbuf) Bytes(n int) []byte {
+	if d.E != nil {
+		return nil
+	}
+
+	b, err := d.r.read(n)
+	if err != nil {
+		d.E = err
+		return nil
+	}
+
+	return b
+}
+
 // UnsafeUvarintBytes reads varint prefixed bytes into a byte slice consuming them but without
 // allocating. The bytes returned are NO LONGER VALID after subsequent reads from the Decbuf.
 // If E is non-nil, this method returns an empty byte slice.
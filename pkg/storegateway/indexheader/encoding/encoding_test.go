@@ -619,6 +619,25 @@ func FuzzDecbuf_UvarintStr(f *testing.F) {
 	})
 }
 
+func TestDecbuf_BytesHappyPath(t *testing.T) {
+	dec := createDecbufWithBytes(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05})
+
+	actual := dec.Bytes(3)
+	require.NoError(t, dec.Err())
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, actual)
+	require.Equal(t, 2, dec.Len())
+
+	// The bytes returned should still be valid after further reads.
+	require.Equal(t, byte(0x04), dec.Byte())
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, actual)
+}
+
+func TestDecbuf_BytesInsufficientBuffer(t *testing.T) {
+	dec := createDecbufWithBytes(t, []byte{0x01, 0x02})
+	_ = dec.Bytes(3)
+	require.ErrorIs(t, dec.Err(), ErrInvalidSize)
+}
+
 func TestDecbuf_Crc32(t *testing.T) {
 	table := crc32.MakeTable(crc32.Castagnoli)
 
@@ -5,6 +5,7 @@ package encoding
 import (
 	"encoding/binary"
 	"hash/crc32"
+	"io"
 	"os"
 	"sync"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
 )
 
 var ErrPoolStopped = errors.New("file handle pool is stopped")
@@ -22,6 +24,11 @@ type DecbufFactoryMetrics struct {
 	pooledOpenCount  prometheus.Counter
 	closeCount       prometheus.Counter
 	pooledCloseCount prometheus.Counter
+	pageFetchCount   prometheus.Counter
+	pageCacheHits    prometheus.Counter
+
+	frameDecompressions prometheus.Counter
+	frameCacheHits      prometheus.Counter
 }
 
 func NewDecbufFactoryMetrics(reg prometheus.Registerer) *DecbufFactoryMetrics {
@@ -42,12 +49,41 @@ func NewDecbufFactoryMetrics(reg prometheus.Registerer) *DecbufFactoryMetrics {
 			Name: "indexheader_stream_pooled_close_total",
 			Help: "Total number of times pooled index-header file handle has been returned to the pool instead of closed.",
 		}),
+		pageFetchCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_stream_bucket_page_fetches_total",
+			Help: "Total number of pages of the index fetched from the bucket when reading the index-header directly from the bucket.",
+		}),
+		pageCacheHits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_stream_bucket_page_cache_hits_total",
+			Help: "Total number of pages of the index read from the in-memory page cache instead of fetched from the bucket when reading the index-header directly from the bucket.",
+		}),
+		frameDecompressions: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_stream_compressed_frame_decompressions_total",
+			Help: "Total number of frames of zstd-compressed index-header files decompressed.",
+		}),
+		frameCacheHits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_stream_compressed_frame_cache_hits_total",
+			Help: "Total number of frames of zstd-compressed index-header files read from the in-memory frame cache instead of decompressed.",
+		}),
 	}
 }
 
+// readerPool provides the readers used to access the contents of an index-header,
+// and takes them back once they're not used anymore.
+type readerPool interface {
+	poolCloser
+
+	get() (io.ReaderAt, error)
+
+	// size returns the size, in bytes, of the contents accessible through r.
+	size(r io.ReaderAt) (int64, error)
+
+	stop()
+}
+
 // DecbufFactory creates new file-backed decoding buffer instances for a specific index-header file.
 type DecbufFactory struct {
-	files *filePool
+	files readerPool
 }
 
 func NewDecbufFactory(path string, maxIdleFileHandles uint, logger log.Logger, metrics *DecbufFactoryMetrics) *DecbufFactory {
@@ -64,6 +100,29 @@ func NewDecbufFactory(path string, maxIdleFileHandles uint, logger log.Logger, m
 	}
 }
 
+// NewBucketDecbufFactory returns a DecbufFactory creating decoding buffers which read the object name,
+// whose size is size bytes, directly from the bucket through range requests, keeping up to maxCachedPages
+// of the read pages in memory.
+func NewBucketDecbufFactory(bkt objstore.BucketReader, name string, size int64, maxCachedPages int, metrics *DecbufFactoryMetrics) (*DecbufFactory, error) {
+	r, err := newBucketReader(bkt, name, size, maxCachedPages, metrics.pageFetchCount, metrics.pageCacheHits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecbufFactory{files: r}, nil
+}
+
+// NewZstdDecbufFactory returns a DecbufFactory creating decoding buffers which read the uncompressed contents
+// of the file at path, written by ZstdWriter, keeping up to maxCachedFrames of the decompressed frames in memory.
+func NewZstdDecbufFactory(path string, maxCachedFrames int, metrics *DecbufFactoryMetrics) (*DecbufFactory, error) {
+	r, err := newZstdReader(path, maxCachedFrames, metrics.frameDecompressions, metrics.frameCacheHits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecbufFactory{files: r}, nil
+}
+
 // NewDecbufAtChecked returns a new file-backed decoding buffer positioned at offset + 4 bytes.
 // It expects the first 4 bytes after offset to hold the big endian encoded content length, followed
 // by the contents and the expected checksum. This method checks the CRC of the content and will
@@ -148,12 +207,11 @@ func (df *DecbufFactory) NewRawDecbuf() Decbuf {
 		}
 	}()
 
-	stat, err := f.Stat()
+	fileSize, err := df.files.size(f)
 	if err != nil {
 		return Decbuf{E: errors.Wrap(err, "stat file for decbuf")}
 	}
 
-	fileSize := stat.Size()
 	reader, err := newFileReader(f, 0, int(fileSize), df.files)
 	if err != nil {
 		return Decbuf{E: errors.Wrap(err, "file reader for decbuf")}
@@ -206,7 +264,7 @@ func newFilePool(path string, cap uint, logger log.Logger, opens prometheus.Coun
 // get returns a pooled file handle if available or opens a new one if there
 // are no pooled handles available. If this pool has been stopped, an error
 // is returned.
-func (p *filePool) get() (*os.File, error) {
+func (p *filePool) get() (io.ReaderAt, error) {
 	p.mtx.RLock()
 	defer p.mtx.RUnlock()
 
@@ -220,14 +278,20 @@ func (p *filePool) get() (*os.File, error) {
 		return f, nil
 	default:
 		p.opens.Inc()
-		return os.Open(p.path)
+		f, err := os.Open(p.path)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
 	}
 }
 
 // put returns a file handle to the pool if there is space available or closes
 // the file handle if there is not. If this pool has been stopped, the file handle
 // is closed immediately.
-func (p *filePool) put(f *os.File) error {
+func (p *filePool) put(r io.ReaderAt) error {
+	f := r.(*os.File)
+
 	p.mtx.RLock()
 	defer p.mtx.RUnlock()
 
@@ -245,6 +309,15 @@ func (p *filePool) put(f *os.File) error {
 	}
 }
 
+// size returns the size of the file opened by the handle r.
+func (p *filePool) size(r io.ReaderAt) (int64, error) {
+	stat, err := r.(*os.File).Stat()
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
+
 // stop closes all pooled file handles. After this method is called, subsequent
 // get calls will return an error and put calls will immediately close the file
 // handle.
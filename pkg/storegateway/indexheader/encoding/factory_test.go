@@ -98,12 +98,12 @@ func TestDecbufFactory_NewDecbufAtChecked_MultipleInstances(t *testing.T) {
 
 	d1 := factory.NewDecbufAtChecked(0, table)
 	require.NoError(t, d1.Err())
-	fd1 := d1.r.file.Fd()
+	fd1 := d1.r.file.(*os.File).Fd()
 	require.NoError(t, d1.Close())
 
 	d2 := factory.NewDecbufAtChecked(0, table)
 	require.NoError(t, d2.Err())
-	fd2 := d2.r.file.Fd()
+	fd2 := d2.r.file.(*os.File).Fd()
 	require.NoError(t, d2.Close())
 
 	require.Equal(t, fd1, fd2, "expected Decbuf instances to use the same file descriptor")
@@ -7,7 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"math"
 	"sync"
 )
 
@@ -16,11 +16,11 @@ import (
 const readerBufferSize = 4096
 
 type poolCloser interface {
-	put(*os.File) error
+	put(io.ReaderAt) error
 }
 
 type fileReader struct {
-	file   *os.File
+	file   io.ReaderAt
 	closer poolCloser
 	buf    *bufio.Reader
 	base   int
@@ -36,7 +36,7 @@ var bufferPool = sync.Pool{
 
 // newFileReader creates a new fileReader for the segment of file beginning at base bytes,
 // extending length bytes, and closing the handle with closer.
-func newFileReader(file *os.File, base, length int, closer poolCloser) (*fileReader, error) {
+func newFileReader(file io.ReaderAt, base, length int, closer poolCloser) (*fileReader, error) {
 	f := &fileReader{
 		file:   file,
 		closer: closer,
@@ -68,12 +68,10 @@ func (f *fileReader) resetAt(off int) error {
 		return ErrInvalidSize
 	}
 
-	_, err := f.file.Seek(int64(f.base+off), io.SeekStart)
-	if err != nil {
-		return err
-	}
-
-	f.buf.Reset(f.file)
+	// The section extends up to the end of the underlying file, because callers
+	// expect to be able to peek past the end of the file segment.
+	start := int64(f.base + off)
+	f.buf.Reset(io.NewSectionReader(f.file, start, math.MaxInt64-start))
 	f.pos = off
 
 	return nil
@@ -3,6 +3,7 @@
 package encoding
 
 import (
+	"io"
 	"os"
 	"path"
 	"testing"
@@ -12,8 +13,8 @@ import (
 
 type closer struct{}
 
-func (c closer) put(file *os.File) error {
-	return file.Close()
+func (c closer) put(file io.ReaderAt) error {
+	return file.(*os.File).Close()
 }
 
 func TestReaders_Read(t *testing.T) {
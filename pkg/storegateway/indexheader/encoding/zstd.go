@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package encoding
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// ZstdFrameSize is the max size, in bytes, of the uncompressed contents of each zstd frame of a
+	// compressed file. The frames are compressed independently, so that any range of the contents
+	// can be read by decompressing only the frames it spans.
+	ZstdFrameSize = 64 * 1024
+
+	// zstdTrailerLen is the length of the trailer at the end of a compressed file: the uncompressed
+	// contents length <8b>, the number of frames <4b>, the offset of the frames index <8b> and the CRC32
+	// of the frames index and trailer <4b>.
+	zstdTrailerLen = 8 + 4 + 8 + crc32.Size
+)
+
+var (
+	zstdCRC32Table = crc32.MakeTable(crc32.Castagnoli)
+
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+)
+
+// getZstdDecoder returns the decoder shared by all the compressed files. It's only used through
+// DecodeAll, which is safe for concurrent use.
+func getZstdDecoder() (*zstd.Decoder, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	return zstdDecoder, zstdDecoderErr
+}
+
+// ZstdWriter writes a compressed file, whose contents can be randomly accessed through a
+// NewZstdDecbufFactory. The file is made of:
+//
+//   - a header, copied as is
+//   - the contents, split into zstd frames of up to ZstdFrameSize bytes
+//   - the frames index, holding the offset in the file of each frame as a big endian uint64
+//   - the trailer, see zstdTrailerLen
+type ZstdWriter struct {
+	w       io.Writer
+	enc     *zstd.Encoder
+	pos     uint64
+	buf     []byte
+	frame   []byte
+	offsets []uint64
+	length  uint64
+}
+
+// NewZstdWriter returns a ZstdWriter writing to w. The header is written to w straight away.
+func NewZstdWriter(w io.Writer, header []byte) (*ZstdWriter, error) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &ZstdWriter{
+		w:     w,
+		enc:   enc,
+		pos:   uint64(len(header)),
+		buf:   make([]byte, 0, ZstdFrameSize),
+		frame: make([]byte, 0, ZstdFrameSize),
+	}, nil
+}
+
+// Write implements io.Writer.
+func (w *ZstdWriter) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c := copy(w.buf[len(w.buf):cap(w.buf)], p[n:])
+		w.buf = w.buf[:len(w.buf)+c]
+		n += c
+
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flushFrame(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *ZstdWriter) flushFrame() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	w.frame = w.enc.EncodeAll(w.buf, w.frame[:0])
+	if _, err := w.w.Write(w.frame); err != nil {
+		return err
+	}
+
+	w.offsets = append(w.offsets, w.pos)
+	w.pos += uint64(len(w.frame))
+	w.length += uint64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close writes the last frame, the frames index and the trailer. It doesn't close the underlying writer.
+func (w *ZstdWriter) Close() error {
+	defer func() { _ = w.enc.Close() }()
+
+	if err := w.flushFrame(); err != nil {
+		return err
+	}
+
+	b := make([]byte, len(w.offsets)*8+zstdTrailerLen)
+	for i, off := range w.offsets {
+		binary.BigEndian.PutUint64(b[i*8:], off)
+	}
+	t := b[len(w.offsets)*8:]
+	binary.BigEndian.PutUint64(t[0:8], w.length)
+	binary.BigEndian.PutUint32(t[8:12], uint32(len(w.offsets)))
+	binary.BigEndian.PutUint64(t[12:20], w.pos)
+	binary.BigEndian.PutUint32(t[20:], crc32.Checksum(b[:len(b)-crc32.Size], zstdCRC32Table))
+
+	_, err := w.w.Write(b)
+	return err
+}
+
+// zstdReader reads the uncompressed contents of a file written by ZstdWriter, decompressing
+// the frames spanned by each read and keeping the most recently used frames in memory.
+//
+// zstdReader is safe for concurrent use, so the same reader is returned to all the decoding
+// buffers of a DecbufFactory: it implements readerPool too.
+type zstdReader struct {
+	f       *os.File
+	path    string
+	dec     *zstd.Decoder
+	len     int64
+	offsets []uint64
+	// framesEnd is the offset in the file where the last frame ends.
+	framesEnd uint64
+
+	// frames maps the index of a frame to its uncompressed contents. It's nil if frames caching is disabled.
+	frames *lru.Cache
+
+	mtx     sync.RWMutex
+	stopped bool
+
+	decompressions prometheus.Counter
+	cacheHits      prometheus.Counter
+}
+
+// newZstdReader opens the compressed file at path, and reads its frames index.
+func newZstdReader(path string, maxCachedFrames int, decompressions, cacheHits prometheus.Counter) (_ *zstdReader, err error) {
+	dec, err := getZstdDecoder()
+	if err != nil {
+		return nil, errors.Wrap(err, "create zstd decoder")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+		}
+	}()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < zstdTrailerLen {
+		return nil, errors.Wrapf(ErrInvalidSize, "compressed file %s trailer", path)
+	}
+
+	trailer := make([]byte, zstdTrailerLen)
+	if _, err := f.ReadAt(trailer, stat.Size()-zstdTrailerLen); err != nil {
+		return nil, errors.Wrapf(err, "read compressed file %s trailer", path)
+	}
+
+	length := binary.BigEndian.Uint64(trailer[0:8])
+	count := uint64(binary.BigEndian.Uint32(trailer[8:12]))
+	indexOffset := binary.BigEndian.Uint64(trailer[12:20])
+	if indexOffset+count*8+zstdTrailerLen != uint64(stat.Size()) {
+		return nil, errors.Wrapf(ErrInvalidSize, "compressed file %s frames index", path)
+	}
+
+	b := make([]byte, count*8+zstdTrailerLen-crc32.Size)
+	if _, err := f.ReadAt(b, int64(indexOffset)); err != nil {
+		return nil, errors.Wrapf(err, "read compressed file %s frames index", path)
+	}
+	if crc32.Checksum(b, zstdCRC32Table) != binary.BigEndian.Uint32(trailer[zstdTrailerLen-crc32.Size:]) {
+		return nil, errors.Wrapf(ErrInvalidChecksum, "compressed file %s frames index", path)
+	}
+
+	offsets := make([]uint64, count)
+	for i := range offsets {
+		offsets[i] = binary.BigEndian.Uint64(b[i*8:])
+	}
+
+	r := &zstdReader{
+		f:              f,
+		path:           path,
+		dec:            dec,
+		len:            int64(length),
+		offsets:        offsets,
+		framesEnd:      indexOffset,
+		decompressions: decompressions,
+		cacheHits:      cacheHits,
+	}
+
+	if maxCachedFrames > 0 {
+		frames, err := lru.New(maxCachedFrames)
+		if err != nil {
+			return nil, err
+		}
+		r.frames = frames
+	}
+
+	return r, nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *zstdReader) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.Errorf("invalid negative offset %d reading %s", off, r.path)
+	}
+
+	n := 0
+	for n < len(b) {
+		pos := off + int64(n)
+		if pos >= r.len {
+			return n, io.EOF
+		}
+
+		frame, err := r.frame(int(pos / ZstdFrameSize))
+		if err != nil {
+			return n, err
+		}
+		if int(pos%ZstdFrameSize) >= len(frame) {
+			return n, errors.Wrapf(ErrInvalidSize, "frame %d of compressed file %s", pos/ZstdFrameSize, r.path)
+		}
+
+		n += copy(b[n:], frame[pos%ZstdFrameSize:])
+	}
+
+	return n, nil
+}
+
+// frame returns the uncompressed contents of the frame at index idx, from the cache if available.
+func (r *zstdReader) frame(idx int) ([]byte, error) {
+	if r.frames != nil {
+		if frame, ok := r.frames.Get(idx); ok {
+			r.cacheHits.Inc()
+			return frame.([]byte), nil
+		}
+	}
+
+	if idx >= len(r.offsets) {
+		return nil, errors.Wrapf(ErrInvalidSize, "frame %d of compressed file %s", idx, r.path)
+	}
+
+	end := r.framesEnd
+	if idx+1 < len(r.offsets) {
+		end = r.offsets[idx+1]
+	}
+
+	compressed := make([]byte, end-r.offsets[idx])
+	if _, err := r.f.ReadAt(compressed, int64(r.offsets[idx])); err != nil {
+		return nil, errors.Wrapf(err, "read frame %d of compressed file %s", idx, r.path)
+	}
+
+	r.decompressions.Inc()
+	frame, err := r.dec.DecodeAll(compressed, make([]byte, 0, ZstdFrameSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "decompress frame %d of compressed file %s", idx, r.path)
+	}
+
+	if r.frames != nil {
+		r.frames.Add(idx, frame)
+	}
+
+	return frame, nil
+}
+
+// get returns the reader itself, unless it has been stopped.
+func (r *zstdReader) get() (io.ReaderAt, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if r.stopped {
+		return nil, ErrPoolStopped
+	}
+
+	return r, nil
+}
+
+// put is a no-op, because the reader is shared and the file is only closed when the reader is stopped.
+func (r *zstdReader) put(io.ReaderAt) error {
+	return nil
+}
+
+// size returns the length of the uncompressed contents.
+func (r *zstdReader) size(io.ReaderAt) (int64, error) {
+	return r.len, nil
+}
+
+// stop drops the cached frames and closes the file. After this method is called, subsequent get calls will return an error.
+func (r *zstdReader) stop() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+
+	if r.frames != nil {
+		r.frames.Purge()
+	}
+	_ = r.f.Close()
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package encoding
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeZstdFile(t *testing.T, header, contents []byte) string {
+	path := filepath.Join(t.TempDir(), "compressed")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	w, err := NewZstdWriter(f, header)
+	require.NoError(t, err)
+	// Write in chunks not aligned to the frames.
+	for off := 0; off < len(contents); off += 1000 {
+		end := off + 1000
+		if end > len(contents) {
+			end = len(contents)
+		}
+		_, err := w.Write(contents[off:end])
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+	return path
+}
+
+func TestZstdReader_ReadAt(t *testing.T) {
+	// Make the contents span 3 frames, the last one partially.
+	contents := make([]byte, 2*ZstdFrameSize+100)
+	for i := range contents {
+		contents[i] = byte(i % 251)
+	}
+	path := writeZstdFile(t, []byte("header"), contents)
+
+	// The contents should have been compressed.
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, stat.Size(), int64(len(contents)/10))
+
+	tests := map[string]struct {
+		off         int64
+		len         int
+		expectedLen int
+		expectedErr error
+	}{
+		"read within a frame": {
+			off:         10,
+			len:         100,
+			expectedLen: 100,
+		},
+		"read across frames": {
+			off:         ZstdFrameSize - 10,
+			len:         ZstdFrameSize + 20,
+			expectedLen: ZstdFrameSize + 20,
+		},
+		"read up to the end of the contents": {
+			off:         int64(len(contents)) - 50,
+			len:         50,
+			expectedLen: 50,
+		},
+		"read beyond the end of the contents": {
+			off:         int64(len(contents)) - 50,
+			len:         100,
+			expectedLen: 50,
+			expectedErr: io.EOF,
+		},
+		"read at the end of the contents": {
+			off:         int64(len(contents)),
+			len:         10,
+			expectedLen: 0,
+			expectedErr: io.EOF,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			r, err := newZstdReader(path, 2, prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}))
+			require.NoError(t, err)
+			t.Cleanup(r.stop)
+
+			size, err := r.size(r)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(contents)), size)
+
+			b := make([]byte, testData.len)
+			n, err := r.ReadAt(b, testData.off)
+			assert.Equal(t, testData.expectedErr, err)
+			require.Equal(t, testData.expectedLen, n)
+			assert.Equal(t, contents[testData.off:testData.off+int64(n)], b[:n])
+		})
+	}
+}
+
+func TestZstdReader_ShouldCacheFrames(t *testing.T) {
+	path := writeZstdFile(t, nil, make([]byte, 3*ZstdFrameSize))
+
+	decompressions := prometheus.NewCounter(prometheus.CounterOpts{})
+	hits := prometheus.NewCounter(prometheus.CounterOpts{})
+	r, err := newZstdReader(path, 2, decompressions, hits)
+	require.NoError(t, err)
+
+	b := make([]byte, 10)
+	for _, off := range []int64{0, 10, ZstdFrameSize, 2 * ZstdFrameSize, 0} {
+		_, err := r.ReadAt(b, off)
+		require.NoError(t, err)
+	}
+
+	// The first frame has been evicted by the third one, so it has been decompressed twice.
+	assert.Equal(t, float64(4), promtestutil.ToFloat64(decompressions))
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(hits))
+
+	// Once stopped, the reader can't be used anymore.
+	r.stop()
+	_, err = r.get()
+	assert.ErrorIs(t, err, ErrPoolStopped)
+}
+
+func TestZstdReader_ShouldFailOnCorruptedFramesIndex(t *testing.T) {
+	path := writeZstdFile(t, nil, make([]byte, 3*ZstdFrameSize))
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	// Corrupt the offset of the last frame.
+	contents[len(contents)-zstdTrailerLen-1]++
+	require.NoError(t, os.WriteFile(path, contents, 0600))
+
+	_, err = newZstdReader(path, 2, prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}))
+	assert.ErrorIs(t, err, ErrInvalidChecksum)
+}
+
+func TestZstdDecbufFactory(t *testing.T) {
+	contents := make([]byte, ZstdFrameSize+100)
+	for i := range contents {
+		contents[i] = byte(i % 7)
+	}
+	path := writeZstdFile(t, []byte("header"), contents)
+
+	factory, err := NewZstdDecbufFactory(path, 2, NewDecbufFactoryMetrics(nil))
+	require.NoError(t, err)
+	t.Cleanup(factory.Stop)
+
+	d := factory.NewRawDecbuf()
+	require.NoError(t, d.Err())
+	require.Equal(t, len(contents), d.Len())
+
+	d.Skip(ZstdFrameSize - 2)
+	assert.Equal(t, contents[ZstdFrameSize-2:ZstdFrameSize+2], d.Bytes(4))
+	require.NoError(t, d.Err())
+	require.NoError(t, d.Close())
+}
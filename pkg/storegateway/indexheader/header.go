@@ -39,6 +39,10 @@ type Reader interface {
 	// If non-nil filter is provided, then only values for which filter returns true are returned.
 	LabelValues(name string, filter func(string) bool) ([]string, error)
 
+	// LabelValuesWithPrefix is like LabelValues, but only returns the label values starting with prefix.
+	// Since label values are sorted, the values which can't start with prefix are skipped without being decoded.
+	LabelValuesWithPrefix(name, prefix string, filter func(string) bool) ([]string, error)
+
 	// LabelNames returns all label names in sorted order.
 	LabelNames() ([]string, error)
 }
@@ -47,10 +51,26 @@ type Config struct {
 	MapPopulateEnabled             bool `yaml:"map_populate_enabled" category:"experimental"`
 	StreamReaderEnabled            bool `yaml:"stream_reader_enabled" category:"experimental"`
 	StreamReaderMaxIdleFileHandles uint `yaml:"stream_reader_max_idle_file_handles" category:"experimental"`
+
+	StreamReaderBucketReadsEnabled        bool `yaml:"stream_reader_bucket_reads_enabled" category:"experimental"`
+	StreamReaderBucketReadsMaxCachedPages uint `yaml:"stream_reader_bucket_reads_max_cached_pages" category:"experimental"`
+
+	StreamReaderSymbolsOffsetsCacheEnabled bool `yaml:"stream_reader_symbols_offsets_cache_enabled" category:"experimental"`
+
+	StreamReaderCompressedMaxCachedFrames uint `yaml:"stream_reader_compressed_max_cached_frames" category:"experimental"`
+
+	BuildConcurrency int `yaml:"build_concurrency" category:"experimental"`
+	FormatVersion    int `yaml:"format_version" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.BoolVar(&cfg.MapPopulateEnabled, prefix+"map-populate-enabled", false, "If enabled, the store-gateway will attempt to pre-populate the file system cache when memory-mapping index-header files.")
 	f.BoolVar(&cfg.StreamReaderEnabled, prefix+"stream-reader-enabled", false, "If enabled, the store-gateway will use an experimental streaming reader to load and parse index-header files.")
 	f.UintVar(&cfg.StreamReaderMaxIdleFileHandles, prefix+"stream-reader-max-idle-file-handles", 1, "Maximum number of idle file handles the store-gateway keeps open for each index-header file when using the streaming reader. This option is used only when the index-header streaming reader is enabled.")
+	f.BoolVar(&cfg.StreamReaderBucketReadsEnabled, prefix+"stream-reader-bucket-reads-enabled", false, "If enabled, the store-gateway doesn't download the index-header files, but the streaming reader reads the symbols and postings offset table directly from the block index in the object storage through range requests. This option is used only when the index-header streaming reader is enabled.")
+	f.UintVar(&cfg.StreamReaderBucketReadsMaxCachedPages, prefix+"stream-reader-bucket-reads-max-cached-pages", 16, "Maximum number of 64KiB pages of the block index the store-gateway keeps in memory for each block when the streaming reader reads directly from the object storage. 0 to disable the cache.")
+	f.BoolVar(&cfg.StreamReaderSymbolsOffsetsCacheEnabled, prefix+"stream-reader-symbols-offsets-cache-enabled", false, "If enabled, the streaming reader persists the offsets of the symbols of each index-header to a file next to the index-header, and memory-maps it when the index-header is loaded again, instead of reading the whole symbols table. This option is used only when the index-header streaming reader is enabled and the index-header is not read directly from the object storage.")
+	f.UintVar(&cfg.StreamReaderCompressedMaxCachedFrames, prefix+"stream-reader-compressed-max-cached-frames", 4, "Maximum number of 64KiB frames of each index-header file of version 3 the store-gateway keeps decompressed in memory when using the streaming reader. 0 to disable the cache.")
+	f.IntVar(&cfg.BuildConcurrency, prefix+"build-concurrency", 1, "Maximum number of concurrent range requests to the object storage used to download the symbols and postings offset table of a block index, when building its index-header file. 1 to build index-header files sequentially.")
+	f.IntVar(&cfg.FormatVersion, prefix+"format-version", BinaryFormatV1, "Format version of the index-header files built by the store-gateway. Version 2 compresses the postings offset table, which reduces the disk usage of the index-header files of high-cardinality blocks. Version 3 compresses the whole index-header file with zstd, and requires the index-header streaming reader. Index-header files of any version are read regardless of this option, except that the files of version 3 can only be read by the streaming reader. Supported values: 1, 2, 3.")
 }
@@ -9,13 +9,16 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb/encoding"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
@@ -87,6 +90,88 @@ func TestReaders(t *testing.T) {
 
 			b := realByteSlice(indexFile.Bytes())
 
+			t.Run("parallel writer", func(t *testing.T) {
+				expected, err := os.ReadFile(indexName)
+				require.NoError(t, err)
+
+				for _, partSize := range []uint64{7, 1024, parallelBuildPartSize} {
+					parallelIndexName := filepath.Join(t.TempDir(), block.IndexHeaderFilename)
+					require.NoError(t, writeBinaryParallel(ctx, bkt, id, parallelIndexName, BinaryFormatV1, 4, partSize))
+
+					actual, err := os.ReadFile(parallelIndexName)
+					require.NoError(t, err)
+					require.Equal(t, expected, actual, "part size: %d", partSize)
+				}
+			})
+
+			t.Run("format v2", func(t *testing.T) {
+				dir := t.TempDir()
+				v2IndexName := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+				require.NoError(t, writeBinaryWithFormat(ctx, bkt, id, v2IndexName, BinaryFormatV2))
+
+				expected, err := os.ReadFile(v2IndexName)
+				require.NoError(t, err)
+
+				// The postings offset table of the index v1 isn't sorted, so it's never compressed.
+				expectedVersion := BinaryFormatV2
+				if id == metaIndexV1.ULID {
+					expectedVersion = BinaryFormatV1
+				}
+				require.Equal(t, byte(expectedVersion), expected[4])
+
+				for _, partSize := range []uint64{7, parallelBuildPartSize} {
+					parallelIndexName := filepath.Join(t.TempDir(), block.IndexHeaderFilename)
+					require.NoError(t, writeBinaryParallel(ctx, bkt, id, parallelIndexName, BinaryFormatV2, 4, partSize))
+
+					actual, err := os.ReadFile(parallelIndexName)
+					require.NoError(t, err)
+					require.Equal(t, expected, actual, "part size: %d", partSize)
+				}
+
+				br, err := NewBinaryReader(ctx, log.NewNopLogger(), nil, dir, id, 3, Config{})
+				require.NoError(t, err)
+				t.Cleanup(func() {
+					require.NoError(t, br.Close())
+				})
+				compareIndexToHeader(t, b, br)
+
+				sbr, err := NewStreamBinaryReader(ctx, log.NewNopLogger(), nil, dir, id, 3, NewStreamBinaryReaderMetrics(nil), Config{})
+				require.NoError(t, err)
+				t.Cleanup(func() {
+					require.NoError(t, sbr.Close())
+				})
+				compareIndexToHeader(t, b, sbr)
+			})
+
+			t.Run("format v3", func(t *testing.T) {
+				for _, buildConcurrency := range []int{1, 4} {
+					dir := t.TempDir()
+					v3IndexName := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+					cfg := Config{FormatVersion: BinaryFormatV3, BuildConcurrency: buildConcurrency, StreamReaderCompressedMaxCachedFrames: 2}
+					require.NoError(t, writeBinary(ctx, bkt, id, v3IndexName, cfg))
+
+					actual, err := os.ReadFile(v3IndexName)
+					require.NoError(t, err)
+					require.Equal(t, byte(BinaryFormatV3), actual[4])
+					require.NoFileExists(t, v3IndexName+".uncompressed")
+
+					sbr, err := NewStreamBinaryReader(ctx, log.NewNopLogger(), nil, dir, id, 3, NewStreamBinaryReaderMetrics(nil), cfg)
+					require.NoError(t, err)
+					compareIndexToHeader(t, b, sbr)
+					require.NoError(t, sbr.Close())
+
+					// The index-header of version 3 can't be read by the mmap reader, so it's rebuilt with the configured version.
+					br, err := NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id, 3, Config{})
+					require.NoError(t, err)
+					compareIndexToHeader(t, b, br)
+					require.NoError(t, br.Close())
+
+					actual, err = os.ReadFile(v3IndexName)
+					require.NoError(t, err)
+					require.Equal(t, byte(BinaryFormatV1), actual[4])
+				}
+			})
+
 			t.Run("binary reader", func(t *testing.T) {
 				br, err := NewBinaryReader(ctx, log.NewNopLogger(), nil, tmpDir, id, 3, Config{})
 				require.NoError(t, err)
@@ -112,7 +197,7 @@ func TestReaders(t *testing.T) {
 					return NewBinaryReader(ctx, log.NewNopLogger(), nil, tmpDir, id, 3, Config{})
 				}
 
-				br, err := NewLazyBinaryReader(ctx, factory, log.NewNopLogger(), nil, tmpDir, id, NewLazyBinaryReaderMetrics(nil), nil)
+				br, err := NewLazyBinaryReader(ctx, factory, log.NewNopLogger(), nil, tmpDir, id, Config{}, NewLazyBinaryReaderMetrics(nil), nil, nil)
 				require.NoError(t, err)
 				t.Cleanup(func() {
 					require.NoError(t, br.Close())
@@ -131,6 +216,40 @@ func TestReaders(t *testing.T) {
 				compareIndexToHeader(t, b, br)
 			})
 
+			t.Run("stream binary reader with symbols offsets cache", func(t *testing.T) {
+				cfg := Config{StreamReaderSymbolsOffsetsCacheEnabled: true}
+				metrics := NewStreamBinaryReaderMetrics(nil)
+
+				// The cache is written on the first load, and read on the second one.
+				for i := 0; i < 2; i++ {
+					br, err := NewStreamBinaryReader(ctx, log.NewNopLogger(), nil, tmpDir, id, 3, metrics, cfg)
+					require.NoError(t, err)
+
+					compareIndexToHeader(t, b, br)
+					require.NoError(t, br.Close())
+				}
+
+				require.FileExists(t, filepath.Join(tmpDir, id.String(), symbolsOffsetsCacheFilename))
+				require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.symbolsOffsetsCacheMisses))
+				require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.symbolsOffsetsCacheHits))
+			})
+
+			for _, maxCachedPages := range []uint{0, 16} {
+				t.Run(fmt.Sprintf("stream binary reader with bucket reads and %d max cached pages", maxCachedPages), func(t *testing.T) {
+					// Use an empty directory, so that the index-header built above can't be used.
+					dir := t.TempDir()
+					cfg := Config{StreamReaderBucketReadsEnabled: true, StreamReaderBucketReadsMaxCachedPages: maxCachedPages}
+
+					br, err := NewStreamBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id, 3, NewStreamBinaryReaderMetrics(nil), cfg)
+					require.NoError(t, err)
+					t.Cleanup(func() {
+						require.NoError(t, br.Close())
+					})
+
+					compareIndexToHeader(t, b, br)
+					require.NoFileExists(t, filepath.Join(dir, id.String(), block.IndexHeaderFilename))
+				})
+			}
 		})
 	}
 
@@ -193,6 +312,23 @@ func compareIndexToHeader(t *testing.T, indexByteSlice index.ByteSlice, headerRe
 		require.NoError(t, err)
 		require.Equal(t, expectedLabelVals, vals)
 
+		// Values starting with a prefix are the same of the ones found by filtering all values.
+		prefixes := []string{"", "zzz"}
+		for _, v := range expectedLabelVals {
+			prefixes = append(prefixes, v, v[:len(v)/2], v+"0")
+		}
+		for _, prefix := range prefixes {
+			expectedPrefixVals := []string{}
+			for _, v := range expectedLabelVals {
+				if strings.HasPrefix(v, prefix) {
+					expectedPrefixVals = append(expectedPrefixVals, v)
+				}
+			}
+			prefixVals, err := headerReader.LabelValuesWithPrefix(lname, prefix, nil)
+			require.NoError(t, err)
+			require.Equal(t, expectedPrefixVals, append([]string{}, prefixVals...), "prefix: %q", prefix)
+		}
+
 		for iv, v := range vals {
 			if minStart > expRanges[labels.Label{Name: lname, Value: v}].Start {
 				minStart = expRanges[labels.Label{Name: lname, Value: v}].Start
@@ -232,6 +368,124 @@ func compareIndexToHeader(t *testing.T, indexByteSlice index.ByteSlice, headerRe
 	require.Equal(t, expRanges[labels.Label{Name: "", Value: ""}].End, ptr.End)
 }
 
+func TestWriteBinary_FormatV2ShouldCompressPostingsOffsetTable(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir := t.TempDir()
+	bkt, err := filesystem.NewBucket(filepath.Join(tmpDir, "bkt"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, bkt.Close())
+	})
+
+	m := prepareIndexV2Block(t, tmpDir, bkt)
+
+	v1Dir, v2Dir := t.TempDir(), t.TempDir()
+	v1IndexName := filepath.Join(v1Dir, m.ULID.String(), block.IndexHeaderFilename)
+	v2IndexName := filepath.Join(v2Dir, m.ULID.String(), block.IndexHeaderFilename)
+	require.NoError(t, WriteBinary(ctx, bkt, m.ULID, v1IndexName))
+	require.NoError(t, writeBinaryWithFormat(ctx, bkt, m.ULID, v2IndexName, BinaryFormatV2))
+
+	v1, err := newFileBinaryReader(v1IndexName, 32, Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, v1.Close())
+	})
+	v2, err := newFileBinaryReader(v2IndexName, 32, Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, v2.Close())
+	})
+
+	// The symbols are the same in both versions, so the postings offset table should be much smaller.
+	v1TableLen := v1.b.Len() - binaryTOCLen - int(v1.toc.PostingsOffsetTable)
+	v2TableLen := v2.b.Len() - binaryTOCLen - int(v2.toc.PostingsOffsetTable)
+	require.Less(t, v2TableLen, v1TableLen/2)
+
+	// Both versions should return the same data.
+	names, err := v1.LabelNames()
+	require.NoError(t, err)
+	v2Names, err := v2.LabelNames()
+	require.NoError(t, err)
+	require.Equal(t, names, v2Names)
+
+	for _, name := range append(names, "") {
+		values, err := v1.LabelValues(name, nil)
+		require.NoError(t, err)
+		v2Values, err := v2.LabelValues(name, nil)
+		require.NoError(t, err)
+		require.Equal(t, values, v2Values)
+
+		for _, value := range values {
+			rng, err := v1.PostingsOffset(name, value)
+			require.NoError(t, err)
+			v2Rng, err := v2.PostingsOffset(name, value)
+			require.NoError(t, err)
+			require.Equal(t, rng, v2Rng, "name: %q value: %q", name, value)
+		}
+	}
+}
+
+func TestWriteBinary_FormatV3ShouldCompressIndexHeader(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir := t.TempDir()
+	bkt, err := filesystem.NewBucket(filepath.Join(tmpDir, "bkt"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, bkt.Close())
+	})
+
+	m := prepareIndexV2Block(t, tmpDir, bkt)
+
+	v1Dir, v3Dir := t.TempDir(), t.TempDir()
+	v1IndexName := filepath.Join(v1Dir, m.ULID.String(), block.IndexHeaderFilename)
+	v3IndexName := filepath.Join(v3Dir, m.ULID.String(), block.IndexHeaderFilename)
+	require.NoError(t, WriteBinary(ctx, bkt, m.ULID, v1IndexName))
+	require.NoError(t, writeBinary(ctx, bkt, m.ULID, v3IndexName, Config{FormatVersion: BinaryFormatV3}))
+
+	v1Stat, err := os.Stat(v1IndexName)
+	require.NoError(t, err)
+	v3Stat, err := os.Stat(v3IndexName)
+	require.NoError(t, err)
+	require.Less(t, v3Stat.Size(), v1Stat.Size()/2)
+
+	// Both versions should return the same data.
+	metrics := NewStreamBinaryReaderMetrics(nil)
+	v1, err := NewStreamBinaryReader(ctx, log.NewNopLogger(), nil, v1Dir, m.ULID, 32, metrics, Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, v1.Close())
+	})
+	v3, err := NewStreamBinaryReader(ctx, log.NewNopLogger(), nil, v3Dir, m.ULID, 32, metrics, Config{StreamReaderCompressedMaxCachedFrames: 4})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, v3.Close())
+	})
+
+	names, err := v1.LabelNames()
+	require.NoError(t, err)
+	v3Names, err := v3.LabelNames()
+	require.NoError(t, err)
+	require.Equal(t, names, v3Names)
+
+	for _, name := range names {
+		values, err := v1.LabelValues(name, nil)
+		require.NoError(t, err)
+		v3Values, err := v3.LabelValues(name, nil)
+		require.NoError(t, err)
+		require.Equal(t, values, v3Values)
+
+		for _, value := range values {
+			rng, err := v1.PostingsOffset(name, value)
+			require.NoError(t, err)
+			v3Rng, err := v3.PostingsOffset(name, value)
+			require.NoError(t, err)
+			require.Equal(t, rng, v3Rng, "name: %q value: %q", name, value)
+		}
+	}
+}
+
 func prepareIndexV2Block(t testing.TB, tmpDir string, bkt objstore.Bucket) *metadata.Meta {
 	/* Copy index 6MB block index version 2. It was generated via thanosbench. Meta.json:
 		{
@@ -28,8 +28,8 @@ type PostingOffsetTable interface {
 	// in there about taking advantage of retrieving multiple values at once.
 	PostingsOffset(name string, values ...string) ([]index.Range, error)
 
-	// LabelValues returns a list of values for the label named name that match filter.
-	LabelValues(name string, filter func(string) bool) ([]string, error)
+	// LabelValues returns a list of values for the label named name that start with prefix and match filter.
+	LabelValues(name string, prefix string, filter func(string) bool) ([]string, error)
 
 	// LabelNames returns a sorted list of all label names in this table.
 	LabelNames() ([]string, error)
@@ -250,14 +250,14 @@ func (t *PostingOffsetTableV1) PostingsOffset(name string, values ...string) ([]
 	return rngs, nil
 }
 
-func (t *PostingOffsetTableV1) LabelValues(name string, filter func(string) bool) ([]string, error) {
+func (t *PostingOffsetTableV1) LabelValues(name string, prefix string, filter func(string) bool) ([]string, error) {
 	e, ok := t.postings[name]
 	if !ok {
 		return nil, nil
 	}
 	values := make([]string, 0, len(e))
 	for k := range e {
-		if filter == nil || filter(k) {
+		if strings.HasPrefix(k, prefix) && (filter == nil || filter(k)) {
 			values = append(values, k)
 		}
 	}
@@ -431,7 +431,7 @@ func (t *PostingOffsetTableV2) PostingsOffset(name string, values ...string) (r
 	return rngs, nil
 }
 
-func (t *PostingOffsetTableV2) LabelValues(name string, filter func(string) bool) (v []string, err error) {
+func (t *PostingOffsetTableV2) LabelValues(name string, prefix string, filter func(string) bool) (v []string, err error) {
 	e, ok := t.postings[name]
 	if !ok {
 		return nil, nil
@@ -439,14 +439,28 @@ func (t *PostingOffsetTableV2) LabelValues(name string, filter func(string) bool
 	if len(e.offsets) == 0 {
 		return nil, nil
 	}
-	values := make([]string, 0, len(e.offsets)*t.postingOffsetsInMemSampling)
+
+	// The values are sorted, so the ones starting with prefix can only be found after the last
+	// sampled value smaller than prefix.
+	start := 0
+	if prefix != "" {
+		start = sort.Search(len(e.offsets), func(i int) bool { return e.offsets[i].value >= prefix })
+		if start == len(e.offsets) {
+			// All values are smaller than prefix.
+			return nil, nil
+		}
+		if start > 0 {
+			start--
+		}
+	}
+	values := make([]string, 0, (len(e.offsets)-start)*t.postingOffsetsInMemSampling)
 
 	// Don't Crc32 the entire postings offset table, this is very slow
 	// so hope any issues were caught at startup.
 	d := t.factory.NewDecbufAtUnchecked(t.tableOffset)
 	defer runutil.CloseWithErrCapture(&err, &d, "get label values")
 
-	d.Skip(e.offsets[0].tableOff)
+	d.Skip(e.offsets[start].tableOff)
 	lastVal := e.offsets[len(e.offsets)-1].value
 
 	skip := 0
@@ -462,7 +476,11 @@ func (t *PostingOffsetTableV2) LabelValues(name string, filter func(string) bool
 			d.Skip(skip)
 		}
 		s := yoloString(d.UnsafeUvarintBytes()) // Label value.
-		if filter == nil || filter(s) {
+		if prefix != "" && !strings.HasPrefix(s, prefix) && s > prefix {
+			// The values are sorted, so no further value can start with prefix.
+			break
+		}
+		if strings.HasPrefix(s, prefix) && (filter == nil || filter(s)) {
 			// Clone the yolo string since its bytes will be invalidated as soon as
 			// any other reads against the decoding buffer are performed.
 			values = append(values, strings.Clone(s))
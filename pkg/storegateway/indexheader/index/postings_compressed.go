@@ -0,0 +1,463 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package index
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	promencoding "github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"golang.org/x/exp/slices"
+
+	streamencoding "github.com/grafana/mimir/pkg/storegateway/indexheader/encoding"
+)
+
+// The compressed postings offset table holds the same entries of the postings offset table of an index v2,
+// sorted by label name and value, in blocks of consecutive entries with the same label name. The label values
+// of each block are delta encoded with the previous value, and each block is compressed with snappy.
+// The blocks are followed by a sparse index holding the first value of each block, which is kept in memory
+// to find the block of a label value. Like the other index-header sections, the contents of the table
+// are preceded by their length and followed by their CRC32.
+//
+// ┌────────────────────────────────────────────────────┐
+// │ len <4b>                                           │
+// ├────────────────────────────────────────────────────┤
+// │ ┌────────────────────────────────────────────────┐ │
+// │ │ block 1 <snappy bytes>                         │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ . . .                                          │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ block n <snappy bytes>                         │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ sparse index                                   │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ sparse index offset <8b>                       │ │
+// │ └────────────────────────────────────────────────┘ │
+// ├────────────────────────────────────────────────────┤
+// │ CRC32 <4b>                                         │
+// └────────────────────────────────────────────────────┘
+//
+// Each block, once decompressed, holds the offsets of the postings of its entries as deltas from the
+// previous entry, and the offset of the postings following the last entry of the block:
+//
+// ┌────────────────────────────────────────────────────┐
+// │ #entries <uvarint>                                 │
+// ├────────────────────────────────────────────────────┤
+// │ ┌────────────────────────────────────────────────┐ │
+// │ │ len(prefix shared with previous value) <uvar>  │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ len(value suffix) <uvarint>                    │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ value suffix <bytes>                           │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ offset delta <uvarint64>                       │ │
+// │ └────────────────────────────────────────────────┘ │
+// │                      . . .                         │
+// ├────────────────────────────────────────────────────┤
+// │ next offset delta <uvarint64>                      │
+// └────────────────────────────────────────────────────┘
+//
+// The sparse index lists the blocks of each label name, which are stored one after the other:
+//
+// ┌────────────────────────────────────────────────────┐
+// │ #names <uvarint>                                   │
+// ├────────────────────────────────────────────────────┤
+// │ ┌────────────────────────────────────────────────┐ │
+// │ │ len(name) <uvarint> │ name <bytes>             │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ #blocks <uvarint>                              │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ len(first value) <uvarint> │ first value <b>   │ │
+// │ ├────────────────────────────────────────────────┤ │
+// │ │ len(compressed block) <uvarint>                │ │
+// │ └────────────────────────────────────────────────┘ │
+// │                      . . .                         │
+// └────────────────────────────────────────────────────┘
+
+const sparseIndexOffsetFieldSize = 8
+
+type compressedPostingOffsetsBlock struct {
+	// First label value of the block.
+	firstValue string
+	// Offset and length of the compressed block in the contents of the table.
+	off, len int
+}
+
+// compressedPostingOffsetsName holds the blocks of a label name, sorted by label value.
+type compressedPostingOffsetsName struct {
+	name   string
+	blocks []compressedPostingOffsetsBlock
+}
+
+// CompressedPostingOffsetTableWriter writes the contents of a compressed postings offset table, adding the entries
+// of the postings offset table of an index v2 in the same order. The length and the CRC32 of the contents aren't
+// written, since the length is only known once all the entries have been written.
+type CompressedPostingOffsetTableWriter struct {
+	w               io.Writer
+	entriesPerBlock int
+	written         int
+
+	names []compressedPostingOffsetsName
+
+	// The entries of the current block.
+	entries    promencoding.Encbuf
+	count      int
+	firstValue string
+	prevValue  []byte
+	prevOffset uint64
+
+	// Reusable memory.
+	block      promencoding.Encbuf
+	compressed []byte
+}
+
+// NewCompressedPostingOffsetTableWriter makes a new CompressedPostingOffsetTableWriter writing to w, and compressing
+// up to entriesPerBlock entries in each block.
+func NewCompressedPostingOffsetTableWriter(w io.Writer, entriesPerBlock int) *CompressedPostingOffsetTableWriter {
+	return &CompressedPostingOffsetTableWriter{
+		w:               w,
+		entriesPerBlock: entriesPerBlock,
+	}
+}
+
+// Add adds the entry of the label name and value whose postings are at offset in the index.
+// The entries must be added sorted by label name and value, like in the postings offset table.
+func (w *CompressedPostingOffsetTableWriter) Add(name, value []byte, offset uint64) error {
+	if w.count > 0 && offset < w.prevOffset {
+		return errors.Errorf("postings offset %d of %s=%s is lower than the previous one %d", offset, name, value, w.prevOffset)
+	}
+
+	if w.count > 0 && (w.count == w.entriesPerBlock || string(name) != w.names[len(w.names)-1].name) {
+		if err := w.flushBlock(offset); err != nil {
+			return err
+		}
+	}
+
+	if w.count == 0 {
+		if len(w.names) == 0 || string(name) != w.names[len(w.names)-1].name {
+			w.names = append(w.names, compressedPostingOffsetsName{name: string(name)})
+		}
+		w.firstValue = string(value)
+		w.prevValue = w.prevValue[:0]
+		w.prevOffset = 0
+	}
+
+	shared := 0
+	for shared < len(value) && shared < len(w.prevValue) && value[shared] == w.prevValue[shared] {
+		shared++
+	}
+
+	w.entries.PutUvarint(shared)
+	w.entries.PutUvarintBytes(value[shared:])
+	w.entries.PutUvarint64(offset - w.prevOffset)
+
+	w.count++
+	w.prevValue = append(w.prevValue[:0], value...)
+	w.prevOffset = offset
+	return nil
+}
+
+// flushBlock compresses and writes the current block, whose last entry is followed by the postings at next.
+func (w *CompressedPostingOffsetTableWriter) flushBlock(next uint64) error {
+	w.block.Reset()
+	w.block.PutUvarint(w.count)
+	w.block.B = append(w.block.B, w.entries.Get()...)
+	w.block.PutUvarint64(next - w.prevOffset)
+
+	w.compressed = snappy.Encode(w.compressed[:cap(w.compressed)], w.block.Get())
+	if _, err := w.w.Write(w.compressed); err != nil {
+		return errors.Wrap(err, "write compressed postings offsets block")
+	}
+
+	n := &w.names[len(w.names)-1]
+	n.blocks = append(n.blocks, compressedPostingOffsetsBlock{firstValue: w.firstValue, off: w.written, len: len(w.compressed)})
+	w.written += len(w.compressed)
+
+	w.entries.Reset()
+	w.count = 0
+	return nil
+}
+
+// Finish writes the last block, whose last entry is followed by the postings ending at lastPostingEnd,
+// and the sparse index.
+func (w *CompressedPostingOffsetTableWriter) Finish(lastPostingEnd uint64) error {
+	if w.count > 0 {
+		if err := w.flushBlock(lastPostingEnd); err != nil {
+			return err
+		}
+	}
+
+	w.block.Reset()
+	w.block.PutUvarint(len(w.names))
+	for _, n := range w.names {
+		w.block.PutUvarintStr(n.name)
+		w.block.PutUvarint(len(n.blocks))
+		for _, b := range n.blocks {
+			w.block.PutUvarintStr(b.firstValue)
+			w.block.PutUvarint(b.len)
+		}
+	}
+	w.block.PutBE64(uint64(w.written))
+
+	if _, err := w.w.Write(w.block.Get()); err != nil {
+		return errors.Wrap(err, "write compressed postings offsets sparse index")
+	}
+	return nil
+}
+
+// CompressedPostingOffsetTable is a PostingOffsetTable reading a compressed postings offset table. Only the sparse
+// index is kept in memory, and the blocks are read and decompressed each time they're looked up.
+type CompressedPostingOffsetTable struct {
+	// Map of label name to the blocks of its values.
+	postings map[string][]compressedPostingOffsetsBlock
+
+	// read returns length bytes at off in the contents of the table.
+	read func(off, length int) ([]byte, error)
+}
+
+// NewCompressedPostingOffsetTable loads the compressed postings offset table at tableOffset in the file of factory.
+func NewCompressedPostingOffsetTable(factory *streamencoding.DecbufFactory, tableOffset int) (*CompressedPostingOffsetTable, error) {
+	d := factory.NewDecbufAtChecked(tableOffset, castagnoliTable)
+	if err := d.Err(); err != nil {
+		_ = d.Close()
+		return nil, errors.Wrap(err, "read compressed postings offset table")
+	}
+	contentLen := d.Len() - crc32.Size
+	if err := d.Close(); err != nil {
+		return nil, errors.Wrap(err, "read compressed postings offset table")
+	}
+
+	return newCompressedPostingOffsetTable(func(off, length int) (b []byte, err error) {
+		d := factory.NewDecbufAtUnchecked(tableOffset)
+		defer runutil.CloseWithErrCapture(&err, &d, "read compressed postings offset table")
+
+		d.ResetAt(postingLengthFieldSize + off)
+		b = d.Bytes(length)
+		return b, d.Err()
+	}, contentLen)
+}
+
+// NewCompressedPostingOffsetTableFromByteSlice loads the compressed postings offset table at tableOffset in bs.
+func NewCompressedPostingOffsetTableFromByteSlice(bs index.ByteSlice, tableOffset int) (*CompressedPostingOffsetTable, error) {
+	d := promencoding.NewDecbufAt(bs, tableOffset, castagnoliTable)
+	if err := d.Err(); err != nil {
+		return nil, errors.Wrap(err, "read compressed postings offset table")
+	}
+
+	contentLen := d.Len()
+	contentOffset := tableOffset + postingLengthFieldSize
+	return newCompressedPostingOffsetTable(func(off, length int) ([]byte, error) {
+		if off < 0 || length < 0 || off+length > contentLen {
+			return nil, promencoding.ErrInvalidSize
+		}
+		return bs.Range(contentOffset+off, contentOffset+off+length), nil
+	}, contentLen)
+}
+
+func newCompressedPostingOffsetTable(read func(off, length int) ([]byte, error), contentLen int) (*CompressedPostingOffsetTable, error) {
+	if contentLen < sparseIndexOffsetFieldSize {
+		return nil, errors.Wrap(promencoding.ErrInvalidSize, "compressed postings offset table")
+	}
+
+	b, err := read(contentLen-sparseIndexOffsetFieldSize, sparseIndexOffsetFieldSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "read sparse index offset")
+	}
+	sparseIndexOffset := binary.BigEndian.Uint64(b)
+	if sparseIndexOffset > uint64(contentLen-sparseIndexOffsetFieldSize) {
+		return nil, errors.Errorf("invalid sparse index offset %d", sparseIndexOffset)
+	}
+
+	b, err = read(int(sparseIndexOffset), contentLen-sparseIndexOffsetFieldSize-int(sparseIndexOffset))
+	if err != nil {
+		return nil, errors.Wrap(err, "read sparse index")
+	}
+
+	t := &CompressedPostingOffsetTable{
+		postings: map[string][]compressedPostingOffsetsBlock{},
+		read:     read,
+	}
+
+	d := promencoding.Decbuf{B: b}
+	blockOffset := 0
+	for names := d.Uvarint(); names > 0 && d.Err() == nil; names-- {
+		name := d.UvarintStr()
+		count := d.Uvarint()
+		blocks := make([]compressedPostingOffsetsBlock, 0, count)
+		for ; count > 0 && d.Err() == nil; count-- {
+			firstValue := d.UvarintStr()
+			blockLen := d.Uvarint()
+			blocks = append(blocks, compressedPostingOffsetsBlock{firstValue: firstValue, off: blockOffset, len: blockLen})
+			blockOffset += blockLen
+		}
+		t.postings[name] = blocks
+	}
+	if d.Err() != nil {
+		return nil, errors.Wrap(d.Err(), "read sparse index")
+	}
+	if uint64(blockOffset) != sparseIndexOffset {
+		return nil, errors.Errorf("the blocks in the sparse index end at %d instead of %d", blockOffset, sparseIndexOffset)
+	}
+
+	return t, nil
+}
+
+// decompressedPostingOffsetsBlock is a decompressed block of entries.
+type decompressedPostingOffsetsBlock struct {
+	values [][]byte
+	// The offsets of the postings of each entry, followed by the offset of the postings following the last entry.
+	offsets []uint64
+}
+
+// postingsRange returns the range of the postings of the i-th entry.
+func (b decompressedPostingOffsetsBlock) postingsRange(i int) index.Range {
+	return index.Range{
+		Start: int64(b.offsets[i]) + postingLengthFieldSize,
+		End:   int64(b.offsets[i+1]) - crc32.Size,
+	}
+}
+
+func (t *CompressedPostingOffsetTable) decompressBlock(blk compressedPostingOffsetsBlock) (decompressedPostingOffsetsBlock, error) {
+	compressed, err := t.read(blk.off, blk.len)
+	if err != nil {
+		return decompressedPostingOffsetsBlock{}, errors.Wrap(err, "read compressed postings offsets block")
+	}
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return decompressedPostingOffsetsBlock{}, errors.Wrap(err, "decompress postings offsets block")
+	}
+
+	d := promencoding.Decbuf{B: b}
+	count := d.Uvarint()
+	res := decompressedPostingOffsetsBlock{
+		values:  make([][]byte, 0, count),
+		offsets: make([]uint64, 0, count+1),
+	}
+
+	// All the values of the block are stored in the same buffer.
+	var buf, prev []byte
+	var offset uint64
+	for i := 0; i < count && d.Err() == nil; i++ {
+		shared := d.Uvarint()
+		suffix := d.UvarintBytes()
+		if shared > len(prev) {
+			return decompressedPostingOffsetsBlock{}, errors.Errorf("invalid shared prefix length %d of a value", shared)
+		}
+
+		start := len(buf)
+		buf = append(buf, prev[:shared]...)
+		buf = append(buf, suffix...)
+		prev = buf[start:len(buf):len(buf)]
+
+		offset += d.Uvarint64()
+		res.values = append(res.values, prev)
+		res.offsets = append(res.offsets, offset)
+	}
+	offset += d.Uvarint64()
+	res.offsets = append(res.offsets, offset)
+
+	if d.Err() != nil {
+		return decompressedPostingOffsetsBlock{}, errors.Wrap(d.Err(), "read postings offsets block")
+	}
+	return res, nil
+}
+
+// blockFor returns the index of the block which may contain value, which is the last block whose first value
+// isn't greater than value, or -1 if value is lower than the first value of all the blocks.
+func blockFor(blocks []compressedPostingOffsetsBlock, value string) int {
+	return sort.Search(len(blocks), func(i int) bool { return blocks[i].firstValue > value }) - 1
+}
+
+func (t *CompressedPostingOffsetTable) PostingsOffset(name string, values ...string) ([]index.Range, error) {
+	blocks, ok := t.postings[name]
+	if !ok || len(values) == 0 {
+		return nil, nil
+	}
+
+	rngs := make([]index.Range, 0, len(values))
+	decompressed := -1
+	var blk decompressedPostingOffsetsBlock
+	for _, v := range values {
+		i := blockFor(blocks, v)
+		if i < 0 {
+			continue
+		}
+
+		// Consecutive values are likely in the same block, so we don't decompress it again.
+		if i != decompressed {
+			var err error
+			if blk, err = t.decompressBlock(blocks[i]); err != nil {
+				return nil, err
+			}
+			decompressed = i
+		}
+
+		j := sort.Search(len(blk.values), func(j int) bool { return string(blk.values[j]) >= v })
+		if j < len(blk.values) && string(blk.values[j]) == v {
+			rngs = append(rngs, blk.postingsRange(j))
+		}
+	}
+
+	return rngs, nil
+}
+
+func (t *CompressedPostingOffsetTable) LabelValues(name string, prefix string, filter func(string) bool) ([]string, error) {
+	blocks, ok := t.postings[name]
+	if !ok {
+		return nil, nil
+	}
+
+	// The values are sorted, so the ones starting with prefix can only be found from the block which may
+	// contain prefix.
+	start := 0
+	if prefix != "" {
+		if start = blockFor(blocks, prefix); start < 0 {
+			start = 0
+		}
+	}
+
+	var values []string
+	for _, b := range blocks[start:] {
+		blk, err := t.decompressBlock(b)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range blk.values {
+			s := yoloString(v)
+			if prefix != "" && !strings.HasPrefix(s, prefix) && s > prefix {
+				// The values are sorted, so no further value can start with prefix.
+				return values, nil
+			}
+			if strings.HasPrefix(s, prefix) && (filter == nil || filter(s)) {
+				values = append(values, string(v))
+			}
+		}
+	}
+
+	return values, nil
+}
+
+func (t *CompressedPostingOffsetTable) LabelNames() ([]string, error) {
+	labelNames := make([]string, 0, len(t.postings))
+	allPostingsKeyName, _ := index.AllPostingsKey()
+
+	for name := range t.postings {
+		if name == allPostingsKeyName {
+			continue
+		}
+
+		labelNames = append(labelNames, name)
+	}
+
+	slices.Sort(labelNames)
+
+	return labelNames, nil
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/require"
+
+	streamencoding "github.com/grafana/mimir/pkg/storegateway/indexheader/encoding"
+)
+
+func TestCompressedPostingOffsetTable(t *testing.T) {
+	type entry struct {
+		name, value string
+		offset      uint64
+	}
+
+	// The entries are sorted by label name and value, and so are the offsets of their postings.
+	var entries []entry
+	offset := uint64(100)
+	add := func(name, value string) {
+		entries = append(entries, entry{name: name, value: value, offset: offset})
+		offset += 20
+	}
+	add("", "")
+	for i := 0; i < 50; i++ {
+		add("a", fmt.Sprintf("value-%03d", i))
+	}
+	add("b", "1")
+	for i := 0; i < 10; i++ {
+		add("c", strings.Repeat("x", i))
+	}
+	lastPostingEnd := offset
+
+	for _, entriesPerBlock := range []int{1, 3, 64} {
+		t.Run(fmt.Sprintf("%d entries per block", entriesPerBlock), func(t *testing.T) {
+			// Write the table preceded by some other bytes, to simulate a table in a larger file.
+			buf := encoding.Encbuf{}
+			buf.PutUvarintStr("something")
+			tableOffset := buf.Len()
+
+			contents := &bytes.Buffer{}
+			w := NewCompressedPostingOffsetTableWriter(contents, entriesPerBlock)
+			for _, e := range entries {
+				require.NoError(t, w.Add([]byte(e.name), []byte(e.value), e.offset))
+			}
+			require.NoError(t, w.Finish(lastPostingEnd))
+
+			buf.PutBE32int(contents.Len())
+			buf.B = append(buf.B, contents.Bytes()...)
+			buf.PutBE32(crc32.Checksum(contents.Bytes(), castagnoliTable))
+
+			dir := t.TempDir()
+			filePath := path.Join(dir, "index-header")
+			require.NoError(t, os.WriteFile(filePath, buf.Get(), 0700))
+
+			df := streamencoding.NewDecbufFactory(filePath, 0, log.NewNopLogger(), streamencoding.NewDecbufFactoryMetrics(prometheus.NewPedanticRegistry()))
+			t.Cleanup(df.Stop)
+
+			fileTable, err := NewCompressedPostingOffsetTable(df, tableOffset)
+			require.NoError(t, err)
+			byteSliceTable, err := NewCompressedPostingOffsetTableFromByteSlice(realByteSlice(buf.Get()), tableOffset)
+			require.NoError(t, err)
+
+			for name, table := range map[string]PostingOffsetTable{"file": fileTable, "byte slice": byteSliceTable} {
+				t.Run(name, func(t *testing.T) {
+					for i, e := range entries {
+						next := lastPostingEnd
+						if i+1 < len(entries) {
+							next = entries[i+1].offset
+						}
+
+						rngs, err := table.PostingsOffset(e.name, e.value)
+						require.NoError(t, err)
+						require.Equal(t, []index.Range{{Start: int64(e.offset) + 4, End: int64(next) - 4}}, rngs, "name: %q value: %q", e.name, e.value)
+					}
+
+					// Multiple values can be looked up at once, skipping the missing ones.
+					rngs, err := table.PostingsOffset("a", "0", "value-000", "value-001", "value-0015", "value-049", "value-050")
+					require.NoError(t, err)
+					require.Equal(t, []index.Range{{Start: 124, End: 136}, {Start: 144, End: 156}, {Start: 1104, End: 1116}}, rngs)
+
+					rngs, err = table.PostingsOffset("missing", "1")
+					require.NoError(t, err)
+					require.Empty(t, rngs)
+
+					names, err := table.LabelNames()
+					require.NoError(t, err)
+					require.Equal(t, []string{"a", "b", "c"}, names)
+
+					for _, prefix := range []string{"", "value-", "value-01", "value-049", "value-05", "v", "0", "z"} {
+						var expected []string
+						for _, e := range entries {
+							if e.name == "a" && strings.HasPrefix(e.value, prefix) {
+								expected = append(expected, e.value)
+							}
+						}
+
+						values, err := table.LabelValues("a", prefix, nil)
+						require.NoError(t, err)
+						require.Equal(t, expected, values, "prefix: %q", prefix)
+					}
+
+					values, err := table.LabelValues("c", "", func(v string) bool { return len(v)%2 == 0 })
+					require.NoError(t, err)
+					require.Equal(t, []string{"", "xx", "xxxx", "xxxxxx", "xxxxxxxx"}, values)
+
+					values, err = table.LabelValues("missing", "", nil)
+					require.NoError(t, err)
+					require.Empty(t, values)
+				})
+			}
+		})
+	}
+}
+
+func TestCompressedPostingOffsetTable_ShouldFailOnCorruptedTable(t *testing.T) {
+	contents := &bytes.Buffer{}
+	w := NewCompressedPostingOffsetTableWriter(contents, 2)
+	require.NoError(t, w.Add([]byte("a"), []byte("1"), 10))
+	require.NoError(t, w.Add([]byte("a"), []byte("2"), 20))
+	require.NoError(t, w.Finish(30))
+
+	buf := encoding.Encbuf{}
+	buf.PutBE32int(contents.Len())
+	buf.B = append(buf.B, contents.Bytes()...)
+	buf.PutBE32(crc32.Checksum(contents.Bytes(), castagnoliTable))
+
+	b := buf.Get()
+	b[5] ^= 0xff
+
+	_, err := NewCompressedPostingOffsetTableFromByteSlice(realByteSlice(b), 0)
+	require.ErrorIs(t, err, encoding.ErrInvalidChecksum)
+}
+
+func TestCompressedPostingOffsetTableWriter_ShouldFailOnUnsortedOffsets(t *testing.T) {
+	w := NewCompressedPostingOffsetTableWriter(&bytes.Buffer{}, 64)
+	require.NoError(t, w.Add([]byte("a"), []byte("1"), 20))
+	require.Error(t, w.Add([]byte("a"), []byte("2"), 10))
+}
+
+type realByteSlice []byte
+
+func (b realByteSlice) Len() int {
+	return len(b)
+}
+
+func (b realByteSlice) Range(start, end int) []byte {
+	return b[start:end]
+}
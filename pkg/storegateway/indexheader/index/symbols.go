@@ -9,9 +9,12 @@ import (
 	"errors"
 	"fmt"
 	"hash/crc32"
+	"os"
 	"sort"
 	"unsafe"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/runutil"
 	"github.com/prometheus/prometheus/tsdb/index"
 
@@ -34,7 +37,7 @@ type Symbols struct {
 	tableLength int
 	tableOffset int
 
-	offsets []int
+	offsets symbolOffsets
 	seen    int
 }
 
@@ -58,10 +61,10 @@ func NewSymbols(factory *streamencoding.DecbufFactory, version, offset int) (s *
 	origLen := d.Len()
 	cnt := d.Be32int()
 	basePos := 4
-	s.offsets = make([]int, 0, 1+cnt/symbolFactor)
+	s.offsets.mem = make([]int, 0, 1+cnt/symbolFactor)
 	for d.Err() == nil && s.seen < cnt {
 		if s.seen%symbolFactor == 0 {
-			s.offsets = append(s.offsets, basePos+origLen-d.Len())
+			s.offsets.mem = append(s.offsets.mem, basePos+origLen-d.Len())
 		}
 		d.SkipUvarintBytes() // The symbol.
 		s.seen++
@@ -74,6 +77,60 @@ func NewSymbols(factory *streamencoding.DecbufFactory, version, offset int) (s *
 	return s, nil
 }
 
+// NewSymbolsWithOffsetsCache returns a Symbols object for symbol lookups like NewSymbols, but reads the offsets
+// of the sampled symbols from the memory-mapped symbols offsets cache file at cachePath instead of reading the
+// whole symbols table, if the cache file is valid. Otherwise, the symbols table is read and the cache file is
+// (re)written. Returns whether the offsets have been read from the cache file.
+func NewSymbolsWithOffsetsCache(factory *streamencoding.DecbufFactory, version, offset int, cachePath string, logger log.Logger) (s *Symbols, cacheHit bool, err error) {
+	tableLength, err := readSymbolsTableLength(factory, offset)
+	if err != nil {
+		return nil, false, err
+	}
+
+	offsets, seen, err := readSymbolsOffsetsCache(cachePath, version, offset, tableLength)
+	if err == nil {
+		return &Symbols{
+			factory:     factory,
+			version:     version,
+			tableLength: tableLength,
+			tableOffset: offset,
+			offsets:     offsets,
+			seen:        seen,
+		}, true, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		level.Warn(logger).Log("msg", "failed to read symbols offsets cache, recreating it", "path", cachePath, "err", err)
+	}
+
+	s, err = NewSymbols(factory, version, offset)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := writeSymbolsOffsetsCache(cachePath, s); err != nil {
+		level.Warn(logger).Log("msg", "failed to write symbols offsets cache", "path", cachePath, "err", err)
+	}
+
+	return s, false, nil
+}
+
+// readSymbolsTableLength returns the length of the symbols table at offset, including its size and checksum.
+func readSymbolsTableLength(factory *streamencoding.DecbufFactory, offset int) (_ int, err error) {
+	d := factory.NewDecbufAtUnchecked(offset)
+	defer runutil.CloseWithErrCapture(&err, &d, "read symbols table length")
+	if err := d.Err(); err != nil {
+		return 0, fmt.Errorf("decode symbol table: %w", err)
+	}
+
+	// The size of the table (4 bytes) has already been read.
+	return d.Len() + 4, nil
+}
+
+// Close releases the resources held by the Symbols.
+func (s *Symbols) Close() error {
+	return s.offsets.close()
+}
+
 func (s *Symbols) Lookup(o uint32) (sym string, err error) {
 	d := s.factory.NewDecbufAtUnchecked(s.tableOffset)
 	defer runutil.CloseWithErrCapture(&err, &d, "lookup symbol")
@@ -85,7 +142,7 @@ func (s *Symbols) Lookup(o uint32) (sym string, err error) {
 		if int(o) >= s.seen {
 			return "", fmt.Errorf("unknown symbol offset %d", o)
 		}
-		d.ResetAt(s.offsets[int(o/symbolFactor)])
+		d.ResetAt(s.offsets.at(int(o / symbolFactor)))
 		// Walk until we find the one we want.
 		for i := o - (o / symbolFactor * symbolFactor); i > 0; i-- {
 			d.SkipUvarintBytes()
@@ -105,7 +162,7 @@ func (s *Symbols) Lookup(o uint32) (sym string, err error) {
 }
 
 func (s *Symbols) ReverseLookup(sym string) (o uint32, err error) {
-	if len(s.offsets) == 0 {
+	if s.offsets.len() == 0 {
 		return 0, fmt.Errorf("unknown symbol %q - no symbols", sym)
 	}
 
@@ -122,7 +179,7 @@ func (s *Symbols) ReverseLookup(sym string) (o uint32, err error) {
 // If the offset of a symbol cannot be looked up, iteration stops immediately and the error is
 // returned. If f returns an error, iteration stops immediately and the error is returned.
 func (s *Symbols) ForEachSymbol(syms []string, f func(sym string, offset uint32) error) (err error) {
-	if len(s.offsets) == 0 {
+	if s.offsets.len() == 0 {
 		return errors.New("no symbols")
 	}
 
@@ -148,8 +205,8 @@ func (s *Symbols) ForEachSymbol(syms []string, f func(sym string, offset uint32)
 }
 
 func (s *Symbols) reverseLookup(sym string, d streamencoding.Decbuf) (uint32, error) {
-	i := sort.Search(len(s.offsets), func(i int) bool {
-		d.ResetAt(s.offsets[i])
+	i := sort.Search(s.offsets.len(), func(i int) bool {
+		d.ResetAt(s.offsets.at(i))
 		return string(d.UnsafeUvarintBytes()) > sym
 	})
 
@@ -157,7 +214,7 @@ func (s *Symbols) reverseLookup(sym string, d streamencoding.Decbuf) (uint32, er
 		i--
 	}
 
-	d.ResetAt(s.offsets[i])
+	d.ResetAt(s.offsets.at(i))
 	res := i * symbolFactor
 	var lastLen int
 	var lastSymbol string
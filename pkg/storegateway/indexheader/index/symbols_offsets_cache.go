@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package index
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+)
+
+const (
+	// symbolsOffsetsCacheMagic is the magic number at the beginning of a symbols offsets cache file.
+	symbolsOffsetsCacheMagic = 0x5E3B0F75
+
+	symbolsOffsetsCacheFormatV1 = 1
+
+	// symbolsOffsetsCacheHeaderLen is the length of the header of a symbols offsets cache file: magic number,
+	// format version, symbol factor, index version, symbols table offset and length, and number of symbols.
+	symbolsOffsetsCacheHeaderLen = 4 + 1 + 4 + 4 + 8 + 8 + 8
+)
+
+// symbolOffsets holds the offsets, within the symbols table, of every symbolFactor-th symbol. The
+// offsets are either held in memory or read from a memory-mapped symbols offsets cache file.
+type symbolOffsets struct {
+	mem []int
+
+	// mmapped are the big endian encoded offsets in the memory-mapped cache file.
+	mmapped []byte
+	file    *fileutil.MmapFile
+}
+
+func (o *symbolOffsets) len() int {
+	if o.file != nil {
+		return len(o.mmapped) / 8
+	}
+	return len(o.mem)
+}
+
+func (o *symbolOffsets) at(i int) int {
+	if o.file != nil {
+		return int(binary.BigEndian.Uint64(o.mmapped[i*8:]))
+	}
+	return o.mem[i]
+}
+
+func (o *symbolOffsets) close() error {
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Close()
+}
+
+// writeSymbolsOffsetsCache writes the offsets of s to the symbols offsets cache file at path.
+// The file is written atomically, so that a partially written file is never read.
+func writeSymbolsOffsetsCache(path string, s *Symbols) error {
+	buf := encoding.Encbuf{}
+	buf.PutBE32(symbolsOffsetsCacheMagic)
+	buf.PutByte(symbolsOffsetsCacheFormatV1)
+	buf.PutBE32int(symbolFactor)
+	buf.PutBE32int(s.version)
+	buf.PutBE64(uint64(s.tableOffset))
+	buf.PutBE64(uint64(s.tableLength))
+	buf.PutBE64(uint64(s.seen))
+	for i := 0; i < s.offsets.len(); i++ {
+		buf.PutBE64(uint64(s.offsets.at(i)))
+	}
+	buf.PutHash(crc32.New(castagnoliTable))
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Get(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// readSymbolsOffsetsCache memory-maps the symbols offsets cache file at path, and returns the offsets
+// it holds if they have been computed for the symbols table at tableOffset, spanning tableLength
+// bytes. The returned offsets must be closed once done, to unmap the file.
+func readSymbolsOffsetsCache(path string, version, tableOffset, tableLength int) (_ symbolOffsets, seen int, err error) {
+	f, err := fileutil.OpenMmapFile(path)
+	if err != nil {
+		return symbolOffsets{}, 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+		}
+	}()
+
+	b := f.Bytes()
+	if len(b) < symbolsOffsetsCacheHeaderLen+crc32.Size || (len(b)-symbolsOffsetsCacheHeaderLen-crc32.Size)%8 != 0 {
+		return symbolOffsets{}, 0, fmt.Errorf("invalid symbols offsets cache size %d", len(b))
+	}
+
+	content, checksum := b[:len(b)-crc32.Size], b[len(b)-crc32.Size:]
+	if actual := crc32.Checksum(content, castagnoliTable); actual != binary.BigEndian.Uint32(checksum) {
+		return symbolOffsets{}, 0, fmt.Errorf("symbols offsets cache checksum mismatch")
+	}
+
+	d := encoding.Decbuf{B: content}
+	if magic := d.Be32(); magic != symbolsOffsetsCacheMagic {
+		return symbolOffsets{}, 0, fmt.Errorf("invalid symbols offsets cache magic number %x", magic)
+	}
+	if format := d.Byte(); format != symbolsOffsetsCacheFormatV1 {
+		return symbolOffsets{}, 0, fmt.Errorf("unknown symbols offsets cache format %d", format)
+	}
+	if factor := d.Be32int(); factor != symbolFactor {
+		return symbolOffsets{}, 0, fmt.Errorf("symbols offsets cache has been computed with symbol factor %d instead of %d", factor, symbolFactor)
+	}
+
+	cachedVersion := d.Be32int()
+	cachedOffset := d.Be64int64()
+	cachedLength := d.Be64int64()
+	seen = int(d.Be64int64())
+	if err := d.Err(); err != nil {
+		return symbolOffsets{}, 0, err
+	}
+	if cachedVersion != version || int(cachedOffset) != tableOffset || int(cachedLength) != tableLength {
+		return symbolOffsets{}, 0, fmt.Errorf("symbols offsets cache doesn't match the symbols table")
+	}
+
+	offsets := symbolOffsets{mmapped: d.Get(), file: f}
+	if expected := (seen + symbolFactor - 1) / symbolFactor; offsets.len() != expected {
+		return symbolOffsets{}, 0, fmt.Errorf("symbols offsets cache holds %d offsets instead of %d", offsets.len(), expected)
+	}
+
+	return offsets, seen, nil
+}
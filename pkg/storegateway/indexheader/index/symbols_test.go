@@ -51,7 +51,7 @@ func TestSymbols(t *testing.T) {
 	require.NoError(t, err)
 
 	// We store only 4 offsets to symbols.
-	require.Len(t, s.offsets, 4)
+	require.Equal(t, 4, s.offsets.len())
 
 	for i := 99; i >= 0; i-- {
 		s, err := s.Lookup(uint32(i))
@@ -87,3 +87,86 @@ func TestSymbols(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expected, actual)
 }
+
+func TestSymbolsWithOffsetsCache(t *testing.T) {
+	buf := encoding.Encbuf{}
+	buf.PutUvarintStr("something")
+
+	symbolsStart := buf.Len()
+	buf.PutBE32int(204) // Length of symbols table.
+	buf.PutBE32int(100) // Number of symbols.
+	for i := 0; i < 100; i++ {
+		buf.PutUvarintStr(string(rune(i)))
+	}
+	buf.PutBE32(crc32.Checksum(buf.Get()[symbolsStart+4:], castagnoliTable))
+
+	dir := t.TempDir()
+	filePath := path.Join(dir, "index")
+	cachePath := path.Join(dir, "symbols-offsets")
+	require.NoError(t, os.WriteFile(filePath, buf.Get(), 0700))
+
+	df := streamencoding.NewDecbufFactory(filePath, 0, log.NewNopLogger(), streamencoding.NewDecbufFactoryMetrics(nil))
+	t.Cleanup(df.Stop)
+
+	expected, err := NewSymbols(df, index.FormatV2, symbolsStart)
+	require.NoError(t, err)
+
+	assertSymbols := func(t *testing.T, s *Symbols) {
+		require.Equal(t, expected.offsets.len(), s.offsets.len())
+		for i := 0; i < expected.offsets.len(); i++ {
+			require.Equal(t, expected.offsets.at(i), s.offsets.at(i))
+		}
+		for i := 99; i >= 0; i-- {
+			sym, err := s.Lookup(uint32(i))
+			require.NoError(t, err)
+			require.Equal(t, string(rune(i)), sym)
+
+			o, err := s.ReverseLookup(string(rune(i)))
+			require.NoError(t, err)
+			require.Equal(t, uint32(i), o)
+		}
+	}
+
+	// The cache file doesn't exist yet, so it's written.
+	s, cacheHit, err := NewSymbolsWithOffsetsCache(df, index.FormatV2, symbolsStart, cachePath, log.NewNopLogger())
+	require.NoError(t, err)
+	require.False(t, cacheHit)
+	assertSymbols(t, s)
+	require.NoError(t, s.Close())
+	require.FileExists(t, cachePath)
+
+	// The cache file is read on the next load.
+	s, cacheHit, err = NewSymbolsWithOffsetsCache(df, index.FormatV2, symbolsStart, cachePath, log.NewNopLogger())
+	require.NoError(t, err)
+	require.True(t, cacheHit)
+	assertSymbols(t, s)
+	require.NoError(t, s.Close())
+
+	// A cache file computed for another symbols table is ignored and rewritten.
+	s, _, err = NewSymbolsWithOffsetsCache(df, index.FormatV1, symbolsStart, cachePath, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	s, cacheHit, err = NewSymbolsWithOffsetsCache(df, index.FormatV2, symbolsStart, cachePath, log.NewNopLogger())
+	require.NoError(t, err)
+	require.False(t, cacheHit)
+	assertSymbols(t, s)
+	require.NoError(t, s.Close())
+
+	// A corrupted cache file is ignored and rewritten.
+	contents, err := os.ReadFile(cachePath)
+	require.NoError(t, err)
+	contents[symbolsOffsetsCacheHeaderLen] ^= 0xff
+	require.NoError(t, os.WriteFile(cachePath, contents, 0600))
+
+	s, cacheHit, err = NewSymbolsWithOffsetsCache(df, index.FormatV2, symbolsStart, cachePath, log.NewNopLogger())
+	require.NoError(t, err)
+	require.False(t, cacheHit)
+	assertSymbols(t, s)
+	require.NoError(t, s.Close())
+
+	s, cacheHit, err = NewSymbolsWithOffsetsCache(df, index.FormatV2, symbolsStart, cachePath, log.NewNopLogger())
+	require.NoError(t, err)
+	require.True(t, cacheHit)
+	assertSymbols(t, s)
+	require.NoError(t, s.Close())
+}
@@ -73,6 +73,7 @@ type LazyBinaryReader struct {
 	filepath string
 	metrics  *LazyBinaryReaderMetrics
 	onClosed func(*LazyBinaryReader)
+	onLoaded func(*LazyBinaryReader)
 
 	readerMx      sync.RWMutex
 	reader        Reader
@@ -94,8 +95,10 @@ func NewLazyBinaryReader(
 	bkt objstore.BucketReader,
 	dir string,
 	id ulid.ULID,
+	cfg Config,
 	metrics *LazyBinaryReaderMetrics,
 	onClosed func(*LazyBinaryReader),
+	onLoaded func(*LazyBinaryReader),
 ) (*LazyBinaryReader, error) {
 	path := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
 
@@ -108,21 +111,28 @@ func NewLazyBinaryReader(
 		level.Debug(logger).Log("msg", "the index-header doesn't exist on disk; recreating", "path", path)
 
 		start := time.Now()
-		if err := WriteBinary(ctx, bkt, id, path); err != nil {
+		if err := writeBinary(ctx, bkt, id, path, cfg); err != nil {
 			return nil, errors.Wrap(err, "write index header")
 		}
 
 		level.Debug(logger).Log("msg", "built index-header file", "path", path, "elapsed", time.Since(start))
 	}
 
+	return newLazyBinaryReader(readerFactory, logger, path, metrics, onClosed, onLoaded), nil
+}
+
+// newLazyBinaryReader makes a new LazyBinaryReader for the index-header at path, without building
+// it if it doesn't exist on the local disk.
+func newLazyBinaryReader(readerFactory func() (Reader, error), logger log.Logger, path string, metrics *LazyBinaryReaderMetrics, onClosed, onLoaded func(*LazyBinaryReader)) *LazyBinaryReader {
 	return &LazyBinaryReader{
 		logger:        logger,
 		filepath:      path,
 		metrics:       metrics,
 		usedAt:        atomic.NewInt64(time.Now().UnixNano()),
 		onClosed:      onClosed,
+		onLoaded:      onLoaded,
 		readerFactory: readerFactory,
-	}, nil
+	}
 }
 
 // Close implements Reader. It unloads the index-header from memory (releasing the mmap
@@ -188,6 +198,19 @@ func (r *LazyBinaryReader) LabelValues(name string, filter func(string) bool) ([
 	return r.reader.LabelValues(name, filter)
 }
 
+// LabelValuesWithPrefix implements Reader.
+func (r *LazyBinaryReader) LabelValuesWithPrefix(name, prefix string, filter func(string) bool) ([]string, error) {
+	r.readerMx.RLock()
+	defer r.readerMx.RUnlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	r.usedAt.Store(time.Now().UnixNano())
+	return r.reader.LabelValuesWithPrefix(name, prefix, filter)
+}
+
 // LabelNames implements Reader.
 func (r *LazyBinaryReader) LabelNames() ([]string, error) {
 	r.readerMx.RLock()
@@ -252,6 +275,13 @@ func (r *LazyBinaryReader) load() (returnErr error) {
 	level.Debug(r.logger).Log("msg", "lazy loaded index-header file", "path", r.filepath, "elapsed", elapsed)
 	r.metrics.loadDuration.Observe(elapsed.Seconds())
 
+	// The reader is going to be used right after being loaded, so we consider it used now,
+	// to not have it unloaded as the least recently used one before the caller uses it.
+	r.usedAt.Store(time.Now().UnixNano())
+	if r.onLoaded != nil {
+		r.onLoaded(r)
+	}
+
 	return nil
 }
 
@@ -281,6 +311,14 @@ func (r *LazyBinaryReader) unloadIfIdleSince(ts int64) error {
 	return nil
 }
 
+// lastUsedAt returns the last time the reader was used (as unix nano), and whether it's currently loaded.
+func (r *LazyBinaryReader) lastUsedAt() (usedAt int64, loaded bool) {
+	r.readerMx.RLock()
+	defer r.readerMx.RUnlock()
+
+	return r.usedAt.Load(), r.reader != nil
+}
+
 // isIdleSince returns true if the reader is idle since given time (as unix nano).
 func (r *LazyBinaryReader) isIdleSince(ts int64) bool {
 	if r.usedAt.Load() > ts {
@@ -291,7 +291,7 @@ func testLazyBinaryReader(t *testing.T, bkt objstore.BucketReader, dir string, i
 			return NewBinaryReader(ctx, logger, bkt, dir, id, 3, Config{})
 		}
 
-		reader, err := NewLazyBinaryReader(ctx, factory, logger, bkt, dir, id, NewLazyBinaryReaderMetrics(nil), nil)
+		reader, err := NewLazyBinaryReader(ctx, factory, logger, bkt, dir, id, Config{}, NewLazyBinaryReaderMetrics(nil), nil, nil)
 		test(t, reader, err)
 	})
 
@@ -302,7 +302,7 @@ func testLazyBinaryReader(t *testing.T, bkt objstore.BucketReader, dir string, i
 			return NewStreamBinaryReader(ctx, logger, bkt, dir, id, 3, NewStreamBinaryReaderMetrics(nil), Config{})
 		}
 
-		reader, err := NewLazyBinaryReader(ctx, factory, logger, bkt, dir, id, NewLazyBinaryReaderMetrics(nil), nil)
+		reader, err := NewLazyBinaryReader(ctx, factory, logger, bkt, dir, id, Config{}, NewLazyBinaryReaderMetrics(nil), nil, nil)
 		test(t, reader, err)
 	})
 }
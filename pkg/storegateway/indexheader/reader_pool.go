@@ -7,6 +7,8 @@ package indexheader
 
 import (
 	"context"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,14 +17,17 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 )
 
 // ReaderPoolMetrics holds metrics tracked by ReaderPool.
 type ReaderPoolMetrics struct {
 	lazyReader   *LazyBinaryReaderMetrics
 	streamReader *StreamBinaryReaderMetrics
+	healed       prometheus.Counter
 }
 
 // NewReaderPoolMetrics makes new ReaderPoolMetrics.
@@ -30,49 +35,68 @@ func NewReaderPoolMetrics(reg prometheus.Registerer) *ReaderPoolMetrics {
 	return &ReaderPoolMetrics{
 		lazyReader:   NewLazyBinaryReaderMetrics(reg),
 		streamReader: NewStreamBinaryReaderMetrics(reg),
+		healed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_healed_total",
+			Help: "Total number of local index-headers which couldn't be read, for example because corrupted, and have been deleted and rebuilt from the block index in the bucket.",
+		}),
 	}
 }
 
 // ReaderPool is used to istantiate new index-header readers and keep track of them.
 // When the lazy reader is enabled, the pool keeps track of all instantiated readers
-// and automatically close them once the idle timeout is reached. A closed lazy reader
+// and automatically close them once the idle timeout is reached, or the least recently
+// used ones once the max number of loaded readers is exceeded. A closed lazy reader
 // will be automatically re-opened upon next usage.
 type ReaderPool struct {
 	lazyReaderEnabled     bool
 	lazyReaderIdleTimeout time.Duration
+	lazyReaderMaxLoaded   int
 	logger                log.Logger
 	metrics               *ReaderPoolMetrics
 
 	// Channel used to signal once the pool is closing.
 	close chan struct{}
 
+	// Channel used to signal once a lazy reader has been loaded.
+	loaded chan struct{}
+
 	// Keep track of all readers managed by the pool.
 	lazyReadersMx sync.Mutex
 	lazyReaders   map[*LazyBinaryReader]struct{}
 }
 
-// NewReaderPool makes a new ReaderPool.
-func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, metrics *ReaderPoolMetrics) *ReaderPool {
+// NewReaderPool makes a new ReaderPool. If lazyReaderMaxLoaded is > 0, the pool keeps at most
+// lazyReaderMaxLoaded lazy readers loaded at the same time, unloading the least recently used ones.
+func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, lazyReaderMaxLoaded int, metrics *ReaderPoolMetrics) *ReaderPool {
 	p := &ReaderPool{
 		logger:                logger,
 		metrics:               metrics,
 		lazyReaderEnabled:     lazyReaderEnabled,
 		lazyReaderIdleTimeout: lazyReaderIdleTimeout,
+		lazyReaderMaxLoaded:   lazyReaderMaxLoaded,
 		lazyReaders:           make(map[*LazyBinaryReader]struct{}),
 		close:                 make(chan struct{}),
+		loaded:                make(chan struct{}, 1),
 	}
 
-	// Start a goroutine to close idle readers (only if required).
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
-		checkFreq := p.lazyReaderIdleTimeout / 10
-
+	// Start a goroutine to close idle and least recently used readers (only if required).
+	if p.isTrackingLazyReaders() {
 		go func() {
+			var checkIdle <-chan time.Time
+			if p.lazyReaderIdleTimeout > 0 {
+				ticker := time.NewTicker(p.lazyReaderIdleTimeout / 10)
+				defer ticker.Stop()
+				checkIdle = ticker.C
+			}
+
 			for {
 				select {
 				case <-p.close:
 					return
-				case <-time.After(checkFreq):
+				case <-checkIdle:
 					p.closeIdleReaders()
+				case <-p.loaded:
+					p.closeLeastRecentlyUsedReaders()
 				}
 			}
 		}()
@@ -81,6 +105,12 @@ func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTime
 	return p
 }
 
+// isTrackingLazyReaders returns whether the pool needs to keep track of the lazy readers
+// in order to close them.
+func (p *ReaderPool) isTrackingLazyReaders() bool {
+	return p.lazyReaderEnabled && (p.lazyReaderIdleTimeout > 0 || p.lazyReaderMaxLoaded > 0)
+}
+
 // NewBinaryReader creates and returns a new binary reader. If the pool has been configured
 // with lazy reader enabled, this function will return a lazy reader. The returned lazy reader
 // is tracked by the pool and automatically closed once the idle timeout expires.
@@ -91,16 +121,19 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 
 	if cfg.StreamReaderEnabled {
 		readerFactory = func() (Reader, error) {
-			return NewStreamBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, p.metrics.streamReader, cfg)
+			return newStreamBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, p.metrics.streamReader, cfg, p.metrics.healed)
 		}
 	} else {
 		readerFactory = func() (Reader, error) {
-			return NewBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, cfg)
+			return newBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, cfg, p.metrics.healed)
 		}
 	}
 
-	if p.lazyReaderEnabled {
-		reader, err = NewLazyBinaryReader(ctx, readerFactory, logger, bkt, dir, id, p.metrics.lazyReader, p.onLazyReaderClosed)
+	if p.lazyReaderEnabled && cfg.StreamReaderEnabled && cfg.StreamReaderBucketReadsEnabled {
+		// The index-header is read directly from the bucket, so there's nothing to download.
+		reader = newLazyBinaryReader(readerFactory, logger, filepath.Join(dir, id.String(), block.IndexHeaderFilename), p.metrics.lazyReader, p.onLazyReaderClosed, p.onLazyReaderLoaded)
+	} else if p.lazyReaderEnabled {
+		reader, err = NewLazyBinaryReader(ctx, readerFactory, logger, bkt, dir, id, cfg, p.metrics.lazyReader, p.onLazyReaderClosed, p.onLazyReaderLoaded)
 	} else {
 		reader, err = readerFactory()
 	}
@@ -110,7 +143,7 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	}
 
 	// Keep track of lazy readers only if required.
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
+	if p.isTrackingLazyReaders() {
 		p.lazyReadersMx.Lock()
 		p.lazyReaders[reader.(*LazyBinaryReader)] = struct{}{}
 		p.lazyReadersMx.Unlock()
@@ -135,6 +168,51 @@ func (p *ReaderPool) closeIdleReaders() {
 	}
 }
 
+// closeLeastRecentlyUsedReaders closes the least recently used readers exceeding the max number
+// of loaded readers. A reader used after being picked for closing is not closed.
+func (p *ReaderPool) closeLeastRecentlyUsedReaders() {
+	if p.lazyReaderMaxLoaded <= 0 {
+		return
+	}
+
+	type loadedReader struct {
+		reader *LazyBinaryReader
+		usedAt int64
+	}
+
+	var loaded []loadedReader
+	for _, r := range p.getTrackedReaders() {
+		if usedAt, ok := r.lastUsedAt(); ok {
+			loaded = append(loaded, loadedReader{reader: r, usedAt: usedAt})
+		}
+	}
+	if len(loaded) <= p.lazyReaderMaxLoaded {
+		return
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].usedAt < loaded[j].usedAt
+	})
+
+	for _, l := range loaded[:len(loaded)-p.lazyReaderMaxLoaded] {
+		if err := l.reader.unloadIfIdleSince(l.usedAt); err != nil && !errors.Is(err, errNotIdle) {
+			level.Warn(p.logger).Log("msg", "failed to close least recently used index-header reader", "err", err)
+		}
+	}
+}
+
+func (p *ReaderPool) getTrackedReaders() []*LazyBinaryReader {
+	p.lazyReadersMx.Lock()
+	defer p.lazyReadersMx.Unlock()
+
+	readers := make([]*LazyBinaryReader, 0, len(p.lazyReaders))
+	for r := range p.lazyReaders {
+		readers = append(readers, r)
+	}
+
+	return readers
+}
+
 func (p *ReaderPool) getIdleReadersSince(ts int64) []*LazyBinaryReader {
 	p.lazyReadersMx.Lock()
 	defer p.lazyReadersMx.Unlock()
@@ -166,3 +244,16 @@ func (p *ReaderPool) onLazyReaderClosed(r *LazyBinaryReader) {
 	// be used anymore, so we can automatically remove it from the pool.
 	delete(p.lazyReaders, r)
 }
+
+func (p *ReaderPool) onLazyReaderLoaded(*LazyBinaryReader) {
+	if p.lazyReaderMaxLoaded <= 0 {
+		return
+	}
+
+	// Signal the loading without blocking: if a signal is already pending, the
+	// least recently used readers will be closed once it's received anyway.
+	select {
+	case p.loaded <- struct{}{}:
+	default:
+	}
+}
@@ -7,6 +7,7 @@ package indexheader
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,17 +28,33 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 	tests := map[string]struct {
 		lazyReaderEnabled     bool
 		lazyReaderIdleTimeout time.Duration
+		cfg                   Config
+		expectIndexHeader     bool
 	}{
 		"lazy reader is disabled": {
 			lazyReaderEnabled: false,
+			expectIndexHeader: true,
 		},
 		"lazy reader is enabled but close on idle timeout is disabled": {
 			lazyReaderEnabled:     true,
 			lazyReaderIdleTimeout: 0,
+			expectIndexHeader:     true,
 		},
 		"lazy reader and close on idle timeout are both enabled": {
 			lazyReaderEnabled:     true,
 			lazyReaderIdleTimeout: time.Minute,
+			expectIndexHeader:     true,
+		},
+		"lazy reader is disabled and stream reader reads from the bucket": {
+			lazyReaderEnabled: false,
+			cfg:               Config{StreamReaderEnabled: true, StreamReaderBucketReadsEnabled: true},
+			expectIndexHeader: false,
+		},
+		"lazy reader is enabled and stream reader reads from the bucket": {
+			lazyReaderEnabled:     true,
+			lazyReaderIdleTimeout: time.Minute,
+			cfg:                   Config{StreamReaderEnabled: true, StreamReaderBucketReadsEnabled: true},
+			expectIndexHeader:     false,
 		},
 	}
 
@@ -61,10 +78,12 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			pool := NewReaderPool(log.NewNopLogger(), testData.lazyReaderEnabled, testData.lazyReaderIdleTimeout, NewReaderPoolMetrics(nil))
+			dir := t.TempDir()
+
+			pool := NewReaderPool(log.NewNopLogger(), testData.lazyReaderEnabled, testData.lazyReaderIdleTimeout, 0, NewReaderPoolMetrics(nil))
 			defer pool.Close()
 
-			r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
+			r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, blockID, 3, testData.cfg)
 			require.NoError(t, err)
 			defer func() { require.NoError(t, r.Close()) }()
 
@@ -72,6 +91,12 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 			labelNames, err := r.LabelNames()
 			require.NoError(t, err)
 			require.Equal(t, []string{"a"}, labelNames)
+
+			if testData.expectIndexHeader {
+				require.FileExists(t, filepath.Join(dir, blockID.String(), block.IndexHeaderFilename))
+			} else {
+				require.NoFileExists(t, filepath.Join(dir, blockID.String(), block.IndexHeaderFilename))
+			}
 		})
 	}
 }
@@ -98,7 +123,7 @@ func TestReaderPool_ShouldCloseIdleLazyReaders(t *testing.T) {
 	require.NoError(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, blockID.String()), nil))
 
 	metrics := NewReaderPoolMetrics(nil)
-	pool := NewReaderPool(log.NewNopLogger(), true, idleTimeout, metrics)
+	pool := NewReaderPool(log.NewNopLogger(), true, idleTimeout, 0, metrics)
 	defer pool.Close()
 
 	r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
@@ -134,3 +159,143 @@ func TestReaderPool_ShouldCloseIdleLazyReaders(t *testing.T) {
 	require.Equal(t, float64(2), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
 	require.Equal(t, float64(2), promtestutil.ToFloat64(metrics.lazyReader.unloadCount))
 }
+
+func TestReaderPool_ShouldCloseLeastRecentlyUsedLazyReaders(t *testing.T) {
+	const maxLoaded = 2
+
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	bkt, err := filesystem.NewBucket(filepath.Join(tmpDir, "bkt"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bkt.Close()) })
+
+	// Create block.
+	blockID, err := testhelper.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("a", "1"),
+		labels.FromStrings("a", "2"),
+	}, 100, 0, 1000, labels.FromStrings("ext1", "1"), 124)
+	require.NoError(t, err)
+	require.NoError(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, blockID.String()), nil))
+
+	metrics := NewReaderPoolMetrics(nil)
+	pool := NewReaderPool(log.NewNopLogger(), true, 0, maxLoaded, metrics)
+	t.Cleanup(pool.Close)
+
+	// Create more readers than the max number of loaded readers.
+	readers := make([]*LazyBinaryReader, 0, maxLoaded+1)
+	for i := 0; i < maxLoaded+1; i++ {
+		r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, r.Close()) })
+
+		readers = append(readers, r.(*LazyBinaryReader))
+	}
+
+	isLoaded := func(r *LazyBinaryReader) bool {
+		_, loaded := r.lastUsedAt()
+		return loaded
+	}
+
+	// Load the first readers, up to the limit. Then use the first one again, so that
+	// the second one becomes the least recently used one.
+	for _, r := range readers[:maxLoaded] {
+		_, err := r.LabelNames()
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, err = readers[0].LabelNames()
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	require.True(t, isLoaded(readers[0]))
+	require.True(t, isLoaded(readers[1]))
+	require.Equal(t, float64(0), promtestutil.ToFloat64(metrics.lazyReader.unloadCount))
+
+	// Loading one more reader exceeds the limit, so the least recently used one gets closed.
+	_, err = readers[2].LabelNames()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(metrics.lazyReader.unloadCount) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.True(t, isLoaded(readers[0]))
+	require.False(t, isLoaded(readers[1]))
+	require.True(t, isLoaded(readers[2]))
+
+	// The closed reader is tracked by the pool, and re-opened upon next usage.
+	require.True(t, pool.isTracking(readers[1]))
+	labelNames, err := readers[1].LabelNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, labelNames)
+	require.Equal(t, float64(maxLoaded+2), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
+
+	require.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(metrics.lazyReader.unloadCount) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.False(t, isLoaded(readers[0]))
+}
+
+func TestReaderPool_ShouldRebuildCorruptedIndexHeaders(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	bkt, err := filesystem.NewBucket(filepath.Join(tmpDir, "bkt"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bkt.Close()) })
+
+	// Create block.
+	blockID, err := testhelper.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("a", "1"),
+		labels.FromStrings("a", "2"),
+	}, 100, 0, 1000, labels.FromStrings("ext1", "1"), 124)
+	require.NoError(t, err)
+	require.NoError(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, blockID.String()), nil))
+
+	// Build the index-header once, to know the position of its sections.
+	originalPath := filepath.Join(t.TempDir(), block.IndexHeaderFilename)
+	require.NoError(t, WriteBinary(ctx, bkt, blockID, originalPath))
+	original, err := os.ReadFile(originalPath)
+	require.NoError(t, err)
+	toc, err := newBinaryTOCFromByteSlice(realByteSlice(original))
+	require.NoError(t, err)
+
+	corruptedOffsets := map[string]int{
+		"symbols":               int(toc.Symbols) + 6,
+		"postings offset table": int(toc.PostingsOffsetTable) + 6,
+		"table of contents":     len(original) - binaryTOCLen + 2,
+	}
+
+	for _, readerCfg := range []Config{{}, {StreamReaderEnabled: true}} {
+		for section, offset := range corruptedOffsets {
+			t.Run(fmt.Sprintf("stream reader: %t, corrupted section: %s", readerCfg.StreamReaderEnabled, section), func(t *testing.T) {
+				dir := t.TempDir()
+				path := filepath.Join(dir, blockID.String(), block.IndexHeaderFilename)
+
+				corrupted := append([]byte{}, original...)
+				corrupted[offset] ^= 0xff
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+				require.NoError(t, os.WriteFile(path, corrupted, 0o600))
+
+				metrics := NewReaderPoolMetrics(nil)
+				pool := NewReaderPool(log.NewNopLogger(), false, 0, 0, metrics)
+				defer pool.Close()
+
+				r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, blockID, 3, readerCfg)
+				require.NoError(t, err)
+				defer func() { require.NoError(t, r.Close()) }()
+
+				labelNames, err := r.LabelNames()
+				require.NoError(t, err)
+				require.Equal(t, []string{"a"}, labelNames)
+
+				// The local index-header should have been rebuilt.
+				rebuilt, err := os.ReadFile(path)
+				require.NoError(t, err)
+				require.Equal(t, original, rebuilt)
+				require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.healed))
+			})
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+)
+
+// openOrBuild opens the index-header at path calling open. If the index-header can't be opened, because
+// it doesn't exist or it's corrupted (for example the checksum of one of its sections doesn't match), the
+// local index-header is deleted and built again from the block index in the bucket, and then opened again.
+// If healed is not nil, it's incremented each time an existing index-header is successfully rebuilt.
+func openOrBuild[T any](ctx context.Context, logger log.Logger, bkt objstore.BucketReader, id ulid.ULID, path string, cfg Config, healed prometheus.Counter, open func() (T, error)) (T, error) {
+	r, err := open()
+	if err == nil {
+		return r, nil
+	}
+
+	_, statErr := os.Stat(path)
+	existed := statErr == nil
+	if existed {
+		level.Warn(logger).Log("msg", "failed to read index-header from disk; deleting and recreating it", "path", path, "err", err)
+
+		// Delete the local copy first, so that it's never read again if the index-header can't be rebuilt.
+		if err := os.Remove(path); err != nil {
+			var zero T
+			return zero, errors.Wrap(err, "delete index header")
+		}
+	} else {
+		level.Debug(logger).Log("msg", "failed to read index-header from disk; recreating", "path", path, "err", err)
+	}
+
+	start := time.Now()
+	if err := writeBinary(ctx, bkt, id, path, cfg); err != nil {
+		var zero T
+		return zero, errors.Wrap(err, "write index header")
+	}
+
+	level.Debug(logger).Log("msg", "built index-header file", "path", path, "elapsed", time.Since(start))
+
+	r, err = open()
+	if err == nil && existed && healed != nil {
+		healed.Inc()
+	}
+	return r, err
+}
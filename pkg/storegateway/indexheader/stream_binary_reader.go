@@ -7,16 +7,18 @@ package indexheader
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"sync"
-	"time"
 
 	"github.com/go-kit/log"
-	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/runutil"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/objstore"
 
@@ -25,13 +27,27 @@ import (
 	streamindex "github.com/grafana/mimir/pkg/storegateway/indexheader/index"
 )
 
+// symbolsOffsetsCacheFilename is the name of the symbols offsets cache file, written next to the index-header.
+const symbolsOffsetsCacheFilename = "index-header-symbols-offsets"
+
 type StreamBinaryReaderMetrics struct {
 	decbufFactory *streamencoding.DecbufFactoryMetrics
+
+	symbolsOffsetsCacheHits   prometheus.Counter
+	symbolsOffsetsCacheMisses prometheus.Counter
 }
 
 func NewStreamBinaryReaderMetrics(reg prometheus.Registerer) *StreamBinaryReaderMetrics {
 	return &StreamBinaryReaderMetrics{
 		decbufFactory: streamencoding.NewDecbufFactoryMetrics(reg),
+		symbolsOffsetsCacheHits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_stream_symbols_offsets_cache_hits_total",
+			Help: "Total number of index-header loads for which the symbols offsets have been read from the persisted symbols offsets cache of the block.",
+		}),
+		symbolsOffsetsCacheMisses: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_stream_symbols_offsets_cache_misses_total",
+			Help: "Total number of index-header loads for which the persisted symbols offsets cache of the block was missing or invalid, and the symbols table has been read.",
+		}),
 	}
 }
 
@@ -55,32 +71,48 @@ type StreamBinaryReader struct {
 
 	postingsOffsetTable streamindex.PostingOffsetTable
 
+	// symbolsOffsetShift is added to the symbol references to get the offset of the symbols
+	// in the file read. For index v1, references are offsets in the index, so they need to be
+	// shifted when the index-header is read.
+	symbolsOffsetShift uint32
+
 	version      int
 	indexVersion int
 }
 
-// NewStreamBinaryReader loads or builds new index-header if not present on disk.
+// NewStreamBinaryReader loads or builds new index-header if not present on disk. If reading the
+// index-header directly from the bucket is enabled, no index-header is built and the index-header
+// sections are read from the index of the block in the bucket.
 func NewStreamBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID, postingOffsetsInMemSampling int, metrics *StreamBinaryReaderMetrics, cfg Config) (*StreamBinaryReader, error) {
-	binfn := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
-	br, err := newFileStreamBinaryReader(binfn, postingOffsetsInMemSampling, logger, metrics, cfg)
-	if err == nil {
-		return br, nil
-	}
-
-	level.Debug(logger).Log("msg", "failed to read index-header from disk; recreating", "path", binfn, "err", err)
+	return newStreamBinaryReader(ctx, logger, bkt, dir, id, postingOffsetsInMemSampling, metrics, cfg, nil)
+}
 
-	start := time.Now()
-	if err := WriteBinary(ctx, bkt, id, binfn); err != nil {
-		return nil, fmt.Errorf("cannot write index header: %w", err)
+// newStreamBinaryReader is like NewStreamBinaryReader, but increments healed each time a local
+// index-header which can't be read is rebuilt.
+func newStreamBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID, postingOffsetsInMemSampling int, metrics *StreamBinaryReaderMetrics, cfg Config, healed prometheus.Counter) (*StreamBinaryReader, error) {
+	if cfg.StreamReaderBucketReadsEnabled {
+		return newBucketStreamBinaryReader(ctx, bkt, id, postingOffsetsInMemSampling, metrics, cfg)
 	}
 
-	level.Debug(logger).Log("msg", "built index-header file", "path", binfn, "elapsed", time.Since(start))
-	return newFileStreamBinaryReader(binfn, postingOffsetsInMemSampling, logger, metrics, cfg)
+	binfn := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+	return openOrBuild(ctx, logger, bkt, id, binfn, cfg, healed, func() (*StreamBinaryReader, error) {
+		return newFileStreamBinaryReader(binfn, postingOffsetsInMemSampling, logger, metrics, cfg)
+	})
 }
 
 func newFileStreamBinaryReader(path string, postingOffsetsInMemSampling int, logger log.Logger, metrics *StreamBinaryReaderMetrics, cfg Config) (bw *StreamBinaryReader, err error) {
+	factory, err := newFileDecbufFactory(path, logger, metrics, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			factory.Stop()
+		}
+	}()
+
 	r := &StreamBinaryReader{
-		factory: streamencoding.NewDecbufFactory(path, cfg.StreamReaderMaxIdleFileHandles, logger, metrics.decbufFactory),
+		factory: factory,
 	}
 
 	// Create a new raw decoding buffer with access to the entire index-header file to
@@ -106,7 +138,7 @@ func newFileStreamBinaryReader(path string, postingOffsetsInMemSampling int, log
 		return nil, fmt.Errorf("cannot read version and index version: %w", err)
 	}
 
-	if r.version != BinaryFormatV1 {
+	if r.version != BinaryFormatV1 && r.version != BinaryFormatV2 {
 		return nil, fmt.Errorf("unknown index-header file version %d", r.version)
 	}
 
@@ -115,32 +147,119 @@ func newFileStreamBinaryReader(path string, postingOffsetsInMemSampling int, log
 		return nil, fmt.Errorf("cannot read table-of-contents: %w", err)
 	}
 
-	r.symbols, err = streamindex.NewSymbols(r.factory, r.indexVersion, int(r.toc.Symbols))
+	if r.indexVersion == index.FormatV1 {
+		// For v1 little trick is needed. Refs are actual offset inside index, not index-header. This is different
+		// of the header length difference between two files.
+		r.symbolsOffsetShift = headerLen - index.HeaderLen
+	}
+
+	if cfg.StreamReaderSymbolsOffsetsCacheEnabled {
+		var cacheHit bool
+		cachePath := filepath.Join(filepath.Dir(path), symbolsOffsetsCacheFilename)
+		r.symbols, cacheHit, err = streamindex.NewSymbolsWithOffsetsCache(r.factory, r.indexVersion, int(r.toc.Symbols), cachePath, logger)
+		if cacheHit {
+			metrics.symbolsOffsetsCacheHits.Inc()
+		} else {
+			metrics.symbolsOffsetsCacheMisses.Inc()
+		}
+	} else {
+		r.symbols, err = streamindex.NewSymbols(r.factory, r.indexVersion, int(r.toc.Symbols))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot load symbols: %w", err)
 	}
 
-	r.postingsOffsetTable, err = streamindex.NewPostingOffsetTable(r.factory, int(r.toc.PostingsOffsetTable), r.indexVersion, indexLastPostingEnd, postingOffsetsInMemSampling)
+	if err = r.loadPostingsOffsetTable(indexLastPostingEnd, postingOffsetsInMemSampling); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// newFileDecbufFactory returns the DecbufFactory to read the index-header file at path. The contents of an
+// index-header of version 3 are an index-header of version 1 compressed with zstd, so they're read through
+// a factory decompressing them.
+func newFileDecbufFactory(path string, logger log.Logger, metrics *StreamBinaryReaderMetrics, cfg Config) (*streamencoding.DecbufFactory, error) {
+	f, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
 
-	labelNames, err := r.postingsOffsetTable.LabelNames()
+	header := make([]byte, 5)
+	_, err = io.ReadFull(f, header)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read version: %w", err)
+	}
+
+	if binary.BigEndian.Uint32(header[0:4]) == MagicIndex && int(header[4]) == BinaryFormatV3 {
+		return streamencoding.NewZstdDecbufFactory(path, int(cfg.StreamReaderCompressedMaxCachedFrames), metrics.decbufFactory)
+	}
+	return streamencoding.NewDecbufFactory(path, cfg.StreamReaderMaxIdleFileHandles, logger, metrics.decbufFactory), nil
+}
+
+// newBucketStreamBinaryReader creates a new StreamBinaryReader which reads the symbols and postings offset
+// table directly from the index of the block in the bucket, through range requests, instead of the index-header.
+func newBucketStreamBinaryReader(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, postingOffsetsInMemSampling int, metrics *StreamBinaryReaderMetrics, cfg Config) (*StreamBinaryReader, error) {
+	ir, indexVersion, err := newChunkedIndexReader(ctx, bkt, id)
 	if err != nil {
 		return nil, err
 	}
 
-	r.nameSymbols = make(map[uint32]string, len(labelNames))
-	if err = r.symbols.ForEachSymbol(labelNames, func(sym string, offset uint32) error {
-		r.nameSymbols[offset] = sym
-		return nil
-	}); err != nil {
+	factory, err := streamencoding.NewBucketDecbufFactory(bkt, ir.path, int64(ir.size), int(cfg.StreamReaderBucketReadsMaxCachedPages), metrics.decbufFactory)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create decoding buffer factory: %w", err)
+	}
+
+	r := &StreamBinaryReader{
+		factory: factory,
+		toc: &BinaryTOC{
+			Symbols:             ir.toc.Symbols,
+			PostingsOffsetTable: ir.toc.PostingsTable,
+		},
+		version:      BinaryFormatV1,
+		indexVersion: indexVersion,
+	}
+
+	r.symbols, err = streamindex.NewSymbols(r.factory, r.indexVersion, int(r.toc.Symbols))
+	if err != nil {
+		factory.Stop()
+		return nil, fmt.Errorf("cannot load symbols: %w", err)
+	}
+
+	// The postings offset table immediately follows the last posting in the index, like WriteBinary assumes.
+	if err = r.loadPostingsOffsetTable(ir.toc.PostingsTable, postingOffsetsInMemSampling); err != nil {
+		factory.Stop()
 		return nil, err
 	}
 
 	return r, nil
 }
 
+func (r *StreamBinaryReader) loadPostingsOffsetTable(indexLastPostingEnd uint64, postingOffsetsInMemSampling int) (err error) {
+	if r.version == BinaryFormatV2 {
+		r.postingsOffsetTable, err = streamindex.NewCompressedPostingOffsetTable(r.factory, int(r.toc.PostingsOffsetTable))
+	} else {
+		r.postingsOffsetTable, err = streamindex.NewPostingOffsetTable(r.factory, int(r.toc.PostingsOffsetTable), r.indexVersion, indexLastPostingEnd, postingOffsetsInMemSampling)
+	}
+	if err != nil {
+		return err
+	}
+
+	labelNames, err := r.postingsOffsetTable.LabelNames()
+	if err != nil {
+		return err
+	}
+
+	r.nameSymbols = make(map[uint32]string, len(labelNames))
+	return r.symbols.ForEachSymbol(labelNames, func(sym string, offset uint32) error {
+		r.nameSymbols[offset] = sym
+		return nil
+	})
+}
+
 // newBinaryTOCFromByteSlice return parsed TOC from given Decbuf. The Decbuf is expected to be
 // configured to access the entirety of the index-header file.
 func newBinaryTOCFromFile(d streamencoding.Decbuf, indexHeaderSize int) (*BinaryTOC, error) {
@@ -197,11 +316,7 @@ func (r *StreamBinaryReader) LookupSymbol(o uint32) (string, error) {
 	}
 	r.valueSymbolsMx.Unlock()
 
-	if r.indexVersion == index.FormatV1 {
-		// For v1 little trick is needed. Refs are actual offset inside index, not index-header. This is different
-		// of the header length difference between two files.
-		o += headerLen - index.HeaderLen
-	}
+	o += r.symbolsOffsetShift
 
 	s, err := r.symbols.Lookup(o)
 	if err != nil {
@@ -217,7 +332,11 @@ func (r *StreamBinaryReader) LookupSymbol(o uint32) (string, error) {
 }
 
 func (r *StreamBinaryReader) LabelValues(name string, filter func(string) bool) ([]string, error) {
-	return r.postingsOffsetTable.LabelValues(name, filter)
+	return r.postingsOffsetTable.LabelValues(name, "", filter)
+}
+
+func (r *StreamBinaryReader) LabelValuesWithPrefix(name, prefix string, filter func(string) bool) ([]string, error) {
+	return r.postingsOffsetTable.LabelValues(name, prefix, filter)
 }
 
 func (r *StreamBinaryReader) LabelNames() ([]string, error) {
@@ -226,5 +345,5 @@ func (r *StreamBinaryReader) LabelNames() ([]string, error) {
 
 func (r *StreamBinaryReader) Close() error {
 	r.factory.Stop()
-	return nil
+	return r.symbols.Close()
 }
@@ -6,6 +6,7 @@
 package storegateway
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -58,11 +59,20 @@ func (l *Limiter) Reserve(num uint64) error {
 		// We need to protect from the counter being incremented twice due to concurrency
 		// while calling Reserve().
 		l.failedOnce.Do(l.failedCounter.Inc)
-		return errors.Errorf("limit %v violated (got %v)", l.limit, reserved)
+		return limitExceededError{limit: l.limit, reserved: reserved}
 	}
 	return nil
 }
 
+// limitExceededError is the error returned by the Limiter when the limit has been exceeded.
+type limitExceededError struct {
+	limit, reserved uint64
+}
+
+func (e limitExceededError) Error() string {
+	return fmt.Sprintf("limit %v violated (got %v)", e.limit, e.reserved)
+}
+
 // NewChunksLimiterFactory makes a new ChunksLimiterFactory with a static limit.
 func NewChunksLimiterFactory(limit uint64) ChunksLimiterFactory {
 	return func(failedCounter prometheus.Counter) ChunksLimiter {
@@ -76,3 +86,26 @@ func NewSeriesLimiterFactory(limit uint64) SeriesLimiterFactory {
 		return NewLimiter(limit, failedCounter)
 	}
 }
+
+// MemoryLimiter rejects new requests once the memory held by the in-flight requests exceeds a limit.
+type MemoryLimiter struct {
+	limit uint64
+	used  func() uint64
+}
+
+// NewMemoryLimiter returns a new limiter with the specified limit, checked against the bytes in use
+// returned by the used function. 0 disables the limit.
+func NewMemoryLimiter(limit uint64, used func() uint64) *MemoryLimiter {
+	return &MemoryLimiter{limit: limit, used: used}
+}
+
+// Admit returns an error if the memory in use has reached the limit. It's safe to call on a nil limiter.
+func (l *MemoryLimiter) Admit() error {
+	if l == nil || l.limit == 0 {
+		return nil
+	}
+	if used := l.used(); used >= l.limit {
+		return errors.Errorf("memory limit of %d bytes reached (in use: %d bytes)", l.limit, used)
+	}
+	return nil
+}
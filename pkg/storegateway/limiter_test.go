@@ -30,3 +30,20 @@ func TestLimiter(t *testing.T) {
 	assert.Error(t, l.Reserve(2))
 	assert.Equal(t, float64(1), prom_testutil.ToFloat64(c))
 }
+
+func TestMemoryLimiter(t *testing.T) {
+	used := uint64(0)
+	l := NewMemoryLimiter(10, func() uint64 { return used })
+
+	assert.NoError(t, l.Admit())
+
+	used = 9
+	assert.NoError(t, l.Admit())
+
+	used = 10
+	assert.EqualError(t, l.Admit(), "memory limit of 10 bytes reached (in use: 10 bytes)")
+
+	// A nil limiter and a limiter with a 0 limit admit any request.
+	assert.NoError(t, (*MemoryLimiter)(nil).Admit())
+	assert.NoError(t, NewMemoryLimiter(0, func() uint64 { return used }).Admit())
+}
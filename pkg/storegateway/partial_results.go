@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+)
+
+// blockFailures keeps track of the blocks whose series couldn't be fetched by a Series() call returning
+// partial results. A nil *blockFailures doesn't tolerate any failure.
+type blockFailures struct {
+	logger log.Logger
+	failed prometheus.Counter
+
+	mtx  sync.Mutex
+	errs map[ulid.ULID]error
+}
+
+func newBlockFailures(logger log.Logger, failed prometheus.Counter) *blockFailures {
+	return &blockFailures{
+		logger: logger,
+		failed: failed,
+		errs:   map[ulid.ULID]error{},
+	}
+}
+
+// tolerate records the failure of the block and returns true if the error is tolerated, so that the series
+// of the other blocks are returned. Errors not caused by the block, like the request being canceled or exceeding
+// a limit, are never tolerated.
+func (f *blockFailures) tolerate(ctx context.Context, blockID ulid.ULID, err error) bool {
+	if f == nil || !isBlockFailure(ctx, err) {
+		return false
+	}
+
+	f.mtx.Lock()
+	_, found := f.errs[blockID]
+	if !found {
+		f.errs[blockID] = err
+	}
+	f.mtx.Unlock()
+
+	if !found {
+		level.Warn(f.logger).Log("msg", "failed to fetch series for block, returning partial results", "block", blockID, "err", err)
+		f.failed.Inc()
+	}
+	return true
+}
+
+// warnings returns a warning for each failed block, sorted by block ID.
+func (f *blockFailures) warnings() []string {
+	if f == nil {
+		return nil
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	ids := make([]ulid.ULID, 0, len(f.errs))
+	for id := range f.errs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Compare(ids[j]) < 0
+	})
+
+	warnings := make([]string, 0, len(ids))
+	for _, id := range ids {
+		warnings = append(warnings, fmt.Sprintf("partial results: failed to fetch series for block %s: %s", id, f.errs[id]))
+	}
+	return warnings
+}
+
+// isBlockFailure returns whether the error has been caused by the block the series were fetched from.
+func isBlockFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// The limits errors, and any other error with an explicit gRPC status, are returned as they are.
+	var limitErr limitExceededError
+	if errors.As(err, &limitErr) {
+		return false
+	}
+	if _, ok := status.FromError(errors.Cause(err)); ok {
+		return false
+	}
+	return true
+}
+
+// partialResultsSeriesChunkRefsSetIterator is a seriesChunkRefsSetIterator of a single block which ends the
+// iteration, instead of failing, if the iterator of the block fails and the failure is tolerated.
+type partialResultsSeriesChunkRefsSetIterator struct {
+	ctx      context.Context
+	from     seriesChunkRefsSetIterator
+	blockID  ulid.ULID
+	failures *blockFailures
+
+	err error
+}
+
+func newPartialResultsSeriesChunkRefsSetIterator(ctx context.Context, from seriesChunkRefsSetIterator, blockID ulid.ULID, failures *blockFailures) *partialResultsSeriesChunkRefsSetIterator {
+	return &partialResultsSeriesChunkRefsSetIterator{
+		ctx:      ctx,
+		from:     from,
+		blockID:  blockID,
+		failures: failures,
+	}
+}
+
+func (p *partialResultsSeriesChunkRefsSetIterator) Next() bool {
+	if p.from.Next() {
+		return true
+	}
+
+	if err := p.from.Err(); err != nil && !p.failures.tolerate(p.ctx, p.blockID, err) {
+		p.err = err
+	}
+	return false
+}
+
+func (p *partialResultsSeriesChunkRefsSetIterator) At() seriesChunkRefsSet {
+	return p.from.At()
+}
+
+func (p *partialResultsSeriesChunkRefsSetIterator) Err() error {
+	return p.err
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+func TestIsBlockFailure(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, tc := range map[string]struct {
+		ctx      context.Context
+		err      error
+		expected bool
+	}{
+		"generic error":     {ctx: context.Background(), err: errors.New("corrupted block"), expected: true},
+		"wrapped error":     {ctx: context.Background(), err: errors.Wrap(errors.New("corrupted block"), "read postings"), expected: true},
+		"canceled context":  {ctx: canceledCtx, err: errors.New("corrupted block"), expected: false},
+		"context error":     {ctx: context.Background(), err: errors.Wrap(context.DeadlineExceeded, "read postings"), expected: false},
+		"limit error":       {ctx: context.Background(), err: errors.Wrap(limitExceededError{limit: 1, reserved: 2}, "exceeded series limit"), expected: false},
+		"gRPC status error": {ctx: context.Background(), err: errors.Wrap(httpgrpc.Errorf(http.StatusUnprocessableEntity, "limit"), "exceeded chunks limit"), expected: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isBlockFailure(tc.ctx, tc.err))
+		})
+	}
+}
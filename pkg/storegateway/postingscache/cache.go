@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package postingscache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+)
+
+const (
+	maxInt = int(^uint(0) >> 1)
+
+	// seriesRefSize is the size of each cached posting.
+	seriesRefSize = 8
+)
+
+// cardinalityBuckets are the upper bounds of the number of postings used to group the cache requests.
+var cardinalityBuckets = []struct {
+	upperBound int
+	label      string
+}{
+	{100, "100"},
+	{1000, "1k"},
+	{10000, "10k"},
+	{100000, "100k"},
+	{1000000, "1M"},
+	{maxInt, "+Inf"},
+}
+
+func cardinalityBucket(postings int) string {
+	for _, b := range cardinalityBuckets {
+		if postings <= b.upperBound {
+			return b.label
+		}
+	}
+	return cardinalityBuckets[len(cardinalityBuckets)-1].label
+}
+
+type blockKey struct {
+	userID  string
+	blockID ulid.ULID
+}
+
+type cacheKey struct {
+	blockKey
+	matchers indexcache.LabelMatchersKey
+}
+
+type cacheEntry struct {
+	refs    []storage.SeriesRef
+	expires time.Time
+}
+
+// Cache is an in-memory LRU cache of the expanded postings of the blocks, keyed by block and
+// label matchers. Its entries expire after a TTL, and are removed once their block is invalidated.
+type Cache struct {
+	mtx sync.Mutex
+
+	logger           log.Logger
+	lru              *lru.LRU
+	maxSizeBytes     uint64
+	maxItemSizeBytes uint64
+	ttl              time.Duration
+	now              func() time.Time
+
+	curSize uint64
+	// blocks tracks the keys of the cached entries of each block, to remove them when the block is invalidated.
+	blocks map[blockKey]map[indexcache.LabelMatchersKey]struct{}
+
+	requests    *prometheus.CounterVec
+	hits        *prometheus.CounterVec
+	overflow    prometheus.Counter
+	invalidated prometheus.Counter
+	items       prometheus.Gauge
+	sizeBytes   prometheus.Gauge
+}
+
+// NewCache makes a new Cache holding up to maxSizeBytes of postings, whose entries expire after ttl.
+func NewCache(maxSizeBytes, maxItemSizeBytes uint64, ttl time.Duration, logger log.Logger, reg prometheus.Registerer) (*Cache, error) {
+	if maxItemSizeBytes > maxSizeBytes {
+		return nil, errors.Errorf("max item size (%d) cannot be bigger than overall cache size (%d)", maxItemSizeBytes, maxSizeBytes)
+	}
+
+	c := &Cache{
+		logger:           logger,
+		maxSizeBytes:     maxSizeBytes,
+		maxItemSizeBytes: maxItemSizeBytes,
+		ttl:              ttl,
+		now:              time.Now,
+		blocks:           map[blockKey]map[indexcache.LabelMatchersKey]struct{}{},
+
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_postings_cache_requests_total",
+			Help: "Total number of expanded postings requested to the postings cache, by number of postings.",
+		}, []string{"cardinality"}),
+		hits: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_postings_cache_hits_total",
+			Help: "Total number of expanded postings requested to the postings cache that were a hit, by number of postings.",
+		}, []string{"cardinality"}),
+		overflow: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_postings_cache_items_overflowed_total",
+			Help: "Total number of expanded postings that could not be added to the postings cache due to being too big.",
+		}),
+		invalidated: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_postings_cache_blocks_invalidated_total",
+			Help: "Total number of blocks whose entries have been removed from the postings cache because the block has been marked for deletion or dropped.",
+		}),
+		items: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_postings_cache_items",
+			Help: "Current number of items in the postings cache.",
+		}),
+		sizeBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_postings_cache_size_bytes",
+			Help: "Current byte size of the postings in the postings cache.",
+		}),
+	}
+	for _, b := range cardinalityBuckets {
+		c.requests.WithLabelValues(b.label)
+		c.hits.WithLabelValues(b.label)
+	}
+
+	// The evictions are managed by the cache based on the stored size.
+	l, err := lru.NewLRU(maxInt, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+
+	level.Info(logger).Log("msg", "created postings cache", "maxSizeBytes", maxSizeBytes, "maxItemSizeBytes", maxItemSizeBytes, "ttl", ttl)
+	return c, nil
+}
+
+func (c *Cache) onEvict(key, val interface{}) {
+	k := key.(cacheKey)
+	size := uint64(len(val.(cacheEntry).refs)) * seriesRefSize
+
+	if keys, ok := c.blocks[k.blockKey]; ok {
+		delete(keys, k.matchers)
+		if len(keys) == 0 {
+			delete(c.blocks, k.blockKey)
+		}
+	}
+
+	c.curSize -= size
+	c.items.Dec()
+	c.sizeBytes.Sub(float64(size))
+}
+
+// Fetch returns the cached expanded postings of the block for the label matchers. The returned slice
+// must not be modified.
+func (c *Cache) Fetch(userID string, blockID ulid.ULID, matchers indexcache.LabelMatchersKey) ([]storage.SeriesRef, bool) {
+	key := cacheKey{blockKey: blockKey{userID: userID, blockID: blockID}, matchers: matchers}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	v, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry := v.(cacheEntry)
+	if c.now().After(entry.expires) {
+		c.lru.Remove(key)
+		return nil, false
+	}
+
+	bucket := cardinalityBucket(len(entry.refs))
+	c.requests.WithLabelValues(bucket).Inc()
+	c.hits.WithLabelValues(bucket).Inc()
+	return entry.refs, true
+}
+
+// Store caches the expanded postings of the block for the label matchers, after a Fetch miss.
+// The input slice must not be modified after this call.
+func (c *Cache) Store(userID string, blockID ulid.ULID, matchers indexcache.LabelMatchersKey, refs []storage.SeriesRef) {
+	key := cacheKey{blockKey: blockKey{userID: userID, blockID: blockID}, matchers: matchers}
+	size := uint64(len(refs)) * seriesRefSize
+
+	// The misses are tracked when storing, once the number of postings is known.
+	c.requests.WithLabelValues(cardinalityBucket(len(refs))).Inc()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.lru.Peek(key); ok {
+		// Replace the entry, which may have expired.
+		c.lru.Remove(key)
+	}
+	if size > c.maxItemSizeBytes {
+		c.overflow.Inc()
+		return
+	}
+	for c.curSize+size > c.maxSizeBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			level.Error(c.logger).Log("msg", "LRU has nothing more to evict, but we still cannot allocate the item", "maxSizeBytes", c.maxSizeBytes, "curSize", c.curSize, "itemSize", size)
+			return
+		}
+	}
+
+	c.lru.Add(key, cacheEntry{refs: refs, expires: c.now().Add(c.ttl)})
+	keys, ok := c.blocks[key.blockKey]
+	if !ok {
+		keys = map[indexcache.LabelMatchersKey]struct{}{}
+		c.blocks[key.blockKey] = keys
+	}
+	keys[matchers] = struct{}{}
+
+	c.curSize += size
+	c.items.Inc()
+	c.sizeBytes.Add(float64(size))
+}
+
+// InvalidateBlock removes all the cached expanded postings of the block.
+func (c *Cache) InvalidateBlock(userID string, blockID ulid.ULID) {
+	bk := blockKey{userID: userID, blockID: blockID}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	keys, ok := c.blocks[bk]
+	if !ok {
+		return
+	}
+	for matchers := range keys {
+		c.lru.Remove(cacheKey{blockKey: bk, matchers: matchers})
+	}
+	c.invalidated.Inc()
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package postingscache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	block1, block2 := ulid.MustNew(1, nil), ulid.MustNew(2, nil)
+	refs := func(n int) []storage.SeriesRef {
+		out := make([]storage.SeriesRef, n)
+		for i := range out {
+			out[i] = storage.SeriesRef(i)
+		}
+		return out
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	c, err := NewCache(100*seriesRefSize, 60*seriesRefSize, time.Hour, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+
+	_, ok := c.Fetch("user-1", block1, `a="1"`)
+	require.False(t, ok)
+	c.Store("user-1", block1, `a="1"`, refs(10))
+
+	actual, ok := c.Fetch("user-1", block1, `a="1"`)
+	require.True(t, ok)
+	assert.Equal(t, refs(10), actual)
+
+	// The entries are keyed by tenant, block and matchers.
+	_, ok = c.Fetch("user-2", block1, `a="1"`)
+	assert.False(t, ok)
+	_, ok = c.Fetch("user-1", block2, `a="1"`)
+	assert.False(t, ok)
+	_, ok = c.Fetch("user-1", block1, `a="2"`)
+	assert.False(t, ok)
+
+	// Items bigger than the max item size aren't cached.
+	c.Store("user-1", block1, `a="2"`, refs(70))
+	_, ok = c.Fetch("user-1", block1, `a="2"`)
+	assert.False(t, ok)
+
+	// The least recently used items are evicted when the cache is full.
+	c.Store("user-1", block2, `a="1"`, refs(50))
+	c.Store("user-1", block2, `a="2"`, refs(50))
+	_, ok = c.Fetch("user-1", block1, `a="1"`)
+	assert.False(t, ok)
+	_, ok = c.Fetch("user-1", block2, `a="1"`)
+	assert.True(t, ok)
+
+	// The invalidation of a block removes all its items.
+	c.InvalidateBlock("user-1", block2)
+	_, ok = c.Fetch("user-1", block2, `a="1"`)
+	assert.False(t, ok)
+	_, ok = c.Fetch("user-1", block2, `a="2"`)
+	assert.False(t, ok)
+
+	// The items expire after the TTL.
+	c.Store("user-1", block1, `a="1"`, refs(200_000))
+	c.Store("user-1", block1, `a="3"`, refs(1))
+	now = now.Add(time.Hour + time.Second)
+	_, ok = c.Fetch("user-1", block1, `a="3"`)
+	assert.False(t, ok)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_postings_cache_requests_total Total number of expanded postings requested to the postings cache, by number of postings.
+		# TYPE cortex_bucket_store_postings_cache_requests_total counter
+		cortex_bucket_store_postings_cache_requests_total{cardinality="100"} 7
+		cortex_bucket_store_postings_cache_requests_total{cardinality="1k"} 0
+		cortex_bucket_store_postings_cache_requests_total{cardinality="10k"} 0
+		cortex_bucket_store_postings_cache_requests_total{cardinality="100k"} 0
+		cortex_bucket_store_postings_cache_requests_total{cardinality="1M"} 1
+		cortex_bucket_store_postings_cache_requests_total{cardinality="+Inf"} 0
+
+		# HELP cortex_bucket_store_postings_cache_hits_total Total number of expanded postings requested to the postings cache that were a hit, by number of postings.
+		# TYPE cortex_bucket_store_postings_cache_hits_total counter
+		cortex_bucket_store_postings_cache_hits_total{cardinality="100"} 2
+		cortex_bucket_store_postings_cache_hits_total{cardinality="1k"} 0
+		cortex_bucket_store_postings_cache_hits_total{cardinality="10k"} 0
+		cortex_bucket_store_postings_cache_hits_total{cardinality="100k"} 0
+		cortex_bucket_store_postings_cache_hits_total{cardinality="1M"} 0
+		cortex_bucket_store_postings_cache_hits_total{cardinality="+Inf"} 0
+
+		# HELP cortex_bucket_store_postings_cache_items Current number of items in the postings cache.
+		# TYPE cortex_bucket_store_postings_cache_items gauge
+		cortex_bucket_store_postings_cache_items 0
+
+		# HELP cortex_bucket_store_postings_cache_blocks_invalidated_total Total number of blocks whose entries have been removed from the postings cache because the block has been marked for deletion or dropped.
+		# TYPE cortex_bucket_store_postings_cache_blocks_invalidated_total counter
+		cortex_bucket_store_postings_cache_blocks_invalidated_total 1
+	`),
+		"cortex_bucket_store_postings_cache_requests_total",
+		"cortex_bucket_store_postings_cache_hits_total",
+		"cortex_bucket_store_postings_cache_items",
+		"cortex_bucket_store_postings_cache_blocks_invalidated_total",
+	))
+}
+
+func TestNewCache_ShouldFailIfMaxItemSizeIsBiggerThanMaxSize(t *testing.T) {
+	_, err := NewCache(10, 20, time.Hour, log.NewNopLogger(), nil)
+	assert.Error(t, err)
+}
@@ -3,25 +3,36 @@
 package storegateway
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"sync"
 	"time"
+	"unsafe"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
+	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
 )
 
-// Mimir compacts blocks up to 24h. Assuming a 5s scrape interval as worst case scenario,
-// and 120 samples per chunk, there could be 86400 * (1 / 5) * (1 / 120) = 144 chunks for
-// a series in the biggest block. Using a slab size of 1000 looks a good trade-off to support
-// high frequency scraping without wasting too much memory in case of queries hitting a low
-// number of chunks (across series).
-const seriesChunksSlabSize = 1000
+const (
+	// seriesChunksSlabSize is the slab size used by seriesChunksSet when no slab size has been set.
+	seriesChunksSlabSize = mimir_tsdb.DefaultStreamingChunksSlabSize
+
+	// minAdaptiveSeriesChunksSlabSize is the min slab size picked by the adaptive chunks slab size,
+	// to avoid getting a new slab from the pool for every few chunks.
+	minAdaptiveSeriesChunksSlabSize = 16
+)
 
 var (
 	seriesEntrySlicePool = pool.Interface(&sync.Pool{
@@ -55,9 +66,15 @@ type seriesChunksSet struct {
 
 	// It gets lazy initialized (only if required).
 	seriesChunksPool *pool.SlabPool[storepb.AggrChunk]
+	// seriesChunksSlabSize is the slab size of seriesChunksPool. If zero, seriesChunksSlabSize is used.
+	seriesChunksSlabSize int
 
 	// chunksReleaser releases the memory used to allocate series chunks.
 	chunksReleaser chunksReleaser
+
+	// loading, if not nil, tracks the chunks which are still being loaded asynchronously.
+	// The chunks of a series can't be accessed until loading.waitSeries() returns for it.
+	loading *seriesChunksLoadTracker
 }
 
 // newSeriesChunksSet creates a new seriesChunksSet. The series slice is pre-allocated with
@@ -102,6 +119,11 @@ type chunksReleaser interface {
 //
 // This function is not idempotent. Calling it twice would introduce subtle bugs.
 func (b *seriesChunksSet) release() {
+	// The chunks can't be released while they're still being loaded.
+	if b.loading != nil {
+		b.loading.wait()
+	}
+
 	if b.chunksReleaser != nil {
 		b.chunksReleaser.Release()
 	}
@@ -135,7 +157,11 @@ func (b *seriesChunksSet) newSeriesAggrChunkSlice(size int) []storepb.AggrChunk
 
 	// Lazy initialise the pool.
 	if b.seriesChunksPool == nil {
-		b.seriesChunksPool = pool.NewSlabPool[storepb.AggrChunk](seriesChunksSlicePool, seriesChunksSlabSize)
+		slabSize := b.seriesChunksSlabSize
+		if slabSize <= 0 {
+			slabSize = seriesChunksSlabSize
+		}
+		b.seriesChunksPool = pool.NewSlabPool[storepb.AggrChunk](seriesChunksSlicePool, slabSize)
 	}
 
 	return b.seriesChunksPool.Get(size)
@@ -145,11 +171,47 @@ func (b *seriesChunksSet) len() int {
 	return len(b.series)
 }
 
+// poolsBorrowedSize returns the size, in bytes, of the series slice and of the slabs of the series chunks
+// slices which will be put back to their pools once the set is released.
+func (b *seriesChunksSet) poolsBorrowedSize() (series, seriesChunks int) {
+	if !b.seriesReleasable {
+		return 0, 0
+	}
+
+	series = cap(b.series) * int(unsafe.Sizeof(seriesEntry{}))
+	if b.seriesChunksPool != nil {
+		seriesChunks = b.seriesChunksPool.Borrowed() * int(unsafe.Sizeof(storepb.AggrChunk{}))
+	}
+	return series, seriesChunks
+}
+
+// chunksSize returns the size, in bytes, of the chunks data held by the set.
+func (b *seriesChunksSet) chunksSize() int {
+	size := 0
+	for _, s := range b.series {
+		for _, c := range s.chks {
+			for _, chk := range []*storepb.Chunk{c.Raw, c.Count, c.Sum, c.Min, c.Max, c.Counter} {
+				if chk != nil {
+					size += len(chk.Data)
+				}
+			}
+		}
+	}
+	return size
+}
+
 type seriesChunksSeriesSet struct {
 	from seriesChunksSetIterator
 
+	// deduplicateChunks, if true, removes the identical chunks of each series before returning it.
+	// deduplicatedChunks is the number of chunks removed so far, recorded in stats once the iteration is done.
+	deduplicateChunks  bool
+	deduplicatedChunks int
+	stats              *safeQueryStats
+
 	currSet    seriesChunksSet
 	currOffset int
+	err        error
 }
 
 func newSeriesChunksSeriesSet(from seriesChunksSetIterator) storepb.SeriesSet {
@@ -158,22 +220,48 @@ func newSeriesChunksSeriesSet(from seriesChunksSetIterator) storepb.SeriesSet {
 	}
 }
 
-func newSeriesSetWithChunks(ctx context.Context, chunkReaders bucketChunkReaders, chunksPool pool.Bytes, refsIterator seriesChunkRefsSetIterator, refsIteratorBatchSize int, stats *safeQueryStats, iteratorLoadDurations *prometheus.HistogramVec) storepb.SeriesSet {
+// newSeriesSetWithChunks returns a storepb.SeriesSet loading the chunks of the series from refsIterator. If
+// adaptivePreloadingMaxBytes is larger than zero, the number of sets preloaded ahead adapts to the time spent
+// waiting for them, bounded by adaptivePreloadingMaxBytes of preloaded chunks; otherwise one set is preloaded.
+// If eagerSending is true, each series is returned as soon as its chunks have been loaded, instead of waiting
+// for the chunks of the whole set to be loaded. eagerSending can't be used together with adaptive preloading,
+// because the latter needs the size of the loaded chunks of the whole set. See newLoadingSeriesChunksSetIterator()
+// for chunksSlabSize, adaptiveChunksSlabSize and batchObserver. If deduplicateChunks is true, the identical chunks of
+// each series, typically coming from overlapping blocks, are returned only once.
+func newSeriesSetWithChunks(ctx context.Context, chunkReaders bucketChunkReaders, chunksPool pool.Bytes, refsIterator seriesChunkRefsSetIterator, refsIteratorBatchSize int, adaptivePreloadingMaxBytes int, eagerSending bool, chunksSlabSize int, adaptiveChunksSlabSize bool, deduplicateChunks bool, batchObserver *seriesChunksBatchObserver, stats *safeQueryStats, iteratorLoadDurations *prometheus.HistogramVec) storepb.SeriesSet {
 	var iterator seriesChunksSetIterator
-	iterator = newLoadingSeriesChunksSetIterator(chunkReaders, chunksPool, refsIterator, refsIteratorBatchSize, stats)
-	iterator = newDurationMeasuringIterator[seriesChunksSet](iterator, iteratorLoadDurations.WithLabelValues("chunks_load"))
-	iterator = newPreloadingSetIterator[seriesChunksSet](ctx, 1, iterator)
+	iterator = newLoadingSeriesChunksSetIterator(ctx, chunkReaders, chunksPool, refsIterator, refsIteratorBatchSize, eagerSending, chunksSlabSize, adaptiveChunksSlabSize, batchObserver, stats)
+	iterator = newDurationMeasuringIterator[seriesChunksSet](iterator, iteratorLoadDurations.WithLabelValues("chunks_load"), stats, func(stats *queryStats, d time.Duration) {
+		stats.streamingChunksLoadDuration += d
+	})
+	if adaptivePreloadingMaxBytes > 0 && !eagerSending {
+		iterator = newAdaptivePreloadingSetIterator[seriesChunksSet](ctx, adaptivePreloadingMaxSets, adaptivePreloadingMaxBytes, func(set seriesChunksSet) int { return set.chunksSize() }, iterator)
+	} else {
+		iterator = newPreloadingSetIterator[seriesChunksSet](ctx, 1, iterator)
+	}
 	// We are measuring the time we wait for a preloaded batch. In an ideal world this is 0 because there's always a preloaded batch waiting.
 	// But realistically it will not be. Along with the duration of the chunks_load iterator,
 	// we can determine where is the bottleneck in the streaming pipeline.
-	iterator = newDurationMeasuringIterator[seriesChunksSet](iterator, iteratorLoadDurations.WithLabelValues("chunks_preloaded"))
-	return newSeriesChunksSeriesSet(iterator)
+	iterator = newDurationMeasuringIterator[seriesChunksSet](iterator, iteratorLoadDurations.WithLabelValues("chunks_preloaded"), stats, func(stats *queryStats, d time.Duration) {
+		stats.streamingChunksPreloadedDuration += d
+	})
+	return &seriesChunksSeriesSet{
+		from:              iterator,
+		deduplicateChunks: deduplicateChunks,
+		stats:             stats,
+	}
 }
 
 // Next advances to the next item. Once the underlying seriesChunksSet has been fully consumed
 // (which means the call to Next moves to the next set), the seriesChunksSet is released. This
 // means that it's not safe to read from the values returned by At() after Next() is called again.
+// If the chunks of the set are loaded asynchronously, Next waits until the chunks of the next
+// series have been loaded.
 func (b *seriesChunksSeriesSet) Next() bool {
+	if b.err != nil {
+		return false
+	}
+
 	b.currOffset++
 	if b.currOffset >= b.currSet.len() {
 		// The current set won't be accessed anymore because the iterator is moving to the next one,
@@ -182,15 +270,82 @@ func (b *seriesChunksSeriesSet) Next() bool {
 
 		if !b.from.Next() {
 			b.currSet = seriesChunksSet{}
+			b.recordDeduplicatedChunks()
 			return false
 		}
 
 		b.currSet = b.from.At()
 		b.currOffset = 0
 	}
+
+	if b.currSet.loading != nil && b.currOffset < b.currSet.len() {
+		if err := b.currSet.loading.waitSeries(b.currOffset); err != nil {
+			b.err = errors.Wrap(err, "loading chunks")
+			b.currSet.release()
+			b.currSet = seriesChunksSet{}
+			return false
+		}
+	}
+
+	if b.deduplicateChunks && b.currOffset < b.currSet.len() {
+		var removed int
+		entry := &b.currSet.series[b.currOffset]
+		entry.chks, removed = deduplicateChunks(entry.chks)
+		b.deduplicatedChunks += removed
+	}
 	return true
 }
 
+func (b *seriesChunksSeriesSet) recordDeduplicatedChunks() {
+	if b.deduplicatedChunks == 0 || b.stats == nil {
+		return
+	}
+
+	deduplicated := b.deduplicatedChunks
+	b.deduplicatedChunks = 0
+	b.stats.update(func(stats *queryStats) {
+		stats.chunksDeduplicated += deduplicated
+	})
+}
+
+// deduplicateChunks removes from chks, in place, the chunks with the same min time, max time, encoding and
+// data of a previous chunk, and returns the resulting slice together with the number of removed chunks.
+// chks must be sorted by min time and then by max time, like the chunks of a merged series are.
+// The removed chunks are reset, because they're not reachable through the returned slice anymore.
+func deduplicateChunks(chks []storepb.AggrChunk) ([]storepb.AggrChunk, int) {
+	if len(chks) < 2 {
+		return chks, 0
+	}
+
+	kept := 1
+	for i := 1; i < len(chks); i++ {
+		if !containsIdenticalChunk(chks[:kept], chks[i]) {
+			chks[kept] = chks[i]
+			kept++
+		}
+	}
+
+	for i := kept; i < len(chks); i++ {
+		chks[i].Reset()
+	}
+	return chks[:kept], len(chks) - kept
+}
+
+// containsIdenticalChunk returns whether sorted contains a chunk identical to c. Since sorted is sorted by min time
+// and then by max time, only its trailing chunks with the same time range of c are checked.
+func containsIdenticalChunk(sorted []storepb.AggrChunk, c storepb.AggrChunk) bool {
+	for i := len(sorted) - 1; i >= 0; i-- {
+		other := sorted[i]
+		if other.MinTime != c.MinTime || other.MaxTime != c.MaxTime {
+			return false
+		}
+		if other.Raw != nil && c.Raw != nil && other.Raw.Type == c.Raw.Type && bytes.Equal(other.Raw.Data, c.Raw.Data) {
+			return true
+		}
+	}
+	return false
+}
+
 // At returns the current series. The result from At() MUST not be retained after calling Next()
 func (b *seriesChunksSeriesSet) At() (labels.Labels, []storepb.AggrChunk) {
 	if b.currOffset >= b.currSet.len() {
@@ -201,6 +356,9 @@ func (b *seriesChunksSeriesSet) At() (labels.Labels, []storepb.AggrChunk) {
 }
 
 func (b *seriesChunksSeriesSet) Err() error {
+	if b.err != nil {
+		return b.err
+	}
 	return b.from.Err()
 }
 
@@ -275,32 +433,271 @@ func (p *preloadingSetIterator[Set]) Err() error {
 	return p.err
 }
 
+const (
+	// adaptivePreloadingMaxSets is the max number of sets preloaded ahead by the adaptive preloading.
+	adaptivePreloadingMaxSets = 16
+
+	// adaptivePreloadingWaitRatio is the ratio between the time spent loading a set and the time spent waiting
+	// for it above which the adaptive preloading increases the number of sets preloaded ahead.
+	adaptivePreloadingWaitRatio = 10
+
+	// adaptivePreloadingMinWait is the min time spent waiting for a set above which the adaptive preloading
+	// increases the number of sets preloaded ahead. Shorter waits are not worth the memory of preloading more.
+	adaptivePreloadingMinWait = time.Millisecond
+)
+
+// adaptivePreloadingSetIterator is like preloadingSetIterator, but the number of sets preloaded ahead (the depth)
+// adapts to the consumer: the depth grows when the consumer waits for a set for more than a fraction of the time
+// it took to load it (and more than adaptivePreloadingMinWait), and shrinks when the consumer keeps finding more sets preloaded than the one it needs.
+// The depth is bounded by maxDepth and by maxBytes, the max size of the preloaded sets, although one set is
+// always preloaded regardless of its size in order to guarantee progress.
+type adaptivePreloadingSetIterator[Set any] struct {
+	ctx      context.Context
+	from     genericIterator[Set]
+	size     func(Set) int
+	maxDepth int
+	maxBytes int
+
+	current Set
+	err     error
+
+	preloaded chan adaptivePreloadedSet[Set]
+	// consumed gets notified each time a set is consumed, or the depth changes.
+	consumed chan struct{}
+
+	// The following fields are protected by mtx.
+	mtx sync.Mutex
+	// depth is the current max number of preloaded sets.
+	depth int
+	// preloadedSets and preloadedBytes are the number and size of the sets preloaded but not consumed yet.
+	preloadedSets  int
+	preloadedBytes int
+	// surplus is the number of consecutive Next() calls which found more than one set preloaded.
+	surplus int
+}
+
+// adaptivePreloadedSet holds the result of preloading the next set with the adaptive preloading. It can either
+// contain the preloaded set or an error, but not both.
+type adaptivePreloadedSet[Set any] struct {
+	set          Set
+	size         int
+	loadDuration time.Duration
+	err          error
+}
+
+func newAdaptivePreloadingSetIterator[Set any](ctx context.Context, maxDepth, maxBytes int, size func(Set) int, from genericIterator[Set]) *adaptivePreloadingSetIterator[Set] {
+	p := &adaptivePreloadingSetIterator[Set]{
+		ctx:      ctx,
+		from:     from,
+		size:     size,
+		maxDepth: maxDepth,
+		maxBytes: maxBytes,
+		depth:    1,
+		// The preloading is bounded by the depth, so sending to the channel never blocks.
+		preloaded: make(chan adaptivePreloadedSet[Set], maxDepth),
+		consumed:  make(chan struct{}, 1),
+	}
+	go p.preload()
+	return p
+}
+
+func (p *adaptivePreloadingSetIterator[Set]) preload() {
+	defer close(p.preloaded)
+
+	for {
+		if !p.waitPreloadCapacity() {
+			// If the context is done, we should just stop the preloading goroutine.
+			return
+		}
+
+		start := time.Now()
+		if !p.from.Next() {
+			break
+		}
+		loadDuration := time.Since(start)
+
+		set := p.from.At()
+		size := p.size(set)
+
+		p.mtx.Lock()
+		p.preloadedSets++
+		p.preloadedBytes += size
+		p.mtx.Unlock()
+
+		p.preloaded <- adaptivePreloadedSet[Set]{set: set, size: size, loadDuration: loadDuration}
+	}
+
+	if p.from.Err() != nil {
+		p.preloaded <- adaptivePreloadedSet[Set]{err: p.from.Err()}
+	}
+}
+
+// waitPreloadCapacity waits until another set can be preloaded. Returns false if the context is done.
+func (p *adaptivePreloadingSetIterator[Set]) waitPreloadCapacity() bool {
+	for {
+		p.mtx.Lock()
+		canPreload := p.preloadedSets == 0 || (p.preloadedSets < p.depth && p.preloadedBytes < p.maxBytes)
+		p.mtx.Unlock()
+
+		if canPreload {
+			return true
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return false
+		case <-p.consumed:
+		}
+	}
+}
+
+func (p *adaptivePreloadingSetIterator[Set]) Next() bool {
+	start := time.Now()
+	preloaded, ok := <-p.preloaded
+	if !ok {
+		// Iteration reached the end or context has been canceled.
+		return false
+	}
+
+	if preloaded.err == nil {
+		p.mtx.Lock()
+		p.preloadedSets--
+		p.preloadedBytes -= preloaded.size
+		p.adjustDepth(time.Since(start), preloaded.loadDuration)
+		p.mtx.Unlock()
+
+		// Notify the preloading goroutine, without blocking if it has already been notified.
+		select {
+		case p.consumed <- struct{}{}:
+		default:
+		}
+	}
+
+	p.current = preloaded.set
+	p.err = preloaded.err
+
+	return p.err == nil
+}
+
+// adjustDepth adjusts the preloading depth based on the time waited for the last consumed set and the time it
+// took to load it. Must be called with mtx held.
+func (p *adaptivePreloadingSetIterator[Set]) adjustDepth(waitDuration, loadDuration time.Duration) {
+	switch {
+	case waitDuration > adaptivePreloadingMinWait && waitDuration > loadDuration/adaptivePreloadingWaitRatio:
+		// The consumer is faster than the preloading, so we should preload more sets ahead.
+		if p.depth < p.maxDepth {
+			p.depth++
+		}
+		p.surplus = 0
+	case p.preloadedSets > 0:
+		// The preloading is ahead of the consumer by more than one set. If it keeps being so, then
+		// we're preloading more sets than needed.
+		p.surplus++
+		if p.surplus >= p.depth && p.depth > 1 {
+			p.depth--
+			p.surplus = 0
+		}
+	default:
+		p.surplus = 0
+	}
+}
+
+func (p *adaptivePreloadingSetIterator[Set]) At() Set {
+	return p.current
+}
+
+func (p *adaptivePreloadingSetIterator[Set]) Err() error {
+	return p.err
+}
+
 type loadingSeriesChunksSetIterator struct {
+	ctx           context.Context
 	chunkReaders  bucketChunkReaders
 	from          seriesChunkRefsSetIterator
 	fromBatchSize int
 	chunksPool    pool.Bytes
 	stats         *safeQueryStats
 
+	// asyncLoading, if true, makes Next() return each set while its chunks are still being loaded.
+	asyncLoading bool
+
+	// chunksSlabSize is the slab size used to allocate the chunks of each set, or the max one
+	// if adaptiveChunksSlabSize is true.
+	chunksSlabSize         int
+	adaptiveChunksSlabSize bool
+	// batchObserver, if not nil, observes each loaded set.
+	batchObserver *seriesChunksBatchObserver
+	// lastSeries and lastChunks are the number of series and chunks of the last returned set.
+	lastSeries int
+	lastChunks int
+	// lastLoading tracks the asynchronous loading of the last returned set, if any.
+	lastLoading *seriesChunksLoadTracker
+
 	current seriesChunksSet
 	err     error
 }
 
-func newLoadingSeriesChunksSetIterator(chunkReaders bucketChunkReaders, chunksPool pool.Bytes, from seriesChunkRefsSetIterator, fromBatchSize int, stats *safeQueryStats) *loadingSeriesChunksSetIterator {
+// newLoadingSeriesChunksSetIterator makes a new loadingSeriesChunksSetIterator. If asyncLoading is true, the chunks of
+// each set are loaded asynchronously and the returned sets can be consumed series by series as their chunks are loaded,
+// as tracked by seriesChunksSet.loading. The chunks of the next set are loaded only once the previous set has been loaded.
+//
+// The chunks of each set are allocated from slabs of chunksSlabSize chunks. If adaptiveChunksSlabSize is true, the slab
+// size of each set is instead estimated from the average number of chunks per series of the previous set, up to
+// chunksSlabSize, so that less memory is wasted by partially filled slabs when the series have few chunks.
+//
+// If batchObserver is not nil, it observes the size and the load duration of each set once its chunks have been loaded.
+//
+// Once ctx is canceled, no more sets are loaded and the in-flight requests to the bucket are aborted.
+func newLoadingSeriesChunksSetIterator(ctx context.Context, chunkReaders bucketChunkReaders, chunksPool pool.Bytes, from seriesChunkRefsSetIterator, fromBatchSize int, asyncLoading bool, chunksSlabSize int, adaptiveChunksSlabSize bool, batchObserver *seriesChunksBatchObserver, stats *safeQueryStats) *loadingSeriesChunksSetIterator {
 	return &loadingSeriesChunksSetIterator{
-		chunkReaders:  chunkReaders,
-		from:          from,
-		fromBatchSize: fromBatchSize,
-		chunksPool:    chunksPool,
-		stats:         stats,
+		ctx:                    ctx,
+		chunkReaders:           chunkReaders,
+		from:                   from,
+		fromBatchSize:          fromBatchSize,
+		chunksPool:             chunksPool,
+		stats:                  stats,
+		asyncLoading:           asyncLoading,
+		chunksSlabSize:         chunksSlabSize,
+		adaptiveChunksSlabSize: adaptiveChunksSlabSize,
+		batchObserver:          batchObserver,
 	}
 }
 
+// nextChunksSlabSize returns the slab size to use for the chunks of the next set, containing numSeries series.
+func (c *loadingSeriesChunksSetIterator) nextChunksSlabSize(numSeries int) int {
+	if !c.adaptiveChunksSlabSize || c.lastSeries == 0 {
+		return c.chunksSlabSize
+	}
+
+	// Round up the estimated number of chunks, so that all chunks fit in a single slab if the
+	// next set has the same average number of chunks per series of the previous one.
+	estimated := (c.lastChunks*numSeries + c.lastSeries - 1) / c.lastSeries
+	return util_math.Max(minAdaptiveSeriesChunksSlabSize, util_math.Min(estimated, c.chunksSlabSize))
+}
+
 func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 	if c.err != nil {
 		return false
 	}
 
+	// The chunk readers can't be reset while they're still loading the chunks of the previous set.
+	if c.lastLoading != nil {
+		c.lastLoading.wait()
+		err := c.lastLoading.err
+		c.lastLoading = nil
+
+		if err != nil {
+			c.err = errors.Wrap(err, "loading chunks")
+			return false
+		}
+	}
+
+	// Don't load the chunks of the next set if the request has been canceled in the meanwhile.
+	if err := c.ctx.Err(); err != nil {
+		c.err = errors.Wrap(err, "loading chunks")
+		return false
+	}
+
 	if !c.from.Next() {
 		c.err = c.from.Err()
 		return false
@@ -314,6 +711,7 @@ func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 	// Pre-allocate the series slice using the expected batchSize even if nextUnloaded has less elements,
 	// so that there's a higher chance the slice will be reused once released.
 	nextSet := newSeriesChunksSet(util_math.Max(c.fromBatchSize, nextUnloaded.len()), true)
+	nextSet.seriesChunksSlabSize = c.nextChunksSlabSize(nextUnloaded.len())
 
 	// Release the set if an error occurred.
 	defer func() {
@@ -328,14 +726,24 @@ func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 
 	c.chunkReaders.reset()
 
+	loadStart := time.Now()
+	numChunks := 0
+	// The IDs of the blocks the chunks are loaded from are only tracked to log the slow sets.
+	trackBlockIDs := c.batchObserver != nil && c.batchObserver.slowThreshold > 0
+	var blockIDs []ulid.ULID
 	for i, s := range nextUnloaded.series {
 		nextSet.series[i].lset = s.lset
 		nextSet.series[i].chks = nextSet.newSeriesAggrChunkSlice(len(s.chunks))
+		numChunks += len(s.chunks)
 
 		for j, chunk := range s.chunks {
 			nextSet.series[i].chks[j].MinTime = chunk.minTime
 			nextSet.series[i].chks[j].MaxTime = chunk.maxTime
 
+			if trackBlockIDs && !slices.Contains(blockIDs, chunk.blockID) {
+				blockIDs = append(blockIDs, chunk.blockID)
+			}
+
 			err := c.chunkReaders.addLoad(chunk.blockID, chunk.ref, i, j)
 			if err != nil {
 				c.err = errors.Wrap(err, "preloading chunks")
@@ -344,20 +752,55 @@ func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 		}
 	}
 
+	c.lastSeries, c.lastChunks = nextUnloaded.len(), numChunks
+	seriesPoolBorrowed, seriesChunksPoolBorrowed := nextSet.poolsBorrowedSize()
+
 	// Create a batched memory pool that can be released all at once.
 	chunksPool := &pool.BatchBytes{Delegate: c.chunksPool}
 
-	err := c.chunkReaders.load(nextSet.series, chunksPool, c.stats)
+	if c.asyncLoading {
+		loading := newSeriesChunksLoadTracker(nextSet.series)
+		go func(set seriesChunksSet) {
+			err := c.chunkReaders.load(c.ctx, set.series, chunksPool, c.stats, loading)
+			if err == nil {
+				// The set is observed before signaling the end of its loading, after which it can be released.
+				c.batchObserver.observe(set, blockIDs, time.Since(loadStart))
+				c.recordPoolsBorrowedSize(seriesPoolBorrowed, seriesChunksPoolBorrowed, chunksPool)
+			}
+			loading.loadDone(err)
+		}(nextSet)
+
+		nextSet.chunksReleaser = chunksPool
+		nextSet.loading = loading
+		c.lastLoading = loading
+		c.current = nextSet
+		return true
+	}
+
+	err := c.chunkReaders.load(c.ctx, nextSet.series, chunksPool, c.stats, nil)
 	if err != nil {
 		c.err = errors.Wrap(err, "loading chunks")
 		return false
 	}
+	c.batchObserver.observe(nextSet, blockIDs, time.Since(loadStart))
+	c.recordPoolsBorrowedSize(seriesPoolBorrowed, seriesChunksPoolBorrowed, chunksPool)
 
 	nextSet.chunksReleaser = chunksPool
 	c.current = nextSet
 	return true
 }
 
+// recordPoolsBorrowedSize records in the query stats the memory borrowed from the pools by a loaded set.
+func (c *loadingSeriesChunksSetIterator) recordPoolsBorrowedSize(seriesPoolBorrowed, seriesChunksPoolBorrowed int, chunksPool *pool.BatchBytes) {
+	chunksPoolBorrowed := chunksPool.Borrowed()
+
+	c.stats.update(func(stats *queryStats) {
+		stats.seriesPoolBorrowedSizeSum += seriesPoolBorrowed
+		stats.seriesChunksPoolBorrowedSizeSum += seriesChunksPoolBorrowed
+		stats.chunksPoolBorrowedSizeSum += chunksPoolBorrowed
+	})
+}
+
 func (c *loadingSeriesChunksSetIterator) At() seriesChunksSet {
 	return c.current
 }
@@ -366,22 +809,133 @@ func (c *loadingSeriesChunksSetIterator) Err() error {
 	return c.err
 }
 
+// seriesChunksBatchObserver observes the size of the chunks of each loaded seriesChunksSet, and logs the
+// sets whose chunks took longer than slowThreshold to load, if larger than zero.
+type seriesChunksBatchObserver struct {
+	chunksBytes   prometheus.Observer
+	slowThreshold time.Duration
+	logger        log.Logger
+}
+
+// observe observes the set, whose chunks have been loaded from the blocks with blockIDs. It's a no-op if o is nil.
+func (o *seriesChunksBatchObserver) observe(set seriesChunksSet, blockIDs []ulid.ULID, loadDuration time.Duration) {
+	if o == nil {
+		return
+	}
+
+	size := set.chunksSize()
+	o.chunksBytes.Observe(float64(size))
+
+	if o.slowThreshold <= 0 || loadDuration < o.slowThreshold {
+		return
+	}
+
+	ids := make([]string, 0, len(blockIDs))
+	for _, id := range blockIDs {
+		ids = append(ids, id.String())
+	}
+
+	level.Warn(o.logger).Log("msg", "slow series chunks batch load", "blocks", strings.Join(ids, ","), "series", set.len(), "bytes", size, "duration", loadDuration)
+}
+
+// seriesChunksLoadTracker tracks the asynchronous loading of the chunks of a set of series,
+// so that each series can be consumed as soon as its chunks have been loaded.
+type seriesChunksLoadTracker struct {
+	// pending is the number of chunks still to load for each series.
+	pending []atomic.Int32
+	// loaded receives the index of each series once all its chunks have been loaded.
+	// It's buffered to hold all the series, so that loading never blocks on the consumer.
+	loaded chan int
+	// done is closed once the loading completed, successfully or not.
+	done chan struct{}
+	err  error
+
+	// ready tracks the series whose chunks have been loaded. It's only accessed by the consumer.
+	ready []bool
+}
+
+func newSeriesChunksLoadTracker(series []seriesEntry) *seriesChunksLoadTracker {
+	t := &seriesChunksLoadTracker{
+		pending: make([]atomic.Int32, len(series)),
+		loaded:  make(chan int, len(series)),
+		done:    make(chan struct{}),
+		ready:   make([]bool, len(series)),
+	}
+	for i, s := range series {
+		t.pending[i].Store(int32(len(s.chks)))
+		t.ready[i] = len(s.chks) == 0
+	}
+	return t
+}
+
+// chunkLoaded notifies that a chunk of the series at the input index has been loaded.
+// It's safe to call on a nil tracker, and safe for concurrent use.
+func (t *seriesChunksLoadTracker) chunkLoaded(seriesIdx int) {
+	if t == nil {
+		return
+	}
+	if t.pending[seriesIdx].Dec() == 0 {
+		t.loaded <- seriesIdx
+	}
+}
+
+// loadDone notifies that the loading completed, with the input error if it failed.
+func (t *seriesChunksLoadTracker) loadDone(err error) {
+	t.err = err
+	close(t.done)
+}
+
+// waitSeries waits until the chunks of the series at the input index have been loaded, and returns
+// the loading error if the loading failed before. It's not safe for concurrent use.
+func (t *seriesChunksLoadTracker) waitSeries(seriesIdx int) error {
+	for !t.ready[seriesIdx] {
+		select {
+		case idx := <-t.loaded:
+			t.ready[idx] = true
+		case <-t.done:
+			if t.err != nil {
+				return t.err
+			}
+			// All the series have been loaded, so their indexes are buffered in the loaded channel.
+			for !t.ready[seriesIdx] {
+				t.ready[<-t.loaded] = true
+			}
+		}
+	}
+	return nil
+}
+
+// wait waits until the loading completed.
+func (t *seriesChunksLoadTracker) wait() {
+	<-t.done
+}
+
+// durationMeasuringIterator measures the time spent in each call to Next() of the wrapped iterator. The
+// duration is both observed by durationObserver and added to the query stats through trackDuration.
 type durationMeasuringIterator[Set any] struct {
 	from             genericIterator[Set]
 	durationObserver prometheus.Observer
+	stats            *safeQueryStats
+	trackDuration    func(stats *queryStats, d time.Duration)
 }
 
-func newDurationMeasuringIterator[Set any](from genericIterator[Set], durationObserver prometheus.Observer) genericIterator[Set] {
+func newDurationMeasuringIterator[Set any](from genericIterator[Set], durationObserver prometheus.Observer, stats *safeQueryStats, trackDuration func(stats *queryStats, d time.Duration)) genericIterator[Set] {
 	return &durationMeasuringIterator[Set]{
 		from:             from,
 		durationObserver: durationObserver,
+		stats:            stats,
+		trackDuration:    trackDuration,
 	}
 }
 
 func (m *durationMeasuringIterator[Set]) Next() bool {
 	start := time.Now()
 	next := m.from.Next()
-	m.durationObserver.Observe(time.Since(start).Seconds())
+	duration := time.Since(start)
+	m.durationObserver.Observe(duration.Seconds())
+	m.stats.update(func(stats *queryStats) {
+		m.trackDuration(stats, duration)
+	})
 	return next
 }
 
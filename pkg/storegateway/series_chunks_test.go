@@ -7,16 +7,25 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/concurrency"
 	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/test"
@@ -289,6 +298,82 @@ func TestSeriesChunksSeriesSet(t *testing.T) {
 		require.False(t, releasers[1].isReleased())
 		require.False(t, releasers[2].isReleased())
 	})
+
+	t.Run("should deduplicate identical chunks of each series if enabled", func(t *testing.T) {
+		chunk := func(minT, maxT int64, data string) storepb.AggrChunk {
+			return storepb.AggrChunk{MinTime: minT, MaxTime: maxT, Raw: &storepb.Chunk{Type: storepb.Chunk_XOR, Data: []byte(data)}}
+		}
+
+		set := newSeriesChunksSet(2, true)
+		set.series = append(set.series,
+			seriesEntry{lset: series1, chks: []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "a"), chunk(3, 4, "b")}},
+			seriesEntry{lset: series2, chks: []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "c"), chunk(1, 2, "a")}},
+		)
+
+		stats := newSafeQueryStats()
+		it := &seriesChunksSeriesSet{from: newSliceSeriesChunksSetIterator(set), deduplicateChunks: true, stats: stats}
+
+		require.True(t, it.Next())
+		lbls, chks := it.At()
+		require.Equal(t, series1, lbls)
+		require.Equal(t, []storepb.AggrChunk{chunk(1, 2, "a"), chunk(3, 4, "b")}, chks)
+
+		require.True(t, it.Next())
+		lbls, chks = it.At()
+		require.Equal(t, series2, lbls)
+		require.Equal(t, []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "c")}, chks)
+
+		require.False(t, it.Next())
+		require.NoError(t, it.Err())
+		require.Equal(t, 2, stats.export().chunksDeduplicated)
+	})
+}
+
+func TestDeduplicateChunks(t *testing.T) {
+	chunk := func(minT, maxT int64, data string) storepb.AggrChunk {
+		return storepb.AggrChunk{MinTime: minT, MaxTime: maxT, Raw: &storepb.Chunk{Type: storepb.Chunk_XOR, Data: []byte(data)}}
+	}
+
+	testCases := map[string]struct {
+		input           []storepb.AggrChunk
+		expected        []storepb.AggrChunk
+		expectedRemoved int
+	}{
+		"no chunks": {
+			input:    nil,
+			expected: nil,
+		},
+		"single chunk": {
+			input:    []storepb.AggrChunk{chunk(1, 2, "a")},
+			expected: []storepb.AggrChunk{chunk(1, 2, "a")},
+		},
+		"no duplicates": {
+			input:    []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 3, "a"), chunk(3, 4, "a")},
+			expected: []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 3, "a"), chunk(3, 4, "a")},
+		},
+		"same time range but different data": {
+			input:    []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "b")},
+			expected: []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "b")},
+		},
+		"adjacent duplicates": {
+			input:           []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "a"), chunk(3, 4, "b"), chunk(3, 4, "b"), chunk(3, 4, "b")},
+			expected:        []storepb.AggrChunk{chunk(1, 2, "a"), chunk(3, 4, "b")},
+			expectedRemoved: 3,
+		},
+		"non-adjacent duplicates with the same time range": {
+			input:           []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "b"), chunk(1, 2, "a"), chunk(1, 2, "b")},
+			expected:        []storepb.AggrChunk{chunk(1, 2, "a"), chunk(1, 2, "b")},
+			expectedRemoved: 2,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			actual, removed := deduplicateChunks(testCase.input)
+			assert.Equal(t, testCase.expected, actual)
+			assert.Equal(t, testCase.expectedRemoved, removed)
+		})
+	}
 }
 
 func TestPreloadingSetIterator(t *testing.T) {
@@ -443,6 +528,160 @@ func TestPreloadingSetIterator_Concurrency(t *testing.T) {
 
 }
 
+func TestAdaptivePreloadingSetIterator(t *testing.T) {
+	const delay = 10 * time.Millisecond
+
+	// Create some sets, each one with 100 bytes of chunks.
+	sets := make([]seriesChunksSet, 0, 10)
+	for i := 0; i < 10; i++ {
+		// The set is not releseable because will be reused by multiple tests.
+		set := newSeriesChunksSet(1, false)
+		set.series = append(set.series, seriesEntry{
+			lset: labels.FromStrings("__name__", fmt.Sprintf("metric_%d", i)),
+			chks: []storepb.AggrChunk{{Raw: &storepb.Chunk{Data: make([]byte, 100)}}},
+		})
+
+		sets = append(sets, set)
+	}
+	chunksSize := func(set seriesChunksSet) int { return set.chunksSize() }
+
+	depth := func(p *adaptivePreloadingSetIterator[seriesChunksSet]) int {
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+		return p.depth
+	}
+
+	t.Run("should iterate all sets if no error occurs", func(t *testing.T) {
+		t.Parallel()
+
+		source := newDelayedSeriesChunksSetIterator(delay, newSliceSeriesChunksSetIterator(sets...))
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](context.Background(), 4, 1000, chunksSize, source)
+
+		expectedIdx := 0
+		for preloading.Next() {
+			require.NoError(t, preloading.Err())
+			require.Equal(t, sets[expectedIdx], preloading.At())
+			expectedIdx++
+		}
+
+		require.NoError(t, preloading.Err())
+		require.Equal(t, len(sets), expectedIdx)
+	})
+
+	t.Run("should stop iterating once an error is found", func(t *testing.T) {
+		t.Parallel()
+
+		source := newDelayedSeriesChunksSetIterator(delay, newSliceSeriesChunksSetIteratorWithError(errors.New("mocked error"), len(sets), sets...))
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](context.Background(), 4, 1000, chunksSize, source)
+
+		expectedIdx := 0
+		for preloading.Next() {
+			require.NoError(t, preloading.Err())
+			require.Equal(t, sets[expectedIdx], preloading.At())
+			expectedIdx++
+		}
+
+		require.Error(t, preloading.Err())
+		require.Equal(t, len(sets), expectedIdx)
+	})
+
+	t.Run("should increase the depth when the consumer waits for the preloaded sets", func(t *testing.T) {
+		t.Parallel()
+
+		source := newDelayedSeriesChunksSetIterator(delay, newSliceSeriesChunksSetIterator(sets...))
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](context.Background(), 4, 1000, chunksSize, source)
+
+		for preloading.Next() {
+		}
+		require.NoError(t, preloading.Err())
+		assert.Equal(t, 4, depth(preloading))
+	})
+
+	t.Run("should decrease the depth when the consumer is slower than the preloading", func(t *testing.T) {
+		t.Parallel()
+
+		source := newSliceSeriesChunksSetIterator(append(append([]seriesChunksSet{}, sets...), sets...)...)
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](context.Background(), 4, 1000, chunksSize, source)
+		preloading.mtx.Lock()
+		preloading.depth = 4
+		preloading.mtx.Unlock()
+
+		for preloading.Next() {
+			time.Sleep(delay)
+		}
+		require.NoError(t, preloading.Err())
+		assert.Equal(t, 1, depth(preloading))
+	})
+
+	t.Run("should not preload more sets than the max bytes allow", func(t *testing.T) {
+		t.Parallel()
+
+		source := newSliceSeriesChunksSetIterator(sets...)
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](context.Background(), 8, 250, chunksSize, source)
+		preloading.mtx.Lock()
+		preloading.depth = 8
+		preloading.mtx.Unlock()
+
+		// Give the preloading goroutine the time to preload the sets.
+		time.Sleep(100 * time.Millisecond)
+
+		// The sets are preloaded until the max bytes are reached.
+		preloading.mtx.Lock()
+		assert.Equal(t, 3, preloading.preloadedSets)
+		assert.Equal(t, 300, preloading.preloadedBytes)
+		preloading.mtx.Unlock()
+
+		expectedIdx := 0
+		for preloading.Next() {
+			require.Equal(t, sets[expectedIdx], preloading.At())
+			expectedIdx++
+		}
+		require.NoError(t, preloading.Err())
+		require.Equal(t, len(sets), expectedIdx)
+	})
+
+	t.Run("should always preload one set even if larger than the max bytes", func(t *testing.T) {
+		t.Parallel()
+
+		source := newSliceSeriesChunksSetIterator(sets...)
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](context.Background(), 4, 10, chunksSize, source)
+
+		expectedIdx := 0
+		for preloading.Next() {
+			require.Equal(t, sets[expectedIdx], preloading.At())
+			expectedIdx++
+		}
+		require.NoError(t, preloading.Err())
+		require.Equal(t, len(sets), expectedIdx)
+	})
+
+	t.Run("should not leak preloading goroutine if caller doesn't iterate until the end of sets but context is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancelCtx := context.WithCancel(context.Background())
+
+		source := newDelayedSeriesChunksSetIterator(delay, newSliceSeriesChunksSetIteratorWithError(errors.New("mocked error"), len(sets), sets...))
+		preloading := newAdaptivePreloadingSetIterator[seriesChunksSet](ctx, 1, 1000, chunksSize, source)
+
+		require.True(t, preloading.Next())
+		require.NoError(t, preloading.Err())
+		require.Equal(t, sets[0], preloading.At())
+
+		cancelCtx()
+
+		// Give a short time to the preloader goroutine to react to the context cancellation.
+		time.Sleep(100 * time.Millisecond)
+
+		// At this point the preloading goroutine may have preloaded one more set before noticing the
+		// canceled context, but no more than that.
+		if preloading.Next() {
+			require.Equal(t, sets[1], preloading.At())
+		}
+		require.False(t, preloading.Next())
+		require.NoError(t, preloading.Err())
+	})
+}
+
 func TestLoadingSeriesChunksSetIterator(t *testing.T) {
 	type testBlock struct {
 		ulid   ulid.ULID
@@ -590,7 +829,8 @@ func TestLoadingSeriesChunksSetIterator(t *testing.T) {
 			readers := newChunkReaders(readersMap)
 
 			// Run test
-			set := newLoadingSeriesChunksSetIterator(*readers, bytesPool, newSliceSeriesChunkRefsSetIterator(nil, testCase.setsToLoad...), 100, newSafeQueryStats())
+			stats := newSafeQueryStats()
+			set := newLoadingSeriesChunksSetIterator(context.Background(), *readers, bytesPool, newSliceSeriesChunkRefsSetIterator(nil, testCase.setsToLoad...), 100, false, seriesChunksSlabSize, false, nil, stats)
 			loadedSets := readAllSeriesChunksSets(set)
 
 			// Assertions
@@ -611,6 +851,12 @@ func TestLoadingSeriesChunksSetIterator(t *testing.T) {
 			}
 			assert.Equal(t, expectedReservedBytes, int(bytesPool.balance.Load()))
 
+			// The memory borrowed from the pools by the loaded sets is tracked in the stats. The series and series chunks
+			// slices reused from the pools may be bigger than requested.
+			assert.Equal(t, expectedReservedBytes, stats.export().chunksPoolBorrowedSizeSum)
+			assert.GreaterOrEqual(t, stats.export().seriesPoolBorrowedSizeSum, len(testCase.expectedSets)*100*int(unsafe.Sizeof(seriesEntry{})))
+			assert.GreaterOrEqual(t, stats.export().seriesChunksPoolBorrowedSizeSum, len(testCase.expectedSets)*seriesChunksSlabSize*int(unsafe.Sizeof(storepb.AggrChunk{})))
+
 			// Check that chunks bytes are what we expect
 			require.Len(t, loadedSets, len(testCase.expectedSets))
 			for i, loadedSet := range loadedSets {
@@ -637,6 +883,197 @@ func TestLoadingSeriesChunksSetIterator(t *testing.T) {
 	}
 }
 
+func TestLoadingSeriesChunksSetIterator_AsyncLoading(t *testing.T) {
+	blockID := ulid.MustNew(1, nil)
+	series := generateSeriesEntriesWithChunks(t, 4)
+
+	refsSet := seriesChunkRefsSet{}
+	for _, s := range series {
+		refs := seriesChunkRefs{lset: s.lset}
+		for i, c := range s.chks {
+			refs.chunks = append(refs.chunks, seriesChunkRef{blockID: blockID, ref: s.refs[i], minTime: c.MinTime, maxTime: c.MaxTime})
+		}
+		refsSet.series = append(refsSet.series, refs)
+	}
+
+	t.Run("should return each series as soon as its chunks have been loaded", func(t *testing.T) {
+		reader := &gatedChunkReaderMock{chunkReaderMock: newChunkReaderMockWithSeries(series, nil, nil), gate: make(chan struct{})}
+		readers := newChunkReaders(map[ulid.ULID]chunkReader{blockID: reader})
+		loading := newLoadingSeriesChunksSetIterator(context.Background(), *readers, pool.NoopBytes{}, newSliceSeriesChunkRefsSetIterator(nil, refsSet), 100, true, seriesChunksSlabSize, false, nil, newSafeQueryStats())
+		set := newSeriesChunksSeriesSet(loading)
+
+		// The first series is returned while the chunks of the other series are still being loaded.
+		require.True(t, set.Next())
+		lset, chks := set.At()
+		assert.Equal(t, series[0].lset, lset)
+		assert.ElementsMatch(t, series[0].chks, chks)
+
+		close(reader.gate)
+		for i := 1; i < len(series); i++ {
+			require.True(t, set.Next())
+			lset, chks := set.At()
+			assert.Equal(t, series[i].lset, lset)
+			assert.ElementsMatch(t, series[i].chks, chks)
+		}
+		require.False(t, set.Next())
+		require.NoError(t, set.Err())
+	})
+
+	t.Run("should return the loading error", func(t *testing.T) {
+		readers := newChunkReaders(map[ulid.ULID]chunkReader{blockID: newChunkReaderMockWithSeries(series, nil, errors.New("test err"))})
+		loading := newLoadingSeriesChunksSetIterator(context.Background(), *readers, pool.NoopBytes{}, newSliceSeriesChunkRefsSetIterator(nil, refsSet, refsSet), 100, true, seriesChunksSlabSize, false, nil, newSafeQueryStats())
+		set := newSeriesChunksSeriesSet(loading)
+
+		require.False(t, set.Next())
+		require.ErrorContains(t, set.Err(), "test err")
+	})
+
+	t.Run("should abort the loading once the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		reader := &gatedChunkReaderMock{chunkReaderMock: newChunkReaderMockWithSeries(series, nil, nil), gate: make(chan struct{})}
+		readers := newChunkReaders(map[ulid.ULID]chunkReader{blockID: reader})
+		loading := newLoadingSeriesChunksSetIterator(ctx, *readers, pool.NoopBytes{}, newSliceSeriesChunkRefsSetIterator(nil, refsSet, refsSet), 100, true, seriesChunksSlabSize, false, nil, newSafeQueryStats())
+		set := newSeriesChunksSeriesSet(loading)
+
+		require.True(t, set.Next())
+
+		// The gate is never closed, so the loading completes only because the context is canceled.
+		cancel()
+		require.False(t, set.Next())
+		require.ErrorIs(t, set.Err(), context.Canceled)
+	})
+
+	t.Run("should not load the next set once the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		readers := newChunkReaders(map[ulid.ULID]chunkReader{blockID: newChunkReaderMockWithSeries(series, nil, nil)})
+		loading := newLoadingSeriesChunksSetIterator(ctx, *readers, pool.NoopBytes{}, newSliceSeriesChunkRefsSetIterator(nil, refsSet, refsSet), 100, false, seriesChunksSlabSize, false, nil, newSafeQueryStats())
+
+		require.True(t, loading.Next())
+		cancel()
+		require.False(t, loading.Next())
+		require.ErrorIs(t, loading.Err(), context.Canceled)
+	})
+}
+
+func TestLoadingSeriesChunksSetIterator_BatchObserver(t *testing.T) {
+	blockID := ulid.MustNew(1, nil)
+	series := generateSeriesEntriesWithChunks(t, 4)
+
+	refsSet := seriesChunkRefsSet{}
+	expectedBytes := 0
+	for _, s := range series {
+		refs := seriesChunkRefs{lset: s.lset}
+		for i, c := range s.chks {
+			refs.chunks = append(refs.chunks, seriesChunkRef{blockID: blockID, ref: s.refs[i], minTime: c.MinTime, maxTime: c.MaxTime})
+			expectedBytes += len(c.Raw.Data)
+		}
+		refsSet.series = append(refsSet.series, refs)
+	}
+
+	for _, asyncLoading := range []bool{false, true} {
+		for name, testData := range map[string]struct {
+			slowThreshold  time.Duration
+			expectedLogged bool
+		}{
+			"slow batch logging disabled":            {slowThreshold: 0, expectedLogged: false},
+			"batch loaded faster than the threshold": {slowThreshold: time.Hour, expectedLogged: false},
+			"batch loaded slower than the threshold": {slowThreshold: time.Nanosecond, expectedLogged: true},
+		} {
+			t.Run(fmt.Sprintf("%s, async loading: %t", name, asyncLoading), func(t *testing.T) {
+				logs := &concurrency.SyncBuffer{}
+				reg := prometheus.NewPedanticRegistry()
+				observer := &seriesChunksBatchObserver{
+					chunksBytes:   promauto.With(reg).NewHistogram(prometheus.HistogramOpts{Name: "test", Help: "Test.", Buckets: []float64{1}}),
+					slowThreshold: testData.slowThreshold,
+					logger:        log.NewLogfmtLogger(logs),
+				}
+
+				readers := newChunkReaders(map[ulid.ULID]chunkReader{blockID: newChunkReaderMockWithSeries(series, nil, nil)})
+				loading := newLoadingSeriesChunksSetIterator(context.Background(), *readers, pool.NoopBytes{}, newSliceSeriesChunkRefsSetIterator(nil, refsSet, refsSet), 100, asyncLoading, seriesChunksSlabSize, false, observer, newSafeQueryStats())
+				set := newSeriesChunksSeriesSet(loading)
+				for set.Next() {
+				}
+				require.NoError(t, set.Err())
+
+				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+					# HELP test Test.
+					# TYPE test histogram
+					test_bucket{le="1"} 0
+					test_bucket{le="+Inf"} 2
+					test_sum %d
+					test_count 2
+				`, 2*expectedBytes))))
+
+				if testData.expectedLogged {
+					assert.Equal(t, 2, strings.Count(logs.String(), fmt.Sprintf(`msg="slow series chunks batch load" blocks=%s series=4 bytes=%d`, blockID, expectedBytes)))
+				} else {
+					assert.Empty(t, logs.String())
+				}
+			})
+		}
+	}
+}
+
+func TestLoadingSeriesChunksSetIterator_nextChunksSlabSize(t *testing.T) {
+	tests := map[string]struct {
+		adaptive       bool
+		lastSeries     int
+		lastChunks     int
+		nextSeries     int
+		expectedResult int
+	}{
+		"should return the configured slab size if adaptive slab size is disabled": {
+			adaptive:       false,
+			lastSeries:     100,
+			lastChunks:     100,
+			nextSeries:     100,
+			expectedResult: 1000,
+		},
+		"should return the configured slab size for the first set": {
+			adaptive:       true,
+			nextSeries:     100,
+			expectedResult: 1000,
+		},
+		"should estimate the slab size from the previous set": {
+			adaptive:       true,
+			lastSeries:     100,
+			lastChunks:     250,
+			nextSeries:     100,
+			expectedResult: 250,
+		},
+		"should round up the estimated slab size": {
+			adaptive:       true,
+			lastSeries:     3,
+			lastChunks:     100,
+			nextSeries:     2,
+			expectedResult: 67,
+		},
+		"should not return a slab size larger than the configured one": {
+			adaptive:       true,
+			lastSeries:     100,
+			lastChunks:     2000,
+			nextSeries:     100,
+			expectedResult: 1000,
+		},
+		"should not return a slab size smaller than the min one": {
+			adaptive:       true,
+			lastSeries:     100,
+			lastChunks:     1,
+			nextSeries:     100,
+			expectedResult: minAdaptiveSeriesChunksSlabSize,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			it := newLoadingSeriesChunksSetIterator(context.Background(), bucketChunkReaders{}, pool.NoopBytes{}, newSliceSeriesChunkRefsSetIterator(nil), 100, false, 1000, testData.adaptive, nil, newSafeQueryStats())
+			it.lastSeries, it.lastChunks = testData.lastSeries, testData.lastChunks
+
+			assert.Equal(t, testData.expectedResult, it.nextChunksSlabSize(testData.nextSeries))
+		})
+	}
+}
+
 func BenchmarkLoadingSeriesChunksSetIterator(b *testing.B) {
 	for batchSize := 10; batchSize <= 10000; batchSize *= 10 {
 		b.Run(fmt.Sprintf("batch size: %d", batchSize), func(b *testing.B) {
@@ -685,7 +1122,7 @@ func BenchmarkLoadingSeriesChunksSetIterator(b *testing.B) {
 
 			for n := 0; n < b.N; n++ {
 				batchSize := numSeriesPerSet
-				it := newLoadingSeriesChunksSetIterator(*chunkReaders, chunksPool, newSliceSeriesChunkRefsSetIterator(nil, sets...), batchSize, stats)
+				it := newLoadingSeriesChunksSetIterator(context.Background(), *chunkReaders, chunksPool, newSliceSeriesChunkRefsSetIterator(nil, sets...), batchSize, false, seriesChunksSlabSize, false, nil, stats)
 
 				actualSeries := 0
 				actualChunks := 0
@@ -751,7 +1188,7 @@ func (f *chunkReaderMock) addLoad(id chunks.ChunkRef, seriesEntry, chunk int) er
 	return nil
 }
 
-func (f *chunkReaderMock) load(result []seriesEntry, chunksPool *pool.BatchBytes, _ *safeQueryStats) error {
+func (f *chunkReaderMock) load(_ context.Context, result []seriesEntry, chunksPool *pool.BatchBytes, _ *safeQueryStats, loaded *seriesChunksLoadTracker) error {
 	if f.loadErr != nil {
 		return f.loadErr
 	}
@@ -764,6 +1201,7 @@ func (f *chunkReaderMock) load(result []seriesEntry, chunksPool *pool.BatchBytes
 		}
 		copy(copiedChunkData, chunkData)
 		result[indices.seriesEntry].chks[indices.chunk].Raw = &storepb.Chunk{Data: copiedChunkData}
+		loaded.chunkLoaded(indices.seriesEntry)
 	}
 	return nil
 }
@@ -772,6 +1210,41 @@ func (f *chunkReaderMock) reset() {
 	f.toLoad = make(map[chunks.ChunkRef]loadIdx)
 }
 
+// gatedChunkReaderMock is a chunkReaderMock which loads the chunks of the first series, and then waits
+// for the gate to be closed before loading the chunks of the other series.
+type gatedChunkReaderMock struct {
+	*chunkReaderMock
+	gate chan struct{}
+}
+
+func (f *gatedChunkReaderMock) load(ctx context.Context, result []seriesEntry, chunksPool *pool.BatchBytes, stats *safeQueryStats, loaded *seriesChunksLoadTracker) error {
+	toLoad := f.toLoad
+
+	f.toLoad = map[chunks.ChunkRef]loadIdx{}
+	for ref, idx := range toLoad {
+		if idx.seriesEntry == 0 {
+			f.toLoad[ref] = idx
+		}
+	}
+	if err := f.chunkReaderMock.load(ctx, result, chunksPool, stats, loaded); err != nil {
+		return err
+	}
+
+	select {
+	case <-f.gate:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	f.toLoad = map[chunks.ChunkRef]loadIdx{}
+	for ref, idx := range toLoad {
+		if idx.seriesEntry != 0 {
+			f.toLoad[ref] = idx
+		}
+	}
+	return f.chunkReaderMock.load(ctx, result, chunksPool, stats, loaded)
+}
+
 // generateSeriesEntriesWithChunks generates seriesEntries with chunks. Each chunk is a random byte slice.
 func generateSeriesEntriesWithChunks(t *testing.T, numSeries int) []seriesEntry {
 	const numChunksPerSeries = 2
@@ -927,3 +1400,41 @@ func readAllSeriesLabels(it storepb.SeriesSet) []labels.Labels {
 	}
 	return out
 }
+
+func TestPopulateChunk_DownsampledChunk(t *testing.T) {
+	newChunk := func(values ...float64) chunkenc.Chunk {
+		c := chunkenc.NewXORChunk()
+		app, err := c.Appender()
+		require.NoError(t, err)
+		for i, v := range values {
+			app.Append(int64(i), v)
+		}
+		return c
+	}
+
+	var aggrs [5]chunkenc.Chunk
+	aggrs[downsample.AggrCount] = newChunk(2, 3)
+	aggrs[downsample.AggrSum] = newChunk(10, 15)
+	aggrs[downsample.AggrMin] = newChunk(4, 4)
+	aggrs[downsample.AggrMax] = newChunk(6, 6)
+	in := rawChunk(append([]byte{byte(downsample.ChunkEncAggr)}, downsample.EncodeAggrChunk(aggrs)...))
+
+	var out storepb.AggrChunk
+	require.NoError(t, populateChunk(&out, in, &pool.BatchBytes{Delegate: pool.NoopBytes{}}))
+
+	assert.Nil(t, out.Raw)
+	assert.Nil(t, out.Counter)
+	assert.Equal(t, &storepb.Chunk{Type: storepb.Chunk_XOR, Data: aggrs[downsample.AggrCount].Bytes()}, out.Count)
+	assert.Equal(t, &storepb.Chunk{Type: storepb.Chunk_XOR, Data: aggrs[downsample.AggrSum].Bytes()}, out.Sum)
+	assert.Equal(t, &storepb.Chunk{Type: storepb.Chunk_XOR, Data: aggrs[downsample.AggrMin].Bytes()}, out.Min)
+	assert.Equal(t, &storepb.Chunk{Type: storepb.Chunk_XOR, Data: aggrs[downsample.AggrMax].Bytes()}, out.Max)
+
+	set := seriesChunksSet{series: []seriesEntry{{chks: []storepb.AggrChunk{out}}}}
+	expectedSize := 0
+	for _, c := range aggrs {
+		if c != nil {
+			expectedSize += len(c.Bytes())
+		}
+	}
+	assert.Equal(t, expectedSize, set.chunksSize())
+}
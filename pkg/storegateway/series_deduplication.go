@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+var errSeriesExecutionAborted = errors.New("the shared execution of the Series() request has been aborted")
+
+// inflightSeriesRequest is a Series() request being executed, whose responses are recorded to be sent
+// to the identical requests received meanwhile.
+type inflightSeriesRequest struct {
+	done chan struct{}
+
+	// overflowed is closed once the responses exceed the max size and stop being recorded, so that
+	// the identical requests don't wait for an execution whose responses they can't reuse.
+	overflowed chan struct{}
+
+	// responses and err can be read only once done is closed. responses is nil if the responses
+	// haven't been recorded, because they exceeded the max size.
+	responses [][]byte
+	err       error
+}
+
+// seriesDeduplication collapses identical Series() requests (same tenant and request) received concurrently
+// into a single execution, like a singleflight. The responses of the executed request are recorded, up to a
+// max size, and sent to the identical requests once the execution completes.
+type seriesDeduplication struct {
+	logger           log.Logger
+	maxResponseBytes int
+
+	mtx      sync.Mutex
+	inflight map[string]*inflightSeriesRequest
+
+	executedRequests     prometheus.Counter
+	deduplicatedRequests prometheus.Counter
+}
+
+func newSeriesDeduplication(maxResponseBytes int, logger log.Logger, reg prometheus.Registerer) *seriesDeduplication {
+	return &seriesDeduplication{
+		logger:           logger,
+		maxResponseBytes: maxResponseBytes,
+		inflight:         map[string]*inflightSeriesRequest{},
+		executedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_series_deduplication_executed_requests_total",
+			Help: "Total number of Series() requests executed by the series requests deduplication.",
+		}),
+		deduplicatedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_series_deduplication_deduplicated_requests_total",
+			Help: "Total number of Series() requests which haven't been executed because they received the responses of an identical concurrent request.",
+		}),
+	}
+}
+
+// series executes the request through the input function, unless an identical request of the same tenant is
+// in flight, in which case the responses of the in-flight request are sent once it completes.
+func (d *seriesDeduplication) series(userID string, req *storepb.SeriesRequest, srv storepb.Store_SeriesServer, execute func(storepb.Store_SeriesServer) error) error {
+	data, err := req.Marshal()
+	if err != nil {
+		return execute(srv)
+	}
+	key := userID + ":" + string(data)
+
+	for {
+		d.mtx.Lock()
+		request, found := d.inflight[key]
+		if !found {
+			request = &inflightSeriesRequest{done: make(chan struct{}), overflowed: make(chan struct{})}
+			d.inflight[key] = request
+		}
+		d.mtx.Unlock()
+
+		if !found {
+			return d.execute(key, request, srv, execute)
+		}
+
+		select {
+		case <-request.done:
+		case <-request.overflowed:
+		case <-srv.Context().Done():
+			return srv.Context().Err()
+		}
+
+		// The responses of the shared execution exceeded the max size, so they haven't been recorded: the request
+		// is executed directly, instead of waiting for another execution of the identical requests.
+		select {
+		case <-request.overflowed:
+			level.Debug(util_log.WithContext(srv.Context(), d.logger)).Log("msg", "the responses of an identical Series() request exceeded the max size, executing the request")
+			d.executedRequests.Inc()
+			return execute(srv)
+		default:
+		}
+
+		// The execution may have failed because of the request which triggered it, for example because it has
+		// been canceled. In this case, the request is executed again.
+		if request.err != nil {
+			level.Debug(util_log.WithContext(srv.Context(), d.logger)).Log("msg", "the responses of an identical Series() request can't be reused, executing the request again", "err", request.err)
+			continue
+		}
+
+		d.deduplicatedRequests.Inc()
+		return sendRecordedSeriesResponses(srv, request.responses)
+	}
+}
+
+func (d *seriesDeduplication) execute(key string, request *inflightSeriesRequest, srv storepb.Store_SeriesServer, execute func(storepb.Store_SeriesServer) error) error {
+	recorder := &recordingSeriesServer{Store_SeriesServer: srv, maxBytes: d.maxResponseBytes, responses: [][]byte{}, overflowed: request.overflowed}
+
+	defer func() {
+		d.mtx.Lock()
+		delete(d.inflight, key)
+		d.mtx.Unlock()
+
+		request.responses = recorder.responses
+		close(request.done)
+	}()
+
+	// The error is overwritten once the execution completes, so that the waiting requests don't
+	// get the responses of an execution which didn't complete.
+	request.err = errSeriesExecutionAborted
+
+	d.executedRequests.Inc()
+	request.err = execute(recorder)
+	return request.err
+}
+
+func sendRecordedSeriesResponses(srv storepb.Store_SeriesServer, responses [][]byte) error {
+	for _, data := range responses {
+		resp := &storepb.SeriesResponse{}
+		if err := resp.Unmarshal(data); err != nil {
+			return err
+		}
+		if err := srv.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordingSeriesServer is a storepb.Store_SeriesServer recording the serialized responses, up to a max size.
+// The responses are serialized because they may reference memory which is released once the request completes.
+type recordingSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	maxBytes int
+	size     int
+
+	// overflowed is closed once the max size has been exceeded.
+	overflowed chan struct{}
+
+	// responses is nil once the max size has been exceeded.
+	responses [][]byte
+}
+
+func (s *recordingSeriesServer) Send(resp *storepb.SeriesResponse) error {
+	if s.responses != nil {
+		data, err := resp.Marshal()
+		if err != nil || s.size+len(data) > s.maxBytes {
+			s.responses = nil
+			close(s.overflowed)
+		} else {
+			s.responses = append(s.responses, data)
+			s.size += len(data)
+		}
+	}
+
+	return s.Store_SeriesServer.Send(resp)
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestSeriesDeduplication(t *testing.T) {
+	req := &storepb.SeriesRequest{
+		MinTime:  10,
+		MaxTime:  20,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "job", Value: "a"}},
+	}
+	otherReq := &storepb.SeriesRequest{
+		MinTime:  10,
+		MaxTime:  30,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "job", Value: "a"}},
+	}
+	series := storepb.NewSeriesResponse(&storepb.Series{Labels: []mimirpb.LabelAdapter{{Name: "job", Value: "a"}}})
+	warning := &storepb.SeriesResponse{Result: &storepb.SeriesResponse_Warning{Warning: "warning"}}
+
+	// blockingExecute returns an execute function sending the input responses once released, and a channel
+	// receiving a value each time the function is called.
+	blockingExecute := func(release chan struct{}, err error, responses ...*storepb.SeriesResponse) (func(storepb.Store_SeriesServer) error, chan struct{}) {
+		started := make(chan struct{}, 10)
+		return func(srv storepb.Store_SeriesServer) error {
+			started <- struct{}{}
+			<-release
+			for _, resp := range responses {
+				if err := srv.Send(resp); err != nil {
+					return err
+				}
+			}
+			return err
+		}, started
+	}
+
+	t.Run("should send the responses of the in-flight identical request", func(t *testing.T) {
+		reg := prometheus.NewPedanticRegistry()
+		d := newSeriesDeduplication(1024, log.NewNopLogger(), reg)
+
+		release := make(chan struct{})
+		execute, started := blockingExecute(release, nil, series, warning)
+
+		results := make(chan *bucketStoreSeriesServer, 4)
+		run := func(userID string, req *storepb.SeriesRequest) {
+			srv := newBucketStoreSeriesServer(context.Background())
+			require.NoError(t, d.series(userID, req, srv, execute))
+			results <- srv
+		}
+
+		go run("user-1", req)
+		<-started
+		go run("user-1", req)
+		time.Sleep(50 * time.Millisecond)
+
+		// Requests of other tenants, or different requests, aren't deduplicated.
+		go run("user-2", req)
+		go run("user-1", otherReq)
+		<-started
+		<-started
+
+		close(release)
+		for i := 0; i < 4; i++ {
+			srv := <-results
+			require.Len(t, srv.SeriesSet, 1)
+			assert.Equal(t, series.GetSeries().Labels, srv.SeriesSet[0].Labels)
+			require.Len(t, srv.Warnings, 1)
+			assert.Equal(t, "warning", srv.Warnings[0].Error())
+		}
+
+		assert.Equal(t, float64(3), testutil.ToFloat64(d.executedRequests))
+		assert.Equal(t, float64(1), testutil.ToFloat64(d.deduplicatedRequests))
+		assert.Empty(t, d.inflight)
+	})
+
+	t.Run("should execute the identical requests again if the in-flight request failed", func(t *testing.T) {
+		d := newSeriesDeduplication(1024, log.NewNopLogger(), nil)
+
+		release := make(chan struct{})
+		failing, started := blockingExecute(release, errors.New("failed"), series)
+
+		errs := make(chan error)
+		go func() {
+			errs <- d.series("user-1", req, newBucketStoreSeriesServer(context.Background()), failing)
+		}()
+		<-started
+
+		released := make(chan struct{})
+		close(released)
+		succeeding, _ := blockingExecute(released, nil, series)
+		srv := newBucketStoreSeriesServer(context.Background())
+		go func() {
+			errs <- d.series("user-1", req, srv, succeeding)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		close(release)
+		assert.EqualError(t, <-errs, "failed")
+		require.NoError(t, <-errs)
+		assert.Len(t, srv.SeriesSet, 1)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(d.executedRequests))
+		assert.Equal(t, float64(0), testutil.ToFloat64(d.deduplicatedRequests))
+	})
+
+	t.Run("should execute the identical requests directly if the responses of the in-flight request exceed the max size", func(t *testing.T) {
+		d := newSeriesDeduplication(1, log.NewNopLogger(), nil)
+
+		release := make(chan struct{})
+		execute, started := blockingExecute(release, nil, series)
+
+		errs := make(chan error)
+		servers := []*bucketStoreSeriesServer{newBucketStoreSeriesServer(context.Background()), newBucketStoreSeriesServer(context.Background())}
+		go func() {
+			errs <- d.series("user-1", req, servers[0], execute)
+		}()
+		<-started
+		go func() {
+			errs <- d.series("user-1", req, servers[1], execute)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		close(release)
+		require.NoError(t, <-errs)
+		require.NoError(t, <-errs)
+		for _, srv := range servers {
+			assert.Len(t, srv.SeriesSet, 1)
+		}
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(d.executedRequests))
+		assert.Equal(t, float64(0), testutil.ToFloat64(d.deduplicatedRequests))
+	})
+
+	t.Run("should not wait for the in-flight request to complete once its responses exceeded the max size", func(t *testing.T) {
+		d := newSeriesDeduplication(1, log.NewNopLogger(), nil)
+
+		release := make(chan struct{})
+		started := make(chan struct{}, 10)
+		execute := func(srv storepb.Store_SeriesServer) error {
+			started <- struct{}{}
+			if err := srv.Send(series); err != nil {
+				return err
+			}
+			<-release
+			return nil
+		}
+
+		errs := make(chan error)
+		go func() {
+			errs <- d.series("user-1", req, newBucketStoreSeriesServer(context.Background()), execute)
+		}()
+		<-started
+
+		// The identical requests are executed concurrently, while the in-flight request is still running.
+		for i := 0; i < 3; i++ {
+			go func() {
+				errs <- d.series("user-1", req, newBucketStoreSeriesServer(context.Background()), execute)
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				require.FailNow(t, "the identical requests haven't been executed while the in-flight request was running")
+			}
+		}
+
+		close(release)
+		for i := 0; i < 4; i++ {
+			require.NoError(t, <-errs)
+		}
+
+		assert.Equal(t, float64(4), testutil.ToFloat64(d.executedRequests))
+		assert.Equal(t, float64(0), testutil.ToFloat64(d.deduplicatedRequests))
+		assert.Empty(t, d.inflight)
+	})
+
+	t.Run("should stop waiting for the in-flight request once the context is canceled", func(t *testing.T) {
+		d := newSeriesDeduplication(1024, log.NewNopLogger(), nil)
+
+		release := make(chan struct{})
+		defer close(release)
+		execute, started := blockingExecute(release, nil, series)
+
+		go func() {
+			_ = d.series("user-1", req, newBucketStoreSeriesServer(context.Background()), execute)
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, d.series("user-1", req, newBucketStoreSeriesServer(ctx), execute), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+)
+
+const (
+	// seriesHashesFilename is the name of the file, in the local directory of a block, where the series
+	// hashes of the block are persisted along with the index-header.
+	seriesHashesFilename = "series-hashes"
+
+	// seriesHashesMagic is the magic number at the beginning of a series hashes file.
+	seriesHashesMagic = 0x5E81E5A5
+
+	seriesHashesFormatV1 = 1
+
+	// seriesHashesHeaderLen is the length of the header of a series hashes file: magic number and format version.
+	seriesHashesHeaderLen = 4 + 1
+
+	// seriesHashesEntryLen is the length of each entry of a series hashes file: series reference and hash.
+	seriesHashesEntryLen = 8 + 8
+)
+
+var seriesHashesCastagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// blockSeriesHashes holds the labels hashes of the series of a block computed by the sharded queries, and
+// persists them in the local directory of the block, so that they survive the restarts of the store-gateway.
+// Differently from the series hash cache, the hashes are never evicted while the block is loaded: given the
+// hash is computed from the series labels, and the shard from the hash, the series not belonging to the
+// requested shard can always be skipped without hashing their labels once they've been queried once.
+type blockSeriesHashes struct {
+	path   string
+	logger log.Logger
+
+	mtx       sync.RWMutex
+	hashes    map[storage.SeriesRef]uint64
+	persisted int // The number of hashes persisted by the last persist().
+}
+
+// loadBlockSeriesHashes returns the series hashes of the block in dir, reading the ones previously persisted, if any.
+func loadBlockSeriesHashes(dir string, logger log.Logger) *blockSeriesHashes {
+	h := &blockSeriesHashes{
+		path:   filepath.Join(dir, seriesHashesFilename),
+		logger: logger,
+		hashes: map[storage.SeriesRef]uint64{},
+	}
+
+	hashes, err := readSeriesHashes(h.path)
+	switch {
+	case err == nil:
+		h.hashes = hashes
+		h.persisted = len(hashes)
+	case !os.IsNotExist(err):
+		level.Warn(logger).Log("msg", "failed to read the persisted series hashes, ignoring them", "path", h.path, "err", err)
+	}
+	return h
+}
+
+func (h *blockSeriesHashes) fetch(id storage.SeriesRef) (uint64, bool) {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	hash, ok := h.hashes[id]
+	return hash, ok
+}
+
+func (h *blockSeriesHashes) store(id storage.SeriesRef, hash uint64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.hashes[id] = hash
+}
+
+// persist writes the hashes to the local directory of the block, if any has been added since the last call.
+func (h *blockSeriesHashes) persist() error {
+	h.mtx.RLock()
+	if len(h.hashes) == h.persisted {
+		h.mtx.RUnlock()
+		return nil
+	}
+	refs := make([]storage.SeriesRef, 0, len(h.hashes))
+	for id := range h.hashes {
+		refs = append(refs, id)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+
+	buf := encoding.Encbuf{B: make([]byte, 0, seriesHashesHeaderLen+len(refs)*seriesHashesEntryLen+crc32.Size)}
+	buf.PutBE32(seriesHashesMagic)
+	buf.PutByte(seriesHashesFormatV1)
+	for _, id := range refs {
+		buf.PutBE64(uint64(id))
+		buf.PutBE64(h.hashes[id])
+	}
+	h.mtx.RUnlock()
+
+	buf.PutHash(crc32.New(seriesHashesCastagnoliTable))
+
+	// The file is written atomically, so that a partially written file is never read.
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Get(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return err
+	}
+
+	h.mtx.Lock()
+	h.persisted = len(refs)
+	h.mtx.Unlock()
+	return nil
+}
+
+// readSeriesHashes reads the series hashes file at path.
+func readSeriesHashes(path string) (map[storage.SeriesRef]uint64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < seriesHashesHeaderLen+crc32.Size || (len(b)-seriesHashesHeaderLen-crc32.Size)%seriesHashesEntryLen != 0 {
+		return nil, fmt.Errorf("invalid series hashes file size %d", len(b))
+	}
+
+	content, checksum := b[:len(b)-crc32.Size], b[len(b)-crc32.Size:]
+	if actual := crc32.Checksum(content, seriesHashesCastagnoliTable); actual != binary.BigEndian.Uint32(checksum) {
+		return nil, fmt.Errorf("series hashes file checksum mismatch")
+	}
+
+	d := encoding.Decbuf{B: content}
+	if magic := d.Be32(); magic != seriesHashesMagic {
+		return nil, fmt.Errorf("invalid series hashes file magic number %x", magic)
+	}
+	if format := d.Byte(); format != seriesHashesFormatV1 {
+		return nil, fmt.Errorf("unknown series hashes file format %d", format)
+	}
+
+	hashes := make(map[storage.SeriesRef]uint64, d.Len()/seriesHashesEntryLen)
+	for d.Len() > 0 {
+		id := storage.SeriesRef(d.Be64())
+		hashes[id] = d.Be64()
+	}
+	return hashes, d.Err()
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSeriesHashes_PersistAndLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	h := loadBlockSeriesHashes(dir, log.NewNopLogger())
+	_, ok := h.fetch(1)
+	assert.False(t, ok)
+
+	// Nothing is written if there are no hashes.
+	require.NoError(t, h.persist())
+	_, err := os.Stat(filepath.Join(dir, seriesHashesFilename))
+	assert.True(t, os.IsNotExist(err))
+
+	h.store(16, 100)
+	h.store(1, 200)
+	h.store(32, 300)
+	require.NoError(t, h.persist())
+
+	loaded := loadBlockSeriesHashes(dir, log.NewNopLogger())
+	assert.Equal(t, map[storage.SeriesRef]uint64{1: 200, 16: 100, 32: 300}, loaded.hashes)
+
+	// The hashes added after the last persist are written by the next one.
+	loaded.store(48, 400)
+	require.NoError(t, loaded.persist())
+
+	loaded = loadBlockSeriesHashes(dir, log.NewNopLogger())
+	assert.Equal(t, map[storage.SeriesRef]uint64{1: 200, 16: 100, 32: 300, 48: 400}, loaded.hashes)
+}
+
+func TestBlockSeriesHashes_ShouldIgnoreCorruptedFile(t *testing.T) {
+	dir := t.TempDir()
+
+	h := loadBlockSeriesHashes(dir, log.NewNopLogger())
+	h.store(1, 100)
+	require.NoError(t, h.persist())
+
+	path := filepath.Join(dir, seriesHashesFilename)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Flip a byte of the content.
+	content[len(content)-5] ^= 0xff
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+
+	_, err = readSeriesHashes(path)
+	assert.EqualError(t, err, "series hashes file checksum mismatch")
+	assert.Empty(t, loadBlockSeriesHashes(dir, log.NewNopLogger()).hashes)
+
+	// Truncate the file.
+	require.NoError(t, os.WriteFile(path, content[:len(content)-3], 0o644))
+	assert.Empty(t, loadBlockSeriesHashes(dir, log.NewNopLogger()).hashes)
+}
+
+func TestPersistedSeriesHasher(t *testing.T) {
+	dir := t.TempDir()
+	lset := labels.FromStrings("a", "1")
+
+	persisted := loadBlockSeriesHashes(dir, log.NewNopLogger())
+	hasher := persistedSeriesHasher{
+		cache:     hashcache.NewSeriesHashCache(1024).GetBlockCache("block"),
+		persisted: persisted,
+	}
+
+	stats := &queryStats{}
+	assert.Equal(t, lset.Hash(), hasher.Hash(1, lset, stats))
+	assert.Equal(t, 0, stats.seriesHashCacheHits)
+	require.NoError(t, persisted.persist())
+
+	// A new hasher, with an empty series hash cache, finds the hash in the persisted ones.
+	hasher = persistedSeriesHasher{
+		cache:     hashcache.NewSeriesHashCache(1024).GetBlockCache("block"),
+		persisted: loadBlockSeriesHashes(dir, log.NewNopLogger()),
+	}
+
+	stats = &queryStats{}
+	hash, ok := hasher.CachedHash(1, stats)
+	require.True(t, ok)
+	assert.Equal(t, lset.Hash(), hash)
+	assert.Equal(t, 1, stats.seriesHashCacheRequests)
+	assert.Equal(t, 1, stats.seriesHashCacheHits)
+}
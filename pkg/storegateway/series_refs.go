@@ -6,6 +6,7 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -16,6 +17,7 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/prometheus/prometheus/tsdb/index"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/sharding"
@@ -627,6 +629,7 @@ type loadingSeriesChunkRefsSetIterator struct {
 func openBlockSeriesChunkRefsSetsIterator(
 	ctx context.Context,
 	batchSize int,
+	lazyPostings bool, // If true the matching postings are streamed in batches instead of being expanded upfront.
 	tenantID string,
 	indexr *bucketIndexReader, // Index reader for block.
 	indexCache indexcache.IndexCache,
@@ -646,15 +649,25 @@ func openBlockSeriesChunkRefsSetsIterator(
 		return nil, errors.New("set size must be a positive number")
 	}
 
-	ps, err := indexr.ExpandedPostings(ctx, matchers, stats)
-	if err != nil {
-		return nil, errors.Wrap(err, "expanded matching postings")
+	var postingsSets *postingsSetsIterator
+	if lazyPostings {
+		p, err := indexr.LazyPostings(ctx, matchers, stats)
+		if err != nil {
+			return nil, errors.Wrap(err, "matching postings")
+		}
+		postingsSets = newLazyPostingsSetsIterator(p, batchSize)
+	} else {
+		ps, err := indexr.ExpandedPostings(ctx, matchers, stats)
+		if err != nil {
+			return nil, errors.Wrap(err, "expanded matching postings")
+		}
+		postingsSets = newPostingsSetsIterator(ps, batchSize)
 	}
 
 	var iterator seriesChunkRefsSetIterator
 	iterator = newLoadingSeriesChunkRefsSetIterator(
 		ctx,
-		newPostingsSetsIterator(ps, batchSize),
+		postingsSets,
 		indexr,
 		indexCache,
 		stats,
@@ -668,7 +681,9 @@ func openBlockSeriesChunkRefsSetsIterator(
 		tenantID,
 		logger,
 	)
-	iterator = newDurationMeasuringIterator[seriesChunkRefsSet](iterator, metrics.iteratorLoadDurations.WithLabelValues("series_load"))
+	iterator = newDurationMeasuringIterator[seriesChunkRefsSet](iterator, metrics.iteratorLoadDurations.WithLabelValues("series_load"), stats, func(stats *queryStats, d time.Duration) {
+		stats.streamingSeriesLoadDuration += d
+	})
 	iterator = newLimitingSeriesChunkRefsSetIterator(iterator, chunksLimiter, seriesLimiter)
 	return iterator, nil
 }
@@ -716,6 +731,9 @@ func (s *loadingSeriesChunkRefsSetIterator) Next() bool {
 		return false
 	}
 	if !s.postingsSetIterator.Next() {
+		if err := s.postingsSetIterator.Err(); err != nil {
+			s.err = errors.Wrap(err, "iterate postings")
+		}
 		return false
 	}
 	nextPostings := s.postingsSetIterator.At()
@@ -936,6 +954,44 @@ func (b cachedSeriesHasher) Hash(id storage.SeriesRef, lset labels.Labels, stats
 	return hash
 }
 
+// persistedSeriesHasher is a cachedSeriesHasher which also looks up and stores the hashes in the series
+// hashes persisted along with the index-header of the block.
+type persistedSeriesHasher struct {
+	cache     *hashcache.BlockSeriesHashCache
+	persisted *blockSeriesHashes
+}
+
+func (b persistedSeriesHasher) CachedHash(seriesID storage.SeriesRef, stats *queryStats) (uint64, bool) {
+	stats.seriesHashCacheRequests++
+	hash, isCached := b.cache.Fetch(seriesID)
+	if !isCached {
+		hash, isCached = b.persisted.fetch(seriesID)
+	}
+	if isCached {
+		stats.seriesHashCacheHits++
+	}
+	return hash, isCached
+}
+
+func (b persistedSeriesHasher) Hash(id storage.SeriesRef, lset labels.Labels, stats *queryStats) uint64 {
+	hash, ok := b.CachedHash(id, stats)
+	if !ok {
+		hash = lset.Hash()
+		b.cache.Store(id, hash)
+		b.persisted.store(id, hash)
+	}
+	return hash
+}
+
+// newSeriesHasher returns the seriesHasher of the block b, using the series hashes persisted along with
+// its index-header if any.
+func newSeriesHasher(b *bucketBlock, cache *hashcache.BlockSeriesHashCache) seriesHasher {
+	if b.seriesHashes != nil {
+		return persistedSeriesHasher{cache: cache, persisted: b.seriesHashes}
+	}
+	return cachedSeriesHasher{cache}
+}
+
 func shardOwned(shard *sharding.ShardSelector, hasher seriesHasher, id storage.SeriesRef, lset labels.Labels, stats *queryStats) bool {
 	if shard == nil {
 		return true
@@ -945,12 +1001,16 @@ func shardOwned(shard *sharding.ShardSelector, hasher seriesHasher, id storage.S
 	return hash%shard.ShardCount == shard.ShardIndex
 }
 
+// postingsSetsIterator iterates the postings in batches of batchSize. The postings are either already
+// expanded, or lazily read from an index.Postings while iterating.
 type postingsSetsIterator struct {
 	postings []storage.SeriesRef
+	lazy     index.Postings
 
 	batchSize               int
 	nextBatchPostingsOffset int
 	currentBatch            []storage.SeriesRef
+	err                     error
 }
 
 func newPostingsSetsIterator(postings []storage.SeriesRef, batchSize int) *postingsSetsIterator {
@@ -960,7 +1020,20 @@ func newPostingsSetsIterator(postings []storage.SeriesRef, batchSize int) *posti
 	}
 }
 
+// newLazyPostingsSetsIterator returns a postingsSetsIterator reading the postings from p only when
+// the next batch is requested.
+func newLazyPostingsSetsIterator(p index.Postings, batchSize int) *postingsSetsIterator {
+	return &postingsSetsIterator{
+		lazy:      p,
+		batchSize: batchSize,
+	}
+}
+
 func (s *postingsSetsIterator) Next() bool {
+	if s.lazy != nil {
+		return s.nextLazy()
+	}
+
 	if s.nextBatchPostingsOffset >= len(s.postings) {
 		return false
 	}
@@ -975,6 +1048,31 @@ func (s *postingsSetsIterator) Next() bool {
 	return true
 }
 
+func (s *postingsSetsIterator) nextLazy() bool {
+	if s.err != nil {
+		return false
+	}
+
+	// The batch is not reused, because the caller may retain it.
+	batch := make([]storage.SeriesRef, 0, s.batchSize)
+	for len(batch) < s.batchSize && s.lazy.Next() {
+		batch = append(batch, s.lazy.At())
+	}
+	if err := s.lazy.Err(); err != nil {
+		s.err = err
+		return false
+	}
+	if len(batch) == 0 {
+		return false
+	}
+	s.currentBatch = batch
+	return true
+}
+
+func (s *postingsSetsIterator) Err() error {
+	return s.err
+}
+
 func (s *postingsSetsIterator) At() []storage.SeriesRef {
 	return s.currentBatch
 }
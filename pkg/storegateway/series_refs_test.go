@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
@@ -1405,60 +1406,66 @@ func TestOpenBlockSeriesChunkRefsSetsIterator(t *testing.T) {
 		t.Run(testName, func(t *testing.T) {
 			t.Parallel()
 
-			var block = newTestBlock()
-			indexReader := block.indexReader()
-			defer indexReader.Close()
+			for _, lazyPostings := range []bool{false, true} {
+				lazyPostings := lazyPostings
+				t.Run(fmt.Sprintf("lazy postings: %t", lazyPostings), func(t *testing.T) {
+					var block = newTestBlock()
+					indexReader := block.indexReader()
+					defer indexReader.Close()
 
-			hashCache := hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache(block.meta.ULID.String())
+					hashCache := hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache(block.meta.ULID.String())
 
-			iterator, err := openBlockSeriesChunkRefsSetsIterator(
-				ctx,
-				testCase.batchSize,
-				"",
-				indexReader,
-				newInMemoryIndexCache(t),
-				block.meta,
-				[]*labels.Matcher{testCase.matcher},
-				nil,
-				cachedSeriesHasher{hashCache},
-				&limiter{limit: testCase.chunksLimit},
-				&limiter{limit: testCase.seriesLimit},
-				testCase.skipChunks,
-				block.meta.MinTime,
-				block.meta.MaxTime,
-				newSafeQueryStats(),
-				NewBucketStoreMetrics(prometheus.NewRegistry()),
-				nil,
-			)
-			require.NoError(t, err)
-
-			actualSeriesSets := readAllSeriesChunkRefsSet(iterator)
-
-			require.Lenf(t, actualSeriesSets, len(testCase.expectedSeries), "expected %d sets, but got %d", len(testCase.expectedSeries), len(actualSeriesSets))
-			for i, actualSeriesSet := range actualSeriesSets {
-				expectedSeriesSet := testCase.expectedSeries[i]
-				require.Equal(t, expectedSeriesSet.len(), actualSeriesSet.len(), i)
-				for j, actualSeries := range actualSeriesSet.series {
-					expectedSeries := testCase.expectedSeries[i].series[j]
-
-					actualLset := actualSeries.lset
-					expectedLset := expectedSeries.lset
-					assert.Truef(t, labels.Equal(actualLset, expectedLset), "%d, %d: expected labels %s got labels %s", i, j, expectedLset, actualLset)
+					iterator, err := openBlockSeriesChunkRefsSetsIterator(
+						ctx,
+						testCase.batchSize,
+						lazyPostings,
+						"",
+						indexReader,
+						newInMemoryIndexCache(t),
+						block.meta,
+						[]*labels.Matcher{testCase.matcher},
+						nil,
+						cachedSeriesHasher{hashCache},
+						&limiter{limit: testCase.chunksLimit},
+						&limiter{limit: testCase.seriesLimit},
+						testCase.skipChunks,
+						block.meta.MinTime,
+						block.meta.MaxTime,
+						newSafeQueryStats(),
+						NewBucketStoreMetrics(prometheus.NewRegistry()),
+						nil,
+					)
+					require.NoError(t, err)
 
-					require.Lenf(t, actualSeries.chunks, len(expectedSeries.chunks), "%d, %d", i, j)
-					for k, actualChunk := range actualSeries.chunks {
-						expectedChunk := expectedSeries.chunks[k]
-						assert.Equalf(t, block.meta.ULID, actualChunk.blockID, "%d, %d, %d", i, j, k)
-						assert.Equalf(t, int(expectedChunk.ref), int(actualChunk.ref), "%d, %d, %d", i, j, k)
-						assert.Equalf(t, expectedChunk.minTime, actualChunk.minTime, "%d, %d, %d", i, j, k)
-						assert.Equalf(t, expectedChunk.maxTime, actualChunk.maxTime, "%d, %d, %d", i, j, k)
+					actualSeriesSets := readAllSeriesChunkRefsSet(iterator)
+
+					require.Lenf(t, actualSeriesSets, len(testCase.expectedSeries), "expected %d sets, but got %d", len(testCase.expectedSeries), len(actualSeriesSets))
+					for i, actualSeriesSet := range actualSeriesSets {
+						expectedSeriesSet := testCase.expectedSeries[i]
+						require.Equal(t, expectedSeriesSet.len(), actualSeriesSet.len(), i)
+						for j, actualSeries := range actualSeriesSet.series {
+							expectedSeries := testCase.expectedSeries[i].series[j]
+
+							actualLset := actualSeries.lset
+							expectedLset := expectedSeries.lset
+							assert.Truef(t, labels.Equal(actualLset, expectedLset), "%d, %d: expected labels %s got labels %s", i, j, expectedLset, actualLset)
+
+							require.Lenf(t, actualSeries.chunks, len(expectedSeries.chunks), "%d, %d", i, j)
+							for k, actualChunk := range actualSeries.chunks {
+								expectedChunk := expectedSeries.chunks[k]
+								assert.Equalf(t, block.meta.ULID, actualChunk.blockID, "%d, %d, %d", i, j, k)
+								assert.Equalf(t, int(expectedChunk.ref), int(actualChunk.ref), "%d, %d, %d", i, j, k)
+								assert.Equalf(t, expectedChunk.minTime, actualChunk.minTime, "%d, %d, %d", i, j, k)
+								assert.Equalf(t, expectedChunk.maxTime, actualChunk.maxTime, "%d, %d, %d", i, j, k)
+							}
+						}
 					}
-				}
-			}
-			if testCase.expectedErr != "" {
-				assert.ErrorContains(t, iterator.Err(), "test limit exceeded")
-			} else {
-				assert.NoError(t, iterator.Err())
+					if testCase.expectedErr != "" {
+						assert.ErrorContains(t, iterator.Err(), "test limit exceeded")
+					} else {
+						assert.NoError(t, iterator.Err())
+					}
+				})
 			}
 		})
 	}
@@ -1655,6 +1662,7 @@ func TestOpenBlockSeriesChunkRefsSetsIterator_SeriesCaching(t *testing.T) {
 					ss, err := openBlockSeriesChunkRefsSetsIterator(
 						context.Background(),
 						batchSize,
+						false,
 						"",
 						indexReader,
 						b.indexCache,
@@ -1688,6 +1696,7 @@ func TestOpenBlockSeriesChunkRefsSetsIterator_SeriesCaching(t *testing.T) {
 					ss, err = openBlockSeriesChunkRefsSetsIterator(
 						context.Background(),
 						batchSize,
+						false,
 						"",
 						indexReader,
 						b.indexCache,
@@ -1771,18 +1780,40 @@ func TestPostingsSetsIterator(t *testing.T) {
 	for testName, testCase := range testCases {
 		testName, testCase := testName, testCase
 		t.Run(testName, func(t *testing.T) {
-			iterator := newPostingsSetsIterator(testCase.postings, testCase.batchSize)
+			t.Run("expanded", func(t *testing.T) {
+				iterator := newPostingsSetsIterator(testCase.postings, testCase.batchSize)
 
-			var actualBatches [][]storage.SeriesRef
-			for iterator.Next() {
-				actualBatches = append(actualBatches, iterator.At())
-			}
+				var actualBatches [][]storage.SeriesRef
+				for iterator.Next() {
+					actualBatches = append(actualBatches, iterator.At())
+				}
 
-			assert.ElementsMatch(t, testCase.expectedBatches, actualBatches)
+				assert.ElementsMatch(t, testCase.expectedBatches, actualBatches)
+				assert.NoError(t, iterator.Err())
+			})
+
+			t.Run("lazy", func(t *testing.T) {
+				iterator := newLazyPostingsSetsIterator(index.NewListPostings(testCase.postings), testCase.batchSize)
+
+				var actualBatches [][]storage.SeriesRef
+				for iterator.Next() {
+					actualBatches = append(actualBatches, iterator.At())
+				}
+
+				assert.ElementsMatch(t, testCase.expectedBatches, actualBatches)
+				assert.NoError(t, iterator.Err())
+			})
 		})
 	}
 }
 
+func TestPostingsSetsIterator_LazyPostingsError(t *testing.T) {
+	iterator := newLazyPostingsSetsIterator(index.ErrPostings(errors.New("postings error")), 2)
+
+	assert.False(t, iterator.Next())
+	assert.EqualError(t, iterator.Err(), "postings error")
+}
+
 type mockSeriesHasher struct {
 	cached map[storage.SeriesRef]uint64
 	hashes map[string]uint64
@@ -6,8 +6,13 @@
 package storegateway
 
 import (
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/oklog/ulid"
+
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 )
 
 // queryStats holds query statistics. This data structure is NOT concurrency safe.
@@ -48,12 +53,33 @@ type queryStats struct {
 	chunksFetchCount       int
 	chunksFetchDurationSum time.Duration
 
+	// chunksFetchedSizeSumByBlock is accumulated in place on merge, so it's cloned when exported
+	// from safeQueryStats, to not be shared with the copies of queryStats read outside the lock.
+	chunksFetchedSizeSumByBlock map[ulid.ULID]int
+
+	chunksCacheRequests int
+	chunksCacheHits     int
+
+	// chunksDeduplicated is the number of identical chunks of the same series, loaded from overlapping blocks,
+	// which haven't been returned because of the chunks deduplication.
+	chunksDeduplicated int
+
 	getAllDuration    time.Duration
 	mergedSeriesCount int
 	mergedChunksCount int
 	mergeDuration     time.Duration
 
 	expandedPostingsDuration time.Duration
+
+	streamingSeriesLoadDuration      time.Duration
+	streamingChunksLoadDuration      time.Duration
+	streamingChunksPreloadedDuration time.Duration
+
+	// The size, in bytes, of the memory borrowed from the pools to hold the series and chunks of the request:
+	// the series slices, the slabs of the series chunks slices and the slabs of the chunks data.
+	seriesPoolBorrowedSizeSum       int
+	seriesChunksPoolBorrowedSizeSum int
+	chunksPoolBorrowedSizeSum       int
 }
 
 func (s queryStats) merge(o *queryStats) *queryStats {
@@ -93,6 +119,20 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 	s.chunksFetchCount += o.chunksFetchCount
 	s.chunksFetchDurationSum += o.chunksFetchDurationSum
 
+	if len(o.chunksFetchedSizeSumByBlock) > 0 {
+		if s.chunksFetchedSizeSumByBlock == nil {
+			s.chunksFetchedSizeSumByBlock = make(map[ulid.ULID]int, len(o.chunksFetchedSizeSumByBlock))
+		}
+		for id, size := range o.chunksFetchedSizeSumByBlock {
+			s.chunksFetchedSizeSumByBlock[id] += size
+		}
+	}
+
+	s.chunksCacheRequests += o.chunksCacheRequests
+	s.chunksCacheHits += o.chunksCacheHits
+
+	s.chunksDeduplicated += o.chunksDeduplicated
+
 	s.getAllDuration += o.getAllDuration
 	s.mergedSeriesCount += o.mergedSeriesCount
 	s.mergedChunksCount += o.mergedChunksCount
@@ -100,9 +140,39 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 
 	s.expandedPostingsDuration += o.expandedPostingsDuration
 
+	s.streamingSeriesLoadDuration += o.streamingSeriesLoadDuration
+	s.streamingChunksLoadDuration += o.streamingChunksLoadDuration
+	s.streamingChunksPreloadedDuration += o.streamingChunksPreloadedDuration
+
+	s.seriesPoolBorrowedSizeSum += o.seriesPoolBorrowedSizeSum
+	s.seriesChunksPoolBorrowedSizeSum += o.seriesChunksPoolBorrowedSizeSum
+	s.chunksPoolBorrowedSizeSum += o.chunksPoolBorrowedSizeSum
+
 	return &s
 }
 
+// toHints returns the chunks loading statistics to return to the querier in the Series() response hints.
+func (s queryStats) toHints() *hintspb.QueryStats {
+	res := &hintspb.QueryStats{
+		ChunksTouched:           uint64(s.chunksTouched),
+		ChunksTouchedSizeBytes:  uint64(s.chunksTouchedSizeSum),
+		ChunksFetched:           uint64(s.chunksFetched),
+		ChunksFetchedSizeBytes:  uint64(s.chunksFetchedSizeSum),
+		ChunksCacheRequests:     uint64(s.chunksCacheRequests),
+		ChunksCacheHits:         uint64(s.chunksCacheHits),
+		SeriesLoadDuration:      s.streamingSeriesLoadDuration,
+		ChunksLoadDuration:      s.streamingChunksLoadDuration,
+		ChunksPreloadedDuration: s.streamingChunksPreloadedDuration,
+	}
+	for id, size := range s.chunksFetchedSizeSumByBlock {
+		res.Blocks = append(res.Blocks, hintspb.BlockQueryStats{Id: id.String(), ChunksFetchedSizeBytes: uint64(size)})
+	}
+	sort.Slice(res.Blocks, func(i, j int) bool {
+		return res.Blocks[i].Id < res.Blocks[j].Id
+	})
+	return res
+}
+
 // safeQueryStats wraps queryStats adding functions manipulate the statistics while holding a lock.
 type safeQueryStats struct {
 	unsafeStatsMx sync.Mutex
@@ -137,5 +207,11 @@ func (s *safeQueryStats) export() *queryStats {
 	defer s.unsafeStatsMx.Unlock()
 
 	copy := *s.unsafeStats
+	if s.unsafeStats.chunksFetchedSizeSumByBlock != nil {
+		copy.chunksFetchedSizeSumByBlock = make(map[ulid.ULID]int, len(s.unsafeStats.chunksFetchedSizeSumByBlock))
+		for id, size := range s.unsafeStats.chunksFetchedSizeSumByBlock {
+			copy.chunksFetchedSizeSumByBlock[id] = size
+		}
+	}
 	return &copy
 }
@@ -4,8 +4,12 @@ package storegateway
 
 import (
 	"testing"
+	"time"
 
+	"github.com/oklog/ulid"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 )
 
 func TestSafeQueryStats_merge(t *testing.T) {
@@ -44,3 +48,41 @@ func TestSafeQueryStats_export(t *testing.T) {
 	assert.Equal(t, 20, orig.unsafeStats.blocksQueried)
 	assert.Equal(t, 10, exported.blocksQueried)
 }
+
+func TestSafeQueryStats_ChunksFetchedSizeSumByBlock(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	stats := newSafeQueryStats()
+	stats.merge(&queryStats{chunksFetchedSizeSumByBlock: map[ulid.ULID]int{block1: 100}})
+	exported := stats.export()
+
+	// The exported statistics should not be modified by the following merges.
+	stats.merge(&queryStats{chunksFetchedSizeSumByBlock: map[ulid.ULID]int{block1: 10, block2: 20}})
+	assert.Equal(t, map[ulid.ULID]int{block1: 100}, exported.chunksFetchedSizeSumByBlock)
+	assert.Equal(t, map[ulid.ULID]int{block1: 110, block2: 20}, stats.export().chunksFetchedSizeSumByBlock)
+}
+
+func TestQueryStats_toHints(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	stats := newSafeQueryStats()
+	stats.merge(&queryStats{chunksFetched: 2, chunksFetchedSizeSum: 100, chunksFetchedSizeSumByBlock: map[ulid.ULID]int{block2: 100}})
+	stats.merge(&queryStats{chunksFetched: 1, chunksFetchedSizeSum: 50, chunksFetchedSizeSumByBlock: map[ulid.ULID]int{block1: 50}})
+	stats.merge(&queryStats{chunksFetched: 1, chunksFetchedSizeSum: 10, chunksFetchedSizeSumByBlock: map[ulid.ULID]int{block2: 10}})
+	stats.merge(&queryStats{chunksTouched: 6, chunksCacheRequests: 4, chunksCacheHits: 2, streamingChunksLoadDuration: time.Second})
+
+	assert.Equal(t, &hintspb.QueryStats{
+		ChunksTouched:          6,
+		ChunksFetched:          4,
+		ChunksFetchedSizeBytes: 160,
+		ChunksCacheRequests:    4,
+		ChunksCacheHits:        2,
+		Blocks: []hintspb.BlockQueryStats{
+			{Id: block1.String(), ChunksFetchedSizeBytes: 50},
+			{Id: block2.String(), ChunksFetchedSizeBytes: 110},
+		},
+		ChunksLoadDuration: time.Second,
+	}, stats.export().toHints())
+}
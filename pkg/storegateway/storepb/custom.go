@@ -24,6 +24,14 @@ func NewSeriesResponse(series *Series) *SeriesResponse {
 	}
 }
 
+func NewWarnSeriesResponse(warning string) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_Warning{
+			Warning: warning,
+		},
+	}
+}
+
 func NewHintsSeriesResponse(hints *types.Any) *SeriesResponse {
 	return &SeriesResponse{
 		Result: &SeriesResponse_Hints{
@@ -264,7 +272,18 @@ func (m AggrChunk) Compare(b AggrChunk) int {
 		return -1
 	}
 
-	return m.Raw.Compare(b.Raw)
+	if c := m.Raw.Compare(b.Raw); c != 0 {
+		return c
+	}
+
+	// Chunks of downsampled blocks have no raw data, but the aggregates of the samples.
+	aggrs := [][2]*Chunk{{m.Count, b.Count}, {m.Sum, b.Sum}, {m.Min, b.Min}, {m.Max, b.Max}, {m.Counter, b.Counter}}
+	for _, a := range aggrs {
+		if c := a[0].Compare(a[1]); c != 0 {
+			return c
+		}
+	}
+	return 0
 }
 
 // Compare returns positive 1 if chunk is smaller -1 if larger.
@@ -9,6 +9,7 @@ import (
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
 	types "github.com/gogo/protobuf/types"
+	mimirpb "github.com/grafana/mimir/pkg/mimirpb"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -396,6 +397,126 @@ func (m *LabelValuesResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_LabelValuesResponse proto.InternalMessageInfo
 
+type ExemplarsRequest struct {
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	/// matchers is a list of label matchers sets. Exemplars of series matching any of the sets are returned.
+	Matchers []ExemplarMatchers `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers"`
+	// hints is an opaque data structure that can be used to carry additional information.
+	// The content of this field and whether it's supported depends on the
+	// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,4,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *ExemplarsRequest) Reset()      { *m = ExemplarsRequest{} }
+func (*ExemplarsRequest) ProtoMessage() {}
+func (*ExemplarsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+}
+func (m *ExemplarsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsRequest.Merge(m, src)
+}
+func (m *ExemplarsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsRequest proto.InternalMessageInfo
+
+type ExemplarMatchers struct {
+	Matchers []LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers"`
+}
+
+func (m *ExemplarMatchers) Reset()      { *m = ExemplarMatchers{} }
+func (*ExemplarMatchers) ProtoMessage() {}
+func (*ExemplarMatchers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+}
+func (m *ExemplarMatchers) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarMatchers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarMatchers.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarMatchers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarMatchers.Merge(m, src)
+}
+func (m *ExemplarMatchers) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarMatchers) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarMatchers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarMatchers proto.InternalMessageInfo
+
+type ExemplarsResponse struct {
+	Timeseries []mimirpb.TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries"`
+	Warnings   []string             `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	/// hints is an opaque data structure that can be used to carry additional information from
+	/// the store. The content of this field and whether it's supported depends on the
+	/// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,3,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *ExemplarsResponse) Reset()      { *m = ExemplarsResponse{} }
+func (*ExemplarsResponse) ProtoMessage() {}
+func (*ExemplarsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
+}
+func (m *ExemplarsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsResponse.Merge(m, src)
+}
+func (m *ExemplarsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
 	proto.RegisterType((*Stats)(nil), "thanos.Stats")
@@ -404,58 +525,68 @@ func init() {
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "thanos.LabelValuesRequest")
 	proto.RegisterType((*LabelValuesResponse)(nil), "thanos.LabelValuesResponse")
+	proto.RegisterType((*ExemplarsRequest)(nil), "thanos.ExemplarsRequest")
+	proto.RegisterType((*ExemplarMatchers)(nil), "thanos.ExemplarMatchers")
+	proto.RegisterType((*ExemplarsResponse)(nil), "thanos.ExemplarsResponse")
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 733 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0xcd, 0x6e, 0xd3, 0x4a,
-	0x14, 0xc7, 0x3d, 0xf1, 0x47, 0x9c, 0xc9, 0x4d, 0xe5, 0x4e, 0xd3, 0xca, 0xf5, 0x95, 0xdc, 0x28,
-	0xd2, 0x95, 0xa2, 0x2b, 0x48, 0x51, 0x90, 0x40, 0x2c, 0x9b, 0x4a, 0xa8, 0x58, 0xc0, 0xc2, 0x45,
-	0x20, 0xb1, 0x89, 0x9c, 0x64, 0x9a, 0x58, 0x4d, 0xc6, 0xc1, 0x33, 0xa6, 0xc9, 0x8e, 0x47, 0xe0,
-	0x31, 0x90, 0x58, 0xf3, 0x02, 0x2c, 0x50, 0x77, 0x74, 0xd9, 0x15, 0x22, 0xee, 0x86, 0x65, 0x1f,
-	0x01, 0xcd, 0x8c, 0xf3, 0x85, 0x82, 0x4a, 0x25, 0x76, 0x73, 0xfe, 0xff, 0xe3, 0x99, 0x73, 0x7e,
-	0xe7, 0x18, 0x16, 0xe2, 0x51, 0xa7, 0x3e, 0x8a, 0x23, 0x16, 0x21, 0x83, 0xf5, 0x03, 0x12, 0x51,
-	0xa7, 0xc8, 0x26, 0x23, 0x4c, 0xa5, 0xe8, 0xdc, 0xed, 0x85, 0xac, 0x9f, 0xb4, 0xeb, 0x9d, 0x68,
-	0xb8, 0xdf, 0x8b, 0x7a, 0xd1, 0xbe, 0x90, 0xdb, 0xc9, 0x89, 0x88, 0x44, 0x20, 0x4e, 0x59, 0xfa,
-	0x6e, 0x2f, 0x8a, 0x7a, 0x03, 0xbc, 0xc8, 0x0a, 0xc8, 0x44, 0x5a, 0xd5, 0x2f, 0x39, 0x58, 0x3a,
-	0xc6, 0x71, 0x88, 0xa9, 0x8f, 0xdf, 0x24, 0x98, 0x32, 0xb4, 0x0b, 0xcd, 0x61, 0x48, 0x5a, 0x2c,
-	0x1c, 0x62, 0x1b, 0x54, 0x40, 0x4d, 0xf5, 0xf3, 0xc3, 0x90, 0xbc, 0x08, 0x87, 0x58, 0x58, 0xc1,
-	0x58, 0x5a, 0xb9, 0xcc, 0x0a, 0xc6, 0xc2, 0x7a, 0xc0, 0x2d, 0xd6, 0xe9, 0xe3, 0x98, 0xda, 0x6a,
-	0x45, 0xad, 0x15, 0x1b, 0xe5, 0xba, 0xac, 0xbc, 0xfe, 0x34, 0x68, 0xe3, 0xc1, 0x33, 0x69, 0x36,
-	0xb5, 0xf3, 0x6f, 0x7b, 0x8a, 0x3f, 0xcf, 0x45, 0x0d, 0xb8, 0xcd, 0xaf, 0x8c, 0x31, 0x8d, 0x06,
-	0x09, 0x0b, 0x23, 0xd2, 0x3a, 0x0b, 0x49, 0x37, 0x3a, 0xb3, 0x35, 0x71, 0xff, 0xd6, 0x30, 0x18,
-	0xfb, 0x73, 0xef, 0x95, 0xb0, 0xd0, 0x1e, 0x2c, 0xd2, 0xd3, 0x70, 0xd4, 0xea, 0xf4, 0x13, 0x72,
-	0x4a, 0x6d, 0xb3, 0x02, 0x6a, 0xa6, 0x0f, 0xb9, 0x74, 0x28, 0x14, 0xf4, 0x3f, 0xd4, 0xfb, 0x21,
-	0x61, 0xd4, 0x2e, 0x54, 0x80, 0xa8, 0x44, 0xf6, 0x5f, 0x9f, 0xf5, 0x5f, 0x3f, 0x20, 0x13, 0x5f,
-	0xa6, 0x20, 0x04, 0x35, 0xca, 0xf0, 0xc8, 0x86, 0xe2, 0x3d, 0x71, 0x46, 0x65, 0xa8, 0xc7, 0x01,
-	0xe9, 0x61, 0xbb, 0x28, 0x44, 0x19, 0x78, 0x9a, 0xa9, 0x5b, 0x86, 0xa7, 0x99, 0x86, 0x95, 0xf7,
-	0x34, 0x33, 0x6f, 0x99, 0x9e, 0x66, 0xfe, 0x63, 0x95, 0x3c, 0xcd, 0x2c, 0x59, 0x1b, 0xd5, 0x87,
-	0x50, 0x3f, 0x66, 0x01, 0xa3, 0xa8, 0x0e, 0xb7, 0x4e, 0x30, 0xef, 0xae, 0xdb, 0x0a, 0x49, 0x17,
-	0x8f, 0x5b, 0xed, 0x09, 0xc3, 0x54, 0xa0, 0xd4, 0xfc, 0xcd, 0xcc, 0x7a, 0xc2, 0x9d, 0x26, 0x37,
-	0xaa, 0x9f, 0x00, 0xdc, 0x98, 0x4d, 0x80, 0x8e, 0x22, 0x42, 0x31, 0xaa, 0x41, 0x83, 0x0a, 0x45,
-	0x7c, 0x55, 0x6c, 0x6c, 0xcc, 0x50, 0xca, 0xbc, 0x23, 0xc5, 0xcf, 0x7c, 0xe4, 0xc0, 0xfc, 0x59,
-	0x10, 0x93, 0x90, 0xf4, 0xc4, 0x40, 0x0a, 0x47, 0x8a, 0x3f, 0x13, 0xd0, 0x9d, 0x19, 0x05, 0xf5,
-	0xf7, 0x14, 0x8e, 0x94, 0x19, 0x87, 0xff, 0xa0, 0x4e, 0x79, 0xfd, 0x02, 0x7c, 0xb1, 0x51, 0x9a,
-	0x3f, 0xc9, 0x45, 0x9e, 0x26, 0xdc, 0xa6, 0x09, 0x8d, 0x18, 0xd3, 0x64, 0xc0, 0xaa, 0x1f, 0x01,
-	0xdc, 0x14, 0xa3, 0x7d, 0x1e, 0x0c, 0x17, 0xdb, 0x53, 0x16, 0xd7, 0xc4, 0x4c, 0x3c, 0xaa, 0xfa,
-	0x32, 0x40, 0x16, 0x54, 0x31, 0xe9, 0x66, 0x33, 0xe5, 0xc7, 0xc5, 0x88, 0xf4, 0x9b, 0x47, 0xb4,
-	0xbc, 0x5b, 0xc6, 0x9f, 0xef, 0x96, 0xa7, 0x99, 0xc0, 0xca, 0x79, 0x9a, 0x99, 0xb3, 0xd4, 0x6a,
-	0x0c, 0xd1, 0x72, 0xb1, 0x19, 0xe8, 0x32, 0xd4, 0x09, 0x17, 0x6c, 0x50, 0x51, 0x6b, 0x05, 0x5f,
-	0x06, 0xc8, 0x81, 0x66, 0xc6, 0x90, 0xda, 0x39, 0x61, 0xcc, 0xe3, 0x45, 0xdd, 0xea, 0x8d, 0x75,
-	0x57, 0x3f, 0x83, 0xec, 0xd1, 0x97, 0xc1, 0x20, 0x59, 0x41, 0x34, 0xe0, 0xaa, 0x18, 0x6e, 0xc1,
-	0x97, 0xc1, 0x02, 0x9c, 0xb6, 0x06, 0x9c, 0xbe, 0x06, 0x9c, 0x71, 0x3b, 0x70, 0xf9, 0x5b, 0x81,
-	0xcb, 0x59, 0xaa, 0xa7, 0x99, 0xaa, 0xa5, 0x55, 0x13, 0xb8, 0xb5, 0xd2, 0x43, 0x46, 0x6e, 0x07,
-	0x1a, 0x6f, 0x85, 0x92, 0xa1, 0xcb, 0xa2, 0xbf, 0xc5, 0xae, 0xf1, 0x15, 0xf0, 0xff, 0x29, 0x8a,
-	0x31, 0x7a, 0x04, 0x0d, 0xb9, 0xf6, 0x68, 0x7b, 0xf5, 0x37, 0xc8, 0x78, 0x3a, 0x3b, 0xbf, 0xca,
-	0xb2, 0xc4, 0x7b, 0x00, 0x1d, 0x42, 0xb8, 0x18, 0x3a, 0xda, 0x5d, 0xe9, 0x7d, 0x79, 0x6b, 0x1d,
-	0x67, 0x9d, 0x95, 0x75, 0xfa, 0x18, 0x16, 0x97, 0x00, 0xa0, 0xd5, 0xd4, 0x95, 0xc9, 0x3a, 0xff,
-	0xae, 0xf5, 0xe4, 0x3d, 0xcd, 0x83, 0xf3, 0xa9, 0xab, 0x5c, 0x4c, 0x5d, 0xe5, 0x72, 0xea, 0x2a,
-	0xd7, 0x53, 0x17, 0xbc, 0x4b, 0x5d, 0xf0, 0x21, 0x75, 0xc1, 0x79, 0xea, 0x82, 0x8b, 0xd4, 0x05,
-	0xdf, 0x53, 0x17, 0xfc, 0x48, 0x5d, 0xe5, 0x3a, 0x75, 0xc1, 0xfb, 0x2b, 0x57, 0xb9, 0xb8, 0x72,
-	0x95, 0xcb, 0x2b, 0x57, 0x79, 0x9d, 0xa7, 0x1c, 0xc4, 0xa8, 0xdd, 0x36, 0x04, 0xa9, 0xfb, 0x3f,
-	0x03, 0x00, 0x00, 0xff, 0xff, 0x0d, 0xf8, 0xe7, 0x0a, 0x1f, 0x06, 0x00, 0x00,
+	// 844 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x94, 0xcf, 0x6f, 0xe3, 0x44,
+	0x14, 0xc7, 0x3d, 0xb1, 0xe3, 0x38, 0x2f, 0xb4, 0x72, 0xa7, 0xdd, 0x95, 0x1b, 0x24, 0x6f, 0x14,
+	0x09, 0x29, 0x42, 0x90, 0xae, 0x82, 0x04, 0x62, 0x6f, 0xdb, 0x15, 0xa8, 0x58, 0xc0, 0xc1, 0x8b,
+	0x40, 0xe2, 0x12, 0x39, 0xe9, 0xd4, 0xb1, 0x1a, 0x8f, 0x8d, 0x67, 0x4c, 0xd3, 0x1b, 0x7f, 0x02,
+	0x37, 0xee, 0x9c, 0x90, 0x38, 0xf3, 0x0f, 0x70, 0x40, 0xbd, 0xd1, 0xe3, 0x9e, 0x10, 0x49, 0x2f,
+	0x1c, 0xf7, 0x4f, 0x40, 0xf3, 0xc3, 0xf9, 0xb1, 0xcd, 0xaa, 0xbb, 0x52, 0x4f, 0x99, 0xf7, 0xbe,
+	0x2f, 0x6f, 0xde, 0xfb, 0xbc, 0xf1, 0x83, 0x66, 0x91, 0x8f, 0xfb, 0x79, 0x91, 0xf1, 0x0c, 0xdb,
+	0x7c, 0x12, 0xd1, 0x8c, 0xb5, 0x5b, 0xfc, 0x32, 0x27, 0x4c, 0x39, 0xdb, 0x1f, 0xc6, 0x09, 0x9f,
+	0x94, 0xa3, 0xfe, 0x38, 0x4b, 0x8f, 0xe2, 0x2c, 0xce, 0x8e, 0xa4, 0x7b, 0x54, 0x9e, 0x49, 0x4b,
+	0x1a, 0xf2, 0xa4, 0xc3, 0x0f, 0xe3, 0x2c, 0x8b, 0xa7, 0x64, 0x15, 0x15, 0xd1, 0x4b, 0x2d, 0x3d,
+	0x5e, 0xcf, 0x54, 0x44, 0x67, 0x11, 0x8d, 0x8e, 0xd2, 0x24, 0x4d, 0x8a, 0xa3, 0xfc, 0x3c, 0x56,
+	0xa7, 0x7c, 0xa4, 0x7e, 0xd5, 0x3f, 0xba, 0x7f, 0xd5, 0x60, 0xe7, 0x39, 0x29, 0x12, 0xc2, 0x42,
+	0xf2, 0x43, 0x49, 0x18, 0xc7, 0x87, 0xe0, 0xa4, 0x09, 0x1d, 0xf2, 0x24, 0x25, 0x1e, 0xea, 0xa0,
+	0x9e, 0x19, 0x36, 0xd2, 0x84, 0x7e, 0x93, 0xa4, 0x44, 0x4a, 0xd1, 0x4c, 0x49, 0x35, 0x2d, 0x45,
+	0x33, 0x29, 0x7d, 0x2c, 0x24, 0x3e, 0x9e, 0x90, 0x82, 0x79, 0x66, 0xc7, 0xec, 0xb5, 0x06, 0x07,
+	0x7d, 0xd5, 0x6b, 0xff, 0xcb, 0x68, 0x44, 0xa6, 0x5f, 0x29, 0xf1, 0xd8, 0xba, 0xfa, 0xe7, 0x91,
+	0x11, 0x2e, 0x63, 0xf1, 0x00, 0x1e, 0x88, 0x94, 0x05, 0x61, 0xd9, 0xb4, 0xe4, 0x49, 0x46, 0x87,
+	0x17, 0x09, 0x3d, 0xcd, 0x2e, 0x3c, 0x4b, 0xe6, 0xdf, 0x4f, 0xa3, 0x59, 0xb8, 0xd4, 0xbe, 0x93,
+	0x12, 0x7e, 0x04, 0x2d, 0x76, 0x9e, 0xe4, 0xc3, 0xf1, 0xa4, 0xa4, 0xe7, 0xcc, 0x73, 0x3a, 0xa8,
+	0xe7, 0x84, 0x20, 0x5c, 0xcf, 0xa4, 0x07, 0xbf, 0x0f, 0xf5, 0x49, 0x42, 0x39, 0xf3, 0x9a, 0x1d,
+	0x24, 0x2b, 0x51, 0xc4, 0xfa, 0x15, 0xb1, 0xfe, 0x53, 0x7a, 0x19, 0xaa, 0x10, 0x8c, 0xc1, 0x62,
+	0x9c, 0xe4, 0x1e, 0xc8, 0xfb, 0xe4, 0x19, 0x1f, 0x40, 0xbd, 0x88, 0x68, 0x4c, 0xbc, 0x96, 0x74,
+	0x2a, 0x23, 0xb0, 0x9c, 0xba, 0x6b, 0x07, 0x96, 0x63, 0xbb, 0x8d, 0xc0, 0x72, 0x1a, 0xae, 0x13,
+	0x58, 0xce, 0x3b, 0xee, 0x4e, 0x60, 0x39, 0x3b, 0xee, 0x6e, 0xf7, 0x13, 0xa8, 0x3f, 0xe7, 0x11,
+	0x67, 0xb8, 0x0f, 0xfb, 0x67, 0x44, 0x74, 0x77, 0x3a, 0x4c, 0xe8, 0x29, 0x99, 0x0d, 0x47, 0x97,
+	0x9c, 0x30, 0x89, 0xd2, 0x0a, 0xf7, 0xb4, 0xf4, 0x85, 0x50, 0x8e, 0x85, 0xd0, 0xfd, 0x03, 0xc1,
+	0x6e, 0x35, 0x01, 0x96, 0x67, 0x94, 0x11, 0xdc, 0x03, 0x9b, 0x49, 0x8f, 0xfc, 0x57, 0x6b, 0xb0,
+	0x5b, 0xa1, 0x54, 0x71, 0x27, 0x46, 0xa8, 0x75, 0xdc, 0x86, 0xc6, 0x45, 0x54, 0xd0, 0x84, 0xc6,
+	0x72, 0x20, 0xcd, 0x13, 0x23, 0xac, 0x1c, 0xf8, 0x83, 0x8a, 0x82, 0xf9, 0x7a, 0x0a, 0x27, 0x46,
+	0xc5, 0xe1, 0x3d, 0xa8, 0x33, 0x51, 0xbf, 0x04, 0xdf, 0x1a, 0xec, 0x2c, 0xaf, 0x14, 0x4e, 0x11,
+	0x26, 0xd5, 0x63, 0x07, 0xec, 0x82, 0xb0, 0x72, 0xca, 0xbb, 0xbf, 0x23, 0xd8, 0x93, 0xa3, 0xfd,
+	0x3a, 0x4a, 0x57, 0xaf, 0xe7, 0x40, 0xa6, 0x29, 0xb8, 0xbc, 0xd4, 0x0c, 0x95, 0x81, 0x5d, 0x30,
+	0x09, 0x3d, 0xd5, 0x33, 0x15, 0xc7, 0xd5, 0x88, 0xea, 0x77, 0x8f, 0x68, 0xfd, 0x6d, 0xd9, 0x6f,
+	0xfe, 0xb6, 0x02, 0xcb, 0x41, 0x6e, 0x2d, 0xb0, 0x9c, 0x9a, 0x6b, 0x76, 0x0b, 0xc0, 0xeb, 0xc5,
+	0x6a, 0xd0, 0x07, 0x50, 0xa7, 0xc2, 0xe1, 0xa1, 0x8e, 0xd9, 0x6b, 0x86, 0xca, 0xc0, 0x6d, 0x70,
+	0x34, 0x43, 0xe6, 0xd5, 0xa4, 0xb0, 0xb4, 0x57, 0x75, 0x9b, 0x77, 0xd6, 0xdd, 0xfd, 0x13, 0xe9,
+	0x4b, 0xbf, 0x8d, 0xa6, 0xe5, 0x06, 0xa2, 0xa9, 0xf0, 0xca, 0xe1, 0x36, 0x43, 0x65, 0xac, 0xc0,
+	0x59, 0x5b, 0xc0, 0xd5, 0xb7, 0x80, 0xb3, 0xdf, 0x0e, 0x5c, 0xe3, 0xad, 0xc0, 0xd5, 0x5c, 0x33,
+	0xb0, 0x1c, 0xd3, 0xb5, 0xba, 0x25, 0xec, 0x6f, 0xf4, 0xa0, 0xc9, 0x3d, 0x04, 0xfb, 0x47, 0xe9,
+	0xd1, 0xe8, 0xb4, 0x75, 0x6f, 0xec, 0x7e, 0x45, 0xe0, 0x7e, 0x36, 0x23, 0x69, 0x3e, 0x8d, 0x8a,
+	0xdb, 0x8f, 0x0b, 0x6d, 0x61, 0x54, 0x5b, 0x31, 0x7a, 0x72, 0x6b, 0x19, 0x79, 0x55, 0xdf, 0x55,
+	0x4e, 0xdd, 0x3a, 0xbb, 0xb5, 0x90, 0x96, 0x45, 0x5a, 0x77, 0x17, 0x19, 0x80, 0xfb, 0x6a, 0xbe,
+	0x0d, 0xe6, 0xe8, 0xcd, 0x99, 0x77, 0x7f, 0x41, 0xb0, 0xb7, 0xd6, 0xb0, 0xc6, 0xfc, 0x04, 0x40,
+	0x6c, 0xdb, 0xe5, 0x36, 0x50, 0xf9, 0xc6, 0x59, 0xc1, 0xc9, 0x2c, 0x1f, 0xf5, 0xc5, 0xea, 0x55,
+	0x3b, 0x41, 0xe7, 0x5b, 0x8b, 0xbe, 0xaf, 0x51, 0x0c, 0xfe, 0x46, 0x62, 0xb5, 0x65, 0x05, 0xc1,
+	0x9f, 0x82, 0xad, 0x6e, 0xc3, 0x0f, 0x36, 0x37, 0x92, 0x1e, 0x50, 0xfb, 0xe1, 0xab, 0x6e, 0xd5,
+	0xc6, 0x63, 0x84, 0x9f, 0x01, 0xac, 0xbe, 0x3f, 0x7c, 0xb8, 0x81, 0x64, 0x7d, 0x81, 0xb4, 0xdb,
+	0xdb, 0x24, 0x4d, 0xe3, 0x73, 0x68, 0xad, 0xbd, 0x45, 0xbc, 0x19, 0xba, 0xf1, 0x91, 0xb5, 0xdf,
+	0xdd, 0xaa, 0xa9, 0x3c, 0xc7, 0x4f, 0xaf, 0xe6, 0xbe, 0x71, 0x3d, 0xf7, 0x8d, 0x17, 0x73, 0xdf,
+	0x78, 0x39, 0xf7, 0xd1, 0x4f, 0x0b, 0x1f, 0xfd, 0xb6, 0xf0, 0xd1, 0xd5, 0xc2, 0x47, 0xd7, 0x0b,
+	0x1f, 0xfd, 0xbb, 0xf0, 0xd1, 0x7f, 0x0b, 0xdf, 0x78, 0xb9, 0xf0, 0xd1, 0xcf, 0x37, 0xbe, 0x71,
+	0x7d, 0xe3, 0x1b, 0x2f, 0x6e, 0x7c, 0xe3, 0xfb, 0x06, 0x13, 0x20, 0xf2, 0xd1, 0xc8, 0x96, 0xa4,
+	0x3e, 0xfa, 0x7f, 0x00, 0x63, 0x95, 0x93, 0x8d, 0xdc, 0x07, 0x00, 0x00,
 }
 
 func (this *SeriesRequest) Equal(that interface{}) bool {
@@ -817,6 +948,113 @@ func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *ExemplarsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarsRequest)
+	if !ok {
+		that2, ok := that.(ExemplarsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
+}
+func (this *ExemplarMatchers) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarMatchers)
+	if !ok {
+		that2, ok := that.(ExemplarMatchers)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *ExemplarsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ExemplarsResponse)
+	if !ok {
+		that2, ok := that.(ExemplarsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Timeseries) != len(that1.Timeseries) {
+		return false
+	}
+	for i := range this.Timeseries {
+		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
+			return false
+		}
+	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
+}
 func (this *SeriesRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -967,6 +1205,63 @@ func (this *LabelValuesResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *ExemplarsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&storepb.ExemplarsRequest{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Matchers != nil {
+		vs := make([]*ExemplarMatchers, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarMatchers) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.ExemplarMatchers{")
+	if this.Matchers != nil {
+		vs := make([]*LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&storepb.ExemplarsResponse{")
+	if this.Timeseries != nil {
+		vs := make([]*mimirpb.TimeSeries, len(this.Timeseries))
+		for i := range vs {
+			vs[i] = &this.Timeseries[i]
+		}
+		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringRpc(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1626,33 +1921,187 @@ func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
-	offset -= sovRpc(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *ExemplarsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *SeriesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *ExemplarsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.MinTime != 0 {
-		n += 1 + sovRpc(uint64(m.MinTime))
-	}
-	if m.MaxTime != 0 {
-		n += 1 + sovRpc(uint64(m.MaxTime))
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
 	}
 	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovRpc(uint64(l))
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.End != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarMatchers) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarMatchers) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarMatchers) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Timeseries) > 0 {
+		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
+	offset -= sovRpc(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *SeriesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MinTime != 0 {
+		n += 1 + sovRpc(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovRpc(uint64(m.MaxTime))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
 		}
 	}
 	if m.MaxResolutionWindow != 0 {
@@ -1848,6 +2297,71 @@ func (m *LabelValuesResponse) Size() (n int) {
 	return n
 }
 
+func (m *ExemplarsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *ExemplarMatchers) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExemplarsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
 func sovRpc(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1997,6 +2511,56 @@ func (this *LabelValuesResponse) String() string {
 	}, "")
 	return s
 }
+func (this *ExemplarsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]ExemplarMatchers{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(strings.Replace(f.String(), "ExemplarMatchers", "ExemplarMatchers", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ExemplarsRequest{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarMatchers) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ExemplarMatchers{`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForTimeseries := "[]TimeSeries{"
+	for _, f := range this.Timeseries {
+		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForTimeseries += "}"
+	s := strings.Join([]string{`&ExemplarsResponse{`,
+		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringRpc(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -3165,6 +3729,409 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ExemplarsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, ExemplarMatchers{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarMatchers) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarMatchers: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarMatchers: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Timeseries = append(m.Timeseries, mimirpb.TimeSeries{})
+			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipRpc(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -152,6 +152,11 @@ type AggrChunk struct {
 	MinTime int64  `protobuf:"varint,1,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
 	MaxTime int64  `protobuf:"varint,2,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
 	Raw     *Chunk `protobuf:"bytes,3,opt,name=raw,proto3" json:"raw,omitempty"`
+	Count   *Chunk `protobuf:"bytes,4,opt,name=count,proto3" json:"count,omitempty"`
+	Sum     *Chunk `protobuf:"bytes,5,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min     *Chunk `protobuf:"bytes,6,opt,name=min,proto3" json:"min,omitempty"`
+	Max     *Chunk `protobuf:"bytes,7,opt,name=max,proto3" json:"max,omitempty"`
+	Counter *Chunk `protobuf:"bytes,8,opt,name=counter,proto3" json:"counter,omitempty"`
 }
 
 func (m *AggrChunk) Reset()      { *m = AggrChunk{} }
@@ -237,40 +242,41 @@ func init() {
 func init() { proto.RegisterFile("types.proto", fileDescriptor_d938547f84707355) }
 
 var fileDescriptor_d938547f84707355 = []byte{
-	// 515 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0x3d, 0x6f, 0xda, 0x40,
-	0x18, 0xf6, 0x81, 0x31, 0xe6, 0x92, 0xb4, 0xd7, 0x4b, 0x54, 0x41, 0x86, 0x03, 0x79, 0x42, 0x95,
-	0x62, 0x5a, 0x3a, 0x75, 0x84, 0x8a, 0x05, 0xf5, 0x2b, 0xd7, 0x0c, 0x55, 0x55, 0x09, 0x9d, 0xe1,
-	0x30, 0xa7, 0xe0, 0x0f, 0x9d, 0x8f, 0x96, 0x6c, 0xf9, 0x09, 0xfd, 0x0b, 0xdd, 0xfa, 0x47, 0x2a,
-	0x31, 0x32, 0x46, 0x1d, 0xa2, 0x62, 0x96, 0x8e, 0xf9, 0x09, 0x95, 0xcf, 0xd0, 0x82, 0xb2, 0x74,
-	0xf2, 0xf3, 0xde, 0xf3, 0x3c, 0xef, 0x3d, 0xef, 0xbd, 0x86, 0x07, 0xea, 0x2a, 0xe6, 0x89, 0x1b,
-	0xcb, 0x48, 0x45, 0xd8, 0x52, 0x13, 0x16, 0x46, 0xc9, 0xe9, 0x99, 0x2f, 0xd4, 0x64, 0xe6, 0xb9,
-	0xc3, 0x28, 0x68, 0xf9, 0x91, 0x1f, 0xb5, 0x34, 0xed, 0xcd, 0xc6, 0xba, 0xd2, 0x85, 0x46, 0xb9,
-	0xed, 0xf4, 0xe9, 0xae, 0x5c, 0xb2, 0x31, 0x0b, 0x59, 0x2b, 0x10, 0x81, 0x90, 0xad, 0xf8, 0xd2,
-	0xcf, 0x51, 0xec, 0xe5, 0xdf, 0xdc, 0xe1, 0x78, 0xb0, 0xf4, 0x72, 0x32, 0x0b, 0x2f, 0xf1, 0x13,
-	0x68, 0x66, 0x01, 0xaa, 0xa0, 0x01, 0x9a, 0x0f, 0xda, 0x8f, 0xdd, 0x3c, 0x80, 0xab, 0x49, 0xb7,
-	0x17, 0x0e, 0xa3, 0x91, 0x08, 0x7d, 0xaa, 0x35, 0x18, 0x43, 0x73, 0xc4, 0x14, 0xab, 0x16, 0x1a,
-	0xa0, 0x79, 0x48, 0x35, 0x76, 0x6a, 0xd0, 0xde, 0xaa, 0xf0, 0x11, 0xac, 0x68, 0xdf, 0xe0, 0xc3,
-	0x5b, 0x8a, 0x0c, 0xe7, 0x1b, 0x80, 0xd6, 0x7b, 0x2e, 0x05, 0x4f, 0xf0, 0x18, 0x5a, 0x53, 0xe6,
-	0xf1, 0x69, 0x52, 0x05, 0x8d, 0x62, 0xf3, 0xa0, 0x7d, 0xec, 0x0e, 0x23, 0xa9, 0xf8, 0x3c, 0xf6,
-	0xdc, 0x57, 0xd9, 0xf9, 0x3b, 0x26, 0x64, 0xf7, 0xc5, 0xe2, 0xb6, 0x6e, 0xfc, 0xbc, 0xad, 0x3f,
-	0xfb, 0x9f, 0x69, 0x72, 0x5f, 0x67, 0xc4, 0x62, 0xc5, 0x25, 0xdd, 0x74, 0xc7, 0x2d, 0x68, 0x0d,
-	0xb3, 0x04, 0x49, 0xb5, 0xa0, 0xef, 0x79, 0xb4, 0x9d, 0xa7, 0xe3, 0xfb, 0x52, 0x67, 0xeb, 0x9a,
-	0xd9, 0x2d, 0x74, 0x23, 0x73, 0xae, 0x01, 0xac, 0xfc, 0xe5, 0x70, 0x0d, 0xda, 0x81, 0x08, 0x07,
-	0x4a, 0x04, 0xf9, 0x83, 0x14, 0x69, 0x39, 0x10, 0xe1, 0x85, 0x08, 0xb8, 0xa6, 0xd8, 0x3c, 0xa7,
-	0x0a, 0x1b, 0x8a, 0xcd, 0x35, 0x55, 0x87, 0x45, 0xc9, 0xbe, 0x54, 0x8b, 0x0d, 0xd0, 0x3c, 0x68,
-	0x1f, 0xed, 0xbd, 0x20, 0xcd, 0x98, 0xbe, 0x69, 0x9b, 0xa8, 0xd4, 0x37, 0xed, 0x12, 0xb2, 0xfa,
-	0xa6, 0x6d, 0xa1, 0x72, 0xdf, 0xb4, 0xcb, 0xc8, 0xee, 0x9b, 0xb6, 0x8d, 0x2a, 0xce, 0x0f, 0x00,
-	0x0f, 0xf5, 0x30, 0xaf, 0x99, 0x1a, 0x4e, 0xb8, 0xc4, 0x67, 0x7b, 0x2b, 0xa9, 0x6d, 0x1b, 0xee,
-	0x6a, 0xdc, 0x8b, 0xab, 0x98, 0xff, 0xdb, 0x4a, 0xc8, 0x36, 0xa9, 0x2a, 0x54, 0x63, 0x7c, 0x02,
-	0x4b, 0x9f, 0xd9, 0x74, 0xc6, 0x75, 0xa8, 0x0a, 0xcd, 0x0b, 0xe7, 0x13, 0x34, 0x33, 0x1f, 0x3e,
-	0x86, 0x0f, 0x77, 0x9b, 0x0d, 0x7a, 0xe7, 0xc8, 0xc0, 0x27, 0x10, 0xed, 0x1d, 0xbe, 0xe9, 0x9d,
-	0x23, 0x70, 0x4f, 0x4a, 0x7b, 0xa8, 0x70, 0x5f, 0x4a, 0x7b, 0xa8, 0xd8, 0xed, 0x2c, 0x56, 0xc4,
-	0x58, 0xae, 0x88, 0x71, 0xb3, 0x22, 0xc6, 0xdd, 0x8a, 0x80, 0xeb, 0x94, 0x80, 0xef, 0x29, 0x01,
-	0x8b, 0x94, 0x80, 0x65, 0x4a, 0xc0, 0xaf, 0x94, 0x80, 0xdf, 0x29, 0x31, 0xee, 0x52, 0x02, 0xbe,
-	0xae, 0x89, 0xb1, 0x5c, 0x13, 0xe3, 0x66, 0x4d, 0x8c, 0x8f, 0xe5, 0x44, 0x45, 0x92, 0xc7, 0x9e,
-	0x67, 0xe9, 0x9f, 0xf3, 0xf9, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x9f, 0x9a, 0x16, 0x25, 0x14,
-	0x03, 0x00, 0x00,
+	// 542 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0xbd, 0x8e, 0xd3, 0x40,
+	0x10, 0xc7, 0xbd, 0xf9, 0x70, 0x92, 0xcd, 0x1d, 0x98, 0xbd, 0x13, 0x72, 0xae, 0xd8, 0x44, 0xa6,
+	0x20, 0x42, 0x3a, 0x07, 0x42, 0x45, 0x99, 0xa0, 0x74, 0x7c, 0x9d, 0xb9, 0x02, 0x21, 0xa4, 0x68,
+	0xed, 0x6c, 0x9c, 0xd5, 0xc5, 0x6b, 0x6b, 0xbd, 0x86, 0x5c, 0xc7, 0x23, 0x20, 0xf1, 0x04, 0x74,
+	0xbc, 0x08, 0x52, 0xca, 0x94, 0x27, 0x8a, 0x13, 0x71, 0x1a, 0xca, 0x7b, 0x04, 0xe4, 0x75, 0x02,
+	0x89, 0x2e, 0x05, 0x95, 0x67, 0xf6, 0xff, 0xfb, 0xcf, 0xcc, 0x8e, 0xd6, 0xb0, 0x2e, 0x2f, 0x23,
+	0x1a, 0xdb, 0x91, 0x08, 0x65, 0x88, 0x74, 0x39, 0x21, 0x3c, 0x8c, 0x4f, 0x4e, 0x7d, 0x26, 0x27,
+	0x89, 0x6b, 0x7b, 0x61, 0xd0, 0xf1, 0x43, 0x3f, 0xec, 0x28, 0xd9, 0x4d, 0xc6, 0x2a, 0x53, 0x89,
+	0x8a, 0x72, 0xdb, 0xc9, 0xe3, 0x6d, 0x5c, 0x90, 0x31, 0xe1, 0xa4, 0x13, 0xb0, 0x80, 0x89, 0x4e,
+	0x74, 0xe1, 0xe7, 0x51, 0xe4, 0xe6, 0xdf, 0xdc, 0x61, 0xb9, 0xb0, 0xfc, 0x7c, 0x92, 0xf0, 0x0b,
+	0xf4, 0x08, 0x96, 0xb2, 0x01, 0x4c, 0xd0, 0x02, 0xed, 0x3b, 0xdd, 0xfb, 0x76, 0x3e, 0x80, 0xad,
+	0x44, 0x7b, 0xc0, 0xbd, 0x70, 0xc4, 0xb8, 0xef, 0x28, 0x06, 0x21, 0x58, 0x1a, 0x11, 0x49, 0xcc,
+	0x42, 0x0b, 0xb4, 0x0f, 0x1c, 0x15, 0x5b, 0x0d, 0x58, 0xdd, 0x50, 0xe8, 0x10, 0xd6, 0x94, 0x6f,
+	0xf8, 0xee, 0xb5, 0x63, 0x68, 0xd6, 0x37, 0x00, 0xf5, 0xb7, 0x54, 0x30, 0x1a, 0xa3, 0x31, 0xd4,
+	0xa7, 0xc4, 0xa5, 0xd3, 0xd8, 0x04, 0xad, 0x62, 0xbb, 0xde, 0x3d, 0xb2, 0xbd, 0x50, 0x48, 0x3a,
+	0x8b, 0x5c, 0xfb, 0x45, 0x76, 0xfe, 0x86, 0x30, 0xd1, 0x7f, 0x36, 0xbf, 0x6e, 0x6a, 0x3f, 0xaf,
+	0x9b, 0x4f, 0xfe, 0xe7, 0x36, 0xb9, 0xaf, 0x37, 0x22, 0x91, 0xa4, 0xc2, 0x59, 0x57, 0x47, 0x1d,
+	0xa8, 0x7b, 0xd9, 0x04, 0xb1, 0x59, 0x50, 0x7d, 0xee, 0x6d, 0xee, 0xd3, 0xf3, 0x7d, 0xa1, 0x66,
+	0xeb, 0x97, 0xb2, 0x2e, 0xce, 0x1a, 0xb3, 0xbe, 0x16, 0x60, 0xed, 0xaf, 0x86, 0x1a, 0xb0, 0x1a,
+	0x30, 0x3e, 0x94, 0x2c, 0xc8, 0x17, 0x52, 0x74, 0x2a, 0x01, 0xe3, 0xe7, 0x2c, 0xa0, 0x4a, 0x22,
+	0xb3, 0x5c, 0x2a, 0xac, 0x25, 0x32, 0x53, 0x52, 0x13, 0x16, 0x05, 0xf9, 0x64, 0x16, 0x5b, 0xa0,
+	0x5d, 0xef, 0x1e, 0xee, 0x6c, 0xd0, 0xc9, 0x14, 0xf4, 0x00, 0x96, 0xbd, 0x30, 0xe1, 0xd2, 0x2c,
+	0xed, 0x43, 0x72, 0x2d, 0xab, 0x12, 0x27, 0x81, 0x59, 0xde, 0x5b, 0x25, 0x4e, 0x82, 0x0c, 0x08,
+	0x18, 0x37, 0xf5, 0xbd, 0x40, 0xc0, 0xb8, 0x02, 0xc8, 0xcc, 0xac, 0xec, 0x07, 0xc8, 0x0c, 0x3d,
+	0x84, 0x15, 0xd5, 0x8b, 0x0a, 0xb3, 0xba, 0x0f, 0xda, 0xa8, 0xd6, 0x0f, 0x00, 0x0f, 0xd4, 0x7e,
+	0x5f, 0x12, 0xe9, 0x4d, 0xa8, 0x40, 0xa7, 0x3b, 0xaf, 0xa4, 0xb1, 0xb1, 0x6d, 0x33, 0xf6, 0xf9,
+	0x65, 0x44, 0xff, 0x3d, 0x14, 0x4e, 0xd6, 0x8b, 0xaa, 0x39, 0x2a, 0x46, 0xc7, 0xb0, 0xfc, 0x91,
+	0x4c, 0x13, 0xaa, 0xf6, 0x54, 0x73, 0xf2, 0xc4, 0xfa, 0x00, 0x4b, 0x99, 0x0f, 0x1d, 0xc1, 0xbb,
+	0xdb, 0xc5, 0x86, 0x83, 0x33, 0x43, 0x43, 0xc7, 0xd0, 0xd8, 0x39, 0x7c, 0x35, 0x38, 0x33, 0xc0,
+	0x2d, 0xd4, 0x19, 0x18, 0x85, 0xdb, 0xa8, 0x33, 0x30, 0x8a, 0xfd, 0xde, 0x7c, 0x89, 0xb5, 0xc5,
+	0x12, 0x6b, 0x57, 0x4b, 0xac, 0xdd, 0x2c, 0x31, 0xf8, 0x9c, 0x62, 0xf0, 0x3d, 0xc5, 0x60, 0x9e,
+	0x62, 0xb0, 0x48, 0x31, 0xf8, 0x95, 0x62, 0xf0, 0x3b, 0xc5, 0xda, 0x4d, 0x8a, 0xc1, 0x97, 0x15,
+	0xd6, 0x16, 0x2b, 0xac, 0x5d, 0xad, 0xb0, 0xf6, 0xbe, 0x12, 0xcb, 0x50, 0xd0, 0xc8, 0x75, 0x75,
+	0xf5, 0xbf, 0x3c, 0xfd, 0x33, 0x00, 0x2b, 0x46, 0xb2, 0x4d, 0xa7, 0x03, 0x00, 0x00,
 }
 
 func (x Chunk_Encoding) String() string {
@@ -379,6 +385,21 @@ func (this *AggrChunk) Equal(that interface{}) bool {
 	if !this.Raw.Equal(that1.Raw) {
 		return false
 	}
+	if !this.Count.Equal(that1.Count) {
+		return false
+	}
+	if !this.Sum.Equal(that1.Sum) {
+		return false
+	}
+	if !this.Min.Equal(that1.Min) {
+		return false
+	}
+	if !this.Max.Equal(that1.Max) {
+		return false
+	}
+	if !this.Counter.Equal(that1.Counter) {
+		return false
+	}
 	return true
 }
 func (this *LabelMatcher) Equal(that interface{}) bool {
@@ -443,13 +464,28 @@ func (this *AggrChunk) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 12)
 	s = append(s, "&storepb.AggrChunk{")
 	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
 	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
 	if this.Raw != nil {
 		s = append(s, "Raw: "+fmt.Sprintf("%#v", this.Raw)+",\n")
 	}
+	if this.Count != nil {
+		s = append(s, "Count: "+fmt.Sprintf("%#v", this.Count)+",\n")
+	}
+	if this.Sum != nil {
+		s = append(s, "Sum: "+fmt.Sprintf("%#v", this.Sum)+",\n")
+	}
+	if this.Min != nil {
+		s = append(s, "Min: "+fmt.Sprintf("%#v", this.Min)+",\n")
+	}
+	if this.Max != nil {
+		s = append(s, "Max: "+fmt.Sprintf("%#v", this.Max)+",\n")
+	}
+	if this.Counter != nil {
+		s = append(s, "Counter: "+fmt.Sprintf("%#v", this.Counter)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -579,6 +615,66 @@ func (m *AggrChunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Counter != nil {
+		{
+			size, err := m.Counter.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.Max != nil {
+		{
+			size, err := m.Max.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.Min != nil {
+		{
+			size, err := m.Min.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Sum != nil {
+		{
+			size, err := m.Sum.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Count != nil {
+		{
+			size, err := m.Count.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
 	if m.Raw != nil {
 		{
 			size, err := m.Raw.MarshalToSizedBuffer(dAtA[:i])
@@ -710,6 +806,26 @@ func (m *AggrChunk) Size() (n int) {
 		l = m.Raw.Size()
 		n += 1 + l + sovTypes(uint64(l))
 	}
+	if m.Count != nil {
+		l = m.Count.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	if m.Sum != nil {
+		l = m.Sum.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	if m.Min != nil {
+		l = m.Min.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	if m.Max != nil {
+		l = m.Max.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	if m.Counter != nil {
+		l = m.Counter.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
 	return n
 }
 
@@ -774,6 +890,11 @@ func (this *AggrChunk) String() string {
 		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
 		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
 		`Raw:` + strings.Replace(this.Raw.String(), "Chunk", "Chunk", 1) + `,`,
+		`Count:` + strings.Replace(this.Count.String(), "Chunk", "Chunk", 1) + `,`,
+		`Sum:` + strings.Replace(this.Sum.String(), "Chunk", "Chunk", 1) + `,`,
+		`Min:` + strings.Replace(this.Min.String(), "Chunk", "Chunk", 1) + `,`,
+		`Max:` + strings.Replace(this.Max.String(), "Chunk", "Chunk", 1) + `,`,
+		`Counter:` + strings.Replace(this.Counter.String(), "Chunk", "Chunk", 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1128,6 +1249,186 @@ func (m *AggrChunk) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Count == nil {
+				m.Count = &Chunk{}
+			}
+			if err := m.Count.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sum", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sum == nil {
+				m.Sum = &Chunk{}
+			}
+			if err := m.Sum.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Min", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Min == nil {
+				m.Min = &Chunk{}
+			}
+			if err := m.Min.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Max", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Max == nil {
+				m.Max = &Chunk{}
+			}
+			if err := m.Max.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Counter", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Counter == nil {
+				m.Counter = &Chunk{}
+			}
+			if err := m.Counter.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
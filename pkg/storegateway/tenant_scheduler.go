@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/gate"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// tenantScheduler limits the number of concurrent queries across all tenants like a blocking gate,
+// but when the queries have to wait for their turn, the free slots are allocated to the tenants
+// in proportion to their weight, instead of in arrival order. This prevents a tenant running many
+// heavy queries from starving the other tenants.
+//
+// When a slot is released, it's allocated to the waiting tenant with the lowest number of in-flight
+// queries per unit of weight. The queries of each tenant are run in arrival order.
+type tenantScheduler struct {
+	maxConcurrent int
+	weight        func(userID string) int
+
+	mtx      sync.Mutex
+	inflight int
+	waiting  int
+	tenants  map[string]*schedulerTenant
+	// seq is the arrival sequence number of the last queued query, used to break ties between tenants.
+	seq uint64
+
+	concurrentMax   prometheus.Gauge
+	inflightQueries prometheus.Gauge
+	duration        prometheus.Histogram
+	tenantDuration  *prometheus.HistogramVec
+}
+
+type schedulerTenant struct {
+	inflight int
+	// queue holds the *schedulerWaiter of the queries waiting for their turn, in arrival order.
+	queue *list.List
+}
+
+type schedulerWaiter struct {
+	seq     uint64
+	ready   chan struct{}
+	granted bool
+}
+
+func newTenantScheduler(maxConcurrent int, weight func(userID string) int, reg prometheus.Registerer) *tenantScheduler {
+	s := &tenantScheduler{
+		maxConcurrent: maxConcurrent,
+		weight:        weight,
+		tenants:       map[string]*schedulerTenant{},
+
+		// The following metrics are the same exported by the gate used when the tenant fair scheduling is disabled.
+		concurrentMax: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "gate_queries_concurrent_max",
+			Help: "Number of maximum concurrent queries allowed.",
+		}),
+		inflightQueries: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "gate_queries_in_flight",
+			Help: "Number of queries that are currently in flight.",
+		}),
+		duration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "gate_duration_seconds",
+			Help:    "How many seconds it took for queries to wait at the gate.",
+			Buckets: []float64{0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120, 240, 360, 720},
+		}),
+		tenantDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "tenant_gate_duration_seconds",
+			Help:    "How many seconds it took for queries to wait at the gate, by tenant.",
+			Buckets: []float64{0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120, 240, 360, 720},
+		}, []string{"user"}),
+	}
+	s.concurrentMax.Set(float64(maxConcurrent))
+	return s
+}
+
+// tenantGate returns the gate.Gate through which the queries of the tenant get their turn.
+func (s *tenantScheduler) tenantGate(userID string) gate.Gate {
+	return &tenantSchedulerGate{scheduler: s, userID: userID}
+}
+
+// removeTenant removes the metrics of the tenant.
+func (s *tenantScheduler) removeTenant(userID string) {
+	s.tenantDuration.DeleteLabelValues(userID)
+}
+
+func (s *tenantScheduler) start(ctx context.Context, userID string) error {
+	begin := time.Now()
+	defer func() {
+		elapsed := time.Since(begin).Seconds()
+		s.duration.Observe(elapsed)
+		s.tenantDuration.WithLabelValues(userID).Observe(elapsed)
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	t := s.getOrCreateTenant(userID)
+
+	// Run the query straight away if there's a free slot and no other query is waiting for its turn.
+	if s.waiting == 0 && s.inflight < s.maxConcurrent {
+		s.acquire(t)
+		s.mtx.Unlock()
+		return nil
+	}
+
+	s.seq++
+	w := &schedulerWaiter{seq: s.seq, ready: make(chan struct{})}
+	elem := t.queue.PushBack(w)
+	s.waiting++
+	s.mtx.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		if w.granted {
+			// The slot has been allocated to this query in the meanwhile, so it has to be released.
+			s.release(userID)
+		} else {
+			t.queue.Remove(elem)
+			s.waiting--
+			s.cleanupTenant(userID, t)
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *tenantScheduler) done(userID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.release(userID)
+}
+
+func (s *tenantScheduler) getOrCreateTenant(userID string) *schedulerTenant {
+	t, ok := s.tenants[userID]
+	if !ok {
+		t = &schedulerTenant{queue: list.New()}
+		s.tenants[userID] = t
+	}
+	return t
+}
+
+func (s *tenantScheduler) acquire(t *schedulerTenant) {
+	t.inflight++
+	s.inflight++
+	s.inflightQueries.Inc()
+}
+
+// release releases the slot of a query of the tenant, and allocates the free slots to the waiting queries.
+// It must be called with the lock held.
+func (s *tenantScheduler) release(userID string) {
+	t, ok := s.tenants[userID]
+	if !ok || t.inflight == 0 {
+		panic("tenantScheduler.done: more operations done than started")
+	}
+
+	t.inflight--
+	s.inflight--
+	s.inflightQueries.Dec()
+	s.cleanupTenant(userID, t)
+
+	for s.waiting > 0 && s.inflight < s.maxConcurrent {
+		next := s.nextTenant()
+		w := next.queue.Remove(next.queue.Front()).(*schedulerWaiter)
+		s.waiting--
+		s.acquire(next)
+
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// nextTenant returns the waiting tenant with the lowest number of in-flight queries per unit of weight.
+// Ties are broken in favour of the tenant whose first waiting query arrived first.
+// It must be called with the lock held and at least one query waiting.
+func (s *tenantScheduler) nextTenant() *schedulerTenant {
+	var (
+		next       *schedulerTenant
+		nextWeight int
+		nextSeq    uint64
+	)
+
+	for userID, t := range s.tenants {
+		if t.queue.Len() == 0 {
+			continue
+		}
+
+		weight := s.weight(userID)
+		if weight <= 0 {
+			weight = 1
+		}
+		seq := t.queue.Front().Value.(*schedulerWaiter).seq
+
+		if next == nil {
+			next, nextWeight, nextSeq = t, weight, seq
+			continue
+		}
+
+		// Compare inflight/weight ratios without divisions.
+		lhs, rhs := t.inflight*nextWeight, next.inflight*weight
+		if lhs < rhs || (lhs == rhs && seq < nextSeq) {
+			next, nextWeight, nextSeq = t, weight, seq
+		}
+	}
+
+	return next
+}
+
+// cleanupTenant removes the tenant from the scheduler once it has no in-flight or waiting query.
+// It must be called with the lock held.
+func (s *tenantScheduler) cleanupTenant(userID string, t *schedulerTenant) {
+	if t.inflight == 0 && t.queue.Len() == 0 {
+		delete(s.tenants, userID)
+	}
+}
+
+// tenantSchedulerGate is the gate.Gate of a tenant.
+type tenantSchedulerGate struct {
+	scheduler *tenantScheduler
+	userID    string
+}
+
+// Start implements gate.Gate.
+func (g *tenantSchedulerGate) Start(ctx context.Context) error {
+	return g.scheduler.start(ctx, g.userID)
+}
+
+// Done implements gate.Gate.
+func (g *tenantSchedulerGate) Done() {
+	g.scheduler.done(g.userID)
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantScheduler_ShouldAllocateSlotsByWeight(t *testing.T) {
+	weights := map[string]int{"user-1": 1, "user-2": 2}
+	s := newTenantScheduler(3, func(userID string) int { return weights[userID] }, nil)
+	gate1, gate2 := s.tenantGate("user-1"), s.tenantGate("user-2")
+	ctx := context.Background()
+
+	// user-1 takes all the slots.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, gate1.Start(ctx))
+	}
+
+	// Both tenants queue some queries, user-1 first.
+	started := make(chan string, 10)
+	startAsync := func(userID string) {
+		g := s.tenantGate(userID)
+		waiting := waitingQueries(s, userID)
+		go func() {
+			if g.Start(ctx) == nil {
+				started <- userID
+			}
+		}()
+		require.Eventually(t, func() bool { return waitingQueries(s, userID) > waiting }, time.Second, time.Millisecond)
+	}
+	startAsync("user-1")
+	startAsync("user-1")
+	startAsync("user-2")
+	startAsync("user-2")
+
+	// The released slots go to user-2 until it gets its share: with weight 2 it's allowed twice the
+	// in-flight queries of user-1.
+	gate1.Done()
+	assert.Equal(t, "user-2", <-started)
+	gate1.Done()
+	assert.Equal(t, "user-2", <-started)
+
+	// user-2 has no more waiting queries, so the next slot goes to user-1.
+	gate2.Done()
+	assert.Equal(t, "user-1", <-started)
+
+	s.mtx.Lock()
+	assert.Equal(t, 3, s.inflight)
+	assert.Equal(t, 1, s.waiting)
+	s.mtx.Unlock()
+}
+
+func TestTenantScheduler_ShouldRunQueriesStraightAwayIfSlotsAreFree(t *testing.T) {
+	s := newTenantScheduler(2, func(string) int { return 1 }, nil)
+	g := s.tenantGate("user-1")
+
+	require.NoError(t, g.Start(context.Background()))
+	require.NoError(t, g.Start(context.Background()))
+	g.Done()
+	g.Done()
+
+	// Tenants without in-flight or waiting queries are removed.
+	s.mtx.Lock()
+	assert.Empty(t, s.tenants)
+	s.mtx.Unlock()
+}
+
+func TestTenantScheduler_ShouldRemoveCanceledWaitingQueries(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	s := newTenantScheduler(1, func(string) int { return 1 }, reg)
+	gate1, gate2 := s.tenantGate("user-1"), s.tenantGate("user-2")
+
+	require.NoError(t, gate1.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, gate2.Start(ctx), context.DeadlineExceeded)
+
+	s.mtx.Lock()
+	assert.Equal(t, 0, s.waiting)
+	assert.Len(t, s.tenants, 1)
+	s.mtx.Unlock()
+
+	// The released slot is free for the next query.
+	gate1.Done()
+	require.NoError(t, gate2.Start(context.Background()))
+	gate2.Done()
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP gate_queries_in_flight Number of queries that are currently in flight.
+		# TYPE gate_queries_in_flight gauge
+		gate_queries_in_flight 0
+	`), "gate_queries_in_flight"))
+	assert.Equal(t, 2, testutil.CollectAndCount(s.tenantDuration))
+
+	s.removeTenant("user-2")
+	assert.Equal(t, 1, testutil.CollectAndCount(s.tenantDuration))
+}
+
+// waitingQueries returns the number of queries of the tenant waiting for their turn.
+func waitingQueries(s *tenantScheduler, userID string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if tenant, ok := s.tenants[userID]; ok {
+		return tenant.queue.Len()
+	}
+	return 0
+}
@@ -0,0 +1,369 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package analyzeblock
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+)
+
+// Report is the result of the analysis of a block.
+type Report struct {
+	BlockID   ulid.ULID
+	NumSeries int
+	NumChunks int
+
+	// LabelNames are the label names with the highest number of series, sorted by number of series.
+	LabelNames []LabelNameStats
+
+	// LabelPairs are the label name and value pairs with the highest number of series, sorted by number of series.
+	LabelPairs []LabelPairStats
+
+	ChunkSizes ChunkSizeStats
+
+	// Churn compares the series of the block with the ones of the previous block.
+	// It's nil if the block hasn't been compared with a previous block.
+	Churn *ChurnStats
+}
+
+type LabelNameStats struct {
+	Name      string
+	NumSeries int
+	NumValues int
+}
+
+type LabelPairStats struct {
+	Name      string
+	Value     string
+	NumSeries int
+}
+
+// ChunkSizeStats is the distribution of the size of the chunks of a block, in bytes, as stored in the segment files.
+type ChunkSizeStats struct {
+	TotalBytes int64
+	Min        int64
+	P50        int64
+	P90        int64
+	P99        int64
+	Max        int64
+}
+
+type ChurnStats struct {
+	PreviousBlockID ulid.ULID
+
+	// AddedSeries is the number of series of the block which are not in the previous block.
+	AddedSeries int
+
+	// RemovedSeries is the number of series of the previous block which are not in the block.
+	RemovedSeries int
+}
+
+type Config struct {
+	// TopN is the max number of label names and label pairs reported. 0 to report all of them.
+	TopN int
+
+	// TempDir is the directory where the block indexes are downloaded to. The downloaded
+	// indexes are removed once done. If empty, the default directory for temporary files is used.
+	TempDir string
+}
+
+// Analyze downloads the index of the block blockID from the bkt, and reports the label names and pairs
+// contributing the most to the cardinality of the block and the distribution of the size of its chunks.
+// If previousBlockID is not zero, the series of the block are compared with the ones of the previous block
+// too. The bkt must be scoped to the tenant owning the blocks.
+func Analyze(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, blockID, previousBlockID ulid.ULID, cfg Config) (_ *Report, returnErr error) {
+	dir, err := os.MkdirTemp(cfg.TempDir, "analyzeblock")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil && returnErr == nil {
+			returnErr = err
+		}
+	}()
+
+	idx, err := openIndex(ctx, logger, bkt, blockID, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer idx.Close()
+
+	segmentSizes, err := readSegmentSizes(ctx, bkt, blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{BlockID: blockID}
+	if err := analyzeLabels(idx, cfg.TopN, report); err != nil {
+		return nil, errors.Wrap(err, "analyze labels")
+	}
+
+	seriesHashes, err := analyzeSeries(idx, segmentSizes, report)
+	if err != nil {
+		return nil, errors.Wrap(err, "analyze series")
+	}
+
+	if previousBlockID == (ulid.ULID{}) {
+		return report, nil
+	}
+
+	previousIdx, err := openIndex(ctx, logger, bkt, previousBlockID, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer previousIdx.Close()
+
+	report.Churn, err = compareSeries(previousIdx, previousBlockID, seriesHashes)
+	if err != nil {
+		return nil, errors.Wrap(err, "compare series with previous block")
+	}
+
+	return report, nil
+}
+
+// FindPreviousBlock returns the ID of the block ending the most recently before the start of the
+// block blockID. If more blocks end at the same time, the one covering the longest time range is returned.
+func FindPreviousBlock(metas map[ulid.ULID]*metadata.Meta, blockID ulid.ULID) (ulid.ULID, bool) {
+	meta, ok := metas[blockID]
+	if !ok {
+		return ulid.ULID{}, false
+	}
+
+	var previous *metadata.Meta
+	for _, m := range metas {
+		if m.ULID == blockID || m.MaxTime > meta.MinTime {
+			continue
+		}
+		if previous == nil || m.MaxTime > previous.MaxTime || (m.MaxTime == previous.MaxTime && m.MinTime < previous.MinTime) {
+			previous = m
+		}
+	}
+	if previous == nil {
+		return ulid.ULID{}, false
+	}
+	return previous.ULID, true
+}
+
+func openIndex(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, blockID ulid.ULID, dir string) (*index.Reader, error) {
+	dst := filepath.Join(dir, blockID.String()+"-"+block.IndexFilename)
+	if err := objstore.DownloadFile(ctx, logger, bkt, path.Join(blockID.String(), block.IndexFilename), dst); err != nil {
+		return nil, errors.Wrapf(err, "download index of block %s", blockID)
+	}
+
+	idx, err := index.NewFileReader(dst)
+	return idx, errors.Wrapf(err, "open index of block %s", blockID)
+}
+
+// readSegmentSizes returns the size of the chunks segment files of the block, by segment file sequence number.
+func readSegmentSizes(ctx context.Context, bkt objstore.BucketReader, blockID ulid.ULID) (map[int]int64, error) {
+	sizes := map[int]int64{}
+	err := bkt.Iter(ctx, path.Join(blockID.String(), block.ChunksDirname), func(name string) error {
+		// Segment files are named after their sequence number, starting from 1.
+		n, err := strconv.Atoi(path.Base(name))
+		if err != nil {
+			return nil
+		}
+
+		attrs, err := bkt.Attributes(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "read attributes of %s", name)
+		}
+		sizes[n-1] = attrs.Size
+		return nil
+	})
+	return sizes, errors.Wrapf(err, "list chunks of block %s", blockID)
+}
+
+func analyzeLabels(idx *index.Reader, topN int, report *Report) error {
+	names, err := idx.LabelNames()
+	if err != nil {
+		return err
+	}
+
+	var pairs []LabelPairStats
+	for _, name := range names {
+		values, err := idx.SortedLabelValues(name)
+		if err != nil {
+			return err
+		}
+
+		// The label names and values returned by the index reader are backed by the memory-mapped
+		// index file, so they're copied to be safely used once the index is closed.
+		name = strings.Clone(name)
+		nameStats := LabelNameStats{Name: name, NumValues: len(values)}
+		for _, value := range values {
+			p, err := idx.Postings(name, value)
+			if err != nil {
+				return err
+			}
+
+			numSeries := 0
+			for p.Next() {
+				numSeries++
+			}
+			if p.Err() != nil {
+				return p.Err()
+			}
+
+			// Each series has a single value for each label name.
+			nameStats.NumSeries += numSeries
+			pairs = append(pairs, LabelPairStats{Name: name, Value: strings.Clone(value), NumSeries: numSeries})
+
+			// Keep only the top pairs, to not hold all the pairs of the block in memory.
+			if topN > 0 && len(pairs) > 2*topN {
+				pairs = topLabelPairs(pairs, topN)
+			}
+		}
+
+		report.LabelNames = append(report.LabelNames, nameStats)
+	}
+
+	sort.SliceStable(report.LabelNames, func(i, j int) bool {
+		return report.LabelNames[i].NumSeries > report.LabelNames[j].NumSeries
+	})
+	if topN > 0 && len(report.LabelNames) > topN {
+		report.LabelNames = report.LabelNames[:topN]
+	}
+	report.LabelPairs = topLabelPairs(pairs, topN)
+
+	return nil
+}
+
+func topLabelPairs(pairs []LabelPairStats, topN int) []LabelPairStats {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].NumSeries > pairs[j].NumSeries
+	})
+	if topN > 0 && len(pairs) > topN {
+		pairs = pairs[:topN]
+	}
+	return pairs
+}
+
+// analyzeSeries counts the series and chunks of the index, computes the distribution of the size
+// of the chunks, and returns the hashes of the series labels.
+func analyzeSeries(idx *index.Reader, segmentSizes map[int]int64, report *Report) (map[uint64]struct{}, error) {
+	p, err := idx.Postings(index.AllPostingsKey())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		hashes = map[uint64]struct{}{}
+		lbls   labels.Labels
+		chks   []chunks.Meta
+
+		// Offsets of the chunks in the segment files, by segment file sequence number.
+		offsets = map[int][]int{}
+	)
+	for p.Next() {
+		if err := idx.Series(p.At(), &lbls, &chks); err != nil {
+			return nil, errors.Wrapf(err, "read series %d", p.At())
+		}
+
+		hashes[lbls.Hash()] = struct{}{}
+		for _, c := range chks {
+			seq, off := chunks.BlockChunkRef(c.Ref).Unpack()
+			offsets[seq] = append(offsets[seq], off)
+		}
+		report.NumSeries++
+		report.NumChunks += len(chks)
+	}
+	if p.Err() != nil {
+		return nil, p.Err()
+	}
+
+	// The size of a chunk is the distance from the next chunk in the same segment file,
+	// or from the end of the segment file for the last chunk.
+	sizes := make([]int64, 0, report.NumChunks)
+	for seq, segmentOffsets := range offsets {
+		segmentSize, ok := segmentSizes[seq]
+		if !ok {
+			return nil, errors.Errorf("missing chunks segment file %d", seq+1)
+		}
+
+		sort.Ints(segmentOffsets)
+		for i, off := range segmentOffsets {
+			end := segmentSize
+			if i+1 < len(segmentOffsets) {
+				end = int64(segmentOffsets[i+1])
+			}
+			sizes = append(sizes, end-int64(off))
+		}
+	}
+	report.ChunkSizes = chunkSizeStats(sizes)
+
+	return hashes, nil
+}
+
+func chunkSizeStats(sizes []int64) ChunkSizeStats {
+	if len(sizes) == 0 {
+		return ChunkSizeStats{}
+	}
+
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	percentile := func(p float64) int64 {
+		return sizes[int(p*float64(len(sizes)-1))]
+	}
+
+	stats := ChunkSizeStats{
+		Min: sizes[0],
+		P50: percentile(0.5),
+		P90: percentile(0.9),
+		P99: percentile(0.99),
+		Max: sizes[len(sizes)-1],
+	}
+	for _, s := range sizes {
+		stats.TotalBytes += s
+	}
+	return stats
+}
+
+// compareSeries compares the series of the previous block index with the series of the block, given
+// the hashes of their labels. The input hashes are modified.
+func compareSeries(previousIdx *index.Reader, previousBlockID ulid.ULID, hashes map[uint64]struct{}) (*ChurnStats, error) {
+	p, err := previousIdx.Postings(index.AllPostingsKey())
+	if err != nil {
+		return nil, err
+	}
+
+	churn := &ChurnStats{PreviousBlockID: previousBlockID}
+	var (
+		lbls labels.Labels
+		chks []chunks.Meta
+	)
+	for p.Next() {
+		if err := previousIdx.Series(p.At(), &lbls, &chks); err != nil {
+			return nil, errors.Wrapf(err, "read series %d", p.At())
+		}
+
+		h := lbls.Hash()
+		if _, ok := hashes[h]; ok {
+			// Remove the series found in both blocks, so that only the added ones are left.
+			delete(hashes, h)
+		} else {
+			churn.RemovedSeries++
+		}
+	}
+	if p.Err() != nil {
+		return nil, p.Err()
+	}
+
+	churn.AddedSeries = len(hashes)
+	return churn, nil
+}
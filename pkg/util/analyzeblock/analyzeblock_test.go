@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package analyzeblock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestAnalyze(t *testing.T) {
+	const userID = "user-1"
+
+	storageDir := t.TempDir()
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	previousMeta, err := testutil.GenerateBlockFromSpec(userID, filepath.Join(storageDir, userID), testutil.BlockSeriesSpecs{
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "0"), 0),
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "1"), 0),
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "2"), 0),
+		seriesSpec(labels.FromStrings(labels.MetricName, "old", "job", "a"), 0),
+	})
+	require.NoError(t, err)
+
+	meta, err := testutil.GenerateBlockFromSpec(userID, filepath.Join(storageDir, userID), testutil.BlockSeriesSpecs{
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "1"), 1000),
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "2"), 1000),
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "3"), 1000),
+		seriesSpec(labels.FromStrings(labels.MetricName, "up", "job", "a", "instance", "4"), 1000),
+		seriesSpec(labels.FromStrings(labels.MetricName, "http_requests", "job", "b"), 1000, 2000),
+	})
+	require.NoError(t, err)
+
+	t.Run("should find the previous block", func(t *testing.T) {
+		metas := map[ulid.ULID]*metadata.Meta{previousMeta.ULID: previousMeta, meta.ULID: meta}
+
+		previousID, ok := FindPreviousBlock(metas, meta.ULID)
+		require.True(t, ok)
+		assert.Equal(t, previousMeta.ULID, previousID)
+
+		_, ok = FindPreviousBlock(metas, previousMeta.ULID)
+		assert.False(t, ok)
+	})
+
+	t.Run("should analyze the block without comparing it with the previous block", func(t *testing.T) {
+		report, err := Analyze(context.Background(), log.NewNopLogger(), userBkt, meta.ULID, ulid.ULID{}, Config{TopN: 2, TempDir: t.TempDir()})
+		require.NoError(t, err)
+
+		assert.Equal(t, meta.ULID, report.BlockID)
+		assert.Equal(t, 5, report.NumSeries)
+		assert.Equal(t, 6, report.NumChunks)
+		assert.Equal(t, []LabelNameStats{
+			{Name: labels.MetricName, NumSeries: 5, NumValues: 2},
+			{Name: "job", NumSeries: 5, NumValues: 2},
+		}, report.LabelNames)
+		assert.Equal(t, []LabelPairStats{
+			{Name: labels.MetricName, Value: "up", NumSeries: 4},
+			{Name: "job", Value: "a", NumSeries: 4},
+		}, report.LabelPairs)
+		assert.Nil(t, report.Churn)
+
+		// The chunks fill the whole segment file, except its header.
+		segment, err := os.Stat(filepath.Join(storageDir, userID, meta.ULID.String(), "chunks", "000001"))
+		require.NoError(t, err)
+		assert.Equal(t, segment.Size()-chunks.SegmentHeaderSize, report.ChunkSizes.TotalBytes)
+		assert.Greater(t, report.ChunkSizes.Min, int64(0))
+		assert.LessOrEqual(t, report.ChunkSizes.Min, report.ChunkSizes.P50)
+		assert.LessOrEqual(t, report.ChunkSizes.P50, report.ChunkSizes.P90)
+		assert.LessOrEqual(t, report.ChunkSizes.P90, report.ChunkSizes.P99)
+		assert.LessOrEqual(t, report.ChunkSizes.P99, report.ChunkSizes.Max)
+	})
+
+	t.Run("should report all label names and pairs if top N is 0", func(t *testing.T) {
+		report, err := Analyze(context.Background(), log.NewNopLogger(), userBkt, meta.ULID, ulid.ULID{}, Config{TempDir: t.TempDir()})
+		require.NoError(t, err)
+
+		assert.Len(t, report.LabelNames, 3)
+		assert.Len(t, report.LabelPairs, 8)
+	})
+
+	t.Run("should compare the block with the previous block", func(t *testing.T) {
+		report, err := Analyze(context.Background(), log.NewNopLogger(), userBkt, meta.ULID, previousMeta.ULID, Config{TopN: 2, TempDir: t.TempDir()})
+		require.NoError(t, err)
+
+		assert.Equal(t, &ChurnStats{PreviousBlockID: previousMeta.ULID, AddedSeries: 3, RemovedSeries: 2}, report.Churn)
+	})
+}
+
+// seriesSpec returns the spec of a series with a chunk starting at each of the input timestamps.
+func seriesSpec(lbls labels.Labels, chunksMinTime ...int64) *testutil.BlockSeriesSpec {
+	spec := &testutil.BlockSeriesSpec{Labels: lbls}
+	for _, t := range chunksMinTime {
+		spec.Chunks = append(spec.Chunks, tsdbutil.ChunkFromSamples([]tsdbutil.Sample{sample{t: t, v: 1}, sample{t: t + 1, v: 2}}))
+	}
+	return spec
+}
+
+type sample struct {
+	t int64
+	v float64
+}
+
+func (s sample) T() int64   { return s.t }
+func (s sample) V() float64 { return s.v }
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package cachedebug records the cache keys fetched while serving sampled traced requests, so that
+// cache misses can be investigated without re-deriving the keys from the code.
+package cachedebug
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/grafana/dskit/cache"
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/weaveworks/common/tracing"
+)
+
+type contextKey int
+
+const collectorContextKey contextKey = 0
+
+// FetchedKey is a cache key fetched while serving a request.
+type FetchedKey struct {
+	Cache string
+	Key   string
+	Hit   bool
+}
+
+// String returns the key in the format <cache>:<key>:<hit|miss>.
+func (k FetchedKey) String() string {
+	result := "miss"
+	if k.Hit {
+		result = "hit"
+	}
+	return k.Cache + ":" + k.Key + ":" + result
+}
+
+// Collector collects the cache keys fetched while serving a request. It's safe for concurrent use.
+type Collector struct {
+	mtx  sync.Mutex
+	keys []FetchedKey
+}
+
+func (c *Collector) add(keys ...FetchedKey) {
+	c.mtx.Lock()
+	c.keys = append(c.keys, keys...)
+	c.mtx.Unlock()
+}
+
+// Keys returns the collected keys, in the order they've been fetched.
+func (c *Collector) Keys() []FetchedKey {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return append([]FetchedKey(nil), c.keys...)
+}
+
+// ContextWithCollector returns a context carrying the input collector, which collects the keys fetched
+// through the caches wrapped by this package.
+func ContextWithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorContextKey, c)
+}
+
+// CollectorFromContext returns the collector carried by the context, or nil if none.
+func CollectorFromContext(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorContextKey).(*Collector)
+	return c
+}
+
+// IsSampled returns whether the request of the input context is traced and sampled.
+func IsSampled(ctx context.Context) bool {
+	_, ok := tracing.ExtractSampledTraceID(ctx)
+	return ok
+}
+
+// record logs the fetched keys into the span of sampled traced requests and adds them to the context
+// collector, if any.
+func record(ctx context.Context, cacheName string, keys []string, hits map[string][]byte) {
+	if !IsSampled(ctx) {
+		return
+	}
+
+	fetched := make([]FetchedKey, 0, len(keys))
+	hitKeys := make([]string, 0, len(hits))
+	missKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		_, hit := hits[key]
+		fetched = append(fetched, FetchedKey{Cache: cacheName, Key: key, Hit: hit})
+		if hit {
+			hitKeys = append(hitKeys, key)
+		} else {
+			missKeys = append(missKeys, key)
+		}
+	}
+
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span.LogFields(
+			otlog.String("event", "cache_fetch_keys"),
+			otlog.String("cache", cacheName),
+			otlog.String("hit_keys", strings.Join(hitKeys, ",")),
+			otlog.String("missed_keys", strings.Join(missKeys, ",")),
+		)
+	}
+
+	if c := CollectorFromContext(ctx); c != nil {
+		c.add(fetched...)
+	}
+}
+
+// keysRecordingCache wraps a cache.Cache and records the keys fetched by sampled traced requests.
+type keysRecordingCache struct {
+	cache.Cache
+}
+
+// NewKeysRecordingCache wraps the input cache to record the keys fetched by sampled traced requests
+// into their span and into the collector carried by the request context, if any.
+func NewKeysRecordingCache(c cache.Cache) cache.Cache {
+	return &keysRecordingCache{Cache: c}
+}
+
+func (c *keysRecordingCache) Fetch(ctx context.Context, keys []string) map[string][]byte {
+	hits := c.Cache.Fetch(ctx, keys)
+	record(ctx, c.Name(), keys, hits)
+	return hits
+}
+
+// keysRecordingRemoteCacheClient wraps a cache.RemoteCacheClient and records the keys fetched by sampled
+// traced requests.
+type keysRecordingRemoteCacheClient struct {
+	cache.RemoteCacheClient
+	name string
+}
+
+// NewKeysRecordingRemoteCacheClient wraps the input client to record the keys fetched by sampled traced
+// requests into their span and into the collector carried by the request context, if any.
+func NewKeysRecordingRemoteCacheClient(name string, c cache.RemoteCacheClient) cache.RemoteCacheClient {
+	return &keysRecordingRemoteCacheClient{RemoteCacheClient: c, name: name}
+}
+
+func (c *keysRecordingRemoteCacheClient) GetMulti(ctx context.Context, keys []string) map[string][]byte {
+	hits := c.RemoteCacheClient.GetMulti(ctx, keys)
+	record(ctx, c.name, keys, hits)
+	return hits
+}
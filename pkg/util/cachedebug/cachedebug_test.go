@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cachedebug
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestKeysRecordingCache(t *testing.T) {
+	backend := cache.NewMockCache()
+	backend.Store(context.Background(), map[string][]byte{"key-1": []byte("value-1")}, time.Minute)
+	c := NewKeysRecordingCache(backend)
+
+	tests := map[string]struct {
+		sampled  bool
+		expected []FetchedKey
+	}{
+		"should record the fetched keys of sampled requests": {
+			sampled: true,
+			expected: []FetchedKey{
+				{Cache: backend.Name(), Key: "key-1", Hit: true},
+				{Cache: backend.Name(), Key: "key-2", Hit: false},
+			},
+		},
+		"should not record the fetched keys of not sampled requests": {
+			sampled: false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(testData.sampled), jaeger.NewNullReporter())
+			defer func() { _ = closer.Close() }()
+
+			span := tracer.StartSpan("operation")
+			defer span.Finish()
+
+			collector := &Collector{}
+			ctx := ContextWithCollector(opentracing.ContextWithSpan(context.Background(), span), collector)
+			assert.Equal(t, testData.sampled, IsSampled(ctx))
+
+			hits := c.Fetch(ctx, []string{"key-1", "key-2"})
+			assert.Equal(t, map[string][]byte{"key-1": []byte("value-1")}, hits)
+			assert.Equal(t, testData.expected, collector.Keys())
+		})
+	}
+}
+
+func TestFetchedKey_String(t *testing.T) {
+	assert.Equal(t, "results-cache:key-1:hit", FetchedKey{Cache: "results-cache", Key: "key-1", Hit: true}.String())
+	assert.Equal(t, "results-cache:key-2:miss", FetchedKey{Cache: "results-cache", Key: "key-2"}.String())
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gctuning
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCgroupRoot = "/sys/fs/cgroup"
+
+	// cgroupV1UnlimitedThreshold is the value above which a cgroup v1 memory limit is considered
+	// unlimited. Unlimited cgroups v1 report the max int64 value rounded down to the page size.
+	cgroupV1UnlimitedThreshold = uint64(1 << 62)
+)
+
+// readCgroupMemoryLimit returns the memory limit of the cgroup of the process, looking for both
+// cgroup v2 and v1 at root. Returns 0 and no error if the memory is unlimited or no cgroup is found.
+func readCgroupMemoryLimit(root string) (uint64, error) {
+	// cgroup v2.
+	data, err := os.ReadFile(filepath.Join(root, "memory.max"))
+	if err == nil {
+		value := strings.TrimSpace(string(data))
+		if value == "max" {
+			return 0, nil
+		}
+		limit, err := strconv.ParseUint(value, 10, 64)
+		return limit, errors.Wrap(err, "parse cgroup v2 memory limit")
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, errors.Wrap(err, "read cgroup v2 memory limit")
+	}
+
+	// cgroup v1.
+	data, err = os.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "read cgroup v1 memory limit")
+	}
+
+	limit, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "parse cgroup v1 memory limit")
+	}
+	if limit >= cgroupV1UnlimitedThreshold {
+		return 0, nil
+	}
+	return limit, nil
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gctuning
+
+import (
+	"context"
+	"flag"
+	"math"
+	"os"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// defaultGOGC is the Go runtime default GOGC.
+	defaultGOGC = 100
+
+	heapGoalMetric = "/gc/heap/goal:bytes"
+)
+
+var (
+	errInvalidMemoryLimitRatio = errors.New("the GC tuning memory limit ratio must be greater than 0 and less than or equal to 1")
+	errInvalidGOGCBounds       = errors.New("the GC tuning min GOGC must be greater than 0 and less than or equal to the max GOGC")
+	errInvalidUpdateInterval   = errors.New("the GC tuning update interval must be greater than 0")
+)
+
+type Config struct {
+	Enabled          bool          `yaml:"enabled" category:"experimental"`
+	MemoryLimitBytes uint64        `yaml:"memory_limit_bytes" category:"experimental"`
+	MemoryLimitRatio float64       `yaml:"memory_limit_ratio" category:"experimental"`
+	MinGOGC          int           `yaml:"min_gogc" category:"experimental"`
+	MaxGOGC          int           `yaml:"max_gogc" category:"experimental"`
+	UpdateInterval   time.Duration `yaml:"update_interval" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "gc-tuning.enabled", false, "If enabled, the Go garbage collector is tuned at runtime based on the memory limit of the container and the observed live heap: the Go runtime soft memory limit (GOMEMLIMIT) is set to a ratio of the container memory limit, and GOGC is periodically adjusted so that the next GC target fits within it. This replaces the memory ballast. The GOGC and GOMEMLIMIT environment variables, if set, take precedence.")
+	f.Uint64Var(&cfg.MemoryLimitBytes, "gc-tuning.memory-limit-bytes", 0, "Memory limit - in bytes - of the process used to tune the garbage collector. 0 to detect it from the cgroup memory limit of the container. If no limit is configured nor detected, the garbage collector is not tuned.")
+	f.Float64Var(&cfg.MemoryLimitRatio, "gc-tuning.memory-limit-ratio", 0.9, "Ratio of the memory limit used as Go runtime soft memory limit. The remaining memory is left for the memory not managed by the Go runtime, like memory mapped files.")
+	f.IntVar(&cfg.MinGOGC, "gc-tuning.min-gogc", 50, "Lower bound of the GOGC set by the GC tuning. It prevents the garbage collector from running too frequently when the live heap is close to the memory limit.")
+	f.IntVar(&cfg.MaxGOGC, "gc-tuning.max-gogc", 400, "Upper bound of the GOGC set by the GC tuning. It limits how much the heap can grow between two garbage collections when the live heap is small compared to the memory limit.")
+	f.DurationVar(&cfg.UpdateInterval, "gc-tuning.update-interval", 10*time.Second, "How frequently GOGC is adjusted based on the observed live heap.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MemoryLimitRatio <= 0 || cfg.MemoryLimitRatio > 1 {
+		return errInvalidMemoryLimitRatio
+	}
+	if cfg.MinGOGC <= 0 || cfg.MinGOGC > cfg.MaxGOGC {
+		return errInvalidGOGCBounds
+	}
+	if cfg.UpdateInterval <= 0 {
+		return errInvalidUpdateInterval
+	}
+	return nil
+}
+
+// runtimeSettings abstracts the Go runtime GC settings, so that they can be mocked in tests.
+type runtimeSettings interface {
+	// SetGCPercent sets GOGC and returns the previous value.
+	SetGCPercent(percent int) int
+
+	// SetMemoryLimit sets the soft memory limit and returns the previous value.
+	SetMemoryLimit(limit int64) int64
+
+	// HeapGoal returns the heap size target of the next GC cycle.
+	HeapGoal() uint64
+}
+
+type goRuntimeSettings struct{}
+
+func (goRuntimeSettings) SetGCPercent(percent int) int     { return debug.SetGCPercent(percent) }
+func (goRuntimeSettings) SetMemoryLimit(limit int64) int64 { return debug.SetMemoryLimit(limit) }
+
+func (goRuntimeSettings) HeapGoal() uint64 {
+	sample := []metrics.Sample{{Name: heapGoalMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// Manager tunes the Go garbage collector at runtime. It sets the Go runtime soft memory limit
+// to a ratio of the memory limit of the process, and periodically adjusts GOGC so that the heap
+// target of the next GC cycle, estimated from the observed live heap, fits within the soft
+// memory limit. GOGC is bounded to keep the GC CPU usage under control.
+type Manager struct {
+	services.Service
+
+	cfg     Config
+	logger  log.Logger
+	runtime runtimeSettings
+
+	// cgroupRoot is where the cgroup filesystem is mounted. Configurable for testing purposes.
+	cgroupRoot string
+
+	// softLimit is the Go runtime soft memory limit, in bytes. 0 if not known.
+	softLimit uint64
+	// tuneGOGC is false if GOGC has been set through the environment.
+	tuneGOGC bool
+	// currGOGC is the GOGC currently set.
+	currGOGC int
+
+	// Previous settings, restored once the manager is stopped.
+	prevGOGC        int
+	prevMemoryLimit int64
+	memoryLimitSet  bool
+
+	gogc                 prometheus.Gauge
+	memoryLimit          prometheus.Gauge
+	containerMemoryLimit prometheus.Gauge
+	liveHeap             prometheus.Gauge
+	updates              prometheus.Counter
+}
+
+// NewManager makes a new Manager.
+func NewManager(cfg Config, logger log.Logger, reg prometheus.Registerer) *Manager {
+	return newManager(cfg, goRuntimeSettings{}, defaultCgroupRoot, logger, reg)
+}
+
+func newManager(cfg Config, runtime runtimeSettings, cgroupRoot string, logger log.Logger, reg prometheus.Registerer) *Manager {
+	m := &Manager{
+		cfg:        cfg,
+		logger:     logger,
+		runtime:    runtime,
+		cgroupRoot: cgroupRoot,
+
+		gogc: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_gc_tuning_gogc",
+			Help: "GOGC currently set by the GC tuning.",
+		}),
+		memoryLimit: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_gc_tuning_soft_memory_limit_bytes",
+			Help: "Go runtime soft memory limit set by the GC tuning, or 0 if not set.",
+		}),
+		containerMemoryLimit: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_gc_tuning_memory_limit_bytes",
+			Help: "Memory limit of the process, configured or detected from the container, used by the GC tuning, or 0 if not known.",
+		}),
+		liveHeap: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_gc_tuning_live_heap_bytes",
+			Help: "Live heap estimated by the GC tuning from the heap target of the next GC cycle.",
+		}),
+		updates: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_gc_tuning_gogc_updates_total",
+			Help: "Total number of times GOGC has been changed by the GC tuning.",
+		}),
+	}
+
+	m.Service = services.NewTimerService(cfg.UpdateInterval, m.starting, m.iteration, m.stopping)
+	return m
+}
+
+func (m *Manager) starting(_ context.Context) error {
+	limit := m.cfg.MemoryLimitBytes
+	if limit == 0 {
+		detected, err := readCgroupMemoryLimit(m.cgroupRoot)
+		if err != nil {
+			level.Warn(m.logger).Log("msg", "GC tuning failed to detect the container memory limit", "err", err)
+		}
+		limit = detected
+	}
+	m.containerMemoryLimit.Set(float64(limit))
+
+	if limit == 0 {
+		level.Warn(m.logger).Log("msg", "GC tuning is disabled because the memory limit is not configured and no container memory limit has been detected")
+		return nil
+	}
+
+	m.softLimit = uint64(float64(limit) * m.cfg.MemoryLimitRatio)
+
+	if _, ok := os.LookupEnv("GOMEMLIMIT"); ok {
+		level.Info(m.logger).Log("msg", "GC tuning is not setting the Go runtime soft memory limit because GOMEMLIMIT is set")
+	} else {
+		m.prevMemoryLimit = m.runtime.SetMemoryLimit(int64(m.softLimit))
+		m.memoryLimitSet = true
+		m.memoryLimit.Set(float64(m.softLimit))
+	}
+
+	if _, ok := os.LookupEnv("GOGC"); ok {
+		level.Info(m.logger).Log("msg", "GC tuning is not adjusting GOGC because the GOGC environment variable is set")
+	} else {
+		m.tuneGOGC = true
+		m.prevGOGC = m.runtime.SetGCPercent(defaultGOGC)
+		m.currGOGC = defaultGOGC
+		m.gogc.Set(defaultGOGC)
+	}
+
+	level.Info(m.logger).Log("msg", "GC tuning enabled", "memory_limit_bytes", limit, "soft_memory_limit_bytes", m.softLimit, "tune_gogc", m.tuneGOGC)
+	return nil
+}
+
+func (m *Manager) iteration(_ context.Context) error {
+	if !m.tuneGOGC {
+		return nil
+	}
+
+	// The heap target is computed by the runtime as live heap * (1 + GOGC/100), so the live heap
+	// can be estimated back from it. It's an upper bound when the soft memory limit caps the target.
+	live := m.runtime.HeapGoal() * 100 / uint64(100+m.currGOGC)
+	m.liveHeap.Set(float64(live))
+
+	gogc := computeGOGC(live, m.softLimit, m.cfg.MinGOGC, m.cfg.MaxGOGC)
+	if gogc == m.currGOGC {
+		return nil
+	}
+
+	level.Debug(m.logger).Log("msg", "GC tuning is changing GOGC", "live_heap_bytes", live, "old_gogc", m.currGOGC, "new_gogc", gogc)
+	m.runtime.SetGCPercent(gogc)
+	m.currGOGC = gogc
+	m.gogc.Set(float64(gogc))
+	m.updates.Inc()
+
+	// Never stop the manager because of a failed iteration.
+	return nil
+}
+
+func (m *Manager) stopping(_ error) error {
+	if m.tuneGOGC {
+		m.runtime.SetGCPercent(m.prevGOGC)
+	}
+	if m.memoryLimitSet {
+		m.runtime.SetMemoryLimit(m.prevMemoryLimit)
+	}
+	return nil
+}
+
+// computeGOGC returns the GOGC, bounded between minGOGC and maxGOGC, for which the heap target of the
+// next GC cycle, given the live heap, matches the soft memory limit.
+func computeGOGC(live, softLimit uint64, minGOGC, maxGOGC int) int {
+	if live == 0 || softLimit <= live {
+		return minGOGC
+	}
+
+	gogc := float64(softLimit-live) / float64(live) * 100
+	return int(math.Max(float64(minGOGC), math.Min(float64(maxGOGC), gogc)))
+}
@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gctuning
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *Config)
+		expected error
+	}{
+		"should pass with default config": {
+			setup: func(cfg *Config) {},
+		},
+		"should pass with invalid config if disabled": {
+			setup: func(cfg *Config) {
+				cfg.MemoryLimitRatio = 0
+			},
+		},
+		"should fail on memory limit ratio equal to 0": {
+			setup: func(cfg *Config) {
+				cfg.Enabled = true
+				cfg.MemoryLimitRatio = 0
+			},
+			expected: errInvalidMemoryLimitRatio,
+		},
+		"should fail on memory limit ratio greater than 1": {
+			setup: func(cfg *Config) {
+				cfg.Enabled = true
+				cfg.MemoryLimitRatio = 1.1
+			},
+			expected: errInvalidMemoryLimitRatio,
+		},
+		"should fail on min GOGC greater than max GOGC": {
+			setup: func(cfg *Config) {
+				cfg.Enabled = true
+				cfg.MinGOGC = 200
+				cfg.MaxGOGC = 100
+			},
+			expected: errInvalidGOGCBounds,
+		},
+		"should fail on update interval equal to 0": {
+			setup: func(cfg *Config) {
+				cfg.Enabled = true
+				cfg.UpdateInterval = 0
+			},
+			expected: errInvalidUpdateInterval,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestComputeGOGC(t *testing.T) {
+	tests := map[string]struct {
+		live, softLimit uint64
+		expected        int
+	}{
+		"no live heap": {
+			live:      0,
+			softLimit: 1000,
+			expected:  50,
+		},
+		"live heap above the soft limit": {
+			live:      2000,
+			softLimit: 1000,
+			expected:  50,
+		},
+		"live heap within bounds": {
+			live:      400,
+			softLimit: 1000,
+			expected:  150,
+		},
+		"live heap close to the soft limit": {
+			live:      900,
+			softLimit: 1000,
+			expected:  50,
+		},
+		"live heap small compared to the soft limit": {
+			live:      10,
+			softLimit: 1000,
+			expected:  400,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, computeGOGC(testData.live, testData.softLimit, 50, 400))
+		})
+	}
+}
+
+func TestReadCgroupMemoryLimit(t *testing.T) {
+	tests := map[string]struct {
+		files       map[string]string
+		expected    uint64
+		expectedErr string
+	}{
+		"no cgroup": {
+			expected: 0,
+		},
+		"cgroup v2 with limit": {
+			files:    map[string]string{"memory.max": "1073741824\n"},
+			expected: 1073741824,
+		},
+		"cgroup v2 unlimited": {
+			files:    map[string]string{"memory.max": "max\n"},
+			expected: 0,
+		},
+		"cgroup v2 with invalid limit": {
+			files:       map[string]string{"memory.max": "invalid\n"},
+			expectedErr: "parse cgroup v2 memory limit",
+		},
+		"cgroup v1 with limit": {
+			files:    map[string]string{"memory/memory.limit_in_bytes": "536870912\n"},
+			expected: 536870912,
+		},
+		"cgroup v1 unlimited": {
+			files:    map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"},
+			expected: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range testData.files {
+				require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+			}
+
+			actual, err := readCgroupMemoryLimit(root)
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestManager(t *testing.T) {
+	t.Run("should set the soft memory limit and adjust GOGC based on the live heap", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, "memory.max"), []byte("1000\n"), 0o644))
+
+		runtime := &runtimeSettingsMock{gogc: 100, memoryLimit: -1}
+		reg := prometheus.NewPedanticRegistry()
+		m := newManager(testConfig(), runtime, root, log.NewNopLogger(), reg)
+
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), m))
+
+		// The soft memory limit is set at startup.
+		assert.Equal(t, int64(900), runtime.getMemoryLimit())
+
+		// The live heap is 300 bytes, so GOGC is set to (900 - 300) / 300 * 100.
+		runtime.setLiveHeap(300)
+		require.Eventually(t, func() bool {
+			return runtime.getGOGC() == 200
+		}, time.Second, 10*time.Millisecond)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_gc_tuning_gogc GOGC currently set by the GC tuning.
+			# TYPE cortex_gc_tuning_gogc gauge
+			cortex_gc_tuning_gogc 200
+
+			# HELP cortex_gc_tuning_soft_memory_limit_bytes Go runtime soft memory limit set by the GC tuning, or 0 if not set.
+			# TYPE cortex_gc_tuning_soft_memory_limit_bytes gauge
+			cortex_gc_tuning_soft_memory_limit_bytes 900
+
+			# HELP cortex_gc_tuning_memory_limit_bytes Memory limit of the process, configured or detected from the container, used by the GC tuning, or 0 if not known.
+			# TYPE cortex_gc_tuning_memory_limit_bytes gauge
+			cortex_gc_tuning_memory_limit_bytes 1000
+
+			# HELP cortex_gc_tuning_gogc_updates_total Total number of times GOGC has been changed by the GC tuning.
+			# TYPE cortex_gc_tuning_gogc_updates_total counter
+			cortex_gc_tuning_gogc_updates_total 1
+		`), "cortex_gc_tuning_gogc", "cortex_gc_tuning_soft_memory_limit_bytes", "cortex_gc_tuning_memory_limit_bytes", "cortex_gc_tuning_gogc_updates_total"))
+
+		// The previous settings are restored once stopped.
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), m))
+		assert.Equal(t, 100, runtime.getGOGC())
+		assert.Equal(t, int64(-1), runtime.getMemoryLimit())
+	})
+
+	t.Run("should prefer the configured memory limit over the detected one", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, "memory.max"), []byte("1000\n"), 0o644))
+
+		cfg := testConfig()
+		cfg.MemoryLimitBytes = 2000
+
+		runtime := &runtimeSettingsMock{gogc: 100, memoryLimit: -1}
+		m := newManager(cfg, runtime, root, log.NewNopLogger(), nil)
+
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), m))
+		assert.Equal(t, int64(1800), runtime.getMemoryLimit())
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), m))
+	})
+
+	t.Run("should not change any setting if the memory limit is unknown", func(t *testing.T) {
+		runtime := &runtimeSettingsMock{gogc: 100, memoryLimit: -1, liveHeap: 300}
+		m := newManager(testConfig(), runtime, t.TempDir(), log.NewNopLogger(), nil)
+
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), m))
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), m))
+
+		assert.Equal(t, 100, runtime.getGOGC())
+		assert.Equal(t, int64(-1), runtime.getMemoryLimit())
+		assert.Equal(t, 0, runtime.getCalls())
+	})
+
+	t.Run("should not override the settings configured through the environment", func(t *testing.T) {
+		t.Setenv("GOGC", "150")
+		t.Setenv("GOMEMLIMIT", "1GiB")
+
+		cfg := testConfig()
+		cfg.MemoryLimitBytes = 1000
+
+		runtime := &runtimeSettingsMock{gogc: 150, memoryLimit: 1 << 30, liveHeap: 300}
+		m := newManager(cfg, runtime, t.TempDir(), log.NewNopLogger(), nil)
+
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), m))
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), m))
+
+		assert.Equal(t, 150, runtime.getGOGC())
+		assert.Equal(t, int64(1<<30), runtime.getMemoryLimit())
+		assert.Equal(t, 0, runtime.getCalls())
+	})
+}
+
+func testConfig() Config {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.Enabled = true
+	cfg.UpdateInterval = 10 * time.Millisecond
+	return cfg
+}
+
+type runtimeSettingsMock struct {
+	mx          sync.Mutex
+	gogc        int
+	memoryLimit int64
+	liveHeap    uint64
+	calls       int
+}
+
+func (m *runtimeSettingsMock) SetGCPercent(percent int) int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	prev := m.gogc
+	m.gogc = percent
+	m.calls++
+	return prev
+}
+
+func (m *runtimeSettingsMock) SetMemoryLimit(limit int64) int64 {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	prev := m.memoryLimit
+	m.memoryLimit = limit
+	m.calls++
+	return prev
+}
+
+// HeapGoal returns the heap goal computed like the Go runtime does, ignoring the soft memory limit.
+func (m *runtimeSettingsMock) HeapGoal() uint64 {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	return m.liveHeap * uint64(100+m.gogc) / 100
+}
+
+func (m *runtimeSettingsMock) setLiveHeap(live uint64) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.liveHeap = live
+}
+
+func (m *runtimeSettingsMock) getGOGC() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	return m.gogc
+}
+
+func (m *runtimeSettingsMock) getMemoryLimit() int64 {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	return m.memoryLimit
+}
+
+func (m *runtimeSettingsMock) getCalls() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	return m.calls
+}
@@ -4,6 +4,7 @@ package globalerror
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -49,11 +50,14 @@ const (
 	MetricMetadataHelpTooLong       ID = "help-too-long" // unused, left here to prevent reuse for different purpose
 	MetricMetadataUnitTooLong       ID = "unit-too-long"
 
-	MaxQueryLength       ID = "max-query-length"
-	MaxTotalQueryLength  ID = "max-total-query-length"
-	RequestRateLimited   ID = "tenant-max-request-rate"
-	IngestionRateLimited ID = "tenant-max-ingestion-rate"
-	TooManyHAClusters    ID = "tenant-too-many-ha-clusters"
+	MaxQueryLength              ID = "max-query-length"
+	MaxTotalQueryLength         ID = "max-total-query-length"
+	MaxEstimatedSeriesPerQuery  ID = "max-estimated-series-per-query"
+	MaxEstimatedChunksPerQuery  ID = "max-estimated-chunks-per-query"
+	RequestRateLimited          ID = "tenant-max-request-rate"
+	InflightPushRequestsLimited ID = "tenant-max-inflight-push-requests"
+	IngestionRateLimited        ID = "tenant-max-ingestion-rate"
+	TooManyHAClusters           ID = "tenant-too-many-ha-clusters"
 
 	SampleTimestampTooOld    ID = "sample-timestamp-too-old"
 	SampleOutOfOrder         ID = "sample-out-of-order"
@@ -64,8 +68,37 @@ const (
 	BucketIndexTooOld           ID = "bucket-index-too-old"
 
 	DistributorMaxWriteMessageSize ID = "distributor-max-write-message-size"
+
+	// Generic IDs of the errors without a more specific ID, used as their machine-readable error codes.
+	BadData         ID = "bad-data"
+	Execution       ID = "execution"
+	Timeout         ID = "timeout"
+	Canceled        ID = "canceled"
+	Internal        ID = "internal"
+	Unavailable     ID = "unavailable"
+	NotFound        ID = "not-found"
+	TooManyRequests ID = "too-many-requests"
+	TooLargeEntry   ID = "too-large-entry"
 )
 
+// idRegexp matches the error ID appended to a message by Message() and the other message functions.
+var idRegexp = regexp.MustCompile(`\(` + errPrefix + `([a-z0-9-]+)\)`)
+
+// IDFromMessage returns the ID appended to the input error message, and whether the message has one.
+// If the message has multiple IDs, because it wraps other errors, the first one is returned.
+func IDFromMessage(msg string) (ID, bool) {
+	match := idRegexp.FindStringSubmatch(msg)
+	if match == nil {
+		return "", false
+	}
+	return ID(match[1]), true
+}
+
+// Code returns the machine-readable error code of the ID, as it appears in the error messages.
+func (id ID) Code() string {
+	return errPrefix + string(id)
+}
+
 // Message returns the provided msg, appending the error id.
 func (id ID) Message(msg string) string {
 	return fmt.Sprintf("%s (%s%s)", msg, errPrefix, id)
@@ -58,3 +58,22 @@ func TestID_MessageWithPerTenantLimitConfig(t *testing.T) {
 		assert.Equal(t, tc.expected, tc.actual)
 	}
 }
+
+func TestIDFromMessage(t *testing.T) {
+	for msg, expected := range map[string]ID{
+		MaxSeriesPerQuery.MessageWithPerTenantLimitConfig("an error", "my-flag1"):                     MaxSeriesPerQuery,
+		"failed to fetch series: " + StoreConsistencyCheckFailed.Message("an error"):                  StoreConsistencyCheckFailed,
+		MaxChunksPerQuery.Message("an error") + ", " + MaxChunkBytesPerQuery.Message("another error"): MaxChunksPerQuery,
+	} {
+		id, ok := IDFromMessage(msg)
+		assert.True(t, ok)
+		assert.Equal(t, expected, id)
+	}
+
+	_, ok := IDFromMessage("an error without ID (err-mimir)")
+	assert.False(t, ok)
+}
+
+func TestID_Code(t *testing.T) {
+	assert.Equal(t, "err-mimir-max-series-per-query", MaxSeriesPerQuery.Code())
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+const (
+	// GrafanaDashboardUIDHeader is the HTTP header set by Grafana with the UID of the dashboard issuing a query.
+	GrafanaDashboardUIDHeader = "X-Dashboard-Uid"
+
+	// GrafanaPanelIDHeader is the HTTP header set by Grafana with the ID of the panel issuing a query.
+	GrafanaPanelIDHeader = "X-Panel-Id"
+)
+
+type grafanaSourceCtxKey struct{}
+
+var grafanaSourceKey = &grafanaSourceCtxKey{}
+
+// GrafanaSource is the Grafana dashboard panel which issued a query.
+type GrafanaSource struct {
+	DashboardUID string
+	PanelID      string
+}
+
+// GrafanaSourceFromHTTPHeaders returns the Grafana dashboard panel set in the input HTTP headers.
+func GrafanaSourceFromHTTPHeaders(headers http.Header) GrafanaSource {
+	return GrafanaSource{
+		DashboardUID: headers.Get(GrafanaDashboardUIDHeader),
+		PanelID:      headers.Get(GrafanaPanelIDHeader),
+	}
+}
+
+// GrafanaSourceFromHTTPGRPCRequest returns the Grafana dashboard panel set in the headers of the input request.
+func GrafanaSourceFromHTTPGRPCRequest(req *httpgrpc.HTTPRequest) GrafanaSource {
+	headers := http.Header{}
+	for _, h := range req.GetHeaders() {
+		headers[h.Key] = h.Values
+	}
+	return GrafanaSourceFromHTTPHeaders(headers)
+}
+
+// IsEmpty returns whether the query has not been issued by a Grafana dashboard panel.
+func (s GrafanaSource) IsEmpty() bool {
+	return s.DashboardUID == "" && s.PanelID == ""
+}
+
+// InjectIntoHTTPHeaders sets the Grafana dashboard panel in the input HTTP headers.
+func (s GrafanaSource) InjectIntoHTTPHeaders(headers http.Header) {
+	if s.DashboardUID != "" {
+		headers.Set(GrafanaDashboardUIDHeader, s.DashboardUID)
+	}
+	if s.PanelID != "" {
+		headers.Set(GrafanaPanelIDHeader, s.PanelID)
+	}
+}
+
+// LogFields returns the key-value pairs to log the Grafana dashboard panel, if any.
+func (s GrafanaSource) LogFields() []interface{} {
+	var fields []interface{}
+	if s.DashboardUID != "" {
+		fields = append(fields, "dashboard_uid", s.DashboardUID)
+	}
+	if s.PanelID != "" {
+		fields = append(fields, "panel_id", s.PanelID)
+	}
+	return fields
+}
+
+// ContextWithGrafanaSource returns a new context carrying the input Grafana dashboard panel.
+func ContextWithGrafanaSource(ctx context.Context, source GrafanaSource) context.Context {
+	if source.IsEmpty() {
+		return ctx
+	}
+	return context.WithValue(ctx, grafanaSourceKey, source)
+}
+
+// GrafanaSourceFromContext returns the Grafana dashboard panel carried by the context, if any.
+func GrafanaSourceFromContext(ctx context.Context) GrafanaSource {
+	source, _ := ctx.Value(grafanaSourceKey).(GrafanaSource)
+	return source
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+func TestGrafanaSource(t *testing.T) {
+	headers := http.Header{}
+	headers.Set(GrafanaDashboardUIDHeader, "dashboard")
+	headers.Set(GrafanaPanelIDHeader, "2")
+
+	source := GrafanaSourceFromHTTPHeaders(headers)
+	assert.Equal(t, GrafanaSource{DashboardUID: "dashboard", PanelID: "2"}, source)
+	assert.False(t, source.IsEmpty())
+	assert.Equal(t, []interface{}{"dashboard_uid", "dashboard", "panel_id", "2"}, source.LogFields())
+
+	req := &httpgrpc.HTTPRequest{Headers: []*httpgrpc.Header{
+		{Key: GrafanaDashboardUIDHeader, Values: []string{"dashboard"}},
+		{Key: GrafanaPanelIDHeader, Values: []string{"2"}},
+	}}
+	assert.Equal(t, source, GrafanaSourceFromHTTPGRPCRequest(req))
+
+	injected := http.Header{}
+	source.InjectIntoHTTPHeaders(injected)
+	assert.Equal(t, headers, injected)
+
+	assert.Equal(t, source, GrafanaSourceFromContext(ContextWithGrafanaSource(context.Background(), source)))
+}
+
+func TestGrafanaSource_Empty(t *testing.T) {
+	source := GrafanaSourceFromHTTPHeaders(http.Header{})
+	assert.True(t, source.IsEmpty())
+	assert.Empty(t, source.LogFields())
+
+	injected := http.Header{}
+	source.InjectIntoHTTPHeaders(injected)
+	assert.Empty(t, injected)
+
+	ctx := context.Background()
+	assert.Equal(t, ctx, ContextWithGrafanaSource(ctx, source))
+	assert.True(t, GrafanaSourceFromContext(ctx).IsEmpty())
+}
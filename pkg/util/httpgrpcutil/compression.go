@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package httpgrpcutil
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+const (
+	// AcceptResponseEncodingHeader is the header of the requests sent by the query-frontend to the queriers,
+	// listing the encodings the response can be compressed with, in order of preference.
+	AcceptResponseEncodingHeader = "X-Mimir-Accept-Response-Encoding"
+
+	// ResponseEncodingHeader is the header of the responses sent by the queriers to the query-frontend,
+	// containing the encoding the response has been compressed with, if any. A dedicated header is used
+	// instead of Content-Encoding because the latter can be set by the querier HTTP handler itself.
+	ResponseEncodingHeader = "X-Mimir-Response-Encoding"
+
+	ResponseEncodingSnappy = "snappy"
+	ResponseEncodingZstd   = "zstd"
+
+	// The responses smaller than this aren't compressed, because the saving isn't worth it.
+	minCompressedResponseSize = 1024
+)
+
+// ResponseEncodings are the supported encodings to compress the responses between the queriers and the query-frontend.
+var ResponseEncodings = []string{ResponseEncodingSnappy, ResponseEncodingZstd}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// ValidateResponseEncodings returns an error if any of the encodings isn't supported.
+func ValidateResponseEncodings(encodings []string) error {
+	for _, encoding := range encodings {
+		if encoding != ResponseEncodingSnappy && encoding != ResponseEncodingZstd {
+			return fmt.Errorf("unsupported response encoding: %s (supported values: %s)", encoding, strings.Join(ResponseEncodings, ", "))
+		}
+	}
+	return nil
+}
+
+// SetAcceptedResponseEncodings sets the encodings the response of the request can be compressed with, in order of preference.
+func SetAcceptedResponseEncodings(req *httpgrpc.HTTPRequest, encodings []string) {
+	if len(encodings) == 0 {
+		return
+	}
+	req.Headers = append(req.Headers, &httpgrpc.Header{
+		Key:    AcceptResponseEncodingHeader,
+		Values: []string{strings.Join(encodings, ",")},
+	})
+}
+
+// NegotiateResponseEncoding returns the first of the encodings accepted by the request which is also
+// in the supported ones, or an empty string if there's none.
+func NegotiateResponseEncoding(req *httpgrpc.HTTPRequest, supported []string) string {
+	for _, value := range headerValues(req.Headers, AcceptResponseEncodingHeader) {
+		for _, accepted := range strings.Split(value, ",") {
+			accepted = strings.TrimSpace(accepted)
+			for _, encoding := range supported {
+				if accepted == encoding {
+					return encoding
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// CompressResponse compresses the body of the response with the encoding, unless the response is too small.
+func CompressResponse(resp *httpgrpc.HTTPResponse, encoding string) error {
+	if len(resp.Body) < minCompressedResponseSize {
+		return nil
+	}
+
+	switch encoding {
+	case ResponseEncodingSnappy:
+		resp.Body = snappy.Encode(nil, resp.Body)
+	case ResponseEncodingZstd:
+		encoder, _, err := zstdCodec()
+		if err != nil {
+			return err
+		}
+		resp.Body = encoder.EncodeAll(resp.Body, nil)
+	default:
+		return fmt.Errorf("unsupported response encoding: %s", encoding)
+	}
+
+	resp.Headers = append(resp.Headers, &httpgrpc.Header{Key: ResponseEncodingHeader, Values: []string{encoding}})
+	return nil
+}
+
+// DecompressResponse decompresses the body of the response, if it has been compressed by CompressResponse.
+func DecompressResponse(resp *httpgrpc.HTTPResponse) error {
+	values := headerValues(resp.Headers, ResponseEncodingHeader)
+	if len(values) == 0 {
+		return nil
+	}
+
+	var err error
+	switch encoding := values[0]; encoding {
+	case ResponseEncodingSnappy:
+		resp.Body, err = snappy.Decode(nil, resp.Body)
+	case ResponseEncodingZstd:
+		var decoder *zstd.Decoder
+		if _, decoder, err = zstdCodec(); err == nil {
+			resp.Body, err = decoder.DecodeAll(resp.Body, nil)
+		}
+	default:
+		err = fmt.Errorf("unsupported response encoding: %s", encoding)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decompress response: %w", err)
+	}
+
+	headers := resp.Headers[:0]
+	for _, h := range resp.Headers {
+		if textproto.CanonicalMIMEHeaderKey(h.Key) != ResponseEncodingHeader {
+			headers = append(headers, h)
+		}
+	}
+	resp.Headers = headers
+	return nil
+}
+
+// zstdCodec returns the zstd encoder and decoder, which are safe for concurrent use when compressing
+// and decompressing whole buffers.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+func headerValues(headers []*httpgrpc.Header, key string) []string {
+	for _, h := range headers {
+		if textproto.CanonicalMIMEHeaderKey(h.Key) == key {
+			return h.Values
+		}
+	}
+	return nil
+}
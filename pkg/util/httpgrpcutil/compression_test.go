@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package httpgrpcutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+func TestNegotiateResponseEncoding(t *testing.T) {
+	tests := map[string]struct {
+		accepted  []string
+		supported []string
+		expected  string
+	}{
+		"should not compress if the request accepts no encoding": {
+			supported: []string{ResponseEncodingSnappy, ResponseEncodingZstd},
+			expected:  "",
+		},
+		"should not compress if no encoding is supported": {
+			accepted: []string{ResponseEncodingSnappy, ResponseEncodingZstd},
+			expected: "",
+		},
+		"should pick the first accepted encoding which is supported": {
+			accepted:  []string{ResponseEncodingZstd, ResponseEncodingSnappy},
+			supported: []string{ResponseEncodingSnappy, ResponseEncodingZstd},
+			expected:  ResponseEncodingZstd,
+		},
+		"should skip the accepted encodings which aren't supported": {
+			accepted:  []string{"unknown", ResponseEncodingSnappy},
+			supported: []string{ResponseEncodingSnappy},
+			expected:  ResponseEncodingSnappy,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := &httpgrpc.HTTPRequest{}
+			SetAcceptedResponseEncodings(req, testData.accepted)
+
+			assert.Equal(t, testData.expected, NegotiateResponseEncoding(req, testData.supported))
+		})
+	}
+}
+
+func TestCompressResponse(t *testing.T) {
+	largeBody := []byte(strings.Repeat(`{"metric":{"__name__":"up"},"values":[[1,"1"]]}`, 100))
+
+	for _, encoding := range ResponseEncodings {
+		t.Run(encoding, func(t *testing.T) {
+			resp := &httpgrpc.HTTPResponse{
+				Code:    200,
+				Headers: []*httpgrpc.Header{{Key: "Content-Type", Values: []string{"application/json"}}},
+				Body:    append([]byte{}, largeBody...),
+			}
+
+			require.NoError(t, CompressResponse(resp, encoding))
+			assert.Less(t, len(resp.Body), len(largeBody))
+			assert.Equal(t, []string{encoding}, headerValues(resp.Headers, ResponseEncodingHeader))
+
+			require.NoError(t, DecompressResponse(resp))
+			assert.Equal(t, largeBody, resp.Body)
+			assert.Equal(t, []*httpgrpc.Header{{Key: "Content-Type", Values: []string{"application/json"}}}, resp.Headers)
+		})
+	}
+
+	t.Run("should not compress small responses", func(t *testing.T) {
+		resp := &httpgrpc.HTTPResponse{Code: 200, Body: []byte(`{"status":"success"}`)}
+
+		require.NoError(t, CompressResponse(resp, ResponseEncodingZstd))
+		assert.Equal(t, []byte(`{"status":"success"}`), resp.Body)
+		assert.Empty(t, resp.Headers)
+
+		require.NoError(t, DecompressResponse(resp))
+		assert.Equal(t, []byte(`{"status":"success"}`), resp.Body)
+	})
+
+	t.Run("should fail to decompress a corrupted response", func(t *testing.T) {
+		resp := &httpgrpc.HTTPResponse{
+			Code:    200,
+			Headers: []*httpgrpc.Header{{Key: ResponseEncodingHeader, Values: []string{ResponseEncodingZstd}}},
+			Body:    []byte("corrupted"),
+		}
+
+		assert.Error(t, DecompressResponse(resp))
+	})
+}
+
+func TestValidateResponseEncodings(t *testing.T) {
+	assert.NoError(t, ValidateResponseEncodings(nil))
+	assert.NoError(t, ValidateResponseEncodings([]string{ResponseEncodingZstd, ResponseEncodingSnappy}))
+	assert.EqualError(t, ValidateResponseEncodings([]string{"gzip"}), "unsupported response encoding: gzip (supported values: snappy, zstd)")
+}
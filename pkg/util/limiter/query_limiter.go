@@ -75,6 +75,16 @@ func QueryLimiterFromContextWithFallback(ctx context.Context) *QueryLimiter {
 	return ql
 }
 
+// MaxSeriesPerQuery returns the max number of unique series allowed by this query limiter. 0 means no limit.
+func (ql *QueryLimiter) MaxSeriesPerQuery() int {
+	return ql.maxSeriesPerQuery
+}
+
+// MaxChunksPerQuery returns the max number of chunks allowed by this query limiter. 0 means no limit.
+func (ql *QueryLimiter) MaxChunksPerQuery() int {
+	return ql.maxChunksPerQuery
+}
+
 // AddSeries adds the input series and returns an error if the limit is reached.
 func (ql *QueryLimiter) AddSeries(seriesLabels []mimirpb.LabelAdapter) error {
 	// If the max series is unlimited just return without managing map
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package memberlistsnapshot allows some members of the memberlist cluster (the seeds) to serve a compressed
+// snapshot of their full memberlist KV state over HTTP, so that members joining the cluster can load the whole
+// state at once instead of waiting for it to be gossiped.
+package memberlistsnapshot
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/kv/memberlist"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Path is the HTTP path the snapshot is served on, relative to the HTTP path prefix.
+const Path = "/memberlist/snapshot"
+
+type Config struct {
+	ServeEnabled  bool                   `yaml:"snapshot_serve_enabled" category:"experimental"`
+	SeedAddresses flagext.StringSliceCSV `yaml:"snapshot_seed_addresses" category:"experimental"`
+	FetchTimeout  time.Duration          `yaml:"snapshot_fetch_timeout" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.ServeEnabled, "memberlist.snapshot-serve-enabled", false, "True to serve a compressed snapshot of the full memberlist KV state on the "+Path+" HTTP endpoint, so that this instance can be used as a seed by the joining members.")
+	f.Var(&cfg.SeedAddresses, "memberlist.snapshot-seed-addresses", "Comma-separated list of HTTP base URLs (for example http://seed-1:8080) of the members serving the memberlist KV state snapshot. When set, the snapshot is fetched from one of them once the memberlist KV has started, and merged into the local state.")
+	f.DurationVar(&cfg.FetchTimeout, "memberlist.snapshot-fetch-timeout", 10*time.Second, "Timeout for fetching the memberlist KV state snapshot from a single seed.")
+}
+
+// Handler returns an HTTP handler serving the snappy-compressed full state of the memberlist KV.
+func Handler(kvs *memberlist.KVInitService, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kv, err := kvs.GetMemberlistKV()
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to get memberlist KV to serve the snapshot", "err", err)
+			http.Error(w, "memberlist KV is not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		// The state of the KV is only consistent once it has joined the cluster.
+		if err := kv.AwaitRunning(r.Context()); err != nil {
+			http.Error(w, "memberlist KV is not running", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if _, err := w.Write(snappy.Encode(nil, kv.LocalState(true))); err != nil {
+			level.Warn(logger).Log("msg", "failed to write memberlist KV snapshot", "err", err)
+		}
+	})
+}
+
+// Seeder loads the memberlist KV state snapshot from the configured seeds.
+type Seeder struct {
+	cfg    Config
+	client *http.Client
+	logger log.Logger
+
+	fetches *prometheus.CounterVec
+}
+
+func NewSeeder(cfg Config, logger log.Logger, reg prometheus.Registerer) *Seeder {
+	return &Seeder{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.FetchTimeout},
+		logger: logger,
+		fetches: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_memberlist_snapshot_fetches_total",
+			Help: "Total number of memberlist KV state snapshots fetched from the seeds.",
+		}, []string{"outcome"}),
+	}
+}
+
+// WrapGetMemberlistKV wraps getKV, and loads the snapshot into the KV in background the first time the KV is
+// successfully returned, once it's running.
+func (s *Seeder) WrapGetMemberlistKV(getKV func() (*memberlist.KV, error)) func() (*memberlist.KV, error) {
+	once := sync.Once{}
+
+	return func() (*memberlist.KV, error) {
+		kv, err := getKV()
+		if err != nil || kv == nil {
+			return kv, err
+		}
+
+		once.Do(func() {
+			go func() {
+				if err := kv.AwaitRunning(context.Background()); err != nil {
+					return
+				}
+				if err := s.Seed(context.Background(), kv); err != nil {
+					level.Warn(s.logger).Log("msg", "failed to load memberlist KV state snapshot from seeds", "err", err)
+				}
+			}()
+		})
+		return kv, nil
+	}
+}
+
+// Seed fetches the snapshot from the seeds, in random order, and merges the first one successfully
+// fetched into the local state of kv.
+func (s *Seeder) Seed(ctx context.Context, kv *memberlist.KV) error {
+	var lastErr error
+	for _, i := range rand.Perm(len(s.cfg.SeedAddresses)) {
+		addr := s.cfg.SeedAddresses[i]
+
+		start := time.Now()
+		state, err := s.fetch(ctx, addr)
+		if err != nil {
+			s.fetches.WithLabelValues("failure").Inc()
+			level.Warn(s.logger).Log("msg", "failed to fetch memberlist KV state snapshot", "seed", addr, "err", err)
+			lastErr = err
+			continue
+		}
+
+		s.fetches.WithLabelValues("success").Inc()
+		kv.MergeRemoteState(state, true)
+		level.Info(s.logger).Log("msg", "loaded memberlist KV state snapshot", "seed", addr, "bytes", len(state), "elapsed", time.Since(start))
+		return nil
+	}
+	return lastErr
+}
+
+func (s *Seeder) fetch(ctx context.Context, addr string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+Path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read snapshot")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	state, err := snappy.Decode(nil, body)
+	return state, errors.Wrap(err, "decompress snapshot")
+}
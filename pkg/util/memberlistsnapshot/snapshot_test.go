@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package memberlistsnapshot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/dns"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/kv/codec"
+	"github.com/grafana/dskit/kv/memberlist"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeeder_ShouldLoadTheStateServedBySeed(t *testing.T) {
+	ctx := context.Background()
+
+	seedKVS := newKVInitService(t)
+	seedKV, err := seedKVS.GetMemberlistKV()
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, seedKVS))
+	require.NoError(t, seedKV.AwaitRunning(ctx))
+
+	// Store a ring in the seed.
+	require.NoError(t, seedKV.CAS(ctx, "ring", ring.GetCodec(), func(interface{}) (interface{}, bool, error) {
+		desc := ring.NewDesc()
+		desc.AddIngester("instance-1", "127.0.0.1:9095", "zone-a", []uint32{1, 2, 3}, ring.ACTIVE, time.Now())
+		return desc, true, nil
+	}))
+
+	seed := httptest.NewServer(Handler(seedKVS, log.NewNopLogger()))
+	t.Cleanup(seed.Close)
+
+	// The joining member doesn't join the seed via gossip, so the ring can only come from the snapshot.
+	joiningKVS := newKVInitService(t)
+	joiningKV, err := joiningKVS.GetMemberlistKV()
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, joiningKVS))
+	require.NoError(t, joiningKV.AwaitRunning(ctx))
+
+	reg := prometheus.NewPedanticRegistry()
+	seeder := NewSeeder(Config{SeedAddresses: []string{"http://127.0.0.1:0", seed.URL + "/"}, FetchTimeout: time.Second}, log.NewNopLogger(), reg)
+	require.NoError(t, seeder.Seed(ctx, joiningKV))
+
+	val, err := joiningKV.Get("ring", ring.GetCodec())
+	require.NoError(t, err)
+	require.NotNil(t, val)
+	assert.Contains(t, val.(*ring.Desc).Ingesters, "instance-1")
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(seeder.fetches.WithLabelValues("success")))
+}
+
+func TestSeeder_ShouldFailIfNoSeedServesTheSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	kvs := newKVInitService(t)
+	kv, err := kvs.GetMemberlistKV()
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, kvs))
+	require.NoError(t, kv.AwaitRunning(ctx))
+
+	seeder := NewSeeder(Config{SeedAddresses: []string{failing.URL}, FetchTimeout: time.Second}, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	err = seeder.Seed(ctx, kv)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 503")
+	assert.Equal(t, float64(1), testutil.ToFloat64(seeder.fetches.WithLabelValues("failure")))
+}
+
+func newKVInitService(t *testing.T) *memberlist.KVInitService {
+	cfg := memberlist.KVConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.TCPTransport.BindAddrs = []string{"127.0.0.1"}
+	cfg.TCPTransport.BindPort = 0
+	cfg.Codecs = []codec.Codec{ring.GetCodec()}
+
+	kvs := memberlist.NewKVInitService(&cfg, log.NewNopLogger(), dns.NewProvider(log.NewNopLogger(), nil, dns.GolangResolverType), nil)
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), kvs))
+	})
+	return kvs
+}
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package outlierdetection
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// The min number of instances with enough requests in an evaluation interval to detect the latency outliers,
+	// because the median latency of fewer instances isn't representative.
+	minInstancesForLatencyOutliers = 3
+)
+
+var (
+	errInvalidEvaluationInterval = errors.New("the outlier detection evaluation interval must be greater than 0")
+	errInvalidLatencyMultiplier  = errors.New("the outlier detection latency multiplier must be greater than 1")
+	errInvalidErrorRate          = errors.New("the outlier detection error rate must be greater than 0 and less than or equal to 1")
+	errInvalidConsecutive        = errors.New("the outlier detection consecutive intervals must be greater than 0")
+	errInvalidEjectionDuration   = errors.New("the outlier detection ejection duration must be greater than 0")
+)
+
+type Config struct {
+	Enabled              bool          `yaml:"enabled" category:"experimental"`
+	EvaluationInterval   time.Duration `yaml:"evaluation_interval" category:"experimental"`
+	MinRequests          int           `yaml:"min_requests" category:"experimental"`
+	LatencyMultiplier    float64       `yaml:"latency_multiplier" category:"experimental"`
+	ErrorRate            float64       `yaml:"error_rate" category:"experimental"`
+	ConsecutiveIntervals int           `yaml:"consecutive_intervals" category:"experimental"`
+	EjectionDuration     time.Duration `yaml:"ejection_duration" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(prefix, instances string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "If enabled, the "+instances+" with persistently elevated latency or error rate are temporarily excluded from the queries, as long as the remaining instances are enough to satisfy the quorum.")
+	f.DurationVar(&cfg.EvaluationInterval, prefix+".evaluation-interval", 10*time.Second, "How frequently the latency and error rate of the requests to each instance are evaluated.")
+	f.IntVar(&cfg.MinRequests, prefix+".min-requests", 10, "Minimum number of requests to an instance within an evaluation interval to evaluate its latency and error rate.")
+	f.Float64Var(&cfg.LatencyMultiplier, prefix+".latency-multiplier", 3, "An instance is an outlier if the average latency of its requests within an evaluation interval is greater than the median of the average latencies of all the instances multiplied by this value.")
+	f.Float64Var(&cfg.ErrorRate, prefix+".error-rate", 0.5, "An instance is an outlier if the ratio of its failed requests within an evaluation interval is greater than or equal to this value.")
+	f.IntVar(&cfg.ConsecutiveIntervals, prefix+".consecutive-intervals", 3, "Number of consecutive evaluation intervals an instance must be an outlier for before being excluded.")
+	f.DurationVar(&cfg.EjectionDuration, prefix+".ejection-duration", time.Minute, "How long an outlier instance is excluded for.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.EvaluationInterval <= 0 {
+		return errInvalidEvaluationInterval
+	}
+	if cfg.LatencyMultiplier <= 1 {
+		return errInvalidLatencyMultiplier
+	}
+	if cfg.ErrorRate <= 0 || cfg.ErrorRate > 1 {
+		return errInvalidErrorRate
+	}
+	if cfg.ConsecutiveIntervals <= 0 {
+		return errInvalidConsecutive
+	}
+	if cfg.EjectionDuration <= 0 {
+		return errInvalidEjectionDuration
+	}
+	return nil
+}
+
+// Detector tracks the latency and error rate of the requests to each instance of a client pool, and temporarily
+// ejects the instances whose latency or error rate is persistently elevated compared to the other ones.
+type Detector struct {
+	services.Service
+
+	cfg    Config
+	pool   string
+	logger log.Logger
+	now    func() time.Time
+
+	mtx       sync.Mutex
+	instances map[string]*instanceStats
+	ejected   map[string]EjectedInstance
+
+	ejections        prometheus.Counter
+	ejectedInstances prometheus.Gauge
+}
+
+// instanceStats holds the stats of the requests to an instance within the current evaluation interval.
+type instanceStats struct {
+	requests int
+	failures int
+	latency  time.Duration
+
+	// consecutive is the number of consecutive evaluation intervals the instance has been an outlier for.
+	consecutive int
+}
+
+// EjectedInstance is an instance excluded because of its elevated latency or error rate.
+type EjectedInstance struct {
+	Addr        string        `json:"addr"`
+	Reason      string        `json:"reason"`
+	Latency     time.Duration `json:"latency"`
+	ErrorRate   float64       `json:"error_rate"`
+	EjectedAt   time.Time     `json:"ejected_at"`
+	EjectedTill time.Time     `json:"ejected_till"`
+}
+
+// NewDetector makes a new Detector of the instances of the client pool.
+func NewDetector(cfg Config, pool string, logger log.Logger, reg prometheus.Registerer) *Detector {
+	d := &Detector{
+		cfg:       cfg,
+		pool:      pool,
+		logger:    log.With(logger, "pool", pool),
+		now:       time.Now,
+		instances: map[string]*instanceStats{},
+		ejected:   map[string]EjectedInstance{},
+		ejections: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_client_pool_outlier_ejections_total",
+			Help:        "Total number of times an instance of the client pool has been ejected because of its elevated latency or error rate.",
+			ConstLabels: prometheus.Labels{"pool": pool},
+		}),
+		ejectedInstances: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name:        "cortex_client_pool_outlier_ejected_instances",
+			Help:        "Number of instances of the client pool currently ejected because of their elevated latency or error rate.",
+			ConstLabels: prometheus.Labels{"pool": pool},
+		}),
+	}
+
+	d.Service = services.NewTimerService(cfg.EvaluationInterval, nil, d.iteration, nil).WithName(pool + " outlier detection")
+	return d
+}
+
+func (d *Detector) iteration(_ context.Context) error {
+	d.evaluate()
+	return nil
+}
+
+// Record tracks a request to the instance. The requests canceled by the caller aren't tracked.
+func (d *Detector) Record(addr string, latency time.Duration, err error) {
+	if !d.cfg.Enabled || isCanceled(err) {
+		return
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	s := d.instances[addr]
+	if s == nil {
+		s = &instanceStats{}
+		d.instances[addr] = s
+	}
+
+	s.requests++
+	s.latency += latency
+	if isInstanceFailure(err) {
+		s.failures++
+	}
+}
+
+// IsEjected returns whether the instance is currently ejected.
+func (d *Detector) IsEjected(addr string) bool {
+	if !d.cfg.Enabled {
+		return false
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	_, ok := d.ejected[addr]
+	return ok
+}
+
+// EjectedInstances returns the currently ejected instances, sorted by address.
+func (d *Detector) EjectedInstances() []EjectedInstance {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	instances := make([]EjectedInstance, 0, len(d.ejected))
+	for _, e := range d.ejected {
+		instances = append(instances, e)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Addr < instances[j].Addr
+	})
+	return instances
+}
+
+// FilterReplicationSet removes the ejected instances from the replication set, as long as the remaining
+// instances are enough to satisfy the quorum: up to MaxErrors instances are removed, or, if the replication
+// set is zone-aware, all the instances of up to MaxUnavailableZones zones with ejected instances.
+func (d *Detector) FilterReplicationSet(set ring.ReplicationSet) ring.ReplicationSet {
+	if !d.cfg.Enabled {
+		return set
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if len(d.ejected) == 0 {
+		return set
+	}
+
+	if set.MaxUnavailableZones > 0 {
+		var zones []string
+		for _, instance := range set.Instances {
+			if _, ok := d.ejected[instance.Addr]; ok && !util.StringsContain(zones, instance.Zone) {
+				zones = append(zones, instance.Zone)
+			}
+		}
+		if len(zones) == 0 {
+			return set
+		}
+		sort.Strings(zones)
+		if len(zones) > set.MaxUnavailableZones {
+			zones = zones[:set.MaxUnavailableZones]
+		}
+
+		instances := make([]ring.InstanceDesc, 0, len(set.Instances))
+		for _, instance := range set.Instances {
+			if !util.StringsContain(zones, instance.Zone) {
+				instances = append(instances, instance)
+			}
+		}
+		set.Instances = instances
+		set.MaxUnavailableZones -= len(zones)
+		return set
+	}
+
+	instances := make([]ring.InstanceDesc, 0, len(set.Instances))
+	for _, instance := range set.Instances {
+		if _, ok := d.ejected[instance.Addr]; ok && set.MaxErrors > 0 {
+			set.MaxErrors--
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	set.Instances = instances
+	return set
+}
+
+// evaluate detects the outliers among the instances requested within the last evaluation interval,
+// ejects the persistent ones and readmits the instances whose ejection has expired.
+func (d *Detector) evaluate() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	now := d.now()
+
+	for addr, e := range d.ejected {
+		if !now.Before(e.EjectedTill) {
+			delete(d.ejected, addr)
+			level.Info(d.logger).Log("msg", "readmitted instance after the ejection expired", "instance", addr)
+		}
+	}
+
+	// The median of the average latencies of the instances with enough requests.
+	var latencies []time.Duration
+	for _, s := range d.instances {
+		if s.requests > 0 && s.requests >= d.cfg.MinRequests {
+			latencies = append(latencies, s.latency/time.Duration(s.requests))
+		}
+	}
+	var medianLatency time.Duration
+	if len(latencies) >= minInstancesForLatencyOutliers {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		medianLatency = latencies[len(latencies)/2]
+	}
+
+	for addr, s := range d.instances {
+		// The instances not requested anymore are forgotten.
+		if s.requests == 0 {
+			delete(d.instances, addr)
+			continue
+		}
+
+		reason := ""
+		latency := s.latency / time.Duration(s.requests)
+		errorRate := float64(s.failures) / float64(s.requests)
+		if s.requests >= d.cfg.MinRequests {
+			if errorRate >= d.cfg.ErrorRate {
+				reason = "error rate"
+			} else if medianLatency > 0 && float64(latency) > float64(medianLatency)*d.cfg.LatencyMultiplier {
+				reason = "latency"
+			}
+		}
+
+		if reason == "" {
+			s.consecutive = 0
+		} else {
+			s.consecutive++
+		}
+
+		if _, ejected := d.ejected[addr]; !ejected && reason != "" && s.consecutive >= d.cfg.ConsecutiveIntervals {
+			d.ejected[addr] = EjectedInstance{
+				Addr:        addr,
+				Reason:      reason,
+				Latency:     latency,
+				ErrorRate:   errorRate,
+				EjectedAt:   now,
+				EjectedTill: now.Add(d.cfg.EjectionDuration),
+			}
+			d.ejections.Inc()
+			s.consecutive = 0
+			level.Warn(d.logger).Log("msg", "ejected instance because of elevated "+reason, "instance", addr, "latency", latency, "median_latency", medianLatency, "error_rate", errorRate, "ejection_duration", d.cfg.EjectionDuration)
+		}
+
+		// Reset the stats for the next evaluation interval.
+		s.requests, s.failures, s.latency = 0, 0, 0
+	}
+
+	d.ejectedInstances.Set(float64(len(d.ejected)))
+}
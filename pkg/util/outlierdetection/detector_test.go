@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package outlierdetection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func testConfig() Config {
+	return Config{
+		Enabled:              true,
+		EvaluationInterval:   10 * time.Second,
+		MinRequests:          10,
+		LatencyMultiplier:    3,
+		ErrorRate:            0.5,
+		ConsecutiveIntervals: 2,
+		EjectionDuration:     time.Minute,
+	}
+}
+
+func newTestDetector(cfg Config, reg prometheus.Registerer) (*Detector, *time.Time) {
+	now := time.Now()
+	d := NewDetector(cfg, "ingester", log.NewNopLogger(), reg)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+// recordRequests records n requests to each instance with the given latency and error.
+func recordRequests(d *Detector, n int, latencies map[string]time.Duration, errs map[string]error) {
+	for addr, latency := range latencies {
+		for i := 0; i < n; i++ {
+			d.Record(addr, latency, errs[addr])
+		}
+	}
+}
+
+func TestDetector_ShouldEjectInstancesWithPersistentlyElevatedLatency(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	d, now := newTestDetector(testConfig(), reg)
+
+	latencies := map[string]time.Duration{"a": 10 * time.Millisecond, "b": 12 * time.Millisecond, "c": 11 * time.Millisecond, "d": 100 * time.Millisecond}
+
+	// The instance shouldn't be ejected until it's an outlier for the configured consecutive intervals.
+	recordRequests(d, 10, latencies, nil)
+	d.evaluate()
+	assert.False(t, d.IsEjected("d"))
+
+	recordRequests(d, 10, latencies, nil)
+	d.evaluate()
+	assert.True(t, d.IsEjected("d"))
+	assert.False(t, d.IsEjected("a"))
+
+	ejected := d.EjectedInstances()
+	require.Len(t, ejected, 1)
+	assert.Equal(t, "d", ejected[0].Addr)
+	assert.Equal(t, "latency", ejected[0].Reason)
+	assert.Equal(t, 100*time.Millisecond, ejected[0].Latency)
+
+	// The instance should be readmitted once the ejection expires.
+	*now = now.Add(time.Minute)
+	d.evaluate()
+	assert.False(t, d.IsEjected("d"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_client_pool_outlier_ejected_instances Number of instances of the client pool currently ejected because of their elevated latency or error rate.
+		# TYPE cortex_client_pool_outlier_ejected_instances gauge
+		cortex_client_pool_outlier_ejected_instances{pool="ingester"} 0
+
+		# HELP cortex_client_pool_outlier_ejections_total Total number of times an instance of the client pool has been ejected because of its elevated latency or error rate.
+		# TYPE cortex_client_pool_outlier_ejections_total counter
+		cortex_client_pool_outlier_ejections_total{pool="ingester"} 1
+	`)))
+}
+
+func TestDetector_ShouldNotEjectInstancesWithIntermittentlyElevatedLatency(t *testing.T) {
+	d, _ := newTestDetector(testConfig(), nil)
+
+	slow := map[string]time.Duration{"a": 10 * time.Millisecond, "b": 12 * time.Millisecond, "c": 11 * time.Millisecond, "d": 100 * time.Millisecond}
+	fast := map[string]time.Duration{"a": 10 * time.Millisecond, "b": 12 * time.Millisecond, "c": 11 * time.Millisecond, "d": 10 * time.Millisecond}
+
+	for _, latencies := range []map[string]time.Duration{slow, fast, slow, fast} {
+		recordRequests(d, 10, latencies, nil)
+		d.evaluate()
+		assert.False(t, d.IsEjected("d"))
+	}
+}
+
+func TestDetector_ShouldNotDetectLatencyOutliersAmongFewInstancesOrRequests(t *testing.T) {
+	d, _ := newTestDetector(testConfig(), nil)
+
+	for i := 0; i < 3; i++ {
+		// Not enough instances to compute a representative median latency.
+		recordRequests(d, 10, map[string]time.Duration{"a": 10 * time.Millisecond, "b": 100 * time.Millisecond}, nil)
+		// Not enough requests to the slow instance.
+		recordRequests(d, 9, map[string]time.Duration{"c": time.Second}, nil)
+		d.evaluate()
+	}
+
+	assert.Empty(t, d.EjectedInstances())
+}
+
+func TestDetector_ShouldEjectInstancesWithPersistentlyElevatedErrorRate(t *testing.T) {
+	d, _ := newTestDetector(testConfig(), nil)
+
+	latencies := map[string]time.Duration{"a": time.Millisecond, "b": time.Millisecond, "c": time.Millisecond, "d": time.Millisecond}
+	errs := map[string]error{
+		"a": httpgrpc.Errorf(http.StatusBadRequest, "per-tenant limit reached"),
+		"b": context.Canceled,
+		"c": status.Error(codes.Canceled, "canceled"),
+		"d": httpgrpc.Errorf(http.StatusInternalServerError, "failure"),
+	}
+
+	for i := 0; i < 2; i++ {
+		recordRequests(d, 10, latencies, errs)
+		d.evaluate()
+	}
+
+	// The rejected and canceled requests aren't failures of the instance.
+	ejected := d.EjectedInstances()
+	require.Len(t, ejected, 1)
+	assert.Equal(t, "d", ejected[0].Addr)
+	assert.Equal(t, "error rate", ejected[0].Reason)
+	assert.Equal(t, 1.0, ejected[0].ErrorRate)
+}
+
+func TestDetector_ShouldNotTrackRequestsIfDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Enabled = false
+	d, _ := newTestDetector(cfg, nil)
+
+	for i := 0; i < 2; i++ {
+		recordRequests(d, 10, map[string]time.Duration{"a": time.Millisecond}, map[string]error{"a": errors.New("failure")})
+		d.evaluate()
+	}
+
+	assert.False(t, d.IsEjected("a"))
+	assert.Empty(t, d.instances)
+}
+
+func TestDetector_FilterReplicationSet(t *testing.T) {
+	instances := []ring.InstanceDesc{
+		{Addr: "a-1", Zone: "zone-a"}, {Addr: "a-2", Zone: "zone-a"},
+		{Addr: "b-1", Zone: "zone-b"}, {Addr: "b-2", Zone: "zone-b"},
+		{Addr: "c-1", Zone: "zone-c"}, {Addr: "c-2", Zone: "zone-c"},
+	}
+
+	tests := map[string]struct {
+		set      ring.ReplicationSet
+		ejected  []string
+		expected ring.ReplicationSet
+	}{
+		"should not filter the replication set if no instance is ejected": {
+			set:      ring.ReplicationSet{Instances: instances, MaxErrors: 1},
+			expected: ring.ReplicationSet{Instances: instances, MaxErrors: 1},
+		},
+		"should remove the ejected instances up to the max errors": {
+			set:      ring.ReplicationSet{Instances: instances, MaxErrors: 1},
+			ejected:  []string{"a-2", "b-1"},
+			expected: ring.ReplicationSet{Instances: []ring.InstanceDesc{instances[0], instances[2], instances[3], instances[4], instances[5]}, MaxErrors: 0},
+		},
+		"should not remove the ejected instances if no error is tolerated": {
+			set:      ring.ReplicationSet{Instances: instances, MaxErrors: 0},
+			ejected:  []string{"a-2"},
+			expected: ring.ReplicationSet{Instances: instances, MaxErrors: 0},
+		},
+		"should remove the zones with ejected instances up to the max unavailable zones": {
+			set:      ring.ReplicationSet{Instances: instances, MaxUnavailableZones: 1},
+			ejected:  []string{"b-2", "c-1"},
+			expected: ring.ReplicationSet{Instances: []ring.InstanceDesc{instances[0], instances[1], instances[4], instances[5]}, MaxUnavailableZones: 0},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			d, now := newTestDetector(testConfig(), nil)
+			for _, addr := range testData.ejected {
+				d.ejected[addr] = EjectedInstance{Addr: addr, EjectedAt: *now, EjectedTill: now.Add(time.Minute)}
+			}
+
+			set := testData.set
+			set.Instances = append([]ring.InstanceDesc(nil), set.Instances...)
+			assert.Equal(t, testData.expected, d.FilterReplicationSet(set))
+		})
+	}
+}
+
+func TestDetector_ClientInterceptors(t *testing.T) {
+	cfg := testConfig()
+	cfg.MinRequests = 1
+	cfg.ConsecutiveIntervals = 1
+	d, _ := newTestDetector(cfg, nil)
+
+	conn, err := grpc.Dial("instance-1:9095", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// The unary requests should be tracked.
+	err = d.UnaryClientInterceptor()(context.Background(), "/method", nil, nil, conn, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return errors.New("failure")
+	})
+	require.Error(t, err)
+
+	// The streams should be tracked once fully received.
+	stream, err := d.StreamClientInterceptor()(context.Background(), &grpc.StreamDesc{}, conn, "/method", func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &mockClientStream{}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, d.instances["instance-1:9095"].requests)
+
+	require.Equal(t, io.EOF, stream.RecvMsg(nil))
+	require.Equal(t, io.EOF, stream.RecvMsg(nil))
+	assert.Equal(t, 2, d.instances["instance-1:9095"].requests)
+	assert.Equal(t, 1, d.instances["instance-1:9095"].failures)
+
+	d.evaluate()
+	assert.True(t, d.IsEjected("instance-1:9095"))
+}
+
+func TestDetector_ServeHTTP(t *testing.T) {
+	d, now := newTestDetector(testConfig(), nil)
+	d.ejected["instance-1"] = EjectedInstance{Addr: "instance-1", Reason: "latency", EjectedAt: *now, EjectedTill: now.Add(time.Minute)}
+
+	req := httptest.NewRequest("GET", "/distributor/ejected_ingesters", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var page ejectedInstancesPageContents
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, "ingester", page.Pool)
+	require.Len(t, page.Instances, 1)
+	assert.Equal(t, "instance-1", page.Instances[0].Addr)
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("GET", "/distributor/ejected_ingesters", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "instance-1")
+}
+
+type mockClientStream struct {
+	grpc.ClientStream
+}
+
+func (m *mockClientStream) RecvMsg(interface{}) error {
+	return io.EOF
+}
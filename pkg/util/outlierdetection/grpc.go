@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package outlierdetection
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor returns a gRPC client interceptor tracking the requests to each instance.
+func (d *Detector) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		d.Record(cc.Target(), time.Since(start), err)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a gRPC client interceptor tracking the streams to each instance. A stream is
+// tracked once it has been fully received or has failed, from its creation.
+func (d *Detector) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			d.Record(cc.Target(), time.Since(start), err)
+			return nil, err
+		}
+
+		return &trackedClientStream{
+			ClientStream: stream,
+			done: func(err error) {
+				d.Record(cc.Target(), time.Since(start), err)
+			},
+		}, nil
+	}
+}
+
+type trackedClientStream struct {
+	grpc.ClientStream
+
+	once sync.Once
+	done func(err error)
+}
+
+func (s *trackedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.once.Do(func() { s.done(nil) })
+	} else if err != nil {
+		s.once.Do(func() { s.done(err) })
+	}
+	return err
+}
+
+// isCanceled returns whether the request has been canceled by the caller, like when the quorum has
+// already been reached, so it tells nothing about the instance.
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled
+}
+
+// isInstanceFailure returns whether the error is a failure of the instance, rather than a rejection
+// of the request, like a 4xx error due to a per-tenant limit.
+func isInstanceFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return resp.Code/100 == 5
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package outlierdetection
+
+import (
+	_ "embed" // Used to embed html template
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+//go:embed ejected_instances.gohtml
+var ejectedInstancesPageHTML string
+var ejectedInstancesTemplate = template.Must(template.New("webpage").Parse(ejectedInstancesPageHTML))
+
+type ejectedInstancesPageContents struct {
+	Now       time.Time         `json:"now"`
+	Pool      string            `json:"pool"`
+	Enabled   bool              `json:"enabled"`
+	Instances []EjectedInstance `json:"instances"`
+}
+
+// ServeHTTP serves the status page of the ejected instances.
+func (d *Detector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	util.RenderHTTPResponse(w, ejectedInstancesPageContents{
+		Now:       time.Now(),
+		Pool:      d.pool,
+		Enabled:   d.cfg.Enabled,
+		Instances: d.EjectedInstances(),
+	}, ejectedInstancesTemplate, req)
+}
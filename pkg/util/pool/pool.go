@@ -111,6 +111,14 @@ func (p *BucketedBytes) Get(sz int) (*[]byte, error) {
 	return p.new(sz), nil
 }
 
+// UsedBytes returns the number of bytes obtained from the pool and not returned yet.
+func (p *BucketedBytes) UsedBytes() uint64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.usedTotal
+}
+
 // Put returns a byte slice to the right bucket in the pool.
 func (p *BucketedBytes) Put(b *[]byte) {
 	if b == nil {
@@ -159,6 +167,18 @@ func (b *BatchBytes) Release() {
 	b.slabs = b.slabs[:0]
 }
 
+// Borrowed returns the size, in bytes, of the slabs got from the delegate pool and not released yet.
+func (b *BatchBytes) Borrowed() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	size := 0
+	for _, slab := range b.slabs {
+		size += cap(*slab)
+	}
+	return size
+}
+
 func (b *BatchBytes) Get(sz int) ([]byte, error) {
 	b.mtx.Lock()
 	defer b.mtx.Unlock()
@@ -208,6 +228,16 @@ func (b *SlabPool[T]) Release() {
 	b.slabs = b.slabs[:0]
 }
 
+// Borrowed returns the capacity, in number of T, of the slabs which will be put back to the delegate pool
+// on Release(). The slices allocated outside the pool, because bigger than the slab size, are not included.
+func (b *SlabPool[T]) Borrowed() int {
+	size := 0
+	for _, slab := range b.slabs {
+		size += cap(*slab)
+	}
+	return size
+}
+
 // Get returns a slice of T with the given length and capacity (both matches).
 func (b *SlabPool[T]) Get(size int) []T {
 	const lookback = 3
@@ -234,7 +264,9 @@ func (b *SlabPool[T]) Get(size int) []T {
 
 	// Get a new one if there's no space available in the last few slabs.
 	if slab == nil {
-		if reused := b.delegate.Get(); reused != nil {
+		// The delegate pool may be shared with SlabPools configured with a smaller slab size,
+		// so a reused slab is discarded if it's smaller than the slab size.
+		if reused := b.delegate.Get(); reused != nil && cap(*(reused.(*[]T))) >= b.slabSize {
 			slab = reused.(*[]T)
 			*slab = (*slab)[:0]
 		} else {
@@ -32,7 +32,7 @@ func TestBytesPool(t *testing.T) {
 		b, err := chunkPool.Get(40)
 		require.NoError(t, err)
 
-		require.Equal(t, uint64(40), chunkPool.usedTotal)
+		require.Equal(t, uint64(40), chunkPool.UsedBytes())
 
 		if i%2 == 0 {
 			for j := 0; j < 6; j++ {
@@ -64,7 +64,7 @@ func TestBytesPool(t *testing.T) {
 	chunkPool.Put(b1)
 	chunkPool.Put(b2)
 
-	require.Equal(t, uint64(0), chunkPool.usedTotal)
+	require.Equal(t, uint64(0), chunkPool.UsedBytes())
 }
 
 func TestRacePutGet(t *testing.T) {
@@ -180,6 +180,24 @@ func TestBatchBytes(t *testing.T) {
 		batchBytes.Release()
 		assert.Zero(t, int(bytesPool.usedTotal))
 	})
+
+	t.Run("borrowed bytes are the capacity of the slabs not released yet", func(t *testing.T) {
+		bytesPool, err := NewBucketedBytes(10, 100, 2, 1000)
+		require.NoError(t, err)
+		batchBytes := BatchBytes{Delegate: bytesPool}
+		assert.Zero(t, batchBytes.Borrowed())
+
+		_, err = batchBytes.Get(5)
+		require.NoError(t, err)
+		assert.Equal(t, 10, batchBytes.Borrowed())
+
+		_, err = batchBytes.Get(15)
+		require.NoError(t, err)
+		assert.Equal(t, 30, batchBytes.Borrowed())
+
+		batchBytes.Release()
+		assert.Zero(t, batchBytes.Borrowed())
+	})
 }
 
 func TestSlabPool(t *testing.T) {
@@ -257,6 +275,12 @@ func TestSlabPool(t *testing.T) {
 		require.Equal(t, 10, cap(*(slabPool.slabs[0])))
 		require.Equal(t, 9, len(*(slabPool.slabs[1])))
 		require.Equal(t, 10, cap(*(slabPool.slabs[1])))
+		require.Equal(t, 20, slabPool.Borrowed())
+
+		// Size bigger than the slab size, so the slice is allocated outside the pool.
+		sliceE := slabPool.Get(11)
+		assert.Len(t, sliceE, 11)
+		require.Equal(t, 20, slabPool.Borrowed())
 
 		assert.Equal(t, "12345", string(sliceA))
 		assert.Equal(t, "67890-", string(sliceB))
@@ -264,11 +288,29 @@ func TestSlabPool(t *testing.T) {
 		assert.Equal(t, "def", string(sliceD))
 
 		slabPool.Release()
+		require.Zero(t, slabPool.Borrowed())
 		require.Zero(t, delegatePool.Balance.Load())
 		require.Greater(t, int(delegatePool.Gets.Load()), 0)
 	})
 }
 
+func TestSlabPool_ShouldNotReuseSlabsSmallerThanTheSlabSize(t *testing.T) {
+	delegatePool := &TrackedPool{Parent: &sync.Pool{}}
+
+	smallSlabPool := NewSlabPool[byte](delegatePool, 5)
+	smallSlabPool.Get(5)
+	smallSlabPool.Release()
+
+	slabPool := NewSlabPool[byte](delegatePool, 10)
+	slice := slabPool.Get(8)
+	require.Len(t, slice, 8)
+	require.Equal(t, 1, len(slabPool.slabs))
+	require.Equal(t, 10, cap(*(slabPool.slabs[0])))
+
+	slabPool.Release()
+	require.Zero(t, delegatePool.Balance.Load())
+}
+
 func TestSlabPool_Fuzzy(t *testing.T) {
 	const (
 		numRuns           = 100
@@ -5,9 +5,11 @@ package push
 import (
 	"compress/gzip"
 	"context"
-	"errors"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	kitlog "github.com/go-kit/log"
@@ -23,6 +25,7 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.uber.org/multierr"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
@@ -34,20 +37,83 @@ const (
 	pbContentType   = "application/x-protobuf"
 	jsonContentType = "application/json"
 
-	otelParseError = "otlp_parse_error"
-	maxErrMsgLen   = 1024
+	otelParseError             = "otlp_parse_error"
+	otelUnsupportedMetricType  = "otlp_unsupported_metric_type"
+	otelUnsupportedTemporality = "otlp_unsupported_temporality"
+	maxErrMsgLen               = 1024
+
+	// otelTargetInfoMetricName is the name of the series added by the translator for the resource attributes.
+	otelTargetInfoMetricName = "target"
 )
 
+// otlpDiscardedCounters holds the per-reason counters of the data points discarded while translating
+// OTLP metrics to Prometheus series.
+type otlpDiscardedCounters struct {
+	parseError             *prometheus.CounterVec
+	unsupportedMetricType  *prometheus.CounterVec
+	unsupportedTemporality *prometheus.CounterVec
+}
+
+// otlpRejections tracks the data points of an OTLP export request which have been rejected, to report
+// them back to the client as an OTLP partial success.
+type otlpRejections struct {
+	dataPoints int64
+	errMsg     strings.Builder
+}
+
+func (r *otlpRejections) add(dataPoints int, msg string) {
+	r.dataPoints += int64(dataPoints)
+
+	// Keep the error message bounded.
+	if r.errMsg.Len() >= maxErrMsgLen {
+		return
+	}
+	if r.errMsg.Len() > 0 {
+		r.errMsg.WriteString("; ")
+	}
+	r.errMsg.WriteString(msg)
+}
+
+func (r *otlpRejections) message() string {
+	msg := r.errMsg.String()
+	if len(msg) > maxErrMsgLen {
+		msg = msg[:maxErrMsgLen]
+	}
+	return msg
+}
+
+// OTLPHandlerLimits are the per-tenant limits used by the OTLP handler.
+type OTLPHandlerLimits interface {
+	OTelDeltaTemporalityConversionEnabled(userID string) bool
+}
+
 func OTLPHandler(
 	maxRecvMsgSize int,
 	sourceIPs *middleware.SourceIPExtractor,
 	allowSkipLabelNameValidation bool,
+	limits OTLPHandlerLimits,
 	reg prometheus.Registerer,
 	push Func,
 ) http.Handler {
-	discardedDueToOtelParseError := validation.DiscardedSamplesCounter(reg, otelParseError)
+	discarded := otlpDiscardedCounters{
+		parseError:             validation.DiscardedSamplesCounter(reg, otelParseError),
+		unsupportedMetricType:  validation.DiscardedSamplesCounter(reg, otelUnsupportedMetricType),
+		unsupportedTemporality: validation.DiscardedSamplesCounter(reg, otelUnsupportedTemporality),
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The rejections are tracked per request and returned to the client once the push succeeded.
+		rejections := &otlpRejections{}
+		onSuccess := func(w http.ResponseWriter) {
+			writeOTLPResponse(w, r.Header.Get("Content-Type"), rejections)
+		}
 
-	return handler(maxRecvMsgSize, sourceIPs, allowSkipLabelNameValidation, push, func(ctx context.Context, r *http.Request, maxRecvMsgSize int, dst []byte, req *mimirpb.PreallocWriteRequest) ([]byte, error) {
+		handler(maxRecvMsgSize, sourceIPs, allowSkipLabelNameValidation, push, otlpParser(limits, discarded, rejections), onSuccess).ServeHTTP(w, r)
+	})
+}
+
+func otlpParser(limits OTLPHandlerLimits, discarded otlpDiscardedCounters, rejections *otlpRejections) parserFunc {
+	return func(ctx context.Context, r *http.Request, maxRecvMsgSize int, dst []byte, req *mimirpb.PreallocWriteRequest) ([]byte, error) {
 		var decoderFunc func(buf []byte) (pmetricotlp.Request, error)
 
 		logger := log.WithContext(ctx, log.Logger)
@@ -114,48 +180,236 @@ func OTLPHandler(
 			return body, err
 		}
 
-		metrics, err := otelMetricsToTimeseries(ctx, discardedDueToOtelParseError, logger, otlpReq.Metrics())
+		metrics, err := otelMetricsToTimeseries(ctx, limits, discarded, rejections, logger, otlpReq.Metrics())
 		if err != nil {
 			return body, err
 		}
 
 		req.Timeseries = metrics
 		return body, nil
-	})
+	}
 }
 
-func otelMetricsToTimeseries(ctx context.Context, discardedDueToOtelParseError *prometheus.CounterVec, logger kitlog.Logger, md pmetric.Metrics) ([]mimirpb.PreallocTimeseries, error) {
-	tsMap, errs := prometheusremotewrite.FromMetrics(md, prometheusremotewrite.Settings{})
+func otelMetricsToTimeseries(ctx context.Context, limits OTLPHandlerLimits, discarded otlpDiscardedCounters, rejections *otlpRejections, logger kitlog.Logger, md pmetric.Metrics) ([]mimirpb.PreallocTimeseries, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if errs != nil {
-		userID, err := tenant.TenantID(ctx)
-		if err != nil {
-			return nil, err
+	// The metrics with delta aggregation temporality are translated separately, so that their
+	// series are flagged as delta and converted to cumulative by the ingesters.
+	deltaMD := pmetric.NewMetrics()
+	if limits != nil && limits.OTelDeltaTemporalityConversionEnabled(userID) {
+		moveDeltaMetrics(md, deltaMD)
+	}
+
+	// Remove the metrics which can't be translated to Prometheus series, so that only the supported ones
+	// are ingested while the rejected ones are reported back to the client.
+	rejectUnsupportedMetrics(md, func(reason string, dataPoints int, msg string) {
+		switch reason {
+		case otelUnsupportedMetricType:
+			discarded.unsupportedMetricType.WithLabelValues(userID).Add(float64(dataPoints))
+		case otelUnsupportedTemporality:
+			discarded.unsupportedTemporality.WithLabelValues(userID).Add(float64(dataPoints))
 		}
+		rejections.add(dataPoints, msg)
+	})
 
-		dropped := len(multierr.Errors(errs))
-		discardedDueToOtelParseError.WithLabelValues(userID).Add(float64(dropped))
+	tsMap, errs := prometheusremotewrite.FromMetrics(md, prometheusremotewrite.Settings{})
+	deltaTsMap, deltaErrs := prometheusremotewrite.FromMetrics(deltaMD, prometheusremotewrite.Settings{})
+	errs = multierr.Append(errs, deltaErrs)
 
-		parseErrs := errs.Error()
-		if len(parseErrs) > maxErrMsgLen {
-			parseErrs = parseErrs[:maxErrMsgLen]
-		}
+	if errs != nil {
+		parseErrs := multierr.Errors(errs)
+		discarded.parseError.WithLabelValues(userID).Add(float64(len(parseErrs)))
 
-		if len(tsMap) == 0 {
-			return nil, errors.New(parseErrs)
+		for _, parseErr := range parseErrs {
+			// The translation errors are about metrics without data points, so no data point is rejected.
+			rejections.add(0, parseErr.Error())
 		}
+	}
 
-		level.Warn(logger).Log("msg", "OTLP parse error", "err", parseErrs)
+	if rejections.errMsg.Len() > 0 {
+		level.Warn(logger).Log("msg", "OTLP parse error", "rejected_data_points", rejections.dataPoints, "err", rejections.message())
 	}
 
 	mimirTs := mimirpb.PreallocTimeseriesSliceFromPool()
 	for _, promTs := range tsMap {
 		mimirTs = append(mimirTs, promToMimirTimeseries(promTs))
 	}
+	for _, promTs := range deltaTsMap {
+		ts := promToMimirTimeseries(promTs)
+		// The series of the resource attributes is a gauge, even if added for the delta metrics.
+		ts.Delta = mimirpb.FromLabelAdaptersToLabels(ts.Labels).Get(model.MetricNameLabel) != otelTargetInfoMetricName
+		mimirTs = append(mimirTs, ts)
+	}
 
 	return mimirTs, nil
 }
 
+// moveDeltaMetrics moves the sums and histograms with delta aggregation temporality from src to dst, setting
+// their aggregation temporality to cumulative so that they can be translated to Prometheus series. The resource
+// and instrumentation scope of each moved metric are copied to dst.
+func moveDeltaMetrics(src, dst pmetric.Metrics) {
+	resourceMetricsSlice := src.ResourceMetrics()
+	for i := 0; i < resourceMetricsSlice.Len(); i++ {
+		resourceMetrics := resourceMetricsSlice.At(i)
+		scopeMetricsSlice := resourceMetrics.ScopeMetrics()
+		for j := 0; j < scopeMetricsSlice.Len(); j++ {
+			scopeMetrics := scopeMetricsSlice.At(j)
+
+			var dstMetrics *pmetric.MetricSlice
+			scopeMetrics.Metrics().RemoveIf(func(metric pmetric.Metric) bool {
+				if !isDeltaMetric(metric) {
+					return false
+				}
+
+				if dstMetrics == nil {
+					dstResourceMetrics := dst.ResourceMetrics().AppendEmpty()
+					resourceMetrics.Resource().CopyTo(dstResourceMetrics.Resource())
+					dstScopeMetrics := dstResourceMetrics.ScopeMetrics().AppendEmpty()
+					scopeMetrics.Scope().CopyTo(dstScopeMetrics.Scope())
+					metrics := dstScopeMetrics.Metrics()
+					dstMetrics = &metrics
+				}
+
+				dstMetric := dstMetrics.AppendEmpty()
+				metric.MoveTo(dstMetric)
+				if dstMetric.DataType() == pmetric.MetricDataTypeSum {
+					dstMetric.Sum().SetAggregationTemporality(pmetric.MetricAggregationTemporalityCumulative)
+				} else {
+					dstMetric.Histogram().SetAggregationTemporality(pmetric.MetricAggregationTemporalityCumulative)
+				}
+				return true
+			})
+		}
+	}
+}
+
+func isDeltaMetric(metric pmetric.Metric) bool {
+	switch metric.DataType() {
+	case pmetric.MetricDataTypeSum:
+		return metric.Sum().AggregationTemporality() == pmetric.MetricAggregationTemporalityDelta
+	case pmetric.MetricDataTypeHistogram:
+		return metric.Histogram().AggregationTemporality() == pmetric.MetricAggregationTemporalityDelta
+	}
+	return false
+}
+
+// rejectUnsupportedMetrics removes from md the metrics whose type or aggregation temporality can't be
+// translated to Prometheus series, calling onReject for each of them.
+func rejectUnsupportedMetrics(md pmetric.Metrics, onReject func(reason string, dataPoints int, msg string)) {
+	resourceMetricsSlice := md.ResourceMetrics()
+	for i := 0; i < resourceMetricsSlice.Len(); i++ {
+		scopeMetricsSlice := resourceMetricsSlice.At(i).ScopeMetrics()
+		for j := 0; j < scopeMetricsSlice.Len(); j++ {
+			scopeMetricsSlice.At(j).Metrics().RemoveIf(func(metric pmetric.Metric) bool {
+				switch metric.DataType() {
+				case pmetric.MetricDataTypeGauge, pmetric.MetricDataTypeSummary:
+					return false
+
+				case pmetric.MetricDataTypeSum:
+					if metric.Sum().AggregationTemporality() == pmetric.MetricAggregationTemporalityCumulative {
+						return false
+					}
+					onReject(otelUnsupportedTemporality, metric.Sum().DataPoints().Len(), unsupportedTemporalityMsg(metric, metric.Sum().AggregationTemporality()))
+					return true
+
+				case pmetric.MetricDataTypeHistogram:
+					if metric.Histogram().AggregationTemporality() == pmetric.MetricAggregationTemporalityCumulative {
+						return false
+					}
+					onReject(otelUnsupportedTemporality, metric.Histogram().DataPoints().Len(), unsupportedTemporalityMsg(metric, metric.Histogram().AggregationTemporality()))
+					return true
+
+				case pmetric.MetricDataTypeExponentialHistogram:
+					onReject(otelUnsupportedMetricType, metric.ExponentialHistogram().DataPoints().Len(),
+						fmt.Sprintf("metric %s: exponential histograms are not supported, configure the exporter, or the connector generating the metric (for example spanmetrics), to use explicit bucket histograms", metric.Name()))
+					return true
+
+				default:
+					onReject(otelUnsupportedMetricType, 0, fmt.Sprintf("metric %s: unsupported metric type %s", metric.Name(), metric.DataType()))
+					return true
+				}
+			})
+		}
+	}
+}
+
+func unsupportedTemporalityMsg(metric pmetric.Metric, temporality pmetric.MetricAggregationTemporality) string {
+	name := "unspecified"
+	if temporality == pmetric.MetricAggregationTemporalityDelta {
+		name = "delta"
+	}
+	return fmt.Sprintf("metric %s: %s aggregation temporality is not supported, configure the exporter, or the connector generating the metric (for example spanmetrics or count), to use cumulative temporality", metric.Name(), name)
+}
+
+// writeOTLPResponse writes the OTLP export response, encoded with the same content type of the request.
+// If some data points have been rejected, the response carries a partial success as defined by the OTLP spec.
+func writeOTLPResponse(w http.ResponseWriter, contentType string, rejections *otlpRejections) {
+	var (
+		body []byte
+		err  error
+	)
+
+	if contentType == jsonContentType {
+		body, err = marshalOTLPResponseJSON(rejections)
+	} else {
+		contentType = pbContentType
+		body, err = marshalOTLPResponseProto(rejections)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// otlpPartialSuccessJSON is the JSON encoding of the ExportMetricsPartialSuccess message, which isn't
+// supported by the vendored OTLP protobuf definitions.
+type otlpPartialSuccessJSON struct {
+	RejectedDataPoints int64  `json:"rejectedDataPoints,string,omitempty"`
+	ErrorMessage       string `json:"errorMessage,omitempty"`
+}
+
+func marshalOTLPResponseJSON(rejections *otlpRejections) ([]byte, error) {
+	if rejections.errMsg.Len() == 0 {
+		return pmetricotlp.NewResponse().MarshalJSON()
+	}
+
+	return json.Marshal(struct {
+		PartialSuccess otlpPartialSuccessJSON `json:"partialSuccess"`
+	}{
+		PartialSuccess: otlpPartialSuccessJSON{
+			RejectedDataPoints: rejections.dataPoints,
+			ErrorMessage:       rejections.message(),
+		},
+	})
+}
+
+func marshalOTLPResponseProto(rejections *otlpRejections) ([]byte, error) {
+	body, err := pmetricotlp.NewResponse().MarshalProto()
+	if err != nil || rejections.errMsg.Len() == 0 {
+		return body, err
+	}
+
+	// ExportMetricsPartialSuccess { int64 rejected_data_points = 1; string error_message = 2; }
+	var partialSuccess []byte
+	if rejections.dataPoints > 0 {
+		partialSuccess = protowire.AppendTag(partialSuccess, 1, protowire.VarintType)
+		partialSuccess = protowire.AppendVarint(partialSuccess, uint64(rejections.dataPoints))
+	}
+	partialSuccess = protowire.AppendTag(partialSuccess, 2, protowire.BytesType)
+	partialSuccess = protowire.AppendString(partialSuccess, rejections.message())
+
+	// ExportMetricsServiceResponse { ExportMetricsPartialSuccess partial_success = 1; }
+	body = protowire.AppendTag(body, 1, protowire.BytesType)
+	return protowire.AppendBytes(body, partialSuccess), nil
+}
+
 func promToMimirTimeseries(promTs *prompb.TimeSeries) mimirpb.PreallocTimeseries {
 	labels := make([]mimirpb.LabelAdapter, 0, len(promTs.Labels))
 	for _, label := range promTs.Labels {
@@ -14,8 +14,10 @@ import (
 
 	"github.com/go-kit/log/level"
 	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/httpgrpc/server"
 	"github.com/weaveworks/common/middleware"
 
+	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/globalerror"
@@ -53,7 +55,7 @@ func Handler(
 			err = distributorMaxWriteMessageSizeErr{actual: int(r.ContentLength), limit: maxRecvMsgSize}
 		}
 		return res, err
-	})
+	}, nil)
 }
 
 type distributorMaxWriteMessageSizeErr struct {
@@ -73,6 +75,7 @@ func handler(maxRecvMsgSize int,
 	allowSkipLabelNameValidation bool,
 	push Func,
 	parser parserFunc,
+	onSuccess func(w http.ResponseWriter),
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -117,19 +120,46 @@ func handler(maxRecvMsgSize int,
 		req := newRequest(supplier)
 		if _, err := push(ctx, req); err != nil {
 			if errors.Is(err, context.Canceled) {
-				http.Error(w, err.Error(), statusClientClosedRequest)
+				WriteErrorResponse(w, r, err.Error(), statusClientClosedRequest)
 				level.Warn(logger).Log("msg", "push request canceled", "err", err)
 				return
 			}
 			resp, ok := httpgrpc.HTTPResponseFromError(err)
 			if !ok {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				WriteErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
 				return
 			}
 			if resp.GetCode() != 202 {
 				level.Error(logger).Log("msg", "push error", "err", err)
 			}
-			http.Error(w, string(resp.Body), int(resp.Code))
+			for _, h := range resp.Headers {
+				for _, v := range h.Values {
+					w.Header().Add(h.Key, v)
+				}
+			}
+			WriteErrorResponse(w, r, string(resp.Body), int(resp.Code))
+			return
+		}
+		if onSuccess != nil {
+			onSuccess(w)
 		}
 	})
 }
+
+// WriteErrorResponse writes the error response with the input message and status code, in the version of the
+// schema of the error responses negotiated by the request. Status codes not representing an error, like the 202
+// of the deduplicated samples, are always written in the legacy format.
+func WriteErrorResponse(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	version := apierror.NegotiateSchemaVersion(r.Header)
+	if version == apierror.SchemaVersionLegacy || code < http.StatusBadRequest {
+		http.Error(w, msg, code)
+		return
+	}
+
+	resp, ok := apierror.HTTPResponseFromErrorWithSchemaVersion(httpgrpc.Errorf(code, "%s", msg), version)
+	if !ok {
+		http.Error(w, msg, code)
+		return
+	}
+	_ = server.WriteResponse(w, resp)
+}
@@ -9,16 +9,20 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
@@ -28,8 +32,11 @@ import (
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"google.golang.org/protobuf/encoding/protowire"
 
+	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 )
 
 func TestHandler_remoteWrite(t *testing.T) {
@@ -43,7 +50,7 @@ func TestHandler_remoteWrite(t *testing.T) {
 func TestHandler_otlpWriteNoCompression(t *testing.T) {
 	req := createOTLPRequest(t, createOTLPMetricRequest(t), false)
 	resp := httptest.NewRecorder()
-	handler := OTLPHandler(100000, nil, false, nil, verifyWritePushFunc(t, mimirpb.API))
+	handler := OTLPHandler(100000, nil, false, nil, nil, verifyWritePushFunc(t, mimirpb.API))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, 200, resp.Code)
 }
@@ -88,7 +95,7 @@ func TestHandler_otlpDroppedMetricsPanic(t *testing.T) {
 
 	req := createOTLPRequest(t, pmetricotlp.NewRequestFromMetrics(md), false)
 	resp := httptest.NewRecorder()
-	handler := OTLPHandler(100000, nil, false, nil, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
+	handler := OTLPHandler(100000, nil, false, nil, nil, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
 		request, err := pushReq.WriteRequest()
 		assert.NoError(t, err)
 		assert.Len(t, request.Timeseries, 3)
@@ -128,7 +135,7 @@ func TestHandler_otlpDroppedMetricsPanic2(t *testing.T) {
 
 	req := createOTLPRequest(t, pmetricotlp.NewRequestFromMetrics(md), false)
 	resp := httptest.NewRecorder()
-	handler := OTLPHandler(100000, nil, false, nil, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
+	handler := OTLPHandler(100000, nil, false, nil, nil, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
 		request, err := pushReq.WriteRequest()
 		assert.NoError(t, err)
 		assert.Len(t, request.Timeseries, 2)
@@ -154,7 +161,7 @@ func TestHandler_otlpDroppedMetricsPanic2(t *testing.T) {
 
 	req = createOTLPRequest(t, pmetricotlp.NewRequestFromMetrics(md), false)
 	resp = httptest.NewRecorder()
-	handler = OTLPHandler(100000, nil, false, nil, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
+	handler = OTLPHandler(100000, nil, false, nil, nil, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
 		request, err := pushReq.WriteRequest()
 		assert.NoError(t, err)
 		assert.Len(t, request.Timeseries, 10) // 6 buckets (including +Inf) + 2 sum/count + 2 from the first case
@@ -166,10 +173,236 @@ func TestHandler_otlpDroppedMetricsPanic2(t *testing.T) {
 	assert.Equal(t, 200, resp.Code)
 }
 
+func TestHandler_otlpPartialSuccess(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("gauge")
+	gauge.SetDataType(pmetric.MetricDataTypeGauge)
+	for i := 0; i < 2; i++ {
+		datapoint := gauge.Gauge().DataPoints().AppendEmpty()
+		datapoint.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		datapoint.SetDoubleVal(float64(i))
+		datapoint.Attributes().InsertString("idx", fmt.Sprint(i))
+	}
+
+	deltaSum := metrics.AppendEmpty()
+	deltaSum.SetName("calls_total")
+	deltaSum.SetDataType(pmetric.MetricDataTypeSum)
+	deltaSum.Sum().SetAggregationTemporality(pmetric.MetricAggregationTemporalityDelta)
+	for i := 0; i < 3; i++ {
+		deltaSum.Sum().DataPoints().AppendEmpty().SetIntVal(1)
+	}
+
+	expHistogram := metrics.AppendEmpty()
+	expHistogram.SetName("duration_seconds")
+	expHistogram.SetDataType(pmetric.MetricDataTypeExponentialHistogram)
+	expHistogram.ExponentialHistogram().DataPoints().AppendEmpty().SetCount(1)
+
+	emptyGauge := metrics.AppendEmpty()
+	emptyGauge.SetName("empty")
+	emptyGauge.SetDataType(pmetric.MetricDataTypeGauge)
+
+	expectedErrMsg := "metric calls_total: delta aggregation temporality is not supported, configure the exporter, or the connector generating the metric (for example spanmetrics or count), to use cumulative temporality; " +
+		"metric duration_seconds: exponential histograms are not supported, configure the exporter, or the connector generating the metric (for example spanmetrics), to use explicit bucket histograms; " +
+		"invalid temporality and type combination"
+
+	for _, contentType := range []string{pbContentType, jsonContentType} {
+		t.Run(contentType, func(t *testing.T) {
+			req := createOTLPRequest(t, pmetricotlp.NewRequestFromMetrics(md), false)
+			if contentType == jsonContentType {
+				body, err := pmetricotlp.NewRequestFromMetrics(md).MarshalJSON()
+				require.NoError(t, err)
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				req.ContentLength = int64(len(body))
+				req.Header.Set("Content-Type", jsonContentType)
+			}
+
+			reg := prometheus.NewPedanticRegistry()
+			resp := httptest.NewRecorder()
+			handler := OTLPHandler(100000, nil, false, nil, reg, func(ctx context.Context, pushReq *Request) (response *mimirpb.WriteResponse, err error) {
+				request, err := pushReq.WriteRequest()
+				assert.NoError(t, err)
+				assert.Len(t, request.Timeseries, 2)
+				pushReq.CleanUp()
+				return &mimirpb.WriteResponse{}, nil
+			})
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, contentType, resp.Header().Get("Content-Type"))
+
+			rejected, errMsg := decodeOTLPPartialSuccess(t, contentType, resp.Body.Bytes())
+			assert.Equal(t, int64(4), rejected)
+			assert.Equal(t, expectedErrMsg, errMsg)
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+				# TYPE cortex_discarded_samples_total counter
+				cortex_discarded_samples_total{reason="otlp_parse_error",user="test"} 1
+				cortex_discarded_samples_total{reason="otlp_unsupported_metric_type",user="test"} 1
+				cortex_discarded_samples_total{reason="otlp_unsupported_temporality",user="test"} 3
+			`), "cortex_discarded_samples_total"))
+		})
+	}
+
+	t.Run("should return an empty response if no data point has been rejected", func(t *testing.T) {
+		for _, contentType := range []string{pbContentType, jsonContentType} {
+			resp := httptest.NewRecorder()
+			writeOTLPResponse(resp, contentType, &otlpRejections{})
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			rejected, errMsg := decodeOTLPPartialSuccess(t, contentType, resp.Body.Bytes())
+			assert.Zero(t, rejected)
+			assert.Empty(t, errMsg)
+		}
+	})
+}
+
+func TestHandler_otlpDeltaTemporality(t *testing.T) {
+	md := pmetric.NewMetrics()
+	resourceMetrics := md.ResourceMetrics().AppendEmpty()
+	resourceMetrics.Resource().Attributes().InsertString("service.name", "app")
+	metrics := resourceMetrics.ScopeMetrics().AppendEmpty().Metrics()
+
+	cumulativeSum := metrics.AppendEmpty()
+	cumulativeSum.SetName("requests_total")
+	cumulativeSum.SetDataType(pmetric.MetricDataTypeSum)
+	cumulativeSum.Sum().SetAggregationTemporality(pmetric.MetricAggregationTemporalityCumulative)
+	cumulativeSum.Sum().SetIsMonotonic(true)
+	cumulativeSum.Sum().DataPoints().AppendEmpty().SetIntVal(10)
+
+	deltaSum := metrics.AppendEmpty()
+	deltaSum.SetName("calls_total")
+	deltaSum.SetDataType(pmetric.MetricDataTypeSum)
+	deltaSum.Sum().SetAggregationTemporality(pmetric.MetricAggregationTemporalityDelta)
+	deltaSum.Sum().SetIsMonotonic(true)
+	deltaSum.Sum().DataPoints().AppendEmpty().SetIntVal(1)
+
+	deltaHistogram := metrics.AppendEmpty()
+	deltaHistogram.SetName("duration_seconds")
+	deltaHistogram.SetDataType(pmetric.MetricDataTypeHistogram)
+	deltaHistogram.Histogram().SetAggregationTemporality(pmetric.MetricAggregationTemporalityDelta)
+	datapoint := deltaHistogram.Histogram().DataPoints().AppendEmpty()
+	datapoint.SetCount(2)
+	datapoint.SetSum(3)
+	datapoint.SetMExplicitBounds([]float64{1})
+	datapoint.SetMBucketCounts([]uint64{1, 1})
+
+	tests := map[string]struct {
+		conversionEnabled  bool
+		expectedDelta      map[string]bool
+		expectedRejections int64
+	}{
+		"delta temporality conversion disabled": {
+			expectedDelta:      map[string]bool{"requests_total": false, "target": false},
+			expectedRejections: 2,
+		},
+		"delta temporality conversion enabled": {
+			conversionEnabled: true,
+			expectedDelta: map[string]bool{
+				"requests_total":          false,
+				"target":                  false,
+				"calls_total":             true,
+				"duration_seconds_count":  true,
+				"duration_seconds_sum":    true,
+				"duration_seconds_bucket": true,
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// The request is translated in place, so each test case needs its own copy.
+			testMD := md.Clone()
+
+			resp := httptest.NewRecorder()
+			handler := OTLPHandler(100000, nil, false, otlpLimitsMock{deltaConversionEnabled: testData.conversionEnabled}, nil, func(ctx context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+				request, err := pushReq.WriteRequest()
+				require.NoError(t, err)
+
+				actualDelta := map[string]bool{}
+				for _, ts := range request.Timeseries {
+					actualDelta[mimirpb.FromLabelAdaptersToLabels(ts.Labels).Get(model.MetricNameLabel)] = ts.Delta
+				}
+				assert.Equal(t, testData.expectedDelta, actualDelta)
+
+				pushReq.CleanUp()
+				return &mimirpb.WriteResponse{}, nil
+			})
+			handler.ServeHTTP(resp, createOTLPRequest(t, pmetricotlp.NewRequestFromMetrics(testMD), false))
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			rejected, _ := decodeOTLPPartialSuccess(t, pbContentType, resp.Body.Bytes())
+			assert.Equal(t, testData.expectedRejections, rejected)
+		})
+	}
+}
+
+type otlpLimitsMock struct {
+	deltaConversionEnabled bool
+}
+
+func (m otlpLimitsMock) OTelDeltaTemporalityConversionEnabled(string) bool {
+	return m.deltaConversionEnabled
+}
+
+// decodeOTLPPartialSuccess decodes the partial success of an OTLP export response.
+func decodeOTLPPartialSuccess(t *testing.T, contentType string, body []byte) (rejected int64, errMsg string) {
+	t.Helper()
+
+	if contentType == jsonContentType {
+		var resp struct {
+			PartialSuccess struct {
+				RejectedDataPoints int64  `json:"rejectedDataPoints,string"`
+				ErrorMessage       string `json:"errorMessage"`
+			} `json:"partialSuccess"`
+		}
+		require.NoError(t, json.Unmarshal(body, &resp))
+		return resp.PartialSuccess.RejectedDataPoints, resp.PartialSuccess.ErrorMessage
+	}
+
+	consumeFields := func(b []byte, fn func(num protowire.Number, value []byte, varint uint64)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+
+			switch typ {
+			case protowire.VarintType:
+				v, n := protowire.ConsumeVarint(b)
+				require.GreaterOrEqual(t, n, 0)
+				fn(num, nil, v)
+				b = b[n:]
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(b)
+				require.GreaterOrEqual(t, n, 0)
+				fn(num, v, 0)
+				b = b[n:]
+			default:
+				require.Fail(t, "unexpected wire type", typ)
+			}
+		}
+	}
+
+	consumeFields(body, func(num protowire.Number, partialSuccess []byte, _ uint64) {
+		require.Equal(t, protowire.Number(1), num)
+		consumeFields(partialSuccess, func(num protowire.Number, value []byte, varint uint64) {
+			switch num {
+			case 1:
+				rejected = int64(varint)
+			case 2:
+				errMsg = string(value)
+			}
+		})
+	})
+	return rejected, errMsg
+}
+
 func TestHandler_otlpWriteWithCompression(t *testing.T) {
 	req := createOTLPRequest(t, createOTLPMetricRequest(t), true)
 	resp := httptest.NewRecorder()
-	handler := OTLPHandler(100000, nil, false, nil, verifyWritePushFunc(t, mimirpb.API))
+	handler := OTLPHandler(100000, nil, false, nil, nil, verifyWritePushFunc(t, mimirpb.API))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, 200, resp.Code)
 }
@@ -179,7 +412,7 @@ func TestHandler_otlpWriteRequestTooBigNoCompression(t *testing.T) {
 	resp := httptest.NewRecorder()
 
 	// This one is caught in the r.ContentLength check.
-	handler := OTLPHandler(30, nil, false, nil, readBodyPushFunc(t))
+	handler := OTLPHandler(30, nil, false, nil, nil, readBodyPushFunc(t))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
 	assert.Contains(t, resp.Body.String(), "the incoming push request has been rejected because its message size of 37 bytes is larger than the allowed limit of 30 bytes (err-mimir-distributor-max-write-message-size). To adjust the related limit, configure -distributor.max-recv-msg-size, or contact your service administrator.")
@@ -202,7 +435,7 @@ func TestHandler_otlpWriteRequestTooBigWithCompression(t *testing.T) {
 
 	resp := httptest.NewRecorder()
 
-	handler := OTLPHandler(140, nil, false, nil, readBodyPushFunc(t))
+	handler := OTLPHandler(140, nil, false, nil, nil, readBodyPushFunc(t))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
 	body, err := io.ReadAll(resp.Body)
@@ -215,7 +448,7 @@ func TestHandler_otlpWriteRequestWithUnSupportedCompression(t *testing.T) {
 	req.Header.Set("Content-Encoding", "snappy")
 
 	resp := httptest.NewRecorder()
-	handler := OTLPHandler(100000, nil, false, nil, readBodyPushFunc(t))
+	handler := OTLPHandler(100000, nil, false, nil, nil, readBodyPushFunc(t))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
 }
@@ -530,6 +763,7 @@ func TestHandler_ErrorTranslation(t *testing.T) {
 		name               string
 		err                error
 		expectedHTTPStatus int
+		expectedHeaders    map[string]string
 	}{
 		{
 			name:               "a generic error gets an HTTP 400",
@@ -541,6 +775,16 @@ func TestHandler_ErrorTranslation(t *testing.T) {
 			err:                httpgrpc.Errorf(http.StatusRequestEntityTooLarge, "too big"),
 			expectedHTTPStatus: http.StatusRequestEntityTooLarge,
 		},
+		{
+			name: "the headers of an HTTP gRPC error are copied to the HTTP response",
+			err: httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+				Code:    http.StatusTooManyRequests,
+				Body:    []byte("slow down"),
+				Headers: []*httpgrpc.Header{{Key: "Retry-After", Values: []string{"2"}}},
+			}),
+			expectedHTTPStatus: http.StatusTooManyRequests,
+			expectedHeaders:    map[string]string{"Retry-After": "2"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -554,12 +798,91 @@ func TestHandler_ErrorTranslation(t *testing.T) {
 				return nil, err
 			}
 
-			h := handler(10, nil, false, pushFunc, parserFunc)
+			h := handler(10, nil, false, pushFunc, parserFunc, nil)
 
 			recorder := httptest.NewRecorder()
 			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/push", bufCloser{&bytes.Buffer{}}))
 
 			assert.Equal(t, tc.expectedHTTPStatus, recorder.Code)
+			for name, value := range tc.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name))
+			}
+		})
+	}
+}
+
+func TestHandler_VersionedErrorSchema(t *testing.T) {
+	testCases := []struct {
+		name               string
+		schemaVersion      string
+		err                error
+		expectedHTTPStatus int
+		expectedBody       string
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "the legacy schema is used if the client doesn't negotiate a version",
+			err:                httpgrpc.Errorf(http.StatusBadRequest, "bad request"),
+			expectedHTTPStatus: http.StatusBadRequest,
+			expectedBody:       "bad request\n",
+		},
+		{
+			name:               "a generic error gets a generic error code",
+			schemaVersion:      "1",
+			err:                fmt.Errorf("something's wrong"),
+			expectedHTTPStatus: http.StatusBadRequest,
+			expectedBody:       `{"status":"error","errorType":"bad_data","errorCode":"err-mimir-bad-data","error":"something's wrong"}`,
+		},
+		{
+			name:               "an error with an ID gets the code of the ID",
+			schemaVersion:      "1",
+			err:                httpgrpc.Errorf(http.StatusBadRequest, globalerror.SampleOutOfOrder.Message("the sample has been rejected")),
+			expectedHTTPStatus: http.StatusBadRequest,
+			expectedBody:       `{"status":"error","errorType":"bad_data","errorCode":"err-mimir-sample-out-of-order","error":"the sample has been rejected (err-mimir-sample-out-of-order)"}`,
+		},
+		{
+			name:          "the latest version is used if the client supports a newer one, and the headers of the error are kept",
+			schemaVersion: "100",
+			err: httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+				Code:    http.StatusTooManyRequests,
+				Body:    []byte("slow down"),
+				Headers: []*httpgrpc.Header{{Key: "Retry-After", Values: []string{"2"}}},
+			}),
+			expectedHTTPStatus: http.StatusTooManyRequests,
+			expectedBody:       `{"status":"error","errorType":"too_many_requests","errorCode":"err-mimir-too-many-requests","error":"slow down"}`,
+			expectedHeaders:    map[string]string{"Retry-After": "2", apierror.SchemaVersionHeader: "1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parserFunc := func(context.Context, *http.Request, int, []byte, *mimirpb.PreallocWriteRequest) ([]byte, error) {
+				return nil, tc.err
+			}
+			pushFunc := func(ctx context.Context, req *Request) (*mimirpb.WriteResponse, error) {
+				_, err := req.WriteRequest() // just read the body so we can trigger the parser
+				return nil, err
+			}
+
+			h := handler(10, nil, false, pushFunc, parserFunc, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/push", bufCloser{&bytes.Buffer{}})
+			if tc.schemaVersion != "" {
+				req.Header.Set(apierror.SchemaVersionHeader, tc.schemaVersion)
+			}
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedHTTPStatus, recorder.Code)
+			if tc.schemaVersion == "" {
+				assert.Equal(t, tc.expectedBody, recorder.Body.String())
+			} else {
+				assert.JSONEq(t, tc.expectedBody, recorder.Body.String())
+			}
+			for name, value := range tc.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name))
+			}
 		})
 	}
 }
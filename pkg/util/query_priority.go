@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+// QueryPriorityHeader is the HTTP header used to set the priority of a query, such as high for the queries
+// evaluating the rules, which are dequeued by the query-scheduler before the lower priority queries of the tenant.
+const QueryPriorityHeader = "X-Mimir-Query-Priority"
+
+type queryPriorityCtxKey struct{}
+
+var queryPriorityKey = &queryPriorityCtxKey{}
+
+// QueryPriorityFromHTTPGRPCRequest returns the query priority set in the headers of the input request, if any.
+func QueryPriorityFromHTTPGRPCRequest(req *httpgrpc.HTTPRequest) string {
+	for _, h := range req.GetHeaders() {
+		if http.CanonicalHeaderKey(h.Key) == QueryPriorityHeader && len(h.Values) > 0 {
+			return h.Values[0]
+		}
+	}
+	return ""
+}
+
+// ContextWithQueryPriority returns a new context carrying the input query priority.
+func ContextWithQueryPriority(ctx context.Context, priority string) context.Context {
+	if priority == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, queryPriorityKey, priority)
+}
+
+// QueryPriorityFromContext returns the query priority carried by the context, if any.
+func QueryPriorityFromContext(ctx context.Context) string {
+	priority, _ := ctx.Value(queryPriorityKey).(string)
+	return priority
+}
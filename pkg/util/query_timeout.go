@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// QueryTimeoutHeader is the HTTP header used by the clients to set a query timeout lower than the one configured
+	// in Mimir, such as for interactive UIs giving up early. It's either a duration (e.g. 30s) or a number of seconds.
+	QueryTimeoutHeader = "X-Mimir-Query-Timeout"
+
+	// QuerySoftTimeoutHeader is the HTTP header used by the clients to opt in to partial results: once the soft
+	// timeout expires, the query-frontend returns the results received so far, annotated with a warning, and
+	// cancels the rest of the query. It has the same format as QueryTimeoutHeader.
+	QuerySoftTimeoutHeader = "X-Mimir-Query-Soft-Timeout"
+)
+
+type querySoftDeadlineCtxKey struct{}
+
+var querySoftDeadlineKey = &querySoftDeadlineCtxKey{}
+
+type queryTimeoutCtxKey struct{}
+
+var queryTimeoutKey = &queryTimeoutCtxKey{}
+
+// QueryTimeoutFromHTTPHeaders returns the query timeout set in the input HTTP headers, or 0 if not set.
+func QueryTimeoutFromHTTPHeaders(headers http.Header) (time.Duration, error) {
+	return parseQueryTimeoutHeader(headers, QueryTimeoutHeader)
+}
+
+// QuerySoftTimeoutFromHTTPHeaders returns the query soft timeout set in the input HTTP headers, or 0 if not set.
+func QuerySoftTimeoutFromHTTPHeaders(headers http.Header) (time.Duration, error) {
+	return parseQueryTimeoutHeader(headers, QuerySoftTimeoutHeader)
+}
+
+func parseQueryTimeoutHeader(headers http.Header, name string) (time.Duration, error) {
+	value := headers.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	var timeout time.Duration
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		timeout = time.Duration(seconds * float64(time.Second))
+	} else if d, err := model.ParseDuration(value); err == nil {
+		timeout = time.Duration(d)
+	} else {
+		return 0, fmt.Errorf("invalid %s header %q: must be a duration or a number of seconds", name, value)
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s header %q: must be greater than 0", name, value)
+	}
+	return timeout, nil
+}
+
+// ContextWithQueryTimeoutFromHTTPHeaders returns a new context with the query timeout set in the input
+// HTTP headers, if any, and the function to release its resources.
+func ContextWithQueryTimeoutFromHTTPHeaders(ctx context.Context, headers http.Header) (context.Context, context.CancelFunc, error) {
+	timeout, err := QueryTimeoutFromHTTPHeaders(headers)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	if timeout == 0 {
+		return ctx, func() {}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, queryTimeoutKey, timeout), timeout)
+	return ctx, cancel, nil
+}
+
+// QueryTimeoutFromContext returns the query timeout set in the HTTP headers and applied to the context
+// by ContextWithQueryTimeoutFromHTTPHeaders, if any.
+func QueryTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(queryTimeoutKey).(time.Duration)
+	return timeout, ok
+}
+
+// InjectQueryTimeoutIntoHTTPHeaders sets the time left until the deadline of the context, if any, as the
+// query timeout in the input HTTP headers, so that the downstream gives up on the query at the same time.
+func InjectQueryTimeoutIntoHTTPHeaders(ctx context.Context, headers http.Header) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	if left := time.Until(deadline); left > 0 {
+		headers.Set(QueryTimeoutHeader, strconv.FormatFloat(left.Seconds(), 'f', 3, 64))
+	}
+}
+
+// QueryTimeoutMiddleware applies the query timeout set in the request headers, if any, to the request context.
+func QueryTimeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel, err := ContextWithQueryTimeoutFromHTTPHeaders(r.Context(), r.Header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ContextWithQuerySoftDeadline returns a new context carrying the input query soft deadline.
+func ContextWithQuerySoftDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, querySoftDeadlineKey, deadline)
+}
+
+// QuerySoftDeadlineFromContext returns the query soft deadline carried by the context, if any.
+func QuerySoftDeadlineFromContext(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(querySoftDeadlineKey).(time.Time)
+	return deadline, ok
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTimeoutFromHTTPHeaders(t *testing.T) {
+	tests := map[string]struct {
+		value           string
+		expectedTimeout time.Duration
+		expectedErr     bool
+	}{
+		"should return 0 if the header is not set": {
+			value:           "",
+			expectedTimeout: 0,
+		},
+		"should parse a duration": {
+			value:           "1m30s",
+			expectedTimeout: 90 * time.Second,
+		},
+		"should parse a number of seconds": {
+			value:           "2.5",
+			expectedTimeout: 2500 * time.Millisecond,
+		},
+		"should fail on an invalid value": {
+			value:       "soon",
+			expectedErr: true,
+		},
+		"should fail on a non-positive value": {
+			value:       "0",
+			expectedErr: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			headers := http.Header{}
+			if testData.value != "" {
+				headers.Set(QueryTimeoutHeader, testData.value)
+			}
+
+			timeout, err := QueryTimeoutFromHTTPHeaders(headers)
+			if testData.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedTimeout, timeout)
+		})
+	}
+}
+
+func TestContextWithQueryTimeoutFromHTTPHeaders(t *testing.T) {
+	ctx, cancel, err := ContextWithQueryTimeoutFromHTTPHeaders(context.Background(), http.Header{})
+	require.NoError(t, err)
+	defer cancel()
+
+	_, ok := QueryTimeoutFromContext(ctx)
+	assert.False(t, ok)
+
+	ctx, cancel, err = ContextWithQueryTimeoutFromHTTPHeaders(context.Background(), http.Header{QueryTimeoutHeader: []string{"10s"}})
+	require.NoError(t, err)
+	defer cancel()
+
+	timeout, ok := QueryTimeoutFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, timeout)
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, time.Second)
+}
+
+func TestInjectQueryTimeoutIntoHTTPHeaders(t *testing.T) {
+	headers := http.Header{}
+	InjectQueryTimeoutIntoHTTPHeaders(context.Background(), headers)
+	assert.Empty(t, headers.Get(QueryTimeoutHeader))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	InjectQueryTimeoutIntoHTTPHeaders(ctx, headers)
+	seconds, err := strconv.ParseFloat(headers.Get(QueryTimeoutHeader), 64)
+	require.NoError(t, err)
+	assert.InDelta(t, 60, seconds, 1)
+}
+
+func TestQueryTimeoutMiddleware(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	handler := QueryTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+	}))
+
+	// The request context should have no deadline if the header is not set.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/query", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, hasDeadline)
+
+	// The request context should have the deadline set in the header.
+	req := httptest.NewRequest("GET", "/api/v1/query", nil)
+	req.Header.Set(QueryTimeoutHeader, "10s")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, time.Second)
+
+	// The request should fail if the header is invalid.
+	req = httptest.NewRequest("GET", "/api/v1/query", nil)
+	req.Header.Set(QueryTimeoutHeader, "invalid")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
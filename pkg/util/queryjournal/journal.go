@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queryjournal
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+var (
+	errInvalidMaxFileSize = errors.New("the query journal max file size must be greater than 0")
+	errInvalidMaxFiles    = errors.New("the query journal max files must be greater than 0")
+	errInvalidSampleRate  = errors.New("the query journal sample rate must be greater than 0 and less than or equal to 1")
+)
+
+type Config struct {
+	Filepath         string  `yaml:"filepath" category:"experimental"`
+	MaxFileSizeBytes int64   `yaml:"max_file_size_bytes" category:"experimental"`
+	MaxFiles         int     `yaml:"max_files" category:"experimental"`
+	SampleRate       float64 `yaml:"sample_rate" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Filepath, prefix+"filepath", "", "File where every executed query is appended, one JSON object per line, for post-mortem analysis or to replay the workload offline. If empty, the query journal is disabled.")
+	f.Int64Var(&cfg.MaxFileSizeBytes, prefix+"max-file-size-bytes", 100*1024*1024, "Max size of the query journal file. When exceeded, the file is rotated.")
+	f.IntVar(&cfg.MaxFiles, prefix+"max-files", 10, "Max number of query journal files to keep, including the one currently written. The oldest rotated files are deleted.")
+	f.Float64Var(&cfg.SampleRate, prefix+"sample-rate", 1, "Ratio of the successful queries recorded in the query journal. The failed queries are always recorded.")
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Filepath == "" {
+		return nil
+	}
+	if cfg.MaxFileSizeBytes <= 0 {
+		return errInvalidMaxFileSize
+	}
+	if cfg.MaxFiles <= 0 {
+		return errInvalidMaxFiles
+	}
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		return errInvalidSampleRate
+	}
+	return nil
+}
+
+// Entry is an executed query recorded in the journal.
+type Entry struct {
+	Timestamp          time.Time     `json:"ts"`
+	Tenant             string        `json:"tenant"`
+	Method             string        `json:"method"`
+	Path               string        `json:"path"`
+	Query              string        `json:"query,omitempty"`
+	Start              string        `json:"start,omitempty"`
+	End                string        `json:"end,omitempty"`
+	Step               string        `json:"step,omitempty"`
+	Time               string        `json:"time,omitempty"`
+	ResponseTime       time.Duration `json:"response_time_ns"`
+	WallTime           time.Duration `json:"wall_time_ns"`
+	FetchedSeriesCount uint64        `json:"fetched_series_count"`
+	FetchedChunksCount uint64        `json:"fetched_chunks_count"`
+	FetchedChunkBytes  uint64        `json:"fetched_chunk_bytes"`
+	FetchedIndexBytes  uint64        `json:"fetched_index_bytes"`
+	ShardedQueries     uint32        `json:"sharded_queries"`
+	SplitQueries       uint32        `json:"split_queries"`
+	Status             string        `json:"status"`
+	Error              string        `json:"error,omitempty"`
+}
+
+// Journal appends the executed queries to a local file, in JSON lines format, rotating it when it
+// exceeds the max size. Nil journal ignores all calls to its public API.
+type Journal struct {
+	cfg    Config
+	logger log.Logger
+
+	mtx  sync.Mutex
+	file *os.File
+	size int64
+
+	// sample returns a random number in [0, 1) to decide whether a successful query is recorded.
+	sample func() float64
+
+	entriesWritten    prometheus.Counter
+	entriesSampledOut prometheus.Counter
+	writeFailures     prometheus.Counter
+	rotations         prometheus.Counter
+}
+
+// NewJournal makes a new Journal, appending to the existing file, if any. It returns nil if the journal is disabled.
+func NewJournal(cfg Config, logger log.Logger, reg prometheus.Registerer) (*Journal, error) {
+	if cfg.Filepath == "" {
+		return nil, nil
+	}
+
+	j := &Journal{
+		cfg:    cfg,
+		logger: log.With(logger, "component", "query-journal"),
+		sample: rand.Float64,
+		entriesWritten: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_journal_entries_written_total",
+			Help: "Total number of queries recorded in the query journal.",
+		}),
+		entriesSampledOut: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_journal_entries_sampled_out_total",
+			Help: "Total number of successful queries not recorded in the query journal because of the sampling.",
+		}),
+		writeFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_journal_write_failures_total",
+			Help: "Total number of queries failed to be recorded in the query journal.",
+		}),
+		rotations: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_journal_rotations_total",
+			Help: "Total number of times the query journal file has been rotated.",
+		}),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Filepath), os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, "failed to create the query journal directory")
+	}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Record appends the entry to the journal. The successful queries are sampled, while the failed ones are always recorded.
+func (j *Journal) Record(e Entry) {
+	if j == nil {
+		return
+	}
+	if e.Status != StatusFailed && j.sample() >= j.cfg.SampleRate {
+		j.entriesSampledOut.Inc()
+		return
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		j.writeFailures.Inc()
+		level.Warn(j.logger).Log("msg", "failed to encode query journal entry", "err", err)
+		return
+	}
+	data = append(data, '\n')
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if j.file == nil {
+		// The journal has been closed, or the file couldn't be reopened after a failed rotation.
+		j.writeFailures.Inc()
+		return
+	}
+
+	if j.size > 0 && j.size+int64(len(data)) > j.cfg.MaxFileSizeBytes {
+		if err := j.rotate(); err != nil {
+			j.writeFailures.Inc()
+			level.Warn(j.logger).Log("msg", "failed to rotate query journal file", "err", err)
+			return
+		}
+	}
+
+	n, err := j.file.Write(data)
+	j.size += int64(n)
+	if err != nil {
+		j.writeFailures.Inc()
+		level.Warn(j.logger).Log("msg", "failed to write query journal entry", "err", err)
+		return
+	}
+	j.entriesWritten.Inc()
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// open opens the journal file in append mode. Must be called with the lock held, if the journal is in use.
+func (j *Journal) open() error {
+	file, err := os.OpenFile(j.cfg.Filepath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the query journal file")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrap(err, "failed to stat the query journal file")
+	}
+
+	j.file = file
+	j.size = info.Size()
+	return nil
+}
+
+// rotate renames the journal file to <filepath>.1, shifting the previously rotated files by one and
+// deleting the ones exceeding the max files, then opens a new journal file. Must be called with the lock held.
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		level.Warn(j.logger).Log("msg", "failed to close query journal file", "err", err)
+	}
+	j.file = nil
+
+	if err := j.shiftFiles(); err != nil {
+		// Keep appending to the current file, rather than losing the next entries.
+		if openErr := j.open(); openErr != nil {
+			level.Warn(j.logger).Log("msg", "failed to reopen query journal file", "err", openErr)
+		}
+		return err
+	}
+
+	j.rotations.Inc()
+	return j.open()
+}
+
+func (j *Journal) shiftFiles() error {
+	if j.cfg.MaxFiles == 1 {
+		return os.Remove(j.cfg.Filepath)
+	}
+
+	if err := os.Remove(rotatedFilepath(j.cfg.Filepath, j.cfg.MaxFiles-1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := j.cfg.MaxFiles - 2; i >= 1; i-- {
+		if err := os.Rename(rotatedFilepath(j.cfg.Filepath, i), rotatedFilepath(j.cfg.Filepath, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(j.cfg.Filepath, rotatedFilepath(j.cfg.Filepath, 1))
+}
+
+func rotatedFilepath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queryjournal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *Config)
+		expected error
+	}{
+		"should pass with the default config": {
+			setup: func(cfg *Config) {},
+		},
+		"should pass with a valid config": {
+			setup: func(cfg *Config) {
+				cfg.Filepath = "./queries.log"
+			},
+		},
+		"should fail on invalid max file size": {
+			setup: func(cfg *Config) {
+				cfg.Filepath = "./queries.log"
+				cfg.MaxFileSizeBytes = 0
+			},
+			expected: errInvalidMaxFileSize,
+		},
+		"should fail on invalid max files": {
+			setup: func(cfg *Config) {
+				cfg.Filepath = "./queries.log"
+				cfg.MaxFiles = 0
+			},
+			expected: errInvalidMaxFiles,
+		},
+		"should fail on invalid sample rate": {
+			setup: func(cfg *Config) {
+				cfg.Filepath = "./queries.log"
+				cfg.SampleRate = 1.5
+			},
+			expected: errInvalidSampleRate,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{MaxFileSizeBytes: 1024, MaxFiles: 10, SampleRate: 1}
+			testData.setup(&cfg)
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestJournal_ShouldReturnNilIfDisabled(t *testing.T) {
+	j, err := NewJournal(Config{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.Nil(t, j)
+
+	// Nil journal should ignore all calls.
+	j.Record(Entry{Query: "up"})
+	require.NoError(t, j.Close())
+}
+
+func TestJournal_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "queries.log")
+	reg := prometheus.NewPedanticRegistry()
+
+	j, err := NewJournal(Config{Filepath: path, MaxFileSizeBytes: 1024 * 1024, MaxFiles: 1, SampleRate: 0.5}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	samples := []float64{0.2, 0.7, 0.9}
+	j.sample = func() float64 {
+		s := samples[0]
+		samples = samples[1:]
+		return s
+	}
+
+	j.Record(Entry{Tenant: "user-1", Query: "sampled-in", Status: StatusSuccess})
+	j.Record(Entry{Tenant: "user-1", Query: "sampled-out", Status: StatusSuccess})
+	j.Record(Entry{Tenant: "user-1", Query: "failed", Status: StatusFailed, Error: "failure"})
+	require.NoError(t, j.Close())
+
+	// The entries recorded after the journal has been closed should be ignored.
+	j.Record(Entry{Tenant: "user-1", Query: "closed", Status: StatusFailed})
+
+	assert.Equal(t, []string{"sampled-in", "failed"}, readQueries(t, path))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_journal_entries_sampled_out_total Total number of successful queries not recorded in the query journal because of the sampling.
+		# TYPE cortex_query_journal_entries_sampled_out_total counter
+		cortex_query_journal_entries_sampled_out_total 1
+
+		# HELP cortex_query_journal_entries_written_total Total number of queries recorded in the query journal.
+		# TYPE cortex_query_journal_entries_written_total counter
+		cortex_query_journal_entries_written_total 2
+
+		# HELP cortex_query_journal_write_failures_total Total number of queries failed to be recorded in the query journal.
+		# TYPE cortex_query_journal_write_failures_total counter
+		cortex_query_journal_write_failures_total 1
+	`), "cortex_query_journal_entries_sampled_out_total", "cortex_query_journal_entries_written_total", "cortex_query_journal_write_failures_total"))
+}
+
+func TestJournal_ShouldAppendToTheExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+	cfg := Config{Filepath: path, MaxFileSizeBytes: 1024 * 1024, MaxFiles: 1, SampleRate: 1}
+
+	for _, query := range []string{"first", "second"} {
+		j, err := NewJournal(cfg, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+		j.Record(Entry{Query: query, Status: StatusSuccess})
+		require.NoError(t, j.Close())
+	}
+
+	assert.Equal(t, []string{"first", "second"}, readQueries(t, path))
+}
+
+func TestJournal_ShouldRotateTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+	reg := prometheus.NewPedanticRegistry()
+
+	// Each entry exceeds half of the max file size, so each file holds a single entry.
+	entrySize := len(encodeEntry(t, Entry{Query: "query-0", Status: StatusSuccess}))
+	j, err := NewJournal(Config{Filepath: path, MaxFileSizeBytes: int64(entrySize * 3 / 2), MaxFiles: 3, SampleRate: 1}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	for _, query := range []string{"query-0", "query-1", "query-2", "query-3"} {
+		j.Record(Entry{Query: query, Status: StatusSuccess})
+	}
+	require.NoError(t, j.Close())
+
+	assert.Equal(t, []string{"query-3"}, readQueries(t, path))
+	assert.Equal(t, []string{"query-2"}, readQueries(t, path+".1"))
+	assert.Equal(t, []string{"query-1"}, readQueries(t, path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_journal_rotations_total Total number of times the query journal file has been rotated.
+		# TYPE cortex_query_journal_rotations_total counter
+		cortex_query_journal_rotations_total 3
+	`), "cortex_query_journal_rotations_total"))
+}
+
+func TestJournal_ShouldTruncateTheFileOnRotationIfMaxFilesIsOne(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+
+	entrySize := len(encodeEntry(t, Entry{Query: "query-0", Status: StatusSuccess}))
+	j, err := NewJournal(Config{Filepath: path, MaxFileSizeBytes: int64(entrySize * 3 / 2), MaxFiles: 1, SampleRate: 1}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	j.Record(Entry{Query: "query-0", Status: StatusSuccess})
+	j.Record(Entry{Query: "query-1", Status: StatusSuccess})
+	require.NoError(t, j.Close())
+
+	assert.Equal(t, []string{"query-1"}, readQueries(t, path))
+	assert.NoFileExists(t, path+".1")
+}
+
+func encodeEntry(t *testing.T, e Entry) []byte {
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	return append(data, '\n')
+}
+
+func readQueries(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var queries []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e Entry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		queries = append(queries, e.Query)
+	}
+	return queries
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	"github.com/grafana/mimir/pkg/util/fieldcategory"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 // Usage prints command-line usage.
@@ -157,6 +158,8 @@ func parseStructure(structure interface{}, fields map[uintptr]reflect.StructFiel
 var ignoredStructTypes = []reflect.Type{
 	reflect.TypeOf(flagext.Secret{}),
 	reflect.TypeOf(activeseries.CustomTrackersConfig{}),
+	reflect.TypeOf(validation.ExemplarLabelsFilters{}),
+	reflect.TypeOf(validation.SeriesSamplingRules{}),
 }
 
 func ignoreStructType(fieldType reflect.Type) bool {
@@ -258,12 +258,30 @@ func NewMaxTotalQueryLengthError(actualQueryLen, maxTotalQueryLength time.Durati
 		maxTotalQueryLengthFlag))
 }
 
+func NewMaxEstimatedSeriesPerQueryError(estimatedSeries uint64, limit int) LimitError {
+	return LimitError(globalerror.MaxEstimatedSeriesPerQuery.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the query has been rejected because it's estimated to fetch more series than the limit, based on the previous execution of the same query (estimated series: %d, limit: %d). Consider making the query selectors more specific or reducing the queried time range", estimatedSeries, limit),
+		maxEstimatedSeriesPerQueryFlag))
+}
+
+func NewMaxEstimatedChunksPerQueryError(estimatedChunks uint64, limit int) LimitError {
+	return LimitError(globalerror.MaxEstimatedChunksPerQuery.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the query has been rejected because it's estimated to fetch more chunks than the limit, based on the previous execution of the same query (estimated chunks: %d, limit: %d). Consider making the query selectors more specific or reducing the queried time range", estimatedChunks, limit),
+		maxEstimatedChunksPerQueryFlag))
+}
+
 func NewRequestRateLimitedError(limit float64, burst int) LimitError {
 	return LimitError(globalerror.RequestRateLimited.MessageWithPerTenantLimitConfig(
 		fmt.Sprintf("the request has been rejected because the tenant exceeded the request rate limit, set to %v requests/s across all distributors with a maximum allowed burst of %d", limit, burst),
 		requestRateFlag, requestBurstSizeFlag))
 }
 
+func NewMaxInflightPushRequestsPerTenantError(limit int) LimitError {
+	return LimitError(globalerror.InflightPushRequestsLimited.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the request has been rejected because the tenant exceeded the limit of %d inflight push requests per distributor", limit),
+		maxInflightPushRequestsPerTenantFlag))
+}
+
 func NewIngestionRateLimitedError(limit float64, burst int) LimitError {
 	return LimitError(globalerror.IngestionRateLimited.MessageWithPerTenantLimitConfig(
 		fmt.Sprintf("the request has been rejected because the tenant exceeded the ingestion rate limit, set to %v items/s with a maximum allowed burst of %d. This limit is applied on the total number of samples, exemplars and metadata received across all distributors", limit, burst),
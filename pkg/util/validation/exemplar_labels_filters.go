@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// ExemplarLabelsFilters is a list of selectors on the exemplar labels (e.g. `{trace_id=~".+"}`). The exemplars
+// whose labels match none of the selectors are discarded. The selectors are parsed once, when unmarshalled.
+type ExemplarLabelsFilters struct {
+	source   []string
+	matchers [][]*labels.Matcher
+}
+
+// NewExemplarLabelsFilters makes ExemplarLabelsFilters from the input selectors.
+func NewExemplarLabelsFilters(selectors []string) (f ExemplarLabelsFilters, err error) {
+	f.source = selectors
+	f.matchers = make([][]*labels.Matcher, 0, len(selectors))
+	for _, selector := range selectors {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return f, errors.Wrapf(err, "invalid exemplar labels filter %q", selector)
+		}
+		f.matchers = append(f.matchers, matchers)
+	}
+	return f, nil
+}
+
+// ExampleDoc provides an example doc for this config, since it's custom-unmarshaled.
+func (f ExemplarLabelsFilters) ExampleDoc() (comment string, yaml interface{}) {
+	return `The following configuration ingests only the exemplars with a trace_id label.`,
+		[]string{`{trace_id=~".+"}`}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (f *ExemplarLabelsFilters) UnmarshalYAML(value *yaml.Node) error {
+	var selectors []string
+	if err := value.Decode(&selectors); err != nil {
+		return err
+	}
+
+	var err error
+	*f, err = NewExemplarLabelsFilters(selectors)
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (f ExemplarLabelsFilters) MarshalYAML() (interface{}, error) {
+	return f.source, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *ExemplarLabelsFilters) UnmarshalJSON(data []byte) error {
+	var selectors []string
+	if err := json.Unmarshal(data, &selectors); err != nil {
+		return err
+	}
+
+	var err error
+	*f, err = NewExemplarLabelsFilters(selectors)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f ExemplarLabelsFilters) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.source)
+}
+
+// Selectors returns the selectors the filters have been built from.
+func (f ExemplarLabelsFilters) Selectors() []string {
+	return f.source
+}
+
+// Matchers returns the parsed label matchers of each selector.
+func (f ExemplarLabelsFilters) Matchers() [][]*labels.Matcher {
+	return f.matchers
+}
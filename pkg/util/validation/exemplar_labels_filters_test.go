@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExemplarLabelsFilters_Unmarshal(t *testing.T) {
+	for name, tc := range map[string]struct {
+		yaml        string
+		json        string
+		expected    []string
+		expectedErr string
+	}{
+		"valid filters": {
+			yaml: `
+- '{trace_id=~".+"}'
+- '{span_id!=""}'
+`,
+			json:     `["{trace_id=~\".+\"}", "{span_id!=\"\"}"]`,
+			expected: []string{`{trace_id=~".+"}`, `{span_id!=""}`},
+		},
+		"invalid selector": {
+			yaml:        `['{trace_id=}']`,
+			json:        `["{trace_id=}"]`,
+			expectedErr: `invalid exemplar labels filter "{trace_id=}"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var fromYAML, fromJSON ExemplarLabelsFilters
+
+			yamlErr := yaml.Unmarshal([]byte(tc.yaml), &fromYAML)
+			jsonErr := json.Unmarshal([]byte(tc.json), &fromJSON)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, yamlErr, tc.expectedErr)
+				require.ErrorContains(t, jsonErr, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, yamlErr)
+			require.NoError(t, jsonErr)
+			assert.Equal(t, tc.expected, fromYAML.Selectors())
+			assert.Equal(t, tc.expected, fromJSON.Selectors())
+			assert.Len(t, fromYAML.Matchers(), len(tc.expected))
+			assert.Equal(t, fromYAML.Matchers(), fromJSON.Matchers())
+
+			// The filters should be marshalled back to the selectors.
+			out, err := yaml.Marshal(fromYAML)
+			require.NoError(t, err)
+			var selectors []string
+			require.NoError(t, yaml.Unmarshal(out, &selectors))
+			assert.Equal(t, tc.expected, selectors)
+		})
+	}
+}
@@ -15,6 +15,7 @@ type OverridesExporter struct {
 	tenantLimits        TenantLimits
 	overrideDescription *prometheus.Desc
 	defaultsDescription *prometheus.Desc
+	scheduleDescription *prometheus.Desc
 }
 
 // NewOverridesExporter creates an OverridesExporter that reads updates to per-tenant
@@ -35,12 +36,19 @@ func NewOverridesExporter(defaultLimits *Limits, tenantLimits TenantLimits) *Ove
 			[]string{"limit_name"},
 			nil,
 		),
+		scheduleDescription: prometheus.NewDesc(
+			"cortex_limits_overrides_active_schedule",
+			"Scheduled limit overrides currently applied to tenants",
+			[]string{"user", "name"},
+			nil,
+		),
 	}
 }
 
 func (oe *OverridesExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- oe.defaultsDescription
 	ch <- oe.overrideDescription
+	ch <- oe.scheduleDescription
 }
 
 func (oe *OverridesExporter) Collect(ch chan<- prometheus.Metric) {
@@ -83,4 +91,10 @@ func (oe *OverridesExporter) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(oe.overrideDescription, prometheus.GaugeValue, float64(limits.RulerMaxRulesPerRuleGroup), "ruler_max_rules_per_rule_group", tenant)
 		ch <- prometheus.MustNewConstMetric(oe.overrideDescription, prometheus.GaugeValue, float64(limits.RulerMaxRuleGroupsPerTenant), "ruler_max_rule_groups_per_tenant", tenant)
 	}
+	// The overrides exported above are the ones of the scheduled limits currently applied, if any.
+	if scheduled, ok := oe.tenantLimits.(ScheduledTenantLimits); ok {
+		for tenant, name := range scheduled.ActiveScheduledLimitsByUserID() {
+			ch <- prometheus.MustNewConstMetric(oe.scheduleDescription, prometheus.GaugeValue, 1, tenant, name)
+		}
+	}
 }
@@ -101,3 +101,30 @@ cortex_limits_defaults{limit_name="ruler_max_rule_groups_per_tenant"} 32
 	err = testutil.CollectAndCompare(exporter, bytes.NewBufferString(limitsMetrics), "cortex_limits_defaults")
 	assert.NoError(t, err)
 }
+
+func TestOverridesExporter_withActiveScheduledLimits(t *testing.T) {
+	exporter := NewOverridesExporter(&Limits{}, &mockScheduledTenantLimits{
+		TenantLimits: NewMockTenantLimits(map[string]*Limits{
+			"tenant-a": {MaxFetchedSeriesPerQuery: 16},
+			"tenant-b": {MaxFetchedSeriesPerQuery: 32},
+		}),
+		active: map[string]string{"tenant-a": "business-hours"},
+	})
+
+	limitsMetrics := `
+# HELP cortex_limits_overrides_active_schedule Scheduled limit overrides currently applied to tenants
+# TYPE cortex_limits_overrides_active_schedule gauge
+cortex_limits_overrides_active_schedule{name="business-hours",user="tenant-a"} 1
+`
+	err := testutil.CollectAndCompare(exporter, bytes.NewBufferString(limitsMetrics), "cortex_limits_overrides_active_schedule")
+	assert.NoError(t, err)
+}
+
+type mockScheduledTenantLimits struct {
+	TenantLimits
+	active map[string]string
+}
+
+func (l *mockScheduledTenantLimits) ActiveScheduledLimitsByUserID() map[string]string {
+	return l.active
+}
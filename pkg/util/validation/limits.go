@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
+	"golang.org/x/exp/slices"
 	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v3"
 
@@ -25,30 +26,41 @@ import (
 )
 
 const (
-	MaxSeriesPerMetricFlag     = "ingester.max-global-series-per-metric"
-	MaxMetadataPerMetricFlag   = "ingester.max-global-metadata-per-metric"
-	MaxSeriesPerUserFlag       = "ingester.max-global-series-per-user"
-	MaxMetadataPerUserFlag     = "ingester.max-global-metadata-per-user"
-	MaxChunksPerQueryFlag      = "querier.max-fetched-chunks-per-query"
-	MaxChunkBytesPerQueryFlag  = "querier.max-fetched-chunk-bytes-per-query"
-	MaxSeriesPerQueryFlag      = "querier.max-fetched-series-per-query"
-	maxLabelNamesPerSeriesFlag = "validation.max-label-names-per-series"
-	maxLabelNameLengthFlag     = "validation.max-length-label-name"
-	maxLabelValueLengthFlag    = "validation.max-length-label-value"
-	maxMetadataLengthFlag      = "validation.max-metadata-length"
-	creationGracePeriodFlag    = "validation.create-grace-period"
-	maxQueryLengthFlag         = "store.max-query-length"
-	maxTotalQueryLengthFlag    = "query-frontend.max-total-query-length"
-	requestRateFlag            = "distributor.request-rate-limit"
-	requestBurstSizeFlag       = "distributor.request-burst-size"
-	ingestionRateFlag          = "distributor.ingestion-rate-limit"
-	ingestionBurstSizeFlag     = "distributor.ingestion-burst-size"
-	HATrackerMaxClustersFlag   = "distributor.ha-tracker.max-clusters"
+	MaxSeriesPerMetricFlag               = "ingester.max-global-series-per-metric"
+	MaxMetadataPerMetricFlag             = "ingester.max-global-metadata-per-metric"
+	MaxSeriesPerUserFlag                 = "ingester.max-global-series-per-user"
+	MaxMetadataPerUserFlag               = "ingester.max-global-metadata-per-user"
+	MaxChunksPerQueryFlag                = "querier.max-fetched-chunks-per-query"
+	MaxChunkBytesPerQueryFlag            = "querier.max-fetched-chunk-bytes-per-query"
+	MaxSeriesPerQueryFlag                = "querier.max-fetched-series-per-query"
+	maxLabelNamesPerSeriesFlag           = "validation.max-label-names-per-series"
+	maxLabelNameLengthFlag               = "validation.max-length-label-name"
+	maxLabelValueLengthFlag              = "validation.max-length-label-value"
+	maxMetadataLengthFlag                = "validation.max-metadata-length"
+	creationGracePeriodFlag              = "validation.create-grace-period"
+	maxQueryLengthFlag                   = "store.max-query-length"
+	maxTotalQueryLengthFlag              = "query-frontend.max-total-query-length"
+	maxEstimatedSeriesPerQueryFlag       = "query-frontend.max-estimated-fetched-series-per-query"
+	maxEstimatedChunksPerQueryFlag       = "query-frontend.max-estimated-fetched-chunks-per-query"
+	requestRateFlag                      = "distributor.request-rate-limit"
+	requestBurstSizeFlag                 = "distributor.request-burst-size"
+	maxInflightPushRequestsPerTenantFlag = "distributor.max-inflight-push-requests-per-tenant"
+	ingestionRateFlag                    = "distributor.ingestion-rate-limit"
+	ingestionBurstSizeFlag               = "distributor.ingestion-burst-size"
+	HATrackerMaxClustersFlag             = "distributor.ha-tracker.max-clusters"
+
+	// Read endpoints which can be disabled in the ingesters on a per-tenant basis.
+	IngesterReadEndpointLabelNames  = "label_names"
+	IngesterReadEndpointLabelValues = "label_values"
+	IngesterReadEndpointSeries      = "series"
 
 	// MinCompactorPartialBlockDeletionDelay is the minimum partial blocks deletion delay that can be configured in Mimir.
 	MinCompactorPartialBlockDeletionDelay = 4 * time.Hour
 )
 
+// ingesterReadEndpoints are the read endpoints which can be disabled in the ingesters.
+var ingesterReadEndpoints = []string{IngesterReadEndpointLabelNames, IngesterReadEndpointLabelValues, IngesterReadEndpointSeries}
+
 // LimitError are errors that do not comply with the limits specified.
 type LimitError string
 
@@ -68,54 +80,89 @@ type ForwardingRules map[string]ForwardingRule
 // limits via flags, or per-user limits via yaml config.
 type Limits struct {
 	// Distributor enforced limits.
-	RequestRate               float64             `yaml:"request_rate" json:"request_rate" category:"experimental"`
-	RequestBurstSize          int                 `yaml:"request_burst_size" json:"request_burst_size" category:"experimental"`
-	IngestionRate             float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
-	IngestionBurstSize        int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
-	AcceptHASamples           bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
-	HAClusterLabel            string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
-	HAReplicaLabel            string              `yaml:"ha_replica_label" json:"ha_replica_label"`
-	HAMaxClusters             int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
-	DropLabels                flagext.StringSlice `yaml:"drop_labels" json:"drop_labels" category:"advanced"`
-	MaxLabelNameLength        int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
-	MaxLabelValueLength       int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
-	MaxLabelNamesPerSeries    int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
-	MaxMetadataLength         int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
-	CreationGracePeriod       model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period" category:"advanced"`
-	EnforceMetadataMetricName bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
-	IngestionTenantShardSize  int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
-	MetricRelabelConfigs      []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs." category:"experimental"`
+	RequestRate                      float64             `yaml:"request_rate" json:"request_rate" category:"experimental"`
+	RequestBurstSize                 int                 `yaml:"request_burst_size" json:"request_burst_size" category:"experimental"`
+	MaxInflightPushRequestsPerTenant int                 `yaml:"max_inflight_push_requests_per_tenant" json:"max_inflight_push_requests_per_tenant" category:"experimental"`
+	IngestionRate                    float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
+	IngestionBurstSize               int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
+	AcceptHASamples                  bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
+	HAClusterLabel                   string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
+	HAReplicaLabel                   string              `yaml:"ha_replica_label" json:"ha_replica_label"`
+	HAMaxClusters                    int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
+	DropLabels                       flagext.StringSlice `yaml:"drop_labels" json:"drop_labels" category:"advanced"`
+	MaxLabelNameLength               int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
+	MaxLabelValueLength              int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
+	MaxLabelNamesPerSeries           int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
+	MaxMetadataLength                int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
+	CreationGracePeriod              model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period" category:"advanced"`
+	EnforceMetadataMetricName        bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
+	IngestionTenantShardSize         int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
+	IngestionReplicationFactor       int                 `yaml:"ingestion_replication_factor" json:"ingestion_replication_factor" category:"experimental"`
+	MetricRelabelConfigs             []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs." category:"experimental"`
+	// Series sampling
+	IngestionSeriesSamplingRatio float64             `yaml:"ingestion_series_sampling_ratio" json:"ingestion_series_sampling_ratio" category:"experimental"`
+	IngestionSeriesSamplingRules SeriesSamplingRules `yaml:"ingestion_series_sampling_rules" json:"ingestion_series_sampling_rules" doc:"nocli|description=Per-selector ratios of the series ingested, instead of the ingestion series sampling ratio. If a series matches multiple selectors, the lowest ratio is used." category:"experimental"`
+	IngestionSeriesSamplingLabel string              `yaml:"ingestion_series_sampling_label" json:"ingestion_series_sampling_label" category:"experimental"`
+	// Push gateway
+	PushGatewayEnabled         bool           `yaml:"push_gateway_enabled" json:"push_gateway_enabled" category:"experimental"`
+	PushGatewayStalenessPeriod model.Duration `yaml:"push_gateway_staleness_period" json:"push_gateway_staleness_period" category:"experimental"`
+	PushGatewayMaxSeries       int            `yaml:"push_gateway_max_series" json:"push_gateway_max_series" category:"experimental"`
+	// Exposition push
+	ExpositionPushHonorTimestamps     bool `yaml:"exposition_push_honor_timestamps" json:"exposition_push_honor_timestamps" category:"experimental"`
+	ExpositionPushMaxSeriesPerRequest int  `yaml:"exposition_push_max_series_per_request" json:"exposition_push_max_series_per_request" category:"experimental"`
+	// OTLP
+	OTelDeltaTemporalityConversionEnabled bool `yaml:"otel_delta_temporality_conversion_enabled" json:"otel_delta_temporality_conversion_enabled" category:"experimental"`
 
 	// Ingester enforced limits.
 	// Series
-	MaxGlobalSeriesPerUser   int `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
-	MaxGlobalSeriesPerMetric int `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
+	MaxGlobalSeriesPerUser   int            `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
+	MaxGlobalSeriesPerMetric int            `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
+	SeriesLimitsGracePeriod  model.Duration `yaml:"series_limits_grace_period" json:"series_limits_grace_period" category:"experimental"`
 	// Metadata
 	MaxGlobalMetricsWithMetadataPerUser int `yaml:"max_global_metadata_per_user" json:"max_global_metadata_per_user"`
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
 	// Exemplars
-	MaxGlobalExemplarsPerUser int `yaml:"max_global_exemplars_per_user" json:"max_global_exemplars_per_user" category:"experimental"`
+	MaxGlobalExemplarsPerUser int                    `yaml:"max_global_exemplars_per_user" json:"max_global_exemplars_per_user" category:"experimental"`
+	ExemplarLabelsFilters     ExemplarLabelsFilters  `yaml:"exemplar_labels_filters" json:"exemplar_labels_filters" doc:"nocli|description=Selectors on the exemplar labels. The exemplars whose labels match none of the selectors are discarded. If empty, all the exemplars are ingested." category:"experimental"`
+	ExemplarLabelsKeep        flagext.StringSliceCSV `yaml:"exemplar_labels_keep" json:"exemplar_labels_keep" category:"experimental"`
 	// Active series custom trackers
 	ActiveSeriesCustomTrackersConfig activeseries.CustomTrackersConfig `yaml:"active_series_custom_trackers" json:"active_series_custom_trackers" doc:"description=Additional custom trackers for active metrics. If there are active series matching a provided matcher (map value), the count will be exposed in the custom trackers metric labeled using the tracker name (map key). Zero valued counts are not exposed (and removed when they go back to zero)." category:"advanced"`
 	// Max allowed time window for out-of-order samples.
 	OutOfOrderTimeWindow model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window" category:"experimental"`
+	// Read endpoints served by the store-gateways only.
+	IngesterDisabledReadEndpoints flagext.StringSliceCSV `yaml:"ingester_disabled_read_endpoints" json:"ingester_disabled_read_endpoints" category:"experimental"`
+	// Staleness markers handling.
+	DiscardOutOfOrderStaleMarkers bool `yaml:"discard_out_of_order_stale_markers" json:"discard_out_of_order_stale_markers" category:"experimental"`
+	TargetDeletionStaleMarkers    bool `yaml:"target_deletion_stale_markers" json:"target_deletion_stale_markers" category:"experimental"`
 
 	// Querier enforced limits.
 	MaxChunksPerQuery              int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
 	MaxFetchedSeriesPerQuery       int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
 	MaxFetchedChunkBytesPerQuery   int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
+	MaxConcurrentQueriesPerTenant  int            `yaml:"max_concurrent_queries_per_tenant" json:"max_concurrent_queries_per_tenant" category:"experimental"`
 	MaxQueryLookback               model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxQueryLength                 model.Duration `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryParallelism            int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
 	MaxLabelsQueryLength           model.Duration `yaml:"max_labels_query_length" json:"max_labels_query_length"`
 	MaxCacheFreshness              model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness" category:"advanced"`
+	ResultsCacheTTL                model.Duration `yaml:"results_cache_ttl" json:"results_cache_ttl" category:"experimental"`
+	ResultsCacheTTLForRecent       model.Duration `yaml:"results_cache_ttl_for_recent_results" json:"results_cache_ttl_for_recent_results" category:"experimental"`
+	ResultsCacheRecentWindow       model.Duration `yaml:"results_cache_recent_results_window" json:"results_cache_recent_results_window" category:"experimental"`
+	ResultsCacheMaxStaleness       model.Duration `yaml:"results_cache_max_staleness" json:"results_cache_max_staleness" category:"experimental"`
+	ResultsCacheInstantTolerance   model.Duration `yaml:"results_cache_instant_queries_time_tolerance" json:"results_cache_instant_queries_time_tolerance" category:"experimental"`
+	ResultsCacheTTLForInstant      model.Duration `yaml:"results_cache_ttl_for_instant_queries" json:"results_cache_ttl_for_instant_queries" category:"experimental"`
 	MaxQueriersPerTenant           int            `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
 	QueryShardingTotalShards       int            `yaml:"query_sharding_total_shards" json:"query_sharding_total_shards"`
 	QueryShardingMaxShardedQueries int            `yaml:"query_sharding_max_sharded_queries" json:"query_sharding_max_sharded_queries"`
 	SplitInstantQueriesByInterval  model.Duration `yaml:"split_instant_queries_by_interval" json:"split_instant_queries_by_interval" category:"experimental"`
 
 	// Query-frontend limits.
-	MaxTotalQueryLength model.Duration `yaml:"max_total_query_length,omitempty" json:"max_total_query_length,omitempty" category:"experimental"`
+	MaxTotalQueryLength               model.Duration `yaml:"max_total_query_length,omitempty" json:"max_total_query_length,omitempty" category:"experimental"`
+	MaxEstimatedFetchedSeriesPerQuery int            `yaml:"max_estimated_fetched_series_per_query" json:"max_estimated_fetched_series_per_query" category:"experimental"`
+	MaxEstimatedFetchedChunksPerQuery int            `yaml:"max_estimated_fetched_chunks_per_query" json:"max_estimated_fetched_chunks_per_query" category:"experimental"`
+
+	// Query-scheduler limits.
+	DefaultQueryPriority string `yaml:"default_query_priority" json:"default_query_priority" category:"experimental"`
 
 	// Cardinality
 	CardinalityAnalysisEnabled                    bool `yaml:"cardinality_analysis_enabled" json:"cardinality_analysis_enabled"`
@@ -129,17 +176,29 @@ type Limits struct {
 	RulerMaxRuleGroupsPerTenant          int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
 	RulerRecordingRulesEvaluationEnabled bool           `yaml:"ruler_recording_rules_evaluation_enabled" json:"ruler_recording_rules_evaluation_enabled" category:"experimental"`
 	RulerAlertingRulesEvaluationEnabled  bool           `yaml:"ruler_alerting_rules_evaluation_enabled" json:"ruler_alerting_rules_evaluation_enabled" category:"experimental"`
+	RulerTenantAlertmanagerURL           string         `yaml:"ruler_tenant_alertmanager_url" json:"ruler_tenant_alertmanager_url" category:"experimental"`
+	RulerSendToDefaultAlertmanager       bool           `yaml:"ruler_send_to_default_alertmanager" json:"ruler_send_to_default_alertmanager" category:"experimental"`
+	RulerLimitsGracePeriod               model.Duration `yaml:"ruler_limits_grace_period" json:"ruler_limits_grace_period" category:"experimental"`
+	RulerMaxReportsPerTenant             int            `yaml:"ruler_max_reports_per_tenant" json:"ruler_max_reports_per_tenant" category:"experimental"`
+	RulerCanaryPeriod                    model.Duration `yaml:"ruler_canary_period" json:"ruler_canary_period" category:"experimental"`
 
 	// Store-gateway.
-	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayTenantShardSize           int  `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayStreamingSeriesBatchSize  int  `yaml:"store_gateway_streaming_series_batch_size" json:"store_gateway_streaming_series_batch_size" category:"experimental"`
+	StoreGatewayQueryConcurrencyWeight    int  `yaml:"store_gateway_query_concurrency_weight" json:"store_gateway_query_concurrency_weight" category:"experimental"`
+	StoreGatewayPartialResultsEnabled     bool `yaml:"store_gateway_partial_results_enabled" json:"store_gateway_partial_results_enabled" category:"experimental"`
+	StoreGatewayChunksFetchMaxConcurrency int  `yaml:"store_gateway_chunks_fetch_max_concurrency" json:"store_gateway_chunks_fetch_max_concurrency" category:"experimental"`
+	StoreGatewayLazyPostingsEnabled       bool `yaml:"store_gateway_lazy_postings_enabled" json:"store_gateway_lazy_postings_enabled" category:"experimental"`
+	StoreGatewayMaxExemplarsBytesPerQuery int  `yaml:"store_gateway_max_exemplars_bytes_per_query" json:"store_gateway_max_exemplars_bytes_per_query" category:"experimental"`
 
 	// Compactor.
-	CompactorBlocksRetentionPeriod     model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
-	CompactorSplitAndMergeShards       int            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
-	CompactorSplitGroups               int            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
-	CompactorTenantShardSize           int            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
-	CompactorPartialBlockDeletionDelay model.Duration `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
-	CompactorBlockUploadEnabled        bool           `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
+	CompactorBlocksRetentionPeriod     model.Duration       `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
+	CompactorSplitAndMergeShards       int                  `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
+	CompactorSplitGroups               int                  `yaml:"compactor_split_groups" json:"compactor_split_groups"`
+	CompactorTenantShardSize           int                  `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorPartialBlockDeletionDelay model.Duration       `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
+	CompactorBlockUploadEnabled        bool                 `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
+	CompactorSeriesRetention           SeriesRetentionRules `yaml:"compactor_series_retention" json:"compactor_series_retention" doc:"nocli|description=Per-selector retention periods. Series matching a selector are deleted by the compactor from the blocks whose samples are all older than the retention period, independently from the tenant blocks retention period." category:"experimental"`
 
 	// This config doesn't have a CLI flag registered here because they're registered in
 	// their own original config struct.
@@ -160,6 +219,10 @@ type Limits struct {
 	AlertmanagerMaxDispatcherAggregationGroups int `yaml:"alertmanager_max_dispatcher_aggregation_groups" json:"alertmanager_max_dispatcher_aggregation_groups"`
 	AlertmanagerMaxAlertsCount                 int `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
 	AlertmanagerMaxAlertsSizeBytes             int `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
+	AlertmanagerMaxReceiversCount              int `yaml:"alertmanager_max_receivers_count" json:"alertmanager_max_receivers_count" category:"experimental"`
+	AlertmanagerMaxRoutesCount                 int `yaml:"alertmanager_max_routes_count" json:"alertmanager_max_routes_count" category:"experimental"`
+
+	AlertmanagerLimitsGracePeriod model.Duration `yaml:"alertmanager_limits_grace_period" json:"alertmanager_limits_grace_period" category:"experimental"`
 
 	ForwardingEndpoint      string          `yaml:"forwarding_endpoint" json:"forwarding_endpoint" doc:"nocli|description=Remote-write endpoint where metrics specified in forwarding_rules are forwarded to. If set, takes precedence over endpoints specified in forwarding rules."`
 	ForwardingDropOlderThan model.Duration  `yaml:"forwarding_drop_older_than" json:"forwarding_drop_older_than" doc:"nocli|description=If set, forwarding drops samples that are older than this duration. If unset or 0, no samples get dropped."`
@@ -169,8 +232,10 @@ type Limits struct {
 // RegisterFlags adds the flags required to config this to the given FlagSet
 func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.IngestionTenantShardSize, "distributor.ingestion-tenant-shard-size", 0, "The tenant's shard size used by shuffle-sharding. Must be set both on ingesters and distributors. 0 disables shuffle sharding.")
+	f.IntVar(&l.IngestionReplicationFactor, "distributor.ingestion-replication-factor", 0, "The number of ingesters the tenant's series are written to, if larger than 0 and lower than -ingester.ring.replication-factor. The queries of the tenant require the responses from a quorum of ingesters computed on this replication factor. Must be set both on ingesters and distributors. 0 to use the value of -ingester.ring.replication-factor.")
 	f.Float64Var(&l.RequestRate, requestRateFlag, 0, "Per-tenant request rate limit in requests per second. 0 to disable.")
 	f.IntVar(&l.RequestBurstSize, requestBurstSizeFlag, 0, "Per-tenant allowed request burst size. 0 to disable.")
+	f.IntVar(&l.MaxInflightPushRequestsPerTenant, maxInflightPushRequestsPerTenantFlag, 0, "Per-tenant max number of inflight push requests in each distributor. Additional push requests are rejected with the 429 status code, along with a Retry-After header and a X-Mimir-Backpressure header suggesting the send rate at which the tenant doesn't exceed the limit. 0 to disable.")
 	f.Float64Var(&l.IngestionRate, ingestionRateFlag, 10000, "Per-tenant ingestion rate limit in samples per second.")
 	f.IntVar(&l.IngestionBurstSize, ingestionBurstSizeFlag, 200000, "Per-tenant allowed ingestion burst size (in number of samples).")
 	f.BoolVar(&l.AcceptHASamples, "distributor.ha-tracker.enable-for-all-users", false, "Flag to enable, for all tenants, handling of samples with external labels identifying replicas in an HA Prometheus setup.")
@@ -178,6 +243,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&l.HAReplicaLabel, "distributor.ha-tracker.replica", "__replica__", "Prometheus label to look for in samples to identify a Prometheus HA replica.")
 	f.IntVar(&l.HAMaxClusters, HATrackerMaxClustersFlag, 100, "Maximum number of clusters that HA tracker will keep track of for a single tenant. 0 to disable the limit.")
 	f.Var(&l.DropLabels, "distributor.drop-label", "This flag can be used to specify label names that to drop during sample ingestion within the distributor and can be repeated in order to drop multiple labels.")
+	f.Float64Var(&l.IngestionSeriesSamplingRatio, "distributor.ingestion-series-sampling-ratio", 1, "Ratio of the series ingested, greater than 0 and less than or equal to 1. The series are sampled deterministically by the hash of their labels, so that the same series are always either ingested or discarded. The samples of the other series are discarded. 1 to ingest all the series.")
+	f.StringVar(&l.IngestionSeriesSamplingLabel, "distributor.ingestion-series-sampling-label", "", "Name of the label added to the sampled series, with the sampling ratio as value, so that the queries can scale the results by the inverse of the ratio. If empty, no label is added.")
 	f.IntVar(&l.MaxLabelNameLength, maxLabelNameLengthFlag, 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, maxLabelValueLengthFlag, 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
 	f.IntVar(&l.MaxLabelNamesPerSeries, maxLabelNamesPerSeriesFlag, 30, "Maximum number of label names per series.")
@@ -185,20 +252,32 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.CreationGracePeriod.Set("10m")
 	f.Var(&l.CreationGracePeriod, creationGracePeriodFlag, "Controls how far into the future incoming samples are accepted compared to the wall clock. Any sample with timestamp `t` will be rejected if `t > (now + validation.create-grace-period)`. Also used by query-frontend to avoid querying too far into the future. 0 to disable.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
+	f.BoolVar(&l.PushGatewayEnabled, "distributor.push-gateway.tenant-enabled", false, "Whether the tenant can push metrics to the push gateway endpoint, when enabled with -distributor.push-gateway.enabled.")
+	f.Var(&l.PushGatewayStalenessPeriod, "distributor.push-gateway.staleness-period", "How long the series pushed to the push gateway endpoint are re-exposed after the last push to their group. When the period expires, the series are marked as stale and the group is deleted. 0 to keep the groups until they're explicitly deleted.")
+	f.IntVar(&l.PushGatewayMaxSeries, "distributor.push-gateway.max-series", 10000, "The maximum number of series per tenant held by the push gateway endpoint of each distributor. Pushes exceeding the limit are rejected. 0 to disable.")
+	f.BoolVar(&l.ExpositionPushHonorTimestamps, "distributor.exposition-push.honor-timestamps", true, "True to write the samples posted to the exposition push endpoint with their timestamp, if any. When false, or when a sample has no timestamp, the sample is written with the time the request has been received.")
+	f.IntVar(&l.ExpositionPushMaxSeriesPerRequest, "distributor.exposition-push.max-series-per-request", 0, "The maximum number of series in a single request to the exposition push endpoint. Requests exceeding the limit are rejected. 0 to disable.")
+	f.BoolVar(&l.OTelDeltaTemporalityConversionEnabled, "distributor.otel-delta-temporality-conversion-enabled", false, "True to accept the OTLP sums and histograms with delta aggregation temporality, which are converted to cumulative by the ingesters. When false, they're rejected.")
 
 	f.IntVar(&l.MaxGlobalSeriesPerUser, MaxSeriesPerUserFlag, 150000, "The maximum number of in-memory series per tenant, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, MaxSeriesPerMetricFlag, 0, "The maximum number of in-memory series per metric name, across the cluster before replication. 0 to disable.")
+	f.Var(&l.SeriesLimitsGracePeriod, "ingester.series-limits-grace-period", "Period during which the series exceeding -ingester.max-global-series-per-user or -ingester.max-global-series-per-metric are accepted by the ingesters, before being rejected. The series accepted during the grace period are matched by the `__mimir_series_policy__=\"grace\"` label matcher at query time. The period starts when the tenant exceeds the limits in an ingester for the first time, and is reset once the tenant creates a series within the limits. 0 to disable.")
 
 	f.IntVar(&l.MaxGlobalMetricsWithMetadataPerUser, MaxMetadataPerUserFlag, 0, "The maximum number of in-memory metrics with metadata per tenant, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalMetadataPerMetric, MaxMetadataPerMetricFlag, 0, "The maximum number of metadata per metric, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalExemplarsPerUser, "ingester.max-global-exemplars-per-user", 0, "The maximum number of exemplars in memory, across the cluster. 0 to disable exemplars ingestion.")
+	f.Var(&l.ExemplarLabelsKeep, "distributor.exemplar-labels-keep", "Comma-separated list of the exemplar labels to keep, such as trace_id. The other labels of the exemplars are stripped, and the exemplars left with no labels are discarded. If empty, all the labels are kept.")
 	f.Var(&l.ActiveSeriesCustomTrackersConfig, "ingester.active-series-custom-trackers", "Additional active series metrics, matching the provided matchers. Matchers should be in form <name>:<matcher>, like 'foobar:{foo=\"bar\"}'. Multiple matchers can be provided either providing the flag multiple times or providing multiple semicolon-separated values to a single flag.")
-	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "Non-zero value enables out-of-order support for most recent samples that are within the time window in relation to the TSDB's maximum time, i.e., within [db.maxTime-timeWindow, db.maxTime]). The ingester will need more memory as a factor of rate of out-of-order samples being ingested and the number of series that are getting out-of-order samples. A lower TTL of 10 minutes will be set for the query cache entries that overlap with this window.")
+	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "Non-zero value enables out-of-order support for most recent samples that are within the time window in relation to the TSDB's maximum time, i.e., within [db.maxTime-timeWindow, db.maxTime]). The ingester will need more memory as a factor of rate of out-of-order samples being ingested and the number of series that are getting out-of-order samples. The TTL for recent results, configured by -query-frontend.results-cache-ttl-for-recent-results, will be set for the query cache entries that overlap with this window.")
+	f.Var(&l.IngesterDisabledReadEndpoints, "ingester.disabled-read-endpoints", "Comma-separated list of read endpoints which are disabled in the ingesters for the tenant. Queriers don't query ingesters for the disabled endpoints, and query the store-gateways regardless of -querier.query-store-after instead, so the most recent samples not yet shipped to the storage are not included in the results. Supported values are: label_names, label_values, series.")
+	f.BoolVar(&l.DiscardOutOfOrderStaleMarkers, "ingester.discard-out-of-order-stale-markers", false, "If enabled, staleness markers which can't be ingested because out-of-order, too old or with the same timestamp of an existing sample are discarded without failing the request and without being counted as discarded samples, because a newer sample for the series has already been ingested.")
+	f.BoolVar(&l.TargetDeletionStaleMarkers, "ingester.target-deletion-stale-markers", false, "If enabled, a staleness marker for the up series of a target, as sent by Prometheus and Grafana Agent when a scrape target disappears, is considered a hint that the target has been deleted. The ingester appends a staleness marker, with the same timestamp, to all the in-memory series with the same job and instance labels that received a sample in the previous 5 minutes and have not been marked as stale yet, so that the series of a disappeared target don't show flat-line artifacts in queries.")
 
 	f.IntVar(&l.MaxChunksPerQuery, MaxChunksPerQueryFlag, 2e6, "Maximum number of chunks that can be fetched in a single query from ingesters and long-term storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, MaxSeriesPerQueryFlag, 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and storage. This limit is enforced in the querier and ruler. 0 to disable")
 	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, MaxChunkBytesPerQueryFlag, 0, "The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler. 0 to disable.")
 	f.Var(&l.MaxQueryLength, maxQueryLengthFlag, "Limit the query time range (end - start time). This limit is enforced in the querier (on the query possibly split by the query-frontend) and ruler. 0 to disable.")
+	f.IntVar(&l.MaxConcurrentQueriesPerTenant, "querier.max-concurrent-queries-per-tenant", 0, "Maximum number of queries of the tenant concurrently executed by each querier. The queries exceeding the limit wait inside the querier for the running queries of the tenant to complete, up to -querier.tenant-queue-timeout, so that the queries of a single tenant can't occupy all the query workers of a querier. 0 to disable.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split (by time) or partial (by shard) queries that will be scheduled in parallel by the query-frontend for a single input query. This limit is introduced to have a fairer query scheduling and avoid a single query over a large time range saturating all available queriers.")
 	f.Var(&l.MaxLabelsQueryLength, "store.max-labels-query-length", "Limit the time range (end - start time) of series, label names and values queries. This limit is enforced in the querier. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
@@ -207,6 +286,15 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.LabelValuesMaxCardinalityLabelNamesPerRequest, "querier.label-values-max-cardinality-label-names-per-request", 100, "Maximum number of label names allowed to be queried in a single /api/v1/cardinality/label_values API call.")
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "query-frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
+	_ = l.ResultsCacheTTL.Set("7d")
+	f.Var(&l.ResultsCacheTTL, "query-frontend.results-cache-ttl", "Time to live of cached query results whose time range ends before the recent results window. 0 to use the default of 7 days.")
+	_ = l.ResultsCacheTTLForRecent.Set("10m")
+	f.Var(&l.ResultsCacheTTLForRecent, "query-frontend.results-cache-ttl-for-recent-results", "Time to live of cached query results whose time range ends within the recent results window or the out-of-order time window, since these results may still change. 0 to use the default of 10 minutes.")
+	f.Var(&l.ResultsCacheRecentWindow, "query-frontend.results-cache-recent-results-window", "Cached query results whose time range ends within this period from now are cached with the TTL for recent results. It should be set to at least the period queried from ingesters, such as -querier.query-ingesters-within. 0 to only apply the TTL for recent results within the out-of-order time window.")
+	f.Var(&l.ResultsCacheMaxStaleness, "query-frontend.results-cache-max-staleness", "Maximum period cached query results are served after their TTL has expired, while they're refreshed in the background. Cached results are kept in the cache for their TTL plus this period. 0 to disable serving stale cached results.")
+	f.Var(&l.ResultsCacheInstantTolerance, "query-frontend.results-cache-instant-queries-time-tolerance", "When set, the time of instant queries is aligned down to a multiple of this period and their results are cached, so that identical instant queries received within the same period are served from the results cache. Requires -query-frontend.cache-results. 0 to disable caching the results of instant queries.")
+	_ = l.ResultsCacheTTLForInstant.Set("1m")
+	f.Var(&l.ResultsCacheTTLForInstant, "query-frontend.results-cache-ttl-for-instant-queries", "Time to live of cached instant query results.")
 	f.IntVar(&l.MaxQueriersPerTenant, "query-frontend.max-queriers-per-tenant", 0, "Maximum number of queriers that can handle requests for a single tenant. If set to 0 or value higher than number of available queriers, *all* queriers will handle requests for the tenant. Each frontend (or query-scheduler, if used) will select the same set of queriers for the same tenant (given that all queriers are connected to all frontends / query-schedulers). This option only works with queriers connecting to the query-frontend / query-scheduler, not when using downstream URL.")
 	f.IntVar(&l.QueryShardingTotalShards, "query-frontend.query-sharding-total-shards", 16, "The amount of shards to use when doing parallelisation via query sharding by tenant. 0 to disable query sharding for tenant. Query sharding implementation will adjust the number of query shards based on compactor shards. This allows querier to not search the blocks which cannot possibly have the series for given query shard.")
 	f.IntVar(&l.QueryShardingMaxShardedQueries, "query-frontend.query-sharding-max-sharded-queries", 128, "The max number of sharded queries that can be run for a given received query. 0 to disable limit.")
@@ -218,6 +306,11 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.RulerMaxRuleGroupsPerTenant, "ruler.max-rule-groups-per-tenant", 70, "Maximum number of rule groups per-tenant. 0 to disable.")
 	f.BoolVar(&l.RulerRecordingRulesEvaluationEnabled, "ruler.recording-rules-evaluation-enabled", true, "Controls whether recording rules evaluation is enabled. This configuration option can be used to forcefully disable recording rules evaluation on a per-tenant basis.")
 	f.BoolVar(&l.RulerAlertingRulesEvaluationEnabled, "ruler.alerting-rules-evaluation-enabled", true, "Controls whether alerting rules evaluation is enabled. This configuration option can be used to forcefully disable alerting rules evaluation on a per-tenant basis.")
+	f.StringVar(&l.RulerTenantAlertmanagerURL, "ruler.tenant-alertmanager-url", "", "Comma-separated list of URL(s) of the Alertmanager(s) to send the tenant's notifications to, instead of the ones configured with -ruler.alertmanager-url. The URLs support the same format as -ruler.alertmanager-url. This option is meant to be set on a per-tenant basis, for tenants running their own alert routing.")
+	f.BoolVar(&l.RulerSendToDefaultAlertmanager, "ruler.send-to-default-alertmanager", false, "If enabled and -ruler.tenant-alertmanager-url is set, the tenant's notifications are sent to both the tenant Alertmanager(s) and the ones configured with -ruler.alertmanager-url.")
+	f.Var(&l.RulerLimitsGracePeriod, "ruler.limits-grace-period", "Period during which the rule groups exceeding -ruler.max-rule-groups-per-tenant or -ruler.max-rules-per-rule-group are accepted, with a warning, before being rejected. The period starts when the tenant exceeds the limits for the first time, and is reset once all the rule groups of the tenant are within the limits. 0 to disable.")
+	f.IntVar(&l.RulerMaxReportsPerTenant, "ruler.max-reports-per-tenant", 10, "Maximum number of reports per-tenant, when the reports are enabled with -ruler.reports.enabled. 0 to disable.")
+	f.Var(&l.RulerCanaryPeriod, "ruler.canary-period", "Period during which a modified rule group is evaluated in shadow, with its results discarded, before replacing the version currently evaluated. The modified rule group is promoted once the period has elapsed only if its last evaluation succeeded, otherwise it stays in shadow until promoted or rolled back through the API. 0 to disable.")
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. Also used by query-frontend to avoid querying beyond the retention period. 0 to disable.")
 	f.IntVar(&l.CompactorSplitAndMergeShards, "compactor.split-and-merge-shards", 0, "The number of shards to use when splitting blocks. 0 to disable splitting.")
@@ -228,9 +321,20 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	// Query-frontend.
 	f.Var(&l.MaxTotalQueryLength, maxTotalQueryLengthFlag, fmt.Sprintf("Limit the total query time range (end - start time). This limit is enforced in the query-frontend on the received query. Defaults to the value of -%s if set to 0.", maxQueryLengthFlag))
+	f.IntVar(&l.MaxEstimatedFetchedSeriesPerQuery, maxEstimatedSeriesPerQueryFlag, 0, "The maximum number of series a query is estimated to fetch, based on the number of series fetched by the previous execution of the same query. Queries exceeding it are rejected by the query-frontend before being executed. Requires -query-frontend.cache-results. 0 to disable.")
+	f.IntVar(&l.MaxEstimatedFetchedChunksPerQuery, maxEstimatedChunksPerQueryFlag, 0, "The maximum number of chunks a query is estimated to fetch, based on the number of chunks fetched by the previous execution of the same query. Queries exceeding it are rejected by the query-frontend before being executed. Requires -query-frontend.cache-results. 0 to disable.")
+
+	// Query-scheduler.
+	f.StringVar(&l.DefaultQueryPriority, "query-scheduler.default-query-priority", "normal", "Priority of the queries of the tenant which don't set the X-Mimir-Query-Priority header. The query-scheduler dequeues the queries of a tenant with a higher priority before the ones with a lower priority. The ruler sends its queries with the high priority. Supported values are: low, normal, high.")
 
 	// Store-gateway.
 	f.IntVar(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The tenant's shard size, used when store-gateway sharding is enabled. Value of 0 disables shuffle sharding for the tenant, that is all tenant blocks are sharded across all store-gateway replicas.")
+	f.IntVar(&l.StoreGatewayStreamingSeriesBatchSize, "store-gateway.streaming-series-batch-size", 0, "If larger than 0, overrides -blocks-storage.bucket-store.batch-series-size for the tenant, enabling store-gateway series streaming with this number of series per batch. Tenants with huge series can use smaller batches to reduce the store-gateway memory utilization. 0 to use the value of -blocks-storage.bucket-store.batch-series-size.")
+	f.IntVar(&l.StoreGatewayQueryConcurrencyWeight, "store-gateway.query-concurrency-weight", 1, "Weight of the tenant when allocating the store-gateway concurrent queries slots to the tenants, if -blocks-storage.bucket-store.tenant-fair-scheduling-enabled is true. When the queries have to wait for their turn, each tenant gets a share of the slots proportional to its weight.")
+	f.BoolVar(&l.StoreGatewayPartialResultsEnabled, "store-gateway.partial-results-enabled", false, "If enabled, the store-gateway returns the series of the healthy blocks when fetching the series of some blocks fails, along with a warning identifying each failed block, instead of failing the whole request. The warnings are returned to the client as PromQL warnings.")
+	f.IntVar(&l.StoreGatewayChunksFetchMaxConcurrency, "store-gateway.chunks-fetch-max-concurrency", 0, "If larger than 0, overrides -blocks-storage.bucket-store.chunks-fetch-adaptive-max-concurrency for the tenant, when the adaptive chunks fetch concurrency is enabled. 0 to use the value of -blocks-storage.bucket-store.chunks-fetch-adaptive-max-concurrency.")
+	f.BoolVar(&l.StoreGatewayLazyPostingsEnabled, "store-gateway.lazy-postings-enabled", false, "If enabled, the store-gateway streams the postings matching the label matchers of the tenant's queries, fetching the series in batches while intersecting the postings, instead of expanding all the matching postings in memory before fetching the series. This reduces the memory utilization of queries with very high cardinality matchers, at the cost of not caching the expanded postings. Applies only when series streaming is enabled.")
+	f.IntVar(&l.StoreGatewayMaxExemplarsBytesPerQuery, "store-gateway.max-exemplars-bytes-per-query", 16*1024*1024, "Maximum size, in bytes, of the exemplars read from the blocks by each exemplars query to a store-gateway. The queries exceeding the limit are failed. 0 to disable.")
 
 	// Alertmanager.
 	f.Var(&l.AlertmanagerReceiversBlockCIDRNetworks, "alertmanager.receivers-firewall-block-cidr-networks", "Comma-separated list of network CIDRs to block in Alertmanager receiver integrations.")
@@ -247,6 +351,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.AlertmanagerMaxTemplateSizeBytes, "alertmanager.max-template-size-bytes", 0, "Maximum size of single template in tenant's Alertmanager configuration uploaded via Alertmanager API. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxDispatcherAggregationGroups, "alertmanager.max-dispatcher-aggregation-groups", 0, "Maximum number of aggregation groups in Alertmanager's dispatcher that a tenant can have. Each active aggregation group uses single goroutine. When the limit is reached, dispatcher will not dispatch alerts that belong to additional aggregation groups, but existing groups will keep working properly. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxAlertsCount, "alertmanager.max-alerts-count", 0, "Maximum number of alerts that a single tenant can have. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
+	f.IntVar(&l.AlertmanagerMaxReceiversCount, "alertmanager.max-receivers-count", 0, "Maximum number of receivers in tenant's Alertmanager configuration uploaded via Alertmanager API. 0 = no limit.")
+	f.IntVar(&l.AlertmanagerMaxRoutesCount, "alertmanager.max-routes-count", 0, "Maximum number of routes, including the nested ones but excluding the root route, in tenant's Alertmanager configuration uploaded via Alertmanager API. 0 = no limit.")
+	f.Var(&l.AlertmanagerLimitsGracePeriod, "alertmanager.limits-grace-period", "Period during which the Alertmanager configurations exceeding -alertmanager.max-receivers-count or -alertmanager.max-routes-count are accepted, with a warning, before being rejected. The period starts when the tenant exceeds the limits for the first time, and is reset once the tenant uploads a configuration within the limits. 0 to disable.")
 	f.IntVar(&l.AlertmanagerMaxAlertsSizeBytes, "alertmanager.max-alerts-size-bytes", 0, "Maximum total size of alerts that a single tenant can have, alert size is the sum of the bytes of its labels, annotations and generatorURL. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
 }
 
@@ -269,7 +376,28 @@ func (l *Limits) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
-	return nil
+	return l.Validate()
+}
+
+// UnmarshalYAMLWithBase unmarshals the input YAML node on top of the base limits, instead of the
+// default limits, so that the limits not set in the input are inherited from the base ones.
+func (l *Limits) UnmarshalYAMLWithBase(value *yaml.Node, base *Limits) error {
+	*l = *base
+	// Make copy of the base limits maps. Otherwise unmarshalling would modify them.
+	l.copyNotificationIntegrationLimits(base.NotificationRateLimitPerIntegration)
+	if base.ForwardingRules != nil {
+		l.ForwardingRules = make(ForwardingRules, len(base.ForwardingRules))
+		for k, v := range base.ForwardingRules {
+			l.ForwardingRules[k] = v
+		}
+	}
+
+	type plain Limits
+	if err := value.DecodeWithOptions((*plain)(l), yaml.DecodeOptions{KnownFields: true}); err != nil {
+		return err
+	}
+
+	return l.Validate()
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -292,6 +420,16 @@ func (l *Limits) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	return l.Validate()
+}
+
+// Validate returns an error if the limits are invalid.
+func (l *Limits) Validate() error {
+	for _, endpoint := range l.IngesterDisabledReadEndpoints {
+		if !slices.Contains(ingesterReadEndpoints, endpoint) {
+			return fmt.Errorf("invalid ingester disabled read endpoint %q: supported values are: %s", endpoint, strings.Join(ingesterReadEndpoints, ", "))
+		}
+	}
 	return nil
 }
 
@@ -315,6 +453,15 @@ func SetDefaultLimitsForYAMLUnmarshalling(defaults Limits) {
 	defaultLimits = &defaults
 }
 
+// TenantGroup is a group of tenants, e.g. a business unit, sharing the same limits overrides.
+type TenantGroup struct {
+	// Tenants is the list of the IDs of the tenants belonging to the group.
+	Tenants []string `yaml:"tenants" json:"tenants"`
+
+	// Limits are the limits overrides inherited by the tenants belonging to the group.
+	Limits *Limits `yaml:"limits" json:"limits"`
+}
+
 // TenantLimits exposes per-tenant limit overrides to various resource usage limits
 type TenantLimits interface {
 	// ByUserID gets limits specific to a particular tenant or nil if there are none
@@ -349,6 +496,11 @@ func (o *Overrides) RequestBurstSize(userID string) int {
 	return o.getOverridesForUser(userID).RequestBurstSize
 }
 
+// MaxInflightPushRequestsPerTenant returns the max number of inflight push requests of the user in each distributor.
+func (o *Overrides) MaxInflightPushRequestsPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).MaxInflightPushRequestsPerTenant
+}
+
 // IngestionRate returns the limit on ingester rate (samples per second).
 func (o *Overrides) IngestionRate(userID string) float64 {
 	return o.getOverridesForUser(userID).IngestionRate
@@ -393,6 +545,39 @@ func (o *Overrides) DropLabels(userID string) flagext.StringSlice {
 	return o.getOverridesForUser(userID).DropLabels
 }
 
+// PushGatewayEnabled returns whether the tenant can push metrics to the push gateway endpoint.
+func (o *Overrides) PushGatewayEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).PushGatewayEnabled
+}
+
+// PushGatewayStalenessPeriod returns how long the series pushed to the push gateway endpoint are re-exposed
+// after the last push to their group. 0 means until the group is deleted.
+func (o *Overrides) PushGatewayStalenessPeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).PushGatewayStalenessPeriod)
+}
+
+// PushGatewayMaxSeries returns the maximum number of series held by the push gateway endpoint for the tenant.
+func (o *Overrides) PushGatewayMaxSeries(userID string) int {
+	return o.getOverridesForUser(userID).PushGatewayMaxSeries
+}
+
+// ExpositionPushHonorTimestamps returns whether the samples posted to the exposition push endpoint are written
+// with their own timestamp.
+func (o *Overrides) ExpositionPushHonorTimestamps(userID string) bool {
+	return o.getOverridesForUser(userID).ExpositionPushHonorTimestamps
+}
+
+// ExpositionPushMaxSeriesPerRequest returns the maximum number of series in a request to the exposition push endpoint.
+func (o *Overrides) ExpositionPushMaxSeriesPerRequest(userID string) int {
+	return o.getOverridesForUser(userID).ExpositionPushMaxSeriesPerRequest
+}
+
+// OTelDeltaTemporalityConversionEnabled returns whether the OTLP sums and histograms with delta aggregation
+// temporality are accepted and converted to cumulative.
+func (o *Overrides) OTelDeltaTemporalityConversionEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).OTelDeltaTemporalityConversionEnabled
+}
+
 // MaxLabelNameLength returns maximum length a label name can be.
 func (o *Overrides) MaxLabelNameLength(userID string) int {
 	return o.getOverridesForUser(userID).MaxLabelNameLength
@@ -431,6 +616,11 @@ func (o *Overrides) MaxGlobalSeriesPerMetric(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalSeriesPerMetric
 }
 
+// SeriesLimitsGracePeriod returns the period during which the series of a given user exceeding the series limits are accepted before being rejected.
+func (o *Overrides) SeriesLimitsGracePeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).SeriesLimitsGracePeriod)
+}
+
 func (o *Overrides) MaxChunksPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxChunksPerQuery
 }
@@ -447,6 +637,11 @@ func (o *Overrides) MaxFetchedChunkBytesPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQuery
 }
 
+// MaxConcurrentQueriesPerTenant returns the maximum number of queries of the tenant concurrently executed by each querier.
+func (o *Overrides) MaxConcurrentQueriesPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).MaxConcurrentQueriesPerTenant
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryLookback)
@@ -466,6 +661,21 @@ func (o *Overrides) MaxTotalQueryLength(userID string) time.Duration {
 	return t
 }
 
+// MaxEstimatedFetchedSeriesPerQuery returns the maximum number of series a query is estimated to fetch.
+func (o *Overrides) MaxEstimatedFetchedSeriesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxEstimatedFetchedSeriesPerQuery
+}
+
+// MaxEstimatedFetchedChunksPerQuery returns the maximum number of chunks a query is estimated to fetch.
+func (o *Overrides) MaxEstimatedFetchedChunksPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxEstimatedFetchedChunksPerQuery
+}
+
+// DefaultQueryPriority returns the priority of the queries of the user without an explicit priority.
+func (o *Overrides) DefaultQueryPriority(userID string) string {
+	return o.getOverridesForUser(userID).DefaultQueryPriority
+}
+
 // MaxLabelsQueryLength returns the limit of the length (in time) of a label names or values request.
 func (o *Overrides) MaxLabelsQueryLength(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxLabelsQueryLength)
@@ -477,6 +687,38 @@ func (o *Overrides) MaxCacheFreshness(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxCacheFreshness)
 }
 
+// ResultsCacheTTL returns the TTL of cached results whose time range ends before the recent results window.
+func (o *Overrides) ResultsCacheTTL(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheTTL)
+}
+
+// ResultsCacheTTLForRecentResults returns the TTL of cached results whose time range ends within the recent results window.
+func (o *Overrides) ResultsCacheTTLForRecentResults(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheTTLForRecent)
+}
+
+// ResultsCacheRecentResultsWindow returns the period, relative to now, within which cached results are considered recent.
+func (o *Overrides) ResultsCacheRecentResultsWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheRecentWindow)
+}
+
+// ResultsCacheMaxStaleness returns the maximum period cached results are served after their TTL has expired,
+// while they're refreshed in the background.
+func (o *Overrides) ResultsCacheMaxStaleness(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheMaxStaleness)
+}
+
+// ResultsCacheInstantQueriesTimeTolerance returns the period the time of instant queries is aligned to in
+// order to cache their results. 0 if the results of instant queries are not cached.
+func (o *Overrides) ResultsCacheInstantQueriesTimeTolerance(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheInstantTolerance)
+}
+
+// ResultsCacheTTLForInstantQueries returns the TTL of cached instant query results.
+func (o *Overrides) ResultsCacheTTLForInstantQueries(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheTTLForInstant)
+}
+
 // MaxQueriersPerUser returns the maximum number of queriers that can handle requests for this user.
 func (o *Overrides) MaxQueriersPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxQueriersPerTenant
@@ -511,6 +753,33 @@ func (o *Overrides) EnforceMetadataMetricName(userID string) bool {
 	return o.getOverridesForUser(userID).EnforceMetadataMetricName
 }
 
+// IngesterDisabledReadEndpoints returns the read endpoints disabled in the ingesters for the user.
+func (o *Overrides) IngesterDisabledReadEndpoints(userID string) []string {
+	return o.getOverridesForUser(userID).IngesterDisabledReadEndpoints
+}
+
+// IngesterReadEndpointDisabled returns whether the given read endpoint is disabled in the ingesters for the user.
+func (o *Overrides) IngesterReadEndpointDisabled(userID, endpoint string) bool {
+	for _, disabled := range o.IngesterDisabledReadEndpoints(userID) {
+		if disabled == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscardOutOfOrderStaleMarkers returns whether the staleness markers which can't be ingested because out-of-order
+// are discarded without failing the request for the user.
+func (o *Overrides) DiscardOutOfOrderStaleMarkers(userID string) bool {
+	return o.getOverridesForUser(userID).DiscardOutOfOrderStaleMarkers
+}
+
+// TargetDeletionStaleMarkers returns whether a staleness marker for the up series of a target triggers the injection
+// of staleness markers to all the series of the target for the user.
+func (o *Overrides) TargetDeletionStaleMarkers(userID string) bool {
+	return o.getOverridesForUser(userID).TargetDeletionStaleMarkers
+}
+
 // MaxGlobalMetricsWithMetadataPerUser returns the maximum number of metrics with metadata a user is allowed to store across the cluster.
 func (o *Overrides) MaxGlobalMetricsWithMetadataPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalMetricsWithMetadataPerUser
@@ -526,6 +795,16 @@ func (o *Overrides) MaxGlobalExemplarsPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalExemplarsPerUser
 }
 
+// ExemplarLabelsFilters returns the selectors on the exemplar labels of the exemplars ingested for a given user.
+func (o *Overrides) ExemplarLabelsFilters(userID string) ExemplarLabelsFilters {
+	return o.getOverridesForUser(userID).ExemplarLabelsFilters
+}
+
+// ExemplarLabelsKeep returns the names of the exemplar labels kept for a given user.
+func (o *Overrides) ExemplarLabelsKeep(userID string) []string {
+	return o.getOverridesForUser(userID).ExemplarLabelsKeep
+}
+
 func (o *Overrides) ActiveSeriesCustomTrackersConfig(userID string) activeseries.CustomTrackersConfig {
 	return o.getOverridesForUser(userID).ActiveSeriesCustomTrackersConfig
 }
@@ -540,6 +819,12 @@ func (o *Overrides) IngestionTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).IngestionTenantShardSize
 }
 
+// IngestionReplicationFactor returns the number of ingesters the series of a given user are written to,
+// overriding the ingesters ring replication factor if larger than 0 and lower than it.
+func (o *Overrides) IngestionReplicationFactor(userID string) int {
+	return o.getOverridesForUser(userID).IngestionReplicationFactor
+}
+
 // CompactorTenantShardSize returns number of compactors that this user can use. 0 = all compactors.
 func (o *Overrides) CompactorTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).CompactorTenantShardSize
@@ -565,6 +850,11 @@ func (o *Overrides) CompactorSplitGroups(userID string) int {
 	return o.getOverridesForUser(userID).CompactorSplitGroups
 }
 
+// CompactorSeriesRetention returns the per-selector series retention periods for a given user.
+func (o *Overrides) CompactorSeriesRetention(userID string) SeriesRetentionRules {
+	return o.getOverridesForUser(userID).CompactorSeriesRetention
+}
+
 // CompactorPartialBlockDeletionDelay returns the partial block deletion delay time period for a given user,
 // and whether the configured value was valid. If the value wasn't valid, the returned delay is the default one
 // and the caller is responsible to warn the Mimir operator about it.
@@ -584,6 +874,21 @@ func (o *Overrides) CompactorBlockUploadEnabled(tenantID string) bool {
 	return o.getOverridesForUser(tenantID).CompactorBlockUploadEnabled
 }
 
+// IngestionSeriesSamplingRatio returns the ratio of the series ingested for a given user.
+func (o *Overrides) IngestionSeriesSamplingRatio(userID string) float64 {
+	return o.getOverridesForUser(userID).IngestionSeriesSamplingRatio
+}
+
+// IngestionSeriesSamplingRules returns the per-selector ratios of the series ingested for a given user.
+func (o *Overrides) IngestionSeriesSamplingRules(userID string) SeriesSamplingRules {
+	return o.getOverridesForUser(userID).IngestionSeriesSamplingRules
+}
+
+// IngestionSeriesSamplingLabel returns the name of the label added to the sampled series for a given user.
+func (o *Overrides) IngestionSeriesSamplingLabel(userID string) string {
+	return o.getOverridesForUser(userID).IngestionSeriesSamplingLabel
+}
+
 // MetricRelabelConfigs returns the metric relabel configs for a given user.
 func (o *Overrides) MetricRelabelConfigs(userID string) []*relabel.Config {
 	return o.getOverridesForUser(userID).MetricRelabelConfigs
@@ -604,6 +909,11 @@ func (o *Overrides) RulerMaxRuleGroupsPerTenant(userID string) int {
 	return o.getOverridesForUser(userID).RulerMaxRuleGroupsPerTenant
 }
 
+// RulerMaxReportsPerTenant returns the maximum number of reports for a given user.
+func (o *Overrides) RulerMaxReportsPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).RulerMaxReportsPerTenant
+}
+
 // RulerRecordingRulesEvaluationEnabled returns whether the recording rules evaluation is enabled for a given user.
 func (o *Overrides) RulerRecordingRulesEvaluationEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).RulerRecordingRulesEvaluationEnabled
@@ -614,11 +924,67 @@ func (o *Overrides) RulerAlertingRulesEvaluationEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).RulerAlertingRulesEvaluationEnabled
 }
 
+// RulerTenantAlertmanagerURL returns the comma-separated list of Alertmanager URLs the ruler sends the notifications of a given user to, instead of the default ones. Empty if not set.
+func (o *Overrides) RulerTenantAlertmanagerURL(userID string) string {
+	return o.getOverridesForUser(userID).RulerTenantAlertmanagerURL
+}
+
+// RulerSendToDefaultAlertmanager returns whether the ruler sends the notifications of a given user to the default Alertmanagers in addition to the tenant-specific ones.
+func (o *Overrides) RulerSendToDefaultAlertmanager(userID string) bool {
+	return o.getOverridesForUser(userID).RulerSendToDefaultAlertmanager
+}
+
+// RulerCanaryPeriod returns the period during which the modified rule groups of a given user are evaluated in shadow before replacing the active ones.
+func (o *Overrides) RulerCanaryPeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).RulerCanaryPeriod)
+}
+
+// RulerLimitsGracePeriod returns the period during which the rule groups of a given user exceeding the limits are accepted before being rejected.
+func (o *Overrides) RulerLimitsGracePeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).RulerLimitsGracePeriod)
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).StoreGatewayTenantShardSize
 }
 
+// StoreGatewayStreamingSeriesBatchSize returns the number of series per batch used by the store-gateway
+// series streaming for a given user, overriding the global setting if larger than 0.
+func (o *Overrides) StoreGatewayStreamingSeriesBatchSize(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayStreamingSeriesBatchSize
+}
+
+// StoreGatewayQueryConcurrencyWeight returns the weight of a given user when the store-gateway allocates
+// the concurrent queries slots to the tenants.
+func (o *Overrides) StoreGatewayQueryConcurrencyWeight(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayQueryConcurrencyWeight
+}
+
+// StoreGatewayPartialResultsEnabled returns whether the store-gateway returns partial results, along with
+// a warning for each failed block, when fetching the series of some blocks fails for a given user.
+func (o *Overrides) StoreGatewayPartialResultsEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).StoreGatewayPartialResultsEnabled
+}
+
+// StoreGatewayChunksFetchMaxConcurrency returns the max number of concurrent requests issued by the store-gateway
+// to fetch the chunks of each request of a given user, overriding the global setting if larger than 0.
+func (o *Overrides) StoreGatewayChunksFetchMaxConcurrency(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayChunksFetchMaxConcurrency
+}
+
+// StoreGatewayMaxExemplarsBytesPerQuery returns the max size of the exemplars read from the blocks by each
+// exemplars query of the tenant to a store-gateway.
+func (o *Overrides) StoreGatewayMaxExemplarsBytesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxExemplarsBytesPerQuery
+}
+
+// StoreGatewayLazyPostingsEnabled returns whether the store-gateway streams the postings matching the label
+// matchers, instead of expanding them before fetching the series, for a given user.
+func (o *Overrides) StoreGatewayLazyPostingsEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).StoreGatewayLazyPostingsEnabled
+}
+
 // MaxHAClusters returns maximum number of clusters that HA tracker will track for a user.
 func (o *Overrides) MaxHAClusters(user string) int {
 	return o.getOverridesForUser(user).HAMaxClusters
@@ -723,6 +1089,18 @@ func (o *Overrides) AlertmanagerMaxAlertsSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).AlertmanagerMaxAlertsSizeBytes
 }
 
+func (o *Overrides) AlertmanagerMaxReceiversCount(userID string) int {
+	return o.getOverridesForUser(userID).AlertmanagerMaxReceiversCount
+}
+
+func (o *Overrides) AlertmanagerMaxRoutesCount(userID string) int {
+	return o.getOverridesForUser(userID).AlertmanagerMaxRoutesCount
+}
+
+func (o *Overrides) AlertmanagerLimitsGracePeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).AlertmanagerLimitsGracePeriod)
+}
+
 func (o *Overrides) ForwardingRules(user string) ForwardingRules {
 	return o.getOverridesForUser(user).ForwardingRules
 }
@@ -815,6 +1193,19 @@ func LargestPositiveNonZeroDurationPerTenant(tenantIDs []string, f func(string)
 	return *result
 }
 
+// MinDurationPerTenant is returning the minimum duration per tenant. Without
+// tenants given it will return a time.Duration(0).
+func MinDurationPerTenant(tenantIDs []string, f func(string) time.Duration) time.Duration {
+	result := time.Duration(0)
+	for idx, tenantID := range tenantIDs {
+		v := f(tenantID)
+		if idx == 0 || v < result {
+			result = v
+		}
+	}
+	return result
+}
+
 // MaxDurationPerTenant is returning the maximum duration per tenant. Without
 // tenants given it will return a time.Duration(0).
 func MaxDurationPerTenant(tenantIDs []string, f func(string) time.Duration) time.Duration {
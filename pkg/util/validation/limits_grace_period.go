@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LimitsExceededSinceStore stores the time since which the configuration of a tenant has been exceeding the limits.
+type LimitsExceededSinceStore interface {
+	// GetLimitsExceededSince returns the time since which the configuration of the tenant has been exceeding
+	// the limits. Returns the zero time if it's not exceeding the limits.
+	GetLimitsExceededSince(ctx context.Context, userID string) (time.Time, error)
+
+	// SetLimitsExceededSince stores the time since which the configuration of the tenant has been exceeding
+	// the limits. If since is the zero time, the stored time is removed.
+	SetLimitsExceededSince(ctx context.Context, userID string, since time.Time) error
+}
+
+// ApplyLimitsGracePeriod applies the limits grace period to the result of the limits validation of a configuration
+// of the tenant. If the configuration exceeds the limits (limitsErr is not nil) but the tenant is within the grace
+// period, the configuration is accepted: the returned limits error is nil, and a warning for the tenant is returned
+// instead. The grace period starts the first time the tenant exceeds the limits, and is reset once the tenant
+// uploads a configuration within the limits. A grace period <= 0 disables it. The returned err is non-nil only
+// if the grace period state can't be read or updated in the store.
+func ApplyLimitsGracePeriod(ctx context.Context, store LimitsExceededSinceStore, userID string, gracePeriod time.Duration, limitsErr error, now time.Time) (warning string, _ error, err error) {
+	if gracePeriod <= 0 {
+		return "", limitsErr, nil
+	}
+
+	since, err := store.GetLimitsExceededSince(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if limitsErr == nil {
+		if !since.IsZero() {
+			if err := store.SetLimitsExceededSince(ctx, userID, time.Time{}); err != nil {
+				return "", nil, err
+			}
+		}
+		return "", nil, nil
+	}
+
+	if since.IsZero() {
+		since = now
+		if err := store.SetLimitsExceededSince(ctx, userID, since); err != nil {
+			return "", nil, err
+		}
+	}
+
+	expiresAt := since.Add(gracePeriod)
+	if !now.Before(expiresAt) {
+		return "", fmt.Errorf("%w (the limits grace period expired at %s)", limitsErr, expiresAt.UTC().Format(time.RFC3339)), nil
+	}
+
+	return fmt.Sprintf("%s (accepted because the limits grace period expires at %s)", limitsErr, expiresAt.UTC().Format(time.RFC3339)), nil, nil
+}
@@ -90,6 +90,42 @@ func TestLimitsLoadingFromJson(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestLimitsValidation_IngesterDisabledReadEndpoints(t *testing.T) {
+	SetDefaultLimitsForYAMLUnmarshalling(Limits{})
+
+	for name, tc := range map[string]struct {
+		yaml        string
+		json        string
+		expectedErr string
+	}{
+		"supported endpoints": {
+			yaml: `ingester_disabled_read_endpoints: label_names,label_values,series`,
+			json: `{"ingester_disabled_read_endpoints": ["label_names", "label_values", "series"]}`,
+		},
+		"unknown endpoint": {
+			yaml:        `ingester_disabled_read_endpoints: label_names,serie`,
+			json:        `{"ingester_disabled_read_endpoints": ["label_names", "serie"]}`,
+			expectedErr: `invalid ingester disabled read endpoint "serie"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var fromYAML, fromJSON Limits
+			yamlErr := yaml.Unmarshal([]byte(tc.yaml), &fromYAML)
+			jsonErr := json.Unmarshal([]byte(tc.json), &fromJSON)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, yamlErr, tc.expectedErr)
+				require.ErrorContains(t, jsonErr, tc.expectedErr)
+				return
+			}
+			require.NoError(t, yamlErr)
+			require.NoError(t, jsonErr)
+			assert.Equal(t, []string{IngesterReadEndpointLabelNames, IngesterReadEndpointLabelValues, IngesterReadEndpointSeries}, []string(fromYAML.IngesterDisabledReadEndpoints))
+			assert.Equal(t, fromYAML.IngesterDisabledReadEndpoints, fromJSON.IngesterDisabledReadEndpoints)
+		})
+	}
+}
+
 func TestLimitsTagsYamlMatchJson(t *testing.T) {
 	limits := reflect.TypeOf(Limits{})
 	n := limits.NumField()
@@ -297,6 +333,38 @@ func TestLargestPositiveNonZeroDurationPerTenant(t *testing.T) {
 	}
 }
 
+func TestMinDurationPerTenant(t *testing.T) {
+	tenantLimits := map[string]*Limits{
+		"tenant-a": {
+			CreationGracePeriod: model.Duration(time.Hour),
+		},
+		"tenant-b": {
+			CreationGracePeriod: model.Duration(4 * time.Hour),
+		},
+	}
+
+	defaults := Limits{
+		CreationGracePeriod: 0,
+	}
+	ov, err := NewOverrides(defaults, NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		tenantIDs []string
+		expLimit  time.Duration
+	}{
+		{tenantIDs: []string{}, expLimit: time.Duration(0)},
+		{tenantIDs: []string{"tenant-a"}, expLimit: time.Hour},
+		{tenantIDs: []string{"tenant-b"}, expLimit: 4 * time.Hour},
+		{tenantIDs: []string{"tenant-c"}, expLimit: time.Duration(0)},
+		{tenantIDs: []string{"tenant-a", "tenant-b"}, expLimit: time.Hour},
+		{tenantIDs: []string{"tenant-b", "tenant-a"}, expLimit: time.Hour},
+		{tenantIDs: []string{"tenant-a", "tenant-b", "tenant-c"}, expLimit: time.Duration(0)},
+	} {
+		assert.Equal(t, tc.expLimit, MinDurationPerTenant(tc.tenantIDs, ov.CreationGracePeriod))
+	}
+}
+
 func TestMaxTotalQueryLengthWithoutDefault(t *testing.T) {
 	tenantLimits := map[string]*Limits{
 		"tenant-a": {
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScheduledLimits are limits overrides applied to a tenant only during a recurring time window,
+// e.g. to relax the query limits during business hours and make them stricter at night.
+type ScheduledLimits struct {
+	// Name identifies the scheduled limits, e.g. in the exported metrics.
+	Name string `yaml:"name" json:"name"`
+
+	// Schedule is the recurring time window during which the limits are applied.
+	Schedule TimeWindow `yaml:"schedule" json:"schedule"`
+
+	// Limits are the limits overrides applied during the time window.
+	Limits *Limits `yaml:"limits" json:"limits"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ScheduledLimits) UnmarshalYAML(value *yaml.Node) error {
+	type plain ScheduledLimits
+	if err := value.DecodeWithOptions((*plain)(s), yaml.DecodeOptions{KnownFields: true}); err != nil {
+		return err
+	}
+
+	if s.Name == "" {
+		return fmt.Errorf("the name of the scheduled limits is required")
+	}
+	return nil
+}
+
+// TimeWindow is a time window recurring on some days of the week, between a start and an end time of the day.
+// The window ends on the next day if the end time is before the start time.
+type TimeWindow struct {
+	// DaysOfWeek are the days of the week on which the window starts. Empty means every day.
+	DaysOfWeek []string `yaml:"days_of_week" json:"days_of_week"`
+
+	// StartTime and EndTime are the start (inclusive) and end (exclusive) time of the day, in the HH:MM format.
+	StartTime string `yaml:"start_time" json:"start_time"`
+	EndTime   string `yaml:"end_time" json:"end_time"`
+
+	// Timezone is the IANA name of the timezone of the start and end time. Empty means UTC.
+	Timezone string `yaml:"timezone" json:"timezone"`
+
+	days     [7]bool
+	start    int // Minutes since midnight.
+	end      int // Minutes since midnight.
+	location *time.Location
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (w *TimeWindow) UnmarshalYAML(value *yaml.Node) error {
+	type plain TimeWindow
+	if err := value.DecodeWithOptions((*plain)(w), yaml.DecodeOptions{KnownFields: true}); err != nil {
+		return err
+	}
+	return w.parse()
+}
+
+func (w *TimeWindow) parse() error {
+	var err error
+
+	if w.start, err = parseTimeOfDay(w.StartTime); err != nil {
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if w.end, err = parseTimeOfDay(w.EndTime); err != nil {
+		return fmt.Errorf("invalid end time: %w", err)
+	}
+	if w.start == w.end {
+		return fmt.Errorf("the start time and end time of the time window must be different")
+	}
+
+	if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	w.days = [7]bool{}
+	if len(w.DaysOfWeek) == 0 {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+	for _, day := range w.DaysOfWeek {
+		weekday, ok := parseWeekday(day)
+		if !ok {
+			return fmt.Errorf("invalid day of the week: %s", day)
+		}
+		w.days[weekday] = true
+	}
+
+	return nil
+}
+
+// Contains returns whether the input time falls in the time window.
+func (w *TimeWindow) Contains(t time.Time) bool {
+	if w.location == nil {
+		return false
+	}
+
+	t = t.In(w.location)
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+
+	// The window crosses midnight, so it may have started on the previous day.
+	if minute >= w.start {
+		return w.days[t.Weekday()]
+	}
+	return minute < w.end && w.days[(t.Weekday()+6)%7]
+}
+
+// parseTimeOfDay parses a time of the day in the HH:MM format, returning the minutes since midnight.
+// 24:00 is allowed to set the end of the day.
+func parseTimeOfDay(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%q is not in the HH:MM format", value)
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 24 {
+		return 0, fmt.Errorf("%q has invalid hours", value)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 || (hours == 24 && minutes > 0) {
+		return 0, fmt.Errorf("%q has invalid minutes", value)
+	}
+
+	return hours*60 + minutes, nil
+}
+
+// parseWeekday parses the full or three letters name of a day of the week, case insensitive.
+func parseWeekday(value string) (time.Weekday, bool) {
+	value = strings.ToLower(value)
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if value == name || value == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// ScheduledTenantLimits is implemented by the TenantLimits supporting scheduled limits overrides.
+type ScheduledTenantLimits interface {
+	// ActiveScheduledLimitsByUserID returns the name of the scheduled limits currently applied to each tenant.
+	ActiveScheduledLimitsByUserID() map[string]string
+}
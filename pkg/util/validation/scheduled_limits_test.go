@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTimeWindow_Contains(t *testing.T) {
+	// 2022-11-07 is a Monday.
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2022, 11, 7, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := map[string]struct {
+		window   string
+		time     time.Time
+		expected bool
+	}{
+		"every day, in the window": {
+			window:   `{start_time: "08:00", end_time: "18:00"}`,
+			time:     monday(8, 0),
+			expected: true,
+		},
+		"every day, end time is exclusive": {
+			window:   `{start_time: "08:00", end_time: "18:00"}`,
+			time:     monday(18, 0),
+			expected: false,
+		},
+		"every day, before the window": {
+			window:   `{start_time: "08:00", end_time: "18:00"}`,
+			time:     monday(7, 59),
+			expected: false,
+		},
+		"weekdays, in the window": {
+			window:   `{days_of_week: [monday, tue, WED, thursday, friday], start_time: "08:00", end_time: "18:00"}`,
+			time:     monday(12, 0),
+			expected: true,
+		},
+		"weekend, in the time of the day but not on the day": {
+			window:   `{days_of_week: [saturday, sunday], start_time: "08:00", end_time: "18:00"}`,
+			time:     monday(12, 0),
+			expected: false,
+		},
+		"crossing midnight, after the start time": {
+			window:   `{days_of_week: [monday], start_time: "22:00", end_time: "06:00"}`,
+			time:     monday(23, 0),
+			expected: true,
+		},
+		"crossing midnight, before the end time on the next day": {
+			window:   `{days_of_week: [sunday], start_time: "22:00", end_time: "06:00"}`,
+			time:     monday(5, 59),
+			expected: true,
+		},
+		"crossing midnight, before the end time but the window didn't start on the previous day": {
+			window:   `{days_of_week: [monday], start_time: "22:00", end_time: "06:00"}`,
+			time:     monday(5, 59),
+			expected: false,
+		},
+		"until the end of the day": {
+			window:   `{start_time: "20:00", end_time: "24:00"}`,
+			time:     monday(23, 59),
+			expected: true,
+		},
+		"timezone": {
+			window:   `{start_time: "08:00", end_time: "18:00", timezone: "America/New_York"}`,
+			time:     monday(12, 0), // 07:00 in New York.
+			expected: false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var w TimeWindow
+			require.NoError(t, yaml.Unmarshal([]byte(testData.window), &w))
+			assert.Equal(t, testData.expected, w.Contains(testData.time))
+		})
+	}
+}
+
+func TestTimeWindow_UnmarshalYAML_ShouldReturnErrorOnInvalidWindow(t *testing.T) {
+	tests := map[string]struct {
+		window      string
+		expectedErr string
+	}{
+		"invalid start time": {
+			window:      `{start_time: "8", end_time: "18:00"}`,
+			expectedErr: `invalid start time: "8" is not in the HH:MM format`,
+		},
+		"invalid end time": {
+			window:      `{start_time: "08:00", end_time: "24:30"}`,
+			expectedErr: `invalid end time: "24:30" has invalid minutes`,
+		},
+		"same start and end time": {
+			window:      `{start_time: "08:00", end_time: "08:00"}`,
+			expectedErr: "the start time and end time of the time window must be different",
+		},
+		"invalid day of the week": {
+			window:      `{days_of_week: [someday], start_time: "08:00", end_time: "18:00"}`,
+			expectedErr: "invalid day of the week: someday",
+		},
+		"invalid timezone": {
+			window:      `{start_time: "08:00", end_time: "18:00", timezone: "Nowhere/Unknown"}`,
+			expectedErr: "invalid timezone: unknown time zone Nowhere/Unknown",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var w TimeWindow
+			assert.EqualError(t, yaml.Unmarshal([]byte(testData.window), &w), testData.expectedErr)
+		})
+	}
+}
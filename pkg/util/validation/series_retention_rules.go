@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// SeriesRetentionRules maps a series selector (e.g. `{job="canary"}`) to the retention period
+// enforced on the series matching it.
+type SeriesRetentionRules map[string]model.Duration
+
+// ExampleDoc provides an example doc for this config, since it's custom-unmarshaled.
+func (r SeriesRetentionRules) ExampleDoc() (comment string, yaml interface{}) {
+	return `The following configuration deletes the series with the label job="canary" from the blocks entirely older than 7 days,` +
+			` and the series of the metric up from the blocks entirely older than 30 days.`,
+		map[string]string{
+			`{job="canary"}`: "7d",
+			`up`:             "30d",
+		}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *SeriesRetentionRules) UnmarshalYAML(value *yaml.Node) error {
+	rules := map[string]model.Duration{}
+	if err := value.DecodeWithOptions(&rules, yaml.DecodeOptions{KnownFields: true}); err != nil {
+		return err
+	}
+	return r.update(rules)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *SeriesRetentionRules) UnmarshalJSON(data []byte) error {
+	rules := map[string]model.Duration{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	return r.update(rules)
+}
+
+func (r *SeriesRetentionRules) update(rules map[string]model.Duration) error {
+	for selector, retention := range rules {
+		if _, err := parser.ParseMetricSelector(selector); err != nil {
+			return errors.Wrapf(err, "invalid series retention selector %q", selector)
+		}
+		if retention <= 0 {
+			return errors.Errorf("invalid series retention period %q for selector %q: must be greater than 0", retention, selector)
+		}
+	}
+
+	*r = rules
+	return nil
+}
+
+// Matchers returns the parsed label matchers for each selector. Selectors are validated when
+// unmarshalling, so this function only returns an error if the rules have been built programmatically.
+func (r SeriesRetentionRules) Matchers() (map[string][]*labels.Matcher, error) {
+	out := make(map[string][]*labels.Matcher, len(r))
+	for selector := range r {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid series retention selector %q", selector)
+		}
+		out[selector] = matchers
+	}
+	return out, nil
+}
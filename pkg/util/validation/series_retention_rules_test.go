@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSeriesRetentionRules_Unmarshal(t *testing.T) {
+	for name, tc := range map[string]struct {
+		yaml        string
+		json        string
+		expected    SeriesRetentionRules
+		expectedErr string
+	}{
+		"valid rules": {
+			yaml: `
+'{job="canary"}': 7d
+up: 30d
+`,
+			json: `{"{job=\"canary\"}": "7d", "up": "30d"}`,
+			expected: SeriesRetentionRules{
+				`{job="canary"}`: model.Duration(7 * 24 * time.Hour),
+				`up`:             model.Duration(30 * 24 * time.Hour),
+			},
+		},
+		"invalid selector": {
+			yaml:        `'{job=}': 7d`,
+			json:        `{"{job=}": "7d"}`,
+			expectedErr: `invalid series retention selector "{job=}"`,
+		},
+		"zero retention": {
+			yaml:        `up: 0s`,
+			json:        `{"up": "0s"}`,
+			expectedErr: `invalid series retention period "0s" for selector "up"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var fromYAML, fromJSON SeriesRetentionRules
+
+			yamlErr := yaml.Unmarshal([]byte(tc.yaml), &fromYAML)
+			jsonErr := json.Unmarshal([]byte(tc.json), &fromJSON)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, yamlErr, tc.expectedErr)
+				require.ErrorContains(t, jsonErr, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, yamlErr)
+			require.NoError(t, jsonErr)
+			assert.Equal(t, tc.expected, fromYAML)
+			assert.Equal(t, tc.expected, fromJSON)
+
+			matchers, err := fromYAML.Matchers()
+			require.NoError(t, err)
+			assert.Len(t, matchers, len(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// SeriesSamplingRules maps a series selector (e.g. `{job="canary"}`) to the ratio of the series
+// matching it which are ingested. The selectors are parsed once, when unmarshalled.
+type SeriesSamplingRules struct {
+	source   map[string]float64
+	matchers map[string][]*labels.Matcher
+}
+
+// NewSeriesSamplingRules makes SeriesSamplingRules from the input ratios by selector.
+func NewSeriesSamplingRules(rules map[string]float64) (r SeriesSamplingRules, err error) {
+	r.source = rules
+	r.matchers = make(map[string][]*labels.Matcher, len(rules))
+	for selector, ratio := range rules {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return r, errors.Wrapf(err, "invalid series sampling selector %q", selector)
+		}
+		if ratio <= 0 || ratio > 1 {
+			return r, errors.Errorf("invalid series sampling ratio %v for selector %q: must be greater than 0 and less than or equal to 1", ratio, selector)
+		}
+		r.matchers[selector] = matchers
+	}
+	return r, nil
+}
+
+// ExampleDoc provides an example doc for this config, since it's custom-unmarshaled.
+func (r SeriesSamplingRules) ExampleDoc() (comment string, yaml interface{}) {
+	return `The following configuration ingests 10% of the series with the label job="canary",` +
+			` and 50% of the series of the metric http_request_duration_seconds_bucket.`,
+		map[string]float64{
+			`{job="canary"}`:                       0.1,
+			`http_request_duration_seconds_bucket`: 0.5,
+		}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *SeriesSamplingRules) UnmarshalYAML(value *yaml.Node) error {
+	rules := map[string]float64{}
+	if err := value.DecodeWithOptions(&rules, yaml.DecodeOptions{KnownFields: true}); err != nil {
+		return err
+	}
+
+	var err error
+	*r, err = NewSeriesSamplingRules(rules)
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (r SeriesSamplingRules) MarshalYAML() (interface{}, error) {
+	return r.source, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *SeriesSamplingRules) UnmarshalJSON(data []byte) error {
+	rules := map[string]float64{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+
+	var err error
+	*r, err = NewSeriesSamplingRules(rules)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r SeriesSamplingRules) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.source)
+}
+
+// Ratios returns the ratio of each selector the rules have been built from.
+func (r SeriesSamplingRules) Ratios() map[string]float64 {
+	return r.source
+}
+
+// Matchers returns the parsed label matchers of each selector.
+func (r SeriesSamplingRules) Matchers() map[string][]*labels.Matcher {
+	return r.matchers
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSeriesSamplingRules_Unmarshal(t *testing.T) {
+	for name, tc := range map[string]struct {
+		yaml        string
+		json        string
+		expected    map[string]float64
+		expectedErr string
+	}{
+		"valid rules": {
+			yaml: `
+'{job="canary"}': 0.1
+up: 1
+`,
+			json: `{"{job=\"canary\"}": 0.1, "up": 1}`,
+			expected: map[string]float64{
+				`{job="canary"}`: 0.1,
+				`up`:             1,
+			},
+		},
+		"invalid selector": {
+			yaml:        `'{job=}': 0.1`,
+			json:        `{"{job=}": 0.1}`,
+			expectedErr: `invalid series sampling selector "{job=}"`,
+		},
+		"zero ratio": {
+			yaml:        `up: 0`,
+			json:        `{"up": 0}`,
+			expectedErr: `invalid series sampling ratio 0 for selector "up"`,
+		},
+		"ratio greater than 1": {
+			yaml:        `up: 1.5`,
+			json:        `{"up": 1.5}`,
+			expectedErr: `invalid series sampling ratio 1.5 for selector "up"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var fromYAML, fromJSON SeriesSamplingRules
+
+			yamlErr := yaml.Unmarshal([]byte(tc.yaml), &fromYAML)
+			jsonErr := json.Unmarshal([]byte(tc.json), &fromJSON)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, yamlErr, tc.expectedErr)
+				require.ErrorContains(t, jsonErr, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, yamlErr)
+			require.NoError(t, jsonErr)
+			assert.Equal(t, tc.expected, fromYAML.Ratios())
+			assert.Equal(t, tc.expected, fromJSON.Ratios())
+			assert.Len(t, fromYAML.Matchers(), len(tc.expected))
+			assert.Len(t, fromJSON.Matchers(), len(tc.expected))
+
+			// The rules should be marshalled back to the source ratios.
+			out, err := yaml.Marshal(fromYAML)
+			require.NoError(t, err)
+			var roundTrip SeriesSamplingRules
+			require.NoError(t, yaml.Unmarshal(out, &roundTrip))
+			assert.Equal(t, tc.expected, roundTrip.Ratios())
+		})
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util/extract"
@@ -45,6 +47,8 @@ var (
 	reasonExemplarTimestampInvalid = metricReasonFromErrorID(globalerror.ExemplarTimestampInvalid)
 	reasonExemplarLabelsBlank      = "exemplar_labels_blank"
 	reasonExemplarTooOld           = "exemplar_too_old"
+	reasonExemplarLabelsFiltered   = "exemplar_labels_filtered"
+	reasonExemplarLabelsStripped   = "exemplar_labels_stripped"
 
 	// Discarded metadata reasons.
 	reasonMetadataMetricNameTooLong = metricReasonFromErrorID(globalerror.MetricMetadataMetricNameTooLong)
@@ -54,6 +58,9 @@ var (
 	// Declared here to avoid duplication in ingester and distributor.
 	ReasonRateLimited = "rate_limited" // same for request and ingestion which are separate errors, so not using metricReasonFromErrorID with global error
 
+	// ReasonInflightPushRequestsLimited is the reason for discarding the requests exceeding the tenant's inflight push requests limit.
+	ReasonInflightPushRequestsLimited = metricReasonFromErrorID(globalerror.InflightPushRequestsLimited)
+
 	// ReasonTooManyHAClusters is one of the reasons for discarding samples.
 	ReasonTooManyHAClusters = "too_many_ha_clusters"
 )
@@ -158,6 +165,8 @@ type ExemplarValidationMetrics struct {
 	labelsTooLong    *prometheus.CounterVec
 	labelsBlank      *prometheus.CounterVec
 	tooOld           *prometheus.CounterVec
+	labelsFiltered   *prometheus.CounterVec
+	labelsStripped   *prometheus.CounterVec
 }
 
 func (m *ExemplarValidationMetrics) DeleteUserMetrics(userID string) {
@@ -166,6 +175,8 @@ func (m *ExemplarValidationMetrics) DeleteUserMetrics(userID string) {
 	m.labelsTooLong.DeleteLabelValues(userID)
 	m.labelsBlank.DeleteLabelValues(userID)
 	m.tooOld.DeleteLabelValues(userID)
+	m.labelsFiltered.DeleteLabelValues(userID)
+	m.labelsStripped.DeleteLabelValues(userID)
 }
 
 func NewExemplarValidationMetrics(r prometheus.Registerer) *ExemplarValidationMetrics {
@@ -175,6 +186,8 @@ func NewExemplarValidationMetrics(r prometheus.Registerer) *ExemplarValidationMe
 		labelsTooLong:    DiscardedExemplarsCounter(r, reasonExemplarLabelsTooLong),
 		labelsBlank:      DiscardedExemplarsCounter(r, reasonExemplarLabelsBlank),
 		tooOld:           DiscardedExemplarsCounter(r, reasonExemplarTooOld),
+		labelsFiltered:   DiscardedExemplarsCounter(r, reasonExemplarLabelsFiltered),
+		labelsStripped:   DiscardedExemplarsCounter(r, reasonExemplarLabelsStripped),
 	}
 }
 
@@ -253,6 +266,69 @@ func ExemplarTimestampOK(m *ExemplarValidationMetrics, userID string, minTS int6
 	return true
 }
 
+// FilterExemplar returns false if the exemplar labels match none of the filters, if any. Otherwise, it strips the
+// exemplar labels not in keep, if any, and returns false if no label with a value is left after stripping some.
+// It must be called before ValidateExemplar(), so that we silently drop the filtered exemplars, not log an error.
+func FilterExemplar(m *ExemplarValidationMetrics, userID string, filters [][]*labels.Matcher, keep []string, e *mimirpb.Exemplar) bool {
+	if len(filters) > 0 && !exemplarMatchesAnyFilter(e.Labels, filters) {
+		m.labelsFiltered.WithLabelValues(userID).Inc()
+		return false
+	}
+
+	if len(keep) == 0 {
+		return true
+	}
+
+	stripped := false
+	foundValidLabel := false
+	kept := e.Labels[:0]
+	for _, l := range e.Labels {
+		if !slices.Contains(keep, l.Name) {
+			stripped = true
+			continue
+		}
+		kept = append(kept, l)
+		if l.Value != "" {
+			foundValidLabel = true
+		}
+	}
+	e.Labels = kept
+
+	// The exemplars with no valid labels in the first place are rejected by ValidateExemplar().
+	if stripped && !foundValidLabel {
+		m.labelsStripped.WithLabelValues(userID).Inc()
+		return false
+	}
+	return true
+}
+
+func exemplarMatchesAnyFilter(ls []mimirpb.LabelAdapter, filters [][]*labels.Matcher) bool {
+	for _, matchers := range filters {
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(exemplarLabelValue(ls, m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// exemplarLabelValue returns the value of the exemplar label, or an empty string if missing.
+// The exemplar labels are not guaranteed to be sorted.
+func exemplarLabelValue(ls []mimirpb.LabelAdapter, name string) string {
+	for _, l := range ls {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
 // LabelValidationConfig helps with getting required config to validate labels.
 type LabelValidationConfig interface {
 	MaxLabelNamesPerSeries(userID string) int
@@ -223,6 +223,40 @@ func TestValidateExemplars(t *testing.T) {
 	`), "cortex_discarded_exemplars_total"))
 }
 
+func TestFilterExemplar(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewExemplarValidationMetrics(reg)
+
+	userID := "testUser"
+	exemplarLabelsFilters, err := NewExemplarLabelsFilters([]string{`{trace_id=~".+"}`})
+	require.NoError(t, err)
+	filters := exemplarLabelsFilters.Matchers()
+
+	// The exemplar is discarded if its labels don't match the filters.
+	e := mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}}, TimestampMs: 1000}
+	assert.False(t, FilterExemplar(m, userID, filters, nil, &e))
+
+	// The exemplar is kept with all its labels if no labels to keep are configured.
+	e = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}, {Name: "trace_id", Value: "123abc"}}, TimestampMs: 1000}
+	assert.True(t, FilterExemplar(m, userID, filters, nil, &e))
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}, {Name: "trace_id", Value: "123abc"}}, e.Labels)
+
+	// The labels not to keep are stripped.
+	assert.True(t, FilterExemplar(m, userID, filters, []string{"trace_id"}, &e))
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "trace_id", Value: "123abc"}}, e.Labels)
+
+	// The exemplar is discarded if no label with a value is left.
+	e = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}, {Name: "trace_id", Value: ""}}, TimestampMs: 1000}
+	assert.False(t, FilterExemplar(m, userID, nil, []string{"trace_id"}, &e))
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_discarded_exemplars_total The total number of exemplars that were discarded.
+			# TYPE cortex_discarded_exemplars_total counter
+			cortex_discarded_exemplars_total{reason="exemplar_labels_filtered",user="testUser"} 1
+			cortex_discarded_exemplars_total{reason="exemplar_labels_stripped",user="testUser"} 1
+		`), "cortex_discarded_exemplars_total"))
+}
+
 func TestValidateMetadata(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 	m := NewMetadataValidationMetrics(reg)
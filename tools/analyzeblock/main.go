@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/oklog/ulid"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util/analyzeblock"
+	"github.com/grafana/mimir/pkg/util/listblocks"
+)
+
+type config struct {
+	bucket          bucket.Config
+	userID          string
+	blockID         string
+	previousBlockID string
+	comparePrevious bool
+	topN            int
+	tempDir         string
+}
+
+func main() {
+	logger := gokitlog.NewNopLogger()
+	cfg := config{}
+	cfg.bucket.RegisterFlags(flag.CommandLine, logger)
+	flag.StringVar(&cfg.userID, "user", "", "User (tenant)")
+	flag.StringVar(&cfg.blockID, "block", "", "ID of the block to analyze")
+	flag.StringVar(&cfg.previousBlockID, "previous-block", "", "If set, the series of the block are compared with the series of this block, to report the series churn")
+	flag.BoolVar(&cfg.comparePrevious, "compare-previous", false, "If true and -previous-block is not set, the series of the block are compared with the series of the block ending the most recently before the start of the analyzed block")
+	flag.IntVar(&cfg.topN, "top", 20, "Number of label names and label pairs with the highest number of series to show. 0 to show all of them")
+	flag.StringVar(&cfg.tempDir, "temp-dir", "", "Directory where the block indexes are downloaded to. Defaults to the directory for temporary files")
+	flag.Parse()
+
+	if cfg.userID == "" {
+		log.Fatalln("no user specified")
+	}
+
+	blockID, err := ulid.Parse(cfg.blockID)
+	if err != nil {
+		log.Fatalln("invalid block ID:", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT)
+	defer cancel()
+
+	bkt, err := bucket.NewClient(ctx, cfg.bucket, "bucket", logger, nil)
+	if err != nil {
+		log.Fatalln("failed to create bucket:", err)
+	}
+
+	var previousBlockID ulid.ULID
+	if cfg.previousBlockID != "" {
+		previousBlockID, err = ulid.Parse(cfg.previousBlockID)
+		if err != nil {
+			log.Fatalln("invalid previous block ID:", err)
+		}
+	} else if cfg.comparePrevious {
+		metas, _, err := listblocks.LoadMetaFilesAndDeletionMarkers(ctx, bkt, cfg.userID, false, time.Time{})
+		if err != nil {
+			log.Fatalln("failed to read block metadata:", err)
+		}
+
+		var ok bool
+		if previousBlockID, ok = analyzeblock.FindPreviousBlock(metas, blockID); !ok {
+			log.Fatalln("no block found before block", blockID)
+		}
+	}
+
+	userBkt := bucket.NewUserBucketClient(cfg.userID, bkt, nil)
+	report, err := analyzeblock.Analyze(ctx, logger, userBkt, blockID, previousBlockID, analyzeblock.Config{
+		TopN:    cfg.topN,
+		TempDir: cfg.tempDir,
+	})
+	if err != nil {
+		log.Fatalln("failed to analyze block:", err)
+	}
+
+	printReport(report)
+}
+
+// nolint:errcheck
+//
+//goland:noinspection GoUnhandledErrorResult
+func printReport(report *analyzeblock.Report) {
+	tabber := tabwriter.NewWriter(os.Stdout, 1, 4, 3, ' ', 0)
+	defer tabber.Flush()
+
+	fmt.Fprintf(tabber, "Block ID:\t%v\n", report.BlockID)
+	fmt.Fprintf(tabber, "Series:\t%d\n", report.NumSeries)
+	fmt.Fprintf(tabber, "Chunks:\t%d\n", report.NumChunks)
+	fmt.Fprintln(tabber)
+
+	fmt.Fprintln(tabber, "Label name\tSeries\tValues\t")
+	for _, n := range report.LabelNames {
+		fmt.Fprintf(tabber, "%s\t%d\t%d\t\n", n.Name, n.NumSeries, n.NumValues)
+	}
+	fmt.Fprintln(tabber)
+
+	fmt.Fprintln(tabber, "Label pair\tSeries\t")
+	for _, p := range report.LabelPairs {
+		fmt.Fprintf(tabber, "%s=%q\t%d\t\n", p.Name, p.Value, p.NumSeries)
+	}
+	fmt.Fprintln(tabber)
+
+	s := report.ChunkSizes
+	fmt.Fprintln(tabber, "Chunks size (bytes)\tTotal\tMin\tp50\tp90\tp99\tMax\t")
+	fmt.Fprintf(tabber, "\t%d\t%d\t%d\t%d\t%d\t%d\t\n", s.TotalBytes, s.Min, s.P50, s.P90, s.P99, s.Max)
+
+	if c := report.Churn; c != nil {
+		fmt.Fprintln(tabber)
+		fmt.Fprintln(tabber, "Previous block ID\tAdded series\tRemoved series\t")
+		fmt.Fprintf(tabber, "%v\t%d\t%d\t\n", c.PreviousBlockID, c.AddedSeries, c.RemovedSeries)
+	}
+}
@@ -348,6 +348,10 @@ func getFieldCustomType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
+	case reflect.TypeOf(validation.ExemplarLabelsFilters{}).String():
+		return "list of strings", true
+	case reflect.TypeOf(validation.SeriesSamplingRules{}).String():
+		return "map of string to float64", true
 	default:
 		return "", false
 	}
@@ -428,6 +432,10 @@ func getCustomFieldType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
+	case reflect.TypeOf(validation.ExemplarLabelsFilters{}).String():
+		return "list of strings", true
+	case reflect.TypeOf(validation.SeriesSamplingRules{}).String():
+		return "map of string to float64", true
 	default:
 		return "", false
 	}
@@ -463,6 +471,8 @@ func ReflectType(typ string) reflect.Type {
 		return reflect.TypeOf(tsdb.DurationList{})
 	case "map of string to validation.ForwardingRule":
 		return reflect.TypeOf(map[string]validation.ForwardingRule{})
+	case "map of string to model.Duration":
+		return reflect.TypeOf(map[string]model.Duration{})
 	default:
 		panic("unknown field type " + typ)
 	}
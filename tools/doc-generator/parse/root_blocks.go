@@ -9,7 +9,6 @@ import (
 	"github.com/grafana/dskit/grpcclient"
 	"github.com/grafana/dskit/kv/consul"
 	"github.com/grafana/dskit/kv/etcd"
-	"github.com/grafana/dskit/kv/memberlist"
 	"github.com/weaveworks/common/server"
 
 	"github.com/grafana/mimir/pkg/alertmanager"
@@ -127,7 +126,7 @@ var (
 		},
 		{
 			Name:       "memberlist",
-			StructType: reflect.TypeOf(memberlist.KVConfig{}),
+			StructType: reflect.TypeOf(mimir.MemberlistKVConfig{}),
 			Desc:       "The memberlist block configures the Gossip memberlist.",
 		},
 		{